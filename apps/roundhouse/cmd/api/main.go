@@ -44,6 +44,9 @@ func main() {
 		GitLabWebhookSecret:    cfg.GitLabWebhookSecret,
 		BitbucketWebhookSecret: cfg.BitbucketWebhookSecret,
 		InternalAPIKey:         cfg.SwitchyardAPIKey,
+		Registry:               cfg.Registry,
+		RegistryUser:           cfg.RegistryUser,
+		RegistryPassword:       cfg.RegistryPassword,
 	}, logger)
 
 	// Start server
@@ -32,6 +32,9 @@ func main() {
 	if cfg.Registry == "" {
 		logger.Fatal("REGISTRY is required")
 	}
+	if cfg.BuildLeaseTTL <= 0 {
+		logger.Fatal("BUILD_LEASE_TTL must be positive")
+	}
 
 	// Initialize Redis queue
 	redisQueue, err := queue.NewRedisQueue(cfg.RedisURL, logger)
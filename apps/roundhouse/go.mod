@@ -2,9 +2,12 @@ module github.com/madfam-org/enclii/apps/roundhouse
 
 go 1.24.0
 
+replace github.com/madfam-org/enclii/packages/sdk-go => ../../packages/sdk-go
+
 require (
 	github.com/gin-gonic/gin v1.10.0
 	github.com/google/uuid v1.6.0
+	github.com/madfam-org/enclii/packages/sdk-go v0.0.0-00010101000000-000000000000
 	github.com/redis/go-redis/v9 v9.6.3
 	github.com/spf13/viper v1.18.2
 	go.uber.org/zap v1.26.0
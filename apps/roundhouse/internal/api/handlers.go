@@ -1,19 +1,24 @@
 package api
 
 import (
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/roundhouse/internal/gc"
 	"github.com/madfam-org/enclii/apps/roundhouse/internal/queue"
 	"go.uber.org/zap"
 )
 
 // Handlers contains all API handlers
 type Handlers struct {
-	queue  *queue.RedisQueue
-	logger *zap.Logger
+	queue     *queue.RedisQueue
+	collector *gc.Collector // nil without a registry configured
+	logger    *zap.Logger
 }
 
 // NewHandlers creates new API handlers
@@ -43,6 +48,16 @@ func (h *Handlers) Enqueue(c *gin.Context) {
 		BuildConfig: req.BuildConfig,
 		CallbackURL: req.CallbackURL,
 		Priority:    req.Priority,
+
+		CommitMessage: req.CommitMessage,
+		TriggeredBy:   req.TriggeredBy,
+		SourceURL:     req.SourceURL,
+
+		RegistrySecret:  req.RegistrySecret,
+		ImageRepository: req.ImageRepository,
+		BuildSecrets:    req.BuildSecrets,
+
+		LogRedactionDisabled: req.LogRedactionDisabled,
 	}
 
 	if err := h.queue.Enqueue(c.Request.Context(), job); err != nil {
@@ -60,6 +75,26 @@ func (h *Handlers) Enqueue(c *gin.Context) {
 	})
 }
 
+// CollectImages handles internal requests to delete images of pruned releases
+func (h *Handlers) CollectImages(c *gin.Context) {
+	if h.collector == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "image GC requires REGISTRY to be configured"})
+		return
+	}
+
+	var req gc.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Images) > gc.MaxImagesPerRequest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d images per request", gc.MaxImagesPerRequest)})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.collector.Collect(c.Request.Context(), req.Images))
+}
+
 // GetJob retrieves a job by ID
 func (h *Handlers) GetJob(c *gin.Context) {
 	idStr := c.Param("id")
@@ -103,6 +138,103 @@ func (h *Handlers) ListJobs(c *gin.Context) {
 	})
 }
 
+const (
+	defaultBuildsLimit = 50
+	maxBuildsLimit     = 200
+)
+
+// ListBuilds lists the build history, newest first, with aggregate stats over
+// every build that matches the filters
+func (h *Handlers) ListBuilds(c *gin.Context) {
+	filter, err := parseBuildFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := defaultBuildsLimit
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxBuildsLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxBuildsLimit)})
+			return
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return
+		}
+	}
+
+	records, err := h.queue.ListBuilds(c.Request.Context(), filter.From, filter.To)
+	if err != nil {
+		h.logger.Error("failed to list builds", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list builds"})
+		return
+	}
+
+	matched := make([]*queue.BuildRecord, 0, len(records))
+	for _, record := range records {
+		if filter.Matches(record) {
+			matched = append(matched, record)
+		}
+	}
+
+	page := []*queue.BuildRecord{}
+	if offset < len(matched) {
+		end := offset + limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		page = matched[offset:end]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"builds": page,
+		"total":  len(matched),
+		"limit":  limit,
+		"offset": offset,
+		"stats":  queue.ComputeBuildStats(matched),
+	})
+}
+
+// parseBuildFilter reads the build history filters from the query string
+func parseBuildFilter(c *gin.Context) (*queue.BuildFilter, error) {
+	filter := &queue.BuildFilter{
+		Service:     c.Query("service"),
+		Branch:      c.Query("branch"),
+		Status:      queue.JobStatus(c.Query("status")),
+		TriggeredBy: c.Query("triggered_by"),
+		Query:       c.Query("q"),
+	}
+
+	switch filter.Status {
+	case "", queue.StatusQueued, queue.StatusBuilding, queue.StatusCompleted, queue.StatusFailed, queue.StatusCancelled:
+	default:
+		return nil, fmt.Errorf("invalid status %q", filter.Status)
+	}
+
+	for param, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an RFC 3339 time", param)
+		}
+		*dst = t
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+
+	return filter, nil
+}
+
 // StreamLogs streams build logs via SSE
 func (h *Handlers) StreamLogs(c *gin.Context) {
 	idStr := c.Param("id")
@@ -198,6 +330,16 @@ func (h *Handlers) RetryJob(c *gin.Context) {
 		BuildConfig: job.BuildConfig,
 		CallbackURL: job.CallbackURL,
 		Priority:    job.Priority + 1, // Slightly higher priority for retries
+
+		CommitMessage: job.CommitMessage,
+		TriggeredBy:   job.TriggeredBy,
+		SourceURL:     job.SourceURL,
+
+		RegistrySecret:  job.RegistrySecret,
+		ImageRepository: job.ImageRepository,
+		BuildSecrets:    job.BuildSecrets,
+
+		LogRedactionDisabled: job.LogRedactionDisabled,
 	}
 
 	if err := h.queue.Enqueue(c.Request.Context(), newJob); err != nil {
@@ -218,6 +360,7 @@ func (h *Handlers) GetStats(c *gin.Context) {
 
 	queueLen, _ := h.queue.QueueLength(ctx)
 	workers, _ := h.queue.ActiveWorkers(ctx)
+	redactions, _ := h.queue.Redactions(ctx)
 
 	c.JSON(http.StatusOK, gin.H{
 		"queue": gin.H{
@@ -227,6 +370,7 @@ func (h *Handlers) GetStats(c *gin.Context) {
 			"active": len(workers),
 			"list":   workers,
 		},
+		"redactions": redactions,
 	})
 }
 
@@ -2,6 +2,7 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/madfam-org/enclii/apps/roundhouse/internal/gc"
 	"github.com/madfam-org/enclii/apps/roundhouse/internal/queue"
 	"github.com/madfam-org/enclii/apps/roundhouse/internal/webhook"
 	"go.uber.org/zap"
@@ -23,6 +24,9 @@ func NewServer(q *queue.RedisQueue, cfg *ServerConfig, logger *zap.Logger) *Serv
 	router.Use(requestLogger(logger))
 
 	handlers := NewHandlers(q, logger)
+	if cfg.Registry != "" {
+		handlers.collector = gc.NewCollector(cfg.Registry, cfg.RegistryUser, cfg.RegistryPassword, logger)
+	}
 
 	s := &Server{
 		router:   router,
@@ -44,6 +48,11 @@ type ServerConfig struct {
 	SwitchyardURL          string
 	SwitchyardAPIKey       string
 	PreviewsEnabled        bool
+
+	// Registry builds are pushed to, where image GC deletes images
+	Registry         string
+	RegistryUser     string
+	RegistryPassword string
 }
 
 func (s *Server) setupRoutes(cfg *ServerConfig) {
@@ -75,6 +84,7 @@ func (s *Server) setupRoutes(cfg *ServerConfig) {
 	}
 	{
 		internal.POST("/enqueue", s.handlers.Enqueue)
+		internal.POST("/gc", s.handlers.CollectImages)
 	}
 
 	// Admin API (authenticated)
@@ -88,6 +98,9 @@ func (s *Server) setupRoutes(cfg *ServerConfig) {
 		api.POST("/jobs/:id/cancel", s.handlers.CancelJob)
 		api.POST("/jobs/:id/retry", s.handlers.RetryJob)
 
+		// Build history
+		api.GET("/builds", s.handlers.ListBuilds)
+
 		// Workers
 		api.GET("/workers", s.handlers.GetWorkers)
 
@@ -2,6 +2,7 @@ package builder
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/madfam-org/enclii/apps/roundhouse/internal/queue"
@@ -26,5 +27,21 @@ type Builder interface {
 	Execute(ctx context.Context, job *queue.BuildJob) (*queue.BuildResult, error)
 }
 
+// ErrBuildNotFound is returned by Resume for a build that is not running
+var ErrBuildNotFound = errors.New("build not found")
+
+// Resumer is implemented by builders whose builds run outside the worker,
+// so that a build outlives a worker that stops and another worker can take
+// it over
+type Resumer interface {
+	// InClusterBuilds returns the IDs of the builds that were started and
+	// whose results have not been collected yet
+	InClusterBuilds(ctx context.Context) ([]uuid.UUID, error)
+
+	// Resume watches a build started earlier until it ends and returns its
+	// result like Execute. It returns ErrBuildNotFound if the build is gone.
+	Resume(ctx context.Context, job *queue.BuildJob) (*queue.BuildResult, error)
+}
+
 // LogFunc is a callback for streaming build logs
 type LogFunc func(jobID uuid.UUID, line string)
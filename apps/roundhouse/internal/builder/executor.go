@@ -1,18 +1,23 @@
 package builder
 
 import (
+	"archive/tar"
 	"bufio"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/madfam-org/enclii/apps/roundhouse/internal/queue"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
 	"go.uber.org/zap"
 )
 
@@ -28,6 +33,10 @@ type Executor struct {
 	timeout      time.Duration
 	logger       *zap.Logger
 	logFunc      func(jobID uuid.UUID, line string)
+
+	// Redactors of the build output of running jobs
+	redactorsMu sync.Mutex
+	redactors   map[uuid.UUID]*redact.Redactor
 }
 
 // ExecutorConfig configures the executor
@@ -55,6 +64,7 @@ func NewExecutor(cfg *ExecutorConfig, logger *zap.Logger, logFunc func(uuid.UUID
 		timeout:      cfg.Timeout,
 		logger:       logger,
 		logFunc:      logFunc,
+		redactors:    make(map[uuid.UUID]*redact.Redactor),
 	}
 }
 
@@ -67,6 +77,27 @@ func (e *Executor) Execute(ctx context.Context, job *queue.BuildJob) (*queue.Bui
 		ReleaseID: job.ReleaseID,
 	}
 
+	// Project registry credentials are only mounted into Kaniko build jobs;
+	// the Docker daemon pulls with its own
+	if job.ImageRepository != "" {
+		return e.failResult(result, startTime, "pushing to %s requires the kaniko build mode", job.ImageRepository)
+	}
+	// Build secrets live in the build namespace, out of the Docker daemon's reach
+	if job.BuildSecrets != "" {
+		return e.failResult(result, startTime, "build secrets require the kaniko build mode")
+	}
+
+	// Token patterns are masked in the build output
+	e.redactorsMu.Lock()
+	e.redactors[job.ID] = redact.New(nil, !job.LogRedactionDisabled)
+	e.redactorsMu.Unlock()
+	defer func() {
+		e.redactorsMu.Lock()
+		result.Redactions = e.redactors[job.ID].Counts()
+		delete(e.redactors, job.ID)
+		e.redactorsMu.Unlock()
+	}()
+
 	// Create build directory
 	buildDir := filepath.Join(e.workDir, job.ID.String())
 	if err := os.MkdirAll(buildDir, 0755); err != nil {
@@ -76,8 +107,12 @@ func (e *Executor) Execute(ctx context.Context, job *queue.BuildJob) (*queue.Bui
 
 	e.log(job.ID, "📦 Starting build for %s @ %s", job.GitRepo, job.GitSHA[:8])
 
-	// Clone repository
-	if err := e.cloneRepo(ctx, job, buildDir); err != nil {
+	// Fetch sources: an uploaded build context, or a clone of the repository
+	if job.SourceURL != "" {
+		if err := e.downloadSource(ctx, job, buildDir); err != nil {
+			return e.failResult(result, startTime, "build context download failed: %v", err)
+		}
+	} else if err := e.cloneRepo(ctx, job, buildDir); err != nil {
 		return e.failResult(result, startTime, "clone failed: %v", err)
 	}
 
@@ -196,6 +231,80 @@ func (e *Executor) cloneRepo(ctx context.Context, job *queue.BuildJob, buildDir
 	return nil
 }
 
+// downloadSource extracts an uploaded gzipped build context into buildDir
+func (e *Executor) downloadSource(ctx context.Context, job *queue.BuildJob, buildDir string) error {
+	e.log(job.ID, "📥 Downloading build context...")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, job.SourceURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if err := extractTarGz(resp.Body, buildDir); err != nil {
+		return err
+	}
+
+	e.log(job.ID, "✅ Build context extracted")
+	return nil
+}
+
+// extractTarGz unpacks a gzipped tarball into dir, rejecting entries that
+// would escape it
+func extractTarGz(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, hdr.Name)
+		if target != dir && !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal path in archive: %s", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+		// Links and special files are skipped; a build context has no use for them
+	}
+}
+
 func (e *Executor) detectBuildType(buildDir string, config *queue.BuildConfig) string {
 	// Check for functions/ directory first (serverless functions)
 	if IsFunctionBuild(buildDir) {
@@ -419,9 +528,13 @@ func (e *Executor) runWithLogs(cmd *exec.Cmd, jobID uuid.UUID) error {
 }
 
 func (e *Executor) streamOutput(jobID uuid.UUID, reader io.Reader) {
+	e.redactorsMu.Lock()
+	redactor := e.redactors[jobID]
+	e.redactorsMu.Unlock()
+
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
-		e.log(jobID, "%s", scanner.Text())
+		e.log(jobID, "%s", redactor.Redact(scanner.Text()))
 	}
 }
 
@@ -0,0 +1,259 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/madfam-org/enclii/apps/roundhouse/internal/queue"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// =============================================================================
+// Build Artifacts
+// =============================================================================
+
+// maxArtifactsSize bounds the files a build can publish; a larger artifacts
+// directory evicts the upload pod
+var maxArtifactsSize = resource.MustParse("2Gi")
+
+// ArtifactStorage is the S3-compatible bucket build artifacts are uploaded to
+type ArtifactStorage struct {
+	Endpoint          string // e.g. https://<account>.r2.cloudflarestorage.com
+	Bucket            string
+	CredentialsSecret string // Secret in the build namespace with access-key-id and secret-access-key
+}
+
+// configured reports whether builds can publish artifacts
+func (s ArtifactStorage) configured() bool {
+	return s.Endpoint != "" && s.Bucket != "" && s.CredentialsSecret != ""
+}
+
+// artifactPrefix is where the artifacts of a build are stored in the bucket
+func artifactPrefix(job *queue.BuildJob) string {
+	return fmt.Sprintf("builds/%s", job.ID)
+}
+
+// runArtifactUpload copies the declared artifacts directory out of the built
+// image and uploads its files to the artifacts bucket. The image's own code
+// only runs to copy the files; the bucket credentials are given to the
+// upload container alone.
+func (e *KanikoExecutor) runArtifactUpload(ctx context.Context, job *queue.BuildJob, imageTag string) ([]queue.Artifact, error) {
+	jobName := fmt.Sprintf("artifacts-%s", job.ID.String()[:8])
+
+	endpoint, err := url.Parse(e.artifacts.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid artifact storage endpoint %q", e.artifacts.Endpoint)
+	}
+
+	// Security context - run as non-root
+	runAsNonRoot := true
+	runAsUser := int64(1000)
+	runAsGroup := int64(1000)
+	fsGroup := int64(1000)
+
+	// Job configuration
+	backoffLimit := int32(0)
+	ttlSeconds := int32(1800)           // Clean up after 30 minutes
+	activeDeadlineSeconds := int64(600) // 10 minute timeout for artifacts
+
+	securityContext := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: boolPtr(false),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+	}
+	artifactsMount := corev1.VolumeMount{Name: "artifacts", MountPath: "/artifacts"}
+	toolsMount := corev1.VolumeMount{Name: "tools", MountPath: "/tools"}
+	credential := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: e.artifacts.CredentialsSecret,
+					},
+					Key: key,
+				},
+			},
+		}
+	}
+
+	k8sJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: KanikoBuildNamespace,
+			Labels: map[string]string{
+				LabelBuildID: job.ID.String(),
+				LabelAppName: "build-artifacts",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttlSeconds,
+			ActiveDeadlineSeconds:   &activeDeadlineSeconds,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						LabelBuildID: job.ID.String(),
+						LabelAppName: "build-artifacts",
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot: &runAsNonRoot,
+						RunAsUser:    &runAsUser,
+						RunAsGroup:   &runAsGroup,
+						FSGroup:      &fsGroup,
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+					// The built image is pulled with the registry credentials it was pushed with
+					ImagePullSecrets: []corev1.LocalObjectReference{
+						{Name: registrySecret(job)},
+					},
+					InitContainers: []corev1.Container{
+						{
+							// The built image may have no shell or cp, so
+							// busybox is copied in for it
+							Name:            "tools",
+							Image:           BusyboxImage,
+							Command:         []string{"cp", "/bin/busybox", "/tools/busybox"},
+							Resources:       resources,
+							SecurityContext: securityContext,
+							VolumeMounts:    []corev1.VolumeMount{toolsMount},
+						},
+						{
+							Name:    "collect",
+							Image:   imageTag,
+							Command: []string{"/tools/busybox", "sh", "-c", `cd "$ARTIFACTS_DIR" && /tools/busybox cp -r . /artifacts/`},
+							Env: []corev1.EnvVar{
+								{Name: "ARTIFACTS_DIR", Value: job.BuildConfig.Artifacts},
+							},
+							Resources:       resources,
+							SecurityContext: securityContext,
+							VolumeMounts:    []corev1.VolumeMount{toolsMount, artifactsMount},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:  "upload",
+							Image: MinioClientImage,
+							Args: []string{
+								"--config-dir", "/tmp/mc",
+								"cp", "--recursive", "/artifacts/",
+								fmt.Sprintf("artifacts/%s/%s/", e.artifacts.Bucket, artifactPrefix(job)),
+							},
+							Env: []corev1.EnvVar{
+								credential("ACCESS_KEY_ID", "access-key-id"),
+								credential("SECRET_ACCESS_KEY", "secret-access-key"),
+								{
+									// mc reads the alias from the environment;
+									// Kubernetes expands the credentials into it
+									Name:  "MC_HOST_artifacts",
+									Value: fmt.Sprintf("%s://$(ACCESS_KEY_ID):$(SECRET_ACCESS_KEY)@%s", endpoint.Scheme, endpoint.Host),
+								},
+							},
+							Resources:       resources,
+							SecurityContext: securityContext,
+							VolumeMounts: []corev1.VolumeMount{
+								artifactsMount,
+								{Name: "tmp", MountPath: "/tmp"},
+							},
+						},
+						{
+							// Prints "<size> ./<path>" for every file, read
+							// back as the artifact metadata
+							Name:            "manifest",
+							Image:           BusyboxImage,
+							Command:         []string{"sh", "-c", "cd /artifacts && find . -type f -exec stat -c '%s %n' {} +"},
+							Resources:       resources,
+							SecurityContext: securityContext,
+							VolumeMounts:    []corev1.VolumeMount{artifactsMount},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "tools",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+						{
+							Name: "artifacts",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &maxArtifactsSize},
+							},
+						},
+						{
+							Name: "tmp",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Create the job
+	_, err = e.k8sClient.BatchV1().Jobs(KanikoBuildNamespace).Create(ctx, k8sJob, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create artifacts job: %w", err)
+	}
+
+	e.log(job.ID, "📦 Created artifacts job: %s", jobName)
+
+	// Wait for completion
+	if err := e.watchJobCompletion(ctx, job.ID, jobName); err != nil {
+		return nil, fmt.Errorf("artifact upload failed: %w", err)
+	}
+
+	manifest, err := e.getJobOutput(ctx, jobName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifact manifest: %w", err)
+	}
+
+	return parseArtifactManifest(manifest, artifactPrefix(job)), nil
+}
+
+// parseArtifactManifest reads the "<size> ./<path>" lines of the manifest
+// container into the artifacts stored under prefix
+func parseArtifactManifest(manifest, prefix string) []queue.Artifact {
+	var artifacts []queue.Artifact
+	for _, line := range strings.Split(manifest, "\n") {
+		size, path, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		sizeBytes, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			continue
+		}
+		path = strings.TrimPrefix(path, "./")
+		artifacts = append(artifacts, queue.Artifact{
+			Path:      path,
+			SizeBytes: sizeBytes,
+			ObjectKey: prefix + "/" + path,
+		})
+	}
+	return artifacts
+}
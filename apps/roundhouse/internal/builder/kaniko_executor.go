@@ -33,6 +33,22 @@ const (
 	// CosignImage is the container image for image signing
 	CosignImage = "gcr.io/projectsigstore/cosign:v2.2.3"
 
+	// BusyboxImage supplies the tools that copy and list build artifacts
+	BusyboxImage = "busybox:1.36"
+
+	// MinioClientImage uploads build artifacts to the artifacts bucket
+	MinioClientImage = "minio/mc:RELEASE.2024-06-12T14-34-03Z"
+
+	// DefaultRegistrySecret is the secret with the platform's registry
+	// credentials, used by builds of projects without their own
+	DefaultRegistrySecret = "regcred"
+
+	// GPUResource is the extended resource GPU builds request one of
+	GPUResource corev1.ResourceName = "nvidia.com/gpu"
+
+	// GPURuntimeClass gives GPU builds access to the node's GPU
+	GPURuntimeClass = "nvidia"
+
 	// Labels for build jobs
 	LabelBuildID   = "enclii.dev/build-id"
 	LabelServiceID = "enclii.dev/service-id"
@@ -59,6 +75,7 @@ type KanikoExecutor struct {
 	timeout        time.Duration
 	cacheRepo      string
 	gitCredentials string // Secret name for git credentials
+	artifacts      ArtifactStorage
 	logger         *zap.Logger
 	logFunc        func(jobID uuid.UUID, line string)
 }
@@ -75,6 +92,7 @@ type KanikoExecutorConfig struct {
 	Timeout        time.Duration
 	CacheRepo      string // Optional: registry path for layer caching
 	GitCredentials string // Optional: secret name with git token
+	Artifacts      ArtifactStorage
 }
 
 // NewKanikoExecutor creates a new Kaniko-based build executor
@@ -95,6 +113,7 @@ func NewKanikoExecutor(cfg *KanikoExecutorConfig, logger *zap.Logger, logFunc fu
 		timeout:        cfg.Timeout,
 		cacheRepo:      cacheRepo,
 		gitCredentials: cfg.GitCredentials,
+		artifacts:      cfg.Artifacts,
 		logger:         logger,
 		logFunc:        logFunc,
 	}
@@ -127,18 +146,78 @@ func (e *KanikoExecutor) Execute(ctx context.Context, job *queue.BuildJob) (*que
 
 	e.log(job.ID, "🚀 Created Kubernetes Job: %s", k8sJob.Name)
 
+	return e.finishBuild(ctx, job, k8sJob.Name, result, startTime)
+}
+
+// InClusterBuilds returns the IDs of the builds whose Kubernetes Jobs still
+// exist. Jobs that finished count as well: a build that finished while no
+// worker watched it still needs its post-build steps and result.
+func (e *KanikoExecutor) InClusterBuilds(ctx context.Context) ([]uuid.UUID, error) {
+	jobs, err := e.k8sClient.BatchV1().Jobs(KanikoBuildNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=kaniko-build", LabelAppName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list build jobs: %w", err)
+	}
+
+	buildIDs := make([]uuid.UUID, 0, len(jobs.Items))
+	for _, k8sJob := range jobs.Items {
+		buildID, err := uuid.Parse(k8sJob.Labels[LabelBuildID])
+		if err != nil {
+			continue
+		}
+		buildIDs = append(buildIDs, buildID)
+	}
+	return buildIDs, nil
+}
+
+// Resume takes over a build whose Kubernetes Job another worker created,
+// watching it from where it is
+func (e *KanikoExecutor) Resume(ctx context.Context, job *queue.BuildJob) (*queue.BuildResult, error) {
+	jobs, err := e.k8sClient.BatchV1().Jobs(KanikoBuildNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", LabelBuildID, job.ID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find build job: %w", err)
+	}
+	if len(jobs.Items) == 0 {
+		return nil, ErrBuildNotFound
+	}
+	k8sJob := jobs.Items[0]
+
+	result := &queue.BuildResult{
+		JobID:     job.ID,
+		ReleaseID: job.ReleaseID,
+		ImageURI:  e.generateImageTag(job),
+	}
+
+	e.log(job.ID, "🔄 Resuming build of Kubernetes Job %s after a worker restart", k8sJob.Name)
+
+	return e.finishBuild(ctx, job, k8sJob.Name, result, k8sJob.CreationTimestamp.Time)
+}
+
+// finishBuild watches a build's Kubernetes Job until it ends, then runs the
+// post-build steps
+func (e *KanikoExecutor) finishBuild(ctx context.Context, job *queue.BuildJob, jobName string, result *queue.BuildResult, startTime time.Time) (*queue.BuildResult, error) {
+	imageTag := result.ImageURI
+
+	// Secrets are masked in the logs of the build
+	redactor := e.logRedactor(ctx, job)
+
 	// Watch for job completion
-	err = e.watchJobCompletion(ctx, job.ID, k8sJob.Name)
+	err := e.watchJobCompletion(ctx, job.ID, jobName)
 	if err != nil {
 		// Try to get logs before failing
-		e.streamJobLogs(ctx, job.ID, k8sJob.Name)
+		e.streamJobLogs(ctx, job.ID, jobName, redactor)
+		result.Redactions = redactor.Counts()
 		return e.failResult(result, startTime, "build failed: %v", err)
 	}
 
 	e.log(job.ID, "✅ Kaniko build completed successfully")
 
 	// Get final logs
-	e.streamJobLogs(ctx, job.ID, k8sJob.Name)
+	e.streamJobLogs(ctx, job.ID, jobName, redactor)
+	result.Redactions = redactor.Counts()
 
 	// Get image digest from registry (post-push)
 	// Note: Kaniko pushes directly, so we need to query the registry
@@ -152,7 +231,7 @@ func (e *KanikoExecutor) Execute(ctx context.Context, job *queue.BuildJob) (*que
 	// Generate SBOM (run as separate job if enabled)
 	if e.generateSBOM {
 		e.log(job.ID, "📋 Generating SBOM...")
-		sbom, format, err := e.runSBOMGeneration(ctx, job.ID, imageTag)
+		sbom, format, err := e.runSBOMGeneration(ctx, job.ID, imageTag, registrySecret(job))
 		if err != nil {
 			e.logger.Warn("failed to generate SBOM", zap.Error(err))
 		} else {
@@ -165,7 +244,7 @@ func (e *KanikoExecutor) Execute(ctx context.Context, job *queue.BuildJob) (*que
 	// Sign image (run as separate job if enabled)
 	if e.signImages && e.cosignKey != "" {
 		e.log(job.ID, "🔐 Signing image...")
-		signature, err := e.runImageSigning(ctx, job.ID, imageTag)
+		signature, err := e.runImageSigning(ctx, job.ID, imageTag, registrySecret(job))
 		if err != nil {
 			e.logger.Warn("failed to sign image", zap.Error(err))
 		} else {
@@ -174,6 +253,23 @@ func (e *KanikoExecutor) Execute(ctx context.Context, job *queue.BuildJob) (*que
 		}
 	}
 
+	// Publish build artifacts (run as separate job if declared)
+	if job.BuildConfig.Artifacts != "" {
+		if !e.artifacts.configured() {
+			e.log(job.ID, "⚠️ Artifact storage is not configured; %s was not published", job.BuildConfig.Artifacts)
+		} else {
+			e.log(job.ID, "📦 Publishing artifacts from %s...", job.BuildConfig.Artifacts)
+			artifacts, err := e.runArtifactUpload(ctx, job, imageTag)
+			if err != nil {
+				e.logger.Warn("failed to publish artifacts", zap.Error(err))
+				e.log(job.ID, "⚠️ Artifacts were not published: %v", err)
+			} else {
+				result.Artifacts = artifacts
+				e.log(job.ID, "✅ %d artifacts published", len(artifacts))
+			}
+		}
+	}
+
 	result.Success = true
 	result.DurationSecs = time.Since(startTime).Seconds()
 
@@ -194,7 +290,11 @@ func (e *KanikoExecutor) createBuildJob(ctx context.Context, job *queue.BuildJob
 	// Job configuration
 	backoffLimit := int32(0)  // Don't retry failed builds
 	ttlSeconds := int32(3600) // Clean up after 1 hour
-	activeDeadlineSeconds := int64(e.timeout.Seconds())
+	activeDeadlineSeconds := int64(job.BuildConfig.Timeout(e.timeout).Seconds())
+	resources, err := buildResources(job.BuildConfig.Resources)
+	if err != nil {
+		return nil, err
+	}
 
 	// Security context - Kaniko MUST run as root (UID 0) to unpack container filesystem layers.
 	// When building images, Kaniko needs to create directories like /bin, /usr, etc. which
@@ -251,7 +351,7 @@ func (e *KanikoExecutor) createBuildJob(ctx context.Context, job *queue.BuildJob
 									Preference: corev1.NodeSelectorTerm{
 										MatchExpressions: []corev1.NodeSelectorRequirement{
 											{
-												Key:      "nvidia.com/gpu",
+												Key:      string(GPUResource),
 												Operator: corev1.NodeSelectorOpDoesNotExist,
 											},
 										},
@@ -262,19 +362,10 @@ func (e *KanikoExecutor) createBuildJob(ctx context.Context, job *queue.BuildJob
 					},
 					Containers: []corev1.Container{
 						{
-							Name:  "kaniko",
-							Image: KanikoImage,
-							Args:  args,
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse("1"),
-									corev1.ResourceMemory: resource.MustParse("2Gi"),
-								},
-								Limits: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse("4"),
-									corev1.ResourceMemory: resource.MustParse("8Gi"),
-								},
-							},
+							Name:      "kaniko",
+							Image:     KanikoImage,
+							Args:      args,
+							Resources: resources,
 							SecurityContext: &corev1.SecurityContext{
 								AllowPrivilegeEscalation: boolPtr(false),
 								ReadOnlyRootFilesystem:   boolPtr(false), // Kaniko needs writable /kaniko
@@ -298,7 +389,7 @@ func (e *KanikoExecutor) createBuildJob(ctx context.Context, job *queue.BuildJob
 							Name: "docker-config",
 							VolumeSource: corev1.VolumeSource{
 								Secret: &corev1.SecretVolumeSource{
-									SecretName: "regcred",
+									SecretName: registrySecret(job),
 									Items: []corev1.KeyToPath{
 										{
 											Key:  ".dockerconfigjson",
@@ -314,6 +405,13 @@ func (e *KanikoExecutor) createBuildJob(ctx context.Context, job *queue.BuildJob
 		},
 	}
 
+	if job.BuildConfig.GPU {
+		useGPU(&k8sJob.Spec.Template.Spec)
+	}
+	if job.BuildSecrets != "" {
+		useBuildSecrets(&k8sJob.Spec.Template.Spec, job.BuildSecrets)
+	}
+
 	// Add git credentials volume if configured
 	if e.gitCredentials != "" {
 		k8sJob.Spec.Template.Spec.Volumes = append(k8sJob.Spec.Template.Spec.Volumes, corev1.Volume{
@@ -341,40 +439,50 @@ func (e *KanikoExecutor) buildKanikoArgs(job *queue.BuildJob, imageTag string) [
 		contextPath = "."
 	}
 
-	// Git context URL format: git://[repository]#[ref]#[commit-sha]
-	// Strip https:// or http:// prefix from repo URL if present
-	repoURL := job.GitRepo
-	repoURL = strings.TrimPrefix(repoURL, "https://")
-	repoURL = strings.TrimPrefix(repoURL, "http://")
-	gitContext := fmt.Sprintf("git://%s#refs/heads/%s#%s",
-		repoURL, job.GitBranch, job.GitSHA)
-
-	// If context is a subdirectory, append to git context
-	if contextPath != "." {
-		gitContext = gitContext + ":" + contextPath
-	}
-
-	args := []string{
-		"--dockerfile=" + dockerfile,
-		"--context=" + gitContext,
-		"--destination=" + imageTag,
-		"--destination=" + e.generateLatestTag(job),
-		// Layer caching
-		"--cache=true",
-		"--cache-repo=" + e.cacheRepo,
-		"--cache-ttl=168h", // 7 days
+	var contextArgs []string
+	if job.SourceURL != "" {
+		// Uploaded build contexts are gzipped tarballs Kaniko downloads itself
+		contextArgs = []string{"--context=" + job.SourceURL}
+		if contextPath != "." {
+			contextArgs = append(contextArgs, "--context-sub-path="+contextPath)
+		}
+	} else {
+		// Git context URL format: git://[repository]#[ref]#[commit-sha]
+		// Strip https:// or http:// prefix from repo URL if present
+		repoURL := job.GitRepo
+		repoURL = strings.TrimPrefix(repoURL, "https://")
+		repoURL = strings.TrimPrefix(repoURL, "http://")
+		gitContext := fmt.Sprintf("git://%s#refs/heads/%s#%s",
+			repoURL, job.GitBranch, job.GitSHA)
+
+		// If context is a subdirectory, append to git context
+		if contextPath != "." {
+			gitContext = gitContext + ":" + contextPath
+		}
+		contextArgs = []string{"--context=" + gitContext}
+	}
+
+	args := append([]string{"--dockerfile=" + dockerfile}, contextArgs...)
+	args = append(args, "--destination="+imageTag)
+	// Uncommitted work never becomes :latest
+	if job.SourceURL == "" {
+		args = append(args, "--destination="+e.generateLatestTag(job))
+	}
+	// Layer caching
+	args = append(args, e.cacheArgs(job)...)
+	args = append(args,
 		// Reproducibility
 		"--reproducible",
 		"--snapshot-mode=redo",
 		// Build metadata
-		"--label=org.opencontainers.image.source=" + job.GitRepo,
-		"--label=org.opencontainers.image.revision=" + job.GitSHA,
-		"--label=org.opencontainers.image.created=" + time.Now().UTC().Format(time.RFC3339),
-		"--label=io.enclii.service-id=" + job.ServiceID.String(),
-		"--label=io.enclii.release-id=" + job.ReleaseID.String(),
+		"--label=org.opencontainers.image.source="+job.GitRepo,
+		"--label=org.opencontainers.image.revision="+job.GitSHA,
+		"--label=org.opencontainers.image.created="+time.Now().UTC().Format(time.RFC3339),
+		"--label=io.enclii.service-id="+job.ServiceID.String(),
+		"--label=io.enclii.release-id="+job.ReleaseID.String(),
 		// Verbosity
 		"--verbosity=info",
-	}
+	)
 
 	// Add build args
 	for key, value := range job.BuildConfig.BuildArgs {
@@ -423,10 +531,15 @@ func (e *KanikoExecutor) generateImageTag(job *queue.BuildJob) string {
 		shortSHA = shortSHA[:8]
 	}
 
+	// Builds of uploaded contexts are tagged apart from git commits
+	if job.SourceURL != "" {
+		shortSHA = "local-" + shortSHA
+	}
+
 	// Use human-readable service name instead of UUID prefixes
 	// Produces: ghcr.io/madfam-org/service-name:abc12345
 	return fmt.Sprintf("%s/%s:%s",
-		e.registry,
+		e.imageRegistry(job),
 		job.ServiceName,
 		shortSHA,
 	)
@@ -436,11 +549,38 @@ func (e *KanikoExecutor) generateImageTag(job *queue.BuildJob) string {
 func (e *KanikoExecutor) generateLatestTag(job *queue.BuildJob) string {
 	// Use human-readable service name instead of UUID prefixes
 	return fmt.Sprintf("%s/%s:latest",
-		e.registry,
+		e.imageRegistry(job),
 		job.ServiceName,
 	)
 }
 
+// imageRegistry is where the job's image is pushed: the project's own
+// repository when it has one, the platform registry otherwise
+func (e *KanikoExecutor) imageRegistry(job *queue.BuildJob) string {
+	if job.ImageRepository != "" {
+		return job.ImageRepository
+	}
+	return e.registry
+}
+
+// cacheRepository keeps the layer cache of builds pushed to a project's
+// repository in that repository
+func (e *KanikoExecutor) cacheRepository(job *queue.BuildJob) string {
+	if job.ImageRepository != "" {
+		return job.ImageRepository + "/cache"
+	}
+	return e.cacheRepo
+}
+
+// registrySecret is the Docker config secret the job's pods authenticate
+// to registries with
+func registrySecret(job *queue.BuildJob) string {
+	if job.RegistrySecret != "" {
+		return job.RegistrySecret
+	}
+	return DefaultRegistrySecret
+}
+
 // =============================================================================
 // Utility Functions
 // =============================================================================
@@ -462,6 +602,21 @@ func (e *KanikoExecutor) failResult(result *queue.BuildResult, startTime time.Ti
 }
 
 // Helper functions
+// useGPU moves a build onto a GPU node with one GPU, for builds that compile
+// or test against CUDA. Requesting the GPU is what places the pod; the
+// toleration lets it past the taint that keeps other builds off GPU nodes.
+func useGPU(pod *corev1.PodSpec) {
+	pod.Affinity = nil
+	pod.Tolerations = append(pod.Tolerations, corev1.Toleration{
+		Key:      string(GPUResource),
+		Operator: corev1.TolerationOpExists,
+		Effect:   corev1.TaintEffectNoSchedule,
+	})
+	runtimeClass := GPURuntimeClass
+	pod.RuntimeClassName = &runtimeClass
+	pod.Containers[0].Resources.Limits[GPUResource] = resource.MustParse("1")
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
@@ -1,12 +1,14 @@
 package builder
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
 	"go.uber.org/zap"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -63,8 +65,13 @@ func (e *KanikoExecutor) watchJobCompletion(ctx context.Context, buildID uuid.UU
 // Log Streaming
 // =============================================================================
 
-// streamJobLogs streams logs from the build pod
-func (e *KanikoExecutor) streamJobLogs(ctx context.Context, buildID uuid.UUID, jobName string) {
+// streamJobLogs streams logs from the build pod, with the secrets redactor
+// finds masked. A nil redactor streams nothing.
+func (e *KanikoExecutor) streamJobLogs(ctx context.Context, buildID uuid.UUID, jobName string, redactor *redact.Redactor) {
+	if redactor == nil {
+		return
+	}
+
 	// Find the pod for this job
 	pods, err := e.k8sClient.CoreV1().Pods(KanikoBuildNamespace).List(ctx, metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
@@ -88,26 +95,17 @@ func (e *KanikoExecutor) streamJobLogs(ctx context.Context, buildID uuid.UUID, j
 	}
 	defer logs.Close()
 
-	// Read and emit logs
-	buf := make([]byte, 4096)
-	for {
-		n, err := logs.Read(buf)
-		if n > 0 {
-			lines := strings.Split(string(buf[:n]), "\n")
-			for _, line := range lines {
-				if line != "" {
-					e.log(buildID, "%s", line)
-				}
-			}
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			e.logger.Warn("error reading logs", zap.Error(err))
-			break
+	// Read and emit logs line by line, so no secret is split across reads
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			e.log(buildID, "%s", redactor.Redact(line))
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		e.logger.Warn("error reading logs", zap.Error(err))
+	}
 }
 
 // getJobOutput retrieves the stdout from a completed job
@@ -127,6 +125,9 @@ func (e *KanikoExecutor) getJobOutput(ctx context.Context, jobName string) (stri
 	if strings.HasPrefix(jobName, "sign-") {
 		containerName = "cosign"
 	}
+	if strings.HasPrefix(jobName, "artifacts-") {
+		containerName = "manifest"
+	}
 
 	// Get logs (stdout)
 	req := e.k8sClient.CoreV1().Pods(KanikoBuildNamespace).GetLogs(podName, &corev1.PodLogOptions{
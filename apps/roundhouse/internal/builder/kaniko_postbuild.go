@@ -16,7 +16,7 @@ import (
 // =============================================================================
 
 // runSBOMGeneration runs Syft to generate SBOM for the image
-func (e *KanikoExecutor) runSBOMGeneration(ctx context.Context, buildID uuid.UUID, imageTag string) (string, string, error) {
+func (e *KanikoExecutor) runSBOMGeneration(ctx context.Context, buildID uuid.UUID, imageTag, configSecret string) (string, string, error) {
 	jobName := fmt.Sprintf("sbom-%s", buildID.String()[:8])
 	format := "spdx-json" // SPDX is widely supported and recommended
 
@@ -112,7 +112,7 @@ func (e *KanikoExecutor) runSBOMGeneration(ctx context.Context, buildID uuid.UUI
 							Name: "docker-config",
 							VolumeSource: corev1.VolumeSource{
 								Secret: &corev1.SecretVolumeSource{
-									SecretName: "regcred",
+									SecretName: configSecret,
 									Items: []corev1.KeyToPath{
 										{
 											Key:  ".dockerconfigjson",
@@ -161,7 +161,7 @@ func (e *KanikoExecutor) runSBOMGeneration(ctx context.Context, buildID uuid.UUI
 // =============================================================================
 
 // runImageSigning runs Cosign to sign the image
-func (e *KanikoExecutor) runImageSigning(ctx context.Context, buildID uuid.UUID, imageTag string) (string, error) {
+func (e *KanikoExecutor) runImageSigning(ctx context.Context, buildID uuid.UUID, imageTag, configSecret string) (string, error) {
 	jobName := fmt.Sprintf("sign-%s", buildID.String()[:8])
 
 	// Security context - run as non-root
@@ -187,7 +187,7 @@ func (e *KanikoExecutor) runImageSigning(ctx context.Context, buildID uuid.UUID,
 		Name: "docker-config",
 		VolumeSource: corev1.VolumeSource{
 			Secret: &corev1.SecretVolumeSource{
-				SecretName: "regcred",
+				SecretName: configSecret,
 				Items: []corev1.KeyToPath{
 					{
 						Key:  ".dockerconfigjson",
@@ -0,0 +1,106 @@
+package builder
+
+import (
+	"fmt"
+
+	"github.com/madfam-org/enclii/apps/roundhouse/internal/queue"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Default resources of the Kaniko container, for builds that do not size
+// themselves
+const (
+	DefaultBuildCPURequest    = "1"
+	DefaultBuildMemoryRequest = "2Gi"
+	DefaultBuildCPULimit      = "4"
+	DefaultBuildMemoryLimit   = "8Gi"
+
+	// DefaultCacheTTLHours is how long cached layers are reused
+	DefaultCacheTTLHours = 168 // 7 days
+)
+
+// buildResources are the resources of the Kaniko container: the build's own
+// requests and limits over the defaults. A request above the default limit
+// raises the limit, and a limit below the default request lowers the
+// request, so a build that sets only one of them still schedules.
+func buildResources(cfg *queue.BuildResources) (corev1.ResourceRequirements, error) {
+	if cfg == nil {
+		cfg = &queue.BuildResources{}
+	}
+
+	cpu, err := requestAndLimit("cpu", cfg.CPURequest, cfg.CPULimit, DefaultBuildCPURequest, DefaultBuildCPULimit)
+	if err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+	memory, err := requestAndLimit("memory", cfg.MemoryRequest, cfg.MemoryLimit, DefaultBuildMemoryRequest, DefaultBuildMemoryLimit)
+	if err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    cpu[0],
+			corev1.ResourceMemory: memory[0],
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    cpu[1],
+			corev1.ResourceMemory: memory[1],
+		},
+	}, nil
+}
+
+// requestAndLimit parses the request and limit of one resource, falling
+// back to the defaults for the unset ones
+func requestAndLimit(name, request, limit, defaultRequest, defaultLimit string) ([2]resource.Quantity, error) {
+	var quantities [2]resource.Quantity
+
+	req, err := parseOr(request, defaultRequest)
+	if err != nil {
+		return quantities, fmt.Errorf("invalid %s request %q: %w", name, request, err)
+	}
+	lim, err := parseOr(limit, defaultLimit)
+	if err != nil {
+		return quantities, fmt.Errorf("invalid %s limit %q: %w", name, limit, err)
+	}
+
+	if req.Cmp(lim) > 0 {
+		switch {
+		case request != "" && limit != "":
+			return quantities, fmt.Errorf("%s request %s exceeds its limit %s", name, request, limit)
+		case request != "":
+			lim = req.DeepCopy()
+		default:
+			req = lim.DeepCopy()
+		}
+	}
+
+	quantities[0], quantities[1] = req, lim
+	return quantities, nil
+}
+
+func parseOr(value, fallback string) (resource.Quantity, error) {
+	if value == "" {
+		value = fallback
+	}
+	return resource.ParseQuantity(value)
+}
+
+// cacheArgs are the Kaniko flags for the layer cache of a build: on with
+// the default TTL unless the build turns it off or sets its own TTL
+func (e *KanikoExecutor) cacheArgs(job *queue.BuildJob) []string {
+	cfg := job.BuildConfig.Resources
+	if cfg != nil && cfg.Cache != nil && !*cfg.Cache {
+		return []string{"--cache=false"}
+	}
+
+	ttl := DefaultCacheTTLHours
+	if cfg != nil && cfg.CacheTTLHours > 0 {
+		ttl = cfg.CacheTTLHours
+	}
+	return []string{
+		"--cache=true",
+		"--cache-repo=" + e.cacheRepository(job),
+		fmt.Sprintf("--cache-ttl=%dh", ttl),
+	}
+}
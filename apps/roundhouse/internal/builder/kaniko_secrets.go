@@ -0,0 +1,61 @@
+package builder
+
+import (
+	"context"
+
+	"github.com/madfam-org/enclii/apps/roundhouse/internal/queue"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// BuildSecretsPath is where build secrets are mounted in the Kaniko
+	// container: the path BuildKit mounts secrets at, so a Dockerfile's
+	// RUN --mount=type=secret,id=NAME finds /run/secrets/NAME
+	BuildSecretsPath = "/run/secrets"
+)
+
+// useBuildSecrets mounts a project's build secrets into the Kaniko
+// container. Kaniko leaves mounted paths out of image layers; ignoring the
+// path keeps them out even where it does not detect the mount.
+func useBuildSecrets(pod *corev1.PodSpec, secretName string) {
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: "build-secrets",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+		},
+	})
+	kaniko := &pod.Containers[0]
+	kaniko.VolumeMounts = append(kaniko.VolumeMounts, corev1.VolumeMount{
+		Name:      "build-secrets",
+		MountPath: BuildSecretsPath,
+		ReadOnly:  true,
+	})
+	kaniko.Args = append(kaniko.Args, "--ignore-path="+BuildSecretsPath)
+}
+
+// logRedactor returns the redactor of a build's logs: it masks the values
+// of the build's secrets and, unless the project turned redaction off,
+// common token patterns. It is nil when the build secrets cannot be read,
+// since no log line could then be trusted.
+func (e *KanikoExecutor) logRedactor(ctx context.Context, job *queue.BuildJob) *redact.Redactor {
+	patterns := !job.LogRedactionDisabled
+	if job.BuildSecrets == "" {
+		return redact.New(nil, patterns)
+	}
+	secret, err := e.k8sClient.CoreV1().Secrets(KanikoBuildNamespace).Get(ctx, job.BuildSecrets, metav1.GetOptions{})
+	if err != nil {
+		e.logger.Warn("could not read build secrets, hiding build logs",
+			zap.String("job_id", job.ID.String()), zap.Error(err))
+		e.log(job.ID, "🔒 Build logs are hidden: the build secrets to mask could not be read")
+		return nil
+	}
+
+	values := make([]string, 0, len(secret.Data))
+	for _, value := range secret.Data {
+		values = append(values, string(value))
+	}
+	return redact.New(values, patterns)
+}
@@ -35,6 +35,12 @@ type Config struct {
 	SignImages   bool   `mapstructure:"SIGN_IMAGES"`
 	CosignKey    string `mapstructure:"COSIGN_KEY"`
 
+	// Build artifacts (Kaniko mode): S3-compatible bucket the files a build
+	// declares are uploaded to, and the secret holding its access keys
+	ArtifactsEndpoint    string `mapstructure:"ARTIFACTS_ENDPOINT"`
+	ArtifactsBucket      string `mapstructure:"ARTIFACTS_BUCKET"`
+	ArtifactsCredentials string `mapstructure:"ARTIFACTS_CREDENTIALS"`
+
 	// Webhooks
 	GitHubWebhookSecret    string `mapstructure:"GITHUB_WEBHOOK_SECRET"`
 	GitLabWebhookSecret    string `mapstructure:"GITLAB_WEBHOOK_SECRET"`
@@ -50,6 +56,11 @@ type Config struct {
 	// Worker settings
 	MaxConcurrentBuilds int           `mapstructure:"MAX_CONCURRENT_BUILDS"`
 	PollInterval        time.Duration `mapstructure:"POLL_INTERVAL"`
+	QueueStallThreshold time.Duration `mapstructure:"QUEUE_STALL_THRESHOLD"` // Queued longer than this = stalled
+
+	// BuildLeaseTTL is how long a build stays leased to its worker without a
+	// heartbeat before other workers take it over
+	BuildLeaseTTL time.Duration `mapstructure:"BUILD_LEASE_TTL"`
 }
 
 func Load() (*Config, error) {
@@ -61,8 +72,11 @@ func Load() (*Config, error) {
 	viper.SetDefault("SIGN_IMAGES", true)
 	viper.SetDefault("MAX_CONCURRENT_BUILDS", 3)
 	viper.SetDefault("POLL_INTERVAL", 5*time.Second)
+	viper.SetDefault("QUEUE_STALL_THRESHOLD", 30*time.Minute)
+	viper.SetDefault("BUILD_LEASE_TTL", 2*time.Minute)
 	viper.SetDefault("REGISTRY", "ghcr.io")
 	viper.SetDefault("KANIKO_GIT_CREDENTIALS", "git-credentials")
+	viper.SetDefault("ARTIFACTS_CREDENTIALS", "artifacts-credentials")
 	viper.SetDefault("PREVIEWS_ENABLED", true)
 
 	// Bind environment variables explicitly for reliable reading
@@ -80,11 +94,16 @@ func Load() (*Config, error) {
 	viper.BindEnv("GENERATE_SBOM")
 	viper.BindEnv("SIGN_IMAGES")
 	viper.BindEnv("COSIGN_KEY")
+	viper.BindEnv("ARTIFACTS_ENDPOINT")
+	viper.BindEnv("ARTIFACTS_BUCKET")
+	viper.BindEnv("ARTIFACTS_CREDENTIALS")
 	viper.BindEnv("SWITCHYARD_INTERNAL_URL")
 	viper.BindEnv("SWITCHYARD_API_KEY")
 	viper.BindEnv("PREVIEWS_ENABLED")
 	viper.BindEnv("MAX_CONCURRENT_BUILDS")
 	viper.BindEnv("POLL_INTERVAL")
+	viper.BindEnv("QUEUE_STALL_THRESHOLD")
+	viper.BindEnv("BUILD_LEASE_TTL")
 
 	viper.AutomaticEnv()
 
@@ -0,0 +1,96 @@
+// Package gc deletes images Switchyard no longer keeps releases of from the
+// registry Roundhouse pushes to. Images elsewhere, such as a project's own
+// registry, are left alone since Roundhouse holds no credentials for them.
+package gc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// MaxImagesPerRequest bounds the images collected in one request
+const MaxImagesPerRequest = 100
+
+// Request lists the images to delete
+type Request struct {
+	Images []string `json:"images" binding:"required"`
+}
+
+// Failure is an image that could not be deleted
+type Failure struct {
+	Image string `json:"image"`
+	Error string `json:"error"`
+}
+
+// Result reports what became of each image. Missing images were already
+// gone; skipped ones are outside the registry or on a registry that does
+// not allow deletion.
+type Result struct {
+	Deleted []string  `json:"deleted"`
+	Missing []string  `json:"missing"`
+	Skipped []string  `json:"skipped"`
+	Failed  []Failure `json:"failed"`
+}
+
+// Collector deletes images from the registry builds are pushed to
+type Collector struct {
+	registry string
+	client   *registryClient
+	logger   *zap.Logger
+}
+
+// NewCollector creates a collector for images under registry, e.g. ghcr.io
+// or ghcr.io/org
+func NewCollector(registry, username, password string, logger *zap.Logger) *Collector {
+	return &Collector{
+		registry: strings.TrimSuffix(registry, "/"),
+		client:   newRegistryClient(username, password),
+		logger:   logger,
+	}
+}
+
+// owns reports whether an image is under the collector's registry
+func (c *Collector) owns(image string) bool {
+	return c.registry != "" && strings.HasPrefix(image, c.registry+"/")
+}
+
+// Collect deletes the images, continuing past the ones that fail
+func (c *Collector) Collect(ctx context.Context, images []string) *Result {
+	result := &Result{
+		Deleted: []string{},
+		Missing: []string{},
+		Skipped: []string{},
+		Failed:  []Failure{},
+	}
+
+	for _, image := range images {
+		if !c.owns(image) {
+			result.Skipped = append(result.Skipped, image)
+			continue
+		}
+
+		err := c.client.deleteImage(ctx, image)
+		switch {
+		case err == nil:
+			result.Deleted = append(result.Deleted, image)
+		case errors.Is(err, ErrImageNotFound):
+			result.Missing = append(result.Missing, image)
+		case errors.Is(err, ErrDeleteUnsupported):
+			result.Skipped = append(result.Skipped, image)
+		default:
+			c.logger.Warn("failed to delete image", zap.String("image", image), zap.Error(err))
+			result.Failed = append(result.Failed, Failure{Image: image, Error: err.Error()})
+		}
+	}
+
+	c.logger.Info("collected images",
+		zap.Int("deleted", len(result.Deleted)),
+		zap.Int("missing", len(result.Missing)),
+		zap.Int("skipped", len(result.Skipped)),
+		zap.Int("failed", len(result.Failed)),
+	)
+	return result
+}
@@ -0,0 +1,78 @@
+package gc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCollect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && strings.HasSuffix(r.URL.Path, "/api/manifests/v1"):
+			w.Header().Set("Docker-Content-Digest", "sha256:aaa")
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/api/manifests/sha256:aaa"):
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodHead && strings.HasSuffix(r.URL.Path, "/worker/manifests/v1"):
+			w.Header().Set("Docker-Content-Digest", "sha256:bbb")
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/worker/manifests/sha256:bbb"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	registry := strings.TrimPrefix(srv.URL, "http://")
+	c := NewCollector(registry+"/org", "", "", zap.NewNop())
+	c.client.scheme = "http"
+
+	result := c.Collect(context.Background(), []string{
+		registry + "/org/api:v1",
+		registry + "/org/web:v1",
+		registry + "/org/worker:v1",
+		"docker.io/other/api:v1",
+	})
+
+	if len(result.Deleted) != 1 || result.Deleted[0] != registry+"/org/api:v1" {
+		t.Errorf("Deleted = %v", result.Deleted)
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != registry+"/org/web:v1" {
+		t.Errorf("Missing = %v", result.Missing)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Image != registry+"/org/worker:v1" {
+		t.Errorf("Failed = %v", result.Failed)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "docker.io/other/api:v1" {
+		t.Errorf("Skipped = %v", result.Skipped)
+	}
+}
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image string
+		want  imageRef
+	}{
+		{"ghcr.io/org/api:v1", imageRef{"ghcr.io", "org/api", "v1"}},
+		{"localhost:5000/api@sha256:abc", imageRef{"localhost:5000", "api", "sha256:abc"}},
+		{"nginx:1.27", imageRef{"registry-1.docker.io", "library/nginx", "1.27"}},
+	}
+	for _, tt := range tests {
+		got, err := parseImageRef(tt.image)
+		if err != nil {
+			t.Errorf("parseImageRef(%q): %v", tt.image, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseImageRef(%q) = %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+
+	if _, err := parseImageRef("ghcr.io/org/api"); err == nil {
+		t.Error("expected an error for an image without a tag")
+	}
+}
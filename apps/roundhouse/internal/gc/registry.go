@@ -0,0 +1,256 @@
+package gc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrImageNotFound is returned when the image is already gone from the registry
+	ErrImageNotFound = errors.New("image not found in registry")
+	// ErrDeleteUnsupported is returned when the registry does not allow
+	// deleting manifests through the Distribution API
+	ErrDeleteUnsupported = errors.New("registry does not support image deletion")
+)
+
+// manifestMediaTypes are the manifest formats a registry may hold an image as
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// registryClient deletes images through the OCI Distribution API. It
+// authenticates with basic auth, exchanging it for a bearer token when the
+// registry asks for one.
+type registryClient struct {
+	username   string
+	password   string
+	scheme     string
+	httpClient *http.Client
+}
+
+func newRegistryClient(username, password string) *registryClient {
+	return &registryClient{
+		username:   username,
+		password:   password,
+		scheme:     "https",
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// imageRef is a parsed image reference
+type imageRef struct {
+	host       string
+	repository string
+	reference  string // tag or digest
+}
+
+// parseImageRef splits an image URI such as ghcr.io/org/api:v1.2.3
+func parseImageRef(image string) (imageRef, error) {
+	ref := imageRef{host: "registry-1.docker.io"}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.reference = name[:i], name[i+1:]
+	}
+	if ref.reference == "" {
+		return ref, fmt.Errorf("image %q has no tag or digest", image)
+	}
+
+	if i := strings.Index(name, "/"); i >= 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.host, name = first, name[i+1:]
+		}
+	}
+	if name == "" {
+		return ref, fmt.Errorf("image %q has no repository", image)
+	}
+	if ref.host == "registry-1.docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.repository = name
+
+	return ref, nil
+}
+
+// deleteImage deletes an image's manifest, releasing its layers to the
+// registry's garbage collector
+func (r *registryClient) deleteImage(ctx context.Context, image string) error {
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return err
+	}
+	session := &registrySession{client: r, ref: ref}
+
+	digest, err := session.resolveDigest(ctx, image)
+	if err != nil {
+		return err
+	}
+
+	resp, err := session.do(ctx, http.MethodDelete, "manifests/"+digest)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrImageNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrDeleteUnsupported
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to delete %s: registry returned %d: %s", image, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
+
+// registrySession holds the bearer token for one repository
+type registrySession struct {
+	client *registryClient
+	ref    imageRef
+	token  string
+}
+
+// resolveDigest returns the digest of the session's reference, looking it
+// up when the reference is a tag
+func (s *registrySession) resolveDigest(ctx context.Context, image string) (string, error) {
+	if strings.Contains(s.ref.reference, ":") {
+		return s.ref.reference, nil
+	}
+
+	resp, err := s.do(ctx, http.MethodHead, "manifests/"+s.ref.reference)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrImageNotFound
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("failed to resolve %s: registry returned %d", image, resp.StatusCode)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("failed to resolve %s: registry returned no digest", image)
+	}
+	return digest, nil
+}
+
+// do sends a request to the repository, fetching a bearer token and
+// retrying once when the registry challenges for one
+func (s *registrySession) do(ctx context.Context, method, path string) (*http.Response, error) {
+	resp, err := s.send(ctx, method, path)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || s.token != "" {
+		return resp, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return nil, fmt.Errorf("registry %s rejected the credentials", s.ref.host)
+	}
+	if err := s.fetchToken(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	return s.send(ctx, method, path)
+}
+
+func (s *registrySession) send(ctx context.Context, method, path string) (*http.Response, error) {
+	u := fmt.Sprintf("%s://%s/v2/%s/%s", s.client.scheme, s.ref.host, s.ref.repository, path)
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+
+	switch {
+	case s.token != "":
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	case s.client.username != "":
+		req.SetBasicAuth(s.client.username, s.client.password)
+	}
+
+	resp, err := s.client.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registry request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// fetchToken exchanges the registry credentials for a token allowed to
+// pull and delete in the repository
+func (s *registrySession) fetchToken(ctx context.Context, challenge string) error {
+	params := parseChallenge(challenge[len("bearer "):])
+	realm := params["realm"]
+	if realm == "" {
+		return fmt.Errorf("registry %s sent a challenge without a realm", s.ref.host)
+	}
+
+	q := url.Values{}
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	q.Set("scope", "repository:"+s.ref.repository+":pull,delete")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if s.client.username != "" {
+		req.SetBasicAuth(s.client.username, s.client.password)
+	}
+
+	resp, err := s.client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("registry token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry token request returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	s.token = body.Token
+	if s.token == "" {
+		s.token = body.AccessToken
+	}
+	if s.token == "" {
+		return fmt.Errorf("registry %s returned an empty token", s.ref.host)
+	}
+	return nil
+}
+
+// parseChallenge parses the key="value" pairs of a WWW-Authenticate challenge
+func parseChallenge(s string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(key)] = strings.Trim(value, `"`)
+	}
+	return params
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"strings"
+	"time"
+)
+
+// BuildRecord is a build job with the state it reached
+type BuildRecord struct {
+	Job         *BuildJob    `json:"job"`
+	Status      JobStatus    `json:"status"`
+	WorkerID    string       `json:"worker_id,omitempty"`
+	StartedAt   *time.Time   `json:"started_at,omitempty"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	Result      *BuildResult `json:"result,omitempty"`
+}
+
+// DurationSecs returns how long a finished build ran, preferring the duration
+// the worker reported. It returns 0 for builds that have not finished.
+func (r *BuildRecord) DurationSecs() float64 {
+	if r.Result != nil && r.Result.DurationSecs > 0 {
+		return r.Result.DurationSecs
+	}
+	if r.StartedAt != nil && r.CompletedAt != nil && r.CompletedAt.After(*r.StartedAt) {
+		return r.CompletedAt.Sub(*r.StartedAt).Seconds()
+	}
+	return 0
+}
+
+// BuildFilter selects builds from the history. Empty fields match everything.
+type BuildFilter struct {
+	Service     string // Service ID or name
+	Branch      string
+	Status      JobStatus
+	TriggeredBy string
+	Query       string    // Case-insensitive text in the commit message
+	From        time.Time // Created at or after
+	To          time.Time // Created at or before
+}
+
+// Matches reports whether a build passes the filter
+func (f *BuildFilter) Matches(r *BuildRecord) bool {
+	job := r.Job
+	if f.Service != "" && job.ServiceID.String() != f.Service && !strings.EqualFold(job.ServiceName, f.Service) {
+		return false
+	}
+	if f.Branch != "" && job.GitBranch != f.Branch {
+		return false
+	}
+	if f.Status != "" && r.Status != f.Status {
+		return false
+	}
+	if f.TriggeredBy != "" && !strings.EqualFold(job.TriggeredBy, f.TriggeredBy) {
+		return false
+	}
+	if f.Query != "" && !strings.Contains(strings.ToLower(job.CommitMessage), strings.ToLower(f.Query)) {
+		return false
+	}
+	if !f.From.IsZero() && job.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && job.CreatedAt.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// BuildStats aggregates a set of builds
+type BuildStats struct {
+	Total           int               `json:"total"`
+	ByStatus        map[JobStatus]int `json:"by_status"`
+	SuccessRate     *float64          `json:"success_rate"`      // Completed / (completed + failed); nil before any build finished
+	AvgDurationSecs *float64          `json:"avg_duration_secs"` // Mean of finished builds; nil before any build finished
+}
+
+// ComputeBuildStats aggregates builds. Cancelled builds and builds still
+// queued or running do not count towards the success rate or duration.
+func ComputeBuildStats(records []*BuildRecord) BuildStats {
+	stats := BuildStats{
+		Total:    len(records),
+		ByStatus: make(map[JobStatus]int),
+	}
+
+	var finished, durations int
+	var totalDuration float64
+	for _, r := range records {
+		stats.ByStatus[r.Status]++
+		if r.Status != StatusCompleted && r.Status != StatusFailed {
+			continue
+		}
+		finished++
+		if d := r.DurationSecs(); d > 0 {
+			totalDuration += d
+			durations++
+		}
+	}
+
+	if finished > 0 {
+		rate := float64(stats.ByStatus[StatusCompleted]) / float64(finished)
+		stats.SuccessRate = &rate
+	}
+	if durations > 0 {
+		avg := totalDuration / float64(durations)
+		stats.AvgDurationSecs = &avg
+	}
+
+	return stats
+}
@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestBuildFilterMatches(t *testing.T) {
+	serviceID := uuid.New()
+	created := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	record := &BuildRecord{
+		Job: &BuildJob{
+			ServiceID:     serviceID,
+			ServiceName:   "api",
+			GitBranch:     "main",
+			CommitMessage: "Fix flaky checkout test",
+			TriggeredBy:   "octocat",
+			CreatedAt:     created,
+		},
+		Status: StatusFailed,
+	}
+
+	tests := []struct {
+		name   string
+		filter BuildFilter
+		want   bool
+	}{
+		{name: "no filters", filter: BuildFilter{}, want: true},
+		{name: "service by ID", filter: BuildFilter{Service: serviceID.String()}, want: true},
+		{name: "service by name", filter: BuildFilter{Service: "API"}, want: true},
+		{name: "other service", filter: BuildFilter{Service: "worker"}, want: false},
+		{name: "branch", filter: BuildFilter{Branch: "main"}, want: true},
+		{name: "other branch", filter: BuildFilter{Branch: "develop"}, want: false},
+		{name: "status", filter: BuildFilter{Status: StatusFailed}, want: true},
+		{name: "other status", filter: BuildFilter{Status: StatusCompleted}, want: false},
+		{name: "triggered by", filter: BuildFilter{TriggeredBy: "OctoCat"}, want: true},
+		{name: "triggered by someone else", filter: BuildFilter{TriggeredBy: "hubot"}, want: false},
+		{name: "commit message text", filter: BuildFilter{Query: "flaky"}, want: true},
+		{name: "commit message text missing", filter: BuildFilter{Query: "deploy"}, want: false},
+		{name: "within range", filter: BuildFilter{From: created.Add(-time.Hour), To: created.Add(time.Hour)}, want: true},
+		{name: "before range", filter: BuildFilter{From: created.Add(time.Minute)}, want: false},
+		{name: "after range", filter: BuildFilter{To: created.Add(-time.Minute)}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(record); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeBuildStats(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+
+	records := []*BuildRecord{
+		{Job: &BuildJob{}, Status: StatusCompleted, Result: &BuildResult{DurationSecs: 60}},
+		{Job: &BuildJob{}, Status: StatusCompleted, StartedAt: &start, CompletedAt: &end},
+		{Job: &BuildJob{}, Status: StatusFailed, Result: &BuildResult{DurationSecs: 30}},
+		{Job: &BuildJob{}, Status: StatusCompleted, Result: &BuildResult{DurationSecs: 120}},
+		{Job: &BuildJob{}, Status: StatusCancelled},
+		{Job: &BuildJob{}, Status: StatusBuilding, StartedAt: &start},
+	}
+
+	stats := ComputeBuildStats(records)
+	if stats.Total != 6 {
+		t.Errorf("Total = %d, want 6", stats.Total)
+	}
+	if stats.ByStatus[StatusCompleted] != 3 || stats.ByStatus[StatusFailed] != 1 || stats.ByStatus[StatusCancelled] != 1 {
+		t.Errorf("ByStatus = %v", stats.ByStatus)
+	}
+	if stats.SuccessRate == nil || *stats.SuccessRate != 0.75 {
+		t.Errorf("SuccessRate = %v, want 0.75", stats.SuccessRate)
+	}
+	if stats.AvgDurationSecs == nil || math.Abs(*stats.AvgDurationSecs-75) > 1e-9 {
+		t.Errorf("AvgDurationSecs = %v, want 75", stats.AvgDurationSecs)
+	}
+
+	empty := ComputeBuildStats([]*BuildRecord{{Job: &BuildJob{}, Status: StatusQueued}})
+	if empty.SuccessRate != nil || empty.AvgDurationSecs != nil {
+		t.Errorf("stats of unfinished builds = %+v, want no rate or duration", empty)
+	}
+}
+
+func TestParseBuildRecord(t *testing.T) {
+	job := &BuildJob{ID: uuid.New(), ServiceName: "api", CommitMessage: "Add builds API"}
+	data, _ := json.Marshal(job)
+	result, _ := json.Marshal(&BuildResult{JobID: job.ID, Success: true, DurationSecs: 42})
+
+	record, err := parseBuildRecord(map[string]string{
+		"data":         string(data),
+		"status":       string(StatusCompleted),
+		"worker_id":    "worker-1",
+		"started_at":   "2026-03-10T12:00:00Z",
+		"completed_at": "2026-03-10T12:00:42Z",
+		"result":       string(result),
+	})
+	if err != nil {
+		t.Fatalf("parseBuildRecord() error = %v", err)
+	}
+	if record.Job.ID != job.ID || record.Job.CommitMessage != job.CommitMessage {
+		t.Errorf("Job = %+v, want %+v", record.Job, job)
+	}
+	if record.Status != StatusCompleted || record.WorkerID != "worker-1" {
+		t.Errorf("Status = %q, WorkerID = %q", record.Status, record.WorkerID)
+	}
+	if record.StartedAt == nil || record.CompletedAt == nil || record.Result == nil || record.Result.DurationSecs != 42 {
+		t.Errorf("record = %+v, want timestamps and result", record)
+	}
+
+	queued, err := parseBuildRecord(map[string]string{"data": string(data), "status": string(StatusQueued)})
+	if err != nil {
+		t.Fatalf("parseBuildRecord() error = %v", err)
+	}
+	if queued.StartedAt != nil || queued.CompletedAt != nil || queued.Result != nil {
+		t.Errorf("queued record = %+v, want no timestamps or result", queued)
+	}
+
+	if _, err := parseBuildRecord(map[string]string{"data": "{"}); err == nil {
+		t.Error("parseBuildRecord() with invalid data succeeded, want error")
+	}
+}
@@ -3,7 +3,10 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,9 +22,31 @@ const (
 	jobHashKeyPrefix   = "roundhouse:job:"
 	logsStreamPrefix   = "roundhouse:logs:"
 	statsKey           = "roundhouse:stats"
+	redactionsField    = "redactions:" // Prefix of the redaction counts in statsKey
 	activeWorkersKey   = "roundhouse:workers:active"
+	buildIndexKey      = "roundhouse:builds:index" // Sorted set of job IDs by creation time
+
+	// buildLeasesKey is a sorted set of building job IDs by lease expiry
+	buildLeasesKey = "roundhouse:builds:leases"
+
+	// jobRetention is how long job details, logs and build history are kept
+	jobRetention = 7 * 24 * time.Hour
 )
 
+// ErrJobNotFound is returned for a job that does not exist or expired
+var ErrJobNotFound = errors.New("job not found")
+
+// leaseScript leases a job to a worker unless another worker holds a lease
+// on it that has not expired yet
+var leaseScript = redis.NewScript(`
+local expiry = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if expiry and tonumber(expiry) > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 1
+`)
+
 // RedisQueue implements the build queue using Redis
 type RedisQueue struct {
 	client *redis.Client
@@ -119,7 +144,16 @@ func (q *RedisQueue) Enqueue(ctx context.Context, job *BuildJob) error {
 	}
 
 	// Set expiry for job data (7 days)
-	q.client.Expire(ctx, jobKey, 7*24*time.Hour)
+	q.client.Expire(ctx, jobKey, jobRetention)
+
+	// Index the job for the build history, dropping entries whose details expired
+	if err := q.client.ZAdd(ctx, buildIndexKey, redis.Z{
+		Score:  float64(job.CreatedAt.UnixMilli()),
+		Member: job.ID.String(),
+	}).Err(); err != nil {
+		q.logger.Warn("failed to index job", zap.String("job_id", job.ID.String()), zap.Error(err))
+	}
+	q.client.ZRemRangeByScore(ctx, buildIndexKey, "-inf", fmt.Sprintf("(%d", job.CreatedAt.Add(-jobRetention).UnixMilli()))
 
 	// Add to queue (priority queue uses sorted set)
 	if job.Priority > 0 {
@@ -230,7 +264,7 @@ func (q *RedisQueue) GetJob(ctx context.Context, jobID uuid.UUID) (*BuildJob, Jo
 	}
 
 	if len(result) == 0 {
-		return nil, "", fmt.Errorf("job not found: %s", jobID.String())
+		return nil, "", fmt.Errorf("%w: %s", ErrJobNotFound, jobID.String())
 	}
 
 	var job BuildJob
@@ -261,6 +295,87 @@ func (q *RedisQueue) GetResult(ctx context.Context, jobID uuid.UUID) (*BuildResu
 	return &result, nil
 }
 
+// ListBuilds returns the builds created between from and to, newest first.
+// A zero from or to leaves that end open. Only builds still retained are returned.
+func (q *RedisQueue) ListBuilds(ctx context.Context, from, to time.Time) ([]*BuildRecord, error) {
+	rangeBy := &redis.ZRangeBy{Min: "-inf", Max: "+inf"}
+	if !from.IsZero() {
+		rangeBy.Min = fmt.Sprintf("%d", from.UnixMilli())
+	}
+	if !to.IsZero() {
+		rangeBy.Max = fmt.Sprintf("%d", to.UnixMilli())
+	}
+
+	jobIDs, err := q.client.ZRevRangeByScore(ctx, buildIndexKey, rangeBy).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list builds: %w", err)
+	}
+	if len(jobIDs) == 0 {
+		return nil, nil
+	}
+
+	pipe := q.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(jobIDs))
+	for i, jobID := range jobIDs {
+		cmds[i] = pipe.HGetAll(ctx, jobHashKeyPrefix+jobID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get builds: %w", err)
+	}
+
+	records := make([]*BuildRecord, 0, len(jobIDs))
+	var expired []interface{}
+	for i, cmd := range cmds {
+		fields, err := cmd.Result()
+		if err != nil || len(fields) == 0 {
+			expired = append(expired, jobIDs[i])
+			continue
+		}
+
+		record, err := parseBuildRecord(fields)
+		if err != nil {
+			q.logger.Warn("failed to parse build", zap.String("job_id", jobIDs[i]), zap.Error(err))
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if len(expired) > 0 {
+		q.client.ZRem(ctx, buildIndexKey, expired...)
+	}
+
+	return records, nil
+}
+
+// parseBuildRecord reads a build from the fields of its job hash
+func parseBuildRecord(fields map[string]string) (*BuildRecord, error) {
+	var job BuildJob
+	if err := json.Unmarshal([]byte(fields["data"]), &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+
+	record := &BuildRecord{
+		Job:      &job,
+		Status:   JobStatus(fields["status"]),
+		WorkerID: fields["worker_id"],
+	}
+	if t, err := time.Parse(time.RFC3339, fields["started_at"]); err == nil {
+		record.StartedAt = &t
+	}
+	if t, err := time.Parse(time.RFC3339, fields["completed_at"]); err == nil {
+		record.CompletedAt = &t
+	}
+	if data := fields["result"]; data != "" {
+		var result BuildResult
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+		}
+		record.Result = &result
+	}
+
+	return record, nil
+}
+
 // AppendLog adds a log line to the build logs stream
 func (q *RedisQueue) AppendLog(ctx context.Context, jobID uuid.UUID, line string) error {
 	streamKey := logsStreamPrefix + jobID.String()
@@ -278,11 +393,49 @@ func (q *RedisQueue) AppendLog(ctx context.Context, jobID uuid.UUID, line string
 	}
 
 	// Set expiry on logs stream (7 days)
-	q.client.Expire(ctx, streamKey, 7*24*time.Hour)
+	q.client.Expire(ctx, streamKey, jobRetention)
 
 	return nil
 }
 
+// RecordRedactions adds the secrets masked in a build's logs to the
+// redaction counts of all builds
+func (q *RedisQueue) RecordRedactions(ctx context.Context, counts map[string]int) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	pipe := q.client.Pipeline()
+	for kind, n := range counts {
+		pipe.HIncrBy(ctx, statsKey, redactionsField+kind, int64(n))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record redactions: %w", err)
+	}
+	return nil
+}
+
+// Redactions returns how many secrets of each kind have been masked in
+// build logs
+func (q *RedisQueue) Redactions(ctx context.Context) (map[string]int64, error) {
+	fields, err := q.client.HGetAll(ctx, statsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redactions: %w", err)
+	}
+	counts := make(map[string]int64)
+	for field, value := range fields {
+		kind, ok := strings.CutPrefix(field, redactionsField)
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		counts[kind] = n
+	}
+	return counts, nil
+}
+
 // StreamLogs streams build logs from a job
 func (q *RedisQueue) StreamLogs(ctx context.Context, jobID uuid.UUID, fromID string) (<-chan string, error) {
 	streamKey := logsStreamPrefix + jobID.String()
@@ -345,6 +498,117 @@ func (q *RedisQueue) QueueLength(ctx context.Context) (int64, error) {
 	return regular + priority, nil
 }
 
+// ListStalledJobs returns queued jobs created before cutoff that have not yet been flagged as stalled
+func (q *RedisQueue) ListStalledJobs(ctx context.Context, cutoff time.Time) ([]*BuildJob, error) {
+	regular, err := q.client.LRange(ctx, buildQueueKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued jobs: %w", err)
+	}
+
+	priority, err := q.client.ZRange(ctx, priorityQueueKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list priority jobs: %w", err)
+	}
+
+	var jobs []*BuildJob
+	for _, jobID := range append(priority, regular...) {
+		fields, err := q.client.HMGet(ctx, jobHashKeyPrefix+jobID, "data", "stalled_at").Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job %s: %w", jobID, err)
+		}
+
+		data, ok := fields[0].(string)
+		if !ok || fields[1] != nil {
+			continue // Expired or already flagged
+		}
+
+		var job BuildJob
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			q.logger.Warn("failed to unmarshal queued job", zap.String("job_id", jobID), zap.Error(err))
+			continue
+		}
+
+		if job.CreatedAt.Before(cutoff) {
+			jobs = append(jobs, &job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// MarkStalled flags a job as stalled. It returns false if the job was already flagged,
+// so that each stalled job is reported only once across workers.
+func (q *RedisQueue) MarkStalled(ctx context.Context, jobID uuid.UUID) (bool, error) {
+	return q.client.HSetNX(ctx, jobHashKeyPrefix+jobID.String(), "stalled_at", time.Now().Format(time.RFC3339)).Result()
+}
+
+// Lease leases a building job to a worker for ttl, so other workers leave it
+// alone while the worker keeps renewing the lease. It returns false if
+// another worker holds a lease on the job that has not expired yet.
+func (q *RedisQueue) Lease(ctx context.Context, jobID uuid.UUID, workerID string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	leased, err := leaseScript.Run(ctx, q.client, []string{buildLeasesKey},
+		jobID.String(), now.UnixMilli(), now.Add(ttl).UnixMilli()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to lease job: %w", err)
+	}
+	if leased == 0 {
+		return false, nil
+	}
+
+	if err := q.client.HSet(ctx, jobHashKeyPrefix+jobID.String(), "worker_id", workerID).Err(); err != nil {
+		q.logger.Warn("failed to record job worker", zap.String("job_id", jobID.String()), zap.Error(err))
+	}
+	return true, nil
+}
+
+// RenewLeases extends the leases of a worker's jobs to ttl from now. A ttl
+// of zero expires them at once, handing the jobs to other workers.
+func (q *RedisQueue) RenewLeases(ctx context.Context, jobIDs []uuid.UUID, ttl time.Duration) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+
+	expiry := float64(time.Now().Add(ttl).UnixMilli())
+	pipe := q.client.Pipeline()
+	for _, jobID := range jobIDs {
+		// XX leaves out leases released in the meantime
+		pipe.ZAddXX(ctx, buildLeasesKey, redis.Z{Score: expiry, Member: jobID.String()})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to renew leases: %w", err)
+	}
+	return nil
+}
+
+// ReleaseLease removes the lease of a job that finished
+func (q *RedisQueue) ReleaseLease(ctx context.Context, jobID uuid.UUID) error {
+	return q.client.ZRem(ctx, buildLeasesKey, jobID.String()).Err()
+}
+
+// ExpiredLeases returns the jobs whose lease expired before now, because the
+// worker building them stopped renewing it
+func (q *RedisQueue) ExpiredLeases(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	members, err := q.client.ZRangeByScore(ctx, buildLeasesKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("(%d", now.UnixMilli()),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired leases: %w", err)
+	}
+
+	jobIDs := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		jobID, err := uuid.Parse(member)
+		if err != nil {
+			q.client.ZRem(ctx, buildLeasesKey, member)
+			continue
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+	return jobIDs, nil
+}
+
 // RegisterWorker registers a worker as active
 func (q *RedisQueue) RegisterWorker(ctx context.Context, workerID string) error {
 	return q.client.SAdd(ctx, activeWorkersKey, workerID).Err()
@@ -20,6 +20,27 @@ type BuildJob struct {
 	CallbackURL string      `json:"callback_url"`
 	CreatedAt   time.Time   `json:"created_at"`
 	Priority    int         `json:"priority"` // Higher = more urgent
+
+	// Metadata for searching build history
+	CommitMessage string `json:"commit_message,omitempty"`
+	TriggeredBy   string `json:"triggered_by,omitempty"` // Pusher or user who started the build
+
+	// SourceURL is a download URL of a gzipped build context uploaded from a
+	// local working directory. When set it is built instead of cloning GitRepo.
+	SourceURL string `json:"source_url,omitempty"`
+
+	// RegistrySecret names a Docker config secret in the build namespace with
+	// the project's registry credentials, used instead of the platform's.
+	RegistrySecret string `json:"registry_secret,omitempty"`
+	// ImageRepository is the project's repository the image is pushed to
+	// instead of the platform registry, e.g. registry.example.com/team
+	ImageRepository string `json:"image_repository,omitempty"`
+	// BuildSecrets names a secret in the build namespace with the project's
+	// build secrets, mounted under /run/secrets in the build
+	BuildSecrets string `json:"build_secrets,omitempty"`
+	// LogRedactionDisabled turns off masking of token patterns in the build
+	// logs; build secrets are masked regardless
+	LogRedactionDisabled bool `json:"log_redaction_disabled,omitempty"`
 }
 
 // BuildConfig specifies how to build the image
@@ -30,6 +51,33 @@ type BuildConfig struct {
 	Context    string            `json:"context"`    // Build context path
 	BuildArgs  map[string]string `json:"build_args"` // Build arguments
 	Target     string            `json:"target"`     // Multi-stage target
+	GPU        bool              `json:"gpu"`        // Run on a GPU node with one GPU
+
+	// Artifacts is a directory of the built image whose files are published
+	// as build artifacts, e.g. test reports or compiled binaries
+	Artifacts string `json:"artifacts,omitempty"`
+	// Resources sizes the build; nil keeps the worker's defaults
+	Resources *BuildResources `json:"resources,omitempty"`
+}
+
+// BuildResources are the resources and timeout of a build and how it uses
+// the layer cache. Unset fields keep the worker's defaults.
+type BuildResources struct {
+	CPURequest     string `json:"cpu_request,omitempty"`    // e.g. "2"
+	MemoryRequest  string `json:"memory_request,omitempty"` // e.g. "4Gi"
+	CPULimit       string `json:"cpu_limit,omitempty"`
+	MemoryLimit    string `json:"memory_limit,omitempty"`
+	TimeoutMinutes int    `json:"timeout_minutes,omitempty"`
+	Cache          *bool  `json:"cache,omitempty"` // Nil keeps the layer cache on
+	CacheTTLHours  int    `json:"cache_ttl_hours,omitempty"`
+}
+
+// Timeout is how long the build may run: its own timeout, or else fallback
+func (c *BuildConfig) Timeout(fallback time.Duration) time.Duration {
+	if c.Resources == nil || c.Resources.TimeoutMinutes <= 0 {
+		return fallback
+	}
+	return time.Duration(c.Resources.TimeoutMinutes) * time.Minute
 }
 
 // JobStatus represents the current state of a build job
@@ -57,6 +105,19 @@ type BuildResult struct {
 	DurationSecs   float64   `json:"duration_secs"`
 	ErrorMessage   string    `json:"error_message,omitempty"`
 	LogsURL        string    `json:"logs_url"`
+
+	// Artifacts are the files published from BuildConfig.Artifacts
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+
+	// Redactions counts the secrets masked in the build logs, by kind
+	Redactions map[string]int `json:"redactions,omitempty"`
+}
+
+// Artifact is a file a build published to the artifacts bucket
+type Artifact struct {
+	Path      string `json:"path"` // Relative to the artifacts directory
+	SizeBytes int64  `json:"size_bytes"`
+	ObjectKey string `json:"object_key"`
 }
 
 // WebhookPayload represents incoming webhook data
@@ -78,12 +139,22 @@ type EnqueueRequest struct {
 	ServiceID   uuid.UUID   `json:"service_id" binding:"required"`
 	ServiceName string      `json:"service_name" binding:"required"` // Human-readable service name for image tagging
 	ProjectID   uuid.UUID   `json:"project_id" binding:"required"`
-	GitRepo     string      `json:"git_repo" binding:"required"`
+	GitRepo     string      `json:"git_repo" binding:"required_without=SourceURL"`
 	GitSHA      string      `json:"git_sha" binding:"required"`
 	GitBranch   string      `json:"git_branch"`
 	BuildConfig BuildConfig `json:"build_config" binding:"required"`
 	CallbackURL string      `json:"callback_url"`
 	Priority    int         `json:"priority"`
+
+	CommitMessage string `json:"commit_message"`
+	TriggeredBy   string `json:"triggered_by"`
+	SourceURL     string `json:"source_url"`
+
+	RegistrySecret  string `json:"registry_secret"`
+	ImageRepository string `json:"image_repository"`
+	BuildSecrets    string `json:"build_secrets"`
+
+	LogRedactionDisabled bool `json:"log_redaction_disabled"`
 }
 
 // EnqueueResponse is the response after enqueueing a build
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -38,6 +40,10 @@ type Processor struct {
 
 	// Callback retry configuration
 	callbackRetry queue.CallbackRetryConfig
+
+	// Builds this worker holds leases on, renewed while they run
+	leasesMu sync.Mutex
+	leases   map[uuid.UUID]struct{}
 }
 
 // NewProcessor creates a new job processor
@@ -81,6 +87,11 @@ func NewProcessor(cfg *config.Config, q *queue.RedisQueue, logger *zap.Logger) (
 			Timeout:        cfg.BuildTimeout,
 			CacheRepo:      cfg.KanikoCacheRepo,
 			GitCredentials: cfg.KanikoGitCredentials,
+			Artifacts: builder.ArtifactStorage{
+				Endpoint:          cfg.ArtifactsEndpoint,
+				Bucket:            cfg.ArtifactsBucket,
+				CredentialsSecret: cfg.ArtifactsCredentials,
+			},
 		}, logger, logFunc)
 
 	case builder.BuildModeDocker:
@@ -121,6 +132,7 @@ func NewProcessor(cfg *config.Config, q *queue.RedisQueue, logger *zap.Logger) (
 			MaxInterval:     5 * time.Minute,
 			Multiplier:      2.0,
 		},
+		leases: make(map[uuid.UUID]struct{}),
 	}
 
 	return p, nil
@@ -173,9 +185,18 @@ func (p *Processor) Start(ctx context.Context) error {
 		close(p.shutdown)
 	}()
 
+	// Take over the builds of workers that stopped, then keep this worker's
+	// leases alive and watch for workers stopping later
+	p.recoverBuilds(ctx, true)
+	go p.renewLeases(ctx)
+	go p.watchAbandonedBuilds(ctx)
+
 	// Start callback retry processor in background
 	go p.processCallbackRetries(ctx)
 
+	// Start stalled job watchdog in background
+	go p.watchStalledJobs(ctx)
+
 	// Main processing loop
 	for {
 		select {
@@ -230,14 +251,24 @@ func (p *Processor) processJob(ctx context.Context, job *queue.BuildJob) {
 	if err := p.queue.UpdateStatus(ctx, job.ID, queue.StatusBuilding, p.workerID); err != nil {
 		logger.Error("failed to update status", zap.Error(err))
 	}
+	if _, err := p.queue.Lease(ctx, job.ID, p.workerID, p.cfg.BuildLeaseTTL); err != nil {
+		logger.Error("failed to lease job", zap.Error(err))
+	}
+	p.track(job.ID)
 
 	// Create build context with timeout
-	buildCtx, cancel := context.WithTimeout(ctx, p.cfg.BuildTimeout)
+	buildCtx, cancel := context.WithTimeout(ctx, job.BuildConfig.Timeout(p.cfg.BuildTimeout))
 	defer cancel()
 
 	// Execute build using configured builder (Docker or Kaniko)
 	result, err := p.builder.Execute(buildCtx, job)
 
+	p.finishJob(ctx, logger, job, result, err)
+}
+
+// finishJob records the result of a build, releases its lease and sends the
+// result to Switchyard
+func (p *Processor) finishJob(ctx context.Context, logger *zap.Logger, job *queue.BuildJob, result *queue.BuildResult, err error) {
 	// Update final status
 	var finalStatus queue.JobStatus
 	if err != nil || !result.Success {
@@ -254,6 +285,10 @@ func (p *Processor) processJob(ctx context.Context, job *queue.BuildJob) {
 		)
 	}
 
+	if err := p.queue.RecordRedactions(ctx, result.Redactions); err != nil {
+		logger.Warn("failed to record redactions", zap.Error(err))
+	}
+
 	// Store result
 	if err := p.queue.SetResult(ctx, job.ID, result); err != nil {
 		logger.Error("failed to store result", zap.Error(err))
@@ -264,6 +299,11 @@ func (p *Processor) processJob(ctx context.Context, job *queue.BuildJob) {
 		logger.Error("failed to update final status", zap.Error(err))
 	}
 
+	p.untrack(job.ID)
+	if err := p.queue.ReleaseLease(ctx, job.ID); err != nil {
+		logger.Warn("failed to release lease", zap.Error(err))
+	}
+
 	// Send callback to Switchyard (with retry on failure)
 	if job.CallbackURL != "" {
 		if err := p.sendCallbackWithRetry(ctx, job.ID, job.CallbackURL, result); err != nil {
@@ -434,13 +474,19 @@ func (p *Processor) gracefulShutdown() error {
 	case <-done:
 		p.logger.Info("all builds completed")
 	case <-time.After(5 * time.Minute):
-		p.logger.Warn("shutdown timeout, some builds may be interrupted")
+		p.logger.Warn("shutdown timeout, handing remaining builds to other workers")
 	}
 
 	// Unregister worker
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Expire the leases of builds still running, so other workers take them
+	// over without waiting for the leases to run out
+	if err := p.queue.RenewLeases(ctx, p.untrackAll(), 0); err != nil {
+		p.logger.Warn("failed to hand over builds", zap.Error(err))
+	}
+
 	if err := p.queue.UnregisterWorker(ctx, p.workerID); err != nil {
 		p.logger.Warn("failed to unregister worker", zap.Error(err))
 	}
@@ -457,3 +503,317 @@ func (p *Processor) Stats() map[string]interface{} {
 		"available_slots": p.cfg.MaxConcurrentBuilds - len(p.semaphore),
 	}
 }
+
+// watchStalledJobs periodically reports jobs that have waited in the queue
+// longer than the configured stall threshold
+func (p *Processor) watchStalledJobs(ctx context.Context) {
+	if p.cfg.QueueStallThreshold <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	p.logger.Info("stalled job watchdog started",
+		zap.Duration("threshold", p.cfg.QueueStallThreshold),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.shutdown:
+			return
+		case <-ticker.C:
+			p.reportStalledJobs(ctx)
+		}
+	}
+}
+
+// reportStalledJobs flags stalled jobs and notifies Switchyard once per job
+func (p *Processor) reportStalledJobs(ctx context.Context) {
+	now := time.Now()
+	jobs, err := p.queue.ListStalledJobs(ctx, now.Add(-p.cfg.QueueStallThreshold))
+	if err != nil {
+		p.logger.Error("failed to list stalled jobs", zap.Error(err))
+		return
+	}
+
+	for _, job := range jobs {
+		marked, err := p.queue.MarkStalled(ctx, job.ID)
+		if err != nil {
+			p.logger.Error("failed to mark job stalled", zap.String("job_id", job.ID.String()), zap.Error(err))
+			continue
+		}
+		if !marked {
+			continue // Another worker already reported it
+		}
+
+		waiting := now.Sub(job.CreatedAt)
+		p.logger.Warn("build job stalled in queue",
+			zap.String("job_id", job.ID.String()),
+			zap.String("release_id", job.ReleaseID.String()),
+			zap.Duration("waiting", waiting),
+		)
+
+		url := stalledCallbackURL(job.CallbackURL)
+		if url == "" {
+			continue
+		}
+		if err := p.sendStalledCallback(ctx, url, job, waiting); err != nil {
+			p.logger.Warn("failed to send stalled callback",
+				zap.String("job_id", job.ID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// sendStalledCallback notifies Switchyard that a job has been waiting too long
+func (p *Processor) sendStalledCallback(ctx context.Context, url string, job *queue.BuildJob, waiting time.Duration) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"job_id":       job.ID,
+		"release_id":   job.ReleaseID,
+		"status":       queue.StatusQueued,
+		"waiting_secs": waiting.Seconds(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.SwitchyardAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.SwitchyardAPIKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("stalled callback request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("stalled callback returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// stalledCallbackURL derives the Switchyard build-stalled endpoint from a
+// job's build-complete callback URL. Other callbacks have no stalled endpoint.
+func stalledCallbackURL(callbackURL string) string {
+	const completeSuffix = "/callbacks/build-complete"
+	if !strings.HasSuffix(callbackURL, completeSuffix) {
+		return ""
+	}
+	return strings.TrimSuffix(callbackURL, completeSuffix) + "/callbacks/build-stalled"
+}
+
+// track marks a build as leased by this worker
+func (p *Processor) track(jobID uuid.UUID) {
+	p.leasesMu.Lock()
+	defer p.leasesMu.Unlock()
+	p.leases[jobID] = struct{}{}
+}
+
+// untrack stops renewing the lease of a build
+func (p *Processor) untrack(jobID uuid.UUID) {
+	p.leasesMu.Lock()
+	defer p.leasesMu.Unlock()
+	delete(p.leases, jobID)
+}
+
+// untrackAll stops renewing every lease and returns the builds they were for
+func (p *Processor) untrackAll() []uuid.UUID {
+	p.leasesMu.Lock()
+	defer p.leasesMu.Unlock()
+	jobIDs := p.leasedJobs()
+	p.leases = make(map[uuid.UUID]struct{})
+	return jobIDs
+}
+
+// leasedJobs returns the builds this worker leases. The caller holds leasesMu.
+func (p *Processor) leasedJobs() []uuid.UUID {
+	jobIDs := make([]uuid.UUID, 0, len(p.leases))
+	for jobID := range p.leases {
+		jobIDs = append(jobIDs, jobID)
+	}
+	return jobIDs
+}
+
+// renewLeases is the heartbeat of this worker's builds: it extends their
+// leases well before they expire, until the worker exits
+func (p *Processor) renewLeases(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.BuildLeaseTTL / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.leasesMu.Lock()
+			jobIDs := p.leasedJobs()
+			p.leasesMu.Unlock()
+
+			if err := p.queue.RenewLeases(ctx, jobIDs, p.cfg.BuildLeaseTTL); err != nil {
+				p.logger.Error("failed to renew build leases", zap.Error(err))
+			}
+		}
+	}
+}
+
+// watchAbandonedBuilds periodically takes over the builds of workers that
+// stopped renewing their leases
+func (p *Processor) watchAbandonedBuilds(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.BuildLeaseTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.shutdown:
+			return
+		case <-ticker.C:
+			p.recoverBuilds(ctx, false)
+		}
+	}
+}
+
+// recoverBuilds takes over the builds whose lease expired and, with a
+// builder that runs builds in the cluster, the builds it finds there without
+// a live lease. At startup it also takes over builds left building without
+// a lease for longer than the build timeout, e.g. by a worker that stopped
+// right after dequeuing them.
+func (p *Processor) recoverBuilds(ctx context.Context, startup bool) {
+	now := time.Now()
+
+	jobIDs, err := p.queue.ExpiredLeases(ctx, now)
+	if err != nil {
+		p.logger.Error("failed to list expired leases", zap.Error(err))
+	}
+
+	if resumer, ok := p.builder.(builder.Resumer); ok {
+		inCluster, err := resumer.InClusterBuilds(ctx)
+		if err != nil {
+			p.logger.Error("failed to list in-cluster builds", zap.Error(err))
+		}
+		jobIDs = append(jobIDs, inCluster...)
+	}
+
+	if startup {
+		unleased, err := p.unleasedBuilds(ctx, now.Add(-p.cfg.BuildTimeout))
+		if err != nil {
+			p.logger.Error("failed to list unleased builds", zap.Error(err))
+		}
+		jobIDs = append(jobIDs, unleased...)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(jobIDs))
+	for _, jobID := range jobIDs {
+		if seen[jobID] {
+			continue
+		}
+		seen[jobID] = true
+		p.adoptBuild(ctx, jobID)
+	}
+}
+
+// unleasedBuilds returns the builds still building that started before
+// cutoff. Those with a live lease are skipped when adopting them.
+func (p *Processor) unleasedBuilds(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	records, err := p.queue.ListBuilds(ctx, time.Time{}, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobIDs []uuid.UUID
+	for _, record := range records {
+		if record.Status != queue.StatusBuilding {
+			continue
+		}
+		if record.StartedAt != nil && record.StartedAt.After(cutoff) {
+			continue
+		}
+		jobIDs = append(jobIDs, record.Job.ID)
+	}
+	return jobIDs, nil
+}
+
+// adoptBuild leases a build another worker left and finishes it in the
+// background: resumed when the builder still runs it, failed otherwise
+func (p *Processor) adoptBuild(ctx context.Context, jobID uuid.UUID) {
+	job, status, err := p.queue.GetJob(ctx, jobID)
+	if err != nil && !errors.Is(err, queue.ErrJobNotFound) {
+		p.logger.Error("failed to get abandoned job", zap.String("job_id", jobID.String()), zap.Error(err))
+		return
+	}
+	if err != nil || status != queue.StatusBuilding {
+		// Expired, or finished before its worker released the lease
+		if err := p.queue.ReleaseLease(ctx, jobID); err != nil {
+			p.logger.Warn("failed to release stale lease", zap.String("job_id", jobID.String()), zap.Error(err))
+		}
+		return
+	}
+
+	leased, err := p.queue.Lease(ctx, jobID, p.workerID, p.cfg.BuildLeaseTTL)
+	if err != nil {
+		p.logger.Error("failed to lease abandoned job", zap.String("job_id", jobID.String()), zap.Error(err))
+		return
+	}
+	if !leased {
+		return // Its worker is alive, or another worker took it over
+	}
+	p.track(jobID)
+
+	logger := p.logger.With(
+		zap.String("job_id", job.ID.String()),
+		zap.String("service_id", job.ServiceID.String()),
+	)
+	logger.Info("taking over build of a stopped worker")
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.resumeJob(ctx, logger, job)
+	}()
+}
+
+// resumeJob finishes an adopted build. Builds the builder no longer runs
+// were lost with their worker and fail.
+func (p *Processor) resumeJob(ctx context.Context, logger *zap.Logger, job *queue.BuildJob) {
+	if resumer, ok := p.builder.(builder.Resumer); ok {
+		buildCtx, cancel := context.WithTimeout(ctx, job.BuildConfig.Timeout(p.cfg.BuildTimeout))
+		defer cancel()
+
+		result, err := resumer.Resume(buildCtx, job)
+		switch {
+		case errors.Is(err, builder.ErrBuildNotFound):
+			// Lost with its worker, failed below
+		case result == nil:
+			// The lease runs out and the next recovery tries again
+			logger.Error("failed to resume build", zap.Error(err))
+			p.untrack(job.ID)
+			return
+		default:
+			p.finishJob(ctx, logger, job, result, err)
+			return
+		}
+	}
+
+	result := &queue.BuildResult{
+		JobID:        job.ID,
+		ReleaseID:    job.ReleaseID,
+		ErrorMessage: "build abandoned: the worker running it stopped",
+	}
+	if err := p.queue.AppendLog(ctx, job.ID, "❌ "+result.ErrorMessage); err != nil {
+		logger.Warn("failed to append log", zap.Error(err))
+	}
+	p.finishJob(ctx, logger, job, result, errors.New(result.ErrorMessage))
+}
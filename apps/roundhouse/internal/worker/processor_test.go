@@ -0,0 +1,35 @@
+package worker
+
+import "testing"
+
+func TestStalledCallbackURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		callbackURL string
+		want        string
+	}{
+		{
+			name:        "build complete callback",
+			callbackURL: "http://switchyard-api.enclii.svc:8080/v1/callbacks/build-complete",
+			want:        "http://switchyard-api.enclii.svc:8080/v1/callbacks/build-stalled",
+		},
+		{
+			name:        "function build callback",
+			callbackURL: "http://switchyard-api.enclii.svc:8080/v1/callbacks/function-build-complete",
+			want:        "",
+		},
+		{
+			name:        "no callback",
+			callbackURL: "",
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stalledCallbackURL(tt.callbackURL); got != tt.want {
+				t.Errorf("stalledCallbackURL(%q) = %q, want %q", tt.callbackURL, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/config"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+)
+
+// Lease timings, as used by Kubernetes' own controller managers
+const (
+	leaderLeaseDuration = 15 * time.Second
+	leaderRenewDeadline = 10 * time.Second
+	leaderRetryPeriod   = 2 * time.Second
+)
+
+// backgroundController is a controller that acts on shared state, such as
+// the reconciler or a cleanup loop, so only one replica may run it
+type backgroundController struct {
+	name  string
+	start func(ctx context.Context)
+}
+
+// controllerGroup collects the background controllers to run once this
+// replica is allowed to
+type controllerGroup struct {
+	controllers []backgroundController
+}
+
+// Add registers a controller; start blocks until ctx is cancelled or
+// returns after starting its own goroutines
+func (g *controllerGroup) Add(name string, start func(ctx context.Context)) {
+	g.controllers = append(g.controllers, backgroundController{name: name, start: start})
+}
+
+// start starts every controller in its own goroutine, recovering panics
+func (g *controllerGroup) start(ctx context.Context) {
+	for _, controller := range g.controllers {
+		go func(controller backgroundController) {
+			defer func() {
+				if r := recover(); r != nil {
+					logrus.Errorf("%s panicked: %v", controller.name, r)
+				}
+			}()
+			controller.start(ctx)
+		}(controller)
+		logrus.Infof("✓ %s started", controller.name)
+	}
+}
+
+// Run starts the controllers. With leader election enabled, they start only
+// once this replica holds the lease; every replica serves HTTP either way.
+// Losing the lease exits the process, so a fresh replica campaigns again
+// instead of running with half-stopped controllers. The returned channel is
+// closed once ctx is cancelled and the lease, if held, is released.
+func (g *controllerGroup) Run(ctx context.Context, cfg *config.Config, k8sClient *k8s.Client) <-chan struct{} {
+	done := make(chan struct{})
+	if !cfg.LeaderElectionEnabled {
+		g.start(ctx)
+		close(done)
+		return done
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"lease":     cfg.LeaderElectionNamespace + "/" + cfg.LeaderElectionLeaseName,
+		"identity":  cfg.LeaderElectionIdentity,
+		"component": "leader-election",
+	})
+	logger.Info("Leader election enabled, waiting for the lease before starting controllers")
+
+	go func() {
+		defer close(done)
+		err := k8sClient.RunLeaderElection(ctx, k8s.LeaderElectionConfig{
+			Namespace:     cfg.LeaderElectionNamespace,
+			LeaseName:     cfg.LeaderElectionLeaseName,
+			Identity:      cfg.LeaderElectionIdentity,
+			LeaseDuration: leaderLeaseDuration,
+			RenewDeadline: leaderRenewDeadline,
+			RetryPeriod:   leaderRetryPeriod,
+		}, k8s.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				logger.Info("✓ Acquired leader lease, starting controllers")
+				g.start(leaderCtx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					logger.Info("Released leader lease")
+					return
+				}
+				logger.Fatal("Lost leader lease, exiting")
+			},
+			OnNewLeader: func(identity string) {
+				logger.WithField("leader", identity).Info("Another replica holds the leader lease, serving HTTP only")
+			},
+		})
+		if err != nil {
+			logger.WithError(err).Fatal("Leader election failed")
+		}
+	}()
+	return done
+}
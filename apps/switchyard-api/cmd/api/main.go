@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"math/rand"
 	"net/http"
 	"os"
@@ -11,28 +10,48 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/alerting"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/api"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildartifacts"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildcontext"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/builder"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/clients"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cloudflare"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cmek"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/compliance"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/config"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/dns"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/environments"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/events"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/hosts"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/idle"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/jobs"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logshipping"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/middleware"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/projectspec"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/provenance"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/reconciler"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/retention"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/secretrotation"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/servicemetrics"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/soak"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/statuspage"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/topology"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/trash"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/uptime"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/validation"
 )
 
@@ -120,7 +139,14 @@ func main() {
 	}
 
 	// Connect to database
-	database, err := sql.Open("postgres", cfg.DatabaseURL)
+	pool := db.PoolConfig{
+		MaxOpenConns:     cfg.DBPoolSize,
+		MaxIdleConns:     cfg.DBMaxIdleConns,
+		ConnMaxLifetime:  time.Duration(cfg.DBConnMaxLifetimeSeconds) * time.Second,
+		ConnMaxIdleTime:  time.Duration(cfg.DBConnMaxIdleTimeSeconds) * time.Second,
+		StatementTimeout: time.Duration(cfg.DBStatementTimeoutSeconds) * time.Second,
+	}
+	database, err := db.Open(cfg.DatabaseURL, pool)
 	if err != nil {
 		logrus.Fatal("Failed to connect to database:", err)
 	}
@@ -139,6 +165,25 @@ func main() {
 	// Initialize repositories
 	repos := db.NewRepositories(database)
 
+	// Send heavy list and report queries to the read replica, if any. The
+	// API serves them from the primary while the replica is unreachable at
+	// startup.
+	if cfg.DatabaseReplicaURL != "" {
+		replica, err := db.Open(cfg.DatabaseReplicaURL, pool)
+		if err == nil {
+			if err = replica.Ping(); err != nil {
+				replica.Close()
+			}
+		}
+		if err != nil {
+			logrus.WithError(err).Warn("Read replica unavailable, serving all queries from the primary")
+		} else {
+			defer replica.Close()
+			repos.UseReadReplica(replica)
+			logrus.Info("Read replica enabled for list and report queries")
+		}
+	}
+
 	// Initialize cache service with retry (handles K8s startup timing)
 	// Supports both standalone Redis and Redis Sentinel (HA mode)
 	// Uses exponential backoff to wait for Redis to become available
@@ -146,6 +191,9 @@ func main() {
 	cacheService := initRedisWithRetry(cfg)
 	if cacheService == nil {
 		logrus.Warn("Running without Redis cache (session revocation disabled)")
+	} else if cfg.RepositoryCacheEnabled {
+		// Cache-aside reads of projects, services and environments
+		repos.UseCache(cacheService)
 	}
 
 	// Initialize authentication manager
@@ -165,6 +213,22 @@ func main() {
 	// Log which authentication mode is active
 	logrus.WithField("auth_mode", cfg.AuthMode).Info("✓ Authentication manager initialized")
 
+	// Initialize customer-managed encryption keys (optional)
+	// Teams with a key get their env vars and backups encrypted under it
+	var encryptionKeyService *cmek.Service
+	if cfg.CMEKEnabled {
+		kmsCredentials, err := cmek.LoadAWSCredentials(ctx, cfg.CMEKAccessKeyID, cfg.CMEKSecretAccessKey)
+		if err != nil {
+			logrus.Fatal("Failed to load KMS credentials for customer-managed keys:", err)
+		}
+		encryptionKeyService = cmek.NewService(repos, cmek.NewAWSKMSFactory(cmek.AWSKMSConfig{
+			Credentials: kmsCredentials,
+			Endpoint:    cfg.CMEKKMSEndpoint,
+		}), logrus.StandardLogger())
+		repos.SetEnvVarKeyring(encryptionKeyService)
+		logrus.Info("✓ Customer-managed encryption keys enabled (AWS KMS)")
+	}
+
 	// Wire up API token validator for CLI/CI/CD authentication
 	// This enables "enclii_xxx" tokens in addition to JWT/OIDC tokens
 	switch am := authManager.(type) {
@@ -184,6 +248,10 @@ func main() {
 		logrus.Fatal("Failed to initialize Kubernetes client:", err)
 	}
 
+	// Registered clusters are reached with their stored kubeconfigs; the
+	// client above serves environments that are not pinned to one
+	clusterClients := k8s.NewClientPool(k8sClient, repos.Clusters.GetKubeconfig)
+
 	// Initialize builder service
 	builderService := builder.NewService(&builder.Config{
 		WorkDir:          cfg.BuildWorkDir,
@@ -204,20 +272,42 @@ func main() {
 		logrus.Warnf("Failed to create build cache directory (non-fatal): %v", err)
 	}
 
+	// Initialize event broker (real-time status stream; fans out via Redis pub/sub when available)
+	eventBroker := events.NewBroker(cacheService, logrus.StandardLogger())
+	eventBroker.Start(ctx)
+	logrus.Info("✓ Event broker started")
+
+	// Initialize background job runner (durable async work; every replica
+	// runs the jobs it claims, so it is started outside the leader lease)
+	jobRunner := jobs.NewRunner(repos.BackgroundJobs, logrus.StandardLogger())
+	jobRunner.SetWorkers(cfg.JobWorkers)
+
+	// Background controllers run on one replica at a time when leader
+	// election is enabled; they are registered here and started at the end
+	var controllers controllerGroup
+
 	// Initialize reconciler
 	reconcilerController := reconciler.NewController(database, repos, k8sClient, logrus.StandardLogger())
+	reconcilerController.SetEventBroker(eventBroker)
+	reconcilerController.SetClientPool(clusterClients)
+	reconcilerController.SetStallThresholds(
+		time.Duration(cfg.StallDeploymentMinutes)*time.Minute,
+		time.Duration(cfg.StallBuildMinutes)*time.Minute,
+	)
 
-	// Start reconciliation controller (processes pending deployments from database)
-	if err := reconcilerController.Start(ctx); err != nil {
-		logrus.Fatal("Failed to start reconciler controller:", err)
-	}
-	logrus.Info("✓ Reconciliation controller started (processing pending deployments)")
+	// Reconciliation controller (processes pending deployments from database)
+	controllers.Add("Reconciliation controller", func(ctx context.Context) {
+		if err := reconcilerController.Start(ctx); err != nil {
+			logrus.Fatal("Failed to start reconciler controller:", err)
+		}
+	})
 
 	// Initialize service reconciler (also used directly by API handlers)
 	serviceReconciler := reconciler.NewServiceReconciler(k8sClient, logrus.StandardLogger())
 
 	// Initialize metrics collector
 	metricsCollector := monitoring.NewMetricsCollector()
+	metricsCollector.RegisterDB(database, "switchyard")
 
 	// Initialize validator
 	validatorInstance := validation.NewValidator()
@@ -256,6 +346,9 @@ func main() {
 
 	// Initialize topology builder
 	topologyBuilder := topology.NewGraphBuilder(repos, k8sClient, logrus.StandardLogger())
+	if cfg.PrometheusURL != "" {
+		topologyBuilder.SetTrafficSource(topology.NewPrometheusTraffic(cfg.PrometheusURL, cfg.TopologyTrafficQuery))
+	}
 	logrus.Info("✓ Topology graph builder initialized")
 
 	// Initialize authentication provider (supports both JWT and OIDC modes)
@@ -292,33 +385,143 @@ func main() {
 	)
 	logrus.Info("✓ DeploymentGroupService initialized")
 
-	// Initialize addon service (database add-ons: PostgreSQL, Redis, MySQL)
+	// Initialize addon service (PostgreSQL, Redis, MySQL, MongoDB, and bucket add-ons)
 	addonService := addons.NewAddonService(repos, k8sClient, logrus.StandardLogger())
-	logrus.Info("✓ AddonService initialized (PostgreSQL, Redis, MySQL add-ons)")
+	addonService.SetJobRunner(jobRunner)
+	logrus.Info("✓ AddonService initialized (PostgreSQL, Redis, MySQL, MongoDB, bucket add-ons)")
+
+	// Configure object storage for addon backups (optional)
+	if cfg.AddonBackupS3Bucket != "" {
+		backupStorage, err := addons.NewBackupStorage(ctx, &addons.BackupStorageConfig{
+			Endpoint:        cfg.AddonBackupS3Endpoint,
+			Region:          cfg.AddonBackupS3Region,
+			Bucket:          cfg.AddonBackupS3Bucket,
+			AccessKeyID:     cfg.AddonBackupS3AccessKeyID,
+			SecretAccessKey: cfg.AddonBackupS3SecretAccessKey,
+		})
+		if err != nil {
+			logrus.Warnf("Addon backup storage unavailable, backups will stay in-cluster: %v", err)
+		} else {
+			addonService.SetBackupStorage(backupStorage)
+			logrus.Infof("✓ Addon backups upload to bucket %s", cfg.AddonBackupS3Bucket)
+		}
+	}
+
+	// Configure the cloud account for "s3" bucket addons (optional; MinIO otherwise)
+	if cfg.AddonBucketS3AccessKeyID != "" {
+		cloudBuckets, err := addons.NewCloudBuckets(ctx, &addons.CloudBucketConfig{
+			Endpoint:        cfg.AddonBucketS3Endpoint,
+			IAMEndpoint:     cfg.AddonBucketS3IAMEndpoint,
+			Region:          cfg.AddonBucketS3Region,
+			AccessKeyID:     cfg.AddonBucketS3AccessKeyID,
+			SecretAccessKey: cfg.AddonBucketS3SecretAccessKey,
+		})
+		if err != nil {
+			logrus.Warnf("Cloud buckets unavailable, bucket addons will use in-cluster MinIO: %v", err)
+		} else {
+			addonService.SetCloudBuckets(cloudBuckets)
+			logrus.Infof("✓ Bucket addons are created in %s", cloudBuckets.Endpoint())
+		}
+	}
 
 	// Initialize and start addon reconciler (syncs database addon status from K8s)
 	addonReconciler := reconciler.NewAddonReconciler(repos, k8sClient, logrus.StandardLogger())
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				logrus.Errorf("Addon reconciler panicked: %v", r)
-			}
-		}()
-		addonReconciler.Start(ctx)
-	}()
-	logrus.Info("✓ Addon reconciler started (syncing database addon status)")
+	addonReconciler.SetEventBroker(eventBroker)
+	addonReconciler.SetAddonService(addonService)
+	if cfg.WaybillURL != "" {
+		addonReconciler.SetWaybillClient(clients.NewWaybillClient(cfg.WaybillURL, cfg.WaybillAPIKey))
+		logrus.Infof("✓ Bucket storage usage reported to Waybill at %s", cfg.WaybillURL)
+	}
+	controllers.Add("Addon reconciler", addonReconciler.Start)
+
+	// Initialize and start addon backup controller (scheduled backups, restores, retention)
+	addonBackupController := reconciler.NewAddonBackupController(addonService, logrus.StandardLogger())
+	controllers.Add("Addon backup controller", addonBackupController.Start)
+
+	if encryptionKeyService != nil {
+		addonService.SetBackupKeyring(encryptionKeyService)
+
+		// Initialize and start encryption key controller (customer key health checks)
+		encryptionKeyController := reconciler.NewEncryptionKeyController(encryptionKeyService, logrus.StandardLogger())
+		controllers.Add("Encryption key controller", encryptionKeyController.Start)
+	}
+
+	// Initialize managed DNS (records in zones teams connect from Cloudflare or Route53)
+	dnsService := dns.NewService(repos, dns.NewProviderFactory(dns.Config{}), logrus.StandardLogger())
+
+	// Initialize and start DNS record controller (drift detection for managed records)
+	dnsRecordController := reconciler.NewDNSRecordController(dnsService, logrus.StandardLogger())
+	controllers.Add("DNS record controller", dnsRecordController.Start)
+
+	// Initialize and start registry token controller (refresh of ECR and GCR tokens in pull secrets)
+	registryTokenController := reconciler.NewRegistryTokenController(repos, k8sClient.Clientset, logrus.StandardLogger())
+	controllers.Add("Registry token controller", registryTokenController.Start)
+
+	// Registry client for image lookups and deletion, with the platform's credentials
+	imageRegistry := previews.NewRegistryClient(cfg.RegistryUsername, cfg.RegistryPassword)
+
+	// Initialize preview cleanup (teardown of closed previews past their project's TTL)
+	previewCleaner := previews.NewCleaner(repos, k8sClient.Clientset, serviceReconciler, imageRegistry, logrus.StandardLogger())
+	previewCleaner.SetDefaultTTLDays(cfg.PreviewTTLDays)
+
+	// Initialize preview databases (per-preview copies of a template addon)
+	previewDatabases := previews.NewDatabases(repos, addonService, k8sClient.Clientset, logrus.StandardLogger())
+	previewCleaner.SetDatabases(previewDatabases)
+
+	// Initialize and start preview cleanup controller
+	previewCleanupController := reconciler.NewPreviewCleanupController(previewCleaner, logrus.StandardLogger())
+	controllers.Add("Preview cleanup controller", previewCleanupController.Start)
+	logrus.WithField("default_ttl_days", previewCleaner.DefaultTTLDays()).Info("Preview cleanup configured")
+
+	// Initialize the trash (soft delete of projects and services, purged after the retention window)
+	trashService := trash.NewService(repos, k8sClient.Clientset, serviceReconciler, imageRegistry, logrus.StandardLogger())
+	trashService.SetRetentionDays(cfg.TrashRetentionDays)
+	trashPurgeController := reconciler.NewTrashPurgeController(trashService, logrus.StandardLogger())
+	controllers.Add("Trash purge controller", trashPurgeController.Start)
+	logrus.WithField("retention_days", trashService.RetentionDays()).Info("Trash purge configured")
+
+	// Resource quotas: ResourceQuota objects in the environment namespaces of limited projects
+	quotaSyncer := quota.NewSyncer(repos, k8sClient.Clientset, logrus.StandardLogger())
+	resourceQuotaController := reconciler.NewResourceQuotaController(quotaSyncer, logrus.StandardLogger())
+	controllers.Add("Resource quota controller", resourceQuotaController.Start)
+
+	// Initialize and start preview database controller (restore and seed of preview databases)
+	previewDatabaseController := reconciler.NewPreviewDatabaseController(previewDatabases, logrus.StandardLogger())
+	controllers.Add("Preview database controller", previewDatabaseController.Start)
+
+	// Initialize soak monitor (holds new releases under their environment's soak policy)
+	soakMonitor := soak.NewMonitor(repos, k8sClient.Clientset, logrus.StandardLogger())
+	soakMonitor.SetScheduler(reconcilerController)
+	if cfg.SoakPrometheusURL != "" {
+		soakMonitor.SetErrorRateSource(soak.NewPrometheusErrorRates(cfg.SoakPrometheusURL, cfg.SoakErrorRateQuery))
+	}
+	reconcilerController.SetSoakMonitor(soakMonitor)
+
+	// Initialize and start soak controller (passes, fails and rolls back soaking deployments)
+	soakController := reconciler.NewSoakController(soakMonitor, logrus.StandardLogger())
+	controllers.Add("Soak controller", soakController.Start)
+	logrus.WithField("error_rates", cfg.SoakPrometheusURL != "").Info("Soak monitor configured")
+
+	// Initialize and start log shipping controller (Vector DaemonSet shipping service logs to Loki)
+	if cfg.LogShippingEnabled {
+		logShipper := logshipping.NewShipper(repos, k8sClient.Clientset, logshipping.Config{
+			Namespace: cfg.LogShippingNamespace,
+			Image:     cfg.LogShippingImage,
+			LokiURL:   cfg.LokiURL,
+			TenantID:  cfg.LokiTenantID,
+		}, logrus.StandardLogger())
+		logShippingController := reconciler.NewLogShippingController(logShipper, logrus.StandardLogger())
+		controllers.Add("Log shipping controller", logShippingController.Start)
+	}
+
+	// Initialize environment cloner and start its controller (provisions, restores and binds addon copies)
+	environmentCloner := environments.NewCloner(repos, addonService, logrus.StandardLogger())
+	environmentCloneController := reconciler.NewEnvironmentCloneController(environmentCloner, logrus.StandardLogger())
+	controllers.Add("Environment clone controller", environmentCloneController.Start)
 
 	// Initialize and start function reconciler (scale-to-zero serverless functions)
 	functionReconciler := reconciler.NewFunctionReconciler(repos, k8sClient, logrus.StandardLogger(), cfg.FunctionBaseDomain)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				logrus.Errorf("Function reconciler panicked: %v", r)
-			}
-		}()
-		functionReconciler.Start(ctx)
-	}()
-	logrus.Info("✓ Function reconciler started (serverless functions with KEDA scale-to-zero)")
+	controllers.Add("Function reconciler", functionReconciler.Start)
 
 	// Initialize Roundhouse client (for async builds)
 	var roundhouseClient *clients.RoundhouseClient
@@ -374,6 +577,21 @@ func main() {
 		logrus.Info("ℹ Tunnel routes service not configured (ENCLII_CLOUDFLARE_TUNNEL_ID required)")
 	}
 
+	// Initialize scale-to-zero of idle services (sleeping requires Prometheus and tunnel routes)
+	idleManager := idle.NewManager(repos, k8sClient.Clientset, cfg.StatusPageServiceName, cfg.StatusPageServiceNamespace, logrus.StandardLogger())
+	if cfg.PrometheusURL != "" {
+		idleManager.SetActivitySource(idle.NewPrometheusActivity(cfg.PrometheusURL, cfg.IdleActivityQuery))
+	}
+	if tunnelRoutesService != nil {
+		idleManager.SetTunnelRoutes(tunnelRoutesService)
+	}
+	if cfg.WaybillURL != "" {
+		idleManager.SetUsageRecorder(clients.NewWaybillClient(cfg.WaybillURL, cfg.WaybillAPIKey))
+	}
+	idleController := reconciler.NewIdleController(idleManager, logrus.StandardLogger())
+	controllers.Add("Idle controller", idleController.Start)
+	logrus.WithField("sleeps", idleManager.CanSleep()).Info("Scale-to-zero configured")
+
 	// Setup HTTP server
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -385,6 +603,12 @@ func main() {
 	// and returns proper JSON error responses instead of empty body
 	router.Use(middleware.RecoveryMiddleware(logger))
 
+	// Requests to verified custom domains of sleeping services wake them;
+	// this runs before CORS, which would reject their cross-origin requests.
+	// Requests to the API's own hosts skip it, and projects cannot claim them.
+	reservedHosts := hosts.NewReserved(cfg.APIHosts, cfg.PlatformDomain)
+	router.Use(idle.NewWaker(idleManager, repos, reservedHosts, logrus.StandardLogger()).Middleware())
+
 	// Initialize security middleware with CORS support
 	securityMiddleware := middleware.NewSecurityMiddleware(nil) // Uses default config with CORS
 	router.Use(securityMiddleware.CORSMiddleware())
@@ -427,30 +651,198 @@ func main() {
 		logrus.Info("✓ Tunnel routes service wired to API handler (automatic route management enabled)")
 	}
 
+	// Wire up event broker (SSE status stream)
+	apiHandler.SetEventBroker(eventBroker)
+
+	// Wire up background jobs (preview cleanup)
+	apiHandler.SetJobRunner(jobRunner)
+
 	// Wire up addon service (database add-ons)
 	apiHandler.SetAddonService(addonService)
 	logrus.Info("✓ Addon service wired to API handler")
 
+	// Wire up customer-managed encryption keys
+	if encryptionKeyService != nil {
+		apiHandler.SetEncryptionKeyService(encryptionKeyService)
+	}
+
+	// Wire up managed DNS (team DNS providers)
+	apiHandler.SetDNSService(dnsService)
+
+	// Wire up preview cleanup (TTL settings and stale preview listing)
+	apiHandler.SetPreviewCleaner(previewCleaner)
+
+	// Wire up the trash (soft delete and restore of projects and services)
+	apiHandler.SetTrash(trashService)
+	apiHandler.SetQuotaSyncer(quotaSyncer)
+	if cfg.LokiURL != "" {
+		apiHandler.SetLogSearch(logshipping.NewLokiClient(cfg.LokiURL, cfg.LokiTenantID))
+		logrus.Infof("✓ Log search backed by Loki at %s", cfg.LokiURL)
+	}
+
+	// Wire up service metrics (Prometheus series, metrics-server snapshots without it)
+	var metricsPrometheus *servicemetrics.PrometheusClient
+	if cfg.PrometheusURL != "" {
+		metricsPrometheus = servicemetrics.NewPrometheusClient(cfg.PrometheusURL)
+	}
+	apiHandler.SetServiceMetrics(servicemetrics.NewCollector(metricsPrometheus, cfg.SoakErrorRateQuery, k8sClient))
+	logrus.WithField("prometheus", cfg.PrometheusURL != "").Info("Service metrics configured")
+
+	// Wire up scale-to-zero (settings and manual wakes)
+	apiHandler.SetIdleScaling(idleManager)
+
+	// Wire up preview databases (config endpoints, binding, redeploy once ready)
+	apiHandler.SetPreviewDatabases(previewDatabases)
+	apiHandler.SetImageRegistry(imageRegistry)
+
+	// Wire up registered clusters (cluster endpoints, logs and status of pinned environments)
+	apiHandler.SetClusterClients(clusterClients)
+	previewDatabases.SetRedeployer(apiHandler)
+
+	// Wire up environment cloning
+	apiHandler.SetEnvironmentCloner(environmentCloner)
+
+	// Wire up project spec export and apply (enclii.yaml)
+	projectSpecs := projectspec.NewManager(repos, addonService, logrus.StandardLogger())
+	projectSpecs.SetReservedHosts(reservedHosts)
+	apiHandler.SetProjectSpecs(projectSpecs)
+
+	// Wire up per-project retention and start purging data past it
+	retentionService := retention.NewService(repos, logrus.StandardLogger())
+	if roundhouseClient != nil {
+		retentionService.SetImageCollector(roundhouseClient)
+	}
+	apiHandler.SetRetention(retentionService)
+	retentionController := reconciler.NewRetentionController(retentionService, logrus.StandardLogger())
+	controllers.Add("Retention controller", retentionController.Start)
+
+	// Serve opt-in public status pages of projects
+	apiHandler.SetStatusPages(statuspage.NewBuilder(repos))
+
+	// Configure object storage for local build contexts (optional; builds start from git only when unset)
+	if cfg.BuildContextS3Bucket != "" {
+		buildContexts, err := buildcontext.NewService(ctx, &buildcontext.Config{
+			Endpoint:        cfg.BuildContextS3Endpoint,
+			Region:          cfg.BuildContextS3Region,
+			Bucket:          cfg.BuildContextS3Bucket,
+			AccessKeyID:     cfg.BuildContextS3AccessKeyID,
+			SecretAccessKey: cfg.BuildContextS3SecretAccessKey,
+			MaxSizeBytes:    int64(cfg.BuildContextMaxSizeMB) << 20,
+			TTL:             time.Duration(cfg.BuildContextTTLHours) * time.Hour,
+		}, repos, logrus.StandardLogger())
+		if err != nil {
+			logrus.Warnf("Build context storage unavailable, local builds are disabled: %v", err)
+		} else {
+			apiHandler.SetBuildContexts(buildContexts)
+
+			// Start build context controller (deletes uploaded contexts past their TTL)
+			buildContextController := reconciler.NewBuildContextController(buildContexts, logrus.StandardLogger())
+			controllers.Add("Build context controller", buildContextController.Start)
+			logrus.Infof("✓ Local build contexts upload to bucket %s", cfg.BuildContextS3Bucket)
+		}
+	}
+
+	// Configure object storage for build artifacts (optional; artifacts are listed without download URLs when unset)
+	if cfg.BuildArtifactsS3Bucket != "" {
+		buildArtifacts, err := buildartifacts.NewService(ctx, &buildartifacts.Config{
+			Endpoint:        cfg.BuildArtifactsS3Endpoint,
+			Region:          cfg.BuildArtifactsS3Region,
+			Bucket:          cfg.BuildArtifactsS3Bucket,
+			AccessKeyID:     cfg.BuildArtifactsS3AccessKeyID,
+			SecretAccessKey: cfg.BuildArtifactsS3SecretAccessKey,
+		})
+		if err != nil {
+			logrus.Warnf("Build artifact storage unavailable, artifacts cannot be downloaded: %v", err)
+		} else {
+			apiHandler.SetBuildArtifacts(buildArtifacts)
+			logrus.Infof("✓ Build artifacts download from bucket %s", cfg.BuildArtifactsS3Bucket)
+		}
+	}
+
 	// Initialize notification service (Slack/Discord/Telegram webhooks)
 	notificationService := notifications.NewService(repos.Webhooks, logrus.StandardLogger())
+	notificationService.SetEventRepository(repos.ProjectEvents)
+	notificationService.SetJobRunner(jobRunner)
 	apiHandler.SetNotificationService(notificationService)
 	reconcilerController.SetNotificationService(notificationService)
+	soakMonitor.SetEventSender(notificationService)
 	logrus.Info("✓ Notification service wired to API handler and reconciler (Slack/Discord/Telegram)")
 
+	// Start webhook retry controller (retries failed deliveries with backoff)
+	webhookRetryController := reconciler.NewWebhookRetryController(notificationService, logrus.StandardLogger())
+	controllers.Add("Webhook retry controller", webhookRetryController.Start)
+
+	// Start deployment group schedule controller (reminds of and executes scheduled groups)
+	deploymentGroupService.SetNotificationService(notificationService)
+	deploymentGroupScheduleController := reconciler.NewDeploymentGroupScheduleController(deploymentGroupService, logrus.StandardLogger())
+	controllers.Add("Deployment group schedule controller", deploymentGroupScheduleController.Start)
+
 	// Initialize email service (team invitations, transactional emails)
-	emailService := notifications.NewEmailService(notifications.EmailConfig{
-		APIKey:    cfg.EmailAPIKey,
+	emailService, err := notifications.NewEmailService(ctx, notifications.EmailConfig{
+		Provider:       cfg.EmailProvider,
+		APIKey:         cfg.EmailAPIKey,
+		SendGridAPIKey: cfg.SendGridAPIKey,
+		SMTP: notifications.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			TLS:      cfg.SMTPTLS,
+		},
+		SES: notifications.SESConfig{
+			Region:          cfg.SESRegion,
+			AccessKeyID:     cfg.SESAccessKeyID,
+			SecretAccessKey: cfg.SESSecretAccessKey,
+		},
 		FromEmail: cfg.EmailFromAddress,
 		FromName:  cfg.EmailFromName,
 		BaseURL:   cfg.AppBaseURL,
-	}, logrus.StandardLogger())
+	}, repos.EmailDeliveries, logrus.StandardLogger())
+	if err != nil {
+		logrus.Fatal("Failed to configure email service:", err)
+	}
 	apiHandler.SetEmailService(emailService)
 	if emailService.IsEnabled() {
-		logrus.Info("✓ Email service wired to API handler (Resend API)")
+		go emailService.Start(ctx)
+		logrus.Infof("✓ Email service wired to API handler (%s)", emailService.ProviderName())
 	} else {
 		logrus.Warn("⚠ Email service not configured - invitation emails will be logged only")
 	}
 
+	// Start alert controller (evaluates service alert rules, notifies webhooks and email)
+	var alertErrorRates soak.ErrorRateSource
+	if cfg.SoakPrometheusURL != "" {
+		alertErrorRates = soak.NewPrometheusErrorRates(cfg.SoakPrometheusURL, cfg.SoakErrorRateQuery)
+	}
+	alertEvaluator := alerting.NewEvaluator(repos, alerting.NewChecker(k8sClient.Clientset, alertErrorRates), logrus.StandardLogger())
+	alertEvaluator.SetEventSender(notificationService)
+	alertEvaluator.SetEmailSender(emailService)
+	alertController := reconciler.NewAlertController(alertEvaluator, logrus.StandardLogger())
+	controllers.Add("Alert controller", alertController.Start)
+
+	// Start uptime controller (runs synthetic HTTP checks, notifies webhooks on downtime)
+	uptimeRunner := uptime.NewRunner(repos, logrus.StandardLogger())
+	uptimeRunner.SetEventSender(notificationService)
+	uptimeController := reconciler.NewUptimeController(uptimeRunner, logrus.StandardLogger())
+	controllers.Add("Uptime controller", uptimeController.Start)
+
+	// Start secret rotation controller (reminds projects of secrets older than their max age)
+	rotationReminder := secretrotation.NewReminder(repos, logrus.StandardLogger())
+	rotationReminder.SetEventSender(notificationService)
+	secretRotationController := reconciler.NewSecretRotationController(rotationReminder, logrus.StandardLogger())
+	controllers.Add("Secret rotation controller", secretRotationController.Start)
+
+	// Validate requests against the OpenAPI spec before they reach handlers
+	if cfg.OpenAPISpecPath != "" {
+		specValidator, err := validation.LoadOpenAPISpec(cfg.OpenAPISpecPath)
+		if err != nil {
+			logrus.WithError(err).Warn("⚠ OpenAPI request validation disabled - spec could not be loaded")
+		} else {
+			apiHandler.SetOpenAPIValidator(specValidator)
+			logrus.WithField("spec", cfg.OpenAPISpecPath).Info("✓ OpenAPI request validation enabled")
+		}
+	}
+
 	api.SetupRoutes(router, apiHandler)
 
 	server := &http.Server{
@@ -461,6 +853,15 @@ func main() {
 		MaxHeaderBytes: 1 << 20, // 1 MB
 	}
 
+	// Start background job runner
+	if err := jobRunner.Start(ctx); err != nil {
+		logrus.Fatal("Failed to start job runner:", err)
+	}
+
+	// Start background controllers (under the leader lease when enabled)
+	controllersCtx, stopControllers := context.WithCancel(ctx)
+	controllersDone := controllers.Run(controllersCtx, cfg, k8sClient)
+
 	// Start server in goroutine
 	go func() {
 		logrus.Infof("🚂 Switchyard API starting on port %s", cfg.Port)
@@ -495,6 +896,17 @@ func main() {
 		logrus.Info("Domain sync service stopped")
 	}
 
+	// Stop background controllers and release the leader lease
+	stopControllers()
+	select {
+	case <-controllersDone:
+	case <-ctx.Done():
+		logrus.Warn("Timed out releasing the leader lease")
+	}
+
+	// Stop background job runner and release its running jobs
+	jobRunner.Stop()
+
 	// Stop reconciler controller gracefully
 	reconcilerController.Stop()
 	logrus.Info("Reconciler controller stopped")
@@ -503,6 +915,10 @@ func main() {
 	addonReconciler.Stop()
 	logrus.Info("Addon reconciler stopped")
 
+	// Stop addon backup controller
+	addonBackupController.Stop()
+	logrus.Info("Addon backup controller stopped")
+
 	// Stop function reconciler
 	functionReconciler.Stop()
 	logrus.Info("Function reconciler stopped")
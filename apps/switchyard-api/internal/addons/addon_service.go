@@ -2,6 +2,7 @@ package addons
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -9,7 +10,9 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/jobs"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -19,6 +22,28 @@ type AddonService struct {
 	k8sClient    *k8s.Client
 	logger       *logrus.Logger
 	provisioners map[types.DatabaseAddonType]AddonProvisioner
+
+	// backupStorage receives uploaded dumps; backups stay on a PVC when nil
+	backupStorage *BackupStorage
+
+	// backupKeyring encrypts uploads for teams with a customer-managed key
+	backupKeyring BackupKeyring
+
+	// buckets is also registered in provisioners; kept typed to pick bucket providers
+	buckets *BucketProvisioner
+
+	// jobRunner runs provisioning as background jobs; goroutines when nil
+	jobRunner *jobs.Runner
+}
+
+// addonProvisionJob is the background job that provisions a new addon
+const addonProvisionJob = "addon.provision"
+
+type addonProvisionPayload struct {
+	AddonID   uuid.UUID `json:"addon_id"`
+	Namespace string    `json:"namespace"`
+	// CloneFrom is the addon whose WAL archive bootstraps the new one
+	CloneFrom *uuid.UUID `json:"clone_from,omitempty"`
 }
 
 // NewAddonService creates a new addon service
@@ -34,10 +59,30 @@ func NewAddonService(repos *db.Repositories, k8sClient *k8s.Client, logger *logr
 	svc.provisioners[types.DatabaseAddonTypePostgres] = NewPostgresProvisioner(k8sClient, logger)
 	svc.provisioners[types.DatabaseAddonTypeRedis] = NewRedisProvisioner(k8sClient, logger)
 	svc.provisioners[types.DatabaseAddonTypeMySQL] = NewMySQLProvisioner(k8sClient, logger)
+	svc.provisioners[types.DatabaseAddonTypeMongoDB] = NewMongoDBProvisioner(k8sClient, logger)
+	svc.buckets = NewBucketProvisioner(k8sClient, logger)
+	svc.provisioners[types.DatabaseAddonTypeBucket] = svc.buckets
 
 	return svc
 }
 
+// SetBackupStorage configures the object storage that addon backups are uploaded to
+func (s *AddonService) SetBackupStorage(storage *BackupStorage) {
+	s.backupStorage = storage
+}
+
+// SetJobRunner provisions addons as background jobs of a runner, so
+// provisioning survives restarts and failed attempts are retried
+func (s *AddonService) SetJobRunner(runner *jobs.Runner) {
+	s.jobRunner = runner
+	runner.Register(addonProvisionJob, s.runProvisionJob)
+}
+
+// SetCloudBuckets enables bucket addons in the platform's cloud account
+func (s *AddonService) SetCloudBuckets(cloud *CloudBuckets) {
+	s.buckets.SetCloud(cloud)
+}
+
 // CreateAddonRequest represents a request to create a database addon
 type CreateAddonRequest struct {
 	ProjectID     uuid.UUID
@@ -47,6 +92,16 @@ type CreateAddonRequest struct {
 	Config        types.DatabaseAddonConfig
 	UserID        *uuid.UUID
 	UserEmail     string
+
+	// Namespace overrides the project namespace the addon is provisioned in
+	Namespace string
+
+	// Ephemeral addons, such as preview databases, get no scheduled backups
+	Ephemeral bool
+
+	// CloneFrom bootstraps a PostgreSQL addon from the WAL archive of another
+	// addon, replayed to its latest point
+	CloneFrom *types.DatabaseAddon
 }
 
 // CreateAddon creates a new database addon
@@ -71,8 +126,40 @@ func (s *AddonService) CreateAddon(ctx context.Context, req *CreateAddonRequest)
 		return nil, fmt.Errorf("addon with name '%s' already exists in project", req.Name)
 	}
 
+	if err := ValidateConfig(req.Type, req.Config); err != nil {
+		return nil, err
+	}
+	if req.Config.PITREnabled && s.backupStorage == nil {
+		return nil, fmt.Errorf("point-in-time recovery requires backup storage to be configured")
+	}
+
+	var recovery *PointInTimeRecovery
+	if req.CloneFrom != nil {
+		if req.Type != types.DatabaseAddonTypePostgres || req.CloneFrom.Type != req.Type {
+			return nil, fmt.Errorf("cloning from an archive is only supported for PostgreSQL addons")
+		}
+		if recovery, err = s.recoveryFrom(req.CloneFrom); err != nil {
+			return nil, err
+		}
+	}
+
 	// Apply default config values
 	config := applyDefaultConfig(req.Type, req.Config)
+	if req.Ephemeral {
+		config.BackupSchedule = ""
+	}
+	if req.Type == types.DatabaseAddonTypeBucket {
+		if config.BucketProvider == "" {
+			config.BucketProvider = s.buckets.DefaultProvider()
+		}
+		if err := s.buckets.ValidateProvider(config.BucketProvider); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := quota.CheckAddonStorage(ctx, s.repos, req.ProjectID, config.StorageGB); err != nil {
+		return nil, err
+	}
 
 	// Create addon record
 	addon := &types.DatabaseAddon{
@@ -103,20 +190,112 @@ func (s *AddonService) CreateAddon(ctx context.Context, req *CreateAddonRequest)
 
 	// Determine namespace - use project's K8s namespace
 	namespace := fmt.Sprintf("project-%s", project.ID.String()[:8])
+	if req.Namespace != "" {
+		namespace = req.Namespace
+	}
 
 	// Update status to provisioning
 	if err := s.repos.DatabaseAddons.UpdateStatus(ctx, addon.ID, types.DatabaseAddonStatusProvisioning, "Provisioning started"); err != nil {
 		logger.WithError(err).Error("Failed to update addon status")
 	}
 
-	// Provision the addon asynchronously
-	go s.provisionAddon(context.Background(), addon, provisioner, namespace)
+	// Provision the addon asynchronously, as a background job that survives
+	// restarts and is retried when an attempt fails
+	if s.jobRunner != nil {
+		payload := addonProvisionPayload{AddonID: addon.ID, Namespace: namespace}
+		if req.CloneFrom != nil {
+			payload.CloneFrom = &req.CloneFrom.ID
+		}
+		_, err := s.jobRunner.Enqueue(ctx, addonProvisionJob, payload)
+		if err == nil {
+			return addon, nil
+		}
+		logger.WithError(err).Warn("Failed to queue addon provisioning, provisioning in the background")
+	}
+	go func() {
+		ctx := context.Background()
+		if err := s.provisionAddon(ctx, addon, provisioner, namespace, recovery); err != nil {
+			s.repos.DatabaseAddons.UpdateStatus(ctx, addon.ID, types.DatabaseAddonStatusFailed, err.Error())
+		}
+	}()
 
 	return addon, nil
 }
 
-// provisionAddon handles the asynchronous provisioning of a database addon
-func (s *AddonService) provisionAddon(ctx context.Context, addon *types.DatabaseAddon, provisioner AddonProvisioner, namespace string) {
+// recoveryFrom returns the recovery that bootstraps a PostgreSQL addon from
+// the WAL archive of source
+func (s *AddonService) recoveryFrom(source *types.DatabaseAddon) (*PointInTimeRecovery, error) {
+	archive := s.walArchive(source)
+	if archive == nil {
+		return nil, ErrPITRNotEnabled
+	}
+	return &PointInTimeRecovery{
+		SourceCluster: source.K8sResourceName,
+		Archive:       archive,
+	}, nil
+}
+
+// runProvisionJob runs a queued addon provisioning. The addon is marked
+// failed when the job fails for good; until then it stays provisioning,
+// with the error of the last attempt as its status message.
+func (s *AddonService) runProvisionJob(ctx context.Context, job *types.BackgroundJob) error {
+	var payload addonProvisionPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, payload.AddonID)
+	if err == sql.ErrNoRows {
+		return jobs.Permanent(fmt.Errorf("addon %s not found", payload.AddonID))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get addon: %w", err)
+	}
+	if addon.Status != types.DatabaseAddonStatusProvisioning {
+		// Deleted or failed since the job was queued
+		return nil
+	}
+
+	err = s.provisionJobAttempt(ctx, addon, payload)
+	if err == nil {
+		return nil
+	}
+	if jobs.IsPermanent(err) || jobs.LastAttempt(job) {
+		s.repos.DatabaseAddons.UpdateStatus(ctx, addon.ID, types.DatabaseAddonStatusFailed, err.Error())
+	} else {
+		s.repos.DatabaseAddons.UpdateStatus(ctx, addon.ID, types.DatabaseAddonStatusProvisioning,
+			fmt.Sprintf("Provisioning attempt %d failed, retrying: %v", job.Attempts, err))
+	}
+	return err
+}
+
+// provisionJobAttempt rebuilds the provisioning of a job's addon and runs it
+func (s *AddonService) provisionJobAttempt(ctx context.Context, addon *types.DatabaseAddon, payload addonProvisionPayload) error {
+	provisioner, ok := s.provisioners[addon.Type]
+	if !ok {
+		return jobs.Permanent(fmt.Errorf("unsupported addon type: %s", addon.Type))
+	}
+
+	var recovery *PointInTimeRecovery
+	if payload.CloneFrom != nil {
+		source, err := s.repos.DatabaseAddons.GetByID(ctx, *payload.CloneFrom)
+		if err == sql.ErrNoRows {
+			return jobs.Permanent(fmt.Errorf("addon %s to clone from not found", *payload.CloneFrom))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get addon to clone from: %w", err)
+		}
+		if recovery, err = s.recoveryFrom(source); err != nil {
+			return jobs.Permanent(err)
+		}
+	}
+
+	return s.provisionAddon(ctx, addon, provisioner, payload.Namespace, recovery)
+}
+
+// provisionAddon provisions a database addon. A non-nil recovery bootstraps
+// it from another addon's archive. A failed provisioning may be run again.
+func (s *AddonService) provisionAddon(ctx context.Context, addon *types.DatabaseAddon, provisioner AddonProvisioner, namespace string, recovery *PointInTimeRecovery) error {
 	logger := s.logger.WithFields(logrus.Fields{
 		"addon_id":  addon.ID,
 		"type":      addon.Type,
@@ -125,16 +304,30 @@ func (s *AddonService) provisionAddon(ctx context.Context, addon *types.Database
 
 	logger.Info("Starting addon provisioning")
 
+	// WAL archiving and recovery read their credentials from the namespace
+	archive := s.walArchive(addon)
+	if archive != nil || recovery != nil {
+		if err := s.k8sClient.EnsureNamespace(ctx, namespace); err != nil {
+			logger.WithError(err).Error("Failed to ensure namespace")
+			return err
+		}
+		if err := s.backupStorage.ensureSecret(ctx, s, namespace); err != nil {
+			logger.WithError(err).Error("Failed to prepare WAL archive credentials")
+			return err
+		}
+	}
+
 	result, err := provisioner.Provision(ctx, &ProvisionRequest{
-		Addon:     addon,
-		Namespace: namespace,
-		ProjectID: addon.ProjectID,
+		Addon:      addon,
+		Namespace:  namespace,
+		ProjectID:  addon.ProjectID,
+		WALArchive: archive,
+		Recovery:   recovery,
 	})
 
 	if err != nil {
 		logger.WithError(err).Error("Addon provisioning failed")
-		s.repos.DatabaseAddons.UpdateStatus(ctx, addon.ID, types.DatabaseAddonStatusFailed, err.Error())
-		return
+		return err
 	}
 
 	// Update addon with K8s resource info
@@ -144,10 +337,11 @@ func (s *AddonService) provisionAddon(ctx context.Context, addon *types.Database
 
 	if err := s.repos.DatabaseAddons.Update(ctx, addon); err != nil {
 		logger.WithError(err).Error("Failed to update addon with K8s info")
-		return
+		return fmt.Errorf("failed to update addon with K8s info: %w", err)
 	}
 
 	logger.Info("Addon provisioning initiated successfully")
+	return nil
 }
 
 // GetAddon retrieves a database addon by ID
@@ -251,7 +445,7 @@ func (s *AddonService) GetCredentials(ctx context.Context, addonID uuid.UUID) (*
 		return nil, fmt.Errorf("addon not found: %w", err)
 	}
 
-	if addon.Status != types.DatabaseAddonStatusReady {
+	if !addon.IsAvailable() {
 		return nil, fmt.Errorf("addon is not ready (status: %s)", addon.Status)
 	}
 
@@ -278,7 +472,7 @@ func (s *AddonService) CreateBinding(ctx context.Context, addonID, serviceID uui
 		return nil, fmt.Errorf("addon not found: %w", err)
 	}
 
-	if addon.Status != types.DatabaseAddonStatusReady {
+	if !addon.IsAvailable() {
 		return nil, fmt.Errorf("addon is not ready for binding (status: %s)", addon.Status)
 	}
 
@@ -331,7 +525,19 @@ func (s *AddonService) GetEnvVarsForService(ctx context.Context, serviceID uuid.
 			continue
 		}
 
-		if addon.Status != types.DatabaseAddonStatusReady {
+		if !addon.IsAvailable() {
+			continue
+		}
+
+		if addon.Type == types.DatabaseAddonTypeBucket {
+			bucketVars, err := s.buckets.GetEnvVars(ctx, addon, binding.EnvVarName)
+			if err != nil {
+				s.logger.WithError(err).WithField("addon_id", addon.ID).Warn("Failed to get bucket credentials")
+				continue
+			}
+			for name, value := range bucketVars {
+				envVars[name] = value
+			}
 			continue
 		}
 
@@ -359,8 +565,9 @@ func (s *AddonService) RefreshStatus(ctx context.Context, addonID uuid.UUID) (*t
 		return nil, err
 	}
 
-	// Only refresh if not already in a terminal state
+	// Only refresh if not already in a terminal state; resizes are tracked by SyncResizes
 	if addon.Status == types.DatabaseAddonStatusReady ||
+		addon.Status == types.DatabaseAddonStatusResizing ||
 		addon.Status == types.DatabaseAddonStatusDeleted ||
 		addon.Status == types.DatabaseAddonStatusFailed {
 		return addon, nil
@@ -425,14 +632,59 @@ func applyDefaultConfig(addonType types.DatabaseAddonType, config types.Database
 		if result.Replicas == 0 {
 			result.Replicas = 1
 		}
+		if result.MaxMemoryPolicy == "" {
+			result.MaxMemoryPolicy = DefaultRedisMaxMemoryPolicy
+		}
 	case types.DatabaseAddonTypeMySQL:
 		if result.Version == "" {
-			result.Version = "8.0"
+			result.Version = DefaultMySQLVersion
+		}
+		if result.StorageGB == 0 {
+			result.StorageGB = 10
+		}
+		if result.CPU == "" {
+			result.CPU = "250m"
+		}
+		if result.Memory == "" {
+			result.Memory = "512Mi"
+		}
+		if result.Replicas == 0 {
+			result.Replicas = 1
+		}
+		applyDefaultBackupPolicy(&result)
+	case types.DatabaseAddonTypeMongoDB:
+		if result.Version == "" {
+			result.Version = DefaultMongoDBVersion
 		}
 		if result.StorageGB == 0 {
 			result.StorageGB = 10
 		}
+		if result.CPU == "" {
+			result.CPU = "250m"
+		}
+		if result.Memory == "" {
+			result.Memory = "512Mi"
+		}
+		if result.Replicas == 0 {
+			result.Replicas = 1
+		}
+		applyDefaultBackupPolicy(&result)
+	case types.DatabaseAddonTypeBucket:
+		// Sizes the MinIO volume; cloud buckets grow with their contents
+		if result.StorageGB == 0 {
+			result.StorageGB = 10
+		}
 	}
 
 	return result
 }
+
+// applyDefaultBackupPolicy enables daily backups for dump-based engines
+func applyDefaultBackupPolicy(config *types.DatabaseAddonConfig) {
+	if config.BackupSchedule == "" {
+		config.BackupSchedule = DefaultBackupSchedule
+	}
+	if config.BackupRetentionDays == 0 {
+		config.BackupRetentionDays = DefaultBackupRetentionDays
+	}
+}
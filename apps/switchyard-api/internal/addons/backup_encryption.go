@@ -0,0 +1,87 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// sseKeyMountPath is where backup and restore jobs read the SSE-C key of a
+// backup encrypted with a team's customer-managed key
+const sseKeyMountPath = "/sse"
+
+// BackupKeyring issues and unwraps per-backup encryption keys for teams with
+// a customer-managed key (see cmek.Service)
+type BackupKeyring interface {
+	// BackupKey returns a new key and its sealed form for a backup of a
+	// project's addon; key is nil when the project's team has no
+	// customer-managed key
+	BackupKey(ctx context.Context, projectID uuid.UUID) (key *db.TeamEncryptionKey, plaintext []byte, sealed string, err error)
+
+	// OpenBackupKey unwraps a key returned by BackupKey
+	OpenBackupKey(ctx context.Context, sealed string) ([]byte, error)
+}
+
+// SetBackupKeyring enables customer-managed encryption of uploaded backups
+func (s *AddonService) SetBackupKeyring(keyring BackupKeyring) {
+	s.backupKeyring = keyring
+}
+
+// sseKeySecretName returns the name of the Secret holding a job's SSE-C key
+func sseKeySecretName(jobName string) string {
+	return jobName + "-sse"
+}
+
+// sseArgs are the aws s3 cp flags that encrypt or decrypt an object with the
+// mounted SSE-C key
+func sseArgs() string {
+	return fmt.Sprintf("--sse-c AES256 --sse-c-key fileb://%s/key", sseKeyMountPath)
+}
+
+// mountSSEKey mounts a job's SSE-C key Secret into a storage tools container
+func mountSSEKey(podSpec *corev1.PodSpec, container *corev1.Container, jobName string) {
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "sse-key",
+		MountPath: sseKeyMountPath,
+		ReadOnly:  true,
+	})
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "sse-key",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: sseKeySecretName(jobName)},
+		},
+	})
+}
+
+// createSSEKeySecret stores a job's SSE-C key in a Secret owned by the job,
+// so it is deleted with the job
+func (s *AddonService) createSSEKeySecret(ctx context.Context, job *batchv1.Job, key []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sseKeySecretName(job.Name),
+			Namespace: job.Namespace,
+			Labels:    job.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+					Name:       job.Name,
+					UID:        job.UID,
+				},
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{"key": key},
+	}
+
+	if _, err := s.k8sClient.Clientset.CoreV1().Secrets(job.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create backup encryption key secret: %w", err)
+	}
+	return nil
+}
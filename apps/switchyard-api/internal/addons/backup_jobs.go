@@ -0,0 +1,638 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Backup job constants
+const (
+	backupMountPath            = "/backups"
+	backupJobTTLSeconds        = int32(24 * 60 * 60)
+	backupJobBackoffLimit      = int32(1)
+	DefaultBackupSchedule      = "0 3 * * *"
+	DefaultBackupRetentionDays = 7
+)
+
+// SupportsBackupJobs reports whether an addon type is backed up by a dump job
+func SupportsBackupJobs(addonType types.DatabaseAddonType) bool {
+	return addonType == types.DatabaseAddonTypeMySQL || addonType == types.DatabaseAddonTypeMongoDB
+}
+
+// NextBackupAt returns the first scheduled backup time after the given time
+func NextBackupAt(schedule string, after time.Time) (time.Time, error) {
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid backup_schedule %q: %w", schedule, err)
+	}
+	return sched.Next(after), nil
+}
+
+// backupRetentionDays returns the configured retention, falling back to the default
+func backupRetentionDays(config types.DatabaseAddonConfig) int {
+	if config.BackupRetentionDays > 0 {
+		return config.BackupRetentionDays
+	}
+	return DefaultBackupRetentionDays
+}
+
+// BackupJobName returns the name of the K8s Job that dumps an addon for a backup
+func BackupJobName(addon *types.DatabaseAddon, backupID uuid.UUID) string {
+	return fmt.Sprintf("%s-backup-%s", addon.K8sResourceName, backupID.String()[:8])
+}
+
+// backupVolumeName returns the PVC that holds dump files for an addon when
+// no object storage is configured
+func backupVolumeName(addon *types.DatabaseAddon) string {
+	return fmt.Sprintf("%s-backups", addon.K8sResourceName)
+}
+
+// backupFileName returns the dump file name for a backup, using the
+// conventional extension of the engine's dump format
+func backupFileName(addonType types.DatabaseAddonType, backupID uuid.UUID) (string, error) {
+	switch addonType {
+	case types.DatabaseAddonTypeMySQL:
+		return backupID.String() + ".sql.gz", nil
+	case types.DatabaseAddonTypeMongoDB:
+		return backupID.String() + ".archive.gz", nil
+	default:
+		return "", fmt.Errorf("backups are not supported for addon type: %s", addonType)
+	}
+}
+
+// backupCommand returns the shell command that dumps the addon into file
+func backupCommand(addonType types.DatabaseAddonType, file string) (string, error) {
+	switch addonType {
+	case types.DatabaseAddonTypeMySQL:
+		return fmt.Sprintf(`mysqldump -h "$DB_HOST" -P "$DB_PORT" -u root --single-transaction --routines --triggers --databases "$DB_NAME" | gzip > %s`, file), nil
+	case types.DatabaseAddonTypeMongoDB:
+		return fmt.Sprintf(`mongodump --uri "$DB_URI" --archive --gzip > %s`, file), nil
+	default:
+		return "", fmt.Errorf("backups are not supported for addon type: %s", addonType)
+	}
+}
+
+// recordSizeCommand writes the dump size to the termination log so the
+// controller can record it on the backup
+func recordSizeCommand(file string) string {
+	return fmt.Sprintf("stat -c %%s %s > /dev/termination-log", file)
+}
+
+// shellScript joins commands into a fail-fast shell script
+func shellScript(commands ...string) string {
+	return strings.Join(append([]string{"set -eo pipefail"}, commands...), "\n")
+}
+
+// engineImage returns the engine image whose client tools match the addon version
+func engineImage(addon *types.DatabaseAddon) string {
+	version := addon.Config.Version
+	switch addon.Type {
+	case types.DatabaseAddonTypeMySQL:
+		if version == "" {
+			version = DefaultMySQLVersion
+		}
+		return fmt.Sprintf("mysql:%s", version)
+	case types.DatabaseAddonTypeMongoDB:
+		if version == "" {
+			version = DefaultMongoDBVersion
+		}
+		return fmt.Sprintf("mongo:%s", version)
+	default:
+		return ""
+	}
+}
+
+// engineEnv returns the env vars the engine client tools read from the addon secret
+func engineEnv(addon *types.DatabaseAddon) []corev1.EnvVar {
+	switch addon.Type {
+	case types.DatabaseAddonTypeMySQL:
+		return []corev1.EnvVar{
+			secretEnvVar("DB_HOST", addon.ConnectionSecret, "host"),
+			secretEnvVar("DB_PORT", addon.ConnectionSecret, "port"),
+			secretEnvVar("DB_NAME", addon.ConnectionSecret, "database"),
+			secretEnvVar("MYSQL_PWD", addon.ConnectionSecret, "root-password"),
+		}
+	case types.DatabaseAddonTypeMongoDB:
+		return []corev1.EnvVar{
+			secretEnvVar("DB_URI", addon.ConnectionSecret, "uri"),
+		}
+	default:
+		return nil
+	}
+}
+
+// storageToolsContainer builds an aws-cli container that reads the bucket
+// credentials from the namespace's backup storage secret
+func storageToolsContainer(name, script string) corev1.Container {
+	return corev1.Container{
+		Name:    name,
+		Image:   backupToolsImage,
+		Command: []string{"/bin/sh", "-c", script},
+		EnvFrom: []corev1.EnvFromSource{
+			{
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: backupStorageSecretName},
+				},
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "backups", MountPath: backupMountPath},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}
+}
+
+// s3CopyCommand copies between a local file and the backup bucket, honouring
+// a custom endpoint for S3-compatible providers. Encrypted copies use the
+// mounted SSE-C key.
+func s3CopyCommand(src, dst string, encrypted bool) string {
+	cmd := fmt.Sprintf(`aws s3 cp %s %s ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"}`, src, dst)
+	if encrypted {
+		cmd += " " + sseArgs()
+	}
+	return cmd
+}
+
+// BuildBackupJob builds the K8s Job that dumps a MySQL (mysqldump) or
+// MongoDB (mongodump) addon. When objectKey is set the dump is written to
+// scratch space and uploaded to the backup bucket; otherwise it is kept on
+// the addon's backup volume. Uploads of backups with an encryption key are
+// encrypted with the key mounted from the job's SSE-C Secret. PostgreSQL
+// backups are handled by CloudNativePG.
+func BuildBackupJob(addon *types.DatabaseAddon, backup *types.DatabaseAddonBackup, objectKey string) (*batchv1.Job, error) {
+	fileName, err := backupFileName(addon.Type, backup.ID)
+	if err != nil {
+		return nil, err
+	}
+	file := fmt.Sprintf("%s/%s", backupMountPath, fileName)
+
+	dump, err := backupCommand(addon.Type, file)
+	if err != nil {
+		return nil, err
+	}
+
+	dumpContainer := corev1.Container{
+		Name:  "dump",
+		Image: engineImage(addon),
+		Env:   engineEnv(addon),
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "backups", MountPath: backupMountPath},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}
+
+	podSpec := corev1.PodSpec{RestartPolicy: corev1.RestartPolicyNever}
+
+	if objectKey != "" {
+		dumpContainer.Command = []string{"/bin/bash", "-c", shellScript(dump)}
+		encrypted := backup.EncryptionKeyID != nil
+		upload := storageToolsContainer("upload", shellScript(
+			s3CopyCommand(file, fmt.Sprintf(`"s3://$S3_BUCKET/%s"`, objectKey), encrypted),
+			recordSizeCommand(file),
+		))
+		podSpec.InitContainers = []corev1.Container{dumpContainer}
+		podSpec.Volumes = []corev1.Volume{
+			{Name: "backups", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		}
+		if encrypted {
+			mountSSEKey(&podSpec, &upload, BackupJobName(addon, backup.ID))
+		}
+		podSpec.Containers = []corev1.Container{upload}
+	} else {
+		// Without object storage the job prunes its own expired dumps
+		prune := fmt.Sprintf("find %s -type f -mtime +%d -delete", backupMountPath, backupRetentionDays(addon.Config))
+		dumpContainer.Command = []string{"/bin/bash", "-c", shellScript(dump, recordSizeCommand(file), prune)}
+		podSpec.Containers = []corev1.Container{dumpContainer}
+		podSpec.Volumes = []corev1.Volume{
+			{
+				Name: "backups",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: backupVolumeName(addon),
+					},
+				},
+			},
+		}
+	}
+
+	labels := map[string]string{
+		LabelManagedBy:           LabelManagedValue,
+		LabelAddonID:             addon.ID.String(),
+		LabelProjectID:           addon.ProjectID.String(),
+		LabelAddonType:           string(addon.Type),
+		"enclii.dev/type":        "addon-backup",
+		"enclii.dev/backup-id":   backup.ID.String(),
+		"enclii.dev/backup-type": string(backup.BackupType),
+	}
+
+	return newAddonJob(BackupJobName(addon, backup.ID), addon.K8sNamespace, labels, podSpec), nil
+}
+
+// newAddonJob wraps a pod spec in a run-once Job that cleans itself up
+func newAddonJob(name, namespace string, labels map[string]string, podSpec corev1.PodSpec) *batchv1.Job {
+	ttl := backupJobTTLSeconds
+	backoffLimit := backupJobBackoffLimit
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			TTLSecondsAfterFinished: &ttl,
+			BackoffLimit:            &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+// ensureBackupVolume creates the PVC that stores an addon's dump files
+func (s *AddonService) ensureBackupVolume(ctx context.Context, addon *types.DatabaseAddon) error {
+	size := fmt.Sprintf("%dGi", addon.Config.StorageGB)
+	if addon.Config.StorageGB == 0 {
+		size = DefaultStorageSize
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupVolumeName(addon),
+			Namespace: addon.K8sNamespace,
+			Labels: map[string]string{
+				LabelManagedBy:    LabelManagedValue,
+				LabelAddonID:      addon.ID.String(),
+				"enclii.dev/type": "addon-backup",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(size),
+				},
+			},
+		},
+	}
+
+	_, err := s.k8sClient.Clientset.CoreV1().PersistentVolumeClaims(addon.K8sNamespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create backup volume: %w", err)
+	}
+	return nil
+}
+
+// CreateBackup starts a dump job for a ready MySQL or MongoDB addon and
+// records it as an in-progress backup
+func (s *AddonService) CreateBackup(ctx context.Context, addonID uuid.UUID, backupType types.DatabaseAddonBackupType) (*types.DatabaseAddonBackup, error) {
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon not found: %w", err)
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"addon_id":    addon.ID,
+		"type":        addon.Type,
+		"backup_type": backupType,
+	})
+
+	if addon.Status != types.DatabaseAddonStatusReady {
+		return nil, fmt.Errorf("addon is not ready for backup (status: %s)", addon.Status)
+	}
+
+	if !SupportsBackupJobs(addon.Type) {
+		return nil, fmt.Errorf("backups are not supported for addon type: %s", addon.Type)
+	}
+
+	backup := &types.DatabaseAddonBackup{
+		AddonID:    addon.ID,
+		BackupType: backupType,
+	}
+
+	// Uploads for teams with a customer-managed key are encrypted with a
+	// per-backup key sealed under the team key
+	var sseKey []byte
+	if s.backupStorage != nil && s.backupKeyring != nil {
+		key, plaintext, sealed, err := s.backupKeyring.BackupKey(ctx, addon.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create backup encryption key: %w", err)
+		}
+		if key != nil {
+			backup.EncryptionKeyID = &key.ID
+			backup.WrappedDataKey = sealed
+			sseKey = plaintext
+		}
+	}
+
+	if err := s.repos.DatabaseAddons.CreateBackup(ctx, backup); err != nil {
+		return nil, fmt.Errorf("failed to create backup record: %w", err)
+	}
+
+	fileName, _ := backupFileName(addon.Type, backup.ID)
+	objectKey := ""
+	storagePath := fmt.Sprintf("%s%s/%s", backupVolumeScheme, backupVolumeName(addon), fileName)
+	if s.backupStorage != nil {
+		objectKey = s.backupStorage.objectKey(addon.ProjectID.String(), addon.ID.String(), fileName)
+		storagePath = s.backupStorage.storagePath(objectKey)
+	}
+
+	job, err := BuildBackupJob(addon, backup, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	fail := func(err error) (*types.DatabaseAddonBackup, error) {
+		logger.WithError(err).Error("Failed to start addon backup")
+		backup.Status = types.DatabaseAddonBackupStatusFailed
+		backup.StatusMessage = err.Error()
+		if updateErr := s.repos.DatabaseAddons.UpdateBackup(ctx, backup); updateErr != nil {
+			logger.WithError(updateErr).Error("Failed to update backup record")
+		}
+		return nil, err
+	}
+
+	if s.backupStorage != nil {
+		if err := s.backupStorage.ensureSecret(ctx, s, addon.K8sNamespace); err != nil {
+			return fail(err)
+		}
+	} else if err := s.ensureBackupVolume(ctx, addon); err != nil {
+		return fail(err)
+	}
+
+	created, err := s.k8sClient.Clientset.BatchV1().Jobs(addon.K8sNamespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return fail(fmt.Errorf("failed to create backup job: %w", err))
+	}
+	if sseKey != nil {
+		if err := s.createSSEKeySecret(ctx, created, sseKey); err != nil {
+			return fail(err)
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, backupRetentionDays(addon.Config))
+	backup.Status = types.DatabaseAddonBackupStatusInProgress
+	backup.StatusMessage = fmt.Sprintf("Backup job %s started", job.Name)
+	backup.StoragePath = storagePath
+	backup.StartedAt = &now
+	backup.ExpiresAt = &expiresAt
+
+	if err := s.repos.DatabaseAddons.UpdateBackup(ctx, backup); err != nil {
+		return nil, fmt.Errorf("failed to update backup record: %w", err)
+	}
+
+	logger.WithField("job", job.Name).Info("Addon backup started")
+	return backup, nil
+}
+
+// ListBackups returns the backups of an addon, refreshing any that are
+// still running from the state of their dump jobs
+func (s *AddonService) ListBackups(ctx context.Context, addonID uuid.UUID, limit int) ([]*types.DatabaseAddonBackup, error) {
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon not found: %w", err)
+	}
+
+	backups, err := s.repos.DatabaseAddons.GetBackupsByAddon(ctx, addonID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, backup := range backups {
+		if backup.Status != types.DatabaseAddonBackupStatusInProgress {
+			continue
+		}
+		if err := s.syncBackupStatus(ctx, addon, backup); err != nil {
+			s.logger.WithError(err).WithField("backup_id", backup.ID).Warn("Failed to sync backup status")
+		}
+	}
+
+	return backups, nil
+}
+
+// RunScheduledBackups starts a backup for every ready addon whose backup
+// schedule has come due since its latest backup
+func (s *AddonService) RunScheduledBackups(ctx context.Context, now time.Time) {
+	for _, addonType := range []types.DatabaseAddonType{types.DatabaseAddonTypeMySQL, types.DatabaseAddonTypeMongoDB} {
+		addons, err := s.repos.DatabaseAddons.ListReadyByType(ctx, addonType)
+		if err != nil {
+			s.logger.WithError(err).WithField("type", addonType).Error("Failed to list addons for scheduled backups")
+			continue
+		}
+
+		for _, addon := range addons {
+			if addon.Config.BackupSchedule == "" {
+				continue
+			}
+
+			due, err := s.backupDue(ctx, addon, now)
+			if err != nil {
+				s.logger.WithError(err).WithField("addon_id", addon.ID).Warn("Failed to evaluate backup schedule")
+				continue
+			}
+			if !due {
+				continue
+			}
+
+			if _, err := s.CreateBackup(ctx, addon.ID, types.DatabaseAddonBackupTypeScheduled); err != nil {
+				s.logger.WithError(err).WithField("addon_id", addon.ID).Error("Scheduled backup failed to start")
+			}
+		}
+	}
+}
+
+// backupDue reports whether the addon's schedule has fired since its latest
+// backup (of any outcome), so failed runs are retried at the next slot
+// rather than on every tick
+func (s *AddonService) backupDue(ctx context.Context, addon *types.DatabaseAddon, now time.Time) (bool, error) {
+	reference := addon.CreatedAt
+	if addon.ProvisionedAt != nil {
+		reference = *addon.ProvisionedAt
+	}
+
+	latest, err := s.repos.DatabaseAddons.GetBackupsByAddon(ctx, addon.ID, 1)
+	if err != nil {
+		return false, err
+	}
+	if len(latest) > 0 {
+		switch latest[0].Status {
+		case types.DatabaseAddonBackupStatusPending, types.DatabaseAddonBackupStatusInProgress:
+			return false, nil
+		}
+		if latest[0].CreatedAt.After(reference) {
+			reference = latest[0].CreatedAt
+		}
+	}
+
+	next, err := NextBackupAt(addon.Config.BackupSchedule, reference)
+	if err != nil {
+		return false, err
+	}
+	return !now.Before(next), nil
+}
+
+// SyncBackups refreshes all in-progress backups from their dump jobs
+func (s *AddonService) SyncBackups(ctx context.Context) {
+	backups, err := s.repos.DatabaseAddons.ListBackupsByStatus(ctx, types.DatabaseAddonBackupStatusInProgress)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list in-progress backups")
+		return
+	}
+
+	for _, backup := range backups {
+		addon, err := s.repos.DatabaseAddons.GetByID(ctx, backup.AddonID)
+		if err != nil {
+			s.logger.WithError(err).WithField("backup_id", backup.ID).Warn("Failed to get addon for backup")
+			continue
+		}
+		if err := s.syncBackupStatus(ctx, addon, backup); err != nil {
+			s.logger.WithError(err).WithField("backup_id", backup.ID).Warn("Failed to sync backup status")
+		}
+	}
+}
+
+// PruneExpiredBackups deletes backups past their retention period, removing
+// uploaded dumps from object storage. Dumps on backup volumes are pruned by
+// the backup jobs themselves.
+func (s *AddonService) PruneExpiredBackups(ctx context.Context, now time.Time) {
+	backups, err := s.repos.DatabaseAddons.ListExpiredBackups(ctx, now)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list expired backups")
+		return
+	}
+
+	for _, backup := range backups {
+		logger := s.logger.WithFields(logrus.Fields{
+			"backup_id": backup.ID,
+			"addon_id":  backup.AddonID,
+		})
+
+		if strings.HasPrefix(backup.StoragePath, backupStorageScheme) {
+			if s.backupStorage == nil {
+				logger.Warn("Backup storage not configured, keeping expired backup")
+				continue
+			}
+			if err := s.backupStorage.Delete(ctx, backup.StoragePath); err != nil {
+				logger.WithError(err).Error("Failed to delete expired backup object")
+				continue
+			}
+		}
+
+		if err := s.repos.DatabaseAddons.DeleteBackup(ctx, backup.ID); err != nil {
+			logger.WithError(err).Error("Failed to delete expired backup record")
+			continue
+		}
+
+		logger.Info("Expired addon backup pruned")
+	}
+}
+
+// syncBackupStatus updates an in-progress backup from its dump job
+func (s *AddonService) syncBackupStatus(ctx context.Context, addon *types.DatabaseAddon, backup *types.DatabaseAddonBackup) error {
+	jobName := BackupJobName(addon, backup.ID)
+	job, err := s.k8sClient.Clientset.BatchV1().Jobs(addon.K8sNamespace).Get(ctx, jobName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		backup.Status = types.DatabaseAddonBackupStatusFailed
+		backup.StatusMessage = "Backup job not found"
+	} else if job.Status.Succeeded > 0 {
+		backup.Status = types.DatabaseAddonBackupStatusCompleted
+		backup.StatusMessage = "Backup completed"
+		backup.SizeBytes = s.backupJobSize(ctx, addon.K8sNamespace, jobName)
+	} else if job.Status.Failed > backupJobBackoffLimit {
+		backup.Status = types.DatabaseAddonBackupStatusFailed
+		backup.StatusMessage = fmt.Sprintf("Backup job %s failed", jobName)
+	} else {
+		return nil
+	}
+
+	now := time.Now()
+	backup.CompletedAt = &now
+	if err := s.repos.DatabaseAddons.UpdateBackup(ctx, backup); err != nil {
+		return err
+	}
+
+	if backup.Status == types.DatabaseAddonBackupStatusCompleted {
+		addon.LastBackupAt = &now
+		if err := s.repos.DatabaseAddons.Update(ctx, addon); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backupJobSize reads the dump size a successful job wrote to its termination log
+func (s *AddonService) backupJobSize(ctx context.Context, namespace, jobName string) int64 {
+	pods, err := s.k8sClient.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil {
+		return 0
+	}
+
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Terminated == nil || cs.State.Terminated.ExitCode != 0 {
+				continue
+			}
+			if size, err := strconv.ParseInt(strings.TrimSpace(cs.State.Terminated.Message), 10, 64); err == nil {
+				return size
+			}
+		}
+	}
+
+	return 0
+}
+
+// backupFileFromPath returns the dump file name at the end of a storage path
+func backupFileFromPath(storagePath string) string {
+	return path.Base(storagePath)
+}
+
+// UpdateBackupPolicy changes the backup schedule and retention of an addon.
+// An empty schedule disables scheduled backups; manual backups still work.
+func (s *AddonService) UpdateBackupPolicy(ctx context.Context, addonID uuid.UUID, schedule string, retentionDays int) (*types.DatabaseAddon, error) {
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon not found: %w", err)
+	}
+
+	if err := ValidateBackupPolicy(addon.Type, schedule, retentionDays); err != nil {
+		return nil, err
+	}
+
+	addon.Config.BackupSchedule = schedule
+	if retentionDays > 0 {
+		addon.Config.BackupRetentionDays = retentionDays
+	}
+
+	if err := s.repos.DatabaseAddons.Update(ctx, addon); err != nil {
+		return nil, fmt.Errorf("failed to update addon: %w", err)
+	}
+
+	return addon, nil
+}
@@ -0,0 +1,298 @@
+package addons
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestBuildBackupJob(t *testing.T) {
+	backup := &types.DatabaseAddonBackup{ID: uuid.New(), BackupType: types.DatabaseAddonBackupTypeManual}
+
+	tests := []struct {
+		name        string
+		addon       *types.DatabaseAddon
+		wantImage   string
+		wantCommand string
+		wantErr     bool
+	}{
+		{
+			name:        "mysql uses mysqldump",
+			addon:       &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMySQL, K8sResourceName: "mysql-abc", ConnectionSecret: "mysql-abc-secret"},
+			wantImage:   "mysql:" + DefaultMySQLVersion,
+			wantCommand: "mysqldump",
+		},
+		{
+			name:        "mongodb uses mongodump with configured version",
+			addon:       &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMongoDB, K8sResourceName: "mongodb-abc", ConnectionSecret: "mongodb-abc-secret", Config: types.DatabaseAddonConfig{Version: "6.0"}},
+			wantImage:   "mongo:6.0",
+			wantCommand: "mongodump",
+		},
+		{
+			name:    "postgres is not supported",
+			addon:   &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypePostgres, K8sResourceName: "pg-abc"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := BuildBackupJob(tt.addon, backup, "")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			container := job.Spec.Template.Spec.Containers[0]
+			if container.Image != tt.wantImage {
+				t.Errorf("image = %q, want %q", container.Image, tt.wantImage)
+			}
+			if script := container.Command[2]; !strings.Contains(script, tt.wantCommand) {
+				t.Errorf("command %q does not run %s", script, tt.wantCommand)
+			}
+			if job.Name != BackupJobName(tt.addon, backup.ID) {
+				t.Errorf("job name = %q", job.Name)
+			}
+			for _, env := range container.Env {
+				if env.ValueFrom.SecretKeyRef.Name != tt.addon.ConnectionSecret {
+					t.Errorf("env %s references secret %q", env.Name, env.ValueFrom.SecretKeyRef.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildBackupJobUpload(t *testing.T) {
+	addon := &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMySQL, K8sResourceName: "mysql-abc", ConnectionSecret: "mysql-abc-secret"}
+	backup := &types.DatabaseAddonBackup{ID: uuid.New(), BackupType: types.DatabaseAddonBackupTypeScheduled}
+
+	job, err := BuildBackupJob(addon, backup, "addon-backups/p/a/dump.sql.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spec := job.Spec.Template.Spec
+	if len(spec.InitContainers) != 1 || spec.InitContainers[0].Name != "dump" {
+		t.Fatalf("expected dump init container, got %+v", spec.InitContainers)
+	}
+	if len(spec.Containers) != 1 || spec.Containers[0].Name != "upload" {
+		t.Fatalf("expected upload container, got %+v", spec.Containers)
+	}
+	if script := spec.Containers[0].Command[2]; !strings.Contains(script, "s3://$S3_BUCKET/addon-backups/p/a/dump.sql.gz") {
+		t.Errorf("upload command %q does not target object key", script)
+	}
+	if spec.Volumes[0].EmptyDir == nil {
+		t.Error("expected scratch emptyDir volume for uploads")
+	}
+}
+
+func TestBuildBackupJobEncryptedUpload(t *testing.T) {
+	addon := &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMongoDB, K8sResourceName: "mongodb-abc", ConnectionSecret: "mongodb-abc-secret"}
+	keyID := uuid.New()
+	backup := &types.DatabaseAddonBackup{ID: uuid.New(), BackupType: types.DatabaseAddonBackupTypeManual, EncryptionKeyID: &keyID, WrappedDataKey: "cmek:v1:sealed"}
+
+	job, err := BuildBackupJob(addon, backup, "addon-backups/p/a/dump.archive.gz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spec := job.Spec.Template.Spec
+	upload := spec.Containers[0]
+	if script := upload.Command[2]; !strings.Contains(script, "--sse-c AES256 --sse-c-key fileb:///sse/key") {
+		t.Errorf("upload command %q does not use the SSE-C key", script)
+	}
+	if !hasVolumeMount(upload, "sse-key", sseKeyMountPath) {
+		t.Errorf("upload container does not mount the SSE-C key: %+v", upload.VolumeMounts)
+	}
+	if !hasSecretVolume(spec, "sse-key", sseKeySecretName(job.Name)) {
+		t.Errorf("job does not mount secret %s: %+v", sseKeySecretName(job.Name), spec.Volumes)
+	}
+	if hasVolumeMount(spec.InitContainers[0], "sse-key", sseKeyMountPath) {
+		t.Error("dump container must not see the SSE-C key")
+	}
+}
+
+func hasVolumeMount(container corev1.Container, name, path string) bool {
+	for _, m := range container.VolumeMounts {
+		if m.Name == name && m.MountPath == path {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSecretVolume(spec corev1.PodSpec, name, secret string) bool {
+	for _, v := range spec.Volumes {
+		if v.Name == name && v.Secret != nil && v.Secret.SecretName == secret {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNextBackupAt(t *testing.T) {
+	after := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule string
+		want     time.Time
+		wantErr  bool
+	}{
+		{name: "daily", schedule: DefaultBackupSchedule, want: time.Date(2024, 5, 2, 3, 0, 0, 0, time.UTC)},
+		{name: "hourly", schedule: "0 * * * *", want: time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)},
+		{name: "descriptor", schedule: "@weekly", want: time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{name: "invalid", schedule: "every day", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextBackupAt(tt.schedule, after)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("NextBackupAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildRestoreJob(t *testing.T) {
+	restore := &types.DatabaseAddonRestore{ID: uuid.New()}
+
+	tests := []struct {
+		name         string
+		addon        *types.DatabaseAddon
+		storagePath  string
+		wrappedKey   string
+		wantCommand  string
+		wantDownload bool
+		wantSSE      bool
+		wantErr      bool
+	}{
+		{
+			name:         "mysql from object storage",
+			addon:        &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMySQL, K8sResourceName: "mysql-abc", ConnectionSecret: "mysql-abc-secret"},
+			storagePath:  "s3://bucket/addon-backups/p/a/dump.sql.gz",
+			wantCommand:  "gunzip -c /backups/dump.sql.gz | mysql",
+			wantDownload: true,
+		},
+		{
+			name:         "encrypted mysql from object storage",
+			addon:        &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMySQL, K8sResourceName: "mysql-abc", ConnectionSecret: "mysql-abc-secret"},
+			storagePath:  "s3://bucket/addon-backups/p/a/dump.sql.gz",
+			wrappedKey:   "cmek:v1:sealed",
+			wantCommand:  "gunzip -c /backups/dump.sql.gz | mysql",
+			wantDownload: true,
+			wantSSE:      true,
+		},
+		{
+			name:        "mongodb from backup volume",
+			addon:       &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMongoDB, K8sResourceName: "mongodb-abc", ConnectionSecret: "mongodb-abc-secret"},
+			storagePath: "pvc://mongodb-abc-backups/dump.archive.gz",
+			wantCommand: "mongorestore",
+		},
+		{
+			name:        "unknown storage path",
+			addon:       &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMySQL, K8sResourceName: "mysql-abc"},
+			storagePath: "/tmp/dump.sql.gz",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := &types.DatabaseAddonBackup{ID: uuid.New(), StoragePath: tt.storagePath, WrappedDataKey: tt.wrappedKey}
+			job, err := BuildRestoreJob(tt.addon, backup, restore)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			spec := job.Spec.Template.Spec
+			if script := spec.Containers[0].Command[2]; !strings.Contains(script, tt.wantCommand) {
+				t.Errorf("command %q does not run %s", script, tt.wantCommand)
+			}
+			if hasDownload := len(spec.InitContainers) == 1; hasDownload != tt.wantDownload {
+				t.Errorf("download init container = %v, want %v", hasDownload, tt.wantDownload)
+			}
+			if tt.wantDownload {
+				download := spec.InitContainers[0]
+				if hasSSE := strings.Contains(download.Command[2], "--sse-c"); hasSSE != tt.wantSSE {
+					t.Errorf("download uses SSE-C = %v, want %v", hasSSE, tt.wantSSE)
+				}
+				if hasKey := hasSecretVolume(spec, "sse-key", sseKeySecretName(job.Name)); hasKey != tt.wantSSE {
+					t.Errorf("SSE-C key volume = %v, want %v", hasKey, tt.wantSSE)
+				}
+			}
+			if job.Name != RestoreJobName(tt.addon, restore.ID) {
+				t.Errorf("job name = %q", job.Name)
+			}
+		})
+	}
+}
+
+func TestRestorePodPhase(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want string
+	}{
+		{name: "pending", pod: &corev1.Pod{}, want: RestorePhaseScheduling},
+		{
+			name: "downloading",
+			pod: &corev1.Pod{Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{Name: "download", State: running}},
+			}},
+			want: RestorePhaseDownloading,
+		},
+		{
+			name: "restoring",
+			pod: &corev1.Pod{Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "restore", State: running}},
+			}},
+			want: RestorePhaseRestoring,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := restorePodPhase(tt.pod); got != tt.want {
+				t.Errorf("restorePodPhase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionURIs(t *testing.T) {
+	if got, want := mysqlConnectionURI("app", "pw", "db.svc", 3306, "app"), "mysql://app:pw@db.svc:3306/app"; got != want {
+		t.Errorf("mysqlConnectionURI() = %q, want %q", got, want)
+	}
+	if got, want := mongoDBConnectionURI("app", "pw", "db.svc", 27017, "app"), "mongodb://app:pw@db.svc:27017/app?authSource=admin"; got != want {
+		t.Errorf("mongoDBConnectionURI() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,406 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Restore phases and the progress reported for each
+const (
+	RestorePhaseScheduling  = "scheduling"
+	RestorePhaseDownloading = "downloading"
+	RestorePhaseRestoring   = "restoring"
+	RestorePhaseDone        = "done"
+)
+
+var restorePhaseProgress = map[string]int{
+	RestorePhaseScheduling:  5,
+	RestorePhaseDownloading: 25,
+	RestorePhaseRecovering:  50,
+	RestorePhaseRestoring:   60,
+	RestorePhaseDone:        100,
+}
+
+// ErrRestoreInProgress is returned when an addon already has an active restore
+var ErrRestoreInProgress = fmt.Errorf("a restore is already in progress for this addon")
+
+// RestoreJobName returns the name of the K8s Job that restores an addon
+func RestoreJobName(addon *types.DatabaseAddon, restoreID uuid.UUID) string {
+	return fmt.Sprintf("%s-restore-%s", addon.K8sResourceName, restoreID.String()[:8])
+}
+
+// restoreCommand returns the shell command that loads a dump file into the addon
+func restoreCommand(addonType types.DatabaseAddonType, file string) (string, error) {
+	switch addonType {
+	case types.DatabaseAddonTypeMySQL:
+		return fmt.Sprintf(`gunzip -c %s | mysql -h "$DB_HOST" -P "$DB_PORT" -u root`, file), nil
+	case types.DatabaseAddonTypeMongoDB:
+		return fmt.Sprintf(`mongorestore --uri "$DB_URI" --archive=%s --gzip --drop`, file), nil
+	default:
+		return "", fmt.Errorf("restores are not supported for addon type: %s", addonType)
+	}
+}
+
+// BuildRestoreJob builds the K8s Job that loads a backup into an addon.
+// Dumps in object storage are downloaded by an init container first; dumps
+// on the addon's backup volume are read in place. Encrypted backups are
+// downloaded with the key mounted from the job's SSE-C Secret.
+func BuildRestoreJob(addon *types.DatabaseAddon, backup *types.DatabaseAddonBackup, restore *types.DatabaseAddonRestore) (*batchv1.Job, error) {
+	file := fmt.Sprintf("%s/%s", backupMountPath, backupFileFromPath(backup.StoragePath))
+
+	load, err := restoreCommand(addon.Type, file)
+	if err != nil {
+		return nil, err
+	}
+
+	podSpec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers: []corev1.Container{
+			{
+				Name:    "restore",
+				Image:   engineImage(addon),
+				Command: []string{"/bin/bash", "-c", shellScript(load)},
+				Env:     engineEnv(addon),
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "backups", MountPath: backupMountPath},
+				},
+			},
+		},
+	}
+
+	switch {
+	case strings.HasPrefix(backup.StoragePath, backupStorageScheme):
+		encrypted := backup.WrappedDataKey != ""
+		download := storageToolsContainer("download", shellScript(s3CopyCommand(fmt.Sprintf("%q", backup.StoragePath), file, encrypted)))
+		podSpec.Volumes = []corev1.Volume{
+			{Name: "backups", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		}
+		if encrypted {
+			mountSSEKey(&podSpec, &download, RestoreJobName(addon, restore.ID))
+		}
+		podSpec.InitContainers = []corev1.Container{download}
+	case strings.HasPrefix(backup.StoragePath, backupVolumeScheme):
+		podSpec.Volumes = []corev1.Volume{
+			{
+				Name: "backups",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: backupVolumeName(addon),
+						ReadOnly:  true,
+					},
+				},
+			},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported backup storage path: %q", backup.StoragePath)
+	}
+
+	labels := map[string]string{
+		LabelManagedBy:          LabelManagedValue,
+		LabelAddonID:            addon.ID.String(),
+		LabelProjectID:          addon.ProjectID.String(),
+		LabelAddonType:          string(addon.Type),
+		"enclii.dev/type":       "addon-restore",
+		"enclii.dev/backup-id":  backup.ID.String(),
+		"enclii.dev/restore-id": restore.ID.String(),
+	}
+
+	return newAddonJob(RestoreJobName(addon, restore.ID), addon.K8sNamespace, labels, podSpec), nil
+}
+
+// RestoreBackup starts restoring an addon from one of its completed backups.
+// Only one restore may run per addon at a time.
+func (s *AddonService) RestoreBackup(ctx context.Context, addonID, backupID uuid.UUID, actorID *uuid.UUID, actorEmail string) (*types.DatabaseAddonRestore, error) {
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon not found: %w", err)
+	}
+
+	if addon.Status != types.DatabaseAddonStatusReady {
+		return nil, fmt.Errorf("addon is not ready for restore (status: %s)", addon.Status)
+	}
+
+	backup, err := s.repos.DatabaseAddons.GetBackup(ctx, backupID)
+	if err != nil || backup.AddonID != addon.ID {
+		return nil, fmt.Errorf("backup not found for addon")
+	}
+
+	return s.startRestore(ctx, addon, backup, actorID, actorEmail)
+}
+
+// RestoreBackupFrom loads a backup of another addon of the same type into an
+// addon, as when cloning a template database. The backup must be in object
+// storage; backup volumes are only mounted by their own addon.
+func (s *AddonService) RestoreBackupFrom(ctx context.Context, addonID, backupID uuid.UUID, actorID *uuid.UUID, actorEmail string) (*types.DatabaseAddonRestore, error) {
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon not found: %w", err)
+	}
+
+	if addon.Status != types.DatabaseAddonStatusReady {
+		return nil, fmt.Errorf("addon is not ready for restore (status: %s)", addon.Status)
+	}
+
+	backup, err := s.repos.DatabaseAddons.GetBackup(ctx, backupID)
+	if err != nil {
+		return nil, fmt.Errorf("backup not found: %w", err)
+	}
+	source, err := s.repos.DatabaseAddons.GetByID(ctx, backup.AddonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon of backup not found: %w", err)
+	}
+	if source.Type != addon.Type {
+		return nil, fmt.Errorf("cannot restore a %s backup into a %s addon", source.Type, addon.Type)
+	}
+	if backup.AddonID != addon.ID && !strings.HasPrefix(backup.StoragePath, backupStorageScheme) {
+		return nil, fmt.Errorf("only backups in object storage can be restored into another addon")
+	}
+
+	return s.startRestore(ctx, addon, backup, actorID, actorEmail)
+}
+
+// startRestore starts the job that loads a backup into an addon
+func (s *AddonService) startRestore(ctx context.Context, addon *types.DatabaseAddon, backup *types.DatabaseAddonBackup, actorID *uuid.UUID, actorEmail string) (*types.DatabaseAddonRestore, error) {
+	if backup.Status != types.DatabaseAddonBackupStatusCompleted {
+		return nil, fmt.Errorf("backup is not restorable (status: %s)", backup.Status)
+	}
+	if strings.HasPrefix(backup.StoragePath, backupStorageScheme) && s.backupStorage == nil {
+		return nil, fmt.Errorf("backup storage is not configured")
+	}
+
+	// Backups encrypted with a customer-managed key can only be restored
+	// while the key is usable
+	var sseKey []byte
+	if backup.WrappedDataKey != "" {
+		if backup.EncryptionKeyID == nil || s.backupKeyring == nil {
+			return nil, fmt.Errorf("backup is encrypted with a customer-managed key that is no longer configured")
+		}
+		var err error
+		sseKey, err = s.backupKeyring.OpenBackupKey(ctx, backup.WrappedDataKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap backup encryption key: %w", err)
+		}
+	}
+
+	active, err := s.repos.DatabaseAddons.ListActiveRestores(ctx, &addon.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active restores: %w", err)
+	}
+	if len(active) > 0 {
+		return nil, ErrRestoreInProgress
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"addon_id":  addon.ID,
+		"backup_id": backup.ID,
+	})
+
+	restore := &types.DatabaseAddonRestore{
+		AddonID:          addon.ID,
+		BackupID:         &backup.ID,
+		Phase:            RestorePhaseScheduling,
+		RequestedBy:      actorID,
+		RequestedByEmail: actorEmail,
+	}
+	if err := s.repos.DatabaseAddons.CreateRestore(ctx, restore); err != nil {
+		return nil, fmt.Errorf("failed to create restore record: %w", err)
+	}
+
+	job, err := BuildRestoreJob(addon, backup, restore)
+	if err == nil && s.backupStorage != nil {
+		err = s.backupStorage.ensureSecret(ctx, s, addon.K8sNamespace)
+	}
+	if err == nil {
+		var created *batchv1.Job
+		created, err = s.k8sClient.Clientset.BatchV1().Jobs(addon.K8sNamespace).Create(ctx, job, metav1.CreateOptions{})
+		if err == nil && sseKey != nil {
+			err = s.createSSEKeySecret(ctx, created, sseKey)
+		}
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to start addon restore")
+		s.finishRestore(ctx, addon, restore, types.DatabaseAddonRestoreStatusFailed, err.Error())
+		return nil, fmt.Errorf("failed to start restore: %w", err)
+	}
+
+	now := time.Now()
+	restore.Status = types.DatabaseAddonRestoreStatusInProgress
+	restore.StatusMessage = fmt.Sprintf("Restore job %s started", job.Name)
+	restore.Progress = restorePhaseProgress[RestorePhaseScheduling]
+	restore.StartedAt = &now
+	if err := s.repos.DatabaseAddons.UpdateRestore(ctx, restore); err != nil {
+		return nil, fmt.Errorf("failed to update restore record: %w", err)
+	}
+
+	logger.WithField("job", job.Name).Info("Addon restore started")
+	return restore, nil
+}
+
+// GetRestore returns a restore of an addon, refreshed from its job if still running
+func (s *AddonService) GetRestore(ctx context.Context, addonID, restoreID uuid.UUID) (*types.DatabaseAddonRestore, error) {
+	restore, err := s.repos.DatabaseAddons.GetRestore(ctx, restoreID)
+	if err != nil || restore.AddonID != addonID {
+		return nil, fmt.Errorf("restore not found for addon")
+	}
+
+	if restore.Status == types.DatabaseAddonRestoreStatusInProgress {
+		addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+		if err != nil {
+			return nil, fmt.Errorf("addon not found: %w", err)
+		}
+		if err := s.syncRestoreStatus(ctx, addon, restore); err != nil {
+			s.logger.WithError(err).WithField("restore_id", restore.ID).Warn("Failed to sync restore status")
+		}
+	}
+
+	return restore, nil
+}
+
+// ListRestores returns the restore history of an addon
+func (s *AddonService) ListRestores(ctx context.Context, addonID uuid.UUID, limit int) ([]*types.DatabaseAddonRestore, error) {
+	return s.repos.DatabaseAddons.GetRestoresByAddon(ctx, addonID, limit)
+}
+
+// SyncRestores refreshes all active restores from their jobs
+func (s *AddonService) SyncRestores(ctx context.Context) {
+	restores, err := s.repos.DatabaseAddons.ListActiveRestores(ctx, nil)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list active restores")
+		return
+	}
+
+	for _, restore := range restores {
+		addon, err := s.repos.DatabaseAddons.GetByID(ctx, restore.AddonID)
+		if err != nil {
+			s.logger.WithError(err).WithField("restore_id", restore.ID).Warn("Failed to get addon for restore")
+			continue
+		}
+		if err := s.syncRestoreStatus(ctx, addon, restore); err != nil {
+			s.logger.WithError(err).WithField("restore_id", restore.ID).Warn("Failed to sync restore status")
+		}
+	}
+}
+
+// syncRestoreStatus updates a restore's phase and progress from its job or,
+// for point-in-time restores, from its recovery cluster
+func (s *AddonService) syncRestoreStatus(ctx context.Context, addon *types.DatabaseAddon, restore *types.DatabaseAddonRestore) error {
+	if restore.TargetTime != nil {
+		return s.syncPointInTimeRestore(ctx, addon, restore)
+	}
+
+	jobName := RestoreJobName(addon, restore.ID)
+	job, err := s.k8sClient.Clientset.BatchV1().Jobs(addon.K8sNamespace).Get(ctx, jobName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			s.finishRestore(ctx, addon, restore, types.DatabaseAddonRestoreStatusFailed, "Restore job not found")
+			return nil
+		}
+		return err
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		s.finishRestore(ctx, addon, restore, types.DatabaseAddonRestoreStatusCompleted, "Restore completed")
+		return nil
+	case job.Status.Failed > backupJobBackoffLimit:
+		s.finishRestore(ctx, addon, restore, types.DatabaseAddonRestoreStatusFailed, fmt.Sprintf("Restore job %s failed", jobName))
+		return nil
+	}
+
+	pods, err := s.k8sClient.Clientset.CoreV1().Pods(addon.K8sNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil {
+		return err
+	}
+
+	phase := RestorePhaseScheduling
+	for i := range pods.Items {
+		if p := restorePodPhase(&pods.Items[i]); restorePhaseProgress[p] > restorePhaseProgress[phase] {
+			phase = p
+		}
+	}
+
+	if phase == restore.Phase {
+		return nil
+	}
+	restore.Phase = phase
+	restore.Progress = restorePhaseProgress[phase]
+	return s.repos.DatabaseAddons.UpdateRestore(ctx, restore)
+}
+
+// restorePodPhase maps the container states of a restore pod to a restore phase
+func restorePodPhase(pod *corev1.Pod) string {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == "restore" && (cs.State.Running != nil || cs.State.Terminated != nil) {
+			return RestorePhaseRestoring
+		}
+	}
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if cs.Name == "download" && (cs.State.Running != nil || cs.State.Terminated != nil) {
+			return RestorePhaseDownloading
+		}
+	}
+	return RestorePhaseScheduling
+}
+
+// finishRestore records the outcome of a restore and writes it to the audit log
+func (s *AddonService) finishRestore(ctx context.Context, addon *types.DatabaseAddon, restore *types.DatabaseAddonRestore, status types.DatabaseAddonRestoreStatus, message string) {
+	now := time.Now()
+	restore.Status = status
+	restore.StatusMessage = message
+	restore.CompletedAt = &now
+	if status == types.DatabaseAddonRestoreStatusCompleted {
+		restore.Phase = RestorePhaseDone
+		restore.Progress = restorePhaseProgress[RestorePhaseDone]
+	}
+
+	if err := s.repos.DatabaseAddons.UpdateRestore(ctx, restore); err != nil {
+		s.logger.WithError(err).WithField("restore_id", restore.ID).Error("Failed to update restore record")
+	}
+
+	outcome := "success"
+	action := "addon.restore_completed"
+	if status == types.DatabaseAddonRestoreStatusFailed {
+		outcome = "failure"
+		action = "addon.restore_failed"
+	}
+
+	auditContext := map[string]interface{}{
+		"restore_id": restore.ID.String(),
+		"addon_type": addon.Type,
+		"message":    message,
+	}
+	if restore.BackupID != nil {
+		auditContext["backup_id"] = restore.BackupID.String()
+	}
+	if restore.TargetTime != nil {
+		auditContext["target_time"] = restore.TargetTime.UTC().Format(time.RFC3339)
+	}
+
+	if err := s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      restore.RequestedBy,
+		ActorEmail:   restore.RequestedByEmail,
+		ActorRole:    types.RoleSystem,
+		Action:       action,
+		ResourceType: "addon",
+		ResourceID:   addon.ID.String(),
+		ResourceName: addon.Name,
+		ProjectID:    &addon.ProjectID,
+		Outcome:      outcome,
+		Context:      auditContext,
+	}); err != nil {
+		s.logger.WithError(err).WithField("restore_id", restore.ID).Warn("Failed to write restore audit log")
+	}
+}
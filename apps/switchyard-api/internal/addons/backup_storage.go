@@ -0,0 +1,148 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Backup storage constants
+const (
+	// backupStorageSecretName holds the object storage credentials that
+	// backup and restore jobs read in each project namespace
+	backupStorageSecretName = "enclii-addon-backup-storage"
+	backupStoragePrefix     = "addon-backups"
+	backupStorageScheme     = "s3://"
+	backupVolumeScheme      = "pvc://"
+	backupToolsImage        = "amazon/aws-cli:2.17.0"
+)
+
+// BackupStorageConfig configures the S3-compatible bucket addon dumps are uploaded to
+type BackupStorageConfig struct {
+	Endpoint        string // Custom endpoint for S3-compatible providers (R2, MinIO); empty for AWS
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// BackupStorage uploads and prunes addon dumps in S3-compatible storage
+type BackupStorage struct {
+	client *s3.Client
+	config *BackupStorageConfig
+}
+
+// NewBackupStorage creates a backup storage client
+func NewBackupStorage(ctx context.Context, cfg *BackupStorageConfig) (*BackupStorage, error) {
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("backup storage configuration incomplete: bucket, access key ID, and secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "auto"
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")),
+		config.WithRegion(cfg.Region),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &BackupStorage{client: client, config: cfg}, nil
+}
+
+// objectKey returns the bucket key for a dump file of an addon
+func (b *BackupStorage) objectKey(projectID, addonID, fileName string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", backupStoragePrefix, projectID, addonID, fileName)
+}
+
+// storagePath returns the s3:// path recorded on a backup
+func (b *BackupStorage) storagePath(key string) string {
+	return fmt.Sprintf("%s%s/%s", backupStorageScheme, b.config.Bucket, key)
+}
+
+// Delete removes the object behind an s3:// storage path
+func (b *BackupStorage) Delete(ctx context.Context, storagePath string) error {
+	bucket, key, ok := parseStoragePath(storagePath)
+	if !ok {
+		return fmt.Errorf("not an object storage path: %s", storagePath)
+	}
+
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete backup object: %w", err)
+	}
+	return nil
+}
+
+// ensureSecret copies the storage credentials into a namespace so jobs can use them
+func (b *BackupStorage) ensureSecret(ctx context.Context, s *AddonService, namespace string) error {
+	data := map[string][]byte{
+		"AWS_ACCESS_KEY_ID":     []byte(b.config.AccessKeyID),
+		"AWS_SECRET_ACCESS_KEY": []byte(b.config.SecretAccessKey),
+		"AWS_DEFAULT_REGION":    []byte(b.config.Region),
+		"S3_BUCKET":             []byte(b.config.Bucket),
+		"S3_ENDPOINT":           []byte(b.config.Endpoint),
+	}
+
+	secrets := s.k8sClient.Clientset.CoreV1().Secrets(namespace)
+	existing, err := secrets.Get(ctx, backupStorageSecretName, metav1.GetOptions{})
+	if err == nil {
+		existing.Data = data
+		if _, err := secrets.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update backup storage secret: %w", err)
+		}
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get backup storage secret: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupStorageSecretName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				LabelManagedBy:    LabelManagedValue,
+				"enclii.dev/type": "addon-backup",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create backup storage secret: %w", err)
+	}
+	return nil
+}
+
+// parseStoragePath splits an s3://bucket/key path
+func parseStoragePath(storagePath string) (bucket, key string, ok bool) {
+	if !strings.HasPrefix(storagePath, backupStorageScheme) {
+		return "", "", false
+	}
+	bucket, key, ok = strings.Cut(strings.TrimPrefix(storagePath, backupStorageScheme), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", false
+	}
+	return bucket, key, true
+}
@@ -0,0 +1,795 @@
+package addons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Bucket constants
+const (
+	BucketProviderMinIO = "minio" // Per-addon MinIO server in the project namespace
+	BucketProviderS3    = "s3"    // Bucket in the platform's cloud account
+
+	DefaultMinIOPort           = 9000
+	DefaultBucketBindingPrefix = "S3"
+
+	minioImage        = "minio/minio:RELEASE.2025-04-22T22-12-26Z"
+	minioClientImage  = "minio/mc:RELEASE.2025-04-16T18-13-26Z"
+	minioRegion       = "us-east-1"
+	bucketSetupSuffix = "-setup"
+)
+
+// Connection secret keys of a bucket addon
+const (
+	bucketSecretEndpoint        = "endpoint"
+	bucketSecretBucket          = "bucket"
+	bucketSecretRegion          = "region"
+	bucketSecretAccessKeyID     = "access_key_id"
+	bucketSecretSecretAccessKey = "secret_access_key"
+	bucketSecretProvider        = "provider"
+	bucketSecretIAMUser         = "iam_user" // S3 provider only
+)
+
+// BucketEnvVar is an env var injected into services bound to a bucket
+type BucketEnvVar struct {
+	Name      string
+	SecretKey string
+}
+
+// BucketEnvVars returns the env vars of a bucket binding. The binding's env
+// var name is used as a prefix, so "S3" yields S3_ENDPOINT, S3_BUCKET, etc.
+func BucketEnvVars(prefix string) []BucketEnvVar {
+	return []BucketEnvVar{
+		{Name: prefix + "_ENDPOINT", SecretKey: bucketSecretEndpoint},
+		{Name: prefix + "_BUCKET", SecretKey: bucketSecretBucket},
+		{Name: prefix + "_REGION", SecretKey: bucketSecretRegion},
+		{Name: prefix + "_ACCESS_KEY_ID", SecretKey: bucketSecretAccessKeyID},
+		{Name: prefix + "_SECRET_ACCESS_KEY", SecretKey: bucketSecretSecretAccessKey},
+	}
+}
+
+// BucketProvisioner implements AddonProvisioner for S3-compatible buckets.
+// Each bucket gets its own access key that is limited to that bucket.
+type BucketProvisioner struct {
+	k8sClient *k8s.Client
+	logger    *logrus.Logger
+
+	// cloud provisions "s3" buckets; only MinIO buckets are available when nil
+	cloud *CloudBuckets
+}
+
+// NewBucketProvisioner creates a new bucket provisioner
+func NewBucketProvisioner(k8sClient *k8s.Client, logger *logrus.Logger) *BucketProvisioner {
+	return &BucketProvisioner{
+		k8sClient: k8sClient,
+		logger:    logger,
+	}
+}
+
+// SetCloud enables buckets in the platform's cloud account
+func (p *BucketProvisioner) SetCloud(cloud *CloudBuckets) {
+	p.cloud = cloud
+}
+
+// DefaultProvider returns the provider used when an addon does not choose one
+func (p *BucketProvisioner) DefaultProvider() string {
+	if p.cloud != nil {
+		return BucketProviderS3
+	}
+	return BucketProviderMinIO
+}
+
+// ValidateProvider checks that a bucket provider is available on this platform
+func (p *BucketProvisioner) ValidateProvider(provider string) error {
+	switch provider {
+	case BucketProviderMinIO:
+		return nil
+	case BucketProviderS3:
+		if p.cloud == nil {
+			return fmt.Errorf("bucket provider %q is not configured on this platform", provider)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported bucket_provider: %s", provider)
+	}
+}
+
+// bucketName returns the bucket name of an addon. Cloud bucket names are
+// global, so the full addon ID is used.
+func bucketName(addon *types.DatabaseAddon) string {
+	return fmt.Sprintf("enclii-%s", addon.ID)
+}
+
+// Provision creates the bucket and its scoped access key
+func (p *BucketProvisioner) Provision(ctx context.Context, req *ProvisionRequest) (*ProvisionResult, error) {
+	if err := p.ValidateProvider(req.Addon.Config.BucketProvider); err != nil {
+		return nil, err
+	}
+
+	if err := p.k8sClient.EnsureNamespace(ctx, req.Namespace); err != nil {
+		return nil, fmt.Errorf("failed to ensure namespace: %w", err)
+	}
+
+	if req.Addon.Config.BucketProvider == BucketProviderS3 {
+		return p.provisionCloud(ctx, req)
+	}
+	return p.provisionMinIO(ctx, req)
+}
+
+// bucketLabels returns the labels of a bucket addon's resources
+func bucketLabels(resourceName string, req *ProvisionRequest) map[string]string {
+	return map[string]string{
+		"app":             resourceName,
+		LabelManagedBy:    LabelManagedValue,
+		LabelAddonID:      req.Addon.ID.String(),
+		LabelProjectID:    req.ProjectID.String(),
+		LabelAddonType:    string(types.DatabaseAddonTypeBucket),
+		"enclii.dev/type": "addon",
+		"enclii.dev/kind": "bucket",
+	}
+}
+
+// provisionMinIO runs a MinIO server for the bucket and a Job that creates
+// the bucket, its policy, and the scoped user once the server is up
+func (p *BucketProvisioner) provisionMinIO(ctx context.Context, req *ProvisionRequest) (*ProvisionResult, error) {
+	addon := req.Addon
+	namespace := req.Namespace
+
+	resourceName := fmt.Sprintf("minio-%s", addon.ID.String()[:8])
+	rootSecretName := fmt.Sprintf("%s-root", resourceName)
+	secretName := fmt.Sprintf("%s-credentials", resourceName)
+	bucket := bucketName(addon)
+
+	logger := p.logger.WithFields(logrus.Fields{
+		"addon_id":  addon.ID,
+		"namespace": namespace,
+		"resource":  resourceName,
+	})
+
+	logger.Info("Provisioning MinIO bucket")
+
+	memory := addon.Config.Memory
+	if memory == "" {
+		memory = "512Mi"
+	}
+	cpu := addon.Config.CPU
+	if cpu == "" {
+		cpu = "100m"
+	}
+	storageSize := fmt.Sprintf("%dGi", addon.Config.StorageGB)
+	if addon.Config.StorageGB == 0 {
+		storageSize = DefaultStorageSize
+	}
+
+	rootPassword, err := generateSecurePassword(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate MinIO root password: %w", err)
+	}
+	accessKeyID, err := generateSecurePassword(20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access key: %w", err)
+	}
+	secretAccessKey, err := generateSecurePassword(40)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %w", err)
+	}
+
+	labels := bucketLabels(resourceName, req)
+	endpoint := fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", resourceName, namespace, DefaultMinIOPort)
+
+	// The root user stays out of the connection secret that services bind to
+	rootSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rootSecretName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"MINIO_ROOT_USER":     []byte("enclii-root"),
+			"MINIO_ROOT_PASSWORD": []byte(rootPassword),
+		},
+	}
+	_, err = p.k8sClient.Clientset.CoreV1().Secrets(namespace).Create(ctx, rootSecret, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create MinIO root secret: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			bucketSecretEndpoint:        []byte(endpoint),
+			bucketSecretBucket:          []byte(bucket),
+			bucketSecretRegion:          []byte(minioRegion),
+			bucketSecretAccessKeyID:     []byte(accessKeyID),
+			bucketSecretSecretAccessKey: []byte(secretAccessKey),
+			bucketSecretProvider:        []byte(BucketProviderMinIO),
+		},
+	}
+	_, err = p.k8sClient.Clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create bucket secret: %w", err)
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "s3",
+					Port:       DefaultMinIOPort,
+					TargetPort: intstr.FromInt(DefaultMinIOPort),
+				},
+			},
+			ClusterIP: "None", // Headless service for StatefulSet
+			Selector: map[string]string{
+				"app": resourceName,
+			},
+		},
+	}
+	_, err = p.k8sClient.Clientset.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create MinIO service: %w", err)
+	}
+
+	statefulSet := minioStatefulSet(resourceName, namespace, rootSecretName, labels, cpu, memory, storageSize)
+	_, err = p.k8sClient.Clientset.AppsV1().StatefulSets(namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create MinIO StatefulSet: %w", err)
+	}
+
+	job, err := minioSetupJob(resourceName, namespace, rootSecretName, secretName, bucket, labels)
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.k8sClient.Clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create bucket setup job: %w", err)
+	}
+
+	logger.WithField("bucket", bucket).Info("MinIO bucket creation initiated")
+
+	return &ProvisionResult{
+		K8sResourceName:  resourceName,
+		ConnectionSecret: secretName,
+		Message:          "MinIO bucket creation initiated",
+	}, nil
+}
+
+// minioStatefulSet builds the single-node MinIO server of a bucket addon
+func minioStatefulSet(resourceName, namespace, rootSecretName string, labels map[string]string, cpu, memory, storageSize string) *appsv1.StatefulSet {
+	replicas := int32(1)
+	healthProbe := func(path string, initialDelay int32) *corev1.Probe {
+		return &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: path,
+					Port: intstr.FromInt(DefaultMinIOPort),
+				},
+			},
+			InitialDelaySeconds: initialDelay,
+			PeriodSeconds:       10,
+		}
+	}
+
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: resourceName,
+			Replicas:    &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": resourceName,
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "data",
+					},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{
+							corev1.ReadWriteOnce,
+						},
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse(storageSize),
+							},
+						},
+					},
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "minio",
+							Image: minioImage,
+							Args:  []string{"server", "/data", "--address", fmt.Sprintf(":%d", DefaultMinIOPort)},
+							Env: []corev1.EnvVar{
+								secretEnvVar("MINIO_ROOT_USER", rootSecretName, "MINIO_ROOT_USER"),
+								secretEnvVar("MINIO_ROOT_PASSWORD", rootSecretName, "MINIO_ROOT_PASSWORD"),
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "s3",
+									ContainerPort: DefaultMinIOPort,
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/data",
+								},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse(memory),
+									corev1.ResourceCPU:    resource.MustParse(cpu),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse(memory),
+								},
+							},
+							ReadinessProbe: healthProbe("/minio/health/ready", 5),
+							LivenessProbe:  healthProbe("/minio/health/live", 30),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// minioSetupScript creates the bucket and a user that can only reach it. Every
+// step is idempotent so the Job can retry until the server accepts requests.
+const minioSetupScript = `set -e
+until mc alias set enclii "$S3_ENDPOINT" "$MINIO_ROOT_USER" "$MINIO_ROOT_PASSWORD" >/dev/null; do sleep 5; done
+mc mb --ignore-existing "enclii/$S3_BUCKET"
+printf '%s' "$BUCKET_POLICY" > /tmp/policy.json
+mc admin policy create enclii "$S3_BUCKET" /tmp/policy.json
+mc admin user add enclii "$S3_ACCESS_KEY_ID" "$S3_SECRET_ACCESS_KEY"
+mc admin policy attach enclii "$S3_BUCKET" --user "$S3_ACCESS_KEY_ID" || mc admin user info enclii "$S3_ACCESS_KEY_ID" | grep -q "$S3_BUCKET"`
+
+// minioSetupJob builds the Job that runs minioSetupScript against a new MinIO server
+func minioSetupJob(resourceName, namespace, rootSecretName, secretName, bucket string, labels map[string]string) (*batchv1.Job, error) {
+	policy, err := bucketPolicy(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	backoffLimit := int32(10)
+	env := []corev1.EnvVar{
+		secretEnvVar("MINIO_ROOT_USER", rootSecretName, "MINIO_ROOT_USER"),
+		secretEnvVar("MINIO_ROOT_PASSWORD", rootSecretName, "MINIO_ROOT_PASSWORD"),
+		{Name: "BUCKET_POLICY", Value: policy},
+		// mc needs a writable config directory; the image runs as a non-root user
+		{Name: "MC_CONFIG_DIR", Value: "/tmp/.mc"},
+	}
+	for _, v := range BucketEnvVars(DefaultBucketBindingPrefix) {
+		env = append(env, secretEnvVar(v.Name, secretName, v.SecretKey))
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName + bucketSetupSuffix,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						LabelManagedBy:    LabelManagedValue,
+						LabelAddonID:      labels[LabelAddonID],
+						"enclii.dev/type": "addon-setup",
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{
+						{
+							Name:    "setup",
+							Image:   minioClientImage,
+							Command: []string{"sh", "-c", minioSetupScript},
+							Env:     env,
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// bucketPolicyDocument is an IAM policy document, understood by both AWS IAM and MinIO
+type bucketPolicyDocument struct {
+	Version   string                  `json:"Version"`
+	Statement []bucketPolicyStatement `json:"Statement"`
+}
+
+type bucketPolicyStatement struct {
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource []string `json:"Resource"`
+}
+
+// bucketPolicy returns the policy that limits an access key to one bucket
+func bucketPolicy(bucket string) (string, error) {
+	doc := bucketPolicyDocument{
+		Version: "2012-10-17",
+		Statement: []bucketPolicyStatement{
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:GetBucketLocation", "s3:ListBucket", "s3:ListBucketMultipartUploads"},
+				Resource: []string{"arn:aws:s3:::" + bucket},
+			},
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:GetObject", "s3:PutObject", "s3:DeleteObject", "s3:AbortMultipartUpload", "s3:ListMultipartUploadParts"},
+				Resource: []string{"arn:aws:s3:::" + bucket + "/*"},
+			},
+		},
+	}
+	policy, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode bucket policy: %w", err)
+	}
+	return string(policy), nil
+}
+
+// provisionCloud creates the bucket and a scoped IAM user in the platform's
+// cloud account. The connection secret is written last, so a retry after a
+// partial failure creates a fresh access key.
+func (p *BucketProvisioner) provisionCloud(ctx context.Context, req *ProvisionRequest) (*ProvisionResult, error) {
+	addon := req.Addon
+	namespace := req.Namespace
+	bucket := bucketName(addon)
+	secretName := fmt.Sprintf("%s-credentials", bucket)
+
+	logger := p.logger.WithFields(logrus.Fields{
+		"addon_id":  addon.ID,
+		"namespace": namespace,
+		"bucket":    bucket,
+	})
+
+	logger.Info("Provisioning cloud bucket")
+
+	_, err := p.k8sClient.Clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err == nil {
+		return &ProvisionResult{K8sResourceName: bucket, ConnectionSecret: secretName}, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get bucket secret: %w", err)
+	}
+
+	if err := p.cloud.CreateBucket(ctx, bucket); err != nil {
+		return nil, err
+	}
+	policy, err := bucketPolicy(bucket)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.cloud.CreateScopedKey(ctx, bucket, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+			Labels:    bucketLabels(bucket, req),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			bucketSecretEndpoint:        []byte(p.cloud.Endpoint()),
+			bucketSecretBucket:          []byte(bucket),
+			bucketSecretRegion:          []byte(p.cloud.Region()),
+			bucketSecretAccessKeyID:     []byte(key.AccessKeyID),
+			bucketSecretSecretAccessKey: []byte(key.SecretAccessKey),
+			bucketSecretProvider:        []byte(BucketProviderS3),
+			bucketSecretIAMUser:         []byte(key.UserName),
+		},
+	}
+	_, err = p.k8sClient.Clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create bucket secret: %w", err)
+	}
+
+	logger.Info("Cloud bucket created successfully")
+
+	return &ProvisionResult{
+		K8sResourceName:  bucket,
+		ConnectionSecret: secretName,
+		Message:          "Bucket created",
+	}, nil
+}
+
+// Deprovision removes the bucket, its data, and its access key
+func (p *BucketProvisioner) Deprovision(ctx context.Context, addon *types.DatabaseAddon) error {
+	if addon.K8sNamespace == "" || addon.K8sResourceName == "" {
+		return nil // Nothing to deprovision
+	}
+
+	logger := p.logger.WithFields(logrus.Fields{
+		"addon_id":  addon.ID,
+		"namespace": addon.K8sNamespace,
+		"resource":  addon.K8sResourceName,
+	})
+
+	logger.Info("Deprovisioning bucket")
+
+	if addon.Config.BucketProvider == BucketProviderS3 {
+		if err := p.deprovisionCloud(ctx, addon); err != nil {
+			return err
+		}
+	} else {
+		if err := p.deprovisionMinIO(ctx, addon, logger); err != nil {
+			return err
+		}
+	}
+
+	if addon.ConnectionSecret != "" {
+		err := p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Delete(ctx, addon.ConnectionSecret, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete bucket secret: %w", err)
+		}
+	}
+
+	logger.Info("Bucket deprovisioned successfully")
+	return nil
+}
+
+// deprovisionMinIO removes the MinIO server together with its volume
+func (p *BucketProvisioner) deprovisionMinIO(ctx context.Context, addon *types.DatabaseAddon, logger *logrus.Entry) error {
+	namespace := addon.K8sNamespace
+	propagation := metav1.DeletePropagationBackground
+
+	err := p.k8sClient.Clientset.BatchV1().Jobs(namespace).Delete(ctx, addon.K8sResourceName+bucketSetupSuffix,
+		metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete bucket setup job: %w", err)
+	}
+
+	err = p.k8sClient.Clientset.AppsV1().StatefulSets(namespace).Delete(ctx, addon.K8sResourceName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete MinIO StatefulSet: %w", err)
+	}
+
+	err = p.k8sClient.Clientset.CoreV1().Services(namespace).Delete(ctx, addon.K8sResourceName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete MinIO service: %w", err)
+	}
+
+	err = p.k8sClient.Clientset.CoreV1().Secrets(namespace).Delete(ctx, addon.K8sResourceName+"-root", metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete MinIO root secret: %w", err)
+	}
+
+	// Delete PVCs (StatefulSet doesn't delete them automatically)
+	pvcName := fmt.Sprintf("data-%s-0", addon.K8sResourceName)
+	err = p.k8sClient.Clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, pvcName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		logger.WithError(err).Warn("Failed to delete MinIO PVC")
+	}
+	return nil
+}
+
+// deprovisionCloud deletes the scoped IAM user and the bucket with its objects
+func (p *BucketProvisioner) deprovisionCloud(ctx context.Context, addon *types.DatabaseAddon) error {
+	if p.cloud == nil {
+		return fmt.Errorf("bucket provider %q is not configured on this platform", BucketProviderS3)
+	}
+
+	if addon.ConnectionSecret != "" {
+		secret, err := p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Get(ctx, addon.ConnectionSecret, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get bucket secret: %w", err)
+		}
+		if err == nil {
+			key := &ScopedKey{
+				UserName:    string(secret.Data[bucketSecretIAMUser]),
+				AccessKeyID: string(secret.Data[bucketSecretAccessKeyID]),
+			}
+			if err := p.cloud.DeleteScopedKey(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return p.cloud.DeleteBucket(ctx, addon.K8sResourceName)
+}
+
+// GetStatus returns the current status of a bucket. MinIO buckets are ready
+// once the server is up and the setup Job has created the scoped user.
+func (p *BucketProvisioner) GetStatus(ctx context.Context, addon *types.DatabaseAddon) (*StatusResult, error) {
+	if addon.K8sNamespace == "" || addon.K8sResourceName == "" {
+		return &StatusResult{
+			Status:        types.DatabaseAddonStatusPending,
+			StatusMessage: "Waiting for K8s resource creation",
+		}, nil
+	}
+
+	if addon.Config.BucketProvider == BucketProviderS3 {
+		return p.cloudStatus(ctx, addon)
+	}
+
+	statefulSet, err := p.k8sClient.Clientset.AppsV1().StatefulSets(addon.K8sNamespace).Get(ctx, addon.K8sResourceName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return &StatusResult{
+				Status:        types.DatabaseAddonStatusDeleted,
+				StatusMessage: "MinIO StatefulSet not found",
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to get MinIO StatefulSet: %w", err)
+	}
+
+	result := &StatusResult{
+		Host:         fmt.Sprintf("%s.%s.svc.cluster.local", addon.K8sResourceName, addon.K8sNamespace),
+		Port:         DefaultMinIOPort,
+		DatabaseName: bucketName(addon),
+	}
+
+	if statefulSet.Status.ReadyReplicas == 0 {
+		result.Status = types.DatabaseAddonStatusProvisioning
+		result.StatusMessage = "MinIO server starting"
+		return result, nil
+	}
+
+	job, err := p.k8sClient.Clientset.BatchV1().Jobs(addon.K8sNamespace).Get(ctx, addon.K8sResourceName+bucketSetupSuffix, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket setup job: %w", err)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		result.Status = types.DatabaseAddonStatusReady
+		result.StatusMessage = "Bucket ready"
+		result.Ready = true
+	case jobFailed(job):
+		result.Status = types.DatabaseAddonStatusFailed
+		result.StatusMessage = "Bucket setup failed"
+	default:
+		result.Status = types.DatabaseAddonStatusProvisioning
+		result.StatusMessage = "Creating bucket and access key"
+	}
+
+	return result, nil
+}
+
+// cloudStatus reports a cloud bucket as ready once its connection secret exists
+func (p *BucketProvisioner) cloudStatus(ctx context.Context, addon *types.DatabaseAddon) (*StatusResult, error) {
+	secret, err := p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Get(ctx, addon.ConnectionSecret, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return &StatusResult{
+				Status:        types.DatabaseAddonStatusDeleted,
+				StatusMessage: "Bucket credentials not found",
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to get bucket secret: %w", err)
+	}
+
+	return &StatusResult{
+		Status:        types.DatabaseAddonStatusReady,
+		StatusMessage: "Bucket ready",
+		Host:          string(secret.Data[bucketSecretEndpoint]),
+		Port:          443,
+		DatabaseName:  string(secret.Data[bucketSecretBucket]),
+		Username:      string(secret.Data[bucketSecretAccessKeyID]),
+		Ready:         true,
+	}, nil
+}
+
+// jobFailed reports whether a Job has given up retrying
+func jobFailed(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCredentials returns the bucket's endpoint and scoped access key. The
+// access key ID is reported as the username and the secret key as the password.
+func (p *BucketProvisioner) GetCredentials(ctx context.Context, addon *types.DatabaseAddon) (*types.DatabaseAddonCredentials, error) {
+	if !addon.IsAvailable() {
+		return nil, fmt.Errorf("addon is not ready")
+	}
+
+	if addon.ConnectionSecret == "" || addon.K8sNamespace == "" {
+		return nil, fmt.Errorf("addon does not have connection secret configured")
+	}
+
+	secret, err := p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Get(ctx, addon.ConnectionSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection secret: %w", err)
+	}
+
+	return bucketCredentials(secret.Data), nil
+}
+
+// bucketCredentials maps a bucket connection secret onto addon credentials
+func bucketCredentials(data map[string][]byte) *types.DatabaseAddonCredentials {
+	endpoint := string(data[bucketSecretEndpoint])
+	bucket := string(data[bucketSecretBucket])
+
+	host, port := endpoint, 443
+	if u, err := parseEndpoint(endpoint); err == nil {
+		host = u.Hostname()
+		if p, err := strconv.Atoi(u.Port()); err == nil {
+			port = p
+		} else if u.Scheme == "http" {
+			port = 80
+		}
+	}
+
+	return &types.DatabaseAddonCredentials{
+		Host:          host,
+		Port:          port,
+		DatabaseName:  bucket,
+		Username:      string(data[bucketSecretAccessKeyID]),
+		Password:      string(data[bucketSecretSecretAccessKey]),
+		ConnectionURI: fmt.Sprintf("s3://%s", bucket),
+	}
+}
+
+// GetConnectionURI returns the s3:// URI of the bucket
+func (p *BucketProvisioner) GetConnectionURI(ctx context.Context, addon *types.DatabaseAddon) (string, error) {
+	creds, err := p.GetCredentials(ctx, addon)
+	if err != nil {
+		return "", err
+	}
+	return creds.ConnectionURI, nil
+}
+
+// GetEnvVars returns the values of a bucket binding's env vars
+func (p *BucketProvisioner) GetEnvVars(ctx context.Context, addon *types.DatabaseAddon, prefix string) (map[string]string, error) {
+	if !addon.IsAvailable() {
+		return nil, fmt.Errorf("addon is not ready")
+	}
+
+	secret, err := p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Get(ctx, addon.ConnectionSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection secret: %w", err)
+	}
+
+	envVars := make(map[string]string)
+	for _, v := range BucketEnvVars(prefix) {
+		envVars[v.Name] = string(secret.Data[v.SecretKey])
+	}
+	return envVars, nil
+}
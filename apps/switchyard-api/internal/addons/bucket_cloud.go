@@ -0,0 +1,343 @@
+package addons
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// iamAPIVersion is the version of the IAM query API
+const iamAPIVersion = "2010-05-08"
+
+// CloudBucketConfig configures the cloud account that "s3" bucket addons are
+// created in. The credentials need s3:CreateBucket/DeleteBucket and the IAM
+// permissions to manage users, inline policies, and access keys.
+type CloudBucketConfig struct {
+	Endpoint        string // Custom S3 endpoint for S3-compatible providers; empty for AWS
+	IAMEndpoint     string // Custom IAM endpoint; empty for AWS IAM
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// CloudBuckets creates buckets and scoped IAM users in a cloud account
+type CloudBuckets struct {
+	client      *s3.Client
+	config      *CloudBucketConfig
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// ScopedKey is an access key limited to a single bucket
+type ScopedKey struct {
+	UserName        string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// NewCloudBuckets creates a cloud bucket client
+func NewCloudBuckets(ctx context.Context, cfg *CloudBucketConfig) (*CloudBuckets, error) {
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("cloud bucket configuration incomplete: access key ID and secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	client, err := newS3Client(ctx, cfg.Endpoint, cfg.Region, cfg.AccessKeyID, cfg.SecretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CloudBuckets{
+		client:      client,
+		config:      cfg,
+		credentials: credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		signer:      v4.NewSigner(),
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// newS3Client creates an S3 client. Custom endpoints use path-style addressing,
+// which MinIO and most S3-compatible providers require.
+func newS3Client(ctx context.Context, endpoint, region, accessKeyID, secretAccessKey string) (*s3.Client, error) {
+	awsCfg, err := config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")),
+		config.WithRegion(region),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint != "" && !isAWSS3Endpoint(endpoint) {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	}), nil
+}
+
+// isAWSS3Endpoint reports whether an endpoint is AWS S3's own regional endpoint
+func isAWSS3Endpoint(endpoint string) bool {
+	u, err := parseEndpoint(endpoint)
+	return err == nil && strings.HasSuffix(u.Hostname(), ".amazonaws.com")
+}
+
+// parseEndpoint parses an http(s) endpoint URL
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint: %s", endpoint)
+	}
+	return u, nil
+}
+
+// Endpoint returns the S3 endpoint that services use to reach cloud buckets
+func (c *CloudBuckets) Endpoint() string {
+	if c.config.Endpoint != "" {
+		return c.config.Endpoint
+	}
+	return fmt.Sprintf("https://s3.%s.amazonaws.com", c.config.Region)
+}
+
+// Region returns the region cloud buckets are created in
+func (c *CloudBuckets) Region() string {
+	return c.config.Region
+}
+
+// CreateBucket creates a private bucket, succeeding if it already exists
+func (c *CloudBuckets) CreateBucket(ctx context.Context, bucket string) error {
+	input := &s3.CreateBucketInput{Bucket: aws.String(bucket)}
+	// us-east-1 is the default location and must not be sent explicitly
+	if c.config.Region != "us-east-1" && c.config.Region != "auto" {
+		input.CreateBucketConfiguration = &s3types.CreateBucketConfiguration{
+			LocationConstraint: s3types.BucketLocationConstraint(c.config.Region),
+		}
+	}
+
+	_, err := c.client.CreateBucket(ctx, input)
+	var owned *s3types.BucketAlreadyOwnedByYou
+	if err != nil && !errors.As(err, &owned) {
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+	return nil
+}
+
+// DeleteBucket deletes a bucket and every object in it
+func (c *CloudBuckets) DeleteBucket(ctx context.Context, bucket string) error {
+	paginator := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{Bucket: aws.String(bucket)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			var missing *s3types.NoSuchBucket
+			if errors.As(err, &missing) {
+				return nil
+			}
+			return fmt.Errorf("failed to list bucket objects: %w", err)
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		objects := make([]s3types.ObjectIdentifier, 0, len(page.Contents))
+		for _, obj := range page.Contents {
+			objects = append(objects, s3types.ObjectIdentifier{Key: obj.Key})
+		}
+		_, err = c.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete bucket objects: %w", err)
+		}
+	}
+
+	_, err := c.client.DeleteBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(bucket)})
+	var missing *s3types.NoSuchBucket
+	if err != nil && !errors.As(err, &missing) {
+		return fmt.Errorf("failed to delete bucket: %w", err)
+	}
+	return nil
+}
+
+// CreateScopedKey creates an IAM user for a bucket, attaches the bucket
+// policy inline, and returns a new access key for it
+func (c *CloudBuckets) CreateScopedKey(ctx context.Context, bucket, policy string) (*ScopedKey, error) {
+	userName := bucket
+
+	err := c.callIAM(ctx, "CreateUser", url.Values{"UserName": {userName}}, nil)
+	if err != nil && !isIAMError(err, "EntityAlreadyExists") {
+		return nil, err
+	}
+
+	err = c.callIAM(ctx, "PutUserPolicy", url.Values{
+		"UserName":       {userName},
+		"PolicyName":     {"bucket-access"},
+		"PolicyDocument": {policy},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		AccessKey struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+		} `xml:"CreateAccessKeyResult>AccessKey"`
+	}
+	if err := c.callIAM(ctx, "CreateAccessKey", url.Values{"UserName": {userName}}, &out); err != nil {
+		return nil, err
+	}
+
+	return &ScopedKey{
+		UserName:        userName,
+		AccessKeyID:     out.AccessKey.AccessKeyID,
+		SecretAccessKey: out.AccessKey.SecretAccessKey,
+	}, nil
+}
+
+// DeleteScopedKey removes a bucket's access key, inline policy, and IAM user
+func (c *CloudBuckets) DeleteScopedKey(ctx context.Context, key *ScopedKey) error {
+	if key.UserName == "" {
+		return nil
+	}
+
+	calls := []struct {
+		action string
+		params url.Values
+	}{
+		{"DeleteAccessKey", url.Values{"UserName": {key.UserName}, "AccessKeyId": {key.AccessKeyID}}},
+		{"DeleteUserPolicy", url.Values{"UserName": {key.UserName}, "PolicyName": {"bucket-access"}}},
+		{"DeleteUser", url.Values{"UserName": {key.UserName}}},
+	}
+	for _, call := range calls {
+		if call.action == "DeleteAccessKey" && key.AccessKeyID == "" {
+			continue
+		}
+		if err := c.callIAM(ctx, call.action, call.params, nil); err != nil && !isIAMError(err, "NoSuchEntity") {
+			return err
+		}
+	}
+	return nil
+}
+
+// iamError is an error response of the IAM query API
+type iamError struct {
+	Action  string
+	Status  int
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+func (e *iamError) Error() string {
+	return fmt.Sprintf("IAM %s failed (%d %s): %s", e.Action, e.Status, e.Code, e.Message)
+}
+
+// isIAMError reports whether err is an IAM error with the given code
+func isIAMError(err error, code string) bool {
+	var apiErr *iamError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// callIAM invokes an IAM query API action signed with SigV4. IAM is a global
+// service, so requests are always signed for us-east-1.
+func (c *CloudBuckets) callIAM(ctx context.Context, action string, params url.Values, out interface{}) error {
+	endpoint := c.config.IAMEndpoint
+	if endpoint == "" {
+		endpoint = "https://iam.amazonaws.com"
+	}
+
+	params.Set("Action", action)
+	params.Set("Version", iamAPIVersion)
+	body := params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create IAM %s request: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256([]byte(body))
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "iam", "us-east-1", time.Now()); err != nil {
+		return fmt.Errorf("failed to sign IAM %s request: %w", action, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("IAM %s request failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read IAM %s response: %w", action, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &iamError{Action: action, Status: resp.StatusCode}
+		_ = xml.Unmarshal(respBody, apiErr)
+		return apiErr
+	}
+
+	if out != nil {
+		if err := xml.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to decode IAM %s response: %w", action, err)
+		}
+	}
+	return nil
+}
+
+// MeasureBucket returns the total size of the objects in a bucket, using the
+// bucket's own connection secret
+func MeasureBucket(ctx context.Context, secret map[string][]byte) (int64, error) {
+	bucket := string(secret[bucketSecretBucket])
+	if bucket == "" {
+		return 0, fmt.Errorf("bucket secret has no bucket name")
+	}
+
+	client, err := newS3Client(ctx,
+		string(secret[bucketSecretEndpoint]),
+		string(secret[bucketSecretRegion]),
+		string(secret[bucketSecretAccessKeyID]),
+		string(secret[bucketSecretSecretAccessKey]),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{Bucket: aws.String(bucket)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list bucket objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			total += aws.ToInt64(obj.Size)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,265 @@
+package addons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBucketPolicy(t *testing.T) {
+	policy, err := bucketPolicy("enclii-test")
+	if err != nil {
+		t.Fatalf("bucketPolicy() error = %v", err)
+	}
+
+	var doc bucketPolicyDocument
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		t.Fatalf("policy is not valid JSON: %v", err)
+	}
+	if len(doc.Statement) != 2 {
+		t.Fatalf("got %d statements, want 2", len(doc.Statement))
+	}
+	for _, stmt := range doc.Statement {
+		for _, res := range stmt.Resource {
+			if res != "arn:aws:s3:::enclii-test" && res != "arn:aws:s3:::enclii-test/*" {
+				t.Errorf("policy grants access beyond the bucket: %s", res)
+			}
+		}
+	}
+}
+
+func TestBucketProvider(t *testing.T) {
+	minioOnly := NewBucketProvisioner(nil, nil)
+	withCloud := NewBucketProvisioner(nil, nil)
+	withCloud.SetCloud(&CloudBuckets{config: &CloudBucketConfig{Region: "us-east-1"}})
+
+	if got := minioOnly.DefaultProvider(); got != BucketProviderMinIO {
+		t.Errorf("DefaultProvider() without cloud = %q", got)
+	}
+	if got := withCloud.DefaultProvider(); got != BucketProviderS3 {
+		t.Errorf("DefaultProvider() with cloud = %q", got)
+	}
+
+	tests := []struct {
+		name        string
+		provisioner *BucketProvisioner
+		provider    string
+		wantErr     bool
+	}{
+		{name: "minio", provisioner: minioOnly, provider: BucketProviderMinIO},
+		{name: "s3 not configured", provisioner: minioOnly, provider: BucketProviderS3, wantErr: true},
+		{name: "s3 configured", provisioner: withCloud, provider: BucketProviderS3},
+		{name: "unknown", provisioner: withCloud, provider: "gcs", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.provisioner.ValidateProvider(tt.provider)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBucketCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		wantHost string
+		wantPort int
+	}{
+		{name: "minio", endpoint: "http://minio-1234abcd.project-1.svc.cluster.local:9000", wantHost: "minio-1234abcd.project-1.svc.cluster.local", wantPort: 9000},
+		{name: "aws", endpoint: "https://s3.eu-west-1.amazonaws.com", wantHost: "s3.eu-west-1.amazonaws.com", wantPort: 443},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds := bucketCredentials(map[string][]byte{
+				bucketSecretEndpoint:        []byte(tt.endpoint),
+				bucketSecretBucket:          []byte("enclii-test"),
+				bucketSecretAccessKeyID:     []byte("AKID"),
+				bucketSecretSecretAccessKey: []byte("secret"),
+			})
+			if creds.Host != tt.wantHost || creds.Port != tt.wantPort {
+				t.Errorf("host:port = %s:%d, want %s:%d", creds.Host, creds.Port, tt.wantHost, tt.wantPort)
+			}
+			if creds.DatabaseName != "enclii-test" || creds.Username != "AKID" || creds.Password != "secret" {
+				t.Errorf("unexpected credentials: %+v", creds)
+			}
+			if creds.ConnectionURI != "s3://enclii-test" {
+				t.Errorf("ConnectionURI = %q", creds.ConnectionURI)
+			}
+		})
+	}
+}
+
+func TestMinIOSetupJob(t *testing.T) {
+	job, err := minioSetupJob("minio-1234abcd", "project-1", "minio-1234abcd-root", "minio-1234abcd-credentials", "enclii-test",
+		map[string]string{LabelAddonID: "addon-1"})
+	if err != nil {
+		t.Fatalf("minioSetupJob() error = %v", err)
+	}
+
+	if job.Name != "minio-1234abcd-setup" {
+		t.Errorf("job name = %q", job.Name)
+	}
+
+	env := map[string]string{}
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		if e.ValueFrom != nil {
+			env[e.Name] = e.ValueFrom.SecretKeyRef.Name + "/" + e.ValueFrom.SecretKeyRef.Key
+		}
+	}
+	for name, want := range map[string]string{
+		"MINIO_ROOT_PASSWORD":  "minio-1234abcd-root/MINIO_ROOT_PASSWORD",
+		"S3_ENDPOINT":          "minio-1234abcd-credentials/endpoint",
+		"S3_BUCKET":            "minio-1234abcd-credentials/bucket",
+		"S3_ACCESS_KEY_ID":     "minio-1234abcd-credentials/access_key_id",
+		"S3_SECRET_ACCESS_KEY": "minio-1234abcd-credentials/secret_access_key",
+	} {
+		if env[name] != want {
+			t.Errorf("%s = %q, want %q", name, env[name], want)
+		}
+	}
+}
+
+// newTestCloudBuckets returns a cloud bucket client whose IAM endpoint is
+// answered by respond, keyed by the query API action
+func newTestCloudBuckets(t *testing.T, respond func(w http.ResponseWriter, action string, params url.Values)) *CloudBuckets {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "/us-east-1/iam/aws4_request") {
+			t.Errorf("request is not SigV4-signed for iam: %q", auth)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid form: %v", err)
+		}
+		if r.PostForm.Get("Version") != iamAPIVersion {
+			t.Errorf("Version = %q", r.PostForm.Get("Version"))
+		}
+		respond(w, r.PostForm.Get("Action"), r.PostForm)
+	}))
+	t.Cleanup(server.Close)
+
+	cloud, err := NewCloudBuckets(context.Background(), &CloudBucketConfig{
+		IAMEndpoint:     server.URL,
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewCloudBuckets() error = %v", err)
+	}
+	return cloud
+}
+
+func iamFault(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<ErrorResponse><Error><Type>Sender</Type><Code>%s</Code><Message>test</Message></Error></ErrorResponse>`, code)
+}
+
+func TestCreateScopedKey(t *testing.T) {
+	var actions []string
+	cloud := newTestCloudBuckets(t, func(w http.ResponseWriter, action string, params url.Values) {
+		actions = append(actions, action)
+		if params.Get("UserName") != "enclii-test" {
+			t.Errorf("%s UserName = %q", action, params.Get("UserName"))
+		}
+		switch action {
+		case "CreateUser":
+			// A retried provisioning finds the user already there
+			iamFault(w, http.StatusConflict, "EntityAlreadyExists")
+		case "PutUserPolicy":
+			if !strings.Contains(params.Get("PolicyDocument"), "arn:aws:s3:::enclii-test") {
+				t.Errorf("PolicyDocument = %q", params.Get("PolicyDocument"))
+			}
+			fmt.Fprint(w, `<PutUserPolicyResponse/>`)
+		case "CreateAccessKey":
+			fmt.Fprint(w, `<CreateAccessKeyResponse><CreateAccessKeyResult><AccessKey><UserName>enclii-test</UserName><AccessKeyId>AKIASCOPED</AccessKeyId><Status>Active</Status><SecretAccessKey>scoped-secret</SecretAccessKey></AccessKey></CreateAccessKeyResult></CreateAccessKeyResponse>`)
+		default:
+			t.Errorf("unexpected action %q", action)
+		}
+	})
+
+	policy, _ := bucketPolicy("enclii-test")
+	key, err := cloud.CreateScopedKey(context.Background(), "enclii-test", policy)
+	if err != nil {
+		t.Fatalf("CreateScopedKey() error = %v", err)
+	}
+	if key.UserName != "enclii-test" || key.AccessKeyID != "AKIASCOPED" || key.SecretAccessKey != "scoped-secret" {
+		t.Errorf("CreateScopedKey() = %+v", key)
+	}
+	if strings.Join(actions, ",") != "CreateUser,PutUserPolicy,CreateAccessKey" {
+		t.Errorf("actions = %v", actions)
+	}
+}
+
+func TestDeleteScopedKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter, action string)
+		wantErr bool
+	}{
+		{
+			name:    "deleted",
+			respond: func(w http.ResponseWriter, action string) { fmt.Fprintf(w, "<%sResponse/>", action) },
+		},
+		{
+			name:    "already gone",
+			respond: func(w http.ResponseWriter, action string) { iamFault(w, http.StatusNotFound, "NoSuchEntity") },
+		},
+		{
+			name:    "access denied",
+			respond: func(w http.ResponseWriter, action string) { iamFault(w, http.StatusForbidden, "AccessDenied") },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cloud := newTestCloudBuckets(t, func(w http.ResponseWriter, action string, params url.Values) {
+				tt.respond(w, action)
+			})
+
+			err := cloud.DeleteScopedKey(context.Background(), &ScopedKey{UserName: "enclii-test", AccessKeyID: "AKIASCOPED"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DeleteScopedKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMeasureBucket(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/enclii-test" || r.URL.Query().Get("list-type") != "2" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.URL.Query().Get("continuation-token") == "" {
+			fmt.Fprint(w, `<ListBucketResult><Name>enclii-test</Name><IsTruncated>true</IsTruncated><NextContinuationToken>page-2</NextContinuationToken>`+
+				`<Contents><Key>a</Key><Size>1024</Size></Contents><Contents><Key>b</Key><Size>2048</Size></Contents></ListBucketResult>`)
+			return
+		}
+		fmt.Fprint(w, `<ListBucketResult><Name>enclii-test</Name><IsTruncated>false</IsTruncated><Contents><Key>c</Key><Size>100</Size></Contents></ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	size, err := MeasureBucket(context.Background(), map[string][]byte{
+		bucketSecretEndpoint:        []byte(server.URL),
+		bucketSecretBucket:          []byte("enclii-test"),
+		bucketSecretRegion:          []byte("us-east-1"),
+		bucketSecretAccessKeyID:     []byte("AKID"),
+		bucketSecretSecretAccessKey: []byte("secret"),
+	})
+	if err != nil {
+		t.Fatalf("MeasureBucket() error = %v", err)
+	}
+	if size != 3172 {
+		t.Errorf("MeasureBucket() = %d, want 3172", size)
+	}
+}
@@ -0,0 +1,112 @@
+package addons
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// supportedVersions lists the engine versions that can be selected via
+// DatabaseAddonConfig.Version. Redis is pinned to the provisioner image.
+var supportedVersions = map[types.DatabaseAddonType]map[string]bool{
+	types.DatabaseAddonTypePostgres: {"14": true, "15": true, "16": true, "17": true},
+	types.DatabaseAddonTypeMySQL:    {"5.7": true, "8.0": true, "8.4": true},
+	types.DatabaseAddonTypeMongoDB:  {"6.0": true, "7.0": true, "8.0": true},
+}
+
+// ValidateConfig checks the engine-specific settings of an addon config
+func ValidateConfig(addonType types.DatabaseAddonType, config types.DatabaseAddonConfig) error {
+	if versions, ok := supportedVersions[addonType]; ok && config.Version != "" && !versions[config.Version] {
+		return fmt.Errorf("unsupported %s version %q, must be one of: %s",
+			addonType, config.Version, strings.Join(SupportedVersions(addonType), ", "))
+	}
+
+	if err := ValidateBackupPolicy(addonType, config.BackupSchedule, config.BackupRetentionDays); err != nil {
+		return err
+	}
+
+	if config.PITREnabled && addonType != types.DatabaseAddonTypePostgres {
+		return fmt.Errorf("point-in-time recovery is only supported for %s addons", types.DatabaseAddonTypePostgres)
+	}
+
+	if config.BucketProvider != "" {
+		if addonType != types.DatabaseAddonTypeBucket {
+			return fmt.Errorf("bucket_provider is only supported for %s addons", types.DatabaseAddonTypeBucket)
+		}
+		if config.BucketProvider != BucketProviderMinIO && config.BucketProvider != BucketProviderS3 {
+			return fmt.Errorf("unsupported bucket_provider %q, must be one of: %s, %s", config.BucketProvider, BucketProviderMinIO, BucketProviderS3)
+		}
+	}
+
+	if addonType == types.DatabaseAddonTypeRedis {
+		return validateRedisConfig(config)
+	}
+
+	return nil
+}
+
+// validateRedisConfig checks the Redis-specific memory and eviction settings
+func validateRedisConfig(config types.DatabaseAddonConfig) error {
+	if config.MaxMemoryPolicy != "" && !redisEvictionPolicies[config.MaxMemoryPolicy] {
+		return fmt.Errorf("unsupported maxmemory_policy: %s", config.MaxMemoryPolicy)
+	}
+	if config.Memory != "" {
+		if _, err := resource.ParseQuantity(config.Memory); err != nil {
+			return fmt.Errorf("invalid memory: %s", config.Memory)
+		}
+	}
+	if config.MaxMemory != "" {
+		if _, err := parseRedisMemory(config.MaxMemory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateBackupPolicy checks a backup schedule and retention for an addon type
+func ValidateBackupPolicy(addonType types.DatabaseAddonType, schedule string, retentionDays int) error {
+	if retentionDays < 0 {
+		return fmt.Errorf("backup_retention_days must not be negative")
+	}
+	if schedule == "" {
+		return nil
+	}
+	if !SupportsBackupJobs(addonType) {
+		return fmt.Errorf("scheduled backups are not supported for %s addons", addonType)
+	}
+	_, err := NextBackupAt(schedule, time.Now())
+	return err
+}
+
+// SupportedVersions returns the selectable versions for an addon type in sorted order
+func SupportedVersions(addonType types.DatabaseAddonType) []string {
+	versions := make([]string, 0, len(supportedVersions[addonType]))
+	for v := range supportedVersions[addonType] {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// DefaultBindingEnvVar returns the conventional env var name used when a
+// service is bound to an addon without an explicit env_var_name
+func DefaultBindingEnvVar(addonType types.DatabaseAddonType) string {
+	switch addonType {
+	case types.DatabaseAddonTypeRedis:
+		return "REDIS_URL"
+	case types.DatabaseAddonTypeMySQL:
+		return "MYSQL_URL"
+	case types.DatabaseAddonTypeMongoDB:
+		return "MONGODB_URI"
+	case types.DatabaseAddonTypeBucket:
+		// A prefix: bucket bindings expand to S3_ENDPOINT, S3_BUCKET, and the access keys
+		return DefaultBucketBindingPrefix
+	default:
+		return "DATABASE_URL"
+	}
+}
@@ -0,0 +1,60 @@
+package addons
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		addonType types.DatabaseAddonType
+		config    types.DatabaseAddonConfig
+		wantErr   bool
+	}{
+		{name: "default version", addonType: types.DatabaseAddonTypeMySQL, config: types.DatabaseAddonConfig{}},
+		{name: "supported mysql version", addonType: types.DatabaseAddonTypeMySQL, config: types.DatabaseAddonConfig{Version: "8.4"}},
+		{name: "unsupported mysql version", addonType: types.DatabaseAddonTypeMySQL, config: types.DatabaseAddonConfig{Version: "9.9"}, wantErr: true},
+		{name: "supported mongodb version", addonType: types.DatabaseAddonTypeMongoDB, config: types.DatabaseAddonConfig{Version: "7.0"}},
+		{name: "unsupported mongodb version", addonType: types.DatabaseAddonTypeMongoDB, config: types.DatabaseAddonConfig{Version: "4.4"}, wantErr: true},
+		{name: "postgres pitr", addonType: types.DatabaseAddonTypePostgres, config: types.DatabaseAddonConfig{PITREnabled: true}},
+		{name: "mysql pitr", addonType: types.DatabaseAddonTypeMySQL, config: types.DatabaseAddonConfig{PITREnabled: true}, wantErr: true},
+		{name: "redis config is validated", addonType: types.DatabaseAddonTypeRedis, config: types.DatabaseAddonConfig{MaxMemoryPolicy: "drop-everything"}, wantErr: true},
+		{name: "minio bucket", addonType: types.DatabaseAddonTypeBucket, config: types.DatabaseAddonConfig{BucketProvider: "minio"}},
+		{name: "s3 bucket", addonType: types.DatabaseAddonTypeBucket, config: types.DatabaseAddonConfig{BucketProvider: "s3"}},
+		{name: "unknown bucket provider", addonType: types.DatabaseAddonTypeBucket, config: types.DatabaseAddonConfig{BucketProvider: "gcs"}, wantErr: true},
+		{name: "bucket provider on database", addonType: types.DatabaseAddonTypePostgres, config: types.DatabaseAddonConfig{BucketProvider: "s3"}, wantErr: true},
+		{name: "bucket backups", addonType: types.DatabaseAddonTypeBucket, config: types.DatabaseAddonConfig{BackupSchedule: "0 3 * * *"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(tt.addonType, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultBindingEnvVar(t *testing.T) {
+	tests := []struct {
+		addonType types.DatabaseAddonType
+		want      string
+	}{
+		{types.DatabaseAddonTypePostgres, "DATABASE_URL"},
+		{types.DatabaseAddonTypeRedis, "REDIS_URL"},
+		{types.DatabaseAddonTypeMySQL, "MYSQL_URL"},
+		{types.DatabaseAddonTypeMongoDB, "MONGODB_URI"},
+		{types.DatabaseAddonTypeBucket, "S3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.addonType), func(t *testing.T) {
+			if got := DefaultBindingEnvVar(tt.addonType); got != tt.want {
+				t.Errorf("DefaultBindingEnvVar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
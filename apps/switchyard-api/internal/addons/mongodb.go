@@ -0,0 +1,416 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// MongoDB constants
+const (
+	DefaultMongoDBVersion = "7.0"
+	DefaultMongoDBPort    = 27017
+	MongoDBDefaultUser    = "app"
+	MongoDBDefaultDB      = "app"
+)
+
+// MongoDBProvisioner implements AddonProvisioner for MongoDB
+type MongoDBProvisioner struct {
+	k8sClient *k8s.Client
+	logger    *logrus.Logger
+}
+
+// NewMongoDBProvisioner creates a new MongoDB provisioner
+func NewMongoDBProvisioner(k8sClient *k8s.Client, logger *logrus.Logger) *MongoDBProvisioner {
+	return &MongoDBProvisioner{
+		k8sClient: k8sClient,
+		logger:    logger,
+	}
+}
+
+// Provision creates a new MongoDB instance using StatefulSet with PVC
+func (p *MongoDBProvisioner) Provision(ctx context.Context, req *ProvisionRequest) (*ProvisionResult, error) {
+	addon := req.Addon
+	namespace := req.Namespace
+
+	// Generate resource name
+	resourceName := fmt.Sprintf("mongodb-%s", addon.ID.String()[:8])
+	secretName := fmt.Sprintf("%s-secret", resourceName)
+
+	logger := p.logger.WithFields(logrus.Fields{
+		"addon_id":  addon.ID,
+		"namespace": namespace,
+		"resource":  resourceName,
+	})
+
+	logger.Info("Provisioning MongoDB StatefulSet")
+
+	// Ensure namespace exists
+	if err := p.k8sClient.EnsureNamespace(ctx, namespace); err != nil {
+		return nil, fmt.Errorf("failed to ensure namespace: %w", err)
+	}
+
+	// Parse config
+	memory := addon.Config.Memory
+	if memory == "" {
+		memory = "512Mi"
+	}
+
+	cpu := addon.Config.CPU
+	if cpu == "" {
+		cpu = "250m"
+	}
+
+	storageSize := fmt.Sprintf("%dGi", addon.Config.StorageGB)
+	if addon.Config.StorageGB == 0 {
+		storageSize = DefaultStorageSize
+	}
+
+	version := addon.Config.Version
+	if version == "" {
+		version = DefaultMongoDBVersion
+	}
+
+	password, err := generateSecurePassword(24)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	host := fmt.Sprintf("%s.%s.svc.cluster.local", resourceName, namespace)
+
+	labels := map[string]string{
+		"app":             resourceName,
+		LabelManagedBy:    LabelManagedValue,
+		LabelAddonID:      addon.ID.String(),
+		LabelProjectID:    req.ProjectID.String(),
+		LabelAddonType:    string(types.DatabaseAddonTypeMongoDB),
+		"enclii.dev/type": "addon",
+		"enclii.dev/kind": "mongodb",
+	}
+
+	// Create secret with credentials. The app user is created as the root
+	// user by the official image, so clients authenticate against "admin".
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"username": []byte(MongoDBDefaultUser),
+			"password": []byte(password),
+			"database": []byte(MongoDBDefaultDB),
+			"host":     []byte(host),
+			"port":     []byte(strconv.Itoa(DefaultMongoDBPort)),
+			"uri":      []byte(mongoDBConnectionURI(MongoDBDefaultUser, password, host, DefaultMongoDBPort, MongoDBDefaultDB)),
+		},
+	}
+
+	_, err = p.k8sClient.Clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create MongoDB secret: %w", err)
+	}
+
+	// Create headless service for StatefulSet
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "mongodb",
+					Port:       DefaultMongoDBPort,
+					TargetPort: intstr.FromInt(DefaultMongoDBPort),
+				},
+			},
+			ClusterIP: "None", // Headless service for StatefulSet
+			Selector: map[string]string{
+				"app": resourceName,
+			},
+		},
+	}
+
+	_, err = p.k8sClient.Clientset.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create MongoDB service: %w", err)
+	}
+
+	pingCommand := []string{
+		"mongosh",
+		"--quiet",
+		"--eval", "db.adminCommand('ping')",
+	}
+
+	// Create StatefulSet
+	replicas := int32(1) // MongoDB standalone for now
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: resourceName,
+			Replicas:    &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": resourceName,
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "data",
+					},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{
+							corev1.ReadWriteOnce,
+						},
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse(storageSize),
+							},
+						},
+					},
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "mongodb",
+							Image: fmt.Sprintf("mongo:%s", version),
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "mongodb",
+									ContainerPort: DefaultMongoDBPort,
+								},
+							},
+							Env: []corev1.EnvVar{
+								secretEnvVar("MONGO_INITDB_ROOT_USERNAME", secretName, "username"),
+								secretEnvVar("MONGO_INITDB_ROOT_PASSWORD", secretName, "password"),
+								secretEnvVar("MONGO_INITDB_DATABASE", secretName, "database"),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "data",
+									MountPath: "/data/db",
+								},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse(memory),
+									corev1.ResourceCPU:    resource.MustParse(cpu),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse(memory),
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{Command: pingCommand},
+								},
+								InitialDelaySeconds: 20,
+								PeriodSeconds:       10,
+								TimeoutSeconds:      5,
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{Command: pingCommand},
+								},
+								InitialDelaySeconds: 60,
+								PeriodSeconds:       15,
+								TimeoutSeconds:      5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, err = p.k8sClient.Clientset.AppsV1().StatefulSets(namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create MongoDB StatefulSet: %w", err)
+	}
+
+	logger.Info("MongoDB StatefulSet created successfully")
+
+	return &ProvisionResult{
+		K8sResourceName:  resourceName,
+		ConnectionSecret: secretName,
+		Message:          "MongoDB instance creation initiated",
+	}, nil
+}
+
+// Deprovision removes a MongoDB instance
+func (p *MongoDBProvisioner) Deprovision(ctx context.Context, addon *types.DatabaseAddon) error {
+	if addon.K8sNamespace == "" || addon.K8sResourceName == "" {
+		return nil // Nothing to deprovision
+	}
+
+	logger := p.logger.WithFields(logrus.Fields{
+		"addon_id":  addon.ID,
+		"namespace": addon.K8sNamespace,
+		"resource":  addon.K8sResourceName,
+	})
+
+	logger.Info("Deprovisioning MongoDB StatefulSet")
+
+	err := p.k8sClient.Clientset.AppsV1().StatefulSets(addon.K8sNamespace).Delete(ctx, addon.K8sResourceName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete MongoDB StatefulSet: %w", err)
+	}
+
+	err = p.k8sClient.Clientset.CoreV1().Services(addon.K8sNamespace).Delete(ctx, addon.K8sResourceName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete MongoDB service: %w", err)
+	}
+
+	secretName := addon.ConnectionSecret
+	if secretName == "" {
+		secretName = fmt.Sprintf("%s-secret", addon.K8sResourceName)
+	}
+	err = p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Delete(ctx, secretName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete MongoDB secret: %w", err)
+	}
+
+	// Delete PVCs (StatefulSet doesn't delete them automatically)
+	pvcName := fmt.Sprintf("data-%s-0", addon.K8sResourceName)
+	err = p.k8sClient.Clientset.CoreV1().PersistentVolumeClaims(addon.K8sNamespace).Delete(ctx, pvcName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		logger.WithError(err).Warn("Failed to delete MongoDB PVC")
+	}
+
+	logger.Info("MongoDB StatefulSet deprovisioned successfully")
+	return nil
+}
+
+// GetStatus returns the current status of a MongoDB instance
+func (p *MongoDBProvisioner) GetStatus(ctx context.Context, addon *types.DatabaseAddon) (*StatusResult, error) {
+	if addon.K8sNamespace == "" || addon.K8sResourceName == "" {
+		return &StatusResult{
+			Status:        types.DatabaseAddonStatusPending,
+			StatusMessage: "Waiting for K8s resource creation",
+		}, nil
+	}
+
+	statefulSet, err := p.k8sClient.Clientset.AppsV1().StatefulSets(addon.K8sNamespace).Get(ctx, addon.K8sResourceName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return &StatusResult{
+				Status:        types.DatabaseAddonStatusDeleted,
+				StatusMessage: "MongoDB StatefulSet not found",
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to get MongoDB StatefulSet: %w", err)
+	}
+
+	result := &StatusResult{
+		Host:         fmt.Sprintf("%s.%s.svc.cluster.local", addon.K8sResourceName, addon.K8sNamespace),
+		Port:         DefaultMongoDBPort,
+		DatabaseName: MongoDBDefaultDB,
+		Username:     MongoDBDefaultUser,
+	}
+
+	if statefulSet.Status.ReadyReplicas == *statefulSet.Spec.Replicas && statefulSet.Status.ReadyReplicas > 0 {
+		result.Status = types.DatabaseAddonStatusReady
+		result.StatusMessage = fmt.Sprintf("MongoDB ready with %d replicas", statefulSet.Status.ReadyReplicas)
+		result.Ready = true
+	} else {
+		result.Status = types.DatabaseAddonStatusProvisioning
+		result.StatusMessage = fmt.Sprintf("MongoDB provisioning: %d/%d replicas ready",
+			statefulSet.Status.ReadyReplicas, *statefulSet.Spec.Replicas)
+	}
+
+	return result, nil
+}
+
+// Resize expands the MongoDB volume in place and rolls the pod onto the new resources
+func (p *MongoDBProvisioner) Resize(ctx context.Context, addon *types.DatabaseAddon) error {
+	memory := addon.Config.Memory
+	if memory == "" {
+		memory = "512Mi"
+	}
+	cpu := addon.Config.CPU
+	if cpu == "" {
+		cpu = "250m"
+	}
+	return resizeStatefulSet(ctx, p.k8sClient, addon, "mongodb", cpu, memory, nil)
+}
+
+// ResizeProgress reports whether the MongoDB StatefulSet has rolled out its latest spec
+func (p *MongoDBProvisioner) ResizeProgress(ctx context.Context, addon *types.DatabaseAddon) (*ResizeProgress, error) {
+	return statefulSetResizeProgress(ctx, p.k8sClient, addon)
+}
+
+// GetCredentials returns connection credentials for a MongoDB instance
+func (p *MongoDBProvisioner) GetCredentials(ctx context.Context, addon *types.DatabaseAddon) (*types.DatabaseAddonCredentials, error) {
+	if !addon.IsAvailable() {
+		return nil, fmt.Errorf("addon is not ready")
+	}
+
+	if addon.ConnectionSecret == "" || addon.K8sNamespace == "" {
+		return nil, fmt.Errorf("addon does not have connection secret configured")
+	}
+
+	secret, err := p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Get(ctx, addon.ConnectionSecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection secret: %w", err)
+	}
+
+	host := string(secret.Data["host"])
+	port := DefaultMongoDBPort
+	if portStr := string(secret.Data["port"]); portStr != "" {
+		if p, err := strconv.Atoi(portStr); err == nil {
+			port = p
+		}
+	}
+
+	username := string(secret.Data["username"])
+	password := string(secret.Data["password"])
+	database := string(secret.Data["database"])
+
+	return &types.DatabaseAddonCredentials{
+		Host:          host,
+		Port:          port,
+		DatabaseName:  database,
+		Username:      username,
+		Password:      password,
+		ConnectionURI: mongoDBConnectionURI(username, password, host, port, database),
+	}, nil
+}
+
+// GetConnectionURI returns the connection URI for a MongoDB instance
+func (p *MongoDBProvisioner) GetConnectionURI(ctx context.Context, addon *types.DatabaseAddon) (string, error) {
+	creds, err := p.GetCredentials(ctx, addon)
+	if err != nil {
+		return "", err
+	}
+	return creds.ConnectionURI, nil
+}
+
+// mongoDBConnectionURI builds a mongodb:// connection URI. The user lives in
+// the admin database, so authSource must point there.
+func mongoDBConnectionURI(username, password, host string, port int, database string) string {
+	return fmt.Sprintf("mongodb://%s:%s@%s:%d/%s?authSource=admin", username, password, host, port, database)
+}
@@ -95,7 +95,10 @@ func (p *MySQLProvisioner) Provision(ctx context.Context, req *ProvisionRequest)
 		return nil, fmt.Errorf("failed to generate app password: %w", err)
 	}
 
-	// Create secret with credentials
+	host := fmt.Sprintf("%s.%s.svc.cluster.local", resourceName, namespace)
+
+	// Create secret with credentials. The "uri" key is what service bindings
+	// reference for MYSQL_URL.
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      secretName,
@@ -116,8 +119,9 @@ func (p *MySQLProvisioner) Provision(ctx context.Context, req *ProvisionRequest)
 			"username":      []byte(MySQLDefaultUser),
 			"password":      []byte(appPassword),
 			"database":      []byte(MySQLDefaultDB),
-			"host":          []byte(fmt.Sprintf("%s.%s.svc.cluster.local", resourceName, namespace)),
+			"host":          []byte(host),
 			"port":          []byte(strconv.Itoa(DefaultMySQLPort)),
+			"uri":           []byte(mysqlConnectionURI(MySQLDefaultUser, appPassword, host, DefaultMySQLPort, MySQLDefaultDB)),
 		},
 	}
 
@@ -369,7 +373,10 @@ func (p *MySQLProvisioner) Deprovision(ctx context.Context, addon *types.Databas
 	}
 
 	// Delete Secret
-	secretName := fmt.Sprintf("%s-secret", addon.K8sResourceName)
+	secretName := addon.ConnectionSecret
+	if secretName == "" {
+		secretName = fmt.Sprintf("%s-secret", addon.K8sResourceName)
+	}
 	err = p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Delete(
 		ctx,
 		secretName,
@@ -439,9 +446,27 @@ func (p *MySQLProvisioner) GetStatus(ctx context.Context, addon *types.DatabaseA
 	return result, nil
 }
 
+// Resize expands the MySQL volume in place and rolls the pod onto the new resources
+func (p *MySQLProvisioner) Resize(ctx context.Context, addon *types.DatabaseAddon) error {
+	memory := addon.Config.Memory
+	if memory == "" {
+		memory = "512Mi"
+	}
+	cpu := addon.Config.CPU
+	if cpu == "" {
+		cpu = "250m"
+	}
+	return resizeStatefulSet(ctx, p.k8sClient, addon, "mysql", cpu, memory, nil)
+}
+
+// ResizeProgress reports whether the MySQL StatefulSet has rolled out its latest spec
+func (p *MySQLProvisioner) ResizeProgress(ctx context.Context, addon *types.DatabaseAddon) (*ResizeProgress, error) {
+	return statefulSetResizeProgress(ctx, p.k8sClient, addon)
+}
+
 // GetCredentials returns connection credentials for a MySQL instance
 func (p *MySQLProvisioner) GetCredentials(ctx context.Context, addon *types.DatabaseAddon) (*types.DatabaseAddonCredentials, error) {
-	if addon.Status != types.DatabaseAddonStatusReady {
+	if !addon.IsAvailable() {
 		return nil, fmt.Errorf("addon is not ready")
 	}
 
@@ -472,19 +497,12 @@ func (p *MySQLProvisioner) GetCredentials(ctx context.Context, addon *types.Data
 	database := string(secret.Data["database"])
 
 	return &types.DatabaseAddonCredentials{
-		Host:         host,
-		Port:         port,
-		DatabaseName: database,
-		Username:     username,
-		Password:     password,
-		ConnectionURI: fmt.Sprintf(
-			"mysql://%s:%s@%s:%d/%s",
-			username,
-			password,
-			host,
-			port,
-			database,
-		),
+		Host:          host,
+		Port:          port,
+		DatabaseName:  database,
+		Username:      username,
+		Password:      password,
+		ConnectionURI: mysqlConnectionURI(username, password, host, port, database),
 	}, nil
 }
 
@@ -497,6 +515,11 @@ func (p *MySQLProvisioner) GetConnectionURI(ctx context.Context, addon *types.Da
 	return creds.ConnectionURI, nil
 }
 
+// mysqlConnectionURI builds a mysql:// connection URI
+func mysqlConnectionURI(username, password, host string, port int, database string) string {
+	return fmt.Sprintf("mysql://%s:%s@%s:%d/%s", username, password, host, port, database)
+}
+
 // generateSecurePassword generates a cryptographically secure random password
 func generateSecurePassword(length int) (string, error) {
 	bytes := make([]byte, length)
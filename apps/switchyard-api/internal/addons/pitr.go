@@ -0,0 +1,342 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Point-in-time recovery constants
+const (
+	walArchivePrefix = "addon-wal"
+
+	// defaultPITRRetentionDays applies when the addon has no backup retention set
+	defaultPITRRetentionDays = 7
+
+	// pitrBaseBackupSchedule takes a nightly base backup to replay WAL from.
+	// CloudNativePG schedules include a leading seconds field.
+	pitrBaseBackupSchedule = "0 0 3 * * *"
+
+	// pitrRecoverySource names the external cluster a recovery replays from
+	pitrRecoverySource = "origin"
+
+	// RestorePhaseRecovering is reported while the recovery cluster replays WAL
+	RestorePhaseRecovering = "recovering"
+
+	// PITRRestoreTimeout bounds how long a recovery cluster may take to become ready
+	PITRRestoreTimeout = 2 * time.Hour
+)
+
+// ErrPITRNotEnabled is returned for point-in-time operations on addons without WAL archiving
+var ErrPITRNotEnabled = fmt.Errorf("point-in-time recovery is not enabled for this addon")
+
+// WALArchive describes the object store a PostgreSQL cluster archives WAL and
+// base backups to
+type WALArchive struct {
+	DestinationPath string
+	EndpointURL     string
+	RetentionDays   int
+}
+
+// PointInTimeRecovery selects the cluster and moment a new cluster is recovered from
+type PointInTimeRecovery struct {
+	SourceCluster string
+	TargetTime    time.Time // Zero replays the whole archive
+
+	// Archive is the source cluster's archive when it is not the one the new
+	// cluster archives to, as when cloning another addon
+	Archive *WALArchive
+}
+
+// newWALArchive returns the archive location of an addon in backup storage
+func newWALArchive(cfg *BackupStorageConfig, addon *types.DatabaseAddon) *WALArchive {
+	retention := addon.Config.BackupRetentionDays
+	if retention <= 0 {
+		retention = defaultPITRRetentionDays
+	}
+
+	return &WALArchive{
+		DestinationPath: fmt.Sprintf("%s%s/%s/%s/%s", backupStorageScheme, cfg.Bucket, walArchivePrefix, addon.ProjectID, addon.ID),
+		EndpointURL:     cfg.Endpoint,
+		RetentionDays:   retention,
+	}
+}
+
+// barmanObjectStore builds the CloudNativePG object store for the archive.
+// Credentials are read from the backup storage secret in the addon namespace.
+func (a *WALArchive) barmanObjectStore() *CloudNativePGBarmanStore {
+	return &CloudNativePGBarmanStore{
+		DestinationPath: a.DestinationPath,
+		EndpointURL:     a.EndpointURL,
+		S3Credentials: &CloudNativePGS3Credentials{
+			AccessKeyID:     CloudNativePGSecretKeyRef{Name: backupStorageSecretName, Key: "AWS_ACCESS_KEY_ID"},
+			SecretAccessKey: CloudNativePGSecretKeyRef{Name: backupStorageSecretName, Key: "AWS_SECRET_ACCESS_KEY"},
+			Region:          &CloudNativePGSecretKeyRef{Name: backupStorageSecretName, Key: "AWS_DEFAULT_REGION"},
+		},
+		Wal:  &CloudNativePGWalConfig{Compression: "gzip"},
+		Data: &CloudNativePGDataConfig{Compression: "gzip"},
+	}
+}
+
+// backupSpec builds the Cluster backup block that turns on continuous archiving
+func (a *WALArchive) backupSpec() *CloudNativePGBackupSpec {
+	return &CloudNativePGBackupSpec{
+		BarmanObjectStore: a.barmanObjectStore(),
+		RetentionPolicy:   fmt.Sprintf("%dd", a.RetentionDays),
+	}
+}
+
+// recoveryBootstrap builds the bootstrap and external cluster that replay the
+// source cluster's archive up to the target time
+func (a *WALArchive) recoveryBootstrap(recovery *PointInTimeRecovery) (*CloudNativePGBootstrap, []CloudNativePGExternalCluster) {
+	source := a.barmanObjectStore()
+	source.ServerName = recovery.SourceCluster
+
+	bootstrap := &CloudNativePGBootstrap{
+		Recovery: &CloudNativePGRecovery{
+			Source:   pitrRecoverySource,
+			Database: DefaultDatabase,
+			Owner:    DefaultUser,
+		},
+	}
+	if !recovery.TargetTime.IsZero() {
+		bootstrap.Recovery.RecoveryTarget = &CloudNativePGRecoveryTarget{
+			TargetTime: recovery.TargetTime.UTC().Format(time.RFC3339),
+		}
+	}
+	return bootstrap, []CloudNativePGExternalCluster{
+		{Name: pitrRecoverySource, BarmanObjectStore: source},
+	}
+}
+
+// RecoveryClusterName returns the name of the cluster a point-in-time restore creates
+func RecoveryClusterName(addon *types.DatabaseAddon, restoreID uuid.UUID) string {
+	return fmt.Sprintf("pg-%s-%s", addon.Name, restoreID.String()[:8])
+}
+
+// ValidateRecoveryTarget checks that a restore target lies within the recovery window
+func ValidateRecoveryTarget(target time.Time, firstRecoverabilityPoint *time.Time, now time.Time) error {
+	if target.IsZero() {
+		return fmt.Errorf("target_time is required")
+	}
+	if target.After(now) {
+		return fmt.Errorf("target_time must not be in the future")
+	}
+	if firstRecoverabilityPoint == nil {
+		return fmt.Errorf("no base backup has completed yet, the recovery window is not available")
+	}
+	if target.Before(*firstRecoverabilityPoint) {
+		return fmt.Errorf("target_time is before the earliest recovery point (%s)", firstRecoverabilityPoint.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// walArchive returns the archive of an addon, or nil if it has no WAL archiving
+func (s *AddonService) walArchive(addon *types.DatabaseAddon) *WALArchive {
+	if addon.Type != types.DatabaseAddonTypePostgres || !addon.Config.PITREnabled || s.backupStorage == nil {
+		return nil
+	}
+	return newWALArchive(s.backupStorage.config, addon)
+}
+
+// postgresProvisioner returns the registered CloudNativePG provisioner
+func (s *AddonService) postgresProvisioner() (*PostgresProvisioner, error) {
+	p, ok := s.provisioners[types.DatabaseAddonTypePostgres].(*PostgresProvisioner)
+	if !ok {
+		return nil, fmt.Errorf("postgres provisioner is not available")
+	}
+	return p, nil
+}
+
+// GetPITRStatus reports the archiving state and recovery window of an addon
+func (s *AddonService) GetPITRStatus(ctx context.Context, addonID uuid.UUID) (*types.DatabaseAddonPITRStatus, error) {
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon not found: %w", err)
+	}
+	if s.walArchive(addon) == nil {
+		return &types.DatabaseAddonPITRStatus{Enabled: false}, nil
+	}
+
+	postgres, err := s.postgresProvisioner()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := postgres.ArchiveStatus(ctx, addon)
+	if err != nil {
+		return nil, err
+	}
+	status.LastArchivedAt = addon.WALArchivedAt
+	status.ArchiveLagSeconds = addon.WALArchiveLagSeconds
+	return status, nil
+}
+
+// RestorePointInTime recovers a PostgreSQL addon to a moment within its
+// recovery window. A new cluster replays the archived WAL next to the current
+// one; once it is ready the addon is switched over and the old cluster removed.
+func (s *AddonService) RestorePointInTime(ctx context.Context, addonID uuid.UUID, targetTime time.Time, actorID *uuid.UUID, actorEmail string) (*types.DatabaseAddonRestore, error) {
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon not found: %w", err)
+	}
+
+	if addon.Type != types.DatabaseAddonTypePostgres {
+		return nil, fmt.Errorf("point-in-time recovery is not supported for addon type: %s", addon.Type)
+	}
+	archive := s.walArchive(addon)
+	if archive == nil {
+		return nil, ErrPITRNotEnabled
+	}
+	if addon.Status != types.DatabaseAddonStatusReady {
+		return nil, fmt.Errorf("addon is not ready for restore (status: %s)", addon.Status)
+	}
+
+	postgres, err := s.postgresProvisioner()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := postgres.ArchiveStatus(ctx, addon)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateRecoveryTarget(targetTime, status.FirstRecoverabilityPoint, time.Now()); err != nil {
+		return nil, err
+	}
+
+	active, err := s.repos.DatabaseAddons.ListActiveRestores(ctx, &addon.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active restores: %w", err)
+	}
+	if len(active) > 0 {
+		return nil, ErrRestoreInProgress
+	}
+
+	restore := &types.DatabaseAddonRestore{
+		AddonID:          addon.ID,
+		TargetTime:       &targetTime,
+		Phase:            RestorePhaseScheduling,
+		RequestedBy:      actorID,
+		RequestedByEmail: actorEmail,
+	}
+	if err := s.repos.DatabaseAddons.CreateRestore(ctx, restore); err != nil {
+		return nil, fmt.Errorf("failed to create restore record: %w", err)
+	}
+
+	clusterName := RecoveryClusterName(addon, restore.ID)
+	logger := s.logger.WithFields(logrus.Fields{
+		"addon_id":    addon.ID,
+		"restore_id":  restore.ID,
+		"target_time": targetTime,
+		"cluster":     clusterName,
+	})
+
+	err = s.backupStorage.ensureSecret(ctx, s, addon.K8sNamespace)
+	if err == nil {
+		err = postgres.createCluster(ctx, &ProvisionRequest{
+			Addon:      addon,
+			Namespace:  addon.K8sNamespace,
+			ProjectID:  addon.ProjectID,
+			WALArchive: archive,
+			Recovery: &PointInTimeRecovery{
+				SourceCluster: addon.K8sResourceName,
+				TargetTime:    targetTime,
+			},
+		}, clusterName)
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to start point-in-time restore")
+		s.finishRestore(ctx, addon, restore, types.DatabaseAddonRestoreStatusFailed, err.Error())
+		return nil, fmt.Errorf("failed to start restore: %w", err)
+	}
+
+	now := time.Now()
+	restore.Status = types.DatabaseAddonRestoreStatusInProgress
+	restore.StatusMessage = fmt.Sprintf("Recovery cluster %s created", clusterName)
+	restore.Progress = restorePhaseProgress[RestorePhaseScheduling]
+	restore.StartedAt = &now
+	if err := s.repos.DatabaseAddons.UpdateRestore(ctx, restore); err != nil {
+		return nil, fmt.Errorf("failed to update restore record: %w", err)
+	}
+
+	logger.Info("Point-in-time restore started")
+	return restore, nil
+}
+
+// syncPointInTimeRestore follows the recovery cluster of a restore and
+// switches the addon over to it once it is ready
+func (s *AddonService) syncPointInTimeRestore(ctx context.Context, addon *types.DatabaseAddon, restore *types.DatabaseAddonRestore) error {
+	postgres, err := s.postgresProvisioner()
+	if err != nil {
+		return err
+	}
+
+	recovered := *addon
+	recovered.K8sResourceName = RecoveryClusterName(addon, restore.ID)
+
+	status, err := postgres.GetStatus(ctx, &recovered)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status.Ready:
+		return s.switchToRecoveredCluster(ctx, addon, &recovered, status, restore)
+	case status.Status == types.DatabaseAddonStatusFailed:
+		s.discardRecoveryCluster(ctx, postgres, &recovered)
+		s.finishRestore(ctx, addon, restore, types.DatabaseAddonRestoreStatusFailed, fmt.Sprintf("Recovery failed: %s", status.StatusMessage))
+		return nil
+	case restore.StartedAt != nil && time.Since(*restore.StartedAt) > PITRRestoreTimeout:
+		s.discardRecoveryCluster(ctx, postgres, &recovered)
+		s.finishRestore(ctx, addon, restore, types.DatabaseAddonRestoreStatusFailed, fmt.Sprintf("Recovery did not complete within %s", PITRRestoreTimeout))
+		return nil
+	}
+
+	if restore.Phase == RestorePhaseRecovering {
+		return nil
+	}
+	restore.Phase = RestorePhaseRecovering
+	restore.Progress = restorePhaseProgress[RestorePhaseRecovering]
+	return s.repos.DatabaseAddons.UpdateRestore(ctx, restore)
+}
+
+// switchToRecoveredCluster points the addon at its recovered cluster and
+// removes the cluster it replaced
+func (s *AddonService) switchToRecoveredCluster(ctx context.Context, addon, recovered *types.DatabaseAddon, status *StatusResult, restore *types.DatabaseAddonRestore) error {
+	previous := *addon
+
+	addon.K8sResourceName = recovered.K8sResourceName
+	addon.ConnectionSecret = fmt.Sprintf("%s-app", recovered.K8sResourceName)
+	addon.Host = status.Host
+	addon.Port = status.Port
+	addon.WALArchivedAt = nil
+	addon.WALArchiveLagSeconds = nil
+	if err := s.repos.DatabaseAddons.Update(ctx, addon); err != nil {
+		return fmt.Errorf("failed to switch addon to recovered cluster: %w", err)
+	}
+
+	if postgres, err := s.postgresProvisioner(); err == nil {
+		if err := postgres.Deprovision(ctx, &previous); err != nil {
+			s.logger.WithError(err).WithField("cluster", previous.K8sResourceName).Warn("Failed to remove replaced PostgreSQL cluster")
+		}
+	}
+
+	s.finishRestore(ctx, addon, restore, types.DatabaseAddonRestoreStatusCompleted, fmt.Sprintf(
+		"Recovered to %s; redeploy bound services to pick up the new credentials",
+		restore.TargetTime.UTC().Format(time.RFC3339),
+	))
+	return nil
+}
+
+// discardRecoveryCluster removes the cluster of a failed point-in-time restore
+func (s *AddonService) discardRecoveryCluster(ctx context.Context, postgres *PostgresProvisioner, recovered *types.DatabaseAddon) {
+	if err := postgres.Deprovision(ctx, recovered); err != nil {
+		s.logger.WithError(err).WithField("cluster", recovered.K8sResourceName).Warn("Failed to remove recovery cluster")
+	}
+}
@@ -0,0 +1,187 @@
+package addons
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateRecoveryTarget(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		target  time.Time
+		first   *time.Time
+		wantErr bool
+	}{
+		{name: "within window", target: now.Add(-time.Hour), first: &first},
+		{name: "first recoverability point", target: first, first: &first},
+		{name: "before window", target: first.Add(-time.Minute), first: &first, wantErr: true},
+		{name: "in the future", target: now.Add(time.Minute), first: &first, wantErr: true},
+		{name: "no base backup yet", target: now.Add(-time.Hour), wantErr: true},
+		{name: "zero target", first: &first, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRecoveryTarget(tt.target, tt.first, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRecoveryTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildClusterManifestWALArchive(t *testing.T) {
+	addon := &types.DatabaseAddon{
+		ID:        uuid.MustParse("11111111-2222-3333-4444-555555555555"),
+		ProjectID: uuid.MustParse("66666666-7777-8888-9999-000000000000"),
+		Name:      "main",
+		Type:      types.DatabaseAddonTypePostgres,
+		Config:    types.DatabaseAddonConfig{PITREnabled: true, BackupRetentionDays: 14},
+	}
+	archive := newWALArchive(&BackupStorageConfig{Bucket: "backups", Endpoint: "https://r2.example.com"}, addon)
+	targetTime := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		req           *ProvisionRequest
+		wantBackup    bool
+		wantRecovery  bool
+		wantRetention string
+		wantTarget    string
+	}{
+		{
+			name: "archiving disabled",
+			req:  &ProvisionRequest{Addon: addon, Namespace: "project-1", ProjectID: addon.ProjectID},
+		},
+		{
+			name:          "archiving enabled",
+			req:           &ProvisionRequest{Addon: addon, Namespace: "project-1", ProjectID: addon.ProjectID, WALArchive: archive},
+			wantBackup:    true,
+			wantRetention: "14d",
+		},
+		{
+			name: "recovery",
+			req: &ProvisionRequest{
+				Addon: addon, Namespace: "project-1", ProjectID: addon.ProjectID, WALArchive: archive,
+				Recovery: &PointInTimeRecovery{SourceCluster: "pg-main-11111111", TargetTime: targetTime},
+			},
+			wantBackup:    true,
+			wantRecovery:  true,
+			wantRetention: "14d",
+			wantTarget:    "2026-03-01T09:30:00Z",
+		},
+		{
+			name: "clone of another cluster's archive",
+			req: &ProvisionRequest{
+				Addon: addon, Namespace: "enclii-preview-pr-1-api", ProjectID: addon.ProjectID,
+				Recovery: &PointInTimeRecovery{SourceCluster: "pg-main-11111111", Archive: archive},
+			},
+			wantRecovery: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := (&PostgresProvisioner{}).buildClusterManifest(tt.req, "pg-main-abcdef12")["spec"].(map[string]interface{})
+
+			backup, ok := spec["backup"].(*CloudNativePGBackupSpec)
+			if ok != tt.wantBackup {
+				t.Fatalf("backup present = %v, want %v", ok, tt.wantBackup)
+			}
+			if tt.wantBackup {
+				wantPath := "s3://backups/addon-wal/66666666-7777-8888-9999-000000000000/11111111-2222-3333-4444-555555555555"
+				if backup.BarmanObjectStore.DestinationPath != wantPath {
+					t.Errorf("destinationPath = %q, want %q", backup.BarmanObjectStore.DestinationPath, wantPath)
+				}
+				if backup.RetentionPolicy != tt.wantRetention {
+					t.Errorf("retentionPolicy = %q, want %q", backup.RetentionPolicy, tt.wantRetention)
+				}
+			}
+
+			bootstrap, ok := spec["bootstrap"].(*CloudNativePGBootstrap)
+			if ok != tt.wantRecovery {
+				t.Fatalf("recovery bootstrap present = %v, want %v", ok, tt.wantRecovery)
+			}
+			if tt.wantRecovery {
+				target := bootstrap.Recovery.RecoveryTarget
+				switch {
+				case tt.wantTarget == "" && target != nil:
+					t.Errorf("recoveryTarget = %+v, want the end of the archive", target)
+				case tt.wantTarget != "" && (target == nil || target.TargetTime != tt.wantTarget):
+					t.Errorf("recoveryTarget = %+v, want targetTime %q", target, tt.wantTarget)
+				}
+				external := spec["externalClusters"].([]CloudNativePGExternalCluster)
+				if len(external) != 1 || external[0].Name != bootstrap.Recovery.Source {
+					t.Fatalf("externalClusters = %+v, want one cluster named %q", external, bootstrap.Recovery.Source)
+				}
+				if external[0].BarmanObjectStore.ServerName != "pg-main-11111111" {
+					t.Errorf("serverName = %q, want source cluster", external[0].BarmanObjectStore.ServerName)
+				}
+			}
+		})
+	}
+}
+
+func TestParseArchiveStatus(t *testing.T) {
+	cluster := func(status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+	}
+
+	tests := []struct {
+		name          string
+		cluster       *unstructured.Unstructured
+		wantArchiving bool
+		wantMessage   string
+		wantFirst     string
+	}{
+		{
+			name: "archiving",
+			cluster: cluster(map[string]interface{}{
+				"firstRecoverabilityPoint": "2026-02-28T03:00:12Z",
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "True"},
+					map[string]interface{}{"type": "ContinuousArchiving", "status": "True", "message": "Continuous archiving is working"},
+				},
+			}),
+			wantArchiving: true,
+			wantFirst:     "2026-02-28T03:00:12Z",
+		},
+		{
+			name: "archiving failing",
+			cluster: cluster(map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "ContinuousArchiving", "status": "False", "message": "unexpected failure invoking barman-cloud-wal-archive"},
+				},
+			}),
+			wantMessage: "unexpected failure invoking barman-cloud-wal-archive",
+		},
+		{
+			name:    "no status yet",
+			cluster: &unstructured.Unstructured{Object: map[string]interface{}{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseArchiveStatus(tt.cluster)
+			if !got.Enabled || got.Archiving != tt.wantArchiving || got.Message != tt.wantMessage {
+				t.Errorf("parseArchiveStatus() = %+v, want archiving=%v message=%q", got, tt.wantArchiving, tt.wantMessage)
+			}
+			first := ""
+			if got.FirstRecoverabilityPoint != nil {
+				first = got.FirstRecoverabilityPoint.Format(time.RFC3339)
+			}
+			if first != tt.wantFirst {
+				t.Errorf("FirstRecoverabilityPoint = %q, want %q", first, tt.wantFirst)
+			}
+		})
+	}
+}
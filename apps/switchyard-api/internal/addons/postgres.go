@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
@@ -23,12 +27,19 @@ type PostgresProvisioner struct {
 	logger        *logrus.Logger
 }
 
-// CloudNativePG Group Version Resource
-var cnpgGVR = schema.GroupVersionResource{
-	Group:    "postgresql.cnpg.io",
-	Version:  "v1",
-	Resource: "clusters",
-}
+// CloudNativePG Group Version Resources
+var (
+	cnpgGVR = schema.GroupVersionResource{
+		Group:    "postgresql.cnpg.io",
+		Version:  "v1",
+		Resource: "clusters",
+	}
+	cnpgScheduledBackupGVR = schema.GroupVersionResource{
+		Group:    "postgresql.cnpg.io",
+		Version:  "v1",
+		Resource: "scheduledbackups",
+	}
+)
 
 // NewPostgresProvisioner creates a new PostgreSQL provisioner
 func NewPostgresProvisioner(k8sClient *k8s.Client, logger *logrus.Logger) *PostgresProvisioner {
@@ -63,29 +74,9 @@ func (p *PostgresProvisioner) Provision(ctx context.Context, req *ProvisionReque
 	// Generate resource name
 	resourceName := fmt.Sprintf("pg-%s-%s", req.Addon.Name, req.Addon.ID.String()[:8])
 
-	// Build CloudNativePG Cluster manifest
-	cluster := p.buildClusterManifest(req, resourceName)
-
-	// Convert to unstructured
-	clusterJSON, err := json.Marshal(cluster)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal cluster spec: %w", err)
-	}
-
-	var unstructuredObj unstructured.Unstructured
-	if err := json.Unmarshal(clusterJSON, &unstructuredObj.Object); err != nil {
-		return nil, fmt.Errorf("failed to convert to unstructured: %w", err)
-	}
-
-	// Create the CloudNativePG Cluster
-	_, err = p.dynamicClient.Resource(cnpgGVR).Namespace(req.Namespace).Create(
-		ctx,
-		&unstructuredObj,
-		metav1.CreateOptions{},
-	)
-	if err != nil {
+	if err := p.createCluster(ctx, req, resourceName); err != nil {
 		logger.WithError(err).Error("Failed to create CloudNativePG cluster")
-		return nil, fmt.Errorf("failed to create PostgreSQL cluster: %w", err)
+		return nil, err
 	}
 
 	// Connection secret name follows CloudNativePG naming convention
@@ -100,18 +91,87 @@ func (p *PostgresProvisioner) Provision(ctx context.Context, req *ProvisionReque
 	}, nil
 }
 
-// buildClusterManifest builds the CloudNativePG Cluster manifest
-func (p *PostgresProvisioner) buildClusterManifest(req *ProvisionRequest, resourceName string) map[string]interface{} {
-	config := req.Addon.Config
+// createCluster creates a CloudNativePG Cluster and, when WAL archiving is
+// enabled, the ScheduledBackup that takes its base backups
+func (p *PostgresProvisioner) createCluster(ctx context.Context, req *ProvisionRequest, resourceName string) error {
+	cluster, err := toUnstructured(p.buildClusterManifest(req, resourceName))
+	if err != nil {
+		return err
+	}
 
-	// Determine instances (default to 1 for non-HA)
-	instances := config.Replicas
-	if instances == 0 {
-		instances = DefaultInstances
+	// A retried provisioning finds the cluster of the earlier attempt
+	_, err = p.dynamicClient.Resource(cnpgGVR).Namespace(req.Namespace).Create(ctx, cluster, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create PostgreSQL cluster: %w", err)
 	}
-	if config.HAEnabled && instances < 3 {
-		instances = 3 // Minimum 3 for HA
+
+	if req.WALArchive == nil {
+		return nil
+	}
+
+	scheduled, err := toUnstructured(buildScheduledBackupManifest(req, resourceName))
+	if err != nil {
+		return err
+	}
+
+	_, err = p.dynamicClient.Resource(cnpgScheduledBackupGVR).Namespace(req.Namespace).Create(ctx, scheduled, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create scheduled base backup: %w", err)
+	}
+	return nil
+}
+
+// toUnstructured converts a manifest built from maps and CRD structs
+func toUnstructured(manifest map[string]interface{}) (*unstructured.Unstructured, error) {
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	var obj unstructured.Unstructured
+	if err := json.Unmarshal(manifestJSON, &obj.Object); err != nil {
+		return nil, fmt.Errorf("failed to convert to unstructured: %w", err)
 	}
+	return &obj, nil
+}
+
+// addonLabels returns the labels set on the CloudNativePG resources of an addon
+func addonLabels(req *ProvisionRequest) map[string]interface{} {
+	return map[string]interface{}{
+		LabelManagedBy: LabelManagedValue,
+		LabelAddonID:   req.Addon.ID.String(),
+		LabelProjectID: req.ProjectID.String(),
+		LabelAddonType: string(types.DatabaseAddonTypePostgres),
+	}
+}
+
+// buildScheduledBackupManifest builds the ScheduledBackup that takes nightly
+// base backups of an archiving cluster. The first runs immediately so the
+// recovery window opens right away.
+func buildScheduledBackupManifest(req *ProvisionRequest, resourceName string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": CloudNativePGAPIVersion,
+		"kind":       CloudNativePGBackupKind,
+		"metadata": map[string]interface{}{
+			"name":      resourceName,
+			"namespace": req.Namespace,
+			"labels":    addonLabels(req),
+		},
+		"spec": map[string]interface{}{
+			"schedule":             pitrBaseBackupSchedule,
+			"immediate":            true,
+			"method":               "barmanObjectStore",
+			"backupOwnerReference": "cluster",
+			"cluster": map[string]interface{}{
+				"name": resourceName,
+			},
+		},
+	}
+}
+
+// buildClusterManifest builds the CloudNativePG Cluster manifest
+func (p *PostgresProvisioner) buildClusterManifest(req *ProvisionRequest, resourceName string) map[string]interface{} {
+	config := req.Addon.Config
 
 	// Parse PostgreSQL version
 	postgresVersion := DefaultPostgresVersion
@@ -134,15 +194,10 @@ func (p *PostgresProvisioner) buildClusterManifest(req *ProvisionRequest, resour
 		"metadata": map[string]interface{}{
 			"name":      resourceName,
 			"namespace": req.Namespace,
-			"labels": map[string]interface{}{
-				LabelManagedBy: LabelManagedValue,
-				LabelAddonID:   req.Addon.ID.String(),
-				LabelProjectID: req.ProjectID.String(),
-				LabelAddonType: string(types.DatabaseAddonTypePostgres),
-			},
+			"labels":    addonLabels(req),
 		},
 		"spec": map[string]interface{}{
-			"instances":             instances,
+			"instances":             postgresInstances(config),
 			"postgresVersion":       postgresVersion,
 			"primaryUpdateStrategy": "unsupervised",
 			"storage": map[string]interface{}{
@@ -159,22 +214,7 @@ func (p *PostgresProvisioner) buildClusterManifest(req *ProvisionRequest, resour
 
 	// Add resource limits if specified
 	spec := cluster["spec"].(map[string]interface{})
-	if config.CPU != "" || config.Memory != "" {
-		resources := map[string]interface{}{}
-		requests := map[string]interface{}{}
-		limits := map[string]interface{}{}
-
-		if config.CPU != "" {
-			requests["cpu"] = config.CPU
-			limits["cpu"] = config.CPU
-		}
-		if config.Memory != "" {
-			requests["memory"] = config.Memory
-			limits["memory"] = config.Memory
-		}
-
-		resources["requests"] = requests
-		resources["limits"] = limits
+	if resources := clusterResources(config); resources != nil {
 		spec["resources"] = resources
 	}
 
@@ -183,9 +223,62 @@ func (p *PostgresProvisioner) buildClusterManifest(req *ProvisionRequest, resour
 		"enablePodMonitor": false, // Can be enabled when monitoring stack is ready
 	}
 
+	// Archive WAL continuously for point-in-time recovery
+	if req.WALArchive != nil {
+		spec["backup"] = req.WALArchive.backupSpec()
+	}
+
+	// Bootstrap from an archive instead of an empty database
+	if req.Recovery != nil {
+		source := req.Recovery.Archive
+		if source == nil {
+			source = req.WALArchive
+		}
+		if source != nil {
+			bootstrap, externalClusters := source.recoveryBootstrap(req.Recovery)
+			spec["bootstrap"] = bootstrap
+			spec["externalClusters"] = externalClusters
+		}
+	}
+
 	return cluster
 }
 
+// postgresInstances returns the instance count for a config (default to 1 for non-HA)
+func postgresInstances(config types.DatabaseAddonConfig) int {
+	instances := config.Replicas
+	if instances == 0 {
+		instances = DefaultInstances
+	}
+	if config.HAEnabled && instances < 3 {
+		instances = 3 // Minimum 3 for HA
+	}
+	return instances
+}
+
+// clusterResources builds the Cluster resources block, or nil if no limits are set
+func clusterResources(config types.DatabaseAddonConfig) map[string]interface{} {
+	if config.CPU == "" && config.Memory == "" {
+		return nil
+	}
+
+	requests := map[string]interface{}{}
+	limits := map[string]interface{}{}
+	if config.CPU != "" {
+		requests["cpu"] = config.CPU
+		limits["cpu"] = config.CPU
+	}
+	if config.Memory != "" {
+		requests["memory"] = config.Memory
+		limits["memory"] = config.Memory
+	}
+
+	return map[string]interface{}{
+		"requests": requests,
+		"limits":   limits,
+	}
+}
+
 // Deprovision removes a PostgreSQL cluster
 func (p *PostgresProvisioner) Deprovision(ctx context.Context, addon *types.DatabaseAddon) error {
 	logger := p.logger.WithFields(logrus.Fields{
@@ -216,6 +309,16 @@ func (p *PostgresProvisioner) Deprovision(ctx context.Context, addon *types.Data
 		return fmt.Errorf("failed to delete PostgreSQL cluster: %w", err)
 	}
 
+	// Remove the base backup schedule of an archiving cluster
+	err = p.dynamicClient.Resource(cnpgScheduledBackupGVR).Namespace(addon.K8sNamespace).Delete(
+		ctx,
+		addon.K8sResourceName,
+		metav1.DeleteOptions{},
+	)
+	if err != nil && !isNotFoundError(err) {
+		logger.WithError(err).Warn("Failed to delete scheduled base backup")
+	}
+
 	logger.Info("PostgreSQL cluster deleted successfully")
 	return nil
 }
@@ -373,6 +476,132 @@ func (p *PostgresProvisioner) GetConnectionURI(ctx context.Context, addon *types
 	return creds.ConnectionURI, nil
 }
 
+// Resize applies addon.Config to the CloudNativePG Cluster. The operator
+// expands the volumes in place and rolls compute changes through the
+// replicas first, switching over the primary last.
+func (p *PostgresProvisioner) Resize(ctx context.Context, addon *types.DatabaseAddon) error {
+	config := addon.Config
+
+	if config.StorageGB > 0 {
+		claims, err := p.clusterClaims(ctx, addon)
+		if err != nil {
+			return err
+		}
+		if err := checkClaimsExpandable(ctx, p.k8sClient, claims, config.StorageGB); err != nil {
+			return err
+		}
+	}
+
+	spec := map[string]interface{}{
+		"instances": postgresInstances(config),
+		// A null resources block clears limits that are no longer set
+		"resources": clusterResources(config),
+	}
+	if config.StorageGB > 0 {
+		spec["storage"] = map[string]interface{}{
+			"size": fmt.Sprintf("%dGi", config.StorageGB),
+		}
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{"spec": spec})
+	if err != nil {
+		return fmt.Errorf("failed to build cluster patch: %w", err)
+	}
+
+	_, err = p.dynamicClient.Resource(cnpgGVR).Namespace(addon.K8sNamespace).Patch(
+		ctx,
+		addon.K8sResourceName,
+		k8stypes.MergePatchType,
+		patch,
+		metav1.PatchOptions{},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to patch PostgreSQL cluster: %w", err)
+	}
+	return nil
+}
+
+// ResizeProgress reports whether the cluster has rolled out its latest spec
+func (p *PostgresProvisioner) ResizeProgress(ctx context.Context, addon *types.DatabaseAddon) (*ResizeProgress, error) {
+	cluster, err := p.dynamicClient.Resource(cnpgGVR).Namespace(addon.K8sNamespace).Get(
+		ctx,
+		addon.K8sResourceName,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster status: %w", err)
+	}
+
+	phase, _, _ := unstructured.NestedString(cluster.Object, "status", "phase")
+	if phase == "Failed" {
+		return &ResizeProgress{Failed: true, Message: "cluster entered failed phase"}, nil
+	}
+
+	instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "instances")
+	readyInstances, _, _ := unstructured.NestedInt64(cluster.Object, "status", "readyInstances")
+	if phase != "Cluster in healthy state" || readyInstances != instances {
+		return &ResizeProgress{
+			Message: fmt.Sprintf("Resizing cluster: %d/%d instances ready (%s)", readyInstances, instances, phase),
+		}, nil
+	}
+
+	claims, err := p.clusterClaims(ctx, addon)
+	if err != nil {
+		return nil, err
+	}
+	return claimsResizeProgress(claims), nil
+}
+
+// ArchiveStatus reads the continuous archiving state and recovery window of a cluster
+func (p *PostgresProvisioner) ArchiveStatus(ctx context.Context, addon *types.DatabaseAddon) (*types.DatabaseAddonPITRStatus, error) {
+	cluster, err := p.dynamicClient.Resource(cnpgGVR).Namespace(addon.K8sNamespace).Get(
+		ctx,
+		addon.K8sResourceName,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster status: %w", err)
+	}
+	return parseArchiveStatus(cluster), nil
+}
+
+// parseArchiveStatus extracts the ContinuousArchiving condition and first
+// recoverability point from a CloudNativePG Cluster
+func parseArchiveStatus(cluster *unstructured.Unstructured) *types.DatabaseAddonPITRStatus {
+	status := &types.DatabaseAddonPITRStatus{Enabled: true}
+
+	if point, _, _ := unstructured.NestedString(cluster.Object, "status", "firstRecoverabilityPoint"); point != "" {
+		if t, err := time.Parse(time.RFC3339, point); err == nil {
+			status.FirstRecoverabilityPoint = &t
+		}
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(cluster.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "ContinuousArchiving" {
+			continue
+		}
+		status.Archiving = condition["status"] == "True"
+		if !status.Archiving {
+			status.Message, _ = condition["message"].(string)
+		}
+	}
+
+	return status
+}
+
+// clusterClaims lists the PVCs of a CloudNativePG cluster
+func (p *PostgresProvisioner) clusterClaims(ctx context.Context, addon *types.DatabaseAddon) ([]corev1.PersistentVolumeClaim, error) {
+	list, err := p.k8sClient.Clientset.CoreV1().PersistentVolumeClaims(addon.K8sNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "cnpg.io/cluster=" + addon.K8sResourceName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster PVCs: %w", err)
+	}
+	return list.Items, nil
+}
+
 // isNotFoundError checks if an error is a "not found" error
 func isNotFoundError(err error) bool {
 	if err == nil {
@@ -3,6 +3,8 @@ package addons
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
@@ -16,6 +18,28 @@ import (
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
+// Redis constants
+const (
+	DefaultRedisPort            = 6379
+	DefaultRedisMaxMemoryPolicy = "noeviction"
+
+	// redisMaxMemoryRatio reserves headroom below the container limit for
+	// replication buffers and fragmentation when maxmemory is not set explicitly
+	redisMaxMemoryRatio = 0.8
+)
+
+// redisEvictionPolicies lists the maxmemory-policy values accepted by Redis 7
+var redisEvictionPolicies = map[string]bool{
+	"noeviction":      true,
+	"allkeys-lru":     true,
+	"allkeys-lfu":     true,
+	"allkeys-random":  true,
+	"volatile-lru":    true,
+	"volatile-lfu":    true,
+	"volatile-random": true,
+	"volatile-ttl":    true,
+}
+
 // RedisProvisioner implements AddonProvisioner for Redis
 type RedisProvisioner struct {
 	k8sClient *k8s.Client
@@ -57,6 +81,51 @@ func (p *RedisProvisioner) Provision(ctx context.Context, req *ProvisionRequest)
 		replicas = 1
 	}
 
+	maxMemory, err := redisMaxMemory(addon.Config)
+	if err != nil {
+		return nil, err
+	}
+	policy := addon.Config.MaxMemoryPolicy
+	if policy == "" {
+		policy = DefaultRedisMaxMemoryPolicy
+	}
+
+	// Generate password and connection secret
+	password, err := generateSecurePassword(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Redis password: %w", err)
+	}
+
+	host := fmt.Sprintf("%s.%s.svc.cluster.local", resourceName, namespace)
+	secretName := fmt.Sprintf("%s-credentials", resourceName)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":             resourceName,
+				LabelManagedBy:    LabelManagedValue,
+				LabelAddonID:      addon.ID.String(),
+				LabelProjectID:    req.ProjectID.String(),
+				LabelAddonType:    string(types.DatabaseAddonTypeRedis),
+				"enclii.dev/type": "addon",
+				"enclii.dev/kind": "redis",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"password": []byte(password),
+			"host":     []byte(host),
+			"port":     []byte(strconv.Itoa(DefaultRedisPort)),
+			"uri":      []byte(redisConnectionURI(host, password)),
+		},
+	}
+
+	_, err = p.k8sClient.Clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create Redis secret: %w", err)
+	}
+
 	// Create headless service for StatefulSet
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -83,7 +152,7 @@ func (p *RedisProvisioner) Provision(ctx context.Context, req *ProvisionRequest)
 		},
 	}
 
-	_, err := p.k8sClient.Clientset.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{})
+	_, err = p.k8sClient.Clientset.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{})
 	if err != nil && !errors.IsAlreadyExists(err) {
 		return nil, fmt.Errorf("failed to create Redis service: %w", err)
 	}
@@ -118,8 +187,19 @@ func (p *RedisProvisioner) Provision(ctx context.Context, req *ProvisionRequest)
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
-							Name:  "redis",
-							Image: "redis:7-alpine",
+							Name:    "redis",
+							Image:   "redis:7-alpine",
+							Command: []string{"redis-server"},
+							Args: []string{
+								"--requirepass", "$(REDIS_PASSWORD)",
+								"--maxmemory", maxMemory,
+								"--maxmemory-policy", policy,
+							},
+							Env: []corev1.EnvVar{
+								secretEnvVar("REDIS_PASSWORD", secretName, "password"),
+								// redis-cli reads REDISCLI_AUTH, which keeps the probes password-free
+								secretEnvVar("REDISCLI_AUTH", secretName, "password"),
+							},
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "redis",
@@ -165,11 +245,14 @@ func (p *RedisProvisioner) Provision(ctx context.Context, req *ProvisionRequest)
 		return nil, fmt.Errorf("failed to create Redis StatefulSet: %w", err)
 	}
 
-	logger.Info("Redis StatefulSet created successfully")
+	logger.WithFields(logrus.Fields{
+		"maxmemory":        maxMemory,
+		"maxmemory_policy": policy,
+	}).Info("Redis StatefulSet created successfully")
 
 	return &ProvisionResult{
 		K8sResourceName:  resourceName,
-		ConnectionSecret: "", // Redis doesn't require a secret by default
+		ConnectionSecret: secretName,
 	}, nil
 }
 
@@ -207,6 +290,18 @@ func (p *RedisProvisioner) Deprovision(ctx context.Context, addon *types.Databas
 		return fmt.Errorf("failed to delete Redis service: %w", err)
 	}
 
+	// Delete Secret
+	if addon.ConnectionSecret != "" {
+		err = p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Delete(
+			ctx,
+			addon.ConnectionSecret,
+			metav1.DeleteOptions{},
+		)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Redis secret: %w", err)
+		}
+	}
+
 	logger.Info("Redis StatefulSet deprovisioned successfully")
 	return nil
 }
@@ -255,21 +350,69 @@ func (p *RedisProvisioner) GetStatus(ctx context.Context, addon *types.DatabaseA
 	return result, nil
 }
 
+// Resize rolls the Redis pod onto new resources. The maxmemory argument is
+// recomputed, since it is derived from the memory limit unless set explicitly.
+func (p *RedisProvisioner) Resize(ctx context.Context, addon *types.DatabaseAddon) error {
+	memory := addon.Config.Memory
+	if memory == "" {
+		memory = DefaultMemory
+	}
+	cpu := addon.Config.CPU
+	if cpu == "" {
+		cpu = "50m"
+	}
+
+	maxMemory, err := redisMaxMemory(addon.Config)
+	if err != nil {
+		return err
+	}
+
+	return resizeStatefulSet(ctx, p.k8sClient, addon, "redis", cpu, memory, func(c *corev1.Container) error {
+		for i := 0; i < len(c.Args)-1; i++ {
+			if c.Args[i] == "--maxmemory" {
+				c.Args[i+1] = maxMemory
+				return nil
+			}
+		}
+		c.Args = append(c.Args, "--maxmemory", maxMemory)
+		return nil
+	})
+}
+
+// ResizeProgress reports whether the Redis StatefulSet has rolled out its latest spec
+func (p *RedisProvisioner) ResizeProgress(ctx context.Context, addon *types.DatabaseAddon) (*ResizeProgress, error) {
+	return statefulSetResizeProgress(ctx, p.k8sClient, addon)
+}
+
 // GetCredentials returns connection credentials for a Redis instance
 func (p *RedisProvisioner) GetCredentials(ctx context.Context, addon *types.DatabaseAddon) (*types.DatabaseAddonCredentials, error) {
-	if addon.Status != types.DatabaseAddonStatusReady {
+	if !addon.IsAvailable() {
 		return nil, fmt.Errorf("addon is not ready")
 	}
 
 	host := fmt.Sprintf("%s.%s.svc.cluster.local", addon.K8sResourceName, addon.K8sNamespace)
 
+	// Addons provisioned before password generation have no secret and run without auth
+	var password string
+	if addon.ConnectionSecret != "" {
+		secret, err := p.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Get(
+			ctx,
+			addon.ConnectionSecret,
+			metav1.GetOptions{},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get connection secret: %w", err)
+		}
+		password = string(secret.Data["password"])
+	}
+
 	return &types.DatabaseAddonCredentials{
 		Host:          host,
-		Port:          6379,
+		Port:          DefaultRedisPort,
 		DatabaseName:  "0",
 		Username:      "",
-		Password:      "", // Redis without auth by default (can be enhanced)
-		ConnectionURI: fmt.Sprintf("redis://%s:6379/0", host),
+		Password:      password,
+		ConnectionURI: redisConnectionURI(host, password),
 	}, nil
 }
 
@@ -281,3 +424,78 @@ func (p *RedisProvisioner) GetConnectionURI(ctx context.Context, addon *types.Da
 	}
 	return creds.ConnectionURI, nil
 }
+
+// redisMaxMemory returns the maxmemory argument for redis-server. An explicit
+// MaxMemory wins; otherwise it is derived from the container memory limit.
+func redisMaxMemory(config types.DatabaseAddonConfig) (string, error) {
+	if config.MaxMemory != "" {
+		bytes, err := parseRedisMemory(config.MaxMemory)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(bytes, 10), nil
+	}
+
+	memory := config.Memory
+	if memory == "" {
+		memory = DefaultMemory
+	}
+	limit, err := resource.ParseQuantity(memory)
+	if err != nil {
+		return "", fmt.Errorf("invalid memory: %s", memory)
+	}
+	return strconv.FormatInt(int64(float64(limit.Value())*redisMaxMemoryRatio), 10), nil
+}
+
+// parseRedisMemory parses a Redis memory size ("100mb", "1gb", "512000") into bytes.
+// Kubernetes quantities ("256Mi") are accepted as well.
+func parseRedisMemory(value string) (int64, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"gb", 1024 * 1024 * 1024},
+		{"mb", 1024 * 1024},
+		{"kb", 1024},
+		{"b", 1},
+	}
+	for _, u := range units {
+		if strings.HasSuffix(v, u.suffix) {
+			n, err := strconv.ParseInt(strings.TrimSuffix(v, u.suffix), 10, 64)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid maxmemory: %s", value)
+			}
+			return n * u.multiplier, nil
+		}
+	}
+
+	q, err := resource.ParseQuantity(value)
+	if err != nil || q.Value() <= 0 {
+		return 0, fmt.Errorf("invalid maxmemory: %s", value)
+	}
+	return q.Value(), nil
+}
+
+// redisConnectionURI builds a redis:// URI, including the password when auth is enabled
+func redisConnectionURI(host, password string) string {
+	if password == "" {
+		return fmt.Sprintf("redis://%s:%d/0", host, DefaultRedisPort)
+	}
+	return fmt.Sprintf("redis://:%s@%s:%d/0", password, host, DefaultRedisPort)
+}
+
+// secretEnvVar builds an env var sourced from a key of an addon secret
+func secretEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package addons
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestRedisMaxMemory(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.DatabaseAddonConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "defaults to 80% of default memory", config: types.DatabaseAddonConfig{}, want: "214748364"},
+		{name: "derived from memory limit", config: types.DatabaseAddonConfig{Memory: "1Gi"}, want: "858993459"},
+		{name: "explicit redis units", config: types.DatabaseAddonConfig{Memory: "1Gi", MaxMemory: "100mb"}, want: "104857600"},
+		{name: "explicit kubernetes quantity", config: types.DatabaseAddonConfig{MaxMemory: "64Mi"}, want: "67108864"},
+		{name: "invalid maxmemory", config: types.DatabaseAddonConfig{MaxMemory: "lots"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := redisMaxMemory(tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("redisMaxMemory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRedisConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.DatabaseAddonConfig
+		wantErr bool
+	}{
+		{name: "empty config", config: types.DatabaseAddonConfig{}},
+		{name: "valid policy", config: types.DatabaseAddonConfig{MaxMemoryPolicy: "allkeys-lru", MaxMemory: "128mb"}},
+		{name: "unknown policy", config: types.DatabaseAddonConfig{MaxMemoryPolicy: "lru"}, wantErr: true},
+		{name: "invalid memory", config: types.DatabaseAddonConfig{Memory: "big"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRedisConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRedisConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,555 @@
+package addons
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// ResizeTimeout is how long a resize may take before it is rolled back
+	ResizeTimeout = 30 * time.Minute
+
+	// resizeSettleTime gives operators and StatefulSet controllers time to
+	// observe the new spec before a healthy status counts as done
+	resizeSettleTime = time.Minute
+
+	// maxPostgresInstances caps the instance count of a CloudNativePG cluster
+	maxPostgresInstances = 9
+)
+
+// ComputePlan is a named CPU and memory class
+type ComputePlan struct {
+	CPU    string
+	Memory string
+}
+
+// ComputePlans are the compute classes selectable via DatabaseAddonUpdateRequest.Plan
+var ComputePlans = map[string]ComputePlan{
+	"small":  {CPU: "250m", Memory: "512Mi"},
+	"medium": {CPU: "500m", Memory: "1Gi"},
+	"large":  {CPU: "1", Memory: "2Gi"},
+	"xlarge": {CPU: "2", Memory: "4Gi"},
+}
+
+// ErrResizeInProgress is returned when an addon is already being resized
+var ErrResizeInProgress = fmt.Errorf("a resize is already in progress for this addon")
+
+// PlanResize applies an update request to an addon's current config and
+// validates the result for the addon type
+func PlanResize(addonType types.DatabaseAddonType, current types.DatabaseAddonConfig, req *types.DatabaseAddonUpdateRequest) (types.DatabaseAddonConfig, error) {
+	target := current
+
+	if req.Plan != "" {
+		plan, ok := ComputePlans[req.Plan]
+		if !ok {
+			return target, fmt.Errorf("unknown plan %q, must be one of: %s", req.Plan, strings.Join(computePlanNames(), ", "))
+		}
+		target.CPU = plan.CPU
+		target.Memory = plan.Memory
+	}
+	if req.CPU != nil {
+		target.CPU = *req.CPU
+	}
+	if req.Memory != nil {
+		target.Memory = *req.Memory
+	}
+	if req.StorageGB != nil {
+		target.StorageGB = *req.StorageGB
+	}
+	if req.Replicas != nil {
+		target.Replicas = *req.Replicas
+	}
+
+	if target == current {
+		return target, fmt.Errorf("no changes requested")
+	}
+
+	for name, value := range map[string]string{"cpu": target.CPU, "memory": target.Memory} {
+		if value == "" {
+			continue
+		}
+		if q, err := resource.ParseQuantity(value); err != nil || q.Sign() <= 0 {
+			return target, fmt.Errorf("invalid %s: %s", name, value)
+		}
+	}
+
+	if target.StorageGB != current.StorageGB {
+		if addonType == types.DatabaseAddonTypeRedis {
+			return target, fmt.Errorf("redis addons have no persistent storage to resize")
+		}
+		if target.StorageGB < current.StorageGB {
+			return target, fmt.Errorf("storage can only be increased (current: %dGi)", current.StorageGB)
+		}
+	}
+
+	if target.Replicas != current.Replicas {
+		if addonType != types.DatabaseAddonTypePostgres {
+			return target, fmt.Errorf("replica changes are only supported for postgres addons")
+		}
+		if target.Replicas < 1 || target.Replicas > maxPostgresInstances {
+			return target, fmt.Errorf("replicas must be between 1 and %d", maxPostgresInstances)
+		}
+		if target.HAEnabled && target.Replicas < 3 {
+			return target, fmt.Errorf("high availability clusters need at least 3 replicas")
+		}
+	}
+
+	if err := ValidateConfig(addonType, target); err != nil {
+		return target, err
+	}
+
+	return target, nil
+}
+
+// rollbackConfig returns the config a failed resize reverts to. Volumes
+// cannot shrink, so an attempted storage increase is kept.
+func rollbackConfig(previous, target types.DatabaseAddonConfig) types.DatabaseAddonConfig {
+	result := previous
+	if target.StorageGB > previous.StorageGB {
+		result.StorageGB = target.StorageGB
+	}
+	return result
+}
+
+// computePlanNames returns the plan names in sorted order
+func computePlanNames() []string {
+	names := make([]string, 0, len(ComputePlans))
+	for name := range ComputePlans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResizeAddon changes an addon's storage, compute class, or replica count.
+// The change is applied in place and tracked to completion by SyncResizes.
+func (s *AddonService) ResizeAddon(ctx context.Context, addonID uuid.UUID, req *types.DatabaseAddonUpdateRequest, actorID *uuid.UUID, actorEmail string) (*types.DatabaseAddonResize, error) {
+	addon, err := s.repos.DatabaseAddons.GetByID(ctx, addonID)
+	if err != nil {
+		return nil, fmt.Errorf("addon not found: %w", err)
+	}
+
+	if addon.Status == types.DatabaseAddonStatusResizing {
+		return nil, ErrResizeInProgress
+	}
+	if addon.Status != types.DatabaseAddonStatusReady {
+		return nil, fmt.Errorf("addon is not ready for resize (status: %s)", addon.Status)
+	}
+
+	resizer, ok := s.provisioners[addon.Type].(AddonResizer)
+	if !ok {
+		return nil, fmt.Errorf("resizing is not supported for %s addons", addon.Type)
+	}
+
+	target, err := PlanResize(addon.Type, addon.Config, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := quota.CheckAddonStorage(ctx, s.repos, addon.ProjectID, target.StorageGB-addon.Config.StorageGB); err != nil {
+		return nil, err
+	}
+
+	// Pods restart during a resize, which would break a running restore job
+	restores, err := s.repos.DatabaseAddons.ListActiveRestores(ctx, &addon.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active restores: %w", err)
+	}
+	if len(restores) > 0 {
+		return nil, ErrRestoreInProgress
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"addon_id": addon.ID,
+		"type":     addon.Type,
+	})
+
+	resize := &types.DatabaseAddonResize{
+		AddonID:          addon.ID,
+		StatusMessage:    "Applying new configuration",
+		PreviousConfig:   addon.Config,
+		TargetConfig:     target,
+		RequestedBy:      actorID,
+		RequestedByEmail: actorEmail,
+	}
+	if err := s.repos.DatabaseAddons.CreateResize(ctx, resize); err != nil {
+		return nil, fmt.Errorf("failed to create resize record: %w", err)
+	}
+
+	addon.Config = target
+	addon.Status = types.DatabaseAddonStatusResizing
+	addon.StatusMessage = resize.StatusMessage
+	if err := s.repos.DatabaseAddons.Update(ctx, addon); err != nil {
+		return nil, fmt.Errorf("failed to update addon: %w", err)
+	}
+
+	if err := resizer.Resize(ctx, addon); err != nil {
+		logger.WithError(err).Error("Failed to apply addon resize")
+		s.rollbackResize(ctx, addon, resize, resizer, err.Error())
+		return nil, fmt.Errorf("failed to apply resize: %w", err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"storage_gb": target.StorageGB,
+		"cpu":        target.CPU,
+		"memory":     target.Memory,
+		"replicas":   target.Replicas,
+	}).Info("Addon resize started")
+
+	return resize, nil
+}
+
+// ListResizes returns the resize history of an addon
+func (s *AddonService) ListResizes(ctx context.Context, addonID uuid.UUID, limit int) ([]*types.DatabaseAddonResize, error) {
+	return s.repos.DatabaseAddons.GetResizesByAddon(ctx, addonID, limit)
+}
+
+// SyncResizes tracks in-progress resizes, completing them once rolled out and
+// rolling them back on failure or timeout. It returns the addons whose resize finished.
+func (s *AddonService) SyncResizes(ctx context.Context) []*types.DatabaseAddon {
+	resizes, err := s.repos.DatabaseAddons.ListActiveResizes(ctx, nil)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list active resizes")
+		return nil
+	}
+
+	var finished []*types.DatabaseAddon
+	for _, resize := range resizes {
+		addon, err := s.repos.DatabaseAddons.GetByID(ctx, resize.AddonID)
+		if err != nil {
+			s.logger.WithError(err).WithField("resize_id", resize.ID).Warn("Failed to get addon for resize")
+			continue
+		}
+		done, err := s.syncResize(ctx, addon, resize)
+		if err != nil {
+			s.logger.WithError(err).WithField("resize_id", resize.ID).Warn("Failed to sync resize status")
+			continue
+		}
+		if done {
+			finished = append(finished, addon)
+		}
+	}
+
+	return finished
+}
+
+// syncResize checks a resize's rollout and reports whether it finished
+func (s *AddonService) syncResize(ctx context.Context, addon *types.DatabaseAddon, resize *types.DatabaseAddonResize) (bool, error) {
+	resizer, ok := s.provisioners[addon.Type].(AddonResizer)
+	if !ok {
+		addon.Status = types.DatabaseAddonStatusFailed
+		addon.StatusMessage = fmt.Sprintf("Resizing is not supported for %s addons", addon.Type)
+		s.finishResize(ctx, addon, resize, types.DatabaseAddonResizeStatusFailed, addon.StatusMessage)
+		return true, nil
+	}
+
+	progress, err := resizer.ResizeProgress(ctx, addon)
+	if err != nil {
+		return false, err
+	}
+
+	elapsed := time.Since(resize.StartedAt)
+	switch {
+	case progress.Failed:
+		s.rollbackResize(ctx, addon, resize, resizer, progress.Message)
+		return true, nil
+	case progress.Done && elapsed >= resizeSettleTime:
+		addon.Status = types.DatabaseAddonStatusReady
+		addon.StatusMessage = "Resize completed"
+		s.finishResize(ctx, addon, resize, types.DatabaseAddonResizeStatusCompleted, addon.StatusMessage)
+		return true, nil
+	case elapsed > ResizeTimeout:
+		s.rollbackResize(ctx, addon, resize, resizer, fmt.Sprintf("timed out after %s (%s)", ResizeTimeout, progress.Message))
+		return true, nil
+	}
+
+	if progress.Message != "" && progress.Message != addon.StatusMessage {
+		addon.StatusMessage = progress.Message
+		return false, s.repos.DatabaseAddons.Update(ctx, addon)
+	}
+	return false, nil
+}
+
+// rollbackResize re-applies the addon's previous config after a failed resize
+func (s *AddonService) rollbackResize(ctx context.Context, addon *types.DatabaseAddon, resize *types.DatabaseAddonResize, resizer AddonResizer, reason string) {
+	addon.Config = rollbackConfig(resize.PreviousConfig, resize.TargetConfig)
+
+	if err := resizer.Resize(ctx, addon); err != nil {
+		addon.Status = types.DatabaseAddonStatusFailed
+		addon.StatusMessage = fmt.Sprintf("Resize failed (%s) and rollback failed: %s", reason, err)
+		s.finishResize(ctx, addon, resize, types.DatabaseAddonResizeStatusFailed, addon.StatusMessage)
+		return
+	}
+
+	message := "Resize rolled back: " + reason
+	if addon.Config.StorageGB != resize.PreviousConfig.StorageGB {
+		message += fmt.Sprintf("; volumes cannot shrink, so storage stays at %dGi", addon.Config.StorageGB)
+	}
+	addon.Status = types.DatabaseAddonStatusReady
+	addon.StatusMessage = message
+	s.finishResize(ctx, addon, resize, types.DatabaseAddonResizeStatusRolledBack, message)
+}
+
+// finishResize records the outcome of a resize on the addon and resize record
+// and writes it to the audit log
+func (s *AddonService) finishResize(ctx context.Context, addon *types.DatabaseAddon, resize *types.DatabaseAddonResize, status types.DatabaseAddonResizeStatus, message string) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"addon_id":  addon.ID,
+		"resize_id": resize.ID,
+		"status":    status,
+	})
+
+	if err := s.repos.DatabaseAddons.Update(ctx, addon); err != nil {
+		logger.WithError(err).Error("Failed to update addon after resize")
+	}
+
+	now := time.Now()
+	resize.Status = status
+	resize.StatusMessage = message
+	resize.CompletedAt = &now
+	if err := s.repos.DatabaseAddons.UpdateResize(ctx, resize); err != nil {
+		logger.WithError(err).Error("Failed to update resize record")
+	}
+
+	outcome := "success"
+	action := "addon.resize_completed"
+	if status != types.DatabaseAddonResizeStatusCompleted {
+		outcome = "failure"
+		action = "addon.resize_failed"
+	}
+
+	if err := s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      resize.RequestedBy,
+		ActorEmail:   resize.RequestedByEmail,
+		ActorRole:    types.RoleSystem,
+		Action:       action,
+		ResourceType: "addon",
+		ResourceID:   addon.ID.String(),
+		ResourceName: addon.Name,
+		ProjectID:    &addon.ProjectID,
+		Outcome:      outcome,
+		Context: map[string]interface{}{
+			"resize_id":  resize.ID.String(),
+			"addon_type": addon.Type,
+			"status":     status,
+			"message":    message,
+		},
+	}); err != nil {
+		logger.WithError(err).Warn("Failed to write resize audit log")
+	}
+
+	logger.WithField("message", message).Info("Addon resize finished")
+}
+
+// resizeStatefulSet applies addon.Config to a StatefulSet-backed addon, with
+// cpu and memory already defaulted by the caller. Volume claim templates are
+// immutable, so existing PVCs are expanded in place; the pod template change
+// rolls the pods onto the new resources.
+func resizeStatefulSet(ctx context.Context, k8sClient *k8s.Client, addon *types.DatabaseAddon, container, cpu, memory string, mutate func(*corev1.Container) error) error {
+	statefulSets := k8sClient.Clientset.AppsV1().StatefulSets(addon.K8sNamespace)
+	sts, err := statefulSets.Get(ctx, addon.K8sResourceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get StatefulSet: %w", err)
+	}
+
+	if addon.Config.StorageGB > 0 && len(sts.Spec.VolumeClaimTemplates) > 0 {
+		claims, err := getClaims(ctx, k8sClient, addon.K8sNamespace, statefulSetClaimNames(sts.Name, sts.Spec.VolumeClaimTemplates, sts.Spec.Replicas))
+		if err != nil {
+			return err
+		}
+		if err := expandClaims(ctx, k8sClient, claims, addon.Config.StorageGB); err != nil {
+			return err
+		}
+	}
+
+	found := false
+	for i := range sts.Spec.Template.Spec.Containers {
+		c := &sts.Spec.Template.Spec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		found = true
+		if err := setContainerResources(c, cpu, memory); err != nil {
+			return err
+		}
+		if mutate != nil {
+			if err := mutate(c); err != nil {
+				return err
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("container %q not found in StatefulSet %s", container, sts.Name)
+	}
+
+	if _, err := statefulSets.Update(ctx, sts, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update StatefulSet: %w", err)
+	}
+	return nil
+}
+
+// statefulSetResizeProgress reports whether a StatefulSet has rolled out its
+// latest spec and its volumes have been expanded
+func statefulSetResizeProgress(ctx context.Context, k8sClient *k8s.Client, addon *types.DatabaseAddon) (*ResizeProgress, error) {
+	sts, err := k8sClient.Clientset.AppsV1().StatefulSets(addon.K8sNamespace).Get(ctx, addon.K8sResourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get StatefulSet: %w", err)
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	status := sts.Status
+	if status.ObservedGeneration < sts.Generation ||
+		status.UpdatedReplicas < replicas ||
+		status.ReadyReplicas < replicas ||
+		(status.UpdateRevision != "" && status.CurrentRevision != status.UpdateRevision) {
+		return &ResizeProgress{
+			Message: fmt.Sprintf("Rolling out pods: %d/%d updated, %d ready", status.UpdatedReplicas, replicas, status.ReadyReplicas),
+		}, nil
+	}
+
+	claims, err := getClaims(ctx, k8sClient, addon.K8sNamespace, statefulSetClaimNames(sts.Name, sts.Spec.VolumeClaimTemplates, sts.Spec.Replicas))
+	if err != nil {
+		return nil, err
+	}
+	return claimsResizeProgress(claims), nil
+}
+
+// statefulSetClaimNames returns the PVC names created from a StatefulSet's claim templates
+func statefulSetClaimNames(name string, templates []corev1.PersistentVolumeClaim, replicas *int32) []string {
+	count := int32(1)
+	if replicas != nil {
+		count = *replicas
+	}
+
+	var names []string
+	for _, template := range templates {
+		for i := int32(0); i < count; i++ {
+			names = append(names, fmt.Sprintf("%s-%s-%d", template.Name, name, i))
+		}
+	}
+	return names
+}
+
+// getClaims fetches PVCs by name
+func getClaims(ctx context.Context, k8sClient *k8s.Client, namespace string, names []string) ([]corev1.PersistentVolumeClaim, error) {
+	claims := make([]corev1.PersistentVolumeClaim, 0, len(names))
+	for _, name := range names {
+		pvc, err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PVC %s: %w", name, err)
+		}
+		claims = append(claims, *pvc)
+	}
+	return claims, nil
+}
+
+// checkClaimsExpandable verifies every claim that must grow to storageGB uses
+// a storage class that allows volume expansion
+func checkClaimsExpandable(ctx context.Context, k8sClient *k8s.Client, claims []corev1.PersistentVolumeClaim, storageGB int) error {
+	size := resource.MustParse(fmt.Sprintf("%dGi", storageGB))
+	checked := map[string]bool{}
+
+	for _, pvc := range claims {
+		current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if current.Cmp(size) >= 0 {
+			continue
+		}
+		if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+			return fmt.Errorf("PVC %s has no storage class and cannot be expanded", pvc.Name)
+		}
+
+		className := *pvc.Spec.StorageClassName
+		if checked[className] {
+			continue
+		}
+		class, err := k8sClient.Clientset.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get storage class %s: %w", className, err)
+		}
+		if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+			return fmt.Errorf("storage class %s does not allow volume expansion", className)
+		}
+		checked[className] = true
+	}
+	return nil
+}
+
+// expandClaims raises the storage request of each claim to storageGB. All
+// claims are checked before any is changed so a resize is never half-applied.
+func expandClaims(ctx context.Context, k8sClient *k8s.Client, claims []corev1.PersistentVolumeClaim, storageGB int) error {
+	if err := checkClaimsExpandable(ctx, k8sClient, claims, storageGB); err != nil {
+		return err
+	}
+
+	size := resource.MustParse(fmt.Sprintf("%dGi", storageGB))
+	for i := range claims {
+		pvc := &claims[i]
+		current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if current.Cmp(size) >= 0 {
+			continue
+		}
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+		if _, err := k8sClient.Clientset.CoreV1().PersistentVolumeClaims(pvc.Namespace).Update(ctx, pvc, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to expand PVC %s: %w", pvc.Name, err)
+		}
+	}
+	return nil
+}
+
+// claimsResizeProgress reports whether all claims have reached their requested size
+func claimsResizeProgress(claims []corev1.PersistentVolumeClaim) *ResizeProgress {
+	for _, pvc := range claims {
+		switch pvc.Status.AllocatedResourceStatuses[corev1.ResourceStorage] {
+		case corev1.PersistentVolumeClaimControllerResizeFailed, corev1.PersistentVolumeClaimNodeResizeFailed:
+			return &ResizeProgress{Failed: true, Message: fmt.Sprintf("volume expansion of %s failed", pvc.Name)}
+		}
+
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+		if capacity.Cmp(requested) < 0 {
+			return &ResizeProgress{
+				Message: fmt.Sprintf("Expanding volume %s (%s of %s)", pvc.Name, capacity.String(), requested.String()),
+			}
+		}
+	}
+	return &ResizeProgress{Done: true, Message: "Resize applied"}
+}
+
+// setContainerResources sets CPU and memory the same way the provisioners do:
+// CPU as a request, memory as both request and limit
+func setContainerResources(c *corev1.Container, cpu, memory string) error {
+	cpuQuantity, err := resource.ParseQuantity(cpu)
+	if err != nil {
+		return fmt.Errorf("invalid cpu: %s", cpu)
+	}
+	memoryQuantity, err := resource.ParseQuantity(memory)
+	if err != nil {
+		return fmt.Errorf("invalid memory: %s", memory)
+	}
+
+	if c.Resources.Requests == nil {
+		c.Resources.Requests = corev1.ResourceList{}
+	}
+	if c.Resources.Limits == nil {
+		c.Resources.Limits = corev1.ResourceList{}
+	}
+	c.Resources.Requests[corev1.ResourceCPU] = cpuQuantity
+	c.Resources.Requests[corev1.ResourceMemory] = memoryQuantity
+	c.Resources.Limits[corev1.ResourceMemory] = memoryQuantity
+	return nil
+}
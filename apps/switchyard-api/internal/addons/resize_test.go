@@ -0,0 +1,195 @@
+package addons
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestPlanResize(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	strPtr := func(v string) *string { return &v }
+
+	current := types.DatabaseAddonConfig{StorageGB: 10, CPU: "250m", Memory: "512Mi", Replicas: 1}
+
+	tests := []struct {
+		name      string
+		addonType types.DatabaseAddonType
+		current   types.DatabaseAddonConfig
+		req       types.DatabaseAddonUpdateRequest
+		want      types.DatabaseAddonConfig
+		wantErr   bool
+	}{
+		{
+			name:      "grow storage",
+			addonType: types.DatabaseAddonTypeMySQL,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{StorageGB: intPtr(20)},
+			want:      types.DatabaseAddonConfig{StorageGB: 20, CPU: "250m", Memory: "512Mi", Replicas: 1},
+		},
+		{
+			name:      "shrink storage",
+			addonType: types.DatabaseAddonTypeMySQL,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{StorageGB: intPtr(5)},
+			wantErr:   true,
+		},
+		{
+			name:      "plan sets compute class",
+			addonType: types.DatabaseAddonTypePostgres,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{Plan: "large"},
+			want:      types.DatabaseAddonConfig{StorageGB: 10, CPU: "1", Memory: "2Gi", Replicas: 1},
+		},
+		{
+			name:      "explicit memory overrides plan",
+			addonType: types.DatabaseAddonTypePostgres,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{Plan: "large", Memory: strPtr("3Gi")},
+			want:      types.DatabaseAddonConfig{StorageGB: 10, CPU: "1", Memory: "3Gi", Replicas: 1},
+		},
+		{
+			name:      "unknown plan",
+			addonType: types.DatabaseAddonTypePostgres,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{Plan: "huge"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid cpu",
+			addonType: types.DatabaseAddonTypeMongoDB,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{CPU: strPtr("lots")},
+			wantErr:   true,
+		},
+		{
+			name:      "no changes",
+			addonType: types.DatabaseAddonTypePostgres,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{StorageGB: intPtr(10)},
+			wantErr:   true,
+		},
+		{
+			name:      "postgres replicas",
+			addonType: types.DatabaseAddonTypePostgres,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{Replicas: intPtr(3)},
+			want:      types.DatabaseAddonConfig{StorageGB: 10, CPU: "250m", Memory: "512Mi", Replicas: 3},
+		},
+		{
+			name:      "replicas beyond limit",
+			addonType: types.DatabaseAddonTypePostgres,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{Replicas: intPtr(12)},
+			wantErr:   true,
+		},
+		{
+			name:      "ha cluster below three replicas",
+			addonType: types.DatabaseAddonTypePostgres,
+			current:   types.DatabaseAddonConfig{StorageGB: 10, Replicas: 3, HAEnabled: true},
+			req:       types.DatabaseAddonUpdateRequest{Replicas: intPtr(2)},
+			wantErr:   true,
+		},
+		{
+			name:      "replicas on mysql",
+			addonType: types.DatabaseAddonTypeMySQL,
+			current:   current,
+			req:       types.DatabaseAddonUpdateRequest{Replicas: intPtr(3)},
+			wantErr:   true,
+		},
+		{
+			name:      "storage on redis",
+			addonType: types.DatabaseAddonTypeRedis,
+			current:   types.DatabaseAddonConfig{Memory: "256Mi"},
+			req:       types.DatabaseAddonUpdateRequest{StorageGB: intPtr(5)},
+			wantErr:   true,
+		},
+		{
+			name:      "redis memory",
+			addonType: types.DatabaseAddonTypeRedis,
+			current:   types.DatabaseAddonConfig{Memory: "256Mi"},
+			req:       types.DatabaseAddonUpdateRequest{Memory: strPtr("1Gi")},
+			want:      types.DatabaseAddonConfig{Memory: "1Gi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PlanResize(tt.addonType, tt.current, &tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PlanResize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("PlanResize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollbackConfig(t *testing.T) {
+	previous := types.DatabaseAddonConfig{StorageGB: 10, CPU: "250m", Memory: "512Mi"}
+
+	tests := []struct {
+		name   string
+		target types.DatabaseAddonConfig
+		want   types.DatabaseAddonConfig
+	}{
+		{
+			name:   "compute change reverts fully",
+			target: types.DatabaseAddonConfig{StorageGB: 10, CPU: "1", Memory: "2Gi"},
+			want:   previous,
+		},
+		{
+			name:   "storage increase is kept",
+			target: types.DatabaseAddonConfig{StorageGB: 20, CPU: "1", Memory: "2Gi"},
+			want:   types.DatabaseAddonConfig{StorageGB: 20, CPU: "250m", Memory: "512Mi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollbackConfig(previous, tt.target); got != tt.want {
+				t.Errorf("rollbackConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimsResizeProgress(t *testing.T) {
+	claim := func(requested, capacity string, status corev1.ClaimResourceStatus) corev1.PersistentVolumeClaim {
+		pvc := corev1.PersistentVolumeClaim{}
+		pvc.Name = "data-db-0"
+		pvc.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(requested)}
+		pvc.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)}
+		if status != "" {
+			pvc.Status.AllocatedResourceStatuses = map[corev1.ResourceName]corev1.ClaimResourceStatus{
+				corev1.ResourceStorage: status,
+			}
+		}
+		return pvc
+	}
+
+	tests := []struct {
+		name       string
+		claims     []corev1.PersistentVolumeClaim
+		wantDone   bool
+		wantFailed bool
+	}{
+		{name: "expanded", claims: []corev1.PersistentVolumeClaim{claim("20Gi", "20Gi", "")}, wantDone: true},
+		{name: "expanding", claims: []corev1.PersistentVolumeClaim{claim("20Gi", "10Gi", corev1.PersistentVolumeClaimControllerResizeInProgress)}},
+		{name: "expansion failed", claims: []corev1.PersistentVolumeClaim{claim("20Gi", "10Gi", corev1.PersistentVolumeClaimNodeResizeFailed)}, wantFailed: true},
+		{name: "no claims", wantDone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := claimsResizeProgress(tt.claims)
+			if got.Done != tt.wantDone || got.Failed != tt.wantFailed {
+				t.Errorf("claimsResizeProgress() = %+v, want done=%v failed=%v", got, tt.wantDone, tt.wantFailed)
+			}
+		})
+	}
+}
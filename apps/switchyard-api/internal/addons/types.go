@@ -25,11 +25,33 @@ type AddonProvisioner interface {
 	GetConnectionURI(ctx context.Context, addon *types.DatabaseAddon) (string, error)
 }
 
+// AddonResizer is implemented by provisioners that can resize an addon in place
+type AddonResizer interface {
+	// Resize applies addon.Config to the addon's Kubernetes resources
+	Resize(ctx context.Context, addon *types.DatabaseAddon) error
+
+	// ResizeProgress reports whether the last applied config has rolled out
+	ResizeProgress(ctx context.Context, addon *types.DatabaseAddon) (*ResizeProgress, error)
+}
+
+// ResizeProgress reports how far a resize has been applied
+type ResizeProgress struct {
+	Done    bool
+	Failed  bool
+	Message string
+}
+
 // ProvisionRequest contains the details for provisioning a new addon
 type ProvisionRequest struct {
 	Addon     *types.DatabaseAddon
 	Namespace string
 	ProjectID uuid.UUID
+
+	// WALArchive enables continuous WAL archiving (PostgreSQL only)
+	WALArchive *WALArchive
+
+	// Recovery bootstraps the cluster from WALArchive instead of initdb
+	Recovery *PointInTimeRecovery
 }
 
 // ProvisionResult contains the result of a provisioning operation
@@ -65,16 +87,17 @@ type CloudNativePGMetadata struct {
 }
 
 type CloudNativePGClusterSpec struct {
-	Instances             int                      `json:"instances"`
-	ImageName             string                   `json:"imageName,omitempty"`
-	PostgresVersion       int                      `json:"postgresVersion,omitempty"`
-	PrimaryUpdateStrategy string                   `json:"primaryUpdateStrategy,omitempty"`
-	Storage               CloudNativePGStorage     `json:"storage"`
-	Resources             CloudNativePGResources   `json:"resources,omitempty"`
-	Bootstrap             *CloudNativePGBootstrap  `json:"bootstrap,omitempty"`
-	Monitoring            *CloudNativePGMonitoring `json:"monitoring,omitempty"`
-	Backup                *CloudNativePGBackupSpec `json:"backup,omitempty"`
-	SuperuserSecret       *CloudNativePGSecretRef  `json:"superuserSecret,omitempty"`
+	Instances             int                            `json:"instances"`
+	ImageName             string                         `json:"imageName,omitempty"`
+	PostgresVersion       int                            `json:"postgresVersion,omitempty"`
+	PrimaryUpdateStrategy string                         `json:"primaryUpdateStrategy,omitempty"`
+	Storage               CloudNativePGStorage           `json:"storage"`
+	Resources             CloudNativePGResources         `json:"resources,omitempty"`
+	Bootstrap             *CloudNativePGBootstrap        `json:"bootstrap,omitempty"`
+	Monitoring            *CloudNativePGMonitoring       `json:"monitoring,omitempty"`
+	Backup                *CloudNativePGBackupSpec       `json:"backup,omitempty"`
+	SuperuserSecret       *CloudNativePGSecretRef        `json:"superuserSecret,omitempty"`
+	ExternalClusters      []CloudNativePGExternalCluster `json:"externalClusters,omitempty"`
 }
 
 type CloudNativePGStorage struct {
@@ -93,7 +116,8 @@ type CloudNativePGResourceList struct {
 }
 
 type CloudNativePGBootstrap struct {
-	InitDB *CloudNativePGInitDB `json:"initdb,omitempty"`
+	InitDB   *CloudNativePGInitDB   `json:"initdb,omitempty"`
+	Recovery *CloudNativePGRecovery `json:"recovery,omitempty"`
 }
 
 type CloudNativePGInitDB struct {
@@ -102,6 +126,22 @@ type CloudNativePGInitDB struct {
 	Secret   *CloudNativePGSecretRef `json:"secret,omitempty"`
 }
 
+type CloudNativePGRecovery struct {
+	Source         string                       `json:"source"`
+	Database       string                       `json:"database,omitempty"`
+	Owner          string                       `json:"owner,omitempty"`
+	RecoveryTarget *CloudNativePGRecoveryTarget `json:"recoveryTarget,omitempty"`
+}
+
+type CloudNativePGRecoveryTarget struct {
+	TargetTime string `json:"targetTime,omitempty"`
+}
+
+type CloudNativePGExternalCluster struct {
+	Name              string                    `json:"name"`
+	BarmanObjectStore *CloudNativePGBarmanStore `json:"barmanObjectStore,omitempty"`
+}
+
 type CloudNativePGSecretRef struct {
 	Name string `json:"name"`
 }
@@ -117,6 +157,7 @@ type CloudNativePGBackupSpec struct {
 
 type CloudNativePGBarmanStore struct {
 	DestinationPath string                      `json:"destinationPath"`
+	ServerName      string                      `json:"serverName,omitempty"`
 	EndpointURL     string                      `json:"endpointURL,omitempty"`
 	S3Credentials   *CloudNativePGS3Credentials `json:"s3Credentials,omitempty"`
 	Wal             *CloudNativePGWalConfig     `json:"wal,omitempty"`
@@ -124,8 +165,9 @@ type CloudNativePGBarmanStore struct {
 }
 
 type CloudNativePGS3Credentials struct {
-	AccessKeyID     CloudNativePGSecretKeyRef `json:"accessKeyId"`
-	SecretAccessKey CloudNativePGSecretKeyRef `json:"secretAccessKey"`
+	AccessKeyID     CloudNativePGSecretKeyRef  `json:"accessKeyId"`
+	SecretAccessKey CloudNativePGSecretKeyRef  `json:"secretAccessKey"`
+	Region          *CloudNativePGSecretKeyRef `json:"region,omitempty"`
 }
 
 type CloudNativePGSecretKeyRef struct {
@@ -154,6 +196,7 @@ const (
 	// CloudNativePG constants
 	CloudNativePGAPIVersion = "postgresql.cnpg.io/v1"
 	CloudNativePGKind       = "Cluster"
+	CloudNativePGBackupKind = "ScheduledBackup"
 
 	// Labels
 	LabelManagedBy    = "managed-by"
@@ -0,0 +1,230 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/soak"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// recentOOMWindow is how long after an OOM kill the oom_killed
+	// condition holds
+	recentOOMWindow = 15 * time.Minute
+
+	// errorRateWindow is the window error rates are taken over
+	errorRateWindow = 5 * time.Minute
+)
+
+// ErrNoErrorRates is returned for error_rate rules when no error rate
+// source is configured
+var ErrNoErrorRates = errors.New("error rates are not configured")
+
+// Target is a service deployed in an environment
+type Target struct {
+	Namespace string
+	App       string // app label of the service's pods and name of its Deployment
+}
+
+// Observation is the state of a rule's condition in one environment
+type Observation struct {
+	Met     bool
+	Summary string
+	Value   *float64
+}
+
+// Checker observes the conditions of alert rules in the cluster
+type Checker struct {
+	kube       kubernetes.Interface
+	errorRates soak.ErrorRateSource
+}
+
+// NewChecker creates a checker. Without an error rate source, error_rate
+// rules cannot be checked.
+func NewChecker(kube kubernetes.Interface, errorRates soak.ErrorRateSource) *Checker {
+	return &Checker{kube: kube, errorRates: errorRates}
+}
+
+// Check observes a rule's condition for a target
+func (c *Checker) Check(ctx context.Context, rule *types.AlertRule, target Target, now time.Time) (*Observation, error) {
+	switch rule.Kind {
+	case types.AlertRuleHPAAtMax:
+		return c.checkAutoscaler(ctx, target)
+	case types.AlertRuleErrorRate:
+		return c.checkErrorRate(ctx, target, rule.Threshold, now)
+	}
+
+	pods, err := c.kube.CoreV1().Pods(target.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=" + target.App,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	switch rule.Kind {
+	case types.AlertRuleCrashLoop:
+		return crashLooping(pods.Items), nil
+	case types.AlertRuleRestartCount:
+		return restartCount(pods.Items, rule.Threshold), nil
+	case types.AlertRuleOOMKilled:
+		return oomKilled(pods.Items, now), nil
+	case types.AlertRuleReadinessFailing:
+		return readinessFailing(pods.Items), nil
+	default:
+		return nil, fmt.Errorf("unknown alert rule kind %q", rule.Kind)
+	}
+}
+
+// crashLooping holds when a container of the pods is in CrashLoopBackOff
+func crashLooping(pods []corev1.Pod) *Observation {
+	count := 0
+	first := ""
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+				if count == 0 {
+					first = fmt.Sprintf("%s of pod %s", status.Name, pod.Name)
+				}
+				count++
+			}
+		}
+	}
+
+	value := float64(count)
+	switch count {
+	case 0:
+		return &Observation{Summary: "No containers are crash looping", Value: &value}
+	case 1:
+		return &Observation{Met: true, Summary: "Container " + first + " is in CrashLoopBackOff", Value: &value}
+	default:
+		return &Observation{Met: true, Summary: fmt.Sprintf("%d containers are in CrashLoopBackOff, including %s", count, first), Value: &value}
+	}
+}
+
+// restartCount holds when the containers of the current pods restarted
+// more than threshold times in total
+func restartCount(pods []corev1.Pod, threshold float64) *Observation {
+	total := 0
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			total += int(status.RestartCount)
+		}
+	}
+
+	value := float64(total)
+	return &Observation{
+		Met:     value > threshold,
+		Summary: fmt.Sprintf("Containers restarted %d times, %g allowed", total, threshold),
+		Value:   &value,
+	}
+}
+
+// oomKilled holds when a container of the pods was killed for running out
+// of memory within recentOOMWindow
+func oomKilled(pods []corev1.Pod, now time.Time) *Observation {
+	count := 0
+	first := ""
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil {
+				terminated = status.LastTerminationState.Terminated
+			}
+			if terminated == nil || terminated.Reason != "OOMKilled" || now.Sub(terminated.FinishedAt.Time) > recentOOMWindow {
+				continue
+			}
+			if count == 0 {
+				first = fmt.Sprintf("%s of pod %s", status.Name, pod.Name)
+			}
+			count++
+		}
+	}
+
+	value := float64(count)
+	switch count {
+	case 0:
+		return &Observation{Summary: "No containers were OOM killed recently", Value: &value}
+	case 1:
+		return &Observation{Met: true, Summary: "Container " + first + " was OOM killed", Value: &value}
+	default:
+		return &Observation{Met: true, Summary: fmt.Sprintf("%d containers were OOM killed, including %s", count, first), Value: &value}
+	}
+}
+
+// readinessFailing holds when a running pod is not ready. Pods shutting
+// down are left out.
+func readinessFailing(pods []corev1.Pod) *Observation {
+	running, unready := 0, 0
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		running++
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status != corev1.ConditionTrue {
+				unready++
+				break
+			}
+		}
+	}
+
+	value := float64(unready)
+	return &Observation{
+		Met:     unready > 0,
+		Summary: fmt.Sprintf("%d of %d running pods are not ready", unready, running),
+		Value:   &value,
+	}
+}
+
+// checkAutoscaler holds when an autoscaler of the service's Deployment
+// runs the most replicas it may
+func (c *Checker) checkAutoscaler(ctx context.Context, target Target) (*Observation, error) {
+	hpas, err := c.kube.AutoscalingV2().HorizontalPodAutoscalers(target.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list autoscalers: %w", err)
+	}
+
+	for _, hpa := range hpas.Items {
+		ref := hpa.Spec.ScaleTargetRef
+		if ref.Kind != "Deployment" || ref.Name != target.App {
+			continue
+		}
+
+		value := float64(hpa.Status.CurrentReplicas)
+		return &Observation{
+			Met:     hpa.Status.CurrentReplicas >= hpa.Spec.MaxReplicas,
+			Summary: fmt.Sprintf("Autoscaler %s runs %d of at most %d replicas", hpa.Name, hpa.Status.CurrentReplicas, hpa.Spec.MaxReplicas),
+			Value:   &value,
+		}, nil
+	}
+	return &Observation{Summary: "The service has no autoscaler"}, nil
+}
+
+// checkErrorRate holds when the share of failed requests over
+// errorRateWindow exceeds threshold. Without traffic it does not hold.
+func (c *Checker) checkErrorRate(ctx context.Context, target Target, threshold float64, now time.Time) (*Observation, error) {
+	if c.errorRates == nil {
+		return nil, ErrNoErrorRates
+	}
+
+	rate, err := c.errorRates.ErrorRate(ctx, target.Namespace, target.App, now.Add(-errorRateWindow))
+	if err != nil {
+		return nil, err
+	}
+	if rate == nil {
+		return &Observation{Summary: "No requests were served"}, nil
+	}
+
+	return &Observation{
+		Met:     *rate > threshold,
+		Summary: fmt.Sprintf("%.1f%% of requests failed over the last %s, %.1f%% allowed", *rate*100, errorRateWindow, threshold*100),
+		Value:   rate,
+	}, nil
+}
@@ -0,0 +1,152 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func pod(name string, phase corev1.PodPhase, ready bool, statuses ...corev1.ContainerStatus) corev1.Pod {
+	readiness := corev1.ConditionFalse
+	if ready {
+		readiness = corev1.ConditionTrue
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "enclii-shop-production", Labels: map[string]string{"app": "api"}},
+		Status: corev1.PodStatus{
+			Phase:             phase,
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: readiness}},
+			ContainerStatuses: statuses,
+		},
+	}
+}
+
+func TestPodConditions(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	crashing := corev1.ContainerStatus{
+		Name:         "web",
+		RestartCount: 6,
+		State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}
+	oomKilledRecently := corev1.ContainerStatus{
+		Name:         "web",
+		RestartCount: 1,
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			Reason:     "OOMKilled",
+			FinishedAt: metav1.NewTime(now.Add(-5 * time.Minute)),
+		}},
+	}
+	oomKilledLongAgo := oomKilledRecently
+	oomKilledLongAgo.LastTerminationState = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+		Reason:     "OOMKilled",
+		FinishedAt: metav1.NewTime(now.Add(-time.Hour)),
+	}}
+	healthy := corev1.ContainerStatus{Name: "web", Ready: true}
+
+	pods := []corev1.Pod{
+		pod("api-1", corev1.PodRunning, false, crashing),
+		pod("api-2", corev1.PodRunning, true, oomKilledRecently),
+		pod("api-3", corev1.PodPending, false, healthy),
+	}
+
+	if got := crashLooping(pods); !got.Met || got.Summary != "Container web of pod api-1 is in CrashLoopBackOff" {
+		t.Errorf("crashLooping() = %+v", got)
+	}
+	if got := crashLooping(pods[1:]); got.Met {
+		t.Errorf("crashLooping() without crash loops = %+v", got)
+	}
+
+	if got := restartCount(pods, 5); !got.Met || *got.Value != 7 {
+		t.Errorf("restartCount(5) = %+v", got)
+	}
+	if got := restartCount(pods, 7); got.Met {
+		t.Errorf("restartCount(7) held at exactly the threshold")
+	}
+
+	if got := oomKilled(pods, now); !got.Met || !strings.Contains(got.Summary, "api-2") {
+		t.Errorf("oomKilled() = %+v", got)
+	}
+	stale := []corev1.Pod{pod("api-2", corev1.PodRunning, true, oomKilledLongAgo)}
+	if got := oomKilled(stale, now); got.Met {
+		t.Errorf("oomKilled() held for a kill an hour ago")
+	}
+
+	// The pending pod is not running, so only api-1 counts
+	if got := readinessFailing(pods); !got.Met || got.Summary != "1 of 2 running pods are not ready" {
+		t.Errorf("readinessFailing() = %+v", got)
+	}
+	terminating := pod("api-4", corev1.PodRunning, false, healthy)
+	terminating.DeletionTimestamp = &metav1.Time{Time: now}
+	if got := readinessFailing([]corev1.Pod{terminating}); got.Met {
+		t.Errorf("readinessFailing() held for a terminating pod")
+	}
+}
+
+func TestCheckAutoscaler(t *testing.T) {
+	ctx := context.Background()
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "enclii-shop-production"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "api"},
+			MaxReplicas:    5,
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 5},
+	}
+	checker := NewChecker(fake.NewSimpleClientset(hpa), nil)
+	rule := &types.AlertRule{Kind: types.AlertRuleHPAAtMax}
+
+	got, err := checker.Check(ctx, rule, Target{Namespace: "enclii-shop-production", App: "api"}, time.Now())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !got.Met || got.Summary != "Autoscaler api runs 5 of at most 5 replicas" {
+		t.Errorf("Check() = %+v", got)
+	}
+
+	got, err = checker.Check(ctx, rule, Target{Namespace: "enclii-shop-production", App: "worker"}, time.Now())
+	if err != nil || got.Met {
+		t.Errorf("Check() for a service without an autoscaler = %+v, %v", got, err)
+	}
+}
+
+type staticErrorRates struct {
+	rate *float64
+}
+
+func (s staticErrorRates) ErrorRate(ctx context.Context, namespace, service string, since time.Time) (*float64, error) {
+	return s.rate, nil
+}
+
+func TestCheckErrorRate(t *testing.T) {
+	ctx := context.Background()
+	rule := &types.AlertRule{Kind: types.AlertRuleErrorRate, Threshold: 0.05}
+	target := Target{Namespace: "enclii-shop-production", App: "api"}
+
+	_, err := NewChecker(fake.NewSimpleClientset(), nil).Check(ctx, rule, target, time.Now())
+	if !errors.Is(err, ErrNoErrorRates) {
+		t.Errorf("Check() without error rates error = %v, want ErrNoErrorRates", err)
+	}
+
+	rate := 0.12
+	got, err := NewChecker(fake.NewSimpleClientset(), staticErrorRates{&rate}).Check(ctx, rule, target, time.Now())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !got.Met || got.Summary != "12.0% of requests failed over the last 5m0s, 5.0% allowed" {
+		t.Errorf("Check() = %+v", got)
+	}
+
+	got, err = NewChecker(fake.NewSimpleClientset(), staticErrorRates{}).Check(ctx, rule, target, time.Now())
+	if err != nil || got.Met {
+		t.Errorf("Check() without traffic = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,398 @@
+// Package alerting evaluates the alert rules of services against the
+// cluster and Prometheus. Alerts open when a rule's condition is met, fire
+// once it has held for the rule's for_minutes, and resolve when it clears;
+// firing and resolving are sent to the project's webhooks and the rule's
+// email recipients.
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// EvaluationInterval is how often alert rules are evaluated
+const EvaluationInterval = time.Minute
+
+// EventSender delivers alert events to a project's webhooks
+type EventSender interface {
+	SendEvent(ctx context.Context, projectID uuid.UUID, event *types.WebhookEvent) error
+}
+
+// EmailSender emails alerts to the recipients of a rule
+type EmailSender interface {
+	SendAlert(ctx context.Context, data notifications.AlertData) error
+}
+
+// Evaluator evaluates alert rules and keeps their alerts up to date
+type Evaluator struct {
+	repos   *db.Repositories
+	checker *Checker
+	events  EventSender
+	emails  EmailSender
+	logger  *logrus.Logger
+}
+
+// NewEvaluator creates an evaluator
+func NewEvaluator(repos *db.Repositories, checker *Checker, logger *logrus.Logger) *Evaluator {
+	return &Evaluator{
+		repos:   repos,
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+// SetEventSender sets where alert events are sent. Without one, no
+// webhooks are notified.
+func (e *Evaluator) SetEventSender(events EventSender) {
+	e.events = events
+}
+
+// SetEmailSender sets how alerts are emailed. Without one, no emails are
+// sent.
+func (e *Evaluator) SetEmailSender(emails EmailSender) {
+	e.emails = emails
+}
+
+// alertKey identifies the one open alert a rule may have per environment
+type alertKey struct {
+	ruleID        uuid.UUID
+	environmentID uuid.UUID
+}
+
+// subject is the service and environment an alert is about
+type subject struct {
+	service *types.Service
+	project *types.Project
+	env     *types.Environment
+}
+
+// action is what an evaluation does to a rule's alert in an environment
+type action int
+
+const (
+	actionNone action = iota
+	actionOpen
+	actionOpenAndFire
+	actionTouch
+	actionTouchAndFire
+	actionDiscard
+	actionResolve
+)
+
+// decide picks the action for an observation, given the open alert of the
+// rule in the environment, if any. A condition that persists is notified
+// once, and pending alerts that clear before firing are dropped silently.
+func decide(alert *types.Alert, met bool, forDuration time.Duration, now time.Time) action {
+	switch {
+	case met && alert == nil && forDuration <= 0:
+		return actionOpenAndFire
+	case met && alert == nil:
+		return actionOpen
+	case met && alert.Status == types.AlertStatusPending && now.Sub(alert.StartedAt) >= forDuration:
+		return actionTouchAndFire
+	case met:
+		return actionTouch
+	case alert == nil:
+		return actionNone
+	case alert.Status == types.AlertStatusPending:
+		return actionDiscard
+	default:
+		return actionResolve
+	}
+}
+
+// Evaluate checks every enabled rule in the environments it covers. Open
+// alerts of rules that were disabled, or no longer cover an environment,
+// resolve.
+func (e *Evaluator) Evaluate(ctx context.Context) error {
+	rules, err := e.repos.AlertRules.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	openAlerts, err := e.repos.Alerts.ListOpen(ctx)
+	if err != nil {
+		return err
+	}
+
+	open := make(map[alertKey]*types.Alert, len(openAlerts))
+	for _, alert := range openAlerts {
+		open[alertKey{alert.RuleID, alert.EnvironmentID}] = alert
+	}
+
+	now := time.Now()
+	subjects := newSubjectCache(e.repos)
+	evaluated := make(map[alertKey]bool, len(open))
+	for _, rule := range rules {
+		subjectsOfRule, err := subjects.forRule(ctx, rule)
+		if err != nil {
+			e.logger.WithError(err).WithField("rule_id", rule.ID).Warn("Failed to load the services of an alert rule")
+			continue
+		}
+
+		for _, sub := range subjectsOfRule {
+			key := alertKey{rule.ID, sub.env.ID}
+			evaluated[key] = true
+
+			observation, err := e.checker.Check(ctx, rule, Target{Namespace: namespaceOf(sub), App: sub.service.Name}, now)
+			if errors.Is(err, ErrNoErrorRates) {
+				continue
+			}
+			if err != nil {
+				// Unknown state: leave the alert as it is
+				e.logger.WithError(err).WithFields(logrus.Fields{
+					"rule_id":     rule.ID,
+					"environment": sub.env.Name,
+				}).Warn("Failed to check alert rule")
+				continue
+			}
+
+			if err := e.apply(ctx, rule, sub, open[key], observation, now); err != nil {
+				e.logger.WithError(err).WithField("rule_id", rule.ID).Error("Failed to update alert")
+			}
+		}
+	}
+
+	for key, alert := range open {
+		if evaluated[key] {
+			continue
+		}
+		if err := e.resolveOrphan(ctx, alert, subjects, now); err != nil {
+			e.logger.WithError(err).WithField("alert_id", alert.ID).Error("Failed to resolve alert")
+		}
+	}
+	return nil
+}
+
+// apply moves a rule's alert in an environment along after an observation
+func (e *Evaluator) apply(ctx context.Context, rule *types.AlertRule, sub subject, alert *types.Alert, observation *Observation, now time.Time) error {
+	act := decide(alert, observation.Met, time.Duration(rule.ForMinutes)*time.Minute, now)
+	switch act {
+	case actionOpen, actionOpenAndFire:
+		alert = &types.Alert{
+			RuleID:        rule.ID,
+			RuleName:      rule.Name,
+			ServiceID:     rule.ServiceID,
+			EnvironmentID: sub.env.ID,
+			Environment:   sub.env.Name,
+			Kind:          rule.Kind,
+			Summary:       observation.Summary,
+			Value:         observation.Value,
+			StartedAt:     now,
+		}
+		opened, err := e.repos.Alerts.Open(ctx, alert)
+		if err != nil || !opened || act == actionOpen {
+			return err
+		}
+		return e.fire(ctx, rule, sub, alert, now)
+
+	case actionTouch, actionTouchAndFire:
+		alert.Summary = observation.Summary
+		alert.Value = observation.Value
+		if err := e.repos.Alerts.Touch(ctx, alert, now); err != nil {
+			return err
+		}
+		if act == actionTouch {
+			return nil
+		}
+		return e.fire(ctx, rule, sub, alert, now)
+
+	case actionDiscard:
+		return e.repos.Alerts.Discard(ctx, alert.ID)
+
+	case actionResolve:
+		if err := e.repos.Alerts.Resolve(ctx, alert, now); err != nil {
+			return err
+		}
+		e.notify(ctx, types.WebhookEventAlertResolved, rule, sub, alert)
+	}
+	return nil
+}
+
+// fire moves a pending alert to firing and notifies, unless it fired
+// already
+func (e *Evaluator) fire(ctx context.Context, rule *types.AlertRule, sub subject, alert *types.Alert, now time.Time) error {
+	fired, err := e.repos.Alerts.Fire(ctx, alert, now)
+	if err != nil || !fired {
+		return err
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"rule":        rule.Name,
+		"service":     sub.service.Name,
+		"environment": sub.env.Name,
+	}).Info("Alert firing")
+	e.notify(ctx, types.WebhookEventAlertFiring, rule, sub, alert)
+	return nil
+}
+
+// resolveOrphan resolves an open alert its rule no longer evaluates
+func (e *Evaluator) resolveOrphan(ctx context.Context, alert *types.Alert, subjects *subjectCache, now time.Time) error {
+	if alert.Status == types.AlertStatusPending {
+		return e.repos.Alerts.Discard(ctx, alert.ID)
+	}
+	if err := e.repos.Alerts.Resolve(ctx, alert, now); err != nil {
+		return err
+	}
+
+	rule, err := e.repos.AlertRules.GetByID(ctx, alert.ServiceID, alert.RuleID)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	sub, err := subjects.forAlert(ctx, alert)
+	if err != nil {
+		return err
+	}
+	e.notify(ctx, types.WebhookEventAlertResolved, rule, sub, alert)
+	return nil
+}
+
+// notify sends an alert event to the project's webhooks and emails the
+// rule's recipients
+func (e *Evaluator) notify(ctx context.Context, eventType types.WebhookEventType, rule *types.AlertRule, sub subject, alert *types.Alert) {
+	if e.events != nil {
+		event := &types.WebhookEvent{
+			ID:        uuid.New(),
+			Type:      eventType,
+			Timestamp: time.Now(),
+			ProjectID: sub.project.ID,
+			Project: types.WebhookProjectInfo{
+				ID:   sub.project.ID,
+				Name: sub.project.Name,
+				Slug: sub.project.Slug,
+			},
+			Alert: &types.WebhookAlertInfo{
+				ID:          alert.ID,
+				RuleID:      rule.ID,
+				RuleName:    rule.Name,
+				Kind:        string(rule.Kind),
+				ServiceName: sub.service.Name,
+				Environment: sub.env.Name,
+				Summary:     alert.Summary,
+				Value:       alert.Value,
+				Threshold:   rule.Threshold,
+				StartedAt:   alert.StartedAt,
+				ResolvedAt:  alert.ResolvedAt,
+			},
+		}
+		if err := e.events.SendEvent(ctx, sub.project.ID, event); err != nil {
+			e.logger.WithError(err).WithField("alert_id", alert.ID).Warn("Failed to send alert event")
+		}
+	}
+
+	if e.emails == nil {
+		return
+	}
+	for _, email := range rule.NotifyEmails {
+		err := e.emails.SendAlert(ctx, notifications.AlertData{
+			Email:       email,
+			ProjectName: sub.project.Name,
+			ProjectSlug: sub.project.Slug,
+			ServiceName: sub.service.Name,
+			Environment: sub.env.Name,
+			RuleName:    rule.Name,
+			Summary:     alert.Summary,
+			StartedAt:   alert.StartedAt,
+			ResolvedAt:  alert.ResolvedAt,
+		})
+		if err != nil {
+			e.logger.WithError(err).WithField("alert_id", alert.ID).Warn("Failed to email alert")
+		}
+	}
+}
+
+// namespaceOf returns the namespace a service runs in in an environment
+func namespaceOf(sub subject) string {
+	if sub.env.KubeNamespace != "" {
+		return sub.env.KubeNamespace
+	}
+	return fmt.Sprintf("enclii-%s-%s", sub.project.Slug, sub.env.Name)
+}
+
+// subjectCache loads the services, projects and environments of rules once
+// per evaluation
+type subjectCache struct {
+	repos        *db.Repositories
+	services     map[uuid.UUID]*types.Service
+	projects     map[uuid.UUID]*types.Project
+	environments map[uuid.UUID][]*types.Environment // By project
+}
+
+func newSubjectCache(repos *db.Repositories) *subjectCache {
+	return &subjectCache{
+		repos:        repos,
+		services:     make(map[uuid.UUID]*types.Service),
+		projects:     make(map[uuid.UUID]*types.Project),
+		environments: make(map[uuid.UUID][]*types.Environment),
+	}
+}
+
+// forRule returns the environments a rule covers: its environment, or
+// every environment of the service's project
+func (s *subjectCache) forRule(ctx context.Context, rule *types.AlertRule) ([]subject, error) {
+	service, project, environments, err := s.load(ctx, rule.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := make([]subject, 0, len(environments))
+	for _, env := range environments {
+		if rule.EnvironmentID != nil && *rule.EnvironmentID != env.ID {
+			continue
+		}
+		subjects = append(subjects, subject{service: service, project: project, env: env})
+	}
+	return subjects, nil
+}
+
+// forAlert returns the service and environment of an alert
+func (s *subjectCache) forAlert(ctx context.Context, alert *types.Alert) (subject, error) {
+	service, project, environments, err := s.load(ctx, alert.ServiceID)
+	if err != nil {
+		return subject{}, err
+	}
+	for _, env := range environments {
+		if env.ID == alert.EnvironmentID {
+			return subject{service: service, project: project, env: env}, nil
+		}
+	}
+	return subject{}, fmt.Errorf("environment %s not found", alert.EnvironmentID)
+}
+
+func (s *subjectCache) load(ctx context.Context, serviceID uuid.UUID) (*types.Service, *types.Project, []*types.Environment, error) {
+	service, ok := s.services[serviceID]
+	if !ok {
+		var err error
+		if service, err = s.repos.Services.GetByID(serviceID); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get service: %w", err)
+		}
+		s.services[serviceID] = service
+	}
+
+	project, ok := s.projects[service.ProjectID]
+	if !ok {
+		var err error
+		if project, err = s.repos.Projects.GetByID(ctx, service.ProjectID); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get project: %w", err)
+		}
+		s.projects[service.ProjectID] = project
+	}
+
+	environments, ok := s.environments[project.ID]
+	if !ok {
+		var err error
+		if environments, err = s.repos.Environments.ListByProject(project.ID); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to list environments: %w", err)
+		}
+		s.environments[project.ID] = environments
+	}
+	return service, project, environments, nil
+}
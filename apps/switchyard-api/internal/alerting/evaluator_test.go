@@ -0,0 +1,39 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestDecide(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	pending := &types.Alert{Status: types.AlertStatusPending, StartedAt: now.Add(-3 * time.Minute)}
+	firing := &types.Alert{Status: types.AlertStatusFiring, StartedAt: now.Add(-time.Hour)}
+
+	tests := []struct {
+		name        string
+		alert       *types.Alert
+		met         bool
+		forDuration time.Duration
+		want        action
+	}{
+		{"met without a for duration fires at once", nil, true, 0, actionOpenAndFire},
+		{"met waits out the for duration", nil, true, 5 * time.Minute, actionOpen},
+		{"pending keeps waiting", pending, true, 5 * time.Minute, actionTouch},
+		{"pending fires once the for duration passed", pending, true, 2 * time.Minute, actionTouchAndFire},
+		{"firing is not notified again", firing, true, 0, actionTouch},
+		{"nothing to do", nil, false, 0, actionNone},
+		{"pending that clears is dropped", pending, false, 5 * time.Minute, actionDiscard},
+		{"firing that clears resolves", firing, false, 0, actionResolve},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decide(tt.alert, tt.met, tt.forDuration, now); got != tt.want {
+				t.Errorf("decide() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
@@ -17,26 +18,27 @@ type ActivityListResponse struct {
 	Count      int               `json:"count"`
 	Limit      int               `json:"limit"`
 	Offset     int               `json:"offset"`
+	NextCursor string            `json:"next_cursor"`
 }
 
-// GetActivity returns paginated audit logs for the activity feed
-// GET /v1/activity
+// GetActivity returns paginated audit logs for the activity feed. Pages
+// follow next_cursor; the older offset parameter still pages by offset.
+// GET /v1/activity?action=deploy&status=failure&since=2026-10-01&cursor=...
 func (h *Handler) GetActivity(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	// Parse query parameters
-	limit := 50
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
+	query, err := GetListQuery(c, "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	offset := 0
-	if o := c.Query("offset"); o != "" {
-		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-			offset = parsed
+	if query.Environment != "" {
+		id, err := uuid.Parse(query.Environment)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "environment must be an environment ID"})
+			return
 		}
+		query.EnvironmentID = &id
 	}
 
 	// Build filters from query parameters
@@ -62,20 +64,30 @@ func (h *Handler) GetActivity(c *gin.Context) {
 		}
 	}
 
-	// Query audit logs
-	logs, err := h.repos.AuditLogs.Query(ctx, filters, limit, offset)
+	response := ActivityListResponse{Limit: query.Limit}
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			response.Offset = parsed
+		}
+		response.Activities, err = h.repos.AuditLogs.Query(ctx, filters, query.Limit, response.Offset)
+	} else {
+		var logs []*types.AuditLog
+		if logs, err = h.repos.AuditLogs.List(ctx, filters, query.ListParams); err == nil {
+			var next *db.Cursor
+			response.Activities, next = db.TrimPage(logs, query.ListParams, func(log *types.AuditLog) db.Cursor {
+				return db.Cursor{Value: listCursorTime(log.Timestamp), ID: log.ID}
+			})
+			response.NextCursor = encodeListCursor(query.ListParams, next)
+		}
+	}
 	if err != nil {
 		h.logger.Error(ctx, "Failed to query activity logs", logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity"})
 		return
 	}
 
-	c.JSON(http.StatusOK, ActivityListResponse{
-		Activities: logs,
-		Count:      len(logs),
-		Limit:      limit,
-		Offset:     offset,
-	})
+	response.Count = len(response.Activities)
+	c.JSON(http.StatusOK, response)
 }
 
 // GetActivityActions returns available action types for filtering
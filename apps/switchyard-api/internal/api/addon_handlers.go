@@ -2,12 +2,16 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
@@ -90,10 +94,10 @@ func (h *Handler) CreateAddon(c *gin.Context) {
 
 	// Validate addon type
 	switch req.Type {
-	case types.DatabaseAddonTypePostgres, types.DatabaseAddonTypeRedis, types.DatabaseAddonTypeMySQL:
+	case types.DatabaseAddonTypePostgres, types.DatabaseAddonTypeRedis, types.DatabaseAddonTypeMySQL, types.DatabaseAddonTypeMongoDB, types.DatabaseAddonTypeBucket:
 		// Valid types
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon type, must be one of: postgres, redis, mysql"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon type, must be one of: postgres, redis, mysql, mongodb, bucket"})
 		return
 	}
 
@@ -124,6 +128,10 @@ func (h *Handler) CreateAddon(c *gin.Context) {
 	if req.Config != nil {
 		config = *req.Config
 	}
+	if err := addons.ValidateConfig(req.Type, config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Create the addon
 	createReq := &addons.CreateAddonRequest{
@@ -140,6 +148,9 @@ func (h *Handler) CreateAddon(c *gin.Context) {
 
 	addon, err := h.addonService.CreateAddon(ctx, createReq)
 	if err != nil {
+		if quotaExceeded(c, err) {
+			return
+		}
 		h.logger.Error(ctx, "Failed to create addon",
 			logging.String("project_slug", slug),
 			logging.String("addon_name", req.Name),
@@ -214,7 +225,7 @@ func (h *Handler) GetAddon(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, addon)
+	respondWithETag(c, http.StatusOK, addonETag(&addon.DatabaseAddon), addon)
 }
 
 // GetAddonCredentials retrieves connection credentials for an addon
@@ -230,6 +241,15 @@ func (h *Handler) GetAddonCredentials(c *gin.Context) {
 		return
 	}
 
+	addon, err := h.addonService.GetAddon(ctx, addonUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "addon not found"})
+		return
+	}
+	if !h.requireTwoFactor(c, &addon.ProjectID, auth.SensitiveSecretRead) {
+		return
+	}
+
 	creds, err := h.addonService.GetCredentials(ctx, addonUUID)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to get addon credentials",
@@ -280,6 +300,15 @@ func (h *Handler) DeleteAddon(c *gin.Context) {
 		return
 	}
 
+	addon, err := h.addonService.GetAddon(ctx, addonUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "addon not found"})
+		return
+	}
+	if !checkPreconditions(c, addonETag(addon)) {
+		return
+	}
+
 	if err := h.addonService.DeleteAddon(ctx, addonUUID); err != nil {
 		h.logger.Error(ctx, "Failed to delete addon",
 			logging.String("addon_id", addonID),
@@ -334,16 +363,7 @@ func (h *Handler) CreateAddonBinding(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get addon"})
 			return
 		}
-		switch addon.Type {
-		case types.DatabaseAddonTypePostgres:
-			envVarName = "DATABASE_URL"
-		case types.DatabaseAddonTypeRedis:
-			envVarName = "REDIS_URL"
-		case types.DatabaseAddonTypeMySQL:
-			envVarName = "MYSQL_URL"
-		default:
-			envVarName = "DATABASE_URL"
-		}
+		envVarName = addons.DefaultBindingEnvVar(addon.Type)
 	}
 
 	binding, err := h.addonService.CreateBinding(ctx, addonUUID, serviceUUID, envVarName)
@@ -431,3 +451,412 @@ func (h *Handler) GetServiceBindings(c *gin.Context) {
 		"count":    len(bindings),
 	})
 }
+
+// CreateAddonBackup starts a manual backup of a MySQL or MongoDB addon
+// POST /v1/addons/:id/backups
+func (h *Handler) CreateAddonBackup(c *gin.Context) {
+	ctx := c.Request.Context()
+	addonID := c.Param("id")
+
+	// Parse addon ID
+	addonUUID, err := uuid.Parse(addonID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	addon, err := h.addonService.GetAddon(ctx, addonUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "addon not found"})
+		return
+	}
+
+	if !addons.SupportsBackupJobs(addon.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "backups are only supported for mysql and mongodb addons"})
+		return
+	}
+
+	backup, err := h.addonService.CreateBackup(ctx, addonUUID, types.DatabaseAddonBackupTypeManual)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to create addon backup",
+			logging.String("addon_id", addonID),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, backup)
+}
+
+// ListAddonBackups retrieves the backup history of an addon
+// GET /v1/addons/:id/backups
+func (h *Handler) ListAddonBackups(c *gin.Context) {
+	ctx := c.Request.Context()
+	addonID := c.Param("id")
+
+	// Parse addon ID
+	addonUUID, err := uuid.Parse(addonID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	backups, err := h.addonService.ListBackups(ctx, addonUUID, 50)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list addon backups",
+			logging.String("addon_id", addonID),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list backups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"backups": backups,
+		"count":   len(backups),
+	})
+}
+
+// RestoreAddonRequest defines the request body for restoring an addon, either
+// from a backup or, for PostgreSQL addons with PITR enabled, to a point in time
+type RestoreAddonRequest struct {
+	BackupID   string     `json:"backup_id"`
+	TargetTime *time.Time `json:"target_time"` // RFC3339
+}
+
+// RestoreAddon restores a MySQL or MongoDB addon from one of its completed
+// backups, or recovers a PostgreSQL addon to a point in time
+// POST /v1/addons/:id/restore
+func (h *Handler) RestoreAddon(c *gin.Context) {
+	ctx := c.Request.Context()
+	addonID := c.Param("id")
+
+	// Parse addon ID
+	addonUUID, err := uuid.Parse(addonID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	var req RestoreAddonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (req.BackupID == "") == (req.TargetTime == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of backup_id or target_time is required"})
+		return
+	}
+
+	var backupUUID uuid.UUID
+	if req.BackupID != "" {
+		backupUUID, err = uuid.Parse(req.BackupID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid backup_id format"})
+			return
+		}
+	}
+
+	addon, err := h.addonService.GetAddon(ctx, addonUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "addon not found"})
+		return
+	}
+
+	// Get user from context
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	auditContext := map[string]interface{}{
+		"addon_type": addon.Type,
+	}
+	target := req.BackupID
+	if req.TargetTime != nil {
+		target = req.TargetTime.UTC().Format(time.RFC3339)
+		auditContext["target_time"] = target
+	} else {
+		auditContext["backup_id"] = req.BackupID
+	}
+
+	var restore *types.DatabaseAddonRestore
+	if req.TargetTime != nil {
+		restore, err = h.addonService.RestorePointInTime(ctx, addonUUID, *req.TargetTime, actorID, email)
+	} else {
+		restore, err = h.addonService.RestoreBackup(ctx, addonUUID, backupUUID, actorID, email)
+	}
+	if err != nil {
+		if errors.Is(err, addons.ErrRestoreInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to restore addon",
+			logging.String("addon_id", addonID),
+			logging.String("target", target),
+			logging.Error("error", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	auditContext["restore_id"] = restore.ID.String()
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   email,
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       "addon.restore_requested",
+		ResourceType: "addon",
+		ResourceID:   addon.ID.String(),
+		ResourceName: addon.Name,
+		ProjectID:    &addon.ProjectID,
+		Outcome:      "success",
+		Context:      auditContext,
+	})
+
+	h.logger.Info(ctx, "Addon restore started",
+		logging.String("addon_id", addonID),
+		logging.String("target", target),
+		logging.String("restore_id", restore.ID.String()))
+
+	c.JSON(http.StatusAccepted, restore)
+}
+
+// GetAddonPITRStatus reports WAL archiving and the recovery window of a PostgreSQL addon
+// GET /v1/addons/:id/pitr
+func (h *Handler) GetAddonPITRStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+	addonID := c.Param("id")
+
+	// Parse addon ID
+	addonUUID, err := uuid.Parse(addonID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	status, err := h.addonService.GetPITRStatus(ctx, addonUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "addon not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get addon PITR status",
+			logging.String("addon_id", addonID),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get point-in-time recovery status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ListAddonRestores retrieves the restore history of an addon
+// GET /v1/addons/:id/restores
+func (h *Handler) ListAddonRestores(c *gin.Context) {
+	ctx := c.Request.Context()
+	addonID := c.Param("id")
+
+	// Parse addon ID
+	addonUUID, err := uuid.Parse(addonID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	restores, err := h.addonService.ListRestores(ctx, addonUUID, 50)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list addon restores",
+			logging.String("addon_id", addonID),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list restores"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"restores": restores,
+		"count":    len(restores),
+	})
+}
+
+// GetAddonRestore retrieves the status and progress of a restore
+// GET /v1/addons/:id/restores/:restore_id
+func (h *Handler) GetAddonRestore(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	addonUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	restoreUUID, err := uuid.Parse(c.Param("restore_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid restore_id format"})
+		return
+	}
+
+	restore, err := h.addonService.GetRestore(ctx, addonUUID, restoreUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "restore not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, restore)
+}
+
+// UpdateBackupPolicyRequest defines the request body for changing an addon's backup policy
+type UpdateBackupPolicyRequest struct {
+	Schedule      string `json:"schedule"`
+	RetentionDays int    `json:"retention_days,omitempty"`
+}
+
+// UpdateAddonBackupPolicy changes the backup schedule and retention of an addon
+// PATCH /v1/addons/:id/backup-policy
+func (h *Handler) UpdateAddonBackupPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	addonID := c.Param("id")
+
+	// Parse addon ID
+	addonUUID, err := uuid.Parse(addonID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	var req UpdateBackupPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	addon, err := h.addonService.UpdateBackupPolicy(ctx, addonUUID, req.Schedule, req.RetentionDays)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info(ctx, "Addon backup policy updated",
+		logging.String("addon_id", addonID),
+		logging.String("schedule", req.Schedule))
+
+	c.JSON(http.StatusOK, addon)
+}
+
+// UpdateAddon changes an addon's storage size, compute class, or replica count
+// PATCH /v1/addons/:id
+func (h *Handler) UpdateAddon(c *gin.Context) {
+	ctx := c.Request.Context()
+	addonID := c.Param("id")
+
+	// Parse addon ID
+	addonUUID, err := uuid.Parse(addonID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	var req types.DatabaseAddonUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	addon, err := h.addonService.GetAddon(ctx, addonUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "addon not found"})
+		return
+	}
+	if !checkPreconditions(c, addonETag(addon)) {
+		return
+	}
+
+	// Get user from context
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	resize, err := h.addonService.ResizeAddon(ctx, addonUUID, &req, actorID, email)
+	if err != nil {
+		if quotaExceeded(c, err) {
+			return
+		}
+		if errors.Is(err, addons.ErrResizeInProgress) || errors.Is(err, addons.ErrRestoreInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to resize addon",
+			logging.String("addon_id", addonID),
+			logging.Error("error", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   email,
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       "addon.resize_requested",
+		ResourceType: "addon",
+		ResourceID:   addon.ID.String(),
+		ResourceName: addon.Name,
+		ProjectID:    &addon.ProjectID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"resize_id":       resize.ID.String(),
+			"addon_type":      addon.Type,
+			"previous_config": resize.PreviousConfig,
+			"target_config":   resize.TargetConfig,
+		},
+	})
+
+	h.logger.Info(ctx, "Addon resize started",
+		logging.String("addon_id", addonID),
+		logging.String("resize_id", resize.ID.String()))
+
+	c.JSON(http.StatusAccepted, resize)
+}
+
+// ListAddonResizes retrieves the resize history of an addon
+// GET /v1/addons/:id/resizes
+func (h *Handler) ListAddonResizes(c *gin.Context) {
+	ctx := c.Request.Context()
+	addonID := c.Param("id")
+
+	// Parse addon ID
+	addonUUID, err := uuid.Parse(addonID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon_id format"})
+		return
+	}
+
+	resizes, err := h.addonService.ListResizes(ctx, addonUUID, 50)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list addon resizes",
+			logging.String("addon_id", addonID),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list resizes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"resizes": resizes,
+		"count":   len(resizes),
+	})
+}
@@ -0,0 +1,435 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"net/mail"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// maxAlertRuleEmails is the most email recipients one alert rule has
+	maxAlertRuleEmails = 10
+
+	// maxAlertRuleForMinutes is the longest a condition may need to hold
+	maxAlertRuleForMinutes = 24 * 60
+
+	// maxAlertsLimit is the most alerts one listing returns
+	maxAlertsLimit = 200
+)
+
+// alertRuleDefaults are the name, threshold and for_minutes a new rule of
+// each kind gets when the request leaves them out
+var alertRuleDefaults = map[types.AlertRuleKind]struct {
+	name       string
+	threshold  float64
+	forMinutes int
+}{
+	types.AlertRuleCrashLoop:        {"Crash looping", 0, 5},
+	types.AlertRuleRestartCount:     {"Too many restarts", 5, 0},
+	types.AlertRuleOOMKilled:        {"Out of memory", 0, 0},
+	types.AlertRuleReadinessFailing: {"Readiness failing", 0, 5},
+	types.AlertRuleHPAAtMax:         {"Autoscaler at maximum", 0, 15},
+	types.AlertRuleErrorRate:        {"High error rate", 0.05, 5},
+}
+
+// CreateAlertRuleRequest is the request body for creating an alert rule
+type CreateAlertRuleRequest struct {
+	Kind         types.AlertRuleKind `json:"kind" binding:"required"`
+	Name         string              `json:"name,omitempty"`
+	Environment  string              `json:"environment,omitempty"` // Every environment when empty
+	Threshold    *float64            `json:"threshold,omitempty"`
+	ForMinutes   *int                `json:"for_minutes,omitempty"`
+	NotifyEmails []string            `json:"notify_emails,omitempty"`
+	Enabled      *bool               `json:"enabled,omitempty"`
+}
+
+// UpdateAlertRuleRequest is the request body for updating an alert rule.
+// Fields left out are unchanged.
+type UpdateAlertRuleRequest struct {
+	Name         *string   `json:"name,omitempty"`
+	Environment  *string   `json:"environment,omitempty"` // "" for every environment
+	Threshold    *float64  `json:"threshold,omitempty"`
+	ForMinutes   *int      `json:"for_minutes,omitempty"`
+	NotifyEmails *[]string `json:"notify_emails,omitempty"`
+	Enabled      *bool     `json:"enabled,omitempty"`
+}
+
+// ListAlertRules lists the alert rules of a service
+// GET /v1/services/:id/alert-rules
+func (h *Handler) ListAlertRules(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	if !h.authorizeServiceEnvironment(c, serviceID, nil) {
+		return
+	}
+
+	rules, err := h.repos.AlertRules.ListByService(ctx, serviceID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list alert rules",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list alert rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// CreateAlertRule adds an alert rule to a service
+// POST /v1/services/:id/alert-rules
+func (h *Handler) CreateAlertRule(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	var req CreateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defaults, ok := alertRuleDefaults[req.Kind]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be crash_loop, restart_count, oom_killed, readiness_failing, hpa_at_max, or error_rate"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return
+	}
+
+	rule := &types.AlertRule{
+		ServiceID:    service.ID,
+		Name:         req.Name,
+		Kind:         req.Kind,
+		Threshold:    defaults.threshold,
+		ForMinutes:   defaults.forMinutes,
+		NotifyEmails: req.NotifyEmails,
+		Enabled:      req.Enabled == nil || *req.Enabled,
+	}
+	if rule.Name == "" {
+		rule.Name = defaults.name
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.ForMinutes != nil {
+		rule.ForMinutes = *req.ForMinutes
+	}
+	if rule.NotifyEmails == nil {
+		rule.NotifyEmails = []string{}
+	}
+	if userEmail, ok := c.Get("user_email"); ok {
+		rule.CreatedBy = fmt.Sprintf("%v", userEmail)
+	}
+
+	if req.Environment != "" {
+		env, err := h.repos.Environments.GetByProjectAndName(project.ID, req.Environment)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found", "environment": req.Environment})
+			return
+		}
+		rule.EnvironmentID = &env.ID
+	}
+	if !h.authorizeEnvironment(c, project.ID, rule.EnvironmentID) {
+		return
+	}
+
+	if err := validateAlertRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repos.AlertRules.Create(ctx, rule); err != nil {
+		h.logger.Error(ctx, "Failed to create alert rule",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create alert rule"})
+		return
+	}
+
+	h.auditAlertRule(c, project, service, rule, "service.alert_rule_created")
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateAlertRule changes the settings of an alert rule. Its kind cannot
+// change.
+// PATCH /v1/services/:id/alert-rules/:rule_id
+func (h *Handler) UpdateAlertRule(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert rule ID"})
+		return
+	}
+
+	var req UpdateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return
+	}
+	rule, err := h.repos.AlertRules.GetByID(ctx, service.ID, ruleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, rule.EnvironmentID) {
+		return
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.ForMinutes != nil {
+		rule.ForMinutes = *req.ForMinutes
+	}
+	if req.NotifyEmails != nil {
+		rule.NotifyEmails = *req.NotifyEmails
+		if rule.NotifyEmails == nil {
+			rule.NotifyEmails = []string{}
+		}
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.Environment != nil {
+		rule.EnvironmentID = nil
+		if *req.Environment != "" {
+			env, err := h.repos.Environments.GetByProjectAndName(project.ID, *req.Environment)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found", "environment": *req.Environment})
+				return
+			}
+			rule.EnvironmentID = &env.ID
+		}
+		if !h.authorizeEnvironment(c, project.ID, rule.EnvironmentID) {
+			return
+		}
+	}
+
+	if err := validateAlertRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repos.AlertRules.Update(ctx, rule); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to update alert rule",
+			logging.String("rule_id", rule.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update alert rule"})
+		return
+	}
+
+	h.auditAlertRule(c, project, service, rule, "service.alert_rule_updated")
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteAlertRule removes an alert rule and its alerts. Firing alerts are
+// dropped without a resolve notification.
+// DELETE /v1/services/:id/alert-rules/:rule_id
+func (h *Handler) DeleteAlertRule(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert rule ID"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return
+	}
+	rule, err := h.repos.AlertRules.GetByID(ctx, service.ID, ruleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, rule.EnvironmentID) {
+		return
+	}
+
+	if err := h.repos.AlertRules.Delete(ctx, service.ID, rule.ID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete alert rule",
+			logging.String("rule_id", rule.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete alert rule"})
+		return
+	}
+
+	h.auditAlertRule(c, project, service, rule, "service.alert_rule_deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted"})
+}
+
+// ListServiceAlerts lists the latest alerts of a service's rules, newest
+// first
+// GET /v1/services/:id/alerts
+func (h *Handler) ListServiceAlerts(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	status := types.AlertStatus(c.Query("status"))
+	switch status {
+	case "", types.AlertStatusPending, types.AlertStatusFiring, types.AlertStatusResolved:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, firing, or resolved"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > maxAlertsLimit {
+		limit = maxAlertsLimit
+	}
+
+	if !h.authorizeServiceEnvironment(c, serviceID, nil) {
+		return
+	}
+
+	alerts, err := h.repos.Alerts.ListByService(ctx, serviceID, status, limit)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list alerts",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+}
+
+// validateAlertRule checks the settings of an alert rule
+func validateAlertRule(rule *types.AlertRule) error {
+	if rule.Name == "" || len(rule.Name) > 255 {
+		return fmt.Errorf("name must be 1 to 255 characters")
+	}
+	if rule.ForMinutes < 0 || rule.ForMinutes > maxAlertRuleForMinutes {
+		return fmt.Errorf("for_minutes must be between 0 and %d", maxAlertRuleForMinutes)
+	}
+
+	switch rule.Kind {
+	case types.AlertRuleRestartCount:
+		if rule.Threshold < 0 || rule.Threshold != math.Trunc(rule.Threshold) {
+			return fmt.Errorf("threshold must be a whole number of restarts")
+		}
+	case types.AlertRuleErrorRate:
+		if rule.Threshold <= 0 || rule.Threshold >= 1 {
+			return fmt.Errorf("threshold must be a ratio of failed requests between 0 and 1")
+		}
+	default:
+		if rule.Threshold != 0 {
+			return fmt.Errorf("threshold only applies to restart_count and error_rate rules")
+		}
+	}
+
+	if len(rule.NotifyEmails) > maxAlertRuleEmails {
+		return fmt.Errorf("at most %d notify_emails are allowed", maxAlertRuleEmails)
+	}
+	for _, email := range rule.NotifyEmails {
+		if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+			return fmt.Errorf("invalid email address %q", email)
+		}
+	}
+	return nil
+}
+
+// auditAlertRule records a change to an alert rule of a service
+func (h *Handler) auditAlertRule(c *gin.Context, project *types.Project, service *types.Service, rule *types.AlertRule, action string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    fmt.Sprintf("%v", userEmail),
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		Action:        action,
+		ResourceType:  "service",
+		ResourceID:    service.ID.String(),
+		ResourceName:  service.Name,
+		ProjectID:     &project.ID,
+		EnvironmentID: rule.EnvironmentID,
+		Outcome:       "success",
+		Context: map[string]interface{}{
+			"alert_rule_id": rule.ID.String(),
+			"name":          rule.Name,
+			"kind":          rule.Kind,
+			"threshold":     rule.Threshold,
+			"for_minutes":   rule.ForMinutes,
+			"enabled":       rule.Enabled,
+		},
+	})
+}
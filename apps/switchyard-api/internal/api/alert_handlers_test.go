@@ -0,0 +1,34 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateAlertRule(t *testing.T) {
+	for kind, defaults := range alertRuleDefaults {
+		rule := &types.AlertRule{Name: defaults.name, Kind: kind, Threshold: defaults.threshold, ForMinutes: defaults.forMinutes}
+		if err := validateAlertRule(rule); err != nil {
+			t.Errorf("defaults of %s are invalid: %v", kind, err)
+		}
+	}
+
+	valid := &types.AlertRule{Name: "Errors", Kind: types.AlertRuleErrorRate, Threshold: 0.1, NotifyEmails: []string{"oncall@example.com"}}
+	if err := validateAlertRule(valid); err != nil {
+		t.Errorf("validateAlertRule() error = %v", err)
+	}
+
+	for _, invalid := range []*types.AlertRule{
+		{Name: "", Kind: types.AlertRuleCrashLoop},
+		{Name: "Errors", Kind: types.AlertRuleErrorRate, Threshold: 5},
+		{Name: "Restarts", Kind: types.AlertRuleRestartCount, Threshold: 2.5},
+		{Name: "Crash", Kind: types.AlertRuleCrashLoop, Threshold: 3},
+		{Name: "Crash", Kind: types.AlertRuleCrashLoop, ForMinutes: maxAlertRuleForMinutes + 1},
+		{Name: "Crash", Kind: types.AlertRuleCrashLoop, NotifyEmails: []string{"On Call <oncall@example.com>"}},
+	} {
+		if err := validateAlertRule(invalid); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
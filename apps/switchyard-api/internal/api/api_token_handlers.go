@@ -1,12 +1,14 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 )
 
@@ -22,6 +24,8 @@ type CreateAPITokenRequest struct {
 	Name      string   `json:"name" binding:"required,min=1,max=100"`
 	Scopes    []string `json:"scopes,omitempty"`          // Optional scopes (empty = full access)
 	ExpiresIn *int     `json:"expires_in_days,omitempty"` // Optional expiration in days
+	// Optional quota in requests per minute, at most the platform's per-token limit
+	RateLimit *int `json:"rate_limit_per_minute,omitempty" binding:"omitempty,min=1"`
 }
 
 // APITokenResponse represents a token in list responses (without the actual token)
@@ -32,6 +36,7 @@ type APITokenResponse struct {
 	Scopes     []string   `json:"scopes,omitempty"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RateLimit  *int       `json:"rate_limit_per_minute,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
 	Revoked    bool       `json:"revoked"`
 }
@@ -77,6 +82,11 @@ func (h *Handler) CreateAPIToken(c *gin.Context) {
 		}
 	}
 
+	// Tokens skip the second factor, so creating one needs a recent one
+	if !h.requireTwoFactor(c, nil, auth.SensitiveTokenCreate) {
+		return
+	}
+
 	var req CreateAPITokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
@@ -95,6 +105,14 @@ func (h *Handler) CreateAPIToken(c *gin.Context) {
 		return
 	}
 
+	// Only platform admins raise a token's quota, through the admin API
+	if req.RateLimit != nil && *req.RateLimit > h.config.RateLimitTokenPerMinute {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("rate_limit_per_minute cannot exceed the platform's per-token limit of %d", h.config.RateLimitTokenPerMinute),
+		})
+		return
+	}
+
 	// Calculate expiration
 	var expiresAt *time.Time
 	if req.ExpiresIn != nil && *req.ExpiresIn > 0 {
@@ -103,7 +121,7 @@ func (h *Handler) CreateAPIToken(c *gin.Context) {
 	}
 
 	// Create the token
-	tokenResp, err := h.repos.APITokens.Create(ctx, uid, req.Name, req.Scopes, expiresAt)
+	tokenResp, err := h.repos.APITokens.Create(ctx, uid, req.Name, req.Scopes, expiresAt, req.RateLimit)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to create API token", logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
@@ -171,6 +189,7 @@ func (h *Handler) ListAPITokens(c *gin.Context) {
 				Scopes:     t.Scopes,
 				ExpiresAt:  t.ExpiresAt,
 				LastUsedAt: t.LastUsedAt,
+				RateLimit:  t.RateLimit,
 				CreatedAt:  t.CreatedAt,
 				Revoked:    t.Revoked,
 			})
@@ -190,6 +209,7 @@ func (h *Handler) ListAPITokens(c *gin.Context) {
 				Scopes:     t.Scopes,
 				ExpiresAt:  t.ExpiresAt,
 				LastUsedAt: t.LastUsedAt,
+				RateLimit:  t.RateLimit,
 				CreatedAt:  t.CreatedAt,
 				Revoked:    t.Revoked,
 			})
@@ -268,6 +288,7 @@ func (h *Handler) GetAPIToken(c *gin.Context) {
 		Scopes:     token.Scopes,
 		ExpiresAt:  token.ExpiresAt,
 		LastUsedAt: token.LastUsedAt,
+		RateLimit:  token.RateLimit,
 		CreatedAt:  token.CreatedAt,
 		Revoked:    token.Revoked,
 	})
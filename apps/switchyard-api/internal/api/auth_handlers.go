@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -11,6 +12,7 @@ import (
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 	"github.com/sirupsen/logrus"
@@ -58,9 +60,12 @@ type RefreshResponse struct {
 //   - Body: LoginRequest {email: string, password: string}
 //
 // Response:
-//   - 200 OK: LoginResponse with access_token, refresh_token, expires_at
+//   - 200 OK: LoginResponse with access_token, refresh_token, expires_at, or
+//     {user, two_factor_required: true, two_factor_token} when the user has
+//     two-factor authentication enabled; see LoginTwoFactor
 //   - 400 Bad Request: Invalid request body
 //   - 401 Unauthorized: Invalid credentials
+//   - 403 Forbidden: Email address not verified yet
 //   - 500 Internal Server Error: Server error
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -84,6 +89,8 @@ func (h *Handler) Login(c *gin.Context) {
 		// Map service errors to HTTP status codes
 		if errors.Is(err, errors.ErrInvalidCredentials) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		} else if errors.Is(err, errors.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "EMAIL_NOT_VERIFIED"})
 		} else if errors.Is(err, errors.ErrUnauthorized) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		} else {
@@ -92,6 +99,16 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	// The password was right; the tokens come with a second factor
+	if resp.TwoFactorToken != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"user":                resp.User,
+			"two_factor_required": true,
+			"two_factor_token":    resp.TwoFactorToken,
+		})
+		return
+	}
+
 	// Return response
 	c.JSON(http.StatusOK, LoginResponse{
 		User:         resp.User,
@@ -224,23 +241,28 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 // Register handles new user registration.
 //
 // This endpoint creates a new user account and returns authentication tokens.
-// Email must be unique across the system.
+// Email must be unique across the system. In invite-only signup mode an
+// invite code is required. When email verification is required, no tokens
+// are returned; a verification link is emailed instead.
 //
 // Request:
 //   - Method: POST /api/v1/auth/register
 //   - Content-Type: application/json
-//   - Body: {email: string, password: string, name: string}
+//   - Body: {email: string, password: string, name: string, invite_code?: string}
 //
 // Response:
-//   - 201 Created: LoginResponse with tokens and user info
+//   - 201 Created: LoginResponse with tokens and user info, or
+//     {user, verification_required: true} when the email must be verified
 //   - 400 Bad Request: Invalid request or validation error
+//   - 403 Forbidden: Signup requires a valid invite code
 //   - 409 Conflict: User with email already exists
 //   - 500 Internal Server Error: Failed to create user
 func (h *Handler) Register(c *gin.Context) {
 	type RegisterRequest struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required"`
-		Name     string `json:"name" binding:"required"`
+		Email      string `json:"email" binding:"required,email"`
+		Password   string `json:"password" binding:"required"`
+		Name       string `json:"name" binding:"required"`
+		InviteCode string `json:"invite_code"`
 	}
 
 	var req RegisterRequest
@@ -253,11 +275,12 @@ func (h *Handler) Register(c *gin.Context) {
 
 	// Use service layer for registration
 	registerReq := &services.RegisterRequest{
-		Email:     req.Email,
-		Password:  req.Password,
-		Name:      req.Name,
-		IP:        c.ClientIP(),
-		UserAgent: c.Request.UserAgent(),
+		Email:      req.Email,
+		Password:   req.Password,
+		Name:       req.Name,
+		InviteCode: req.InviteCode,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
 	}
 
 	resp, err := h.authService.Register(ctx, registerReq)
@@ -265,6 +288,8 @@ func (h *Handler) Register(c *gin.Context) {
 		// Map service errors to HTTP status codes
 		if errors.Is(err, errors.ErrEmailAlreadyExists) {
 			c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
+		} else if errors.Is(err, errors.ErrInviteRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "INVITE_REQUIRED"})
 		} else if errors.Is(err, errors.ErrValidation) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		} else {
@@ -273,6 +298,20 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	if resp.VerificationToken != "" {
+		h.sendEmailVerification(ctx, &auth.EmailVerification{
+			Email:     resp.User.Email,
+			Name:      resp.User.Name,
+			Token:     resp.VerificationToken,
+			ExpiresAt: time.Now().Add(auth.EmailVerificationTTL),
+		})
+		c.JSON(http.StatusCreated, gin.H{
+			"user":                  resp.User,
+			"verification_required": true,
+		})
+		return
+	}
+
 	c.JSON(http.StatusCreated, LoginResponse{
 		User:         resp.User,
 		AccessToken:  resp.AccessToken,
@@ -282,6 +321,153 @@ func (h *Handler) Register(c *gin.Context) {
 	})
 }
 
+// VerifyEmail handles the link emailed to a new account.
+//
+// The token is single-use. A verified account is signed in right away.
+//
+// Request:
+//   - Method: POST /api/v1/auth/verify-email
+//   - Content-Type: application/json
+//   - Body: {token: string}
+//
+// Response:
+//   - 200 OK: LoginResponse with tokens and user info
+//   - 400 Bad Request: Invalid, used, or expired token
+//   - 401 Unauthorized: Account is disabled
+//   - 500 Internal Server Error: Server error
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	resp, err := h.authService.VerifyEmail(c.Request.Context(), &services.VerifyEmailRequest{
+		Token:     req.Token,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		if errors.Is(err, errors.ErrTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Verification link is invalid or has expired"})
+		} else if errors.Is(err, errors.ErrUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, LoginResponse{
+		User:         resp.User,
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresAt:    resp.ExpiresAt,
+		TokenType:    "Bearer",
+	})
+}
+
+// ResendEmailVerification emails a new verification link.
+//
+// The response is the same whether or not the address belongs to an
+// unverified account, so it cannot be used to discover accounts.
+//
+// Request:
+//   - Method: POST /api/v1/auth/verify-email/resend
+//   - Content-Type: application/json
+//   - Body: {email: string}
+//
+// Response:
+//   - 202 Accepted: A link is sent if the account needs one
+//   - 400 Bad Request: Invalid request body
+func (h *Handler) ResendEmailVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	verification, err := h.authService.ResendEmailVerification(ctx, req.Email)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to resend email verification", logging.Error("error", err))
+	} else if verification != nil {
+		h.sendEmailVerification(ctx, verification)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account needs verification, a new link has been sent",
+	})
+}
+
+// sendEmailVerification emails a verification link without blocking the
+// response
+func (h *Handler) sendEmailVerification(ctx context.Context, verification *auth.EmailVerification) {
+	if h.emailService == nil {
+		h.logger.Warn(ctx, "Email service not configured; verification link not sent",
+			logging.String("email", verification.Email))
+		return
+	}
+
+	emailData := notifications.EmailVerificationData{
+		Email:     verification.Email,
+		Name:      verification.Name,
+		Token:     verification.Token,
+		ExpiresAt: verification.ExpiresAt,
+	}
+	go func() {
+		emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := h.emailService.SendEmailVerification(emailCtx, emailData); err != nil {
+			h.logger.Error(emailCtx, "Failed to send verification email",
+				logging.String("email", emailData.Email),
+				logging.Error("error", err))
+		}
+	}()
+}
+
+// SignupPolicyResponse describes how accounts can be created, so signup
+// forms can ask for an invite code and check passwords before submitting
+type SignupPolicyResponse struct {
+	Mode                      string                 `json:"mode"`
+	EmailVerificationRequired bool                   `json:"email_verification_required"`
+	PasswordPolicy            PasswordPolicyResponse `json:"password_policy"`
+}
+
+// PasswordPolicyResponse is the password policy of local accounts
+type PasswordPolicyResponse struct {
+	MinLength        int  `json:"min_length"`
+	RequireMixedCase bool `json:"require_mixed_case"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+}
+
+// GetSignupPolicy returns the signup mode and password policy.
+//
+// Request:
+//   - Method: GET /api/v1/auth/signup
+//
+// Response:
+//   - 200 OK: SignupPolicyResponse
+func (h *Handler) GetSignupPolicy(c *gin.Context) {
+	policy := auth.SignupPolicyFromConfig(h.config)
+
+	c.JSON(http.StatusOK, SignupPolicyResponse{
+		Mode:                      policy.Mode,
+		EmailVerificationRequired: policy.RequireEmailVerification,
+		PasswordPolicy: PasswordPolicyResponse{
+			MinLength:        policy.Password.MinLength,
+			RequireMixedCase: policy.Password.RequireMixedCase,
+			RequireDigit:     policy.Password.RequireDigit,
+			RequireSymbol:    policy.Password.RequireSymbol,
+		},
+	})
+}
+
 // =============== OIDC Authentication Handlers (Phase C) ===============
 
 // SilentAuthResponse contains the silent auth check URL for the frontend iframe
@@ -481,7 +667,7 @@ window.parent.postMessage({
 		return
 	}
 
-	tokens, err := oidcMgr.HandleCallback(ctx, code)
+	tokens, err := oidcMgr.HandleCallback(ctx, code, auth.SessionDevice{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()})
 	if err != nil {
 		logrus.WithError(err).Error("Silent OIDC callback token exchange failed")
 		html := `<!DOCTYPE html>
@@ -661,7 +847,7 @@ func (h *Handler) OIDCCallback(c *gin.Context) {
 		return
 	}
 
-	tokens, err := oidcMgr.HandleCallback(ctx, code)
+	tokens, err := oidcMgr.HandleCallback(ctx, code, auth.SessionDevice{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()})
 	if err != nil {
 		logrus.WithError(err).WithField("code_length", len(code)).Error("OIDC callback failed")
 
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/audit"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/config"
+)
+
+// publicRoutes are registered outside the protected group and authenticate
+// (if at all) by other means
+var publicRoutes = map[string]bool{
+	"GET /health":                                true,
+	"GET /health/live":                           true,
+	"GET /health/ready":                          true,
+	"GET /v1/builds/:id/status":                  true,
+	"GET /v1/dashboard/stats":                    true,
+	"POST /v1/webhooks/github":                   true,
+	"POST /v1/callbacks/build-complete":          true,
+	"POST /v1/callbacks/build-stalled":           true,
+	"POST /v1/callbacks/function-build-complete": true,
+	"POST /v1/callbacks/budget":                  true,
+	"POST /v1/callbacks/billing":                 true,
+	"GET /v1/services":                           true,
+	"GET /status/:slug":                          true,
+	"GET /":                                      true,
+	"POST /v1/auth/register":                     true,
+	"POST /v1/auth/login":                        true,
+	"POST /v1/auth/login/two-factor":             true,
+	"GET /v1/auth/signup":                        true,
+	"POST /v1/auth/verify-email":                 true,
+	"POST /v1/auth/verify-email/resend":          true,
+	"GET /v1/auth/jwks":                          true,
+	"POST /v1/auth/refresh":                      true,
+	"POST /v1/auth/logout":                       true,
+}
+
+// viewerMutations are the non-GET routes a viewer may call: read-only
+// queries sent as POST and actions on the viewer's own account
+var viewerMutations = map[string]bool{
+	"POST /v1/services/:id/logs/search":                       true,
+	"POST /v1/integrations/github/repos/:owner/:repo/analyze": true,
+	"POST /v1/previews/:id/access":                            true,
+	"POST /v1/integrations/github/link":                       true,
+	"POST /v1/invitations/:token/accept":                      true,
+	"POST /v1/invitations/:token/decline":                     true,
+	"POST /v1/user/tokens":                                    true,
+	"DELETE /v1/user/tokens/:token_id":                        true,
+	"POST /v1/user/two-factor/enroll":                         true,
+	"POST /v1/user/two-factor/enable":                         true,
+	"POST /v1/user/two-factor/verify":                         true,
+	"POST /v1/user/two-factor/recovery-codes":                 true,
+	"DELETE /v1/user/two-factor":                              true,
+	"POST /v1/me/sessions/revoke-others":                      true,
+	"DELETE /v1/me/sessions/:id":                              true,
+}
+
+// stubAuth authenticates every request with a fixed role and leaves
+// authorization to auth.Authorize
+type stubAuth struct {
+	role string
+}
+
+func (s *stubAuth) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_role", s.role)
+		c.Next()
+	}
+}
+
+func (s *stubAuth) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) { c.Next() }
+}
+
+func newAuthorizationRouter(role string) *gin.Engine {
+	h := &Handler{
+		config:          &config.Config{AuthMode: "local"},
+		auth:            &stubAuth{role: role},
+		auditMiddleware: audit.NewMiddleware(nil),
+	}
+	router := gin.New()
+	SetupRoutes(router, h)
+	return router
+}
+
+// routePath fills the parameters of a route pattern with placeholder values
+func routePath(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "p" + strings.TrimPrefix(segment, ":")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestEveryProtectedRouteHasPermission(t *testing.T) {
+	routes := newAuthorizationRouter(string(auth.RoleViewer)).Routes()
+
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if publicRoutes[key] {
+			continue
+		}
+		if _, ok := auth.GetRequiredPermission(route.Method, route.Path); !ok {
+			t.Errorf("%s has no entry in auth.EndpointPermissions", key)
+		}
+	}
+
+	for method, paths := range auth.EndpointPermissions {
+		for path := range paths {
+			if !registered[method+" "+path] {
+				t.Errorf("auth.EndpointPermissions maps %s %s, which is not a registered route", method, path)
+			}
+		}
+	}
+}
+
+func TestViewerCannotMutate(t *testing.T) {
+	router := newAuthorizationRouter(string(auth.RoleViewer))
+
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		if route.Method == http.MethodGet || publicRoutes[key] || viewerMutations[key] {
+			continue
+		}
+
+		t.Run(key, func(t *testing.T) {
+			req := httptest.NewRequest(route.Method, routePath(route.Path), strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("viewer got status %d, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestViewerMutationsAreReadOnlyOrSelfService(t *testing.T) {
+	for key := range viewerMutations {
+		method, path, _ := strings.Cut(key, " ")
+		permission, ok := auth.GetRequiredPermission(method, path)
+		if !ok {
+			t.Errorf("%s has no entry in auth.EndpointPermissions", key)
+			continue
+		}
+		if !auth.HasPermission(auth.RoleViewer, permission) {
+			t.Errorf("%s requires %s, which viewers do not have", key, permission)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// defaultAutoDeployBranches build when a service has no branch rules
+var defaultAutoDeployBranches = []string{"main", "master"}
+
+// autoDeploySkipReason applies the auto-deploy rules of a service to a push.
+// It returns the environment a tag build deploys to, or why the push does
+// not build the service.
+func autoDeploySkipReason(service *types.Service, push *pushBuild) (deployEnv, reason string) {
+	rules := service.AutoDeployRules
+	if rules == nil {
+		rules = &types.AutoDeployRules{}
+	}
+	if rules.Mode == types.AutoDeployModeManual {
+		return "", "Service is deployed manually"
+	}
+
+	if push.Tag != "" {
+		for _, rule := range rules.Tags {
+			if matchRef(rule.Pattern, push.Tag) {
+				deployEnv = rule.Environment
+				break
+			}
+		}
+		if deployEnv == "" {
+			return "", fmt.Sprintf("Tag %s matches no tag rule", push.Tag)
+		}
+	} else if !matchAnyRef(autoDeployBranches(service), push.Branch) {
+		return "", fmt.Sprintf("Branch %s is not an auto-deploy branch", push.Branch)
+	}
+
+	if onlyIgnoredChanges(rules.IgnorePaths, push.ChangedFiles) {
+		return "", "Only ignored paths changed"
+	}
+	return deployEnv, ""
+}
+
+// autoDeployBranches are the branch globs whose pushes build a service: its
+// branch rules, or else main, master and its auto-deploy branch
+func autoDeployBranches(service *types.Service) []string {
+	if service.AutoDeployRules != nil && len(service.AutoDeployRules.Branches) > 0 {
+		return service.AutoDeployRules.Branches
+	}
+	branches := defaultAutoDeployBranches
+	if service.AutoDeployBranch != "" && !matchAnyRef(branches, service.AutoDeployBranch) {
+		branches = append([]string{service.AutoDeployBranch}, branches...)
+	}
+	return branches
+}
+
+// matchRef matches a branch or tag name against a glob; "*" stops at "/"
+func matchRef(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// matchAnyRef matches a branch or tag name against any of the globs
+func matchAnyRef(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchRef(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyIgnoredChanges reports whether every changed file matches an ignore
+// path. Without a list of changed files nothing is ignored.
+func onlyIgnoredChanges(ignorePaths, changedFiles []string) bool {
+	if len(ignorePaths) == 0 || len(changedFiles) == 0 {
+		return false
+	}
+	for _, file := range changedFiles {
+		ignored := false
+		for _, pattern := range ignorePaths {
+			if matchWatchPath(file, pattern) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			return false
+		}
+	}
+	return true
+}
+
+// validateAutoDeployRules checks the mode, the globs and the tag rules
+func validateAutoDeployRules(rules *types.AutoDeployRules) error {
+	switch rules.Mode {
+	case "", types.AutoDeployModeAuto, types.AutoDeployModeManual:
+	default:
+		return fmt.Errorf("mode must be %q or %q", types.AutoDeployModeAuto, types.AutoDeployModeManual)
+	}
+	for _, pattern := range append(append([]string{}, rules.Branches...), rules.IgnorePaths...) {
+		if _, err := path.Match(pattern, ""); pattern == "" || err != nil {
+			return fmt.Errorf("invalid glob %q", pattern)
+		}
+	}
+	for _, rule := range rules.Tags {
+		if _, err := path.Match(rule.Pattern, ""); rule.Pattern == "" || err != nil {
+			return fmt.Errorf("invalid tag glob %q", rule.Pattern)
+		}
+		if rule.Environment == "" {
+			return fmt.Errorf("tag rule %q needs an environment", rule.Pattern)
+		}
+	}
+	return nil
+}
+
+// isDefaultAutoDeployRules reports whether rules change nothing, so they
+// need not be stored
+func isDefaultAutoDeployRules(rules *types.AutoDeployRules) bool {
+	return (rules.Mode == "" || rules.Mode == types.AutoDeployModeAuto) &&
+		len(rules.Branches) == 0 && len(rules.IgnorePaths) == 0 && len(rules.Tags) == 0
+}
+
+// autoDeployTarget is the environment a successful build of a release
+// deploys to, or "" when it is not deployed. Builds of tag pushes go to the
+// environment of the tag rule they matched.
+func autoDeployTarget(service *types.Service, release *types.Release) string {
+	if release.DeployEnv != "" {
+		return release.DeployEnv
+	}
+	if service.AutoDeploy {
+		return service.AutoDeployEnv
+	}
+	return ""
+}
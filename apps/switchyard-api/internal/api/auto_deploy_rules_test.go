@@ -0,0 +1,75 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestAutoDeploySkipReason(t *testing.T) {
+	plain := &types.Service{Name: "api", AutoDeployBranch: "main"}
+	staging := &types.Service{Name: "api", AutoDeployBranch: "develop"}
+	ruled := &types.Service{Name: "api", AutoDeployRules: &types.AutoDeployRules{
+		Branches:    []string{"main", "release/*"},
+		IgnorePaths: []string{"docs/**", "*.md"},
+		Tags:        []types.TagDeployRule{{Pattern: "v*", Environment: "production"}},
+	}}
+	manual := &types.Service{Name: "api", AutoDeployRules: &types.AutoDeployRules{Mode: types.AutoDeployModeManual}}
+
+	tests := []struct {
+		name    string
+		service *types.Service
+		push    pushBuild
+		env     string
+		skip    bool
+	}{
+		{"main without rules", plain, pushBuild{Branch: "main"}, "", false},
+		{"master without rules", plain, pushBuild{Branch: "master"}, "", false},
+		{"feature branch without rules", plain, pushBuild{Branch: "feature/x"}, "", true},
+		{"auto-deploy branch", staging, pushBuild{Branch: "develop"}, "", false},
+		{"tag without rules", plain, pushBuild{Tag: "v1.0.0"}, "", true},
+		{"branch glob", ruled, pushBuild{Branch: "release/1.2"}, "", false},
+		{"branch rules replace the defaults", ruled, pushBuild{Branch: "master"}, "", true},
+		{"only ignored files", ruled, pushBuild{Branch: "main", ChangedFiles: []string{"docs/guide.md", "README.md"}}, "", true},
+		{"ignored and other files", ruled, pushBuild{Branch: "main", ChangedFiles: []string{"README.md", "main.go"}}, "", false},
+		{"no changed files listed", ruled, pushBuild{Branch: "main"}, "", false},
+		{"tag rule", ruled, pushBuild{Tag: "v1.0.0"}, "production", false},
+		{"tag without a rule", ruled, pushBuild{Tag: "nightly"}, "", true},
+		{"manual", manual, pushBuild{Branch: "main"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, reason := autoDeploySkipReason(tt.service, &tt.push)
+			if skipped := reason != ""; skipped != tt.skip {
+				t.Errorf("autoDeploySkipReason() reason = %q, want skipped %v", reason, tt.skip)
+			}
+			if env != tt.env {
+				t.Errorf("autoDeploySkipReason() env = %q, want %q", env, tt.env)
+			}
+		})
+	}
+}
+
+func TestValidateAutoDeployRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   types.AutoDeployRules
+		wantErr bool
+	}{
+		{"empty", types.AutoDeployRules{}, false},
+		{"full", types.AutoDeployRules{Mode: types.AutoDeployModeAuto, Branches: []string{"release/*"},
+			IgnorePaths: []string{"docs/**"}, Tags: []types.TagDeployRule{{Pattern: "v*", Environment: "production"}}}, false},
+		{"unknown mode", types.AutoDeployRules{Mode: "sometimes"}, true},
+		{"bad glob", types.AutoDeployRules{Branches: []string{"release/["}}, true},
+		{"tag rule without environment", types.AutoDeployRules{Tags: []types.TagDeployRule{{Pattern: "v*"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAutoDeployRules(&tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("validateAutoDeployRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
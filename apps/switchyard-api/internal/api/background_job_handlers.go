@@ -0,0 +1,116 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ListBackgroundJobs lists background jobs, newest first, for platform
+// operators. ?status= and ?kind= filter them, e.g. ?status=failed.
+// GET /v1/admin/jobs
+func (h *Handler) ListBackgroundJobs(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	query, err := GetListQuery(c, "created_at", "updated_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch types.BackgroundJobStatus(query.Status) {
+	case "", types.BackgroundJobStatusQueued, types.BackgroundJobStatusRunning,
+		types.BackgroundJobStatusSucceeded, types.BackgroundJobStatusFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be queued, running, succeeded or failed"})
+		return
+	}
+
+	jobs, err := h.repos.BackgroundJobs.List(ctx, c.Query("kind"), query.ListParams)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list background jobs", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list background jobs"})
+		return
+	}
+	jobs, next := db.TrimPage(jobs, query.ListParams, func(job *types.BackgroundJob) db.Cursor {
+		return itemCursor(query.Sort, job.ID, "", job.CreatedAt, job.UpdatedAt)
+	})
+	if jobs == nil {
+		jobs = []*types.BackgroundJob{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":        jobs,
+		"next_cursor": encodeListCursor(query.ListParams, next),
+	})
+}
+
+// GetBackgroundJob returns a background job with its payload and last error
+// GET /v1/admin/jobs/:id
+func (h *Handler) GetBackgroundJob(c *gin.Context) {
+	job := h.loadBackgroundJob(c)
+	if job == nil {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryBackgroundJob queues a failed background job again with a fresh set
+// of attempts, e.g. once the outage that failed it is over
+// POST /v1/admin/jobs/:id/retry
+func (h *Handler) RetryBackgroundJob(c *gin.Context) {
+	job := h.loadBackgroundJob(c)
+	if job == nil {
+		return
+	}
+	if job.Status != types.BackgroundJobStatusFailed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only failed jobs can be retried"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	requeued, err := h.repos.BackgroundJobs.Requeue(ctx, job.ID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only failed jobs can be retried"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to retry background job", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry background job"})
+		return
+	}
+
+	h.auditAdminChange(c, "admin.background_job_retried", "background_job", job.ID.String(), job.Kind, map[string]interface{}{
+		"attempts":   job.Attempts,
+		"last_error": job.LastError,
+	})
+	c.JSON(http.StatusOK, requeued)
+}
+
+// loadBackgroundJob returns the job of the :id parameter, or responds with
+// an error and returns nil
+func (h *Handler) loadBackgroundJob(c *gin.Context) *types.BackgroundJob {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return nil
+	}
+	ctx := c.Request.Context()
+
+	job, err := h.repos.BackgroundJobs.GetByID(ctx, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Background job not found"})
+		return nil
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get background job", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get background job"})
+		return nil
+	}
+	return job
+}
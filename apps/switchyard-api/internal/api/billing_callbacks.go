@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Waybill billing notification kinds
+const (
+	billingPaymentFailed        = "payment_failed"
+	billingSuspended            = "suspended"
+	billingPaymentRecovered     = "payment_recovered"
+	billingCardExpiring         = "card_expiring"
+	billingSubscriptionCanceled = "subscription_canceled"
+)
+
+// BillingCallbackRequest is sent by Waybill when the payment standing of a
+// team or project changes, from Stripe webhooks
+type BillingCallbackRequest struct {
+	Kind           string       `json:"kind" binding:"required,oneof=payment_failed suspended payment_recovered card_expiring subscription_canceled"`
+	TeamID         *uuid.UUID   `json:"team_id,omitempty"`
+	ProjectID      *uuid.UUID   `json:"project_id,omitempty"` // Set for projects without a team
+	ProjectIDs     []uuid.UUID  `json:"project_ids"`
+	Status         string       `json:"status"` // active, past_due or suspended
+	Amount         float64      `json:"amount,omitempty"`
+	Currency       string       `json:"currency,omitempty"`
+	FailedPayments int          `json:"failed_payments,omitempty"`
+	SuspendAfter   int          `json:"suspend_after,omitempty"`
+	NextAttempt    *time.Time   `json:"next_attempt,omitempty"`
+	InvoiceURL     string       `json:"invoice_url,omitempty"`
+	Resume         bool         `json:"resume,omitempty"` // Recovered from suspension
+	Card           *BillingCard `json:"card,omitempty"`
+	PlanName       string       `json:"plan_name,omitempty"`
+}
+
+// BillingCard describes an expiring card
+type BillingCard struct {
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+}
+
+// BillingCallback sends dunning and billing emails and, when an account is
+// suspended for non-payment, scales all services of its projects to zero
+// until a payment succeeds
+// POST /v1/callbacks/billing
+func (h *Handler) BillingCallback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if !h.authorizeWaybillCallback(c) {
+		return
+	}
+
+	var req BillingCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info(ctx, "Received billing callback from Waybill",
+		logging.String("kind", req.Kind),
+		logging.String("status", req.Status),
+		logging.Int("failed_payments", req.FailedPayments))
+
+	exists, err := h.billingAccountExists(ctx, &req)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to look up billing account", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up billing account"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Billing account not found"})
+		return
+	}
+
+	// Only projects the billing account pays for are acted on
+	var projects []*types.Project
+	for _, projectID := range req.ProjectIDs {
+		project, err := h.repos.Projects.GetByID(ctx, projectID)
+		if err != nil {
+			h.logger.Warn(ctx, "Project of billing account not found",
+				logging.String("project_id", projectID.String()),
+				logging.Error("error", err))
+			continue
+		}
+		covered, err := h.repos.BillingAccounts.Covers(ctx, req.TeamID, req.ProjectID, project.ID)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to check project of billing account",
+				logging.String("project_id", projectID.String()),
+				logging.Error("error", err))
+			continue
+		}
+		if !covered {
+			h.logger.Warn(ctx, "Project is not paid for by billing account",
+				logging.String("project_id", projectID.String()))
+			continue
+		}
+		projects = append(projects, project)
+	}
+
+	var changed []string
+	switch {
+	case req.Kind == billingSuspended:
+		for _, project := range projects {
+			changed = append(changed, h.setBillingSuspension(ctx, project, true)...)
+		}
+	case req.Kind == billingPaymentRecovered && req.Resume:
+		for _, project := range projects {
+			changed = append(changed, h.setBillingSuspension(ctx, project, false)...)
+		}
+	}
+
+	h.sendBillingEmails(ctx, &req, projects, changed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "processed",
+		"kind":     req.Kind,
+		"services": changed,
+	})
+}
+
+// billingAccountExists reports whether the team, or the project without a
+// team, of a callback has a billing account
+func (h *Handler) billingAccountExists(ctx context.Context, req *BillingCallbackRequest) (bool, error) {
+	switch {
+	case req.TeamID != nil:
+		return h.repos.BillingAccounts.ExistsForTeam(ctx, *req.TeamID)
+	case req.ProjectID != nil:
+		return h.repos.BillingAccounts.ExistsForProject(ctx, *req.ProjectID)
+	}
+	return false, nil
+}
+
+// setBillingSuspension scales every service of a project to zero, or back
+// to the replicas of its current deployment. It returns the services it
+// changed as "service (environment)".
+func (h *Handler) setBillingSuspension(ctx context.Context, project *types.Project, suspend bool) []string {
+	if !suspend {
+		// A team suspended by a platform operator stays down after payment
+		if h.teamSuspension(ctx, project.ID) != nil {
+			h.logger.Info(ctx, "Team is suspended; billing resume skipped",
+				logging.String("project_id", project.ID.String()))
+			return nil
+		}
+	}
+
+	changed := h.scaleProjectServices(ctx, project, suspend)
+
+	action := "billing.services_resumed"
+	if suspend {
+		action = "billing.services_suspended"
+	}
+	if len(changed) > 0 {
+		h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+			ActorEmail:   "system",
+			ActorRole:    types.RoleSystem,
+			Action:       action,
+			ResourceType: "project",
+			ResourceID:   project.ID.String(),
+			ResourceName: project.Name,
+			ProjectID:    &project.ID,
+			Outcome:      "success",
+			Context: map[string]interface{}{
+				"services": changed,
+			},
+		})
+	}
+
+	h.logger.Info(ctx, "Billing suspension applied",
+		logging.String("project_id", project.ID.String()),
+		logging.String("action", action),
+		logging.Int("services", len(changed)))
+
+	return changed
+}
+
+// scaleProjectServices scales every service of a project to zero in each
+// environment, or back to the replicas of its current deployment there. It
+// returns the services scaled, as "name (environment)".
+func (h *Handler) scaleProjectServices(ctx context.Context, project *types.Project, down bool) []string {
+	if h.k8sClient == nil {
+		h.logger.Warn(ctx, "Kubernetes client not configured; services not scaled",
+			logging.String("project_id", project.ID.String()))
+		return nil
+	}
+
+	environments, err := h.repos.Environments.ListByProject(project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list environments for scaling",
+			logging.String("project_id", project.ID.String()),
+			logging.Error("error", err))
+		return nil
+	}
+	services, err := h.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list services for scaling",
+			logging.String("project_id", project.ID.String()),
+			logging.Error("error", err))
+		return nil
+	}
+
+	var changed []string
+	for _, env := range environments {
+		for _, service := range services {
+			replicas := int32(0)
+			if !down {
+				deployment, err := h.repos.Deployments.GetCurrentByServiceAndEnvironment(ctx, service.ID, env.ID)
+				if err != nil || deployment.Replicas == 0 {
+					continue
+				}
+				replicas = int32(deployment.Replicas)
+			}
+
+			if err := h.k8sClient.ScaleDeployment(ctx, env.KubeNamespace, service.Name, replicas); err != nil {
+				// Services not deployed to this environment have no deployment
+				h.logger.Debug(ctx, "Failed to scale service",
+					logging.String("service", service.Name),
+					logging.String("namespace", env.KubeNamespace),
+					logging.Error("error", err))
+				continue
+			}
+			changed = append(changed, fmt.Sprintf("%s (%s)", service.Name, env.Name))
+		}
+	}
+	return changed
+}
+
+// billingAccountName returns the team or project name billing emails refer
+// to
+func (h *Handler) billingAccountName(ctx context.Context, req *BillingCallbackRequest, projects []*types.Project) string {
+	if req.TeamID != nil {
+		if team, err := h.repos.Teams.GetByID(ctx, *req.TeamID); err == nil {
+			return team.Name
+		}
+		return req.TeamID.String()
+	}
+	if len(projects) > 0 {
+		return projects[0].Name
+	}
+	if req.ProjectID != nil {
+		return req.ProjectID.String()
+	}
+	return "your account"
+}
+
+// sendBillingEmails emails a billing notification to the account's
+// recipients without blocking the response
+func (h *Handler) sendBillingEmails(ctx context.Context, req *BillingCallbackRequest, projects []*types.Project, services []string) {
+	if h.emailService == nil {
+		return
+	}
+
+	recipients := h.billingRecipients(ctx, req.TeamID, projects)
+	if len(recipients) == 0 {
+		h.logger.Warn(ctx, "No recipients for billing email",
+			logging.String("kind", req.Kind))
+		return
+	}
+
+	name := h.billingAccountName(ctx, req, projects)
+	go func() {
+		emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, email := range recipients {
+			var err error
+			switch req.Kind {
+			case billingPaymentFailed:
+				err = h.emailService.SendPaymentFailed(emailCtx, notifications.PaymentFailedData{
+					Email:          email,
+					AccountName:    name,
+					Amount:         req.Amount,
+					Currency:       req.Currency,
+					FailedPayments: req.FailedPayments,
+					SuspendAfter:   req.SuspendAfter,
+					NextAttempt:    req.NextAttempt,
+					InvoiceURL:     req.InvoiceURL,
+				})
+			case billingSuspended:
+				err = h.emailService.SendServicesSuspended(emailCtx, notifications.ServicesSuspendedData{
+					Email:           email,
+					AccountName:     name,
+					Amount:          req.Amount,
+					Currency:        req.Currency,
+					InvoiceURL:      req.InvoiceURL,
+					StoppedServices: services,
+				})
+			case billingPaymentRecovered:
+				err = h.emailService.SendPaymentRecovered(emailCtx, notifications.PaymentRecoveredData{
+					Email:           email,
+					AccountName:     name,
+					Amount:          req.Amount,
+					Currency:        req.Currency,
+					ResumedServices: services,
+				})
+			case billingCardExpiring:
+				if req.Card == nil {
+					return
+				}
+				err = h.emailService.SendCardExpiring(emailCtx, notifications.CardExpiringData{
+					Email:       email,
+					AccountName: name,
+					Brand:       req.Card.Brand,
+					Last4:       req.Card.Last4,
+					ExpMonth:    req.Card.ExpMonth,
+					ExpYear:     req.Card.ExpYear,
+				})
+			case billingSubscriptionCanceled:
+				err = h.emailService.SendSubscriptionCanceled(emailCtx, notifications.SubscriptionCanceledData{
+					Email:       email,
+					AccountName: name,
+					PlanName:    req.PlanName,
+				})
+			}
+			if err != nil {
+				h.logger.Error(emailCtx, "Failed to send billing email",
+					logging.String("email", email),
+					logging.String("kind", req.Kind),
+					logging.Error("error", err))
+			}
+		}
+	}()
+}
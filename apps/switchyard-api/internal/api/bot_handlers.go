@@ -0,0 +1,534 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// botNameRegex matches bot names: lowercase letters, digits and dashes
+var botNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$`)
+
+// BotGrantRequest names a project, and optionally one of its environments,
+// that a bot may deploy to, roll back, scale and configure
+type BotGrantRequest struct {
+	Project     string `json:"project" binding:"required"` // Project slug
+	Environment string `json:"environment"`                // Environment name; empty = every environment
+}
+
+// CreateBotRequest represents the request to create a bot
+type CreateBotRequest struct {
+	Name        string            `json:"name" binding:"required,min=2,max=100"`
+	Description string            `json:"description"`
+	Role        string            `json:"role"` // developer (default) or viewer
+	Grants      []BotGrantRequest `json:"grants"`
+}
+
+// UpdateBotRequest represents the request to update a bot
+type UpdateBotRequest struct {
+	Description *string `json:"description,omitempty"`
+	Role        *string `json:"role,omitempty"`
+	Disabled    *bool   `json:"disabled,omitempty"` // Disabled bots' tokens are rejected
+}
+
+// ReplaceBotGrantsRequest represents the request to set the grants of a bot
+type ReplaceBotGrantsRequest struct {
+	Grants []BotGrantRequest `json:"grants"`
+}
+
+// CreateBotTokenRequest represents the request to create a token for a bot
+type CreateBotTokenRequest struct {
+	Name      string `json:"name" binding:"required,min=1,max=100"`
+	ExpiresIn *int   `json:"expires_in_days,omitempty"` // Optional expiration in days
+}
+
+// validBotRole reports whether a bot may have a role. Bots are never admins.
+func validBotRole(role types.Role) bool {
+	return role == types.RoleDeveloper || role == types.RoleViewer
+}
+
+// ListBots returns all bots with their grants
+// GET /v1/bots
+func (h *Handler) ListBots(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	bots, err := h.repos.Bots.List(ctx)
+	if err != nil && !isTableNotExistError(err) {
+		h.logger.Error(ctx, "Failed to list bots", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list bots"})
+		return
+	}
+	if bots == nil {
+		bots = []*types.Bot{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bots": bots})
+}
+
+// CreateBot creates a bot identity for a CI pipeline
+// POST /v1/bots
+func (h *Handler) CreateBot(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateBotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if !botNameRegex.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bot name must contain only lowercase letters, digits and dashes"})
+		return
+	}
+
+	bot := &types.Bot{
+		Name:        req.Name,
+		Description: req.Description,
+		Role:        types.RoleDeveloper,
+	}
+	if req.Role != "" {
+		bot.Role = types.Role(req.Role)
+	}
+	if !validBotRole(bot.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bot role must be developer or viewer"})
+		return
+	}
+
+	grants, ok := h.resolveBotGrants(c, req.Grants)
+	if !ok {
+		return
+	}
+	bot.Grants = grants
+
+	if userID, err := auth.GetUserIDFromContext(c); err == nil {
+		bot.CreatedBy = &userID
+	}
+	if userEmail, err := auth.GetUserEmailFromContext(c); err == nil {
+		bot.CreatedByEmail = userEmail
+	}
+
+	err := h.repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+		if err := tx.Bots.Create(ctx, bot); err != nil {
+			return err
+		}
+		return tx.Bots.ReplaceGrants(ctx, bot.ID, bot.Grants)
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			c.JSON(http.StatusConflict, gin.H{"error": "A bot with this name already exists"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to create bot", logging.String("name", req.Name), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bot"})
+		return
+	}
+
+	h.auditBot(c, bot, "bot.created", map[string]interface{}{
+		"role":   bot.Role,
+		"grants": len(bot.Grants),
+	})
+
+	c.JSON(http.StatusCreated, bot)
+}
+
+// GetBot returns a bot with its grants
+// GET /v1/bots/:id
+func (h *Handler) GetBot(c *gin.Context) {
+	bot := h.loadBot(c)
+	if bot == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, bot)
+}
+
+// UpdateBot changes the description or role of a bot, or disables it
+// PATCH /v1/bots/:id
+func (h *Handler) UpdateBot(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req UpdateBotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	bot := h.loadBot(c)
+	if bot == nil {
+		return
+	}
+
+	if req.Description != nil {
+		bot.Description = *req.Description
+	}
+	if req.Role != nil {
+		if !validBotRole(types.Role(*req.Role)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bot role must be developer or viewer"})
+			return
+		}
+		bot.Role = types.Role(*req.Role)
+	}
+	if req.Disabled != nil {
+		bot.Disabled = *req.Disabled
+	}
+
+	if err := h.repos.Bots.Update(ctx, bot); err != nil {
+		h.logger.Error(ctx, "Failed to update bot", logging.String("bot_id", bot.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bot"})
+		return
+	}
+
+	h.auditBot(c, bot, "bot.updated", map[string]interface{}{
+		"role":     bot.Role,
+		"disabled": bot.Disabled,
+	})
+
+	c.JSON(http.StatusOK, bot)
+}
+
+// DeleteBot deletes a bot together with its grants and tokens
+// DELETE /v1/bots/:id
+func (h *Handler) DeleteBot(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	bot := h.loadBot(c)
+	if bot == nil {
+		return
+	}
+
+	if err := h.repos.Bots.Delete(ctx, bot.ID); err != nil {
+		h.logger.Error(ctx, "Failed to delete bot", logging.String("bot_id", bot.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bot"})
+		return
+	}
+
+	h.auditBot(c, bot, "bot.deleted", nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// ReplaceBotGrants sets the projects and environments a bot's tokens may
+// act on. Tokens pick the new grants up on their next request.
+// PUT /v1/bots/:id/grants
+func (h *Handler) ReplaceBotGrants(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req ReplaceBotGrantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	bot := h.loadBot(c)
+	if bot == nil {
+		return
+	}
+
+	grants, ok := h.resolveBotGrants(c, req.Grants)
+	if !ok {
+		return
+	}
+
+	err := h.repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+		return tx.Bots.ReplaceGrants(ctx, bot.ID, grants)
+	})
+	if err != nil {
+		h.logger.Error(ctx, "Failed to replace bot grants", logging.String("bot_id", bot.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bot grants"})
+		return
+	}
+	bot.Grants = grants
+
+	h.auditBot(c, bot, "bot.grants_updated", map[string]interface{}{
+		"grants": len(grants),
+	})
+
+	c.JSON(http.StatusOK, bot)
+}
+
+// ListBotTokens lists the API tokens that act as a bot
+// GET /v1/bots/:id/tokens
+func (h *Handler) ListBotTokens(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	bot := h.loadBot(c)
+	if bot == nil {
+		return
+	}
+
+	dbTokens, err := h.repos.APITokens.ListByBot(ctx, bot.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list bot tokens", logging.String("bot_id", bot.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tokens"})
+		return
+	}
+
+	tokens := []*APITokenResponse{}
+	for _, t := range dbTokens {
+		tokens = append(tokens, &APITokenResponse{
+			ID:         t.ID,
+			Name:       t.Name,
+			Prefix:     t.Prefix,
+			ExpiresAt:  t.ExpiresAt,
+			LastUsedAt: t.LastUsedAt,
+			CreatedAt:  t.CreatedAt,
+			Revoked:    t.Revoked,
+		})
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateBotToken creates an API token that acts as a bot
+// POST /v1/bots/:id/tokens
+func (h *Handler) CreateBotToken(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateBotTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	userID, err := auth.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bot := h.loadBot(c)
+	if bot == nil {
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn != nil && *req.ExpiresIn > 0 {
+		exp := time.Now().AddDate(0, 0, *req.ExpiresIn)
+		expiresAt = &exp
+	}
+
+	tokenResp, err := h.repos.APITokens.CreateForBot(ctx, bot.ID, userID, req.Name, expiresAt)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to create bot token", logging.String("bot_id", bot.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	h.auditBot(c, bot, "bot.token_created", map[string]interface{}{
+		"token_id":   tokenResp.ID,
+		"token_name": req.Name,
+	})
+
+	c.JSON(http.StatusCreated, tokenResp)
+}
+
+// RevokeBotToken revokes an API token of a bot
+// DELETE /v1/bots/:id/tokens/:token_id
+func (h *Handler) RevokeBotToken(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tokenID, err := uuid.Parse(c.Param("token_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID format"})
+		return
+	}
+
+	bot := h.loadBot(c)
+	if bot == nil {
+		return
+	}
+
+	if err := h.repos.APITokens.RevokeForBot(ctx, tokenID, bot.ID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found or already revoked"})
+		return
+	}
+
+	h.auditBot(c, bot, "bot.token_revoked", map[string]interface{}{
+		"token_id": tokenID,
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+// loadBot returns the bot named by :id, or writes the error response and
+// returns nil
+func (h *Handler) loadBot(c *gin.Context) *types.Bot {
+	botID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bot ID"})
+		return nil
+	}
+
+	bot, err := h.repos.Bots.GetByID(c.Request.Context(), botID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bot not found"})
+			return nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get bot", logging.String("bot_id", botID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bot"})
+		return nil
+	}
+	return bot
+}
+
+// resolveBotGrants turns project slugs and environment names into grants,
+// dropping duplicates, or writes the error response and returns false
+func (h *Handler) resolveBotGrants(c *gin.Context, reqs []BotGrantRequest) ([]types.BotGrant, bool) {
+	grants := []types.BotGrant{}
+	seen := make(map[string]bool)
+
+	for _, req := range reqs {
+		project, err := h.repos.Projects.GetBySlug(req.Project)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Project %q not found", req.Project)})
+				return nil, false
+			}
+			h.logger.Error(c.Request.Context(), "Failed to get project", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+			return nil, false
+		}
+
+		grant := types.BotGrant{ProjectID: project.ID}
+		if req.Environment != "" {
+			env, err := h.repos.Environments.GetByProjectAndName(project.ID, req.Environment)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Environment %q not found in project %q", req.Environment, req.Project)})
+					return nil, false
+				}
+				h.logger.Error(c.Request.Context(), "Failed to get environment", logging.Error("error", err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get environment"})
+				return nil, false
+			}
+			grant.EnvironmentID = &env.ID
+		}
+
+		key := grant.ProjectID.String()
+		if grant.EnvironmentID != nil {
+			key += "/" + grant.EnvironmentID.String()
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		grants = append(grants, grant)
+	}
+
+	return grants, true
+}
+
+// auditBot records a change to a bot
+func (h *Handler) auditBot(c *gin.Context, bot *types.Bot, action string, details map[string]interface{}) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   email,
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       action,
+		ResourceType: "bot",
+		ResourceID:   bot.ID.String(),
+		ResourceName: bot.Name,
+		Outcome:      "success",
+		Context:      details,
+	})
+}
+
+// authorizeEnvironment rejects requests from bot tokens whose grants do not
+// cover the environment. A nil environment stands for every environment of
+// the project and needs a grant for the whole project.
+func (h *Handler) authorizeEnvironment(c *gin.Context, projectID uuid.UUID, environmentID *uuid.UUID) bool {
+	scope := auth.TokenScopeFromContext(c)
+	if scope == nil || scope.Allows(projectID, environmentID) {
+		return true
+	}
+
+	h.logger.Warn(c.Request.Context(), "Bot token denied outside its grants",
+		logging.String("bot", scope.BotName),
+		logging.String("project_id", projectID.String()),
+		logging.String("path", c.FullPath()))
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":   "Forbidden",
+		"message": fmt.Sprintf("Bot %s is not granted access to this environment", scope.BotName),
+	})
+	return false
+}
+
+// authorizeNamedEnvironment is authorizeEnvironment for an environment given
+// by project slug and name. They are only looked up for bot tokens.
+func (h *Handler) authorizeNamedEnvironment(c *gin.Context, projectSlug, envName string) bool {
+	if auth.TokenScopeFromContext(c) == nil {
+		return true
+	}
+
+	project, err := h.repos.Projects.GetBySlug(projectSlug)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return false
+	}
+	env, err := h.repos.Environments.GetByProjectAndName(project.ID, envName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return false
+	}
+	return h.authorizeEnvironment(c, project.ID, &env.ID)
+}
+
+// authorizeServiceEnvironment is authorizeEnvironment for the project of a
+// service. The service is only loaded for bot tokens.
+func (h *Handler) authorizeServiceEnvironment(c *gin.Context, serviceID uuid.UUID, environmentID *uuid.UUID) bool {
+	if auth.TokenScopeFromContext(c) == nil {
+		return true
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return false
+	}
+	return h.authorizeEnvironment(c, service.ProjectID, environmentID)
+}
+
+// authorizeDeploymentEnvironment is authorizeEnvironment for the environment
+// of a deployment. Its release and service are only loaded for bot tokens.
+func (h *Handler) authorizeDeploymentEnvironment(c *gin.Context, deploymentID uuid.UUID) bool {
+	if auth.TokenScopeFromContext(c) == nil {
+		return true
+	}
+
+	deployment, err := h.repos.Deployments.GetByID(c.Request.Context(), deploymentID.String())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deployment not found"})
+		return false
+	}
+	release, err := h.repos.Releases.GetByID(deployment.ReleaseID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+		return false
+	}
+	return h.authorizeServiceEnvironment(c, release.ServiceID, &deployment.EnvironmentID)
+}
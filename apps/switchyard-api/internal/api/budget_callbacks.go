@@ -0,0 +1,355 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// budgetAlertHardCap is the Waybill alert kind for an exceeded hard cap
+const budgetAlertHardCap = "hard_cap"
+
+// BudgetCallbackRequest is sent by Waybill when a budget crosses an alert
+// threshold or its hard cap
+type BudgetCallbackRequest struct {
+	BudgetID    uuid.UUID   `json:"budget_id" binding:"required"`
+	TeamID      *uuid.UUID  `json:"team_id,omitempty"`
+	ProjectID   *uuid.UUID  `json:"project_id,omitempty"` // Set for project budgets
+	ProjectIDs  []uuid.UUID `json:"project_ids"`
+	Kind        string      `json:"kind" binding:"required,oneof=threshold hard_cap"`
+	Threshold   int         `json:"threshold"`
+	PeriodStart time.Time   `json:"period_start"`
+	Spend       float64     `json:"spend"`
+	Limit       float64     `json:"limit"`
+	Currency    string      `json:"currency"`
+}
+
+// BudgetCallback sends budget alerts and, when the hard cap is exceeded,
+// scales the non-production services of the budget's projects to zero
+// POST /v1/callbacks/budget
+func (h *Handler) BudgetCallback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if !h.authorizeWaybillCallback(c) {
+		return
+	}
+
+	var req BudgetCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info(ctx, "Received budget callback from Waybill",
+		logging.String("budget_id", req.BudgetID.String()),
+		logging.String("kind", req.Kind),
+		logging.Int("threshold", req.Threshold))
+
+	var projects []*types.Project
+	for _, projectID := range req.ProjectIDs {
+		project, err := h.repos.Projects.GetByID(ctx, projectID)
+		if err != nil {
+			h.logger.Warn(ctx, "Project of budget not found",
+				logging.String("budget_id", req.BudgetID.String()),
+				logging.String("project_id", projectID.String()),
+				logging.Error("error", err))
+			continue
+		}
+		projects = append(projects, project)
+	}
+
+	scope, name := h.budgetScopeName(ctx, &req, projects)
+
+	stopped := map[uuid.UUID][]string{}
+	if req.Kind == budgetAlertHardCap {
+		for _, project := range projects {
+			stopped[project.ID] = h.enforceBudgetCap(ctx, &req, project)
+		}
+	}
+
+	eventType := types.WebhookEventBudgetThreshold
+	if req.Kind == budgetAlertHardCap {
+		eventType = types.WebhookEventBudgetCapExceeded
+	}
+	if h.notificationService != nil {
+		for _, project := range projects {
+			event := &types.WebhookEvent{
+				ID:        uuid.New(),
+				Type:      eventType,
+				Timestamp: time.Now(),
+				ProjectID: project.ID,
+				Project: types.WebhookProjectInfo{
+					ID:   project.ID,
+					Name: project.Name,
+					Slug: project.Slug,
+				},
+				Budget: &types.WebhookBudgetInfo{
+					Scope:        scope,
+					Name:         name,
+					Threshold:    req.Threshold,
+					Spend:        req.Spend,
+					Limit:        req.Limit,
+					Currency:     req.Currency,
+					PeriodStart:  req.PeriodStart,
+					ScaledToZero: stopped[project.ID],
+				},
+			}
+			if err := h.notificationService.SendEvent(ctx, project.ID, event); err != nil {
+				h.logger.Warn(ctx, "Failed to send budget webhook",
+					logging.String("project_id", project.ID.String()),
+					logging.Error("error", err))
+			}
+		}
+	}
+
+	var stoppedServices []string
+	for _, project := range projects {
+		stoppedServices = append(stoppedServices, stopped[project.ID]...)
+	}
+	h.sendBudgetEmails(ctx, &req, projects, name, stoppedServices)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "processed",
+		"budget_id":      req.BudgetID,
+		"scaled_to_zero": stoppedServices,
+	})
+}
+
+// authorizeWaybillCallback checks that a callback comes from Waybill, which
+// authenticates with the key switchyard uses to call it. Without a key no
+// callback is accepted.
+func (h *Handler) authorizeWaybillCallback(c *gin.Context) bool {
+	if h.config.WaybillAPIKey == "" {
+		h.logger.Warn(c.Request.Context(), "Waybill callback rejected: no Waybill API key configured",
+			logging.String("path", c.FullPath()))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Waybill callbacks are not configured"})
+		return false
+	}
+	expectedAuth := "Bearer " + h.config.WaybillAPIKey
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte(expectedAuth)) != 1 {
+		h.logger.Warn(c.Request.Context(), "Waybill callback unauthorized",
+			logging.String("path", c.FullPath()),
+			logging.String("remote_addr", c.ClientIP()))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return false
+	}
+	return true
+}
+
+// budgetScopeName returns whether a budget is a team or project budget, and
+// the name alerts refer to it by
+func (h *Handler) budgetScopeName(ctx context.Context, req *BudgetCallbackRequest, projects []*types.Project) (scope, name string) {
+	if req.ProjectID != nil {
+		for _, project := range projects {
+			if project.ID == *req.ProjectID {
+				return "project", project.Name
+			}
+		}
+		return "project", req.ProjectID.String()
+	}
+
+	if req.TeamID != nil {
+		if team, err := h.repos.Teams.GetByID(ctx, *req.TeamID); err == nil {
+			return "team", team.Name
+		}
+		return "team", req.TeamID.String()
+	}
+	return "team", req.BudgetID.String()
+}
+
+// enforceBudgetCap scales every service in the non-production environments
+// of a project to zero. It returns the services it stopped as
+// "service (environment)".
+func (h *Handler) enforceBudgetCap(ctx context.Context, req *BudgetCallbackRequest, project *types.Project) []string {
+	if h.k8sClient == nil {
+		h.logger.Warn(ctx, "Kubernetes client not configured; budget hard cap not enforced",
+			logging.String("project_id", project.ID.String()))
+		return nil
+	}
+
+	environments, err := h.repos.Environments.ListByProject(project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list environments for budget hard cap",
+			logging.String("project_id", project.ID.String()),
+			logging.Error("error", err))
+		return nil
+	}
+	services, err := h.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list services for budget hard cap",
+			logging.String("project_id", project.ID.String()),
+			logging.Error("error", err))
+		return nil
+	}
+
+	var stopped []string
+	for _, env := range environments {
+		if env.Name == "production" {
+			continue
+		}
+		for _, service := range services {
+			if err := h.k8sClient.ScaleDeployment(ctx, env.KubeNamespace, service.Name, 0); err != nil {
+				// Services not deployed to this environment have no deployment
+				h.logger.Debug(ctx, "Failed to scale service for budget hard cap",
+					logging.String("service", service.Name),
+					logging.String("namespace", env.KubeNamespace),
+					logging.Error("error", err))
+				continue
+			}
+			stopped = append(stopped, fmt.Sprintf("%s (%s)", service.Name, env.Name))
+		}
+	}
+
+	if len(stopped) > 0 {
+		h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+			ActorEmail:   "system",
+			ActorRole:    types.RoleSystem,
+			Action:       "budget.hard_cap_enforced",
+			ResourceType: "project",
+			ResourceID:   project.ID.String(),
+			ResourceName: project.Name,
+			ProjectID:    &project.ID,
+			Outcome:      "success",
+			Context: map[string]interface{}{
+				"budget_id":      req.BudgetID.String(),
+				"spend":          req.Spend,
+				"hard_limit":     req.Limit,
+				"currency":       req.Currency,
+				"scaled_to_zero": stopped,
+			},
+		})
+	}
+
+	h.logger.Info(ctx, "Budget hard cap enforced",
+		logging.String("project_id", project.ID.String()),
+		logging.Int("scaled_to_zero", len(stopped)))
+
+	return stopped
+}
+
+// billingRecipients returns who gets budget and billing emails: the team's
+// billing email, or its owners and admins. Projects without a team fall
+// back to their admins.
+func (h *Handler) billingRecipients(ctx context.Context, teamID *uuid.UUID, projects []*types.Project) []string {
+	seen := map[string]bool{}
+	var recipients []string
+	add := func(email string) {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email != "" && !seen[email] {
+			seen[email] = true
+			recipients = append(recipients, email)
+		}
+	}
+
+	if teamID != nil {
+		team, err := h.repos.Teams.GetByID(ctx, *teamID)
+		if err == nil && team.BillingEmail != nil && *team.BillingEmail != "" {
+			add(*team.BillingEmail)
+			return recipients
+		}
+
+		members, err := h.repos.TeamMembers.ListByTeam(ctx, *teamID)
+		if err != nil {
+			h.logger.Warn(ctx, "Failed to list team members for billing email",
+				logging.String("team_id", teamID.String()),
+				logging.Error("error", err))
+			return nil
+		}
+		for _, member := range members {
+			if member.Role == "owner" || member.Role == "admin" {
+				add(member.UserEmail)
+			}
+		}
+		return recipients
+	}
+
+	for _, project := range projects {
+		grants, err := h.repos.ProjectAccess.ListByProject(ctx, project.ID)
+		if err != nil {
+			h.logger.Warn(ctx, "Failed to list project access for billing email",
+				logging.String("project_id", project.ID.String()),
+				logging.Error("error", err))
+			continue
+		}
+		for _, grant := range grants {
+			if grant.Role != types.RoleAdmin {
+				continue
+			}
+			if user, err := h.repos.Users.GetByID(ctx, grant.UserID); err == nil {
+				add(user.Email)
+			}
+		}
+	}
+	return recipients
+}
+
+// sendBudgetEmails emails a budget alert to its recipients without blocking
+// the response
+func (h *Handler) sendBudgetEmails(ctx context.Context, req *BudgetCallbackRequest, projects []*types.Project, name string, stopped []string) {
+	if h.emailService == nil {
+		return
+	}
+
+	recipients := h.billingRecipients(ctx, req.TeamID, projects)
+	if len(recipients) == 0 {
+		h.logger.Warn(ctx, "No recipients for budget alert",
+			logging.String("budget_id", req.BudgetID.String()))
+		return
+	}
+
+	period := req.PeriodStart.UTC().Format("January 2006")
+	go func() {
+		emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, email := range recipients {
+			var err error
+			switch {
+			case req.Kind == budgetAlertHardCap:
+				err = h.emailService.SendBudgetCapReached(emailCtx, notifications.BudgetCapReachedData{
+					Email:           email,
+					ProjectName:     name,
+					Period:          period,
+					HardLimit:       req.Limit,
+					Spend:           req.Spend,
+					Currency:        req.Currency,
+					StoppedServices: stopped,
+				})
+			case req.Threshold >= 100:
+				err = h.emailService.SendBudgetExceeded(emailCtx, notifications.BudgetExceededData{
+					Email:       email,
+					ProjectName: name,
+					Period:      period,
+					Budget:      req.Limit,
+					Spend:       req.Spend,
+					Currency:    req.Currency,
+				})
+			default:
+				err = h.emailService.SendBudgetWarning(emailCtx, notifications.BudgetWarningData{
+					Email:       email,
+					ProjectName: name,
+					Period:      period,
+					Threshold:   req.Threshold,
+					Budget:      req.Limit,
+					Spend:       req.Spend,
+					Currency:    req.Currency,
+				})
+			}
+			if err != nil {
+				h.logger.Error(emailCtx, "Failed to send budget alert email",
+					logging.String("email", email),
+					logging.Error("error", err))
+			}
+		}
+	}()
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+)
+
+// BuildArtifactResponse is an artifact of a build with a link to download it
+type BuildArtifactResponse struct {
+	*db.BuildArtifact
+	DownloadURL string `json:"download_url,omitempty"` // Short-lived; empty when artifact storage is not configured
+}
+
+// ListBuildArtifacts lists the files a build published, with download URLs
+// GET /v1/builds/:id/artifacts
+func (h *Handler) ListBuildArtifacts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	releaseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid build ID"})
+		return
+	}
+
+	release, err := h.repos.Releases.GetByID(releaseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Build not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get release", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get build"})
+		return
+	}
+	if !h.authorizeServiceEnvironment(c, release.ServiceID, nil) {
+		return
+	}
+
+	artifacts, err := h.repos.BuildArtifacts.ListByRelease(ctx, release.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list build artifacts", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list build artifacts"})
+		return
+	}
+
+	response := make([]BuildArtifactResponse, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		item := BuildArtifactResponse{BuildArtifact: artifact}
+		if h.buildArtifacts != nil {
+			item.DownloadURL, err = h.buildArtifacts.DownloadURL(ctx, artifact)
+			if err != nil {
+				h.logger.Error(ctx, "Failed to sign build artifact URL", logging.Error("error", err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign build artifact URLs"})
+				return
+			}
+		}
+		response = append(response, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"release_id": release.ID,
+		"artifacts":  response,
+	})
+}
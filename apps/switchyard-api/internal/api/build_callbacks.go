@@ -2,12 +2,17 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -26,6 +31,18 @@ type BuildCallbackRequest struct {
 	DurationSecs   float64   `json:"duration_secs"`
 	ErrorMessage   string    `json:"error_message"`
 	LogsURL        string    `json:"logs_url"`
+
+	// Artifacts are the files the build published to the artifacts bucket
+	Artifacts []BuildCallbackArtifact `json:"artifacts,omitempty"`
+	// Redactions counts the secrets masked in the build logs, by kind
+	Redactions map[string]int `json:"redactions,omitempty"`
+}
+
+// BuildCallbackArtifact matches the Artifact type in apps/roundhouse/internal/queue/types.go
+type BuildCallbackArtifact struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+	ObjectKey string `json:"object_key"`
 }
 
 // BuildCompleteCallback handles the callback from Roundhouse when a build finishes
@@ -72,6 +89,52 @@ func (h *Handler) BuildCompleteCallback(c *gin.Context) {
 	})
 }
 
+// BuildStalledRequest is sent by Roundhouse when a job has been queued longer than its stall threshold
+type BuildStalledRequest struct {
+	JobID       uuid.UUID `json:"job_id" binding:"required"`
+	ReleaseID   uuid.UUID `json:"release_id" binding:"required"`
+	Status      string    `json:"status"`
+	WaitingSecs float64   `json:"waiting_secs"`
+}
+
+// BuildStalledCallback flags a release whose build job is stuck in the Roundhouse queue
+// POST /v1/callbacks/build-stalled
+func (h *Handler) BuildStalledCallback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	authHeader := c.GetHeader("Authorization")
+	expectedAuth := "Bearer " + h.config.RoundhouseAPIKey
+	if h.config.RoundhouseAPIKey != "" && authHeader != expectedAuth {
+		h.logger.Warn(ctx, "Build stalled callback unauthorized",
+			logging.String("remote_addr", c.ClientIP()))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req BuildStalledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reason := fmt.Sprintf("job %s %s for %s", req.JobID, req.Status,
+		(time.Duration(req.WaitingSecs) * time.Second).Round(time.Minute))
+
+	if err := h.reconciler.MarkBuildStalled(ctx, req.ReleaseID, reason); err != nil {
+		h.logger.Error(ctx, "Failed to flag stalled build",
+			logging.String("release_id", req.ReleaseID.String()),
+			logging.String("job_id", req.JobID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process callback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "processed",
+		"release_id": req.ReleaseID,
+	})
+}
+
 // processBuildCallback updates the release and triggers auto-deploy if applicable
 func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRequest) error {
 	// Get the release
@@ -83,6 +146,9 @@ func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRe
 		return err
 	}
 
+	// Roundhouse masks secrets in build logs before storing them
+	monitoring.RecordLogRedactions(redact.SourceBuild, req.Redactions)
+
 	if req.Success {
 		// Update release with build results
 		if req.ImageURI != "" {
@@ -97,6 +163,15 @@ func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRe
 				logging.String("image_uri", req.ImageURI))
 		}
 
+		// The digest lets promotions record exactly which image moved between environments
+		if req.ImageDigest != "" {
+			if err := h.repos.Releases.UpdateImageDigest(ctx, req.ReleaseID, req.ImageDigest); err != nil {
+				h.logger.Warn(ctx, "Failed to store image digest (non-fatal)",
+					logging.String("release_id", req.ReleaseID.String()),
+					logging.Error("db_error", err))
+			}
+		}
+
 		// Store SBOM if provided
 		if req.SBOM != "" {
 			if err := h.repos.Releases.UpdateSBOM(ctx, req.ReleaseID, req.SBOM, req.SBOMFormat); err != nil {
@@ -124,6 +199,35 @@ func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRe
 			}
 		}
 
+		// Record published artifacts
+		for _, artifact := range req.Artifacts {
+			if err := h.repos.BuildArtifacts.Record(ctx, &db.BuildArtifact{
+				ReleaseID: req.ReleaseID,
+				Path:      artifact.Path,
+				SizeBytes: artifact.SizeBytes,
+				ObjectKey: artifact.ObjectKey,
+			}); err != nil {
+				// Artifact storage failure is non-fatal
+				h.logger.Warn(ctx, "Failed to record build artifact (non-fatal)",
+					logging.String("release_id", req.ReleaseID.String()),
+					logging.String("path", artifact.Path),
+					logging.Error("db_error", err))
+			}
+		}
+
+		// Store build metrics for deployment cost/performance annotations
+		var imageSizeBytes *int64
+		if req.ImageSizeMB > 0 {
+			size := int64(req.ImageSizeMB * 1024 * 1024)
+			imageSizeBytes = &size
+		}
+		if err := h.repos.Releases.UpdateBuildMetrics(ctx, req.ReleaseID, imageSizeBytes, req.DurationSecs); err != nil {
+			// Metrics storage failure is non-fatal
+			h.logger.Warn(ctx, "Failed to store build metrics (non-fatal)",
+				logging.String("release_id", req.ReleaseID.String()),
+				logging.Error("db_error", err))
+		}
+
 		// Mark release as ready
 		if err := h.repos.Releases.UpdateStatus(req.ReleaseID, types.ReleaseStatusReady); err != nil {
 			h.logger.Error(ctx, "Failed to update release status to ready",
@@ -132,6 +236,9 @@ func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRe
 			return err
 		}
 
+		h.publishBuildEvent(ctx, release, types.ReleaseStatusReady, "")
+		h.recordBuildMetrics(ctx, release.ServiceID, "success", "roundhouse", secondsToDuration(req.DurationSecs))
+
 		h.logger.Info(ctx, "Build completed successfully (via Roundhouse)",
 			logging.String("release_id", req.ReleaseID.String()),
 			logging.String("job_id", req.JobID.String()),
@@ -145,10 +252,27 @@ func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRe
 				logging.String("service_id", release.ServiceID.String()),
 				logging.Error("db_error", err))
 			// Non-fatal - build succeeded, just can't auto-deploy
-		} else if service.AutoDeploy && service.AutoDeployEnv != "" {
+		} else {
+			// Roundhouse builds out-of-process, so refresh runtime detection from the repo
+			h.detectServiceRuntimes(service)
+		}
+
+		var target string
+		if err == nil {
+			target = autoDeployTarget(service, release)
+		}
+		autoDeploy := target != ""
+		if autoDeploy {
+			// Local builds are deployed explicitly by the CLI, never to the auto-deploy target
+			if local, localErr := h.isLocalRelease(ctx, release.ID); localErr != nil || local {
+				autoDeploy = false
+			}
+		}
+
+		if autoDeploy {
 			h.logger.Info(ctx, "Triggering auto-deploy from Roundhouse callback",
 				logging.String("service_name", service.Name),
-				logging.String("target_env", service.AutoDeployEnv))
+				logging.String("target_env", target))
 
 			// Log auto-deploy to Activity feed for dashboard visibility
 			h.repos.AuditLogs.Log(ctx, &types.AuditLog{
@@ -165,14 +289,14 @@ func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRe
 					"service_name": service.Name,
 					"service_id":   service.ID.String(),
 					"release_id":   release.ID.String(),
-					"target_env":   service.AutoDeployEnv,
+					"target_env":   target,
 					"trigger":      "build_success",
 					"commit_sha":   release.GitSHA,
 					"image":        req.ImageURI,
 				},
 			})
 
-			h.triggerAutoDeploy(ctx, service, release)
+			h.triggerAutoDeploy(ctx, service, release, target)
 		}
 	} else {
 		// Build failed - store the error message for debugging
@@ -187,6 +311,9 @@ func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRe
 			return err
 		}
 
+		h.publishBuildEvent(ctx, release, types.ReleaseStatusFailed, req.ErrorMessage)
+		h.recordBuildMetrics(ctx, release.ServiceID, "failure", "roundhouse", secondsToDuration(req.DurationSecs))
+
 		h.logger.Error(ctx, "Build failed (via Roundhouse)",
 			logging.String("release_id", req.ReleaseID.String()),
 			logging.String("job_id", req.JobID.String()),
@@ -196,3 +323,20 @@ func (h *Handler) processBuildCallback(ctx context.Context, req *BuildCallbackRe
 
 	return nil
 }
+
+// recordBuildMetrics records a finished build against its service's project
+func (h *Handler) recordBuildMetrics(ctx context.Context, serviceID uuid.UUID, status, buildType string, duration time.Duration) {
+	project := "unknown"
+	if service, err := h.repos.Services.GetByID(serviceID); err == nil {
+		project = service.ProjectID.String()
+		if p, err := h.repos.Projects.GetByID(ctx, service.ProjectID); err == nil {
+			project = p.Slug
+		}
+	}
+	monitoring.RecordBuild(project, status, buildType, duration)
+}
+
+// secondsToDuration converts a duration reported in seconds
+func secondsToDuration(secs float64) time.Duration {
+	return time.Duration(secs * float64(time.Second))
+}
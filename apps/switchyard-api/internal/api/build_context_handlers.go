@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildcontext"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// UploadBuildContext stores a gzipped tarball of a local working directory,
+// which can then be built with POST /v1/services/:id/build using its context_id
+func (h *Handler) UploadBuildContext(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if h.buildContexts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Build context storage is not configured"})
+		return
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	if c.Request.ContentLength > h.buildContexts.MaxSizeBytes() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     buildcontext.ErrTooLarge.Error(),
+			"max_bytes": h.buildContexts.MaxSizeBytes(),
+		})
+		return
+	}
+
+	uploadedBy := ""
+	if userEmail, ok := c.Get("user_email"); ok {
+		uploadedBy = fmt.Sprintf("%v", userEmail)
+	}
+
+	bc, err := h.buildContexts.Upload(ctx, service.ID, c.Request.Body, uploadedBy)
+	if err != nil {
+		switch {
+		case stderrors.Is(err, buildcontext.ErrTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":     err.Error(),
+				"max_bytes": h.buildContexts.MaxSizeBytes(),
+			})
+		case stderrors.Is(err, buildcontext.ErrNotGzip):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error(ctx, "Failed to upload build context",
+				logging.String("service_id", service.ID.String()),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload build context"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, bc)
+}
+
+// buildFromContext creates a release for an uploaded build context and
+// enqueues it to Roundhouse. Only Roundhouse can build from a tarball; the
+// in-process builder always clones from git.
+func (h *Handler) buildFromContext(c *gin.Context, service *types.Service, contextIDStr string, trigger buildTrigger) {
+	ctx := c.Request.Context()
+
+	if h.buildContexts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Build context storage is not configured"})
+		return
+	}
+	if h.config.BuildMode != "roundhouse" || h.roundhouseClient == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Builds from a build context require the Roundhouse build mode",
+		})
+		return
+	}
+
+	contextID, err := uuid.Parse(contextIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid context ID"})
+		return
+	}
+
+	bc, err := h.repos.BuildContexts.GetByID(ctx, contextID)
+	if err == nil && bc.ServiceID != service.ID {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Build context not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get build context", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get build context"})
+		return
+	}
+	if bc.ReleaseID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "Build context was already built",
+			"release_id": bc.ReleaseID,
+		})
+		return
+	}
+
+	sourceURL, err := h.buildContexts.SourceURL(ctx, bc)
+	if err != nil {
+		if stderrors.Is(err, buildcontext.ErrExpired) {
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get build context URL", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get build context URL"})
+		return
+	}
+
+	// The digest stands in for the git SHA, so tags and versions stay unique per upload
+	shortDigest := bc.Digest[:7]
+	release := &types.Release{
+		ID:        uuid.New(),
+		ServiceID: service.ID,
+		Version:   "local-" + time.Now().Format("20060102-150405") + "-" + shortDigest,
+		ImageURI:  h.config.Registry + "/" + service.Name + ":local-" + shortDigest,
+		GitSHA:    bc.Digest,
+		Status:    types.ReleaseStatusBuilding,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := h.repos.Releases.Create(release); err != nil {
+		h.logger.Error(ctx, "Failed to create release", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create release"})
+		return
+	}
+
+	if err := h.repos.BuildContexts.SetRelease(ctx, bc.ID, release.ID); err != nil {
+		h.logger.Error(ctx, "Failed to link build context to release", logging.Error("db_error", err))
+		h.failBuildEnqueue(ctx, release, "build context could not be enqueued")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link build context to release"})
+		return
+	}
+
+	trigger.CommitMessage = "Local build context " + bc.ID.String()
+	trigger.SourceURL = sourceURL
+	go h.enqueueToRoundhouse(context.Background(), service, release, bc.Digest, "local", trigger)
+
+	c.JSON(http.StatusCreated, release)
+}
+
+// failBuildEnqueue marks a release Roundhouse could not be given as failed.
+// Build contexts and project registries have no in-process fallback.
+func (h *Handler) failBuildEnqueue(ctx context.Context, release *types.Release, message string) {
+	if err := h.repos.Releases.UpdateStatus(release.ID, types.ReleaseStatusFailed); err != nil {
+		h.logger.Error(ctx, "Failed to update release status to failed",
+			logging.String("release_id", release.ID.String()),
+			logging.Error("db_error", err))
+	}
+	h.publishBuildEvent(ctx, release, types.ReleaseStatusFailed, message)
+}
+
+// isLocalRelease reports whether a release was built from an uploaded build
+// context rather than a git commit
+func (h *Handler) isLocalRelease(ctx context.Context, releaseID uuid.UUID) (bool, error) {
+	_, err := h.repos.BuildContexts.GetByReleaseID(ctx, releaseID)
+	if err == sql.ErrNoRows || isTableNotExistError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
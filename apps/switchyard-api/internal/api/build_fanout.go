@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// pushBuild is a push to a repository, fanned out into a build per service
+// of the repository that it affects
+type pushBuild struct {
+	Repository   string // owner/name, for the audit log
+	GitSHA       string
+	Branch       string
+	Tag          string   // Set instead of Branch for tag pushes
+	ChangedFiles []string // Empty when the provider did not list them
+	Trigger      buildTrigger
+}
+
+// pushBuildResult is the outcome of a push for one service
+type pushBuildResult struct {
+	Service   string `json:"service"`
+	ReleaseID string `json:"release_id"`
+	Status    string `json:"status"`
+	Skipped   bool   `json:"skipped,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// dispatchPushBuilds creates a release and starts a build for every service
+// the push affects, and reports what happened to each service
+func (h *Handler) dispatchPushBuilds(ctx context.Context, services []*types.Service, push *pushBuild) []pushBuildResult {
+	shared := len(services) > 1
+	results := make([]pushBuildResult, 0, len(services))
+
+	for _, service := range services {
+		// Services of a suspended team are not built
+		if h.teamSuspension(ctx, service.ProjectID) != nil {
+			h.logger.Info(ctx, "Skipping build for service - team is suspended",
+				logging.String("service", service.Name))
+			results = append(results, pushBuildResult{
+				Service: service.Name,
+				Status:  "skipped",
+				Skipped: true,
+				Reason:  "Team is suspended",
+			})
+			continue
+		}
+		deployEnv, reason := autoDeploySkipReason(service, push)
+		if reason != "" {
+			h.logger.Info(ctx, "Skipping build for service - auto-deploy rules",
+				logging.String("service", service.Name),
+				logging.String("reason", reason))
+			results = append(results, pushBuildResult{
+				Service: service.Name,
+				Status:  "skipped",
+				Skipped: true,
+				Reason:  reason,
+			})
+			continue
+		}
+		if reason := buildSkipReason(service, shared, push.ChangedFiles); reason != "" {
+			h.logger.Info(ctx, "Skipping build for service - no relevant file changes",
+				logging.String("service", service.Name),
+				logging.String("watch_paths", strings.Join(service.WatchPaths, ", ")),
+				logging.String("build_root", buildRoot(service)))
+			results = append(results, pushBuildResult{
+				Service: service.Name,
+				Status:  "skipped",
+				Skipped: true,
+				Reason:  reason,
+			})
+			continue
+		}
+
+		// Create release record for this service
+		release := &types.Release{
+			ID:        uuid.New(),
+			ServiceID: service.ID,
+			Version:   "v" + time.Now().Format("20060102-150405") + "-" + push.GitSHA[:7],
+			ImageURI:  h.config.Registry + "/" + service.Name + ":" + push.GitSHA[:7],
+			GitSHA:    push.GitSHA,
+			Status:    types.ReleaseStatusBuilding,
+			DeployEnv: deployEnv,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		if err := h.repos.Releases.Create(release); err != nil {
+			h.logger.Error(ctx, "Failed to create release for service",
+				logging.String("service", service.Name),
+				logging.Error("db_error", err))
+			results = append(results, pushBuildResult{
+				Service: service.Name,
+				Status:  "failed: " + err.Error(),
+			})
+			continue
+		}
+
+		// Trigger async build (routes to Roundhouse or in-process based on config)
+		ref := push.Branch
+		if push.Tag != "" {
+			ref = push.Tag
+		}
+		h.triggerBuildAsync(service, release, push.GitSHA, ref, push.Trigger)
+
+		h.logger.Info(ctx, "Build triggered for service",
+			logging.String("service_id", service.ID.String()),
+			logging.String("service_name", service.Name),
+			logging.String("release_id", release.ID.String()))
+
+		// Log webhook event to Activity feed for dashboard visibility (async to not block response)
+		go h.repos.AuditLogs.Log(context.Background(), &types.AuditLog{
+			ActorID:      nil, // System action (webhook)
+			ActorEmail:   "github-webhook@system.enclii.dev",
+			ActorRole:    types.RoleSystem,
+			Action:       "webhook.build_triggered",
+			ResourceType: "service",
+			ResourceID:   service.ID.String(),
+			ResourceName: service.Name,
+			ProjectID:    &service.ProjectID,
+			Outcome:      "success",
+			Context: map[string]interface{}{
+				"event_type": "push",
+				"commit_sha": push.GitSHA,
+				"branch":     push.Branch,
+				"tag":        push.Tag,
+				"deploy_env": deployEnv,
+				"repository": push.Repository,
+				"release_id": release.ID.String(),
+				"pusher":     push.Trigger.TriggeredBy,
+				"trigger":    "github_push",
+			},
+		})
+
+		results = append(results, pushBuildResult{
+			Service:   service.Name,
+			ReleaseID: release.ID.String(),
+			Status:    "building",
+		})
+	}
+
+	return results
+}
+
+// buildSkipReason tells why a push does not rebuild a service, or returns ""
+// when it does. Services with watch paths build when a watched file changed.
+// Otherwise a service that shares its repository with others builds when a
+// file under its build root changed, so a monorepo push only rebuilds the
+// services it touched. Without a list of changed files every such service
+// builds.
+func buildSkipReason(service *types.Service, shared bool, changedFiles []string) string {
+	if len(service.WatchPaths) > 0 {
+		if !shouldRebuildService(service.WatchPaths, changedFiles) {
+			return "No files changed in watched paths"
+		}
+		return ""
+	}
+
+	root := buildRoot(service)
+	if !shared || root == "" || len(changedFiles) == 0 {
+		return ""
+	}
+	for _, file := range changedFiles {
+		if strings.HasPrefix(file, root+"/") {
+			return ""
+		}
+	}
+	return "No files changed in " + root
+}
+
+// buildRoot is the directory of the repository a service is built from: its
+// build context, or else its app path. It is "" for the repository root.
+func buildRoot(service *types.Service) string {
+	root := service.BuildConfig.Context
+	if root == "" || root == "." {
+		root = service.AppPath
+	}
+	root = strings.Trim(path.Clean("/"+root), "/")
+	return root
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestBuildSkipReason(t *testing.T) {
+	api := &types.Service{Name: "api", AppPath: "apps/api"}
+	web := &types.Service{Name: "web", BuildConfig: types.BuildConfig{Context: "./apps/web/"}}
+	root := &types.Service{Name: "worker", AppPath: "."}
+	watching := &types.Service{Name: "docs", AppPath: "apps/docs", WatchPaths: []string{"packages/shared/"}}
+
+	tests := []struct {
+		name    string
+		service *types.Service
+		shared  bool
+		changed []string
+		skip    bool
+	}{
+		{"change under app path", api, true, []string{"apps/api/main.go"}, false},
+		{"change elsewhere", api, true, []string{"apps/web/index.ts"}, true},
+		{"sibling with common prefix", api, true, []string{"apps/api-gateway/main.go"}, true},
+		{"build context over app path", web, true, []string{"apps/web/index.ts"}, false},
+		{"repository root", root, true, []string{"apps/web/index.ts"}, false},
+		{"only service of the repository", api, false, []string{"apps/web/index.ts"}, false},
+		{"no changed files listed", api, true, nil, false},
+		{"watch paths win", watching, true, []string{"apps/docs/index.md"}, true},
+		{"watched change", watching, true, []string{"packages/shared/log.go"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := buildSkipReason(tt.service, tt.shared, tt.changed)
+			if skipped := reason != ""; skipped != tt.skip {
+				t.Errorf("buildSkipReason() = %q, want skipped %v", reason, tt.skip)
+			}
+		})
+	}
+}
+
+func TestGroupCommitBuilds(t *testing.T) {
+	apiID, webID := uuid.New(), uuid.New()
+	names := map[uuid.UUID]string{apiID: "api", webID: "web"}
+	release := func(serviceID uuid.UUID, status types.ReleaseStatus) *types.Release {
+		return &types.Release{ID: uuid.New(), ServiceID: serviceID, GitSHA: "abc1234def", Status: status}
+	}
+
+	tests := []struct {
+		name     string
+		statuses [2]types.ReleaseStatus
+		want     string
+	}{
+		{"building", [2]types.ReleaseStatus{types.ReleaseStatusReady, types.ReleaseStatusBuilding}, "building"},
+		{"ready", [2]types.ReleaseStatus{types.ReleaseStatusReady, types.ReleaseStatusReady}, "ready"},
+		{"failed", [2]types.ReleaseStatus{types.ReleaseStatusFailed, types.ReleaseStatusFailed}, "failed"},
+		{"partial", [2]types.ReleaseStatus{types.ReleaseStatusFailed, types.ReleaseStatusReady}, "partial"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := groupCommitBuilds([]*types.Release{
+				release(webID, tt.statuses[0]),
+				release(apiID, tt.statuses[1]),
+			}, names)
+			if group.Status != tt.want {
+				t.Errorf("status = %q, want %q", group.Status, tt.want)
+			}
+			if group.Total != 2 || group.Builds[0].ServiceName != "api" {
+				t.Errorf("builds = %+v, want both sorted by service name", group.Builds)
+			}
+		})
+	}
+}
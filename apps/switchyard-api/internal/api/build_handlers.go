@@ -2,8 +2,10 @@ package api
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,13 +14,21 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildlimits"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/clients"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/deploypolicy"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/gpuquota"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/registry"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/soak"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
-// BuildService triggers a build for a service from a given git SHA
+// BuildService triggers a build for a service from a given git SHA, or from a
+// build context uploaded from a local working directory
 func (h *Handler) BuildService(c *gin.Context) {
 	ctx := c.Request.Context()
 	idStr := c.Param("id")
@@ -29,14 +39,23 @@ func (h *Handler) BuildService(c *gin.Context) {
 	}
 
 	var req struct {
-		GitSHA    string `json:"git_sha" binding:"required"`
+		GitSHA    string `json:"git_sha"`
 		GitBranch string `json:"git_branch"`
+		ContextID string `json:"context_id"` // Uploaded build context to build instead of a git checkout
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if (req.GitSHA == "") == (req.ContextID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of git_sha and context_id is required"})
+		return
+	}
+	if req.ContextID == "" && len(req.GitSHA) < 7 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "git_sha must have at least 7 characters"})
+		return
+	}
 
 	// Default to main branch if not specified
 	gitBranch := req.GitBranch
@@ -52,6 +71,16 @@ func (h *Handler) BuildService(c *gin.Context) {
 		return
 	}
 
+	trigger := buildTrigger{}
+	if userEmail, ok := c.Get("user_email"); ok {
+		trigger.TriggeredBy = fmt.Sprintf("%v", userEmail)
+	}
+
+	if req.ContextID != "" {
+		h.buildFromContext(c, service, req.ContextID, trigger)
+		return
+	}
+
 	// Create release record
 	release := &types.Release{
 		ID:        uuid.New(),
@@ -71,22 +100,29 @@ func (h *Handler) BuildService(c *gin.Context) {
 	}
 
 	// Trigger async build process (mode-aware)
-	h.triggerBuildAsync(service, release, req.GitSHA, gitBranch)
+	h.triggerBuildAsync(service, release, req.GitSHA, gitBranch, trigger)
 
 	c.JSON(http.StatusCreated, release)
 }
 
+// buildTrigger describes what started a build, recorded in the Roundhouse build history
+type buildTrigger struct {
+	CommitMessage string
+	TriggeredBy   string // Pusher or user email
+	SourceURL     string // Download URL of an uploaded build context; empty to build from git
+}
+
 // triggerBuildAsync routes builds to either in-process execution or Roundhouse queue
 // based on the ENCLII_BUILD_MODE configuration
 // This function returns immediately and processes builds in the background to avoid
 // blocking webhook responses (GitHub has a 10-second timeout)
-func (h *Handler) triggerBuildAsync(service *types.Service, release *types.Release, gitSHA, gitBranch string) {
+func (h *Handler) triggerBuildAsync(service *types.Service, release *types.Release, gitSHA, gitBranch string, trigger buildTrigger) {
 	if h.config.BuildMode == "roundhouse" && h.roundhouseClient != nil {
 		// Enqueue to Roundhouse for fault-tolerant, scalable builds
 		// Run in goroutine to avoid blocking webhook response
 		go func() {
 			ctx := context.Background()
-			h.enqueueToRoundhouse(ctx, service, release, gitSHA, gitBranch)
+			h.enqueueToRoundhouse(ctx, service, release, gitSHA, gitBranch, trigger)
 		}()
 	} else {
 		// Fall back to in-process builds (legacy behavior)
@@ -95,7 +131,7 @@ func (h *Handler) triggerBuildAsync(service *types.Service, release *types.Relea
 }
 
 // enqueueToRoundhouse sends a build job to the Roundhouse worker queue
-func (h *Handler) enqueueToRoundhouse(ctx context.Context, service *types.Service, release *types.Release, gitSHA, gitBranch string) {
+func (h *Handler) enqueueToRoundhouse(ctx context.Context, service *types.Service, release *types.Release, gitSHA, gitBranch string, trigger buildTrigger) {
 	// Debug: Log the service's build config to verify context is populated
 	h.logger.Info(ctx, "Enqueueing build to Roundhouse",
 		logging.String("service_id", service.ID.String()),
@@ -111,11 +147,44 @@ func (h *Handler) enqueueToRoundhouse(ctx context.Context, service *types.Servic
 		h.logger.Error(ctx, "Failed to get project for build enqueue",
 			logging.String("project_id", service.ProjectID.String()),
 			logging.Error("db_error", err))
+		if trigger.SourceURL != "" {
+			h.failBuildEnqueue(ctx, release, "build context could not be enqueued")
+			return
+		}
 		// Fall back to in-process build
 		go h.triggerBuild(service, release, gitSHA)
 		return
 	}
 
+	registrySecret, imageRepository, err := h.prepareBuildRegistry(ctx, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to prepare registry credentials for build",
+			logging.String("project_id", project.ID.String()),
+			logging.String("release_id", release.ID.String()),
+			logging.Error("error", err))
+		h.failBuildEnqueue(ctx, release, "registry credentials of the project could not be prepared")
+		return
+	}
+
+	buildSecrets, err := h.prepareBuildSecrets(ctx, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to prepare build secrets",
+			logging.String("project_id", project.ID.String()),
+			logging.String("release_id", release.ID.String()),
+			logging.Error("error", err))
+		h.failBuildEnqueue(ctx, release, "build secrets of the project could not be prepared")
+		return
+	}
+
+	// Builds are held to the team's build limits, which may have been
+	// lowered since the service set its resources
+	buildConfig := clients.BuildServiceConfigToRoundhouse(service.BuildConfig)
+	if buildConfig.Resources, err = buildlimits.Apply(ctx, h.repos, project.ID, buildConfig.Resources); err != nil {
+		h.logger.Warn(ctx, "Failed to apply build limits, building with the default resources",
+			logging.String("release_id", release.ID.String()),
+			logging.Error("db_error", err))
+	}
+
 	// Build callback URL
 	callbackURL := fmt.Sprintf("%s/v1/callbacks/build-complete", h.config.SelfURL)
 
@@ -127,12 +196,30 @@ func (h *Handler) enqueueToRoundhouse(ctx context.Context, service *types.Servic
 		GitRepo:     service.GitRepo,
 		GitSHA:      gitSHA,
 		GitBranch:   gitBranch,
-		BuildConfig: clients.BuildServiceConfigToRoundhouse(service.BuildConfig),
+		BuildConfig: buildConfig,
 		CallbackURL: callbackURL,
 		Priority:    1, // Normal priority
+
+		CommitMessage: trigger.CommitMessage,
+		TriggeredBy:   trigger.TriggeredBy,
+		SourceURL:     trigger.SourceURL,
+
+		RegistrySecret:  registrySecret,
+		ImageRepository: imageRepository,
+		BuildSecrets:    buildSecrets,
+
+		LogRedactionDisabled: project.LogRedactionDisabled,
 	}
 
 	resp, err := h.roundhouseClient.Enqueue(ctx, req)
+	if err != nil && (trigger.SourceURL != "" || imageRepository != "" || buildSecrets != "") {
+		// Neither build contexts, project registries nor build secrets can be built in-process
+		h.logger.Error(ctx, "Failed to enqueue build to Roundhouse",
+			logging.String("release_id", release.ID.String()),
+			logging.Error("roundhouse_error", err))
+		h.failBuildEnqueue(ctx, release, "build could not be enqueued")
+		return
+	}
 	if err != nil {
 		h.logger.Error(ctx, "Failed to enqueue build to Roundhouse, falling back to in-process",
 			logging.String("release_id", release.ID.String()),
@@ -179,8 +266,11 @@ func (h *Handler) triggerBuild(service *types.Service, release *types.Release, g
 		logging.String("release_id", release.ID.String()),
 		logging.String("git_sha", gitSHA))
 
+	h.publishBuildEvent(ctx, release, types.ReleaseStatusBuilding, "")
+
 	// Execute the build
 	buildResult := h.builder.BuildFromGit(ctx, service, gitSHA)
+	h.storeServiceRuntime(ctx, service.ID, buildResult.Runtime)
 
 	if !buildResult.Success {
 		h.logger.Error(ctx, "Build failed",
@@ -192,6 +282,9 @@ func (h *Handler) triggerBuild(service *types.Service, release *types.Release, g
 			h.logger.Error(ctx, "Failed to update release status", logging.Error("db_error", err))
 		}
 
+		h.publishBuildEvent(ctx, release, types.ReleaseStatusFailed, fmt.Sprintf("%v", buildResult.Error))
+		h.recordBuildMetrics(ctx, service.ID, "failure", "inline", buildResult.Duration)
+
 		// Store build logs (in production, we'd save these to a logging service or database)
 		h.logger.Error(ctx, "Build logs", logging.String("logs", fmt.Sprintf("%v", buildResult.Logs)))
 		return
@@ -241,6 +334,11 @@ func (h *Handler) triggerBuild(service *types.Service, release *types.Release, g
 		}
 	}
 
+	// Store build duration for deployment cost/performance annotations (image size is unknown in-process)
+	if err := h.repos.Releases.UpdateBuildMetrics(ctx, release.ID, nil, buildResult.Duration.Seconds()); err != nil {
+		h.logger.Warn(ctx, "Failed to store build metrics (non-fatal)", logging.Error("db_error", err))
+	}
+
 	if err := h.repos.Releases.UpdateStatus(release.ID, types.ReleaseStatusReady); err != nil {
 		h.logger.Error(ctx, "Failed to update release status", logging.Error("db_error", err))
 		if statusErr := h.repos.Releases.UpdateStatus(release.ID, types.ReleaseStatusFailed); statusErr != nil {
@@ -251,6 +349,8 @@ func (h *Handler) triggerBuild(service *types.Service, release *types.Release, g
 		return
 	}
 
+	h.publishBuildEvent(ctx, release, types.ReleaseStatusReady, "")
+
 	h.logger.Info(ctx, "Build completed successfully",
 		logging.String("release_id", release.ID.String()),
 		logging.String("image_uri", buildResult.ImageURI),
@@ -261,23 +361,21 @@ func (h *Handler) triggerBuild(service *types.Service, release *types.Release, g
 		h.logger.Debug(ctx, "Build log", logging.String("line", log))
 	}
 
-	// Record metrics
-	// TODO: Use proper metrics methods once available
-	// monitoring.RecordBuild("success", "git", buildResult.Duration)
+	h.recordBuildMetrics(ctx, service.ID, "success", "inline", buildResult.Duration)
 
-	// Auto-deploy if enabled for this service
-	if service.AutoDeploy && service.AutoDeployEnv != "" {
-		h.triggerAutoDeploy(ctx, service, release)
+	// Auto-deploy if enabled for this service or the tag it was built from
+	if target := autoDeployTarget(service, release); target != "" {
+		h.triggerAutoDeploy(ctx, service, release, target)
 	}
 }
 
-// triggerAutoDeploy creates a deployment for the successful build if auto-deploy is configured
-func (h *Handler) triggerAutoDeploy(ctx context.Context, service *types.Service, release *types.Release) {
+// triggerAutoDeploy creates a deployment of the successful build in the target environment
+func (h *Handler) triggerAutoDeploy(ctx context.Context, service *types.Service, release *types.Release, target string) {
 	h.logger.Info(ctx, "Auto-deploy triggered",
 		logging.String("service_id", service.ID.String()),
 		logging.String("service_name", service.Name),
 		logging.String("release_id", release.ID.String()),
-		logging.String("target_env", service.AutoDeployEnv))
+		logging.String("target_env", target))
 
 	// Get project to build consistent namespace name
 	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
@@ -289,33 +387,33 @@ func (h *Handler) triggerAutoDeploy(ctx context.Context, service *types.Service,
 	}
 
 	// Look up the target environment
-	env, err := h.repos.Environments.GetByProjectAndName(service.ProjectID, service.AutoDeployEnv)
+	env, err := h.repos.Environments.GetByProjectAndName(service.ProjectID, target)
 	if err != nil {
 		// Environment doesn't exist - auto-create it
 		h.logger.Info(ctx, "Auto-creating missing environment for auto-deploy",
-			logging.String("environment", service.AutoDeployEnv),
+			logging.String("environment", target),
 			logging.String("project_id", service.ProjectID.String()))
 
 		// Generate kubernetes namespace with consistent pattern: enclii-{project_slug}-{env_name}
 		// This matches the pattern used in logs_handlers.go and environment_handlers.go
-		envNameNormalized := strings.ToLower(strings.ReplaceAll(service.AutoDeployEnv, "_", "-"))
+		envNameNormalized := strings.ToLower(strings.ReplaceAll(target, "_", "-"))
 		kubeNamespace := fmt.Sprintf("enclii-%s-%s", project.Slug, envNameNormalized)
 
 		env = &types.Environment{
 			ProjectID:     service.ProjectID,
-			Name:          service.AutoDeployEnv,
+			Name:          target,
 			KubeNamespace: kubeNamespace,
 		}
 		if err := h.repos.Environments.Create(env); err != nil {
 			h.logger.Error(ctx, "Auto-deploy failed: could not create environment",
-				logging.String("environment", service.AutoDeployEnv),
+				logging.String("environment", target),
 				logging.Error("db_error", err))
 			return
 		}
 
 		h.logger.Info(ctx, "Successfully created environment for auto-deploy",
 			logging.String("environment_id", env.ID.String()),
-			logging.String("environment", service.AutoDeployEnv),
+			logging.String("environment", target),
 			logging.String("kube_namespace", kubeNamespace))
 	}
 
@@ -329,6 +427,105 @@ func (h *Handler) triggerAutoDeploy(ctx context.Context, service *types.Service,
 		return
 	}
 
+	// Environments that require stable releases never take a fresh build
+	if err := soak.CheckPromotion(ctx, h.repos, release.ID, env.ID); err != nil && !isTableNotExistError(err) {
+		h.logger.Info(ctx, "Auto-deploy skipped: environment only accepts stable releases",
+			logging.String("release_id", release.ID.String()),
+			logging.String("environment", target),
+			logging.Error("reason", err))
+		return
+	}
+
+	// Auto-deploys never override the deploy policy of the environment
+	if decision, err := deploypolicy.Check(ctx, h.repos, env.ID, time.Now()); err != nil && !isTableNotExistError(err) {
+		h.logger.Error(ctx, "Auto-deploy failed: could not check deploy policy",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("db_error", err))
+		return
+	} else if err == nil && !decision.Allowed {
+		h.logger.Info(ctx, "Auto-deploy skipped: deploy policy",
+			logging.String("release_id", release.ID.String()),
+			logging.String("environment", target),
+			logging.String("reason", decision.Reason))
+		h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+			ActorEmail:    "auto-deploy@system.enclii.dev",
+			ActorRole:     types.RoleSystem,
+			Action:        "deployment.blocked_by_policy",
+			ResourceType:  "release",
+			ResourceID:    release.ID.String(),
+			ResourceName:  service.Name,
+			ProjectID:     &service.ProjectID,
+			EnvironmentID: &env.ID,
+			Outcome:       "denied",
+			Context: map[string]interface{}{
+				"environment":     env.Name,
+				"reason":          decision.Reason,
+				"next_allowed_at": decision.NextAllowedAt,
+			},
+		})
+		return
+	}
+
+	// Auto-deploys of GPU services are held to the team's GPU quota
+	if err := gpuquota.Check(ctx, h.repos, service, env.ID, 1); err != nil {
+		if !stderrors.Is(err, gpuquota.ErrQuotaExceeded) {
+			h.logger.Error(ctx, "Auto-deploy failed: could not check GPU quota",
+				logging.String("environment_id", env.ID.String()),
+				logging.Error("db_error", err))
+			return
+		}
+		h.logger.Info(ctx, "Auto-deploy skipped: GPU quota",
+			logging.String("release_id", release.ID.String()),
+			logging.String("environment", target),
+			logging.Error("reason", err))
+		h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+			ActorEmail:    "auto-deploy@system.enclii.dev",
+			ActorRole:     types.RoleSystem,
+			Action:        "deployment.blocked_by_gpu_quota",
+			ResourceType:  "release",
+			ResourceID:    release.ID.String(),
+			ResourceName:  service.Name,
+			ProjectID:     &service.ProjectID,
+			EnvironmentID: &env.ID,
+			Outcome:       "denied",
+			Context: map[string]interface{}{
+				"environment": env.Name,
+				"reason":      err.Error(),
+			},
+		})
+		return
+	}
+
+	// And to the CPU and memory quotas of the project and team
+	if err := quota.CheckDeploy(ctx, h.repos, service, env.ID, 1); err != nil {
+		if !stderrors.Is(err, quota.ErrQuotaExceeded) {
+			h.logger.Error(ctx, "Auto-deploy failed: could not check resource quota",
+				logging.String("environment_id", env.ID.String()),
+				logging.Error("db_error", err))
+			return
+		}
+		h.logger.Info(ctx, "Auto-deploy skipped: resource quota",
+			logging.String("release_id", release.ID.String()),
+			logging.String("environment", target),
+			logging.Error("reason", err))
+		h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+			ActorEmail:    "auto-deploy@system.enclii.dev",
+			ActorRole:     types.RoleSystem,
+			Action:        "deployment.blocked_by_quota",
+			ResourceType:  "release",
+			ResourceID:    release.ID.String(),
+			ResourceName:  service.Name,
+			ProjectID:     &service.ProjectID,
+			EnvironmentID: &env.ID,
+			Outcome:       "denied",
+			Context: map[string]interface{}{
+				"environment": env.Name,
+				"reason":      err.Error(),
+			},
+		})
+		return
+	}
+
 	// Check if a deployment already exists for this release + environment
 	existingDeployments, err := h.repos.Deployments.ListByRelease(ctx, release.ID.String())
 	if err != nil {
@@ -379,7 +576,7 @@ func (h *Handler) triggerAutoDeploy(ctx context.Context, service *types.Service,
 
 	// Schedule deployment with reconciler (high priority)
 	if err := h.reconciler.ScheduleReconciliation(deployment.ID.String(), 1); err != nil {
-		h.logger.Warn(ctx, "Reconciler queue full, work queued for retry",
+		h.logger.Warn(ctx, "Failed to queue reconciliation, pending deployment scan will retry",
 			logging.String("deployment_id", deployment.ID.String()),
 			logging.Error("queue_error", err))
 	}
@@ -387,7 +584,7 @@ func (h *Handler) triggerAutoDeploy(ctx context.Context, service *types.Service,
 	h.logger.Info(ctx, "Auto-deploy scheduled successfully",
 		logging.String("deployment_id", deployment.ID.String()),
 		logging.String("service_name", service.Name),
-		logging.String("environment", service.AutoDeployEnv))
+		logging.String("environment", target))
 }
 
 // ensureRegistryCredentials ensures the target namespace has the registry credentials secret
@@ -451,7 +648,153 @@ func (h *Handler) ensureRegistryCredentials(ctx context.Context, targetNamespace
 	return nil
 }
 
-// ListReleases returns all releases for a given service
+const (
+	// roundhouseBuildNamespace is the namespace Roundhouse runs build jobs in
+	roundhouseBuildNamespace = "enclii-builds"
+	// roundhouseRegistrySecret holds the platform's build credentials there
+	roundhouseRegistrySecret = "regcred"
+)
+
+// prepareBuildRegistry writes the registry credentials of a project, merged
+// with the platform's, to a Docker config secret in the Roundhouse build
+// namespace so builds can pull private base images. It returns the secret
+// name and the repository builds push to, both empty when the project has
+// no credentials.
+func (h *Handler) prepareBuildRegistry(ctx context.Context, projectID uuid.UUID) (string, string, error) {
+	auths, err := h.repos.RegistryCredentials.ListDecrypted(ctx, projectID, nil)
+	if err != nil {
+		if isTableNotExistError(err) {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("failed to get registry credentials: %w", err)
+	}
+	if len(auths) == 0 {
+		return "", "", nil
+	}
+	if auths, err = registry.Resolve(ctx, auths); err != nil {
+		return "", "", err
+	}
+
+	// The push registry uses the project's credential even where the
+	// platform has one for the same host
+	var imageRepository string
+	var override []string
+	for _, auth := range auths {
+		if auth.PushRepository != "" {
+			imageRepository = auth.PushRepository
+			override = append(override, auth.Registry)
+		}
+	}
+
+	secretClient := h.k8sClient.Clientset.CoreV1().Secrets(roundhouseBuildNamespace)
+	var base []byte
+	platform, err := secretClient.Get(ctx, roundhouseRegistrySecret, metav1.GetOptions{})
+	if err == nil {
+		base = platform.Data[corev1.DockerConfigJsonKey]
+	} else if !errors.IsNotFound(err) {
+		return "", "", fmt.Errorf("failed to get platform registry credentials: %w", err)
+	}
+	config, err := registry.MergeDockerConfig(base, auths, override...)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "regcred-" + projectID.String(),
+			Namespace: roundhouseBuildNamespace,
+			Labels: map[string]string{
+				"enclii.dev/project":    projectID.String(),
+				"enclii.dev/managed-by": "switchyard",
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: config},
+	}
+	if err := writeBuildNamespaceSecret(ctx, secretClient, secret); err != nil {
+		return "", "", fmt.Errorf("failed to write build registry secret: %w", err)
+	}
+
+	return secret.Name, imageRepository, nil
+}
+
+// prepareBuildSecrets writes the build secrets of a project to a secret in
+// the Roundhouse build namespace, which builds mount under /run/secrets:
+// a file per build secret, and build.env exporting all of them for shell
+// steps. It returns the secret name, empty when the project has no build
+// secrets.
+func (h *Handler) prepareBuildSecrets(ctx context.Context, projectID uuid.UUID) (string, error) {
+	values, err := h.repos.BuildSecrets.ListDecrypted(ctx, projectID)
+	if err != nil {
+		if isTableNotExistError(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get build secrets: %w", err)
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	if h.k8sClient == nil {
+		return "", fmt.Errorf("build secrets need a Kubernetes client")
+	}
+
+	names := make([]string, 0, len(values))
+	data := make(map[string][]byte, len(values)+1)
+	for name, value := range values {
+		names = append(names, name)
+		data[name] = []byte(value)
+	}
+	sort.Strings(names)
+	var env strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&env, "export %s=%s\n", name, shellQuote(values[name]))
+	}
+	data[buildSecretsEnvFile] = []byte(env.String())
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "build-secrets-" + projectID.String(),
+			Namespace: roundhouseBuildNamespace,
+			Labels: map[string]string{
+				"enclii.dev/project":    projectID.String(),
+				"enclii.dev/managed-by": "switchyard",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	secretClient := h.k8sClient.Clientset.CoreV1().Secrets(roundhouseBuildNamespace)
+	if err := writeBuildNamespaceSecret(ctx, secretClient, secret); err != nil {
+		return "", fmt.Errorf("failed to write build secrets: %w", err)
+	}
+	return secret.Name, nil
+}
+
+// buildSecretsEnvFile is the file of the build secrets secret that exports
+// every build secret as an environment variable
+const buildSecretsEnvFile = "build.env"
+
+// shellQuote quotes a value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// writeBuildNamespaceSecret creates a secret in the build namespace, or
+// replaces the one with its name
+func writeBuildNamespaceSecret(ctx context.Context, secretClient typedcorev1.SecretInterface, secret *corev1.Secret) error {
+	existing, err := secretClient.Get(ctx, secret.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		_, err = secretClient.Create(ctx, secret, metav1.CreateOptions{})
+	case err == nil:
+		secret.ResourceVersion = existing.ResourceVersion
+		_, err = secretClient.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// ListReleases returns the releases of a service. Pruned releases are only
+// included with include_pruned=true.
 func (h *Handler) ListReleases(c *gin.Context) {
 	ctx := c.Request.Context()
 	idStr := c.Param("id")
@@ -461,7 +804,12 @@ func (h *Handler) ListReleases(c *gin.Context) {
 		return
 	}
 
-	releases, err := h.repos.Releases.ListByService(serviceID)
+	var releases []*types.Release
+	if c.Query("include_pruned") == "true" {
+		releases, err = h.repos.Releases.ListHistoryByService(serviceID)
+	} else {
+		releases, err = h.repos.Releases.ListByService(serviceID)
+	}
 	if err != nil {
 		h.logger.Error(ctx, "Failed to list releases", logging.Error("db_error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list releases"})
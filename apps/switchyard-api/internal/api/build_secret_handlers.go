@@ -0,0 +1,153 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// buildSecretNamePattern matches environment variable style names, which
+// are also valid file names and Secret keys
+var buildSecretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+
+// maxBuildSecretSize bounds a build secret's value, well within what a
+// Kubernetes Secret holds for all of a project's secrets
+const maxBuildSecretSize = 64 * 1024
+
+// SetBuildSecretRequest stores the value of a build secret
+type SetBuildSecretRequest struct {
+	Value string `json:"value" binding:"required"` // Never returned
+}
+
+// ListBuildSecrets lists the build secrets of a project, without their
+// values
+// GET /v1/projects/:slug/build-secrets
+func (h *Handler) ListBuildSecrets(c *gin.Context) {
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	secrets, err := h.repos.BuildSecrets.ListByProject(ctx, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list build secrets",
+			logging.String("project_slug", project.Slug),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list build secrets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"build_secrets": secrets})
+}
+
+// SetBuildSecret creates or replaces a build secret of a project. Builds
+// enqueued from then on get the new value.
+// PUT /v1/projects/:slug/build-secrets/:name
+func (h *Handler) SetBuildSecret(c *gin.Context) {
+	name := c.Param("name")
+	if !buildSecretNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid build secret name: use letters, digits and underscores, not starting with a digit"})
+		return
+	}
+	var req SetBuildSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Value) > maxBuildSecretSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Build secret value must be at most %d bytes", maxBuildSecretSize)})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	secret := &types.BuildSecret{ProjectID: project.ID, Name: name}
+	if userEmail, ok := c.Get("user_email"); ok {
+		secret.CreatedBy = fmt.Sprintf("%v", userEmail)
+	}
+	if err := h.repos.BuildSecrets.Upsert(ctx, secret, req.Value); err != nil {
+		h.logger.Error(ctx, "Failed to save build secret",
+			logging.String("project_slug", project.Slug),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save build secret"})
+		return
+	}
+
+	h.auditBuildSecret(c, project, name, "project.build_secret_set")
+	c.JSON(http.StatusOK, secret)
+}
+
+// DeleteBuildSecret removes a build secret of a project
+// DELETE /v1/projects/:slug/build-secrets/:name
+func (h *Handler) DeleteBuildSecret(c *gin.Context) {
+	name := c.Param("name")
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.BuildSecrets.Delete(ctx, project.ID, name); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Build secret not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete build secret",
+			logging.String("project_slug", project.Slug),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete build secret"})
+		return
+	}
+
+	h.auditBuildSecret(c, project, name, "project.build_secret_deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Build secret deleted"})
+}
+
+// auditBuildSecret records a change to the build secrets of a project
+func (h *Handler) auditBuildSecret(c *gin.Context, project *types.Project, name, action string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   fmt.Sprintf("%v", userEmail),
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       action,
+		ResourceType: "project",
+		ResourceID:   project.ID.String(),
+		ResourceName: project.Slug,
+		ProjectID:    &project.ID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"name": name,
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestBuildSecretNamePattern(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"NPM_TOKEN":    true,
+		"_PRIVATE":     true,
+		"pip_index":    true,
+		"1PASSWORD":    false,
+		"NPM-TOKEN":    false,
+		"build.env":    false,
+		"":             false,
+		"../etc/token": false,
+	} {
+		if got := buildSecretNamePattern.MatchString(name); got != valid {
+			t.Errorf("buildSecretNamePattern.MatchString(%q) = %v, want %v", name, got, valid)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell available")
+	}
+	for _, value := range []string{"plain", "it's", `$(id) "quoted" \n`, "multi\nline"} {
+		out, err := exec.Command(sh, "-c", "export V="+shellQuote(value)+"; printf %s \"$V\"").Output()
+		if err != nil {
+			t.Fatalf("sh error = %v", err)
+		}
+		if string(out) != value {
+			t.Errorf("shellQuote(%q) evaluates to %q", value, out)
+		}
+	}
+}
@@ -207,6 +207,11 @@ func (h *Handler) GetUnifiedBuildStatus(c *gin.Context) {
 						if deployment.ErrorMessage != nil {
 							depStatus.ErrorMessage = *deployment.ErrorMessage
 						}
+					case types.DeploymentStatusSuperseded:
+						depStatus.Status = "superseded"
+						if deployment.ErrorMessage != nil {
+							depStatus.ErrorMessage = *deployment.ErrorMessage
+						}
 					}
 
 					status.Deployment = depStatus
@@ -277,10 +282,10 @@ func calculateOverallStatus(status *UnifiedBuildStatus) string {
 }
 
 // GetBuildStatusByCommit returns the unified build status for a commit across all services
-// GET /v1/builds/:commit_sha/status
+// GET /v1/builds/:id/status, where :id is a commit SHA
 func (h *Handler) GetBuildStatusByCommit(c *gin.Context) {
 	ctx := c.Request.Context()
-	commitSHA := c.Param("commit_sha")
+	commitSHA := c.Param("id")
 
 	h.logger.Info(ctx, "Getting build status by commit",
 		logging.String("commit_sha", commitSHA))
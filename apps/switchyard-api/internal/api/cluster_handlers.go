@@ -0,0 +1,351 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// clusterHealthTimeout bounds the health check of one cluster
+const clusterHealthTimeout = 15 * time.Second
+
+var clusterNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// RegisterClusterRequest registers a Kubernetes cluster environments can be
+// deployed to
+type RegisterClusterRequest struct {
+	Name        string `json:"name" binding:"required"`       // e.g. production-eu
+	Description string `json:"description,omitempty"`         // Shown in cluster lists
+	Kubeconfig  string `json:"kubeconfig" binding:"required"` // Kubeconfig YAML; never returned
+	Context     string `json:"context,omitempty"`             // Defaults to the kubeconfig's current context
+}
+
+// clusterClient returns the client of the cluster an environment is
+// deployed to
+func (h *Handler) clusterClient(ctx context.Context, env *types.Environment) (*k8s.Client, error) {
+	if env.ClusterID == nil || h.clusterClients == nil {
+		return h.k8sClient, nil
+	}
+	return h.clusterClients.Get(ctx, env.ClusterID)
+}
+
+// environmentClient returns the client of the cluster of an environment
+// known by ID
+func (h *Handler) environmentClient(ctx context.Context, environmentID uuid.UUID) (*k8s.Client, error) {
+	env, err := h.repos.Environments.GetByID(ctx, environmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment: %w", err)
+	}
+	return h.clusterClient(ctx, env)
+}
+
+// ListClusters lists the registered clusters, without their kubeconfigs.
+// Environments without a cluster run in the one the API runs in.
+// GET /v1/clusters
+func (h *Handler) ListClusters(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	clusters, err := h.repos.Clusters.List(ctx)
+	if err != nil {
+		if isTableNotExistError(err) {
+			c.JSON(http.StatusOK, gin.H{"clusters": []*types.Cluster{}})
+			return
+		}
+		h.logger.Error(ctx, "Failed to list clusters", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list clusters"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clusters": clusters})
+}
+
+// RegisterCluster registers a cluster after checking that its API server
+// can be reached with the kubeconfig
+// POST /v1/clusters
+func (h *Handler) RegisterCluster(c *gin.Context) {
+	var req RegisterClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if !clusterNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must be a DNS label: lowercase letters, digits and '-', at most 63 characters"})
+		return
+	}
+	if name == types.LocalClusterName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%q names the cluster the API runs in", types.LocalClusterName)})
+		return
+	}
+	if h.clusterClients == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Multi-cluster support is not available"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	client, err := k8s.NewClientFromKubeconfig([]byte(req.Kubeconfig), req.Context)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, clusterHealthTimeout)
+	defer cancel()
+	if health := client.Health(checkCtx, name); !health.Reachable {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Cluster is not reachable with the kubeconfig", "details": health.Error})
+		return
+	}
+
+	cluster := &types.Cluster{
+		Name:        name,
+		Description: req.Description,
+		APIServer:   client.APIServer(),
+		KubeContext: req.Context,
+	}
+	if userEmail, ok := c.Get("user_email"); ok {
+		cluster.CreatedBy = fmt.Sprintf("%v", userEmail)
+	}
+	if err := h.repos.Clusters.Create(ctx, cluster, req.Kubeconfig); err != nil {
+		if strings.Contains(err.Error(), "clusters_name_key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "A cluster with this name is already registered"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to register cluster", logging.String("cluster", name), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register cluster"})
+		return
+	}
+
+	h.auditCluster(c, cluster, "cluster.registered")
+	c.JSON(http.StatusCreated, cluster)
+}
+
+// DeleteCluster removes a cluster no environment is deployed to
+// DELETE /v1/clusters/:name
+func (h *Handler) DeleteCluster(c *gin.Context) {
+	ctx := c.Request.Context()
+	cluster := h.loadCluster(c)
+	if cluster == nil {
+		return
+	}
+
+	if err := h.repos.Clusters.Delete(ctx, cluster.ID); err != nil {
+		if strings.Contains(err.Error(), "environments_cluster_id_fkey") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Environments are deployed to the cluster; move or delete them first"})
+			return
+		}
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cluster not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete cluster", logging.String("cluster", cluster.Name), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete cluster"})
+		return
+	}
+	if h.clusterClients != nil {
+		h.clusterClients.Forget(cluster.ID)
+	}
+
+	h.auditCluster(c, cluster, "cluster.deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Cluster deleted"})
+}
+
+// GetClusterHealth reports the reachability and capacity of a cluster, or
+// of the one the API runs in as "local"
+// GET /v1/clusters/:name/health
+func (h *Handler) GetClusterHealth(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if c.Param("name") == types.LocalClusterName {
+		c.JSON(http.StatusOK, h.clusterHealth(ctx, nil, types.LocalClusterName))
+		return
+	}
+	cluster := h.loadCluster(c)
+	if cluster == nil {
+		return
+	}
+	c.JSON(http.StatusOK, h.clusterHealth(ctx, &cluster.ID, cluster.Name))
+}
+
+// ListClusterHealth reports the reachability and capacity of every cluster,
+// starting with the one the API runs in
+// GET /v1/clusters/health
+func (h *Handler) ListClusterHealth(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	clusters, err := h.repos.Clusters.List(ctx)
+	if err != nil && !isTableNotExistError(err) {
+		h.logger.Error(ctx, "Failed to list clusters", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list clusters"})
+		return
+	}
+
+	health := make([]*types.ClusterHealth, len(clusters)+1)
+	var wg sync.WaitGroup
+	wg.Add(len(health))
+	go func() {
+		defer wg.Done()
+		health[0] = h.clusterHealth(ctx, nil, types.LocalClusterName)
+	}()
+	for i, cluster := range clusters {
+		go func(i int, cluster *types.Cluster) {
+			defer wg.Done()
+			health[i+1] = h.clusterHealth(ctx, &cluster.ID, cluster.Name)
+		}(i, cluster)
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, gin.H{"clusters": health})
+}
+
+// ListNodePools lists the node pools of a cluster, or of the one the API
+// runs in as "local", for services to be placed on
+// GET /v1/clusters/:name/node-pools
+func (h *Handler) ListNodePools(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	name := c.Param("name")
+	client := h.k8sClient
+	if name != types.LocalClusterName {
+		cluster := h.loadCluster(c)
+		if cluster == nil {
+			return
+		}
+		var err error
+		if client, err = h.clusterClients.Get(ctx, &cluster.ID); err != nil {
+			h.logger.Error(ctx, "Failed to connect to cluster", logging.String("cluster", name), logging.Error("error", err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect to the cluster"})
+			return
+		}
+	}
+	if !client.IsValid() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Kubernetes client not available"})
+		return
+	}
+
+	pools, err := client.NodePools(ctx)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list node pools", logging.String("cluster", name), logging.Error("error", err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to list the cluster's nodes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cluster": name, "node_pools": pools})
+}
+
+// checkPlacement checks a service's placement against the node pools of
+// every cluster its project deploys to. Clusters that cannot be reached
+// are not checked.
+func (h *Handler) checkPlacement(ctx context.Context, projectID uuid.UUID, scheduling *types.SchedulingConfig) error {
+	environments, err := h.repos.Environments.ListByProject(projectID)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+	if len(environments) == 0 {
+		environments = []*types.Environment{{}}
+	}
+
+	checked := make(map[string]bool)
+	for _, env := range environments {
+		name := types.LocalClusterName
+		if env.ClusterID != nil {
+			name = env.Cluster
+		}
+		if checked[name] {
+			continue
+		}
+		checked[name] = true
+
+		client, err := h.clusterClient(ctx, env)
+		if err != nil || !client.IsValid() {
+			continue
+		}
+		pools, err := client.NodePools(ctx)
+		if err != nil {
+			h.logger.Warn(ctx, "Failed to list node pools to check placement",
+				logging.String("cluster", name), logging.Error("error", err))
+			continue
+		}
+		if err := k8s.CheckPlacement(pools, scheduling); err != nil {
+			return fmt.Errorf("cluster %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// clusterHealth checks a cluster, nil for the API's own
+func (h *Handler) clusterHealth(ctx context.Context, clusterID *uuid.UUID, name string) *types.ClusterHealth {
+	ctx, cancel := context.WithTimeout(ctx, clusterHealthTimeout)
+	defer cancel()
+
+	client := h.k8sClient
+	if clusterID != nil {
+		var err error
+		if client, err = h.clusterClients.Get(ctx, clusterID); err != nil {
+			return &types.ClusterHealth{Cluster: name, Error: err.Error(), CheckedAt: time.Now()}
+		}
+	}
+	health := client.Health(ctx, name)
+
+	if count, err := h.repos.Clusters.CountEnvironments(ctx, clusterID); err == nil {
+		health.Environments = count
+	}
+	return health
+}
+
+// loadCluster loads the registered cluster named in the path. It writes the
+// error response and returns nil when it cannot be loaded.
+func (h *Handler) loadCluster(c *gin.Context) *types.Cluster {
+	cluster, err := h.repos.Clusters.GetByName(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cluster not found"})
+			return nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get cluster", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cluster"})
+		return nil
+	}
+	if h.clusterClients == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Multi-cluster support is not available"})
+		return nil
+	}
+	return cluster
+}
+
+// auditCluster records a change to the registered clusters
+func (h *Handler) auditCluster(c *gin.Context, cluster *types.Cluster, action string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   fmt.Sprintf("%v", userEmail),
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       action,
+		ResourceType: "cluster",
+		ResourceID:   cluster.ID.String(),
+		ResourceName: cluster.Name,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"api_server":   cluster.APIServer,
+			"kube_context": cluster.KubeContext,
+		},
+	})
+}
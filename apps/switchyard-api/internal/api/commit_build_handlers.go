@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// commitSHAPattern matches full and abbreviated commit SHAs
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// CommitBuild is the build of one service from a commit
+type CommitBuild struct {
+	ServiceID    uuid.UUID           `json:"service_id"`
+	ServiceName  string              `json:"service_name"`
+	ReleaseID    uuid.UUID           `json:"release_id"`
+	Version      string              `json:"version"`
+	Status       types.ReleaseStatus `json:"status"`
+	ErrorMessage *string             `json:"error_message,omitempty"`
+	DurationSecs *float64            `json:"duration_secs,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// CommitBuildGroup is every build a commit fanned out into in a project
+type CommitBuildGroup struct {
+	CommitSHA string `json:"commit_sha"`
+	// Status is building while any build runs, then ready when all
+	// succeeded, failed when all failed, and partial otherwise
+	Status   string        `json:"status"`
+	Total    int           `json:"total"`
+	Building int           `json:"building"`
+	Ready    int           `json:"ready"`
+	Failed   int           `json:"failed"`
+	Builds   []CommitBuild `json:"builds"`
+}
+
+// GetCommitBuilds returns the builds of the services of a project from one
+// commit, e.g. the fan-out of a monorepo push, with their combined status
+// GET /v1/projects/:slug/commits/:sha/builds
+func (h *Handler) GetCommitBuilds(c *gin.Context) {
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	sha := c.Param("sha")
+	if !commitSHAPattern.MatchString(sha) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid commit SHA: use at least 7 lowercase hex characters"})
+		return
+	}
+
+	releases, err := h.repos.Releases.ListLatestByProjectCommit(ctx, project.ID, sha)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list commit builds",
+			logging.String("project_slug", project.Slug),
+			logging.String("commit_sha", sha),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list builds"})
+		return
+	}
+	if len(releases) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No builds of this commit in the project"})
+		return
+	}
+
+	services, err := h.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list services", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list builds"})
+		return
+	}
+	names := make(map[uuid.UUID]string, len(services))
+	for _, service := range services {
+		names[service.ID] = service.Name
+	}
+
+	c.JSON(http.StatusOK, groupCommitBuilds(releases, names))
+}
+
+// groupCommitBuilds sums up the releases of a commit, by service name
+func groupCommitBuilds(releases []*types.Release, serviceNames map[uuid.UUID]string) *CommitBuildGroup {
+	group := &CommitBuildGroup{
+		CommitSHA: releases[0].GitSHA,
+		Total:     len(releases),
+		Builds:    make([]CommitBuild, 0, len(releases)),
+	}
+	for _, release := range releases {
+		switch release.Status {
+		case types.ReleaseStatusBuilding:
+			group.Building++
+		case types.ReleaseStatusReady:
+			group.Ready++
+		case types.ReleaseStatusFailed:
+			group.Failed++
+		}
+		group.Builds = append(group.Builds, CommitBuild{
+			ServiceID:    release.ServiceID,
+			ServiceName:  serviceNames[release.ServiceID],
+			ReleaseID:    release.ID,
+			Version:      release.Version,
+			Status:       release.Status,
+			ErrorMessage: release.ErrorMessage,
+			DurationSecs: release.BuildDurationSecs,
+			CreatedAt:    release.CreatedAt,
+			UpdatedAt:    release.UpdatedAt,
+		})
+	}
+	sort.Slice(group.Builds, func(i, j int) bool {
+		return group.Builds[i].ServiceName < group.Builds[j].ServiceName
+	})
+
+	switch {
+	case group.Building > 0:
+		group.Status = "building"
+	case group.Failed == 0:
+		group.Status = "ready"
+	case group.Ready == 0:
+		group.Status = "failed"
+	default:
+		group.Status = "partial"
+	}
+	return group
+}
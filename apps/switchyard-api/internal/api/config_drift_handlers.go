@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/reconciler"
+)
+
+// GetConfigDrift compares the environment variables a service should have in
+// an environment with those its running pods were started with, so pods that
+// missed a config rollout stand out
+// GET /v1/services/:id/config-drift?environment_id=
+func (h *Handler) GetConfigDrift(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	svcID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(svcID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	envID, err := uuid.Parse(c.Query("environment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "environment_id query parameter is required"})
+		return
+	}
+
+	env, err := h.repos.Environments.GetByID(ctx, envID)
+	if err != nil || env.ProjectID != service.ProjectID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+
+	if !h.authorizeEnvironment(c, service.ProjectID, &envID) {
+		return
+	}
+
+	if h.reconciler == nil || h.k8sClient == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Kubernetes client not available"})
+		return
+	}
+
+	envVars, err := h.repos.EnvVars.List(ctx, svcID, &envID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list env vars", logging.String("service_id", svcID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list environment variables"})
+		return
+	}
+
+	// Environment-specific variables override those shared by all environments
+	desired := make(map[string]reconciler.DesiredEnvVar, len(envVars))
+	for _, ev := range envVars {
+		if _, overridden := desired[ev.Key]; overridden && ev.EnvironmentID == nil {
+			continue
+		}
+		desired[ev.Key] = reconciler.DesiredEnvVar{Value: ev.Value, IsSecret: ev.IsSecret, UpdatedAt: ev.UpdatedAt}
+	}
+
+	ignore, err := h.injectedEnvVarKeys(ctx, svcID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get addon bindings", logging.String("service_id", svcID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get addon bindings"})
+		return
+	}
+
+	pods, err := h.k8sClient.ListPods(ctx, env.KubeNamespace, fmt.Sprintf("enclii.dev/service=%s", service.Name))
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list pods",
+			logging.String("namespace", env.KubeNamespace),
+			logging.String("service", service.Name),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list pods"})
+		return
+	}
+
+	inSync := true
+	drifts := make([]reconciler.PodConfigDrift, 0, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// Terminating and finished pods are on their way out
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		values, err := h.reconciler.GetPodEnvVars(ctx, env.KubeNamespace, pod.Name)
+		if err != nil {
+			// The pod may have gone away since it was listed
+			h.logger.Warn(ctx, "Failed to get pod env vars",
+				logging.String("namespace", env.KubeNamespace),
+				logging.String("pod", pod.Name),
+				logging.Error("error", err))
+			continue
+		}
+
+		drift := reconciler.DiffPodConfig(desired, pod, values, ignore)
+		if !drift.InSync() {
+			inSync = false
+		}
+		drifts = append(drifts, drift)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"service_id":     svcID,
+		"environment_id": envID,
+		"namespace":      env.KubeNamespace,
+		"in_sync":        inSync,
+		"checked_at":     time.Now(),
+		"pods":           drifts,
+	})
+}
+
+// injectedEnvVarKeys returns the keys set on a service's pods by the
+// reconciler and its addon bindings rather than by its environment variables
+func (h *Handler) injectedEnvVarKeys(ctx context.Context, serviceID uuid.UUID) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	for _, key := range reconciler.ReconcilerEnvVarKeys {
+		keys[key] = true
+	}
+
+	if h.repos.DatabaseAddons == nil {
+		return keys, nil
+	}
+	bindings, err := h.repos.DatabaseAddons.GetBindingsByService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range bindings {
+		keys[binding.EnvVarName] = true
+		// Bucket bindings use the name as a prefix
+		for _, v := range addons.BucketEnvVars(binding.EnvVarName) {
+			keys[v.Name] = true
+		}
+	}
+
+	return keys, nil
+}
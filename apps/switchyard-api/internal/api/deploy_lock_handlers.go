@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// UpdateDeployConcurrencyRequest sets what happens to a deployment started
+// while another deployment of the same service is in flight
+type UpdateDeployConcurrencyRequest struct {
+	Mode types.DeployConcurrency `json:"mode" binding:"required"`
+}
+
+// DeployLocksResponse is an environment's deploy concurrency and the
+// services currently being deployed in it
+type DeployLocksResponse struct {
+	Mode  types.DeployConcurrency `json:"mode"`
+	Locks []*types.DeployLock     `json:"locks"`
+}
+
+// GetDeployLocks returns the deploy concurrency of an environment and the
+// deploy locks held in it
+// GET /v1/projects/:slug/environments/:env_name/deploy-locks
+func (h *Handler) GetDeployLocks(c *gin.Context) {
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	locks, err := h.repos.DeployLocks.ListByEnvironment(ctx, env.ID)
+	if err != nil {
+		if !isTableNotExistError(err) {
+			h.logger.Error(ctx, "Failed to list deploy locks",
+				logging.String("environment_id", env.ID.String()),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list deploy locks"})
+			return
+		}
+		locks = []*types.DeployLock{}
+	}
+
+	mode := env.DeployConcurrency
+	if mode == "" {
+		mode = types.DeployConcurrencySupersede
+	}
+	c.JSON(http.StatusOK, DeployLocksResponse{Mode: mode, Locks: locks})
+}
+
+// UpdateDeployConcurrency sets whether a new deployment of a service
+// supersedes the pending ones or queues behind them
+// PUT /v1/projects/:slug/environments/:env_name/deploy-concurrency
+func (h *Handler) UpdateDeployConcurrency(c *gin.Context) {
+	var req UpdateDeployConcurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Mode != types.DeployConcurrencySupersede && req.Mode != types.DeployConcurrencyQueue {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be supersede or queue"})
+		return
+	}
+
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.Environments.SetDeployConcurrency(ctx, env, req.Mode); err != nil {
+		h.logger.Error(ctx, "Failed to set deploy concurrency",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set deploy concurrency"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.deploy_concurrency_updated", map[string]interface{}{
+		"mode": req.Mode,
+	})
+
+	env.DeployConcurrency = req.Mode
+	c.JSON(http.StatusOK, env)
+}
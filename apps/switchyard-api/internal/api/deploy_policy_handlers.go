@@ -0,0 +1,234 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/deploypolicy"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// UpdateDeployPolicyRequest sets when releases are deployed to an environment
+type UpdateDeployPolicyRequest struct {
+	Timezone      string               `json:"timezone"`       // Defaults to UTC
+	Windows       []types.DeployWindow `json:"windows"`        // Empty = any time outside freeze windows
+	AllowOverride *bool                `json:"allow_override"` // Defaults to true
+}
+
+// GetDeployPolicy returns the deploy policy of an environment and whether
+// it allows deploys now
+// GET /v1/projects/:slug/environments/:env_name/deploy-policy
+func (h *Handler) GetDeployPolicy(c *gin.Context) {
+	_, env := h.loadEnvironment(c)
+	if env == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	policy, err := h.repos.DeployPolicies.Get(ctx, env.ID)
+	if err != nil {
+		if err == sql.ErrNoRows || isTableNotExistError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment has no deploy policy"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get deploy policy",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get deploy policy"})
+		return
+	}
+
+	now := time.Now()
+	freezes, err := h.repos.FreezeWindows.ListEndingAfter(ctx, env.ID, now)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list freeze windows",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get deploy policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": policy,
+		"now":    deploypolicy.Evaluate(policy, freezes, now),
+	})
+}
+
+// UpdateDeployPolicy creates or replaces the deploy policy of an
+// environment. From then on its freeze windows block every deploy, not just
+// scheduled deployment groups.
+// PUT /v1/projects/:slug/environments/:env_name/deploy-policy
+func (h *Handler) UpdateDeployPolicy(c *gin.Context) {
+	var req UpdateDeployPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := &types.DeployPolicy{
+		Timezone:      req.Timezone,
+		Windows:       req.Windows,
+		AllowOverride: req.AllowOverride == nil || *req.AllowOverride,
+	}
+	if policy.Timezone == "" {
+		policy.Timezone = "UTC"
+	}
+	for i := range policy.Windows {
+		for j, day := range policy.Windows[i].Days {
+			policy.Windows[i].Days[j] = strings.ToLower(day)
+		}
+	}
+	if err := deploypolicy.ValidatePolicy(policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, env := h.loadEnvironment(c)
+	if env == nil {
+		return
+	}
+	ctx := c.Request.Context()
+	policy.EnvironmentID = env.ID
+
+	if err := h.repos.DeployPolicies.Upsert(ctx, policy); err != nil {
+		h.logger.Error(ctx, "Failed to save deploy policy",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save deploy policy"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.deploy_policy_updated", map[string]interface{}{
+		"timezone":       policy.Timezone,
+		"windows":        policy.Windows,
+		"allow_override": policy.AllowOverride,
+	})
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteDeployPolicy removes the deploy policy of an environment. Deploys
+// are allowed at any time again, and freeze windows only hold back
+// scheduled deployment groups.
+// DELETE /v1/projects/:slug/environments/:env_name/deploy-policy
+func (h *Handler) DeleteDeployPolicy(c *gin.Context) {
+	project, env := h.loadEnvironment(c)
+	if env == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.DeployPolicies.Delete(ctx, env.ID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment has no deploy policy"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete deploy policy",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete deploy policy"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.deploy_policy_deleted", nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deploy policy removed"})
+}
+
+// checkDeployPolicy checks a deploy into an environment against its deploy
+// policy. A blocked deploy goes ahead only with an override justification
+// from an admin; the decision it overrides is returned so the caller can
+// record it once the deployment exists. It writes the error response and
+// returns false when the deploy must not go ahead.
+func (h *Handler) checkDeployPolicy(c *gin.Context, env *types.Environment, overrideReason string) (*deploypolicy.Decision, bool) {
+	ctx := c.Request.Context()
+
+	decision, err := deploypolicy.Check(ctx, h.repos, env.ID, time.Now())
+	if err != nil {
+		if isTableNotExistError(err) {
+			return nil, true
+		}
+		h.logger.Error(ctx, "Failed to check deploy policy", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check deploy policy"})
+		return nil, false
+	}
+	if decision.Allowed {
+		return nil, true
+	}
+
+	if overrideReason == "" {
+		help := "Deploy again once deploys are allowed"
+		if decision.AllowOverride {
+			help += ", or as an admin give an override_reason to override the policy in an emergency"
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "Deploy blocked by the deploy policy of the environment",
+			"environment":     env.Name,
+			"reason":          decision.Reason,
+			"next_allowed_at": decision.NextAllowedAt,
+			"allow_override":  decision.AllowOverride,
+			"help":            help,
+		})
+		return nil, false
+	}
+
+	userRole, _ := c.Get("user_role")
+	if err := deploypolicy.CheckOverride(decision, types.Role(fmt.Sprintf("%v", userRole)), overrideReason); err != nil {
+		status := http.StatusConflict
+		switch {
+		case errors.Is(err, deploypolicy.ErrOverrideForbidden):
+			status = http.StatusForbidden
+		case errors.Is(err, deploypolicy.ErrJustificationRequired):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":       err.Error(),
+			"environment": env.Name,
+			"reason":      decision.Reason,
+		})
+		return nil, false
+	}
+
+	return decision, true
+}
+
+// recordDeployPolicyOverride records in the audit log that a deployment
+// went ahead against the deploy policy of its environment
+func (h *Handler) recordDeployPolicyOverride(c *gin.Context, decision *deploypolicy.Decision, deployment *types.Deployment, service *types.Service, env *types.Environment, justification string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	deploypolicy.RecordOverride(c.Request.Context(), h.repos, &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    fmt.Sprintf("%v", userEmail),
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		ResourceType:  "deployment",
+		ResourceID:    deployment.ID.String(),
+		ResourceName:  service.Name,
+		ProjectID:     &service.ProjectID,
+		EnvironmentID: &env.ID,
+		Context: map[string]interface{}{
+			"release_id":  deployment.ReleaseID.String(),
+			"environment": env.Name,
+		},
+	}, decision, justification)
+
+	h.logger.Warn(c.Request.Context(), "Deploy policy overridden",
+		logging.String("deployment_id", deployment.ID.String()),
+		logging.String("environment", env.Name),
+		logging.String("reason", decision.Reason))
+}
@@ -1,11 +1,16 @@
 package api
 
 import (
+	stderrors "errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
@@ -17,15 +22,24 @@ type CreateDeploymentGroupRequest struct {
 	Strategy   string   `json:"strategy,omitempty"`    // "parallel", "sequential", "dependency_ordered" (default)
 	GitSHA     string   `json:"git_sha,omitempty"`
 	PRURL      string   `json:"pr_url,omitempty"`
+
+	// ScheduledAt defers execution to a time instead of waiting for execute
+	ScheduledAt          *time.Time `json:"scheduled_at,omitempty"`
+	RespectFreezeWindows bool       `json:"respect_freeze_windows,omitempty"` // Wait out freeze windows of the environment
 }
 
-// CreateDeploymentGroup creates a new deployment group for coordinated multi-service deployment
+// CreateDeploymentGroup creates a new deployment group for coordinated multi-service deployment.
+// With scheduled_at the group is executed at that time by the scheduler.
 // POST /v1/projects/:slug/environments/:env_name/deployment-groups
 func (h *Handler) CreateDeploymentGroup(c *gin.Context) {
 	ctx := c.Request.Context()
 	projectSlug := c.Param("slug")
 	envName := c.Param("env_name")
 
+	if !h.authorizeNamedEnvironment(c, projectSlug, envName) {
+		return
+	}
+
 	// Get user from context
 	user, ok := c.Get("user")
 	if !ok {
@@ -72,12 +86,15 @@ func (h *Handler) CreateDeploymentGroup(c *gin.Context) {
 		UserID:        userObj.ID.String(),
 		UserEmail:     userObj.Email,
 		UserRole:      string(userObj.Role),
+
+		ScheduledAt:          req.ScheduledAt,
+		RespectFreezeWindows: req.RespectFreezeWindows,
 	})
 	if err != nil {
 		h.logger.Error(ctx, "Failed to create deployment group",
 			logging.Error("error", err),
 			logging.String("project_slug", projectSlug))
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(errors.GetHTTPStatus(err), gin.H{
 			"error":   "Failed to create deployment group",
 			"details": err.Error(),
 		})
@@ -158,12 +175,27 @@ func (h *Handler) GetDeploymentGroup(c *gin.Context) {
 	})
 }
 
+// ExecuteDeploymentGroupRequest is the optional request body for executing a deployment group
+type ExecuteDeploymentGroupRequest struct {
+	OverrideReason string `json:"override_reason,omitempty"` // Justifies overriding the deploy policy
+}
+
 // ExecuteDeploymentGroup triggers the execution of a pending deployment group
 // POST /v1/projects/:slug/deployment-groups/:group_id/execute
 func (h *Handler) ExecuteDeploymentGroup(c *gin.Context) {
 	ctx := c.Request.Context()
 	groupID := c.Param("group_id")
 
+	var req ExecuteDeploymentGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !stderrors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if !h.authorizeDeploymentGroup(c, groupID) {
+		return
+	}
+
 	// Get user from context
 	user, ok := c.Get("user")
 	if !ok {
@@ -173,16 +205,17 @@ func (h *Handler) ExecuteDeploymentGroup(c *gin.Context) {
 	userObj := user.(*types.User)
 
 	result, err := h.deploymentGroupService.ExecuteGroupDeployment(ctx, &services.ExecuteGroupDeploymentRequest{
-		GroupID:   groupID,
-		UserID:    userObj.ID.String(),
-		UserEmail: userObj.Email,
-		UserRole:  string(userObj.Role),
+		GroupID:        groupID,
+		UserID:         userObj.ID.String(),
+		UserEmail:      userObj.Email,
+		UserRole:       string(userObj.Role),
+		OverrideReason: req.OverrideReason,
 	})
 	if err != nil {
 		h.logger.Error(ctx, "Failed to execute deployment group",
 			logging.Error("error", err),
 			logging.String("group_id", groupID))
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(errors.GetHTTPStatus(err), gin.H{
 			"error":   "Failed to execute deployment group",
 			"details": err.Error(),
 		})
@@ -208,12 +241,56 @@ func (h *Handler) ExecuteDeploymentGroup(c *gin.Context) {
 	})
 }
 
+// CancelDeploymentGroup cancels a scheduled deployment group before it runs
+// POST /v1/projects/:slug/deployment-groups/:group_id/cancel
+func (h *Handler) CancelDeploymentGroup(c *gin.Context) {
+	ctx := c.Request.Context()
+	groupID := c.Param("group_id")
+
+	if !h.authorizeDeploymentGroup(c, groupID) {
+		return
+	}
+
+	// Get user from context
+	user, ok := c.Get("user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	userObj := user.(*types.User)
+
+	group, err := h.deploymentGroupService.CancelGroupDeployment(ctx, &services.CancelGroupDeploymentRequest{
+		GroupID:   groupID,
+		UserEmail: userObj.Email,
+		UserRole:  string(userObj.Role),
+	})
+	if err != nil {
+		h.logger.Error(ctx, "Failed to cancel deployment group",
+			logging.Error("error", err),
+			logging.String("group_id", groupID))
+		c.JSON(errors.GetHTTPStatus(err), gin.H{
+			"error":   "Failed to cancel deployment group",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info(ctx, "Deployment group cancelled",
+		logging.String("group_id", groupID))
+
+	c.JSON(http.StatusOK, gin.H{"group": group})
+}
+
 // RollbackDeploymentGroup rolls back all deployments in a group
 // POST /v1/projects/:slug/deployment-groups/:group_id/rollback
 func (h *Handler) RollbackDeploymentGroup(c *gin.Context) {
 	ctx := c.Request.Context()
 	groupID := c.Param("group_id")
 
+	if !h.authorizeDeploymentGroup(c, groupID) {
+		return
+	}
+
 	// Get user from context
 	user, ok := c.Get("user")
 	if !ok {
@@ -259,6 +336,24 @@ func (h *Handler) RollbackDeploymentGroup(c *gin.Context) {
 	})
 }
 
+// authorizeDeploymentGroup checks the environment of a deployment group
+// against the grants of bot tokens
+func (h *Handler) authorizeDeploymentGroup(c *gin.Context, groupID string) bool {
+	if auth.TokenScopeFromContext(c) == nil {
+		return true
+	}
+
+	group, err := h.deploymentGroupService.GetGroupDeployment(c.Request.Context(), groupID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":    "Deployment group not found",
+			"group_id": groupID,
+		})
+		return false
+	}
+	return h.authorizeEnvironment(c, group.ProjectID, &group.EnvironmentID)
+}
+
 // --- Service Dependencies API ---
 
 // AddServiceDependencyRequest represents the request body for adding a service dependency
@@ -399,3 +494,84 @@ func (h *Handler) RemoveServiceDependency(c *gin.Context) {
 		"depends_on_id": dependsOnID,
 	})
 }
+
+// --- Bulk Operations API ---
+
+// BulkOperationRequest represents the request body for a bulk operation on project services
+type BulkOperationRequest struct {
+	Action      string            `json:"action" binding:"required"`      // "restart", "redeploy-latest", "scale"
+	Environment string            `json:"environment" binding:"required"` // Environment name
+	Replicas    int               `json:"replicas,omitempty"`             // Required for "scale"
+	Labels      map[string]string `json:"labels,omitempty"`               // Only services with all of these labels
+	ServiceIDs  []string          `json:"service_ids,omitempty"`          // Only these services (all if empty)
+	Strategy    string            `json:"strategy,omitempty"`             // "parallel" (default), "sequential", "dependency_ordered"
+
+	OverrideReason string `json:"override_reason,omitempty"` // Justifies a redeploy against the deploy policy
+}
+
+// ExecuteBulkOperation restarts, redeploys, or scales a filtered set of project services
+// as a single deployment group
+// POST /v1/projects/:slug/bulk
+func (h *Handler) ExecuteBulkOperation(c *gin.Context) {
+	ctx := c.Request.Context()
+	projectSlug := c.Param("slug")
+
+	// Get user from context
+	user, ok := c.Get("user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	userObj := user.(*types.User)
+
+	var req BulkOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.authorizeNamedEnvironment(c, projectSlug, req.Environment) {
+		return
+	}
+
+	result, err := h.deploymentGroupService.ExecuteBulkOperation(ctx, &services.BulkOperationRequest{
+		ProjectSlug: projectSlug,
+		Environment: req.Environment,
+		Action:      services.BulkAction(req.Action),
+		Replicas:    req.Replicas,
+		Labels:      req.Labels,
+		ServiceIDs:  req.ServiceIDs,
+		Strategy:    req.Strategy,
+		UserID:      userObj.ID.String(),
+		UserEmail:   userObj.Email,
+		UserRole:    string(userObj.Role),
+
+		OverrideReason: req.OverrideReason,
+	})
+	if err != nil {
+		h.logger.Error(ctx, "Failed to execute bulk operation",
+			logging.Error("error", err),
+			logging.String("project_slug", projectSlug),
+			logging.String("action", req.Action))
+		c.JSON(errors.GetHTTPStatus(err), gin.H{
+			"error":   "Failed to execute bulk operation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info(ctx, "Bulk operation executed",
+		logging.String("group_id", result.Group.ID.String()),
+		logging.String("project_slug", projectSlug),
+		logging.String("action", string(result.Action)),
+		logging.Int("queued", result.Summary.Queued),
+		logging.Int("skipped", result.Summary.Skipped),
+		logging.Int("failed", result.Summary.Failed))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"group":   result.Group,
+		"action":  result.Action,
+		"results": result.Results,
+		"summary": result.Summary,
+	})
+}
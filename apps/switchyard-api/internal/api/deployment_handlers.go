@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,10 +14,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/compliance"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/deploypolicy"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/gpuquota"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/provenance"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/soak"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -35,6 +44,7 @@ func (h *Handler) DeployService(c *gin.Context) {
 		EnvironmentName string            `json:"environment_name"` // e.g., "production", "staging", "dev"
 		Replicas        int               `json:"replicas,omitempty"`
 		ChangeTicketURL string            `json:"change_ticket_url,omitempty"` // For production deployments
+		OverrideReason  string            `json:"override_reason,omitempty"`   // Justifies overriding the deploy policy
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -75,6 +85,10 @@ func (h *Handler) DeployService(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Release is not ready for deployment"})
 		return
 	}
+	if release.PrunedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Release was pruned and its image deleted; build or deploy a newer release"})
+		return
+	}
 
 	// Look up environment by project and name
 	env, err := h.repos.Environments.GetByProjectAndName(service.ProjectID, req.EnvironmentName)
@@ -92,6 +106,11 @@ func (h *Handler) DeployService(c *gin.Context) {
 	}
 	environmentID := env.ID
 
+	override, ok := h.checkDeploy(c, service, releaseID, env, req.Replicas, req.OverrideReason)
+	if !ok {
+		return
+	}
+
 	// Create deployment record
 	deployment := &types.Deployment{
 		ID:            uuid.New(),
@@ -105,74 +124,8 @@ func (h *Handler) DeployService(c *gin.Context) {
 	}
 
 	// Check PR approvals before deployment (if provenance checker is configured)
-	if h.provenanceChecker != nil {
-		approvalResult, err := h.provenanceChecker.CheckDeploymentApproval(
-			ctx,
-			deployment,
-			release,
-			service,
-			req.EnvironmentName,
-			req.ChangeTicketURL,
-		)
-
-		if err != nil {
-			h.logger.Error(ctx, "Failed to check deployment approval", logging.Error("provenance_error", err))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to verify deployment approval",
-				"details": err.Error(),
-			})
-			return
-		}
-
-		if !approvalResult.Approved {
-			h.logger.Warn(ctx, "Deployment blocked by approval policy",
-				logging.String("environment", req.EnvironmentName),
-				logging.String("service_id", serviceID.String()),
-				logging.String("violations", approvalResult.Violations.Error()))
-
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":             "Deployment does not meet approval requirements",
-				"policy_violations": approvalResult.Violations,
-				"environment":       req.EnvironmentName,
-				"help":              "Ensure your PR has sufficient approvals and CI checks pass before deploying to this environment",
-			})
-			return
-		}
-
-		// Store approval record for audit trail
-		if approvalResult.Receipt != nil {
-			receiptJSON, err := approvalResult.Receipt.ToJSON()
-			if err != nil {
-				h.logger.Error(ctx, "Failed to serialize compliance receipt", logging.Error("receipt_error", err))
-			}
-
-			approvalRecord := &types.ApprovalRecord{
-				DeploymentID:      deployment.ID,
-				PRURL:             approvalResult.PRURL,
-				PRNumber:          approvalResult.PRNumber,
-				ApproverEmail:     approvalResult.ApproverEmail,
-				ApproverName:      approvalResult.ApproverName,
-				ApprovedAt:        &approvalResult.ApprovedAt,
-				CIStatus:          approvalResult.CIStatus,
-				ChangeTicketURL:   req.ChangeTicketURL,
-				ComplianceReceipt: receiptJSON,
-			}
-
-			if err := h.repos.ApprovalRecords.Create(ctx, approvalRecord); err != nil {
-				// Log error but don't block deployment - approval record is for audit only
-				h.logger.Error(ctx, "Failed to store approval record", logging.Error("db_error", err))
-			} else {
-				h.logger.Info(ctx, "Approval record stored",
-					logging.String("deployment_id", deployment.ID.String()),
-					logging.String("pr_url", approvalResult.PRURL),
-					logging.String("approver", approvalResult.ApproverEmail))
-			}
-
-			// Send compliance evidence to Vanta/Drata (if enabled)
-			if h.complianceExporter != nil && h.complianceExporter.IsEnabled() {
-				go h.sendComplianceWebhooks(ctx, deployment, release, service, req.EnvironmentName, approvalResult, receiptJSON)
-			}
-		}
+	if !h.checkDeploymentApproval(c, deployment, release, service, req.EnvironmentName, req.ChangeTicketURL) {
+		return
 	}
 
 	if deployment.Replicas <= 0 {
@@ -185,9 +138,13 @@ func (h *Handler) DeployService(c *gin.Context) {
 		return
 	}
 
+	if override != nil {
+		h.recordDeployPolicyOverride(c, override, deployment, service, env, req.OverrideReason)
+	}
+
 	// Schedule deployment with reconciler
 	if err := h.reconciler.ScheduleReconciliation(deployment.ID.String(), 1); err != nil {
-		h.logger.Warn(ctx, "Reconciler queue full, work queued for retry",
+		h.logger.Warn(ctx, "Failed to queue reconciliation, pending deployment scan will retry",
 			logging.String("deployment_id", deployment.ID.String()),
 			logging.Error("queue_error", err))
 	}
@@ -204,6 +161,174 @@ func (h *Handler) DeployService(c *gin.Context) {
 	c.JSON(http.StatusCreated, deployment)
 }
 
+// checkDeploy checks a deploy of a release of a service into an environment:
+// access to the environment, two-factor authentication and reviewed commits
+// for production, release stability, the deploy policy and the quotas. The
+// deploy policy decision an override went against is returned so the caller
+// can record it once the deployment exists. It writes the error response and
+// returns false when the deploy must not go ahead.
+func (h *Handler) checkDeploy(c *gin.Context, service *types.Service, releaseID uuid.UUID, env *types.Environment, replicas int, overrideReason string) (*deploypolicy.Decision, bool) {
+	ctx := c.Request.Context()
+
+	if !h.authorizeEnvironment(c, service.ProjectID, &env.ID) {
+		return nil, false
+	}
+
+	if env.Name == "production" && !h.requireTwoFactor(c, &service.ProjectID, auth.SensitiveProductionDeploy) {
+		return nil, false
+	}
+
+	// Releases built from an uploaded working directory have no reviewed commit behind them
+	if env.Name == "production" {
+		local, err := h.isLocalRelease(ctx, releaseID)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to check release source", logging.Error("db_error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check release source"})
+			return nil, false
+		}
+		if local {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":       "Release was built from a local build context",
+				"environment": env.Name,
+				"release_id":  releaseID,
+				"help":        "Local builds can only be deployed to non-production environments. Commit and push the change to deploy it to production",
+			})
+			return nil, false
+		}
+	}
+
+	// Environments that require stable releases only take releases that passed a soak elsewhere
+	if err := soak.CheckPromotion(ctx, h.repos, releaseID, env.ID); err != nil && !isTableNotExistError(err) {
+		if errors.Is(err, soak.ErrReleaseNotStable) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":       "Release is not stable",
+				"environment": env.Name,
+				"release_id":  releaseID,
+				"help":        "This environment only accepts releases that passed a soak in another environment. Deploy the release there first and wait for its soak to pass",
+			})
+			return nil, false
+		}
+		h.logger.Error(ctx, "Failed to check release stability", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check release stability"})
+		return nil, false
+	}
+
+	// Deploys outside the environment's deploy windows or during a freeze need an override
+	override, ok := h.checkDeployPolicy(c, env, overrideReason)
+	if !ok {
+		return nil, false
+	}
+
+	// GPU services are held to the GPU quota of the project's team
+	if err := gpuquota.Check(ctx, h.repos, service, env.ID, replicas); err != nil {
+		if errors.Is(err, gpuquota.ErrQuotaExceeded) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":       "GPU quota exceeded",
+				"details":     err.Error(),
+				"environment": env.Name,
+				"help":        "Scale down other GPU services of the team or ask a platform admin to raise the team's GPU quota",
+			})
+			return nil, false
+		}
+		h.logger.Error(ctx, "Failed to check GPU quota", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check GPU quota"})
+		return nil, false
+	}
+
+	// The pods of the deploy are held to the CPU and memory quotas of the project and team
+	if err := quota.CheckDeploy(ctx, h.repos, service, env.ID, replicas); err != nil {
+		if quotaExceeded(c, err) {
+			return nil, false
+		}
+		h.logger.Error(ctx, "Failed to check resource quota", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check resource quota"})
+		return nil, false
+	}
+
+	return override, true
+}
+
+// checkDeploymentApproval checks the PR approvals of a deployment against
+// the approval policy of its environment and stores the approval record. It
+// writes the error response and returns false when the deployment is not
+// approved.
+func (h *Handler) checkDeploymentApproval(c *gin.Context, deployment *types.Deployment, release *types.Release, service *types.Service, environmentName, changeTicketURL string) bool {
+	if h.provenanceChecker == nil {
+		return true
+	}
+	ctx := c.Request.Context()
+
+	approvalResult, err := h.provenanceChecker.CheckDeploymentApproval(
+		ctx,
+		deployment,
+		release,
+		service,
+		environmentName,
+		changeTicketURL,
+	)
+
+	if err != nil {
+		h.logger.Error(ctx, "Failed to check deployment approval", logging.Error("provenance_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to verify deployment approval",
+			"details": err.Error(),
+		})
+		return false
+	}
+
+	if !approvalResult.Approved {
+		h.logger.Warn(ctx, "Deployment blocked by approval policy",
+			logging.String("environment", environmentName),
+			logging.String("service_id", service.ID.String()),
+			logging.String("violations", approvalResult.Violations.Error()))
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "Deployment does not meet approval requirements",
+			"policy_violations": approvalResult.Violations,
+			"environment":       environmentName,
+			"help":              "Ensure your PR has sufficient approvals and CI checks pass before deploying to this environment",
+		})
+		return false
+	}
+
+	// Store approval record for audit trail
+	if approvalResult.Receipt != nil {
+		receiptJSON, err := approvalResult.Receipt.ToJSON()
+		if err != nil {
+			h.logger.Error(ctx, "Failed to serialize compliance receipt", logging.Error("receipt_error", err))
+		}
+
+		approvalRecord := &types.ApprovalRecord{
+			DeploymentID:      deployment.ID,
+			PRURL:             approvalResult.PRURL,
+			PRNumber:          approvalResult.PRNumber,
+			ApproverEmail:     approvalResult.ApproverEmail,
+			ApproverName:      approvalResult.ApproverName,
+			ApprovedAt:        &approvalResult.ApprovedAt,
+			CIStatus:          approvalResult.CIStatus,
+			ChangeTicketURL:   changeTicketURL,
+			ComplianceReceipt: receiptJSON,
+		}
+
+		if err := h.repos.ApprovalRecords.Create(ctx, approvalRecord); err != nil {
+			// Log error but don't block deployment - approval record is for audit only
+			h.logger.Error(ctx, "Failed to store approval record", logging.Error("db_error", err))
+		} else {
+			h.logger.Info(ctx, "Approval record stored",
+				logging.String("deployment_id", deployment.ID.String()),
+				logging.String("pr_url", approvalResult.PRURL),
+				logging.String("approver", approvalResult.ApproverEmail))
+		}
+
+		// Send compliance evidence to Vanta/Drata (if enabled)
+		if h.complianceExporter != nil && h.complianceExporter.IsEnabled() {
+			go h.sendComplianceWebhooks(ctx, deployment, release, service, environmentName, approvalResult, receiptJSON)
+		}
+	}
+
+	return true
+}
+
 // GetServiceStatus returns the current status of a service
 func (h *Handler) GetServiceStatus(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -252,7 +377,9 @@ func (h *Handler) GetServiceStatus(c *gin.Context) {
 			env, err := h.repos.Environments.GetByID(ctx, latestDeployment.EnvironmentID)
 			if err == nil && env.KubeNamespace != "" {
 				namespace := env.KubeNamespace
-				if pods, err := h.k8sClient.ListPods(ctx, namespace, fmt.Sprintf("enclii.dev/service=%s", service.Name)); err == nil {
+				if k8sClient, err := h.clusterClient(ctx, env); err != nil {
+					h.logger.Warn(ctx, "Failed to connect to the environment's cluster", logging.Error("error", err))
+				} else if pods, err := k8sClient.ListPods(ctx, namespace, fmt.Sprintf("enclii.dev/service=%s", service.Name)); err == nil {
 					status["pods"] = pods.Items
 					status["running_pods"] = len(pods.Items)
 				}
@@ -331,14 +458,23 @@ func (h *Handler) GetLogs(c *gin.Context) {
 	}
 	labelSelector := fmt.Sprintf("enclii.dev/service=%s", service.Name)
 
+	k8sClient, err := h.clusterClient(ctx, env)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to connect to the environment's cluster", logging.Error("k8s_error", err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect to the environment's cluster"})
+		return
+	}
+
 	// Get logs from Kubernetes
-	logs, err := h.k8sClient.GetLogs(ctx, namespace, labelSelector, linesInt, follow)
+	logs, err := k8sClient.GetLogs(ctx, namespace, labelSelector, linesInt, follow)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to get logs", logging.Error("k8s_error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve logs"})
 		return
 	}
 
+	logs = h.projectLogRedactor(ctx, service.ProjectID, redact.SourceRuntime).Redact(logs)
+
 	if follow {
 		// Stream logs via SSE
 		c.Header("Content-Type", "text/event-stream")
@@ -392,6 +528,20 @@ func (h *Handler) RollbackDeployment(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeEnvironment(c, service.ProjectID, &deployment.EnvironmentID) {
+		return
+	}
+
+	env, err := h.repos.Environments.GetByID(ctx, deployment.EnvironmentID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get environment", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get environment"})
+		return
+	}
+	if env.Name == "production" && !h.requireTwoFactor(c, &service.ProjectID, auth.SensitiveProductionDeploy) {
+		return
+	}
+
 	// Find previous successful deployment by getting all releases for the service
 	// then finding deployments for those releases
 	releases, err := h.repos.Releases.ListByService(release.ServiceID)
@@ -536,11 +686,194 @@ func (h *Handler) GetDeployment(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deployment"})
 		return
 	}
+	if !h.authorizeDeploymentEnvironment(c, deployment.ID) {
+		return
+	}
+
+	// Attach the rollout phases; the deployment is useful without them
+	progress, err := h.repos.DeploymentPhases.GetProgress(ctx, deployment)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get deployment progress",
+			logging.String("deployment_id", deploymentID.String()),
+			logging.Error("error", err))
+	} else {
+		deployment.Progress = progress
+	}
 
 	c.JSON(http.StatusOK, deployment)
 }
 
-// ListServiceDeployments returns all deployments for a service
+// GetDeploymentSnapshot returns the configuration snapshot captured for a deployment
+// GET /v1/deployments/:id/snapshot
+func (h *Handler) GetDeploymentSnapshot(c *gin.Context) {
+	ctx := c.Request.Context()
+	deploymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deployment ID"})
+		return
+	}
+	if !h.authorizeDeploymentEnvironment(c, deploymentID) {
+		return
+	}
+
+	snapshot, err := h.repos.DeploymentSnapshots.GetByDeploymentID(ctx, deploymentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No config snapshot recorded for this deployment"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get deployment snapshot",
+			logging.String("deployment_id", deploymentID.String()),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deployment snapshot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// ReconcileDeploymentRequest is the body of a forced reconciliation
+type ReconcileDeploymentRequest struct {
+	// DriftPolicy decides what happens to manual changes made to the
+	// deployment's Kubernetes resources: overwrite (default) or preserve
+	DriftPolicy types.DriftPolicy `json:"drift_policy"`
+}
+
+// ReconcileDeployment forces a deployment's Kubernetes resources to be
+// re-applied, overwriting or preserving manual changes made to them
+func (h *Handler) ReconcileDeployment(c *gin.Context) {
+	ctx := c.Request.Context()
+	deploymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deployment ID"})
+		return
+	}
+
+	var req ReconcileDeploymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	switch req.DriftPolicy {
+	case "":
+		req.DriftPolicy = types.DriftPolicyOverwrite
+	case types.DriftPolicyOverwrite, types.DriftPolicyPreserve:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "drift_policy must be overwrite or preserve"})
+		return
+	}
+
+	deployment, err := h.repos.Deployments.GetByID(ctx, deploymentID.String())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deployment not found"})
+		return
+	}
+
+	release, err := h.repos.Releases.GetByID(deployment.ReleaseID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get release", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get release"})
+		return
+	}
+	service, err := h.repos.Services.GetByID(release.ServiceID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get service", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get service"})
+		return
+	}
+
+	if !h.authorizeEnvironment(c, service.ProjectID, &deployment.EnvironmentID) {
+		return
+	}
+
+	env, err := h.repos.Environments.GetByID(ctx, deployment.EnvironmentID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get environment", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get environment"})
+		return
+	}
+	if env.Name == "production" && !h.requireTwoFactor(c, &service.ProjectID, auth.SensitiveProductionDeploy) {
+		return
+	}
+
+	if deployment.Status != types.DeploymentStatusRunning && deployment.Status != types.DeploymentStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Cannot reconcile a deployment that is %s", deployment.Status)})
+		return
+	}
+
+	if err := h.reconciler.ForceReconciliation(deployment.ID.String(), req.DriftPolicy); err != nil {
+		h.logger.Error(ctx, "Failed to queue deployment reconciliation",
+			logging.String("deployment_id", deployment.ID.String()),
+			logging.Error("queue_error", err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to queue reconciliation"})
+		return
+	}
+
+	h.logger.Info(ctx, "Deployment reconciliation forced",
+		logging.String("deployment_id", deployment.ID.String()),
+		logging.String("drift_policy", string(req.DriftPolicy)))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"deployment_id": deployment.ID,
+		"drift_policy":  req.DriftPolicy,
+		"status":        "queued",
+	})
+}
+
+// ListDeploymentDrift returns the manual changes found on a deployment's
+// Kubernetes resources, newest first
+func (h *Handler) ListDeploymentDrift(c *gin.Context) {
+	ctx := c.Request.Context()
+	deploymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deployment ID"})
+		return
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+	}
+
+	deployment, err := h.repos.Deployments.GetByID(ctx, deploymentID.String())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deployment not found"})
+		return
+	}
+	release, err := h.repos.Releases.GetByID(deployment.ReleaseID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get release", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get release"})
+		return
+	}
+	service, err := h.repos.Services.GetByID(release.ServiceID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get service", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get service"})
+		return
+	}
+	if !h.authorizeEnvironment(c, service.ProjectID, &deployment.EnvironmentID) {
+		return
+	}
+
+	events, err := h.repos.DriftEvents.ListByDeployment(ctx, deploymentID, limit)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list drift events",
+			logging.String("deployment_id", deploymentID.String()),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve drift events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drift_events": events})
+}
+
+// ListServiceDeployments returns a page of the deployments of a service,
+// which can be filtered by status, environment (a name or an ID) and time
+// GET /v1/services/:id/deployments?environment=production&status=failed&since=2026-10-01
 func (h *Handler) ListServiceDeployments(c *gin.Context) {
 	ctx := c.Request.Context()
 	idStr := c.Param("id")
@@ -550,32 +883,58 @@ func (h *Handler) ListServiceDeployments(c *gin.Context) {
 		return
 	}
 
-	// Get all releases for this service
-	releases, err := h.repos.Releases.ListByService(serviceID)
+	query, err := GetListQuery(c, "created_at", "updated_at")
 	if err != nil {
-		h.logger.Error(ctx, "Failed to list releases", logging.Error("db_error", err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve releases"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Get deployments for each release
-	var allDeployments []*types.Deployment
-	for _, release := range releases {
-		deployments, err := h.repos.Deployments.ListByRelease(ctx, release.ID.String())
-		if err != nil {
-			h.logger.Error(ctx, "Failed to list deployments", logging.Error("db_error", err))
-			continue
+	if query.Environment != "" {
+		if query.EnvironmentID, err = h.resolveServiceEnvironment(ctx, serviceID, query.Environment); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown environment"})
+			return
 		}
-		allDeployments = append(allDeployments, deployments...)
 	}
 
+	deployments, err := h.repos.Deployments.ListPageByService(ctx, serviceID, query.ListParams)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list deployments", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deployments"})
+		return
+	}
+
+	deployments, next := db.TrimPage(deployments, query.ListParams, func(deployment *types.Deployment) db.Cursor {
+		return itemCursor(query.Sort, deployment.ID, "", deployment.CreatedAt, deployment.UpdatedAt)
+	})
 	c.JSON(http.StatusOK, gin.H{
 		"service_id":  serviceID,
-		"deployments": allDeployments,
-		"count":       len(allDeployments),
+		"deployments": deployments,
+		"count":       len(deployments),
+		"next_cursor": encodeListCursor(query.ListParams, next),
 	})
 }
 
+// resolveServiceEnvironment finds an environment of the project of a service
+// by ID or name
+func (h *Handler) resolveServiceEnvironment(ctx context.Context, serviceID uuid.UUID, environment string) (*uuid.UUID, error) {
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	var env *types.Environment
+	if id, parseErr := uuid.Parse(environment); parseErr == nil {
+		env, err = h.repos.Environments.GetByID(ctx, id)
+	} else {
+		env, err = h.repos.Environments.GetByProjectAndName(service.ProjectID, environment)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if env.ProjectID != service.ProjectID {
+		return nil, sql.ErrNoRows
+	}
+	return &env.ID, nil
+}
+
 // sendComplianceWebhooks sends deployment evidence to Vanta/Drata
 func (h *Handler) sendComplianceWebhooks(
 	ctx context.Context,
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/dns"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// resolveDNSMode decides whether Enclii manages the DNS record of a new
+// domain. With no mode requested, DNS is managed when the service's team has
+// connected the domain's zone, and manual otherwise. It writes the error
+// response and returns ok=false when the requested mode cannot be used.
+func (h *Handler) resolveDNSMode(c *gin.Context, serviceID uuid.UUID, domain, requested string) (mode string, providerID *uuid.UUID, ok bool) {
+	ctx := c.Request.Context()
+
+	switch requested {
+	case types.DNSModeManual:
+		return types.DNSModeManual, nil, true
+	case "", types.DNSModeManaged:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dns_mode must be managed or manual"})
+		return "", nil, false
+	}
+
+	if h.dnsService == nil {
+		if requested == types.DNSModeManaged {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Managed DNS is not enabled"})
+			return "", nil, false
+		}
+		return types.DNSModeManual, nil, true
+	}
+
+	provider, err := h.dnsService.FindProvider(ctx, serviceID, domain)
+	if err != nil {
+		if requested == types.DNSModeManaged {
+			if errors.Is(err, dns.ErrNoProvider) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			} else {
+				h.logger.Error(ctx, "Failed to look up DNS provider", logging.Error("error", err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up DNS provider"})
+			}
+			return "", nil, false
+		}
+		if !errors.Is(err, dns.ErrNoProvider) {
+			h.logger.Warn(ctx, "Failed to look up DNS provider, using manual DNS",
+				logging.String("domain", domain),
+				logging.Error("error", err))
+		}
+		return types.DNSModeManual, nil, true
+	}
+
+	return types.DNSModeManaged, &provider.ID, true
+}
+
+// applyManagedDNS writes the record of a managed domain. A failure leaves
+// the domain in place with dns_status "error"; the DNS record controller
+// retries it, and the caller can fall back to manual DNS.
+func (h *Handler) applyManagedDNS(c *gin.Context, domain *types.CustomDomain) bool {
+	if domain.DNSMode != types.DNSModeManaged || h.dnsService == nil {
+		return false
+	}
+	ctx := c.Request.Context()
+
+	if err := h.dnsService.Apply(ctx, domain); err != nil {
+		h.logger.Warn(ctx, "Failed to write managed DNS record",
+			logging.String("domain", domain.Domain),
+			logging.Error("error", err))
+		return false
+	}
+	return true
+}
+
+// CheckDomainDNS compares the DNS record of a managed domain with the one it
+// needs and records any drift
+// GET /api/v1/domains/:domain_id/dns
+func (h *Handler) CheckDomainDNS(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	domain, err := h.repos.CustomDomains.GetByID(ctx, c.Param("domain_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "domain not found"})
+		return
+	}
+
+	if domain.DNSMode != types.DNSModeManaged || h.dnsService == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"dns_mode": types.DNSModeManual,
+			"expected": dns.Record{Type: "CNAME", Name: domain.Domain, Content: domain.DNSCNAME},
+		})
+		return
+	}
+
+	actual, err := h.dnsService.Check(ctx, domain)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to check managed DNS record",
+			logging.String("domain", domain.Domain),
+			logging.Error("error", err))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dns_mode":           domain.DNSMode,
+		"dns_status":         domain.DNSStatus,
+		"dns_status_message": domain.DNSStatusMessage,
+		"dns_checked_at":     domain.DNSCheckedAt,
+		"expected":           h.dnsService.DesiredRecord(domain),
+		"actual":             actual,
+	})
+}
+
+// SyncDomainDNS rewrites the DNS record of a managed domain, repairing drift
+// POST /api/v1/domains/:domain_id/dns/sync
+func (h *Handler) SyncDomainDNS(c *gin.Context) {
+	if h.dnsService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Managed DNS is not enabled"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	domain, err := h.repos.CustomDomains.GetByID(ctx, c.Param("domain_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "domain not found"})
+		return
+	}
+
+	if domain.DNSMode != types.DNSModeManaged {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "domain uses manual DNS; set dns_mode to managed first"})
+		return
+	}
+
+	if err := h.dnsService.Apply(ctx, domain); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":  "failed to write DNS record: " + err.Error(),
+			"domain": domain,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"domain":  domain,
+		"message": "DNS record written",
+	})
+}
@@ -64,6 +64,9 @@ func (h *Handler) AddCustomDomain(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid domain format"})
 		return
 	}
+	if !h.checkDomainClaim(c, req.Domain, false) {
+		return
+	}
 
 	// Check if domain is already in use
 	exists, err := h.repos.CustomDomains.Exists(ctx, req.Domain)
@@ -105,29 +108,7 @@ func (h *Handler) AddCustomDomain(c *gin.Context) {
 	}
 
 	// Add tunnel route if tunnel routes service is configured
-	tunnelRouteAdded := false
-	if h.tunnelRoutesService != nil {
-		routeSpec := &services.RouteSpec{
-			Hostname:         req.Domain,
-			ServiceName:      service.Name,
-			ServiceNamespace: fmt.Sprintf("enclii-%s", req.Environment),
-			ServicePort:      80, // K8s Service port (not container port)
-			ConnectTimeout:   "30s",
-			KeepAliveTimeout: "90s",
-		}
-
-		if err := h.tunnelRoutesService.AddRoute(ctx, routeSpec); err != nil {
-			h.logger.Warn(ctx, "Failed to add tunnel route (domain created, manual tunnel config may be needed)",
-				logging.String("domain", req.Domain),
-				logging.Error("error", err))
-			// Don't fail the request - domain is created, tunnel route is optional
-		} else {
-			tunnelRouteAdded = true
-			h.logger.Info(ctx, "Tunnel route added automatically",
-				logging.String("domain", req.Domain),
-				logging.String("service", service.Name))
-		}
-	}
+	tunnelRouteAdded := h.addTunnelRoute(ctx, req.Domain, service.Name, req.Environment)
 
 	// Trigger reconciliation to create Ingress
 	go h.triggerDomainReconciliation(ctx, serviceUUID, env.ID)
@@ -146,6 +127,37 @@ func (h *Handler) AddCustomDomain(c *gin.Context) {
 	})
 }
 
+// addTunnelRoute routes a custom domain to a service through the tunnel, if
+// the tunnel routes service is configured. Failures are logged and leave the
+// route to be configured by hand.
+func (h *Handler) addTunnelRoute(ctx context.Context, hostname, serviceName, envName string) bool {
+	if h.tunnelRoutesService == nil {
+		return false
+	}
+
+	routeSpec := &services.RouteSpec{
+		Hostname:         hostname,
+		ServiceName:      serviceName,
+		ServiceNamespace: fmt.Sprintf("enclii-%s", envName),
+		ServicePort:      80, // K8s Service port (not container port)
+		ConnectTimeout:   "30s",
+		KeepAliveTimeout: "90s",
+	}
+
+	if err := h.tunnelRoutesService.AddRoute(ctx, routeSpec); err != nil {
+		h.logger.Warn(ctx, "Failed to add tunnel route (domain created, manual tunnel config may be needed)",
+			logging.String("domain", hostname),
+			logging.Error("error", err))
+		// Don't fail the request - domain is created, tunnel route is optional
+		return false
+	}
+
+	h.logger.Info(ctx, "Tunnel route added automatically",
+		logging.String("domain", hostname),
+		logging.String("service", serviceName))
+	return true
+}
+
 // ListCustomDomains lists all custom domains for a service
 // GET /api/v1/services/:service_id/domains
 func (h *Handler) ListCustomDomains(c *gin.Context) {
@@ -184,7 +196,7 @@ func (h *Handler) GetCustomDomain(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"domain": domain})
+	respondWithETag(c, http.StatusOK, domainETag(domain), gin.H{"domain": domain})
 }
 
 // UpdateCustomDomain updates a custom domain
@@ -199,6 +211,7 @@ func (h *Handler) UpdateCustomDomain(c *gin.Context) {
 	var req struct {
 		TLSEnabled *bool   `json:"tls_enabled,omitempty"`
 		TLSIssuer  *string `json:"tls_issuer,omitempty"`
+		DNSMode    *string `json:"dns_mode,omitempty"` // "managed" or "manual"
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -214,6 +227,9 @@ func (h *Handler) UpdateCustomDomain(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "custom domain not found"})
 		return
 	}
+	if !checkPreconditions(c, domainETag(domain)) {
+		return
+	}
 
 	// Update fields
 	if req.TLSEnabled != nil {
@@ -223,15 +239,44 @@ func (h *Handler) UpdateCustomDomain(c *gin.Context) {
 		domain.TLSIssuer = *req.TLSIssuer
 	}
 
+	dnsModeChanged := req.DNSMode != nil && *req.DNSMode != domain.DNSMode
+	if dnsModeChanged {
+		if *req.DNSMode == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dns_mode must be managed or manual"})
+			return
+		}
+		mode, providerID, ok := h.resolveDNSMode(c, domain.ServiceID, domain.Domain, *req.DNSMode)
+		if !ok {
+			return
+		}
+		domain.DNSMode = mode
+		domain.DNSProviderID = providerID
+		domain.DNSRecordID = ""
+		domain.DNSStatus = ""
+		domain.DNSStatusMessage = ""
+	}
+
 	if err := h.repos.CustomDomains.Update(ctx, domain); err != nil {
 		h.logger.Error(ctx, "Failed to update custom domain", logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update custom domain"})
 		return
 	}
 
+	// Switching to manual leaves the existing record for the user to manage;
+	// switching to managed writes it through the team's provider
+	if dnsModeChanged {
+		if err := h.repos.CustomDomains.UpdateDNS(ctx, domain); err != nil {
+			h.logger.Error(ctx, "Failed to update custom domain DNS mode", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update custom domain"})
+			return
+		}
+		h.applyManagedDNS(c, domain)
+	}
+
 	// Trigger reconciliation to update Ingress
 	go h.triggerDomainReconciliation(ctx, domain.ServiceID, domain.EnvironmentID)
 
+	c.Header("ETag", domainETag(domain))
 	c.JSON(http.StatusOK, gin.H{"domain": domain})
 }
 
@@ -252,6 +297,9 @@ func (h *Handler) DeleteCustomDomain(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "custom domain not found"})
 		return
 	}
+	if !checkPreconditions(c, domainETag(domain)) {
+		return
+	}
 
 	// Remove tunnel route if tunnel routes service is configured
 	tunnelRouteRemoved := false
@@ -268,6 +316,18 @@ func (h *Handler) DeleteCustomDomain(c *gin.Context) {
 		}
 	}
 
+	// Remove the DNS record if Enclii manages it
+	dnsRecordRemoved := false
+	if domain.DNSMode == types.DNSModeManaged && h.dnsService != nil {
+		if err := h.dnsService.Remove(ctx, domain); err != nil {
+			h.logger.Warn(ctx, "Failed to remove managed DNS record (continuing with domain deletion)",
+				logging.String("domain", domain.Domain),
+				logging.Error("error", err))
+		} else {
+			dnsRecordRemoved = true
+		}
+	}
+
 	// Delete domain
 	if err := h.repos.CustomDomains.Delete(ctx, domainID); err != nil {
 		h.logger.Error(ctx, "Failed to delete custom domain", logging.Error("error", err))
@@ -281,6 +341,7 @@ func (h *Handler) DeleteCustomDomain(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message":              "custom domain deleted",
 		"tunnel_route_removed": tunnelRouteRemoved,
+		"dns_record_removed":   dnsRecordRemoved,
 	})
 }
 
@@ -411,6 +472,16 @@ func (h *Handler) triggerDomainReconciliation(ctx context.Context, serviceID, en
 	}
 }
 
+// checkDomainClaim responds 400 and returns false when a project may not
+// claim a domain because the platform serves it
+func (h *Handler) checkDomainClaim(c *gin.Context, domain string, platform bool) bool {
+	if err := h.reservedHosts.CheckClaim(domain, platform); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
 // isValidDomain checks if a domain name is valid
 func isValidDomain(domain string) bool {
 	// Basic validation
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dotenvEntry is a variable of a dotenv file
+type dotenvEntry struct {
+	Key   string
+	Value string
+}
+
+// parseDotenv parses dotenv text: KEY=VALUE lines, optionally prefixed with
+// "export", with values unquoted, in single quotes taken literally, or in
+// double quotes with \n, \r, \t, \" and \\ escapes. Blank lines and lines
+// starting with # are skipped, as is a # comment after an unquoted value.
+// A key set twice keeps its last value.
+func parseDotenv(text string) ([]dotenvEntry, error) {
+	var entries []dotenvEntry
+	index := make(map[string]int)
+
+	for n, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n+1)
+		}
+		key := strings.TrimSpace(line[:eq])
+		if !isValidEnvVarKey(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", n+1, key)
+		}
+
+		value, err := parseDotenvValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		if i, ok := index[key]; ok {
+			entries[i].Value = value
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, dotenvEntry{Key: key, Value: value})
+	}
+
+	return entries, nil
+}
+
+// parseDotenvValue parses the value after the = of a dotenv line
+func parseDotenvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch raw[i] {
+			case '"':
+				return b.String(), nil
+			case '\\':
+				if i+1 == len(raw) {
+					return "", fmt.Errorf("unterminated double-quoted value")
+				}
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(raw[i])
+				}
+			default:
+				b.WriteByte(raw[i])
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	}
+
+	if raw[0] == '#' {
+		return "", nil
+	}
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// formatDotenv writes entries as dotenv text sorted by key. Values that are
+// not plain words are double-quoted, so parseDotenv reads them back unchanged.
+func formatDotenv(entries []dotenvEntry) string {
+	sorted := append([]dotenvEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	var b strings.Builder
+	for _, e := range sorted {
+		b.WriteString(e.Key)
+		b.WriteByte('=')
+		b.WriteString(quoteDotenvValue(e.Value))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// quoteDotenvValue returns value as written in a dotenv file
+func quoteDotenvValue(value string) string {
+	plain := true
+	for _, c := range value {
+		if !((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			strings.ContainsRune("_-.,:/@+%", c)) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return value
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	text := `# database
+DATABASE_URL=postgres://db:5432/app
+export LOG_LEVEL=info # verbose in staging
+GREETING="hello \"world\"\nbye"
+LITERAL='a\nb #c'
+EMPTY=
+LOG_LEVEL=debug
+`
+	entries, err := parseDotenv(text)
+	if err != nil {
+		t.Fatalf("parseDotenv() error = %v", err)
+	}
+
+	want := []dotenvEntry{
+		{Key: "DATABASE_URL", Value: "postgres://db:5432/app"},
+		{Key: "LOG_LEVEL", Value: "debug"},
+		{Key: "GREETING", Value: "hello \"world\"\nbye"},
+		{Key: "LITERAL", Value: `a\nb #c`},
+		{Key: "EMPTY", Value: ""},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("parseDotenv() = %+v, want %+v", entries, want)
+	}
+
+	for _, invalid := range []string{
+		"NO_EQUALS",
+		"1KEY=value",
+		`OPEN="unterminated`,
+		"OPEN='unterminated",
+	} {
+		if _, err := parseDotenv(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestFormatDotenvRoundTrip(t *testing.T) {
+	entries := []dotenvEntry{
+		{Key: "B_URL", Value: "https://example.com/path"},
+		{Key: "A_MULTILINE", Value: "-----BEGIN KEY-----\nabc\n-----END KEY-----"},
+		{Key: "C_QUOTES", Value: `say "hi" \ # $HOME`},
+		{Key: "D_EMPTY", Value: ""},
+	}
+
+	text := formatDotenv(entries)
+	if want := "A_MULTILINE="; text[:len(want)] != want {
+		t.Errorf("formatDotenv() is not sorted by key:\n%s", text)
+	}
+
+	parsed, err := parseDotenv(text)
+	if err != nil {
+		t.Fatalf("parseDotenv() error = %v", err)
+	}
+	byKey := make(map[string]string)
+	for _, e := range parsed {
+		byKey[e.Key] = e.Value
+	}
+	for _, e := range entries {
+		if byKey[e.Key] != e.Value {
+			t.Errorf("%s = %q after a round trip, want %q", e.Key, byKey[e.Key], e.Value)
+		}
+	}
+}
+
+func TestParseEnvImportJSON(t *testing.T) {
+	entries, err := parseEnvImport("application/json", []byte(`{"B": "2", "A": "1"}`))
+	if err != nil {
+		t.Fatalf("parseEnvImport() error = %v", err)
+	}
+	want := []dotenvEntry{{Key: "A", Value: "1"}, {Key: "B", Value: "2"}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("parseEnvImport() = %+v, want %+v", entries, want)
+	}
+
+	for _, invalid := range []string{`{"A": 1}`, `["A"]`, `{"bad-key": "x"}`} {
+		if _, err := parseEnvImport("application/json", []byte(invalid)); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+}
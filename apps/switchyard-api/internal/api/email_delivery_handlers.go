@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// maxEmailDeliveriesLimit caps how many deliveries one request returns
+const maxEmailDeliveriesLimit = 200
+
+// ListEmailDeliveries returns the delivery status of transactional emails,
+// newest first, optionally filtered by status or recipient
+// GET /v1/email-deliveries?status=failed&recipient=dev@example.com&limit=50
+func (h *Handler) ListEmailDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	status := types.EmailDeliveryStatus(c.Query("status"))
+	switch status {
+	case "", types.EmailDeliveryStatusQueued, types.EmailDeliveryStatusSending, types.EmailDeliveryStatusRetrying,
+		types.EmailDeliveryStatusSent, types.EmailDeliveryStatusFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be queued, sending, retrying, sent, or failed"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > maxEmailDeliveriesLimit {
+		limit = maxEmailDeliveriesLimit
+	}
+
+	deliveries, err := h.repos.EmailDeliveries.List(ctx, status, c.Query("recipient"), limit)
+	if err != nil && !isTableNotExistError(err) {
+		h.logger.Error(ctx, "Failed to list email deliveries", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list email deliveries"})
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*types.EmailDelivery{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/environments"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// CloneEnvironmentRequest names the environment created from an existing one
+type CloneEnvironmentRequest struct {
+	Name          string `json:"name" binding:"required"`
+	KubeNamespace string `json:"kube_namespace"`
+	IncludeAddons *bool  `json:"include_addons"`  // Defaults to true
+	CopyAddonData bool   `json:"copy_addon_data"` // Clone or restore the data of copied addons
+}
+
+// CloneEnvironment creates an environment with the variables, routes, soak
+// policy and addons of an existing one. Addon copies are provisioned in the
+// background; their progress is listed under addon-copies.
+// POST /v1/projects/:slug/environments/:env_name/clone
+func (h *Handler) CloneEnvironment(c *gin.Context) {
+	if h.environmentCloner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Environment cloning is not enabled"})
+		return
+	}
+
+	var req CloneEnvironmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// Bot tokens may only copy the variables and addons of environments
+	// they are granted
+	if !h.authorizeNamedEnvironment(c, c.Param("slug"), c.Param("env_name")) {
+		return
+	}
+	project, source := h.loadEnvironment(c)
+	if source == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	cloneReq := &environments.CloneRequest{
+		Name:          req.Name,
+		KubeNamespace: req.KubeNamespace,
+		IncludeAddons: req.IncludeAddons == nil || *req.IncludeAddons,
+		CopyAddonData: req.CopyAddonData,
+	}
+	if userID, ok := c.Get("user_id"); ok {
+		if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+			cloneReq.UserID = &parsed
+		}
+	}
+	if userEmail, ok := c.Get("user_email"); ok {
+		cloneReq.UserEmail = fmt.Sprintf("%v", userEmail)
+	}
+
+	result, err := h.environmentCloner.Clone(ctx, project, source, cloneReq)
+	if err != nil {
+		if errors.Is(err, environments.ErrEnvironmentExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Environment already exists"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to clone environment",
+			logging.String("project", project.Slug),
+			logging.String("source", source.Name),
+			logging.String("environment", req.Name),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clone environment"})
+		return
+	}
+
+	h.auditEnvironment(c, project, result.Environment, "environment.cloned", map[string]interface{}{
+		"source":             source.Name,
+		"env_vars_copied":    result.EnvVarsCopied,
+		"routes_copied":      result.RoutesCopied,
+		"soak_policy_copied": result.SoakPolicyCopied,
+		"addons_copied":      len(result.Addons),
+		"copy_addon_data":    req.CopyAddonData,
+	})
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// ListEnvironmentAddonCopies returns the addons copied into a cloned
+// environment and how far along each is
+// GET /v1/projects/:slug/environments/:env_name/addon-copies
+func (h *Handler) ListEnvironmentAddonCopies(c *gin.Context) {
+	_, env := h.loadEnvironment(c)
+	if env == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	copies, err := h.repos.AddonCopies.ListByEnvironment(ctx, env.ID)
+	if err != nil && !isTableNotExistError(err) {
+		h.logger.Error(ctx, "Failed to list addon copies",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list addon copies"})
+		return
+	}
+	if copies == nil {
+		copies = []*types.EnvironmentAddonCopy{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"addon_copies": copies})
+}
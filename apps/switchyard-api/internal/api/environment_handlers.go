@@ -1,13 +1,14 @@
 package api
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/environments"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
@@ -20,6 +21,7 @@ func (h *Handler) CreateEnvironment(c *gin.Context) {
 	var req struct {
 		Name          string `json:"name" binding:"required"`
 		KubeNamespace string `json:"kube_namespace"`
+		Cluster       string `json:"cluster"` // Registered cluster; empty or "local" for the API's own
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -45,8 +47,7 @@ func (h *Handler) CreateEnvironment(c *gin.Context) {
 	// Use consistent pattern: enclii-{project_slug}-{env_name}
 	kubeNamespace := req.KubeNamespace
 	if kubeNamespace == "" {
-		envNameNormalized := strings.ToLower(strings.ReplaceAll(req.Name, "_", "-"))
-		kubeNamespace = fmt.Sprintf("enclii-%s-%s", projectSlug, envNameNormalized)
+		kubeNamespace = environments.Namespace(projectSlug, req.Name)
 	}
 
 	env := &types.Environment{
@@ -55,6 +56,16 @@ func (h *Handler) CreateEnvironment(c *gin.Context) {
 		KubeNamespace: kubeNamespace,
 	}
 
+	if req.Cluster != "" && req.Cluster != types.LocalClusterName {
+		cluster, err := h.repos.Clusters.GetByName(ctx, req.Cluster)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cluster not found"})
+			return
+		}
+		env.ClusterID = &cluster.ID
+		env.Cluster = cluster.Name
+	}
+
 	if err := h.repos.Environments.Create(env); err != nil {
 		h.logger.Error(ctx, "Failed to create environment",
 			logging.Error("error", err),
@@ -124,3 +135,60 @@ func (h *Handler) GetEnvironment(c *gin.Context) {
 
 	c.JSON(http.StatusOK, env)
 }
+
+// loadEnvironment loads the project and environment in the path. It
+// writes the error response and returns nil when they cannot be loaded.
+func (h *Handler) loadEnvironment(c *gin.Context) (*types.Project, *types.Environment) {
+	project, err := h.repos.Projects.GetBySlug(c.Param("slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return nil, nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return nil, nil
+	}
+
+	env, err := h.repos.Environments.GetByProjectAndName(project.ID, c.Param("env_name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+			return nil, nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get environment", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get environment"})
+		return nil, nil
+	}
+	return project, env
+}
+
+// auditEnvironment records a change to an environment
+func (h *Handler) auditEnvironment(c *gin.Context, project *types.Project, env *types.Environment, action string, details map[string]interface{}) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    email,
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		Action:        action,
+		ResourceType:  "environment",
+		ResourceID:    env.ID.String(),
+		ResourceName:  env.Name,
+		ProjectID:     &project.ID,
+		EnvironmentID: &env.ID,
+		Outcome:       "success",
+		Context:       details,
+	})
+}
@@ -3,13 +3,16 @@ package api
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/reconciler"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -55,7 +58,7 @@ func (h *Handler) ListEnvVars(c *gin.Context) {
 	}
 
 	// Verify service exists
-	_, err = h.repos.Services.GetByID(svcID)
+	service, err := h.repos.Services.GetByID(svcID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
@@ -70,20 +73,33 @@ func (h *Handler) ListEnvVars(c *gin.Context) {
 			return
 		}
 		envID = &parsed
+
+		if !h.authorizeEnvironment(c, service.ProjectID, envID) {
+			return
+		}
 	}
 
 	// Get env vars
 	envVars, err := h.repos.EnvVars.List(ctx, svcID, envID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.Error(ctx, "Failed to list env vars", logging.String("service_id", serviceID), logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list environment variables"})
 		return
 	}
 
-	// Convert to response format (mask secrets)
-	response := make([]types.EnvironmentVariableResponse, len(envVars))
-	for i, ev := range envVars {
-		response[i] = toEnvVarResponse(ev)
+	// Convert to response format (mask secrets), leaving out variables of
+	// environments a bot token was not granted
+	scope := auth.TokenScopeFromContext(c)
+	response := make([]types.EnvironmentVariableResponse, 0, len(envVars))
+	for _, ev := range envVars {
+		if scope != nil && !scope.Allows(service.ProjectID, ev.EnvironmentID) {
+			continue
+		}
+		response = append(response, toEnvVarResponse(ev))
 	}
 
 	c.JSON(http.StatusOK, gin.H{"environment_variables": response})
@@ -103,7 +119,7 @@ func (h *Handler) CreateEnvVar(c *gin.Context) {
 	}
 
 	// Verify service exists
-	_, err = h.repos.Services.GetByID(svcID)
+	service, err := h.repos.Services.GetByID(svcID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
@@ -139,6 +155,10 @@ func (h *Handler) CreateEnvVar(c *gin.Context) {
 		}
 	}
 
+	if !h.authorizeEnvironment(c, service.ProjectID, envID) {
+		return
+	}
+
 	// Get user info from context
 	userID := c.GetString("user_id")
 	userEmail := c.GetString("user_email")
@@ -165,6 +185,10 @@ func (h *Handler) CreateEnvVar(c *gin.Context) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Environment variable with this key already exists"})
 			return
 		}
+		if isEncryptionKeyUnavailable(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error(ctx, "Failed to create env var", logging.String("service_id", serviceID), logging.String("key", req.Key), logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create environment variable"})
 		return
@@ -183,6 +207,7 @@ func (h *Handler) CreateEnvVar(c *gin.Context) {
 		ActorIP:       c.ClientIP(),
 		UserAgent:     c.GetHeader("User-Agent"),
 	})
+	h.recordEnvVarChange(c, svcID, envID, "created", []string{ev.Key}, &ev.ID)
 
 	c.JSON(http.StatusCreated, toEnvVarResponse(ev))
 }
@@ -202,12 +227,20 @@ func (h *Handler) GetEnvVar(c *gin.Context) {
 
 	// Get env var
 	ev, err := h.repos.EnvVars.GetByID(ctx, evID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Environment variable not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, toEnvVarResponse(ev))
+	if !h.authorizeServiceEnvironment(c, ev.ServiceID, ev.EnvironmentID) {
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, envVarETag(ev), toEnvVarResponse(ev))
 }
 
 // UpdateEnvVar updates an environment variable
@@ -225,11 +258,22 @@ func (h *Handler) UpdateEnvVar(c *gin.Context) {
 
 	// Get existing env var
 	ev, err := h.repos.EnvVars.GetByID(ctx, evID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Environment variable not found"})
 		return
 	}
 
+	if !h.authorizeServiceEnvironment(c, ev.ServiceID, ev.EnvironmentID) {
+		return
+	}
+	if !checkPreconditions(c, envVarETag(ev)) {
+		return
+	}
+
 	oldValueHash := hashValue(ev.Value)
 
 	var req UpdateEnvVarRequest
@@ -258,6 +302,10 @@ func (h *Handler) UpdateEnvVar(c *gin.Context) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Environment variable with this key already exists"})
 			return
 		}
+		if isEncryptionKeyUnavailable(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Error(ctx, "Failed to update env var", logging.String("var_id", varID), logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update environment variable"})
 		return
@@ -286,7 +334,9 @@ func (h *Handler) UpdateEnvVar(c *gin.Context) {
 		ActorIP:       c.ClientIP(),
 		UserAgent:     c.GetHeader("User-Agent"),
 	})
+	h.recordEnvVarChange(c, ev.ServiceID, ev.EnvironmentID, "updated", []string{ev.Key}, &ev.ID)
 
+	c.Header("ETag", envVarETag(ev))
 	c.JSON(http.StatusOK, toEnvVarResponse(ev))
 }
 
@@ -305,11 +355,22 @@ func (h *Handler) DeleteEnvVar(c *gin.Context) {
 
 	// Get existing env var for audit log
 	ev, err := h.repos.EnvVars.GetByID(ctx, evID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Environment variable not found"})
 		return
 	}
 
+	if !h.authorizeServiceEnvironment(c, ev.ServiceID, ev.EnvironmentID) {
+		return
+	}
+	if !checkPreconditions(c, envVarETag(ev)) {
+		return
+	}
+
 	if err := h.repos.EnvVars.Delete(ctx, evID); err != nil {
 		h.logger.Error(ctx, "Failed to delete env var", logging.String("var_id", varID), logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete environment variable"})
@@ -338,6 +399,7 @@ func (h *Handler) DeleteEnvVar(c *gin.Context) {
 		ActorIP:       c.ClientIP(),
 		UserAgent:     c.GetHeader("User-Agent"),
 	})
+	h.recordEnvVarChange(c, ev.ServiceID, ev.EnvironmentID, "deleted", []string{ev.Key}, &ev.ID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Environment variable deleted"})
 }
@@ -356,7 +418,7 @@ func (h *Handler) BulkUpsertEnvVars(c *gin.Context) {
 	}
 
 	// Verify service exists
-	_, err = h.repos.Services.GetByID(svcID)
+	service, err := h.repos.Services.GetByID(svcID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
@@ -385,6 +447,10 @@ func (h *Handler) BulkUpsertEnvVars(c *gin.Context) {
 		envID = &parsed
 	}
 
+	if !h.authorizeEnvironment(c, service.ProjectID, envID) {
+		return
+	}
+
 	// Validate all keys
 	for _, v := range req.Variables {
 		if !isValidEnvVarKey(v.Key) {
@@ -420,6 +486,12 @@ func (h *Handler) BulkUpsertEnvVars(c *gin.Context) {
 		return
 	}
 
+	keys := make([]string, len(vars))
+	for i, v := range vars {
+		keys[i] = v.Key
+	}
+	h.recordEnvVarChange(c, svcID, envID, "updated", keys, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Environment variables created/updated",
 		"count":   len(vars),
@@ -441,11 +513,31 @@ func (h *Handler) RevealEnvVar(c *gin.Context) {
 
 	// Get env var
 	ev, err := h.repos.EnvVars.GetByID(ctx, evID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Environment variable not found"})
 		return
 	}
 
+	if !h.authorizeServiceEnvironment(c, ev.ServiceID, ev.EnvironmentID) {
+		return
+	}
+
+	if ev.IsSecret {
+		service, err := h.repos.Services.GetByID(ev.ServiceID)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to get service", logging.Error("db_error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get service"})
+			return
+		}
+		if !h.requireTwoFactor(c, &service.ProjectID, auth.SensitiveSecretRead) {
+			return
+		}
+	}
+
 	// Get user info
 	userID := c.GetString("user_id")
 	userEmail := c.GetString("user_email")
@@ -523,6 +615,46 @@ func isValidEnvVarKey(key string) bool {
 	return true
 }
 
+// recordEnvVarChange adds a change of env vars to the event history of the
+// service's project. Only keys are recorded, never values.
+func (h *Handler) recordEnvVarChange(c *gin.Context, serviceID uuid.UUID, environmentID *uuid.UUID, action string, keys []string, envVarID *uuid.UUID) {
+	if h.notificationService == nil || len(keys) == 0 {
+		return
+	}
+	ctx := c.Request.Context()
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get service for env var event", logging.Error("error", err))
+		return
+	}
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		actorID = &parsed
+	}
+
+	data := map[string]any{"action": action, "keys": keys, "service_id": service.ID, "service_name": service.Name}
+	if environmentID != nil {
+		data["environment_id"] = *environmentID
+	}
+
+	subject := keys[0]
+	if len(keys) > 1 {
+		subject = fmt.Sprintf("%d env vars", len(keys))
+	}
+	h.notificationService.RecordEvent(ctx, &types.ProjectEvent{
+		ProjectID:    service.ProjectID,
+		Type:         types.ProjectEventEnvVarChanged,
+		ResourceType: "env_var",
+		ResourceID:   envVarID,
+		ActorID:      actorID,
+		ActorEmail:   c.GetString("user_email"),
+		Message:      fmt.Sprintf("%s %s on %s", subject, action, service.Name),
+		Data:         data,
+	})
+}
+
 // hashValue creates a SHA-256 hash for audit logging
 func hashValue(value string) string {
 	h := sha256.New()
@@ -578,6 +710,10 @@ func (h *Handler) SyncEnvVarsFromPod(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeEnvironment(c, service.ProjectID, &envID) {
+		return
+	}
+
 	// Check if reconciler has k8s client
 	if h.reconciler == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Kubernetes client not available"})
@@ -597,12 +733,9 @@ func (h *Handler) SyncEnvVarsFromPod(c *gin.Context) {
 
 	// Filter to only ENCLII_ prefixed vars (application config)
 	// and exclude some internal ones
-	excludeKeys := map[string]bool{
-		"ENCLII_SERVICE_NAME":    true, // Set by reconciler
-		"ENCLII_PROJECT_ID":      true, // Set by reconciler
-		"ENCLII_RELEASE_VERSION": true, // Set by reconciler
-		"ENCLII_DEPLOYMENT_ID":   true, // Set by reconciler
-		"PORT":                   true, // Set by reconciler
+	excludeKeys := make(map[string]bool)
+	for _, key := range reconciler.ReconcilerEnvVarKeys {
+		excludeKeys[key] = true
 	}
 
 	// Get user info for audit
@@ -676,6 +809,8 @@ func (h *Handler) SyncEnvVarsFromPod(c *gin.Context) {
 		logging.Int("updated", updated),
 		logging.Int("skipped", skipped))
 
+	h.recordEnvVarChange(c, svcID, &envID, "synced", syncedVars, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "Environment variables synced successfully",
 		"created":     created,
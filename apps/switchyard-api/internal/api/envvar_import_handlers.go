@@ -0,0 +1,352 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// maxEnvImportBytes caps the size of an imported dotenv or JSON payload
+	maxEnvImportBytes = 1 << 20
+
+	// maxEnvImportVars caps the number of variables of one import
+	maxEnvImportVars = 500
+)
+
+// Conflict strategies of an env var import
+const (
+	envImportSkip      = "skip"
+	envImportOverwrite = "overwrite"
+)
+
+// ExportEnvVars returns the environment variables of a service as a dotenv
+// file or a JSON object. With environment_id it returns the variables the
+// environment's deployments get, otherwise the ones of all environments.
+// Secret values are left out unless include_secrets is set, which needs the
+// env var reveal permission and is audited per secret.
+// GET /v1/services/:id/env/export
+func (h *Handler) ExportEnvVars(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID := c.Param("id")
+
+	svcID, err := uuid.Parse(serviceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(svcID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "dotenv")
+	if format != "dotenv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be dotenv or json"})
+		return
+	}
+
+	var envID *uuid.UUID
+	if envIDStr := c.Query("environment_id"); envIDStr != "" {
+		parsed, err := uuid.Parse(envIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid environment ID"})
+			return
+		}
+		envID = &parsed
+	}
+
+	if !h.authorizeEnvironment(c, service.ProjectID, envID) {
+		return
+	}
+
+	includeSecrets := c.Query("include_secrets") == "true"
+	if includeSecrets {
+		userRole, _ := c.Get("user_role")
+		if !auth.HasPermission(auth.Role(fmt.Sprintf("%v", userRole)), auth.PermissionEnvVarReveal) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Exporting secret values requires permission to reveal them"})
+			return
+		}
+		if !h.requireTwoFactor(c, &service.ProjectID, auth.SensitiveSecretRead) {
+			return
+		}
+	}
+
+	envVars, err := h.repos.EnvVars.List(ctx, svcID, envID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list env vars for export", logging.String("service_id", serviceID), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list environment variables"})
+		return
+	}
+
+	// Variables of the environment override the ones of all environments
+	effective := make(map[string]*types.EnvironmentVariable)
+	for _, ev := range envVars {
+		if envID == nil && ev.EnvironmentID != nil {
+			continue
+		}
+		if existing, ok := effective[ev.Key]; ok && existing.EnvironmentID != nil {
+			continue
+		}
+		effective[ev.Key] = ev
+	}
+
+	entries := make([]dotenvEntry, 0, len(effective))
+	excluded := []string{}
+	var exported []*types.EnvironmentVariable
+	for _, ev := range effective {
+		if ev.IsSecret && !includeSecrets {
+			excluded = append(excluded, ev.Key)
+			continue
+		}
+		if ev.IsSecret {
+			exported = append(exported, ev)
+		}
+		entries = append(entries, dotenvEntry{Key: ev.Key, Value: ev.Value})
+	}
+	sort.Strings(excluded)
+
+	if len(exported) > 0 {
+		userID := c.GetString("user_id")
+		userEmail := c.GetString("user_email")
+		var actorID *uuid.UUID
+		if userID != "" {
+			parsed, _ := uuid.Parse(userID)
+			actorID = &parsed
+		}
+
+		for _, ev := range exported {
+			h.repos.EnvVars.LogAudit(ctx, &types.EnvVarAuditLog{
+				EnvVarID:      ev.ID,
+				ServiceID:     ev.ServiceID,
+				EnvironmentID: ev.EnvironmentID,
+				Action:        "exported",
+				Key:           ev.Key,
+				ActorID:       actorID,
+				ActorEmail:    userEmail,
+				ActorIP:       c.ClientIP(),
+				UserAgent:     c.GetHeader("User-Agent"),
+			})
+		}
+	}
+
+	if format == "json" {
+		variables := make(map[string]string, len(entries))
+		for _, e := range entries {
+			variables[e.Key] = e.Value
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"service_id":       svcID,
+			"environment_id":   envID,
+			"variables":        variables,
+			"secrets_excluded": excluded,
+		})
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(formatDotenv(entries))
+	for _, key := range excluded {
+		fmt.Fprintf(&b, "# %s (secret, not exported)\n", key)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.env"`, service.Name))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(b.String()))
+}
+
+// ImportEnvVars creates or updates the environment variables of a service
+// from a dotenv file, or from a JSON object of keys to values when sent as
+// application/json. Keys that already exist are skipped unless strategy is
+// overwrite; overwritten variables stay secret or plain as they were, new
+// ones are secret when their key looks sensitive.
+// POST /v1/services/:id/env/import
+func (h *Handler) ImportEnvVars(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID := c.Param("id")
+
+	svcID, err := uuid.Parse(serviceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(svcID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	strategy := c.DefaultQuery("strategy", envImportSkip)
+	if strategy != envImportSkip && strategy != envImportOverwrite {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strategy must be skip or overwrite"})
+		return
+	}
+
+	var envID *uuid.UUID
+	if envIDStr := c.Query("environment_id"); envIDStr != "" {
+		parsed, err := uuid.Parse(envIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid environment ID"})
+			return
+		}
+		envID = &parsed
+
+		if _, err := h.repos.Environments.GetByID(ctx, parsed); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+			return
+		}
+	}
+
+	if !h.authorizeEnvironment(c, service.ProjectID, envID) {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxEnvImportBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	if len(body) > maxEnvImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import is larger than 1MiB"})
+		return
+	}
+
+	entries, err := parseEnvImport(c.ContentType(), body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No environment variables to import"})
+		return
+	}
+	if len(entries) > maxEnvImportVars {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Maximum %d variables can be imported at once", maxEnvImportVars)})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	userEmail := c.GetString("user_email")
+	var actorID *uuid.UUID
+	if userID != "" {
+		parsed, _ := uuid.Parse(userID)
+		actorID = &parsed
+	}
+
+	created, updated, skipped := []string{}, []string{}, []string{}
+	for _, e := range entries {
+		existing, err := h.repos.EnvVars.GetByServiceEnvKey(ctx, svcID, envID, e.Key)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			if isEncryptionKeyUnavailable(err) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+			h.logger.Error(ctx, "Failed to get env var for import", logging.String("service_id", serviceID), logging.String("key", e.Key), logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import environment variables"})
+			return
+		}
+
+		audit := &types.EnvVarAuditLog{
+			ServiceID:     svcID,
+			EnvironmentID: envID,
+			Key:           e.Key,
+			NewValueHash:  hashValue(e.Value),
+			ActorID:       actorID,
+			ActorEmail:    userEmail,
+			ActorIP:       c.ClientIP(),
+			UserAgent:     c.GetHeader("User-Agent"),
+		}
+
+		if existing != nil {
+			if strategy == envImportSkip || existing.Value == e.Value {
+				skipped = append(skipped, e.Key)
+				continue
+			}
+			audit.OldValueHash = hashValue(existing.Value)
+			existing.Value = e.Value
+			err = h.repos.EnvVars.Update(ctx, existing)
+			audit.EnvVarID, audit.Action = existing.ID, "updated"
+		} else {
+			ev := &types.EnvironmentVariable{
+				ServiceID:      svcID,
+				EnvironmentID:  envID,
+				Key:            e.Key,
+				Value:          e.Value,
+				IsSecret:       isSensitiveKey(e.Key),
+				CreatedBy:      actorID,
+				CreatedByEmail: userEmail,
+			}
+			err = h.repos.EnvVars.Create(ctx, ev)
+			audit.EnvVarID, audit.Action = ev.ID, "created"
+		}
+		if isEncryptionKeyUnavailable(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			h.logger.Error(ctx, "Failed to import env var", logging.String("service_id", serviceID), logging.String("key", e.Key), logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import " + e.Key})
+			return
+		}
+
+		h.repos.EnvVars.LogAudit(ctx, audit)
+		if audit.Action == "created" {
+			created = append(created, e.Key)
+		} else {
+			updated = append(updated, e.Key)
+		}
+	}
+
+	h.recordEnvVarChange(c, svcID, envID, "imported", append(append([]string{}, created...), updated...), nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Environment variables imported",
+		"strategy":     strategy,
+		"created":      len(created),
+		"updated":      len(updated),
+		"skipped":      len(skipped),
+		"created_keys": created,
+		"updated_keys": updated,
+		"skipped_keys": skipped,
+	})
+}
+
+// parseEnvImport parses an import payload: a JSON object of keys to string
+// values when contentType is application/json, dotenv text otherwise
+func parseEnvImport(contentType string, body []byte) ([]dotenvEntry, error) {
+	if contentType != "application/json" {
+		return parseDotenv(string(body))
+	}
+
+	var variables map[string]string
+	if err := json.Unmarshal(body, &variables); err != nil {
+		return nil, fmt.Errorf("expected a JSON object of keys to string values: %w", err)
+	}
+
+	entries := make([]dotenvEntry, 0, len(variables))
+	for key, value := range variables {
+		if !isValidEnvVarKey(key) {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+		entries = append(entries, dotenvEntry{Key: key, Value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
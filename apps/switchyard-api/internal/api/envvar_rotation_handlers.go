@@ -0,0 +1,436 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/secretrotation"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SetEnvVarRotationRequest marks a secret as rotatable
+type SetEnvVarRotationRequest struct {
+	MaxAgeDays *int `json:"max_age_days"` // Remind once the value is older (no reminders if nil)
+}
+
+// StartEnvVarRotationRequest stages a new value for a rotatable secret
+type StartEnvVarRotationRequest struct {
+	Value    string `json:"value" binding:"required"`
+	Redeploy *bool  `json:"redeploy"` // Restart running deployments (default true)
+}
+
+// FinishEnvVarRotationRequest finalizes or aborts a rotation
+type FinishEnvVarRotationRequest struct {
+	Redeploy *bool `json:"redeploy"` // Restart running deployments (default true)
+}
+
+// rotationRedeploy is the restart of a service in one environment after a
+// rotation step
+type rotationRedeploy struct {
+	Environment  string     `json:"environment"`
+	GroupID      *uuid.UUID `json:"group_id,omitempty"`
+	DeploymentID *uuid.UUID `json:"deployment_id,omitempty"`
+	Status       string     `json:"status"` // queued, failed
+	Message      string     `json:"message,omitempty"`
+}
+
+// ListEnvVarRotations lists the rotatable secrets of a service
+// GET /v1/services/:id/env-vars/rotations
+func (h *Handler) ListEnvVarRotations(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID := c.Param("id")
+
+	svcID, err := uuid.Parse(serviceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(svcID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	if !h.authorizeEnvironment(c, service.ProjectID, nil) {
+		return
+	}
+
+	rotations, err := h.repos.EnvVars.ListRotations(ctx, svcID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list env var rotations", logging.String("service_id", serviceID), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list rotations"})
+		return
+	}
+	if rotations == nil {
+		rotations = []*types.EnvVarRotation{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rotations": rotations})
+}
+
+// SetEnvVarRotation marks a secret as rotatable, or changes its max age
+// PUT /v1/services/:id/env-vars/:var_id/rotation
+func (h *Handler) SetEnvVarRotation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ev, ok := h.loadRotationEnvVar(c)
+	if !ok {
+		return
+	}
+	if !ev.IsSecret {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only secrets can be rotated"})
+		return
+	}
+
+	var req SetEnvVarRotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.MaxAgeDays != nil && (*req.MaxAgeDays < 1 || *req.MaxAgeDays > secretrotation.MaxMaxAgeDays) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("max_age_days must be between 1 and %d", secretrotation.MaxMaxAgeDays)})
+		return
+	}
+
+	if err := h.repos.EnvVars.SetRotatable(ctx, ev.ID, req.MaxAgeDays); err != nil {
+		h.logger.Error(ctx, "Failed to mark env var rotatable", logging.String("var_id", ev.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rotation"})
+		return
+	}
+
+	rotation, err := h.repos.EnvVars.GetRotation(ctx, ev.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get env var rotation", logging.String("var_id", ev.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rotation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rotation)
+}
+
+// DeleteEnvVarRotation makes a secret no longer rotatable
+// DELETE /v1/services/:id/env-vars/:var_id/rotation
+func (h *Handler) DeleteEnvVarRotation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ev, ok := h.loadRotationEnvVar(c)
+	if !ok {
+		return
+	}
+
+	err := h.repos.EnvVars.UnsetRotatable(ctx, ev.ID)
+	if errors.Is(err, db.ErrRotationInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Finalize or abort the rotation in progress first"})
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment variable is not rotatable"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to unset env var rotation", logging.String("var_id", ev.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rotation"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// StartEnvVarRotation stages a new value for a rotatable secret. Until the
+// rotation is finalized or aborted, deployments get the old value as
+// KEY_CURRENT and the new one as KEY_NEXT next to KEY, which keeps the old
+// value, so both can be accepted while consumers switch over.
+// POST /v1/services/:id/env-vars/:var_id/rotation/start
+func (h *Handler) StartEnvVarRotation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ev, ok := h.loadRotationEnvVar(c)
+	if !ok {
+		return
+	}
+
+	var req StartEnvVarRotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Value == ev.Value {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The new value is the same as the current one"})
+		return
+	}
+
+	if _, err := h.repos.EnvVars.GetRotation(ctx, ev.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Environment variable is not rotatable"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get env var rotation", logging.String("var_id", ev.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rotation"})
+		return
+	}
+
+	// The suffixed names must be free, or deployments would not see both values
+	existing, err := h.repos.EnvVars.List(ctx, ev.ServiceID, nil)
+	if err != nil && !isEncryptionKeyUnavailable(err) {
+		h.logger.Error(ctx, "Failed to list env vars", logging.String("service_id", ev.ServiceID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list environment variables"})
+		return
+	}
+	for _, other := range existing {
+		if other.Key == ev.Key+db.RotationCurrentSuffix || other.Key == ev.Key+db.RotationNextSuffix {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s already exists and would hide a value of the rotation", other.Key)})
+			return
+		}
+	}
+
+	userEmail := c.GetString("user_email")
+	err = h.repos.EnvVars.StageRotation(ctx, ev, req.Value, userEmail)
+	if errors.Is(err, db.ErrRotationInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to stage env var rotation", logging.String("var_id", ev.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start rotation"})
+		return
+	}
+
+	h.logRotationAudit(c, ev, "rotation_started", hashValue(ev.Value), hashValue(req.Value))
+	h.recordEnvVarChange(c, ev.ServiceID, ev.EnvironmentID, "staged for rotation", []string{ev.Key}, &ev.ID)
+
+	h.respondRotation(c, ev, req.Redeploy)
+}
+
+// FinalizeEnvVarRotation makes the staged value of a secret its value and
+// stops exposing the suffixed names
+// POST /v1/services/:id/env-vars/:var_id/rotation/finalize
+func (h *Handler) FinalizeEnvVarRotation(c *gin.Context) {
+	h.finishEnvVarRotation(c, true)
+}
+
+// AbortEnvVarRotation discards the staged value of a secret and stops
+// exposing the suffixed names
+// POST /v1/services/:id/env-vars/:var_id/rotation/abort
+func (h *Handler) AbortEnvVarRotation(c *gin.Context) {
+	h.finishEnvVarRotation(c, false)
+}
+
+// finishEnvVarRotation finalizes or aborts the rotation of a secret
+func (h *Handler) finishEnvVarRotation(c *gin.Context, finalize bool) {
+	ctx := c.Request.Context()
+
+	ev, ok := h.loadRotationEnvVar(c)
+	if !ok {
+		return
+	}
+
+	var req FinishEnvVarRotationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	rotation, err := h.repos.EnvVars.GetRotation(ctx, ev.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusConflict, gin.H{"error": db.ErrNoRotationInProgress.Error()})
+		return
+	}
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get env var rotation", logging.String("var_id", ev.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rotation"})
+		return
+	}
+
+	action, change := "rotation_aborted", "rotation aborted"
+	if finalize {
+		action, change = "rotation_finalized", "rotated"
+		err = h.repos.EnvVars.FinalizeRotation(ctx, ev.ID)
+	} else {
+		err = h.repos.EnvVars.AbortRotation(ctx, ev.ID)
+	}
+	if errors.Is(err, db.ErrNoRotationInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to finish env var rotation", logging.String("var_id", ev.ID.String()), logging.String("action", action), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finish rotation"})
+		return
+	}
+
+	// An aborted rotation leaves the value as it was
+	newValueHash := hashValue(ev.Value)
+	if finalize {
+		newValueHash = hashValue(rotation.PendingValue)
+	}
+	h.logRotationAudit(c, ev, action, hashValue(ev.Value), newValueHash)
+	h.recordEnvVarChange(c, ev.ServiceID, ev.EnvironmentID, change, []string{ev.Key}, &ev.ID)
+
+	h.respondRotation(c, ev, req.Redeploy)
+}
+
+// loadRotationEnvVar loads the env var of a rotation request and checks it
+// belongs to the service of the path
+func (h *Handler) loadRotationEnvVar(c *gin.Context) (*types.EnvironmentVariable, bool) {
+	ctx := c.Request.Context()
+
+	svcID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return nil, false
+	}
+	evID, err := uuid.Parse(c.Param("var_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variable ID"})
+		return nil, false
+	}
+
+	ev, err := h.repos.EnvVars.GetByID(ctx, evID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil || ev.ServiceID != svcID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment variable not found"})
+		return nil, false
+	}
+
+	if !h.authorizeServiceEnvironment(c, ev.ServiceID, ev.EnvironmentID) {
+		return nil, false
+	}
+	return ev, true
+}
+
+// logRotationAudit records a rotation step in the env var audit log
+func (h *Handler) logRotationAudit(c *gin.Context, ev *types.EnvironmentVariable, action, oldValueHash, newValueHash string) {
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.EnvVars.LogAudit(c.Request.Context(), &types.EnvVarAuditLog{
+		EnvVarID:      ev.ID,
+		ServiceID:     ev.ServiceID,
+		EnvironmentID: ev.EnvironmentID,
+		Action:        action,
+		Key:           ev.Key,
+		OldValueHash:  oldValueHash,
+		NewValueHash:  newValueHash,
+		ActorID:       actorID,
+		ActorEmail:    c.GetString("user_email"),
+		ActorIP:       c.ClientIP(),
+		UserAgent:     c.GetHeader("User-Agent"),
+	})
+}
+
+// respondRotation restarts the service unless redeploy is false, and
+// responds with the rotation and the restarts
+func (h *Handler) respondRotation(c *gin.Context, ev *types.EnvironmentVariable, redeploy *bool) {
+	ctx := c.Request.Context()
+
+	rotation, err := h.repos.EnvVars.GetRotation(ctx, ev.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get env var rotation", logging.String("var_id", ev.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rotation"})
+		return
+	}
+
+	redeploys := []rotationRedeploy{}
+	if redeploy == nil || *redeploy {
+		redeploys = h.redeployForRotation(c, ev)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rotation": rotation, "redeploys": redeploys})
+}
+
+// redeployForRotation restarts the service of a rotated env var in every
+// environment the variable applies to and the service is running in, so
+// its pods pick up the new values
+func (h *Handler) redeployForRotation(c *gin.Context, ev *types.EnvironmentVariable) []rotationRedeploy {
+	ctx := c.Request.Context()
+	redeploys := []rotationRedeploy{}
+	if h.deploymentGroupService == nil {
+		return redeploys
+	}
+
+	service, err := h.repos.Services.GetByID(ev.ServiceID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get service for rotation redeploy", logging.Error("error", err))
+		return redeploys
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get project for rotation redeploy", logging.Error("error", err))
+		return redeploys
+	}
+
+	var environments []*types.Environment
+	if ev.EnvironmentID != nil {
+		env, err := h.repos.Environments.GetByID(ctx, *ev.EnvironmentID)
+		if err != nil {
+			h.logger.Warn(ctx, "Failed to get environment for rotation redeploy", logging.Error("error", err))
+			return redeploys
+		}
+		environments = append(environments, env)
+	} else {
+		environments, err = h.repos.Environments.ListByProject(service.ProjectID)
+		if err != nil {
+			h.logger.Warn(ctx, "Failed to list environments for rotation redeploy", logging.Error("error", err))
+			return redeploys
+		}
+	}
+
+	userRole, _ := c.Get("user_role")
+	for _, env := range environments {
+		// Only where the service runs, which also skips creating empty groups
+		if current, err := h.repos.Deployments.GetCurrentByServiceAndEnvironment(ctx, service.ID, env.ID); err != nil || current == nil {
+			continue
+		}
+
+		result, err := h.deploymentGroupService.ExecuteBulkOperation(ctx, &services.BulkOperationRequest{
+			ProjectSlug: project.Slug,
+			Environment: env.Name,
+			Action:      services.BulkActionRestart,
+			ServiceIDs:  []string{service.ID.String()},
+			UserID:      c.GetString("user_id"),
+			UserEmail:   c.GetString("user_email"),
+			UserRole:    fmt.Sprintf("%v", userRole),
+		})
+		if err != nil {
+			h.logger.Error(ctx, "Failed to redeploy for rotation",
+				logging.String("service_id", service.ID.String()),
+				logging.String("environment", env.Name),
+				logging.Error("error", err))
+			redeploys = append(redeploys, rotationRedeploy{Environment: env.Name, Status: services.BulkResultFailed, Message: err.Error()})
+			continue
+		}
+
+		redeploy := rotationRedeploy{Environment: env.Name, GroupID: &result.Group.ID, Status: services.BulkResultQueued}
+		if len(result.Results) > 0 {
+			redeploy.DeploymentID = result.Results[0].DeploymentID
+			redeploy.Status = result.Results[0].Status
+			redeploy.Message = result.Results[0].Message
+		}
+		redeploys = append(redeploys, redeploy)
+	}
+	return redeploys
+}
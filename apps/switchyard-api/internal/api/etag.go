@@ -0,0 +1,109 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ETags version the configurable fields of a resource, so status and usage
+// updates (health, metrics, verification) do not invalidate a client's copy.
+// Clients send them back in If-Match to make sure they change what they read.
+
+// resourceETag derives a strong ETag from the fields that version a resource
+func resourceETag(fields ...interface{}) string {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// projectETag versions a project
+func projectETag(p *types.Project) string {
+	return resourceETag(p.ID, p.Name, p.Slug, p.PreviewTTLDays, p.LogRedactionDisabled)
+}
+
+// serviceETag versions a service. No labels and an empty label set are the
+// same version.
+func serviceETag(s *types.Service) string {
+	labels := s.Labels
+	if len(labels) == 0 {
+		labels = nil
+	}
+	return resourceETag(s.ID, s.ProjectID, s.Name, s.GitRepo, s.AppPath, s.BuildConfig,
+		s.AutoDeploy, s.AutoDeployBranch, s.AutoDeployEnv, s.AutoDeployRules, labels, s.Scheduling, s.GPU, s.Command, s.Shutdown, s.HighAvailability)
+}
+
+// envVarETag versions an environment variable. The value only enters as a
+// hash, so the ETag of a secret does not reveal it.
+func envVarETag(ev *types.EnvironmentVariable) string {
+	return resourceETag(ev.ID, ev.ServiceID, ev.EnvironmentID, ev.Key, hashValue(ev.Value), ev.IsSecret)
+}
+
+// domainETag versions a custom domain
+func domainETag(d *types.CustomDomain) string {
+	return resourceETag(d.ID, d.ServiceID, d.EnvironmentID, d.Domain, d.TLSEnabled, d.TLSIssuer, d.DNSMode)
+}
+
+// addonETag versions a database addon
+func addonETag(a *types.DatabaseAddon) string {
+	return resourceETag(a.ID, a.ProjectID, a.EnvironmentID, a.Type, a.Name, a.Config)
+}
+
+// checkPreconditions evaluates the If-Match and If-None-Match headers of a
+// write against the current ETag of the resource, "" when it does not exist.
+// It writes 412 Precondition Failed and returns false when one fails.
+func checkPreconditions(c *gin.Context, current string) bool {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !etagMatches(ifMatch, current) {
+		preconditionFailed(c, current, "Resource was changed since it was read")
+		return false
+	}
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, current) {
+		preconditionFailed(c, current, "Resource already exists")
+		return false
+	}
+	return true
+}
+
+// respondWithETag writes a resource with its ETag. A GET whose If-None-Match
+// still matches is answered with 304 Not Modified.
+func respondWithETag(c *gin.Context, status int, etag string, body interface{}) {
+	c.Header("ETag", etag)
+	if c.Request.Method == http.MethodGet && etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(status, body)
+}
+
+// etagMatches reports whether a comma-separated If-Match or If-None-Match
+// header lists the current ETag. "*" matches any existing resource.
+func etagMatches(header, current string) bool {
+	if current == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+func preconditionFailed(c *gin.Context, current, message string) {
+	body := gin.H{"error": "Precondition failed", "message": message}
+	if current != "" {
+		c.Header("ETag", current)
+		body["etag"] = current
+	}
+	c.JSON(http.StatusPreconditionFailed, body)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		current string
+		want    bool
+	}{
+		{"exact", `"abc"`, `"abc"`, true},
+		{"list", `"xyz", "abc"`, `"abc"`, true},
+		{"weak", `W/"abc"`, `"abc"`, true},
+		{"stale", `"xyz"`, `"abc"`, false},
+		{"wildcard", `*`, `"abc"`, true},
+		{"wildcard without resource", `*`, "", false},
+		{"empty header", "", `"abc"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.header, tt.current); got != tt.want {
+				t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.header, tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceETagIgnoresEmptyLabels(t *testing.T) {
+	svc := &types.Service{Name: "api"}
+	withLabels := &types.Service{Name: "api", Labels: map[string]string{}}
+	if serviceETag(svc) != serviceETag(withLabels) {
+		t.Error("expected nil and empty labels to have the same ETag")
+	}
+
+	withLabels.Labels["team"] = "core"
+	if serviceETag(svc) == serviceETag(withLabels) {
+		t.Error("expected a label change to change the ETag")
+	}
+}
+
+func TestAddonConfigChanges(t *testing.T) {
+	current := types.DatabaseAddonConfig{Version: "16", StorageGB: 10, CPU: "500m", Memory: "1Gi"}
+
+	resize, immutable := addonConfigChanges(current, types.DatabaseAddonConfig{StorageGB: 10})
+	if resize != nil || len(immutable) != 0 {
+		t.Errorf("unchanged config: got resize %+v, immutable %v", resize, immutable)
+	}
+
+	resize, immutable = addonConfigChanges(current, types.DatabaseAddonConfig{StorageGB: 20, Memory: "1Gi"})
+	if resize == nil || resize.StorageGB == nil || *resize.StorageGB != 20 || resize.Memory != nil {
+		t.Errorf("storage change: got resize %+v", resize)
+	}
+	if len(immutable) != 0 {
+		t.Errorf("storage change: got immutable %v", immutable)
+	}
+
+	_, immutable = addonConfigChanges(current, types.DatabaseAddonConfig{Version: "15", PITREnabled: true})
+	if want := []string{"version", "pitr_enabled"}; !reflect.DeepEqual(immutable, want) {
+		t.Errorf("immutable = %v, want %v", immutable, want)
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/events"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// eventStreamHeartbeat keeps idle SSE connections alive through proxies (Cloudflare drops at ~100s)
+const eventStreamHeartbeat = 15 * time.Second
+
+// StreamEvents pushes deployment, build, and addon status changes of a
+// project over Server-Sent Events. Only events of the environments the
+// caller may access are sent.
+// GET /v1/events/stream?project_id=...&types=deployment,build,addon
+func (h *Handler) StreamEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if h.eventBroker == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event streaming is not configured"})
+		return
+	}
+
+	projectIDStr := c.Query("project_id")
+	if projectIDStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project_id is required"})
+		return
+	}
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	if _, err := h.repos.Projects.GetByID(ctx, projectID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	access, err := h.callerAccess(c)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list project access", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authorize event stream"})
+		return
+	}
+	if !access.Allows(projectID, nil) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this project"})
+		return
+	}
+	scope := auth.TokenScopeFromContext(c)
+
+	filter := events.Filter{ProjectID: &projectID}
+	if typesParam := c.Query("types"); typesParam != "" {
+		for _, t := range strings.Split(typesParam, ",") {
+			switch rt := events.ResourceType(strings.TrimSpace(t)); rt {
+			case events.ResourceDeployment, events.ResourceBuild, events.ResourceAddon:
+				filter.ResourceTypes = append(filter.ResourceTypes, rt)
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown event type: %s", t)})
+				return
+			}
+		}
+	}
+
+	// The server-wide WriteTimeout would otherwise terminate long-lived streams
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn(ctx, "Failed to clear write deadline for event stream", logging.Error("error", err))
+	}
+
+	eventsCh, unsubscribe := h.eventBroker.Subscribe(filter)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "event: connected\ndata: {\"timestamp\":%q}\n\n", time.Now().Format(time.RFC3339))
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-eventsCh:
+			if !ok {
+				return
+			}
+			if !access.Allows(*event.ProjectID, event.EnvironmentID) ||
+				(scope != nil && !scope.Allows(*event.ProjectID, event.EnvironmentID)) {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				h.logger.Warn(ctx, "Failed to encode stream event", logging.Error("error", err))
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// publishBuildEvent emits a build status change for the dashboard event
+// stream, and sends it to the project's webhooks and event history
+func (h *Handler) publishBuildEvent(ctx context.Context, release *types.Release, status types.ReleaseStatus, message string) {
+	if h.eventBroker == nil && h.notificationService == nil {
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(release.ServiceID)
+
+	if h.eventBroker != nil {
+		event := events.NewStatusEvent(events.ResourceBuild, release.ID, string(status))
+		event.ServiceID = &release.ServiceID
+		event.Message = message
+		event.Data = map[string]any{"git_sha": release.GitSHA, "version": release.Version}
+		if err == nil {
+			event.ProjectID = &service.ProjectID
+		}
+		h.eventBroker.Publish(ctx, event)
+	}
+
+	if h.notificationService != nil && err == nil {
+		h.sendBuildNotification(ctx, service, release, status, message)
+	}
+}
+
+// sendBuildNotification sends the webhook event of a build status
+func (h *Handler) sendBuildNotification(ctx context.Context, service *types.Service, release *types.Release, status types.ReleaseStatus, message string) {
+	var eventType types.WebhookEventType
+	switch status {
+	case types.ReleaseStatusBuilding:
+		eventType = types.WebhookEventBuildStarted
+	case types.ReleaseStatusReady:
+		eventType = types.WebhookEventBuildSucceeded
+	case types.ReleaseStatusFailed:
+		eventType = types.WebhookEventBuildFailed
+	default:
+		return
+	}
+
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get project for build notification", logging.Error("error", err))
+		return
+	}
+
+	err = h.notificationService.SendEvent(ctx, project.ID, &types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		Timestamp: time.Now(),
+		ProjectID: project.ID,
+		Project: types.WebhookProjectInfo{
+			ID:   project.ID,
+			Name: project.Name,
+			Slug: project.Slug,
+		},
+		Build: &types.WebhookBuildInfo{
+			ID:          release.ID,
+			ServiceName: service.Name,
+			Status:      string(status),
+			CommitSHA:   release.GitSHA,
+			ImageTag:    release.Version,
+			Error:       message,
+		},
+	})
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to send build notification", logging.Error("error", err))
+	}
+}
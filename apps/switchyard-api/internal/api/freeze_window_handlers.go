@@ -0,0 +1,133 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+)
+
+// CreateFreezeWindowRequest holds back scheduled deployment groups of an
+// environment for a period
+type CreateFreezeWindowRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// ListFreezeWindows lists the current and upcoming freeze windows of an environment
+// GET /v1/projects/:slug/environments/:env_name/freeze-windows
+func (h *Handler) ListFreezeWindows(c *gin.Context) {
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	windows, err := h.repos.FreezeWindows.ListEndingAfter(ctx, env.ID, time.Now())
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list freeze windows",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list freeze windows"})
+		return
+	}
+	if windows == nil {
+		windows = []*db.FreezeWindow{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"freeze_windows": windows})
+}
+
+// CreateFreezeWindow adds a freeze window to an environment. Scheduled
+// deployment groups that respect freeze windows wait for it to end.
+// POST /v1/projects/:slug/environments/:env_name/freeze-windows
+func (h *Handler) CreateFreezeWindow(c *gin.Context) {
+	var req CreateFreezeWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at"})
+		return
+	}
+	if !req.EndsAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be in the future"})
+		return
+	}
+
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	window := &db.FreezeWindow{
+		EnvironmentID: env.ID,
+		StartsAt:      req.StartsAt.UTC(),
+		EndsAt:        req.EndsAt.UTC(),
+		Reason:        req.Reason,
+	}
+	if userEmail, ok := c.Get("user_email"); ok {
+		window.CreatedBy = fmt.Sprintf("%v", userEmail)
+	}
+
+	if err := h.repos.FreezeWindows.Create(ctx, window); err != nil {
+		h.logger.Error(ctx, "Failed to create freeze window",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create freeze window"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.freeze_window_created", map[string]interface{}{
+		"freeze_window_id": window.ID.String(),
+		"starts_at":        window.StartsAt,
+		"ends_at":          window.EndsAt,
+		"reason":           window.Reason,
+	})
+
+	c.JSON(http.StatusCreated, window)
+}
+
+// DeleteFreezeWindow removes a freeze window from an environment. Groups it
+// postponed keep their new time.
+// DELETE /v1/projects/:slug/environments/:env_name/freeze-windows/:window_id
+func (h *Handler) DeleteFreezeWindow(c *gin.Context) {
+	windowID, err := uuid.Parse(c.Param("window_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid freeze window ID"})
+		return
+	}
+
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.FreezeWindows.Delete(ctx, env.ID, windowID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Freeze window not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete freeze window",
+			logging.String("freeze_window_id", windowID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete freeze window"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.freeze_window_deleted", map[string]interface{}{
+		"freeze_window_id": windowID.String(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Freeze window deleted"})
+}
@@ -2,25 +2,44 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/audit"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildartifacts"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildcontext"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/builder"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/clients"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cmek"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/compliance"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/config"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/dns"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/environments"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/events"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/hosts"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/idle"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/jobs"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logshipping"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/middleware"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/projectspec"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/provenance"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/ratelimit"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/reconciler"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/retention"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/servicemetrics"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/statuspage"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/topology"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/trash"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/validation"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
@@ -38,8 +57,29 @@ type Handler struct {
 	domainSyncService      *services.DomainSyncService
 	tunnelRoutesService    services.TunnelRoutesManager
 	addonService           *addons.AddonService
+	encryptionKeyService   *cmek.Service
+	dnsService             *dns.Service
+	previewCleaner         *previews.Cleaner
+	trash                  *trash.Service
+	quotaSyncer            *quota.Syncer
+	rateLimitPolicies      *ratelimit.Policies
+	previewDatabases       *previews.Databases
+	imageRegistry          *previews.RegistryClient
+	clusterClients         *k8s.ClientPool
+	logSearch              *logshipping.LokiClient
+	serviceMetrics         *servicemetrics.Collector
+	environmentCloner      *environments.Cloner
+	projectSpecs           *projectspec.Manager
+	buildContexts          *buildcontext.Service
+	buildArtifacts         *buildartifacts.Service
+	retention              *retention.Service
+	statusPages            *statuspage.Builder
+	idleScaling            *idle.Manager
+	openAPIValidator       *validation.OpenAPIValidator
 	notificationService    *notifications.Service
 	emailService           *notifications.EmailService
+	eventBroker            *events.Broker
+	jobRunner              *jobs.Runner
 
 	// Infrastructure
 	config             *config.Config
@@ -56,6 +96,7 @@ type Handler struct {
 	provenanceChecker  *provenance.Checker
 	complianceExporter *compliance.Exporter
 	topologyBuilder    *topology.GraphBuilder
+	reservedHosts      *hosts.Reserved
 
 	// Build concurrency control - semaphore to limit concurrent builds (prevents OOM)
 	buildSemaphore chan struct{}
@@ -117,6 +158,7 @@ func NewHandler(
 		provenanceChecker:  provenanceChecker,
 		complianceExporter: complianceExporter,
 		topologyBuilder:    topologyBuilder,
+		reservedHosts:      hosts.NewReserved(config.APIHosts, config.PlatformDomain),
 
 		// Build concurrency control
 		buildSemaphore: buildSem,
@@ -138,6 +180,129 @@ func (h *Handler) SetAddonService(svc *addons.AddonService) {
 	h.addonService = svc
 }
 
+// SetEncryptionKeyService sets the service for customer-managed team encryption keys
+// This is optional - if not set, encryption key endpoints will return 503 Service Unavailable
+func (h *Handler) SetEncryptionKeyService(svc *cmek.Service) {
+	h.encryptionKeyService = svc
+}
+
+// SetDNSService sets the service for DNS records managed through team DNS providers
+// This is optional - if not set, DNS provider endpoints will return 503 Service Unavailable
+// and all domains use manual DNS
+func (h *Handler) SetDNSService(svc *dns.Service) {
+	h.dnsService = svc
+}
+
+// SetPreviewCleaner sets the cleaner that tears down closed preview environments
+// This is optional - if not set, preview cleanup endpoints will return 503 Service Unavailable
+// and closed previews are not torn down
+func (h *Handler) SetPreviewCleaner(cleaner *previews.Cleaner) {
+	h.previewCleaner = cleaner
+}
+
+// SetTrash sets the service that soft-deletes projects and services
+// This is optional - if not set, delete and restore endpoints of projects and
+// services will return 503 Service Unavailable
+func (h *Handler) SetTrash(service *trash.Service) {
+	h.trash = service
+}
+
+// SetQuotaSyncer sets the syncer of the Kubernetes ResourceQuota objects of
+// projects
+// This is optional - if not set, quota changes only reach the cluster on the
+// next periodic sync
+func (h *Handler) SetQuotaSyncer(syncer *quota.Syncer) {
+	h.quotaSyncer = syncer
+}
+
+// SetLogSearch sets the Loki client service log searches run against
+// This is optional - if not set, log searches only cover the recent logs of running pods
+func (h *Handler) SetLogSearch(client *logshipping.LokiClient) {
+	h.logSearch = client
+}
+
+// SetServiceMetrics sets the collector of service metric series
+// This is optional - if not set, service metrics endpoints will return 503 Service Unavailable
+func (h *Handler) SetServiceMetrics(collector *servicemetrics.Collector) {
+	h.serviceMetrics = collector
+}
+
+// SetPreviewDatabases sets the manager of preview environment databases
+// This is optional - if not set, preview database endpoints will return 503 Service Unavailable
+// and previews deploy without a database of their own
+func (h *Handler) SetPreviewDatabases(databases *previews.Databases) {
+	h.previewDatabases = databases
+}
+
+// SetImageRegistry sets the registry client image releases look up tags with
+// This is optional - if not set, image releases of a tag are not pinned to its digest
+func (h *Handler) SetImageRegistry(client *previews.RegistryClient) {
+	h.imageRegistry = client
+}
+
+// SetClusterClients sets the pool of clients of registered clusters
+// This is optional - if not set, every environment runs in the cluster the API runs in
+func (h *Handler) SetClusterClients(pool *k8s.ClientPool) {
+	h.clusterClients = pool
+}
+
+// SetEnvironmentCloner sets the cloner that creates environments from existing ones
+// This is optional - if not set, environment clone endpoints will return 503 Service Unavailable
+func (h *Handler) SetEnvironmentCloner(cloner *environments.Cloner) {
+	h.environmentCloner = cloner
+}
+
+// SetProjectSpecs sets the manager that exports and applies project specs
+// This is optional - if not set, project spec endpoints will return 503 Service Unavailable
+func (h *Handler) SetProjectSpecs(manager *projectspec.Manager) {
+	h.projectSpecs = manager
+}
+
+// SetBuildContexts sets the service that stores uploaded local build contexts
+// This is optional - if not set, build context uploads will return 503 Service Unavailable
+func (h *Handler) SetBuildContexts(svc *buildcontext.Service) {
+	h.buildContexts = svc
+}
+
+// SetBuildArtifacts sets the service that signs build artifact downloads
+// This is optional - if not set, build artifacts are listed without download URLs
+func (h *Handler) SetBuildArtifacts(svc *buildartifacts.Service) {
+	h.buildArtifacts = svc
+}
+
+// SetRetention sets the service that resolves and previews project retention policies
+// This is optional - if not set, retention endpoints will return 503 Service Unavailable
+func (h *Handler) SetRetention(svc *retention.Service) {
+	h.retention = svc
+}
+
+// SetStatusPages sets the builder of public project status pages
+// This is optional - if not set, status pages return 404 Not Found
+func (h *Handler) SetStatusPages(builder *statuspage.Builder) {
+	h.statusPages = builder
+}
+
+// SetIdleScaling sets the manager of services scaled to zero while idle
+// This is optional - if not set, scale-to-zero endpoints will return 503 Service Unavailable
+func (h *Handler) SetIdleScaling(manager *idle.Manager) {
+	h.idleScaling = manager
+}
+
+// SetOpenAPIValidator sets the OpenAPI spec requests are validated against
+// This is optional - if not set, requests are only checked by handler binding
+func (h *Handler) SetOpenAPIValidator(v *validation.OpenAPIValidator) {
+	h.openAPIValidator = v
+}
+
+// validateRequest rejects requests that do not match the OpenAPI spec with
+// 422 and field-level details, or passes everything through when no spec is set
+func (h *Handler) validateRequest() gin.HandlerFunc {
+	if h.openAPIValidator == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return h.openAPIValidator.Middleware()
+}
+
 // SetNotificationService sets the notification service for webhook delivery
 // This is optional - if not set, notification test endpoints will return 503 Service Unavailable
 func (h *Handler) SetNotificationService(svc *notifications.Service) {
@@ -150,6 +315,20 @@ func (h *Handler) SetEmailService(svc *notifications.EmailService) {
 	h.emailService = svc
 }
 
+// SetEventBroker sets the event broker for real-time status streaming
+// This is optional - if not set, the event stream endpoint will return 503 Service Unavailable
+func (h *Handler) SetEventBroker(broker *events.Broker) {
+	h.eventBroker = broker
+}
+
+// SetJobRunner sets the runner of background jobs and registers the jobs the
+// handlers queue, such as the cleanup of closed previews
+// This is optional - if not set, that work runs in goroutines and is lost on restart
+func (h *Handler) SetJobRunner(runner *jobs.Runner) {
+	h.jobRunner = runner
+	runner.Register(previewCleanupJob, h.runPreviewCleanupJob)
+}
+
 // SetTunnelRoutesService sets the tunnel routes service for automatic cloudflared route management
 // This is optional - if not set, domain additions will not automatically update tunnel routes
 // Accepts either TunnelRoutesService (ConfigMap-based) or TunnelRoutesServiceCloudflare (API-based)
@@ -169,6 +348,7 @@ func (h *Handler) SetTunnelRoutesService(svc services.TunnelRoutesManager) {
 // - topology_handlers.go: Service dependency graph
 // - webhook_handlers.go: GitHub webhook handlers
 // - observability_handlers.go: Metrics and monitoring endpoints
+// - events_handlers.go: Real-time status event stream (SSE)
 func SetupRoutes(router *gin.Engine, h *Handler) {
 	// HTTP metrics middleware
 	if h.metrics != nil {
@@ -186,7 +366,7 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 	router.GET("/health/ready", h.ReadinessProbe)
 
 	// Build status - public endpoint for cross-service commit status lookup
-	router.GET("/v1/builds/:commit_sha/status", h.GetBuildStatusByCommit)
+	router.GET("/v1/builds/:id/status", h.GetBuildStatusByCommit)
 
 	// Dashboard stats (public endpoint for local development)
 	router.GET("/v1/dashboard/stats", h.GetDashboardStats)
@@ -198,19 +378,43 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 	// Build callbacks (internal - from Roundhouse worker)
 	// Uses API key authentication instead of user auth
 	router.POST("/v1/callbacks/build-complete", h.BuildCompleteCallback)
+	router.POST("/v1/callbacks/build-stalled", h.BuildStalledCallback)
 	router.POST("/v1/callbacks/function-build-complete", h.FunctionBuildCompleteCallback)
 
+	// Budget and billing callbacks (internal - from Waybill)
+	router.POST("/v1/callbacks/budget", h.BudgetCallback)
+	router.POST("/v1/callbacks/billing", h.BillingCallback)
+
 	// Internal API endpoints (for Roundhouse webhook integration)
 	// GET /v1/services?git_repo=... - Find services by git repository URL
 	// Used by Roundhouse to look up services when processing PR webhooks for preview environments
 	router.GET("/v1/services", h.ListServicesByGitRepo)
 
+	// Public project status pages (no auth required - opt-in per project)
+	// Custom domains of status pages are routed to / and matched by Host
+	// Rate limits are counted in Redis when it is available, so they hold
+	// across replicas; otherwise each replica counts on its own
+	var rateStore middleware.RateStore
+	if h.cache != nil {
+		rateStore = h.cache
+	}
+
+	statusPageRateLimiter := middleware.NewPublicPageRateLimiter().WithStore(rateStore) // 120 req/min per IP
+	router.GET("/status/:slug", statusPageRateLimiter.Middleware(), h.GetPublicStatusPage)
+	router.GET("/", statusPageRateLimiter.Middleware(), h.ServeStatusPageDomain)
+
 	// Rate limiters for auth endpoints
-	authRateLimiter := middleware.NewAuthRateLimiter()             // 10 req/min per IP
-	strictAuthRateLimiter := middleware.NewStrictAuthRateLimiter() // 5 req/min per IP
+	authRateLimiter := middleware.NewAuthRateLimiter().WithStore(rateStore)             // 10 req/min per IP
+	strictAuthRateLimiter := middleware.NewStrictAuthRateLimiter().WithStore(rateStore) // 5 req/min per IP
+
+	// Request schema validation (after rate limiting and authentication)
+	validateRequest := h.validateRequest()
 
 	// API v1 routes
 	v1 := router.Group("/v1")
+	if h.config.RateLimitEnabled {
+		v1.Use(middleware.NewIPRateLimiter(h.config.RateLimitRequestsPerMinute).WithStore(rateStore).Middleware())
+	}
 	{
 		// Auth routes - Different endpoints based on auth mode
 		if h.config.AuthMode == "oidc" {
@@ -233,71 +437,179 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 		} else {
 			// ===== Local Mode (Bootstrap) =====
 			// Local user registration (strict rate limit - abuse prevention)
-			v1.POST("/auth/register", strictAuthRateLimiter.Middleware(), h.auditMiddleware.AuditMiddleware(), h.Register)
+			v1.POST("/auth/register", strictAuthRateLimiter.Middleware(), h.auditMiddleware.AuditMiddleware(), validateRequest, h.Register)
 
 			// Local login with email/password (strict rate limit - brute force prevention)
-			v1.POST("/auth/login", strictAuthRateLimiter.Middleware(), h.auditMiddleware.AuditMiddleware(), h.Login)
+			v1.POST("/auth/login", strictAuthRateLimiter.Middleware(), h.auditMiddleware.AuditMiddleware(), validateRequest, h.Login)
+			v1.POST("/auth/login/two-factor", strictAuthRateLimiter.Middleware(), h.auditMiddleware.AuditMiddleware(), validateRequest, h.LoginTwoFactor)
+
+			// Signup mode and password policy for signup forms
+			v1.GET("/auth/signup", authRateLimiter.Middleware(), h.GetSignupPolicy)
+
+			// Email verification of new accounts (strict rate limit - token guessing and mail abuse)
+			v1.POST("/auth/verify-email", strictAuthRateLimiter.Middleware(), h.auditMiddleware.AuditMiddleware(), validateRequest, h.VerifyEmail)
+			v1.POST("/auth/verify-email/resend", strictAuthRateLimiter.Middleware(), validateRequest, h.ResendEmailVerification)
 
 			// JWKS endpoint for external services to verify our tokens
 			v1.GET("/auth/jwks", h.JWKS)
 		}
 
 		// Common auth endpoints (both modes) - rate limited
-		v1.POST("/auth/refresh", authRateLimiter.Middleware(), h.RefreshToken)
+		v1.POST("/auth/refresh", authRateLimiter.Middleware(), validateRequest, h.RefreshToken)
 		v1.POST("/auth/logout", authRateLimiter.Middleware(), h.auth.AuthMiddleware(), h.auditMiddleware.AuditMiddleware(), h.Logout)
 
 		// Protected routes (require authentication + audit)
 		// These work the same way in both local and OIDC modes
 		protected := v1.Group("")
 		protected.Use(h.auth.AuthMiddleware())
+		if h.config.RateLimitEnabled {
+			// Per user, and per API token on top
+			protected.Use(middleware.NewUserRateLimiter(h.config.RateLimitUserPerMinute).WithStore(rateStore).Middleware())
+			protected.Use(middleware.NewTokenRateLimiter(h.config.RateLimitTokenPerMinute).WithStore(rateStore).Middleware())
+			// Tighter budgets of expensive routes such as builds and deploys
+			h.rateLimitPolicies = ratelimit.NewPolicies(h.repos.RateLimitPolicies, rateStore, logrus.StandardLogger())
+			protected.Use(h.rateLimitPolicies.Middleware())
+		}
 		protected.Use(h.auditMiddleware.AuditMiddleware())
+		// Every protected route must declare a permission in auth.EndpointPermissions
+		protected.Use(auth.Authorize())
+		// Members of a suspended team can read but not change what it owns
+		protected.Use(h.rejectSuspendedTeams())
+		protected.Use(validateRequest)
 		{
 			// Projects
 			protected.POST("/projects", h.auth.RequireRole(string(types.RoleAdmin)), h.CreateProject)
 			protected.GET("/projects", h.ListProjects)
 			protected.GET("/projects/:slug", h.GetProject)
 			protected.DELETE("/projects/:slug", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteProject)
+			protected.POST("/projects/:slug/restore", h.auth.RequireRole(string(types.RoleAdmin)), h.RestoreProject)
+			protected.PUT("/projects/:slug", h.auth.RequireRole(string(types.RoleAdmin)), h.PutProject)
+			protected.GET("/projects/:slug/import", h.ImportProjectResources)
+			protected.GET("/projects/:slug/spec", h.ExportProjectSpec)
+			protected.POST("/projects/:slug/spec", h.auth.RequireRole(string(types.RoleDeveloper)), h.ApplyProjectSpec)
+			protected.GET("/projects/:slug/retention", h.GetRetention)
+			protected.PUT("/projects/:slug/retention", h.auth.RequireRole(string(types.RoleAdmin)), h.UpdateRetention)
+			protected.GET("/projects/:slug/retention/preview", h.PreviewRetentionPurge)
+			protected.GET("/projects/:slug/events", h.ListProjectEvents)
+			protected.GET("/projects/:slug/status-page", h.GetStatusPage)
+			protected.PUT("/projects/:slug/status-page", h.auth.RequireRole(string(types.RoleAdmin)), h.UpdateStatusPage)
+			protected.DELETE("/projects/:slug/status-page", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteStatusPage)
+			protected.POST("/projects/:slug/status-page/verify-domain", h.auth.RequireRole(string(types.RoleAdmin)), h.VerifyStatusPageDomain)
+			protected.GET("/projects/:slug/registry-credentials", h.ListRegistryCredentials)
+			protected.POST("/projects/:slug/registry-credentials", h.auth.RequireRole(string(types.RoleAdmin)), h.SetRegistryCredential)
+			protected.DELETE("/projects/:slug/registry-credentials/:credential_id", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteRegistryCredential)
+			protected.GET("/projects/:slug/build-secrets", h.ListBuildSecrets)
+			protected.PUT("/projects/:slug/build-secrets/:name", h.auth.RequireRole(string(types.RoleDeveloper)), h.SetBuildSecret)
+			protected.DELETE("/projects/:slug/build-secrets/:name", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteBuildSecret)
+			protected.GET("/projects/:slug/log-redaction", h.GetLogRedaction)
+			protected.PUT("/projects/:slug/log-redaction", h.auth.RequireRole(string(types.RoleAdmin)), h.UpdateLogRedaction)
+			protected.GET("/projects/:slug/status", h.GetProjectStatus)
+			protected.GET("/projects/:slug/topology", h.GetProjectTopology)
+			protected.GET("/search", h.Search)
 
 			// Environments
 			protected.POST("/projects/:slug/environments", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateEnvironment)
 			protected.GET("/projects/:slug/environments", h.ListEnvironments)
 			protected.GET("/projects/:slug/environments/:env_name", h.GetEnvironment)
+			protected.POST("/projects/:slug/environments/:env_name/clone", h.auth.RequireRole(string(types.RoleDeveloper)), h.CloneEnvironment)
+			protected.GET("/projects/:slug/environments/:env_name/addon-copies", h.ListEnvironmentAddonCopies)
+			protected.GET("/projects/:slug/environments/:env_name/soak-policy", h.GetSoakPolicy)
+			protected.PUT("/projects/:slug/environments/:env_name/soak-policy", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateSoakPolicy)
+			protected.DELETE("/projects/:slug/environments/:env_name/soak-policy", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteSoakPolicy)
+			protected.GET("/projects/:slug/environments/:env_name/deploy-guard", h.GetDeployGuard)
+			protected.PUT("/projects/:slug/environments/:env_name/deploy-guard", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateDeployGuard)
+			protected.GET("/projects/:slug/environments/:env_name/promotion", h.GetPromotion)
+			protected.PUT("/projects/:slug/environments/:env_name/promotion", h.auth.RequireRole(string(types.RoleAdmin)), h.UpdatePromotion)
+			protected.GET("/projects/:slug/environments/:env_name/deploy-policy", h.GetDeployPolicy)
+			protected.PUT("/projects/:slug/environments/:env_name/deploy-policy", h.auth.RequireRole(string(types.RoleAdmin)), h.UpdateDeployPolicy)
+			protected.DELETE("/projects/:slug/environments/:env_name/deploy-policy", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteDeployPolicy)
+			protected.GET("/projects/:slug/environments/:env_name/deploy-locks", h.GetDeployLocks)
+			protected.PUT("/projects/:slug/environments/:env_name/deploy-concurrency", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateDeployConcurrency)
+			protected.GET("/projects/:slug/environments/:env_name/freeze-windows", h.ListFreezeWindows)
+			protected.POST("/projects/:slug/environments/:env_name/freeze-windows", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateFreezeWindow)
+			protected.DELETE("/projects/:slug/environments/:env_name/freeze-windows/:window_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteFreezeWindow)
 
 			// Services
 			protected.POST("/projects/:slug/services", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateService)
 			protected.POST("/projects/:slug/services/bulk", h.auth.RequireRole(string(types.RoleDeveloper)), h.BulkCreateServices)
 			protected.GET("/projects/:slug/services", h.ListServices)
+			protected.PUT("/projects/:slug/services/:name", h.auth.RequireRole(string(types.RoleDeveloper)), h.PutService)
 			protected.GET("/services/:id", h.GetService)
 			protected.GET("/services/:id/settings", h.GetServiceSettings)
 			protected.PATCH("/services/:id", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateService)
 			protected.DELETE("/services/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteService)
+			protected.POST("/services/:id/restore", h.auth.RequireRole(string(types.RoleAdmin)), h.RestoreService)
 
 			// Build & Deploy
 			protected.POST("/services/:id/build", h.auth.RequireRole(string(types.RoleDeveloper)), h.BuildService)
+			protected.POST("/services/:id/build-contexts", h.auth.RequireRole(string(types.RoleDeveloper)), h.UploadBuildContext)
 			protected.GET("/services/:id/releases", h.ListReleases)
+			protected.POST("/services/:id/releases", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateImageRelease)
 			protected.POST("/services/:id/deploy", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeployService)
+			protected.POST("/releases/:id/promote", h.auth.RequireRole(string(types.RoleDeveloper)), h.PromoteRelease)
+			protected.GET("/releases/:id/promotions", h.ListReleasePromotions)
 
 			// Status & Deployments
 			protected.GET("/services/:id/status", h.GetServiceStatus)
 			protected.GET("/services/:id/metrics", h.GetServiceResourceMetrics)
+			protected.GET("/services/:id/alert-rules", h.ListAlertRules)
+			protected.POST("/services/:id/alert-rules", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateAlertRule)
+			protected.PATCH("/services/:id/alert-rules/:rule_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateAlertRule)
+			protected.DELETE("/services/:id/alert-rules/:rule_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteAlertRule)
+			protected.GET("/services/:id/alerts", h.ListServiceAlerts)
+			protected.GET("/services/:id/uptime-checks", h.ListUptimeChecks)
+			protected.POST("/services/:id/uptime-checks", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateUptimeCheck)
+			protected.PATCH("/services/:id/uptime-checks/:check_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateUptimeCheck)
+			protected.DELETE("/services/:id/uptime-checks/:check_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteUptimeCheck)
+			protected.GET("/services/:id/uptime-checks/:check_id/results", h.ListUptimeCheckResults)
+			protected.GET("/services/:id/scale-to-zero", h.ListIdleScaling)
+			protected.PUT("/services/:id/scale-to-zero/:env_name", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateIdleScaling)
+			protected.DELETE("/services/:id/scale-to-zero/:env_name", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteIdleScaling)
+			protected.POST("/services/:id/scale-to-zero/:env_name/wake", h.auth.RequireRole(string(types.RoleDeveloper)), h.WakeService)
+			protected.GET("/services/:id/commands", h.ListServiceCommands)
+			protected.PUT("/services/:id/commands/:env_name", h.auth.RequireRole(string(types.RoleDeveloper)), h.SetServiceEnvironmentCommand)
+			protected.DELETE("/services/:id/commands/:env_name", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteServiceEnvironmentCommand)
 			protected.GET("/services/:id/deployments", h.ListServiceDeployments)
 			protected.GET("/services/:id/deployments/latest", h.GetLatestDeployment)
 			protected.GET("/deployments/:id", h.GetDeployment)
+			protected.GET("/deployments/:id/snapshot", h.GetDeploymentSnapshot)
+			protected.GET("/deployments/:id/soak", h.GetDeploymentSoak)
 			protected.GET("/deployments/:id/logs", h.GetLogs)
 			protected.POST("/deployments/:id/rollback", h.auth.RequireRole(string(types.RoleDeveloper)), h.RollbackDeployment)
+			protected.POST("/deployments/:id/reconcile", h.auth.RequireRole(string(types.RoleDeveloper)), h.ReconcileDeployment)
+			protected.GET("/deployments/:id/drift", h.ListDeploymentDrift)
 
 			// Real-time Logs (WebSocket streaming)
 			protected.GET("/services/:id/logs/stream", h.StreamServiceLogsWS)
 			protected.GET("/services/:id/logs/history", h.GetLogsHistory)
 			protected.POST("/services/:id/logs/search", h.SearchLogs)
+			protected.GET("/services/:id/logs/search", h.SearchServiceLogs)
 			protected.GET("/deployments/:id/logs/stream", h.StreamLogsWS)
+			protected.GET("/projects/:slug/logs/stream", h.StreamProjectLogsWS)
 			protected.GET("/services/:id/builds/:build_id/logs", h.GetBuildLogs)
 			protected.GET("/services/:id/builds/:build_id/logs/stream", h.StreamBuildLogsWS)
 
+			// Port forwarding (WebSocket tunnel to a service or addon)
+			protected.GET("/projects/:slug/port-forward", h.PortForwardWS)
+
+			// One-off jobs (commands run once in a service's deployed image)
+			protected.POST("/services/:id/jobs", h.auth.RequireRole(string(types.RoleDeveloper)), h.RunOneOffJob)
+			protected.GET("/jobs/:id", h.GetOneOffJob)
+			protected.GET("/jobs/:id/logs/stream", h.StreamOneOffJobLogsWS)
+
 			// Build Status (Unified CI + Build + Deploy status)
 			// Note: :build_id here can be either a release UUID or commit SHA
 			protected.GET("/services/:id/builds/:build_id/status", h.GetUnifiedBuildStatus)
 
+			// Build artifacts (files a build published to object storage)
+			protected.GET("/builds/:id/artifacts", h.ListBuildArtifacts)
+
+			// Builds a commit fanned out into across a project's services
+			protected.GET("/projects/:slug/commits/:sha/builds", h.GetCommitBuilds)
+
+			// Real-time status events (SSE)
+			protected.GET("/events/stream", h.StreamEvents)
+
 			// Topology
 			protected.GET("/topology", h.GetTopology)
 			protected.GET("/topology/services/:id/dependencies", h.GetServiceDependencies)
@@ -311,12 +623,21 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 			protected.GET("/services/:id/domains/:domain_id", h.GetCustomDomain)
 			protected.PATCH("/services/:id/domains/:domain_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateCustomDomain)
 			protected.DELETE("/services/:id/domains/:domain_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteCustomDomain)
+			protected.PUT("/services/:id/domains/names/:domain", h.auth.RequireRole(string(types.RoleDeveloper)), h.PutCustomDomain)
 			protected.POST("/services/:id/domains/:domain_id/verify", h.auth.RequireRole(string(types.RoleDeveloper)), h.VerifyCustomDomain)
 			protected.PUT("/domains/:domain_id/protection", h.auth.RequireRole(string(types.RoleDeveloper)), h.ToggleZeroTrust)
 
 			// Environments
 			protected.GET("/environments", h.GetEnvironments)
 
+			// Clusters
+			protected.GET("/clusters", h.ListClusters)
+			protected.POST("/clusters", h.auth.RequireRole(string(types.RoleAdmin)), h.RegisterCluster)
+			protected.GET("/clusters/health", h.ListClusterHealth)
+			protected.DELETE("/clusters/:name", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteCluster)
+			protected.GET("/clusters/:name/health", h.GetClusterHealth)
+			protected.GET("/clusters/:name/node-pools", h.ListNodePools)
+
 			// Integrations (GitHub via Janua OAuth tokens)
 			protected.GET("/integrations/github/status", h.GetGitHubStatus)
 			protected.GET("/integrations/github/repos", h.ListGitHubRepos)
@@ -330,6 +651,10 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 			protected.GET("/projects/:slug/deployment-groups/:group_id", h.GetDeploymentGroup)
 			protected.POST("/projects/:slug/deployment-groups/:group_id/execute", h.auth.RequireRole(string(types.RoleDeveloper)), h.ExecuteDeploymentGroup)
 			protected.POST("/projects/:slug/deployment-groups/:group_id/rollback", h.auth.RequireRole(string(types.RoleDeveloper)), h.RollbackDeploymentGroup)
+			protected.POST("/projects/:slug/deployment-groups/:group_id/cancel", h.auth.RequireRole(string(types.RoleDeveloper)), h.CancelDeploymentGroup)
+
+			// Bulk operations (restart/redeploy/scale many services as one deployment group)
+			protected.POST("/projects/:slug/bulk", h.auth.RequireRole(string(types.RoleDeveloper)), h.ExecuteBulkOperation)
 
 			// Service Dependencies
 			protected.POST("/services/:id/dependencies", h.auth.RequireRole(string(types.RoleDeveloper)), h.AddServiceDependency)
@@ -343,14 +668,33 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 			protected.GET("/services/:id/env-vars/:var_id", h.GetEnvVar)
 			protected.PUT("/services/:id/env-vars/:var_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateEnvVar)
 			protected.DELETE("/services/:id/env-vars/:var_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteEnvVar)
+			protected.PUT("/services/:id/env-vars/keys/:key", h.auth.RequireRole(string(types.RoleDeveloper)), h.PutEnvVar)
 			protected.POST("/services/:id/env-vars/bulk", h.auth.RequireRole(string(types.RoleDeveloper)), h.BulkUpsertEnvVars)
 			protected.POST("/services/:id/env-vars/sync-from-pod", h.auth.RequireRole(string(types.RoleAdmin)), h.SyncEnvVarsFromPod)
 			protected.POST("/services/:id/env-vars/:var_id/reveal", h.auth.RequireRole(string(types.RoleDeveloper)), h.RevealEnvVar)
+			protected.GET("/services/:id/env-vars/rotations", h.ListEnvVarRotations)
+			protected.PUT("/services/:id/env-vars/:var_id/rotation", h.auth.RequireRole(string(types.RoleDeveloper)), h.SetEnvVarRotation)
+			protected.DELETE("/services/:id/env-vars/:var_id/rotation", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteEnvVarRotation)
+			protected.POST("/services/:id/env-vars/:var_id/rotation/start", h.auth.RequireRole(string(types.RoleDeveloper)), h.StartEnvVarRotation)
+			protected.POST("/services/:id/env-vars/:var_id/rotation/finalize", h.auth.RequireRole(string(types.RoleDeveloper)), h.FinalizeEnvVarRotation)
+			protected.POST("/services/:id/env-vars/:var_id/rotation/abort", h.auth.RequireRole(string(types.RoleDeveloper)), h.AbortEnvVarRotation)
+			protected.GET("/services/:id/env/export", h.ExportEnvVars)
+			protected.POST("/services/:id/env/import", h.auth.RequireRole(string(types.RoleDeveloper)), h.ImportEnvVars)
+			protected.GET("/services/:id/config-drift", h.GetConfigDrift)
+			protected.GET("/services/:id/preview-env", h.ListPreviewEnvVars)
+			protected.PUT("/services/:id/preview-env", h.auth.RequireRole(string(types.RoleDeveloper)), h.ReplacePreviewEnvVars)
 
 			// Preview Environments (PR-based ephemeral deployments)
 			protected.GET("/services/:id/previews", h.ListPreviews)
 			protected.GET("/projects/:slug/previews", h.ListProjectPreviews)
+			protected.GET("/projects/:slug/previews/stale", h.ListStalePreviews)
+			protected.GET("/projects/:slug/preview-settings", h.GetPreviewSettings)
+			protected.PUT("/projects/:slug/preview-settings", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdatePreviewSettings)
+			protected.GET("/services/:id/preview-database", h.GetPreviewDatabaseConfig)
+			protected.PUT("/services/:id/preview-database", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdatePreviewDatabaseConfig)
+			protected.DELETE("/services/:id/preview-database", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeletePreviewDatabaseConfig)
 			protected.GET("/previews/:id", h.GetPreview)
+			protected.GET("/previews/:id/database", h.GetPreviewDatabase)
 			protected.POST("/previews", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreatePreview)
 			protected.POST("/previews/:id/close", h.auth.RequireRole(string(types.RoleDeveloper)), h.ClosePreview)
 			protected.POST("/previews/:id/wake", h.auth.RequireRole(string(types.RoleDeveloper)), h.WakePreview)
@@ -379,6 +723,30 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 			protected.GET("/teams/:slug/invitations", h.ListTeamInvitations)
 			protected.DELETE("/teams/:slug/invitations/:invitation_id", h.CancelTeamInvitation)
 
+			// Team Encryption Keys (customer-managed keys)
+			protected.GET("/teams/:slug/encryption-key", h.GetTeamEncryptionKey)
+			protected.PUT("/teams/:slug/encryption-key", h.ConfigureTeamEncryptionKey)
+			protected.POST("/teams/:slug/encryption-key/check", h.CheckTeamEncryptionKey)
+			protected.DELETE("/teams/:slug/encryption-key", h.RemoveTeamEncryptionKey)
+
+			// Team DNS providers (managed DNS records for company domains)
+			protected.GET("/teams/:slug/dns-providers", h.ListTeamDNSProviders)
+			protected.POST("/teams/:slug/dns-providers", h.ConnectTeamDNSProvider)
+			protected.POST("/teams/:slug/dns-providers/:provider_id/check", h.CheckTeamDNSProvider)
+			protected.DELETE("/teams/:slug/dns-providers/:provider_id", h.RemoveTeamDNSProvider)
+
+			// Team GPU quotas (set by platform admins, enforced at deploy time)
+			protected.GET("/teams/:slug/gpu-quota", h.GetTeamGPUQuota)
+			protected.PUT("/teams/:slug/gpu-quota", h.auth.RequireRole(string(types.RoleAdmin)), h.SetTeamGPUQuota)
+			protected.GET("/teams/:slug/build-limits", h.GetTeamBuildLimits)
+			protected.PUT("/teams/:slug/build-limits", h.auth.RequireRole(string(types.RoleAdmin)), h.SetTeamBuildLimits)
+
+			// Resource quotas of teams and projects (set by platform admins)
+			protected.GET("/teams/:slug/quota", h.GetTeamQuota)
+			protected.PUT("/teams/:slug/quota", h.auth.RequireRole(string(types.RoleAdmin)), h.SetTeamQuota)
+			protected.GET("/projects/:slug/quota", h.GetProjectQuota)
+			protected.PUT("/projects/:slug/quota", h.auth.RequireRole(string(types.RoleAdmin)), h.SetProjectQuota)
+
 			// User Invitations (personal invitation management)
 			protected.GET("/invitations", h.ListMyInvitations)
 			protected.GET("/invitations/:token", h.GetInvitationByToken)
@@ -395,6 +763,8 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 			protected.GET("/domains/stats", h.GetDomainStats)
 			protected.POST("/domains/sync", h.auth.RequireRole(string(types.RoleAdmin)), h.SyncDomainsFromCloudflare)
 			protected.POST("/domains/:domain_id/sync", h.auth.RequireRole(string(types.RoleDeveloper)), h.SyncDomainFromCloudflare)
+			protected.GET("/domains/:domain_id/dns", h.CheckDomainDNS)
+			protected.POST("/domains/:domain_id/dns/sync", h.auth.RequireRole(string(types.RoleDeveloper)), h.SyncDomainDNS)
 
 			// Cloudflare Tunnel Status
 			protected.GET("/tunnel/status", h.GetTunnelStatus)
@@ -417,16 +787,58 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 			protected.GET("/user/tokens/:token_id", h.GetAPIToken)
 			protected.DELETE("/user/tokens/:token_id", h.RevokeAPIToken)
 
-			// Database Add-ons (PostgreSQL, Redis, MySQL)
+			// Two-factor authentication (TOTP) of local accounts
+			protected.GET("/user/two-factor", h.GetTwoFactorStatus)
+			protected.DELETE("/user/two-factor", h.DisableTwoFactor)
+			protected.POST("/user/two-factor/enroll", h.BeginTwoFactorEnrollment)
+			protected.POST("/user/two-factor/enable", h.EnableTwoFactor)
+			protected.POST("/user/two-factor/verify", h.VerifyTwoFactor)
+			protected.POST("/user/two-factor/recovery-codes", h.RegenerateRecoveryCodes)
+
+			// Sessions of the current user (signed-in devices)
+			protected.GET("/me/sessions", h.ListSessions)
+			protected.POST("/me/sessions/revoke-others", h.RevokeOtherSessions)
+			protected.DELETE("/me/sessions/:id", h.RevokeSession)
+
+			// Bots (CI identities with project/environment-scoped tokens)
+			protected.GET("/bots", h.auth.RequireRole(string(types.RoleAdmin)), h.ListBots)
+			protected.POST("/bots", h.auth.RequireRole(string(types.RoleAdmin)), h.CreateBot)
+			protected.GET("/bots/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.GetBot)
+			protected.PATCH("/bots/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.UpdateBot)
+			protected.DELETE("/bots/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteBot)
+			protected.PUT("/bots/:id/grants", h.auth.RequireRole(string(types.RoleAdmin)), h.ReplaceBotGrants)
+			protected.GET("/bots/:id/tokens", h.auth.RequireRole(string(types.RoleAdmin)), h.ListBotTokens)
+			protected.POST("/bots/:id/tokens", h.auth.RequireRole(string(types.RoleAdmin)), h.CreateBotToken)
+			protected.DELETE("/bots/:id/tokens/:token_id", h.auth.RequireRole(string(types.RoleAdmin)), h.RevokeBotToken)
+
+			// Signup invites (codes for invite-only signup)
+			protected.GET("/signup-invites", h.auth.RequireRole(string(types.RoleAdmin)), h.ListSignupInvites)
+			protected.POST("/signup-invites", h.auth.RequireRole(string(types.RoleAdmin)), h.CreateSignupInvite)
+			protected.DELETE("/signup-invites/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.RevokeSignupInvite)
+
+			// Transactional email delivery status
+			protected.GET("/email-deliveries", h.auth.RequireRole(string(types.RoleAdmin)), h.ListEmailDeliveries)
+
+			// Database Add-ons (PostgreSQL, Redis, MySQL, MongoDB)
 			// Global addon listing (all addons user has access to)
 			protected.GET("/addons", h.ListAllAddons)
 			protected.GET("/databases", h.ListAllAddons) // Alias for better UX
 			// Project-specific addon operations
 			protected.POST("/projects/:slug/addons", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateAddon)
 			protected.GET("/projects/:slug/addons", h.ListAddons)
+			protected.PUT("/projects/:slug/addons/:name", h.auth.RequireRole(string(types.RoleDeveloper)), h.PutAddon)
 			protected.GET("/addons/:id", h.GetAddon)
 			protected.GET("/addons/:id/credentials", h.GetAddonCredentials)
 			protected.POST("/addons/:id/refresh", h.RefreshAddonStatus)
+			protected.POST("/addons/:id/backups", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateAddonBackup)
+			protected.GET("/addons/:id/backups", h.ListAddonBackups)
+			protected.PATCH("/addons/:id/backup-policy", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateAddonBackupPolicy)
+			protected.POST("/addons/:id/restore", h.auth.RequireRole(string(types.RoleAdmin)), h.RestoreAddon)
+			protected.GET("/addons/:id/pitr", h.GetAddonPITRStatus)
+			protected.GET("/addons/:id/restores", h.ListAddonRestores)
+			protected.GET("/addons/:id/restores/:restore_id", h.GetAddonRestore)
+			protected.PATCH("/addons/:id", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateAddon)
+			protected.GET("/addons/:id/resizes", h.ListAddonResizes)
 			protected.DELETE("/addons/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteAddon)
 			protected.POST("/addons/:id/bindings", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateAddonBinding)
 			protected.DELETE("/addons/:id/bindings/:service_id", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeleteAddonBinding)
@@ -448,13 +860,17 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 			// Notification Webhooks (Slack/Discord/Telegram/Custom)
 			protected.POST("/projects/:slug/webhooks", h.auth.RequireRole(string(types.RoleDeveloper)), h.CreateWebhook)
 			protected.GET("/projects/:slug/webhooks", h.ListWebhooks)
+			protected.GET("/projects/:slug/webhooks/dead-letters", h.ListWebhookDeadLetters)
 			protected.GET("/webhooks/event-types", h.GetWebhookEventTypes)
 			protected.GET("/webhooks/:id", h.GetWebhook)
 			protected.PATCH("/webhooks/:id", h.auth.RequireRole(string(types.RoleDeveloper)), h.UpdateWebhook)
 			protected.DELETE("/webhooks/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteWebhook)
 			protected.POST("/webhooks/:id/test", h.auth.RequireRole(string(types.RoleDeveloper)), h.TestWebhook)
+			protected.POST("/webhooks/:id/rotate-secret", h.auth.RequireRole(string(types.RoleAdmin)), h.RotateWebhookSecret)
 			protected.GET("/webhooks/:id/deliveries", h.ListWebhookDeliveries)
-			protected.POST("/webhooks/:id/deliveries/:delivery_id/retry", h.auth.RequireRole(string(types.RoleDeveloper)), h.RetryWebhookDelivery)
+			protected.POST("/webhooks/:id/deliveries/:delivery_id/replay", h.auth.RequireRole(string(types.RoleDeveloper)), h.ReplayWebhookDelivery)
+			// Older name of the replay route, kept for existing clients
+			protected.POST("/webhooks/:id/deliveries/:delivery_id/retry", h.auth.RequireRole(string(types.RoleDeveloper)), h.ReplayWebhookDelivery)
 
 			// Templates (Starter templates and marketplace)
 			protected.GET("/templates", h.ListTemplates)
@@ -465,6 +881,22 @@ func SetupRoutes(router *gin.Engine, h *Handler) {
 			protected.POST("/templates/:slug/deploy", h.auth.RequireRole(string(types.RoleDeveloper)), h.DeployTemplate)
 			protected.GET("/templates/deployments/:id", h.GetTemplateDeployment)
 			protected.POST("/templates/import", h.auth.RequireRole(string(types.RoleDeveloper)), h.ImportTemplateFromGitHub)
+
+			// Platform administration (operators, admins and superadmins)
+			protected.GET("/admin/teams", h.auth.RequireRole(string(types.RoleOperator)), h.AdminListTeams)
+			protected.GET("/admin/projects", h.auth.RequireRole(string(types.RoleOperator)), h.AdminListProjects)
+			protected.GET("/admin/metrics", h.auth.RequireRole(string(types.RoleOperator)), h.AdminMetrics)
+			protected.POST("/admin/teams/:slug/suspend", h.auth.RequireRole(string(types.RoleOperator)), h.SuspendTeam)
+			protected.POST("/admin/teams/:slug/unsuspend", h.auth.RequireRole(string(types.RoleOperator)), h.UnsuspendTeam)
+			protected.POST("/admin/impersonate", h.auth.RequireRole(string(types.RoleOperator)), h.Impersonate)
+			protected.GET("/admin/jobs", h.auth.RequireRole(string(types.RoleOperator)), h.ListBackgroundJobs)
+			protected.GET("/admin/jobs/:id", h.auth.RequireRole(string(types.RoleOperator)), h.GetBackgroundJob)
+			protected.POST("/admin/jobs/:id/retry", h.auth.RequireRole(string(types.RoleOperator)), h.RetryBackgroundJob)
+			protected.GET("/admin/rate-limit-policies", h.auth.RequireRole(string(types.RoleOperator)), h.ListRateLimitPolicies)
+			protected.POST("/admin/rate-limit-policies", h.auth.RequireRole(string(types.RoleAdmin)), h.CreateRateLimitPolicy)
+			protected.PUT("/admin/rate-limit-policies/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.UpdateRateLimitPolicy)
+			protected.DELETE("/admin/rate-limit-policies/:id", h.auth.RequireRole(string(types.RoleAdmin)), h.DeleteRateLimitPolicy)
+			protected.PUT("/admin/tokens/:id/rate-limit", h.auth.RequireRole(string(types.RoleAdmin)), h.SetAPITokenRateLimit)
 		}
 	}
 }
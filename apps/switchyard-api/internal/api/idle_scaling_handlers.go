@@ -0,0 +1,258 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/idle"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// defaultIdleMinutes is the idle period of services scale-to-zero is
+// enabled for without one
+const defaultIdleMinutes = 30
+
+// UpdateIdleScalingRequest enables scale-to-zero of a service in an
+// environment
+type UpdateIdleScalingRequest struct {
+	IdleMinutes *int `json:"idle_minutes,omitempty"` // Defaults to 30
+}
+
+// ListIdleScaling lists the environments a service is scaled to zero in
+// while idle, with whether it sleeps now and the compute it saved
+// GET /v1/services/:id/scale-to-zero
+func (h *Handler) ListIdleScaling(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	if !h.authorizeServiceEnvironment(c, serviceID, nil) {
+		return
+	}
+
+	entries, err := h.repos.IdleScaling.ListByService(ctx, serviceID)
+	if err != nil {
+		if isTableNotExistError(err) {
+			c.JSON(http.StatusOK, gin.H{"environments": []*types.ServiceIdleScaling{}, "active": false})
+			return
+		}
+		h.logger.Error(ctx, "Failed to list idle scaling",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list scale-to-zero settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"environments": entries,
+		"active":       h.idleScaling != nil && h.idleScaling.CanSleep(),
+	})
+}
+
+// UpdateIdleScaling enables scale-to-zero of a service in a non-production
+// environment, or changes its idle period
+// PUT /v1/services/:id/scale-to-zero/:env_name
+func (h *Handler) UpdateIdleScaling(c *gin.Context) {
+	var req UpdateIdleScalingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	idleMinutes := defaultIdleMinutes
+	if req.IdleMinutes != nil {
+		idleMinutes = *req.IdleMinutes
+	}
+	if idleMinutes < idle.MinIdleMinutes || idleMinutes > idle.MaxIdleMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("idle_minutes must be between %d and %d", idle.MinIdleMinutes, idle.MaxIdleMinutes),
+		})
+		return
+	}
+
+	service, project, env, ok := h.loadServiceEnvironment(c)
+	if !ok {
+		return
+	}
+	if env.Name == "production" || env.Name == "prod" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Production services cannot be scaled to zero"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	scaling := &types.ServiceIdleScaling{
+		ServiceID:     service.ID,
+		EnvironmentID: env.ID,
+		IdleMinutes:   idleMinutes,
+	}
+	if err := h.repos.IdleScaling.Upsert(ctx, scaling); err != nil {
+		h.logger.Error(ctx, "Failed to save idle scaling",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save scale-to-zero settings"})
+		return
+	}
+
+	h.auditIdleScaling(c, project, service, scaling, "service.scale_to_zero_updated")
+
+	response := gin.H{"scale_to_zero": scaling}
+	if h.idleScaling == nil || !h.idleScaling.CanSleep() {
+		response["warning"] = "Idle services are not put to sleep: the API needs ENCLII_PROMETHEUS_URL and a Cloudflare tunnel"
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteIdleScaling turns scale-to-zero of a service in an environment off,
+// scaling it back up if it sleeps
+// DELETE /v1/services/:id/scale-to-zero/:env_name
+func (h *Handler) DeleteIdleScaling(c *gin.Context) {
+	if h.idleScaling == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Scale-to-zero is not configured"})
+		return
+	}
+	service, project, env, ok := h.loadServiceEnvironment(c)
+	if !ok {
+		return
+	}
+	scaling, ok := h.getIdleScaling(c, service, env)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.idleScaling.Disable(ctx, scaling); err != nil && err != sql.ErrNoRows {
+		h.logger.Error(ctx, "Failed to disable idle scaling",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to turn scale-to-zero off"})
+		return
+	}
+
+	h.auditIdleScaling(c, project, service, scaling, "service.scale_to_zero_disabled")
+	c.JSON(http.StatusOK, gin.H{"message": "Scale-to-zero turned off"})
+}
+
+// WakeService wakes a service that sleeps while idle without waiting for
+// a request. The wake continues in the background.
+// POST /v1/services/:id/scale-to-zero/:env_name/wake
+func (h *Handler) WakeService(c *gin.Context) {
+	if h.idleScaling == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Scale-to-zero is not configured"})
+		return
+	}
+	service, project, env, ok := h.loadServiceEnvironment(c)
+	if !ok {
+		return
+	}
+	scaling, ok := h.getIdleScaling(c, service, env)
+	if !ok {
+		return
+	}
+	if scaling.State != types.IdleStateAsleep {
+		c.JSON(http.StatusOK, gin.H{"message": "Service is awake", "scale_to_zero": scaling})
+		return
+	}
+
+	go func() {
+		if _, err := h.idleScaling.Wake(context.Background(), scaling.ID); err != nil {
+			h.logger.Warn(context.Background(), "Failed to wake idle service",
+				logging.String("service_id", service.ID.String()),
+				logging.String("environment", env.Name),
+				logging.Error("error", err))
+		}
+	}()
+
+	h.auditIdleScaling(c, project, service, scaling, "service.woken")
+	c.JSON(http.StatusAccepted, gin.H{"message": "Service is waking up", "scale_to_zero": scaling})
+}
+
+// loadServiceEnvironment loads the service and environment of a request on
+// a service in one environment and authorizes access to the environment.
+// It writes the error response and returns false when that fails.
+func (h *Handler) loadServiceEnvironment(c *gin.Context) (*types.Service, *types.Project, *types.Environment, bool) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return nil, nil, nil, false
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return nil, nil, nil, false
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return nil, nil, nil, false
+	}
+	env, err := h.repos.Environments.GetByProjectAndName(project.ID, c.Param("env_name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found", "environment": c.Param("env_name")})
+		return nil, nil, nil, false
+	}
+	if !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return nil, nil, nil, false
+	}
+	return service, project, env, true
+}
+
+// getIdleScaling loads the scale-to-zero settings of a service in an
+// environment. It writes the error response and returns false when there
+// are none.
+func (h *Handler) getIdleScaling(c *gin.Context, service *types.Service, env *types.Environment) (*types.ServiceIdleScaling, bool) {
+	ctx := c.Request.Context()
+	scaling, err := h.repos.IdleScaling.Get(ctx, service.ID, env.ID)
+	if err != nil {
+		if err == sql.ErrNoRows || isTableNotExistError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Scale-to-zero is not enabled for the service in this environment"})
+			return nil, false
+		}
+		h.logger.Error(ctx, "Failed to get idle scaling",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get scale-to-zero settings"})
+		return nil, false
+	}
+	return scaling, true
+}
+
+// auditIdleScaling records a change to the scale-to-zero settings of a
+// service
+func (h *Handler) auditIdleScaling(c *gin.Context, project *types.Project, service *types.Service, scaling *types.ServiceIdleScaling, action string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    fmt.Sprintf("%v", userEmail),
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		Action:        action,
+		ResourceType:  "service",
+		ResourceID:    service.ID.String(),
+		ResourceName:  service.Name,
+		ProjectID:     &project.ID,
+		EnvironmentID: &scaling.EnvironmentID,
+		Outcome:       "success",
+		Context: map[string]interface{}{
+			"environment":  scaling.Environment,
+			"idle_minutes": scaling.IdleMinutes,
+			"state":        scaling.State,
+		},
+	})
+}
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/registry"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/signing"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/vulnscan"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// imageCheckTimeout bounds each check of an image release
+const imageCheckTimeout = 5 * time.Minute
+
+// CreateImageReleaseRequest creates a release from an image already in a
+// registry, without a build
+type CreateImageReleaseRequest struct {
+	ImageURI        string `json:"image_uri" binding:"required"` // e.g. ghcr.io/org/api:v1.2.0
+	Digest          string `json:"digest,omitempty"`             // Pins the release to sha256:<hex>
+	Version         string `json:"version,omitempty"`            // Defaults to a timestamp and the tag
+	VerifySignature bool   `json:"verify_signature,omitempty"`   // Require a valid Cosign signature
+	ScanSeverity    string `json:"scan_severity,omitempty"`      // Fail on vulnerabilities of this severity or above
+}
+
+// imageChecks are the checks an image release must pass before it can be
+// deployed
+type imageChecks struct {
+	verifySignature bool
+	scanSeverity    vulnscan.Severity // Empty to skip the scan
+}
+
+func (c imageChecks) any() bool {
+	return c.verifySignature || c.scanSeverity != ""
+}
+
+// CreateImageRelease creates a release of an existing registry image. It is
+// ready to deploy at once, or once its signature and vulnerability checks
+// pass when they are requested; until then it stays building.
+// POST /v1/services/:id/releases
+func (h *Handler) CreateImageRelease(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	var req CreateImageReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ref, err := registry.ParseReference(strings.TrimSpace(req.ImageURI))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Digest != "" {
+		if ref, err = ref.WithDigest(req.Digest); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	checks := imageChecks{verifySignature: req.VerifySignature}
+	if req.ScanSeverity != "" {
+		if checks.scanSeverity, err = vulnscan.ParseSeverity(req.ScanSeverity); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	version := strings.TrimSpace(req.Version)
+	if version == "" {
+		version = imageReleaseVersion(ref)
+	} else if len(version) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version must be at most 255 characters"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	if !h.authorizeEnvironment(c, service.ProjectID, nil) {
+		return
+	}
+	if ref.Digest == "" && h.imageRegistry != nil {
+		digest, err := h.resolveImageDigest(ctx, service.ProjectID, ref.String())
+		switch {
+		case errors.Is(err, previews.ErrImageNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Image not found in the registry", "image_uri": ref.String()})
+			return
+		case err != nil:
+			// The release follows the tag; a registry outage does not block it
+			h.logger.Warn(ctx, "Failed to resolve image digest",
+				logging.String("image_uri", ref.String()),
+				logging.Error("error", err))
+		default:
+			if pinned, err := ref.WithDigest(digest); err == nil {
+				ref = pinned
+			}
+		}
+	}
+
+	status := types.ReleaseStatusReady
+	if checks.any() {
+		status = types.ReleaseStatusBuilding
+	}
+	release := &types.Release{
+		ServiceID:   serviceID,
+		Version:     version,
+		ImageURI:    ref.String(),
+		Source:      types.ReleaseSourceImage,
+		ImageDigest: ref.Digest,
+		Status:      status,
+	}
+	if err := h.repos.Releases.Create(release); err != nil {
+		if strings.Contains(err.Error(), "releases_service_id_version_key") {
+			c.JSON(http.StatusConflict, gin.H{"error": "The service already has a release with this version", "version": version})
+			return
+		}
+		h.logger.Error(ctx, "Failed to create image release", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create release"})
+		return
+	}
+
+	h.auditImageRelease(c, service, release, checks)
+
+	if !checks.any() {
+		h.publishBuildEvent(ctx, release, types.ReleaseStatusReady, "")
+		c.JSON(http.StatusCreated, release)
+		return
+	}
+
+	go h.checkImageRelease(context.Background(), service, release, checks)
+	c.JSON(http.StatusAccepted, release)
+}
+
+// imageReleaseVersion names a release after the time and the image's tag,
+// or its digest when it has no tag
+func imageReleaseVersion(ref *registry.Reference) string {
+	suffix := ref.Tag
+	if suffix == "" {
+		suffix = strings.TrimPrefix(ref.Digest, "sha256:")[:12]
+	}
+	return "v" + time.Now().Format("20060102-150405") + "-" + suffix
+}
+
+// checkImageRelease runs the requested checks of an image release and
+// marks it ready when they pass, or failed with the reason when they do not
+func (h *Handler) checkImageRelease(ctx context.Context, service *types.Service, release *types.Release, checks imageChecks) {
+	if err := h.runImageChecks(ctx, service, release, checks); err != nil {
+		h.logger.Warn(ctx, "Image release failed its checks",
+			logging.String("release_id", release.ID.String()),
+			logging.String("image_uri", release.ImageURI),
+			logging.Error("error", err))
+		message := err.Error()
+		if updateErr := h.repos.Releases.UpdateStatusWithError(release.ID, types.ReleaseStatusFailed, &message); updateErr != nil {
+			h.logger.Error(ctx, "Failed to update release status to failed",
+				logging.String("release_id", release.ID.String()),
+				logging.Error("db_error", updateErr))
+		}
+		h.publishBuildEvent(ctx, release, types.ReleaseStatusFailed, message)
+		return
+	}
+
+	if err := h.repos.Releases.UpdateStatus(release.ID, types.ReleaseStatusReady); err != nil {
+		h.logger.Error(ctx, "Failed to update release status",
+			logging.String("release_id", release.ID.String()),
+			logging.Error("db_error", err))
+		return
+	}
+	h.publishBuildEvent(ctx, release, types.ReleaseStatusReady, "")
+}
+
+// runImageChecks verifies the signature of an image and scans it, pulling
+// it with the project's registry credentials
+func (h *Handler) runImageChecks(ctx context.Context, service *types.Service, release *types.Release, checks imageChecks) error {
+	configDir, err := h.writeRegistryConfig(ctx, service.ProjectID)
+	if err != nil {
+		return err
+	}
+	if configDir != "" {
+		defer os.RemoveAll(configDir)
+	}
+
+	if checks.verifySignature {
+		// Verify against COSIGN_PUBLIC_KEY when it is set, keyless signatures otherwise
+		signer := signing.NewSigner(os.Getenv("COSIGN_PUBLIC_KEY") == "", imageCheckTimeout)
+		if err := signer.ValidateCosignInstalled(); err != nil {
+			return fmt.Errorf("signature verification is unavailable: %w", err)
+		}
+		if _, err := signer.VerifySignatureWithConfig(ctx, release.ImageURI, configDir); err != nil {
+			return fmt.Errorf("image signature is not valid: %w", err)
+		}
+		if err := h.repos.Releases.UpdateSignature(ctx, release.ID, "cosign"); err != nil {
+			h.logger.Warn(ctx, "Failed to record image signature verification",
+				logging.String("release_id", release.ID.String()),
+				logging.Error("db_error", err))
+		}
+	}
+
+	if checks.scanSeverity != "" {
+		scanner := vulnscan.NewScanner(imageCheckTimeout)
+		if err := scanner.ValidateGrypeInstalled(); err != nil {
+			return fmt.Errorf("vulnerability scanning is unavailable: %w", err)
+		}
+		report, err := scanner.ScanImage(ctx, release.ImageURI, configDir, checks.scanSeverity)
+		if err != nil {
+			return fmt.Errorf("vulnerability scan failed: %w", err)
+		}
+		if !report.Passed() {
+			return fmt.Errorf("image has %s", report.Summary())
+		}
+	}
+
+	return nil
+}
+
+// resolveImageDigest looks up the digest a tag points to with the
+// project's registry credentials
+func (h *Handler) resolveImageDigest(ctx context.Context, projectID uuid.UUID, image string) (string, error) {
+	auths, err := h.repos.RegistryCredentials.ListDecrypted(ctx, projectID, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get registry credentials: %w", err)
+	}
+	if auths, err = registry.Resolve(ctx, auths); err != nil {
+		return "", err
+	}
+	return h.imageRegistry.WithCredentials(auths).ResolveDigest(ctx, image)
+}
+
+// writeRegistryConfig writes the project's registry credentials to a
+// temporary Docker config directory for the check tools. It returns an
+// empty directory name when the project has no credentials.
+func (h *Handler) writeRegistryConfig(ctx context.Context, projectID uuid.UUID) (string, error) {
+	auths, err := h.repos.RegistryCredentials.ListDecrypted(ctx, projectID, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get registry credentials: %w", err)
+	}
+	if len(auths) == 0 {
+		return "", nil
+	}
+	if auths, err = registry.Resolve(ctx, auths); err != nil {
+		return "", err
+	}
+
+	config, err := registry.DockerConfigJSON(auths)
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp("", "enclii-registry-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create docker config directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), config, 0o600); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to write docker config: %w", err)
+	}
+	return dir, nil
+}
+
+// auditImageRelease records the creation of a release from a registry image
+func (h *Handler) auditImageRelease(c *gin.Context, service *types.Service, release *types.Release, checks imageChecks) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   fmt.Sprintf("%v", userEmail),
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       "release.image_created",
+		ResourceType: "release",
+		ResourceID:   release.ID.String(),
+		ResourceName: release.Version,
+		ProjectID:    &service.ProjectID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"service":          service.Name,
+			"image_uri":        release.ImageURI,
+			"verify_signature": checks.verifySignature,
+			"scan_severity":    string(checks.scanSeverity),
+		},
+	})
+}
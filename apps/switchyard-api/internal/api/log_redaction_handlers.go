@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// UpdateLogRedactionRequest turns log redaction of a project on or off
+type UpdateLogRedactionRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// logRedactor returns the redactor of logs of a project from source. It
+// masks the values of the project's secret env vars and common token
+// patterns unless the project turned redaction off; build logs also get the
+// project's build secrets masked regardless. Values that cannot be loaded
+// are logged and left out, so the patterns still apply.
+func (h *Handler) logRedactor(ctx context.Context, project *types.Project, source string) *redact.Redactor {
+	enabled := !project.LogRedactionDisabled
+	var values []string
+
+	if enabled {
+		secrets, err := h.repos.EnvVars.ListSecretValues(ctx, project.ID)
+		if err != nil {
+			h.logger.Warn(ctx, "Failed to load secret env vars to redact from logs",
+				logging.String("project_slug", project.Slug),
+				logging.Error("db_error", err))
+		}
+		values = append(values, secrets...)
+	}
+
+	if source == redact.SourceBuild {
+		secrets, err := h.repos.BuildSecrets.ListDecrypted(ctx, project.ID)
+		if err != nil {
+			h.logger.Warn(ctx, "Failed to load build secrets to redact from logs",
+				logging.String("project_slug", project.Slug),
+				logging.Error("db_error", err))
+		}
+		for _, value := range secrets {
+			values = append(values, value)
+		}
+	}
+
+	if !enabled && len(values) == 0 {
+		return nil
+	}
+	return newLogRedactor(source, values, enabled)
+}
+
+// newLogRedactor returns a redactor of logs from source that records what it
+// masks in metrics. Build logs are redacted by Roundhouse before they are
+// stored, and their redactions recorded then, so masking them again when
+// they are viewed records nothing.
+func newLogRedactor(source string, values []string, patterns bool) *redact.Redactor {
+	r := redact.New(values, patterns)
+	if source == redact.SourceBuild {
+		return r
+	}
+	return r.Observe(func(counts map[string]int) {
+		monitoring.RecordLogRedactions(source, counts)
+	})
+}
+
+// projectLogRedactor returns the redactor of logs from source of the
+// project with the given ID. Projects that cannot be loaded get the token
+// patterns masked.
+func (h *Handler) projectLogRedactor(ctx context.Context, projectID uuid.UUID, source string) *redact.Redactor {
+	project, err := h.repos.Projects.GetByID(ctx, projectID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get project for log redaction",
+			logging.String("project_id", projectID.String()),
+			logging.Error("db_error", err))
+		return newLogRedactor(source, nil, true)
+	}
+	return h.logRedactor(ctx, project, source)
+}
+
+// serviceLogRedactor returns the redactor of logs from source of the
+// project of a service
+func (h *Handler) serviceLogRedactor(ctx context.Context, serviceID uuid.UUID, source string) *redact.Redactor {
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get service for log redaction",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("db_error", err))
+		return newLogRedactor(source, nil, true)
+	}
+	return h.projectLogRedactor(ctx, service.ProjectID, source)
+}
+
+// GetLogRedaction returns whether a project's logs are redacted
+// GET /v1/projects/:slug/log-redaction
+func (h *Handler) GetLogRedaction(c *gin.Context) {
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_id": project.ID,
+		"enabled":    !project.LogRedactionDisabled,
+	})
+}
+
+// UpdateLogRedaction turns the redaction of token patterns and secret env
+// var values in a project's logs on or off. Build secrets stay masked.
+// PUT /v1/projects/:slug/log-redaction
+func (h *Handler) UpdateLogRedaction(c *gin.Context) {
+	var req UpdateLogRedactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.Projects.SetLogRedaction(ctx, project.ID, *req.Enabled); err != nil {
+		h.logger.Error(ctx, "Failed to update log redaction",
+			logging.String("project_slug", project.Slug),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update log redaction"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   fmt.Sprintf("%v", userEmail),
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       "project.log_redaction_updated",
+		ResourceType: "project",
+		ResourceID:   project.ID.String(),
+		ResourceName: project.Slug,
+		ProjectID:    &project.ID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"enabled": *req.Enabled,
+		},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_id": project.ID,
+		"enabled":    *req.Enabled,
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +16,8 @@ import (
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logshipping"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -54,6 +58,7 @@ func (h *Handler) getWebSocketUpgrader() *websocket.Upgrader {
 // LogStreamMessage represents a WebSocket message for log streaming
 type LogStreamMessage struct {
 	Type      string    `json:"type"` // "log", "error", "info", "connected", "disconnected"
+	Service   string    `json:"service,omitempty"`
 	Pod       string    `json:"pod,omitempty"`
 	Container string    `json:"container,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
@@ -105,6 +110,14 @@ func (h *Handler) StreamLogsWS(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get environment"})
 		return
 	}
+	k8sClient, err := h.clusterClient(ctx, env)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to connect to the environment's cluster", logging.Error("error", err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect to the environment's cluster"})
+		return
+	}
+
+	redactor := h.logRedactor(ctx, project, redact.SourceRuntime)
 
 	// Upgrade HTTP connection to WebSocket
 	conn, err := h.getWebSocketUpgrader().Upgrade(c.Writer, c.Request, nil)
@@ -159,7 +172,7 @@ func (h *Handler) StreamLogsWS(c *gin.Context) {
 	errChan := make(chan error, 10)
 
 	// Start streaming logs
-	go h.k8sClient.StreamLogs(streamCtx, k8s.LogStreamOptions{
+	go k8sClient.StreamLogs(streamCtx, k8s.LogStreamOptions{
 		Namespace:     namespace,
 		LabelSelector: labelSelector,
 		TailLines:     tailLines,
@@ -190,7 +203,7 @@ func (h *Handler) StreamLogsWS(c *gin.Context) {
 				Pod:       logLine.Pod,
 				Container: logLine.Container,
 				Timestamp: logLine.Timestamp,
-				Message:   logLine.Message,
+				Message:   redactor.Redact(logLine.Message),
 			}
 			if err := conn.WriteJSON(msg); err != nil {
 				h.logger.Error(ctx, "Failed to write log message", logging.Error("error", err))
@@ -244,6 +257,8 @@ func (h *Handler) StreamServiceLogsWS(c *gin.Context) {
 		return
 	}
 
+	redactor := h.logRedactor(ctx, project, redact.SourceRuntime)
+
 	// Upgrade HTTP connection to WebSocket
 	conn, err := h.getWebSocketUpgrader().Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -326,7 +341,7 @@ func (h *Handler) StreamServiceLogsWS(c *gin.Context) {
 				Pod:       logLine.Pod,
 				Container: logLine.Container,
 				Timestamp: logLine.Timestamp,
-				Message:   logLine.Message,
+				Message:   redactor.Redact(logLine.Message),
 			}
 			if err := conn.WriteJSON(msg); err != nil {
 				h.logger.Error(ctx, "Failed to write log message", logging.Error("error", err))
@@ -405,7 +420,7 @@ func (h *Handler) GetLogsHistory(c *gin.Context) {
 		"service_name": service.Name,
 		"environment":  envName,
 		"namespace":    namespace,
-		"logs":         logs,
+		"logs":         h.logRedactor(ctx, project, redact.SourceRuntime).Redact(logs),
 		"lines":        lines,
 	})
 }
@@ -510,6 +525,8 @@ func (h *Handler) StreamBuildLogsWS(c *gin.Context) {
 		return
 	}
 
+	redactor := h.projectLogRedactor(ctx, service.ProjectID, redact.SourceBuild)
+
 	// Upgrade HTTP connection to WebSocket
 	conn, err := h.getWebSocketUpgrader().Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -588,7 +605,7 @@ func (h *Handler) StreamBuildLogsWS(c *gin.Context) {
 					Pod:       logLine.Pod,
 					Container: logLine.Container,
 					Timestamp: logLine.Timestamp,
-					Message:   logLine.Message,
+					Message:   redactor.Redact(logLine.Message),
 				}
 				if err := conn.WriteJSON(msg); err != nil {
 					h.logger.Error(ctx, "Failed to write log message", logging.Error("error", err))
@@ -683,9 +700,12 @@ func (h *Handler) SearchLogs(c *gin.Context) {
 		return
 	}
 
-	// Simple grep-style search (in production, use a log aggregation service)
+	// Simple grep-style search (in production, use a log aggregation service).
+	// Lines are matched once redacted, so a query cannot probe for secrets.
+	redactor := h.logRedactor(ctx, project, redact.SourceRuntime)
 	var matchingLines []string
 	for _, line := range splitLines(logs) {
+		line = redactor.Redact(line)
 		if containsIgnoreCase(line, req.Query) {
 			matchingLines = append(matchingLines, line)
 		}
@@ -701,6 +721,247 @@ func (h *Handler) SearchLogs(c *gin.Context) {
 	})
 }
 
+// SearchServiceLogs searches the logs of a service over a time range. Logs
+// come from Loki when log search is configured, and otherwise from the
+// current pods, which only covers what they still hold.
+// GET /v1/services/:id/logs/search
+func (h *Handler) SearchServiceLogs(c *gin.Context) {
+	ctx := c.Request.Context()
+	envName := c.DefaultQuery("env", "development")
+
+	serviceUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(serviceUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		return
+	}
+
+	env, err := h.repos.Environments.GetByProjectAndName(project.ID, envName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+
+	query, err := parseLogSearchQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Project = project.Slug
+	query.Environment = envName
+	query.App = service.Name
+
+	result := types.LogSearchResult{
+		ServiceID:   service.ID,
+		ServiceName: service.Name,
+		Environment: envName,
+		Start:       query.Start,
+		End:         query.End,
+	}
+
+	if h.logSearch != nil {
+		result.Source = "loki"
+		result.Query, result.Entries, err = h.logSearch.Search(ctx, query)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to search logs in Loki",
+				logging.String("service_id", service.ID.String()),
+				logging.Error("error", err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to search logs"})
+			return
+		}
+		redactLogEntries(h.logRedactor(ctx, project, redact.SourceRuntime), result.Entries)
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	if query.Labels[logshipping.LabelStream] != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Filtering on stream requires log aggregation"})
+		return
+	}
+
+	result.Source = "kubernetes"
+	result.Entries, err = h.searchPodLogs(ctx, env, fmt.Sprintf("enclii-%s-%s", project.Slug, envName), service.Name, query)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to search pod logs",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search logs"})
+		return
+	}
+	redactLogEntries(h.logRedactor(ctx, project, redact.SourceRuntime), result.Entries)
+	c.JSON(http.StatusOK, result)
+}
+
+// redactLogEntries masks secrets in the lines of log entries
+func redactLogEntries(redactor *redact.Redactor, entries []types.LogEntry) {
+	if redactor == nil {
+		return
+	}
+	for i := range entries {
+		entries[i].Line = redactor.Redact(entries[i].Line)
+	}
+}
+
+// parseLogSearchQuery reads the filters and time range of a log search.
+// The range is the last hour unless since or start/end say otherwise.
+func parseLogSearchQuery(c *gin.Context) (logshipping.SearchQuery, error) {
+	query := logshipping.SearchQuery{
+		Contains: c.Query("q"),
+		Regexp:   c.Query("regex"),
+		Labels:   make(map[string]string),
+		End:      time.Now().UTC(),
+		Limit:    500,
+	}
+	for _, label := range logshipping.FilterLabels {
+		if value := c.Query(label); value != "" {
+			query.Labels[label] = value
+		}
+	}
+
+	if end := c.Query("end"); end != "" {
+		parsed, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return query, fmt.Errorf("invalid end time, expected RFC3339")
+		}
+		query.End = parsed.UTC()
+	}
+	since := time.Hour
+	if s := c.Query("since"); s != "" {
+		parsed, err := time.ParseDuration(s)
+		if err != nil || parsed <= 0 {
+			return query, fmt.Errorf("invalid since duration")
+		}
+		since = parsed
+	}
+	query.Start = query.End.Add(-since)
+	if start := c.Query("start"); start != "" {
+		parsed, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return query, fmt.Errorf("invalid start time, expected RFC3339")
+		}
+		query.Start = parsed.UTC()
+	}
+	if !query.Start.Before(query.End) {
+		return query, fmt.Errorf("start must be before end")
+	}
+
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 || parsed > logshipping.MaxSearchLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", logshipping.MaxSearchLimit)
+		}
+		query.Limit = parsed
+	}
+
+	switch c.DefaultQuery("direction", "backward") {
+	case "backward":
+	case "forward":
+		query.Forward = true
+	default:
+		return query, fmt.Errorf("direction must be backward or forward")
+	}
+
+	// Validates the labels and pattern for both sources
+	if _, err := logshipping.BuildQuery(query); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+// searchPodLogs applies a search to the logs the service's pods still hold
+func (h *Handler) searchPodLogs(ctx context.Context, env *types.Environment, namespace, app string, query logshipping.SearchQuery) ([]types.LogEntry, error) {
+	k8sClient, err := h.clusterClient(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := k8sClient.ListPods(ctx, namespace, fmt.Sprintf("app=%s", app))
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return []types.LogEntry{}, nil
+	}
+
+	var pattern *regexp.Regexp
+	if query.Regexp != "" {
+		pattern = regexp.MustCompile(query.Regexp) // Validated by parseLogSearchQuery
+	}
+
+	logChan := make(chan k8s.LogLine, 100)
+	errChan := make(chan error, 10)
+	go k8sClient.StreamLogs(ctx, k8s.LogStreamOptions{
+		Namespace:     namespace,
+		LabelSelector: fmt.Sprintf("app=%s", app),
+		Timestamps:    true,
+		SinceTime:     &query.Start,
+	}, logChan, errChan)
+
+	entries := []types.LogEntry{}
+	for logChan != nil || errChan != nil {
+		select {
+		case line, ok := <-logChan:
+			if !ok {
+				logChan = nil
+				continue
+			}
+			if pod := query.Labels[logshipping.LabelPod]; pod != "" && line.Pod != pod {
+				continue
+			}
+			if container := query.Labels[logshipping.LabelContainer]; container != "" && line.Container != container {
+				continue
+			}
+			if line.Timestamp.After(query.End) {
+				continue
+			}
+			if query.Contains != "" && !containsIgnoreCase(line.Message, query.Contains) {
+				continue
+			}
+			if pattern != nil && !pattern.MatchString(line.Message) {
+				continue
+			}
+			entries = append(entries, types.LogEntry{
+				Timestamp: line.Timestamp.UTC(),
+				Pod:       line.Pod,
+				Container: line.Container,
+				Line:      line.Message,
+			})
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			// A pod that went away should not fail the whole search
+			h.logger.Warn(ctx, "Failed to read pod logs for search", logging.Error("error", err))
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if query.Forward {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		}
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	if len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+	}
+	return entries, nil
+}
+
 // Helper functions
 func splitLines(s string) []string {
 	var lines []string
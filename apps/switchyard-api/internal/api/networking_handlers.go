@@ -78,6 +78,8 @@ func (h *Handler) GetServiceNetworking(c *gin.Context) {
 			DNSVerifiedAt:    domain.VerifiedAt,
 			VerificationTXT:  verificationTXT,
 			DNSCNAME:         domain.DNSCNAME,
+			DNSMode:          domain.DNSMode,
+			DNSStatus:        domain.DNSStatus,
 			CreatedAt:        domain.CreatedAt,
 		}
 
@@ -186,12 +188,13 @@ type AddDomainRequest struct {
 	IsPlatformDomain bool   `json:"is_platform_domain"`
 	TLSProvider      string `json:"tls_provider"`
 	ZeroTrustEnabled bool   `json:"zero_trust_enabled"`
+	DNSMode          string `json:"dns_mode"` // "managed", "manual", or empty to manage DNS when the team has connected the zone
 }
 
 // AddServiceDomain adds a domain to a service (enhanced version)
 // POST /api/v1/services/:service_id/domains
 func (h *Handler) AddServiceDomain(c *gin.Context) {
-	serviceID := c.Param("service_id")
+	serviceID := c.Param("id")
 	if serviceID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "service_id is required"})
 		return
@@ -252,6 +255,9 @@ func (h *Handler) AddServiceDomain(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid domain format"})
 		return
 	}
+	if !h.checkDomainClaim(c, domainName, req.IsPlatformDomain) {
+		return
+	}
 
 	// Check if domain is already in use
 	exists, err := h.repos.CustomDomains.Exists(ctx, domainName)
@@ -293,6 +299,11 @@ func (h *Handler) AddServiceDomain(c *gin.Context) {
 	}
 	dnsCNAME := fmt.Sprintf("tunnel.%s", platformDomain)
 
+	dnsMode, dnsProviderID, ok := h.resolveDNSMode(c, serviceUUID, domainName, req.DNSMode)
+	if !ok {
+		return
+	}
+
 	// Create custom domain
 	domain := &types.CustomDomain{
 		ServiceID:        serviceUUID,
@@ -306,6 +317,8 @@ func (h *Handler) AddServiceDomain(c *gin.Context) {
 		TLSProvider:      tlsProvider,
 		Status:           status,
 		DNSCNAME:         dnsCNAME,
+		DNSMode:          dnsMode,
+		DNSProviderID:    dnsProviderID,
 	}
 
 	if err := h.repos.CustomDomains.Create(ctx, domain); err != nil {
@@ -314,6 +327,10 @@ func (h *Handler) AddServiceDomain(c *gin.Context) {
 		return
 	}
 
+	// Write the DNS record through the team's provider; on failure the user
+	// gets manual instructions while the record is retried in the background
+	dnsManaged := h.applyManagedDNS(c, domain)
+
 	// Trigger reconciliation for platform domains
 	if req.IsPlatformDomain {
 		go h.triggerDomainReconciliation(ctx, serviceUUID, envUUID)
@@ -325,8 +342,10 @@ func (h *Handler) AddServiceDomain(c *gin.Context) {
 		"message": "Domain added successfully",
 	}
 
-	// Add DNS instructions for custom domains
-	if !req.IsPlatformDomain {
+	// Add DNS instructions for custom domains whose record Enclii did not write
+	if dnsManaged {
+		response["message"] = "Domain added and DNS record created"
+	} else if !req.IsPlatformDomain {
 		response["dns_instructions"] = gin.H{
 			"verification": gin.H{
 				"type":  "TXT",
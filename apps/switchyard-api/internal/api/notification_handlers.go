@@ -3,14 +3,19 @@ package api
 import (
 	"database/sql"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
+// maxWebhookDeliveriesLimit caps how many deliveries one request returns
+const maxWebhookDeliveriesLimit = 200
+
 // CreateWebhookRequest defines the request body for creating a webhook destination
 type CreateWebhookRequest struct {
 	Name             string                   `json:"name" binding:"required"`
@@ -20,7 +25,9 @@ type CreateWebhookRequest struct {
 	TelegramChatID   string                   `json:"telegram_chat_id,omitempty"`
 	Events           []types.WebhookEventType `json:"events" binding:"required,min=1"`
 	CustomHeaders    map[string]string        `json:"custom_headers,omitempty"`
-	SigningSecret    string                   `json:"signing_secret,omitempty"`
+	// SigningSecret signs custom webhook payloads; one is generated when
+	// left empty
+	SigningSecret string `json:"signing_secret,omitempty"`
 }
 
 // UpdateWebhookRequest defines the request body for updating a webhook destination
@@ -82,6 +89,17 @@ func (h *Handler) CreateWebhook(c *gin.Context) {
 		return
 	}
 
+	// Custom webhooks are always signed so receivers can verify payloads
+	signingSecret := req.SigningSecret
+	if req.Type == types.WebhookTypeCustom && signingSecret == "" {
+		signingSecret, err = notifications.GenerateSigningSecret()
+		if err != nil {
+			h.logger.Error(ctx, "Failed to generate signing secret", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+			return
+		}
+	}
+
 	// Create the webhook destination
 	webhook := &types.WebhookDestination{
 		ProjectID:        project.ID,
@@ -93,7 +111,7 @@ func (h *Handler) CreateWebhook(c *gin.Context) {
 		Events:           req.Events,
 		Enabled:          true,
 		CustomHeaders:    req.CustomHeaders,
-		SigningSecret:    req.SigningSecret,
+		SigningSecret:    signingSecret,
 	}
 
 	if err := h.repos.Webhooks.Create(ctx, webhook); err != nil {
@@ -106,10 +124,15 @@ func (h *Handler) CreateWebhook(c *gin.Context) {
 	webhook.TelegramBotToken = ""
 	webhook.SigningSecret = ""
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"webhook": webhook,
 		"message": "Webhook created successfully",
-	})
+	}
+	// The signing secret is only ever shown here and when it is rotated
+	if signingSecret != "" {
+		response["signing_secret"] = signingSecret
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // ListWebhooks lists all webhook destinations for a project
@@ -339,8 +362,9 @@ func (h *Handler) TestWebhook(c *gin.Context) {
 	})
 }
 
-// ListWebhookDeliveries lists delivery history for a webhook
-// GET /v1/webhooks/:id/deliveries
+// ListWebhookDeliveries lists delivery history for a webhook, newest first,
+// optionally filtered by status
+// GET /v1/webhooks/:id/deliveries?status=dead&limit=50
 func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
 	ctx := c.Request.Context()
 	idStr := c.Param("id")
@@ -363,8 +387,16 @@ func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
 		return
 	}
 
-	// Get deliveries (limited to last 50)
-	deliveries, err := h.repos.Webhooks.ListDeliveries(ctx, id, 50)
+	status := types.WebhookDeliveryStatus(c.Query("status"))
+	switch status {
+	case "", types.WebhookDeliveryStatusPending, types.WebhookDeliveryStatusSuccess, types.WebhookDeliveryStatusFailed,
+		types.WebhookDeliveryStatusRetrying, types.WebhookDeliveryStatusDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, success, failed, retrying, or dead"})
+		return
+	}
+
+	deliveries, err := h.repos.Webhooks.ListDeliveries(ctx, id, status, webhookDeliveriesLimit(c))
 	if err != nil {
 		h.logger.Error(ctx, "Failed to list deliveries", logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list deliveries"})
@@ -374,9 +406,10 @@ func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
 }
 
-// RetryWebhookDelivery retries a failed webhook delivery
-// POST /v1/webhooks/:id/deliveries/:delivery_id/retry
-func (h *Handler) RetryWebhookDelivery(c *gin.Context) {
+// ReplayWebhookDelivery sends the payload of a past delivery again as a new
+// delivery, e.g. to recover a dead letter once the receiver is fixed
+// POST /v1/webhooks/:id/deliveries/:delivery_id/replay
+func (h *Handler) ReplayWebhookDelivery(c *gin.Context) {
 	ctx := c.Request.Context()
 	webhookIDStr := c.Param("id")
 	deliveryIDStr := c.Param("delivery_id")
@@ -429,17 +462,109 @@ func (h *Handler) RetryWebhookDelivery(c *gin.Context) {
 		return
 	}
 
-	// Retry the delivery
-	if err := h.notificationService.RetryDelivery(ctx, webhook, delivery); err != nil {
-		h.logger.Error(ctx, "Failed to retry delivery", logging.Error("error", err))
+	replay, err := h.notificationService.ReplayDelivery(ctx, webhook, delivery)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to replay delivery", logging.Error("error", err))
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "retry failed",
+			"error":   "replay failed",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Delivery retry initiated"})
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": replay,
+		"message":  "Delivery replayed",
+	})
+}
+
+// RotateWebhookSecret replaces the signing secret of a custom webhook and
+// returns the new one. Deliveries are signed with it from now on.
+// POST /v1/webhooks/:id/rotate-secret
+func (h *Handler) RotateWebhookSecret(c *gin.Context) {
+	ctx := c.Request.Context()
+	idStr := c.Param("id")
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return
+	}
+
+	webhook, err := h.repos.Webhooks.GetByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get webhook", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get webhook"})
+		return
+	}
+
+	if webhook.Type != types.WebhookTypeCustom {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only custom webhooks have a signing secret"})
+		return
+	}
+
+	signingSecret, err := notifications.GenerateSigningSecret()
+	if err != nil {
+		h.logger.Error(ctx, "Failed to generate signing secret", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate signing secret"})
+		return
+	}
+	webhook.SigningSecret = signingSecret
+
+	if err := h.repos.Webhooks.Update(ctx, webhook); err != nil {
+		h.logger.Error(ctx, "Failed to update webhook", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate signing secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"signing_secret": signingSecret,
+		"message":        "Signing secret rotated",
+	})
+}
+
+// ListWebhookDeadLetters lists the deliveries of a project's webhooks that
+// ran out of attempts, newest first
+// GET /v1/projects/:slug/webhooks/dead-letters?limit=50
+func (h *Handler) ListWebhookDeadLetters(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+
+	project, err := h.repos.Projects.GetBySlug(slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return
+	}
+
+	deliveries, err := h.repos.Webhooks.ListDeadLetters(ctx, project.ID, webhookDeliveriesLimit(c))
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list dead letters", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list dead letters"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// webhookDeliveriesLimit reads the limit query parameter of delivery lists
+func webhookDeliveriesLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > maxWebhookDeliveriesLimit {
+		limit = maxWebhookDeliveriesLimit
+	}
+	return limit
 }
 
 // GetWebhookEventTypes returns available webhook event types
@@ -455,10 +580,15 @@ func (h *Handler) GetWebhookEventTypes(c *gin.Context) {
 		{types.WebhookEventDeploymentSucceeded, "deployment", "Deployment completed successfully"},
 		{types.WebhookEventDeploymentFailed, "deployment", "Deployment failed"},
 		{types.WebhookEventDeploymentCancelled, "deployment", "Deployment was cancelled"},
+		{types.WebhookEventDeploymentStalled, "deployment", "Deployment is stuck pending"},
+		{types.WebhookEventDeploymentRolledBack, "deployment", "Deployment failed its soak or deploy guard and the previous release was deployed again"},
+		// Deployment group events
+		{types.WebhookEventDeploymentGroupReminder, "deployment_group", "Scheduled deployment group runs soon"},
 		// Build events
 		{types.WebhookEventBuildStarted, "build", "Build has started"},
 		{types.WebhookEventBuildSucceeded, "build", "Build completed successfully"},
 		{types.WebhookEventBuildFailed, "build", "Build failed"},
+		{types.WebhookEventBuildStalled, "build", "Build has been pending too long"},
 		// Service events
 		{types.WebhookEventServiceCreated, "service", "New service was created"},
 		{types.WebhookEventServiceDeleted, "service", "Service was deleted"},
@@ -468,6 +598,19 @@ func (h *Handler) GetWebhookEventTypes(c *gin.Context) {
 		// Database events
 		{types.WebhookEventDatabaseReady, "database", "Database is ready"},
 		{types.WebhookEventDatabaseFailed, "database", "Database provisioning failed"},
+		// Budget events
+		{types.WebhookEventBudgetThreshold, "budget", "Spend reached 50%, 80% or 100% of the monthly budget"},
+		{types.WebhookEventBudgetCapExceeded, "budget", "Spend exceeded the hard cap; non-production services were stopped"},
+		// Alert events
+		{types.WebhookEventAlertFiring, "alert", "A service alert rule's condition held long enough to fire"},
+		{types.WebhookEventAlertResolved, "alert", "The condition of a firing alert cleared"},
+
+		// Uptime events
+		{types.WebhookEventUptimeDown, "uptime", "An uptime check of a service endpoint failed enough times in a row to be down"},
+		{types.WebhookEventUptimeRecovered, "uptime", "A down service endpoint answered an uptime check again"},
+
+		// Secret events
+		{types.WebhookEventSecretRotationDue, "secret", "A rotatable secret is older than its max age"},
 	}
 
 	c.JSON(http.StatusOK, gin.H{"event_types": eventTypes})
@@ -0,0 +1,459 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// defaultOneOffJobTimeout bounds a one-off job that sets no timeout
+	defaultOneOffJobTimeout = time.Hour
+
+	// maxOneOffJobTimeout is the longest a one-off job may run
+	maxOneOffJobTimeout = 24 * time.Hour
+
+	// oneOffJobPollInterval is how often a job's pod is checked while waiting
+	// for it to start or finish
+	oneOffJobPollInterval = 2 * time.Second
+)
+
+// RunOneOffJobRequest is the request body for running a one-off job
+type RunOneOffJobRequest struct {
+	Environment    string   `json:"environment"`
+	Command        []string `json:"command" binding:"required"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+}
+
+// RunOneOffJob runs a command once in the image and configuration the
+// service is deployed with in an environment
+// POST /v1/services/:id/jobs
+func (h *Handler) RunOneOffJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	var req RunOneOffJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	timeout, err := validateOneOffJob(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return
+	}
+	env, err := h.repos.Environments.GetByProjectAndName(service.ProjectID, req.Environment)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found", "environment": req.Environment})
+		return
+	}
+	if !h.authorizeEnvironment(c, service.ProjectID, &env.ID) {
+		return
+	}
+
+	if h.k8sClient == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Kubernetes client not available"})
+		return
+	}
+	k8sClient, err := h.clusterClient(ctx, env)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to connect to the environment's cluster", logging.Error("error", err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect to the environment's cluster"})
+		return
+	}
+
+	userEmail, _ := c.Get("user_email")
+	job := &types.OneOffJob{
+		ID:             uuid.New(),
+		ServiceID:      service.ID,
+		EnvironmentID:  env.ID,
+		Command:        req.Command,
+		K8sNamespace:   kubeNamespace(project.Slug, env),
+		TimeoutSeconds: int(timeout / time.Second),
+	}
+	job.K8sJobName = oneOffJobName(service.Name, job.ID)
+	if userEmail != nil {
+		job.CreatedBy = fmt.Sprintf("%v", userEmail)
+	}
+
+	if err := h.repos.OneOffJobs.Create(ctx, job); err != nil {
+		h.logger.Error(ctx, "Failed to create one-off job", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create job"})
+		return
+	}
+
+	image, err := k8sClient.RunOneOffJob(ctx, &k8s.OneOffJobSpec{
+		Namespace:   job.K8sNamespace,
+		ServiceName: service.Name,
+		JobName:     job.K8sJobName,
+		Command:     job.Command,
+		Timeout:     timeout,
+		Labels: map[string]string{
+			"enclii.dev/managed-by": "switchyard",
+			"enclii.dev/type":       "one-off-job",
+			"enclii.dev/service":    service.Name,
+			"enclii.dev/job-id":     job.ID.String(),
+		},
+	})
+	if err != nil {
+		now := time.Now()
+		job.Status = types.OneOffJobStatusFailed
+		job.Message = err.Error()
+		job.FinishedAt = &now
+		h.repos.OneOffJobs.UpdateStatus(ctx, job)
+
+		if k8serrors.IsNotFound(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Service %s is not deployed to %s", service.Name, env.Name)})
+			return
+		}
+		h.logger.Error(ctx, "Failed to start one-off job",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start job"})
+		return
+	}
+
+	job.Image = image
+	if err := h.repos.OneOffJobs.UpdateStatus(ctx, job); err != nil {
+		h.logger.Warn(ctx, "Failed to record one-off job image", logging.Error("error", err))
+	}
+
+	h.auditOneOffJob(c, project, service, job)
+
+	h.logger.Info(ctx, "One-off job started",
+		logging.String("job_id", job.ID.String()),
+		logging.String("service", service.Name),
+		logging.String("environment", env.Name))
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetOneOffJob returns a one-off job, refreshed from its pod while it runs
+// GET /v1/jobs/:id
+func (h *Handler) GetOneOffJob(c *gin.Context) {
+	job := h.loadOneOffJob(c)
+	if job == nil {
+		return
+	}
+
+	if err := h.refreshOneOffJob(c.Request.Context(), job); err != nil {
+		h.logger.Warn(c.Request.Context(), "Failed to refresh one-off job",
+			logging.String("job_id", job.ID.String()),
+			logging.Error("error", err))
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// StreamOneOffJobLogsWS streams the output of a one-off job over a
+// WebSocket once its pod starts, and closes the connection when the command
+// exits. The final message reports the job's status and exit code.
+// GET /v1/jobs/:id/logs/stream
+func (h *Handler) StreamOneOffJobLogsWS(c *gin.Context) {
+	job := h.loadOneOffJob(c)
+	if job == nil {
+		return
+	}
+	if h.k8sClient == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Kubernetes client not available"})
+		return
+	}
+	ctx := c.Request.Context()
+	k8sClient, err := h.environmentClient(ctx, job.EnvironmentID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to connect to the environment's cluster", logging.Error("error", err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to connect to the environment's cluster"})
+		return
+	}
+
+	conn, err := h.getWebSocketUpgrader().Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to upgrade to WebSocket", logging.Error("error", err))
+		return
+	}
+	defer conn.Close()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Handle WebSocket close
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	conn.WriteJSON(LogStreamMessage{
+		Type:      "connected",
+		Timestamp: time.Now(),
+		Message:   fmt.Sprintf("Waiting for job %s to start", job.K8sJobName),
+	})
+
+	// Logs can only be followed once the container runs
+	for job.StartedAt == nil && !oneOffJobFinished(job) {
+		select {
+		case <-streamCtx.Done():
+			return
+		case <-time.After(oneOffJobPollInterval):
+		}
+		if err := h.refreshOneOffJob(streamCtx, job); err != nil {
+			conn.WriteJSON(LogStreamMessage{Type: "error", Timestamp: time.Now(), Message: err.Error()})
+			return
+		}
+	}
+
+	redactor := h.serviceLogRedactor(streamCtx, job.ServiceID, redact.SourceRuntime)
+	logChan := make(chan k8s.LogLine, 100)
+	errChan := make(chan error, 10)
+	go k8sClient.StreamLogs(streamCtx, k8s.LogStreamOptions{
+		Namespace:     job.K8sNamespace,
+		LabelSelector: "job-name=" + job.K8sJobName,
+		Follow:        true,
+	}, logChan, errChan)
+
+	for logChan != nil || errChan != nil {
+		select {
+		case <-streamCtx.Done():
+			return
+		case line, ok := <-logChan:
+			if !ok {
+				logChan = nil
+				continue
+			}
+			if err := conn.WriteJSON(LogStreamMessage{
+				Type:      "log",
+				Pod:       line.Pod,
+				Container: line.Container,
+				Timestamp: line.Timestamp,
+				Message:   redactor.Redact(line.Message),
+			}); err != nil {
+				return
+			}
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			conn.WriteJSON(LogStreamMessage{Type: "error", Timestamp: time.Now(), Message: err.Error()})
+		}
+	}
+
+	// The log stream ends with the container; the exit code may lag behind
+	for !oneOffJobFinished(job) {
+		if err := h.refreshOneOffJob(streamCtx, job); err != nil {
+			break
+		}
+		if oneOffJobFinished(job) {
+			break
+		}
+		select {
+		case <-streamCtx.Done():
+			return
+		case <-time.After(oneOffJobPollInterval):
+		}
+	}
+
+	conn.WriteJSON(LogStreamMessage{
+		Type:      "disconnected",
+		Timestamp: time.Now(),
+		Message:   oneOffJobSummary(job),
+	})
+}
+
+// loadOneOffJob loads the job of the :id parameter and authorizes access to
+// its environment, answering the request when either fails
+func (h *Handler) loadOneOffJob(c *gin.Context) *types.OneOffJob {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return nil
+	}
+
+	job, err := h.repos.OneOffJobs.GetByID(c.Request.Context(), jobID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get one-off job", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get job"})
+		return nil
+	}
+
+	if !h.authorizeServiceEnvironment(c, job.ServiceID, &job.EnvironmentID) {
+		return nil
+	}
+	return job
+}
+
+// refreshOneOffJob updates a running job from its pod and records changes
+func (h *Handler) refreshOneOffJob(ctx context.Context, job *types.OneOffJob) error {
+	if oneOffJobFinished(job) || h.k8sClient == nil {
+		return nil
+	}
+
+	k8sClient, err := h.environmentClient(ctx, job.EnvironmentID)
+	if err != nil {
+		return err
+	}
+	result, err := k8sClient.GetOneOffJobResult(ctx, job.K8sNamespace, job.K8sJobName)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			// The Job was deleted before its result was recorded
+			now := time.Now()
+			job.Status = types.OneOffJobStatusFailed
+			job.Message = "job was deleted before it finished"
+			job.FinishedAt = &now
+			return h.repos.OneOffJobs.UpdateStatus(ctx, job)
+		}
+		return err
+	}
+
+	if !applyOneOffJobResult(job, result, time.Now()) {
+		return nil
+	}
+	return h.repos.OneOffJobs.UpdateStatus(ctx, job)
+}
+
+// applyOneOffJobResult moves a job forward to the progress of its pod. It
+// reports whether the job changed.
+func applyOneOffJobResult(job *types.OneOffJob, result *k8s.OneOffJobResult, now time.Time) bool {
+	changed := false
+	if result.Started && job.StartedAt == nil {
+		job.StartedAt = &now
+		job.Status = types.OneOffJobStatusRunning
+		changed = true
+	}
+	if result.Message != job.Message && !result.Finished {
+		job.Message = result.Message
+		changed = true
+	}
+	if result.Finished {
+		job.ExitCode = result.ExitCode
+		job.Message = result.Message
+		job.FinishedAt = &now
+		job.Status = types.OneOffJobStatusFailed
+		if result.ExitCode != nil && *result.ExitCode == 0 {
+			job.Status = types.OneOffJobStatusSucceeded
+		}
+		changed = true
+	}
+	return changed
+}
+
+// auditOneOffJob records a command run against a service
+func (h *Handler) auditOneOffJob(c *gin.Context, project *types.Project, service *types.Service, job *types.OneOffJob) {
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    job.CreatedBy,
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		Action:        "service.job_run",
+		ResourceType:  "service",
+		ResourceID:    service.ID.String(),
+		ResourceName:  service.Name,
+		ProjectID:     &project.ID,
+		EnvironmentID: &job.EnvironmentID,
+		Outcome:       "success",
+		Context: map[string]interface{}{
+			"job_id":  job.ID.String(),
+			"command": strings.Join(job.Command, " "),
+			"image":   job.Image,
+		},
+	})
+}
+
+// validateOneOffJob checks a run request and returns the job's timeout
+func validateOneOffJob(req *RunOneOffJobRequest) (time.Duration, error) {
+	if len(req.Command) == 0 || strings.TrimSpace(req.Command[0]) == "" {
+		return 0, fmt.Errorf("command is required")
+	}
+	if req.Environment == "" {
+		req.Environment = "development"
+	}
+
+	timeout := defaultOneOffJobTimeout
+	if req.TimeoutSeconds < 0 {
+		return 0, fmt.Errorf("timeout_seconds must be positive")
+	}
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	if timeout > maxOneOffJobTimeout {
+		return 0, fmt.Errorf("timeout_seconds must be at most %d", int(maxOneOffJobTimeout/time.Second))
+	}
+	return timeout, nil
+}
+
+// oneOffJobName names the Kubernetes Job of a one-off job, within the 63
+// characters allowed for the job-name label of its pod
+func oneOffJobName(serviceName string, id uuid.UUID) string {
+	suffix := "-run-" + id.String()[:8]
+	if len(serviceName) > 63-len(suffix) {
+		serviceName = strings.TrimRight(serviceName[:63-len(suffix)], "-")
+	}
+	return serviceName + suffix
+}
+
+func oneOffJobFinished(job *types.OneOffJob) bool {
+	return job.Status == types.OneOffJobStatusSucceeded || job.Status == types.OneOffJobStatusFailed
+}
+
+// oneOffJobSummary describes how a job ended
+func oneOffJobSummary(job *types.OneOffJob) string {
+	summary := fmt.Sprintf("Job %s", job.Status)
+	if job.ExitCode != nil {
+		summary += fmt.Sprintf(" with exit code %d", *job.ExitCode)
+	}
+	if job.Message != "" {
+		summary += ": " + job.Message
+	}
+	return summary
+}
+
+// kubeNamespace returns the namespace of an environment, falling back to the
+// naming convention for environments created before namespaces were stored
+func kubeNamespace(projectSlug string, env *types.Environment) string {
+	if env.KubeNamespace != "" {
+		return env.KubeNamespace
+	}
+	return fmt.Sprintf("enclii-%s-%s", projectSlug, env.Name)
+}
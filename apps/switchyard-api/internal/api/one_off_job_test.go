@@ -0,0 +1,79 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateOneOffJob(t *testing.T) {
+	req := &RunOneOffJobRequest{Command: []string{"rails", "db:migrate"}}
+	timeout, err := validateOneOffJob(req)
+	if err != nil || timeout != defaultOneOffJobTimeout {
+		t.Errorf("validateOneOffJob() = %v, %v; want the default timeout", timeout, err)
+	}
+	if req.Environment != "development" {
+		t.Errorf("environment = %q, want development", req.Environment)
+	}
+
+	req = &RunOneOffJobRequest{Command: []string{"sh"}, TimeoutSeconds: 90}
+	if timeout, err := validateOneOffJob(req); err != nil || timeout != 90*time.Second {
+		t.Errorf("validateOneOffJob() = %v, %v; want 90s", timeout, err)
+	}
+
+	for _, invalid := range []*RunOneOffJobRequest{
+		{Command: []string{" "}},
+		{Command: []string{"sh"}, TimeoutSeconds: -1},
+		{Command: []string{"sh"}, TimeoutSeconds: int(maxOneOffJobTimeout/time.Second) + 1},
+	} {
+		if _, err := validateOneOffJob(invalid); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
+
+func TestOneOffJobName(t *testing.T) {
+	id := uuid.MustParse("3f2b8c1d-0000-4000-8000-000000000000")
+	if name := oneOffJobName("api", id); name != "api-run-3f2b8c1d" {
+		t.Errorf("oneOffJobName() = %q", name)
+	}
+
+	long := oneOffJobName(strings.Repeat("a", 40)+"-"+strings.Repeat("b", 30), id)
+	if len(long) > 63 || !strings.HasSuffix(long, "-run-3f2b8c1d") || strings.Contains(long, "--") {
+		t.Errorf("oneOffJobName() = %q, want a valid label value", long)
+	}
+}
+
+func TestApplyOneOffJobResult(t *testing.T) {
+	now := time.Now()
+	job := &types.OneOffJob{Status: types.OneOffJobStatusPending}
+
+	if applyOneOffJobResult(job, &k8s.OneOffJobResult{}, now) {
+		t.Error("a pending pod should not change the job")
+	}
+
+	if !applyOneOffJobResult(job, &k8s.OneOffJobResult{Started: true}, now) || job.Status != types.OneOffJobStatusRunning || job.StartedAt == nil {
+		t.Errorf("started job = %+v, want running", job)
+	}
+
+	code := 3
+	applyOneOffJobResult(job, &k8s.OneOffJobResult{Started: true, Finished: true, ExitCode: &code, Message: "Error"}, now)
+	if job.Status != types.OneOffJobStatusFailed || job.ExitCode == nil || *job.ExitCode != 3 || job.FinishedAt == nil {
+		t.Errorf("finished job = %+v, want failed with exit code 3", job)
+	}
+	if got := oneOffJobSummary(job); got != "Job failed with exit code 3: Error" {
+		t.Errorf("oneOffJobSummary() = %q", got)
+	}
+
+	code = 0
+	job = &types.OneOffJob{Status: types.OneOffJobStatusRunning}
+	applyOneOffJobResult(job, &k8s.OneOffJobResult{Started: true, Finished: true, ExitCode: &code}, now)
+	if job.Status != types.OneOffJobStatusSucceeded {
+		t.Errorf("status = %s, want succeeded", job.Status)
+	}
+}
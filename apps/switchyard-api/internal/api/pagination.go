@@ -1,9 +1,18 @@
 package api
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 )
 
 // PaginationParams holds pagination parameters
@@ -95,3 +104,140 @@ func NewPaginatedData(data interface{}, params PaginationParams, total int64) Pa
 		Pagination: params.BuildPaginationResponse(total),
 	}
 }
+
+// ListQuery is the pagination, sorting and filtering of a cursor-paginated
+// list request:
+//
+//	?limit=50&cursor=...&sort=created_at&order=desc&status=running&since=2026-01-01
+//
+// Without a limit a page holds MaxPageSize items.
+type ListQuery struct {
+	db.ListParams
+	// Environment is the environment filter as given, a name or an ID; the
+	// handler resolves it into EnvironmentID
+	Environment string
+}
+
+// GetListQuery parses the list parameters of a request. sorts are the sort
+// keys the list supports, the first being its default. Sorting by name
+// defaults to ascending order, other keys to newest first.
+func GetListQuery(c *gin.Context, sorts ...string) (ListQuery, error) {
+	q := ListQuery{ListParams: db.ListParams{Limit: MaxPageSize}}
+
+	if l := c.Query("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 1 {
+			return q, errors.New("limit must be a positive number")
+		}
+		q.Limit = min(limit, MaxPageSize)
+	}
+
+	q.Sort = c.DefaultQuery("sort", sorts[0])
+	if !slices.Contains(sorts, q.Sort) {
+		return q, fmt.Errorf("sort must be one of %s", strings.Join(sorts, ", "))
+	}
+	switch c.Query("order") {
+	case "":
+		q.Descending = q.Sort != "name"
+	case "asc":
+		q.Descending = false
+	case "desc":
+		q.Descending = true
+	default:
+		return q, errors.New("order must be asc or desc")
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err := decodeListCursor(cursor, q.ListParams)
+		if err != nil {
+			return q, err
+		}
+		q.After = after
+	}
+
+	q.Status = c.Query("status")
+	q.Environment = c.Query("environment")
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		at, err := parseListTime(value)
+		if err != nil {
+			return q, fmt.Errorf("%s must be an RFC 3339 time or a YYYY-MM-DD date", bound.name)
+		}
+		*bound.dst = &at
+	}
+	return q, nil
+}
+
+func parseListTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// listCursorTime formats a timestamp sort value of a cursor
+func listCursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// encodeListCursor makes the opaque cursor of the page after the item at
+// position. The cursor carries the sort it was made for, so it cannot be
+// used with another one.
+func encodeListCursor(params db.ListParams, position *db.Cursor) string {
+	if position == nil {
+		return ""
+	}
+	raw := strings.Join([]string{params.Sort, listOrder(params), position.ID.String(), position.Value}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func listOrder(params db.ListParams) string {
+	if params.Descending {
+		return "desc"
+	}
+	return "asc"
+}
+
+func decodeListCursor(cursor string, params db.ListParams) (*db.Cursor, error) {
+	invalid := errors.New("invalid cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	parts := strings.SplitN(string(raw), "|", 4)
+	if len(parts) != 4 {
+		return nil, invalid
+	}
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return nil, invalid
+	}
+	if parts[0] != params.Sort || parts[1] != listOrder(params) {
+		return nil, errors.New("cursor was made for another sort order")
+	}
+	if parts[0] != "name" {
+		if _, err := time.Parse(time.RFC3339Nano, parts[3]); err != nil {
+			return nil, invalid
+		}
+	}
+	return &db.Cursor{Value: parts[3], ID: id}, nil
+}
+
+// itemCursor is the position of an item in a list sorted by name,
+// created_at or updated_at
+func itemCursor(sort string, id uuid.UUID, name string, createdAt, updatedAt time.Time) db.Cursor {
+	switch sort {
+	case "name":
+		return db.Cursor{Value: name, ID: id}
+	case "updated_at":
+		return db.Cursor{Value: listCursorTime(updatedAt), ID: id}
+	default:
+		return db.Cursor{Value: listCursorTime(createdAt), ID: id}
+	}
+}
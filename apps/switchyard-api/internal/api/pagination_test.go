@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+func listQueryContext(rawQuery string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/v1/things?"+rawQuery, nil)
+	return c
+}
+
+func TestGetListQuery(t *testing.T) {
+	q, err := GetListQuery(listQueryContext(""), "created_at", "name")
+	if err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+	if q.Limit != MaxPageSize || q.Sort != "created_at" || !q.Descending || q.After != nil {
+		t.Errorf("defaults = %+v, want a full page of the newest first", q.ListParams)
+	}
+
+	q, err = GetListQuery(listQueryContext("limit=500&sort=name&status=running&environment=production&since=2026-10-01&until=2026-10-17T12:00:00Z"), "created_at", "name")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if q.Limit != MaxPageSize {
+		t.Errorf("limit = %d, want it capped at %d", q.Limit, MaxPageSize)
+	}
+	if q.Sort != "name" || q.Descending {
+		t.Errorf("sort by name should default to ascending, got descending=%v", q.Descending)
+	}
+	if q.Status != "running" || q.Environment != "production" {
+		t.Errorf("filters = %q %q", q.Status, q.Environment)
+	}
+	if q.Since == nil || !q.Since.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("since = %v", q.Since)
+	}
+	if q.Until == nil || !q.Until.Equal(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("until = %v", q.Until)
+	}
+
+	for _, invalid := range []string{"limit=0", "limit=x", "sort=updated_at", "order=up", "since=yesterday", "cursor=bm9wZQ"} {
+		if _, err := GetListQuery(listQueryContext(invalid), "created_at", "name"); err == nil {
+			t.Errorf("%s: want an error", invalid)
+		}
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	params := db.ListParams{Sort: "created_at", Descending: true}
+	position := itemCursor("created_at", uuid.New(), "api",
+		time.Date(2026, 10, 17, 12, 30, 0, 123456000, time.FixedZone("CST", -6*3600)), time.Time{})
+
+	encoded := encodeListCursor(params, &position)
+	q, err := GetListQuery(listQueryContext("cursor="+encoded), "created_at")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if *q.After != position {
+		t.Errorf("cursor = %+v, want %+v", *q.After, position)
+	}
+	if position.Value != "2026-10-17T18:30:00.123456Z" {
+		t.Errorf("time values are kept in UTC, got %q", position.Value)
+	}
+
+	// A cursor only continues the sort it was made for
+	if _, err := GetListQuery(listQueryContext("order=asc&cursor="+encoded), "created_at"); err == nil {
+		t.Error("cursor of a descending list accepted for an ascending one")
+	}
+	if encodeListCursor(params, nil) != "" {
+		t.Error("the last page should have no next cursor")
+	}
+}
+
+func TestTrimPage(t *testing.T) {
+	params := db.ListParams{Limit: 2}
+	cursor := func(n int) db.Cursor { return db.Cursor{Value: string(rune('a' + n))} }
+
+	items, next := db.TrimPage([]int{0, 1, 2}, params, cursor)
+	if len(items) != 2 || next == nil || next.Value != "b" {
+		t.Errorf("got %v next %v, want two items and a cursor at the second", items, next)
+	}
+	items, next = db.TrimPage([]int{0, 1}, params, cursor)
+	if len(items) != 2 || next != nil {
+		t.Errorf("got %v next %v, want the last page", items, next)
+	}
+}
@@ -0,0 +1,464 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// platformMetricsWindow is how far back the build metrics of
+// GET /v1/admin/metrics reach
+const platformMetricsWindow = 24 * time.Hour
+
+// SuspendTeamRequest suspends a team
+type SuspendTeamRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ImpersonateRequest asks for a support session as a user, given by ID or
+// by email
+type ImpersonateRequest struct {
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	Email  string     `json:"email,omitempty"`
+	Reason string     `json:"reason" binding:"required,max=500"`
+}
+
+// ImpersonateResponse is the access token of an impersonated session. It
+// cannot be refreshed.
+type ImpersonateResponse struct {
+	User        *types.User `json:"user"`
+	AccessToken string      `json:"access_token"`
+	TokenType   string      `json:"token_type"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+	Banner      string      `json:"banner"`
+	Reason      string      `json:"reason"`
+}
+
+// AdminListTeams lists all teams with their suspension, for platform
+// operators. ?status=active or ?status=suspended filters on suspension.
+// GET /v1/admin/teams
+func (h *Handler) AdminListTeams(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	query, err := GetListQuery(c, "name", "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch query.Status {
+	case "", db.TeamStatusActive, db.TeamStatusSuspended:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or suspended"})
+		return
+	}
+
+	teams, err := h.repos.PlatformAdmin.ListTeams(ctx, query.ListParams)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list teams", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list teams"})
+		return
+	}
+	teams, next := db.TrimPage(teams, query.ListParams, func(team *types.AdminTeam) db.Cursor {
+		return itemCursor(query.Sort, team.ID, team.Name, team.CreatedAt, team.CreatedAt)
+	})
+	if teams == nil {
+		teams = []*types.AdminTeam{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"teams":       teams,
+		"next_cursor": encodeListCursor(query.ListParams, next),
+	})
+}
+
+// AdminListProjects lists all projects, or those of the team given as
+// ?team=slug, for platform operators
+// GET /v1/admin/projects
+func (h *Handler) AdminListProjects(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	query, err := GetListQuery(c, "name", "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var teamID *uuid.UUID
+	if slug := c.Query("team"); slug != "" {
+		team, err := h.repos.Teams.GetBySlug(ctx, slug)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+			return
+		}
+		if err != nil {
+			h.logger.Error(ctx, "Failed to get team", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list projects"})
+			return
+		}
+		teamID = &team.ID
+	}
+
+	projects, err := h.repos.PlatformAdmin.ListProjects(ctx, teamID, query.ListParams)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list projects", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list projects"})
+		return
+	}
+	projects, next := db.TrimPage(projects, query.ListParams, func(project *types.AdminProject) db.Cursor {
+		return itemCursor(query.Sort, project.ID, project.Name, project.CreatedAt, project.CreatedAt)
+	})
+	if projects == nil {
+		projects = []*types.AdminProject{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"projects":    projects,
+		"next_cursor": encodeListCursor(query.ListParams, next),
+	})
+}
+
+// AdminMetrics returns platform counts, the build queue, and the builds of
+// the last 24 hours
+// GET /v1/admin/metrics
+func (h *Handler) AdminMetrics(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	metrics, err := h.repos.PlatformAdmin.Metrics(ctx, time.Now().Add(-platformMetricsWindow))
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get platform metrics", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get platform metrics"})
+		return
+	}
+	metrics.Builds.Window = "24h"
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// SuspendTeam suspends a team: every service of its projects is scaled to
+// zero, and its members can no longer change anything, push builds or open
+// previews until it is unsuspended. Suspending a suspended team updates the
+// reason.
+// POST /v1/admin/teams/:slug/suspend
+func (h *Handler) SuspendTeam(c *gin.Context) {
+	var req SuspendTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	team := h.loadAdminTeam(c)
+	if team == nil {
+		return
+	}
+	actorEmail, _ := auth.GetUserEmailFromContext(c)
+
+	suspension := &types.TeamSuspension{
+		SuspendedAt: time.Now(),
+		Reason:      strings.TrimSpace(req.Reason),
+		SuspendedBy: actorEmail,
+	}
+	if err := h.repos.Teams.Suspend(ctx, team.ID, suspension); err != nil {
+		h.logger.Error(ctx, "Failed to suspend team", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend team"})
+		return
+	}
+
+	changed := h.scaleTeamServices(ctx, team.ID, true)
+	h.auditTeamSuspension(c, "admin.team_suspended", team, map[string]interface{}{
+		"reason":   suspension.Reason,
+		"services": changed,
+	})
+
+	h.logger.Info(ctx, "Team suspended",
+		logging.String("team", team.Slug),
+		logging.Int("services", len(changed)))
+
+	if current, err := h.repos.Teams.GetSuspension(ctx, team.ID); err == nil && current != nil {
+		suspension = current
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"team":       team.Slug,
+		"suspension": suspension,
+		"services":   changed,
+	})
+}
+
+// UnsuspendTeam lifts the suspension of a team and scales its services
+// back to the replicas of their current deployments
+// POST /v1/admin/teams/:slug/unsuspend
+func (h *Handler) UnsuspendTeam(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	team := h.loadAdminTeam(c)
+	if team == nil {
+		return
+	}
+
+	previous, err := h.repos.Teams.GetSuspension(ctx, team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team suspension", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsuspend team"})
+		return
+	}
+	if previous == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Team is not suspended"})
+		return
+	}
+	if err := h.repos.Teams.Unsuspend(ctx, team.ID); err != nil {
+		h.logger.Error(ctx, "Failed to unsuspend team", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsuspend team"})
+		return
+	}
+
+	changed := h.scaleTeamServices(ctx, team.ID, false)
+	h.auditTeamSuspension(c, "admin.team_unsuspended", team, map[string]interface{}{
+		"previous_reason": previous.Reason,
+		"suspended_at":    previous.SuspendedAt,
+		"services":        changed,
+	})
+
+	h.logger.Info(ctx, "Team unsuspended",
+		logging.String("team", team.Slug),
+		logging.Int("services", len(changed)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"team":     team.Slug,
+		"services": changed,
+	})
+}
+
+// Impersonate issues a platform operator a short-lived access token acting
+// as a user, to see what they see. The token cannot be refreshed or pass a
+// second factor, and sensitive account actions are refused with it.
+// POST /v1/admin/impersonate
+func (h *Handler) Impersonate(c *gin.Context) {
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if (req.UserID == nil) == (req.Email == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give either user_id or email"})
+		return
+	}
+	if _, impersonating := c.Get("impersonator_id"); impersonating {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This action is not available in an impersonated session"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	operator, ok := sessionActor(c)
+	if !ok {
+		return
+	}
+
+	userID := req.UserID
+	if userID == nil {
+		user, err := h.repos.Users.GetByEmail(ctx, strings.TrimSpace(req.Email))
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		if err != nil {
+			h.logger.Error(ctx, "Failed to get user", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to impersonate user"})
+			return
+		}
+		userID = &user.ID
+	}
+
+	result, err := h.authService.Impersonate(ctx, &services.ImpersonationRequest{
+		Operator: operator,
+		UserID:   *userID,
+		Reason:   strings.TrimSpace(req.Reason),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errors.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot impersonate yourself"})
+		case errors.Is(err, errors.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case errors.Is(err, errors.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "This user cannot be impersonated", "details": err.Error()})
+		default:
+			h.logger.Error(ctx, "Failed to impersonate user", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to impersonate user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ImpersonateResponse{
+		User:        result.User,
+		AccessToken: result.Tokens.AccessToken,
+		TokenType:   result.Tokens.TokenType,
+		ExpiresAt:   result.Tokens.ExpiresAt,
+		Banner:      result.Impersonation.Banner,
+		Reason:      result.Impersonation.Reason,
+	})
+}
+
+// loadAdminTeam resolves the team in the path. It writes the error response
+// and returns nil when there is none.
+func (h *Handler) loadAdminTeam(c *gin.Context) *db.Team {
+	ctx := c.Request.Context()
+	team, err := h.repos.Teams.GetBySlug(ctx, c.Param("slug"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return nil
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team"})
+		return nil
+	}
+	return team
+}
+
+// scaleTeamServices scales the services of every live project of a team
+// down to zero, or back up
+func (h *Handler) scaleTeamServices(ctx context.Context, teamID uuid.UUID, down bool) []string {
+	projectIDs, err := h.repos.ResourceQuotas.ListTeamProjects(ctx, teamID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list team projects", logging.Error("error", err))
+		return nil
+	}
+
+	changed := []string{}
+	for _, projectID := range projectIDs {
+		project, err := h.repos.Projects.GetByID(ctx, projectID)
+		if err != nil {
+			continue
+		}
+		for _, service := range h.scaleProjectServices(ctx, project, down) {
+			changed = append(changed, project.Slug+"/"+service)
+		}
+	}
+	return changed
+}
+
+func (h *Handler) auditTeamSuspension(c *gin.Context, action string, team *db.Team, auditContext map[string]interface{}) {
+	actorEmail, _ := auth.GetUserEmailFromContext(c)
+	// Operators from OIDC may not have a local user row
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorEmail:   actorEmail,
+		ActorRole:    types.Role(c.GetString("user_role")),
+		Action:       action,
+		ResourceType: "team",
+		ResourceID:   team.ID.String(),
+		ResourceName: team.Slug,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Outcome:      "success",
+		Context:      auditContext,
+	})
+}
+
+// teamSuspension returns the suspension of the team that owns a project, or
+// nil. Lookup errors are logged and treated as not suspended.
+func (h *Handler) teamSuspension(ctx context.Context, projectID uuid.UUID) *types.TeamSuspension {
+	suspension, err := h.repos.Teams.GetSuspensionByProject(ctx, projectID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to check team suspension",
+			logging.String("project_id", projectID.String()),
+			logging.Error("error", err))
+		return nil
+	}
+	return suspension
+}
+
+// suspensionKinds maps the collections in route paths to the kind of
+// resource their parameter addresses, for db.TeamRepository.GetSuspensionOf
+var suspensionKinds = map[string]string{
+	"teams":       "team",
+	"projects":    "project",
+	"services":    "service",
+	"addons":      "addon",
+	"previews":    "preview",
+	"functions":   "function",
+	"deployments": "deployment",
+	"releases":    "release",
+	"domains":     "domain",
+	"webhooks":    "webhook",
+}
+
+// requestTeamSuspension returns the suspension of the team a request acts
+// on, or nil. The team is resolved from the outermost route parameter that
+// addresses a resource a team owns, such as :slug in
+// /v1/projects/:slug/environments/:env_name/promotion. Requests that create
+// projects outside any team, from scratch or from a template, act for the
+// caller's teams.
+func (h *Handler) requestTeamSuspension(c *gin.Context) (*types.TeamSuspension, error) {
+	ctx := c.Request.Context()
+	segments := strings.Split(c.FullPath(), "/")
+	for i := 1; i < len(segments); i++ {
+		kind, ok := suspensionKinds[segments[i-1]]
+		if !ok || !strings.HasPrefix(segments[i], ":") {
+			continue
+		}
+		return h.repos.Teams.GetSuspensionOf(ctx, kind, c.Param(strings.TrimPrefix(segments[i], ":")))
+	}
+
+	permission, _ := auth.GetRequiredPermission(c.Request.Method, c.FullPath())
+	switch permission {
+	case auth.PermissionProjectCreate, auth.PermissionTemplateDeploy:
+		return h.repos.Teams.GetSuspensionOf(ctx, "member", c.GetString("user_id"))
+	}
+	return nil, nil
+}
+
+// exemptFromSuspension reports whether the caller is a platform operator,
+// who may change what a suspended team owns to help it
+func exemptFromSuspension(c *gin.Context) bool {
+	switch auth.Role(c.GetString("user_role")) {
+	case auth.RoleAdmin, auth.RoleSuperAdmin, auth.RoleOperator:
+		return true
+	}
+	return false
+}
+
+// respondTeamSuspended refuses a request because its team is suspended
+func respondTeamSuspended(c *gin.Context, suspension *types.TeamSuspension) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":        "Team is suspended",
+		"reason":       suspension.Reason,
+		"suspended_at": suspension.SuspendedAt,
+		"help":         "Contact support to restore the team",
+	})
+}
+
+// rejectSuspendedTeams refuses changes to what a suspended team owns. Reads
+// still work so members can see their projects, and platform operators are
+// let through to help. A failed lookup lets the request through.
+func (h *Handler) rejectSuspendedTeams() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || exemptFromSuspension(c) {
+			c.Next()
+			return
+		}
+
+		suspension, err := h.requestTeamSuspension(c)
+		if err != nil {
+			h.logger.Warn(c.Request.Context(), "Failed to check team suspension",
+				logging.String("path", c.FullPath()),
+				logging.Error("error", err))
+		}
+		if suspension != nil {
+			respondTeamSuspended(c, suspension)
+			return
+		}
+		c.Next()
+	}
+}
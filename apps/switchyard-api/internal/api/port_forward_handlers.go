@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Kinds of port-forward targets
+const (
+	PortForwardKindService = "service"
+	PortForwardKindAddon   = "addon"
+)
+
+// PortForwardTarget describes what a port-forward connects to
+type PortForwardTarget struct {
+	Target      string `json:"target"`
+	Kind        string `json:"kind"`
+	Environment string `json:"environment"`
+	Port        int    `json:"port"`
+
+	namespace     string
+	kubeService   string
+	resourceID    string
+	environmentID uuid.UUID
+}
+
+// PortForwardWS tunnels one TCP connection to a service or addon of a project
+// over a WebSocket. Binary messages carry the bytes in both directions; the
+// CLI opens one WebSocket per local connection. Without a WebSocket upgrade
+// the resolved target is returned, so clients can check it up front.
+// GET /v1/projects/:slug/port-forward?target=NAME&env=ENV&port=PORT
+func (h *Handler) PortForwardWS(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+
+	targetName := c.Query("target")
+	if targetName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target query parameter is required"})
+		return
+	}
+	port, err := parsePortForwardPort(c.Query("port"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	env, err := h.repos.Environments.GetByProjectAndName(project.ID, c.DefaultQuery("env", "development"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+
+	if h.k8sClient == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Kubernetes client not available"})
+		return
+	}
+
+	target, status, err := h.resolvePortForwardTarget(ctx, project, env, targetName, port)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Resolve the pod now so a missing or unready target fails before upgrading
+	podTarget, err := h.k8sClient.ResolveServicePort(ctx, target.namespace, target.kubeService, target.Port)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	target.Port = podTarget.ServicePort
+
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		c.JSON(http.StatusOK, target)
+		return
+	}
+
+	conn, err := h.getWebSocketUpgrader().Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to upgrade to WebSocket", logging.Error("error", err))
+		return
+	}
+	defer conn.Close()
+
+	h.auditPortForward(c, project, target, podTarget.Pod)
+
+	h.logger.Info(ctx, "Port-forward opened",
+		logging.String("project_slug", project.Slug),
+		logging.String("target", target.Target),
+		logging.String("kind", target.Kind),
+		logging.String("pod", podTarget.Pod),
+		logging.Int("port", podTarget.PodPort))
+	started := time.Now()
+
+	// The tunnel lives as long as the WebSocket, not the request context
+	tunnelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := &webSocketStream{conn: conn}
+	err = h.k8sClient.PortForward(tunnelCtx, target.namespace, podTarget.Pod, podTarget.PodPort, stream)
+	if err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Warn(ctx, "Port-forward ended with error",
+			logging.String("target", target.Target),
+			logging.Error("error", err))
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, truncateCloseReason(err.Error())),
+			time.Now().Add(time.Second))
+		return
+	}
+
+	h.logger.Info(ctx, "Port-forward closed",
+		logging.String("target", target.Target),
+		logging.Duration("duration", time.Since(started)))
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+}
+
+// resolvePortForwardTarget finds the service or addon named target in an
+// environment of a project. Services take precedence over addons of the
+// same name. It returns the HTTP status to answer with on error.
+func (h *Handler) resolvePortForwardTarget(ctx context.Context, project *types.Project, env *types.Environment, name string, port int) (*PortForwardTarget, int, error) {
+	target := &PortForwardTarget{
+		Target:        name,
+		Environment:   env.Name,
+		Port:          port,
+		environmentID: env.ID,
+	}
+
+	services, err := h.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to list services")
+	}
+	for _, service := range services {
+		if service.Name == name {
+			target.Kind = PortForwardKindService
+			target.namespace = kubeNamespace(project.Slug, env)
+			target.kubeService = service.Name
+			target.resourceID = service.ID.String()
+			return target, 0, nil
+		}
+	}
+
+	addon, err := h.repos.DatabaseAddons.GetByName(ctx, project.ID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, http.StatusNotFound, fmt.Errorf("no service or addon named %s in project %s", name, project.Slug)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to get addon")
+	}
+	if addon.EnvironmentID != nil && *addon.EnvironmentID != env.ID {
+		return nil, http.StatusNotFound, fmt.Errorf("addon %s does not belong to environment %s", name, env.Name)
+	}
+	if addon.Status != types.DatabaseAddonStatusReady {
+		return nil, http.StatusConflict, fmt.Errorf("addon %s is %s", name, addon.Status)
+	}
+
+	kubeService, err := addonKubeService(addon)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+	target.Kind = PortForwardKindAddon
+	target.namespace = addon.K8sNamespace
+	target.kubeService = kubeService
+	target.resourceID = addon.ID.String()
+	if target.Port == 0 {
+		target.Port = addon.Port
+	}
+	return target, 0, nil
+}
+
+// addonKubeService returns the Kubernetes Service an addon is reached
+// through, taken from its in-cluster host name
+func addonKubeService(addon *types.DatabaseAddon) (string, error) {
+	suffix := fmt.Sprintf(".%s.svc.cluster.local", addon.K8sNamespace)
+	if addon.K8sNamespace == "" || !strings.HasSuffix(addon.Host, suffix) {
+		return "", fmt.Errorf("addon %s is not hosted in the cluster", addon.Name)
+	}
+	return strings.TrimSuffix(addon.Host, suffix), nil
+}
+
+// auditPortForward records a port-forward connection
+func (h *Handler) auditPortForward(c *gin.Context, project *types.Project, target *PortForwardTarget, pod string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    email,
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		Action:        "port_forward.opened",
+		ResourceType:  target.Kind,
+		ResourceID:    target.resourceID,
+		ResourceName:  target.Target,
+		ProjectID:     &project.ID,
+		EnvironmentID: &target.environmentID,
+		Outcome:       "success",
+		Context: map[string]interface{}{
+			"environment": target.Environment,
+			"port":        target.Port,
+			"pod":         pod,
+		},
+	})
+}
+
+// parsePortForwardPort parses the optional port query parameter
+func parsePortForwardPort(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	port, err := strconv.Atoi(raw)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", raw)
+	}
+	return port, nil
+}
+
+// truncateCloseReason keeps a close reason within the 123 bytes a WebSocket
+// close frame allows
+func truncateCloseReason(reason string) string {
+	if len(reason) > 123 {
+		return reason[:123]
+	}
+	return reason
+}
+
+// webSocketStream reads and writes the binary messages of a WebSocket as a
+// byte stream. Text messages are ignored.
+type webSocketStream struct {
+	conn   *websocket.Conn
+	reader io.Reader
+}
+
+func (s *webSocketStream) Read(p []byte) (int, error) {
+	for {
+		if s.reader == nil {
+			messageType, reader, err := s.conn.NextReader()
+			if err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+			s.reader = reader
+		}
+
+		n, err := s.reader.Read(p)
+		if err == io.EOF {
+			s.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (s *webSocketStream) Write(p []byte) (int, error) {
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestAddonKubeService(t *testing.T) {
+	addon := &types.DatabaseAddon{
+		Name:         "db",
+		K8sNamespace: "enclii-shop",
+		Host:         "db-shop-rw.enclii-shop.svc.cluster.local",
+	}
+	name, err := addonKubeService(addon)
+	if err != nil || name != "db-shop-rw" {
+		t.Errorf("addonKubeService() = %q, %v; want db-shop-rw", name, err)
+	}
+
+	// Buckets on external object storage cannot be forwarded to
+	addon.Host = "https://storage.example.com"
+	if _, err := addonKubeService(addon); err == nil {
+		t.Error("expected an error for an addon hosted outside the cluster")
+	}
+}
+
+func TestParsePortForwardPort(t *testing.T) {
+	if port, err := parsePortForwardPort(""); port != 0 || err != nil {
+		t.Errorf("empty port = %d, %v; want the default", port, err)
+	}
+	if port, err := parsePortForwardPort("5432"); port != 5432 || err != nil {
+		t.Errorf("parsePortForwardPort(5432) = %d, %v", port, err)
+	}
+	for _, raw := range []string{"0", "70000", "http"} {
+		if _, err := parsePortForwardPort(raw); err == nil {
+			t.Errorf("parsePortForwardPort(%q): expected an error", raw)
+		}
+	}
+}
+
+func TestWebSocketStream(t *testing.T) {
+	received := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := defaultWebSocketUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		stream := &webSocketStream{conn: conn}
+		data := make([]byte, 4)
+		io.ReadFull(stream, data)
+		received <- string(data)
+		stream.Write([]byte("pong"))
+
+		// A normal close ends the stream
+		_, err = stream.Read(data)
+		received <- fmt.Sprint(err)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	// Bytes span messages; text messages are not part of the stream
+	conn.WriteMessage(websocket.BinaryMessage, []byte("pi"))
+	conn.WriteMessage(websocket.TextMessage, []byte("ignored"))
+	conn.WriteMessage(websocket.BinaryMessage, []byte("ng"))
+
+	if got := <-received; got != "ping" {
+		t.Errorf("server read %q, want ping", got)
+	}
+	messageType, data, err := conn.ReadMessage()
+	if err != nil || messageType != websocket.BinaryMessage || string(data) != "pong" {
+		t.Errorf("ReadMessage() = %d, %q, %v; want binary pong", messageType, data, err)
+	}
+
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if got := <-received; got != io.EOF.Error() {
+		t.Errorf("read after close = %s, want EOF", got)
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// UpdatePreviewSettingsRequest sets how long a project keeps closed previews
+type UpdatePreviewSettingsRequest struct {
+	PreviewTTLDays *int `json:"preview_ttl_days"` // null resets to the platform default
+}
+
+// previewSettings is the preview TTL of a project as returned by the API
+func (h *Handler) previewSettings(project *types.Project) gin.H {
+	return gin.H{
+		"preview_ttl_days":   project.PreviewTTLDays,
+		"default_ttl_days":   h.previewCleaner.DefaultTTLDays(),
+		"effective_ttl_days": h.previewCleaner.TTLDays(project),
+	}
+}
+
+// loadPreviewCleanupProject checks that preview cleanup is enabled and loads
+// the project in the path. It writes the error response and returns nil when
+// the project cannot be loaded.
+func (h *Handler) loadPreviewCleanupProject(c *gin.Context) *types.Project {
+	if h.previewCleaner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Preview cleanup is not enabled"})
+		return nil
+	}
+
+	project, err := h.repos.Projects.GetBySlug(c.Param("slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return nil
+	}
+	return project
+}
+
+// GetPreviewSettings returns how long a project keeps closed previews
+// GET /v1/projects/:slug/preview-settings
+func (h *Handler) GetPreviewSettings(c *gin.Context) {
+	project := h.loadPreviewCleanupProject(c)
+	if project == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.previewSettings(project))
+}
+
+// UpdatePreviewSettings sets how long a project keeps closed previews before
+// they are torn down. A TTL of 0 tears previews down as soon as they close.
+// PUT /v1/projects/:slug/preview-settings
+func (h *Handler) UpdatePreviewSettings(c *gin.Context) {
+	var req UpdatePreviewSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PreviewTTLDays != nil && (*req.PreviewTTLDays < 0 || *req.PreviewTTLDays > previews.MaxTTLDays) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("preview_ttl_days must be between 0 and %d", previews.MaxTTLDays)})
+		return
+	}
+
+	project := h.loadPreviewCleanupProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	previous := project.PreviewTTLDays
+	if err := h.repos.Projects.SetPreviewTTL(ctx, project.ID, req.PreviewTTLDays); err != nil {
+		h.logger.Error(ctx, "Failed to update preview TTL",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update preview settings"})
+		return
+	}
+	project.PreviewTTLDays = req.PreviewTTLDays
+
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   email,
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       "project.preview_ttl_updated",
+		ResourceType: "project",
+		ResourceID:   project.ID.String(),
+		ResourceName: project.Slug,
+		ProjectID:    &project.ID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"previous_ttl_days": previous,
+			"ttl_days":          req.PreviewTTLDays,
+		},
+	})
+
+	c.JSON(http.StatusOK, h.previewSettings(project))
+}
+
+// ListStalePreviews lists the closed previews of a project that are past
+// their TTL and what tearing each down would remove. Nothing is deleted.
+// GET /v1/projects/:slug/previews/stale
+func (h *Handler) ListStalePreviews(c *gin.Context) {
+	project := h.loadPreviewCleanupProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	plans, err := h.previewCleaner.ListStale(ctx, &project.ID)
+	if err != nil {
+		if isTableNotExistError(err) {
+			c.JSON(http.StatusOK, gin.H{"previews": []*previews.Plan{}, "count": 0})
+			return
+		}
+		h.logger.Error(ctx, "Failed to list stale previews",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list stale previews"})
+		return
+	}
+
+	releases := 0
+	var imageBytes int64
+	for _, plan := range plans {
+		releases += len(plan.Releases)
+		imageBytes += plan.ImageBytes
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"previews":    plans,
+		"count":       len(plans),
+		"ttl_days":    h.previewCleaner.TTLDays(project),
+		"releases":    releases,
+		"image_bytes": imageBytes,
+	})
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// UpdatePreviewDatabaseConfigRequest sets the template a service's previews
+// get their own database from
+type UpdatePreviewDatabaseConfigRequest struct {
+	TemplateAddonID uuid.UUID                 `json:"template_addon_id" binding:"required"`
+	Mode            types.PreviewDatabaseMode `json:"mode"`                   // clone (default) or seed
+	BackupID        *uuid.UUID                `json:"backup_id,omitempty"`    // clone mode only, latest backup if unset
+	SeedCommand     string                    `json:"seed_command,omitempty"` // seed mode only, runs in the preview image
+	EnvVarName      string                    `json:"env_var_name,omitempty"` // defaults to the service's binding of the template
+}
+
+// loadPreviewDatabaseService checks that preview databases are enabled and
+// loads the service in the path. It writes the error response and returns nil
+// when the service cannot be loaded.
+func (h *Handler) loadPreviewDatabaseService(c *gin.Context) *types.Service {
+	if h.previewDatabases == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Preview databases are not enabled"})
+		return nil
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service_id format"})
+		return nil
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+			return nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get service", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get service"})
+		return nil
+	}
+	// Previews are environments of their own, so only bot tokens granted
+	// the whole project configure them
+	if !h.authorizeEnvironment(c, service.ProjectID, nil) {
+		return nil
+	}
+	return service
+}
+
+// GetPreviewDatabaseConfig returns the template a service's previews get
+// their database from
+// GET /v1/services/:id/preview-database
+func (h *Handler) GetPreviewDatabaseConfig(c *gin.Context) {
+	service := h.loadPreviewDatabaseService(c)
+	if service == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	config, err := h.repos.PreviewDatabases.GetConfig(ctx, service.ID)
+	if err != nil {
+		if err == sql.ErrNoRows || isTableNotExistError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service has no preview database"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get preview database config",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get preview database config"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"config": config})
+}
+
+// UpdatePreviewDatabaseConfig sets the template a service's previews get
+// their database from. Previews that already have a database keep it; new
+// and rebuilt failed ones use the new config.
+// PUT /v1/services/:id/preview-database
+func (h *Handler) UpdatePreviewDatabaseConfig(c *gin.Context) {
+	var req UpdatePreviewDatabaseConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := h.loadPreviewDatabaseService(c)
+	if service == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	template, err := h.repos.DatabaseAddons.GetByID(ctx, req.TemplateAddonID)
+	if err != nil || template.ProjectID != service.ProjectID {
+		if err != nil && err != sql.ErrNoRows {
+			h.logger.Error(ctx, "Failed to get template addon", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get template addon"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "template_addon_id must be an addon of the service's project"})
+		return
+	}
+
+	config := &types.PreviewDatabaseConfig{
+		ServiceID:       service.ID,
+		TemplateAddonID: template.ID,
+		Mode:            req.Mode,
+		BackupID:        req.BackupID,
+		SeedCommand:     req.SeedCommand,
+		EnvVarName:      req.EnvVarName,
+	}
+	if config.Mode == "" {
+		config.Mode = types.PreviewDatabaseModeClone
+	}
+	if config.EnvVarName == "" {
+		config.EnvVarName = h.previewDatabases.DefaultEnvVarName(ctx, service.ID, template.ID)
+	}
+
+	var backup *types.DatabaseAddonBackup
+	if req.BackupID != nil {
+		backup, err = h.repos.DatabaseAddons.GetBackup(ctx, *req.BackupID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "backup not found"})
+				return
+			}
+			h.logger.Error(ctx, "Failed to get backup", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get backup"})
+			return
+		}
+	}
+
+	if err := previews.ValidateConfig(config, template, backup); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repos.PreviewDatabases.UpsertConfig(ctx, config); err != nil {
+		h.logger.Error(ctx, "Failed to save preview database config",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save preview database config"})
+		return
+	}
+
+	h.auditPreviewConfig(c, service, "service.preview_database_updated", map[string]interface{}{
+		"template_addon_id": template.ID.String(),
+		"template_addon":    template.Name,
+		"mode":              config.Mode,
+		"env_var_name":      config.EnvVarName,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"config": config})
+}
+
+// DeletePreviewDatabaseConfig stops giving a service's new previews a
+// database. Previews that already have one keep it until they are torn down.
+// DELETE /v1/services/:id/preview-database
+func (h *Handler) DeletePreviewDatabaseConfig(c *gin.Context) {
+	service := h.loadPreviewDatabaseService(c)
+	if service == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.PreviewDatabases.DeleteConfig(ctx, service.ID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service has no preview database"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete preview database config",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete preview database config"})
+		return
+	}
+
+	h.auditPreviewConfig(c, service, "service.preview_database_deleted", nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Preview database config deleted"})
+}
+
+// GetPreviewDatabase returns the database of a preview environment
+// GET /v1/previews/:id/database
+func (h *Handler) GetPreviewDatabase(c *gin.Context) {
+	if h.previewDatabases == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Preview databases are not enabled"})
+		return
+	}
+
+	previewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid preview_id format"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	if auth.TokenScopeFromContext(c) != nil {
+		preview, err := h.repos.PreviewEnvironments.GetByID(ctx, previewID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Preview not found"})
+			return
+		}
+		if !h.authorizeEnvironment(c, preview.ProjectID, nil) {
+			return
+		}
+	}
+
+	database, err := h.repos.PreviewDatabases.GetByPreview(ctx, previewID)
+	if err != nil {
+		if err == sql.ErrNoRows || isTableNotExistError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Preview has no database"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get preview database",
+			logging.String("preview_id", previewID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get preview database"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"database": database})
+}
+
+// RedeployPreview redeploys the current release of a preview so it picks up
+// its database binding. It implements previews.PreviewRedeployer.
+func (h *Handler) RedeployPreview(ctx context.Context, preview *types.PreviewEnvironment) error {
+	if preview.DeploymentID == nil {
+		return fmt.Errorf("preview has no deployment")
+	}
+
+	deployment, err := h.repos.Deployments.GetByID(ctx, preview.DeploymentID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get preview deployment: %w", err)
+	}
+	release, err := h.repos.Releases.GetByID(deployment.ReleaseID)
+	if err != nil {
+		return fmt.Errorf("failed to get preview release: %w", err)
+	}
+	service, err := h.repos.Services.GetByID(preview.ServiceID)
+	if err != nil {
+		return fmt.Errorf("failed to get service: %w", err)
+	}
+
+	result := h.reconcilePreviewDeployment(ctx, &previewReconcileRequest{
+		Preview:       preview,
+		Service:       service,
+		Release:       release,
+		Deployment:    deployment,
+		CustomDomains: previewDomains(preview),
+		Namespace:     previews.Namespace(preview),
+	})
+	if !result.Success {
+		return fmt.Errorf("failed to redeploy preview: %s", result.Message)
+	}
+	return nil
+}
+
+// auditPreviewConfig records a change to how a service's previews are set up
+func (h *Handler) auditPreviewConfig(c *gin.Context, service *types.Service, action string, details map[string]interface{}) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   email,
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       action,
+		ResourceType: "service",
+		ResourceID:   service.ID.String(),
+		ResourceName: service.Name,
+		ProjectID:    &service.ProjectID,
+		Outcome:      "success",
+		Context:      details,
+	})
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ReplacePreviewEnvVarsRequest sets the env var overrides of a service's
+// previews. It replaces the whole set; an empty list removes them.
+type ReplacePreviewEnvVarsRequest struct {
+	Variables []BulkEnvVarRequest `json:"variables"`
+}
+
+// loadPreviewEnvService loads the service in the path. It writes the error
+// response and returns nil when the service cannot be loaded.
+func (h *Handler) loadPreviewEnvService(c *gin.Context) *types.Service {
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return nil
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+			return nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get service", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get service"})
+		return nil
+	}
+	// Previews are environments of their own, so only bot tokens granted
+	// the whole project configure them
+	if !h.authorizeEnvironment(c, service.ProjectID, nil) {
+		return nil
+	}
+	return service
+}
+
+// ListPreviewEnvVars returns the env var overrides of a service's previews
+// GET /v1/services/:id/preview-env
+func (h *Handler) ListPreviewEnvVars(c *gin.Context) {
+	service := h.loadPreviewEnvService(c)
+	if service == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	vars, err := h.repos.EnvVars.ListPreview(ctx, service.ID)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil && !isTableNotExistError(err) {
+		h.logger.Error(ctx, "Failed to list preview env vars",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list preview environment variables"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"variables": toPreviewEnvVarResponses(vars),
+		"count":     len(vars),
+	})
+}
+
+// ReplacePreviewEnvVars replaces the env var overrides of a service's
+// previews. Previews pick them up on their next deploy.
+// PUT /v1/services/:id/preview-env
+func (h *Handler) ReplacePreviewEnvVars(c *gin.Context) {
+	var req ReplacePreviewEnvVarsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Variables) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Maximum 100 preview overrides per service"})
+		return
+	}
+
+	seen := make(map[string]bool, len(req.Variables))
+	for _, v := range req.Variables {
+		if !isValidEnvVarKey(v.Key) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid environment variable key: " + v.Key})
+			return
+		}
+		if seen[v.Key] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Duplicate environment variable key: " + v.Key})
+			return
+		}
+		seen[v.Key] = true
+	}
+
+	service := h.loadPreviewEnvService(c)
+	if service == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	userEmail := c.GetString("user_email")
+	var createdBy *uuid.UUID
+	if parsed, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		createdBy = &parsed
+	}
+
+	vars := make([]types.PreviewEnvVar, len(req.Variables))
+	keys := make([]string, len(req.Variables))
+	for i, v := range req.Variables {
+		vars[i] = types.PreviewEnvVar{
+			Key:            v.Key,
+			Value:          v.Value,
+			IsSecret:       v.IsSecret,
+			CreatedBy:      createdBy,
+			CreatedByEmail: userEmail,
+		}
+		keys[i] = v.Key
+	}
+
+	err := h.repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+		return tx.EnvVars.ReplacePreview(ctx, service.ID, vars)
+	})
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to replace preview env vars",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save preview environment variables"})
+		return
+	}
+
+	h.auditPreviewConfig(c, service, "service.preview_env_updated", map[string]interface{}{
+		"keys": keys,
+	})
+
+	responses := make([]types.PreviewEnvVar, len(vars))
+	for i := range vars {
+		responses[i] = toPreviewEnvVarResponse(&vars[i])
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"variables": responses,
+		"count":     len(responses),
+	})
+}
+
+// toPreviewEnvVarResponses masks the secret values of preview overrides
+func toPreviewEnvVarResponses(vars []*types.PreviewEnvVar) []types.PreviewEnvVar {
+	responses := make([]types.PreviewEnvVar, len(vars))
+	for i, ev := range vars {
+		responses[i] = toPreviewEnvVarResponse(ev)
+	}
+	return responses
+}
+
+// toPreviewEnvVarResponse returns a copy of a preview override with its
+// value masked if it is a secret
+func toPreviewEnvVarResponse(ev *types.PreviewEnvVar) types.PreviewEnvVar {
+	response := *ev
+	if response.IsSecret {
+		response.Value = "••••••••" // Mask secret values
+	}
+	return response
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
@@ -45,8 +46,8 @@ type CreatePreviewCommentRequest struct {
 	YPosition *int   `json:"y_position,omitempty"`
 }
 
-// ListPreviews returns all preview environments for a service
-// GET /v1/services/:id/previews
+// ListPreviews returns a page of the preview environments of a service
+// GET /v1/services/:id/previews?status=active&limit=20&cursor=...
 func (h *Handler) ListPreviews(c *gin.Context) {
 	serviceID := c.Param("id")
 	if serviceID == "" {
@@ -62,15 +63,22 @@ func (h *Handler) ListPreviews(c *gin.Context) {
 		return
 	}
 
-	previews, err := h.repos.PreviewEnvironments.ListByService(ctx, serviceUUID)
+	query, err := GetListQuery(c, "created_at", "updated_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previews, err := h.repos.PreviewEnvironments.ListPageByService(ctx, serviceUUID, query.ListParams)
 	if err != nil {
 		// Gracefully handle missing table (migrations not applied)
 		if isTableNotExistError(err) {
 			h.logger.Warn(ctx, "Preview environments table not found, returning empty list",
 				logging.String("service_id", serviceID))
 			c.JSON(http.StatusOK, gin.H{
-				"previews": []*types.PreviewEnvironment{},
-				"count":    0,
+				"previews":    []*types.PreviewEnvironment{},
+				"count":       0,
+				"next_cursor": "",
 			})
 			return
 		}
@@ -81,14 +89,16 @@ func (h *Handler) ListPreviews(c *gin.Context) {
 		return
 	}
 
+	previews, next := db.TrimPage(previews, query.ListParams, previewCursor(query.Sort))
 	c.JSON(http.StatusOK, gin.H{
-		"previews": previews,
-		"count":    len(previews),
+		"previews":    previews,
+		"count":       len(previews),
+		"next_cursor": encodeListCursor(query.ListParams, next),
 	})
 }
 
-// ListProjectPreviews returns all preview environments for a project
-// GET /v1/projects/:slug/previews
+// ListProjectPreviews returns a page of the preview environments of a project
+// GET /v1/projects/:slug/previews?status=active&limit=20&cursor=...
 func (h *Handler) ListProjectPreviews(c *gin.Context) {
 	slug := c.Param("slug")
 	if slug == "" {
@@ -105,15 +115,22 @@ func (h *Handler) ListProjectPreviews(c *gin.Context) {
 		return
 	}
 
-	previews, err := h.repos.PreviewEnvironments.ListByProject(ctx, project.ID)
+	query, err := GetListQuery(c, "created_at", "updated_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previews, err := h.repos.PreviewEnvironments.ListPageByProject(ctx, project.ID, query.ListParams)
 	if err != nil {
 		// Gracefully handle missing table (migrations not applied)
 		if isTableNotExistError(err) {
 			h.logger.Warn(ctx, "Preview environments table not found, returning empty list",
 				logging.String("project_slug", slug))
 			c.JSON(http.StatusOK, gin.H{
-				"previews": []*types.PreviewEnvironment{},
-				"count":    0,
+				"previews":    []*types.PreviewEnvironment{},
+				"count":       0,
+				"next_cursor": "",
 			})
 			return
 		}
@@ -124,12 +141,20 @@ func (h *Handler) ListProjectPreviews(c *gin.Context) {
 		return
 	}
 
+	previews, next := db.TrimPage(previews, query.ListParams, previewCursor(query.Sort))
 	c.JSON(http.StatusOK, gin.H{
-		"previews": previews,
-		"count":    len(previews),
+		"previews":    previews,
+		"count":       len(previews),
+		"next_cursor": encodeListCursor(query.ListParams, next),
 	})
 }
 
+func previewCursor(sort string) func(*types.PreviewEnvironment) db.Cursor {
+	return func(preview *types.PreviewEnvironment) db.Cursor {
+		return itemCursor(sort, preview.ID, "", preview.CreatedAt, preview.UpdatedAt)
+	}
+}
+
 // GetPreview returns a single preview environment
 // GET /v1/previews/:id
 func (h *Handler) GetPreview(c *gin.Context) {
@@ -195,6 +220,15 @@ func (h *Handler) CreatePreview(c *gin.Context) {
 		return
 	}
 
+	// The service is named in the body, so the suspension middleware cannot
+	// resolve its team
+	if !exemptFromSuspension(c) {
+		if suspension := h.teamSuspension(ctx, service.ProjectID); suspension != nil {
+			respondTeamSuspended(c, suspension)
+			return
+		}
+	}
+
 	// Check if preview already exists for this PR
 	existing, err := h.repos.PreviewEnvironments.GetByServiceAndPR(ctx, serviceUUID, req.PRNumber)
 	if err != nil && err != sql.ErrNoRows {
@@ -221,6 +255,10 @@ func (h *Handler) CreatePreview(c *gin.Context) {
 		return
 	}
 
+	if !h.checkPreviewQuota(c, service.ProjectID) {
+		return
+	}
+
 	// Generate preview subdomain: pr-{number}-{service-slug}.preview.enclii.app
 	serviceSlug := strings.ToLower(strings.ReplaceAll(service.Name, " ", "-"))
 	subdomain := fmt.Sprintf("pr-%d-%s", req.PRNumber, serviceSlug)
@@ -470,6 +508,15 @@ func (h *Handler) DeletePreview(c *gin.Context) {
 		}
 	}
 
+	// Delete the preview's own database before its record cascades away
+	if h.previewDatabases != nil {
+		if _, err := h.previewDatabases.Release(ctx, preview); err != nil {
+			h.logger.Warn(ctx, "Failed to delete preview database",
+				logging.String("preview_id", previewID),
+				logging.Error("error", err))
+		}
+	}
+
 	// Delete the preview from the database
 	if err := h.repos.PreviewEnvironments.Delete(ctx, previewUUID); err != nil {
 		h.logger.Error(ctx, "Failed to delete preview from database",
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ProjectEventListResponse is a page of the event history of a project
+type ProjectEventListResponse struct {
+	Events []*types.ProjectEvent `json:"events"`
+	// NextCursor fetches the next, older page; empty on the last page
+	NextCursor string `json:"next_cursor"`
+}
+
+// ListProjectEvents returns the event history of a project, newest first,
+// for the activity feed
+// GET /v1/projects/:slug/events?type=deployment&type=env_var.changed&cursor=...&limit=50
+func (h *Handler) ListProjectEvents(c *gin.Context) {
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	query, err := GetListQuery(c, "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.repos.ProjectEvents.ListByProject(ctx, project.ID, projectEventTypes(c.QueryArray("type")), query.ListParams)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list project events",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list events"})
+		return
+	}
+
+	events, next := db.TrimPage(events, query.ListParams, func(event *types.ProjectEvent) db.Cursor {
+		return db.Cursor{Value: listCursorTime(event.CreatedAt), ID: event.ID}
+	})
+	if events == nil {
+		events = []*types.ProjectEvent{}
+	}
+	c.JSON(http.StatusOK, ProjectEventListResponse{
+		Events:     events,
+		NextCursor: encodeListCursor(query.ListParams, next),
+	})
+}
+
+// projectEventTypes splits type filters, which may be repeated or comma
+// separated
+func projectEventTypes(values []string) []string {
+	var eventTypes []string
+	for _, value := range values {
+		for _, eventType := range strings.Split(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				eventTypes = append(eventTypes, eventType)
+			}
+		}
+	}
+	return eventTypes
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectEventTypes(t *testing.T) {
+	got := projectEventTypes([]string{"deployment, build.failed", "", "env_var.changed,"})
+	want := []string{"deployment", "build.failed", "env_var.changed"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("types = %v, want %v", got, want)
+	}
+	if got := projectEventTypes(nil); got != nil {
+		t.Errorf("no filter = %v, want nil", got)
+	}
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/redact"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// maxAggregatedLogServices bounds the services one project log stream tails,
+// since each opens a stream per pod and container
+const maxAggregatedLogServices = 20
+
+// StreamProjectLogsWS handles WebSocket connections streaming the logs of
+// several services of a project at once. The pods of every selected service
+// are fanned in to one stream and each message names its service.
+// GET /v1/projects/:slug/logs/stream
+func (h *Handler) StreamProjectLogsWS(c *gin.Context) {
+	ctx := c.Request.Context()
+	envName := c.DefaultQuery("env", "development")
+
+	grep, err := parseLogGrep(c.Query("grep"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	since, err := parseLogSince(c.Query("since"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeNamedEnvironment(c, project.Slug, envName) {
+		return
+	}
+
+	services, err := h.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list services for log streaming", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list services"})
+		return
+	}
+
+	selected, unknown := selectLogServices(services, splitLogServices(c.Query("services")))
+	if len(unknown) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Services not found in project", "services": unknown})
+		return
+	}
+	if len(selected) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project has no services"})
+		return
+	}
+	if len(selected) > maxAggregatedLogServices {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d services can be streamed at once", maxAggregatedLogServices)})
+		return
+	}
+
+	// Upgrade HTTP connection to WebSocket
+	conn, err := h.getWebSocketUpgrader().Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to upgrade to WebSocket", logging.Error("error", err))
+		return
+	}
+	defer conn.Close()
+
+	tailLines := int64(100)
+	if tl := c.Query("lines"); tl != "" {
+		if parsed, err := strconv.ParseInt(tl, 10, 64); err == nil && parsed > 0 {
+			tailLines = parsed
+		}
+	}
+
+	namespace := fmt.Sprintf("enclii-%s-%s", project.Slug, envName)
+	names := make([]string, 0, len(selected))
+	for _, service := range selected {
+		names = append(names, service.Name)
+	}
+
+	connMsg := LogStreamMessage{
+		Type:      "connected",
+		Timestamp: time.Now(),
+		Message:   fmt.Sprintf("Connected to logs for %s in %s", strings.Join(names, ", "), namespace),
+	}
+	if err := conn.WriteJSON(connMsg); err != nil {
+		h.logger.Error(ctx, "Failed to send connected message", logging.Error("error", err))
+		return
+	}
+
+	// Create cancellable context for the stream
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Handle WebSocket close
+	go func() {
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	opts := k8s.LogStreamOptions{
+		Namespace:  namespace,
+		TailLines:  tailLines,
+		Follow:     true,
+		Timestamps: c.Query("timestamps") == "true",
+		SinceTime:  since,
+	}
+
+	redactor := h.logRedactor(ctx, project, redact.SourceRuntime)
+
+	// Fan in the logs of every service
+	msgChan := make(chan LogStreamMessage, 100)
+	var wg sync.WaitGroup
+	for _, service := range selected {
+		wg.Add(1)
+		go func(serviceName string) {
+			defer wg.Done()
+			h.streamServiceLogsTo(streamCtx, opts, serviceName, grep, redactor, msgChan)
+		}(service.Name)
+	}
+	go func() {
+		wg.Wait()
+		close(msgChan)
+	}()
+
+	for {
+		select {
+		case <-streamCtx.Done():
+			disconnMsg := LogStreamMessage{
+				Type:      "disconnected",
+				Timestamp: time.Now(),
+				Message:   "Log stream disconnected",
+			}
+			conn.WriteJSON(disconnMsg)
+			return
+
+		case msg, ok := <-msgChan:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(msg); err != nil {
+				h.logger.Error(ctx, "Failed to write log message", logging.Error("error", err))
+				return
+			}
+		}
+	}
+}
+
+// streamServiceLogsTo streams the logs of one service's pods to msgChan,
+// tagged with the service name and with secrets masked. Log lines not
+// matching grep are dropped; errors are always forwarded.
+func (h *Handler) streamServiceLogsTo(ctx context.Context, opts k8s.LogStreamOptions, serviceName string, grep *regexp.Regexp, redactor *redact.Redactor, msgChan chan<- LogStreamMessage) {
+	opts.LabelSelector = fmt.Sprintf("app=%s", serviceName)
+
+	logChan := make(chan k8s.LogLine, 100)
+	errChan := make(chan error, 10)
+	go h.k8sClient.StreamLogs(ctx, opts, logChan, errChan)
+
+	for logChan != nil || errChan != nil {
+		var msg LogStreamMessage
+		select {
+		case <-ctx.Done():
+			return
+
+		case logLine, ok := <-logChan:
+			if !ok {
+				logChan = nil
+				continue
+			}
+			if grep != nil && !grep.MatchString(logLine.Message) {
+				continue
+			}
+			msg = LogStreamMessage{
+				Type:      "log",
+				Service:   serviceName,
+				Pod:       logLine.Pod,
+				Container: logLine.Container,
+				Timestamp: logLine.Timestamp,
+				Message:   redactor.Redact(logLine.Message),
+			}
+
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			msg = LogStreamMessage{
+				Type:      "error",
+				Service:   serviceName,
+				Timestamp: time.Now(),
+				Message:   err.Error(),
+			}
+		}
+
+		select {
+		case msgChan <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// splitLogServices parses the comma-separated services query parameter
+func splitLogServices(raw string) []string {
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// selectLogServices picks the named services of a project, or all of them
+// when no names are given. Names the project has no service for are returned
+// as unknown.
+func selectLogServices(services []*types.Service, names []string) (selected []*types.Service, unknown []string) {
+	if len(names) == 0 {
+		return services, nil
+	}
+
+	byName := make(map[string]*types.Service, len(services))
+	for _, service := range services {
+		byName[service.Name] = service
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if service, ok := byName[name]; ok {
+			selected = append(selected, service)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	return selected, unknown
+}
+
+// parseLogGrep compiles the grep query parameter, a regular expression log
+// messages must match. An empty pattern matches everything.
+func parseLogGrep(raw string) (*regexp.Regexp, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grep pattern: %w", err)
+	}
+	return re, nil
+}
+
+// parseLogSince parses the since query parameter, either an RFC 3339 time or
+// a duration before now such as 15m
+func parseLogSince(raw string, now time.Time) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return nil, fmt.Errorf("invalid since value %q: use an RFC 3339 time or a duration such as 15m", raw)
+	}
+	t := now.Add(-d)
+	return &t, nil
+}
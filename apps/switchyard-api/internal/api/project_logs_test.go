@@ -0,0 +1,78 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestSelectLogServices(t *testing.T) {
+	services := []*types.Service{{Name: "api"}, {Name: "web"}, {Name: "worker"}}
+
+	selected, unknown := selectLogServices(services, nil)
+	if len(selected) != 3 || unknown != nil {
+		t.Errorf("no names: selected %d services, unknown %v; want all and none", len(selected), unknown)
+	}
+
+	selected, unknown = selectLogServices(services, splitLogServices(" web, api,web,,billing "))
+	var names []string
+	for _, service := range selected {
+		names = append(names, service.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"web", "api"}) {
+		t.Errorf("selected = %v, want [web api]", names)
+	}
+	if !reflect.DeepEqual(unknown, []string{"billing"}) {
+		t.Errorf("unknown = %v, want [billing]", unknown)
+	}
+}
+
+func TestParseLogGrep(t *testing.T) {
+	if re, err := parseLogGrep(""); re != nil || err != nil {
+		t.Errorf("empty pattern = %v, %v; want no filter", re, err)
+	}
+
+	re, err := parseLogGrep(`(?i)error|timeout`)
+	if err != nil {
+		t.Fatalf("parseLogGrep() error = %v", err)
+	}
+	if !re.MatchString("upstream TIMEOUT after 30s") || re.MatchString("request served") {
+		t.Error("pattern matched the wrong messages")
+	}
+
+	if _, err := parseLogGrep(`[unclosed`); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestParseLogSince(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		raw     string
+		want    *time.Time
+		wantErr bool
+	}{
+		{raw: ""},
+		{raw: "15m", want: timePtr(now.Add(-15 * time.Minute))},
+		{raw: "2026-03-01T10:00:00Z", want: timePtr(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))},
+		{raw: "-5m", wantErr: true},
+		{raw: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseLogSince(tt.raw, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLogSince(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("parseLogSince(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/projectspec"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// maxProjectSpecSize bounds the size of an applied spec
+const maxProjectSpecSize = 1 << 20
+
+// ExportProjectSpec describes a project as an enclii.yaml spec. Secret values
+// are left out.
+// GET /v1/projects/:slug/spec?format=yaml
+func (h *Handler) ExportProjectSpec(c *gin.Context) {
+	if h.projectSpecs == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Project specs are not enabled"})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	// A spec covers every environment of the project
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	spec, err := h.projectSpecs.Export(ctx, project)
+	if isEncryptionKeyUnavailable(err) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to export project spec", logging.String("project", project.Slug), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export project spec"})
+		return
+	}
+
+	if c.Query("format") != "yaml" {
+		c.JSON(http.StatusOK, spec)
+		return
+	}
+	out, err := yaml.Marshal(spec)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to encode project spec", logging.String("project", project.Slug), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export project spec"})
+		return
+	}
+	c.Data(http.StatusOK, "application/yaml", out)
+}
+
+// ApplyProjectSpec brings a project to an enclii.yaml spec. The spec is sent
+// as JSON, or as YAML with a YAML content type. With dry_run=true the changes
+// are reported without being made; with prune=true environment variables and
+// dependencies missing from the spec are deleted.
+// POST /v1/projects/:slug/spec?dry_run=&prune=
+func (h *Handler) ApplyProjectSpec(c *gin.Context) {
+	if h.projectSpecs == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Project specs are not enabled"})
+		return
+	}
+
+	spec, err := readProjectSpec(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	opts := projectspec.ApplyOptions{
+		DryRun: c.Query("dry_run") == "true",
+		Prune:  c.Query("prune") == "true",
+	}
+	if userID, ok := c.Get("user_id"); ok {
+		if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+			opts.UserID = &parsed
+		}
+	}
+	if userEmail, ok := c.Get("user_email"); ok {
+		opts.UserEmail = fmt.Sprintf("%v", userEmail)
+	}
+
+	result, err := h.projectSpecs.Apply(ctx, project, spec, opts)
+	if err != nil {
+		var invalid *projectspec.InvalidSpecError
+		switch {
+		case errors.As(err, &invalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project spec", "problems": invalid.Problems})
+		case isEncryptionKeyUnavailable(err):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error(ctx, "Failed to apply project spec", logging.String("project", project.Slug), logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply project spec"})
+		}
+		return
+	}
+
+	if opts.DryRun {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	for _, domain := range result.Domains {
+		service, err := h.repos.Services.GetByID(domain.ServiceID)
+		if err != nil {
+			continue
+		}
+		if env, err := h.repos.Environments.GetByID(ctx, domain.EnvironmentID); err == nil {
+			h.addTunnelRoute(ctx, domain.Domain, service.Name, env.Name)
+		}
+		go h.triggerDomainReconciliation(ctx, domain.ServiceID, domain.EnvironmentID)
+	}
+
+	h.auditProjectSpec(c, project, result)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// readProjectSpec decodes the spec in a request body
+func readProjectSpec(c *gin.Context) (*types.ProjectSpec, error) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxProjectSpecSize+1))
+	if err != nil {
+		return nil, errors.New("request body could not be read")
+	}
+	if len(body) > maxProjectSpecSize {
+		return nil, errors.New("project spec is too large")
+	}
+
+	spec := &types.ProjectSpec{}
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if strings.HasSuffix(mediaType, "yaml") {
+		if err := yaml.Unmarshal(body, spec); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
+		return spec, nil
+	}
+	if err := json.Unmarshal(body, spec); err != nil {
+		return nil, errors.New("Invalid request body")
+	}
+	return spec, nil
+}
+
+// loadProject loads the project of the :slug route parameter, or writes the
+// error response and returns nil
+func (h *Handler) loadProject(c *gin.Context) *types.Project {
+	project, err := h.repos.Projects.GetBySlug(c.Param("slug"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return nil
+		}
+		h.logger.Error(c.Request.Context(), "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return nil
+	}
+	return project
+}
+
+// auditProjectSpec records an applied spec
+func (h *Handler) auditProjectSpec(c *gin.Context, project *types.Project, result *projectspec.Result) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	counts := make(map[string]int)
+	for _, change := range result.Changes {
+		counts[change.Action]++
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   email,
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       "project.spec_applied",
+		ResourceType: "project",
+		ResourceID:   project.ID.String(),
+		ResourceName: project.Slug,
+		ProjectID:    &project.ID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"created":  counts["create"],
+			"updated":  counts["update"],
+			"deleted":  counts["delete"],
+			"warnings": len(result.Warnings),
+			"prune":    c.Query("prune") == "true",
+		},
+	})
+}
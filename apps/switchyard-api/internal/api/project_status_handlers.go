@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// recentBuildsLimit bounds the builds a project status lists
+const recentBuildsLimit = 10
+
+// GetProjectStatus returns a snapshot of a project's services with their
+// latest deployment in each environment, its recent builds, and the pressure
+// on the build queue. Live views refresh it on events from /v1/events/stream.
+// GET /v1/projects/:slug/status
+func (h *Handler) GetProjectStatus(c *gin.Context) {
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	status, err := h.projectStatus(ctx, project)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project status",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// projectStatus assembles the status snapshot of a project
+func (h *Handler) projectStatus(ctx context.Context, project *types.Project) (*types.ProjectStatus, error) {
+	services, err := h.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := h.repos.ProjectStatus.LatestDeployments(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	builds, err := h.repos.ProjectStatus.RecentBuilds(ctx, project.ID, recentBuildsLimit)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := h.repos.ProjectStatus.BuildQueue(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	byService := make(map[string][]types.DeploymentStatusEntry)
+	for _, deployment := range deployments {
+		key := deployment.ServiceID.String()
+		byService[key] = append(byService[key], deployment)
+	}
+
+	status := &types.ProjectStatus{
+		ProjectID:    project.ID,
+		ProjectSlug:  project.Slug,
+		Services:     make([]types.ServiceStatusEntry, 0, len(services)),
+		RecentBuilds: builds,
+		Queue:        *queue,
+		GeneratedAt:  time.Now(),
+	}
+	if status.RecentBuilds == nil {
+		status.RecentBuilds = []types.BuildStatusEntry{}
+	}
+	for _, service := range services {
+		entries := byService[service.ID.String()]
+		if entries == nil {
+			entries = []types.DeploymentStatusEntry{}
+		}
+		status.Services = append(status.Services, types.ServiceStatusEntry{
+			ServiceID:   service.ID,
+			Name:        service.Name,
+			Deployments: entries,
+		})
+	}
+
+	return status, nil
+}
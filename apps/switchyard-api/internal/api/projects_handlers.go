@@ -1,6 +1,7 @@
 package api
 
 import (
+	"database/sql"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -84,13 +85,29 @@ func (h *Handler) CreateProject(c *gin.Context) {
 // Request:
 //   - Method: GET /api/v1/projects
 //   - Authorization: Bearer <access_token>
+//   - Query Parameters: trashed (bool) - list the projects in the trash instead (admins only)
 //
 // Response:
 //   - 200 OK: {projects: Project[]}
+//   - 403 Forbidden: Trashed projects requested by a non-admin
 //   - 500 Internal Server Error: Failed to list projects
 func (h *Handler) ListProjects(c *gin.Context) {
 	ctx := c.Request.Context()
 
+	trashed, ok := trashedFilter(c)
+	if !ok {
+		return
+	}
+	if trashed {
+		projects, err := h.repos.Projects.ListTrashed(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list projects"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"projects": projects})
+		return
+	}
+
 	// Use service layer for listing projects
 	projects, err := h.projectService.ListProjects(ctx)
 	if err != nil {
@@ -113,6 +130,7 @@ func (h *Handler) ListProjects(c *gin.Context) {
 //
 // Response:
 //   - 200 OK: Project object
+//   - 304 Not Modified: If-None-Match still matches the ETag
 //   - 404 Not Found: Project not found
 //   - 500 Internal Server Error: Failed to get project
 func (h *Handler) GetProject(c *gin.Context) {
@@ -130,14 +148,15 @@ func (h *Handler) GetProject(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, project)
+	respondWithETag(c, http.StatusOK, projectETag(project), project)
 }
 
-// DeleteProject deletes a project and all associated resources.
+// DeleteProject moves a project and its services to the trash.
 //
-// Projects can only be deleted by administrators. All services, environments,
-// and other resources associated with the project are automatically deleted
-// via database cascading deletes.
+// Projects can only be deleted by administrators. Their services are scaled
+// to zero and hidden along with the project, which stays restorable until
+// the retention window passes; then its workloads, namespaces, images and
+// rows are purged for good.
 //
 // Request:
 //   - Method: DELETE /api/v1/projects/:slug
@@ -145,13 +164,20 @@ func (h *Handler) GetProject(c *gin.Context) {
 //   - Path Parameters: slug (string) - Project slug
 //
 // Response:
-//   - 200 OK: {message: "Project deleted successfully"}
+//   - 200 OK: {message, deleted_at, restorable_until}
 //   - 404 Not Found: Project not found
+//   - 412 Precondition Failed: If-Match does not match the project's ETag
 //   - 500 Internal Server Error: Failed to delete project
+//   - 503 Service Unavailable: Trash not configured
 func (h *Handler) DeleteProject(c *gin.Context) {
 	ctx := c.Request.Context()
 	slug := c.Param("slug")
 
+	if h.trash == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Project deletion is not available"})
+		return
+	}
+
 	// Get project first to verify it exists and get its ID
 	project, err := h.projectService.GetProject(ctx, slug)
 	if err != nil {
@@ -165,9 +191,16 @@ func (h *Handler) DeleteProject(c *gin.Context) {
 		}
 		return
 	}
+	if !checkPreconditions(c, projectETag(project)) {
+		return
+	}
 
-	// Delete the project (CASCADE will handle related records)
-	if err := h.repos.Projects.Delete(ctx, project.ID); err != nil {
+	deletedAt, err := h.trash.TrashProject(ctx, project, actorUUID(c))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
 		h.logger.Error(ctx, "Failed to delete project",
 			logging.Error("error", err),
 			logging.String("project_slug", slug),
@@ -176,10 +209,20 @@ func (h *Handler) DeleteProject(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info(ctx, "Project deleted",
+	if h.cache != nil {
+		if err := h.cache.InvalidateTags(ctx, "projects"); err != nil {
+			h.logger.Warn(ctx, "Failed to invalidate project cache", logging.Error("error", err))
+		}
+	}
+
+	h.logger.Info(ctx, "Project moved to trash",
 		logging.String("project_id", project.ID.String()),
 		logging.String("project_slug", slug),
 		logging.String("deleted_by", c.GetString("user_email")))
 
-	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Project moved to trash",
+		"deleted_at":       deletedAt,
+		"restorable_until": h.trash.RestorableUntil(deletedAt),
+	})
 }
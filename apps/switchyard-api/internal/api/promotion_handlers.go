@@ -0,0 +1,311 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/promotion"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// PromoteReleaseRequest adjusts a promotion; all fields are optional
+type PromoteReleaseRequest struct {
+	Replicas        int    `json:"replicas,omitempty"`          // Defaults to the scale of the service in the target environment
+	ChangeTicketURL string `json:"change_ticket_url,omitempty"` // For production promotions
+	OverrideReason  string `json:"override_reason,omitempty"`   // Justifies overriding the deploy policy
+}
+
+// PromoteReleaseResponse is a promotion and the deployment it created
+type PromoteReleaseResponse struct {
+	Promotion  *types.ReleasePromotion `json:"promotion"`
+	Deployment *types.Deployment       `json:"deployment"`
+}
+
+// UpdatePromotionRequest sets where releases are promoted to from an
+// environment
+type UpdatePromotionRequest struct {
+	To   string `json:"to"` // Environment name; empty ends the pipeline here
+	Auto bool   `json:"auto"`
+}
+
+// PromotionResponse is where releases are promoted to from an environment
+type PromotionResponse struct {
+	Environment string `json:"environment"`
+	To          string `json:"to,omitempty"`
+	Auto        bool   `json:"auto"`
+}
+
+// PromoteRelease deploys a release running in one environment to another,
+// with the same image, and records the promotion
+// POST /v1/releases/:id/promote?to=<env>[&from=<env>]
+func (h *Handler) PromoteRelease(c *gin.Context) {
+	ctx := c.Request.Context()
+	releaseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+	to := c.Query("to")
+	if to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to is required"})
+		return
+	}
+
+	var req PromoteReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	release, err := h.repos.Releases.GetByID(releaseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get release", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get release"})
+		return
+	}
+	if release.Status != types.ReleaseStatusReady {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Release is not ready for deployment"})
+		return
+	}
+	if release.PrunedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Release was pruned and its image deleted; build or deploy a newer release"})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(release.ServiceID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get service", logging.Error("db_error", err))
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		return
+	}
+
+	target, err := h.repos.Environments.GetByProjectAndName(service.ProjectID, to)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found", "environment": to})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get environment", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get environment"})
+		return
+	}
+
+	source, err := promotion.Source(ctx, h.repos, release, target, c.Query("from"))
+	if err != nil {
+		if errors.Is(err, promotion.ErrNotValidated) || errors.Is(err, promotion.ErrAmbiguousSource) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":       err.Error(),
+				"environment": to,
+				"release_id":  releaseID,
+				"help":        "Promote a release that is the current deployment of its service in another environment, naming that environment with from when there are several",
+			})
+			return
+		}
+		h.logger.Error(ctx, "Failed to find promotion source", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find the environment to promote from"})
+		return
+	}
+
+	if req.Replicas <= 0 {
+		if req.Replicas, err = promotion.Replicas(ctx, h.repos, service.ID, target); err != nil {
+			h.logger.Error(ctx, "Failed to get current deployment", logging.Error("db_error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current deployment"})
+			return
+		}
+	}
+
+	override, ok := h.checkDeploy(c, service, releaseID, target, req.Replicas, req.OverrideReason)
+	if !ok {
+		return
+	}
+	if !h.checkDeploymentApproval(c, &types.Deployment{ReleaseID: releaseID, EnvironmentID: target.ID, Replicas: req.Replicas}, release, service, target.Name, req.ChangeTicketURL) {
+		return
+	}
+
+	promotedBy := ""
+	if userEmail, ok := c.Get("user_email"); ok {
+		promotedBy = fmt.Sprintf("%v", userEmail)
+	}
+	promoted, deployment, err := promotion.Promote(ctx, h.repos, release, source, target, req.Replicas, promotedBy, false)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to promote release",
+			logging.String("release_id", releaseID.String()),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote release"})
+		return
+	}
+
+	if override != nil {
+		h.recordDeployPolicyOverride(c, override, deployment, service, target, req.OverrideReason)
+	}
+
+	if err := h.reconciler.ScheduleReconciliation(deployment.ID.String(), 1); err != nil {
+		h.logger.Warn(ctx, "Failed to queue reconciliation, pending deployment scan will retry",
+			logging.String("deployment_id", deployment.ID.String()),
+			logging.Error("queue_error", err))
+	}
+
+	h.auditEnvironment(c, project, target, "environment.release_promoted", map[string]interface{}{
+		"release_id":    releaseID.String(),
+		"service_id":    service.ID.String(),
+		"from":          source.Name,
+		"deployment_id": deployment.ID.String(),
+		"image_uri":     promoted.ImageURI,
+		"image_digest":  promoted.ImageDigest,
+	})
+
+	h.logger.Info(ctx, "Release promoted",
+		logging.String("release_id", releaseID.String()),
+		logging.String("from", source.Name),
+		logging.String("to", target.Name),
+		logging.String("deployment_id", deployment.ID.String()))
+
+	c.JSON(http.StatusCreated, PromoteReleaseResponse{Promotion: promoted, Deployment: deployment})
+}
+
+// ListReleasePromotions returns the promotion chain of a release
+// GET /v1/releases/:id/promotions
+func (h *Handler) ListReleasePromotions(c *gin.Context) {
+	ctx := c.Request.Context()
+	releaseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid release ID"})
+		return
+	}
+
+	release, err := h.repos.Releases.GetByID(releaseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get release", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get release"})
+		return
+	}
+
+	promotions, err := h.repos.ReleasePromotions.ListByRelease(ctx, release.ID)
+	if err != nil {
+		if isTableNotExistError(err) {
+			c.JSON(http.StatusOK, gin.H{"promotions": []*types.ReleasePromotion{}})
+			return
+		}
+		h.logger.Error(ctx, "Failed to list release promotions",
+			logging.String("release_id", releaseID.String()),
+			logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list release promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promotions": promotions})
+}
+
+// GetPromotion returns where releases are promoted to from an environment
+// GET /v1/projects/:slug/environments/:env_name/promotion
+func (h *Handler) GetPromotion(c *gin.Context) {
+	_, env := h.loadEnvironment(c)
+	if env == nil {
+		return
+	}
+
+	response, err := h.promotionResponse(c, env)
+	if err != nil {
+		h.logger.Error(c.Request.Context(), "Failed to get promotion",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get promotion"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdatePromotion sets where releases are promoted to from an environment
+// and whether they are promoted once they pass their soak there
+// PUT /v1/projects/:slug/environments/:env_name/promotion
+func (h *Handler) UpdatePromotion(c *gin.Context) {
+	var req UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.To == "" && req.Auto {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "auto needs an environment to promote to"})
+		return
+	}
+
+	project, env := h.loadEnvironment(c)
+	if env == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var promotesTo *uuid.UUID
+	if req.To != "" {
+		envs, err := h.repos.Environments.ListByProject(project.ID)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to list environments", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update promotion"})
+			return
+		}
+		var next *types.Environment
+		for _, e := range envs {
+			if e.Name == req.To {
+				next = e
+			}
+		}
+		if next == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("environment %s not found", req.To)})
+			return
+		}
+		if err := promotion.CheckPipeline(envs, env, next); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		promotesTo = &next.ID
+	}
+
+	if err := h.repos.Environments.SetPromotion(ctx, env, promotesTo, req.Auto); err != nil {
+		h.logger.Error(ctx, "Failed to update promotion",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update promotion"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.promotion_updated", map[string]interface{}{
+		"to":   req.To,
+		"auto": req.Auto,
+	})
+
+	c.JSON(http.StatusOK, PromotionResponse{Environment: env.Name, To: req.To, Auto: req.Auto})
+}
+
+// promotionResponse names the environment an environment promotes to
+func (h *Handler) promotionResponse(c *gin.Context, env *types.Environment) (*PromotionResponse, error) {
+	response := &PromotionResponse{Environment: env.Name, Auto: env.AutoPromote}
+	if env.PromotesTo != nil {
+		next, err := h.repos.Environments.GetByID(c.Request.Context(), *env.PromotesTo)
+		if err != nil {
+			return nil, err
+		}
+		response.To = next.Name
+	}
+	return response, nil
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/ratelimit"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// RateLimitPolicyRequest creates or replaces a rate limit policy
+type RateLimitPolicyRequest struct {
+	Name          string               `json:"name" binding:"required,max=100"`
+	Method        string               `json:"method" binding:"required"`
+	Route         string               `json:"route" binding:"required,max=255"`
+	Scope         types.RateLimitScope `json:"scope,omitempty"` // Defaults to user
+	Requests      int                  `json:"requests" binding:"required,min=1"`
+	WindowSeconds int                  `json:"window_seconds" binding:"required,min=1,max=86400"`
+	Enabled       *bool                `json:"enabled,omitempty"` // Defaults to true
+	Description   string               `json:"description,omitempty" binding:"max=500"`
+}
+
+// SetAPITokenRateLimitRequest sets the custom quota of an API token; null
+// returns it to the platform's per-token limit
+type SetAPITokenRateLimitRequest struct {
+	RateLimitPerMinute *int `json:"rate_limit_per_minute" binding:"omitempty,min=1"`
+}
+
+// policy validates the request and applies it to a policy
+func (r *RateLimitPolicyRequest) policy(policy *types.RateLimitPolicy) error {
+	method := strings.ToUpper(r.Method)
+	if !ratelimit.ValidMethod(method) {
+		return fmt.Errorf("method must be GET, POST, PUT, PATCH, DELETE or *")
+	}
+	if !strings.HasPrefix(r.Route, "/v1/") {
+		return fmt.Errorf("route must be an API route pattern such as /v1/services/:id/build")
+	}
+	scope := r.Scope
+	if scope == "" {
+		scope = types.RateLimitScopeUser
+	}
+	if !ratelimit.ValidScope(scope) {
+		return fmt.Errorf("scope must be user, token or ip")
+	}
+
+	policy.Name = r.Name
+	policy.Method = method
+	policy.Route = r.Route
+	policy.Scope = scope
+	policy.Requests = r.Requests
+	policy.WindowSeconds = r.WindowSeconds
+	policy.Enabled = r.Enabled == nil || *r.Enabled
+	policy.Description = r.Description
+	return nil
+}
+
+// ListRateLimitPolicies lists the rate limit policies of expensive routes
+// GET /v1/admin/rate-limit-policies
+func (h *Handler) ListRateLimitPolicies(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	policies, err := h.repos.RateLimitPolicies.List(ctx)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list rate limit policies", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list rate limit policies"})
+		return
+	}
+	if policies == nil {
+		policies = []*types.RateLimitPolicy{}
+	}
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// CreateRateLimitPolicy gives a route its own budget. Every replica applies
+// it within 30 seconds.
+// POST /v1/admin/rate-limit-policies
+func (h *Handler) CreateRateLimitPolicy(c *gin.Context) {
+	var req RateLimitPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	policy := &types.RateLimitPolicy{}
+	if err := req.policy(policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.RateLimitPolicies.Create(ctx, policy); err != nil {
+		if db.IsUniqueConstraintError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A rate limit policy with this name already exists"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to create rate limit policy", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rate limit policy"})
+		return
+	}
+
+	h.auditAdminChange(c, "admin.rate_limit_policy_created", "rate_limit_policy", policy.ID.String(), policy.Name, map[string]interface{}{
+		"policy": policy,
+	})
+	h.invalidateRateLimitPolicies()
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdateRateLimitPolicy replaces a rate limit policy
+// PUT /v1/admin/rate-limit-policies/:id
+func (h *Handler) UpdateRateLimitPolicy(c *gin.Context) {
+	policy := h.loadRateLimitPolicy(c)
+	if policy == nil {
+		return
+	}
+	previous := *policy
+
+	var req RateLimitPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := req.policy(policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.RateLimitPolicies.Update(ctx, policy); err != nil {
+		if db.IsUniqueConstraintError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A rate limit policy with this name already exists"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to update rate limit policy", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rate limit policy"})
+		return
+	}
+
+	h.auditAdminChange(c, "admin.rate_limit_policy_updated", "rate_limit_policy", policy.ID.String(), policy.Name, map[string]interface{}{
+		"previous_policy": previous,
+		"policy":          policy,
+	})
+	h.invalidateRateLimitPolicies()
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteRateLimitPolicy removes a rate limit policy; its route is left to
+// the limits per IP, user and API token
+// DELETE /v1/admin/rate-limit-policies/:id
+func (h *Handler) DeleteRateLimitPolicy(c *gin.Context) {
+	policy := h.loadRateLimitPolicy(c)
+	if policy == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.RateLimitPolicies.Delete(ctx, policy.ID); err != nil && err != sql.ErrNoRows {
+		h.logger.Error(ctx, "Failed to delete rate limit policy", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rate limit policy"})
+		return
+	}
+
+	h.auditAdminChange(c, "admin.rate_limit_policy_deleted", "rate_limit_policy", policy.ID.String(), policy.Name, map[string]interface{}{
+		"policy": policy,
+	})
+	h.invalidateRateLimitPolicies()
+	c.Status(http.StatusNoContent)
+}
+
+// SetAPITokenRateLimit sets the requests per minute an API token may make,
+// in place of the platform's per-token limit, e.g. to raise it for a busy
+// CI pipeline
+// PUT /v1/admin/tokens/:id/rate-limit
+func (h *Handler) SetAPITokenRateLimit(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+	var req SetAPITokenRateLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	token, err := h.repos.APITokens.GetByID(ctx, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get API token", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set token rate limit"})
+		return
+	}
+
+	if err := h.repos.APITokens.SetRateLimit(ctx, id, req.RateLimitPerMinute); err != nil {
+		h.logger.Error(ctx, "Failed to set API token rate limit", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set token rate limit"})
+		return
+	}
+
+	h.auditAdminChange(c, "admin.token_rate_limit_updated", "api_token", token.ID.String(), token.Name, map[string]interface{}{
+		"owner_id":              token.UserID.String(),
+		"previous_rate_limit":   token.RateLimit,
+		"rate_limit_per_minute": req.RateLimitPerMinute,
+		"default_rate_limit":    h.config.RateLimitTokenPerMinute,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"id":                    token.ID,
+		"name":                  token.Name,
+		"rate_limit_per_minute": req.RateLimitPerMinute,
+	})
+}
+
+// loadRateLimitPolicy returns the policy of the :id parameter, or responds
+// with an error and returns nil
+func (h *Handler) loadRateLimitPolicy(c *gin.Context) *types.RateLimitPolicy {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy ID"})
+		return nil
+	}
+	ctx := c.Request.Context()
+
+	policy, err := h.repos.RateLimitPolicies.GetByID(ctx, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rate limit policy not found"})
+		return nil
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get rate limit policy", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rate limit policy"})
+		return nil
+	}
+	return policy
+}
+
+// auditAdminChange records a change made through the /admin API
+func (h *Handler) auditAdminChange(c *gin.Context, action, resourceType, resourceID, resourceName string, auditContext map[string]interface{}) {
+	actorEmail, _ := auth.GetUserEmailFromContext(c)
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorEmail:   actorEmail,
+		ActorRole:    types.Role(c.GetString("user_role")),
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		ResourceName: resourceName,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Outcome:      "success",
+		Context:      auditContext,
+	})
+}
+
+// invalidateRateLimitPolicies applies a policy change on this replica at
+// once; the others reload within 30 seconds
+func (h *Handler) invalidateRateLimitPolicies() {
+	if h.rateLimitPolicies != nil {
+		h.rateLimitPolicies.Invalidate()
+	}
+}
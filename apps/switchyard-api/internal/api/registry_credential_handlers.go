@@ -0,0 +1,211 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/registry"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SetRegistryCredentialRequest stores the credential builds and pods of a
+// project use with a private registry
+type SetRegistryCredentialRequest struct {
+	Registry       string                 `json:"registry" binding:"required"` // Registry host, e.g. ghcr.io
+	Provider       types.RegistryProvider `json:"provider,omitempty"`          // basic (default), ecr, gcr or harbor
+	Username       string                 `json:"username,omitempty"`          // Access key ID for ECR; taken from the key for GCR
+	Password       string                 `json:"password" binding:"required"` // Password, access token, AWS secret key or GCP JSON key; never returned
+	Environment    string                 `json:"environment,omitempty"`       // Scope the credential to one environment's pods
+	PushRepository string                 `json:"push_repository,omitempty"`   // Repository on the registry builds push images to
+}
+
+// ListRegistryCredentials lists the private registry credentials of a
+// project, without their passwords
+// GET /v1/projects/:slug/registry-credentials
+func (h *Handler) ListRegistryCredentials(c *gin.Context) {
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	creds, err := h.repos.RegistryCredentials.ListByProject(ctx, project.ID)
+	if err != nil {
+		if isTableNotExistError(err) {
+			c.JSON(http.StatusOK, gin.H{"registry_credentials": []*types.RegistryCredential{}})
+			return
+		}
+		h.logger.Error(ctx, "Failed to list registry credentials",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list registry credentials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registry_credentials": creds})
+}
+
+// SetRegistryCredential stores the credential of a project, or of one of
+// its environments, for a registry, replacing the one it had in that scope.
+// Services pick it up on their next build and deployment; with a push
+// repository their builds push images there.
+// POST /v1/projects/:slug/registry-credentials
+func (h *Handler) SetRegistryCredential(c *gin.Context) {
+	var req SetRegistryCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	host, err := registry.NormalizeHost(req.Registry)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	provider := req.Provider
+	if provider == "" {
+		provider = types.RegistryProviderBasic
+	}
+	username, err := registry.ValidateCredential(provider, host, strings.TrimSpace(req.Username), req.Password)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var pushRepository string
+	if req.PushRepository != "" {
+		if req.Environment != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "push_repository applies to the builds of the whole project and cannot be set on an environment's credential"})
+			return
+		}
+		repoHost, repoPath, err := registry.ParseRepository(req.PushRepository)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if repoHost != host {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("push_repository must be on %s", host)})
+			return
+		}
+		pushRepository = repoHost + "/" + repoPath
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	var environmentID *uuid.UUID
+	if req.Environment != "" {
+		env, err := h.repos.Environments.GetByProjectAndName(project.ID, req.Environment)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found", "environment": req.Environment})
+			return
+		}
+		environmentID = &env.ID
+	}
+	if !h.authorizeEnvironment(c, project.ID, environmentID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	cred := &types.RegistryCredential{
+		ProjectID:      project.ID,
+		EnvironmentID:  environmentID,
+		Environment:    req.Environment,
+		Registry:       host,
+		Provider:       provider,
+		Username:       username,
+		PushRepository: pushRepository,
+	}
+	if userEmail, ok := c.Get("user_email"); ok {
+		cred.CreatedBy = fmt.Sprintf("%v", userEmail)
+	}
+	if err := h.repos.RegistryCredentials.Upsert(ctx, cred, req.Password); err != nil {
+		if strings.Contains(err.Error(), "idx_registry_credentials_push_repository") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Builds of the project already push to another registry; remove its push_repository first"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to save registry credential",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save registry credential"})
+		return
+	}
+
+	h.auditRegistryCredential(c, project, cred, "project.registry_credential_set")
+	c.JSON(http.StatusOK, cred)
+}
+
+// DeleteRegistryCredential removes a registry credential of a project
+// DELETE /v1/projects/:slug/registry-credentials/:credential_id
+func (h *Handler) DeleteRegistryCredential(c *gin.Context) {
+	credentialID, err := uuid.Parse(c.Param("credential_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registry credential ID"})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.RegistryCredentials.Delete(ctx, project.ID, credentialID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Registry credential not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete registry credential",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete registry credential"})
+		return
+	}
+
+	h.auditRegistryCredential(c, project, &types.RegistryCredential{ID: credentialID}, "project.registry_credential_deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Registry credential deleted"})
+}
+
+// auditRegistryCredential records a change to the registry credentials of
+// a project
+func (h *Handler) auditRegistryCredential(c *gin.Context, project *types.Project, cred *types.RegistryCredential, action string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   fmt.Sprintf("%v", userEmail),
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       action,
+		ResourceType: "project",
+		ResourceID:   project.ID.String(),
+		ResourceName: project.Slug,
+		ProjectID:    &project.ID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"credential_id":   cred.ID.String(),
+			"registry":        cred.Registry,
+			"provider":        cred.Provider,
+			"environment":     cred.Environment,
+			"username":        cred.Username,
+			"push_repository": cred.PushRepository,
+		},
+	})
+}
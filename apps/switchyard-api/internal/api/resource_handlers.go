@@ -0,0 +1,868 @@
+package api
+
+import (
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// The PUT endpoints below address resources by stable, user-chosen names
+// (project slug, service name, env var key, domain name, addon name) so that
+// infrastructure-as-code clients can converge on a desired state by
+// repeating the same request. They answer 201 when the resource was created
+// and 200 when it already existed, with the ETag of the stored version.
+// If-Match guards against overwriting a concurrent change and
+// If-None-Match: * against replacing a resource that already exists; a
+// failed precondition is 412. A change the resource cannot take in place is
+// 409.
+
+// PutProjectRequest is the desired state of a project
+type PutProjectRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// PutProject creates a project under the slug, or renames the existing one
+// PUT /v1/projects/:slug
+func (h *Handler) PutProject(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+
+	var req PutProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	project, err := h.repos.Projects.GetBySlug(slug)
+	if err != nil && err != sql.ErrNoRows {
+		h.logger.Error(ctx, "Failed to get project", logging.String("project", slug), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		return
+	}
+	current := ""
+	if project != nil {
+		current = projectETag(project)
+	}
+	if !checkPreconditions(c, current) {
+		return
+	}
+
+	userEmail := c.GetString("user_email")
+	userRole := c.GetString("user_role")
+
+	if project == nil {
+		resp, err := h.projectService.CreateProject(ctx, &services.CreateProjectRequest{
+			Name:      req.Name,
+			Slug:      slug,
+			UserID:    c.GetString("user_id"),
+			UserEmail: userEmail,
+			UserRole:  userRole,
+		})
+		if err != nil {
+			if errors.Is(err, errors.ErrSlugAlreadyExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": "A project with this slug already exists"})
+			} else if errors.Is(err, errors.ErrValidation) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			} else {
+				h.logger.Error(ctx, "Failed to create project", logging.String("project", slug), logging.Error("error", err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+			}
+			return
+		}
+
+		if h.cache != nil {
+			if err := h.cache.InvalidateTags(ctx, "projects"); err != nil {
+				h.logger.Warn(ctx, "Failed to invalidate project cache", logging.Error("error", err))
+			}
+		}
+		monitoring.RecordProjectCreated()
+
+		respondWithETag(c, http.StatusCreated, projectETag(resp.Project), resp.Project)
+		return
+	}
+
+	if err := h.projectService.RenameProject(ctx, project, req.Name, userEmail, userRole); err != nil {
+		if errors.Is(err, errors.ErrValidation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to rename project", logging.String("project", slug), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		return
+	}
+
+	respondWithETag(c, http.StatusOK, projectETag(project), project)
+}
+
+// PutServiceRequest is the desired state of a service. Omitted settings take
+// their defaults, as on creation.
+type PutServiceRequest struct {
+	GitRepo          string            `json:"git_repo" binding:"required"`
+	AppPath          string            `json:"app_path"`
+	BuildConfig      types.BuildConfig `json:"build_config"`
+	AutoDeploy       *bool             `json:"auto_deploy"`
+	AutoDeployBranch string            `json:"auto_deploy_branch"`
+	AutoDeployEnv    string            `json:"auto_deploy_env"`
+	Labels           map[string]string `json:"labels"`
+}
+
+// PutService creates the named service in a project, or replaces its settings
+// PUT /v1/projects/:slug/services/:name
+func (h *Handler) PutService(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Param("name")
+
+	var req PutServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+
+	service, err := h.findServiceByName(project.ID, name)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list services", logging.String("project", project.Slug), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get service"})
+		return
+	}
+	current := ""
+	if service != nil {
+		current = serviceETag(service)
+	}
+	if !checkPreconditions(c, current) {
+		return
+	}
+
+	autoDeploy := true
+	if req.AutoDeploy != nil {
+		autoDeploy = *req.AutoDeploy
+	}
+	userEmail := c.GetString("user_email")
+	userRole := c.GetString("user_role")
+
+	status := http.StatusOK
+	if service == nil {
+		resp, err := h.projectService.CreateService(ctx, &services.CreateServiceRequest{
+			ProjectID:        project.ID.String(),
+			Name:             name,
+			GitRepo:          req.GitRepo,
+			AppPath:          req.AppPath,
+			AutoDeploy:       &autoDeploy,
+			AutoDeployBranch: req.AutoDeployBranch,
+			AutoDeployEnv:    req.AutoDeployEnv,
+			BuildConfig:      req.BuildConfig,
+			Labels:           req.Labels,
+			UserID:           c.GetString("user_id"),
+			UserEmail:        userEmail,
+			UserRole:         userRole,
+		})
+		if err != nil {
+			if errors.Is(err, errors.ErrValidation) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if quotaExceeded(c, err) {
+				return
+			}
+			h.logger.Error(ctx, "Failed to create service", logging.String("project", project.Slug), logging.String("service", name), logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service"})
+			return
+		}
+		h.detectServiceRuntimes(resp.Service)
+		service = resp.Service
+		status = http.StatusCreated
+	} else {
+		service.GitRepo = req.GitRepo
+		service.AppPath = req.AppPath
+		service.BuildConfig = req.BuildConfig
+		service.AutoDeploy = autoDeploy
+		service.AutoDeployBranch = req.AutoDeployBranch
+		service.AutoDeployEnv = req.AutoDeployEnv
+		service.Labels = req.Labels
+		if err := h.projectService.ReplaceService(ctx, service, userEmail, userRole); err != nil {
+			if errors.Is(err, errors.ErrValidation) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			h.logger.Error(ctx, "Failed to update service", logging.String("service_id", service.ID.String()), logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service"})
+			return
+		}
+	}
+
+	stored, err := h.repos.Services.GetByID(service.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to reload service", logging.String("service_id", service.ID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get service"})
+		return
+	}
+	respondWithETag(c, status, serviceETag(stored), stored)
+}
+
+// findServiceByName returns the named service of a project, or nil when it
+// has none
+func (h *Handler) findServiceByName(projectID uuid.UUID, name string) (*types.Service, error) {
+	svcs, err := h.repos.Services.ListByProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range svcs {
+		if svc.Name == name {
+			return svc, nil
+		}
+	}
+	return nil, nil
+}
+
+// PutEnvVarRequest is the desired state of an environment variable
+type PutEnvVarRequest struct {
+	Value    string `json:"value" binding:"required"`
+	IsSecret bool   `json:"is_secret"`
+}
+
+// PutEnvVar sets an environment variable of a service by key. Without
+// environment_id the variable applies to every environment.
+// PUT /v1/services/:id/env-vars/keys/:key?environment_id=
+func (h *Handler) PutEnvVar(c *gin.Context) {
+	ctx := c.Request.Context()
+	key := c.Param("key")
+
+	svcID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	service, err := h.repos.Services.GetByID(svcID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	if !isValidEnvVarKey(key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid environment variable key. Must start with letter or underscore, and contain only alphanumeric characters and underscores."})
+		return
+	}
+
+	var envID *uuid.UUID
+	if raw := c.Query("environment_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid environment ID"})
+			return
+		}
+		env, err := h.repos.Environments.GetByID(ctx, parsed)
+		if err != nil || env.ProjectID != service.ProjectID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+			return
+		}
+		envID = &parsed
+	}
+
+	if !h.authorizeEnvironment(c, service.ProjectID, envID) {
+		return
+	}
+
+	var req PutEnvVarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ev, err := h.repos.EnvVars.GetByServiceEnvKey(ctx, svcID, envID, key)
+	if err != nil && err != sql.ErrNoRows {
+		if isEncryptionKeyUnavailable(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get env var", logging.String("service_id", svcID.String()), logging.String("key", key), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get environment variable"})
+		return
+	}
+	if err == sql.ErrNoRows {
+		ev = nil
+	}
+	current := ""
+	if ev != nil {
+		current = envVarETag(ev)
+	}
+	if !checkPreconditions(c, current) {
+		return
+	}
+
+	userEmail := c.GetString("user_email")
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		actorID = &parsed
+	}
+
+	status := http.StatusOK
+	action := "updated"
+	oldValueHash := ""
+	if ev == nil {
+		ev = &types.EnvironmentVariable{
+			ServiceID:      svcID,
+			EnvironmentID:  envID,
+			Key:            key,
+			Value:          req.Value,
+			IsSecret:       req.IsSecret,
+			CreatedBy:      actorID,
+			CreatedByEmail: userEmail,
+		}
+		err = h.repos.EnvVars.Create(ctx, ev)
+		status = http.StatusCreated
+		action = "created"
+	} else {
+		oldValueHash = hashValue(ev.Value)
+		ev.Value = req.Value
+		ev.IsSecret = req.IsSecret
+		err = h.repos.EnvVars.Update(ctx, ev)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Environment variable was created concurrently"})
+			return
+		}
+		if isEncryptionKeyUnavailable(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to set env var", logging.String("service_id", svcID.String()), logging.String("key", key), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set environment variable"})
+		return
+	}
+
+	h.repos.EnvVars.LogAudit(ctx, &types.EnvVarAuditLog{
+		EnvVarID:      ev.ID,
+		ServiceID:     svcID,
+		EnvironmentID: envID,
+		Action:        action,
+		Key:           ev.Key,
+		OldValueHash:  oldValueHash,
+		NewValueHash:  hashValue(ev.Value),
+		ActorID:       actorID,
+		ActorEmail:    userEmail,
+		ActorIP:       c.ClientIP(),
+		UserAgent:     c.GetHeader("User-Agent"),
+	})
+	h.recordEnvVarChange(c, svcID, envID, action, []string{ev.Key}, &ev.ID)
+
+	respondWithETag(c, status, envVarETag(ev), toEnvVarResponse(ev))
+}
+
+// PutDomainRequest is the desired state of a custom domain
+type PutDomainRequest struct {
+	EnvironmentID string `json:"environment_id" binding:"required"`
+	TLSEnabled    *bool  `json:"tls_enabled"`
+	TLSIssuer     string `json:"tls_issuer"`
+	DNSMode       string `json:"dns_mode"` // "managed", "manual", or empty to keep the current mode
+}
+
+// PutCustomDomain attaches a custom domain to a service, or updates its TLS
+// and DNS settings. The environment of an existing domain cannot change.
+// PUT /v1/services/:id/domains/names/:domain
+func (h *Handler) PutCustomDomain(c *gin.Context) {
+	ctx := c.Request.Context()
+	domainName := strings.ToLower(c.Param("domain"))
+
+	var req PutDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	serviceUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service_id"})
+		return
+	}
+	service, err := h.repos.Services.GetByID(serviceUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "service not found"})
+		return
+	}
+
+	envUUID, err := uuid.Parse(req.EnvironmentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment_id"})
+		return
+	}
+	env, err := h.repos.Environments.GetByID(ctx, envUUID)
+	if err != nil || env.ProjectID != service.ProjectID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "environment not found"})
+		return
+	}
+	if !h.authorizeEnvironment(c, service.ProjectID, &envUUID) {
+		return
+	}
+
+	if !isValidDomain(domainName) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid domain format"})
+		return
+	}
+
+	domains, err := h.repos.CustomDomains.GetByServiceID(ctx, serviceUUID.String())
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list custom domains", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	var domain *types.CustomDomain
+	for i := range domains {
+		if domains[i].Domain == domainName {
+			domain = &domains[i]
+			break
+		}
+	}
+
+	if domain == nil {
+		if !h.checkDomainClaim(c, domainName, false) {
+			return
+		}
+		exists, err := h.repos.CustomDomains.Exists(ctx, domainName)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to check domain existence", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		if exists {
+			c.JSON(http.StatusConflict, gin.H{"error": "domain already in use by another service"})
+			return
+		}
+	}
+
+	current := ""
+	if domain != nil {
+		current = domainETag(domain)
+	}
+	if !checkPreconditions(c, current) {
+		return
+	}
+
+	tlsEnabled := true
+	if req.TLSEnabled != nil {
+		tlsEnabled = *req.TLSEnabled
+	}
+	tlsIssuer := req.TLSIssuer
+	if tlsIssuer == "" {
+		tlsIssuer = "letsencrypt-staging"
+		if env.Name == "production" {
+			tlsIssuer = "letsencrypt-prod"
+		}
+	}
+
+	status := http.StatusOK
+	if domain == nil {
+		dnsMode, dnsProviderID, ok := h.resolveDNSMode(c, serviceUUID, domainName, req.DNSMode)
+		if !ok {
+			return
+		}
+
+		platformDomain := os.Getenv("ENCLII_PLATFORM_DOMAIN")
+		if platformDomain == "" {
+			platformDomain = "enclii.dev"
+		}
+
+		domain = &types.CustomDomain{
+			ServiceID:     serviceUUID,
+			EnvironmentID: envUUID,
+			Domain:        domainName,
+			TLSEnabled:    tlsEnabled,
+			TLSIssuer:     tlsIssuer,
+			TLSProvider:   types.TLSProviderCertManager,
+			Status:        types.DomainStatusPending,
+			DNSCNAME:      fmt.Sprintf("tunnel.%s", platformDomain),
+			DNSMode:       dnsMode,
+			DNSProviderID: dnsProviderID,
+		}
+		if err := h.repos.CustomDomains.Create(ctx, domain); err != nil {
+			h.logger.Error(ctx, "Failed to create custom domain", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create custom domain"})
+			return
+		}
+		h.applyManagedDNS(c, domain)
+		status = http.StatusCreated
+	} else {
+		if domain.EnvironmentID != envUUID {
+			c.JSON(http.StatusConflict, gin.H{"error": "the environment of a domain cannot be changed; delete and recreate the domain"})
+			return
+		}
+
+		domain.TLSEnabled = tlsEnabled
+		domain.TLSIssuer = tlsIssuer
+
+		dnsModeChanged := req.DNSMode != "" && req.DNSMode != domain.DNSMode
+		if dnsModeChanged {
+			mode, providerID, ok := h.resolveDNSMode(c, domain.ServiceID, domain.Domain, req.DNSMode)
+			if !ok {
+				return
+			}
+			domain.DNSMode = mode
+			domain.DNSProviderID = providerID
+			domain.DNSRecordID = ""
+			domain.DNSStatus = ""
+			domain.DNSStatusMessage = ""
+		}
+
+		if err := h.repos.CustomDomains.Update(ctx, domain); err != nil {
+			h.logger.Error(ctx, "Failed to update custom domain", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update custom domain"})
+			return
+		}
+		if dnsModeChanged {
+			if err := h.repos.CustomDomains.UpdateDNS(ctx, domain); err != nil {
+				h.logger.Error(ctx, "Failed to update custom domain DNS mode", logging.Error("error", err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update custom domain"})
+				return
+			}
+			h.applyManagedDNS(c, domain)
+		}
+
+		go h.triggerDomainReconciliation(ctx, domain.ServiceID, domain.EnvironmentID)
+	}
+
+	stored, err := h.repos.CustomDomains.GetByID(ctx, domain.ID.String())
+	if err != nil {
+		h.logger.Error(ctx, "Failed to reload custom domain", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get custom domain"})
+		return
+	}
+	respondWithETag(c, status, domainETag(stored), gin.H{"domain": stored})
+}
+
+// PutAddonRequest is the desired state of a database addon
+type PutAddonRequest struct {
+	Type          types.DatabaseAddonType    `json:"type" binding:"required"`
+	EnvironmentID *string                    `json:"environment_id"`
+	Config        *types.DatabaseAddonConfig `json:"config"`
+}
+
+// PutAddon provisions the named addon in a project, or resizes it to the
+// requested storage, CPU, memory, and replicas. A resize is answered with 202
+// while it runs; the type, environment, and other settings of an existing
+// addon cannot change here.
+// PUT /v1/projects/:slug/addons/:name
+func (h *Handler) PutAddon(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Param("name")
+
+	var req PutAddonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch req.Type {
+	case types.DatabaseAddonTypePostgres, types.DatabaseAddonTypeRedis, types.DatabaseAddonTypeMySQL, types.DatabaseAddonTypeMongoDB, types.DatabaseAddonTypeBucket:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid addon type, must be one of: postgres, redis, mysql, mongodb, bucket"})
+		return
+	}
+	config := types.DatabaseAddonConfig{}
+	if req.Config != nil {
+		config = *req.Config
+	}
+	if err := addons.ValidateConfig(req.Type, config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+
+	var environmentID *uuid.UUID
+	if req.EnvironmentID != nil && *req.EnvironmentID != "" {
+		envID, err := uuid.Parse(*req.EnvironmentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid environment_id format"})
+			return
+		}
+		env, err := h.repos.Environments.GetByID(ctx, envID)
+		if err != nil || env.ProjectID != project.ID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "environment not found"})
+			return
+		}
+		environmentID = &envID
+	}
+	if !h.authorizeEnvironment(c, project.ID, environmentID) {
+		return
+	}
+
+	addon, err := h.repos.DatabaseAddons.GetByName(ctx, project.ID, name)
+	if err != nil && err != sql.ErrNoRows {
+		h.logger.Error(ctx, "Failed to get addon", logging.String("project_slug", project.Slug), logging.String("addon_name", name), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get addon"})
+		return
+	}
+	if err == sql.ErrNoRows {
+		addon = nil
+	}
+	current := ""
+	if addon != nil {
+		current = addonETag(addon)
+	}
+	if !checkPreconditions(c, current) {
+		return
+	}
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(c.GetString("user_id")); err == nil {
+		actorID = &parsed
+	}
+	userEmail := c.GetString("user_email")
+
+	if addon == nil {
+		addon, err = h.addonService.CreateAddon(ctx, &addons.CreateAddonRequest{
+			ProjectID:     project.ID,
+			EnvironmentID: environmentID,
+			Type:          req.Type,
+			Name:          name,
+			Config:        config,
+			UserID:        actorID,
+			UserEmail:     userEmail,
+		})
+		if err != nil {
+			if quotaExceeded(c, err) {
+				return
+			}
+			h.logger.Error(ctx, "Failed to create addon",
+				logging.String("project_slug", project.Slug),
+				logging.String("addon_name", name),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.respondWithAddon(c, http.StatusCreated, addon.ID)
+		return
+	}
+
+	if addon.Type != req.Type {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("addon %s is a %s addon; its type cannot be changed", name, addon.Type)})
+		return
+	}
+	if !sameEnvironment(addon.EnvironmentID, environmentID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "the environment of an addon cannot be changed"})
+		return
+	}
+
+	resize, immutable := addonConfigChanges(addon.Config, config)
+	if len(immutable) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "addon settings cannot be changed in place",
+			"fields": immutable,
+		})
+		return
+	}
+	if resize == nil {
+		h.respondWithAddon(c, http.StatusOK, addon.ID)
+		return
+	}
+
+	started, err := h.addonService.ResizeAddon(ctx, addon.ID, resize, actorID, userEmail)
+	if err != nil {
+		if quotaExceeded(c, err) {
+			return
+		}
+		if stderrors.Is(err, addons.ErrResizeInProgress) || stderrors.Is(err, addons.ErrRestoreInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to resize addon",
+			logging.String("addon_id", addon.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   userEmail,
+		ActorRole:    types.Role(c.GetString("user_role")),
+		Action:       "addon.resize_requested",
+		ResourceType: "addon",
+		ResourceID:   addon.ID.String(),
+		ResourceName: addon.Name,
+		ProjectID:    &addon.ProjectID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"resize_id":       started.ID.String(),
+			"addon_type":      addon.Type,
+			"previous_config": started.PreviousConfig,
+			"target_config":   started.TargetConfig,
+		},
+	})
+
+	h.respondWithAddon(c, http.StatusAccepted, addon.ID)
+}
+
+// respondWithAddon writes the stored addon with its ETag
+func (h *Handler) respondWithAddon(c *gin.Context, status int, addonID uuid.UUID) {
+	addon, err := h.addonService.GetAddon(c.Request.Context(), addonID)
+	if err != nil {
+		h.logger.Error(c.Request.Context(), "Failed to reload addon", logging.String("addon_id", addonID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get addon"})
+		return
+	}
+	respondWithETag(c, status, addonETag(addon), addon)
+}
+
+// addonConfigChanges compares the config of an addon with a desired one.
+// Settings left empty in the desired config keep their current value. It
+// returns the resize that reaches the desired storage, CPU, memory, and
+// replicas (nil when they already match) and the names of other settings
+// that differ, which an addon cannot change in place.
+func addonConfigChanges(current, desired types.DatabaseAddonConfig) (*types.DatabaseAddonUpdateRequest, []string) {
+	resize := &types.DatabaseAddonUpdateRequest{}
+	changed := false
+	if desired.StorageGB != 0 && desired.StorageGB != current.StorageGB {
+		resize.StorageGB = &desired.StorageGB
+		changed = true
+	}
+	if desired.CPU != "" && desired.CPU != current.CPU {
+		resize.CPU = &desired.CPU
+		changed = true
+	}
+	if desired.Memory != "" && desired.Memory != current.Memory {
+		resize.Memory = &desired.Memory
+		changed = true
+	}
+	if desired.Replicas != 0 && desired.Replicas != current.Replicas {
+		resize.Replicas = &desired.Replicas
+		changed = true
+	}
+	if !changed {
+		resize = nil
+	}
+
+	var immutable []string
+	if desired.Version != "" && desired.Version != current.Version {
+		immutable = append(immutable, "version")
+	}
+	if desired.HAEnabled && !current.HAEnabled {
+		immutable = append(immutable, "ha_enabled")
+	}
+	if desired.MaxMemory != "" && desired.MaxMemory != current.MaxMemory {
+		immutable = append(immutable, "maxmemory")
+	}
+	if desired.MaxMemoryPolicy != "" && desired.MaxMemoryPolicy != current.MaxMemoryPolicy {
+		immutable = append(immutable, "maxmemory_policy")
+	}
+	if desired.BackupSchedule != "" && desired.BackupSchedule != current.BackupSchedule {
+		immutable = append(immutable, "backup_schedule")
+	}
+	if desired.BackupRetentionDays != 0 && desired.BackupRetentionDays != current.BackupRetentionDays {
+		immutable = append(immutable, "backup_retention_days")
+	}
+	if desired.PITREnabled && !current.PITREnabled {
+		immutable = append(immutable, "pitr_enabled")
+	}
+	if desired.BucketProvider != "" && desired.BucketProvider != current.BucketProvider {
+		immutable = append(immutable, "bucket_provider")
+	}
+	return resize, immutable
+}
+
+// sameEnvironment reports whether two optional environment IDs are equal
+func sameEnvironment(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// ImportProjectResources returns every resource of a project with its ETag,
+// so a client can adopt existing infrastructure into its state. Secret values
+// are masked.
+// GET /v1/projects/:slug/import
+func (h *Handler) ImportProjectResources(c *gin.Context) {
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	// The import covers every environment of the project
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	state := &types.ProjectResourceState{
+		Project:      types.ResourceState{ID: project.ID, ETag: projectETag(project), Attributes: project},
+		Services:     []types.ResourceState{},
+		EnvVars:      []types.ResourceState{},
+		Domains:      []types.ResourceState{},
+		Addons:       []types.ResourceState{},
+		Environments: []*types.Environment{},
+	}
+
+	envs, err := h.repos.Environments.ListByProject(project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list environments", logging.String("project", project.Slug), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project"})
+		return
+	}
+	state.Environments = append(state.Environments, envs...)
+
+	svcs, err := h.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list services", logging.String("project", project.Slug), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project"})
+		return
+	}
+	for _, svc := range svcs {
+		state.Services = append(state.Services, types.ResourceState{ID: svc.ID, ETag: serviceETag(svc), Attributes: svc})
+
+		envVars, err := h.repos.EnvVars.List(ctx, svc.ID, nil)
+		if isEncryptionKeyUnavailable(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			h.logger.Error(ctx, "Failed to list env vars", logging.String("service_id", svc.ID.String()), logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project"})
+			return
+		}
+		for _, ev := range envVars {
+			state.EnvVars = append(state.EnvVars, types.ResourceState{ID: ev.ID, ETag: envVarETag(ev), Attributes: toEnvVarResponse(ev)})
+		}
+
+		domains, err := h.repos.CustomDomains.GetByServiceID(ctx, svc.ID.String())
+		if err != nil {
+			h.logger.Error(ctx, "Failed to list custom domains", logging.String("service_id", svc.ID.String()), logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project"})
+			return
+		}
+		for i := range domains {
+			domain := &domains[i]
+			state.Domains = append(state.Domains, types.ResourceState{ID: domain.ID, ETag: domainETag(domain), Attributes: domain})
+		}
+	}
+
+	addonList, err := h.repos.DatabaseAddons.ListByProject(ctx, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list addons", logging.String("project", project.Slug), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import project"})
+		return
+	}
+	for _, addon := range addonList {
+		state.Addons = append(state.Addons, types.ResourceState{ID: addon.ID, ETag: addonETag(addon), Attributes: addon})
+	}
+
+	c.JSON(http.StatusOK, state)
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SetResourceQuotaRequest sets the resource quota of a team or a project.
+// It replaces the whole quota: a null or missing limit removes it.
+type SetResourceQuotaRequest struct {
+	MaxServices            *int `json:"max_services" binding:"omitempty,min=0"`
+	MaxCPUMillicores       *int `json:"max_cpu_millicores" binding:"omitempty,min=0"`
+	MaxMemoryMB            *int `json:"max_memory_mb" binding:"omitempty,min=0"`
+	MaxAddonStorageGB      *int `json:"max_addon_storage_gb" binding:"omitempty,min=0"`
+	MaxPreviewEnvironments *int `json:"max_preview_environments" binding:"omitempty,min=0"`
+}
+
+// ResourceQuotaResponse is the resource quota of a team or a project and
+// what it holds
+type ResourceQuotaResponse struct {
+	Team    string               `json:"team,omitempty"`
+	Project string               `json:"project,omitempty"`
+	Quota   *types.ResourceQuota `json:"quota"`
+	Usage   *types.ResourceUsage `json:"usage"`
+}
+
+func (r *SetResourceQuotaRequest) quota() *types.ResourceQuota {
+	return &types.ResourceQuota{
+		MaxServices:            r.MaxServices,
+		MaxCPUMillicores:       r.MaxCPUMillicores,
+		MaxMemoryMB:            r.MaxMemoryMB,
+		MaxAddonStorageGB:      r.MaxAddonStorageGB,
+		MaxPreviewEnvironments: r.MaxPreviewEnvironments,
+	}
+}
+
+// quotaExceeded writes a 422 with the usage and limit of the exceeded quota
+// when err is a quota error, and reports whether it did
+func quotaExceeded(c *gin.Context, err error) bool {
+	var exceeded *quota.ExceededError
+	if !errors.As(err, &exceeded) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":   "Resource quota exceeded",
+		"details": exceeded.Error(),
+		"quota":   exceeded,
+		"help":    fmt.Sprintf("Free up %s of the %s or ask a platform admin to raise its quota", exceeded.Resource, exceeded.Scope),
+	})
+	return true
+}
+
+// checkPreviewQuota checks that a project may open one more preview
+// environment. It writes the error response and returns false when it may
+// not.
+func (h *Handler) checkPreviewQuota(c *gin.Context, projectID uuid.UUID) bool {
+	ctx := c.Request.Context()
+	err := quota.CheckNewPreview(ctx, h.repos, projectID)
+	if err == nil {
+		return true
+	}
+	if !quotaExceeded(c, err) {
+		h.logger.Error(ctx, "Failed to check preview quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check resource quota"})
+	}
+	return false
+}
+
+// GetTeamQuota returns a team's resource quota and what its projects hold
+// GET /v1/teams/:slug/quota
+func (h *Handler) GetTeamQuota(c *gin.Context) {
+	access := h.loadTeamAccess(c)
+	if access == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	current, err := h.repos.ResourceQuotas.GetForTeam(ctx, access.team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get quota"})
+		return
+	}
+	usage, err := quota.TeamUsage(ctx, h.repos, access.team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to count team resources", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get quota"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ResourceQuotaResponse{Team: access.team.Slug, Quota: current, Usage: usage})
+}
+
+// SetTeamQuota sets a team's resource quota. Only platform admins set
+// quotas. What the team already holds past the new limits keeps running;
+// only new services, deploys, addons and previews are held to them.
+// PUT /v1/teams/:slug/quota
+func (h *Handler) SetTeamQuota(c *gin.Context) {
+	var req SetResourceQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	access := h.loadTeamAccess(c)
+	if access == nil {
+		return
+	}
+	if !access.platformAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only platform admins can set a team's quota"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	previous, err := h.repos.ResourceQuotas.GetForTeam(ctx, access.team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set quota"})
+		return
+	}
+	updated := req.quota()
+	if err := h.repos.ResourceQuotas.SetForTeam(ctx, access.team.ID, updated); err != nil {
+		h.logger.Error(ctx, "Failed to set team quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set quota"})
+		return
+	}
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      &access.actorID,
+		ActorEmail:   access.actorEmail,
+		ActorRole:    types.Role(access.actorRole),
+		Action:       "team.quota_updated",
+		ResourceType: "team",
+		ResourceID:   access.team.ID.String(),
+		ResourceName: access.team.Slug,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"previous_quota": previous,
+			"quota":          updated,
+		},
+	})
+
+	if h.quotaSyncer != nil {
+		h.quotaSyncer.SyncTeam(ctx, access.team.ID)
+	}
+
+	usage, err := quota.TeamUsage(ctx, h.repos, access.team.ID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to count team resources", logging.Error("error", err))
+	}
+	c.JSON(http.StatusOK, ResourceQuotaResponse{Team: access.team.Slug, Quota: updated, Usage: usage})
+}
+
+// GetProjectQuota returns a project's resource quota and what it holds. The
+// project is also held to the quota of its team.
+// GET /v1/projects/:slug/quota
+func (h *Handler) GetProjectQuota(c *gin.Context) {
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	current, err := h.repos.ResourceQuotas.GetForProject(ctx, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get quota"})
+		return
+	}
+	usage, err := quota.ProjectUsage(ctx, h.repos, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to count project resources", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get quota"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ResourceQuotaResponse{Project: project.Slug, Quota: current, Usage: usage})
+}
+
+// SetProjectQuota sets a project's resource quota. Only platform admins set
+// quotas; like team quotas, they apply to what is created or deployed next.
+// PUT /v1/projects/:slug/quota
+func (h *Handler) SetProjectQuota(c *gin.Context) {
+	var req SetResourceQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	role := c.GetString("user_role")
+	if role != string(auth.RoleAdmin) && role != string(auth.RoleSuperAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only platform admins can set a project's quota"})
+		return
+	}
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	previous, err := h.repos.ResourceQuotas.GetForProject(ctx, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set quota"})
+		return
+	}
+	updated := req.quota()
+	if err := h.repos.ResourceQuotas.SetForProject(ctx, project.ID, updated); err != nil {
+		h.logger.Error(ctx, "Failed to set project quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set quota"})
+		return
+	}
+
+	var actorID *uuid.UUID
+	if id, err := auth.GetUserIDFromContext(c); err == nil {
+		actorID = &id
+	}
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   c.GetString("user_email"),
+		ActorRole:    types.Role(role),
+		Action:       "project.quota_updated",
+		ResourceType: "project",
+		ResourceID:   project.ID.String(),
+		ResourceName: project.Slug,
+		ProjectID:    &project.ID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"previous_quota": previous,
+			"quota":          updated,
+		},
+	})
+
+	if h.quotaSyncer != nil {
+		if err := h.quotaSyncer.SyncProject(ctx, project.ID); err != nil {
+			h.logger.Warn(ctx, "Failed to sync project quota to the cluster", logging.Error("error", err))
+		}
+	}
+
+	usage, err := quota.ProjectUsage(ctx, h.repos, project.ID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to count project resources", logging.Error("error", err))
+	}
+	c.JSON(http.StatusOK, ResourceQuotaResponse{Project: project.Slug, Quota: updated, Usage: usage})
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/retention"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// defaultPurgePreviewWindowDays is how far ahead purge volumes are previewed
+// when no window is given
+const defaultPurgePreviewWindowDays = 7
+
+// UpdateRetentionRequest sets how many days a project keeps each class of
+// data and how many releases of each service. Omitted or null fields use
+// the default.
+type UpdateRetentionRequest struct {
+	BuildLogDays    *int `json:"build_log_days"`
+	AccessLogDays   *int `json:"access_log_days"`
+	AuditLogDays    *int `json:"audit_log_days"`
+	UsageDetailDays *int `json:"usage_detail_days"`
+	KeepReleases    *int `json:"keep_releases"`
+}
+
+// GetRetention returns the configured and effective retention of a project
+// and the bounds of its plan
+// GET /v1/projects/:slug/retention
+func (h *Handler) GetRetention(c *gin.Context) {
+	project := h.loadRetentionProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	result, err := h.retention.Get(ctx, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get retention policy",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get retention policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdateRetention replaces the retention policy of a project. Periods must be
+// within the bounds of the project's plan; data past a shortened period is
+// deleted by the next purge run.
+// PUT /v1/projects/:slug/retention
+func (h *Handler) UpdateRetention(c *gin.Context) {
+	var req UpdateRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := h.loadRetentionProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	previous, err := h.retention.Get(ctx, project.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get retention policy",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get retention policy"})
+		return
+	}
+
+	result, problems, err := h.retention.Update(ctx, &types.RetentionPolicy{
+		ProjectID:       project.ID,
+		BuildLogDays:    req.BuildLogDays,
+		AccessLogDays:   req.AccessLogDays,
+		AuditLogDays:    req.AuditLogDays,
+		UsageDetailDays: req.UsageDetailDays,
+		KeepReleases:    req.KeepReleases,
+	})
+	if err != nil {
+		h.logger.Error(ctx, "Failed to update retention policy",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update retention policy"})
+		return
+	}
+	if len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "Retention policy is outside the bounds of the plan",
+			"plan":     previous.Plan,
+			"problems": problems,
+			"bounds":   previous.Bounds,
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   email,
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       "project.retention_updated",
+		ResourceType: "project",
+		ResourceID:   project.ID.String(),
+		ResourceName: project.Slug,
+		ProjectID:    &project.ID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"previous_effective_days": previous.Effective,
+			"effective_days":          result.Effective,
+			"previous_keep_releases":  previous.EffectiveKeepReleases,
+			"keep_releases":           result.EffectiveKeepReleases,
+		},
+	})
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PreviewRetentionPurge counts the records of a project the next purge run
+// deletes, and those deleted within the next window_days days. Nothing is
+// deleted.
+// GET /v1/projects/:slug/retention/preview
+func (h *Handler) PreviewRetentionPurge(c *gin.Context) {
+	windowDays := defaultPurgePreviewWindowDays
+	if raw := c.Query("window_days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > retention.MaxPreviewWindowDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("window_days must be between 0 and %d", retention.MaxPreviewWindowDays)})
+			return
+		}
+		windowDays = parsed
+	}
+
+	project := h.loadRetentionProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	preview, err := h.retention.Preview(ctx, project.ID, windowDays)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to preview retention purge",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to preview retention purge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// loadRetentionProject checks retention is configured and loads the project
+// of the :slug route parameter, or writes the error response and returns nil
+func (h *Handler) loadRetentionProject(c *gin.Context) *types.Project {
+	if h.retention == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Retention settings are not configured"})
+		return nil
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return nil
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return nil
+	}
+	return project
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// runtimeDetectionTimeout bounds the shallow clone used for registration-time detection
+const runtimeDetectionTimeout = 2 * time.Minute
+
+// detectServiceRuntimes clones each service's repository in the background and
+// stores the detected language/framework. Services are processed sequentially so
+// a bulk import doesn't fan out into many parallel clones.
+func (h *Handler) detectServiceRuntimes(svcs ...*types.Service) {
+	if h.builder == nil || len(svcs) == 0 {
+		return
+	}
+
+	go func() {
+		for _, service := range svcs {
+			ctx, cancel := context.WithTimeout(context.Background(), runtimeDetectionTimeout)
+			runtime, err := h.builder.DetectFromGit(ctx, service)
+			if err != nil {
+				h.logger.Warn(ctx, "Runtime detection failed (non-fatal)",
+					logging.String("service_id", service.ID.String()),
+					logging.Error("error", err))
+			} else {
+				h.storeServiceRuntime(ctx, service.ID, runtime)
+			}
+			cancel()
+		}
+	}()
+}
+
+// storeServiceRuntime persists detected runtime metadata; failures are non-fatal
+func (h *Handler) storeServiceRuntime(ctx context.Context, serviceID uuid.UUID, runtime *types.ServiceRuntime) {
+	if runtime == nil {
+		return
+	}
+
+	if err := h.repos.Services.UpdateRuntime(ctx, serviceID, runtime); err != nil {
+		h.logger.Warn(ctx, "Failed to store detected runtime (non-fatal)",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("db_error", err))
+		return
+	}
+
+	h.logger.Info(ctx, "✓ Service runtime detected",
+		logging.String("service_id", serviceID.String()),
+		logging.String("language", runtime.Language),
+		logging.String("framework", runtime.Framework),
+		logging.String("package_manager", runtime.PackageManager),
+		logging.String("source", runtime.DetectedFrom))
+}
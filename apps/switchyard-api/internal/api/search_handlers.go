@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/search"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+
+	// searchCandidateLimit bounds the matches of each type that are ranked
+	searchCandidateLimit = 200
+)
+
+// Search finds the projects, services, deployments and domains the caller
+// can access whose names, slugs, domains, git repos, image URIs or versions
+// match a query, best match first
+// GET /v1/search?q=<query>[&type=service,domain][&limit=20]
+func (h *Handler) Search(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	if len(query) > search.MaxQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must be at most 100 characters"})
+		return
+	}
+
+	var only []types.SearchResultType
+	if raw := c.Query("type"); raw != "" {
+		for _, t := range strings.Split(raw, ",") {
+			switch resultType := types.SearchResultType(strings.TrimSpace(t)); resultType {
+			case types.SearchResultProject, types.SearchResultService, types.SearchResultDeployment, types.SearchResultDomain:
+				only = append(only, resultType)
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "type must be project, service, deployment or domain"})
+				return
+			}
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	access, err := h.callerAccess(c)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list project access", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search"})
+		return
+	}
+
+	response := types.SearchResponse{Query: query, Results: []types.SearchResult{}}
+	if access != nil && len(access) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var projectIDs []uuid.UUID
+	if access != nil {
+		projectIDs = access.ProjectIDs()
+	}
+	candidates, err := h.repos.Search.Candidates(ctx, search.Pattern(query), projectIDs, searchCandidateLimit)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to search", logging.String("query", query), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search"})
+		return
+	}
+
+	response.Results = search.Rank(query, candidates, access, only, limit)
+	c.JSON(http.StatusOK, response)
+}
+
+// callerAccess is what the caller may see: everything for administrators,
+// else the projects and environments they were granted, narrowed to the
+// grants of bot tokens
+func (h *Handler) callerAccess(c *gin.Context) (search.Access, error) {
+	var access search.Access
+
+	role := c.GetString("user_role")
+	if role != string(auth.RoleAdmin) && role != string(auth.RoleSuperAdmin) {
+		access = search.Access{}
+		userID, err := auth.GetUserIDFromContext(c)
+		if err == nil {
+			grants, err := h.repos.ProjectAccess.ListByUser(c.Request.Context(), userID)
+			if err != nil {
+				return nil, err
+			}
+			for _, grant := range grants {
+				access = append(access, search.Grant{ProjectID: grant.ProjectID, EnvironmentID: grant.EnvironmentID})
+			}
+		}
+	}
+
+	if scope := auth.TokenScopeFromContext(c); scope != nil {
+		botAccess := search.Access{}
+		for _, grant := range scope.Grants {
+			botAccess = append(botAccess, search.Grant{ProjectID: grant.ProjectID, EnvironmentID: grant.EnvironmentID})
+		}
+		access = access.Intersect(botAccess)
+	}
+	return access, nil
+}
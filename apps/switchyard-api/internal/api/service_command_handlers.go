@@ -0,0 +1,141 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ListServiceCommands returns the command a service runs and the commands
+// it runs in particular environments instead
+// GET /v1/services/:id/commands
+func (h *Handler) ListServiceCommands(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	if !h.authorizeServiceEnvironment(c, serviceID, nil) {
+		return
+	}
+
+	overrides, err := h.repos.ServiceCommands.ListByService(ctx, serviceID)
+	if err != nil {
+		if !isTableNotExistError(err) {
+			h.logger.Error(ctx, "Failed to list service commands",
+				logging.String("service_id", serviceID.String()),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list service commands"})
+			return
+		}
+		overrides = []*types.ServiceEnvironmentCommand{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"command": service.Command, "environments": overrides})
+}
+
+// SetServiceEnvironmentCommand sets the command a service runs in one
+// environment instead of its own. It applies on the next deploy.
+// PUT /v1/services/:id/commands/:env_name
+func (h *Handler) SetServiceEnvironmentCommand(c *gin.Context) {
+	var command types.ContainerCommand
+	if err := c.ShouldBindJSON(&command); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := k8s.ValidateCommand(&command); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid command: " + err.Error()})
+		return
+	}
+
+	service, project, env, ok := h.loadServiceEnvironment(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	override := &types.ServiceEnvironmentCommand{
+		ServiceID:     service.ID,
+		EnvironmentID: env.ID,
+		Command:       command,
+	}
+	if err := h.repos.ServiceCommands.Upsert(ctx, override); err != nil {
+		h.logger.Error(ctx, "Failed to save service command",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save service command"})
+		return
+	}
+
+	h.auditServiceCommand(c, project, service, env, &override.Command, "service.command_updated")
+	c.JSON(http.StatusOK, gin.H{"command": override})
+}
+
+// DeleteServiceEnvironmentCommand removes the command a service runs in one
+// environment, so it runs its own there again from the next deploy
+// DELETE /v1/services/:id/commands/:env_name
+func (h *Handler) DeleteServiceEnvironmentCommand(c *gin.Context) {
+	service, project, env, ok := h.loadServiceEnvironment(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.ServiceCommands.Delete(ctx, service.ID, env.ID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "The service has no command of its own in this environment"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete service command",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete service command"})
+		return
+	}
+
+	h.auditServiceCommand(c, project, service, env, service.Command, "service.command_removed")
+	c.JSON(http.StatusOK, gin.H{"message": "Environment command removed", "command": service.Command})
+}
+
+// auditServiceCommand records a change to what a service runs in an
+// environment
+func (h *Handler) auditServiceCommand(c *gin.Context, project *types.Project, service *types.Service, env *types.Environment, command *types.ContainerCommand, action string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    fmt.Sprintf("%v", userEmail),
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		Action:        action,
+		ResourceType:  "service",
+		ResourceID:    service.ID.String(),
+		ResourceName:  service.Name,
+		ProjectID:     &project.ID,
+		EnvironmentID: &env.ID,
+		Outcome:       "success",
+		Context: map[string]interface{}{
+			"environment": env.Name,
+			"command":     command,
+		},
+	})
+}
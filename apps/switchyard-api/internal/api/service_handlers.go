@@ -2,24 +2,42 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildlimits"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // UpdateServiceRequest defines the request body for updating a service
 type UpdateServiceRequest struct {
-	Name             *string            `json:"name,omitempty"`
-	GitRepo          *string            `json:"git_repo,omitempty"`
-	AppPath          *string            `json:"app_path,omitempty"`
-	AutoDeploy       *bool              `json:"auto_deploy,omitempty"`
-	AutoDeployBranch *string            `json:"auto_deploy_branch,omitempty"`
-	AutoDeployEnv    *string            `json:"auto_deploy_env,omitempty"`
-	BuildConfig      *types.BuildConfig `json:"build_config,omitempty"`
+	Name             *string `json:"name,omitempty"`
+	GitRepo          *string `json:"git_repo,omitempty"`
+	AppPath          *string `json:"app_path,omitempty"`
+	AutoDeploy       *bool   `json:"auto_deploy,omitempty"`
+	AutoDeployBranch *string `json:"auto_deploy_branch,omitempty"`
+	AutoDeployEnv    *string `json:"auto_deploy_env,omitempty"`
+	// Replaces the auto-deploy rules; {} restores the defaults
+	AutoDeployRules *types.AutoDeployRules `json:"auto_deploy_rules,omitempty"`
+	BuildConfig     *types.BuildConfig     `json:"build_config,omitempty"`
+	Labels          *map[string]string     `json:"labels,omitempty"` // Replaces all labels; {} clears them
+	// Replaces the pod placement; {} clears it
+	Scheduling *types.SchedulingConfig `json:"scheduling,omitempty"`
+	// Replaces the GPU request; {"count": 0} removes it
+	GPU *types.GPUConfig `json:"gpu,omitempty"`
+	// Replaces the command, args and working directory; {} runs the image as built
+	Command *types.ContainerCommand `json:"command,omitempty"`
+	// Replaces the shutdown settings; {} restores the defaults
+	Shutdown *types.ShutdownConfig `json:"shutdown,omitempty"`
+	// Gives services with more than one replica a pod disruption budget and anti-affinity
+	HighAvailability *bool `json:"high_availability,omitempty"`
 }
 
 // UpdateService updates a service's settings
@@ -51,6 +69,9 @@ func (h *Handler) UpdateService(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get service"})
 		return
 	}
+	if !checkPreconditions(c, serviceETag(service)) {
+		return
+	}
 
 	// Parse request body
 	var req UpdateServiceRequest
@@ -78,9 +99,98 @@ func (h *Handler) UpdateService(c *gin.Context) {
 	if req.AutoDeployEnv != nil {
 		service.AutoDeployEnv = *req.AutoDeployEnv
 	}
+	if req.AutoDeployRules != nil {
+		if err := validateAutoDeployRules(req.AutoDeployRules); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid auto_deploy_rules: " + err.Error()})
+			return
+		}
+		service.AutoDeployRules = req.AutoDeployRules
+		if isDefaultAutoDeployRules(service.AutoDeployRules) {
+			service.AutoDeployRules = nil
+		}
+	}
 	if req.BuildConfig != nil {
+		if a := req.BuildConfig.Artifacts; a != "" && !strings.HasPrefix(a, "/") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "build_config.artifacts must be an absolute path in the image"})
+			return
+		}
+		if err := k8s.ValidateBuildResources(req.BuildConfig.Resources); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid build_config.resources: " + err.Error()})
+			return
+		}
+		if err := buildlimits.Check(ctx, h.repos, service.ProjectID, req.BuildConfig.Resources); err != nil {
+			if errors.Is(err, buildlimits.ErrLimitExceeded) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"error":   "Build limit exceeded",
+					"details": err.Error(),
+					"help":    "Lower the build resources or ask a platform admin to raise the team's build limits",
+				})
+				return
+			}
+			h.logger.Error(ctx, "Failed to check build limits", logging.Error("db_error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check build limits"})
+			return
+		}
 		service.BuildConfig = *req.BuildConfig
 	}
+	if req.Labels != nil {
+		for key := range *req.Labels {
+			if key == "" || len(key) > 63 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid label key %q: must be 1-63 characters", key)})
+				return
+			}
+		}
+		service.Labels = *req.Labels
+	}
+	if req.Scheduling != nil {
+		if err := k8s.ValidateScheduling(req.Scheduling); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scheduling: " + err.Error()})
+			return
+		}
+		service.Scheduling = req.Scheduling
+		if k8s.SchedulesAnywhere(service.Scheduling) {
+			service.Scheduling = nil
+		}
+	}
+	if req.GPU != nil {
+		service.GPU = nil
+		if req.GPU.Count != 0 {
+			if err := k8s.ValidateGPU(req.GPU); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid gpu: " + err.Error()})
+				return
+			}
+			service.GPU = req.GPU
+		}
+	}
+	if req.Command != nil {
+		if err := k8s.ValidateCommand(req.Command); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid command: " + err.Error()})
+			return
+		}
+		service.Command = req.Command
+		if service.Command.IsEmpty() {
+			service.Command = nil
+		}
+	}
+	if req.Shutdown != nil {
+		if err := k8s.ValidateShutdown(req.Shutdown); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shutdown: " + err.Error()})
+			return
+		}
+		service.Shutdown = req.Shutdown
+		if *service.Shutdown == (types.ShutdownConfig{}) {
+			service.Shutdown = nil
+		}
+	}
+	if req.HighAvailability != nil {
+		service.HighAvailability = *req.HighAvailability
+	}
+	if (req.Scheduling != nil || req.GPU != nil) && service.Scheduling != nil {
+		if err := h.checkPlacement(ctx, service.ProjectID, k8s.WithGPU(service.Scheduling, service.GPU)); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	// Update in database
 	if err := h.repos.Services.Update(ctx, service); err != nil {
@@ -90,18 +200,84 @@ func (h *Handler) UpdateService(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service"})
 		return
 	}
+	if req.Labels != nil {
+		if err := h.repos.Services.UpdateLabels(ctx, service.ID, service.Labels); err != nil {
+			h.logger.Error(ctx, "Failed to update service labels",
+				logging.String("service_id", serviceID),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service labels"})
+			return
+		}
+	}
+	if req.Scheduling != nil {
+		if err := h.repos.Services.UpdateScheduling(ctx, service.ID, service.Scheduling); err != nil {
+			h.logger.Error(ctx, "Failed to update service scheduling",
+				logging.String("service_id", serviceID),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service scheduling"})
+			return
+		}
+	}
+	if req.GPU != nil {
+		if err := h.repos.Services.UpdateGPU(ctx, service.ID, service.GPU); err != nil {
+			h.logger.Error(ctx, "Failed to update service GPU request",
+				logging.String("service_id", serviceID),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service gpu"})
+			return
+		}
+	}
+	if req.Command != nil {
+		if err := h.repos.Services.UpdateCommand(ctx, service.ID, service.Command); err != nil {
+			h.logger.Error(ctx, "Failed to update service command",
+				logging.String("service_id", serviceID),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service command"})
+			return
+		}
+	}
+	if req.Shutdown != nil {
+		if err := h.repos.Services.UpdateShutdown(ctx, service.ID, service.Shutdown); err != nil {
+			h.logger.Error(ctx, "Failed to update service shutdown settings",
+				logging.String("service_id", serviceID),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service shutdown"})
+			return
+		}
+	}
+	if req.AutoDeployRules != nil {
+		if err := h.repos.Services.UpdateAutoDeployRules(ctx, service.ID, service.AutoDeployRules); err != nil {
+			h.logger.Error(ctx, "Failed to update service auto-deploy rules",
+				logging.String("service_id", serviceID),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service auto_deploy_rules"})
+			return
+		}
+	}
+	if req.HighAvailability != nil {
+		if err := h.repos.Services.UpdateHighAvailability(ctx, service.ID, service.HighAvailability); err != nil {
+			h.logger.Error(ctx, "Failed to update service high availability",
+				logging.String("service_id", serviceID),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update service high_availability"})
+			return
+		}
+	}
 
 	h.logger.Info(ctx, "Service updated",
 		logging.String("service_id", serviceID),
 		logging.String("name", service.Name))
 
+	c.Header("ETag", serviceETag(service))
 	c.JSON(http.StatusOK, gin.H{
 		"service": service,
 		"message": "Service updated successfully",
 	})
 }
 
-// DeleteService deletes a service and all associated resources
+// DeleteService moves a service to the trash. It is scaled to zero and
+// stays restorable until the retention window passes, then its workloads,
+// images and rows are purged for good.
 // DELETE /v1/services/:id
 func (h *Handler) DeleteService(c *gin.Context) {
 	serviceID := c.Param("id")
@@ -112,6 +288,11 @@ func (h *Handler) DeleteService(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
+	if h.trash == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "service deletion is not available"})
+		return
+	}
+
 	// Parse service ID
 	serviceUUID, err := uuid.Parse(serviceID)
 	if err != nil {
@@ -130,44 +311,16 @@ func (h *Handler) DeleteService(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get service"})
 		return
 	}
-
-	// Delete env vars for this service first (due to FK constraints)
-	if h.repos.EnvVars != nil {
-		if err := h.repos.EnvVars.DeleteByService(ctx, serviceUUID); err != nil {
-			h.logger.Warn(ctx, "Failed to delete env vars for service",
-				logging.String("service_id", serviceID),
-				logging.Error("error", err))
-			// Continue anyway - might not have any
-		}
-	}
-
-	// Delete custom domains for this service
-	if err := h.repos.CustomDomains.DeleteByServiceID(ctx, serviceID); err != nil {
-		h.logger.Warn(ctx, "Failed to delete custom domains for service",
-			logging.String("service_id", serviceID),
-			logging.Error("error", err))
-		// Continue anyway
-	}
-
-	// Delete routes for this service
-	if err := h.repos.Routes.DeleteByServiceID(ctx, serviceID); err != nil {
-		h.logger.Warn(ctx, "Failed to delete routes for service",
-			logging.String("service_id", serviceID),
-			logging.Error("error", err))
-		// Continue anyway
+	if !checkPreconditions(c, serviceETag(service)) {
+		return
 	}
 
-	// Delete service dependencies
-	if h.repos.ServiceDependencies != nil {
-		if err := h.repos.ServiceDependencies.DeleteByServiceID(ctx, serviceUUID); err != nil {
-			h.logger.Warn(ctx, "Failed to delete service dependencies",
-				logging.String("service_id", serviceID),
-				logging.Error("error", err))
+	deletedAt, err := h.trash.TrashService(ctx, service, actorUUID(c))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "service not found"})
+			return
 		}
-	}
-
-	// Delete the service
-	if err := h.repos.Services.Delete(ctx, serviceUUID); err != nil {
 		h.logger.Error(ctx, "Failed to delete service",
 			logging.String("service_id", serviceID),
 			logging.Error("error", err))
@@ -175,12 +328,14 @@ func (h *Handler) DeleteService(c *gin.Context) {
 		return
 	}
 
-	h.logger.Info(ctx, "Service deleted",
+	h.logger.Info(ctx, "Service moved to trash",
 		logging.String("service_id", serviceID),
 		logging.String("name", service.Name))
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Service deleted successfully",
+		"message":          "Service moved to trash",
+		"deleted_at":       deletedAt,
+		"restorable_until": h.trash.RestorableUntil(deletedAt),
 	})
 }
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
@@ -25,6 +26,7 @@ import (
 //   - 201 Created: Service object
 //   - 400 Bad Request: Invalid request body
 //   - 404 Not Found: Project not found
+//   - 422 Unprocessable Entity: Service quota of the project or team reached
 //   - 500 Internal Server Error: Failed to create service
 func (h *Handler) CreateService(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -70,35 +72,52 @@ func (h *Handler) CreateService(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
 		} else if errors.Is(err, errors.ErrValidation) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else {
+		} else if !quotaExceeded(c, err) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service"})
 		}
 		return
 	}
 
+	h.detectServiceRuntimes(resp.Service)
+
 	c.JSON(http.StatusCreated, resp.Service)
 }
 
-// ListServices returns all services in a project.
+// ListServices returns the services in a project.
 //
-// This endpoint lists all services within a project, including their
-// current deployment status and configuration.
+// This endpoint lists the services within a project, including their
+// current deployment status and configuration, a page at a time.
 //
 // Request:
 //   - Method: GET /api/v1/projects/:slug/services
 //   - Authorization: Bearer <access_token>
 //   - Path Parameters: slug (string) - Project slug
+//   - Query Parameters: limit, cursor, sort (created_at, updated_at, name),
+//     order, status, since, until, trashed (admins only)
 //
 // Response:
-//   - 200 OK: {services: Service[]}
+//   - 200 OK: {services: Service[], next_cursor: string}
+//   - 400 Bad Request: Invalid list parameters
+//   - 403 Forbidden: Trashed services requested by a non-admin
 //   - 404 Not Found: Project not found
 //   - 500 Internal Server Error: Failed to list services
 func (h *Handler) ListServices(c *gin.Context) {
 	ctx := c.Request.Context()
 	slug := c.Param("slug")
 
+	query, err := GetListQuery(c, "created_at", "updated_at", "name")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	trashed, ok := trashedFilter(c)
+	if !ok {
+		return
+	}
+	query.Trashed = trashed
+
 	// Use service layer for listing services
-	svcList, err := h.projectService.ListServices(ctx, slug)
+	svcList, err := h.projectService.ListServicesPage(ctx, slug, query.ListParams)
 	if err != nil {
 		if errors.Is(err, errors.ErrProjectNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
@@ -108,7 +127,13 @@ func (h *Handler) ListServices(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"services": svcList})
+	svcList, next := db.TrimPage(svcList, query.ListParams, func(service *types.Service) db.Cursor {
+		return itemCursor(query.Sort, service.ID, service.Name, service.CreatedAt, service.UpdatedAt)
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"services":    svcList,
+		"next_cursor": encodeListCursor(query.ListParams, next),
+	})
 }
 
 // GetService returns a service by its unique ID.
@@ -123,6 +148,7 @@ func (h *Handler) ListServices(c *gin.Context) {
 //
 // Response:
 //   - 200 OK: Service object
+//   - 304 Not Modified: If-None-Match still matches the ETag
 //   - 404 Not Found: Service not found
 //   - 500 Internal Server Error: Failed to get service
 func (h *Handler) GetService(c *gin.Context) {
@@ -140,7 +166,7 @@ func (h *Handler) GetService(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, service)
+	respondWithETag(c, http.StatusOK, serviceETag(service), service)
 }
 
 // BulkServiceRequest represents a single service in a bulk import request
@@ -228,7 +254,8 @@ func (h *Handler) BulkCreateServices(c *gin.Context) {
 			AutoDeployBranch: autoDeployBranch,
 			AutoDeployEnv:    svc.AutoDeployEnv,
 			BuildConfig: types.BuildConfig{
-				Type: types.BuildTypeBuildpack, // Default to buildpack
+				Type:    types.BuildTypeBuildpack, // Default to buildpack
+				Context: appPath,                  // Each service builds its own directory of the repo
 			},
 			UserID:    c.GetString("user_id"),
 			UserEmail: c.GetString("user_email"),
@@ -244,6 +271,12 @@ func (h *Handler) BulkCreateServices(c *gin.Context) {
 		createdServices = append(createdServices, *resp.Service)
 	}
 
+	detectTargets := make([]*types.Service, len(createdServices))
+	for i := range createdServices {
+		detectTargets[i] = &createdServices[i]
+	}
+	h.detectServiceRuntimes(detectTargets...)
+
 	// Return partial success if some services were created
 	response := BulkCreateServicesResponse{
 		Services: createdServices,
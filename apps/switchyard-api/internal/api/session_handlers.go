@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+)
+
+// SessionResponse is a session the current user is signed in with
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // The session of this request
+}
+
+// ListSessions returns the sessions the current user is signed in with
+// GET /v1/me/sessions
+func (h *Handler) ListSessions(c *gin.Context) {
+	actor, ok := sessionActor(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(c.Request.Context(), actor.UserID)
+	if err != nil {
+		h.respondSessionError(c, err)
+		return
+	}
+
+	responses := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, SessionResponse{
+			ID:         session.ID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == actor.SessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": responses})
+}
+
+// RevokeSession signs the current user out of one of their sessions
+// DELETE /v1/me/sessions/:id
+func (h *Handler) RevokeSession(c *gin.Context) {
+	actor, ok := sessionActor(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeSession(c.Request.Context(), actor, c.Param("id")); err != nil {
+		h.respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeOtherSessions signs the current user out of every session but the
+// one making the request
+// POST /v1/me/sessions/revoke-others
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	actor, ok := sessionActor(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.authService.RevokeOtherSessions(c.Request.Context(), actor)
+	if err != nil {
+		h.respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Other sessions revoked",
+		"revoked": revoked,
+	})
+}
+
+// sessionActor returns the current user and, for session tokens, their
+// session. API tokens have no session, so none of the listed ones is current.
+func sessionActor(c *gin.Context) (*services.SessionActor, bool) {
+	userID, err := auth.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	actor := &services.SessionActor{
+		UserID:    userID,
+		Email:     c.GetString("user_email"),
+		Role:      c.GetString("user_role"),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if claims, err := auth.GetClaimsFromContext(c); err == nil {
+		actor.SessionID = claims.SessionID
+	}
+	return actor, true
+}
+
+func (h *Handler) respondSessionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errors.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+	case errors.Is(err, errors.ErrServiceUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Session management is not available"})
+	default:
+		h.logger.Error(c.Request.Context(), "Session operation failed", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
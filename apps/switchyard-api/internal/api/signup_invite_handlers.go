@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// maxSignupInviteUses caps the accounts one invite code may create
+const maxSignupInviteUses = 1000
+
+// CreateSignupInviteRequest represents the request to create a signup invite
+type CreateSignupInviteRequest struct {
+	Email     string `json:"email" binding:"omitempty,email"` // Only this address may sign up; it is emailed the code
+	Note      string `json:"note" binding:"max=200"`
+	MaxUses   int    `json:"max_uses"`                  // Accounts the code may create (default 1)
+	ExpiresIn *int   `json:"expires_in_days,omitempty"` // Optional expiration in days
+}
+
+// ListSignupInvites returns all invite codes for invite-only signup. Codes
+// themselves are only shown when created.
+// GET /v1/signup-invites
+func (h *Handler) ListSignupInvites(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	invites, err := h.repos.SignupInvites.List(ctx)
+	if err != nil && !isTableNotExistError(err) {
+		h.logger.Error(ctx, "Failed to list signup invites", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list signup invites"})
+		return
+	}
+	if invites == nil {
+		invites = []*types.SignupInvite{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invites": invites})
+}
+
+// CreateSignupInvite creates an invite code for invite-only signup and emails
+// it when the invite is for one address
+// POST /v1/signup-invites
+func (h *Handler) CreateSignupInvite(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateSignupInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	invite, err := newSignupInvite(&req, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if userID, err := auth.GetUserIDFromContext(c); err == nil {
+		invite.CreatedBy = &userID
+	}
+
+	if err := h.repos.SignupInvites.Create(ctx, invite); err != nil {
+		h.logger.Error(ctx, "Failed to create signup invite", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create signup invite"})
+		return
+	}
+
+	if invite.Email != "" {
+		h.sendSignupInvite(c, invite)
+	}
+
+	h.auditSignupInvite(c, invite, "signup_invite.created", map[string]interface{}{
+		"email":    invite.Email,
+		"max_uses": invite.MaxUses,
+	})
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// RevokeSignupInvite stops an invite code from creating more accounts.
+// Accounts already created with it are kept.
+// DELETE /v1/signup-invites/:id
+func (h *Handler) RevokeSignupInvite(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	inviteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite ID"})
+		return
+	}
+
+	if err := h.repos.SignupInvites.Revoke(ctx, inviteID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Signup invite not found or already revoked"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to revoke signup invite", logging.String("invite_id", inviteID.String()), logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke signup invite"})
+		return
+	}
+
+	h.auditSignupInvite(c, &types.SignupInvite{ID: inviteID}, "signup_invite.revoked", nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// newSignupInvite validates a create request and builds its invite
+func newSignupInvite(req *CreateSignupInviteRequest, now time.Time) (*types.SignupInvite, error) {
+	invite := &types.SignupInvite{
+		Email:   strings.ToLower(strings.TrimSpace(req.Email)),
+		Note:    strings.TrimSpace(req.Note),
+		MaxUses: req.MaxUses,
+	}
+
+	if invite.MaxUses == 0 {
+		invite.MaxUses = 1
+	}
+	if invite.MaxUses < 0 || invite.MaxUses > maxSignupInviteUses {
+		return nil, fmt.Errorf("max_uses must be between 1 and %d", maxSignupInviteUses)
+	}
+	if invite.Email != "" && invite.MaxUses > 1 {
+		return nil, fmt.Errorf("an invite for one email address can only be used once")
+	}
+
+	if req.ExpiresIn != nil {
+		if *req.ExpiresIn <= 0 {
+			return nil, fmt.Errorf("expires_in_days must be positive")
+		}
+		expiresAt := now.Add(time.Duration(*req.ExpiresIn) * 24 * time.Hour)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	return invite, nil
+}
+
+// sendSignupInvite emails an invite code without blocking the response
+func (h *Handler) sendSignupInvite(c *gin.Context, invite *types.SignupInvite) {
+	ctx := c.Request.Context()
+	if h.emailService == nil {
+		h.logger.Warn(ctx, "Email service not configured; signup invite not sent",
+			logging.String("email", invite.Email))
+		return
+	}
+
+	inviterName := "An Enclii admin"
+	if userEmail, err := auth.GetUserEmailFromContext(c); err == nil && userEmail != "" {
+		inviterName = userEmail
+	}
+
+	emailData := notifications.SignupInviteData{
+		Email:       invite.Email,
+		InviterName: inviterName,
+		Code:        invite.Code,
+		ExpiresAt:   invite.ExpiresAt,
+	}
+	go func() {
+		emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := h.emailService.SendSignupInvite(emailCtx, emailData); err != nil {
+			h.logger.Error(emailCtx, "Failed to send signup invite email",
+				logging.String("email", emailData.Email),
+				logging.Error("error", err))
+		}
+	}()
+}
+
+// auditSignupInvite records an action on a signup invite
+func (h *Handler) auditSignupInvite(c *gin.Context, invite *types.SignupInvite, action string, details map[string]interface{}) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+	email := ""
+	if userEmail != nil {
+		email = fmt.Sprintf("%v", userEmail)
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      actorID,
+		ActorEmail:   email,
+		ActorRole:    types.Role(fmt.Sprintf("%v", userRole)),
+		Action:       action,
+		ResourceType: "signup_invite",
+		ResourceID:   invite.ID.String(),
+		ResourceName: invite.Prefix,
+		Outcome:      "success",
+		Context:      details,
+	})
+}
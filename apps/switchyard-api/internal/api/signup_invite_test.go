@@ -0,0 +1,39 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSignupInvite(t *testing.T) {
+	now := time.Now()
+
+	invite, err := newSignupInvite(&CreateSignupInviteRequest{Email: " Dev@Example.com "}, now)
+	if err != nil {
+		t.Fatalf("newSignupInvite() error = %v", err)
+	}
+	if invite.Email != "dev@example.com" || invite.MaxUses != 1 || invite.ExpiresAt != nil {
+		t.Errorf("invite = %+v, want a single use invite for dev@example.com", invite)
+	}
+
+	days := 7
+	invite, err = newSignupInvite(&CreateSignupInviteRequest{MaxUses: 25, ExpiresIn: &days}, now)
+	if err != nil {
+		t.Fatalf("newSignupInvite() error = %v", err)
+	}
+	if invite.MaxUses != 25 || invite.ExpiresAt == nil || !invite.ExpiresAt.Equal(now.Add(7*24*time.Hour)) {
+		t.Errorf("invite = %+v, want 25 uses expiring in 7 days", invite)
+	}
+
+	zero := 0
+	for _, invalid := range []*CreateSignupInviteRequest{
+		{MaxUses: -1},
+		{MaxUses: maxSignupInviteUses + 1},
+		{Email: "dev@example.com", MaxUses: 2},
+		{ExpiresIn: &zero},
+	} {
+		if _, err := newSignupInvite(invalid, now); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/soak"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// UpdateSoakPolicyRequest sets how deployments soak in an environment
+type UpdateSoakPolicyRequest struct {
+	DurationMinutes int      `json:"duration_minutes"`
+	MaxRestarts     int      `json:"max_restarts"`
+	MaxErrorRate    *float64 `json:"max_error_rate"` // null = error rate is not checked
+	AutoRollback    *bool    `json:"auto_rollback"`  // Defaults to true
+	RequireStable   bool     `json:"require_stable"`
+}
+
+// GetSoakPolicy returns the soak policy of an environment
+// GET /v1/projects/:slug/environments/:env_name/soak-policy
+func (h *Handler) GetSoakPolicy(c *gin.Context) {
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	policy, err := h.repos.Soaks.GetPolicy(ctx, env.ID)
+	if err != nil {
+		if err == sql.ErrNoRows || isTableNotExistError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment has no soak policy"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get soak policy",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get soak policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateSoakPolicy creates or replaces the soak policy of an environment.
+// Deployments that are already soaking keep their end time.
+// PUT /v1/projects/:slug/environments/:env_name/soak-policy
+func (h *Handler) UpdateSoakPolicy(c *gin.Context) {
+	var req UpdateSoakPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := &types.SoakPolicy{
+		DurationMinutes: req.DurationMinutes,
+		MaxRestarts:     req.MaxRestarts,
+		MaxErrorRate:    req.MaxErrorRate,
+		AutoRollback:    req.AutoRollback == nil || *req.AutoRollback,
+		RequireStable:   req.RequireStable,
+	}
+	if err := soak.ValidatePolicy(policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+	policy.EnvironmentID = env.ID
+
+	if err := h.repos.Soaks.UpsertPolicy(ctx, policy); err != nil {
+		h.logger.Error(ctx, "Failed to save soak policy",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save soak policy"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.soak_policy_updated", map[string]interface{}{
+		"duration_minutes": policy.DurationMinutes,
+		"max_restarts":     policy.MaxRestarts,
+		"max_error_rate":   policy.MaxErrorRate,
+		"auto_rollback":    policy.AutoRollback,
+		"require_stable":   policy.RequireStable,
+	})
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteSoakPolicy removes the soak policy of an environment. Deployments
+// that are soaking pass on the next check.
+// DELETE /v1/projects/:slug/environments/:env_name/soak-policy
+func (h *Handler) DeleteSoakPolicy(c *gin.Context) {
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.Soaks.DeletePolicy(ctx, env.ID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment has no soak policy"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete soak policy",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete soak policy"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.soak_policy_deleted", nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Soak policy removed"})
+}
+
+// UpdateDeployGuardRequest opts an environment in or out of the deploy guard
+type UpdateDeployGuardRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// DeployGuardResponse is whether new deployments to an environment are
+// watched and what they are held to. Source is "soak_policy" when the
+// environment's own soak policy applies, "deploy_guard" for the default
+// guard, and "none" when neither does.
+type DeployGuardResponse struct {
+	Enabled bool              `json:"enabled"`
+	Source  string            `json:"source"`
+	Policy  *types.SoakPolicy `json:"policy,omitempty"`
+}
+
+// deployGuardResponse describes what deployments to an environment are held to
+func (h *Handler) deployGuardResponse(ctx context.Context, env *types.Environment) (*DeployGuardResponse, error) {
+	response := &DeployGuardResponse{Enabled: !env.DeployGuardDisabled, Source: "none"}
+
+	policy, err := h.repos.Soaks.GetPolicy(ctx, env.ID)
+	switch {
+	case err == nil:
+		response.Policy, response.Source = policy, "soak_policy"
+	case err != sql.ErrNoRows && !isTableNotExistError(err):
+		return nil, err
+	case response.Enabled:
+		response.Policy, response.Source = soak.GuardPolicy(env.ID), "deploy_guard"
+	}
+	return response, nil
+}
+
+// GetDeployGuard returns whether new deployments to an environment are
+// watched and rolled back when they crash
+// GET /v1/projects/:slug/environments/:env_name/deploy-guard
+func (h *Handler) GetDeployGuard(c *gin.Context) {
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	response, err := h.deployGuardResponse(ctx, env)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get deploy guard",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get deploy guard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateDeployGuard opts an environment in or out of the deploy guard. A
+// soak policy of the environment applies either way.
+// PUT /v1/projects/:slug/environments/:env_name/deploy-guard
+func (h *Handler) UpdateDeployGuard(c *gin.Context) {
+	var req UpdateDeployGuardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, env := h.loadEnvironment(c)
+	if env == nil || !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.Environments.SetDeployGuardDisabled(ctx, env, !*req.Enabled); err != nil {
+		h.logger.Error(ctx, "Failed to update deploy guard",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update deploy guard"})
+		return
+	}
+
+	h.auditEnvironment(c, project, env, "environment.deploy_guard_updated", map[string]interface{}{
+		"enabled": *req.Enabled,
+	})
+
+	response, err := h.deployGuardResponse(ctx, env)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get deploy guard",
+			logging.String("environment_id", env.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get deploy guard"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDeploymentSoak returns the soak of a deployment
+// GET /v1/deployments/:id/soak
+func (h *Handler) GetDeploymentSoak(c *gin.Context) {
+	deploymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deployment ID"})
+		return
+	}
+	if !h.authorizeDeploymentEnvironment(c, deploymentID) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	result, err := h.repos.Soaks.Get(ctx, deploymentID)
+	if err != nil {
+		if err == sql.ErrNoRows || isTableNotExistError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deployment was not soaked"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get deployment soak",
+			logging.String("deployment_id", deploymentID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get deployment soak"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
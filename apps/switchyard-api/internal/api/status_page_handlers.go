@@ -0,0 +1,435 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/hosts"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/statuspage"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// maxStatusPageTitleLength and maxStatusPageDescriptionLength bound the
+	// text shown on a status page
+	maxStatusPageTitleLength       = 255
+	maxStatusPageDescriptionLength = 1000
+)
+
+// UpdateStatusPageRequest sets up the public status page of a project
+type UpdateStatusPageRequest struct {
+	Enabled      bool   `json:"enabled"`
+	Title        string `json:"title"`                          // Defaults to the project name
+	Description  string `json:"description"`                    // Shown under the title
+	Environment  string `json:"environment" binding:"required"` // Environment whose services are shown
+	CustomDomain string `json:"custom_domain"`                  // Also serve the page at this hostname
+}
+
+// GetPublicStatusPage serves the status page of a project without
+// authentication: HTML by default, JSON for format=json or an Accept header
+// preferring application/json. Projects without an enabled page get 404.
+// GET /status/:slug
+func (h *Handler) GetPublicStatusPage(c *gin.Context) {
+	if h.statusPages == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status page not found"})
+		return
+	}
+
+	page, err := h.repos.StatusPages.GetEnabledBySlug(c.Request.Context(), c.Param("slug"))
+	h.serveStatusPage(c, page, err)
+}
+
+// ServeStatusPageDomain serves the status page whose verified custom domain
+// is the request's host, for hostnames routed to the API by
+// VerifyStatusPageDomain. The API's own hosts never serve a page.
+// GET /
+func (h *Handler) ServeStatusPageDomain(c *gin.Context) {
+	if h.statusPages == nil || h.reservedHosts.IsAPIHost(c.Request.Host) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	page, err := h.repos.StatusPages.GetEnabledByDomain(c.Request.Context(), hosts.Normalize(c.Request.Host))
+	h.serveStatusPage(c, page, err)
+}
+
+func (h *Handler) serveStatusPage(c *gin.Context, page *types.StatusPage, err error) {
+	ctx := c.Request.Context()
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status page not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get status page", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get status page"})
+		return
+	}
+
+	status, err := h.statusPages.Build(ctx, page)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to build status page",
+			logging.String("project_id", page.ProjectID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build status page"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=30")
+	if c.Query("format") == "json" || c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		c.JSON(http.StatusOK, status)
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := statuspage.Render(c.Writer, status); err != nil {
+		h.logger.Error(ctx, "Failed to render status page", logging.Error("error", err))
+	}
+}
+
+// GetStatusPage returns the status page settings of a project
+// GET /v1/projects/:slug/status-page
+func (h *Handler) GetStatusPage(c *gin.Context) {
+	project := h.loadStatusPageProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	page, err := h.repos.StatusPages.Get(ctx, project.ID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status page not set up"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get status page",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get status page"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// UpdateStatusPage creates or replaces the status page of a project. A new
+// custom domain is served once verified with VerifyStatusPageDomain, and
+// the previous domain is unrouted.
+// PUT /v1/projects/:slug/status-page
+func (h *Handler) UpdateStatusPage(c *gin.Context) {
+	var req UpdateStatusPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.CustomDomain = strings.ToLower(strings.TrimSpace(req.CustomDomain))
+
+	project := h.loadStatusPageProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if req.Title == "" {
+		req.Title = project.Name
+	}
+	if len(req.Title) > maxStatusPageTitleLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("title must be at most %d characters", maxStatusPageTitleLength)})
+		return
+	}
+	if len(req.Description) > maxStatusPageDescriptionLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("description must be at most %d characters", maxStatusPageDescriptionLength)})
+		return
+	}
+
+	env, err := h.repos.Environments.GetByProjectAndName(project.ID, req.Environment)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get environment", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get environment"})
+		return
+	}
+
+	previous, err := h.repos.StatusPages.Get(ctx, project.ID)
+	if err != nil && err != sql.ErrNoRows {
+		h.logger.Error(ctx, "Failed to get status page",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get status page"})
+		return
+	}
+	previousDomain := ""
+	if previous != nil {
+		previousDomain = previous.CustomDomain
+	}
+
+	if req.CustomDomain != "" && req.CustomDomain != previousDomain {
+		if !isValidDomain(req.CustomDomain) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid domain format"})
+			return
+		}
+		if !h.checkDomainClaim(c, req.CustomDomain, false) {
+			return
+		}
+		serviceDomain, err := h.repos.CustomDomains.Exists(ctx, req.CustomDomain)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to check domain existence", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		pageDomain, err := h.repos.StatusPages.DomainInUse(ctx, req.CustomDomain, project.ID)
+		if err != nil {
+			h.logger.Error(ctx, "Failed to check domain existence", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		if serviceDomain || pageDomain {
+			c.JSON(http.StatusConflict, gin.H{"error": "domain already in use"})
+			return
+		}
+	}
+
+	page := &types.StatusPage{
+		ProjectID:     project.ID,
+		Enabled:       req.Enabled,
+		Title:         req.Title,
+		Description:   req.Description,
+		EnvironmentID: env.ID,
+		Environment:   env.Name,
+		CustomDomain:  req.CustomDomain,
+	}
+	if previous != nil && req.CustomDomain == previousDomain {
+		page.DomainVerifiedAt = previous.DomainVerifiedAt
+	}
+	if err := h.repos.StatusPages.Upsert(ctx, page); err != nil {
+		h.logger.Error(ctx, "Failed to save status page",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save status page"})
+		return
+	}
+	if h.statusPages != nil {
+		h.statusPages.Invalidate(project.ID)
+	}
+
+	if page.CustomDomain != previousDomain {
+		h.removeStatusPageRoute(c, previousDomain)
+	}
+
+	h.auditStatusPage(c, project, page, "project.status_page_updated")
+
+	response := gin.H{"status_page": page}
+	if page.CustomDomain != "" && page.DomainVerifiedAt == nil {
+		value := statusPageVerificationValue(project)
+		response["verification_value"] = value
+		response["message"] = fmt.Sprintf("Add a TXT record to %s with value %s, then verify the domain", page.CustomDomain, value)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyStatusPageDomain checks the TXT record of a status page's custom
+// domain and, once it is found, routes the domain to the API through the
+// tunnel, like the custom domains of services
+// POST /v1/projects/:slug/status-page/verify-domain
+func (h *Handler) VerifyStatusPageDomain(c *gin.Context) {
+	project := h.loadStatusPageProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	page, err := h.repos.StatusPages.Get(ctx, project.ID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status page not set up"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get status page",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get status page"})
+		return
+	}
+	if page.CustomDomain == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status page has no custom domain"})
+		return
+	}
+
+	expectedValue := statusPageVerificationValue(project)
+	verified, err := verifyDNSTXTRecord(page.CustomDomain, expectedValue)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to verify DNS",
+			logging.String("domain", page.CustomDomain),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify DNS record", "details": err.Error()})
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":              "domain not verified",
+			"message":            fmt.Sprintf("Add a TXT record to %s with value: %s", page.CustomDomain, expectedValue),
+			"verification_value": expectedValue,
+		})
+		return
+	}
+
+	now := time.Now()
+	if err := h.repos.StatusPages.VerifyDomain(ctx, project.ID, page.CustomDomain, now); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "Status page domain changed; verify again"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to save status page domain verification",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save status page"})
+		return
+	}
+	page.DomainVerifiedAt = &now
+	if h.statusPages != nil {
+		h.statusPages.Invalidate(project.ID)
+	}
+
+	tunnelRouteAdded := h.addStatusPageRoute(c, page.CustomDomain)
+	h.auditStatusPage(c, project, page, "project.status_page_domain_verified")
+
+	c.JSON(http.StatusOK, gin.H{
+		"status_page":        page,
+		"tunnel_route_added": tunnelRouteAdded,
+	})
+}
+
+// statusPageVerificationValue is the TXT record that proves control of a
+// status page's custom domain
+func statusPageVerificationValue(project *types.Project) string {
+	return fmt.Sprintf("enclii-verification=%s", project.ID)
+}
+
+// DeleteStatusPage removes the status page of a project and unroutes its
+// custom domain
+// DELETE /v1/projects/:slug/status-page
+func (h *Handler) DeleteStatusPage(c *gin.Context) {
+	project := h.loadStatusPageProject(c)
+	if project == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	page, err := h.repos.StatusPages.Get(ctx, project.ID)
+	if err == nil {
+		err = h.repos.StatusPages.Delete(ctx, project.ID)
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status page not set up"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to delete status page",
+			logging.String("project_slug", project.Slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete status page"})
+		return
+	}
+	if h.statusPages != nil {
+		h.statusPages.Invalidate(project.ID)
+	}
+
+	h.removeStatusPageRoute(c, page.CustomDomain)
+	h.auditStatusPage(c, project, page, "project.status_page_deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Status page deleted"})
+}
+
+// loadStatusPageProject loads the project of the :slug route parameter, or
+// writes the error response and returns nil
+func (h *Handler) loadStatusPageProject(c *gin.Context) *types.Project {
+	project := h.loadProject(c)
+	if project == nil {
+		return nil
+	}
+	if !h.authorizeEnvironment(c, project.ID, nil) {
+		return nil
+	}
+	return project
+}
+
+// addStatusPageRoute routes a status page's custom domain to the API's
+// Service. It returns false when there is no domain or tunnel routes are
+// not managed, leaving the route to be configured by hand.
+func (h *Handler) addStatusPageRoute(c *gin.Context, hostname string) bool {
+	if hostname == "" || h.tunnelRoutesService == nil {
+		return false
+	}
+	ctx := c.Request.Context()
+
+	routeSpec := &services.RouteSpec{
+		Hostname:         hostname,
+		ServiceName:      h.config.StatusPageServiceName,
+		ServiceNamespace: h.config.StatusPageServiceNamespace,
+		ServicePort:      80,
+		ConnectTimeout:   "30s",
+		KeepAliveTimeout: "90s",
+	}
+	if err := h.tunnelRoutesService.AddRoute(ctx, routeSpec); err != nil {
+		h.logger.Warn(ctx, "Failed to add tunnel route for status page (manual tunnel config may be needed)",
+			logging.String("domain", hostname),
+			logging.Error("error", err))
+		return false
+	}
+	return true
+}
+
+// removeStatusPageRoute unroutes a status page's previous custom domain
+func (h *Handler) removeStatusPageRoute(c *gin.Context, hostname string) {
+	if hostname == "" || h.tunnelRoutesService == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.tunnelRoutesService.RemoveRoute(ctx, hostname); err != nil {
+		h.logger.Warn(ctx, "Failed to remove tunnel route of status page",
+			logging.String("domain", hostname),
+			logging.Error("error", err))
+	}
+}
+
+// auditStatusPage records a change to a project's status page
+func (h *Handler) auditStatusPage(c *gin.Context, project *types.Project, page *types.StatusPage, action string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    fmt.Sprintf("%v", userEmail),
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		Action:        action,
+		ResourceType:  "project",
+		ResourceID:    project.ID.String(),
+		ResourceName:  project.Slug,
+		ProjectID:     &project.ID,
+		EnvironmentID: &page.EnvironmentID,
+		Outcome:       "success",
+		Context: map[string]interface{}{
+			"enabled":       page.Enabled,
+			"environment":   page.Environment,
+			"custom_domain": page.CustomDomain,
+		},
+	})
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+)
+
+func TestStatusPagesWithoutBuilder(t *testing.T) {
+	router := newAuthorizationRouter(string(auth.RoleViewer))
+
+	for _, path := range []string{"/status/shop", "/"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404 when status pages are not configured", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildlimits"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SetTeamBuildLimitsRequest sets a team's build limits
+type SetTeamBuildLimitsRequest struct {
+	// Limits are the largest build resources the team's services may set;
+	// null removes them
+	Limits *types.TeamBuildLimits `json:"limits"`
+}
+
+// GetTeamBuildLimits returns a team's build limits
+// GET /v1/teams/:slug/build-limits
+func (h *Handler) GetTeamBuildLimits(c *gin.Context) {
+	access := h.loadTeamAccess(c)
+	if access == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	limits, err := h.repos.Teams.GetBuildLimits(ctx, access.team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team build limits", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get build limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team": access.team.Slug, "limits": limits})
+}
+
+// SetTeamBuildLimits sets or removes a team's build limits. Only platform
+// admins set limits, since builds share the platform's build nodes.
+// Services already over new limits keep their settings, and their builds
+// are lowered to the limits when enqueued.
+// PUT /v1/teams/:slug/build-limits
+func (h *Handler) SetTeamBuildLimits(c *gin.Context) {
+	var req SetTeamBuildLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if err := buildlimits.Validate(req.Limits); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid build limits: " + err.Error()})
+		return
+	}
+	if req.Limits != nil && *req.Limits == (types.TeamBuildLimits{}) {
+		req.Limits = nil
+	}
+
+	access := h.loadTeamAccess(c)
+	if access == nil {
+		return
+	}
+	if !access.platformAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only platform admins can set a team's build limits"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	previous, err := h.repos.Teams.GetBuildLimits(ctx, access.team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team build limits", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set build limits"})
+		return
+	}
+	if err := h.repos.Teams.SetBuildLimits(ctx, access.team.ID, req.Limits); err != nil {
+		h.logger.Error(ctx, "Failed to set team build limits", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set build limits"})
+		return
+	}
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      &access.actorID,
+		ActorEmail:   access.actorEmail,
+		ActorRole:    types.Role(access.actorRole),
+		Action:       "team.build_limits_updated",
+		ResourceType: "team",
+		ResourceID:   access.team.ID.String(),
+		ResourceName: access.team.Slug,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"previous_limits": previous,
+			"limits":          req.Limits,
+		},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"team": access.team.Slug, "limits": req.Limits})
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/dns"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ConnectTeamDNSProviderRequest connects a DNS zone of a team
+type ConnectTeamDNSProviderRequest struct {
+	Provider        string `json:"provider" binding:"required"` // "cloudflare" or "route53"
+	ZoneID          string `json:"zone_id" binding:"required"`  // Cloudflare zone ID or Route53 hosted zone ID
+	Zone            string `json:"zone"`                        // Optional; checked against the provider when set
+	APIToken        string `json:"api_token"`                   // Cloudflare
+	AccessKeyID     string `json:"access_key_id"`               // Route53
+	SecretAccessKey string `json:"secret_access_key"`           // Route53
+}
+
+// loadTeamDNSAccess checks that managed DNS is enabled and loads the
+// caller's access to the team in the path
+func (h *Handler) loadTeamDNSAccess(c *gin.Context) *teamAccess {
+	if h.dnsService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Managed DNS is not enabled"})
+		return nil
+	}
+	return h.loadTeamAccess(c)
+}
+
+// canManageDNS reports whether the caller may connect and remove DNS providers
+func (a *teamAccess) canManageDNS() bool {
+	return a.teamRole == "owner" || a.teamRole == "admin" || a.platformAdmin
+}
+
+// loadTeamDNSProvider loads the provider in the path, checking that it
+// belongs to the team. It writes the error response and returns nil when
+// the provider cannot be loaded.
+func (h *Handler) loadTeamDNSProvider(c *gin.Context, access *teamAccess) *db.TeamDNSProvider {
+	ctx := c.Request.Context()
+
+	providerID, err := uuid.Parse(c.Param("provider_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider ID"})
+		return nil
+	}
+
+	provider, err := h.repos.TeamDNSProviders.GetByID(ctx, providerID)
+	if err == sql.ErrNoRows || (err == nil && provider.TeamID != access.team.ID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DNS provider not found"})
+		return nil
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get DNS provider", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get DNS provider"})
+		return nil
+	}
+	return provider
+}
+
+// auditDNSProvider records an action on a team's DNS provider
+func (h *Handler) auditDNSProvider(c *gin.Context, access *teamAccess, action string, provider *db.TeamDNSProvider) {
+	auditContext := map[string]interface{}{
+		"team_id":  access.team.ID.String(),
+		"provider": provider.Provider,
+		"zone":     provider.Zone,
+		"zone_id":  provider.ZoneID,
+	}
+	if access.override() && access.teamRole != "admin" {
+		auditContext["override"] = true
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      &access.actorID,
+		ActorEmail:   access.actorEmail,
+		ActorRole:    types.Role(access.actorRole),
+		Action:       action,
+		ResourceType: "team_dns_provider",
+		ResourceID:   provider.ID.String(),
+		ResourceName: access.team.Slug,
+		Outcome:      "success",
+		Context:      auditContext,
+	})
+}
+
+// ListTeamDNSProviders returns the DNS zones a team has connected
+func (h *Handler) ListTeamDNSProviders(c *gin.Context) {
+	access := h.loadTeamDNSAccess(c)
+	if access == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	providers, err := h.repos.TeamDNSProviders.ListByTeam(ctx, access.team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list DNS providers", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list DNS providers"})
+		return
+	}
+	if providers == nil {
+		providers = []*db.TeamDNSProvider{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// ConnectTeamDNSProvider connects a DNS zone so that Enclii can manage the
+// records of the team's domains in it. The credentials are checked against
+// the zone before they are stored.
+func (h *Handler) ConnectTeamDNSProvider(c *gin.Context) {
+	var req ConnectTeamDNSProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	access := h.loadTeamDNSAccess(c)
+	if access == nil {
+		return
+	}
+	if !access.canManageDNS() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only team owners and admins can connect a DNS provider"})
+		return
+	}
+
+	provider := &db.TeamDNSProvider{
+		TeamID:   access.team.ID,
+		Provider: req.Provider,
+		Zone:     dns.NormalizeName(req.Zone),
+		ZoneID:   req.ZoneID,
+		Credentials: db.DNSProviderCredentials{
+			APIToken:        req.APIToken,
+			AccessKeyID:     req.AccessKeyID,
+			SecretAccessKey: req.SecretAccessKey,
+		},
+		CreatedBy: &access.actorID,
+	}
+	if access.actorEmail != "" {
+		provider.CreatedByEmail = &access.actorEmail
+	}
+	if err := dns.ValidateCredentials(provider.Provider, provider.Credentials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if err := h.dnsService.Connect(ctx, provider); err != nil {
+		switch {
+		case errors.Is(err, dns.ErrZoneUnreachable), errors.Is(err, dns.ErrZoneMismatch):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case db.IsUniqueConstraintError(err):
+			c.JSON(http.StatusConflict, gin.H{"error": "Team already has a DNS provider for zone " + provider.Zone})
+		default:
+			h.logger.Error(ctx, "Failed to connect DNS provider",
+				logging.String("team", access.team.Slug),
+				logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect DNS provider"})
+		}
+		return
+	}
+
+	h.auditDNSProvider(c, access, "team.dns_provider_connected", provider)
+
+	c.JSON(http.StatusCreated, provider)
+}
+
+// CheckTeamDNSProvider checks that a provider's credentials still reach its zone
+func (h *Handler) CheckTeamDNSProvider(c *gin.Context) {
+	access := h.loadTeamDNSAccess(c)
+	if access == nil {
+		return
+	}
+	if !access.canManageDNS() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only team owners and admins can check a DNS provider"})
+		return
+	}
+
+	provider := h.loadTeamDNSProvider(c, access)
+	if provider == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.dnsService.CheckProvider(c.Request.Context(), provider))
+}
+
+// RemoveTeamDNSProvider disconnects a DNS zone. Records Enclii created stay
+// in place and the domains using them switch to manual DNS.
+func (h *Handler) RemoveTeamDNSProvider(c *gin.Context) {
+	access := h.loadTeamDNSAccess(c)
+	if access == nil {
+		return
+	}
+	if !access.canManageDNS() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only team owners and admins can remove a DNS provider"})
+		return
+	}
+
+	provider := h.loadTeamDNSProvider(c, access)
+	if provider == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if err := h.repos.TeamDNSProviders.Delete(ctx, provider.ID); err != nil {
+		h.logger.Error(ctx, "Failed to remove DNS provider", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove DNS provider"})
+		return
+	}
+
+	h.auditDNSProvider(c, access, "team.dns_provider_removed", provider)
+
+	c.JSON(http.StatusOK, gin.H{"message": "DNS provider removed; its domains now use manual DNS"})
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cmek"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ConfigureTeamEncryptionKeyRequest configures a customer-managed key
+type ConfigureTeamEncryptionKeyRequest struct {
+	KeyARN string `json:"key_arn" binding:"required"`
+}
+
+// teamAccess is the caller's standing on a team
+type teamAccess struct {
+	team          *db.Team
+	teamRole      string // Empty when the caller is not a member
+	platformAdmin bool
+	actorID       uuid.UUID
+	actorEmail    string
+	actorRole     string
+}
+
+// loadTeamKeyAccess checks that customer-managed keys are enabled and loads
+// the caller's access to the team in the path
+func (h *Handler) loadTeamKeyAccess(c *gin.Context) *teamAccess {
+	if h.encryptionKeyService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Customer-managed encryption keys are not enabled"})
+		return nil
+	}
+	return h.loadTeamAccess(c)
+}
+
+// loadTeamAccess resolves the team in the path and the caller's roles. It
+// writes the error response and returns nil when the request cannot proceed.
+func (h *Handler) loadTeamAccess(c *gin.Context) *teamAccess {
+	userID, err := auth.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil
+	}
+
+	ctx := c.Request.Context()
+
+	team, err := h.repos.Teams.GetBySlug(ctx, c.Param("slug"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return nil
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team"})
+		return nil
+	}
+
+	access := &teamAccess{team: team, actorID: userID}
+	access.teamRole, _ = h.repos.TeamMembers.GetUserRole(ctx, team.ID, userID)
+	access.actorEmail, _ = auth.GetUserEmailFromContext(c)
+	if role, ok := c.Get("user_role"); ok {
+		access.actorRole = fmt.Sprintf("%v", role)
+	}
+	access.platformAdmin = access.actorRole == string(auth.RoleAdmin) || access.actorRole == string(auth.RoleSuperAdmin)
+
+	if access.teamRole == "" && !access.platformAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this team"})
+		return nil
+	}
+	return access
+}
+
+// override reports whether the caller acts as a platform admin rather than
+// as the team's owner
+func (a *teamAccess) override() bool {
+	return a.teamRole != "owner"
+}
+
+// auditKey records an action on a team's encryption key
+func (h *Handler) auditKey(c *gin.Context, access *teamAccess, action string, key *db.TeamEncryptionKey, auditContext map[string]interface{}) {
+	if auditContext == nil {
+		auditContext = map[string]interface{}{}
+	}
+	auditContext["team_id"] = access.team.ID.String()
+	auditContext["key_arn"] = key.KeyARN
+	if access.override() {
+		auditContext["override"] = true
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:      &access.actorID,
+		ActorEmail:   access.actorEmail,
+		ActorRole:    types.Role(access.actorRole),
+		Action:       action,
+		ResourceType: "team_encryption_key",
+		ResourceID:   key.ID.String(),
+		ResourceName: access.team.Slug,
+		Outcome:      "success",
+		Context:      auditContext,
+	})
+}
+
+// isEncryptionKeyUnavailable reports whether an error is caused by a team's
+// customer-managed key being revoked, unreachable, or removed
+func isEncryptionKeyUnavailable(err error) bool {
+	return errors.Is(err, cmek.ErrKeyRevoked) || errors.Is(err, cmek.ErrKeyUnreachable) ||
+		errors.Is(err, cmek.ErrKeyRemoved) || errors.Is(err, db.ErrKeyringUnavailable)
+}
+
+// GetTeamEncryptionKey returns the customer-managed key of a team and its health
+func (h *Handler) GetTeamEncryptionKey(c *gin.Context) {
+	access := h.loadTeamKeyAccess(c)
+	if access == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	key, err := h.encryptionKeyService.GetKey(ctx, access.team.ID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team has no customer-managed encryption key"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team encryption key", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get encryption key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// ConfigureTeamEncryptionKey sets up a customer-managed key for a team and
+// re-encrypts the team's secrets with it. Team owners configure their own
+// key; platform admins may do so on their behalf, which is audited as an
+// override.
+func (h *Handler) ConfigureTeamEncryptionKey(c *gin.Context) {
+	var req ConfigureTeamEncryptionKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	access := h.loadTeamKeyAccess(c)
+	if access == nil {
+		return
+	}
+	if access.teamRole != "owner" && !access.platformAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the team owner can configure an encryption key"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	key, reencrypted, err := h.encryptionKeyService.Configure(ctx, access.team.ID, req.KeyARN, &access.actorID, access.actorEmail)
+	if err != nil {
+		switch {
+		case errors.Is(err, cmek.ErrKeyAlreadyConfigured):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case isEncryptionKeyUnavailable(err):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error(ctx, "Failed to configure team encryption key",
+				logging.String("team", access.team.Slug),
+				logging.Error("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	h.auditKey(c, access, "team.encryption_key_configured", key, map[string]interface{}{
+		"reencrypted": reencrypted,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{"key": key, "reencrypted": reencrypted})
+}
+
+// CheckTeamEncryptionKey runs a health check on a team's key now
+func (h *Handler) CheckTeamEncryptionKey(c *gin.Context) {
+	access := h.loadTeamKeyAccess(c)
+	if access == nil {
+		return
+	}
+	if access.teamRole != "owner" && access.teamRole != "admin" && !access.platformAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only team owners and admins can check the encryption key"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	key, err := h.encryptionKeyService.GetKey(ctx, access.team.ID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team has no customer-managed encryption key"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team encryption key", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get encryption key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.encryptionKeyService.Check(ctx, key))
+}
+
+// RemoveTeamEncryptionKey moves a team back to the platform key. With
+// ?force=true a platform admin removes a key that can no longer be used;
+// data encrypted with it stays unreadable.
+func (h *Handler) RemoveTeamEncryptionKey(c *gin.Context) {
+	access := h.loadTeamKeyAccess(c)
+	if access == nil {
+		return
+	}
+
+	force := c.Query("force") == "true"
+	if force && !access.platformAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only platform admins can force-remove an encryption key"})
+		return
+	}
+	if access.teamRole != "owner" && !access.platformAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the team owner can remove the encryption key"})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if force {
+		key, err := h.encryptionKeyService.ForceRemove(ctx, access.team.ID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Team has no customer-managed encryption key"})
+			return
+		}
+		if err != nil {
+			h.logger.Error(ctx, "Failed to force-remove team encryption key", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove encryption key"})
+			return
+		}
+
+		h.auditKey(c, access, "team.encryption_key_force_removed", key, map[string]interface{}{
+			"override":   true,
+			"key_status": key.Status,
+		})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Encryption key removed; data encrypted with it is no longer recoverable"})
+		return
+	}
+
+	key, err := h.encryptionKeyService.GetKey(ctx, access.team.ID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team has no customer-managed encryption key"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team encryption key", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get encryption key"})
+		return
+	}
+
+	reencrypted, err := h.encryptionKeyService.Remove(ctx, access.team.ID)
+	if err != nil {
+		if isEncryptionKeyUnavailable(err) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to remove team encryption key", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove encryption key"})
+		return
+	}
+
+	h.auditKey(c, access, "team.encryption_key_removed", key, map[string]interface{}{
+		"reencrypted": reencrypted,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Encryption key removed", "reencrypted": reencrypted})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SetTeamGPUQuotaRequest sets a team's GPU quota
+type SetTeamGPUQuotaRequest struct {
+	// Quota is the most GPUs the team's services may hold; null removes the limit
+	Quota *int `json:"quota" binding:"omitempty,min=0"`
+}
+
+// GetTeamGPUQuota returns a team's GPU quota and the GPUs its services hold
+// GET /v1/teams/:slug/gpu-quota
+func (h *Handler) GetTeamGPUQuota(c *gin.Context) {
+	access := h.loadTeamAccess(c)
+	if access == nil {
+		return
+	}
+	ctx := c.Request.Context()
+
+	quota, err := h.repos.Teams.GetGPUQuota(ctx, access.team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team GPU quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get GPU quota"})
+		return
+	}
+	used, err := h.repos.Teams.GPUsInUse(ctx, access.team.ID, uuid.Nil, uuid.Nil)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to count team GPUs", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get GPU quota"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team": access.team.Slug, "quota": quota, "used": used})
+}
+
+// SetTeamGPUQuota sets or removes a team's GPU quota. Only platform admins
+// set quotas, since they share out the platform's GPUs between teams.
+// Services already holding more GPUs keep running; only new deploys are
+// held to the quota.
+// PUT /v1/teams/:slug/gpu-quota
+func (h *Handler) SetTeamGPUQuota(c *gin.Context) {
+	var req SetTeamGPUQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	access := h.loadTeamAccess(c)
+	if access == nil {
+		return
+	}
+	if !access.platformAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only platform admins can set a team's GPU quota"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	previous, err := h.repos.Teams.GetGPUQuota(ctx, access.team.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get team GPU quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set GPU quota"})
+		return
+	}
+	if err := h.repos.Teams.SetGPUQuota(ctx, access.team.ID, req.Quota); err != nil {
+		h.logger.Error(ctx, "Failed to set team GPU quota", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set GPU quota"})
+		return
+	}
+
+	h.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      &access.actorID,
+		ActorEmail:   access.actorEmail,
+		ActorRole:    types.Role(access.actorRole),
+		Action:       "team.gpu_quota_updated",
+		ResourceType: "team",
+		ResourceID:   access.team.ID.String(),
+		ResourceName: access.team.Slug,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"previous_quota": previous,
+			"quota":          req.Quota,
+		},
+	})
+
+	used, err := h.repos.Teams.GPUsInUse(ctx, access.team.ID, uuid.Nil, uuid.Nil)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to count team GPUs", logging.Error("error", err))
+	}
+	c.JSON(http.StatusOK, gin.H{"team": access.team.Slug, "quota": req.Quota, "used": used})
+}
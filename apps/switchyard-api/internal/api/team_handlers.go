@@ -11,6 +11,7 @@ import (
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // Team Management API Handlers
@@ -32,6 +33,8 @@ type UpdateTeamRequest struct {
 	Description  *string `json:"description,omitempty"`
 	BillingEmail *string `json:"billing_email,omitempty"`
 	AvatarURL    *string `json:"avatar_url,omitempty"`
+	// TwoFactorPolicy sets which operations in the team's projects need a second factor
+	TwoFactorPolicy *string `json:"two_factor_policy,omitempty" binding:"omitempty,oneof=off production all"`
 }
 
 type InviteMemberRequest struct {
@@ -44,16 +47,17 @@ type UpdateMemberRoleRequest struct {
 }
 
 type TeamResponse struct {
-	ID           uuid.UUID `json:"id"`
-	Name         string    `json:"name"`
-	Slug         string    `json:"slug"`
-	Description  *string   `json:"description,omitempty"`
-	AvatarURL    *string   `json:"avatar_url,omitempty"`
-	BillingEmail *string   `json:"billing_email,omitempty"`
-	MemberCount  int       `json:"member_count"`
-	UserRole     string    `json:"user_role,omitempty"`
-	CreatedAt    string    `json:"created_at"`
-	UpdatedAt    string    `json:"updated_at"`
+	ID              uuid.UUID             `json:"id"`
+	Name            string                `json:"name"`
+	Slug            string                `json:"slug"`
+	Description     *string               `json:"description,omitempty"`
+	AvatarURL       *string               `json:"avatar_url,omitempty"`
+	BillingEmail    *string               `json:"billing_email,omitempty"`
+	MemberCount     int                   `json:"member_count"`
+	TwoFactorPolicy types.TwoFactorPolicy `json:"two_factor_policy"`
+	UserRole        string                `json:"user_role,omitempty"`
+	CreatedAt       string                `json:"created_at"`
+	UpdatedAt       string                `json:"updated_at"`
 }
 
 type TeamMemberResponse struct {
@@ -156,19 +160,21 @@ func (h *Handler) CreateTeam(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, TeamResponse{
-		ID:           team.ID,
-		Name:         team.Name,
-		Slug:         team.Slug,
-		Description:  team.Description,
-		BillingEmail: team.BillingEmail,
-		MemberCount:  1,
-		UserRole:     "owner",
-		CreatedAt:    team.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:    team.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:              team.ID,
+		Name:            team.Name,
+		Slug:            team.Slug,
+		Description:     team.Description,
+		BillingEmail:    team.BillingEmail,
+		MemberCount:     1,
+		TwoFactorPolicy: team.TwoFactorPolicy,
+		UserRole:        "owner",
+		CreatedAt:       team.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:       team.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	})
 }
 
-// ListTeams returns all teams the current user is a member of
+// ListTeams returns the teams the current user is a member of, a page at a
+// time, sorted by name unless sort says otherwise
 func (h *Handler) ListTeams(c *gin.Context) {
 	currentUserID, err := auth.GetUserIDFromContext(c)
 	if err != nil {
@@ -178,12 +184,21 @@ func (h *Handler) ListTeams(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
-	teams, err := h.repos.Teams.ListByUser(ctx, currentUserID)
+	query, err := GetListQuery(c, "name", "created_at", "updated_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	teams, err := h.repos.Teams.ListPageByUser(ctx, currentUserID, query.ListParams)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to list teams", logging.Error("error", err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list teams"})
 		return
 	}
+	teams, next := db.TrimPage(teams, query.ListParams, func(team *db.Team) db.Cursor {
+		return itemCursor(query.Sort, team.ID, team.Name, team.CreatedAt, team.UpdatedAt)
+	})
 
 	// Build response with member counts and user roles
 	responses := make([]TeamResponse, 0, len(teams))
@@ -192,20 +207,24 @@ func (h *Handler) ListTeams(c *gin.Context) {
 		userRole, _ := h.repos.TeamMembers.GetUserRole(ctx, team.ID, currentUserID)
 
 		responses = append(responses, TeamResponse{
-			ID:           team.ID,
-			Name:         team.Name,
-			Slug:         team.Slug,
-			Description:  team.Description,
-			AvatarURL:    team.AvatarURL,
-			BillingEmail: team.BillingEmail,
-			MemberCount:  memberCount,
-			UserRole:     userRole,
-			CreatedAt:    team.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:    team.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			ID:              team.ID,
+			Name:            team.Name,
+			Slug:            team.Slug,
+			Description:     team.Description,
+			AvatarURL:       team.AvatarURL,
+			BillingEmail:    team.BillingEmail,
+			MemberCount:     memberCount,
+			TwoFactorPolicy: team.TwoFactorPolicy,
+			UserRole:        userRole,
+			CreatedAt:       team.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:       team.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"teams": responses})
+	c.JSON(http.StatusOK, gin.H{
+		"teams":       responses,
+		"next_cursor": encodeListCursor(query.ListParams, next),
+	})
 }
 
 // GetTeam returns a single team by slug
@@ -241,16 +260,17 @@ func (h *Handler) GetTeam(c *gin.Context) {
 	memberCount, _ := h.repos.TeamMembers.CountByTeam(ctx, team.ID)
 
 	c.JSON(http.StatusOK, TeamResponse{
-		ID:           team.ID,
-		Name:         team.Name,
-		Slug:         team.Slug,
-		Description:  team.Description,
-		AvatarURL:    team.AvatarURL,
-		BillingEmail: team.BillingEmail,
-		MemberCount:  memberCount,
-		UserRole:     userRole,
-		CreatedAt:    team.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:    team.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:              team.ID,
+		Name:            team.Name,
+		Slug:            team.Slug,
+		Description:     team.Description,
+		AvatarURL:       team.AvatarURL,
+		BillingEmail:    team.BillingEmail,
+		MemberCount:     memberCount,
+		TwoFactorPolicy: team.TwoFactorPolicy,
+		UserRole:        userRole,
+		CreatedAt:       team.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:       team.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	})
 }
 
@@ -303,6 +323,9 @@ func (h *Handler) UpdateTeam(c *gin.Context) {
 	if req.AvatarURL != nil {
 		team.AvatarURL = req.AvatarURL
 	}
+	if req.TwoFactorPolicy != nil {
+		team.TwoFactorPolicy = types.TwoFactorPolicy(*req.TwoFactorPolicy)
+	}
 
 	if err := h.repos.Teams.Update(ctx, team); err != nil {
 		h.logger.Error(ctx, "Failed to update team", logging.Error("error", err))
@@ -313,16 +336,17 @@ func (h *Handler) UpdateTeam(c *gin.Context) {
 	memberCount, _ := h.repos.TeamMembers.CountByTeam(ctx, team.ID)
 
 	c.JSON(http.StatusOK, TeamResponse{
-		ID:           team.ID,
-		Name:         team.Name,
-		Slug:         team.Slug,
-		Description:  team.Description,
-		AvatarURL:    team.AvatarURL,
-		BillingEmail: team.BillingEmail,
-		MemberCount:  memberCount,
-		UserRole:     userRole,
-		CreatedAt:    team.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:    team.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:              team.ID,
+		Name:            team.Name,
+		Slug:            team.Slug,
+		Description:     team.Description,
+		AvatarURL:       team.AvatarURL,
+		BillingEmail:    team.BillingEmail,
+		MemberCount:     memberCount,
+		TwoFactorPolicy: team.TwoFactorPolicy,
+		UserRole:        userRole,
+		CreatedAt:       team.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:       team.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	})
 }
 
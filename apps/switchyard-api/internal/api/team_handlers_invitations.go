@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // InviteTeamMember creates an invitation to join the team
@@ -347,6 +349,19 @@ func (h *Handler) AcceptInvitation(c *gin.Context) {
 		// Member was already added, so continue
 	}
 
+	// Show the new member in the activity feed of the team's projects
+	if h.notificationService != nil {
+		h.notificationService.RecordTeamEvent(ctx, invitation.TeamID, &types.ProjectEvent{
+			Type:         types.ProjectEventMemberAdded,
+			ResourceType: "member",
+			ResourceID:   &currentUserID,
+			ActorID:      &currentUserID,
+			ActorEmail:   user.Email,
+			Message:      fmt.Sprintf("%s joined the team as %s", user.Email, invitation.Role),
+			Data:         map[string]any{"user_id": currentUserID, "email": user.Email, "role": invitation.Role},
+		})
+	}
+
 	// Get team for response
 	team, _ := h.repos.Teams.GetByID(ctx, invitation.TeamID)
 
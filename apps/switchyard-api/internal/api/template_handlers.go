@@ -12,6 +12,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -365,6 +366,10 @@ func (h *Handler) processTemplateDeployment(
 			AutoDeployBranch: template.SourceBranch,
 		}
 
+		if err := quota.CheckNewService(ctx, h.repos, project.ID); err != nil {
+			deploymentError = fmt.Sprintf("failed to create service %s: %v", svcConfig.Name, err)
+			break
+		}
 		if err := h.repos.Services.Create(service); err != nil {
 			h.logger.Error(ctx, "Failed to create service from template",
 				logging.String("service_name", svcConfig.Name),
@@ -1,11 +1,23 @@
 package api
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/topology"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	defaultTrafficWindow = 15 * time.Minute
+	maxTrafficWindow     = 24 * time.Hour
 )
 
 // GetTopology returns the complete service topology graph
@@ -86,3 +98,105 @@ func (h *Handler) FindDependencyPath(c *gin.Context) {
 
 	c.JSON(http.StatusOK, path)
 }
+
+// GetProjectTopology returns the service map of a project in one
+// environment: its services, addons and domains with their live health and
+// the edges between them, as JSON, Graphviz DOT or Mermaid
+// GET /v1/projects/:slug/topology?environment=<env>&format=json|dot|mermaid&traffic=true&window=15m
+func (h *Handler) GetProjectTopology(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dot" && format != "mermaid" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, dot or mermaid"})
+		return
+	}
+	opts, err := projectTopologyOptions(c.Query("traffic"), c.Query("window"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := h.loadProject(c)
+	if project == nil {
+		return
+	}
+	env, err := h.topologyEnvironment(project, c.Query("environment"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get environment", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get environment"})
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+
+	graph, err := h.topologyBuilder.BuildProjectTopology(ctx, project, env, opts)
+	if err != nil {
+		if errors.Is(err, topology.ErrTrafficUnavailable) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error(ctx, "Failed to build project topology",
+			logging.String("project", project.Slug),
+			logging.String("environment", env.Name),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build project topology"})
+		return
+	}
+
+	switch format {
+	case "dot":
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(graph.DOT(project.Slug+"-"+env.Name)))
+	case "mermaid":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(graph.Mermaid()))
+	default:
+		c.JSON(http.StatusOK, graph)
+	}
+}
+
+// topologyEnvironment finds the environment of a project service map: the
+// one named, or else production, or else the first environment
+func (h *Handler) topologyEnvironment(project *types.Project, name string) (*types.Environment, error) {
+	if name != "" {
+		return h.repos.Environments.GetByProjectAndName(project.ID, name)
+	}
+	envs, err := h.repos.Environments.ListByProject(project.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(envs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	for _, env := range envs {
+		if env.Name == "production" {
+			return env, nil
+		}
+	}
+	return envs[0], nil
+}
+
+// projectTopologyOptions reads whether to include observed traffic and over
+// what window
+func projectTopologyOptions(traffic, window string) (topology.ProjectOptions, error) {
+	opts := topology.ProjectOptions{TrafficWindow: defaultTrafficWindow}
+	if traffic != "" {
+		include, err := strconv.ParseBool(traffic)
+		if err != nil {
+			return opts, fmt.Errorf("traffic must be true or false")
+		}
+		opts.Traffic = include
+	}
+	if window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d < time.Minute || d > maxTrafficWindow {
+			return opts, fmt.Errorf("invalid window %q: use a duration between 1m and 24h", window)
+		}
+		opts.TrafficWindow = d
+	}
+	return opts, nil
+}
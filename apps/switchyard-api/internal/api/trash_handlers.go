@@ -0,0 +1,162 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+)
+
+// RestoreProject takes a project and the services deleted with it out of
+// the trash and scales the services back up. Services deleted on their own
+// before the project stay in the trash.
+// POST /v1/projects/:slug/restore
+func (h *Handler) RestoreProject(c *gin.Context) {
+	ctx := c.Request.Context()
+	slug := c.Param("slug")
+
+	if h.trash == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Trash is not available"})
+		return
+	}
+
+	project, err := h.repos.Projects.GetTrashedBySlug(ctx, slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found in trash"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get trashed project",
+			logging.String("project_slug", slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		return
+	}
+
+	if err := h.trash.RestoreProject(ctx, project); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found in trash"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to restore project",
+			logging.String("project_slug", slug),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore project"})
+		return
+	}
+
+	if h.cache != nil {
+		if err := h.cache.InvalidateTags(ctx, "projects"); err != nil {
+			h.logger.Warn(ctx, "Failed to invalidate project cache", logging.Error("error", err))
+		}
+	}
+
+	h.logger.Info(ctx, "Project restored from trash",
+		logging.String("project_id", project.ID.String()),
+		logging.String("project_slug", slug),
+		logging.String("restored_by", c.GetString("user_email")))
+
+	project.DeletedAt = nil
+	c.JSON(http.StatusOK, project)
+}
+
+// RestoreService takes a service out of the trash and scales it back up.
+// A service whose project is in the trash is restored with the project.
+// POST /v1/services/:id/restore
+func (h *Handler) RestoreService(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if h.trash == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "trash is not available"})
+		return
+	}
+
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid service_id format"})
+		return
+	}
+
+	service, err := h.repos.Services.GetTrashed(ctx, serviceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "service not found in trash"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to get trashed service", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get service"})
+		return
+	}
+
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project of trashed service", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return
+	}
+	if project.DeletedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "project is in the trash",
+			"message": "Restore project " + project.Slug + " to restore its services",
+		})
+		return
+	}
+
+	if err := h.trash.RestoreService(ctx, service); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "service not found in trash"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to restore service",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore service"})
+		return
+	}
+
+	h.logger.Info(ctx, "Service restored from trash",
+		logging.String("service_id", serviceID.String()),
+		logging.String("name", service.Name))
+
+	service.DeletedAt = nil
+	c.JSON(http.StatusOK, gin.H{
+		"service": service,
+		"message": "Service restored",
+	})
+}
+
+// trashedFilter reads the trashed filter of a list. Only platform admins
+// may list trashed resources; on a bad or forbidden filter it responds and
+// returns ok false.
+func trashedFilter(c *gin.Context) (trashed, ok bool) {
+	value := c.Query("trashed")
+	if value == "" {
+		return false, true
+	}
+	trashed, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "trashed must be true or false"})
+		return false, false
+	}
+	role := c.GetString("user_role")
+	if trashed && role != string(auth.RoleAdmin) && role != string(auth.RoleSuperAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can list trashed resources"})
+		return false, false
+	}
+	return trashed, true
+}
+
+// actorUUID returns the ID of the authenticated user, or nil when it is not
+// a UUID
+func actorUUID(c *gin.Context) *uuid.UUID {
+	id, err := auth.GetUserIDFromContext(c)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// TwoFactorCodeRequest carries a TOTP code, or a recovery code where one is
+// accepted
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorTokensResponse carries the tokens of a session that just verified
+// a second factor
+type TwoFactorTokensResponse struct {
+	AccessToken   string    `json:"access_token"`
+	RefreshToken  string    `json:"refresh_token"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	TokenType     string    `json:"token_type"`
+	RecoveryCodes []string  `json:"recovery_codes,omitempty"`
+}
+
+// LoginTwoFactor completes a password login with a TOTP or recovery code.
+//
+// Request:
+//   - Method: POST /api/v1/auth/login/two-factor
+//   - Body: {two_factor_token: string, code: string}
+//
+// Response:
+//   - 200 OK: LoginResponse with tokens and user info
+//   - 400 Bad Request: Invalid request body
+//   - 401 Unauthorized: Invalid code, or expired challenge
+//   - 429 Too Many Requests: Locked after too many invalid codes
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req struct {
+		TwoFactorToken string `json:"two_factor_token" binding:"required"`
+		Code           string `json:"code" binding:"required,max=32"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	resp, err := h.authService.VerifyTwoFactorLogin(c.Request.Context(), &services.TwoFactorLoginRequest{
+		TwoFactorToken: req.TwoFactorToken,
+		Code:           req.Code,
+		IP:             c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+	})
+	if err != nil {
+		if errors.Is(err, errors.ErrTokenInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Login has expired, sign in again", "code": "TWO_FACTOR_CHALLENGE_EXPIRED"})
+		} else {
+			h.respondTwoFactorError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, LoginResponse{
+		User:         resp.User,
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresAt:    resp.ExpiresAt,
+		TokenType:    "Bearer",
+	})
+}
+
+// GetTwoFactorStatus returns the two-factor authentication of the current user
+// GET /v1/user/two-factor
+func (h *Handler) GetTwoFactorStatus(c *gin.Context) {
+	userID, err := auth.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status, err := h.authService.GetTwoFactorStatus(c.Request.Context(), userID)
+	if err != nil {
+		h.respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                  status.Enabled,
+		"enabled_at":               status.EnabledAt,
+		"recovery_codes_remaining": status.RecoveryCodesRemaining,
+	})
+}
+
+// BeginTwoFactorEnrollment creates an authenticator for the current user,
+// returned as a secret and an otpauth:// URI for a QR code. It is enabled
+// once a code from it is sent to the enable endpoint.
+// POST /v1/user/two-factor/enroll
+func (h *Handler) BeginTwoFactorEnrollment(c *gin.Context) {
+	claims, ok := h.twoFactorSession(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.authService.BeginTwoFactorEnrollment(c.Request.Context(), claims)
+	if err != nil {
+		h.respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           enrollment.Secret,
+		"provisioning_uri": enrollment.ProvisioningURI,
+	})
+}
+
+// EnableTwoFactor confirms the pending authenticator with a TOTP code. The
+// recovery codes are only shown in this response.
+// POST /v1/user/two-factor/enable
+func (h *Handler) EnableTwoFactor(c *gin.Context) {
+	req, ok := h.bindTwoFactorRequest(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.authService.EnableTwoFactor(c.Request.Context(), req)
+	if err != nil {
+		h.respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, twoFactorTokensResponse(resp))
+}
+
+// VerifyTwoFactor checks a code of the current user and returns tokens that
+// allow sensitive operations, such as production deploys and secret reads,
+// for the next few minutes
+// POST /v1/user/two-factor/verify
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	req, ok := h.bindTwoFactorRequest(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.authService.VerifyTwoFactor(c.Request.Context(), req)
+	if err != nil {
+		h.respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, twoFactorTokensResponse(resp))
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+// POST /v1/user/two-factor/recovery-codes
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	req, ok := h.bindTwoFactorRequest(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), req)
+	if err != nil {
+		h.respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableTwoFactor turns off two-factor authentication for the current user
+// DELETE /v1/user/two-factor
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	req, ok := h.bindTwoFactorRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(c.Request.Context(), req); err != nil {
+		h.respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// requireTwoFactor enforces the second factor before a sensitive operation.
+// Users who enabled two-factor authentication must have verified a code
+// recently; others are refused when the policy of the project's team
+// requires it. It writes the error response and returns false when the
+// operation may not proceed.
+//
+// In OIDC mode the identity provider enforces MFA. API tokens cannot
+// answer a challenge, and creating one requires a recent second factor
+// instead, so tokens of enrolled users pass; tokens of other users are
+// refused like their sessions. Without a project, as for token creation,
+// the policies of all of the user's teams apply.
+func (h *Handler) requireTwoFactor(c *gin.Context, projectID *uuid.UUID, op auth.SensitiveOperation) bool {
+	if h.config.AuthMode == "oidc" {
+		return true
+	}
+	claims, err := auth.GetClaimsFromContext(c)
+	if err != nil && c.GetString("auth_type") != "api_token" {
+		return true
+	}
+	userID, err := auth.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return false
+	}
+
+	ctx := c.Request.Context()
+	enrolled, err := h.repos.TwoFactor.IsEnabled(ctx, userID)
+	if err != nil && !isTableNotExistError(err) {
+		h.logger.Error(ctx, "Failed to check two-factor authentication", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor authentication"})
+		return false
+	}
+	if enrolled {
+		if claims == nil || claims.HasRecentTwoFactor(time.Now()) {
+			return true
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":     "This operation requires a recent second factor; verify a code first",
+			"code":      "TWO_FACTOR_REQUIRED",
+			"operation": op,
+		})
+		return false
+	}
+
+	policies, err := h.twoFactorPolicies(c, userID, projectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get two-factor policy", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor authentication"})
+		return false
+	}
+	if slices.ContainsFunc(policies, func(policy types.TwoFactorPolicy) bool {
+		return auth.PolicyRequiresTwoFactor(policy, op)
+	}) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":     "Your team requires two-factor authentication for this operation; enable it first",
+			"code":      "TWO_FACTOR_ENROLLMENT_REQUIRED",
+			"operation": op,
+		})
+		return false
+	}
+	return true
+}
+
+// twoFactorPolicies returns the two-factor policy of the team of a project,
+// or without a project those of every team the user belongs to
+func (h *Handler) twoFactorPolicies(c *gin.Context, userID uuid.UUID, projectID *uuid.UUID) ([]types.TwoFactorPolicy, error) {
+	ctx := c.Request.Context()
+	if projectID != nil {
+		policy, err := h.repos.Teams.GetTwoFactorPolicyByProject(ctx, *projectID)
+		if err != nil {
+			return nil, err
+		}
+		return []types.TwoFactorPolicy{policy}, nil
+	}
+
+	teams, err := h.repos.Teams.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]types.TwoFactorPolicy, 0, len(teams))
+	for _, team := range teams {
+		policies = append(policies, team.TwoFactorPolicy)
+	}
+	return policies, nil
+}
+
+// twoFactorSession returns the claims of the current local session. API
+// tokens cannot manage two-factor authentication.
+func (h *Handler) twoFactorSession(c *gin.Context) (*auth.Claims, bool) {
+	claims, err := auth.GetClaimsFromContext(c)
+	if err != nil || h.config.AuthMode == "oidc" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is managed from a signed-in session"})
+		return nil, false
+	}
+	return claims, true
+}
+
+// bindTwoFactorRequest reads the code of a two-factor operation of the
+// current session
+func (h *Handler) bindTwoFactorRequest(c *gin.Context) (*services.TwoFactorRequest, bool) {
+	claims, ok := h.twoFactorSession(c)
+	if !ok {
+		return nil, false
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return nil, false
+	}
+
+	return &services.TwoFactorRequest{
+		Claims:      claims,
+		TokenString: strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "),
+		Code:        req.Code,
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+	}, true
+}
+
+func (h *Handler) respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errors.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code", "code": "INVALID_TWO_FACTOR_CODE"})
+	case errors.Is(err, errors.ErrTwoFactorLocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "code": "TWO_FACTOR_LOCKED"})
+	case errors.Is(err, errors.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "TWO_FACTOR_NOT_ENABLED"})
+	case errors.Is(err, errors.ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "TWO_FACTOR_ALREADY_ENABLED"})
+	case errors.Is(err, errors.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(c.Request.Context(), "Two-factor operation failed", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+func twoFactorTokensResponse(resp *services.TwoFactorResponse) TwoFactorTokensResponse {
+	return TwoFactorTokensResponse{
+		AccessToken:   resp.AccessToken,
+		RefreshToken:  resp.RefreshToken,
+		ExpiresAt:     resp.ExpiresAt,
+		TokenType:     "Bearer",
+		RecoveryCodes: resp.RecoveryCodes,
+	}
+}
@@ -0,0 +1,434 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/uptime"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// Defaults of new uptime checks
+	defaultUptimePath             = "/"
+	defaultUptimeExpectedStatus   = http.StatusOK
+	defaultUptimeIntervalSeconds  = 60
+	defaultUptimeTimeoutSeconds   = 10
+	defaultUptimeFailureThreshold = 2
+
+	// maxUptimeResultsLimit is the most results one listing returns
+	maxUptimeResultsLimit = 1000
+)
+
+// CreateUptimeCheckRequest is the request body for creating an uptime
+// check
+type CreateUptimeCheckRequest struct {
+	Environment      string `json:"environment" binding:"required"`
+	Domain           string `json:"domain,omitempty"` // A custom domain of the service; the service inside the cluster when empty
+	Path             string `json:"path,omitempty"`
+	ExpectedStatus   *int   `json:"expected_status,omitempty"`
+	IntervalSeconds  *int   `json:"interval_seconds,omitempty"`
+	TimeoutSeconds   *int   `json:"timeout_seconds,omitempty"`
+	FailureThreshold *int   `json:"failure_threshold,omitempty"`
+	Enabled          *bool  `json:"enabled,omitempty"`
+}
+
+// UpdateUptimeCheckRequest is the request body for updating an uptime
+// check. Fields left out are unchanged.
+type UpdateUptimeCheckRequest struct {
+	Path             *string `json:"path,omitempty"`
+	ExpectedStatus   *int    `json:"expected_status,omitempty"`
+	IntervalSeconds  *int    `json:"interval_seconds,omitempty"`
+	TimeoutSeconds   *int    `json:"timeout_seconds,omitempty"`
+	FailureThreshold *int    `json:"failure_threshold,omitempty"`
+	Enabled          *bool   `json:"enabled,omitempty"`
+}
+
+// ListUptimeChecks lists the uptime checks of a service with their latest
+// state and uptime percentages
+// GET /v1/services/:id/uptime-checks
+func (h *Handler) ListUptimeChecks(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	if !h.authorizeServiceEnvironment(c, serviceID, nil) {
+		return
+	}
+
+	checks, err := h.repos.UptimeChecks.ListByService(ctx, serviceID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list uptime checks",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list uptime checks"})
+		return
+	}
+
+	uptimes, err := h.repos.UptimeChecks.UptimeByService(ctx, serviceID, time.Now())
+	if err != nil {
+		h.logger.Error(ctx, "Failed to compute uptime",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute uptime"})
+		return
+	}
+	for _, check := range checks {
+		check.Uptime = uptimes[check.ID]
+		if check.Uptime == nil {
+			check.Uptime = &types.UptimePercentages{}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checks": checks})
+}
+
+// CreateUptimeCheck adds an uptime check to a service in an environment
+// POST /v1/services/:id/uptime-checks
+func (h *Handler) CreateUptimeCheck(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+
+	var req CreateUptimeCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return
+	}
+	env, err := h.repos.Environments.GetByProjectAndName(project.ID, req.Environment)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found", "environment": req.Environment})
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
+	}
+
+	check := &types.UptimeCheck{
+		ServiceID:        service.ID,
+		EnvironmentID:    env.ID,
+		Environment:      env.Name,
+		Path:             req.Path,
+		ExpectedStatus:   defaultUptimeExpectedStatus,
+		IntervalSeconds:  defaultUptimeIntervalSeconds,
+		TimeoutSeconds:   defaultUptimeTimeoutSeconds,
+		FailureThreshold: defaultUptimeFailureThreshold,
+		Enabled:          req.Enabled == nil || *req.Enabled,
+	}
+	if check.Path == "" {
+		check.Path = defaultUptimePath
+	}
+	if req.ExpectedStatus != nil {
+		check.ExpectedStatus = *req.ExpectedStatus
+	}
+	if req.IntervalSeconds != nil {
+		check.IntervalSeconds = *req.IntervalSeconds
+	}
+	if req.TimeoutSeconds != nil {
+		check.TimeoutSeconds = *req.TimeoutSeconds
+	}
+	if req.FailureThreshold != nil {
+		check.FailureThreshold = *req.FailureThreshold
+	}
+	if userEmail, ok := c.Get("user_email"); ok {
+		check.CreatedBy = fmt.Sprintf("%v", userEmail)
+	}
+
+	if req.Domain != "" {
+		domains, err := h.repos.CustomDomains.GetByServiceAndEnvironment(ctx, service.ID.String(), env.ID.String())
+		if err != nil {
+			h.logger.Error(ctx, "Failed to list custom domains", logging.Error("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list custom domains"})
+			return
+		}
+		for i := range domains {
+			if strings.EqualFold(domains[i].Domain, req.Domain) {
+				check.CustomDomainID = &domains[i].ID
+				check.Domain = domains[i].Domain
+				break
+			}
+		}
+		if check.CustomDomainID == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Domain is not a custom domain of the service in this environment", "domain": req.Domain})
+			return
+		}
+	}
+
+	if err := validateUptimeCheck(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repos.UptimeChecks.Create(ctx, check); err != nil {
+		h.logger.Error(ctx, "Failed to create uptime check",
+			logging.String("service_id", service.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create uptime check"})
+		return
+	}
+
+	h.auditUptimeCheck(c, project, service, check, "service.uptime_check_created")
+	c.JSON(http.StatusCreated, check)
+}
+
+// UpdateUptimeCheck changes the settings of an uptime check. Its
+// environment and domain cannot change.
+// PATCH /v1/services/:id/uptime-checks/:check_id
+func (h *Handler) UpdateUptimeCheck(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	checkID, err := uuid.Parse(c.Param("check_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid uptime check ID"})
+		return
+	}
+
+	var req UpdateUptimeCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service, project, check, ok := h.loadUptimeCheck(c, serviceID, checkID)
+	if !ok {
+		return
+	}
+
+	if req.Path != nil {
+		check.Path = *req.Path
+	}
+	if req.ExpectedStatus != nil {
+		check.ExpectedStatus = *req.ExpectedStatus
+	}
+	if req.IntervalSeconds != nil {
+		check.IntervalSeconds = *req.IntervalSeconds
+	}
+	if req.TimeoutSeconds != nil {
+		check.TimeoutSeconds = *req.TimeoutSeconds
+	}
+	if req.FailureThreshold != nil {
+		check.FailureThreshold = *req.FailureThreshold
+	}
+	if req.Enabled != nil {
+		check.Enabled = *req.Enabled
+	}
+
+	if err := validateUptimeCheck(check); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.repos.UptimeChecks.Update(ctx, check); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Uptime check not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to update uptime check",
+			logging.String("check_id", check.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update uptime check"})
+		return
+	}
+
+	h.auditUptimeCheck(c, project, service, check, "service.uptime_check_updated")
+	c.JSON(http.StatusOK, check)
+}
+
+// DeleteUptimeCheck removes an uptime check and its results
+// DELETE /v1/services/:id/uptime-checks/:check_id
+func (h *Handler) DeleteUptimeCheck(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	checkID, err := uuid.Parse(c.Param("check_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid uptime check ID"})
+		return
+	}
+
+	service, project, check, ok := h.loadUptimeCheck(c, serviceID, checkID)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.UptimeChecks.Delete(ctx, service.ID, check.ID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Uptime check not found"})
+			return
+		}
+		h.logger.Error(ctx, "Failed to delete uptime check",
+			logging.String("check_id", check.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete uptime check"})
+		return
+	}
+
+	h.auditUptimeCheck(c, project, service, check, "service.uptime_check_deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Uptime check deleted"})
+}
+
+// ListUptimeCheckResults lists the latest results of an uptime check,
+// newest first. since is an RFC 3339 time or a duration before now, and
+// defaults to 24h.
+// GET /v1/services/:id/uptime-checks/:check_id/results
+func (h *Handler) ListUptimeCheckResults(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
+		return
+	}
+	checkID, err := uuid.Parse(c.Param("check_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid uptime check ID"})
+		return
+	}
+
+	now := time.Now()
+	since, err := parseLogSince(c.DefaultQuery("since", "24h"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if oldest := now.Add(-uptime.ResultRetention); since.Before(oldest) {
+		since = &oldest
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	if limit > maxUptimeResultsLimit {
+		limit = maxUptimeResultsLimit
+	}
+
+	_, _, check, ok := h.loadUptimeCheck(c, serviceID, checkID)
+	if !ok {
+		return
+	}
+
+	results, err := h.repos.UptimeChecks.ListResults(ctx, check.ID, *since, limit)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list uptime check results",
+			logging.String("check_id", check.ID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list uptime check results"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// loadUptimeCheck loads an uptime check of a service and authorizes access
+// to its environment. It writes the error response and returns false when
+// that fails.
+func (h *Handler) loadUptimeCheck(c *gin.Context, serviceID, checkID uuid.UUID) (*types.Service, *types.Project, *types.UptimeCheck, bool) {
+	ctx := c.Request.Context()
+
+	service, err := h.repos.Services.GetByID(serviceID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return nil, nil, nil, false
+	}
+	project, err := h.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return nil, nil, nil, false
+	}
+	check, err := h.repos.UptimeChecks.GetByID(ctx, service.ID, checkID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Uptime check not found"})
+		return nil, nil, nil, false
+	}
+	if !h.authorizeEnvironment(c, project.ID, &check.EnvironmentID) {
+		return nil, nil, nil, false
+	}
+	return service, project, check, true
+}
+
+// validateUptimeCheck checks the settings of an uptime check
+func validateUptimeCheck(check *types.UptimeCheck) error {
+	if !strings.HasPrefix(check.Path, "/") || len(check.Path) > 2048 || strings.ContainsAny(check.Path, " \t\r\n#") {
+		return fmt.Errorf("path must start with / and be at most 2048 characters without spaces or a fragment")
+	}
+	if check.ExpectedStatus < 100 || check.ExpectedStatus > 599 {
+		return fmt.Errorf("expected_status must be an HTTP status between 100 and 599")
+	}
+	if check.IntervalSeconds < 30 || check.IntervalSeconds > 3600 {
+		return fmt.Errorf("interval_seconds must be between 30 and 3600")
+	}
+	if check.TimeoutSeconds < 1 || check.TimeoutSeconds > 60 || check.TimeoutSeconds >= check.IntervalSeconds {
+		return fmt.Errorf("timeout_seconds must be between 1 and 60 and shorter than interval_seconds")
+	}
+	if check.FailureThreshold < 1 || check.FailureThreshold > 10 {
+		return fmt.Errorf("failure_threshold must be between 1 and 10")
+	}
+	return nil
+}
+
+// auditUptimeCheck records a change to an uptime check of a service
+func (h *Handler) auditUptimeCheck(c *gin.Context, project *types.Project, service *types.Service, check *types.UptimeCheck, action string) {
+	userID, _ := c.Get("user_id")
+	userEmail, _ := c.Get("user_email")
+	userRole, _ := c.Get("user_role")
+
+	var actorID *uuid.UUID
+	if parsed, err := uuid.Parse(fmt.Sprintf("%v", userID)); err == nil {
+		actorID = &parsed
+	}
+
+	h.repos.AuditLogs.Log(c.Request.Context(), &types.AuditLog{
+		ActorID:       actorID,
+		ActorEmail:    fmt.Sprintf("%v", userEmail),
+		ActorRole:     types.Role(fmt.Sprintf("%v", userRole)),
+		Action:        action,
+		ResourceType:  "service",
+		ResourceID:    service.ID.String(),
+		ResourceName:  service.Name,
+		ProjectID:     &project.ID,
+		EnvironmentID: &check.EnvironmentID,
+		Outcome:       "success",
+		Context: map[string]interface{}{
+			"uptime_check_id":   check.ID.String(),
+			"domain":            check.Domain,
+			"path":              check.Path,
+			"expected_status":   check.ExpectedStatus,
+			"interval_seconds":  check.IntervalSeconds,
+			"failure_threshold": check.FailureThreshold,
+			"enabled":           check.Enabled,
+		},
+	})
+}
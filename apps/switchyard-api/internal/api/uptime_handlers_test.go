@@ -0,0 +1,43 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateUptimeCheck(t *testing.T) {
+	valid := func() *types.UptimeCheck {
+		return &types.UptimeCheck{
+			Path:             defaultUptimePath,
+			ExpectedStatus:   defaultUptimeExpectedStatus,
+			IntervalSeconds:  defaultUptimeIntervalSeconds,
+			TimeoutSeconds:   defaultUptimeTimeoutSeconds,
+			FailureThreshold: defaultUptimeFailureThreshold,
+		}
+	}
+	if err := validateUptimeCheck(valid()); err != nil {
+		t.Errorf("defaults are invalid: %v", err)
+	}
+
+	withQuery := valid()
+	withQuery.Path = "/healthz?deep=1"
+	if err := validateUptimeCheck(withQuery); err != nil {
+		t.Errorf("validateUptimeCheck() with a query error = %v", err)
+	}
+
+	for name, change := range map[string]func(*types.UptimeCheck){
+		"relative path":         func(u *types.UptimeCheck) { u.Path = "healthz" },
+		"path with a fragment":  func(u *types.UptimeCheck) { u.Path = "/#top" },
+		"status out of range":   func(u *types.UptimeCheck) { u.ExpectedStatus = 600 },
+		"interval too short":    func(u *types.UptimeCheck) { u.IntervalSeconds = 10 },
+		"timeout past interval": func(u *types.UptimeCheck) { u.IntervalSeconds, u.TimeoutSeconds = 30, 30 },
+		"no failures allowed":   func(u *types.UptimeCheck) { u.FailureThreshold = 0 },
+	} {
+		check := valid()
+		change(check)
+		if err := validateUptimeCheck(check); err == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+	}
+}
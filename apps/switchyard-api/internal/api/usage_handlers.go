@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/pricing"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/servicemetrics"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -52,15 +55,6 @@ type CostBreakdown struct {
 	GrandTotal  float64        `json:"grand_total"`
 }
 
-// Plan pricing constants (in a real system, these would come from a billing service)
-const (
-	proPlanBase      = 20.00
-	computePerGBHour = 0.05
-	buildPerMinute   = 0.01
-	storagePerGB     = 0.25
-	bandwidthPerGB   = 0.10
-)
-
 // Included resources per plan
 const (
 	includedCompute   = 500.0 // GB-hours
@@ -158,10 +152,10 @@ func (h *Handler) calculateUsage(ctx context.Context, periodStart, periodEnd tim
 	bandwidthUsed := float64(len(services)) * 10.0 * (daysInPeriod / 30.0)
 
 	// Calculate overage costs
-	computeCost := calculateOverage(computeUsed, includedCompute, computePerGBHour)
-	buildCost := calculateOverage(totalBuildMinutes, includedBuild, buildPerMinute)
-	storageCost := calculateOverage(storageUsed, includedStorage, storagePerGB)
-	bandwidthCost := calculateOverage(bandwidthUsed, includedBandwidth, bandwidthPerGB)
+	computeCost := calculateOverage(computeUsed, includedCompute, pricing.ComputePerGBHour)
+	buildCost := calculateOverage(totalBuildMinutes, includedBuild, pricing.BuildPerMinute)
+	storageCost := calculateOverage(storageUsed, includedStorage, pricing.StoragePerGB)
+	bandwidthCost := calculateOverage(bandwidthUsed, includedBandwidth, pricing.BandwidthPerGB)
 
 	totalCost := computeCost + buildCost + storageCost + bandwidthCost
 
@@ -213,8 +207,8 @@ func (h *Handler) calculateUsage(ctx context.Context, periodStart, periodEnd tim
 		PeriodEnd:   periodEnd.Format("2006-01-02"),
 		Metrics:     metrics,
 		TotalCost:   roundToTwoDecimals(totalCost),
-		PlanBase:    proPlanBase,
-		GrandTotal:  roundToTwoDecimals(proPlanBase + totalCost),
+		PlanBase:    pricing.ProPlanBase,
+		GrandTotal:  roundToTwoDecimals(pricing.ProPlanBase + totalCost),
 		PlanName:    "Pro",
 	}, nil
 }
@@ -376,52 +370,74 @@ func (h *Handler) GetRealTimeMetrics(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// GetServiceResourceMetrics returns metrics for a specific service
-// GET /v1/services/:id/metrics
+// GetServiceResourceMetrics returns the CPU, memory, restart and traffic
+// series of a service in an environment over a window
+// GET /v1/services/:id/metrics?env=production&window=1h
 func (h *Handler) GetServiceResourceMetrics(c *gin.Context) {
 	ctx := c.Request.Context()
-	serviceIDStr := c.Param("id")
+	envName := c.DefaultQuery("env", "development")
 
-	// Parse service ID
-	serviceID, err := uuid.Parse(serviceIDStr)
+	serviceID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service ID"})
 		return
 	}
 
-	// Get service
+	window, err := servicemetrics.ParseWindow(c.Query("window"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.serviceMetrics == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service metrics are not configured"})
+		return
+	}
+
 	svc, err := h.repos.Services.GetByID(serviceID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
 	}
 
-	namespace := getServiceNamespace(svc)
-	response := ServiceMetrics{
-		ServiceID:   svc.ID.String(),
-		ServiceName: svc.Name,
-		Namespace:   namespace,
-		Status:      "running",
+	project, err := h.repos.Projects.GetByID(ctx, svc.ProjectID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get project", logging.Error("db_error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		return
 	}
 
-	// Get metrics if K8s client is available
-	if h.k8sClient != nil {
-		metrics, err := h.k8sClient.GetServiceMetrics(ctx, namespace, svc.Name)
-		if err == nil && metrics != nil {
-			response.PodCount = metrics.PodCount
-			response.CPUUsage = float64(metrics.TotalCPU)
-			response.MemoryUsage = float64(metrics.TotalMemory) / (1024 * 1024) // Convert to MB
-		} else {
-			response.Status = "unknown"
-			h.logger.Warn(ctx, "Failed to get service metrics",
-				logging.String("service_id", serviceID.String()),
-				logging.Error("error", err))
-		}
-	} else {
-		response.Status = "metrics_unavailable"
+	env, err := h.repos.Environments.GetByProjectAndName(project.ID, envName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Environment not found"})
+		return
+	}
+	if !h.authorizeEnvironment(c, project.ID, &env.ID) {
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	namespace := env.KubeNamespace
+	if namespace == "" {
+		namespace = fmt.Sprintf("enclii-%s-%s", project.Slug, envName)
+	}
+
+	report, err := h.serviceMetrics.Collect(ctx, servicemetrics.Target{Namespace: namespace, App: svc.Name}, window)
+	if errors.Is(err, servicemetrics.ErrUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service metrics are not configured"})
+		return
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Failed to collect service metrics",
+			logging.String("service_id", serviceID.String()),
+			logging.Error("error", err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to collect service metrics"})
+		return
+	}
+	report.ServiceID = svc.ID
+	report.ServiceName = svc.Name
+	report.Environment = envName
+
+	c.JSON(http.StatusOK, report)
 }
 
 // calculateCostBreakdown computes cost breakdown from actual data
@@ -441,7 +457,7 @@ func (h *Handler) calculateCostBreakdown(ctx context.Context, periodStart, perio
 	return &CostBreakdown{
 		PeriodStart: usage.PeriodStart,
 		PeriodEnd:   usage.PeriodEnd,
-		PlanBase:    proPlanBase,
+		PlanBase:    pricing.ProPlanBase,
 		PlanName:    "Pro",
 		Categories:  categories,
 		TotalUsage:  usage.TotalCost,
@@ -2,15 +2,17 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/jobs"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
@@ -81,6 +83,18 @@ func (h *Handler) handleGitHubPullRequest(c *gin.Context, ctx context.Context, b
 		return
 	}
 
+	// A suspended team's previews are not built, but can still be closed
+	if event.Action != "closed" && h.teamSuspension(ctx, service.ProjectID) != nil {
+		h.logger.Info(ctx, "Skipping preview - team is suspended",
+			logging.String("service", service.Name),
+			logging.Int("pr_number", event.Number))
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Preview skipped: team is suspended",
+			"action":  event.Action,
+		})
+		return
+	}
+
 	switch event.Action {
 	case "opened", "reopened":
 		h.createPreviewEnvironment(c, ctx, service, &event)
@@ -103,6 +117,9 @@ func (h *Handler) createPreviewEnvironment(c *gin.Context, ctx context.Context,
 	if existing != nil {
 		// Reopen existing preview
 		if existing.Status == types.PreviewStatusClosed {
+			if !h.checkPreviewQuota(c, service.ProjectID) {
+				return
+			}
 			existing.Status = types.PreviewStatusPending
 			existing.CommitSHA = event.PullRequest.Head.SHA
 			existing.ClosedAt = nil
@@ -137,6 +154,10 @@ func (h *Handler) createPreviewEnvironment(c *gin.Context, ctx context.Context,
 		return
 	}
 
+	if !h.checkPreviewQuota(c, service.ProjectID) {
+		return
+	}
+
 	// Generate preview subdomain: pr-{number}-{service-slug}.preview.enclii.app
 	serviceSlug := strings.ToLower(strings.ReplaceAll(service.Name, " ", "-"))
 	serviceSlug = strings.ToLower(strings.ReplaceAll(serviceSlug, "_", "-"))
@@ -267,8 +288,7 @@ func (h *Handler) closePreviewEnvironment(c *gin.Context, ctx context.Context, s
 		logging.Int("pr_number", event.Number),
 		logging.String("reason", statusMessage))
 
-	// TODO: Trigger cleanup of preview resources (deployment, ingress, etc.)
-	go h.cleanupPreviewResources(preview)
+	h.schedulePreviewCleanup(ctx, preview)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Preview environment closed",
@@ -285,6 +305,15 @@ func (h *Handler) triggerPreviewBuild(service *types.Service, preview *types.Pre
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
 
+	// Start the preview's database so it is filled while the image builds
+	if h.previewDatabases != nil {
+		if _, err := h.previewDatabases.Provision(ctx, preview); err != nil {
+			h.logger.Warn(ctx, "Failed to provision preview database",
+				logging.String("preview_id", preview.ID.String()),
+				logging.Error("error", err))
+		}
+	}
+
 	// Acquire build semaphore (blocks if another build is running)
 	h.logger.Info(ctx, "Preview build waiting for build slot",
 		logging.String("preview_id", preview.ID.String()))
@@ -383,21 +412,13 @@ func (h *Handler) triggerPreviewBuild(service *types.Service, preview *types.Pre
 		h.logger.Warn(ctx, "Failed to link deployment to preview", logging.Error("error", err))
 	}
 
-	// Generate preview-specific Ingress with the preview subdomain
-	previewDomains := []types.CustomDomain{
-		{
-			Domain:     preview.PreviewSubdomain + ".preview.enclii.app",
-			TLSEnabled: true,
-			TLSIssuer:  "letsencrypt-prod",
-		},
-	}
-
 	// Reconcile preview deployment using the service reconciler
 	reconcileReq := &previewReconcileRequest{
+		Preview:       preview,
 		Service:       service,
 		Release:       release,
 		Deployment:    deployment,
-		CustomDomains: previewDomains,
+		CustomDomains: previewDomains(preview),
 		Namespace:     previewNamespace,
 	}
 
@@ -423,8 +444,20 @@ func (h *Handler) triggerPreviewBuild(service *types.Service, preview *types.Pre
 	go h.postGitHubPRComment(service, preview)
 }
 
+// previewDomains returns the preview-specific Ingress domain of a preview
+func previewDomains(preview *types.PreviewEnvironment) []types.CustomDomain {
+	return []types.CustomDomain{
+		{
+			Domain:     preview.PreviewSubdomain + ".preview.enclii.app",
+			TLSEnabled: true,
+			TLSIssuer:  "letsencrypt-prod",
+		},
+	}
+}
+
 // previewReconcileRequest holds data needed to reconcile a preview deployment
 type previewReconcileRequest struct {
+	Preview       *types.PreviewEnvironment
 	Service       *types.Service
 	Release       *types.Release
 	Deployment    *types.Deployment
@@ -468,13 +501,33 @@ func (h *Handler) reconcilePreviewDeployment(ctx context.Context, req *previewRe
 		}
 	}
 
+	// Apply the service's preview overrides on top
+	overrides, err := h.repos.EnvVars.ListPreview(ctx, req.Service.ID)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get preview env var overrides", logging.Error("error", err))
+	}
+	for _, ev := range overrides {
+		reconcileReq.EnvVars[ev.Key] = ev.Value
+	}
+
 	// Add preview-specific env vars
 	reconcileReq.EnvVars["ENCLII_PREVIEW_URL"] = "https://" + req.CustomDomains[0].Domain
 	reconcileReq.EnvVars["ENCLII_IS_PREVIEW"] = "true"
 
+	// Bind the preview's own database in place of the parent's
+	if h.previewDatabases != nil && req.Preview != nil {
+		databaseEnv, err := h.previewDatabases.EnvVars(ctx, req.Preview)
+		if err != nil {
+			h.logger.Warn(ctx, "Failed to get preview database binding", logging.Error("error", err))
+		}
+		for key, value := range databaseEnv {
+			reconcileReq.EnvVars[key] = value
+		}
+	}
+
 	// Schedule reconciliation
 	if err := h.reconciler.ScheduleReconciliation(req.Deployment.ID.String(), 1); err != nil {
-		h.logger.Warn(context.Background(), "Reconciler queue full, work queued for retry",
+		h.logger.Warn(context.Background(), "Failed to queue reconciliation, pending deployment scan will retry",
 			logging.String("deployment_id", req.Deployment.ID.String()),
 			logging.Error("queue_error", err))
 	}
@@ -485,63 +538,93 @@ func (h *Handler) reconcilePreviewDeployment(ctx context.Context, req *previewRe
 	}
 }
 
-// cleanupPreviewResources cleans up resources for a closed preview environment
-func (h *Handler) cleanupPreviewResources(preview *types.PreviewEnvironment) {
-	ctx := context.Background()
-	h.logger.Info(ctx, "Starting cleanup for preview environment",
-		logging.String("preview_id", preview.ID.String()),
-		logging.String("subdomain", preview.PreviewSubdomain))
+// previewCleanupJob is the background job that cleans up a closed preview
+const previewCleanupJob = "preview.cleanup"
 
-	// Get the service for namespace calculation
-	service, err := h.repos.Services.GetByID(preview.ServiceID)
-	if err != nil {
-		h.logger.Error(ctx, "Failed to get service for preview cleanup",
-			logging.String("preview_id", preview.ID.String()),
-			logging.Error("error", err))
+type previewCleanupPayload struct {
+	PreviewID uuid.UUID `json:"preview_id"`
+}
+
+// schedulePreviewCleanup queues the cleanup of a closed preview environment
+// as a background job, so it survives restarts and is retried on failure
+func (h *Handler) schedulePreviewCleanup(ctx context.Context, preview *types.PreviewEnvironment) {
+	if h.previewCleaner == nil {
+		h.logger.Warn(ctx, "Preview cleanup not enabled, leaving preview resources in place",
+			logging.String("preview_id", preview.ID.String()))
+		return
+	}
+	if h.jobRunner == nil {
+		go func() {
+			if err := h.cleanupPreviewResources(context.Background(), preview.ID); err != nil {
+				h.logger.Error(context.Background(), "Failed to clean up preview resources",
+					logging.String("preview_id", preview.ID.String()),
+					logging.Error("error", err))
+			}
+		}()
 		return
 	}
 
-	// Calculate preview namespace
-	previewNamespace := "enclii-preview-" + preview.PreviewSubdomain
-
-	// Delete Kubernetes resources using the service reconciler
-	if err := h.serviceReconciler.Delete(ctx, previewNamespace, service.Name); err != nil {
-		h.logger.Error(ctx, "Failed to delete K8s resources for preview",
+	if _, err := h.jobRunner.Enqueue(ctx, previewCleanupJob, previewCleanupPayload{PreviewID: preview.ID}); err != nil {
+		h.logger.Error(ctx, "Failed to queue preview cleanup; the cleanup sweep picks the preview up after its TTL",
 			logging.String("preview_id", preview.ID.String()),
-			logging.String("namespace", previewNamespace),
 			logging.Error("error", err))
-		// Continue cleanup even if this fails
-	} else {
-		h.logger.Info(ctx, "Deleted K8s deployment and service for preview",
-			logging.String("preview_id", preview.ID.String()),
-			logging.String("namespace", previewNamespace))
 	}
+}
 
-	// Delete the preview namespace itself (if empty)
-	if err := h.deletePreviewNamespace(ctx, previewNamespace); err != nil {
-		h.logger.Warn(ctx, "Failed to delete preview namespace (may not be empty)",
-			logging.String("namespace", previewNamespace),
-			logging.Error("error", err))
+// runPreviewCleanupJob runs a queued preview cleanup
+func (h *Handler) runPreviewCleanupJob(ctx context.Context, job *types.BackgroundJob) error {
+	var payload previewCleanupPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	return h.cleanupPreviewResources(ctx, payload.PreviewID)
+}
+
+// cleanupPreviewResources stops the workload of a closed preview environment.
+// The namespace, releases and images are kept until the project's preview TTL
+// passes, or torn down right away when the TTL is 0.
+func (h *Handler) cleanupPreviewResources(ctx context.Context, previewID uuid.UUID) error {
+	if h.previewCleaner == nil {
+		return jobs.Permanent(fmt.Errorf("preview cleanup is not enabled"))
 	}
 
-	// Delete the preview ingress if it exists
-	if err := h.deletePreviewIngress(ctx, previewNamespace, service.Name); err != nil {
-		h.logger.Warn(ctx, "Failed to delete preview ingress",
-			logging.String("namespace", previewNamespace),
-			logging.Error("error", err))
+	preview, err := h.repos.PreviewEnvironments.GetByID(ctx, previewID)
+	if err == sql.ErrNoRows {
+		return jobs.Permanent(fmt.Errorf("preview %s not found", previewID))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get preview: %w", err)
+	}
+	if preview.Status != types.PreviewStatusClosed {
+		// Reopened since the job was queued
+		return nil
 	}
 
-	h.logger.Info(ctx, "Preview environment cleanup completed",
-		logging.String("preview_id", preview.ID.String()),
-		logging.String("namespace", previewNamespace))
-}
+	project, err := h.repos.Projects.GetByID(ctx, preview.ProjectID)
+	if err == sql.ErrNoRows {
+		return jobs.Permanent(fmt.Errorf("project of preview %s not found", previewID))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get project: %w", err)
+	}
 
-// deletePreviewNamespace deletes the preview-specific namespace
-func (h *Handler) deletePreviewNamespace(ctx context.Context, namespace string) error {
-	return h.k8sClient.Clientset.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{})
-}
+	ttlDays := h.previewCleaner.TTLDays(project)
+	if ttlDays > 0 {
+		if err := h.previewCleaner.StopWorkload(ctx, preview); err != nil {
+			return fmt.Errorf("failed to stop preview workload: %w", err)
+		}
+		h.logger.Info(ctx, "Stopped preview workload, teardown scheduled after TTL",
+			logging.String("preview_id", preview.ID.String()),
+			logging.Int("ttl_days", ttlDays))
+		return nil
+	}
 
-// deletePreviewIngress deletes the ingress for a preview environment
-func (h *Handler) deletePreviewIngress(ctx context.Context, namespace, serviceName string) error {
-	return h.k8sClient.Clientset.NetworkingV1().Ingresses(namespace).Delete(ctx, serviceName, metav1.DeleteOptions{})
+	plan, err := h.previewCleaner.Plan(ctx, preview, ttlDays)
+	if err != nil {
+		return fmt.Errorf("failed to plan preview teardown: %w", err)
+	}
+	if result := h.previewCleaner.Teardown(ctx, plan); len(result.Errors) > 0 {
+		return fmt.Errorf("preview teardown incomplete: %s", strings.Join(result.Errors, "; "))
+	}
+	return nil
 }
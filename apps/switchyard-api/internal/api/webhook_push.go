@@ -5,11 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logging"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
@@ -65,23 +62,16 @@ func (h *Handler) handleGitHubPush(c *gin.Context, ctx context.Context, body []b
 		return
 	}
 
-	// Only trigger builds for pushes to main/master branch
-	branch := extractBranchName(event.Ref)
-	if branch != "main" && branch != "master" {
-		h.logger.Info(ctx, "Ignoring push to non-main branch",
-			logging.String("branch", branch),
-			logging.String("repo", event.Repository.FullName))
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Push to non-main branch ignored",
-			"branch":  branch,
-		})
-		return
+	// Which branches and tags build is up to the auto-deploy rules of each service
+	branch, tag := extractBranchName(event.Ref), extractTagName(event.Ref)
+	if tag != "" {
+		branch = ""
 	}
 
-	// Skip if this is a branch deletion
+	// Skip if this is a branch or tag deletion
 	if event.Deleted {
-		h.logger.Info(ctx, "Ignoring branch deletion event",
-			logging.String("branch", branch))
+		h.logger.Info(ctx, "Ignoring ref deletion event",
+			logging.String("ref", event.Ref))
 		c.JSON(http.StatusOK, gin.H{"message": "Branch deletion ignored"})
 		return
 	}
@@ -131,93 +121,28 @@ func (h *Handler) handleGitHubPush(c *gin.Context, ctx context.Context, body []b
 		logging.String("repo", event.Repository.FullName),
 		logging.String("git_sha", gitSHA),
 		logging.String("branch", branch),
+		logging.String("tag", tag),
 		logging.String("pusher", event.Pusher.Name),
 		logging.String("commit_message", truncateString(event.HeadCommit.Message, 100)))
 
-	// Trigger builds for matching services (filtered by watch paths if configured)
-	type buildResult struct {
-		Service   string `json:"service"`
-		ReleaseID string `json:"release_id"`
-		Status    string `json:"status"`
-		Skipped   bool   `json:"skipped,omitempty"`
-		Reason    string `json:"reason,omitempty"`
-	}
-	var results []buildResult
+	// Fan out into a build per affected service (filtered by auto-deploy rules,
+	// watch paths or build roots)
+	results := h.dispatchPushBuilds(ctx, services, &pushBuild{
+		Repository:   event.Repository.FullName,
+		GitSHA:       gitSHA,
+		Branch:       branch,
+		Tag:          tag,
+		ChangedFiles: changedFiles,
+		Trigger: buildTrigger{
+			CommitMessage: event.HeadCommit.Message,
+			TriggeredBy:   event.Pusher.Name,
+		},
+	})
 	var skippedCount int
-
-	for _, service := range services {
-		// Check if service should be rebuilt based on changed files and WatchPaths
-		if len(service.WatchPaths) > 0 && !shouldRebuildService(service.WatchPaths, changedFiles) {
-			h.logger.Info(ctx, "Skipping build for service - no relevant file changes",
-				logging.String("service", service.Name),
-				logging.String("watch_paths", strings.Join(service.WatchPaths, ", ")))
-			results = append(results, buildResult{
-				Service: service.Name,
-				Status:  "skipped",
-				Skipped: true,
-				Reason:  "No files changed in watched paths",
-			})
+	for _, result := range results {
+		if result.Skipped {
 			skippedCount++
-			continue
-		}
-		// Create release record for this service
-		release := &types.Release{
-			ID:        uuid.New(),
-			ServiceID: service.ID,
-			Version:   "v" + time.Now().Format("20060102-150405") + "-" + gitSHA[:7],
-			ImageURI:  h.config.Registry + "/" + service.Name + ":" + gitSHA[:7],
-			GitSHA:    gitSHA,
-			Status:    types.ReleaseStatusBuilding,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
-
-		if err := h.repos.Releases.Create(release); err != nil {
-			h.logger.Error(ctx, "Failed to create release for service",
-				logging.String("service", service.Name),
-				logging.Error("db_error", err))
-			results = append(results, buildResult{
-				Service: service.Name,
-				Status:  "failed: " + err.Error(),
-			})
-			continue
 		}
-
-		// Trigger async build (routes to Roundhouse or in-process based on config)
-		h.triggerBuildAsync(service, release, gitSHA, branch)
-
-		h.logger.Info(ctx, "Build triggered for service",
-			logging.String("service_id", service.ID.String()),
-			logging.String("service_name", service.Name),
-			logging.String("release_id", release.ID.String()))
-
-		// Log webhook event to Activity feed for dashboard visibility (async to not block response)
-		go h.repos.AuditLogs.Log(context.Background(), &types.AuditLog{
-			ActorID:      nil, // System action (webhook)
-			ActorEmail:   "github-webhook@system.enclii.dev",
-			ActorRole:    types.RoleSystem,
-			Action:       "webhook.build_triggered",
-			ResourceType: "service",
-			ResourceID:   service.ID.String(),
-			ResourceName: service.Name,
-			ProjectID:    &service.ProjectID,
-			Outcome:      "success",
-			Context: map[string]interface{}{
-				"event_type": "push",
-				"commit_sha": gitSHA,
-				"branch":     branch,
-				"repository": event.Repository.FullName,
-				"release_id": release.ID.String(),
-				"pusher":     event.Pusher.Name,
-				"trigger":    "github_push",
-			},
-		})
-
-		results = append(results, buildResult{
-			Service:   service.Name,
-			ReleaseID: release.ID.String(),
-			Status:    "building",
-		})
 	}
 
 	triggeredCount := len(results) - skippedCount
@@ -226,6 +151,7 @@ func (h *Handler) handleGitHubPush(c *gin.Context, ctx context.Context, body []b
 		"repo":            event.Repository.FullName,
 		"git_sha":         gitSHA,
 		"branch":          branch,
+		"tag":             tag,
 		"builds":          results,
 		"service_count":   len(results),
 		"triggered_count": triggeredCount,
@@ -38,6 +38,15 @@ func extractBranchName(ref string) string {
 	return ref
 }
 
+// extractTagName extracts the tag name from a git ref, or returns "" when
+// the ref is not a tag
+func extractTagName(ref string) string {
+	if strings.HasPrefix(ref, "refs/tags/") {
+		return strings.TrimPrefix(ref, "refs/tags/")
+	}
+	return ""
+}
+
 // truncateString truncates a string to maxLen characters, adding "..." if truncated
 func truncateString(s string, maxLen int) string {
 	// Replace newlines with spaces for log readability
@@ -100,6 +100,12 @@ func (m *Middleware) AuditMiddleware() gin.HandlerFunc {
 			},
 		}
 
+		// Record the operator behind an impersonated session
+		if impersonatorID, ok := c.Get("impersonator_id"); ok {
+			auditLog.Context["impersonator_id"] = impersonatorID
+			auditLog.Context["impersonated_by"] = c.GetString("impersonator_email")
+		}
+
 		// Extract project/environment IDs if present
 		if projectID := c.Param("project_id"); projectID != "" {
 			if pid, err := uuid.Parse(projectID); err == nil {
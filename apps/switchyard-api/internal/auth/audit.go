@@ -27,6 +27,9 @@ const (
 	EventLogout         AuthEvent = "auth.logout"
 	EventSessionRevoked AuthEvent = "auth.session.revoked"
 
+	// Impersonation events
+	EventImpersonationStarted AuthEvent = "auth.impersonation.started"
+
 	// External auth events
 	EventExternalTokenValidated AuthEvent = "auth.external.validated"
 	EventExternalUserCreated    AuthEvent = "auth.external.user_created"
@@ -244,6 +247,23 @@ func LogSessionRevoked(userID uuid.UUID, sessionID, reason string) {
 	})
 }
 
+// LogImpersonationStarted logs when a platform operator is issued a token
+// acting as a user
+func LogImpersonationStarted(userID uuid.UUID, impersonation *Impersonation, sessionID string, expiresAt time.Time) {
+	defaultAuditor.Log(&AuthAuditLog{
+		Event:     EventImpersonationStarted,
+		UserID:    userID.String(),
+		TokenType: "access",
+		SessionID: sessionID,
+		ExpiresAt: &expiresAt,
+		Reason:    impersonation.Reason,
+		Extra: map[string]interface{}{
+			"operator_id":    impersonation.OperatorID.String(),
+			"operator_email": impersonation.OperatorEmail,
+		},
+	})
+}
+
 // LogExternalTokenValidated logs when an external token (e.g., Janua) is validated
 func LogExternalTokenValidated(userID uuid.UUID, email, issuer string) {
 	defaultAuditor.Log(&AuthAuditLog{
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
+)
+
+// ImpersonationTTL is how long a platform operator may act as a user with
+// one token. Impersonation tokens cannot be refreshed.
+const ImpersonationTTL = 30 * time.Minute
+
+// ImpersonationHeader carries the banner of an impersonated session on every
+// response, so clients can show it without decoding the token
+const ImpersonationHeader = "X-Enclii-Impersonation"
+
+// ErrCannotImpersonate is returned for users whose role reaches past their
+// projects: platform admins and operators are never impersonated
+var ErrCannotImpersonate = errors.New("platform admins and operators cannot be impersonated")
+
+// Impersonation is the claim of a token a platform operator acts as a user
+// with, for support
+type Impersonation struct {
+	OperatorID    uuid.UUID `json:"operator_id"`
+	OperatorEmail string    `json:"operator_email"`
+	Reason        string    `json:"reason"`
+	// Banner is the notice clients show for as long as the session lasts
+	Banner string `json:"banner"`
+}
+
+// CanBeImpersonated reports whether users with a role may be impersonated
+func CanBeImpersonated(role string) bool {
+	switch Role(role) {
+	case RoleSuperAdmin, RoleAdmin, RoleOperator:
+		return false
+	}
+	return true
+}
+
+// GenerateImpersonationToken issues an access token acting as a user for a
+// platform operator. It carries the user's role and projects, no second
+// factor, and the impersonation claim; its session shows among the user's.
+func (j *JWTManager) GenerateImpersonationToken(user *User, impersonation *Impersonation) (*TokenPair, error) {
+	if !CanBeImpersonated(user.Role) {
+		return nil, ErrCannotImpersonate
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ImpersonationTTL)
+	sessionID := uuid.New().String()
+	if impersonation.Banner == "" {
+		impersonation.Banner = fmt.Sprintf("%s is signed in as %s for support", impersonation.OperatorEmail, user.Email)
+	}
+
+	claims := &Claims{
+		UserID:        user.ID,
+		Email:         user.Email,
+		Role:          user.Role,
+		ProjectIDs:    user.ProjectIDs,
+		SessionID:     sessionID,
+		TokenType:     "access",
+		Impersonation: impersonation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "enclii-switchyard",
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(j.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign impersonation token: %w", err)
+	}
+
+	j.saveSession(&cache.Session{
+		ID:         sessionID,
+		UserID:     user.ID.String(),
+		IP:         user.Device.IP,
+		UserAgent:  user.Device.UserAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+	})
+	LogImpersonationStarted(user.ID, impersonation, sessionID, expiresAt)
+
+	return &TokenPair{
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+		TokenType:   "Bearer",
+	}, nil
+}
+
+// setImpersonationContext marks a request made with an impersonation token:
+// handlers and the audit log see the operator, and the response carries the
+// banner
+func setImpersonationContext(c *gin.Context, claims *Claims) {
+	if claims.Impersonation == nil {
+		return
+	}
+	c.Set("impersonator_id", claims.Impersonation.OperatorID.String())
+	c.Set("impersonator_email", claims.Impersonation.OperatorEmail)
+	c.Header(ImpersonationHeader, claims.Impersonation.Banner)
+}
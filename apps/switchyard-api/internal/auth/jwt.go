@@ -16,6 +16,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/sirupsen/logrus"
 )
@@ -72,7 +73,11 @@ type Claims struct {
 	Role       string    `json:"role"`
 	ProjectIDs []string  `json:"project_ids,omitempty"`
 	SessionID  string    `json:"session_id"` // Unique session identifier for revocation
-	TokenType  string    `json:"token_type"` // "access" or "refresh"
+	TokenType  string    `json:"token_type"` // "access", "refresh", or "two_factor"
+	// TwoFactorAt is when the session last verified a second factor
+	TwoFactorAt *jwt.NumericDate `json:"two_factor_at,omitempty"`
+	// Impersonation is set when a platform operator acts as the user
+	Impersonation *Impersonation `json:"impersonation,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -96,6 +101,10 @@ type User struct {
 	ProjectIDs []string  `json:"project_ids"`
 	CreatedAt  time.Time `json:"created_at"`
 	Active     bool      `json:"active"`
+	// TwoFactorAt is when the user verified a second factor, if they did
+	TwoFactorAt *time.Time `json:"-"`
+	// Device is the client signing in, recorded in the session metadata
+	Device SessionDevice `json:"-"`
 }
 
 func NewJWTManager(tokenDuration, refreshDuration time.Duration, repos *db.Repositories, cache SessionRevoker) (*JWTManager, error) {
@@ -163,20 +172,35 @@ func generateRSAKey() (*rsa.PrivateKey, error) {
 }
 
 func (j *JWTManager) GenerateTokenPair(user *User) (*TokenPair, error) {
+	return j.generateTokenPair(user, &cache.Session{
+		IP:        user.Device.IP,
+		UserAgent: user.Device.UserAgent,
+	})
+}
+
+// generateTokenPair issues the tokens of a new session and records its
+// metadata, filling in the session's ID, user, and times
+func (j *JWTManager) generateTokenPair(user *User, session *cache.Session) (*TokenPair, error) {
 	now := time.Now()
 
 	// Generate unique session ID for this token pair
 	// This allows us to revoke both access and refresh tokens together
 	sessionID := uuid.New().String()
 
+	var twoFactorAt *jwt.NumericDate
+	if user.TwoFactorAt != nil {
+		twoFactorAt = jwt.NewNumericDate(*user.TwoFactorAt)
+	}
+
 	// Generate access token
 	accessClaims := &Claims{
-		UserID:     user.ID,
-		Email:      user.Email,
-		Role:       user.Role,
-		ProjectIDs: user.ProjectIDs,
-		SessionID:  sessionID,
-		TokenType:  "access",
+		UserID:      user.ID,
+		Email:       user.Email,
+		Role:        user.Role,
+		ProjectIDs:  user.ProjectIDs,
+		SessionID:   sessionID,
+		TokenType:   "access",
+		TwoFactorAt: twoFactorAt,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   user.ID.String(),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -194,11 +218,12 @@ func (j *JWTManager) GenerateTokenPair(user *User) (*TokenPair, error) {
 
 	// Generate refresh token with same session ID
 	refreshClaims := &Claims{
-		UserID:    user.ID,
-		Email:     user.Email,
-		Role:      user.Role,
-		SessionID: sessionID,
-		TokenType: "refresh",
+		UserID:      user.ID,
+		Email:       user.Email,
+		Role:        user.Role,
+		SessionID:   sessionID,
+		TokenType:   "refresh",
+		TwoFactorAt: twoFactorAt,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   user.ID.String(),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -214,6 +239,15 @@ func (j *JWTManager) GenerateTokenPair(user *User) (*TokenPair, error) {
 		return nil, fmt.Errorf("failed to sign refresh token: %w", err)
 	}
 
+	session.ID = sessionID
+	session.UserID = user.ID.String()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(j.refreshDuration)
+	j.saveSession(session)
+
 	return &TokenPair{
 		AccessToken:  accessTokenString,
 		RefreshToken: refreshTokenString,
@@ -280,12 +314,25 @@ func (j *JWTManager) RefreshToken(refreshTokenString string) (*TokenPair, error)
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
 
+	// The new session carries on where the old one was signed in
+	session := &cache.Session{}
+	if store, ok := j.sessionStore(); ok && claims.SessionID != "" {
+		if previous, err := store.GetSession(context.Background(), claims.SessionID); err == nil {
+			session = &cache.Session{
+				IP:        previous.IP,
+				UserAgent: previous.UserAgent,
+				CreatedAt: previous.CreatedAt,
+			}
+		}
+	}
+
 	// Revoke old session (token rotation for security)
 	if j.cache != nil && claims.SessionID != "" {
 		if err := j.cache.RevokeSession(context.Background(), claims.SessionID, j.refreshDuration); err != nil {
 			logrus.WithError(err).Warn("Failed to revoke old session during token refresh")
 			// Continue anyway - new tokens will be valid
 		}
+		j.forgetSession(context.Background(), claims.SessionID)
 	}
 
 	// Create user from claims
@@ -296,8 +343,11 @@ func (j *JWTManager) RefreshToken(refreshTokenString string) (*TokenPair, error)
 		ProjectIDs: claims.ProjectIDs,
 		Active:     true,
 	}
+	if claims.TwoFactorAt != nil {
+		user.TwoFactorAt = &claims.TwoFactorAt.Time
+	}
 
-	newTokens, err := j.GenerateTokenPair(user)
+	newTokens, err := j.generateTokenPair(user, session)
 	if err != nil {
 		return nil, err
 	}
@@ -309,6 +359,42 @@ func (j *JWTManager) RefreshToken(refreshTokenString string) (*TokenPair, error)
 }
 
 func (j *JWTManager) validateRefreshToken(tokenString string) (*Claims, error) {
+	return j.parseToken(tokenString, "refresh")
+}
+
+// GenerateTwoFactorChallenge issues a short-lived token proving a user's
+// password was accepted, to be exchanged for a session with their second
+// factor
+func (j *JWTManager) GenerateTwoFactorChallenge(user *User) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenType: "two_factor",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TwoFactorChallengeTTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "enclii-switchyard",
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(j.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign two-factor challenge: %w", err)
+	}
+	return token, nil
+}
+
+// ValidateTwoFactorChallenge validates a token issued by
+// GenerateTwoFactorChallenge
+func (j *JWTManager) ValidateTwoFactorChallenge(tokenString string) (*Claims, error) {
+	return j.parseToken(tokenString, "two_factor")
+}
+
+// parseToken validates a token signed by this manager and checks its type
+func (j *JWTManager) parseToken(tokenString, tokenType string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -329,7 +415,7 @@ func (j *JWTManager) validateRefreshToken(tokenString string) (*Claims, error) {
 		return nil, fmt.Errorf("failed to parse claims")
 	}
 
-	if claims.TokenType != "refresh" {
+	if claims.TokenType != tokenType {
 		return nil, fmt.Errorf("invalid token type")
 	}
 
@@ -348,6 +434,7 @@ func (j *JWTManager) RevokeSession(ctx context.Context, sessionID string) error
 	if err != nil {
 		return fmt.Errorf("failed to revoke session: %w", err)
 	}
+	j.forgetSession(ctx, sessionID)
 
 	logrus.Infof("Session revoked: %s", sessionID)
 	return nil
@@ -9,6 +9,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 )
 
 // AuthMiddleware supports both Authorization header and query parameter (for WebSocket connections)
@@ -52,6 +54,7 @@ func (j *JWTManager) AuthMiddleware() gin.HandlerFunc {
 			c.Set("user_role", claims.Role)
 			c.Set("project_ids", claims.ProjectIDs)
 			c.Set("claims", claims)
+			setImpersonationContext(c, claims)
 			c.Next()
 			return
 		}
@@ -99,6 +102,32 @@ func (j *JWTManager) AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// roleRank orders roles by privilege for comparisons
+var roleRank = map[Role]int{
+	RoleViewer:     1,
+	RoleOperator:   1, // Writes nothing in projects
+	RoleDeveloper:  2,
+	RoleAdmin:      3,
+	RoleSuperAdmin: 4,
+}
+
+// apiTokenRole returns the role an API token acts with: developer, or admin
+// with the admin scope, but never more than the role of the token's owner
+func apiTokenRole(token *db.APITokenInfo) string {
+	role := RoleDeveloper
+	for _, scope := range token.Scopes {
+		if scope == "admin" {
+			role = RoleAdmin
+			break
+		}
+	}
+
+	if owner := Role(token.UserRole); roleRank[owner] < roleRank[role] {
+		return string(owner)
+	}
+	return string(role)
+}
+
 // handleAPITokenAuth handles authentication via API tokens (enclii_xxx format)
 func (j *JWTManager) handleAPITokenAuth(c *gin.Context, tokenString string) {
 	if j.apiTokenValidator == nil {
@@ -129,23 +158,7 @@ func (j *JWTManager) handleAPITokenAuth(c *gin.Context, tokenString string) {
 	}
 
 	// Set user context from API token
-	c.Set("user_id", apiToken.UserID.String())
-	c.Set("auth_type", "api_token")
-	c.Set("api_token_id", apiToken.ID)
-	c.Set("api_token_name", apiToken.Name)
-
-	// API tokens get developer role by default (scoped by token scopes if needed)
-	userRole := "developer"
-	if len(apiToken.Scopes) > 0 {
-		// Check if admin scope is present
-		for _, scope := range apiToken.Scopes {
-			if scope == "admin" {
-				userRole = "admin"
-				break
-			}
-		}
-	}
-	c.Set("user_role", userRole)
+	setAPITokenContext(c, apiToken)
 
 	// Update last used timestamp (async, don't block the request)
 	go func() {
@@ -199,8 +212,8 @@ func (j *JWTManager) RequireRole(roles ...string) gin.HandlerFunc {
 				hasRole = true
 				break
 			}
-			// developer can do viewer tasks
-			if roleStr == "developer" && role == "viewer" {
+			// developer and operator can do viewer tasks
+			if (roleStr == "developer" || roleStr == "operator") && role == "viewer" {
 				hasRole = true
 				break
 			}
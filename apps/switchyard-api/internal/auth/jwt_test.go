@@ -406,3 +406,62 @@ func (m *MockSessionRevoker) IsSessionRevoked(ctx context.Context, sessionID str
 	}
 	return m.revoked[sessionID], nil
 }
+
+func TestJWTManager_GenerateImpersonationToken(t *testing.T) {
+	manager, err := NewJWTManager(
+		15*time.Minute,
+		7*24*time.Hour,
+		nil,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewJWTManager() failed: %v", err)
+	}
+
+	impersonation := &Impersonation{
+		OperatorID:    uuid.New(),
+		OperatorEmail: "support@example.com",
+		Reason:        "Ticket 4821",
+	}
+
+	t.Run("carries the impersonation claim", func(t *testing.T) {
+		user := &User{ID: uuid.New(), Email: "dev@example.com", Role: "developer"}
+		tokens, err := manager.GenerateImpersonationToken(user, impersonation)
+		if err != nil {
+			t.Fatalf("GenerateImpersonationToken() failed: %v", err)
+		}
+		if tokens.RefreshToken != "" {
+			t.Error("Impersonation tokens should not be refreshable")
+		}
+		if time.Until(tokens.ExpiresAt) > ImpersonationTTL {
+			t.Errorf("Token expires after %v, want at most %v", time.Until(tokens.ExpiresAt), ImpersonationTTL)
+		}
+
+		claims, err := manager.ValidateToken(tokens.AccessToken)
+		if err != nil {
+			t.Fatalf("ValidateToken() failed: %v", err)
+		}
+		if claims.UserID != user.ID {
+			t.Errorf("Claims UserID = %v, want %v", claims.UserID, user.ID)
+		}
+		if claims.Impersonation == nil {
+			t.Fatal("Claims have no impersonation")
+		}
+		if claims.Impersonation.OperatorID != impersonation.OperatorID {
+			t.Errorf("OperatorID = %v, want %v", claims.Impersonation.OperatorID, impersonation.OperatorID)
+		}
+		if !strings.Contains(claims.Impersonation.Banner, "support@example.com") {
+			t.Errorf("Banner %q does not name the operator", claims.Impersonation.Banner)
+		}
+		if claims.TwoFactorAt != nil {
+			t.Error("Impersonation tokens should not pass a second factor")
+		}
+	})
+
+	t.Run("refuses platform admins", func(t *testing.T) {
+		admin := &User{ID: uuid.New(), Email: "admin@example.com", Role: "admin"}
+		if _, err := manager.GenerateImpersonationToken(admin, impersonation); err != ErrCannotImpersonate {
+			t.Errorf("GenerateImpersonationToken() error = %v, want ErrCannotImpersonate", err)
+		}
+	})
+}
@@ -176,8 +176,9 @@ func (o *OIDCManager) loadUserProjectIDs(ctx context.Context, userID uuid.UUID)
 	return projectIDs
 }
 
-// HandleCallback processes the OAuth callback and creates/updates user
-func (o *OIDCManager) HandleCallback(ctx context.Context, code string) (*TokenPair, error) {
+// HandleCallback processes the OAuth callback and creates/updates user.
+// The device is recorded in the metadata of the new session.
+func (o *OIDCManager) HandleCallback(ctx context.Context, code string, device SessionDevice) (*TokenPair, error) {
 	// Exchange authorization code for token
 	oauth2Token, err := o.oauth2Config.Exchange(ctx, code)
 	if err != nil {
@@ -219,6 +220,7 @@ func (o *OIDCManager) HandleCallback(ctx context.Context, code string) (*TokenPa
 	}
 
 	// Generate local JWT session tokens
+	user.Device = device
 	tokens, err := o.jwtManager.GenerateTokenPair(user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate session tokens: %w", err)
@@ -387,22 +389,7 @@ func (o *OIDCManager) AuthMiddleware() gin.HandlerFunc {
 			}
 
 			// Set user context from API token
-			c.Set("user_id", apiToken.UserID.String())
-			c.Set("auth_type", "api_token")
-			c.Set("api_token_id", apiToken.ID)
-			c.Set("api_token_name", apiToken.Name)
-
-			// API tokens get developer role by default (scoped by token scopes if needed)
-			userRole := "developer"
-			if len(apiToken.Scopes) > 0 {
-				for _, scope := range apiToken.Scopes {
-					if scope == "admin" {
-						userRole = "admin"
-						break
-					}
-				}
-			}
-			c.Set("user_role", userRole)
+			setAPITokenContext(c, apiToken)
 
 			// Update last used timestamp (async)
 			go func() {
@@ -436,6 +423,7 @@ func (o *OIDCManager) AuthMiddleware() gin.HandlerFunc {
 			c.Set("project_ids", localClaims.ProjectIDs)
 			c.Set("claims", localClaims)
 			c.Set("token_source", "local")
+			setImpersonationContext(c, localClaims)
 
 			// Audit: Log successful local token validation
 			LogTokenValidated(localClaims.UserID, localClaims.Email, "local")
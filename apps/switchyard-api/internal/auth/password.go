@@ -2,6 +2,7 @@ package auth
 
 import (
 	"fmt"
+	"unicode"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -45,10 +46,25 @@ func ComparePassword(hashedPassword, plainPassword string) error {
 	return nil
 }
 
-// ValidatePasswordStrength validates password meets minimum requirements
-func ValidatePasswordStrength(password string) error {
-	if len(password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters long")
+// PasswordPolicy is the strength new passwords must have
+type PasswordPolicy struct {
+	MinLength        int  // At least 8; bcrypt limits passwords to 72 bytes
+	RequireMixedCase bool // Both upper and lower case letters
+	RequireDigit     bool
+	RequireSymbol    bool // Any character that is not a letter or digit
+}
+
+// DefaultPasswordPolicy only requires a minimum length
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 8}
+
+// Validate checks that a password meets the policy
+func (p PasswordPolicy) Validate(password string) error {
+	minLength := p.MinLength
+	if minLength < DefaultPasswordPolicy.MinLength {
+		minLength = DefaultPasswordPolicy.MinLength
+	}
+	if len(password) < minLength {
+		return fmt.Errorf("password must be at least %d characters long", minLength)
 	}
 
 	if len(password) > 72 {
@@ -56,10 +72,36 @@ func ValidatePasswordStrength(password string) error {
 		return fmt.Errorf("password must be less than 72 characters")
 	}
 
-	// Add more validation rules as needed:
-	// - Require uppercase/lowercase/numbers/special chars
-	// - Check against common passwords
-	// - etc.
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsLetter(r):
+			// Letters without case count towards neither requirement
+		default:
+			symbol = true
+		}
+	}
+
+	if p.RequireMixedCase && !(upper && lower) {
+		return fmt.Errorf("password must contain both upper and lower case letters")
+	}
+	if p.RequireDigit && !digit {
+		return fmt.Errorf("password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		return fmt.Errorf("password must contain a symbol")
+	}
 
 	return nil
 }
+
+// ValidatePasswordStrength validates password meets minimum requirements
+func ValidatePasswordStrength(password string) error {
+	return DefaultPasswordPolicy.Validate(password)
+}
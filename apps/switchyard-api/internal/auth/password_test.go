@@ -195,6 +195,37 @@ func TestComparePassword_SpecialCharacters(t *testing.T) {
 	}
 }
 
+func TestPasswordPolicy_Validate(t *testing.T) {
+	strict := PasswordPolicy{MinLength: 12, RequireMixedCase: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		wantErr  bool
+	}{
+		{"default accepts 8 characters", DefaultPasswordPolicy, "abcdefgh", false},
+		{"default rejects 7 characters", DefaultPasswordPolicy, "abcdefg", true},
+		{"minimum never below 8", PasswordPolicy{MinLength: 4}, "abcdef", true},
+		{"bcrypt limit", DefaultPasswordPolicy, strings.Repeat("a", 73), true},
+		{"strict accepts all classes", strict, "Correct-Horse-9", false},
+		{"strict requires mixed case", strict, "correct-horse-9", true},
+		{"strict requires digit", strict, "Correct-Horse-X", true},
+		{"strict requires symbol", strict, "CorrectHorse99", true},
+		{"strict requires length", strict, "Co-rrect9", true},
+		{"caseless letters are not mixed case", PasswordPolicy{MinLength: 8, RequireMixedCase: true}, "パスワードパスワード", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func BenchmarkHashPassword(b *testing.B) {
 	password := "benchmark123"
 
@@ -16,23 +16,79 @@ import (
 // This is returned by OIDC providers when local authentication methods (Register, Login, RefreshToken) are called.
 var ErrNotSupported = errors.New("operation not supported in current authentication mode")
 
+// Signup modes of local authentication
+const (
+	SignupModeOpen   = "open"   // Anyone may register
+	SignupModeInvite = "invite" // Registering needs an invite code or a pending team invitation
+)
+
+// EmailVerificationTTL is how long an email verification link stays valid
+const EmailVerificationTTL = 24 * time.Hour
+
+// SignupPolicy controls who may register local accounts and what they must
+// prove before signing in
+type SignupPolicy struct {
+	Mode                     string
+	RequireEmailVerification bool
+	AdminEmails              []string // May register without an invite, so an invite-only platform can be bootstrapped
+	Password                 PasswordPolicy
+}
+
+// SignupPolicyFromConfig builds the signup policy of the configuration
+func SignupPolicyFromConfig(cfg *config.Config) SignupPolicy {
+	mode := cfg.SignupMode
+	if mode == "" {
+		mode = SignupModeOpen
+	}
+	return SignupPolicy{
+		Mode:                     mode,
+		RequireEmailVerification: cfg.SignupEmailVerification,
+		AdminEmails:              cfg.AdminEmails,
+		Password: PasswordPolicy{
+			MinLength:        cfg.PasswordMinLength,
+			RequireMixedCase: cfg.PasswordRequireMixedCase,
+			RequireDigit:     cfg.PasswordRequireDigit,
+			RequireSymbol:    cfg.PasswordRequireSymbol,
+		},
+	}
+}
+
 // RegisterRequest represents a user registration request
 type RegisterRequest struct {
-	Email     string
-	Password  string
-	Name      string
+	Email      string
+	Password   string
+	Name       string
+	InviteCode string // Required in invite-only mode unless the email has a pending team invitation
+	IP         string
+	UserAgent  string
+}
+
+// RegisterResponse represents the response from registration. When email
+// verification is required no tokens are issued; VerificationToken is to be
+// sent to the user instead.
+type RegisterResponse struct {
+	UserID            uuid.UUID
+	Email             string
+	Name              string
+	AccessToken       string
+	RefreshToken      string
+	ExpiresAt         time.Time
+	VerificationToken string
+}
+
+// VerifyEmailRequest represents a request to verify an email address
+type VerifyEmailRequest struct {
+	Token     string
 	IP        string
 	UserAgent string
 }
 
-// RegisterResponse represents the response from registration
-type RegisterResponse struct {
-	UserID       uuid.UUID
-	Email        string
-	Name         string
-	AccessToken  string
-	RefreshToken string
-	ExpiresAt    time.Time
+// EmailVerification is a verification link to send to a user
+type EmailVerification struct {
+	Email     string
+	Name      string
+	Token     string
+	ExpiresAt time.Time
 }
 
 // LoginRequest represents a login request
@@ -43,15 +99,26 @@ type LoginRequest struct {
 	UserAgent string
 }
 
-// LoginResponse represents the response from login
+// LoginResponse represents the response from login. When the user has
+// two-factor authentication enabled, no tokens are issued by the password
+// alone; TwoFactorToken is to be exchanged for them with a code instead.
 type LoginResponse struct {
-	UserID       uuid.UUID
-	Email        string
-	Name         string
-	Role         string
-	AccessToken  string
-	RefreshToken string
-	ExpiresAt    time.Time
+	UserID         uuid.UUID
+	Email          string
+	Name           string
+	Role           string
+	AccessToken    string
+	RefreshToken   string
+	ExpiresAt      time.Time
+	TwoFactorToken string
+}
+
+// TwoFactorLoginRequest completes a login with the second factor of a user
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string
+	Code           string // TOTP code or recovery code
+	IP             string
+	UserAgent      string
 }
 
 // RefreshTokenRequest represents a token refresh request
@@ -91,10 +158,25 @@ type AuthenticationProvider interface {
 	// Returns ErrNotSupported in OIDC mode - users authenticate via the identity provider.
 	Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error)
 
+	// VerifyTwoFactorLogin completes a login that needs a second factor.
+	// Returns ErrNotSupported in OIDC mode - the identity provider enforces MFA.
+	VerifyTwoFactorLogin(ctx context.Context, req *TwoFactorLoginRequest) (*LoginResponse, error)
+
 	// RefreshToken generates new tokens using a refresh token.
 	// Returns ErrNotSupported in OIDC mode - users re-authenticate via the identity provider.
 	RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*RefreshTokenResponse, error)
 
+	// VerifyEmail marks the email address of a verification token's user as
+	// verified and signs them in.
+	// Returns ErrNotSupported in OIDC mode - the identity provider verifies addresses.
+	VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*LoginResponse, error)
+
+	// ResendEmailVerification issues a new verification token for an
+	// unverified user. It returns nil when there is nothing to send, so
+	// callers cannot tell which addresses have accounts.
+	// Returns ErrNotSupported in OIDC mode.
+	ResendEmailVerification(ctx context.Context, email string) (*EmailVerification, error)
+
 	// Logout revokes a session by session ID.
 	// Works in both modes - used to invalidate local session tokens.
 	Logout(ctx context.Context, sessionID string) error
@@ -103,6 +185,12 @@ type AuthenticationProvider interface {
 	// Works in both modes - used after OIDC callback to create local session tokens.
 	GenerateTokenPair(user *User) (*TokenPair, error)
 
+	// ImpersonateUser issues an access token acting as a user for a platform
+	// operator, with the role and projects the user signs in with. Returns
+	// ErrCannotImpersonate for platform admins and operators.
+	// Works in both modes.
+	ImpersonateUser(ctx context.Context, userID uuid.UUID, impersonation *Impersonation, device SessionDevice) (*TokenPair, error)
+
 	// SupportsLocalAuth returns true if local authentication (email/password) is supported.
 	// Returns true for JWT mode, false for OIDC mode.
 	SupportsLocalAuth() bool
@@ -110,6 +198,19 @@ type AuthenticationProvider interface {
 	// RevokeSessionFromToken extracts session ID from token and revokes it.
 	// Works in both modes for session management.
 	RevokeSessionFromToken(ctx context.Context, tokenString string) error
+
+	// ListSessions returns the active sessions of a user, most recently used first.
+	// Works in both modes - sessions are local either way.
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]*cache.Session, error)
+
+	// RevokeUserSession revokes one session of a user.
+	// Works in both modes.
+	RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+
+	// RevokeOtherSessions revokes every session of a user except the current
+	// one and returns how many it revoked.
+	// Works in both modes.
+	RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) (int, error)
 }
 
 // NewAuthProvider creates the appropriate AuthenticationProvider based on configuration.
@@ -130,7 +231,7 @@ func NewAuthProvider(
 		if !ok {
 			return nil, errors.New("expected JWTManager for local auth mode")
 		}
-		return NewLocalAuthProvider(repos, jwtManager, logger), nil
+		return NewLocalAuthProvider(repos, jwtManager, SignupPolicyFromConfig(cfg), logger), nil
 
 	case "oidc":
 		// OIDC mode - external identity provider
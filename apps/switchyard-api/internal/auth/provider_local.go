@@ -3,20 +3,28 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 	"github.com/sirupsen/logrus"
 )
 
+// emailVerificationResendInterval is the least time between two
+// verification emails to the same user
+const emailVerificationResendInterval = time.Minute
+
 // LocalAuthProvider implements AuthenticationProvider for JWT (local) authentication mode.
 // It wraps a JWTManager and provides full local authentication capabilities including
 // user registration, login with email/password, and token refresh.
 type LocalAuthProvider struct {
 	repos      *db.Repositories
 	jwtManager *JWTManager
+	signup     SignupPolicy
 	logger     *logrus.Logger
 }
 
@@ -24,11 +32,13 @@ type LocalAuthProvider struct {
 func NewLocalAuthProvider(
 	repos *db.Repositories,
 	jwtManager *JWTManager,
+	signup SignupPolicy,
 	logger *logrus.Logger,
 ) *LocalAuthProvider {
 	return &LocalAuthProvider{
 		repos:      repos,
 		jwtManager: jwtManager,
+		signup:     signup,
 		logger:     logger,
 	}
 }
@@ -76,10 +86,56 @@ func (p *LocalAuthProvider) Register(ctx context.Context, req *RegisterRequest)
 		Name:         req.Name,
 		Active:       true,
 	}
+	if !p.signup.RequireEmailVerification {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
 
-	if err := p.repos.Users.Create(ctx, user); err != nil {
-		p.logger.WithError(err).WithField("email", req.Email).Error("Failed to create user")
-		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	// The invite is only used up if the account is created
+	var invite *types.SignupInvite
+	var verificationToken string
+	err = p.repos.WithTransaction(ctx, func(txRepos *db.Repositories) error {
+		var err error
+		if invite, err = p.checkSignupAllowed(ctx, txRepos, req); err != nil {
+			return err
+		}
+
+		if err := txRepos.Users.Create(ctx, user); err != nil {
+			p.logger.WithError(err).WithField("email", req.Email).Error("Failed to create user")
+			return errors.Wrap(err, errors.ErrDatabaseError)
+		}
+
+		if user.EmailVerifiedAt == nil {
+			verificationToken, err = txRepos.EmailVerifications.Create(ctx, user.ID, EmailVerificationTTL)
+			if err != nil {
+				p.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to create email verification token")
+				return errors.Wrap(err, errors.ErrDatabaseError)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	auditContext := map[string]interface{}{
+		"signup_mode": p.signup.Mode,
+	}
+	if invite != nil {
+		auditContext["invite_id"] = invite.ID.String()
+	}
+
+	// Unverified users are signed in once they follow the emailed link
+	if verificationToken != "" {
+		auditContext["email_verification"] = "pending"
+		p.auditRegistration(ctx, user, req, auditContext)
+
+		return &RegisterResponse{
+			UserID:            user.ID,
+			Email:             user.Email,
+			Name:              user.Name,
+			VerificationToken: verificationToken,
+		}, nil
 	}
 
 	// Get user's role and accessible projects from project_access table
@@ -94,25 +150,14 @@ func (p *LocalAuthProvider) Register(ctx context.Context, req *RegisterRequest)
 		ProjectIDs: projectIDs,
 		CreatedAt:  user.CreatedAt,
 		Active:     user.Active,
+		Device:     SessionDevice{IP: req.IP, UserAgent: req.UserAgent},
 	})
 	if err != nil {
 		p.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to generate token pair")
 		return nil, errors.Wrap(err, errors.ErrInternal)
 	}
 
-	// Audit log
-	p.repos.AuditLogs.Log(ctx, &types.AuditLog{
-		ActorID:      &user.ID,
-		ActorEmail:   user.Email,
-		ActorRole:    types.RoleViewer,
-		Action:       "user_register",
-		ResourceType: "user",
-		ResourceID:   user.ID.String(),
-		ResourceName: user.Email,
-		IPAddress:    req.IP,
-		UserAgent:    req.UserAgent,
-		Outcome:      "success",
-	})
+	p.auditRegistration(ctx, user, req, auditContext)
 
 	return &RegisterResponse{
 		UserID:       user.ID,
@@ -174,6 +219,31 @@ func (p *LocalAuthProvider) Login(ctx context.Context, req *LoginRequest) (*Logi
 		return nil, errors.ErrInvalidCredentials
 	}
 
+	// Only checked once the password matched, so it reveals nothing to others
+	if p.signup.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		return nil, errors.ErrEmailNotVerified
+	}
+
+	// Users with a second factor get a challenge to exchange with a code
+	twoFactor, err := p.repos.TwoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		p.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to check two-factor authentication")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if twoFactor {
+		challenge, err := p.jwtManager.GenerateTwoFactorChallenge(&User{ID: user.ID, Email: user.Email})
+		if err != nil {
+			p.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to generate two-factor challenge")
+			return nil, errors.Wrap(err, errors.ErrInternal)
+		}
+		return &LoginResponse{
+			UserID:         user.ID,
+			Email:          user.Email,
+			Name:           user.Name,
+			TwoFactorToken: challenge,
+		}, nil
+	}
+
 	// Get user's role and accessible projects
 	userRole, projectIDs := p.getUserRoleAndProjects(ctx, user.ID)
 
@@ -186,6 +256,7 @@ func (p *LocalAuthProvider) Login(ctx context.Context, req *LoginRequest) (*Logi
 		ProjectIDs: projectIDs,
 		CreatedAt:  user.CreatedAt,
 		Active:     user.Active,
+		Device:     SessionDevice{IP: req.IP, UserAgent: req.UserAgent},
 	})
 	if err != nil {
 		p.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to generate token pair")
@@ -225,6 +296,100 @@ func (p *LocalAuthProvider) Login(ctx context.Context, req *LoginRequest) (*Logi
 	}, nil
 }
 
+// VerifyTwoFactorLogin exchanges the challenge of a password login and a
+// TOTP or recovery code for tokens.
+func (p *LocalAuthProvider) VerifyTwoFactorLogin(ctx context.Context, req *TwoFactorLoginRequest) (*LoginResponse, error) {
+	challenge, err := p.jwtManager.ValidateTwoFactorChallenge(req.TwoFactorToken)
+	if err != nil {
+		return nil, errors.ErrTokenInvalid
+	}
+
+	user, err := p.repos.Users.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrTokenInvalid
+		}
+		p.logger.WithError(err).WithField("user_id", challenge.UserID).Error("Failed to get user")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if !user.Active {
+		return nil, errors.ErrUnauthorized.WithDetails(map[string]any{
+			"reason": "Account is disabled",
+		})
+	}
+
+	method, err := VerifySecondFactor(ctx, p.repos.TwoFactor, user.ID, req.Code)
+	if err != nil {
+		if errors.Is(err, errors.ErrInvalidTwoFactorCode) {
+			p.repos.AuditLogs.Log(ctx, &types.AuditLog{
+				ActorID:      &user.ID,
+				ActorEmail:   user.Email,
+				ActorRole:    types.RoleViewer,
+				Action:       "login_failed",
+				ResourceType: "user",
+				ResourceID:   user.ID.String(),
+				ResourceName: user.Email,
+				IPAddress:    req.IP,
+				UserAgent:    req.UserAgent,
+				Outcome:      "failure",
+				Context: map[string]interface{}{
+					"reason": "invalid_two_factor_code",
+				},
+			})
+		}
+		return nil, err
+	}
+
+	userRole, projectIDs := p.getUserRoleAndProjects(ctx, user.ID)
+
+	now := time.Now()
+	tokenPair, err := p.jwtManager.GenerateTokenPair(&User{
+		ID:          user.ID,
+		Email:       user.Email,
+		Name:        user.Name,
+		Role:        userRole,
+		ProjectIDs:  projectIDs,
+		CreatedAt:   user.CreatedAt,
+		Active:      user.Active,
+		TwoFactorAt: &now,
+		Device:      SessionDevice{IP: req.IP, UserAgent: req.UserAgent},
+	})
+	if err != nil {
+		p.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to generate token pair")
+		return nil, errors.Wrap(err, errors.ErrInternal)
+	}
+
+	if err := p.repos.Users.UpdateLastLogin(ctx, user.ID); err != nil {
+		p.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to update last login time")
+	}
+
+	p.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      &user.ID,
+		ActorEmail:   user.Email,
+		ActorRole:    types.Role(userRole),
+		Action:       "login_success",
+		ResourceType: "user",
+		ResourceID:   user.ID.String(),
+		ResourceName: user.Email,
+		IPAddress:    req.IP,
+		UserAgent:    req.UserAgent,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"method": "password+" + method,
+		},
+	})
+
+	return &LoginResponse{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Name:         user.Name,
+		Role:         userRole,
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		ExpiresAt:    tokenPair.ExpiresAt,
+	}, nil
+}
+
 // RefreshToken generates new tokens using a refresh token.
 func (p *LocalAuthProvider) RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*RefreshTokenResponse, error) {
 	tokenPair, err := p.jwtManager.RefreshToken(req.RefreshToken)
@@ -240,6 +405,120 @@ func (p *LocalAuthProvider) RefreshToken(ctx context.Context, req *RefreshTokenR
 	}, nil
 }
 
+// VerifyEmail marks the email address of a verification token's user as
+// verified and signs them in.
+func (p *LocalAuthProvider) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*LoginResponse, error) {
+	if req.Token == "" {
+		return nil, errors.ErrTokenInvalid
+	}
+
+	userID, err := p.repos.EmailVerifications.Consume(ctx, req.Token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrTokenInvalid
+		}
+		p.logger.WithError(err).Error("Failed to consume email verification token")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	if err := p.repos.Users.MarkEmailVerified(ctx, userID); err != nil {
+		p.logger.WithError(err).WithField("user_id", userID).Error("Failed to mark email verified")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	user, err := p.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		p.logger.WithError(err).WithField("user_id", userID).Error("Failed to get verified user")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if !user.Active {
+		return nil, errors.ErrUnauthorized.WithDetails(map[string]any{
+			"reason": "Account is disabled",
+		})
+	}
+
+	userRole, projectIDs := p.getUserRoleAndProjects(ctx, user.ID)
+
+	tokenPair, err := p.jwtManager.GenerateTokenPair(&User{
+		ID:         user.ID,
+		Email:      user.Email,
+		Name:       user.Name,
+		Role:       userRole,
+		ProjectIDs: projectIDs,
+		CreatedAt:  user.CreatedAt,
+		Active:     user.Active,
+		Device:     SessionDevice{IP: req.IP, UserAgent: req.UserAgent},
+	})
+	if err != nil {
+		p.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to generate token pair")
+		return nil, errors.Wrap(err, errors.ErrInternal)
+	}
+
+	if err := p.repos.Users.UpdateLastLogin(ctx, user.ID); err != nil {
+		p.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to update last login time")
+	}
+
+	p.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      &user.ID,
+		ActorEmail:   user.Email,
+		ActorRole:    types.Role(userRole),
+		Action:       "email_verified",
+		ResourceType: "user",
+		ResourceID:   user.ID.String(),
+		ResourceName: user.Email,
+		IPAddress:    req.IP,
+		UserAgent:    req.UserAgent,
+		Outcome:      "success",
+	})
+
+	return &LoginResponse{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Name:         user.Name,
+		Role:         userRole,
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		ExpiresAt:    tokenPair.ExpiresAt,
+	}, nil
+}
+
+// ResendEmailVerification issues a new verification token for an unverified
+// user, at most once per emailVerificationResendInterval.
+func (p *LocalAuthProvider) ResendEmailVerification(ctx context.Context, email string) (*EmailVerification, error) {
+	user, err := p.repos.Users.GetByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		p.logger.WithError(err).WithField("email", email).Error("Failed to get user by email")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if user.EmailVerifiedAt != nil || !user.Active || user.PasswordHash == "" {
+		return nil, nil
+	}
+
+	lastSent, err := p.repos.EmailVerifications.LastSentAt(ctx, user.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if lastSent != nil && time.Since(*lastSent) < emailVerificationResendInterval {
+		return nil, nil
+	}
+
+	token, err := p.repos.EmailVerifications.Create(ctx, user.ID, EmailVerificationTTL)
+	if err != nil {
+		p.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to create email verification token")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	return &EmailVerification{
+		Email:     user.Email,
+		Name:      user.Name,
+		Token:     token,
+		ExpiresAt: time.Now().Add(EmailVerificationTTL),
+	}, nil
+}
+
 // Logout revokes a session by session ID.
 func (p *LocalAuthProvider) Logout(ctx context.Context, sessionID string) error {
 	return p.jwtManager.RevokeSession(ctx, sessionID)
@@ -250,11 +529,103 @@ func (p *LocalAuthProvider) GenerateTokenPair(user *User) (*TokenPair, error) {
 	return p.jwtManager.GenerateTokenPair(user)
 }
 
+// ImpersonateUser issues an access token acting as a user for a platform
+// operator. The user gets the role of their project access, as at login.
+func (p *LocalAuthProvider) ImpersonateUser(ctx context.Context, userID uuid.UUID, impersonation *Impersonation, device SessionDevice) (*TokenPair, error) {
+	user, err := p.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	userRole, projectIDs := p.getUserRoleAndProjects(ctx, user.ID)
+	return p.jwtManager.GenerateImpersonationToken(&User{
+		ID:         user.ID,
+		Email:      user.Email,
+		Name:       user.Name,
+		Role:       userRole,
+		ProjectIDs: projectIDs,
+		CreatedAt:  user.CreatedAt,
+		Active:     user.Active,
+		Device:     device,
+	}, impersonation)
+}
+
 // RevokeSessionFromToken extracts session ID from token and revokes it.
 func (p *LocalAuthProvider) RevokeSessionFromToken(ctx context.Context, tokenString string) error {
 	return p.jwtManager.RevokeSessionFromToken(ctx, tokenString)
 }
 
+// ListSessions returns the active sessions of a user.
+func (p *LocalAuthProvider) ListSessions(ctx context.Context, userID uuid.UUID) ([]*cache.Session, error) {
+	return p.jwtManager.ListSessions(ctx, userID)
+}
+
+// RevokeUserSession revokes one session of a user.
+func (p *LocalAuthProvider) RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	return p.jwtManager.RevokeUserSession(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions revokes every session of a user except the current one.
+func (p *LocalAuthProvider) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) (int, error) {
+	return p.jwtManager.RevokeOtherSessions(ctx, userID, currentSessionID)
+}
+
+// checkSignupAllowed enforces the signup mode. In invite-only mode it
+// redeems the request's invite code, unless the email is an admin email or
+// has a pending team invitation; the redeemed invite is returned.
+func (p *LocalAuthProvider) checkSignupAllowed(ctx context.Context, repos *db.Repositories, req *RegisterRequest) (*types.SignupInvite, error) {
+	if p.signup.Mode != SignupModeInvite {
+		return nil, nil
+	}
+
+	if req.InviteCode == "" {
+		for _, adminEmail := range p.signup.AdminEmails {
+			if strings.EqualFold(adminEmail, req.Email) {
+				return nil, nil
+			}
+		}
+
+		invitations, err := repos.TeamInvitations.ListPendingByEmail(ctx, strings.ToLower(req.Email))
+		if err != nil {
+			p.logger.WithError(err).WithField("email", req.Email).Warn("Failed to check team invitations")
+		}
+		if len(invitations) > 0 {
+			return nil, nil
+		}
+
+		return nil, errors.ErrInviteRequired
+	}
+
+	invite, err := repos.SignupInvites.Redeem(ctx, req.InviteCode, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrInviteRequired.WithDetails(map[string]any{
+				"reason": "Invite code is invalid, expired, used up, or for another email address",
+			})
+		}
+		p.logger.WithError(err).Error("Failed to redeem invite code")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	return invite, nil
+}
+
+// auditRegistration records a new account
+func (p *LocalAuthProvider) auditRegistration(ctx context.Context, user *types.User, req *RegisterRequest, auditContext map[string]interface{}) {
+	p.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      &user.ID,
+		ActorEmail:   user.Email,
+		ActorRole:    types.RoleViewer,
+		Action:       "user_register",
+		ResourceType: "user",
+		ResourceID:   user.ID.String(),
+		ResourceName: user.Email,
+		IPAddress:    req.IP,
+		UserAgent:    req.UserAgent,
+		Outcome:      "success",
+		Context:      auditContext,
+	})
+}
+
 // getUserRoleAndProjects retrieves the user's highest role and list of project IDs.
 func (p *LocalAuthProvider) getUserRoleAndProjects(ctx context.Context, userID uuid.UUID) (string, []string) {
 	accesses, err := p.repos.ProjectAccess.ListByUser(ctx, userID)
@@ -300,7 +671,7 @@ func (p *LocalAuthProvider) validateRegistrationInput(req *RegisterRequest) erro
 		})
 	}
 
-	if err := ValidatePasswordStrength(req.Password); err != nil {
+	if err := p.signup.Password.Validate(req.Password); err != nil {
 		return errors.ErrValidation.WithDetails(map[string]any{
 			"field":  "password",
 			"reason": err.Error(),
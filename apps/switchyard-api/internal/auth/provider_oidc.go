@@ -3,6 +3,8 @@ package auth
 import (
 	"context"
 
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/sirupsen/logrus"
 )
@@ -52,6 +54,17 @@ func (p *OIDCAuthProvider) Register(ctx context.Context, req *RegisterRequest) (
 	return nil, ErrNotSupported
 }
 
+// VerifyEmail is not supported in OIDC mode.
+// Email addresses are verified by the identity provider.
+func (p *OIDCAuthProvider) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*LoginResponse, error) {
+	return nil, ErrNotSupported
+}
+
+// ResendEmailVerification is not supported in OIDC mode.
+func (p *OIDCAuthProvider) ResendEmailVerification(ctx context.Context, email string) (*EmailVerification, error) {
+	return nil, ErrNotSupported
+}
+
 // Login is not supported in OIDC mode.
 // Returns ErrNotSupported with guidance to use the identity provider.
 func (p *OIDCAuthProvider) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
@@ -62,6 +75,12 @@ func (p *OIDCAuthProvider) Login(ctx context.Context, req *LoginRequest) (*Login
 	return nil, ErrNotSupported
 }
 
+// VerifyTwoFactorLogin is not supported in OIDC mode.
+// Multi-factor authentication is enforced by the identity provider.
+func (p *OIDCAuthProvider) VerifyTwoFactorLogin(ctx context.Context, req *TwoFactorLoginRequest) (*LoginResponse, error) {
+	return nil, ErrNotSupported
+}
+
 // RefreshToken is not supported in OIDC mode.
 // Users must re-authenticate via the identity provider.
 func (p *OIDCAuthProvider) RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*RefreshTokenResponse, error) {
@@ -82,7 +101,45 @@ func (p *OIDCAuthProvider) GenerateTokenPair(user *User) (*TokenPair, error) {
 	return p.oidcManager.jwtManager.GenerateTokenPair(user)
 }
 
+// ImpersonateUser issues an access token acting as a user for a platform
+// operator. Users on the admin email list count as admins.
+func (p *OIDCAuthProvider) ImpersonateUser(ctx context.Context, userID uuid.UUID, impersonation *Impersonation, device SessionDevice) (*TokenPair, error) {
+	user, err := p.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	role := user.Role
+	if p.oidcManager.adminEmails[user.Email] {
+		role = string(RoleAdmin)
+	}
+	return p.oidcManager.jwtManager.GenerateImpersonationToken(&User{
+		ID:         user.ID,
+		Email:      user.Email,
+		Name:       user.Name,
+		Role:       role,
+		ProjectIDs: p.oidcManager.loadUserProjectIDs(ctx, user.ID),
+		Active:     user.Active,
+		Device:     device,
+	}, impersonation)
+}
+
 // RevokeSessionFromToken extracts session ID from token and revokes it.
 func (p *OIDCAuthProvider) RevokeSessionFromToken(ctx context.Context, tokenString string) error {
 	return p.oidcManager.jwtManager.RevokeSessionFromToken(ctx, tokenString)
 }
+
+// ListSessions returns the active sessions of a user.
+func (p *OIDCAuthProvider) ListSessions(ctx context.Context, userID uuid.UUID) ([]*cache.Session, error) {
+	return p.oidcManager.jwtManager.ListSessions(ctx, userID)
+}
+
+// RevokeUserSession revokes one session of a user.
+func (p *OIDCAuthProvider) RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	return p.oidcManager.jwtManager.RevokeUserSession(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions revokes every session of a user except the current one.
+func (p *OIDCAuthProvider) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) (int, error) {
+	return p.oidcManager.jwtManager.RevokeOtherSessions(ctx, userID, currentSessionID)
+}
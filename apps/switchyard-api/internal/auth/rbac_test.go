@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+func TestAuthorize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		target       string
+		role         string
+		impersonated bool
+		wantStatus   int
+	}{
+		{name: "viewer reads", method: http.MethodGet, path: "/v1/services/:id", target: "/v1/services/x", role: "viewer", wantStatus: http.StatusOK},
+		{name: "viewer writes", method: http.MethodPatch, path: "/v1/services/:id", target: "/v1/services/x", role: "viewer", wantStatus: http.StatusForbidden},
+		{name: "developer writes", method: http.MethodPatch, path: "/v1/services/:id", target: "/v1/services/x", role: "developer", wantStatus: http.StatusOK},
+		{name: "developer deletes project", method: http.MethodDelete, path: "/v1/projects/:slug", target: "/v1/projects/x", role: "developer", wantStatus: http.StatusForbidden},
+		{name: "superadmin deletes project", method: http.MethodDelete, path: "/v1/projects/:slug", target: "/v1/projects/x", role: "superadmin", wantStatus: http.StatusOK},
+		{name: "unmapped route denied", method: http.MethodPost, path: "/v1/unmapped", target: "/v1/unmapped", role: "admin", wantStatus: http.StatusForbidden},
+		{name: "unknown role denied", method: http.MethodGet, path: "/v1/services/:id", target: "/v1/services/x", role: "", wantStatus: http.StatusForbidden},
+		{name: "operator reads admin teams", method: http.MethodGet, path: "/v1/admin/teams", target: "/v1/admin/teams", role: "operator", wantStatus: http.StatusOK},
+		{name: "operator suspends team", method: http.MethodPost, path: "/v1/admin/teams/:slug/suspend", target: "/v1/admin/teams/x/suspend", role: "operator", wantStatus: http.StatusOK},
+		{name: "operator reads service", method: http.MethodGet, path: "/v1/services/:id", target: "/v1/services/x", role: "operator", wantStatus: http.StatusOK},
+		{name: "operator writes service", method: http.MethodPatch, path: "/v1/services/:id", target: "/v1/services/x", role: "operator", wantStatus: http.StatusForbidden},
+		{name: "developer reads admin teams", method: http.MethodGet, path: "/v1/admin/teams", target: "/v1/admin/teams", role: "developer", wantStatus: http.StatusForbidden},
+		{name: "impersonated developer writes service", method: http.MethodPatch, path: "/v1/services/:id", target: "/v1/services/x", role: "developer", impersonated: true, wantStatus: http.StatusOK},
+		{name: "impersonated reads tokens", method: http.MethodGet, path: "/v1/user/tokens", target: "/v1/user/tokens", role: "developer", impersonated: true, wantStatus: http.StatusOK},
+		{name: "impersonated creates token", method: http.MethodPost, path: "/v1/user/tokens", target: "/v1/user/tokens", role: "developer", impersonated: true, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_role", tt.role)
+				if tt.impersonated {
+					c.Set("impersonator_id", "operator")
+				}
+				c.Next()
+			}, Authorize())
+			router.Handle(tt.method, tt.path, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAPITokenRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		scopes    []string
+		ownerRole string
+		want      string
+	}{
+		{name: "developer token", ownerRole: "developer", want: "developer"},
+		{name: "admin scope for admin", scopes: []string{"read", "admin"}, ownerRole: "admin", want: "admin"},
+		{name: "admin scope capped for developer", scopes: []string{"admin"}, ownerRole: "developer", want: "developer"},
+		{name: "viewer token stays viewer", ownerRole: "viewer", want: "viewer"},
+		{name: "viewer admin scope stays viewer", scopes: []string{"admin"}, ownerRole: "viewer", want: "viewer"},
+		{name: "superadmin without scope", ownerRole: "superadmin", want: "developer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := apiTokenRole(&db.APITokenInfo{Scopes: tt.scopes, UserRole: tt.ownerRole})
+			if got != tt.want {
+				t.Errorf("apiTokenRole() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
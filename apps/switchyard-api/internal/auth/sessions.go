@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+)
+
+// SessionStore keeps the metadata of active sessions so users can list and
+// revoke them. The Redis cache implements it next to SessionRevoker; without
+// it sessions still work but cannot be listed.
+type SessionStore interface {
+	SaveSession(ctx context.Context, session *cache.Session) error
+	GetSession(ctx context.Context, sessionID string) (*cache.Session, error)
+	ListSessions(ctx context.Context, userID string) ([]*cache.Session, error)
+	DeleteSession(ctx context.Context, userID, sessionID string) error
+}
+
+// SessionDevice is the client a user signed in from
+type SessionDevice struct {
+	IP        string
+	UserAgent string
+}
+
+// sessionStore returns the session store of the cache, if it has one
+func (j *JWTManager) sessionStore() (SessionStore, bool) {
+	store, ok := j.cache.(SessionStore)
+	return store, ok
+}
+
+// saveSession records the metadata of a new token pair's session. Failures
+// are logged only; the tokens work either way.
+func (j *JWTManager) saveSession(session *cache.Session) {
+	store, ok := j.sessionStore()
+	if !ok {
+		return
+	}
+	if err := store.SaveSession(context.Background(), session); err != nil {
+		logrus.WithError(err).WithField("session_id", session.ID).Warn("Failed to save session metadata")
+	}
+}
+
+// forgetSession removes the metadata of a revoked session
+func (j *JWTManager) forgetSession(ctx context.Context, sessionID string) {
+	store, ok := j.sessionStore()
+	if !ok {
+		return
+	}
+	session, err := store.GetSession(ctx, sessionID)
+	if err != nil {
+		return
+	}
+	if err := store.DeleteSession(ctx, session.UserID, sessionID); err != nil {
+		logrus.WithError(err).WithField("session_id", sessionID).Warn("Failed to delete session metadata")
+	}
+}
+
+// ListSessions returns the active sessions of a user, most recently used
+// first
+func (j *JWTManager) ListSessions(ctx context.Context, userID uuid.UUID) ([]*cache.Session, error) {
+	store, ok := j.sessionStore()
+	if !ok {
+		return nil, errors.ErrServiceUnavailable.WithDetails(map[string]any{
+			"reason": "Session listing requires the Redis cache",
+		})
+	}
+
+	sessions, err := store.ListSessions(ctx, userID.String())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrServiceUnavailable)
+	}
+
+	// A session revoked while its metadata could not be deleted is not active
+	active := make([]*cache.Session, 0, len(sessions))
+	now := time.Now()
+	for _, session := range sessions {
+		if session.ExpiresAt.Before(now) {
+			continue
+		}
+		if revoked, err := j.cache.IsSessionRevoked(ctx, session.ID); err == nil && revoked {
+			continue
+		}
+		active = append(active, session)
+	}
+
+	sort.Slice(active, func(a, b int) bool {
+		return active[a].LastUsedAt.After(active[b].LastUsedAt)
+	})
+	return active, nil
+}
+
+// RevokeUserSession revokes one session of a user. It returns
+// errors.ErrSessionNotFound for sessions of other users.
+func (j *JWTManager) RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	store, ok := j.sessionStore()
+	if !ok {
+		return errors.ErrServiceUnavailable.WithDetails(map[string]any{
+			"reason": "Session management requires the Redis cache",
+		})
+	}
+
+	session, err := store.GetSession(ctx, sessionID)
+	if err == cache.ErrCacheMiss || (err == nil && session.UserID != userID.String()) {
+		return errors.ErrSessionNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.ErrServiceUnavailable)
+	}
+
+	if err := j.RevokeSession(ctx, sessionID); err != nil {
+		return errors.Wrap(err, errors.ErrServiceUnavailable)
+	}
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of a user except the current
+// one, and returns how many it revoked
+func (j *JWTManager) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentSessionID string) (int, error) {
+	sessions, err := j.ListSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+		if err := j.RevokeSession(ctx, session.ID); err != nil {
+			return revoked, errors.Wrap(err, errors.ErrServiceUnavailable)
+		}
+		revoked++
+	}
+	return revoked, nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+)
+
+// memorySessionCache is an in-memory SessionRevoker and SessionStore
+type memorySessionCache struct {
+	MockSessionRevoker
+	sessions map[string]*cache.Session
+}
+
+func newMemorySessionCache() *memorySessionCache {
+	return &memorySessionCache{sessions: make(map[string]*cache.Session)}
+}
+
+func (m *memorySessionCache) SaveSession(ctx context.Context, session *cache.Session) error {
+	saved := *session
+	m.sessions[session.ID] = &saved
+	return nil
+}
+
+func (m *memorySessionCache) GetSession(ctx context.Context, sessionID string) (*cache.Session, error) {
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, cache.ErrCacheMiss
+	}
+	return session, nil
+}
+
+func (m *memorySessionCache) ListSessions(ctx context.Context, userID string) ([]*cache.Session, error) {
+	var sessions []*cache.Session
+	for _, session := range m.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *memorySessionCache) DeleteSession(ctx context.Context, userID, sessionID string) error {
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+func TestJWTManager_SessionMetadata(t *testing.T) {
+	store := newMemorySessionCache()
+	manager, err := NewJWTManager(15*time.Minute, 7*24*time.Hour, nil, store)
+	if err != nil {
+		t.Fatalf("NewJWTManager() failed: %v", err)
+	}
+	ctx := context.Background()
+	user := &User{
+		ID:     uuid.New(),
+		Email:  "dev@example.com",
+		Device: SessionDevice{IP: "203.0.113.7", UserAgent: "enclii-cli/1.0"},
+	}
+
+	pair, err := manager.GenerateTokenPair(user)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	claims, err := manager.ValidateToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	first, ok := store.sessions[claims.SessionID]
+	if !ok || first.UserID != user.ID.String() || first.IP != "203.0.113.7" || first.UserAgent != "enclii-cli/1.0" {
+		t.Fatalf("saved session = %+v, want the user's device", first)
+	}
+
+	t.Run("refresh carries the session forward", func(t *testing.T) {
+		refreshed, err := manager.RefreshToken(pair.RefreshToken)
+		if err != nil {
+			t.Fatalf("RefreshToken() error = %v", err)
+		}
+		newClaims, err := manager.ValidateToken(refreshed.AccessToken)
+		if err != nil {
+			t.Fatalf("ValidateToken() error = %v", err)
+		}
+
+		if _, ok := store.sessions[claims.SessionID]; ok {
+			t.Error("metadata of the rotated session was not removed")
+		}
+		session := store.sessions[newClaims.SessionID]
+		if session == nil || session.IP != first.IP || !session.CreatedAt.Equal(first.CreatedAt) {
+			t.Errorf("refreshed session = %+v, want IP and sign-in time of %+v", session, first)
+		}
+		pair = refreshed
+		claims = newClaims
+	})
+
+	t.Run("lists and revokes sessions", func(t *testing.T) {
+		other, err := manager.GenerateTokenPair(user)
+		if err != nil {
+			t.Fatalf("GenerateTokenPair() error = %v", err)
+		}
+		stranger, err := manager.GenerateTokenPair(&User{ID: uuid.New(), Email: "other@example.com"})
+		if err != nil {
+			t.Fatalf("GenerateTokenPair() error = %v", err)
+		}
+		strangerClaims, _ := manager.ValidateToken(stranger.AccessToken)
+
+		sessions, err := manager.ListSessions(ctx, user.ID)
+		if err != nil || len(sessions) != 2 {
+			t.Fatalf("ListSessions() = %d sessions, %v, want 2", len(sessions), err)
+		}
+
+		if err := manager.RevokeUserSession(ctx, user.ID, strangerClaims.SessionID); !errors.Is(err, errors.ErrSessionNotFound) {
+			t.Errorf("RevokeUserSession() of another user's session error = %v, want ErrSessionNotFound", err)
+		}
+		if err := manager.RevokeUserSession(ctx, user.ID, "unknown"); !errors.Is(err, errors.ErrSessionNotFound) {
+			t.Errorf("RevokeUserSession() of an unknown session error = %v, want ErrSessionNotFound", err)
+		}
+
+		revoked, err := manager.RevokeOtherSessions(ctx, user.ID, claims.SessionID)
+		if err != nil || revoked != 1 {
+			t.Fatalf("RevokeOtherSessions() = %d, %v, want 1", revoked, err)
+		}
+		if _, err := manager.ValidateToken(other.AccessToken); err == nil {
+			t.Error("token of a revoked session is still valid")
+		}
+		if _, err := manager.ValidateToken(pair.AccessToken); err != nil {
+			t.Errorf("token of the current session was revoked: %v", err)
+		}
+
+		sessions, err = manager.ListSessions(ctx, user.ID)
+		if err != nil || len(sessions) != 1 || sessions[0].ID != claims.SessionID {
+			t.Errorf("ListSessions() after revoking others = %+v, %v", sessions, err)
+		}
+	})
+}
+
+func TestJWTManager_ListSessionsWithoutStore(t *testing.T) {
+	manager, err := NewJWTManager(15*time.Minute, 7*24*time.Hour, nil, &MockSessionRevoker{})
+	if err != nil {
+		t.Fatalf("NewJWTManager() failed: %v", err)
+	}
+
+	if _, err := manager.ListSessions(context.Background(), uuid.New()); !errors.Is(err, errors.ErrServiceUnavailable) {
+		t.Errorf("ListSessions() without a session store error = %v, want ErrServiceUnavailable", err)
+	}
+}
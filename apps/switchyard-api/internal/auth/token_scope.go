@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// tokenScopeKey is the context key of the TokenScope of bot tokens
+const tokenScopeKey = "token_scope"
+
+// TokenScope limits a bot token to the projects and environments granted to
+// its bot. Requests without a scope are not restricted by it.
+type TokenScope struct {
+	BotID   uuid.UUID
+	BotName string
+	Grants  []types.BotGrant
+}
+
+// Allows reports whether the scope covers an environment of a project. A nil
+// environment stands for every environment of the project, as with shared
+// environment variables, and needs a grant for the whole project.
+func (s *TokenScope) Allows(projectID uuid.UUID, environmentID *uuid.UUID) bool {
+	for _, grant := range s.Grants {
+		if grant.ProjectID != projectID {
+			continue
+		}
+		if grant.EnvironmentID == nil {
+			return true
+		}
+		if environmentID != nil && *grant.EnvironmentID == *environmentID {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenScopeFromContext returns the scope of the bot token that authenticated
+// the request, or nil when the caller is not restricted to grants
+func TokenScopeFromContext(c *gin.Context) *TokenScope {
+	value, exists := c.Get(tokenScopeKey)
+	if !exists {
+		return nil
+	}
+	scope, _ := value.(*TokenScope)
+	return scope
+}
+
+// setAPITokenContext sets the identity an API token acts with on the request
+func setAPITokenContext(c *gin.Context, apiToken *db.APITokenInfo) {
+	c.Set("user_id", apiToken.UserID.String())
+	c.Set("auth_type", "api_token")
+	c.Set("api_token_id", apiToken.ID)
+	c.Set("api_token_name", apiToken.Name)
+	if apiToken.RateLimit != nil {
+		c.Set("api_token_rate_limit", *apiToken.RateLimit)
+	}
+
+	c.Set("user_role", apiTokenRole(apiToken))
+
+	if apiToken.BotID != nil {
+		c.Set("bot_id", *apiToken.BotID)
+		c.Set("bot_name", apiToken.BotName)
+		c.Set(tokenScopeKey, &TokenScope{
+			BotID:   *apiToken.BotID,
+			BotName: apiToken.BotName,
+			Grants:  apiToken.Grants,
+		})
+	}
+}
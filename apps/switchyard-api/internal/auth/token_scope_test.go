@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestTokenScopeAllows(t *testing.T) {
+	shop, blog, other := uuid.New(), uuid.New(), uuid.New()
+	staging, production, blogStaging := uuid.New(), uuid.New(), uuid.New()
+
+	scope := &TokenScope{Grants: []types.BotGrant{
+		{ProjectID: shop, EnvironmentID: &staging},
+		{ProjectID: blog},
+	}}
+
+	tests := []struct {
+		name          string
+		projectID     uuid.UUID
+		environmentID *uuid.UUID
+		want          bool
+	}{
+		{name: "granted environment", projectID: shop, environmentID: &staging, want: true},
+		{name: "other environment of the project", projectID: shop, environmentID: &production, want: false},
+		{name: "all environments of a partly granted project", projectID: shop, environmentID: nil, want: false},
+		{name: "environment of a project granted whole", projectID: blog, environmentID: &blogStaging, want: true},
+		{name: "all environments of a project granted whole", projectID: blog, environmentID: nil, want: true},
+		{name: "environment ID of another project", projectID: other, environmentID: &staging, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scope.Allows(tt.projectID, tt.environmentID); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if (&TokenScope{}).Allows(shop, &staging) {
+		t.Error("scope without grants allows an environment, want none")
+	}
+}
+
+func TestSetAPITokenContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userToken := &db.APITokenInfo{ID: uuid.New(), UserID: uuid.New(), UserRole: "admin"}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	setAPITokenContext(c, userToken)
+
+	if scope := TokenScopeFromContext(c); scope != nil {
+		t.Errorf("user token scope = %+v, want none", scope)
+	}
+	if role := c.GetString("user_role"); role != "developer" {
+		t.Errorf("user token role = %q, want developer", role)
+	}
+
+	botID := uuid.New()
+	grants := []types.BotGrant{{ProjectID: uuid.New()}}
+	botToken := &db.APITokenInfo{ID: uuid.New(), UserID: uuid.New(), UserRole: "viewer", BotID: &botID, BotName: "ci", Grants: grants}
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	setAPITokenContext(c, botToken)
+
+	scope := TokenScopeFromContext(c)
+	if scope == nil || scope.BotID != botID || scope.BotName != "ci" || len(scope.Grants) != 1 {
+		t.Fatalf("bot token scope = %+v, want the bot's grants", scope)
+	}
+	if role := c.GetString("user_role"); role != "viewer" {
+		t.Errorf("bot token role = %q, want the bot's role viewer", role)
+	}
+}
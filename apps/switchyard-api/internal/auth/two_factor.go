@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// TOTP parameters (RFC 6238). These are the defaults of authenticator apps,
+// which ignore the parameters of a provisioning URI.
+const (
+	TOTPIssuer = "Enclii"
+	totpPeriod = 30 // seconds
+	totpDigits = 6
+	totpSkew   = 1 // Steps either side of now that are accepted, for clock drift
+)
+
+// TwoFactorMaxAge is how long after a second factor was verified a session
+// may perform sensitive operations
+const TwoFactorMaxAge = 15 * time.Minute
+
+// Wrong codes lock two-factor verification of a user for TwoFactorLockout
+// after TwoFactorMaxAttempts of them
+const (
+	TwoFactorMaxAttempts = 5
+	TwoFactorLockout     = 15 * time.Minute
+)
+
+// TwoFactorChallengeTTL is how long a user has to enter their code after
+// their password was accepted
+const TwoFactorChallengeTTL = 5 * time.Minute
+
+// Second factor methods, as recorded in audit logs
+const (
+	TwoFactorMethodTOTP         = "totp"
+	TwoFactorMethodRecoveryCode = "recovery_code"
+)
+
+// SensitiveOperation is an operation that may need a recent second factor
+type SensitiveOperation string
+
+const (
+	SensitiveProductionDeploy SensitiveOperation = "production_deploy"
+	SensitiveSecretRead       SensitiveOperation = "secret_read"
+	SensitiveTokenCreate      SensitiveOperation = "token_create"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret creates a random 160-bit TOTP secret, base32 encoded
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI that authenticator apps
+// scan from a QR code
+func TOTPProvisioningURI(secret, account string) string {
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {TOTPIssuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	return "otpauth://totp/" + url.PathEscape(TOTPIssuer+":"+account) + "?" + query.Encode()
+}
+
+// totpCode computes the code of a time step (RFC 4226 HOTP)
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range totpDigits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// ValidateTOTP checks a code against a secret at a time, allowing for clock
+// drift. It returns the time step of the code, so callers can refuse a code
+// that was already used.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// VerifySecondFactor checks a TOTP code or recovery code of a user with
+// two-factor authentication enabled. Every code works once, and too many
+// wrong codes lock verification for a while. It returns the method of the
+// code.
+func VerifySecondFactor(ctx context.Context, repo *db.TwoFactorRepository, userID uuid.UUID, code string) (string, error) {
+	tf, err := repo.Get(ctx, userID)
+	if err == sql.ErrNoRows || (err == nil && tf.EnabledAt == nil) {
+		return "", errors.ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if tf.LockedUntil != nil && time.Now().Before(*tf.LockedUntil) {
+		return "", errors.ErrTwoFactorLocked
+	}
+
+	method, err := verifyCode(ctx, repo, tf, code)
+	if errors.Is(err, errors.ErrInvalidTwoFactorCode) {
+		if err := repo.RecordFailure(ctx, userID, TwoFactorMaxAttempts, TwoFactorLockout); err != nil {
+			return "", errors.Wrap(err, errors.ErrDatabaseError)
+		}
+		return "", err
+	}
+	if err == nil && tf.FailedAttempts > 0 {
+		if err := repo.ResetFailures(ctx, userID); err != nil {
+			return "", errors.Wrap(err, errors.ErrDatabaseError)
+		}
+	}
+	return method, err
+}
+
+// verifyCode checks and uses up a TOTP code or recovery code
+func verifyCode(ctx context.Context, repo *db.TwoFactorRepository, tf *db.UserTwoFactor, code string) (string, error) {
+	userID := tf.UserID
+
+	// Recovery codes are longer than TOTP codes, so the two cannot be confused
+	if len(strings.ReplaceAll(code, " ", "")) == totpDigits {
+		step, ok := ValidateTOTP(tf.Secret, code, time.Now())
+		if !ok {
+			return "", errors.ErrInvalidTwoFactorCode
+		}
+		if err := repo.UseStep(ctx, userID, step); err != nil {
+			if err == sql.ErrNoRows {
+				return "", errors.ErrInvalidTwoFactorCode
+			}
+			return "", errors.Wrap(err, errors.ErrDatabaseError)
+		}
+		return TwoFactorMethodTOTP, nil
+	}
+
+	if err := repo.UseRecoveryCode(ctx, userID, code); err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.ErrInvalidTwoFactorCode
+		}
+		return "", errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	return TwoFactorMethodRecoveryCode, nil
+}
+
+// PolicyRequiresTwoFactor reports whether a team's two-factor policy
+// requires a second factor for an operation in its projects. Any policy
+// covers creating API tokens, which would otherwise get around it.
+func PolicyRequiresTwoFactor(policy types.TwoFactorPolicy, op SensitiveOperation) bool {
+	switch policy {
+	case types.TwoFactorPolicyProduction:
+		return op == SensitiveProductionDeploy || op == SensitiveTokenCreate
+	case types.TwoFactorPolicyAll:
+		return op == SensitiveProductionDeploy || op == SensitiveSecretRead || op == SensitiveTokenCreate
+	default:
+		return false
+	}
+}
+
+// HasRecentTwoFactor reports whether the session verified a second factor
+// within TwoFactorMaxAge
+func (c *Claims) HasRecentTwoFactor(now time.Time) bool {
+	return c.TwoFactorAt != nil && now.Sub(c.TwoFactorAt.Time) < TwoFactorMaxAge
+}
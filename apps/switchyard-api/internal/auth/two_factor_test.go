@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// rfc6238Secret is the SHA1 key of the RFC 6238 test vectors, base32 encoded
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	// The 6-digit codes are the last digits of the RFC's 8-digit codes
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode([]byte("12345678901234567890"), tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode(t=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	got, ok := ValidateTOTP(rfc6238Secret, "050471", now)
+	if !ok || got != step {
+		t.Errorf("ValidateTOTP() = %d, %v, want %d, true", got, ok, step)
+	}
+
+	// A code of the previous step is accepted for clock drift, and spaces are ignored
+	if got, ok := ValidateTOTP(rfc6238Secret, "081 804", now); !ok || got != step-1 {
+		t.Errorf("ValidateTOTP() for the previous step = %d, %v", got, ok)
+	}
+
+	if _, ok := ValidateTOTP(rfc6238Secret, "050471", now.Add(2*totpPeriod*time.Second)); ok {
+		t.Error("ValidateTOTP() accepted a code two steps old")
+	}
+	for _, code := range []string{"000000", "05047", "0504711", ""} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("ValidateTOTP() accepted %q", code)
+		}
+	}
+	if _, ok := ValidateTOTP("not base32!", "050471", now); ok {
+		t.Error("ValidateTOTP() accepted an invalid secret")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Errorf("GenerateTOTPSecret() = %q, want 20 base32 encoded bytes", secret)
+	}
+
+	// A code computed from the secret validates against it
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("code of a generated secret does not validate")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(TOTPProvisioningURI("JBSWY3DPEHPK3PXP", "dev@example.com"))
+	if err != nil {
+		t.Fatalf("provisioning URI does not parse: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Enclii:dev@example.com" {
+		t.Errorf("provisioning URI = %s", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != "JBSWY3DPEHPK3PXP" || query.Get("issuer") != "Enclii" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("provisioning URI parameters = %v", query)
+	}
+}
+
+func TestPolicyRequiresTwoFactor(t *testing.T) {
+	tests := []struct {
+		policy types.TwoFactorPolicy
+		op     SensitiveOperation
+		want   bool
+	}{
+		{types.TwoFactorPolicyOff, SensitiveProductionDeploy, false},
+		{types.TwoFactorPolicyProduction, SensitiveProductionDeploy, true},
+		{types.TwoFactorPolicyProduction, SensitiveSecretRead, false},
+		{types.TwoFactorPolicyAll, SensitiveProductionDeploy, true},
+		{types.TwoFactorPolicyAll, SensitiveSecretRead, true},
+		{types.TwoFactorPolicyAll, SensitiveTokenCreate, true},
+		{types.TwoFactorPolicyProduction, SensitiveTokenCreate, true},
+		{types.TwoFactorPolicyOff, SensitiveTokenCreate, false},
+		{"", SensitiveProductionDeploy, false},
+	}
+
+	for _, tt := range tests {
+		if got := PolicyRequiresTwoFactor(tt.policy, tt.op); got != tt.want {
+			t.Errorf("PolicyRequiresTwoFactor(%q, %q) = %v, want %v", tt.policy, tt.op, got, tt.want)
+		}
+	}
+}
+
+func TestClaims_HasRecentTwoFactor(t *testing.T) {
+	now := time.Now()
+
+	if (&Claims{}).HasRecentTwoFactor(now) {
+		t.Error("claims without a second factor should not count as recent")
+	}
+	if !(&Claims{TwoFactorAt: jwt.NewNumericDate(now.Add(-time.Minute))}).HasRecentTwoFactor(now) {
+		t.Error("a second factor a minute ago should count as recent")
+	}
+	if (&Claims{TwoFactorAt: jwt.NewNumericDate(now.Add(-TwoFactorMaxAge - time.Second))}).HasRecentTwoFactor(now) {
+		t.Error("a second factor older than TwoFactorMaxAge should not count as recent")
+	}
+}
+
+func TestJWTManager_TwoFactorChallenge(t *testing.T) {
+	manager, err := NewJWTManager(15*time.Minute, 7*24*time.Hour, nil, nil)
+	if err != nil {
+		t.Fatalf("NewJWTManager() failed: %v", err)
+	}
+	user := &User{ID: uuid.New(), Email: "dev@example.com"}
+
+	challenge, err := manager.GenerateTwoFactorChallenge(user)
+	if err != nil {
+		t.Fatalf("GenerateTwoFactorChallenge() error = %v", err)
+	}
+	claims, err := manager.ValidateTwoFactorChallenge(challenge)
+	if err != nil || claims.UserID != user.ID {
+		t.Fatalf("ValidateTwoFactorChallenge() = %+v, %v", claims, err)
+	}
+
+	// A challenge is no access token, and an access token no challenge
+	if _, err := manager.ValidateToken(challenge); err == nil {
+		t.Error("ValidateToken() accepted a two-factor challenge")
+	}
+	now := time.Now()
+	user.TwoFactorAt = &now
+	pair, err := manager.GenerateTokenPair(user)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	if _, err := manager.ValidateTwoFactorChallenge(pair.AccessToken); err == nil {
+		t.Error("ValidateTwoFactorChallenge() accepted an access token")
+	}
+
+	// The second factor is carried in the tokens
+	access, err := manager.ValidateToken(pair.AccessToken)
+	if err != nil || !access.HasRecentTwoFactor(now) {
+		t.Errorf("access token claims = %+v, %v, want a recent second factor", access, err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package buildartifacts hands out downloads of the files builds published,
+// such as test reports, coverage and compiled binaries. Roundhouse uploads
+// them to the bucket; Switchyard only reads it.
+package buildartifacts
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// downloadURLExpiry bounds how long a listed download URL works
+const downloadURLExpiry = 15 * time.Minute
+
+// Config configures the S3-compatible bucket Roundhouse uploads artifacts to
+type Config struct {
+	Endpoint        string // Custom endpoint for S3-compatible providers (R2, MinIO); empty for AWS
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Service signs download URLs of build artifacts
+type Service struct {
+	presigner *s3.PresignClient
+	config    *Config
+}
+
+// NewService creates a build artifacts service
+func NewService(ctx context.Context, cfg *Config) (*Service, error) {
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("build artifact storage configuration incomplete: bucket, access key ID, and secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "auto"
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")),
+		config.WithRegion(cfg.Region),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &Service{
+		presigner: s3.NewPresignClient(client),
+		config:    cfg,
+	}, nil
+}
+
+// DownloadURL returns a short-lived URL that downloads an artifact under
+// its file name
+func (s *Service) DownloadURL(ctx context.Context, artifact *db.BuildArtifact) (string, error) {
+	req, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     aws.String(s.config.Bucket),
+		Key:                        aws.String(artifact.ObjectKey),
+		ResponseContentDisposition: aws.String(fmt.Sprintf("attachment; filename=%q", path.Base(artifact.Path))),
+	}, s3.WithPresignExpires(downloadURLExpiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign build artifact URL: %w", err)
+	}
+	return req.URL, nil
+}
@@ -0,0 +1,224 @@
+// Package buildcontext stores tarballs of local working directories that are
+// built in place of a git checkout, so uncommitted work can be deployed to
+// non-production environments.
+package buildcontext
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+const (
+	// objectPrefix is the bucket prefix build contexts are stored under
+	objectPrefix = "build-contexts"
+
+	// sourceURLExpiry bounds how long the builder can download a context
+	sourceURLExpiry = time.Hour
+
+	// purgeBatchSize bounds the contexts deleted per purge run
+	purgeBatchSize = 100
+
+	// PurgeInterval is how often expired contexts are deleted
+	PurgeInterval = time.Hour
+)
+
+var (
+	// ErrTooLarge is returned for uploads above the configured maximum size
+	ErrTooLarge = errors.New("build context is too large")
+
+	// ErrNotGzip is returned for uploads that are not gzip compressed
+	ErrNotGzip = errors.New("build context must be a gzipped tarball")
+
+	// ErrExpired is returned for contexts whose tarball was already purged
+	ErrExpired = errors.New("build context has expired; upload it again")
+)
+
+// Config configures the S3-compatible bucket build contexts are uploaded to
+type Config struct {
+	Endpoint        string // Custom endpoint for S3-compatible providers (R2, MinIO); empty for AWS
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	MaxSizeBytes    int64         // Largest accepted upload
+	TTL             time.Duration // How long an uploaded context is kept
+}
+
+// Service uploads, hands out, and purges build contexts
+type Service struct {
+	repos     *db.Repositories
+	client    *s3.Client
+	presigner *s3.PresignClient
+	config    *Config
+	logger    *logrus.Logger
+}
+
+// NewService creates a build context service
+func NewService(ctx context.Context, cfg *Config, repos *db.Repositories, logger *logrus.Logger) (*Service, error) {
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("build context storage configuration incomplete: bucket, access key ID, and secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "auto"
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")),
+		config.WithRegion(cfg.Region),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &Service{
+		repos:     repos,
+		client:    client,
+		presigner: s3.NewPresignClient(client),
+		config:    cfg,
+		logger:    logger,
+	}, nil
+}
+
+// MaxSizeBytes returns the largest accepted upload
+func (s *Service) MaxSizeBytes() int64 {
+	return s.config.MaxSizeBytes
+}
+
+// Upload stores a gzipped tarball read from body as a build context of a
+// service. The body is spooled to disk to hash it and learn its size before
+// it is sent to object storage.
+func (s *Service) Upload(ctx context.Context, serviceID uuid.UUID, body io.Reader, uploadedBy string) (*db.BuildContext, error) {
+	tmp, err := os.CreateTemp("", "build-context-*.tar.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), io.LimitReader(body, s.config.MaxSizeBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build context: %w", err)
+	}
+	if size > s.config.MaxSizeBytes {
+		return nil, ErrTooLarge
+	}
+	if err := checkGzip(tmp); err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind build context: %w", err)
+	}
+
+	digest := hex.EncodeToString(hasher.Sum(nil))
+	bc := &db.BuildContext{
+		ID:         uuid.New(),
+		ServiceID:  serviceID,
+		Digest:     digest,
+		SizeBytes:  size,
+		UploadedBy: uploadedBy,
+		ExpiresAt:  time.Now().Add(s.config.TTL),
+	}
+	bc.ObjectKey = fmt.Sprintf("%s/%s/%s.tar.gz", objectPrefix, serviceID, bc.ID)
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.config.Bucket),
+		Key:           aws.String(bc.ObjectKey),
+		Body:          tmp,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String("application/gzip"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload build context: %w", err)
+	}
+
+	if err := s.repos.BuildContexts.Create(ctx, bc); err != nil {
+		return nil, fmt.Errorf("failed to record build context: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"service_id":       serviceID,
+		"build_context_id": bc.ID,
+		"size_bytes":       size,
+	}).Info("Build context uploaded")
+
+	return bc, nil
+}
+
+// SourceURL returns a short-lived URL the builder downloads a context from
+func (s *Service) SourceURL(ctx context.Context, bc *db.BuildContext) (string, error) {
+	if bc.PurgedAt != nil || time.Now().After(bc.ExpiresAt) {
+		return "", ErrExpired
+	}
+
+	req, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(bc.ObjectKey),
+	}, s3.WithPresignExpires(sourceURLExpiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign build context URL: %w", err)
+	}
+	return req.URL, nil
+}
+
+// PurgeExpired deletes the tarballs of contexts past their TTL. Their rows
+// stay, so releases built from them remain marked as local builds.
+func (s *Service) PurgeExpired(ctx context.Context) {
+	expired, err := s.repos.BuildContexts.ListExpired(ctx, time.Now(), purgeBatchSize)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list expired build contexts")
+		return
+	}
+
+	for _, bc := range expired {
+		_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s.config.Bucket),
+			Key:    aws.String(bc.ObjectKey),
+		})
+		if err != nil {
+			s.logger.WithError(err).WithField("build_context_id", bc.ID).Warn("Failed to delete expired build context")
+			continue
+		}
+		if err := s.repos.BuildContexts.MarkPurged(ctx, bc.ID); err != nil {
+			s.logger.WithError(err).WithField("build_context_id", bc.ID).Warn("Failed to mark build context purged")
+		}
+	}
+
+	if len(expired) > 0 {
+		s.logger.WithField("count", len(expired)).Info("Purged expired build contexts")
+	}
+}
+
+// checkGzip verifies a spooled upload starts with the gzip magic number
+func checkGzip(f *os.File) error {
+	magic := make([]byte, 2)
+	if _, err := f.ReadAt(magic, 0); err != nil {
+		return ErrNotGzip
+	}
+	if magic[0] != 0x1f || magic[1] != 0x8b {
+		return ErrNotGzip
+	}
+	return nil
+}
@@ -3,9 +3,7 @@ package builder
 import (
 	"context"
 	"fmt"
-	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -105,32 +103,8 @@ func (b *BuildpacksBuilder) detectBuildStrategy(sourcePath string, config types.
 	}
 
 	// Auto-detect based on files present
-	files := []string{
-		"Dockerfile",
-		"package.json",
-		"go.mod",
-		"requirements.txt",
-		"Gemfile",
-		"pom.xml",
-	}
-
-	for _, file := range files {
-		if _, err := os.Stat(filepath.Join(sourcePath, file)); err == nil {
-			switch file {
-			case "Dockerfile":
-				return "dockerfile", nil
-			case "package.json":
-				return "buildpacks", nil
-			case "go.mod":
-				return "buildpacks", nil
-			case "requirements.txt":
-				return "buildpacks", nil
-			case "Gemfile":
-				return "buildpacks", nil
-			case "pom.xml":
-				return "buildpacks", nil
-			}
-		}
+	if runtime := DetectRuntime(sourcePath); runtime != nil {
+		return runtime.BuildStrategy, nil
 	}
 
 	// Default to buildpacks
@@ -0,0 +1,204 @@
+package builder
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Sources of runtime detection
+const (
+	DetectedFromRegistration = "registration"
+	DetectedFromBuild        = "build"
+)
+
+// frameworkDefaults holds the conventional port and a probe path that works
+// out of the box for a framework (most frameworks don't ship a /health route)
+type frameworkDefaults struct {
+	port       int
+	healthPath string
+}
+
+var frameworkDefaultsByName = map[string]frameworkDefaults{
+	"nextjs":  {port: 3000, healthPath: "/"},
+	"remix":   {port: 3000, healthPath: "/"},
+	"nuxt":    {port: 3000, healthPath: "/"},
+	"nestjs":  {port: 3000},
+	"express": {port: 3000},
+	"fastify": {port: 3000},
+	"vite":    {port: 4173, healthPath: "/"},
+	"gin":     {port: 8080},
+	"echo":    {port: 8080},
+	"fiber":   {port: 3000},
+	"fastapi": {port: 8000, healthPath: "/docs"},
+	"flask":   {port: 5000},
+	"django":  {port: 8000},
+	"rails":   {port: 3000, healthPath: "/up"},
+	"spring":  {port: 8080, healthPath: "/actuator/health"},
+}
+
+var languageDefaultPorts = map[string]int{
+	"nodejs": 3000,
+	"go":     8080,
+	"python": 8000,
+	"ruby":   3000,
+	"java":   8080,
+	"rust":   8080,
+	"static": 8080,
+}
+
+// DetectRuntime inspects a source directory and reports its language, framework,
+// and package manager. Returns nil when no known project files are present.
+func DetectRuntime(sourcePath string) *types.ServiceRuntime {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(sourcePath, name))
+		return err == nil
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(sourcePath, name))
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+
+	rt := &types.ServiceRuntime{
+		HasDockerfile: exists("Dockerfile"),
+		BuildStrategy: "buildpacks",
+		DetectedAt:    time.Now(),
+	}
+	if rt.HasDockerfile {
+		rt.BuildStrategy = "dockerfile"
+	}
+
+	switch {
+	case exists("package.json"):
+		rt.Language = "nodejs"
+		rt.PackageManager = detectNodePackageManager(exists)
+		rt.Framework = detectNodeFramework(read("package.json"))
+	case exists("go.mod"):
+		rt.Language = "go"
+		rt.PackageManager = "go"
+		rt.Framework = detectByContent(read("go.mod"), [][2]string{
+			{"github.com/gin-gonic/gin", "gin"},
+			{"github.com/labstack/echo", "echo"},
+			{"github.com/gofiber/fiber", "fiber"},
+		})
+	case exists("pyproject.toml") || exists("requirements.txt") || exists("Pipfile"):
+		rt.Language = "python"
+		rt.PackageManager = detectPythonPackageManager(exists, read("pyproject.toml"))
+		rt.Framework = detectByContent(strings.ToLower(read("pyproject.toml")+read("requirements.txt")+read("Pipfile")), [][2]string{
+			{"fastapi", "fastapi"},
+			{"django", "django"},
+			{"flask", "flask"},
+		})
+	case exists("Gemfile"):
+		rt.Language = "ruby"
+		rt.PackageManager = "bundler"
+		rt.Framework = detectByContent(read("Gemfile"), [][2]string{{"rails", "rails"}})
+	case exists("pom.xml") || exists("build.gradle") || exists("build.gradle.kts"):
+		rt.Language = "java"
+		rt.PackageManager = "maven"
+		if !exists("pom.xml") {
+			rt.PackageManager = "gradle"
+		}
+		rt.Framework = detectByContent(read("pom.xml")+read("build.gradle")+read("build.gradle.kts"), [][2]string{
+			{"spring-boot", "spring"},
+			{"org.springframework.boot", "spring"},
+		})
+	case exists("Cargo.toml"):
+		rt.Language = "rust"
+		rt.PackageManager = "cargo"
+	case exists("index.html"):
+		rt.Language = "static"
+	case rt.HasDockerfile:
+		rt.Language = "docker"
+	default:
+		return nil
+	}
+
+	if defaults, ok := frameworkDefaultsByName[rt.Framework]; ok {
+		rt.DefaultPort = defaults.port
+		rt.HealthPath = defaults.healthPath
+	} else {
+		rt.DefaultPort = languageDefaultPorts[rt.Language]
+	}
+
+	return rt
+}
+
+// detectNodePackageManager picks the package manager from lockfiles
+func detectNodePackageManager(exists func(string) bool) string {
+	switch {
+	case exists("pnpm-lock.yaml"):
+		return "pnpm"
+	case exists("yarn.lock"):
+		return "yarn"
+	case exists("bun.lockb"), exists("bun.lock"):
+		return "bun"
+	default:
+		return "npm"
+	}
+}
+
+// detectPythonPackageManager picks the package manager from lockfiles and pyproject sections
+func detectPythonPackageManager(exists func(string) bool, pyproject string) string {
+	switch {
+	case exists("uv.lock"):
+		return "uv"
+	case exists("poetry.lock"), strings.Contains(pyproject, "[tool.poetry]"):
+		return "poetry"
+	case exists("Pipfile"):
+		return "pipenv"
+	default:
+		return "pip"
+	}
+}
+
+// detectNodeFramework detects the framework from package.json dependencies.
+// Order matters: meta-frameworks are checked before the libraries they build on.
+func detectNodeFramework(packageJSON string) string {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(packageJSON), &pkg); err != nil {
+		return ""
+	}
+
+	has := func(name string) bool {
+		_, inDeps := pkg.Dependencies[name]
+		_, inDev := pkg.DevDependencies[name]
+		return inDeps || inDev
+	}
+
+	candidates := [][2]string{
+		{"next", "nextjs"},
+		{"@remix-run/node", "remix"},
+		{"nuxt", "nuxt"},
+		{"@nestjs/core", "nestjs"},
+		{"fastify", "fastify"},
+		{"express", "express"},
+		{"vite", "vite"},
+	}
+	for _, c := range candidates {
+		if has(c[0]) {
+			return c[1]
+		}
+	}
+	return ""
+}
+
+// detectByContent returns the framework of the first marker found in content
+func detectByContent(content string, markers [][2]string) string {
+	for _, m := range markers {
+		if strings.Contains(content, m[0]) {
+			return m[1]
+		}
+	}
+	return ""
+}
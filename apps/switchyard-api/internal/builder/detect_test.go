@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectRuntime(t *testing.T) {
+	tests := []struct {
+		name               string
+		files              map[string]string
+		wantNil            bool
+		wantLanguage       string
+		wantFramework      string
+		wantPackageManager string
+		wantStrategy       string
+		wantPort           int
+		wantHealthPath     string
+	}{
+		{
+			name:    "empty directory",
+			files:   map[string]string{},
+			wantNil: true,
+		},
+		{
+			name: "nextjs with pnpm",
+			files: map[string]string{
+				"package.json":   `{"dependencies": {"next": "14.0.0", "react": "18.0.0"}}`,
+				"pnpm-lock.yaml": "",
+			},
+			wantLanguage:       "nodejs",
+			wantFramework:      "nextjs",
+			wantPackageManager: "pnpm",
+			wantStrategy:       "buildpacks",
+			wantPort:           3000,
+			wantHealthPath:     "/",
+		},
+		{
+			name: "go gin with dockerfile",
+			files: map[string]string{
+				"go.mod":     "module example.com/api\n\nrequire github.com/gin-gonic/gin v1.9.1\n",
+				"Dockerfile": "FROM golang:1.22",
+			},
+			wantLanguage:       "go",
+			wantFramework:      "gin",
+			wantPackageManager: "go",
+			wantStrategy:       "dockerfile",
+			wantPort:           8080,
+		},
+		{
+			name: "fastapi with poetry",
+			files: map[string]string{
+				"pyproject.toml": "[tool.poetry]\nname = \"api\"\n\n[tool.poetry.dependencies]\nfastapi = \"^0.110\"\n",
+			},
+			wantLanguage:       "python",
+			wantFramework:      "fastapi",
+			wantPackageManager: "poetry",
+			wantStrategy:       "buildpacks",
+			wantPort:           8000,
+			wantHealthPath:     "/docs",
+		},
+		{
+			name: "dockerfile only",
+			files: map[string]string{
+				"Dockerfile": "FROM nginx",
+			},
+			wantLanguage: "docker",
+			wantStrategy: "dockerfile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+
+			got := DetectRuntime(dir)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("DetectRuntime() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("DetectRuntime() returned nil")
+			}
+
+			if got.Language != tt.wantLanguage {
+				t.Errorf("Language = %q, want %q", got.Language, tt.wantLanguage)
+			}
+			if got.Framework != tt.wantFramework {
+				t.Errorf("Framework = %q, want %q", got.Framework, tt.wantFramework)
+			}
+			if got.PackageManager != tt.wantPackageManager {
+				t.Errorf("PackageManager = %q, want %q", got.PackageManager, tt.wantPackageManager)
+			}
+			if got.BuildStrategy != tt.wantStrategy {
+				t.Errorf("BuildStrategy = %q, want %q", got.BuildStrategy, tt.wantStrategy)
+			}
+			if got.DefaultPort != tt.wantPort {
+				t.Errorf("DefaultPort = %d, want %d", got.DefaultPort, tt.wantPort)
+			}
+			if got.HealthPath != tt.wantHealthPath {
+				t.Errorf("HealthPath = %q, want %q", got.HealthPath, tt.wantHealthPath)
+			}
+		})
+	}
+}
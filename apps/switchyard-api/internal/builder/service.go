@@ -3,6 +3,7 @@ package builder
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/sbom"
@@ -116,13 +117,14 @@ type CompleteBuildResult struct {
 	Logs          []string
 	Duration      time.Duration
 	ClonePath     string
-	SBOM          *sbom.SBOM          // Software Bill of Materials
-	SBOMFormat    string              // e.g., "cyclonedx-json"
-	SBOMGenerated bool                // Whether SBOM was successfully generated
-	Signature     *signing.SignResult // Image signature information
-	ImageSigned   bool                // Whether image was successfully signed
-	CacheHit      bool                // Whether build used cached layers
-	CacheImage    string              // Cache image URI used
+	SBOM          *sbom.SBOM            // Software Bill of Materials
+	SBOMFormat    string                // e.g., "cyclonedx-json"
+	SBOMGenerated bool                  // Whether SBOM was successfully generated
+	Signature     *signing.SignResult   // Image signature information
+	ImageSigned   bool                  // Whether image was successfully signed
+	CacheHit      bool                  // Whether build used cached layers
+	CacheImage    string                // Cache image URI used
+	Runtime       *types.ServiceRuntime // Detected language/framework (nil if unknown)
 }
 
 // BuildFromGit clones a repository and builds it
@@ -159,6 +161,14 @@ func (s *Service) BuildFromGit(ctx context.Context, service *types.Service, gitS
 		}()
 	}
 
+	// Detect language/framework from the checked-out source
+	result.Runtime = DetectRuntime(filepath.Join(cloneResult.Path, service.AppPath))
+	if result.Runtime != nil {
+		result.Runtime.DetectedFrom = DetectedFromBuild
+		result.Logs = append(result.Logs, fmt.Sprintf("Detected runtime: %s %s (%s)",
+			result.Runtime.Language, result.Runtime.Framework, result.Runtime.PackageManager))
+	}
+
 	// Step 2: Build the service
 	result.Logs = append(result.Logs, fmt.Sprintf("Starting build for service: %s", service.Name))
 	buildResult, err := s.builder.BuildService(buildCtx, service, gitSHA, cloneResult.Path)
@@ -222,6 +232,28 @@ func (s *Service) BuildFromGit(ctx context.Context, service *types.Service, gitS
 	return result
 }
 
+// DetectFromGit shallow-clones a service's repository and detects its runtime.
+// Used at registration time, before any build has run.
+func (s *Service) DetectFromGit(ctx context.Context, service *types.Service) (*types.ServiceRuntime, error) {
+	cloneResult := s.git.CloneShallow(ctx, service.GitRepo, "HEAD")
+	if !cloneResult.Success {
+		return nil, fmt.Errorf("clone failed: %w", cloneResult.Error)
+	}
+	if cloneResult.CleanupFn != nil {
+		defer func() {
+			if cleanupErr := cloneResult.CleanupFn(); cleanupErr != nil {
+				s.logger.Errorf("Failed to cleanup clone directory: %v", cleanupErr)
+			}
+		}()
+	}
+
+	runtime := DetectRuntime(filepath.Join(cloneResult.Path, service.AppPath))
+	if runtime != nil {
+		runtime.DetectedFrom = DetectedFromRegistration
+	}
+	return runtime, nil
+}
+
 // ValidateService checks if a service can be built
 func (s *Service) ValidateService(ctx context.Context, service *types.Service) error {
 	// Validate git repository
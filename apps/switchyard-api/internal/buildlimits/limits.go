@@ -0,0 +1,145 @@
+// Package buildlimits holds the build resources of services to the build
+// limits of their team: the most CPU and memory a build may request or be
+// limited to, and the longest timeout it may set. Services are checked when
+// they set their build resources, and every build is fitted into the limits
+// when it is enqueued, since a platform admin may have lowered them since.
+// Builds that keep the defaults are not limited, nor are projects without a
+// team and teams without limits.
+package buildlimits
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ErrLimitExceeded is returned when build resources are over a team's
+// build limits
+var ErrLimitExceeded = errors.New("team build limit exceeded")
+
+// Validate checks a team's build limits
+func Validate(limits *types.TeamBuildLimits) error {
+	if limits == nil {
+		return nil
+	}
+	if _, err := k8s.ParsePositiveQuantity(limits.MaxCPU); err != nil {
+		return fmt.Errorf("max_cpu: %w", err)
+	}
+	if _, err := k8s.ParsePositiveQuantity(limits.MaxMemory); err != nil {
+		return fmt.Errorf("max_memory: %w", err)
+	}
+	if limits.MaxTimeoutMinutes < 0 || limits.MaxTimeoutMinutes > k8s.MaxBuildTimeoutMinutes {
+		return fmt.Errorf("max_timeout_minutes must be between 1 and %d", k8s.MaxBuildTimeoutMinutes)
+	}
+	return nil
+}
+
+// Check checks the build resources of a service of a project against the
+// build limits of the project's team
+func Check(ctx context.Context, repos *db.Repositories, projectID uuid.UUID, r *types.BuildResources) error {
+	if r == nil {
+		return nil
+	}
+	limits, err := forProject(ctx, repos, projectID)
+	if err != nil {
+		return err
+	}
+	return Evaluate(limits, r)
+}
+
+// Apply fits the build resources of a service of a project into the build
+// limits of the project's team
+func Apply(ctx context.Context, repos *db.Repositories, projectID uuid.UUID, r *types.BuildResources) (*types.BuildResources, error) {
+	if r == nil {
+		return nil, nil
+	}
+	limits, err := forProject(ctx, repos, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return Fit(limits, r), nil
+}
+
+func forProject(ctx context.Context, repos *db.Repositories, projectID uuid.UUID) (*types.TeamBuildLimits, error) {
+	limits, err := repos.Teams.GetBuildLimitsByProject(ctx, projectID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build limits: %w", err)
+	}
+	return limits, nil
+}
+
+// Evaluate checks that build resources are within a team's build limits
+func Evaluate(limits *types.TeamBuildLimits, r *types.BuildResources) error {
+	if limits == nil || r == nil {
+		return nil
+	}
+	for _, v := range []struct{ field, value, max string }{
+		{"cpu_request", r.CPURequest, limits.MaxCPU},
+		{"cpu_limit", r.CPULimit, limits.MaxCPU},
+		{"memory_request", r.MemoryRequest, limits.MaxMemory},
+		{"memory_limit", r.MemoryLimit, limits.MaxMemory},
+	} {
+		if exceeds(v.value, v.max) {
+			return fmt.Errorf("%w: %s %s is over the team's maximum of %s", ErrLimitExceeded, v.field, v.value, v.max)
+		}
+	}
+	if limits.MaxTimeoutMinutes > 0 && r.TimeoutMinutes > limits.MaxTimeoutMinutes {
+		return fmt.Errorf("%w: timeout_minutes %d is over the team's maximum of %d",
+			ErrLimitExceeded, r.TimeoutMinutes, limits.MaxTimeoutMinutes)
+	}
+	return nil
+}
+
+// Fit returns build resources lowered to a team's build limits where they
+// are over them
+func Fit(limits *types.TeamBuildLimits, r *types.BuildResources) *types.BuildResources {
+	if limits == nil || r == nil {
+		return r
+	}
+	fitted := *r
+	for _, v := range []struct {
+		value *string
+		max   string
+	}{
+		{&fitted.CPURequest, limits.MaxCPU},
+		{&fitted.CPULimit, limits.MaxCPU},
+		{&fitted.MemoryRequest, limits.MaxMemory},
+		{&fitted.MemoryLimit, limits.MaxMemory},
+	} {
+		if exceeds(*v.value, v.max) {
+			*v.value = v.max
+		}
+	}
+	if limits.MaxTimeoutMinutes > 0 && fitted.TimeoutMinutes > limits.MaxTimeoutMinutes {
+		fitted.TimeoutMinutes = limits.MaxTimeoutMinutes
+	}
+	return &fitted
+}
+
+// exceeds tells whether a quantity is over a maximum; unset or invalid
+// values are left to validation
+func exceeds(value, max string) bool {
+	if value == "" || max == "" {
+		return false
+	}
+	v, err := resource.ParseQuantity(value)
+	if err != nil {
+		return false
+	}
+	m, err := resource.ParseQuantity(max)
+	if err != nil {
+		return false
+	}
+	return v.Cmp(m) > 0
+}
@@ -0,0 +1,66 @@
+package buildlimits
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestEvaluate(t *testing.T) {
+	limits := &types.TeamBuildLimits{MaxCPU: "8", MaxMemory: "16Gi", MaxTimeoutMinutes: 60}
+	tests := []struct {
+		name      string
+		limits    *types.TeamBuildLimits
+		resources *types.BuildResources
+		wantErr   bool
+	}{
+		{name: "defaults", limits: limits},
+		{name: "no limits", resources: &types.BuildResources{CPULimit: "64", TimeoutMinutes: 300}},
+		{name: "within", limits: limits, resources: &types.BuildResources{CPULimit: "8", MemoryLimit: "16384Mi", TimeoutMinutes: 60}},
+		{name: "cpu over", limits: limits, resources: &types.BuildResources{CPURequest: "8500m"}, wantErr: true},
+		{name: "memory over", limits: limits, resources: &types.BuildResources{MemoryLimit: "32Gi"}, wantErr: true},
+		{name: "timeout over", limits: limits, resources: &types.BuildResources{TimeoutMinutes: 90}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Evaluate(tt.limits, tt.resources)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Evaluate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrLimitExceeded) {
+				t.Errorf("Evaluate() error = %v, want ErrLimitExceeded", err)
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	limits := &types.TeamBuildLimits{MaxCPU: "4", MaxMemory: "8Gi", MaxTimeoutMinutes: 60}
+	resources := &types.BuildResources{CPURequest: "2", CPULimit: "8", MemoryLimit: "16Gi", TimeoutMinutes: 120, CacheTTLHours: 24}
+
+	fitted := Fit(limits, resources)
+	want := types.BuildResources{CPURequest: "2", CPULimit: "4", MemoryLimit: "8Gi", TimeoutMinutes: 60, CacheTTLHours: 24}
+	if *fitted != want {
+		t.Errorf("Fit() = %+v, want %+v", *fitted, want)
+	}
+	if resources.CPULimit != "8" {
+		t.Error("Fit() changed the service's resources")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(&types.TeamBuildLimits{MaxCPU: "8", MaxMemory: "16Gi", MaxTimeoutMinutes: 120}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, limits := range []*types.TeamBuildLimits{
+		{MaxCPU: "eight"},
+		{MaxMemory: "-1Gi"},
+		{MaxTimeoutMinutes: -5},
+	} {
+		if err := Validate(limits); err == nil {
+			t.Errorf("Validate(%+v) error = nil, want an error", limits)
+		}
+	}
+}
@@ -77,6 +77,9 @@ type CacheService interface {
 	RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error
 	IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
 
+	// Rate limiting shared by every replica
+	AllowRate(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error)
+
 	// Lifecycle
 	Close() error
 }
@@ -126,8 +129,14 @@ const (
 	DeploymentCacheKey      = "deployment:%s"
 	UserCacheKey            = "user:%s"
 	ProjectServicesCacheKey = "project:%s:services"
+	ProjectSlugCacheKey     = "project:slug:%s" // ID of the project with a slug
+	EnvironmentCacheKey     = "environment:%s"
+	ProjectEnvCacheKey      = "project:%s:env:%s" // Environment of a project by name
 	ServiceReleasesCacheKey = "service:%s:releases"
 	SessionRevokedKey       = "session:revoked:%s" // For JWT session revocation
+	SessionMetaKey          = "session:meta:%s"    // Metadata of active JWT sessions
+	UserSessionsKey         = "user:%s:sessions"   // IDs of a user's sessions
+	RateLimitKey            = "ratelimit:%s"       // Sliding window of a rate limit key
 
 	// Cache tags for invalidation
 	ProjectTag    = "project"
@@ -568,6 +577,181 @@ func (r *RedisCache) IsSessionRevoked(ctx context.Context, sessionID string) (bo
 	return exists > 0, nil
 }
 
+// Session is the metadata of an active JWT session, kept next to its
+// revocation marker so users can see where they are signed in
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`   // When the user signed in
+	LastUsedAt time.Time `json:"last_used_at"` // When the session last signed in or refreshed its tokens
+	ExpiresAt  time.Time `json:"expires_at"`   // When its refresh token expires
+}
+
+// SaveSession stores the metadata of a session until its refresh token
+// expires, indexed by user
+func (r *RedisCache) SaveSession(ctx context.Context, session *Session) error {
+	if r == nil || r.client == nil {
+		return fmt.Errorf("cache not available")
+	}
+
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	// Sessions all last as long, so the newest one expires last and the
+	// index can share its TTL
+	userKey := fmt.Sprintf(UserSessionsKey, session.UserID)
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf(SessionMetaKey, session.ID), data, ttl)
+	pipe.SAdd(ctx, userKey, session.ID)
+	pipe.Expire(ctx, userKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// GetSession returns the metadata of a session, or ErrCacheMiss
+func (r *RedisCache) GetSession(ctx context.Context, sessionID string) (*Session, error) {
+	if r == nil || r.client == nil {
+		return nil, fmt.Errorf("cache not available")
+	}
+
+	data, err := r.Get(ctx, fmt.Sprintf(SessionMetaKey, sessionID))
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return &session, nil
+}
+
+// ListSessions returns the metadata of a user's active sessions. Sessions
+// that expired are dropped from the index.
+func (r *RedisCache) ListSessions(ctx context.Context, userID string) ([]*Session, error) {
+	if r == nil || r.client == nil {
+		return nil, fmt.Errorf("cache not available")
+	}
+
+	userKey := fmt.Sprintf(UserSessionsKey, userID)
+	ids, err := r.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(ids) == 0 {
+		return []*Session{}, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf(SessionMetaKey, id)
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	sessions := make([]*Session, 0, len(values))
+	var expired []interface{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, ids[i])
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil {
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+	if len(expired) > 0 {
+		r.client.SRem(ctx, userKey, expired...)
+	}
+
+	return sessions, nil
+}
+
+// DeleteSession removes the metadata of a session
+func (r *RedisCache) DeleteSession(ctx context.Context, userID, sessionID string) error {
+	if r == nil || r.client == nil {
+		return fmt.Errorf("cache not available")
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, fmt.Sprintf(SessionMetaKey, sessionID))
+	pipe.SRem(ctx, fmt.Sprintf(UserSessionsKey, userID), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// slidingWindowScript counts the requests of a key in the last window in a
+// sorted set scored by time, and adds the request when it fits. Time comes
+// from Redis so replicas with skewed clocks share one window. It returns
+// whether the request is allowed, the requests left, and when the oldest
+// request leaves the window, in Unix milliseconds.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local member = ARGV[3]
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = now + window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window
+end
+return {allowed, limit - count, reset}
+`)
+
+// AllowRate records a request of a rate limit key and reports whether it
+// is within limit requests in the sliding window, the requests left, and
+// when the next one frees up
+func (r *RedisCache) AllowRate(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error) {
+	if r == nil || r.client == nil {
+		return false, 0, time.Time{}, fmt.Errorf("cache not available")
+	}
+
+	member := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(rateLimitSeq.Add(1), 36)
+	result, err := slidingWindowScript.Run(ctx, r.client, []string{fmt.Sprintf(RateLimitKey, key)},
+		limit, window.Milliseconds(), member).Int64Slice()
+	if err != nil {
+		return false, 0, time.Time{}, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if len(result) != 3 {
+		return false, 0, time.Time{}, fmt.Errorf("unexpected rate limit result %v", result)
+	}
+	return result[0] == 1, int(result[1]), time.UnixMilli(result[2]), nil
+}
+
+// rateLimitSeq keeps the sorted set members of requests made in the same
+// nanosecond apart
+var rateLimitSeq atomic.Int64
+
 // Close connection
 func (r *RedisCache) Close() error {
 	if r == nil || r.client == nil {
@@ -618,6 +802,18 @@ func UserKey(userID string) string {
 	return fmt.Sprintf(UserCacheKey, userID)
 }
 
+func ProjectSlugKey(slug string) string {
+	return fmt.Sprintf(ProjectSlugCacheKey, slug)
+}
+
+func EnvironmentKey(environmentID string) string {
+	return fmt.Sprintf(EnvironmentCacheKey, environmentID)
+}
+
+func ProjectEnvironmentKey(projectID, name string) string {
+	return fmt.Sprintf(ProjectEnvCacheKey, projectID, name)
+}
+
 func ProjectServicesKey(projectID string) string {
 	return fmt.Sprintf(ProjectServicesCacheKey, projectID)
 }
@@ -39,6 +39,11 @@ type RoundhouseBuildConfig struct {
 	Context    string            `json:"context"`
 	BuildArgs  map[string]string `json:"build_args"`
 	Target     string            `json:"target"`
+	GPU        bool              `json:"gpu,omitempty"`
+	Artifacts  string            `json:"artifacts,omitempty"`
+
+	// Resources sizes the build, fitted into the team's build limits
+	Resources *types.BuildResources `json:"resources,omitempty"`
 }
 
 // EnqueueRequest is the request body for enqueueing a build job
@@ -53,6 +58,27 @@ type EnqueueRequest struct {
 	BuildConfig RoundhouseBuildConfig `json:"build_config"`
 	CallbackURL string                `json:"callback_url"`
 	Priority    int                   `json:"priority"`
+
+	// Metadata for the Roundhouse build history
+	CommitMessage string `json:"commit_message,omitempty"`
+	TriggeredBy   string `json:"triggered_by,omitempty"`
+
+	// SourceURL is a download URL of a gzipped build context to build
+	// instead of cloning GitRepo
+	SourceURL string `json:"source_url,omitempty"`
+
+	// RegistrySecret names the Docker config secret in the build namespace
+	// with the project's registry credentials; empty for the platform's
+	RegistrySecret string `json:"registry_secret,omitempty"`
+	// ImageRepository is where the image is pushed instead of the platform
+	// registry, e.g. registry.example.com/team
+	ImageRepository string `json:"image_repository,omitempty"`
+	// BuildSecrets names the secret in the build namespace with the
+	// project's build secrets, mounted under /run/secrets in the build
+	BuildSecrets string `json:"build_secrets,omitempty"`
+	// LogRedactionDisabled stops Roundhouse masking token patterns in the
+	// build logs; build secrets are masked regardless
+	LogRedactionDisabled bool `json:"log_redaction_disabled,omitempty"`
 }
 
 // EnqueueResponse is the response from enqueueing a build job
@@ -75,6 +101,9 @@ func BuildServiceConfigToRoundhouse(cfg types.BuildConfig) RoundhouseBuildConfig
 		Context:    context,
 		BuildArgs:  cfg.BuildArgs,
 		Target:     cfg.Target,
+		GPU:        cfg.GPU,
+		Artifacts:  cfg.Artifacts,
+		Resources:  cfg.Resources,
 	}
 }
 
@@ -118,6 +147,62 @@ func (c *RoundhouseClient) Enqueue(ctx context.Context, req *EnqueueRequest) (*E
 	return &enqueueResp, nil
 }
 
+// ImageGCFailure is an image Roundhouse could not delete
+type ImageGCFailure struct {
+	Image string `json:"image"`
+	Error string `json:"error"`
+}
+
+// ImageGCResult matches Roundhouse's gc.Result. Missing images were already
+// gone; skipped ones are outside Roundhouse's registry or on one that does
+// not allow deletion.
+type ImageGCResult struct {
+	Deleted []string         `json:"deleted"`
+	Missing []string         `json:"missing"`
+	Skipped []string         `json:"skipped"`
+	Failed  []ImageGCFailure `json:"failed"`
+}
+
+// CollectImages asks Roundhouse to delete images from its registry
+func (c *RoundhouseClient) CollectImages(ctx context.Context, images []string) (*ImageGCResult, error) {
+	body, err := json.Marshal(map[string][]string{"images": images})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal gc request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/gc", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to roundhouse: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("roundhouse returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result ImageGCResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // GetJobStatus retrieves the status of a build job
 func (c *RoundhouseClient) GetJobStatus(ctx context.Context, jobID uuid.UUID) (string, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/internal/jobs/"+jobID.String()+"/status", nil)
@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Waybill event types sent by the API
+const (
+	// WaybillEventStorageUsage is a point-in-time storage sample with a
+	// "size_gb" metric; Waybill bills each hour at the latest sample
+	WaybillEventStorageUsage = "storage.usage"
+
+	// WaybillEventIdleSavings is compute a service scaled to zero did not
+	// use over a period, with a "saved_gb_hours" metric; it is not billed
+	WaybillEventIdleSavings = "compute.idle_savings"
+)
+
+// WaybillClient is an HTTP client for reporting billable usage to Waybill
+type WaybillClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewWaybillClient creates a new Waybill API client
+func NewWaybillClient(baseURL, apiKey string) *WaybillClient {
+	return &WaybillClient{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// WaybillEvent matches Waybill's events.EventRequest
+type WaybillEvent struct {
+	EventType    string             `json:"event_type"`
+	ProjectID    uuid.UUID          `json:"project_id"`
+	TeamID       *uuid.UUID         `json:"team_id,omitempty"`
+	ResourceType string             `json:"resource_type"`
+	ResourceID   uuid.UUID          `json:"resource_id"`
+	ResourceName string             `json:"resource_name,omitempty"`
+	Metrics      map[string]float64 `json:"metrics"`
+	Metadata     map[string]string  `json:"metadata,omitempty"`
+	Timestamp    *time.Time         `json:"timestamp,omitempty"`
+
+	// IdempotencyKey makes retried sends of the event record it once
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// RecordEvent sends a usage event to Waybill
+func (c *WaybillClient) RecordEvent(ctx context.Context, event *WaybillEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage event: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/events", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request to waybill: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("waybill returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -59,6 +59,32 @@ func NewClient(cfg *Config) (*Client, error) {
 	return client, nil
 }
 
+// NewZoneClient creates a client for the DNS records of a single zone, such
+// as one of a team's own zones. Only an API token and zone ID are required.
+func NewZoneClient(cfg *Config) (*Client, error) {
+	if cfg.APIToken == "" {
+		return nil, fmt.Errorf("cloudflare: API token is required")
+	}
+	if cfg.ZoneID == "" {
+		return nil, fmt.Errorf("cloudflare: zone ID is required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		baseURL:   baseURL,
+		apiToken:  cfg.APIToken,
+		accountID: cfg.AccountID,
+		zoneID:    cfg.ZoneID,
+	}, nil
+}
+
 // doRequest performs an authenticated HTTP request to the Cloudflare API
 func (c *Client) doRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
 	reqURL := c.baseURL + path
@@ -105,6 +131,28 @@ func (c *Client) put(ctx context.Context, path string, body io.Reader, result in
 	return c.handleResponse(resp, result)
 }
 
+// post performs a POST request and decodes the response
+func (c *Client) post(ctx context.Context, path string, body io.Reader, result interface{}) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return c.handleResponse(resp, result)
+}
+
+// delete performs a DELETE request and decodes the response
+func (c *Client) delete(ctx context.Context, path string, result interface{}) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return c.handleResponse(resp, result)
+}
+
 // handleResponse processes the API response
 func (c *Client) handleResponse(resp *http.Response, result interface{}) error {
 	body, err := io.ReadAll(resp.Body)
@@ -1,7 +1,9 @@
 package cloudflare
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
@@ -156,3 +158,72 @@ func (c *Client) CheckDomainExists(ctx context.Context, domain string) (bool, er
 	}
 	return record != nil, nil
 }
+
+// DNSRecordInput is the writable part of a DNS record
+type DNSRecordInput struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"` // 1 means automatic
+	Proxied bool   `json:"proxied"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// CreateDNSRecord creates a DNS record in the configured zone
+func (c *Client) CreateDNSRecord(ctx context.Context, input DNSRecordInput) (*DNSRecord, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode DNS record: %w", err)
+	}
+
+	var resp APIResponse[DNSRecord]
+	path := fmt.Sprintf("/zones/%s/dns_records", c.zoneID)
+
+	if err := c.post(ctx, path, bytes.NewReader(body), &resp); err != nil {
+		return nil, fmt.Errorf("failed to create %s record for %s: %w", input.Type, input.Name, err)
+	}
+
+	return &resp.Result, nil
+}
+
+// UpdateDNSRecord overwrites a DNS record in the configured zone
+func (c *Client) UpdateDNSRecord(ctx context.Context, recordID string, input DNSRecordInput) (*DNSRecord, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode DNS record: %w", err)
+	}
+
+	var resp APIResponse[DNSRecord]
+	path := fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, recordID)
+
+	if err := c.put(ctx, path, bytes.NewReader(body), &resp); err != nil {
+		return nil, fmt.Errorf("failed to update %s record for %s: %w", input.Type, input.Name, err)
+	}
+
+	return &resp.Result, nil
+}
+
+// DeleteDNSRecord deletes a DNS record from the configured zone
+func (c *Client) DeleteDNSRecord(ctx context.Context, recordID string) error {
+	path := fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, recordID)
+
+	if err := c.delete(ctx, path, nil); err != nil {
+		return fmt.Errorf("failed to delete DNS record %s: %w", recordID, err)
+	}
+
+	return nil
+}
+
+// GetZone checks that the configured zone is reachable with the API token
+// and returns its name
+func (c *Client) GetZone(ctx context.Context) (string, error) {
+	var resp APIResponse[struct {
+		Name string `json:"name"`
+	}]
+
+	if err := c.get(ctx, fmt.Sprintf("/zones/%s", c.zoneID), nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to get zone %s: %w", c.zoneID, err)
+	}
+
+	return resp.Result.Name, nil
+}
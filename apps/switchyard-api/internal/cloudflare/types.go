@@ -8,6 +8,7 @@ type Config struct {
 	AccountID string // Cloudflare account ID
 	ZoneID    string // Primary zone ID (e.g., enclii.dev zone)
 	TunnelID  string // Production tunnel ID
+	BaseURL   string // Optional API URL override; used by NewZoneClient
 }
 
 // APIResponse wraps all Cloudflare API responses
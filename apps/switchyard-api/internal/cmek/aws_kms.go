@@ -0,0 +1,204 @@
+package cmek
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// AWS KMS error types that mean the customer has taken the key away from us
+var revokedKMSErrors = map[string]bool{
+	"AccessDeniedException":      true,
+	"DisabledException":          true,
+	"KMSInvalidStateException":   true,
+	"NotFoundException":          true,
+	"IncorrectKeyException":      true,
+	"InvalidGrantTokenException": true,
+}
+
+// AWSKMSConfig configures access to customer keys in AWS KMS. Customers grant
+// the platform's IAM principal kms:DescribeKey, kms:GenerateDataKey, and
+// kms:Decrypt on their key.
+type AWSKMSConfig struct {
+	Credentials aws.CredentialsProvider
+	Endpoint    string // Optional override (e.g. LocalStack); defaults to the key's regional endpoint
+}
+
+// LoadAWSCredentials returns static credentials when an access key is given,
+// or the default AWS credential chain (environment, IRSA, instance profile)
+func LoadAWSCredentials(ctx context.Context, accessKeyID, secretAccessKey string) (aws.CredentialsProvider, error) {
+	if accessKeyID != "" {
+		return credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, ""), nil
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if awsCfg.Credentials == nil {
+		return nil, fmt.Errorf("no AWS credentials found")
+	}
+	return awsCfg.Credentials, nil
+}
+
+// AWSKMS wraps data keys with one customer key in AWS KMS
+type AWSKMS struct {
+	keyARN      string
+	region      string
+	endpoint    string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// NewAWSKMSFactory returns a ProviderFactory for AWS KMS keys
+func NewAWSKMSFactory(cfg AWSKMSConfig) ProviderFactory {
+	return func(key *db.TeamEncryptionKey) (KeyProvider, error) {
+		if key.Provider != db.EncryptionKeyProviderAWSKMS {
+			return nil, fmt.Errorf("unsupported key provider: %s", key.Provider)
+		}
+		if cfg.Credentials == nil {
+			return nil, fmt.Errorf("AWS credentials for KMS are not configured")
+		}
+
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", key.Region)
+		}
+
+		return &AWSKMS{
+			keyARN:      key.KeyARN,
+			region:      key.Region,
+			endpoint:    strings.TrimSuffix(endpoint, "/"),
+			credentials: cfg.Credentials,
+			signer:      v4.NewSigner(),
+			httpClient:  &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	}
+}
+
+// Describe checks that the key is enabled
+func (k *AWSKMS) Describe(ctx context.Context) error {
+	var out struct {
+		KeyMetadata struct {
+			KeyState string `json:"KeyState"`
+		} `json:"KeyMetadata"`
+	}
+	if err := k.call(ctx, "DescribeKey", map[string]interface{}{"KeyId": k.keyARN}, &out); err != nil {
+		return err
+	}
+	if state := out.KeyMetadata.KeyState; state != "Enabled" {
+		return fmt.Errorf("%w: key state is %s", ErrKeyRevoked, state)
+	}
+	return nil
+}
+
+// GenerateDataKey returns a new AES-256 data key wrapped by the customer key
+func (k *AWSKMS) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	var out struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+		Plaintext      []byte `json:"Plaintext"`
+	}
+	in := map[string]interface{}{"KeyId": k.keyARN, "KeySpec": "AES_256"}
+	if err := k.call(ctx, "GenerateDataKey", in, &out); err != nil {
+		return nil, nil, err
+	}
+	return out.Plaintext, out.CiphertextBlob, nil
+}
+
+// Decrypt unwraps a data key with the customer key
+func (k *AWSKMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var out struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	in := map[string]interface{}{"KeyId": k.keyARN, "CiphertextBlob": wrapped}
+	if err := k.call(ctx, "Decrypt", in, &out); err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
+
+// call invokes a KMS JSON API operation signed with SigV4
+func (k *AWSKMS) call(ctx context.Context, operation string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", operation, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", operation, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+operation)
+
+	creds, err := k.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to load AWS credentials: %v", ErrKeyUnreachable, err)
+	}
+	hash := sha256.Sum256(body)
+	if err := k.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "kms", k.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign %s request: %w", operation, err)
+	}
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrKeyUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read %s response: %v", ErrKeyUnreachable, operation, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return kmsError(resp.StatusCode, respBody)
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", operation, err)
+	}
+	return nil
+}
+
+// kmsError classifies a KMS error response
+func kmsError(status int, body []byte) error {
+	var apiErr struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	_ = json.Unmarshal(body, &apiErr)
+
+	errType := apiErr.Type
+	if i := strings.LastIndex(errType, "#"); i >= 0 {
+		errType = errType[i+1:]
+	}
+	message := apiErr.Message
+	if message == "" {
+		message = apiErr.MessageUpper
+	}
+
+	switch {
+	case revokedKMSErrors[errType]:
+		return fmt.Errorf("%w: %s: %s", ErrKeyRevoked, errType, message)
+	case status >= 500 || errType == "KeyUnavailableException" || errType == "ThrottlingException":
+		return fmt.Errorf("%w: KMS returned %d %s: %s", ErrKeyUnreachable, status, errType, message)
+	default:
+		return fmt.Errorf("KMS returned %d %s: %s", status, errType, message)
+	}
+}
@@ -0,0 +1,183 @@
+package cmek
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+const testKeyARN = "arn:aws:kms:us-east-1:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+func TestParseKeyARN(t *testing.T) {
+	tests := []struct {
+		name       string
+		arn        string
+		wantRegion string
+		wantErr    bool
+	}{
+		{name: "key", arn: testKeyARN, wantRegion: "us-east-1"},
+		{name: "alias", arn: "arn:aws:kms:eu-west-1:111122223333:alias/enclii", wantRegion: "eu-west-1"},
+		{name: "not an ARN", arn: "1234abcd-12ab-34cd-56ef-1234567890ab", wantErr: true},
+		{name: "other service", arn: "arn:aws:s3:us-east-1:111122223333:key/abc", wantErr: true},
+		{name: "other resource", arn: "arn:aws:kms:us-east-1:111122223333:grant/abc", wantErr: true},
+		{name: "no region", arn: "arn:aws:kms::111122223333:key/abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, err := ParseKeyARN(tt.arn)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if region != tt.wantRegion {
+				t.Errorf("region = %q, want %q", region, tt.wantRegion)
+			}
+		})
+	}
+}
+
+// newTestKMS returns a provider for a fake KMS endpoint that answers each
+// operation from responses, keyed by the X-Amz-Target operation name
+func newTestKMS(t *testing.T, responses map[string]func(w http.ResponseWriter, in map[string]interface{})) KeyProvider {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-amz-json-1.1" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "AWS4-HMAC-SHA256") || !strings.Contains(auth, "/us-east-1/kms/aws4_request") {
+			t.Errorf("request is not SigV4-signed for kms in us-east-1: %q", auth)
+		}
+
+		op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "TrentService.")
+		respond, ok := responses[op]
+		if !ok {
+			t.Errorf("unexpected operation %q", op)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var in map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		if in["KeyId"] != testKeyARN {
+			t.Errorf("KeyId = %v", in["KeyId"])
+		}
+		respond(w, in)
+	}))
+	t.Cleanup(server.Close)
+
+	factory := NewAWSKMSFactory(AWSKMSConfig{
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+		Endpoint:    server.URL,
+	})
+	provider, err := factory(&db.TeamEncryptionKey{Provider: db.EncryptionKeyProviderAWSKMS, KeyARN: testKeyARN, Region: "us-east-1"})
+	if err != nil {
+		t.Fatalf("factory error: %v", err)
+	}
+	return provider
+}
+
+func kmsFault(status int, errType string) func(w http.ResponseWriter, in map[string]interface{}) {
+	return func(w http.ResponseWriter, in map[string]interface{}) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"__type": errType, "message": "test"})
+	}
+}
+
+func TestAWSKMSDataKeys(t *testing.T) {
+	plaintext := []byte(strings.Repeat("k", 32))
+	wrapped := []byte("wrapped-by-customer-key")
+
+	provider := newTestKMS(t, map[string]func(w http.ResponseWriter, in map[string]interface{}){
+		"GenerateDataKey": func(w http.ResponseWriter, in map[string]interface{}) {
+			if in["KeySpec"] != "AES_256" {
+				t.Errorf("KeySpec = %v", in["KeySpec"])
+			}
+			_ = json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": plaintext, "CiphertextBlob": wrapped})
+		},
+		"Decrypt": func(w http.ResponseWriter, in map[string]interface{}) {
+			if in["CiphertextBlob"] != "d3JhcHBlZC1ieS1jdXN0b21lci1rZXk=" {
+				t.Errorf("CiphertextBlob = %v", in["CiphertextBlob"])
+			}
+			_ = json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": plaintext})
+		},
+	})
+
+	ctx := context.Background()
+	gotPlain, gotWrapped, err := provider.GenerateDataKey(ctx)
+	if err != nil {
+		t.Fatalf("GenerateDataKey() error = %v", err)
+	}
+	if string(gotPlain) != string(plaintext) || string(gotWrapped) != string(wrapped) {
+		t.Errorf("GenerateDataKey() = %q, %q", gotPlain, gotWrapped)
+	}
+
+	unwrapped, err := provider.Decrypt(ctx, wrapped)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(unwrapped) != string(plaintext) {
+		t.Errorf("Decrypt() = %q", unwrapped)
+	}
+}
+
+func TestAWSKMSErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter, in map[string]interface{})
+		wantErr error
+	}{
+		{
+			name: "enabled",
+			respond: func(w http.ResponseWriter, in map[string]interface{}) {
+				_, _ = w.Write([]byte(`{"KeyMetadata":{"KeyState":"Enabled"}}`))
+			},
+		},
+		{
+			name: "pending deletion",
+			respond: func(w http.ResponseWriter, in map[string]interface{}) {
+				_, _ = w.Write([]byte(`{"KeyMetadata":{"KeyState":"PendingDeletion"}}`))
+			},
+			wantErr: ErrKeyRevoked,
+		},
+		{name: "access denied", respond: kmsFault(http.StatusBadRequest, "AccessDeniedException"), wantErr: ErrKeyRevoked},
+		{name: "disabled", respond: kmsFault(http.StatusBadRequest, "com.amazonaws.kms#DisabledException"), wantErr: ErrKeyRevoked},
+		{name: "throttled", respond: kmsFault(http.StatusBadRequest, "ThrottlingException"), wantErr: ErrKeyUnreachable},
+		{name: "internal error", respond: kmsFault(http.StatusInternalServerError, "KMSInternalException"), wantErr: ErrKeyUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestKMS(t, map[string]func(w http.ResponseWriter, in map[string]interface{}){
+				"DescribeKey": tt.respond,
+			})
+
+			err := provider.Describe(context.Background())
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Describe() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
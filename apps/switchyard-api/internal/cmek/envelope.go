@@ -0,0 +1,93 @@
+package cmek
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// sealedVersion is the envelope format written by sealValue:
+// cmek:v1:<key id>:<base64 nonce+ciphertext>
+const sealedVersion = "v1"
+
+// sealValue encrypts plaintext with a team data key using AES-256-GCM. The key
+// ID is bound as additional data so a value cannot be moved between teams.
+func sealValue(keyID uuid.UUID, dataKey []byte, plaintext []byte) (string, error) {
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, []byte(keyID.String()))
+	return fmt.Sprintf("%s%s:%s:%s", db.SealedValuePrefix, sealedVersion, keyID, base64.StdEncoding.EncodeToString(ciphertext)), nil
+}
+
+// sealedKeyID returns the ID of the key a sealed value was encrypted with
+func sealedKeyID(sealed string) (uuid.UUID, string, error) {
+	rest, ok := strings.CutPrefix(sealed, db.SealedValuePrefix+sealedVersion+":")
+	if !ok {
+		return uuid.Nil, "", fmt.Errorf("unsupported sealed value format")
+	}
+	id, payload, ok := strings.Cut(rest, ":")
+	if !ok {
+		return uuid.Nil, "", fmt.Errorf("malformed sealed value")
+	}
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("malformed sealed value key ID: %w", err)
+	}
+	return keyID, payload, nil
+}
+
+// openValue decrypts the payload of a sealed value
+func openValue(keyID uuid.UUID, dataKey []byte, payload string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sealed value: %w", err)
+	}
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed value too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(keyID.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt sealed value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// newGCM creates an AES-256-GCM cipher for a data key
+func newGCM(dataKey []byte) (cipher.AEAD, error) {
+	if len(dataKey) != 32 {
+		return nil, fmt.Errorf("data key must be 32 bytes, got %d", len(dataKey))
+	}
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
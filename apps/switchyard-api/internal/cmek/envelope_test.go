@@ -0,0 +1,95 @@
+package cmek
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+func TestSealValueRoundTrip(t *testing.T) {
+	keyID := uuid.New()
+	dataKey := bytes.Repeat([]byte{7}, 32)
+
+	sealed, err := sealValue(keyID, dataKey, []byte("postgres://user:secret@db/app"))
+	if err != nil {
+		t.Fatalf("sealValue() error = %v", err)
+	}
+	if !strings.HasPrefix(sealed, db.SealedValuePrefix+sealedVersion+":"+keyID.String()+":") {
+		t.Errorf("sealed value %q does not name its key", sealed)
+	}
+
+	gotID, payload, err := sealedKeyID(sealed)
+	if err != nil {
+		t.Fatalf("sealedKeyID() error = %v", err)
+	}
+	if gotID != keyID {
+		t.Errorf("sealedKeyID() = %s, want %s", gotID, keyID)
+	}
+
+	plaintext, err := openValue(keyID, dataKey, payload)
+	if err != nil {
+		t.Fatalf("openValue() error = %v", err)
+	}
+	if string(plaintext) != "postgres://user:secret@db/app" {
+		t.Errorf("openValue() = %q", plaintext)
+	}
+}
+
+func TestOpenValueRejects(t *testing.T) {
+	keyID := uuid.New()
+	dataKey := bytes.Repeat([]byte{7}, 32)
+
+	sealed, err := sealValue(keyID, dataKey, []byte("secret"))
+	if err != nil {
+		t.Fatalf("sealValue() error = %v", err)
+	}
+	_, payload, _ := sealedKeyID(sealed)
+
+	tampered := []byte(payload)
+	tampered[len(tampered)-2] ^= 1
+
+	tests := []struct {
+		name    string
+		keyID   uuid.UUID
+		dataKey []byte
+		payload string
+	}{
+		{name: "wrong data key", keyID: keyID, dataKey: bytes.Repeat([]byte{8}, 32), payload: payload},
+		{name: "moved to another key", keyID: uuid.New(), dataKey: dataKey, payload: payload},
+		{name: "tampered ciphertext", keyID: keyID, dataKey: dataKey, payload: string(tampered)},
+		{name: "truncated", keyID: keyID, dataKey: dataKey, payload: "AAAA"},
+		{name: "short data key", keyID: keyID, dataKey: []byte("short"), payload: payload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := openValue(tt.keyID, tt.dataKey, tt.payload); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestSealedKeyIDMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		sealed string
+	}{
+		{name: "platform ciphertext", sealed: "bm90LXNlYWxlZA=="},
+		{name: "unknown version", sealed: "cmek:v9:" + uuid.NewString() + ":AAAA"},
+		{name: "missing payload", sealed: "cmek:v1:" + uuid.NewString()},
+		{name: "bad key ID", sealed: "cmek:v1:not-a-uuid:AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := sealedKeyID(tt.sealed); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
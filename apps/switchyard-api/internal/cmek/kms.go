@@ -0,0 +1,63 @@
+package cmek
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+var (
+	// ErrKeyRevoked is returned when the customer has disabled, scheduled for
+	// deletion, or withdrawn access to their key. Data it protects stays
+	// unreadable until access is restored.
+	ErrKeyRevoked = errors.New("customer-managed key has been revoked")
+
+	// ErrKeyUnreachable is returned when the key service cannot be reached
+	ErrKeyUnreachable = errors.New("customer-managed key service is unreachable")
+
+	// ErrKeyRemoved is returned for data sealed with a key whose configuration
+	// was removed without re-encrypting it
+	ErrKeyRemoved = errors.New("the customer-managed key this data was encrypted with has been removed")
+
+	// ErrKeyAlreadyConfigured is returned when a team already has a key
+	ErrKeyAlreadyConfigured = errors.New("team already has a customer-managed key")
+)
+
+// KeyProvider wraps and unwraps data keys with one customer key
+type KeyProvider interface {
+	// Describe returns nil if the key is enabled, or an error wrapping
+	// ErrKeyRevoked or ErrKeyUnreachable
+	Describe(ctx context.Context) error
+
+	// GenerateDataKey returns a new 256-bit data key and its wrapped form
+	GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, err error)
+
+	// Decrypt unwraps a data key returned by GenerateDataKey
+	Decrypt(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// ProviderFactory returns the provider for a team's key
+type ProviderFactory func(key *db.TeamEncryptionKey) (KeyProvider, error)
+
+// ParseKeyARN validates an AWS KMS key or alias ARN and returns its region
+func ParseKeyARN(keyARN string) (region string, err error) {
+	parsed, err := arn.Parse(keyARN)
+	if err != nil {
+		return "", fmt.Errorf("invalid key ARN: %w", err)
+	}
+	if parsed.Service != "kms" {
+		return "", fmt.Errorf("invalid key ARN: expected a kms ARN, got service %q", parsed.Service)
+	}
+	if !strings.HasPrefix(parsed.Resource, "key/") && !strings.HasPrefix(parsed.Resource, "alias/") {
+		return "", fmt.Errorf("invalid key ARN: resource must be key/<id> or alias/<name>")
+	}
+	if parsed.Region == "" {
+		return "", fmt.Errorf("invalid key ARN: region is required")
+	}
+	return parsed.Region, nil
+}
@@ -0,0 +1,371 @@
+package cmek
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// DataKeyCacheTTL bounds how long an unwrapped team data key is kept in
+// memory. A revoked key stops working for reads and writes within this time.
+const DataKeyCacheTTL = 5 * time.Minute
+
+// Service manages customer-managed encryption keys (CMEK) per team. Each team
+// key wraps one data key; environment variables and backup keys of the team
+// are encrypted with that data key (envelope encryption).
+type Service struct {
+	repos     *db.Repositories
+	providers ProviderFactory
+	logger    *logrus.Logger
+
+	mu       sync.Mutex
+	dataKeys map[uuid.UUID]cachedDataKey
+}
+
+type cachedDataKey struct {
+	key       []byte
+	expiresAt time.Time
+}
+
+// NewService creates a CMEK service
+func NewService(repos *db.Repositories, providers ProviderFactory, logger *logrus.Logger) *Service {
+	return &Service{
+		repos:     repos,
+		providers: providers,
+		logger:    logger,
+		dataKeys:  make(map[uuid.UUID]cachedDataKey),
+	}
+}
+
+// GetKey returns the key of a team, or sql.ErrNoRows if it has none
+func (s *Service) GetKey(ctx context.Context, teamID uuid.UUID) (*db.TeamEncryptionKey, error) {
+	return s.repos.TeamEncryptionKeys.GetByTeam(ctx, teamID)
+}
+
+// Configure sets up a customer key for a team. The key must be enabled and
+// usable; a team data key is generated under it and the team's existing
+// environment variables are re-encrypted with it. It returns the number of
+// values re-encrypted.
+func (s *Service) Configure(ctx context.Context, teamID uuid.UUID, keyARN string, actorID *uuid.UUID, actorEmail string) (*db.TeamEncryptionKey, int, error) {
+	if _, err := s.repos.TeamEncryptionKeys.GetByTeam(ctx, teamID); err == nil {
+		return nil, 0, ErrKeyAlreadyConfigured
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, fmt.Errorf("failed to check existing key: %w", err)
+	}
+
+	region, err := ParseKeyARN(keyARN)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	key := &db.TeamEncryptionKey{
+		TeamID:    teamID,
+		Provider:  db.EncryptionKeyProviderAWSKMS,
+		KeyARN:    keyARN,
+		Region:    region,
+		Status:    db.EncryptionKeyStatusHealthy,
+		CreatedBy: actorID,
+	}
+	if actorEmail != "" {
+		key.CreatedByEmail = &actorEmail
+	}
+
+	provider, err := s.providers(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := provider.Describe(ctx); err != nil {
+		return nil, 0, fmt.Errorf("key check failed: %w", err)
+	}
+	dataKey, wrapped, err := provider.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	now := time.Now()
+	key.WrappedDataKey = base64.StdEncoding.EncodeToString(wrapped)
+	key.LastCheckedAt = &now
+
+	var reencrypted int
+	err = s.repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+		if err := tx.TeamEncryptionKeys.Create(ctx, key); err != nil {
+			return fmt.Errorf("failed to store key: %w", err)
+		}
+		reencrypted, err = tx.EnvVars.ReencryptTeam(ctx, teamID, func(plaintext string) (string, error) {
+			return sealValue(key.ID, dataKey, []byte(plaintext))
+		})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.cacheDataKey(key.ID, dataKey)
+
+	s.logger.WithFields(logrus.Fields{
+		"team_id":     teamID,
+		"key_id":      key.ID,
+		"key_arn":     keyARN,
+		"reencrypted": reencrypted,
+	}).Info("Customer-managed encryption key configured")
+
+	return key, reencrypted, nil
+}
+
+// Remove moves a team's data back to the platform key and deletes its key
+// configuration. The customer key must still be usable.
+func (s *Service) Remove(ctx context.Context, teamID uuid.UUID) (int, error) {
+	key, err := s.repos.TeamEncryptionKeys.GetByTeam(ctx, teamID)
+	if err != nil {
+		return 0, err
+	}
+
+	var reencrypted int
+	err = s.repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+		reencrypted, err = tx.EnvVars.ReencryptTeam(ctx, teamID, nil)
+		if err != nil {
+			return err
+		}
+		return tx.TeamEncryptionKeys.Delete(ctx, key.ID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.forgetDataKey(key.ID)
+
+	s.logger.WithFields(logrus.Fields{
+		"team_id":     teamID,
+		"key_id":      key.ID,
+		"reencrypted": reencrypted,
+	}).Info("Customer-managed encryption key removed")
+
+	return reencrypted, nil
+}
+
+// ForceRemove deletes a team's key configuration without re-encrypting its
+// data. It is a platform-admin override for keys the customer has revoked:
+// values and backups sealed with the key stay unreadable, and the team can
+// set new values under the platform key.
+func (s *Service) ForceRemove(ctx context.Context, teamID uuid.UUID) (*db.TeamEncryptionKey, error) {
+	key, err := s.repos.TeamEncryptionKeys.GetByTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repos.TeamEncryptionKeys.Delete(ctx, key.ID); err != nil {
+		return nil, err
+	}
+
+	s.forgetDataKey(key.ID)
+
+	s.logger.WithFields(logrus.Fields{
+		"team_id": teamID,
+		"key_id":  key.ID,
+	}).Warn("Customer-managed encryption key force-removed; data sealed with it is unrecoverable")
+
+	return key, nil
+}
+
+// Check verifies that a key is enabled and still unwraps the team data key,
+// and records the result
+func (s *Service) Check(ctx context.Context, key *db.TeamEncryptionKey) *db.TeamEncryptionKey {
+	status, message := db.EncryptionKeyStatusHealthy, (*string)(nil)
+
+	err := s.checkKey(ctx, key)
+	if err != nil {
+		status = db.EncryptionKeyStatusUnreachable
+		if errors.Is(err, ErrKeyRevoked) {
+			status = db.EncryptionKeyStatusRevoked
+			s.forgetDataKey(key.ID)
+		}
+		msg := err.Error()
+		message = &msg
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{"team_id": key.TeamID, "key_id": key.ID})
+	if status != key.Status {
+		if status == db.EncryptionKeyStatusHealthy {
+			logger.Info("Customer-managed encryption key is healthy again")
+		} else {
+			logger.WithError(err).Warnf("Customer-managed encryption key is %s", status)
+		}
+	}
+
+	now := time.Now()
+	if err := s.repos.TeamEncryptionKeys.UpdateStatus(ctx, key.ID, status, message, now); err != nil {
+		logger.WithError(err).Error("Failed to record encryption key status")
+	}
+
+	key.Status = status
+	key.StatusMessage = message
+	key.LastCheckedAt = &now
+	return key
+}
+
+// CheckAll runs a health check on every team key
+func (s *Service) CheckAll(ctx context.Context) {
+	keys, err := s.repos.TeamEncryptionKeys.List(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list encryption keys")
+		return
+	}
+	for _, key := range keys {
+		s.Check(ctx, key)
+	}
+}
+
+// checkKey describes the key and unwraps the team data key without the cache
+func (s *Service) checkKey(ctx context.Context, key *db.TeamEncryptionKey) error {
+	provider, err := s.providers(key)
+	if err != nil {
+		return err
+	}
+	if err := provider.Describe(ctx); err != nil {
+		return err
+	}
+	dataKey, err := s.unwrap(ctx, provider, key)
+	if err != nil {
+		return err
+	}
+	s.cacheDataKey(key.ID, dataKey)
+	return nil
+}
+
+// Seal encrypts an environment variable value with the key of the service's
+// team. It implements db.EnvVarKeyring.
+func (s *Service) Seal(ctx context.Context, serviceID uuid.UUID, plaintext string) (string, bool, error) {
+	key, err := s.repos.TeamEncryptionKeys.GetByService(ctx, serviceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to look up team encryption key: %w", err)
+	}
+
+	dataKey, err := s.dataKey(ctx, key)
+	if err != nil {
+		return "", false, err
+	}
+	sealed, err := sealValue(key.ID, dataKey, []byte(plaintext))
+	return sealed, true, err
+}
+
+// Open decrypts a value returned by Seal. It implements db.EnvVarKeyring.
+func (s *Service) Open(ctx context.Context, sealed string) (string, error) {
+	plaintext, err := s.open(ctx, sealed)
+	return string(plaintext), err
+}
+
+// BackupKey returns a new SSE-C key for a backup of a project's addon, and
+// the key sealed under the team's data key for storage with the backup. key
+// is nil when the project's team has no customer-managed key.
+func (s *Service) BackupKey(ctx context.Context, projectID uuid.UUID) (key *db.TeamEncryptionKey, plaintext []byte, sealed string, err error) {
+	key, err = s.repos.TeamEncryptionKeys.GetByProject(ctx, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, "", nil
+	}
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to look up team encryption key: %w", err)
+	}
+
+	dataKey, err := s.dataKey(ctx, key)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	plaintext = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, plaintext); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to generate backup key: %w", err)
+	}
+	sealed, err = sealValue(key.ID, dataKey, plaintext)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return key, plaintext, sealed, nil
+}
+
+// OpenBackupKey unwraps the SSE-C key of a backup
+func (s *Service) OpenBackupKey(ctx context.Context, sealed string) ([]byte, error) {
+	return s.open(ctx, sealed)
+}
+
+// open decrypts a sealed value with the data key it names
+func (s *Service) open(ctx context.Context, sealed string) ([]byte, error) {
+	keyID, payload, err := sealedKeyID(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.repos.TeamEncryptionKeys.GetByID(ctx, keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyRemoved
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up team encryption key: %w", err)
+	}
+
+	dataKey, err := s.dataKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return openValue(key.ID, dataKey, payload)
+}
+
+// dataKey returns the unwrapped data key of a team key, from the cache when
+// it was unwrapped recently
+func (s *Service) dataKey(ctx context.Context, key *db.TeamEncryptionKey) ([]byte, error) {
+	s.mu.Lock()
+	cached, ok := s.dataKeys[key.ID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.key, nil
+	}
+
+	provider, err := s.providers(key)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := s.unwrap(ctx, provider, key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheDataKey(key.ID, dataKey)
+	return dataKey, nil
+}
+
+// unwrap decrypts the wrapped team data key with the customer key
+func (s *Service) unwrap(ctx context.Context, provider KeyProvider, key *db.TeamEncryptionKey) ([]byte, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(key.WrappedDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wrapped data key: %w", err)
+	}
+	dataKey, err := provider.Decrypt(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap team data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+func (s *Service) cacheDataKey(keyID uuid.UUID, dataKey []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dataKeys[keyID] = cachedDataKey{key: dataKey, expiresAt: time.Now().Add(DataKeyCacheTTL)}
+}
+
+func (s *Service) forgetDataKey(keyID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.dataKeys, keyID)
+}
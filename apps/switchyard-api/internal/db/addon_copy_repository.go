@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// AddonCopyRepository handles the addons copied into cloned environments
+type AddonCopyRepository struct {
+	db DBTX
+}
+
+func NewAddonCopyRepository(db DBTX) *AddonCopyRepository {
+	return &AddonCopyRepository{db: db}
+}
+
+// NewAddonCopyRepositoryWithTx creates a repository using a transaction
+func NewAddonCopyRepositoryWithTx(tx DBTX) *AddonCopyRepository {
+	return &AddonCopyRepository{db: tx}
+}
+
+const addonCopyColumns = `
+	id, environment_id, source_addon_id, addon_id, copy_data, status, status_message,
+	restore_id, created_at, updated_at, ready_at
+`
+
+// Create records an addon copied into a cloned environment
+func (r *AddonCopyRepository) Create(ctx context.Context, addonCopy *types.EnvironmentAddonCopy) error {
+	if addonCopy.ID == uuid.Nil {
+		addonCopy.ID = uuid.New()
+	}
+	addonCopy.CreatedAt = time.Now()
+	addonCopy.UpdatedAt = addonCopy.CreatedAt
+
+	query := `
+		INSERT INTO environment_addon_copies (
+			id, environment_id, source_addon_id, addon_id, copy_data, status, status_message, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		addonCopy.ID, addonCopy.EnvironmentID, addonCopy.SourceAddonID, addonCopy.AddonID, addonCopy.CopyData,
+		addonCopy.Status, addonCopy.StatusMessage, addonCopy.CreatedAt, addonCopy.UpdatedAt,
+	)
+	return err
+}
+
+// ListByEnvironment retrieves the addons copied into an environment
+func (r *AddonCopyRepository) ListByEnvironment(ctx context.Context, environmentID uuid.UUID) ([]*types.EnvironmentAddonCopy, error) {
+	query := `
+		SELECT ` + addonCopyColumns + `
+		FROM environment_addon_copies
+		WHERE environment_id = $1
+		ORDER BY created_at ASC
+	`
+	return r.list(ctx, query, environmentID)
+}
+
+// ListInProgress retrieves addon copies that are still being provisioned or restored
+func (r *AddonCopyRepository) ListInProgress(ctx context.Context) ([]*types.EnvironmentAddonCopy, error) {
+	query := `
+		SELECT ` + addonCopyColumns + `
+		FROM environment_addon_copies
+		WHERE status IN ('provisioning', 'restoring')
+		ORDER BY created_at ASC
+	`
+	return r.list(ctx, query)
+}
+
+func (r *AddonCopyRepository) list(ctx context.Context, query string, args ...interface{}) ([]*types.EnvironmentAddonCopy, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var copies []*types.EnvironmentAddonCopy
+	for rows.Next() {
+		addonCopy, err := scanAddonCopy(rows)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, addonCopy)
+	}
+
+	return copies, rows.Err()
+}
+
+// Update saves the status and restore of an addon copy. ready_at is set the
+// first time it becomes ready.
+func (r *AddonCopyRepository) Update(ctx context.Context, addonCopy *types.EnvironmentAddonCopy) error {
+	addonCopy.UpdatedAt = time.Now()
+	if addonCopy.Status == types.EnvironmentAddonCopyStatusReady && addonCopy.ReadyAt == nil {
+		addonCopy.ReadyAt = &addonCopy.UpdatedAt
+	}
+
+	query := `
+		UPDATE environment_addon_copies
+		SET status = $1, status_message = $2, restore_id = $3, ready_at = $4, updated_at = $5
+		WHERE id = $6
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		addonCopy.Status, addonCopy.StatusMessage, addonCopy.RestoreID, addonCopy.ReadyAt, addonCopy.UpdatedAt, addonCopy.ID,
+	)
+	return err
+}
+
+// scanAddonCopy scans an addon copy from a row
+func scanAddonCopy(row interface{ Scan(...interface{}) error }) (*types.EnvironmentAddonCopy, error) {
+	addonCopy := &types.EnvironmentAddonCopy{}
+	var sourceAddonID uuid.NullUUID
+	var statusMessage sql.NullString
+	var restoreID uuid.NullUUID
+	var readyAt sql.NullTime
+
+	err := row.Scan(
+		&addonCopy.ID, &addonCopy.EnvironmentID, &sourceAddonID, &addonCopy.AddonID, &addonCopy.CopyData,
+		&addonCopy.Status, &statusMessage, &restoreID, &addonCopy.CreatedAt, &addonCopy.UpdatedAt, &readyAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if sourceAddonID.Valid {
+		addonCopy.SourceAddonID = &sourceAddonID.UUID
+	}
+	addonCopy.StatusMessage = statusMessage.String
+	if restoreID.Valid {
+		addonCopy.RestoreID = &restoreID.UUID
+	}
+	if readyAt.Valid {
+		addonCopy.ReadyAt = &readyAt.Time
+	}
+
+	return addonCopy, nil
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// AlertRepository handles the alerts opened by alert rules
+type AlertRepository struct {
+	db DBTX
+}
+
+// NewAlertRepository creates a new AlertRepository
+func NewAlertRepository(db DBTX) *AlertRepository {
+	return &AlertRepository{db: db}
+}
+
+// NewAlertRepositoryWithTx creates a repository using a transaction
+func NewAlertRepositoryWithTx(tx DBTX) *AlertRepository {
+	return &AlertRepository{db: tx}
+}
+
+const alertColumns = `
+	a.id, a.rule_id, r.name, r.service_id, a.environment_id, e.name, r.kind, a.status,
+	a.summary, a.value, a.started_at, a.fired_at, a.last_seen_at, a.resolved_at
+`
+
+const alertJoins = `
+	FROM alerts a
+	JOIN alert_rules r ON r.id = a.rule_id
+	JOIN environments e ON e.id = a.environment_id
+`
+
+// Open records that a rule's condition was met in an environment, as a
+// pending alert. It returns false without changes when the rule already
+// has an open alert there.
+func (r *AlertRepository) Open(ctx context.Context, alert *types.Alert) (bool, error) {
+	query := `
+		INSERT INTO alerts (rule_id, environment_id, status, summary, value, started_at, last_seen_at)
+		VALUES ($1, $2, 'pending', $3, $4, $5, $5)
+		ON CONFLICT (rule_id, environment_id) WHERE status <> 'resolved' DO NOTHING
+		RETURNING id
+	`
+	rows, err := r.db.QueryContext(ctx, query, alert.RuleID, alert.EnvironmentID, alert.Summary, alert.Value, alert.StartedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to open alert: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	if err := rows.Scan(&alert.ID); err != nil {
+		return false, fmt.Errorf("failed to open alert: %w", err)
+	}
+	alert.Status = types.AlertStatusPending
+	alert.LastSeenAt = alert.StartedAt
+	return true, nil
+}
+
+// ListOpen retrieves every pending and firing alert
+func (r *AlertRepository) ListOpen(ctx context.Context) ([]*types.Alert, error) {
+	query := `SELECT ` + alertColumns + alertJoins + ` WHERE a.status <> 'resolved'`
+	return r.list(ctx, query)
+}
+
+// ListByService retrieves the latest alerts of a service's rules, newest
+// first. An empty status lists alerts of every status.
+func (r *AlertRepository) ListByService(ctx context.Context, serviceID uuid.UUID, status types.AlertStatus, limit int) ([]*types.Alert, error) {
+	query := `
+		SELECT ` + alertColumns + alertJoins + `
+		WHERE r.service_id = $1 AND ($2 = '' OR a.status = $2)
+		ORDER BY a.started_at DESC
+		LIMIT $3
+	`
+	return r.list(ctx, query, serviceID, string(status), limit)
+}
+
+// ListFiredByEnvironment retrieves the alerts of an environment that fired
+// and are still firing or resolved since a time, newest first
+func (r *AlertRepository) ListFiredByEnvironment(ctx context.Context, environmentID uuid.UUID, since time.Time) ([]*types.Alert, error) {
+	query := `
+		SELECT ` + alertColumns + alertJoins + `
+		WHERE a.environment_id = $1 AND a.fired_at IS NOT NULL
+		  AND (a.status = 'firing' OR a.resolved_at >= $2)
+		ORDER BY a.fired_at DESC
+	`
+	return r.list(ctx, query, environmentID, since)
+}
+
+func (r *AlertRepository) list(ctx context.Context, query string, args ...interface{}) ([]*types.Alert, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []*types.Alert{}
+	for rows.Next() {
+		alert := &types.Alert{}
+		if err := rows.Scan(
+			&alert.ID, &alert.RuleID, &alert.RuleName, &alert.ServiceID, &alert.EnvironmentID,
+			&alert.Environment, &alert.Kind, &alert.Status, &alert.Summary, &alert.Value,
+			&alert.StartedAt, &alert.FiredAt, &alert.LastSeenAt, &alert.ResolvedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+// Touch records that an open alert's condition still holds
+func (r *AlertRepository) Touch(ctx context.Context, alert *types.Alert, seenAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE alerts SET summary = $2, value = $3, last_seen_at = $4 WHERE id = $1`,
+		alert.ID, alert.Summary, alert.Value, seenAt)
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+	alert.LastSeenAt = seenAt
+	return nil
+}
+
+// Fire moves a pending alert to firing. It returns false when the alert
+// was no longer pending, so each alert is notified once.
+func (r *AlertRepository) Fire(ctx context.Context, alert *types.Alert, firedAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE alerts SET status = 'firing', fired_at = $2 WHERE id = $1 AND status = 'pending'`,
+		alert.ID, firedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to fire alert: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return false, nil
+	}
+	alert.Status = types.AlertStatusFiring
+	alert.FiredAt = &firedAt
+	return true, nil
+}
+
+// Resolve closes an open alert
+func (r *AlertRepository) Resolve(ctx context.Context, alert *types.Alert, resolvedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE alerts SET status = 'resolved', resolved_at = $2 WHERE id = $1 AND status <> 'resolved'`,
+		alert.ID, resolvedAt)
+	if err != nil {
+		return fmt.Errorf("failed to resolve alert: %w", err)
+	}
+	alert.Status = types.AlertStatusResolved
+	alert.ResolvedAt = &resolvedAt
+	return nil
+}
+
+// Discard removes a pending alert whose condition cleared before it fired
+func (r *AlertRepository) Discard(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM alerts WHERE id = $1 AND status = 'pending'`, id)
+	if err != nil {
+		return fmt.Errorf("failed to discard alert: %w", err)
+	}
+	return nil
+}
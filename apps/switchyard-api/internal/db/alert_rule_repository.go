@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// AlertRuleRepository handles the alert rules of services
+type AlertRuleRepository struct {
+	db DBTX
+}
+
+// NewAlertRuleRepository creates a new AlertRuleRepository
+func NewAlertRuleRepository(db DBTX) *AlertRuleRepository {
+	return &AlertRuleRepository{db: db}
+}
+
+// NewAlertRuleRepositoryWithTx creates a repository using a transaction
+func NewAlertRuleRepositoryWithTx(tx DBTX) *AlertRuleRepository {
+	return &AlertRuleRepository{db: tx}
+}
+
+const alertRuleColumns = `
+	id, service_id, environment_id, name, kind, threshold, for_minutes,
+	notify_emails, enabled, COALESCE(created_by, ''), created_at, updated_at
+`
+
+// Create inserts a new alert rule
+func (r *AlertRuleRepository) Create(ctx context.Context, rule *types.AlertRule) error {
+	query := `
+		INSERT INTO alert_rules (service_id, environment_id, name, kind, threshold, for_minutes, notify_emails, enabled, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
+		RETURNING id, created_at, updated_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		rule.ServiceID, rule.EnvironmentID, rule.Name, rule.Kind, rule.Threshold, rule.ForMinutes,
+		pq.Array(rule.NotifyEmails), rule.Enabled, rule.CreatedBy,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an alert rule of a service
+func (r *AlertRuleRepository) GetByID(ctx context.Context, serviceID, id uuid.UUID) (*types.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE id = $1 AND service_id = $2`
+	return scanAlertRule(r.db.QueryRowContext(ctx, query, id, serviceID))
+}
+
+// ListByService retrieves the alert rules of a service, oldest first
+func (r *AlertRuleRepository) ListByService(ctx context.Context, serviceID uuid.UUID) ([]*types.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE service_id = $1 ORDER BY created_at ASC`
+	return r.list(ctx, query, serviceID)
+}
+
+// ListEnabled retrieves every enabled alert rule
+func (r *AlertRuleRepository) ListEnabled(ctx context.Context) ([]*types.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE enabled = true ORDER BY service_id, created_at`
+	return r.list(ctx, query)
+}
+
+func (r *AlertRuleRepository) list(ctx context.Context, query string, args ...interface{}) ([]*types.AlertRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*types.AlertRule{}
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func scanAlertRule(row interface{ Scan(...interface{}) error }) (*types.AlertRule, error) {
+	rule := &types.AlertRule{}
+	err := row.Scan(
+		&rule.ID, &rule.ServiceID, &rule.EnvironmentID, &rule.Name, &rule.Kind, &rule.Threshold,
+		&rule.ForMinutes, pq.Array(&rule.NotifyEmails), &rule.Enabled, &rule.CreatedBy,
+		&rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if rule.NotifyEmails == nil {
+		rule.NotifyEmails = []string{}
+	}
+	return rule, nil
+}
+
+// Update saves the settings of an alert rule
+func (r *AlertRuleRepository) Update(ctx context.Context, rule *types.AlertRule) error {
+	query := `
+		UPDATE alert_rules
+		SET environment_id = $3, name = $4, threshold = $5, for_minutes = $6,
+		    notify_emails = $7, enabled = $8, updated_at = NOW()
+		WHERE id = $1 AND service_id = $2
+		RETURNING updated_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		rule.ID, rule.ServiceID, rule.EnvironmentID, rule.Name, rule.Threshold, rule.ForMinutes,
+		pq.Array(rule.NotifyEmails), rule.Enabled,
+	).Scan(&rule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an alert rule of a service along with its alerts
+func (r *AlertRuleRepository) Delete(ctx context.Context, serviceID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1 AND service_id = $2`, id, serviceID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
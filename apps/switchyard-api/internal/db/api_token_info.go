@@ -2,6 +2,8 @@ package db
 
 import (
 	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // APITokenInfo contains minimal token info needed for authentication
@@ -11,4 +13,17 @@ type APITokenInfo struct {
 	UserID uuid.UUID
 	Name   string
 	Scopes []string
+
+	// UserRole is the role of the token owner; a token never grants more
+	UserRole string
+
+	// RateLimit is the token's own requests per minute, or nil for the
+	// platform's per-token limit
+	RateLimit *int
+
+	// BotID is set when the token acts as a bot. UserRole is then the bot's
+	// role, and the token only reaches the projects and environments in Grants.
+	BotID   *uuid.UUID
+	BotName string
+	Grants  []types.BotGrant
 }
@@ -57,9 +57,20 @@ func hashAPIToken(token string) string {
 	return hex.EncodeToString(hashBytes[:])
 }
 
-// Create generates a new API token for a user
+// Create generates a new API token for a user. A nil rateLimit leaves the
+// token to the platform's per-token limit.
 // Returns the raw token (only shown once!) and the token metadata
-func (r *APITokenRepository) Create(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*types.APITokenCreateResponse, error) {
+func (r *APITokenRepository) Create(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time, rateLimit *int) (*types.APITokenCreateResponse, error) {
+	return r.create(ctx, userID, nil, name, scopes, expiresAt, rateLimit)
+}
+
+// CreateForBot generates a new API token that acts as a bot. issuedBy is the
+// admin creating it; the token is revoked with the bot, not by them.
+func (r *APITokenRepository) CreateForBot(ctx context.Context, botID, issuedBy uuid.UUID, name string, expiresAt *time.Time) (*types.APITokenCreateResponse, error) {
+	return r.create(ctx, issuedBy, &botID, name, nil, expiresAt, nil)
+}
+
+func (r *APITokenRepository) create(ctx context.Context, userID uuid.UUID, botID *uuid.UUID, name string, scopes []string, expiresAt *time.Time, rateLimit *int) (*types.APITokenCreateResponse, error) {
 	rawToken, prefix, hash, err := generateAPIToken()
 	if err != nil {
 		return nil, err
@@ -69,13 +80,13 @@ func (r *APITokenRepository) Create(ctx context.Context, userID uuid.UUID, name
 	now := time.Now()
 
 	query := `
-		INSERT INTO api_tokens (id, user_id, name, prefix, token_hash, scopes, expires_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		INSERT INTO api_tokens (id, user_id, bot_id, name, prefix, token_hash, scopes, expires_at, rate_limit_per_minute, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
 		RETURNING id
 	`
 
 	err = r.db.QueryRowContext(ctx, query,
-		id, userID, name, prefix, hash, pq.Array(scopes), expiresAt, now,
+		id, userID, botID, name, prefix, hash, pq.Array(scopes), expiresAt, rateLimit, now,
 	).Scan(&id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create API token: %w", err)
@@ -101,7 +112,7 @@ func (r *APITokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.
 	token := &types.APIToken{}
 	query := `
 		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, last_used_ip,
-		       revoked, revoked_at, created_at, updated_at
+		       revoked, revoked_at, bot_id, rate_limit_per_minute, created_at, updated_at
 		FROM api_tokens
 		WHERE id = $1
 	`
@@ -111,7 +122,7 @@ func (r *APITokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes,
 		&token.ExpiresAt, &token.LastUsedAt, &lastUsedIP,
-		&token.Revoked, &token.RevokedAt, &token.CreatedAt, &token.UpdatedAt,
+		&token.Revoked, &token.RevokedAt, &token.BotID, &token.RateLimit, &token.CreatedAt, &token.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -129,7 +140,7 @@ func (r *APITokenRepository) GetByHash(ctx context.Context, tokenHash string) (*
 	token := &types.APIToken{}
 	query := `
 		SELECT id, user_id, name, prefix, token_hash, scopes, expires_at, last_used_at, last_used_ip,
-		       revoked, revoked_at, created_at, updated_at
+		       revoked, revoked_at, bot_id, rate_limit_per_minute, created_at, updated_at
 		FROM api_tokens
 		WHERE token_hash = $1 AND revoked = false
 	`
@@ -139,7 +150,7 @@ func (r *APITokenRepository) GetByHash(ctx context.Context, tokenHash string) (*
 	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
 		&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.TokenHash, &scopes,
 		&token.ExpiresAt, &token.LastUsedAt, &lastUsedIP,
-		&token.Revoked, &token.RevokedAt, &token.CreatedAt, &token.UpdatedAt,
+		&token.Revoked, &token.RevokedAt, &token.BotID, &token.RateLimit, &token.CreatedAt, &token.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -179,11 +190,46 @@ func (r *APITokenRepository) ValidateTokenForAuth(ctx context.Context, rawToken
 		return nil, err
 	}
 
+	if token.BotID != nil {
+		return r.botTokenInfo(ctx, token)
+	}
+
+	var userRole string
+	if err := r.db.QueryRowContext(ctx, `SELECT role FROM users WHERE id = $1`, token.UserID).Scan(&userRole); err != nil {
+		return nil, fmt.Errorf("failed to load token owner: %w", err)
+	}
+
 	return &APITokenInfo{
-		ID:     token.ID,
-		UserID: token.UserID,
-		Name:   token.Name,
-		Scopes: token.Scopes,
+		ID:        token.ID,
+		UserID:    token.UserID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		UserRole:  userRole,
+		RateLimit: token.RateLimit,
+	}, nil
+}
+
+// botTokenInfo returns the auth info of a token that acts as a bot: the
+// bot's role and grants instead of those of the admin who issued it
+func (r *APITokenRepository) botTokenInfo(ctx context.Context, token *types.APIToken) (*APITokenInfo, error) {
+	bots := NewBotRepository(r.db)
+	bot, err := bots.GetByID(ctx, *token.BotID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token bot: %w", err)
+	}
+	if bot.Disabled {
+		return nil, fmt.Errorf("bot is disabled")
+	}
+
+	return &APITokenInfo{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		Name:      token.Name,
+		UserRole:  string(bot.Role),
+		BotID:     &bot.ID,
+		BotName:   bot.Name,
+		Grants:    bot.Grants,
+		RateLimit: token.RateLimit,
 	}, nil
 }
 
@@ -191,9 +237,9 @@ func (r *APITokenRepository) ValidateTokenForAuth(ctx context.Context, rawToken
 func (r *APITokenRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*types.APIToken, error) {
 	query := `
 		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, last_used_ip,
-		       revoked, revoked_at, created_at, updated_at
+		       revoked, revoked_at, rate_limit_per_minute, created_at, updated_at
 		FROM api_tokens
-		WHERE user_id = $1
+		WHERE user_id = $1 AND bot_id IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -211,7 +257,7 @@ func (r *APITokenRepository) ListByUser(ctx context.Context, userID uuid.UUID) (
 		err := rows.Scan(
 			&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes,
 			&token.ExpiresAt, &token.LastUsedAt, &lastUsedIP,
-			&token.Revoked, &token.RevokedAt, &token.CreatedAt, &token.UpdatedAt,
+			&token.Revoked, &token.RevokedAt, &token.RateLimit, &token.CreatedAt, &token.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -230,9 +276,9 @@ func (r *APITokenRepository) ListByUser(ctx context.Context, userID uuid.UUID) (
 func (r *APITokenRepository) ListActiveByUser(ctx context.Context, userID uuid.UUID) ([]*types.APIToken, error) {
 	query := `
 		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, last_used_ip,
-		       revoked, revoked_at, created_at, updated_at
+		       revoked, revoked_at, rate_limit_per_minute, created_at, updated_at
 		FROM api_tokens
-		WHERE user_id = $1
+		WHERE user_id = $1 AND bot_id IS NULL
 		  AND revoked = false
 		  AND (expires_at IS NULL OR expires_at > NOW())
 		ORDER BY created_at DESC
@@ -252,7 +298,7 @@ func (r *APITokenRepository) ListActiveByUser(ctx context.Context, userID uuid.U
 		err := rows.Scan(
 			&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes,
 			&token.ExpiresAt, &token.LastUsedAt, &lastUsedIP,
-			&token.Revoked, &token.RevokedAt, &token.CreatedAt, &token.UpdatedAt,
+			&token.Revoked, &token.RevokedAt, &token.RateLimit, &token.CreatedAt, &token.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -273,7 +319,7 @@ func (r *APITokenRepository) Revoke(ctx context.Context, id uuid.UUID, userID uu
 	query := `
 		UPDATE api_tokens
 		SET revoked = true, revoked_at = $1, updated_at = $1
-		WHERE id = $2 AND user_id = $3 AND revoked = false
+		WHERE id = $2 AND user_id = $3 AND bot_id IS NULL AND revoked = false
 	`
 
 	result, err := r.db.ExecContext(ctx, query, now, id, userID)
@@ -306,9 +352,35 @@ func (r *APITokenRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID, i
 	return err
 }
 
+// SetRateLimit sets the requests per minute a token may make; nil returns
+// it to the platform's per-token limit
+func (r *APITokenRepository) SetRateLimit(ctx context.Context, id uuid.UUID, rateLimit *int) error {
+	query := `
+		UPDATE api_tokens
+		SET rate_limit_per_minute = $1, updated_at = NOW()
+		WHERE id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, rateLimit, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 // Delete permanently removes a token (use Revoke for soft delete)
 func (r *APITokenRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
-	query := `DELETE FROM api_tokens WHERE id = $1 AND user_id = $2`
+	query := `DELETE FROM api_tokens WHERE id = $1 AND user_id = $2 AND bot_id IS NULL`
 	result, err := r.db.ExecContext(ctx, query, id, userID)
 	if err != nil {
 		return err
@@ -329,7 +401,72 @@ func (r *APITokenRepository) Delete(ctx context.Context, id uuid.UUID, userID uu
 // CountByUser returns the number of tokens for a user
 func (r *APITokenRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM api_tokens WHERE user_id = $1 AND revoked = false`
+	query := `SELECT COUNT(*) FROM api_tokens WHERE user_id = $1 AND bot_id IS NULL AND revoked = false`
 	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
 	return count, err
 }
+
+// ListByBot retrieves all tokens that act as a bot (active and revoked)
+func (r *APITokenRepository) ListByBot(ctx context.Context, botID uuid.UUID) ([]*types.APIToken, error) {
+	query := `
+		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, last_used_ip,
+		       revoked, revoked_at, bot_id, rate_limit_per_minute, created_at, updated_at
+		FROM api_tokens
+		WHERE bot_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, botID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*types.APIToken
+	for rows.Next() {
+		token := &types.APIToken{}
+		var scopes pq.StringArray
+		var lastUsedIP sql.NullString
+		err := rows.Scan(
+			&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes,
+			&token.ExpiresAt, &token.LastUsedAt, &lastUsedIP,
+			&token.Revoked, &token.RevokedAt, &token.BotID, &token.RateLimit, &token.CreatedAt, &token.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		token.Scopes = []string(scopes)
+		if lastUsedIP.Valid {
+			token.LastUsedIP = lastUsedIP.String
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RevokeForBot marks a token of a bot as revoked
+func (r *APITokenRepository) RevokeForBot(ctx context.Context, id, botID uuid.UUID) error {
+	now := time.Now()
+	query := `
+		UPDATE api_tokens
+		SET revoked = true, revoked_at = $1, updated_at = $1
+		WHERE id = $2 AND bot_id = $3 AND revoked = false
+	`
+
+	result, err := r.db.ExecContext(ctx, query, now, id, botID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("token not found or already revoked")
+	}
+
+	return nil
+}
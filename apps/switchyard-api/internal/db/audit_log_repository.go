@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ import (
 // AuditLogRepository handles audit log operations (immutable)
 type AuditLogRepository struct {
 	db DBTX
+	readRouter
 }
 
 func NewAuditLogRepository(db DBTX) *AuditLogRepository {
@@ -49,44 +51,65 @@ func (r *AuditLogRepository) Log(ctx context.Context, log *types.AuditLog) error
 	return err
 }
 
-func (r *AuditLogRepository) Query(ctx context.Context, filters map[string]interface{}, limit int, offset int) ([]*types.AuditLog, error) {
-	query := `
+const auditLogColumns = `
 		SELECT id, timestamp, actor_id, actor_email, actor_role, action,
 		       resource_type, resource_id, resource_name,
 		       project_id, environment_id, ip_address, user_agent, outcome, context, metadata
-		FROM audit_logs
-		WHERE 1=1
-	`
+		FROM audit_logs`
+
+var auditLogListColumns = listColumns{
+	id:          "id",
+	sorts:       map[string]string{"created_at": "timestamp"},
+	status:      "outcome",
+	environment: "environment_id",
+	created:     "timestamp",
+}
+
+func (r *AuditLogRepository) Query(ctx context.Context, filters map[string]interface{}, limit int, offset int) ([]*types.AuditLog, error) {
+	conds, args := auditLogFilters(filters)
+	query := auditLogColumns + " WHERE " + strings.Join(append([]string{"1=1"}, conds...), " AND ")
+	query += " ORDER BY timestamp DESC"
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	return r.queryAuditLogs(ctx, query, args...)
+}
+
+// List returns a page of the audit logs matching filters. Status filters
+// by outcome.
+func (r *AuditLogRepository) List(ctx context.Context, filters map[string]interface{}, params ListParams) ([]*types.AuditLog, error) {
+	conds, args := auditLogFilters(filters)
+	where, args := params.where(auditLogListColumns, conds, args)
+	return r.queryAuditLogs(ctx, auditLogColumns+where, args...)
+}
+
+// auditLogFilters builds the conditions of the actor_id, action,
+// resource_type and project_id filters
+func auditLogFilters(filters map[string]interface{}) ([]string, []interface{}) {
+	var conds []string
 	args := []interface{}{}
-	argCount := 1
+	add := func(column string, value interface{}) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
 
-	// Add filters dynamically
 	if actorID, ok := filters["actor_id"].(uuid.UUID); ok {
-		query += fmt.Sprintf(" AND actor_id = $%d", argCount)
-		args = append(args, actorID)
-		argCount++
+		add("actor_id", actorID)
 	}
 	if action, ok := filters["action"].(string); ok {
-		query += fmt.Sprintf(" AND action = $%d", argCount)
-		args = append(args, action)
-		argCount++
+		add("action", action)
 	}
 	if resourceType, ok := filters["resource_type"].(string); ok {
-		query += fmt.Sprintf(" AND resource_type = $%d", argCount)
-		args = append(args, resourceType)
-		argCount++
+		add("resource_type", resourceType)
 	}
 	if projectID, ok := filters["project_id"].(uuid.UUID); ok {
-		query += fmt.Sprintf(" AND project_id = $%d", argCount)
-		args = append(args, projectID)
-		argCount++
+		add("project_id", projectID)
 	}
+	return conds, args
+}
 
-	query += " ORDER BY timestamp DESC"
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
-	args = append(args, limit, offset)
-
-	rows, err := r.db.QueryContext(ctx, query, args...)
+func (r *AuditLogRepository) queryAuditLogs(ctx context.Context, query string, args ...interface{}) ([]*types.AuditLog, error) {
+	rows, err := r.reader(r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// BackgroundJobRepository is the durable queue of background jobs
+type BackgroundJobRepository struct {
+	db DBTX
+}
+
+func NewBackgroundJobRepository(db DBTX) *BackgroundJobRepository {
+	return &BackgroundJobRepository{db: db}
+}
+
+const backgroundJobColumns = `id, kind, payload, status, attempts, max_attempts, run_after,
+	COALESCE(claimed_by, ''), COALESCE(last_error, ''), created_at, started_at, finished_at, updated_at`
+
+var backgroundJobListColumns = listColumns{
+	id:      "id",
+	sorts:   map[string]string{"created_at": "created_at", "updated_at": "updated_at"},
+	status:  "status",
+	created: "created_at",
+}
+
+func scanBackgroundJob(row interface{ Scan(...any) error }) (*types.BackgroundJob, error) {
+	job := &types.BackgroundJob{}
+	var payload []byte
+	err := row.Scan(&job.ID, &job.Kind, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAfter,
+		&job.ClaimedBy, &job.LastError, &job.CreatedAt, &job.StartedAt, &job.FinishedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+	return job, nil
+}
+
+// Enqueue queues a job to run at job.RunAfter, or right away when it is zero
+func (r *BackgroundJobRepository) Enqueue(ctx context.Context, job *types.BackgroundJob) error {
+	if job.RunAfter.IsZero() {
+		job.RunAfter = time.Now()
+	}
+	payload := []byte(job.Payload)
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	query := `
+		INSERT INTO background_jobs (kind, payload, max_attempts, run_after)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + backgroundJobColumns
+	stored, err := scanBackgroundJob(r.db.QueryRowContext(ctx, query, job.Kind, payload, job.MaxAttempts, job.RunAfter))
+	if err != nil {
+		return fmt.Errorf("failed to enqueue background job: %w", err)
+	}
+	*job = *stored
+	return nil
+}
+
+// Claim takes up to limit ready jobs of the given kinds for owner until the
+// lease runs out, oldest first, and starts their next attempt. Running jobs
+// whose lease expired, because their replica died, are claimed again while
+// they have attempts left. Jobs other replicas are claiming at the same time
+// are skipped.
+func (r *BackgroundJobRepository) Claim(ctx context.Context, owner string, kinds []string, limit int, lease time.Duration) ([]*types.BackgroundJob, error) {
+	query := `
+		WITH next AS (
+			SELECT id
+			FROM background_jobs
+			WHERE kind = ANY($4)
+			  AND ((status = 'queued' AND run_after <= NOW())
+			    OR (status = 'running' AND claimed_until < NOW() AND attempts < max_attempts))
+			ORDER BY run_after
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE background_jobs j
+		SET status = 'running', attempts = j.attempts + 1,
+			claimed_by = $1, claimed_until = NOW() + make_interval(secs => $3),
+			started_at = NOW(), updated_at = NOW()
+		FROM next
+		WHERE j.id = next.id
+		RETURNING j.id, j.kind, j.payload, j.status, j.attempts, j.max_attempts, j.run_after,
+			COALESCE(j.claimed_by, ''), COALESCE(j.last_error, ''), j.created_at, j.started_at, j.finished_at, j.updated_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, owner, limit, lease.Seconds(), pq.Array(kinds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim background jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*types.BackgroundJob
+	for rows.Next() {
+		job, err := scanBackgroundJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan background job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// RenewClaims extends the lease on every job owner is running
+func (r *BackgroundJobRepository) RenewClaims(ctx context.Context, owner string, lease time.Duration) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE background_jobs
+		SET claimed_until = NOW() + make_interval(secs => $2)
+		WHERE claimed_by = $1 AND status = 'running'
+	`, owner, lease.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to renew background job claims: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Complete marks a job owner ran as succeeded
+func (r *BackgroundJobRepository) Complete(ctx context.Context, id uuid.UUID, owner string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE background_jobs
+		SET status = 'succeeded', claimed_by = NULL, claimed_until = NULL,
+			finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND claimed_by = $2
+	`, id, owner)
+	if err != nil {
+		return fmt.Errorf("failed to complete background job: %w", err)
+	}
+	return nil
+}
+
+// Retry releases a job owner ran to try again at runAfter, recording why
+// the attempt failed
+func (r *BackgroundJobRepository) Retry(ctx context.Context, id uuid.UUID, owner string, runAfter time.Time, lastError string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE background_jobs
+		SET status = 'queued', claimed_by = NULL, claimed_until = NULL,
+			run_after = $3, last_error = $4, updated_at = NOW()
+		WHERE id = $1 AND claimed_by = $2
+	`, id, owner, runAfter, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to retry background job: %w", err)
+	}
+	return nil
+}
+
+// Fail marks a job owner ran as failed for good
+func (r *BackgroundJobRepository) Fail(ctx context.Context, id uuid.UUID, owner string, lastError string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE background_jobs
+		SET status = 'failed', claimed_by = NULL, claimed_until = NULL,
+			last_error = $3, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND claimed_by = $2
+	`, id, owner, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to fail background job: %w", err)
+	}
+	return nil
+}
+
+// ReleaseAll gives up every job owner is running, e.g. on shutdown, so other
+// replicas run them without waiting for the leases to run out. The attempts
+// that were cut short are not counted.
+func (r *BackgroundJobRepository) ReleaseAll(ctx context.Context, owner string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE background_jobs
+		SET status = 'queued', attempts = GREATEST(attempts - 1, 0),
+			claimed_by = NULL, claimed_until = NULL, updated_at = NOW()
+		WHERE claimed_by = $1 AND status = 'running'
+	`, owner)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release background job claims: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// FailAbandoned marks as failed the jobs whose lease expired during their
+// last attempt, which Claim no longer takes
+func (r *BackgroundJobRepository) FailAbandoned(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE background_jobs
+		SET status = 'failed', claimed_by = NULL, claimed_until = NULL,
+			last_error = 'Replica stopped during the last attempt',
+			finished_at = NOW(), updated_at = NOW()
+		WHERE status = 'running' AND claimed_until < NOW() AND attempts >= max_attempts
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail abandoned background jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Requeue queues a failed job again with a fresh set of attempts. It
+// returns sql.ErrNoRows unless the job exists and has failed.
+func (r *BackgroundJobRepository) Requeue(ctx context.Context, id uuid.UUID) (*types.BackgroundJob, error) {
+	query := `
+		UPDATE background_jobs
+		SET status = 'queued', attempts = 0, run_after = NOW(),
+			finished_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'failed'
+		RETURNING ` + backgroundJobColumns
+	return scanBackgroundJob(r.db.QueryRowContext(ctx, query, id))
+}
+
+// Prune deletes the jobs that finished before a time, and reports how many
+func (r *BackgroundJobRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM background_jobs
+		WHERE status IN ('succeeded', 'failed') AND finished_at < $1
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune background jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetByID returns a job, or sql.ErrNoRows
+func (r *BackgroundJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.BackgroundJob, error) {
+	query := `SELECT ` + backgroundJobColumns + ` FROM background_jobs WHERE id = $1`
+	return scanBackgroundJob(r.db.QueryRowContext(ctx, query, id))
+}
+
+// List returns a page of jobs, of one kind when kind is set. params.Status
+// filters on a job status.
+func (r *BackgroundJobRepository) List(ctx context.Context, kind string, params ListParams) ([]*types.BackgroundJob, error) {
+	var conds []string
+	var args []any
+	if kind != "" {
+		conds = append(conds, "kind = $1")
+		args = append(args, kind)
+	}
+	where, args := params.where(backgroundJobListColumns, conds, args)
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+backgroundJobColumns+` FROM background_jobs`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*types.BackgroundJob
+	for rows.Next() {
+		job, err := scanBackgroundJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// BillingAccountRepository reads the Stripe customers of teams and
+// projects, which Waybill keeps current
+type BillingAccountRepository struct {
+	db DBTX
+}
+
+func NewBillingAccountRepository(db DBTX) *BillingAccountRepository {
+	return &BillingAccountRepository{db: db}
+}
+
+// ExistsForTeam reports whether a team has a billing account
+func (r *BillingAccountRepository) ExistsForTeam(ctx context.Context, teamID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM billing_accounts WHERE team_id = $1)`, teamID).Scan(&exists)
+	return exists, err
+}
+
+// ExistsForProject reports whether a project without a team has a billing
+// account of its own
+func (r *BillingAccountRepository) ExistsForProject(ctx context.Context, projectID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM billing_accounts WHERE project_id = $1)`, projectID).Scan(&exists)
+	return exists, err
+}
+
+// Covers reports whether the billing account of a team, or of a project
+// without a team, pays for a project: one of the team's projects, or that
+// project itself
+func (r *BillingAccountRepository) Covers(ctx context.Context, teamID, accountProjectID *uuid.UUID, projectID uuid.UUID) (bool, error) {
+	var covered bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM projects
+			WHERE id = $1
+				AND (team_id = $2 OR ($2 IS NULL AND team_id IS NULL AND id = $3))
+		)
+	`, projectID, teamID, accountProjectID).Scan(&covered)
+	return covered, err
+}
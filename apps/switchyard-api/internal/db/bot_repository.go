@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// BotRepository handles bot identities and their grants
+type BotRepository struct {
+	db DBTX
+}
+
+func NewBotRepository(db DBTX) *BotRepository {
+	return &BotRepository{db: db}
+}
+
+// NewBotRepositoryWithTx creates a repository using a transaction
+func NewBotRepositoryWithTx(tx DBTX) *BotRepository {
+	return &BotRepository{db: tx}
+}
+
+const botColumns = `
+	id, name, description, role, disabled, created_by, created_by_email, created_at, updated_at
+`
+
+// Create adds a bot. Its grants are written with ReplaceGrants.
+func (r *BotRepository) Create(ctx context.Context, bot *types.Bot) error {
+	if bot.ID == uuid.Nil {
+		bot.ID = uuid.New()
+	}
+	bot.CreatedAt = time.Now()
+	bot.UpdatedAt = bot.CreatedAt
+
+	query := `
+		INSERT INTO bots (id, name, description, role, disabled, created_by, created_by_email, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		bot.ID, bot.Name, bot.Description, bot.Role, bot.Disabled,
+		bot.CreatedBy, bot.CreatedByEmail, bot.CreatedAt, bot.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a bot with its grants
+func (r *BotRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.Bot, error) {
+	query := `SELECT ` + botColumns + ` FROM bots WHERE id = $1`
+	bot, err := scanBot(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := r.ListGrants(ctx, bot.ID)
+	if err != nil {
+		return nil, err
+	}
+	bot.Grants = grants
+	return bot, nil
+}
+
+// List retrieves all bots with their grants
+func (r *BotRepository) List(ctx context.Context) ([]*types.Bot, error) {
+	query := `SELECT ` + botColumns + ` FROM bots ORDER BY name ASC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bots []*types.Bot
+	for rows.Next() {
+		bot, err := scanBot(rows)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, bot := range bots {
+		grants, err := r.ListGrants(ctx, bot.ID)
+		if err != nil {
+			return nil, err
+		}
+		bot.Grants = grants
+	}
+
+	return bots, nil
+}
+
+// Update saves the description, role and disabled flag of a bot
+func (r *BotRepository) Update(ctx context.Context, bot *types.Bot) error {
+	bot.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE bots
+		SET description = $2, role = $3, disabled = $4, updated_at = $5
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query, bot.ID, bot.Description, bot.Role, bot.Disabled, bot.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes a bot. Its grants and tokens are deleted with it.
+func (r *BotRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM bots WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListGrants retrieves the projects and environments a bot may act on
+func (r *BotRepository) ListGrants(ctx context.Context, botID uuid.UUID) ([]types.BotGrant, error) {
+	query := `
+		SELECT project_id, environment_id
+		FROM bot_grants
+		WHERE bot_id = $1
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, botID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []types.BotGrant{}
+	for rows.Next() {
+		var grant types.BotGrant
+		if err := rows.Scan(&grant.ProjectID, &grant.EnvironmentID); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
+
+// ReplaceGrants sets the grants of a bot. Run it in a transaction so tokens
+// never see a partial set.
+func (r *BotRepository) ReplaceGrants(ctx context.Context, botID uuid.UUID, grants []types.BotGrant) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM bot_grants WHERE bot_id = $1`, botID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO bot_grants (id, bot_id, project_id, environment_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	now := time.Now()
+	for _, grant := range grants {
+		if _, err := r.db.ExecContext(ctx, query, uuid.New(), botID, grant.ProjectID, grant.EnvironmentID, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func scanBot(row interface{ Scan(...interface{}) error }) (*types.Bot, error) {
+	bot := &types.Bot{}
+	var description, createdByEmail sql.NullString
+	err := row.Scan(
+		&bot.ID, &bot.Name, &description, &bot.Role, &bot.Disabled,
+		&bot.CreatedBy, &createdByEmail, &bot.CreatedAt, &bot.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	bot.Description = description.String
+	bot.CreatedByEmail = createdByEmail.String
+	return bot, nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BuildArtifact is a file a build published, e.g. a test report or a
+// compiled binary, stored in the build artifacts bucket
+type BuildArtifact struct {
+	ID        uuid.UUID `json:"id"`
+	ReleaseID uuid.UUID `json:"release_id"`
+	Path      string    `json:"path"`
+	SizeBytes int64     `json:"size_bytes"`
+	ObjectKey string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BuildArtifactRepository handles the artifacts of builds
+type BuildArtifactRepository struct {
+	db DBTX
+}
+
+// NewBuildArtifactRepository creates a new BuildArtifactRepository
+func NewBuildArtifactRepository(db DBTX) *BuildArtifactRepository {
+	return &BuildArtifactRepository{db: db}
+}
+
+// Record stores an artifact of a release. A callback retried by Roundhouse
+// records the same paths again, which replaces them.
+func (r *BuildArtifactRepository) Record(ctx context.Context, a *BuildArtifact) error {
+	query := `
+		INSERT INTO build_artifacts (release_id, path, size_bytes, object_key)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (release_id, path) DO UPDATE
+		SET size_bytes = EXCLUDED.size_bytes, object_key = EXCLUDED.object_key
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(ctx, query, a.ReleaseID, a.Path, a.SizeBytes, a.ObjectKey).Scan(&a.ID, &a.CreatedAt)
+}
+
+// ListByRelease retrieves the artifacts of a release by path
+func (r *BuildArtifactRepository) ListByRelease(ctx context.Context, releaseID uuid.UUID) ([]*BuildArtifact, error) {
+	query := `
+		SELECT id, release_id, path, size_bytes, object_key, created_at
+		FROM build_artifacts
+		WHERE release_id = $1
+		ORDER BY path
+	`
+	rows, err := r.db.QueryContext(ctx, query, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var artifacts []*BuildArtifact
+	for rows.Next() {
+		a := &BuildArtifact{}
+		if err := rows.Scan(&a.ID, &a.ReleaseID, &a.Path, &a.SizeBytes, &a.ObjectKey, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, rows.Err()
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BuildContext is a gzipped tarball of a local working directory, uploaded
+// to object storage for a build that does not start from a git checkout
+type BuildContext struct {
+	ID         uuid.UUID  `json:"id"`
+	ServiceID  uuid.UUID  `json:"service_id"`
+	ObjectKey  string     `json:"-"`
+	Digest     string     `json:"digest"`
+	SizeBytes  int64      `json:"size_bytes"`
+	ReleaseID  *uuid.UUID `json:"release_id,omitempty"`
+	UploadedBy string     `json:"uploaded_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	PurgedAt   *time.Time `json:"purged_at,omitempty"`
+}
+
+// BuildContextRepository handles uploaded build contexts
+type BuildContextRepository struct {
+	db DBTX
+}
+
+// NewBuildContextRepository creates a new BuildContextRepository
+func NewBuildContextRepository(db DBTX) *BuildContextRepository {
+	return &BuildContextRepository{db: db}
+}
+
+const buildContextColumns = `
+	id, service_id, object_key, digest, size_bytes, release_id, uploaded_by, created_at, expires_at, purged_at
+`
+
+// Create inserts a new build context
+func (r *BuildContextRepository) Create(ctx context.Context, bc *BuildContext) error {
+	if bc.ID == uuid.Nil {
+		bc.ID = uuid.New()
+	}
+	bc.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO build_contexts (id, service_id, object_key, digest, size_bytes, uploaded_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		bc.ID, bc.ServiceID, bc.ObjectKey, bc.Digest, bc.SizeBytes,
+		bc.UploadedBy, bc.CreatedAt, bc.ExpiresAt,
+	)
+	return err
+}
+
+// GetByID retrieves a build context, including purged ones
+func (r *BuildContextRepository) GetByID(ctx context.Context, id uuid.UUID) (*BuildContext, error) {
+	query := `SELECT ` + buildContextColumns + ` FROM build_contexts WHERE id = $1`
+	return scanBuildContext(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetByReleaseID retrieves the build context a release was built from.
+// Releases built from git have none and return sql.ErrNoRows.
+func (r *BuildContextRepository) GetByReleaseID(ctx context.Context, releaseID uuid.UUID) (*BuildContext, error) {
+	query := `SELECT ` + buildContextColumns + ` FROM build_contexts WHERE release_id = $1 LIMIT 1`
+	return scanBuildContext(r.db.QueryRowContext(ctx, query, releaseID))
+}
+
+// SetRelease records the release built from a build context
+func (r *BuildContextRepository) SetRelease(ctx context.Context, id, releaseID uuid.UUID) error {
+	query := `UPDATE build_contexts SET release_id = $1 WHERE id = $2`
+	result, err := r.db.ExecContext(ctx, query, releaseID, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListExpired retrieves build contexts whose tarballs are due for deletion
+func (r *BuildContextRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*BuildContext, error) {
+	query := `
+		SELECT ` + buildContextColumns + `
+		FROM build_contexts
+		WHERE expires_at <= $1 AND purged_at IS NULL
+		ORDER BY expires_at ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contexts []*BuildContext
+	for rows.Next() {
+		bc, err := scanBuildContext(rows)
+		if err != nil {
+			return nil, err
+		}
+		contexts = append(contexts, bc)
+	}
+	return contexts, rows.Err()
+}
+
+// MarkPurged records that the tarball of a build context was deleted. The
+// row stays, since it marks the release built from the context.
+func (r *BuildContextRepository) MarkPurged(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE build_contexts SET purged_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func scanBuildContext(row interface{ Scan(...interface{}) error }) (*BuildContext, error) {
+	bc := &BuildContext{}
+	var releaseID uuid.NullUUID
+	var uploadedBy sql.NullString
+	var purgedAt sql.NullTime
+	err := row.Scan(
+		&bc.ID, &bc.ServiceID, &bc.ObjectKey, &bc.Digest, &bc.SizeBytes,
+		&releaseID, &uploadedBy, &bc.CreatedAt, &bc.ExpiresAt, &purgedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if releaseID.Valid {
+		bc.ReleaseID = &releaseID.UUID
+	}
+	bc.UploadedBy = uploadedBy.String
+	if purgedAt.Valid {
+		bc.PurgedAt = &purgedAt.Time
+	}
+	return bc, nil
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// BuildSecretRepository handles the build secrets of projects. Values are
+// encrypted with the environment variable key.
+type BuildSecretRepository struct {
+	db            DBTX
+	encryptionKey []byte // 32-byte AES-256 key
+}
+
+// NewBuildSecretRepository creates a new BuildSecretRepository
+func NewBuildSecretRepository(db DBTX) *BuildSecretRepository {
+	return &BuildSecretRepository{db: db, encryptionKey: getEncryptionKey()}
+}
+
+const buildSecretColumns = `id, project_id, name, COALESCE(created_by, ''), created_at, updated_at`
+
+func scanBuildSecret(row interface{ Scan(...interface{}) error }, secret *types.BuildSecret) error {
+	return row.Scan(&secret.ID, &secret.ProjectID, &secret.Name, &secret.CreatedBy, &secret.CreatedAt, &secret.UpdatedAt)
+}
+
+// Upsert stores a build secret of a project, replacing the value of the
+// one it had with the same name
+func (r *BuildSecretRepository) Upsert(ctx context.Context, secret *types.BuildSecret, value string) error {
+	encrypted, err := sealAESGCM(r.encryptionKey, value)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt build secret: %w", err)
+	}
+
+	query := `
+		INSERT INTO build_secrets (project_id, name, value_encrypted, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (project_id, name) DO UPDATE SET
+			value_encrypted = EXCLUDED.value_encrypted,
+			created_by = EXCLUDED.created_by,
+			updated_at = NOW()
+		RETURNING ` + buildSecretColumns
+	row := r.db.QueryRowContext(ctx, query, secret.ProjectID, secret.Name, encrypted, secret.CreatedBy)
+	if err := scanBuildSecret(row, secret); err != nil {
+		return fmt.Errorf("failed to save build secret: %w", err)
+	}
+	return nil
+}
+
+// ListByProject retrieves the build secrets of a project, without their
+// values
+func (r *BuildSecretRepository) ListByProject(ctx context.Context, projectID uuid.UUID) ([]*types.BuildSecret, error) {
+	query := `SELECT ` + buildSecretColumns + ` FROM build_secrets WHERE project_id = $1 ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list build secrets: %w", err)
+	}
+	defer rows.Close()
+
+	secrets := []*types.BuildSecret{}
+	for rows.Next() {
+		secret := &types.BuildSecret{}
+		if err := scanBuildSecret(rows, secret); err != nil {
+			return nil, fmt.Errorf("failed to scan build secret: %w", err)
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, rows.Err()
+}
+
+// ListDecrypted retrieves the build secrets of a project with their values,
+// by name
+func (r *BuildSecretRepository) ListDecrypted(ctx context.Context, projectID uuid.UUID) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT name, value_encrypted FROM build_secrets WHERE project_id = $1`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list build secrets: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var name, encrypted string
+		if err := rows.Scan(&name, &encrypted); err != nil {
+			return nil, fmt.Errorf("failed to scan build secret: %w", err)
+		}
+		values[name], err = openAESGCM(r.encryptionKey, encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt build secret %s: %w", name, err)
+		}
+	}
+	return values, rows.Err()
+}
+
+// Delete removes a build secret of a project
+func (r *BuildSecretRepository) Delete(ctx context.Context, projectID uuid.UUID, name string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM build_secrets WHERE project_id = $1 AND name = $2`, projectID, name)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ClusterRepository handles the Kubernetes clusters registered besides the
+// API's own. Kubeconfigs are encrypted with the environment variable key.
+type ClusterRepository struct {
+	db            DBTX
+	encryptionKey []byte // 32-byte AES-256 key
+}
+
+// NewClusterRepository creates a new ClusterRepository
+func NewClusterRepository(db DBTX) *ClusterRepository {
+	return &ClusterRepository{db: db, encryptionKey: getEncryptionKey()}
+}
+
+// NewClusterRepositoryWithTx creates a repository using a transaction
+func NewClusterRepositoryWithTx(tx DBTX) *ClusterRepository {
+	return &ClusterRepository{db: tx, encryptionKey: getEncryptionKey()}
+}
+
+const clusterColumns = `id, name, COALESCE(description, ''), api_server, COALESCE(kube_context, ''),
+	COALESCE(created_by, ''), created_at, updated_at`
+
+func scanCluster(row interface{ Scan(...interface{}) error }, cluster *types.Cluster) error {
+	return row.Scan(&cluster.ID, &cluster.Name, &cluster.Description, &cluster.APIServer, &cluster.KubeContext,
+		&cluster.CreatedBy, &cluster.CreatedAt, &cluster.UpdatedAt)
+}
+
+// Create registers a cluster with the kubeconfig used to reach it
+func (r *ClusterRepository) Create(ctx context.Context, cluster *types.Cluster, kubeconfig string) error {
+	encrypted, err := sealAESGCM(r.encryptionKey, kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt kubeconfig: %w", err)
+	}
+
+	query := `
+		INSERT INTO clusters (name, description, api_server, kube_context, kubeconfig_encrypted, created_by)
+		VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), $5, NULLIF($6, ''))
+		RETURNING ` + clusterColumns
+	row := r.db.QueryRowContext(ctx, query, cluster.Name, cluster.Description, cluster.APIServer, cluster.KubeContext,
+		encrypted, cluster.CreatedBy)
+	if err := scanCluster(row, cluster); err != nil {
+		return fmt.Errorf("failed to create cluster: %w", err)
+	}
+	return nil
+}
+
+// List retrieves the registered clusters, without their kubeconfigs
+func (r *ClusterRepository) List(ctx context.Context) ([]*types.Cluster, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+clusterColumns+` FROM clusters ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := []*types.Cluster{}
+	for rows.Next() {
+		cluster := &types.Cluster{}
+		if err := scanCluster(rows, cluster); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster: %w", err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, rows.Err()
+}
+
+// GetByName retrieves a cluster by name
+func (r *ClusterRepository) GetByName(ctx context.Context, name string) (*types.Cluster, error) {
+	cluster := &types.Cluster{}
+	row := r.db.QueryRowContext(ctx, `SELECT `+clusterColumns+` FROM clusters WHERE name = $1`, name)
+	if err := scanCluster(row, cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// GetKubeconfig retrieves the decrypted kubeconfig and context of a cluster
+func (r *ClusterRepository) GetKubeconfig(ctx context.Context, id uuid.UUID) (string, string, error) {
+	var encrypted, kubeContext string
+	err := r.db.QueryRowContext(ctx, `SELECT kubeconfig_encrypted, COALESCE(kube_context, '') FROM clusters WHERE id = $1`, id).
+		Scan(&encrypted, &kubeContext)
+	if err != nil {
+		return "", "", err
+	}
+	kubeconfig, err := openAESGCM(r.encryptionKey, encrypted)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt kubeconfig of cluster %s: %w", id, err)
+	}
+	return kubeconfig, kubeContext, nil
+}
+
+// CountEnvironments counts the environments deployed to a cluster, or to
+// the API's own cluster when clusterID is nil
+func (r *ClusterRepository) CountEnvironments(ctx context.Context, clusterID *uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM environments WHERE cluster_id IS NOT DISTINCT FROM $1`, clusterID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count environments of cluster: %w", err)
+	}
+	return count, nil
+}
+
+// Delete removes a cluster. It fails while environments are deployed to it.
+func (r *ClusterRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM clusters WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -3,9 +3,13 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
@@ -80,6 +84,41 @@ func NewDatabaseManager(config *DatabaseConfig) (*DatabaseManager, error) {
 	return manager, nil
 }
 
+// PoolConfig sizes the connection pool of a database URL
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// StatementTimeout is set on every connection, so Postgres cancels
+	// runaway queries instead of letting them hold a connection. Zero keeps
+	// the server's setting.
+	StatementTimeout time.Duration
+}
+
+// Open connects to a database URL through the pgx driver with a sized pool.
+// Connections are made lazily; ping the database to check the URL.
+func Open(databaseURL string, pool PoolConfig) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+	if pool.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = fmt.Sprintf("%d", pool.StatementTimeout.Milliseconds())
+	}
+	if _, ok := connConfig.RuntimeParams["application_name"]; !ok {
+		connConfig.RuntimeParams["application_name"] = "enclii-switchyard"
+	}
+
+	db := stdlib.OpenDB(*connConfig)
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	return db, nil
+}
+
 func buildConnectionString(config *DatabaseConfig) string {
 	params := make(map[string]string)
 
@@ -356,32 +395,39 @@ func DefaultDatabaseConfig() *DatabaseConfig {
 }
 
 // Error handling for database operations
+
+// pgErrorCode returns the SQLSTATE code of a PostgreSQL error from either
+// the pgx or the lib/pq driver, or "" for other errors
+func pgErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
 func IsConnectionError(err error) bool {
 	if err == nil {
 		return false
 	}
 
 	// Check for PostgreSQL specific connection errors
-	if pqErr, ok := err.(*pq.Error); ok {
-		switch pqErr.Code {
-		case "08000", "08003", "08006", "08001", "08004":
-			return true
-		}
+	switch pgErrorCode(err) {
+	case "08000", "08003", "08006", "08001", "08004":
+		return true
 	}
 
 	return false
 }
 
 func IsDeadlockError(err error) bool {
-	if pqErr, ok := err.(*pq.Error); ok {
-		return pqErr.Code == "40P01"
-	}
-	return false
+	return pgErrorCode(err) == "40P01"
 }
 
 func IsUniqueConstraintError(err error) bool {
-	if pqErr, ok := err.(*pq.Error); ok {
-		return pqErr.Code == "23505"
-	}
-	return false
+	return pgErrorCode(err) == "23505"
 }
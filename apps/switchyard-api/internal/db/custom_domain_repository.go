@@ -23,17 +23,25 @@ func NewCustomDomainRepositoryWithTx(tx DBTX) *CustomDomainRepository {
 	return &CustomDomainRepository{db: tx}
 }
 
+// customDomainDNSColumns are the DNS target and managed DNS columns, scanned
+// into a CustomDomain after verified_at
+const customDomainDNSColumns = `COALESCE(dns_cname, ''), dns_mode, dns_provider_id, COALESCE(dns_record_id, ''),
+		       COALESCE(dns_status, ''), COALESCE(dns_status_message, ''), dns_checked_at`
+
 // Create adds a new custom domain
 func (r *CustomDomainRepository) Create(ctx context.Context, domain *types.CustomDomain) error {
 	query := `
 		INSERT INTO custom_domains (
 			id, service_id, environment_id, domain, verified, tls_enabled, tls_issuer,
-			created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+			dns_cname, dns_mode, dns_provider_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, NOW(), NOW())
 		RETURNING id, created_at, updated_at
 	`
 
 	domain.ID = uuid.New()
+	if domain.DNSMode == "" {
+		domain.DNSMode = types.DNSModeManual
+	}
 
 	err := r.db.QueryRowContext(
 		ctx,
@@ -45,6 +53,9 @@ func (r *CustomDomainRepository) Create(ctx context.Context, domain *types.Custo
 		domain.Verified,
 		domain.TLSEnabled,
 		domain.TLSIssuer,
+		domain.DNSCNAME,
+		domain.DNSMode,
+		domain.DNSProviderID,
 	).Scan(&domain.ID, &domain.CreatedAt, &domain.UpdatedAt)
 
 	if err != nil {
@@ -58,7 +69,7 @@ func (r *CustomDomainRepository) Create(ctx context.Context, domain *types.Custo
 func (r *CustomDomainRepository) GetByID(ctx context.Context, id string) (*types.CustomDomain, error) {
 	query := `
 		SELECT id, service_id, environment_id, domain, verified, tls_enabled, tls_issuer,
-		       created_at, updated_at, verified_at
+		       created_at, updated_at, verified_at, ` + customDomainDNSColumns + `
 		FROM custom_domains
 		WHERE id = $1
 	`
@@ -75,6 +86,13 @@ func (r *CustomDomainRepository) GetByID(ctx context.Context, id string) (*types
 		&domain.CreatedAt,
 		&domain.UpdatedAt,
 		&domain.VerifiedAt,
+		&domain.DNSCNAME,
+		&domain.DNSMode,
+		&domain.DNSProviderID,
+		&domain.DNSRecordID,
+		&domain.DNSStatus,
+		&domain.DNSStatusMessage,
+		&domain.DNSCheckedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -91,7 +109,7 @@ func (r *CustomDomainRepository) GetByID(ctx context.Context, id string) (*types
 func (r *CustomDomainRepository) GetByServiceID(ctx context.Context, serviceID string) ([]types.CustomDomain, error) {
 	query := `
 		SELECT id, service_id, environment_id, domain, verified, tls_enabled, tls_issuer,
-		       created_at, updated_at, verified_at
+		       created_at, updated_at, verified_at, ` + customDomainDNSColumns + `
 		FROM custom_domains
 		WHERE service_id = $1
 		ORDER BY created_at DESC
@@ -117,6 +135,13 @@ func (r *CustomDomainRepository) GetByServiceID(ctx context.Context, serviceID s
 			&domain.CreatedAt,
 			&domain.UpdatedAt,
 			&domain.VerifiedAt,
+			&domain.DNSCNAME,
+			&domain.DNSMode,
+			&domain.DNSProviderID,
+			&domain.DNSRecordID,
+			&domain.DNSStatus,
+			&domain.DNSStatusMessage,
+			&domain.DNSCheckedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan custom domain: %w", err)
@@ -131,7 +156,7 @@ func (r *CustomDomainRepository) GetByServiceID(ctx context.Context, serviceID s
 func (r *CustomDomainRepository) GetByServiceAndEnvironment(ctx context.Context, serviceID, environmentID string) ([]types.CustomDomain, error) {
 	query := `
 		SELECT id, service_id, environment_id, domain, verified, tls_enabled, tls_issuer,
-		       created_at, updated_at, verified_at
+		       created_at, updated_at, verified_at, ` + customDomainDNSColumns + `
 		FROM custom_domains
 		WHERE service_id = $1 AND environment_id = $2
 		ORDER BY created_at DESC
@@ -157,6 +182,13 @@ func (r *CustomDomainRepository) GetByServiceAndEnvironment(ctx context.Context,
 			&domain.CreatedAt,
 			&domain.UpdatedAt,
 			&domain.VerifiedAt,
+			&domain.DNSCNAME,
+			&domain.DNSMode,
+			&domain.DNSProviderID,
+			&domain.DNSRecordID,
+			&domain.DNSStatus,
+			&domain.DNSStatusMessage,
+			&domain.DNSCheckedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan custom domain: %w", err)
@@ -193,6 +225,82 @@ func (r *CustomDomainRepository) Update(ctx context.Context, domain *types.Custo
 	return nil
 }
 
+// UpdateDNS records the managed DNS state of a domain
+func (r *CustomDomainRepository) UpdateDNS(ctx context.Context, domain *types.CustomDomain) error {
+	query := `
+		UPDATE custom_domains
+		SET dns_mode = $1, dns_provider_id = $2, dns_record_id = NULLIF($3, ''), dns_status = NULLIF($4, ''),
+		    dns_status_message = NULLIF($5, ''), dns_checked_at = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		domain.DNSMode,
+		domain.DNSProviderID,
+		domain.DNSRecordID,
+		domain.DNSStatus,
+		domain.DNSStatusMessage,
+		domain.DNSCheckedAt,
+		domain.ID,
+	).Scan(&domain.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to update custom domain DNS: %w", err)
+	}
+
+	return nil
+}
+
+// ListManagedDNS retrieves all domains whose DNS record Enclii manages
+func (r *CustomDomainRepository) ListManagedDNS(ctx context.Context) ([]types.CustomDomain, error) {
+	query := `
+		SELECT id, service_id, environment_id, domain, verified, tls_enabled, tls_issuer,
+		       created_at, updated_at, verified_at, ` + customDomainDNSColumns + `
+		FROM custom_domains
+		WHERE dns_mode = 'managed' AND dns_provider_id IS NOT NULL
+		ORDER BY dns_checked_at ASC NULLS FIRST
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query managed DNS domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []types.CustomDomain
+	for rows.Next() {
+		var domain types.CustomDomain
+		err := rows.Scan(
+			&domain.ID,
+			&domain.ServiceID,
+			&domain.EnvironmentID,
+			&domain.Domain,
+			&domain.Verified,
+			&domain.TLSEnabled,
+			&domain.TLSIssuer,
+			&domain.CreatedAt,
+			&domain.UpdatedAt,
+			&domain.VerifiedAt,
+			&domain.DNSCNAME,
+			&domain.DNSMode,
+			&domain.DNSProviderID,
+			&domain.DNSRecordID,
+			&domain.DNSStatus,
+			&domain.DNSStatusMessage,
+			&domain.DNSCheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom domain: %w", err)
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, rows.Err()
+}
+
 // Delete removes a custom domain
 func (r *CustomDomainRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM custom_domains WHERE id = $1`
@@ -65,13 +65,15 @@ func (r *DatabaseAddonRepository) GetByID(ctx context.Context, id uuid.UUID) (*t
 	var envID, createdBy sql.NullString
 	var statusMsg, k8sNs, k8sRes, connSecret, host, dbName, username, createdByEmail sql.NullString
 	var port sql.NullInt64
-	var provisionedAt, deletedAt, lastBackupAt sql.NullTime
+	var provisionedAt, deletedAt, lastBackupAt, walArchivedAt sql.NullTime
+	var walArchiveLag sql.NullInt64
 
 	query := `
 		SELECT id, project_id, environment_id, type, name, status, status_message,
 		       config, k8s_namespace, k8s_resource_name, connection_secret,
 		       host, port, database_name, username,
-		       storage_used_bytes, connections_active, last_backup_at,
+		       storage_used_bytes, memory_used_bytes, connections_active, last_backup_at,
+		       wal_archived_at, wal_archive_lag_seconds,
 		       created_by, created_by_email, created_at, updated_at, provisioned_at, deleted_at
 		FROM database_addons WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -80,7 +82,8 @@ func (r *DatabaseAddonRepository) GetByID(ctx context.Context, id uuid.UUID) (*t
 		&addon.ID, &addon.ProjectID, &envID, &addon.Type, &addon.Name, &addon.Status, &statusMsg,
 		&configJSON, &k8sNs, &k8sRes, &connSecret,
 		&host, &port, &dbName, &username,
-		&addon.StorageUsedBytes, &addon.ConnectionsActive, &lastBackupAt,
+		&addon.StorageUsedBytes, &addon.MemoryUsedBytes, &addon.ConnectionsActive, &lastBackupAt,
+		&walArchivedAt, &walArchiveLag,
 		&createdBy, &createdByEmail, &addon.CreatedAt, &addon.UpdatedAt, &provisionedAt, &deletedAt,
 	)
 	if err != nil {
@@ -132,6 +135,12 @@ func (r *DatabaseAddonRepository) GetByID(ctx context.Context, id uuid.UUID) (*t
 	if lastBackupAt.Valid {
 		addon.LastBackupAt = &lastBackupAt.Time
 	}
+	if walArchivedAt.Valid {
+		addon.WALArchivedAt = &walArchivedAt.Time
+	}
+	if walArchiveLag.Valid {
+		addon.WALArchiveLagSeconds = &walArchiveLag.Int64
+	}
 
 	// Parse config JSON
 	if len(configJSON) > 0 {
@@ -150,13 +159,15 @@ func (r *DatabaseAddonRepository) GetByName(ctx context.Context, projectID uuid.
 	var envID, createdBy sql.NullString
 	var statusMsg, k8sNs, k8sRes, connSecret, host, dbName, username, createdByEmail sql.NullString
 	var port sql.NullInt64
-	var provisionedAt, deletedAt, lastBackupAt sql.NullTime
+	var provisionedAt, deletedAt, lastBackupAt, walArchivedAt sql.NullTime
+	var walArchiveLag sql.NullInt64
 
 	query := `
 		SELECT id, project_id, environment_id, type, name, status, status_message,
 		       config, k8s_namespace, k8s_resource_name, connection_secret,
 		       host, port, database_name, username,
-		       storage_used_bytes, connections_active, last_backup_at,
+		       storage_used_bytes, memory_used_bytes, connections_active, last_backup_at,
+		       wal_archived_at, wal_archive_lag_seconds,
 		       created_by, created_by_email, created_at, updated_at, provisioned_at, deleted_at
 		FROM database_addons
 		WHERE project_id = $1 AND name = $2 AND deleted_at IS NULL
@@ -166,7 +177,8 @@ func (r *DatabaseAddonRepository) GetByName(ctx context.Context, projectID uuid.
 		&addon.ID, &addon.ProjectID, &envID, &addon.Type, &addon.Name, &addon.Status, &statusMsg,
 		&configJSON, &k8sNs, &k8sRes, &connSecret,
 		&host, &port, &dbName, &username,
-		&addon.StorageUsedBytes, &addon.ConnectionsActive, &lastBackupAt,
+		&addon.StorageUsedBytes, &addon.MemoryUsedBytes, &addon.ConnectionsActive, &lastBackupAt,
+		&walArchivedAt, &walArchiveLag,
 		&createdBy, &createdByEmail, &addon.CreatedAt, &addon.UpdatedAt, &provisionedAt, &deletedAt,
 	)
 	if err != nil {
@@ -218,6 +230,12 @@ func (r *DatabaseAddonRepository) GetByName(ctx context.Context, projectID uuid.
 	if lastBackupAt.Valid {
 		addon.LastBackupAt = &lastBackupAt.Time
 	}
+	if walArchivedAt.Valid {
+		addon.WALArchivedAt = &walArchivedAt.Time
+	}
+	if walArchiveLag.Valid {
+		addon.WALArchiveLagSeconds = &walArchiveLag.Int64
+	}
 
 	// Parse config JSON
 	if len(configJSON) > 0 {
@@ -235,7 +253,8 @@ func (r *DatabaseAddonRepository) ListByProject(ctx context.Context, projectID u
 		SELECT id, project_id, environment_id, type, name, status, status_message,
 		       config, k8s_namespace, k8s_resource_name, connection_secret,
 		       host, port, database_name, username,
-		       storage_used_bytes, connections_active, last_backup_at,
+		       storage_used_bytes, memory_used_bytes, connections_active, last_backup_at,
+		       wal_archived_at, wal_archive_lag_seconds,
 		       created_by, created_by_email, created_at, updated_at, provisioned_at, deleted_at
 		FROM database_addons
 		WHERE project_id = $1 AND deleted_at IS NULL
@@ -262,7 +281,8 @@ func (r *DatabaseAddonRepository) ListByProjects(ctx context.Context, projectIDs
 		SELECT id, project_id, environment_id, type, name, status, status_message,
 		       config, k8s_namespace, k8s_resource_name, connection_secret,
 		       host, port, database_name, username,
-		       storage_used_bytes, connections_active, last_backup_at,
+		       storage_used_bytes, memory_used_bytes, connections_active, last_backup_at,
+		       wal_archived_at, wal_archive_lag_seconds,
 		       created_by, created_by_email, created_at, updated_at, provisioned_at, deleted_at
 		FROM database_addons
 		WHERE project_id = ANY($1) AND deleted_at IS NULL
@@ -284,7 +304,8 @@ func (r *DatabaseAddonRepository) ListByType(ctx context.Context, projectID uuid
 		SELECT id, project_id, environment_id, type, name, status, status_message,
 		       config, k8s_namespace, k8s_resource_name, connection_secret,
 		       host, port, database_name, username,
-		       storage_used_bytes, connections_active, last_backup_at,
+		       storage_used_bytes, memory_used_bytes, connections_active, last_backup_at,
+		       wal_archived_at, wal_archive_lag_seconds,
 		       created_by, created_by_email, created_at, updated_at, provisioned_at, deleted_at
 		FROM database_addons
 		WHERE project_id = $1 AND type = $2 AND deleted_at IS NULL
@@ -306,7 +327,8 @@ func (r *DatabaseAddonRepository) ListPending(ctx context.Context) ([]*types.Dat
 		SELECT id, project_id, environment_id, type, name, status, status_message,
 		       config, k8s_namespace, k8s_resource_name, connection_secret,
 		       host, port, database_name, username,
-		       storage_used_bytes, connections_active, last_backup_at,
+		       storage_used_bytes, memory_used_bytes, connections_active, last_backup_at,
+		       wal_archived_at, wal_archive_lag_seconds,
 		       created_by, created_by_email, created_at, updated_at, provisioned_at, deleted_at
 		FROM database_addons
 		WHERE status IN ('pending', 'provisioning', 'deleting') AND deleted_at IS NULL
@@ -322,6 +344,29 @@ func (r *DatabaseAddonRepository) ListPending(ctx context.Context) ([]*types.Dat
 	return r.scanAddons(rows)
 }
 
+// ListReadyByType retrieves all ready addons of a given type across projects (for metrics collection)
+func (r *DatabaseAddonRepository) ListReadyByType(ctx context.Context, addonType types.DatabaseAddonType) ([]*types.DatabaseAddon, error) {
+	query := `
+		SELECT id, project_id, environment_id, type, name, status, status_message,
+		       config, k8s_namespace, k8s_resource_name, connection_secret,
+		       host, port, database_name, username,
+		       storage_used_bytes, memory_used_bytes, connections_active, last_backup_at,
+		       wal_archived_at, wal_archive_lag_seconds,
+		       created_by, created_by_email, created_at, updated_at, provisioned_at, deleted_at
+		FROM database_addons
+		WHERE type = $1 AND status = 'ready' AND deleted_at IS NULL
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, addonType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanAddons(rows)
+}
+
 // scanAddons scans multiple addon rows
 func (r *DatabaseAddonRepository) scanAddons(rows *sql.Rows) ([]*types.DatabaseAddon, error) {
 	var addons []*types.DatabaseAddon
@@ -332,13 +377,15 @@ func (r *DatabaseAddonRepository) scanAddons(rows *sql.Rows) ([]*types.DatabaseA
 		var envID, createdBy sql.NullString
 		var statusMsg, k8sNs, k8sRes, connSecret, host, dbName, username, createdByEmail sql.NullString
 		var port sql.NullInt64
-		var provisionedAt, deletedAt, lastBackupAt sql.NullTime
+		var provisionedAt, deletedAt, lastBackupAt, walArchivedAt sql.NullTime
+		var walArchiveLag sql.NullInt64
 
 		err := rows.Scan(
 			&addon.ID, &addon.ProjectID, &envID, &addon.Type, &addon.Name, &addon.Status, &statusMsg,
 			&configJSON, &k8sNs, &k8sRes, &connSecret,
 			&host, &port, &dbName, &username,
-			&addon.StorageUsedBytes, &addon.ConnectionsActive, &lastBackupAt,
+			&addon.StorageUsedBytes, &addon.MemoryUsedBytes, &addon.ConnectionsActive, &lastBackupAt,
+			&walArchivedAt, &walArchiveLag,
 			&createdBy, &createdByEmail, &addon.CreatedAt, &addon.UpdatedAt, &provisionedAt, &deletedAt,
 		)
 		if err != nil {
@@ -390,6 +437,12 @@ func (r *DatabaseAddonRepository) scanAddons(rows *sql.Rows) ([]*types.DatabaseA
 		if lastBackupAt.Valid {
 			addon.LastBackupAt = &lastBackupAt.Time
 		}
+		if walArchivedAt.Valid {
+			addon.WALArchivedAt = &walArchivedAt.Time
+		}
+		if walArchiveLag.Valid {
+			addon.WALArchiveLagSeconds = &walArchiveLag.Int64
+		}
 
 		// Parse config JSON
 		if len(configJSON) > 0 {
@@ -418,15 +471,15 @@ func (r *DatabaseAddonRepository) Update(ctx context.Context, addon *types.Datab
 		SET status = $1, status_message = $2, config = $3,
 		    k8s_namespace = $4, k8s_resource_name = $5, connection_secret = $6,
 		    host = $7, port = $8, database_name = $9, username = $10,
-		    storage_used_bytes = $11, connections_active = $12, last_backup_at = $13,
-		    updated_at = $14, provisioned_at = $15
-		WHERE id = $16
+		    storage_used_bytes = $11, memory_used_bytes = $12, connections_active = $13, last_backup_at = $14,
+		    updated_at = $15, provisioned_at = $16
+		WHERE id = $17
 	`
 	result, err := r.db.ExecContext(ctx, query,
 		addon.Status, addon.StatusMessage, configJSON,
 		addon.K8sNamespace, addon.K8sResourceName, addon.ConnectionSecret,
 		addon.Host, addon.Port, addon.DatabaseName, addon.Username,
-		addon.StorageUsedBytes, addon.ConnectionsActive, addon.LastBackupAt,
+		addon.StorageUsedBytes, addon.MemoryUsedBytes, addon.ConnectionsActive, addon.LastBackupAt,
 		addon.UpdatedAt, addon.ProvisionedAt,
 		addon.ID,
 	)
@@ -442,6 +495,47 @@ func (r *DatabaseAddonRepository) Update(ctx context.Context, addon *types.Datab
 	return nil
 }
 
+// UpdateMetrics updates the resource usage metrics of a database addon
+func (r *DatabaseAddonRepository) UpdateMetrics(ctx context.Context, id uuid.UUID, storageUsedBytes, memoryUsedBytes int64, connectionsActive int) error {
+	query := `
+		UPDATE database_addons
+		SET storage_used_bytes = $1, memory_used_bytes = $2, connections_active = $3, updated_at = $4
+		WHERE id = $5
+	`
+	result, err := r.db.ExecContext(ctx, query, storageUsedBytes, memoryUsedBytes, connectionsActive, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateWALArchiveStatus records when a PostgreSQL addon last archived a WAL
+// segment and how far behind archiving was when sampled
+func (r *DatabaseAddonRepository) UpdateWALArchiveStatus(ctx context.Context, id uuid.UUID, archivedAt *time.Time, lagSeconds *int64) error {
+	query := `
+		UPDATE database_addons
+		SET wal_archived_at = $1, wal_archive_lag_seconds = $2, updated_at = $3
+		WHERE id = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, archivedAt, lagSeconds, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 // UpdateStatus updates just the status of a database addon
 func (r *DatabaseAddonRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status types.DatabaseAddonStatus, message string) error {
 	query := `
@@ -656,11 +750,13 @@ func (r *DatabaseAddonRepository) CreateBackup(ctx context.Context, backup *type
 	backup.Status = types.DatabaseAddonBackupStatusPending
 
 	query := `
-		INSERT INTO database_addon_backups (id, addon_id, backup_type, status, status_message, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO database_addon_backups (id, addon_id, backup_type, status, status_message, created_at,
+		                                    encryption_key_id, wrapped_data_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		backup.ID, backup.AddonID, backup.BackupType, backup.Status, backup.StatusMessage, backup.CreatedAt,
+		backup.EncryptionKeyID, backup.WrappedDataKey,
 	)
 	return err
 }
@@ -698,7 +794,7 @@ func (r *DatabaseAddonRepository) GetBackupsByAddon(ctx context.Context, addonID
 
 	query := `
 		SELECT id, addon_id, backup_type, status, status_message, storage_path, size_bytes,
-		       started_at, completed_at, expires_at, created_at
+		       started_at, completed_at, expires_at, created_at, encryption_key_id, wrapped_data_key
 		FROM database_addon_backups
 		WHERE addon_id = $1
 		ORDER BY created_at DESC
@@ -711,16 +807,103 @@ func (r *DatabaseAddonRepository) GetBackupsByAddon(ctx context.Context, addonID
 	}
 	defer rows.Close()
 
+	return scanBackups(rows)
+}
+
+// GetBackup retrieves a backup by ID
+func (r *DatabaseAddonRepository) GetBackup(ctx context.Context, id uuid.UUID) (*types.DatabaseAddonBackup, error) {
+	query := `
+		SELECT id, addon_id, backup_type, status, status_message, storage_path, size_bytes,
+		       started_at, completed_at, expires_at, created_at, encryption_key_id, wrapped_data_key
+		FROM database_addon_backups
+		WHERE id = $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	backups, err := scanBackups(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return backups[0], nil
+}
+
+// ListBackupsByStatus retrieves backups in the given status across all addons
+func (r *DatabaseAddonRepository) ListBackupsByStatus(ctx context.Context, status types.DatabaseAddonBackupStatus) ([]*types.DatabaseAddonBackup, error) {
+	query := `
+		SELECT id, addon_id, backup_type, status, status_message, storage_path, size_bytes,
+		       started_at, completed_at, expires_at, created_at, encryption_key_id, wrapped_data_key
+		FROM database_addon_backups
+		WHERE status = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanBackups(rows)
+}
+
+// ListExpiredBackups retrieves finished backups whose retention period has passed
+func (r *DatabaseAddonRepository) ListExpiredBackups(ctx context.Context, now time.Time) ([]*types.DatabaseAddonBackup, error) {
+	query := `
+		SELECT id, addon_id, backup_type, status, status_message, storage_path, size_bytes,
+		       started_at, completed_at, expires_at, created_at, encryption_key_id, wrapped_data_key
+		FROM database_addon_backups
+		WHERE expires_at IS NOT NULL AND expires_at < $1
+		  AND status IN ('completed', 'failed')
+		ORDER BY expires_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanBackups(rows)
+}
+
+// DeleteBackup removes a backup record
+func (r *DatabaseAddonRepository) DeleteBackup(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM database_addon_backups WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// scanBackups scans backup rows into a slice
+func scanBackups(rows *sql.Rows) ([]*types.DatabaseAddonBackup, error) {
 	var backups []*types.DatabaseAddonBackup
 	for rows.Next() {
 		backup := &types.DatabaseAddonBackup{}
 		var statusMsg, storagePath sql.NullString
 		var sizeBytes sql.NullInt64
 		var startedAt, completedAt, expiresAt sql.NullTime
+		var wrappedDataKey sql.NullString
+		var encryptionKeyID uuid.NullUUID
 
 		err := rows.Scan(
 			&backup.ID, &backup.AddonID, &backup.BackupType, &backup.Status, &statusMsg, &storagePath, &sizeBytes,
-			&startedAt, &completedAt, &expiresAt, &backup.CreatedAt,
+			&startedAt, &completedAt, &expiresAt, &backup.CreatedAt, &encryptionKeyID, &wrappedDataKey,
 		)
 		if err != nil {
 			return nil, err
@@ -744,9 +927,277 @@ func (r *DatabaseAddonRepository) GetBackupsByAddon(ctx context.Context, addonID
 		if expiresAt.Valid {
 			backup.ExpiresAt = &expiresAt.Time
 		}
+		if encryptionKeyID.Valid {
+			backup.EncryptionKeyID = &encryptionKeyID.UUID
+		}
+		if wrappedDataKey.Valid {
+			backup.WrappedDataKey = wrappedDataKey.String
+		}
 
 		backups = append(backups, backup)
 	}
 
-	return backups, nil
+	return backups, rows.Err()
+}
+
+// ============================================================================
+// RESTORE OPERATIONS
+// ============================================================================
+
+// CreateRestore creates a new restore record
+func (r *DatabaseAddonRepository) CreateRestore(ctx context.Context, restore *types.DatabaseAddonRestore) error {
+	restore.ID = uuid.New()
+	restore.CreatedAt = time.Now()
+	restore.Status = types.DatabaseAddonRestoreStatusPending
+
+	query := `
+		INSERT INTO database_addon_restores (id, addon_id, backup_id, target_time, status, status_message, phase, progress,
+		                                     requested_by, requested_by_email, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		restore.ID, restore.AddonID, restore.BackupID, restore.TargetTime, restore.Status, restore.StatusMessage, restore.Phase, restore.Progress,
+		restore.RequestedBy, restore.RequestedByEmail, restore.CreatedAt,
+	)
+	return err
+}
+
+// UpdateRestore updates a restore record
+func (r *DatabaseAddonRepository) UpdateRestore(ctx context.Context, restore *types.DatabaseAddonRestore) error {
+	query := `
+		UPDATE database_addon_restores
+		SET status = $1, status_message = $2, phase = $3, progress = $4, started_at = $5, completed_at = $6
+		WHERE id = $7
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		restore.Status, restore.StatusMessage, restore.Phase, restore.Progress, restore.StartedAt, restore.CompletedAt,
+		restore.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetRestore retrieves a restore by ID
+func (r *DatabaseAddonRepository) GetRestore(ctx context.Context, id uuid.UUID) (*types.DatabaseAddonRestore, error) {
+	rows, err := r.db.QueryContext(ctx, restoreSelect+` WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	restores, err := scanRestores(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(restores) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return restores[0], nil
+}
+
+// GetRestoresByAddon retrieves the restore history of an addon
+func (r *DatabaseAddonRepository) GetRestoresByAddon(ctx context.Context, addonID uuid.UUID, limit int) ([]*types.DatabaseAddonRestore, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := r.db.QueryContext(ctx, restoreSelect+` WHERE addon_id = $1 ORDER BY created_at DESC LIMIT $2`, addonID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRestores(rows)
+}
+
+// ListActiveRestores retrieves pending and in-progress restores, optionally for a single addon
+func (r *DatabaseAddonRepository) ListActiveRestores(ctx context.Context, addonID *uuid.UUID) ([]*types.DatabaseAddonRestore, error) {
+	query := restoreSelect + ` WHERE status IN ('pending', 'in_progress') AND ($1::uuid IS NULL OR addon_id = $1) ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, addonID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRestores(rows)
+}
+
+const restoreSelect = `
+	SELECT id, addon_id, backup_id, target_time, status, status_message, phase, progress,
+	       requested_by, requested_by_email, started_at, completed_at, created_at
+	FROM database_addon_restores`
+
+// scanRestores scans restore rows into a slice
+func scanRestores(rows *sql.Rows) ([]*types.DatabaseAddonRestore, error) {
+	var restores []*types.DatabaseAddonRestore
+	for rows.Next() {
+		restore := &types.DatabaseAddonRestore{}
+		var statusMsg, phase, requestedByEmail sql.NullString
+		var backupID, requestedBy uuid.NullUUID
+		var targetTime, startedAt, completedAt sql.NullTime
+
+		err := rows.Scan(
+			&restore.ID, &restore.AddonID, &backupID, &targetTime, &restore.Status, &statusMsg, &phase, &restore.Progress,
+			&requestedBy, &requestedByEmail, &startedAt, &completedAt, &restore.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if backupID.Valid {
+			restore.BackupID = &backupID.UUID
+		}
+		if targetTime.Valid {
+			restore.TargetTime = &targetTime.Time
+		}
+		restore.StatusMessage = statusMsg.String
+		restore.Phase = phase.String
+		restore.RequestedByEmail = requestedByEmail.String
+		if requestedBy.Valid {
+			restore.RequestedBy = &requestedBy.UUID
+		}
+		if startedAt.Valid {
+			restore.StartedAt = &startedAt.Time
+		}
+		if completedAt.Valid {
+			restore.CompletedAt = &completedAt.Time
+		}
+
+		restores = append(restores, restore)
+	}
+
+	return restores, rows.Err()
+}
+
+// ============================================================================
+// RESIZE OPERATIONS
+// ============================================================================
+
+// CreateResize creates a new in-progress resize record
+func (r *DatabaseAddonRepository) CreateResize(ctx context.Context, resize *types.DatabaseAddonResize) error {
+	resize.ID = uuid.New()
+	resize.StartedAt = time.Now()
+	resize.Status = types.DatabaseAddonResizeStatusInProgress
+
+	previousJSON, err := json.Marshal(resize.PreviousConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal previous config: %w", err)
+	}
+	targetJSON, err := json.Marshal(resize.TargetConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal target config: %w", err)
+	}
+
+	query := `
+		INSERT INTO database_addon_resizes (id, addon_id, status, status_message, previous_config, target_config,
+		                                    requested_by, requested_by_email, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		resize.ID, resize.AddonID, resize.Status, resize.StatusMessage, previousJSON, targetJSON,
+		resize.RequestedBy, resize.RequestedByEmail, resize.StartedAt,
+	)
+	return err
+}
+
+// UpdateResize updates the status of a resize record
+func (r *DatabaseAddonRepository) UpdateResize(ctx context.Context, resize *types.DatabaseAddonResize) error {
+	query := `
+		UPDATE database_addon_resizes
+		SET status = $1, status_message = $2, completed_at = $3
+		WHERE id = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, resize.Status, resize.StatusMessage, resize.CompletedAt, resize.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetResizesByAddon retrieves the resize history of an addon
+func (r *DatabaseAddonRepository) GetResizesByAddon(ctx context.Context, addonID uuid.UUID, limit int) ([]*types.DatabaseAddonResize, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := r.db.QueryContext(ctx, resizeSelect+` WHERE addon_id = $1 ORDER BY started_at DESC LIMIT $2`, addonID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanResizes(rows)
+}
+
+// ListActiveResizes retrieves in-progress resizes, optionally for a single addon
+func (r *DatabaseAddonRepository) ListActiveResizes(ctx context.Context, addonID *uuid.UUID) ([]*types.DatabaseAddonResize, error) {
+	query := resizeSelect + ` WHERE status = 'in_progress' AND ($1::uuid IS NULL OR addon_id = $1) ORDER BY started_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, addonID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanResizes(rows)
+}
+
+const resizeSelect = `
+	SELECT id, addon_id, status, status_message, previous_config, target_config,
+	       requested_by, requested_by_email, started_at, completed_at
+	FROM database_addon_resizes`
+
+// scanResizes scans resize rows into a slice
+func scanResizes(rows *sql.Rows) ([]*types.DatabaseAddonResize, error) {
+	var resizes []*types.DatabaseAddonResize
+	for rows.Next() {
+		resize := &types.DatabaseAddonResize{}
+		var statusMsg, requestedByEmail sql.NullString
+		var previousJSON, targetJSON []byte
+		var requestedBy uuid.NullUUID
+		var completedAt sql.NullTime
+
+		err := rows.Scan(
+			&resize.ID, &resize.AddonID, &resize.Status, &statusMsg, &previousJSON, &targetJSON,
+			&requestedBy, &requestedByEmail, &resize.StartedAt, &completedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(previousJSON, &resize.PreviousConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal previous config: %w", err)
+		}
+		if err := json.Unmarshal(targetJSON, &resize.TargetConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal target config: %w", err)
+		}
+		resize.StatusMessage = statusMsg.String
+		resize.RequestedByEmail = requestedByEmail.String
+		if requestedBy.Valid {
+			resize.RequestedBy = &requestedBy.UUID
+		}
+		if completedAt.Valid {
+			resize.CompletedAt = &completedAt.Time
+		}
+
+		resizes = append(resizes, resize)
+	}
+
+	return resizes, rows.Err()
 }
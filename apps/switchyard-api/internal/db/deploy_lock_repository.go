@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// DeployLockRepository handles the locks that keep deployments of a service
+// in an environment from being reconciled at the same time
+type DeployLockRepository struct {
+	db DBTX
+}
+
+// NewDeployLockRepository creates a new DeployLockRepository
+func NewDeployLockRepository(db DBTX) *DeployLockRepository {
+	return &DeployLockRepository{db: db}
+}
+
+// NewDeployLockRepositoryWithTx creates a repository using a transaction
+func NewDeployLockRepositoryWithTx(tx DBTX) *DeployLockRepository {
+	return &DeployLockRepository{db: tx}
+}
+
+// Acquire takes the lock of a service in an environment for a deployment
+// until the lease runs out. The lock is free when nobody holds it, its lease
+// ran out, or the deployment already holds it. Otherwise the current lock is
+// returned with acquired false.
+func (r *DeployLockRepository) Acquire(ctx context.Context, serviceID, environmentID, deploymentID uuid.UUID, owner string, lease time.Duration) (*types.DeployLock, bool, error) {
+	query := `
+		INSERT INTO deploy_locks (service_id, environment_id, deployment_id, owner, locked_until)
+		VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5))
+		ON CONFLICT (service_id, environment_id) DO UPDATE SET
+			deployment_id = EXCLUDED.deployment_id,
+			owner = EXCLUDED.owner,
+			locked_until = EXCLUDED.locked_until,
+			acquired_at = NOW()
+		WHERE deploy_locks.locked_until < NOW() OR deploy_locks.deployment_id = EXCLUDED.deployment_id
+		RETURNING service_id, environment_id, deployment_id, owner, locked_until, acquired_at
+	`
+	lock := &types.DeployLock{}
+	err := r.db.QueryRowContext(ctx, query, serviceID, environmentID, deploymentID, owner, lease.Seconds()).Scan(
+		&lock.ServiceID, &lock.EnvironmentID, &lock.DeploymentID, &lock.Owner, &lock.LockedUntil, &lock.AcquiredAt,
+	)
+	if err == nil {
+		return lock, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("failed to acquire deploy lock: %w", err)
+	}
+
+	// Held by another deployment
+	err = r.db.QueryRowContext(ctx, `
+		SELECT service_id, environment_id, deployment_id, owner, locked_until, acquired_at
+		FROM deploy_locks WHERE service_id = $1 AND environment_id = $2
+	`, serviceID, environmentID).Scan(
+		&lock.ServiceID, &lock.EnvironmentID, &lock.DeploymentID, &lock.Owner, &lock.LockedUntil, &lock.AcquiredAt,
+	)
+	if err == sql.ErrNoRows {
+		// Released in the meantime; the caller tries again later
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get deploy lock: %w", err)
+	}
+	return lock, false, nil
+}
+
+// Release gives up the lock a deployment holds, if any
+func (r *DeployLockRepository) Release(ctx context.Context, deploymentID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM deploy_locks WHERE deployment_id = $1`, deploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to release deploy lock: %w", err)
+	}
+	return nil
+}
+
+// ListByEnvironment returns the locks held in an environment
+func (r *DeployLockRepository) ListByEnvironment(ctx context.Context, environmentID uuid.UUID) ([]*types.DeployLock, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT l.service_id, s.name, l.environment_id, l.deployment_id, l.owner, l.locked_until, l.acquired_at
+		FROM deploy_locks l
+		JOIN services s ON s.id = l.service_id
+		WHERE l.environment_id = $1 AND l.locked_until >= NOW()
+		ORDER BY s.name
+	`, environmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locks := []*types.DeployLock{}
+	for rows.Next() {
+		lock := &types.DeployLock{}
+		if err := rows.Scan(&lock.ServiceID, &lock.ServiceName, &lock.EnvironmentID, &lock.DeploymentID,
+			&lock.Owner, &lock.LockedUntil, &lock.AcquiredAt); err != nil {
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+	return locks, rows.Err()
+}
+
+// ListPendingByServiceAndEnvironment returns the pending deployments of a
+// service in an environment, oldest first
+func (r *DeploymentRepository) ListPendingByServiceAndEnvironment(ctx context.Context, serviceID, environmentID uuid.UUID) ([]*types.Deployment, error) {
+	query := `
+		SELECT d.id, d.release_id, d.environment_id, d.replicas, d.status, d.health, d.error_message, d.annotations, d.stalled_at, d.created_at, d.updated_at
+		FROM deployments d
+		JOIN releases r ON d.release_id = r.id
+		WHERE r.service_id = $1 AND d.environment_id = $2 AND d.status = $3
+		ORDER BY d.created_at ASC, d.id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, serviceID, environmentID, types.DeploymentStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deployments []*types.Deployment
+	for rows.Next() {
+		deployment := &types.Deployment{}
+		var annotations []byte
+		err := rows.Scan(
+			&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID,
+			&deployment.Replicas, &deployment.Status, &deployment.Health,
+			&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, deployment)
+	}
+	return deployments, rows.Err()
+}
+
+// MarkSuperseded marks a pending deployment superseded, with a message
+// naming what replaced it. It reports false when the deployment was no
+// longer pending.
+func (r *DeploymentRepository) MarkSuperseded(ctx context.Context, id uuid.UUID, message string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE deployments SET status = $1, health = $2, error_message = $3, stalled_at = NULL, updated_at = NOW()
+		WHERE id = $4 AND status = $5
+	`, types.DeploymentStatusSuperseded, types.HealthStatusUnknown, message, id, types.DeploymentStatusPending)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
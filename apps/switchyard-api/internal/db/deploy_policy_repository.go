@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// DeployPolicyRepository handles the deploy policies of environments
+type DeployPolicyRepository struct {
+	db DBTX
+}
+
+// NewDeployPolicyRepository creates a new DeployPolicyRepository
+func NewDeployPolicyRepository(db DBTX) *DeployPolicyRepository {
+	return &DeployPolicyRepository{db: db}
+}
+
+// NewDeployPolicyRepositoryWithTx creates a repository using a transaction
+func NewDeployPolicyRepositoryWithTx(tx DBTX) *DeployPolicyRepository {
+	return &DeployPolicyRepository{db: tx}
+}
+
+// Get retrieves the deploy policy of an environment
+func (r *DeployPolicyRepository) Get(ctx context.Context, environmentID uuid.UUID) (*types.DeployPolicy, error) {
+	query := `
+		SELECT environment_id, timezone, windows, allow_override, created_at, updated_at
+		FROM environment_deploy_policies
+		WHERE environment_id = $1
+	`
+
+	policy := &types.DeployPolicy{}
+	var windows []byte
+	err := r.db.QueryRowContext(ctx, query, environmentID).Scan(
+		&policy.EnvironmentID, &policy.Timezone, &windows, &policy.AllowOverride,
+		&policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(windows, &policy.Windows); err != nil {
+		return nil, fmt.Errorf("failed to decode deploy windows: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Upsert creates or replaces the deploy policy of an environment
+func (r *DeployPolicyRepository) Upsert(ctx context.Context, policy *types.DeployPolicy) error {
+	if policy.Windows == nil {
+		policy.Windows = []types.DeployWindow{}
+	}
+	windows, err := json.Marshal(policy.Windows)
+	if err != nil {
+		return fmt.Errorf("failed to encode deploy windows: %w", err)
+	}
+
+	now := time.Now()
+	policy.UpdatedAt = now
+
+	query := `
+		INSERT INTO environment_deploy_policies (environment_id, timezone, windows, allow_override, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (environment_id) DO UPDATE SET
+			timezone = EXCLUDED.timezone,
+			windows = EXCLUDED.windows,
+			allow_override = EXCLUDED.allow_override,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+	err = r.db.QueryRowContext(ctx, query,
+		policy.EnvironmentID, policy.Timezone, windows, policy.AllowOverride, now,
+	).Scan(&policy.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save deploy policy: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the deploy policy of an environment
+func (r *DeployPolicyRepository) Delete(ctx context.Context, environmentID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM environment_deploy_policies WHERE environment_id = $1`, environmentID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
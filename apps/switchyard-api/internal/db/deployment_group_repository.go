@@ -19,6 +19,8 @@ const (
 	DeploymentGroupStatusSucceeded  DeploymentGroupStatus = "succeeded"
 	DeploymentGroupStatusFailed     DeploymentGroupStatus = "failed"
 	DeploymentGroupStatusRolledBack DeploymentGroupStatus = "rolled_back"
+	DeploymentGroupStatusScheduled  DeploymentGroupStatus = "scheduled" // Waiting for its scheduled time
+	DeploymentGroupStatusCancelled  DeploymentGroupStatus = "cancelled" // Cancelled before its scheduled time
 )
 
 // DeploymentGroupStrategy represents the deployment strategy
@@ -44,8 +46,16 @@ type DeploymentGroup struct {
 	StartedAt     *time.Time              `json:"started_at,omitempty"`
 	CompletedAt   *time.Time              `json:"completed_at,omitempty"`
 	ErrorMessage  *string                 `json:"error_message,omitempty"`
-	CreatedAt     time.Time               `json:"created_at"`
-	UpdatedAt     time.Time               `json:"updated_at"`
+
+	// Scheduled execution
+	ScheduledAt          *time.Time `json:"scheduled_at,omitempty"`
+	RespectFreezeWindows bool       `json:"respect_freeze_windows"`
+	ReminderSentAt       *time.Time `json:"reminder_sent_at,omitempty"`
+	CancelledAt          *time.Time `json:"cancelled_at,omitempty"`
+	CancelledBy          *string    `json:"cancelled_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // DependencyType represents the type of service dependency
@@ -81,6 +91,13 @@ func NewDeploymentGroupRepositoryWithTx(tx DBTX) *DeploymentGroupRepository {
 	return &DeploymentGroupRepository{db: tx}
 }
 
+const deploymentGroupColumns = `
+	id, project_id, environment_id, name, status, strategy,
+	triggered_by, git_sha, pr_url, started_at, completed_at,
+	error_message, scheduled_at, respect_freeze_windows, reminder_sent_at,
+	cancelled_at, cancelled_by, created_at, updated_at
+`
+
 // Create inserts a new deployment group
 func (r *DeploymentGroupRepository) Create(ctx context.Context, group *DeploymentGroup) error {
 	group.ID = uuid.New()
@@ -97,67 +114,24 @@ func (r *DeploymentGroupRepository) Create(ctx context.Context, group *Deploymen
 		INSERT INTO deployment_groups (
 			id, project_id, environment_id, name, status, strategy,
 			triggered_by, git_sha, pr_url, started_at, completed_at,
-			error_message, created_at, updated_at
+			error_message, scheduled_at, respect_freeze_windows, created_at, updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		group.ID, group.ProjectID, group.EnvironmentID, group.Name,
 		group.Status, group.Strategy, group.TriggeredBy, group.GitSHA,
 		group.PRURL, group.StartedAt, group.CompletedAt, group.ErrorMessage,
-		group.CreatedAt, group.UpdatedAt,
+		group.ScheduledAt, group.RespectFreezeWindows, group.CreatedAt, group.UpdatedAt,
 	)
 	return err
 }
 
 // GetByID retrieves a deployment group by ID
 func (r *DeploymentGroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*DeploymentGroup, error) {
-	group := &DeploymentGroup{}
-	var name, triggeredBy, gitSHA, prURL, errorMessage sql.NullString
-	var startedAt, completedAt sql.NullTime
-
-	query := `
-		SELECT id, project_id, environment_id, name, status, strategy,
-		       triggered_by, git_sha, pr_url, started_at, completed_at,
-		       error_message, created_at, updated_at
-		FROM deployment_groups
-		WHERE id = $1
-	`
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&group.ID, &group.ProjectID, &group.EnvironmentID, &name,
-		&group.Status, &group.Strategy, &triggeredBy, &gitSHA,
-		&prURL, &startedAt, &completedAt, &errorMessage,
-		&group.CreatedAt, &group.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	if name.Valid {
-		group.Name = &name.String
-	}
-	if triggeredBy.Valid {
-		group.TriggeredBy = &triggeredBy.String
-	}
-	if gitSHA.Valid {
-		group.GitSHA = &gitSHA.String
-	}
-	if prURL.Valid {
-		group.PRURL = &prURL.String
-	}
-	if startedAt.Valid {
-		group.StartedAt = &startedAt.Time
-	}
-	if completedAt.Valid {
-		group.CompletedAt = &completedAt.Time
-	}
-	if errorMessage.Valid {
-		group.ErrorMessage = &errorMessage.String
-	}
-
-	return group, nil
+	query := `SELECT ` + deploymentGroupColumns + ` FROM deployment_groups WHERE id = $1`
+	return scanDeploymentGroup(r.db.QueryRowContext(ctx, query, id))
 }
 
 // ListByProject retrieves deployment groups for a project
@@ -167,9 +141,7 @@ func (r *DeploymentGroupRepository) ListByProject(ctx context.Context, projectID
 	}
 
 	query := `
-		SELECT id, project_id, environment_id, name, status, strategy,
-		       triggered_by, git_sha, pr_url, started_at, completed_at,
-		       error_message, created_at, updated_at
+		SELECT ` + deploymentGroupColumns + `
 		FROM deployment_groups
 		WHERE project_id = $1
 		ORDER BY created_at DESC
@@ -192,9 +164,7 @@ func (r *DeploymentGroupRepository) ListByProjectAndEnvironment(ctx context.Cont
 	}
 
 	query := `
-		SELECT id, project_id, environment_id, name, status, strategy,
-		       triggered_by, git_sha, pr_url, started_at, completed_at,
-		       error_message, created_at, updated_at
+		SELECT ` + deploymentGroupColumns + `
 		FROM deployment_groups
 		WHERE project_id = $1 AND environment_id = $2
 		ORDER BY created_at DESC
@@ -217,9 +187,7 @@ func (r *DeploymentGroupRepository) ListByStatus(ctx context.Context, status Dep
 	}
 
 	query := `
-		SELECT id, project_id, environment_id, name, status, strategy,
-		       triggered_by, git_sha, pr_url, started_at, completed_at,
-		       error_message, created_at, updated_at
+		SELECT ` + deploymentGroupColumns + `
 		FROM deployment_groups
 		WHERE status = $1
 		ORDER BY created_at ASC
@@ -235,6 +203,78 @@ func (r *DeploymentGroupRepository) ListByStatus(ctx context.Context, status Dep
 	return r.scanGroups(rows)
 }
 
+// ListScheduledBefore retrieves the scheduled groups due to run before a
+// time, soonest first
+func (r *DeploymentGroupRepository) ListScheduledBefore(ctx context.Context, before time.Time) ([]*DeploymentGroup, error) {
+	query := `
+		SELECT ` + deploymentGroupColumns + `
+		FROM deployment_groups
+		WHERE status = 'scheduled' AND scheduled_at <= $1
+		ORDER BY scheduled_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanGroups(rows)
+}
+
+// ClaimScheduled moves a scheduled group to pending so it can be executed.
+// It reports false when the group was cancelled or claimed in the meantime.
+func (r *DeploymentGroupRepository) ClaimScheduled(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE deployment_groups
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = 'scheduled'
+	`
+	result, err := r.db.ExecContext(ctx, query, DeploymentGroupStatusPending, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// Reschedule moves a scheduled group to a new time. The reminder is sent
+// again before the new time.
+func (r *DeploymentGroupRepository) Reschedule(ctx context.Context, id uuid.UUID, scheduledAt time.Time) error {
+	query := `
+		UPDATE deployment_groups
+		SET scheduled_at = $1, reminder_sent_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND status = 'scheduled'
+	`
+	_, err := r.db.ExecContext(ctx, query, scheduledAt, id)
+	return err
+}
+
+// MarkReminderSent records that the reminder of a scheduled group was sent
+func (r *DeploymentGroupRepository) MarkReminderSent(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE deployment_groups SET reminder_sent_at = NOW(), updated_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// Cancel cancels a scheduled group. It reports false when the group is no
+// longer scheduled.
+func (r *DeploymentGroupRepository) Cancel(ctx context.Context, id uuid.UUID, cancelledBy string) (bool, error) {
+	query := `
+		UPDATE deployment_groups
+		SET status = $1, cancelled_at = NOW(), cancelled_by = $2, updated_at = NOW()
+		WHERE id = $3 AND status = 'scheduled'
+	`
+	result, err := r.db.ExecContext(ctx, query, DeploymentGroupStatusCancelled, cancelledBy, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
 // UpdateStatus updates the status of a deployment group
 func (r *DeploymentGroupRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status DeploymentGroupStatus, errorMsg *string) error {
 	query := `
@@ -279,46 +319,68 @@ func (r *DeploymentGroupRepository) scanGroups(rows *sql.Rows) ([]*DeploymentGro
 	var groups []*DeploymentGroup
 
 	for rows.Next() {
-		group := &DeploymentGroup{}
-		var name, triggeredBy, gitSHA, prURL, errorMessage sql.NullString
-		var startedAt, completedAt sql.NullTime
-
-		err := rows.Scan(
-			&group.ID, &group.ProjectID, &group.EnvironmentID, &name,
-			&group.Status, &group.Strategy, &triggeredBy, &gitSHA,
-			&prURL, &startedAt, &completedAt, &errorMessage,
-			&group.CreatedAt, &group.UpdatedAt,
-		)
+		group, err := scanDeploymentGroup(rows)
 		if err != nil {
 			return nil, err
 		}
+		groups = append(groups, group)
+	}
 
-		if name.Valid {
-			group.Name = &name.String
-		}
-		if triggeredBy.Valid {
-			group.TriggeredBy = &triggeredBy.String
-		}
-		if gitSHA.Valid {
-			group.GitSHA = &gitSHA.String
-		}
-		if prURL.Valid {
-			group.PRURL = &prURL.String
-		}
-		if startedAt.Valid {
-			group.StartedAt = &startedAt.Time
-		}
-		if completedAt.Valid {
-			group.CompletedAt = &completedAt.Time
-		}
-		if errorMessage.Valid {
-			group.ErrorMessage = &errorMessage.String
-		}
+	return groups, rows.Err()
+}
 
-		groups = append(groups, group)
+// scanDeploymentGroup scans a deployment group from a row
+func scanDeploymentGroup(row interface{ Scan(...interface{}) error }) (*DeploymentGroup, error) {
+	group := &DeploymentGroup{}
+	var name, triggeredBy, gitSHA, prURL, errorMessage, cancelledBy sql.NullString
+	var startedAt, completedAt, scheduledAt, reminderSentAt, cancelledAt sql.NullTime
+
+	err := row.Scan(
+		&group.ID, &group.ProjectID, &group.EnvironmentID, &name,
+		&group.Status, &group.Strategy, &triggeredBy, &gitSHA,
+		&prURL, &startedAt, &completedAt, &errorMessage,
+		&scheduledAt, &group.RespectFreezeWindows, &reminderSentAt,
+		&cancelledAt, &cancelledBy, &group.CreatedAt, &group.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if name.Valid {
+		group.Name = &name.String
+	}
+	if triggeredBy.Valid {
+		group.TriggeredBy = &triggeredBy.String
+	}
+	if gitSHA.Valid {
+		group.GitSHA = &gitSHA.String
+	}
+	if prURL.Valid {
+		group.PRURL = &prURL.String
+	}
+	if startedAt.Valid {
+		group.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		group.CompletedAt = &completedAt.Time
+	}
+	if errorMessage.Valid {
+		group.ErrorMessage = &errorMessage.String
+	}
+	if scheduledAt.Valid {
+		group.ScheduledAt = &scheduledAt.Time
+	}
+	if reminderSentAt.Valid {
+		group.ReminderSentAt = &reminderSentAt.Time
+	}
+	if cancelledAt.Valid {
+		group.CancelledAt = &cancelledAt.Time
+	}
+	if cancelledBy.Valid {
+		group.CancelledBy = &cancelledBy.String
 	}
 
-	return groups, nil
+	return group, nil
 }
 
 // ServiceDependencyRepository handles service dependency CRUD operations
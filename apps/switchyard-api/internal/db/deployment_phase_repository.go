@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// DeploymentPhaseRepository records the phases deployments go through while
+// they roll out
+type DeploymentPhaseRepository struct {
+	db DBTX
+}
+
+// NewDeploymentPhaseRepository creates a new DeploymentPhaseRepository
+func NewDeploymentPhaseRepository(db DBTX) *DeploymentPhaseRepository {
+	return &DeploymentPhaseRepository{db: db}
+}
+
+// NewDeploymentPhaseRepositoryWithTx creates a repository using a transaction
+func NewDeploymentPhaseRepositoryWithTx(tx DBTX) *DeploymentPhaseRepository {
+	return &DeploymentPhaseRepository{db: tx}
+}
+
+// Enter moves a deployment into a phase, finishing the one it was in. The
+// done phase is finished as soon as it is entered.
+func (r *DeploymentPhaseRepository) Enter(ctx context.Context, deploymentID uuid.UUID, phase types.DeploymentPhase) error {
+	query := `
+		WITH finished AS (
+			UPDATE deployment_phases SET finished_at = NOW()
+			WHERE deployment_id = $1 AND phase <> $2 AND finished_at IS NULL
+		)
+		INSERT INTO deployment_phases (deployment_id, phase, started_at, finished_at)
+		VALUES ($1, $2, NOW(), CASE WHEN $3 THEN NOW() END)
+		ON CONFLICT (deployment_id, phase) DO UPDATE SET
+			started_at = EXCLUDED.started_at,
+			finished_at = EXCLUDED.finished_at,
+			ready_replicas = 0,
+			desired_replicas = 0
+	`
+	_, err := r.db.ExecContext(ctx, query, deploymentID, phase, phase == types.DeploymentPhaseDone)
+	if err != nil {
+		return fmt.Errorf("failed to record deployment phase: %w", err)
+	}
+	return nil
+}
+
+// UpdateRollout records how many replicas of a deployment waiting for its
+// rollout are ready
+func (r *DeploymentPhaseRepository) UpdateRollout(ctx context.Context, deploymentID uuid.UUID, ready, desired int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE deployment_phases SET ready_replicas = $1, desired_replicas = $2
+		WHERE deployment_id = $3 AND phase = $4
+	`, ready, desired, deploymentID, types.DeploymentPhaseWaitingRollout)
+	if err != nil {
+		return fmt.Errorf("failed to record rollout progress: %w", err)
+	}
+	return nil
+}
+
+// Finish ends the phase a deployment is in, for deployments that failed or
+// were superseded. The phase stays the deployment's last one.
+func (r *DeploymentPhaseRepository) Finish(ctx context.Context, deploymentID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE deployment_phases SET finished_at = NOW()
+		WHERE deployment_id = $1 AND finished_at IS NULL
+	`, deploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to finish deployment phase: %w", err)
+	}
+	return nil
+}
+
+// GetProgress returns the phases of a deployment. A deployment is queued
+// from its creation until it enters its first phase.
+func (r *DeploymentPhaseRepository) GetProgress(ctx context.Context, deployment *types.Deployment) (*types.DeploymentProgress, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT phase, started_at, finished_at, ready_replicas, desired_replicas
+		FROM deployment_phases WHERE deployment_id = $1
+		ORDER BY started_at ASC
+	`, deployment.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queued := &types.DeploymentPhaseRecord{
+		Phase:     types.DeploymentPhaseQueued,
+		StartedAt: deployment.CreatedAt,
+	}
+	progress := &types.DeploymentProgress{
+		Phase:  types.DeploymentPhaseQueued,
+		Phases: []*types.DeploymentPhaseRecord{queued},
+	}
+	for rows.Next() {
+		record := &types.DeploymentPhaseRecord{}
+		var ready, desired int
+		if err := rows.Scan(&record.Phase, &record.StartedAt, &record.FinishedAt, &ready, &desired); err != nil {
+			return nil, err
+		}
+		if queued.FinishedAt == nil {
+			queued.FinishedAt = &record.StartedAt
+		}
+		progress.Phases = append(progress.Phases, record)
+		progress.Phase = record.Phase
+		progress.ReadyReplicas, progress.DesiredReplicas = ready, desired
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	progress.Percent = progress.Phase.Percent(progress.ReadyReplicas, progress.DesiredReplicas)
+	return progress, nil
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,48 +24,52 @@ func (r *DeploymentRepository) Create(deployment *types.Deployment) error {
 	deployment.CreatedAt = time.Now()
 	deployment.UpdatedAt = time.Now()
 
-	// Note: group_id and deploy_order columns don't exist in the database yet
-	// They're part of the deployment group feature that hasn't been migrated
 	query := `
-		INSERT INTO deployments (id, release_id, environment_id, replicas, status, health, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO deployments (id, release_id, environment_id, group_id, deploy_order, replicas, status, health, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
-	_, err := r.db.Exec(query, deployment.ID, deployment.ReleaseID, deployment.EnvironmentID, deployment.Replicas, deployment.Status, deployment.Health, deployment.CreatedAt, deployment.UpdatedAt)
+	_, err := r.db.Exec(query, deployment.ID, deployment.ReleaseID, deployment.EnvironmentID, deployment.GroupID, deployment.DeployOrder,
+		deployment.Replicas, deployment.Status, deployment.Health, deployment.CreatedAt, deployment.UpdatedAt)
 	return err
 }
 
 func (r *DeploymentRepository) UpdateStatus(id uuid.UUID, status types.DeploymentStatus, health types.HealthStatus) error {
-	query := `UPDATE deployments SET status = $1, health = $2, updated_at = NOW() WHERE id = $3`
+	query := `UPDATE deployments SET status = $1, health = $2, stalled_at = CASE WHEN status = $1 THEN stalled_at END, updated_at = NOW() WHERE id = $3`
 	_, err := r.db.Exec(query, status, health, id)
 	return err
 }
 
 // UpdateStatusWithError updates deployment status and stores error message for failed deployments
 func (r *DeploymentRepository) UpdateStatusWithError(id uuid.UUID, status types.DeploymentStatus, health types.HealthStatus, errorMsg *string) error {
-	query := `UPDATE deployments SET status = $1, health = $2, error_message = $3, updated_at = NOW() WHERE id = $4`
+	query := `UPDATE deployments SET status = $1, health = $2, error_message = $3, stalled_at = CASE WHEN status = $1 THEN stalled_at END, updated_at = NOW() WHERE id = $4`
 	_, err := r.db.Exec(query, status, health, errorMsg, id)
 	return err
 }
 
 func (r *DeploymentRepository) GetByID(ctx context.Context, id string) (*types.Deployment, error) {
 	deployment := &types.Deployment{}
-	query := `SELECT id, release_id, environment_id, replicas, status, health, error_message, created_at, updated_at
+	var annotations []byte
+	query := `SELECT id, release_id, environment_id, replicas, status, health, error_message, annotations, stalled_at, created_at, updated_at
 	          FROM deployments WHERE id = $1`
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID,
 		&deployment.Replicas, &deployment.Status, &deployment.Health,
-		&deployment.ErrorMessage, &deployment.CreatedAt, &deployment.UpdatedAt,
+		&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+		return nil, err
+	}
+
 	return deployment, nil
 }
 
 func (r *DeploymentRepository) ListByRelease(ctx context.Context, releaseID string) ([]*types.Deployment, error) {
-	query := `SELECT id, release_id, environment_id, replicas, status, health, error_message, created_at, updated_at
+	query := `SELECT id, release_id, environment_id, replicas, status, health, error_message, annotations, stalled_at, created_at, updated_at
 	          FROM deployments WHERE release_id = $1 ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, releaseID)
@@ -75,24 +81,73 @@ func (r *DeploymentRepository) ListByRelease(ctx context.Context, releaseID stri
 	var deployments []*types.Deployment
 	for rows.Next() {
 		deployment := &types.Deployment{}
+		var annotations []byte
 		err := rows.Scan(
 			&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID,
 			&deployment.Replicas, &deployment.Status, &deployment.Health,
-			&deployment.ErrorMessage, &deployment.CreatedAt, &deployment.UpdatedAt,
+			&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+			return nil, err
+		}
 		deployments = append(deployments, deployment)
 	}
 
 	return deployments, nil
 }
 
+var deploymentListColumns = listColumns{
+	id:          "d.id",
+	sorts:       map[string]string{"created_at": "d.created_at", "updated_at": "d.updated_at"},
+	status:      "d.status",
+	environment: "d.environment_id",
+	created:     "d.created_at",
+}
+
+// ListPageByService returns a page of the deployments of a service across
+// its releases
+func (r *DeploymentRepository) ListPageByService(ctx context.Context, serviceID uuid.UUID, params ListParams) ([]*types.Deployment, error) {
+	where, args := params.where(deploymentListColumns, []string{"r.service_id = $1"}, []any{serviceID})
+	query := `
+		SELECT d.id, d.release_id, d.environment_id, d.replicas, d.status, d.health, d.error_message, d.annotations, d.stalled_at, d.created_at, d.updated_at
+		FROM deployments d
+		JOIN releases r ON d.release_id = r.id` + where
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deployments []*types.Deployment
+	for rows.Next() {
+		deployment := &types.Deployment{}
+		var annotations []byte
+		err := rows.Scan(
+			&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID,
+			&deployment.Replicas, &deployment.Status, &deployment.Health,
+			&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, rows.Err()
+}
+
 func (r *DeploymentRepository) GetLatestByService(ctx context.Context, serviceID string) (*types.Deployment, error) {
 	deployment := &types.Deployment{}
+	var annotations []byte
 	query := `
-		SELECT d.id, d.release_id, d.environment_id, d.replicas, d.status, d.health, d.error_message, d.created_at, d.updated_at
+		SELECT d.id, d.release_id, d.environment_id, d.replicas, d.status, d.health, d.error_message, d.annotations, d.stalled_at, d.created_at, d.updated_at
 		FROM deployments d
 		JOIN releases r ON d.release_id = r.id
 		WHERE r.service_id = $1
@@ -103,19 +158,21 @@ func (r *DeploymentRepository) GetLatestByService(ctx context.Context, serviceID
 	err := r.db.QueryRowContext(ctx, query, serviceID).Scan(
 		&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID,
 		&deployment.Replicas, &deployment.Status, &deployment.Health,
-		&deployment.ErrorMessage, &deployment.CreatedAt, &deployment.UpdatedAt,
+		&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+		return nil, err
+	}
+
 	return deployment, nil
 }
 
 func (r *DeploymentRepository) GetByStatus(ctx context.Context, status types.DeploymentStatus) ([]*types.Deployment, error) {
-	// Note: group_id and deploy_order columns don't exist in the database yet
-	// They're part of the deployment group feature that hasn't been migrated
-	query := `SELECT id, release_id, environment_id, replicas, status, health, error_message, created_at, updated_at
+	query := `SELECT id, release_id, environment_id, replicas, status, health, error_message, annotations, stalled_at, created_at, updated_at
 	          FROM deployments WHERE status = $1 ORDER BY created_at ASC`
 
 	rows, err := r.db.QueryContext(ctx, query, status)
@@ -127,25 +184,220 @@ func (r *DeploymentRepository) GetByStatus(ctx context.Context, status types.Dep
 	var deployments []*types.Deployment
 	for rows.Next() {
 		deployment := &types.Deployment{}
+		var annotations []byte
 		err := rows.Scan(
 			&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID,
 			&deployment.Replicas, &deployment.Status, &deployment.Health,
-			&deployment.ErrorMessage, &deployment.CreatedAt, &deployment.UpdatedAt,
+			&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		// GroupID and DeployOrder default to nil/0 until feature is migrated
+		if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+			return nil, err
+		}
 		deployments = append(deployments, deployment)
 	}
 
 	return deployments, nil
 }
 
-// ListByGroup retrieves all deployments for a deployment group
-// Note: This feature is not yet implemented - group_id and deploy_order columns
-// don't exist in the database. Returns empty slice until feature is migrated.
+// ListByGroup retrieves all deployments for a deployment group in deploy order
 func (r *DeploymentRepository) ListByGroup(ctx context.Context, groupID uuid.UUID) ([]*types.Deployment, error) {
-	// Deployment groups feature not yet migrated - return empty slice
-	return []*types.Deployment{}, nil
+	query := `SELECT id, release_id, environment_id, group_id, deploy_order, replicas, status, health, error_message, annotations, stalled_at, created_at, updated_at
+	          FROM deployments WHERE group_id = $1 ORDER BY deploy_order ASC, created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deployments := []*types.Deployment{}
+	for rows.Next() {
+		deployment := &types.Deployment{}
+		var annotations []byte
+		err := rows.Scan(
+			&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID, &deployment.GroupID, &deployment.DeployOrder,
+			&deployment.Replicas, &deployment.Status, &deployment.Health,
+			&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, rows.Err()
+}
+
+// GetCurrentByServiceAndEnvironment returns the most recent running deployment of a
+// service in an environment. Returns sql.ErrNoRows if the service is not running there.
+func (r *DeploymentRepository) GetCurrentByServiceAndEnvironment(ctx context.Context, serviceID, environmentID uuid.UUID) (*types.Deployment, error) {
+	deployment := &types.Deployment{}
+	var annotations []byte
+	query := `
+		SELECT d.id, d.release_id, d.environment_id, d.replicas, d.status, d.health, d.error_message, d.annotations, d.stalled_at, d.created_at, d.updated_at
+		FROM deployments d
+		JOIN releases r ON d.release_id = r.id
+		WHERE r.service_id = $1 AND d.environment_id = $2 AND d.status = $3
+		ORDER BY d.created_at DESC
+		LIMIT 1
+	`
+
+	err := r.db.QueryRowContext(ctx, query, serviceID, environmentID, types.DeploymentStatusRunning).Scan(
+		&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID,
+		&deployment.Replicas, &deployment.Status, &deployment.Health,
+		&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+		return nil, err
+	}
+
+	return deployment, nil
+}
+
+// UpdateAnnotations stores computed cost/performance deltas for a deployment
+func (r *DeploymentRepository) UpdateAnnotations(ctx context.Context, id uuid.UUID, annotations *types.DeploymentAnnotations) error {
+	annotationsJSON, err := json.Marshal(annotations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment annotations: %w", err)
+	}
+
+	query := `UPDATE deployments SET annotations = $1, updated_at = NOW() WHERE id = $2`
+	_, err = r.db.ExecContext(ctx, query, annotationsJSON, id)
+	return err
+}
+
+// GetPreviousSuccessful returns the most recent running deployment of the same service
+// in the same environment that was created before the given deployment.
+// Returns sql.ErrNoRows if this is the first successful deployment.
+func (r *DeploymentRepository) GetPreviousSuccessful(ctx context.Context, deployment *types.Deployment, serviceID uuid.UUID) (*types.Deployment, error) {
+	previous := &types.Deployment{}
+	var annotations []byte
+	query := `
+		SELECT d.id, d.release_id, d.environment_id, d.replicas, d.status, d.health, d.error_message, d.annotations, d.stalled_at, d.created_at, d.updated_at
+		FROM deployments d
+		JOIN releases r ON d.release_id = r.id
+		WHERE r.service_id = $1 AND d.environment_id = $2 AND d.id != $3
+		  AND d.status = $4 AND d.created_at < $5
+		ORDER BY d.created_at DESC
+		LIMIT 1
+	`
+
+	err := r.db.QueryRowContext(ctx, query, serviceID, deployment.EnvironmentID, deployment.ID,
+		types.DeploymentStatusRunning, deployment.CreatedAt).Scan(
+		&previous.ID, &previous.ReleaseID, &previous.EnvironmentID,
+		&previous.Replicas, &previous.Status, &previous.Health,
+		&previous.ErrorMessage, &annotations, &previous.StalledAt, &previous.CreatedAt, &previous.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalDeploymentAnnotations(annotations, previous); err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+// GetPreviousRelease returns the most recent running deployment of the same service
+// in the same environment that was created before the given deployment and runs a
+// different release. Returns sql.ErrNoRows if no earlier release ran there.
+func (r *DeploymentRepository) GetPreviousRelease(ctx context.Context, deployment *types.Deployment, serviceID uuid.UUID) (*types.Deployment, error) {
+	previous := &types.Deployment{}
+	var annotations []byte
+	query := `
+		SELECT d.id, d.release_id, d.environment_id, d.replicas, d.status, d.health, d.error_message, d.annotations, d.stalled_at, d.created_at, d.updated_at
+		FROM deployments d
+		JOIN releases r ON d.release_id = r.id
+		WHERE r.service_id = $1 AND d.environment_id = $2 AND d.release_id != $3
+		  AND d.status = $4 AND d.created_at < $5
+		ORDER BY d.created_at DESC
+		LIMIT 1
+	`
+
+	err := r.db.QueryRowContext(ctx, query, serviceID, deployment.EnvironmentID, deployment.ReleaseID,
+		types.DeploymentStatusRunning, deployment.CreatedAt).Scan(
+		&previous.ID, &previous.ReleaseID, &previous.EnvironmentID,
+		&previous.Replicas, &previous.Status, &previous.Health,
+		&previous.ErrorMessage, &annotations, &previous.StalledAt, &previous.CreatedAt, &previous.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalDeploymentAnnotations(annotations, previous); err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+// ListStalled returns deployments that have been in the given status since before
+// the cutoff and are not yet flagged as stalled
+func (r *DeploymentRepository) ListStalled(ctx context.Context, status types.DeploymentStatus, cutoff time.Time) ([]*types.Deployment, error) {
+	query := `SELECT id, release_id, environment_id, replicas, status, health, error_message, annotations, stalled_at, created_at, updated_at
+	          FROM deployments WHERE status = $1 AND created_at < $2 AND stalled_at IS NULL ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, status, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deployments []*types.Deployment
+	for rows.Next() {
+		deployment := &types.Deployment{}
+		var annotations []byte
+		err := rows.Scan(
+			&deployment.ID, &deployment.ReleaseID, &deployment.EnvironmentID,
+			&deployment.Replicas, &deployment.Status, &deployment.Health,
+			&deployment.ErrorMessage, &annotations, &deployment.StalledAt, &deployment.CreatedAt, &deployment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalDeploymentAnnotations(annotations, deployment); err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, rows.Err()
+}
+
+// MarkStalled flags a deployment as stalled. It returns false if the deployment
+// was already flagged, so callers notify only once.
+func (r *DeploymentRepository) MarkStalled(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE deployments SET stalled_at = NOW() WHERE id = $1 AND stalled_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// unmarshalDeploymentAnnotations decodes the nullable annotations JSONB column
+func unmarshalDeploymentAnnotations(raw []byte, deployment *types.Deployment) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	deployment.Annotations = &types.DeploymentAnnotations{}
+	if err := json.Unmarshal(raw, deployment.Annotations); err != nil {
+		return fmt.Errorf("failed to unmarshal deployment annotations: %w", err)
+	}
+	return nil
 }
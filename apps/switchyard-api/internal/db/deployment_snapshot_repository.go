@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// DeploymentSnapshotRepository handles deployment configuration snapshots
+type DeploymentSnapshotRepository struct {
+	db DBTX
+}
+
+func NewDeploymentSnapshotRepository(db DBTX) *DeploymentSnapshotRepository {
+	return &DeploymentSnapshotRepository{db: db}
+}
+
+// NewDeploymentSnapshotRepositoryWithTx creates a repository using a transaction
+func NewDeploymentSnapshotRepositoryWithTx(tx DBTX) *DeploymentSnapshotRepository {
+	return &DeploymentSnapshotRepository{db: tx}
+}
+
+// Create stores a snapshot for a deployment. Snapshots are immutable: if one
+// already exists for the deployment it is kept and created is false.
+func (r *DeploymentSnapshotRepository) Create(ctx context.Context, snapshot *types.DeploymentConfigSnapshot) (bool, error) {
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	query := `
+		INSERT INTO deployment_snapshots (deployment_id, snapshot, manifest_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (deployment_id) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query, snapshot.DeploymentID, snapshotJSON, snapshot.ManifestHash, snapshot.CapturedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create deployment snapshot: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// Exists reports whether a snapshot has been captured for a deployment
+func (r *DeploymentSnapshotRepository) Exists(ctx context.Context, deploymentID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM deployment_snapshots WHERE deployment_id = $1)`
+	if err := r.db.QueryRowContext(ctx, query, deploymentID).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// GetByDeploymentID retrieves the snapshot captured for a deployment
+func (r *DeploymentSnapshotRepository) GetByDeploymentID(ctx context.Context, deploymentID uuid.UUID) (*types.DeploymentConfigSnapshot, error) {
+	var snapshotJSON []byte
+	query := `SELECT snapshot FROM deployment_snapshots WHERE deployment_id = $1`
+	if err := r.db.QueryRowContext(ctx, query, deploymentID).Scan(&snapshotJSON); err != nil {
+		return nil, err
+	}
+
+	var snapshot types.DeploymentConfigSnapshot
+	if err := json.Unmarshal(snapshotJSON, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// DriftEventRepository handles the drift found on managed Kubernetes
+// resources when deployments are reconciled
+type DriftEventRepository struct {
+	db DBTX
+}
+
+func NewDriftEventRepository(db DBTX) *DriftEventRepository {
+	return &DriftEventRepository{db: db}
+}
+
+// NewDriftEventRepositoryWithTx creates a repository using a transaction
+func NewDriftEventRepositoryWithTx(tx DBTX) *DriftEventRepository {
+	return &DriftEventRepository{db: tx}
+}
+
+// Create records a drift event
+func (r *DriftEventRepository) Create(ctx context.Context, event *types.DriftEvent) error {
+	query := `
+		INSERT INTO deployment_drift_events (deployment_id, kind, namespace, name, manager, fields, action)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, detected_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		event.DeploymentID, event.Kind, event.Namespace, event.Name, event.Manager,
+		pq.Array(event.Fields), event.Action,
+	).Scan(&event.ID, &event.DetectedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create drift event: %w", err)
+	}
+	return nil
+}
+
+// ListByDeployment returns the latest drift events of a deployment, newest first
+func (r *DriftEventRepository) ListByDeployment(ctx context.Context, deploymentID uuid.UUID, limit int) ([]*types.DriftEvent, error) {
+	query := `
+		SELECT id, deployment_id, kind, namespace, name, manager, fields, action, detected_at
+		FROM deployment_drift_events
+		WHERE deployment_id = $1
+		ORDER BY detected_at DESC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, deploymentID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drift events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*types.DriftEvent{}
+	for rows.Next() {
+		event := &types.DriftEvent{}
+		if err := rows.Scan(
+			&event.ID, &event.DeploymentID, &event.Kind, &event.Namespace, &event.Name,
+			&event.Manager, pq.Array(&event.Fields), &event.Action, &event.DetectedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan drift event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// EmailDeliveryRepository tracks the delivery status of transactional emails
+type EmailDeliveryRepository struct {
+	db DBTX
+}
+
+func NewEmailDeliveryRepository(db DBTX) *EmailDeliveryRepository {
+	return &EmailDeliveryRepository{db: db}
+}
+
+// Create records a queued email
+func (r *EmailDeliveryRepository) Create(ctx context.Context, delivery *types.EmailDelivery) error {
+	delivery.ID = uuid.New()
+	delivery.CreatedAt = time.Now()
+	delivery.UpdatedAt = delivery.CreatedAt
+	if delivery.Status == "" {
+		delivery.Status = types.EmailDeliveryStatusQueued
+	}
+
+	query := `
+		INSERT INTO email_deliveries (id, template, recipient, subject, provider, status, attempts, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.ID, delivery.Template, delivery.Recipient, delivery.Subject, delivery.Provider,
+		delivery.Status, delivery.Attempts, delivery.CreatedAt, delivery.UpdatedAt,
+	)
+	return err
+}
+
+// Update saves the status of a delivery after an attempt
+func (r *EmailDeliveryRepository) Update(ctx context.Context, delivery *types.EmailDelivery) error {
+	delivery.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE email_deliveries
+		SET status = $2, attempts = $3, last_error = NULLIF($4, ''), provider_message_id = NULLIF($5, ''),
+		    next_attempt_at = $6, sent_at = $7, updated_at = $8
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.ID, delivery.Status, delivery.Attempts, delivery.LastError, delivery.ProviderMessageID,
+		delivery.NextAttemptAt, delivery.SentAt, delivery.UpdatedAt,
+	)
+	return err
+}
+
+// List returns the newest deliveries, optionally only those with a status
+// or to a recipient
+func (r *EmailDeliveryRepository) List(ctx context.Context, status types.EmailDeliveryStatus, recipient string, limit int) ([]*types.EmailDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, template, recipient, subject, provider, status, attempts,
+		       COALESCE(last_error, ''), COALESCE(provider_message_id, ''),
+		       next_attempt_at, sent_at, created_at, updated_at
+		FROM email_deliveries
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR LOWER(recipient) = LOWER($2))
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(status), recipient, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*types.EmailDelivery
+	for rows.Next() {
+		delivery := &types.EmailDelivery{}
+		err := rows.Scan(
+			&delivery.ID, &delivery.Template, &delivery.Recipient, &delivery.Subject, &delivery.Provider,
+			&delivery.Status, &delivery.Attempts, &delivery.LastError, &delivery.ProviderMessageID,
+			&delivery.NextAttemptAt, &delivery.SentAt, &delivery.CreatedAt, &delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// FailStale marks deliveries that stopped making progress before a time as
+// failed. Emails are queued in memory, so those of a restarted API instance
+// are lost.
+func (r *EmailDeliveryRepository) FailStale(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE email_deliveries
+		SET status = 'failed', last_error = 'Delivery was interrupted by an API restart', next_attempt_at = NULL, updated_at = NOW()
+		WHERE status IN ('queued', 'sending', 'retrying') AND updated_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
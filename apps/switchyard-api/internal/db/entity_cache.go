@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
+)
+
+// How long cached entities live. Writes through the repositories evict them
+// at once; the TTL bounds how stale an entry gets when a write misses that,
+// e.g. one made directly in the database.
+const (
+	projectCacheTTL     = 10 * time.Minute
+	serviceCacheTTL     = 5 * time.Minute
+	environmentCacheTTL = 30 * time.Minute
+)
+
+var repositoryCacheLookups = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "enclii_repository_cache_lookups_total",
+		Help: "Cache-aside lookups of projects, services and environments by result (hit, miss, error)",
+	},
+	[]string{"entity", "result"},
+)
+
+// entityCache keeps the hot reads of a repository in the shared cache,
+// aside of the database. The zero value caches nothing.
+type entityCache struct {
+	cache  cache.CacheService
+	entity string
+
+	// evictOnly is set in transaction-scoped repositories: their writes
+	// evict entries, but their reads, which may see uncommitted rows, are
+	// neither served from nor stored in the cache
+	evictOnly bool
+}
+
+func newEntityCache(c cache.CacheService, entity string) entityCache {
+	return entityCache{cache: c, entity: entity}
+}
+
+// enabled reports whether writes must evict entries
+func (c entityCache) enabled() bool {
+	return c.cache != nil
+}
+
+// evicting returns the cache for a transaction-scoped repository
+func (c entityCache) evicting() entityCache {
+	c.evictOnly = true
+	return c
+}
+
+// get decodes the entry of a key into dest and reports whether there was
+// one. Cache errors are misses, so reads fall back to the database.
+func (c entityCache) get(ctx context.Context, key string, dest interface{}) bool {
+	if c.cache == nil || c.evictOnly {
+		return false
+	}
+
+	data, err := c.cache.Get(ctx, key)
+	if err == nil {
+		err = json.Unmarshal(data, dest)
+	}
+	switch {
+	case err == nil:
+		repositoryCacheLookups.WithLabelValues(c.entity, "hit").Inc()
+		return true
+	case errors.Is(err, cache.ErrCacheMiss):
+		repositoryCacheLookups.WithLabelValues(c.entity, "miss").Inc()
+	default:
+		repositoryCacheLookups.WithLabelValues(c.entity, "error").Inc()
+		logrus.WithError(err).WithField("key", key).Debug("Repository cache read failed")
+	}
+	return false
+}
+
+// set stores an entity read from the database
+func (c entityCache) set(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if c.cache == nil || c.evictOnly {
+		return
+	}
+	if err := c.cache.Set(ctx, key, value, ttl); err != nil {
+		logrus.WithError(err).WithField("key", key).Debug("Repository cache write failed")
+	}
+}
+
+// forget evicts the entries of entities that were written
+func (c entityCache) forget(ctx context.Context, keys ...string) {
+	if c.cache == nil || len(keys) == 0 {
+		return
+	}
+	if err := c.cache.Del(ctx, keys...); err != nil {
+		logrus.WithError(err).WithField("keys", keys).Warn("Failed to evict repository cache entries; they expire with their TTL")
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // EnvironmentRepository handles environment CRUD operations
 type EnvironmentRepository struct {
-	db DBTX
+	db    DBTX
+	cache entityCache
 }
 
 func NewEnvironmentRepository(db DBTX) *EnvironmentRepository {
@@ -21,47 +24,116 @@ func (r *EnvironmentRepository) Create(env *types.Environment) error {
 	env.ID = uuid.New()
 	env.CreatedAt = time.Now()
 	env.UpdatedAt = time.Now()
+	if env.DeployConcurrency == "" {
+		env.DeployConcurrency = types.DeployConcurrencySupersede
+	}
 
 	query := `
-		INSERT INTO environments (id, project_id, name, kube_namespace, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO environments (id, project_id, name, kube_namespace, cluster_id, deploy_concurrency, deploy_guard_disabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
-	_, err := r.db.Exec(query, env.ID, env.ProjectID, env.Name, env.KubeNamespace, env.CreatedAt, env.UpdatedAt)
+	_, err := r.db.Exec(query, env.ID, env.ProjectID, env.Name, env.KubeNamespace, env.ClusterID, env.DeployConcurrency, env.DeployGuardDisabled, env.CreatedAt, env.UpdatedAt)
 	return err
 }
 
+// SetDeployConcurrency sets what deployments to an environment do about
+// pending deployments of the same service
+func (r *EnvironmentRepository) SetDeployConcurrency(ctx context.Context, env *types.Environment, mode types.DeployConcurrency) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE environments SET deploy_concurrency = $1, updated_at = NOW() WHERE id = $2`, mode, env.ID)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.EnvironmentKey(env.ID.String()), cache.ProjectEnvironmentKey(env.ProjectID.String(), env.Name))
+	env.DeployConcurrency = mode
+	return nil
+}
+
+// SetDeployGuardDisabled opts an environment out of the deploy guard, or
+// back in
+func (r *EnvironmentRepository) SetDeployGuardDisabled(ctx context.Context, env *types.Environment, disabled bool) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE environments SET deploy_guard_disabled = $1, updated_at = NOW() WHERE id = $2`, disabled, env.ID)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.EnvironmentKey(env.ID.String()), cache.ProjectEnvironmentKey(env.ProjectID.String(), env.Name))
+	env.DeployGuardDisabled = disabled
+	return nil
+}
+
+// SetPromotion sets the environment releases are promoted to from an
+// environment, nil for none, and whether they are promoted automatically
+func (r *EnvironmentRepository) SetPromotion(ctx context.Context, env *types.Environment, promotesTo *uuid.UUID, auto bool) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE environments SET promotes_to = $1, auto_promote = $2, updated_at = NOW() WHERE id = $3`, promotesTo, auto, env.ID)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.EnvironmentKey(env.ID.String()), cache.ProjectEnvironmentKey(env.ProjectID.String(), env.Name))
+	env.PromotesTo = promotesTo
+	env.AutoPromote = auto
+	return nil
+}
+
+const environmentColumns = `e.id, e.project_id, e.name, e.kube_namespace, e.cluster_id, COALESCE(c.name, ''), e.deploy_concurrency, e.deploy_guard_disabled, e.promotes_to, e.auto_promote, e.created_at, e.updated_at`
+
+const environmentFrom = ` FROM environments e LEFT JOIN clusters c ON c.id = e.cluster_id`
+
+func scanEnvironment(row interface{ Scan(...interface{}) error }, env *types.Environment) error {
+	var clusterID, promotesTo uuid.NullUUID
+	err := row.Scan(&env.ID, &env.ProjectID, &env.Name, &env.KubeNamespace, &clusterID, &env.Cluster,
+		&env.DeployConcurrency, &env.DeployGuardDisabled, &promotesTo, &env.AutoPromote, &env.CreatedAt, &env.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	env.ClusterID = nil
+	if clusterID.Valid {
+		env.ClusterID = &clusterID.UUID
+	}
+	env.PromotesTo = nil
+	if promotesTo.Valid {
+		env.PromotesTo = &promotesTo.UUID
+	}
+	return nil
+}
+
 func (r *EnvironmentRepository) GetByProjectAndName(projectID uuid.UUID, name string) (*types.Environment, error) {
+	ctx := context.Background()
+	key := cache.ProjectEnvironmentKey(projectID.String(), name)
+	var cached types.Environment
+	if r.cache.get(ctx, key, &cached) {
+		return &cached, nil
+	}
+
 	env := &types.Environment{}
-	query := `SELECT id, project_id, name, kube_namespace, created_at, updated_at FROM environments WHERE project_id = $1 AND name = $2`
+	query := `SELECT ` + environmentColumns + environmentFrom + ` WHERE e.project_id = $1 AND e.name = $2`
 
-	err := r.db.QueryRow(query, projectID, name).Scan(
-		&env.ID, &env.ProjectID, &env.Name, &env.KubeNamespace,
-		&env.CreatedAt, &env.UpdatedAt,
-	)
-	if err != nil {
+	if err := scanEnvironment(r.db.QueryRow(query, projectID, name), env); err != nil {
 		return nil, err
 	}
 
+	r.cache.set(ctx, key, env, environmentCacheTTL)
 	return env, nil
 }
 
 func (r *EnvironmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.Environment, error) {
+	key := cache.EnvironmentKey(id.String())
+	var cached types.Environment
+	if r.cache.get(ctx, key, &cached) {
+		return &cached, nil
+	}
+
 	env := &types.Environment{}
-	query := `SELECT id, project_id, name, kube_namespace, created_at, updated_at FROM environments WHERE id = $1`
+	query := `SELECT ` + environmentColumns + environmentFrom + ` WHERE e.id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&env.ID, &env.ProjectID, &env.Name, &env.KubeNamespace,
-		&env.CreatedAt, &env.UpdatedAt,
-	)
-	if err != nil {
+	if err := scanEnvironment(r.db.QueryRowContext(ctx, query, id), env); err != nil {
 		return nil, err
 	}
 
+	r.cache.set(ctx, key, env, environmentCacheTTL)
 	return env, nil
 }
 
 func (r *EnvironmentRepository) ListByProject(projectID uuid.UUID) ([]*types.Environment, error) {
-	query := `SELECT id, project_id, name, kube_namespace, created_at, updated_at FROM environments WHERE project_id = $1 ORDER BY name`
+	query := `SELECT ` + environmentColumns + environmentFrom + ` WHERE e.project_id = $1 ORDER BY e.name`
 
 	rows, err := r.db.Query(query, projectID)
 	if err != nil {
@@ -72,7 +144,7 @@ func (r *EnvironmentRepository) ListByProject(projectID uuid.UUID) ([]*types.Env
 	var environments []*types.Environment
 	for rows.Next() {
 		env := &types.Environment{}
-		if err := rows.Scan(&env.ID, &env.ProjectID, &env.Name, &env.KubeNamespace, &env.CreatedAt, &env.UpdatedAt); err != nil {
+		if err := scanEnvironment(rows, env); err != nil {
 			return nil, err
 		}
 		environments = append(environments, env)
@@ -84,7 +156,7 @@ func (r *EnvironmentRepository) ListByProject(projectID uuid.UUID) ([]*types.Env
 // ListAll retrieves all environments across all projects
 // Used by the reconciler to build dynamic namespace list for K8s sync
 func (r *EnvironmentRepository) ListAll() ([]*types.Environment, error) {
-	query := `SELECT id, project_id, name, kube_namespace, created_at, updated_at FROM environments ORDER BY created_at DESC`
+	query := `SELECT ` + environmentColumns + environmentFrom + ` ORDER BY e.created_at DESC`
 
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -95,7 +167,7 @@ func (r *EnvironmentRepository) ListAll() ([]*types.Environment, error) {
 	var environments []*types.Environment
 	for rows.Next() {
 		env := &types.Environment{}
-		if err := rows.Scan(&env.ID, &env.ProjectID, &env.Name, &env.KubeNamespace, &env.CreatedAt, &env.UpdatedAt); err != nil {
+		if err := scanEnvironment(rows, env); err != nil {
 			return nil, err
 		}
 		environments = append(environments, env)
@@ -107,13 +179,9 @@ func (r *EnvironmentRepository) ListAll() ([]*types.Environment, error) {
 // GetByKubeNamespace retrieves an environment by its Kubernetes namespace (used for K8s→DB reconciliation)
 func (r *EnvironmentRepository) GetByKubeNamespace(namespace string) (*types.Environment, error) {
 	env := &types.Environment{}
-	query := `SELECT id, project_id, name, kube_namespace, created_at, updated_at FROM environments WHERE kube_namespace = $1`
+	query := `SELECT ` + environmentColumns + environmentFrom + ` WHERE e.kube_namespace = $1`
 
-	err := r.db.QueryRow(query, namespace).Scan(
-		&env.ID, &env.ProjectID, &env.Name, &env.KubeNamespace,
-		&env.CreatedAt, &env.UpdatedAt,
-	)
-	if err != nil {
+	if err := scanEnvironment(r.db.QueryRow(query, namespace), env); err != nil {
 		return nil, err
 	}
 
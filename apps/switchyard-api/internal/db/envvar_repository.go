@@ -9,19 +9,41 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
+// SealedValuePrefix marks values encrypted with a team's customer-managed key
+// rather than the platform key
+const SealedValuePrefix = "cmek:"
+
+// ErrKeyringUnavailable is returned when a value is sealed with a
+// customer-managed key but no keyring is configured to open it
+var ErrKeyringUnavailable = errors.New("value is encrypted with a customer-managed key, but customer-managed keys are not configured")
+
+// EnvVarKeyring seals the environment variables of teams that bring their
+// own encryption key
+type EnvVarKeyring interface {
+	// Seal encrypts a value with the key of the service's team. ok is false
+	// when the team has no customer-managed key and the platform key applies.
+	Seal(ctx context.Context, serviceID uuid.UUID, plaintext string) (ciphertext string, ok bool, err error)
+
+	// Open decrypts a value returned by Seal
+	Open(ctx context.Context, ciphertext string) (string, error)
+}
+
 // EnvVarRepository handles environment variable CRUD operations with encryption
 type EnvVarRepository struct {
 	db            DBTX
-	encryptionKey []byte // 32-byte AES-256 key
+	encryptionKey []byte        // 32-byte AES-256 key
+	keyring       EnvVarKeyring // Optional - customer-managed keys per team
 }
 
 // getEncryptionKey returns the encryption key from environment or default
@@ -56,9 +78,45 @@ func NewEnvVarRepositoryWithTx(tx DBTX) *EnvVarRepository {
 	}
 }
 
-// encrypt encrypts plaintext using AES-256-GCM
-func (r *EnvVarRepository) encrypt(plaintext string) (string, error) {
-	block, err := aes.NewCipher(r.encryptionKey)
+// encrypt encrypts a value of a service with its team's customer-managed key,
+// or the platform key when the team has none
+func (r *EnvVarRepository) encrypt(ctx context.Context, serviceID uuid.UUID, plaintext string) (string, error) {
+	if r.keyring != nil {
+		sealed, ok, err := r.keyring.Seal(ctx, serviceID, plaintext)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return sealed, nil
+		}
+	}
+	return r.encryptPlatform(plaintext)
+}
+
+// decrypt decrypts a value encrypted by encrypt
+func (r *EnvVarRepository) decrypt(ctx context.Context, ciphertext string) (string, error) {
+	if strings.HasPrefix(ciphertext, SealedValuePrefix) {
+		if r.keyring == nil {
+			return "", ErrKeyringUnavailable
+		}
+		return r.keyring.Open(ctx, ciphertext)
+	}
+	return r.decryptPlatform(ciphertext)
+}
+
+// encryptPlatform encrypts plaintext with the platform key using AES-256-GCM
+func (r *EnvVarRepository) encryptPlatform(plaintext string) (string, error) {
+	return sealAESGCM(r.encryptionKey, plaintext)
+}
+
+// decryptPlatform decrypts ciphertext with the platform key using AES-256-GCM
+func (r *EnvVarRepository) decryptPlatform(ciphertext string) (string, error) {
+	return openAESGCM(r.encryptionKey, ciphertext)
+}
+
+// sealAESGCM encrypts plaintext with an AES-256 key, prefixing the nonce
+func sealAESGCM(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -77,14 +135,14 @@ func (r *EnvVarRepository) encrypt(plaintext string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// decrypt decrypts ciphertext using AES-256-GCM
-func (r *EnvVarRepository) decrypt(ciphertext string) (string, error) {
+// openAESGCM decrypts ciphertext returned by sealAESGCM
+func openAESGCM(key []byte, ciphertext string) (string, error) {
 	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	block, err := aes.NewCipher(r.encryptionKey)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -122,7 +180,7 @@ func (r *EnvVarRepository) Create(ctx context.Context, ev *types.EnvironmentVari
 	ev.UpdatedAt = time.Now()
 
 	// Encrypt the value
-	encrypted, err := r.encrypt(ev.Value)
+	encrypted, err := r.encrypt(ctx, ev.ServiceID, ev.Value)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt value: %w", err)
 	}
@@ -175,7 +233,7 @@ func (r *EnvVarRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.En
 	}
 
 	// Decrypt the value
-	decrypted, err := r.decrypt(ev.ValueEncrypted)
+	decrypted, err := r.decrypt(ctx, ev.ValueEncrypted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt value: %w", err)
 	}
@@ -233,7 +291,7 @@ func (r *EnvVarRepository) GetByServiceEnvKey(ctx context.Context, serviceID uui
 	}
 
 	// Decrypt the value
-	decrypted, err := r.decrypt(ev.ValueEncrypted)
+	decrypted, err := r.decrypt(ctx, ev.ValueEncrypted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt value: %w", err)
 	}
@@ -303,7 +361,7 @@ func (r *EnvVarRepository) List(ctx context.Context, serviceID uuid.UUID, enviro
 		}
 
 		// Decrypt the value
-		decrypted, err := r.decrypt(ev.ValueEncrypted)
+		decrypted, err := r.decrypt(ctx, ev.ValueEncrypted)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decrypt value for key %s: %w", ev.Key, err)
 		}
@@ -320,7 +378,7 @@ func (r *EnvVarRepository) Update(ctx context.Context, ev *types.EnvironmentVari
 	ev.UpdatedAt = time.Now()
 
 	// Encrypt the new value
-	encrypted, err := r.encrypt(ev.Value)
+	encrypted, err := r.encrypt(ctx, ev.ServiceID, ev.Value)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt value: %w", err)
 	}
@@ -374,7 +432,7 @@ func (r *EnvVarRepository) DeleteByService(ctx context.Context, serviceID uuid.U
 func (r *EnvVarRepository) BulkUpsert(ctx context.Context, serviceID uuid.UUID, environmentID *uuid.UUID, vars []types.EnvironmentVariable) error {
 	for _, ev := range vars {
 		// Encrypt the value
-		encrypted, err := r.encrypt(ev.Value)
+		encrypted, err := r.encrypt(ctx, ev.ServiceID, ev.Value)
 		if err != nil {
 			return fmt.Errorf("failed to encrypt value for key %s: %w", ev.Key, err)
 		}
@@ -409,6 +467,177 @@ func (r *EnvVarRepository) BulkUpsert(ctx context.Context, serviceID uuid.UUID,
 	return nil
 }
 
+// ReencryptTeam re-encrypts the environment variables, staged rotations and
+// preview overrides of all services in a team's projects. seal encrypts with the team's new
+// customer-managed key; a nil seal moves the values back to the platform key.
+// Run it inside a transaction so a failure leaves every value under its
+// previous key.
+func (r *EnvVarRepository) ReencryptTeam(ctx context.Context, teamID uuid.UUID, seal func(plaintext string) (string, error)) (int, error) {
+	if seal == nil {
+		seal = r.encryptPlatform
+	}
+
+	total := 0
+	for _, table := range []string{"environment_variables", "preview_env_vars"} {
+		n, err := r.reencryptTable(ctx, table, teamID, seal)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+
+	n, err := r.reencryptStagedRotations(ctx, teamID, seal)
+	if err != nil {
+		return 0, err
+	}
+	return total + n, nil
+}
+
+// reencryptTable re-encrypts the values of a team's services in one table
+func (r *EnvVarRepository) reencryptTable(ctx context.Context, table string, teamID uuid.UUID, seal func(plaintext string) (string, error)) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT ev.id, ev.key, ev.value_encrypted
+		FROM %s ev
+		JOIN services s ON s.id = ev.service_id
+		JOIN projects p ON p.id = s.project_id
+		WHERE p.team_id = $1
+	`, table)
+
+	rows, err := r.db.QueryContext(ctx, query, teamID)
+	if err != nil {
+		return 0, err
+	}
+
+	type value struct {
+		id         uuid.UUID
+		key        string
+		ciphertext string
+	}
+	var values []value
+	for rows.Next() {
+		var v value
+		if err := rows.Scan(&v.id, &v.key, &v.ciphertext); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		values = append(values, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	update := fmt.Sprintf(`UPDATE %s SET value_encrypted = $1 WHERE id = $2`, table)
+	for _, v := range values {
+		plaintext, err := r.decrypt(ctx, v.ciphertext)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decrypt value for key %s: %w", v.key, err)
+		}
+		ciphertext, err := seal(plaintext)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encrypt value for key %s: %w", v.key, err)
+		}
+		if _, err := r.db.ExecContext(ctx, update, ciphertext, v.id); err != nil {
+			return 0, fmt.Errorf("failed to update key %s: %w", v.key, err)
+		}
+	}
+
+	return len(values), nil
+}
+
+// ListPreview returns the preview overrides of a service, decrypted
+func (r *EnvVarRepository) ListPreview(ctx context.Context, serviceID uuid.UUID) ([]*types.PreviewEnvVar, error) {
+	query := `
+		SELECT id, service_id, key, value_encrypted, is_secret,
+		       created_at, updated_at, created_by, created_by_email
+		FROM preview_env_vars
+		WHERE service_id = $1
+		ORDER BY key
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vars []*types.PreviewEnvVar
+	for rows.Next() {
+		ev := &types.PreviewEnvVar{}
+		var createdByEmail sql.NullString
+		err := rows.Scan(
+			&ev.ID, &ev.ServiceID, &ev.Key, &ev.ValueEncrypted, &ev.IsSecret,
+			&ev.CreatedAt, &ev.UpdatedAt, &ev.CreatedBy, &createdByEmail,
+		)
+		if err != nil {
+			return nil, err
+		}
+		ev.CreatedByEmail = createdByEmail.String
+
+		ev.Value, err = r.decrypt(ctx, ev.ValueEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt value for key %s: %w", ev.Key, err)
+		}
+		vars = append(vars, ev)
+	}
+
+	return vars, rows.Err()
+}
+
+// ReplacePreview replaces all preview overrides of a service; an empty list
+// removes them. Run it inside a transaction so the set changes at once.
+func (r *EnvVarRepository) ReplacePreview(ctx context.Context, serviceID uuid.UUID, vars []types.PreviewEnvVar) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM preview_env_vars WHERE service_id = $1`, serviceID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO preview_env_vars (
+			id, service_id, key, value_encrypted, is_secret,
+			created_at, updated_at, created_by, created_by_email
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	now := time.Now()
+	for i := range vars {
+		ev := &vars[i]
+		encrypted, err := r.encrypt(ctx, serviceID, ev.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt value for key %s: %w", ev.Key, err)
+		}
+
+		ev.ID = uuid.New()
+		ev.ServiceID = serviceID
+		ev.ValueEncrypted = encrypted
+		ev.CreatedAt = now
+		ev.UpdatedAt = now
+
+		_, err = r.db.ExecContext(ctx, query,
+			ev.ID, ev.ServiceID, ev.Key, ev.ValueEncrypted, ev.IsSecret,
+			ev.CreatedAt, ev.UpdatedAt, ev.CreatedBy, ev.CreatedByEmail,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert key %s: %w", ev.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// GetPreviewDecryptedWithMeta returns the preview overrides of a service for
+// injection into a preview deployment
+func (r *EnvVarRepository) GetPreviewDecryptedWithMeta(ctx context.Context, serviceID uuid.UUID) ([]EnvVarWithMeta, error) {
+	vars, err := r.ListPreview(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]EnvVarWithMeta, len(vars))
+	for i, ev := range vars {
+		result[i] = EnvVarWithMeta{Key: ev.Key, Value: ev.Value, IsSecret: ev.IsSecret}
+	}
+	return result, nil
+}
+
 // EnvVarWithMeta represents an environment variable with metadata for K8s secret creation
 type EnvVarWithMeta struct {
 	Key      string
@@ -438,6 +667,7 @@ func (r *EnvVarRepository) GetDecryptedWithMeta(ctx context.Context, serviceID,
 
 	// Use a map to handle overrides, then convert to slice
 	resultMap := make(map[string]EnvVarWithMeta)
+	scoped := make(map[string]bool)
 	for rows.Next() {
 		var key, valueEncrypted string
 		var isSecret bool
@@ -449,7 +679,7 @@ func (r *EnvVarRepository) GetDecryptedWithMeta(ctx context.Context, serviceID,
 		}
 
 		// Decrypt the value
-		decrypted, err := r.decrypt(valueEncrypted)
+		decrypted, err := r.decrypt(ctx, valueEncrypted)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decrypt value for key %s: %w", key, err)
 		}
@@ -460,6 +690,26 @@ func (r *EnvVarRepository) GetDecryptedWithMeta(ctx context.Context, serviceID,
 			Value:    decrypted,
 			IsSecret: isSecret,
 		}
+		scoped[key] = envID.Valid
+	}
+
+	// Secrets being rotated also get their old and staged values under
+	// suffixed names, unless the service defines those names itself
+	staged, err := r.stagedRotations(ctx, serviceID, environmentID)
+	if err != nil {
+		return nil, err
+	}
+	for scope, values := range staged {
+		if _, ok := resultMap[scope.key]; !ok || scoped[scope.key] != scope.environment {
+			continue
+		}
+		for i, suffix := range []string{RotationCurrentSuffix, RotationNextSuffix} {
+			name := scope.key + suffix
+			if _, ok := resultMap[name]; ok {
+				continue
+			}
+			resultMap[name] = EnvVarWithMeta{Key: name, Value: values[i], IsSecret: true}
+		}
 	}
 
 	// Convert map to slice
@@ -501,7 +751,7 @@ func (r *EnvVarRepository) GetDecrypted(ctx context.Context, serviceID, environm
 		}
 
 		// Decrypt the value
-		decrypted, err := r.decrypt(valueEncrypted)
+		decrypted, err := r.decrypt(ctx, valueEncrypted)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decrypt value for key %s: %w", key, err)
 		}
@@ -513,6 +763,40 @@ func (r *EnvVarRepository) GetDecrypted(ctx context.Context, serviceID, environm
 	return result, nil
 }
 
+// ListSecretValues returns the decrypted values of the secret environment
+// variables of every service of a project, in any environment, for masking
+// them in logs
+func (r *EnvVarRepository) ListSecretValues(ctx context.Context, projectID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT ev.key, ev.value_encrypted
+		FROM environment_variables ev
+		JOIN services s ON s.id = ev.service_id
+		WHERE s.project_id = $1 AND ev.is_secret = true
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var key, valueEncrypted string
+		if err := rows.Scan(&key, &valueEncrypted); err != nil {
+			return nil, err
+		}
+
+		decrypted, err := r.decrypt(ctx, valueEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt value for key %s: %w", key, err)
+		}
+		values = append(values, decrypted)
+	}
+
+	return values, rows.Err()
+}
+
 // LogAudit creates an audit log entry for environment variable changes
 func (r *EnvVarRepository) LogAudit(ctx context.Context, log *types.EnvVarAuditLog) error {
 	log.ID = uuid.New()
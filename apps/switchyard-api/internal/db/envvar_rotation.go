@@ -0,0 +1,344 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Suffixes of the names the old and the staged value of a secret are
+// exposed under while it is being rotated
+const (
+	RotationCurrentSuffix = "_CURRENT"
+	RotationNextSuffix    = "_NEXT"
+)
+
+var (
+	// ErrRotationInProgress is returned when a rotation is staged for a
+	// secret that is already being rotated
+	ErrRotationInProgress = errors.New("a rotation of this secret is already in progress")
+
+	// ErrNoRotationInProgress is returned when finalizing or aborting a
+	// rotation of a secret that is not being rotated
+	ErrNoRotationInProgress = errors.New("no rotation of this secret is in progress")
+)
+
+const rotationColumns = `
+	r.env_var_id, ev.service_id, ev.environment_id, ev.key, r.max_age_days, r.rotated_at,
+	COALESCE(r.rotated_at, ev.updated_at), r.pending_value_encrypted, r.started_at,
+	r.started_by_email, r.reminded_at
+`
+
+// scanRotation scans a row of rotationColumns. The staged value is
+// decrypted when decrypt is set.
+func (r *EnvVarRepository) scanRotation(ctx context.Context, row interface{ Scan(...any) error }, decrypt bool) (*types.EnvVarRotation, error) {
+	rot := &types.EnvVarRotation{}
+	var envID uuid.NullUUID
+	var maxAgeDays sql.NullInt64
+	var rotatedAt, startedAt, remindedAt sql.NullTime
+	var pending, startedBy sql.NullString
+
+	if err := row.Scan(
+		&rot.EnvVarID, &rot.ServiceID, &envID, &rot.Key, &maxAgeDays, &rotatedAt,
+		&rot.ValueSince, &pending, &startedAt, &startedBy, &remindedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if envID.Valid {
+		rot.EnvironmentID = &envID.UUID
+	}
+	if maxAgeDays.Valid {
+		days := int(maxAgeDays.Int64)
+		rot.MaxAgeDays = &days
+	}
+	if rotatedAt.Valid {
+		rot.RotatedAt = &rotatedAt.Time
+	}
+	if startedAt.Valid {
+		rot.StartedAt = &startedAt.Time
+	}
+	if remindedAt.Valid {
+		rot.RemindedAt = &remindedAt.Time
+	}
+	rot.StartedByEmail = startedBy.String
+	rot.InProgress = pending.Valid
+
+	if decrypt && pending.Valid {
+		value, err := r.decrypt(ctx, pending.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt staged value for key %s: %w", rot.Key, err)
+		}
+		rot.PendingValue = value
+	}
+	return rot, nil
+}
+
+// GetRotation returns the rotation of a rotatable env var with its staged
+// value decrypted. It returns sql.ErrNoRows when the variable is not
+// rotatable.
+func (r *EnvVarRepository) GetRotation(ctx context.Context, envVarID uuid.UUID) (*types.EnvVarRotation, error) {
+	query := `SELECT ` + rotationColumns + `
+		FROM env_var_rotations r
+		JOIN environment_variables ev ON ev.id = r.env_var_id
+		WHERE r.env_var_id = $1
+	`
+	return r.scanRotation(ctx, r.db.QueryRowContext(ctx, query, envVarID), true)
+}
+
+// ListRotations returns the rotatable env vars of a service, without their
+// staged values
+func (r *EnvVarRepository) ListRotations(ctx context.Context, serviceID uuid.UUID) ([]*types.EnvVarRotation, error) {
+	query := `SELECT ` + rotationColumns + `
+		FROM env_var_rotations r
+		JOIN environment_variables ev ON ev.id = r.env_var_id
+		WHERE ev.service_id = $1
+		ORDER BY ev.key, ev.environment_id NULLS FIRST
+	`
+	rows, err := r.db.QueryContext(ctx, query, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rotations []*types.EnvVarRotation
+	for rows.Next() {
+		rot, err := r.scanRotation(ctx, rows, false)
+		if err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, rot)
+	}
+	return rotations, rows.Err()
+}
+
+// ListRotationsDue returns the rotatable env vars older than their max age
+// that are not being rotated and were not reminded of since repeat ago
+func (r *EnvVarRepository) ListRotationsDue(ctx context.Context, now time.Time, repeat time.Duration) ([]*types.EnvVarRotation, error) {
+	query := `SELECT ` + rotationColumns + `
+		FROM env_var_rotations r
+		JOIN environment_variables ev ON ev.id = r.env_var_id
+		WHERE r.max_age_days IS NOT NULL
+		  AND r.pending_value_encrypted IS NULL
+		  AND COALESCE(r.rotated_at, ev.updated_at) + make_interval(days => r.max_age_days) < $1
+		  AND (r.reminded_at IS NULL OR r.reminded_at < $2)
+		ORDER BY ev.service_id, ev.key
+	`
+	rows, err := r.db.QueryContext(ctx, query, now, now.Add(-repeat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rotations []*types.EnvVarRotation
+	for rows.Next() {
+		rot, err := r.scanRotation(ctx, rows, false)
+		if err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, rot)
+	}
+	return rotations, rows.Err()
+}
+
+// SetRotatable marks an env var rotatable, or updates its max age
+func (r *EnvVarRepository) SetRotatable(ctx context.Context, envVarID uuid.UUID, maxAgeDays *int) error {
+	query := `
+		INSERT INTO env_var_rotations (env_var_id, max_age_days)
+		VALUES ($1, $2)
+		ON CONFLICT (env_var_id) DO UPDATE SET max_age_days = EXCLUDED.max_age_days
+	`
+	_, err := r.db.ExecContext(ctx, query, envVarID, maxAgeDays)
+	return err
+}
+
+// UnsetRotatable stops treating an env var as rotatable. It returns
+// ErrRotationInProgress while a rotation is staged.
+func (r *EnvVarRepository) UnsetRotatable(ctx context.Context, envVarID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM env_var_rotations WHERE env_var_id = $1 AND pending_value_encrypted IS NULL`, envVarID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		if _, err := r.GetRotation(ctx, envVarID); err == nil {
+			return ErrRotationInProgress
+		}
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// StageRotation stages a new value for a rotatable env var. It returns
+// ErrRotationInProgress when a value is already staged.
+func (r *EnvVarRepository) StageRotation(ctx context.Context, ev *types.EnvironmentVariable, value, startedByEmail string) error {
+	encrypted, err := r.encrypt(ctx, ev.ServiceID, value)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt value: %w", err)
+	}
+
+	query := `
+		UPDATE env_var_rotations
+		SET pending_value_encrypted = $2, started_at = NOW(), started_by_email = $3
+		WHERE env_var_id = $1 AND pending_value_encrypted IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, ev.ID, encrypted, startedByEmail)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrRotationInProgress
+	}
+	return nil
+}
+
+// FinalizeRotation makes the staged value the env var's value. It returns
+// ErrNoRotationInProgress when no value is staged.
+func (r *EnvVarRepository) FinalizeRotation(ctx context.Context, envVarID uuid.UUID) error {
+	// The staged value is encrypted like the variable's, so it moves over as is
+	query := `
+		WITH finished AS (
+			UPDATE env_var_rotations r
+			SET pending_value_encrypted = NULL, started_at = NULL, started_by_email = NULL,
+			    rotated_at = NOW(), reminded_at = NULL
+			FROM env_var_rotations staged
+			WHERE r.env_var_id = $1 AND staged.env_var_id = r.env_var_id
+			  AND staged.pending_value_encrypted IS NOT NULL
+			RETURNING r.env_var_id, staged.pending_value_encrypted
+		)
+		UPDATE environment_variables ev
+		SET value_encrypted = finished.pending_value_encrypted, updated_at = NOW()
+		FROM finished
+		WHERE ev.id = finished.env_var_id
+	`
+	result, err := r.db.ExecContext(ctx, query, envVarID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNoRotationInProgress
+	}
+	return nil
+}
+
+// AbortRotation discards the staged value of an env var. It returns
+// ErrNoRotationInProgress when no value is staged.
+func (r *EnvVarRepository) AbortRotation(ctx context.Context, envVarID uuid.UUID) error {
+	query := `
+		UPDATE env_var_rotations
+		SET pending_value_encrypted = NULL, started_at = NULL, started_by_email = NULL
+		WHERE env_var_id = $1 AND pending_value_encrypted IS NOT NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, envVarID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNoRotationInProgress
+	}
+	return nil
+}
+
+// MarkRotationReminded records that a reminder to rotate an env var was sent
+func (r *EnvVarRepository) MarkRotationReminded(ctx context.Context, envVarID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE env_var_rotations SET reminded_at = NOW() WHERE env_var_id = $1`, envVarID)
+	return err
+}
+
+// stagedRotations returns the old and the staged values of the env vars of
+// a service in an environment that are being rotated, keyed by the key and
+// whether the variable is specific to the environment
+func (r *EnvVarRepository) stagedRotations(ctx context.Context, serviceID, environmentID uuid.UUID) (map[rotationScope][2]string, error) {
+	query := `
+		SELECT ev.key, ev.environment_id IS NOT NULL, ev.value_encrypted, r.pending_value_encrypted
+		FROM env_var_rotations r
+		JOIN environment_variables ev ON ev.id = r.env_var_id
+		WHERE ev.service_id = $1 AND (ev.environment_id = $2 OR ev.environment_id IS NULL)
+		  AND r.pending_value_encrypted IS NOT NULL
+	`
+	rows, err := r.db.QueryContext(ctx, query, serviceID, environmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	staged := make(map[rotationScope][2]string)
+	for rows.Next() {
+		var scope rotationScope
+		var current, next string
+		if err := rows.Scan(&scope.key, &scope.environment, &current, &next); err != nil {
+			return nil, err
+		}
+		if current, err = r.decrypt(ctx, current); err != nil {
+			return nil, fmt.Errorf("failed to decrypt value for key %s: %w", scope.key, err)
+		}
+		if next, err = r.decrypt(ctx, next); err != nil {
+			return nil, fmt.Errorf("failed to decrypt staged value for key %s: %w", scope.key, err)
+		}
+		staged[scope] = [2]string{current, next}
+	}
+	return staged, rows.Err()
+}
+
+// rotationScope identifies the variable a key resolves to in an environment
+type rotationScope struct {
+	key         string
+	environment bool // Specific to the environment rather than for all
+}
+
+// reencryptStagedRotations re-encrypts the staged values of rotations of a
+// team's services
+func (r *EnvVarRepository) reencryptStagedRotations(ctx context.Context, teamID uuid.UUID, seal func(plaintext string) (string, error)) (int, error) {
+	query := `
+		SELECT r.env_var_id, ev.key, r.pending_value_encrypted
+		FROM env_var_rotations r
+		JOIN environment_variables ev ON ev.id = r.env_var_id
+		JOIN services s ON s.id = ev.service_id
+		JOIN projects p ON p.id = s.project_id
+		WHERE p.team_id = $1 AND r.pending_value_encrypted IS NOT NULL
+	`
+	rows, err := r.db.QueryContext(ctx, query, teamID)
+	if err != nil {
+		return 0, err
+	}
+
+	type value struct {
+		id         uuid.UUID
+		key        string
+		ciphertext string
+	}
+	var values []value
+	for rows.Next() {
+		var v value
+		if err := rows.Scan(&v.id, &v.key, &v.ciphertext); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		values = append(values, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, v := range values {
+		plaintext, err := r.decrypt(ctx, v.ciphertext)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decrypt staged value for key %s: %w", v.key, err)
+		}
+		ciphertext, err := seal(plaintext)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encrypt staged value for key %s: %w", v.key, err)
+		}
+		if _, err := r.db.ExecContext(ctx, `UPDATE env_var_rotations SET pending_value_encrypted = $1 WHERE env_var_id = $2`, ciphertext, v.id); err != nil {
+			return 0, fmt.Errorf("failed to update staged value for key %s: %w", v.key, err)
+		}
+	}
+
+	return len(values), nil
+}
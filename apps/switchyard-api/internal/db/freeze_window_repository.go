@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FreezeWindow is a period in which scheduled deployment groups of an
+// environment that respect freeze windows are held back
+type FreezeWindow struct {
+	ID            uuid.UUID `json:"id"`
+	EnvironmentID uuid.UUID `json:"environment_id"`
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+	Reason        string    `json:"reason,omitempty"`
+	CreatedBy     string    `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// FreezeWindowRepository handles the freeze windows of environments
+type FreezeWindowRepository struct {
+	db DBTX
+}
+
+// NewFreezeWindowRepository creates a new FreezeWindowRepository
+func NewFreezeWindowRepository(db DBTX) *FreezeWindowRepository {
+	return &FreezeWindowRepository{db: db}
+}
+
+// NewFreezeWindowRepositoryWithTx creates a repository using a transaction
+func NewFreezeWindowRepositoryWithTx(tx DBTX) *FreezeWindowRepository {
+	return &FreezeWindowRepository{db: tx}
+}
+
+const freezeWindowColumns = `
+	id, environment_id, starts_at, ends_at, reason, created_by, created_at
+`
+
+// Create inserts a new freeze window
+func (r *FreezeWindowRepository) Create(ctx context.Context, window *FreezeWindow) error {
+	window.ID = uuid.New()
+	window.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO environment_freeze_windows (id, environment_id, starts_at, ends_at, reason, created_by, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		window.ID, window.EnvironmentID, window.StartsAt, window.EndsAt,
+		window.Reason, window.CreatedBy, window.CreatedAt,
+	)
+	return err
+}
+
+// ListEndingAfter retrieves the freeze windows of an environment that end
+// after a time, in the order they start
+func (r *FreezeWindowRepository) ListEndingAfter(ctx context.Context, environmentID uuid.UUID, after time.Time) ([]*FreezeWindow, error) {
+	query := `
+		SELECT ` + freezeWindowColumns + `
+		FROM environment_freeze_windows
+		WHERE environment_id = $1 AND ends_at > $2
+		ORDER BY starts_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, environmentID, after)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []*FreezeWindow
+	for rows.Next() {
+		window := &FreezeWindow{}
+		var reason, createdBy sql.NullString
+		err := rows.Scan(
+			&window.ID, &window.EnvironmentID, &window.StartsAt, &window.EndsAt,
+			&reason, &createdBy, &window.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		window.Reason = reason.String
+		window.CreatedBy = createdBy.String
+		windows = append(windows, window)
+	}
+
+	return windows, rows.Err()
+}
+
+// Delete removes a freeze window of an environment
+func (r *FreezeWindowRepository) Delete(ctx context.Context, environmentID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM environment_freeze_windows WHERE id = $1 AND environment_id = $2`, id, environmentID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
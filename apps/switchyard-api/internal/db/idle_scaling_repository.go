@@ -0,0 +1,297 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// IdleScalingRepository handles the scale-to-zero settings and sleep state
+// of services
+type IdleScalingRepository struct {
+	db DBTX
+}
+
+// NewIdleScalingRepository creates a new IdleScalingRepository
+func NewIdleScalingRepository(db DBTX) *IdleScalingRepository {
+	return &IdleScalingRepository{db: db}
+}
+
+// NewIdleScalingRepositoryWithTx creates a repository using a transaction
+func NewIdleScalingRepositoryWithTx(tx DBTX) *IdleScalingRepository {
+	return &IdleScalingRepository{db: tx}
+}
+
+// IdleScalingTarget is a service with scale-to-zero, with what is needed to
+// find its deployment
+type IdleScalingTarget struct {
+	Scaling     *types.ServiceIdleScaling
+	ServiceName string
+	ProjectID   uuid.UUID
+	Namespace   string // Kubernetes namespace of the environment
+}
+
+const idleScalingColumns = `
+	i.id, i.service_id, i.environment_id, e.name, i.idle_minutes, i.state, i.replicas, i.replica_gb,
+	i.last_active_at, i.asleep_since, i.saved_gb_hours, i.savings_reported_until, i.created_at, i.updated_at
+`
+
+const idleScalingJoins = `
+	FROM service_idle_scaling i
+	JOIN environments e ON e.id = i.environment_id
+`
+
+// Upsert enables scale-to-zero of a service in an environment, or changes
+// its idle period. The sleep state of an existing entry is kept.
+func (r *IdleScalingRepository) Upsert(ctx context.Context, scaling *types.ServiceIdleScaling) error {
+	query := `
+		INSERT INTO service_idle_scaling (service_id, environment_id, idle_minutes)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (service_id, environment_id) DO UPDATE SET
+			idle_minutes = EXCLUDED.idle_minutes,
+			updated_at = NOW()
+		RETURNING id
+	`
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, scaling.ServiceID, scaling.EnvironmentID, scaling.IdleMinutes).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to save idle scaling: %w", err)
+	}
+
+	saved, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	*scaling = *saved
+	return nil
+}
+
+// GetByID retrieves a scale-to-zero entry
+func (r *IdleScalingRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.ServiceIdleScaling, error) {
+	query := `SELECT ` + idleScalingColumns + idleScalingJoins + ` WHERE i.id = $1`
+	return scanIdleScaling(r.db.QueryRowContext(ctx, query, id))
+}
+
+// Get retrieves the scale-to-zero entry of a service in an environment
+func (r *IdleScalingRepository) Get(ctx context.Context, serviceID, environmentID uuid.UUID) (*types.ServiceIdleScaling, error) {
+	query := `SELECT ` + idleScalingColumns + idleScalingJoins + ` WHERE i.service_id = $1 AND i.environment_id = $2`
+	return scanIdleScaling(r.db.QueryRowContext(ctx, query, serviceID, environmentID))
+}
+
+// ListByService retrieves the scale-to-zero entries of a service
+func (r *IdleScalingRepository) ListByService(ctx context.Context, serviceID uuid.UUID) ([]*types.ServiceIdleScaling, error) {
+	query := `SELECT ` + idleScalingColumns + idleScalingJoins + ` WHERE i.service_id = $1 ORDER BY e.name`
+	rows, err := r.db.QueryContext(ctx, query, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list idle scaling: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*types.ServiceIdleScaling{}
+	for rows.Next() {
+		scaling, err := scanIdleScaling(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan idle scaling: %w", err)
+		}
+		entries = append(entries, scaling)
+	}
+	return entries, rows.Err()
+}
+
+// ListTargets retrieves every scale-to-zero entry with its service and
+// namespace
+func (r *IdleScalingRepository) ListTargets(ctx context.Context) ([]*IdleScalingTarget, error) {
+	return r.listTargets(ctx, `ORDER BY i.created_at`)
+}
+
+// GetTarget retrieves a scale-to-zero entry with its service and namespace
+func (r *IdleScalingRepository) GetTarget(ctx context.Context, id uuid.UUID) (*IdleScalingTarget, error) {
+	targets, err := r.listTargets(ctx, `WHERE i.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return targets[0], nil
+}
+
+func (r *IdleScalingRepository) listTargets(ctx context.Context, where string, args ...interface{}) ([]*IdleScalingTarget, error) {
+	query := `SELECT ` + idleScalingColumns + `, s.name, s.project_id, e.kube_namespace` + idleScalingJoins + `
+		JOIN services s ON s.id = i.service_id ` + where
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list idle scaling targets: %w", err)
+	}
+	defer rows.Close()
+
+	targets := []*IdleScalingTarget{}
+	for rows.Next() {
+		target := &IdleScalingTarget{Scaling: &types.ServiceIdleScaling{}}
+		s := target.Scaling
+		err := rows.Scan(
+			&s.ID, &s.ServiceID, &s.EnvironmentID, &s.Environment, &s.IdleMinutes, &s.State, &s.Replicas,
+			&s.ReplicaGB, &s.LastActiveAt, &s.AsleepSince, &s.SavedGBHours, &s.SavingsUntil,
+			&s.CreatedAt, &s.UpdatedAt, &target.ServiceName, &target.ProjectID, &target.Namespace,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan idle scaling target: %w", err)
+		}
+		targets = append(targets, target)
+	}
+	return targets, rows.Err()
+}
+
+func scanIdleScaling(row interface{ Scan(...interface{}) error }) (*types.ServiceIdleScaling, error) {
+	s := &types.ServiceIdleScaling{}
+	err := row.Scan(
+		&s.ID, &s.ServiceID, &s.EnvironmentID, &s.Environment, &s.IdleMinutes, &s.State, &s.Replicas,
+		&s.ReplicaGB, &s.LastActiveAt, &s.AsleepSince, &s.SavedGBHours, &s.SavingsUntil,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Delete disables scale-to-zero of a service in an environment
+func (r *IdleScalingRepository) Delete(ctx context.Context, serviceID, environmentID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM service_idle_scaling WHERE service_id = $1 AND environment_id = $2`, serviceID, environmentID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AsleepDomain is a custom domain of a sleeping service
+type AsleepDomain struct {
+	IdleScalingID uuid.UUID
+	ZeroTrust     bool // Requests must come through Cloudflare Access
+}
+
+// ListAsleepDomains maps the verified custom domains of sleeping services
+// to their scale-to-zero entries. Unverified domains are left out: anyone
+// may add them, so they are not trusted to route requests.
+func (r *IdleScalingRepository) ListAsleepDomains(ctx context.Context) (map[string]AsleepDomain, error) {
+	query := `
+		SELECT d.domain, i.id, d.zero_trust_enabled
+		FROM service_idle_scaling i
+		JOIN custom_domains d ON d.service_id = i.service_id AND d.environment_id = i.environment_id
+		WHERE i.state = 'asleep' AND d.verified = true
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains of sleeping services: %w", err)
+	}
+	defer rows.Close()
+
+	domains := make(map[string]AsleepDomain)
+	for rows.Next() {
+		var domain string
+		var asleep AsleepDomain
+		if err := rows.Scan(&domain, &asleep.IdleScalingID, &asleep.ZeroTrust); err != nil {
+			return nil, fmt.Errorf("failed to scan domain of sleeping service: %w", err)
+		}
+		domains[domain] = asleep
+	}
+	return domains, rows.Err()
+}
+
+// MarkActive records that a service served requests at a time
+func (r *IdleScalingRepository) MarkActive(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE service_idle_scaling
+		SET last_active_at = GREATEST(last_active_at, $2), updated_at = NOW()
+		WHERE id = $1
+	`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark service active: %w", err)
+	}
+	return nil
+}
+
+// MarkAsleep puts an awake service to sleep with the replicas it wakes up
+// with. It returns false when the service was not awake.
+func (r *IdleScalingRepository) MarkAsleep(ctx context.Context, id uuid.UUID, replicas int, replicaGB float64, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE service_idle_scaling
+		SET state = 'asleep', replicas = $2, replica_gb = $3, asleep_since = $4,
+		    savings_reported_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND state = 'awake'
+	`, id, replicas, replicaGB, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark service asleep: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// UnreportedSavings is the compute a service saved while asleep that has
+// not been reported to Waybill yet
+type UnreportedSavings struct {
+	From      time.Time
+	Until     time.Time
+	GBHours   float64
+	Replicas  int
+	ReplicaGB float64
+}
+
+// MarkAwake wakes a sleeping service, adding the compute it saved since
+// savings were last reported to its total. It returns nil when the service
+// was not asleep, so only one caller wakes it.
+func (r *IdleScalingRepository) MarkAwake(ctx context.Context, id uuid.UUID, at time.Time) (*UnreportedSavings, error) {
+	query := `
+		UPDATE service_idle_scaling i
+		SET state = 'awake', last_active_at = $2, asleep_since = NULL, savings_reported_until = NULL,
+		    saved_gb_hours = i.saved_gb_hours + i.replica_gb * i.replicas * GREATEST(EXTRACT(EPOCH FROM ($2 - old.since)) / 3600, 0),
+		    updated_at = NOW()
+		FROM (
+			SELECT id, COALESCE(savings_reported_until, asleep_since, $2) AS since
+			FROM service_idle_scaling
+			WHERE id = $1 AND state = 'asleep'
+			FOR UPDATE
+		) old
+		WHERE i.id = old.id
+		RETURNING old.since, i.replicas, i.replica_gb
+	`
+	savings := &UnreportedSavings{Until: at}
+	err := r.db.QueryRowContext(ctx, query, id, at).Scan(&savings.From, &savings.Replicas, &savings.ReplicaGB)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark service awake: %w", err)
+	}
+	if at.After(savings.From) {
+		savings.GBHours = savings.ReplicaGB * float64(savings.Replicas) * at.Sub(savings.From).Hours()
+	}
+	return savings, nil
+}
+
+// AddSavings adds compute a sleeping service saved between from and until
+// to its total and marks it reported. It returns false when the service
+// woke up or the savings were already added.
+func (r *IdleScalingRepository) AddSavings(ctx context.Context, id uuid.UUID, from, until time.Time, gbHours float64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE service_idle_scaling
+		SET saved_gb_hours = saved_gb_hours + $4, savings_reported_until = $3, updated_at = NOW()
+		WHERE id = $1 AND state = 'asleep' AND COALESCE(savings_reported_until, asleep_since) = $2
+	`, id, from, until, gbHours)
+	if err != nil {
+		return false, fmt.Errorf("failed to add idle savings: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListParams pages, filters and sorts a list query.
+//
+// Pages are keyset based: After is the position of the last item of the
+// previous page, so pages stay stable while items are added. A query built
+// from ListParams returns up to Limit+1 rows; the extra row only tells the
+// caller there is a next page (see TrimPage).
+type ListParams struct {
+	Limit int
+	// Sort is a sort key of the list, e.g. "created_at" or "name"
+	Sort       string
+	Descending bool
+	After      *Cursor
+
+	// Filters; lists ignore those they cannot apply
+	Status        string
+	EnvironmentID *uuid.UUID
+	Since         *time.Time // Created at or after
+	Until         *time.Time // Created before
+	// Trashed lists the soft-deleted items instead of the live ones
+	Trashed bool
+}
+
+// Cursor is the position of an item in a sorted list: the item's value of
+// the sort key, and its ID to break ties
+type Cursor struct {
+	Value string
+	ID    uuid.UUID
+}
+
+// listColumns maps the keys of ListParams to the columns of one list query
+type listColumns struct {
+	id          string
+	sorts       map[string]string // sort key to column
+	status      string            // "" when the list has no status
+	environment string            // "" when the list is not per environment
+	created     string            // column Since and Until apply to
+	deleted     string            // soft-delete column; "" when items are deleted for good
+}
+
+// where builds the conditions, order and limit of params for a query whose
+// own conditions are conds with args, and returns them with the full args
+func (p ListParams) where(cols listColumns, conds []string, args []any) (string, []any) {
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if cols.deleted != "" {
+		if p.Trashed {
+			conds = append(conds, cols.deleted+" IS NOT NULL")
+		} else {
+			conds = append(conds, cols.deleted+" IS NULL")
+		}
+	}
+	if p.Status != "" && cols.status != "" {
+		conds = append(conds, cols.status+" = "+arg(p.Status))
+	}
+	if p.EnvironmentID != nil && cols.environment != "" {
+		conds = append(conds, cols.environment+" = "+arg(*p.EnvironmentID))
+	}
+	if p.Since != nil {
+		conds = append(conds, cols.created+" >= "+arg(*p.Since))
+	}
+	if p.Until != nil {
+		conds = append(conds, cols.created+" < "+arg(*p.Until))
+	}
+
+	sort, ok := cols.sorts[p.Sort]
+	if !ok {
+		sort = cols.created
+	}
+	direction, comparison := "ASC", ">"
+	if p.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if p.After != nil {
+		conds = append(conds, fmt.Sprintf("(%s, %s) %s (%s, %s)",
+			sort, cols.id, comparison, arg(p.After.Value), arg(p.After.ID)))
+	}
+
+	var clause strings.Builder
+	if len(conds) > 0 {
+		clause.WriteString(" WHERE " + strings.Join(conds, " AND "))
+	}
+	fmt.Fprintf(&clause, " ORDER BY %s %s, %s %s", sort, direction, cols.id, direction)
+	if p.Limit > 0 {
+		clause.WriteString(" LIMIT " + arg(p.Limit+1))
+	}
+	return clause.String(), args
+}
+
+// TrimPage cuts items fetched with params down to one page and returns the
+// cursor of the next page, or nil on the last one. cursor gives the position
+// of an item.
+func TrimPage[T any](items []T, params ListParams, cursor func(T) Cursor) ([]T, *Cursor) {
+	if params.Limit <= 0 || len(items) <= params.Limit {
+		return items, nil
+	}
+	items = items[:params.Limit]
+	next := cursor(items[len(items)-1])
+	return items, &next
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 
@@ -13,8 +14,23 @@ import (
 var migrationFS embed.FS
 
 func Migrate(db *sql.DB, databaseURL string) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Migrations may outlast the pool's statement timeout, e.g. index builds
+	// on large tables, so they run without one. The connection gets it back
+	// before returning to the pool.
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "RESET statement_timeout")
+
 	// Create migrate instance
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
 	if err != nil {
 		return err
 	}
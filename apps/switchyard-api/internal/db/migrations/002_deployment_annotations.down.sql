@@ -0,0 +1,5 @@
+ALTER TABLE public.deployments DROP COLUMN IF EXISTS annotations;
+
+ALTER TABLE public.releases
+    DROP COLUMN IF EXISTS build_duration_seconds,
+    DROP COLUMN IF EXISTS image_size_bytes;
@@ -0,0 +1,14 @@
+-- Deployment cost/performance annotations
+-- Releases record build metrics so deployments can be compared against history
+
+ALTER TABLE public.releases
+    ADD COLUMN IF NOT EXISTS image_size_bytes bigint,
+    ADD COLUMN IF NOT EXISTS build_duration_seconds numeric(10,2);
+
+COMMENT ON COLUMN public.releases.image_size_bytes IS 'Size of the built container image in bytes, NULL if unknown';
+COMMENT ON COLUMN public.releases.build_duration_seconds IS 'Wall-clock build duration in seconds, NULL if unknown';
+
+ALTER TABLE public.deployments
+    ADD COLUMN IF NOT EXISTS annotations jsonb;
+
+COMMENT ON COLUMN public.deployments.annotations IS 'Computed deltas vs previous deployment: {image_size_delta_bytes, build_duration_delta_pct, estimated_monthly_cost_delta, ...}';
@@ -0,0 +1,2 @@
+ALTER TABLE public.database_addons
+    DROP COLUMN IF EXISTS memory_used_bytes;
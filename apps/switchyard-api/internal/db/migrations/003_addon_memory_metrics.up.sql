@@ -0,0 +1,7 @@
+-- Memory usage tracking for in-memory addons (Redis)
+-- connections_active already covers connected clients
+
+ALTER TABLE public.database_addons
+    ADD COLUMN IF NOT EXISTS memory_used_bytes bigint DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN public.database_addons.memory_used_bytes IS 'Memory used by the addon process in bytes (Redis used_memory), refreshed by the addon reconciler';
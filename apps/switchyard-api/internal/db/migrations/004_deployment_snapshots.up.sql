@@ -0,0 +1,13 @@
+-- Immutable configuration snapshots captured when a deployment is first reconciled
+-- Env var values are never stored; the snapshot holds hashes and masked previews only
+
+CREATE TABLE IF NOT EXISTS public.deployment_snapshots (
+    deployment_id uuid NOT NULL,
+    snapshot jsonb NOT NULL,
+    manifest_hash text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT deployment_snapshots_pkey PRIMARY KEY (deployment_id),
+    CONSTRAINT deployment_snapshots_deployment_id_fkey FOREIGN KEY (deployment_id) REFERENCES public.deployments(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.deployment_snapshots IS 'Resolved configuration (env var hashes, addon bindings, resources, routes, manifest hash) per deployment';
@@ -0,0 +1,6 @@
+-- Auto-detected language/framework metadata for services
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS runtime jsonb;
+
+COMMENT ON COLUMN public.services.runtime IS 'Detected runtime: {language, framework, package_manager, build_strategy, default_port, health_path, ...}';
@@ -0,0 +1,26 @@
+-- Restore history for database addons
+
+CREATE TABLE IF NOT EXISTS public.database_addon_restores (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    addon_id uuid NOT NULL,
+    backup_id uuid,
+    status character varying(50) DEFAULT 'pending'::character varying NOT NULL,
+    status_message text,
+    phase character varying(50),
+    progress integer DEFAULT 0 NOT NULL,
+    requested_by uuid,
+    requested_by_email character varying(255),
+    started_at timestamp with time zone,
+    completed_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT database_addon_restores_pkey PRIMARY KEY (id),
+    CONSTRAINT database_addon_restores_addon_id_fkey FOREIGN KEY (addon_id) REFERENCES public.database_addons(id) ON DELETE CASCADE,
+    CONSTRAINT database_addon_restores_backup_id_fkey FOREIGN KEY (backup_id) REFERENCES public.database_addon_backups(id) ON DELETE SET NULL,
+    CONSTRAINT valid_restore_status CHECK (((status)::text = ANY ((ARRAY['pending'::character varying, 'in_progress'::character varying, 'completed'::character varying, 'failed'::character varying])::text[]))),
+    CONSTRAINT valid_restore_progress CHECK (((progress >= 0) AND (progress <= 100)))
+);
+
+CREATE INDEX IF NOT EXISTS idx_database_addon_restores_addon_id ON public.database_addon_restores USING btree (addon_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_database_addon_restores_status ON public.database_addon_restores USING btree (status) WHERE ((status)::text = ANY ((ARRAY['pending'::character varying, 'in_progress'::character varying])::text[]));
+
+COMMENT ON TABLE public.database_addon_restores IS 'Restore history for database addons';
@@ -0,0 +1,3 @@
+ALTER TABLE public.releases DROP COLUMN IF EXISTS stalled_at;
+
+ALTER TABLE public.deployments DROP COLUMN IF EXISTS stalled_at;
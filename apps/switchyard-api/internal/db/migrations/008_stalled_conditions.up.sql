@@ -0,0 +1,12 @@
+-- Stalled condition for deployments stuck in pending and builds stuck in building
+-- Set once by the stall watchdog; cleared when the resource changes status
+
+ALTER TABLE public.deployments
+    ADD COLUMN IF NOT EXISTS stalled_at timestamp with time zone;
+
+COMMENT ON COLUMN public.deployments.stalled_at IS 'When the deployment was flagged as stalled in its current status, NULL if not stalled';
+
+ALTER TABLE public.releases
+    ADD COLUMN IF NOT EXISTS stalled_at timestamp with time zone;
+
+COMMENT ON COLUMN public.releases.stalled_at IS 'When the build was flagged as stalled, NULL if not stalled';
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS public.database_addon_resizes;
+
+UPDATE public.database_addons SET status = 'ready' WHERE status = 'resizing';
+
+ALTER TABLE public.database_addons
+    DROP CONSTRAINT IF EXISTS valid_addon_status;
+
+ALTER TABLE public.database_addons
+    ADD CONSTRAINT valid_addon_status CHECK (((status)::text = ANY ((ARRAY['pending'::character varying, 'provisioning'::character varying, 'ready'::character varying, 'failed'::character varying, 'deleting'::character varying, 'deleted'::character varying])::text[])));
@@ -0,0 +1,28 @@
+-- Vertical scaling and plan changes for database addons
+
+ALTER TABLE public.database_addons
+    DROP CONSTRAINT IF EXISTS valid_addon_status;
+
+ALTER TABLE public.database_addons
+    ADD CONSTRAINT valid_addon_status CHECK (((status)::text = ANY ((ARRAY['pending'::character varying, 'provisioning'::character varying, 'ready'::character varying, 'resizing'::character varying, 'failed'::character varying, 'deleting'::character varying, 'deleted'::character varying])::text[])));
+
+CREATE TABLE IF NOT EXISTS public.database_addon_resizes (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    addon_id uuid NOT NULL,
+    status character varying(50) DEFAULT 'in_progress'::character varying NOT NULL,
+    status_message text,
+    previous_config jsonb DEFAULT '{}'::jsonb NOT NULL,
+    target_config jsonb DEFAULT '{}'::jsonb NOT NULL,
+    requested_by uuid,
+    requested_by_email character varying(255),
+    started_at timestamp with time zone DEFAULT now() NOT NULL,
+    completed_at timestamp with time zone,
+    CONSTRAINT database_addon_resizes_pkey PRIMARY KEY (id),
+    CONSTRAINT database_addon_resizes_addon_id_fkey FOREIGN KEY (addon_id) REFERENCES public.database_addons(id) ON DELETE CASCADE,
+    CONSTRAINT valid_resize_status CHECK (((status)::text = ANY ((ARRAY['in_progress'::character varying, 'completed'::character varying, 'rolled_back'::character varying, 'failed'::character varying])::text[])))
+);
+
+CREATE INDEX IF NOT EXISTS idx_database_addon_resizes_addon_id ON public.database_addon_resizes USING btree (addon_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_database_addon_resizes_in_progress ON public.database_addon_resizes USING btree (status) WHERE ((status)::text = 'in_progress'::text);
+
+COMMENT ON TABLE public.database_addon_resizes IS 'Storage, compute, and replica changes applied to database addons';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_services_labels;
+
+ALTER TABLE public.services DROP COLUMN IF EXISTS labels;
@@ -0,0 +1,8 @@
+-- Free-form key/value labels on services, used to select services for bulk operations
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS labels jsonb DEFAULT '{}'::jsonb NOT NULL;
+
+COMMENT ON COLUMN public.services.labels IS 'Key/value labels for grouping services, e.g. {"tier": "frontend"}';
+
+CREATE INDEX IF NOT EXISTS idx_services_labels ON public.services USING gin (labels);
@@ -0,0 +1,6 @@
+ALTER TABLE public.database_addon_restores
+    DROP COLUMN IF EXISTS target_time;
+
+ALTER TABLE public.database_addons
+    DROP COLUMN IF EXISTS wal_archive_lag_seconds,
+    DROP COLUMN IF EXISTS wal_archived_at;
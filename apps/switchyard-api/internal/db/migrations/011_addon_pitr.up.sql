@@ -0,0 +1,11 @@
+-- Point-in-time recovery for PostgreSQL addons
+
+ALTER TABLE public.database_addons
+    ADD COLUMN IF NOT EXISTS wal_archived_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS wal_archive_lag_seconds bigint;
+
+ALTER TABLE public.database_addon_restores
+    ADD COLUMN IF NOT EXISTS target_time timestamp with time zone;
+
+COMMENT ON COLUMN public.database_addons.wal_archive_lag_seconds IS 'Age of the last archived WAL segment when last sampled';
+COMMENT ON COLUMN public.database_addon_restores.target_time IS 'Recovery target of a point-in-time restore; NULL for backup restores';
@@ -0,0 +1,6 @@
+ALTER TABLE public.database_addon_backups
+    DROP CONSTRAINT IF EXISTS database_addon_backups_encryption_key_id_fkey,
+    DROP COLUMN IF EXISTS wrapped_data_key,
+    DROP COLUMN IF EXISTS encryption_key_id;
+
+DROP TABLE IF EXISTS public.team_encryption_keys;
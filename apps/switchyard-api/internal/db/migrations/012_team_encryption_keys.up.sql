@@ -0,0 +1,34 @@
+-- Customer-managed encryption keys (CMEK) per team
+
+CREATE TABLE IF NOT EXISTS public.team_encryption_keys (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    team_id uuid NOT NULL,
+    provider character varying(50) NOT NULL,
+    key_arn text NOT NULL,
+    region character varying(50) NOT NULL,
+    wrapped_data_key text NOT NULL,
+    status character varying(50) DEFAULT 'healthy'::character varying NOT NULL,
+    status_message text,
+    last_checked_at timestamp with time zone,
+    created_by uuid,
+    created_by_email character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT team_encryption_keys_pkey PRIMARY KEY (id),
+    CONSTRAINT team_encryption_keys_team_id_key UNIQUE (team_id),
+    CONSTRAINT team_encryption_keys_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE,
+    CONSTRAINT valid_encryption_key_provider CHECK (((provider)::text = ANY ((ARRAY['aws_kms'::character varying])::text[]))),
+    CONSTRAINT valid_encryption_key_status CHECK (((status)::text = ANY ((ARRAY['healthy'::character varying, 'unreachable'::character varying, 'revoked'::character varying])::text[])))
+);
+
+COMMENT ON TABLE public.team_encryption_keys IS 'Customer KMS keys that wrap the data key protecting a team''s secrets and backups';
+COMMENT ON COLUMN public.team_encryption_keys.wrapped_data_key IS 'Team data key encrypted by the customer key; unusable once the customer revokes the key';
+
+ALTER TABLE public.database_addon_backups
+    ADD COLUMN IF NOT EXISTS encryption_key_id uuid,
+    ADD COLUMN IF NOT EXISTS wrapped_data_key text;
+
+ALTER TABLE public.database_addon_backups
+    ADD CONSTRAINT database_addon_backups_encryption_key_id_fkey FOREIGN KEY (encryption_key_id) REFERENCES public.team_encryption_keys(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN public.database_addon_backups.wrapped_data_key IS 'Per-backup SSE-C key encrypted by the team''s customer key';
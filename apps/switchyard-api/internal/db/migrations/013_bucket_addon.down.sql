@@ -0,0 +1,5 @@
+ALTER TABLE public.database_addons
+    DROP CONSTRAINT IF EXISTS valid_addon_type;
+
+ALTER TABLE public.database_addons
+    ADD CONSTRAINT valid_addon_type CHECK (((type)::text = ANY ((ARRAY['postgres'::character varying, 'redis'::character varying, 'mysql'::character varying, 'mongodb'::character varying])::text[])));
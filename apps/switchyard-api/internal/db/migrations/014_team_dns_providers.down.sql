@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS public.idx_custom_domains_dns_managed;
+
+ALTER TABLE public.custom_domains
+    DROP CONSTRAINT IF EXISTS valid_custom_domain_dns_mode,
+    DROP CONSTRAINT IF EXISTS custom_domains_dns_provider_id_fkey,
+    DROP COLUMN IF EXISTS dns_checked_at,
+    DROP COLUMN IF EXISTS dns_status_message,
+    DROP COLUMN IF EXISTS dns_status,
+    DROP COLUMN IF EXISTS dns_record_id,
+    DROP COLUMN IF EXISTS dns_provider_id,
+    DROP COLUMN IF EXISTS dns_mode;
+
+DROP TABLE IF EXISTS public.team_dns_providers;
@@ -0,0 +1,42 @@
+-- DNS provider credentials per team, and Enclii-managed DNS records for domains
+
+CREATE TABLE IF NOT EXISTS public.team_dns_providers (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    team_id uuid NOT NULL,
+    provider character varying(50) NOT NULL,
+    zone character varying(255) NOT NULL,
+    zone_id character varying(255) NOT NULL,
+    credentials_encrypted text NOT NULL,
+    status character varying(50) DEFAULT 'healthy'::character varying NOT NULL,
+    status_message text,
+    last_checked_at timestamp with time zone,
+    created_by uuid,
+    created_by_email character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT team_dns_providers_pkey PRIMARY KEY (id),
+    CONSTRAINT team_dns_providers_team_id_zone_key UNIQUE (team_id, zone),
+    CONSTRAINT team_dns_providers_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE,
+    CONSTRAINT valid_dns_provider CHECK (((provider)::text = ANY ((ARRAY['cloudflare'::character varying, 'route53'::character varying])::text[]))),
+    CONSTRAINT valid_dns_provider_status CHECK (((status)::text = ANY ((ARRAY['healthy'::character varying, 'invalid'::character varying])::text[])))
+);
+
+COMMENT ON TABLE public.team_dns_providers IS 'DNS provider accounts a team lets Enclii manage records in, one per zone';
+COMMENT ON COLUMN public.team_dns_providers.credentials_encrypted IS 'Provider API credentials encrypted with the platform key';
+
+ALTER TABLE public.custom_domains
+    ADD COLUMN IF NOT EXISTS dns_mode character varying(20) DEFAULT 'manual'::character varying NOT NULL,
+    ADD COLUMN IF NOT EXISTS dns_provider_id uuid,
+    ADD COLUMN IF NOT EXISTS dns_record_id character varying(255),
+    ADD COLUMN IF NOT EXISTS dns_status character varying(50),
+    ADD COLUMN IF NOT EXISTS dns_status_message text,
+    ADD COLUMN IF NOT EXISTS dns_checked_at timestamp with time zone;
+
+ALTER TABLE public.custom_domains
+    ADD CONSTRAINT custom_domains_dns_provider_id_fkey FOREIGN KEY (dns_provider_id) REFERENCES public.team_dns_providers(id) ON DELETE SET NULL,
+    ADD CONSTRAINT valid_custom_domain_dns_mode CHECK (((dns_mode)::text = ANY ((ARRAY['manual'::character varying, 'managed'::character varying])::text[])));
+
+CREATE INDEX IF NOT EXISTS idx_custom_domains_dns_managed ON public.custom_domains USING btree (dns_provider_id) WHERE ((dns_mode)::text = 'managed'::text);
+
+COMMENT ON COLUMN public.custom_domains.dns_mode IS 'managed: Enclii writes the CNAME through the team''s DNS provider; manual: the user does';
+COMMENT ON COLUMN public.custom_domains.dns_status IS 'Result of the last drift check of a managed record: in_sync, drifted, or error';
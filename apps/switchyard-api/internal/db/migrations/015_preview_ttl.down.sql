@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS public.idx_preview_environments_cleanup;
+
+ALTER TABLE public.preview_environments
+    DROP COLUMN IF EXISTS cleaned_up_at;
+
+ALTER TABLE public.projects
+    DROP CONSTRAINT IF EXISTS valid_preview_ttl_days,
+    DROP COLUMN IF EXISTS preview_ttl_days;
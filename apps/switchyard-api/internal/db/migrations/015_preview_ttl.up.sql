@@ -0,0 +1,18 @@
+-- Preview environment TTL and cleanup tracking
+
+ALTER TABLE public.projects
+    ADD COLUMN IF NOT EXISTS preview_ttl_days integer;
+
+ALTER TABLE public.projects
+    ADD CONSTRAINT valid_preview_ttl_days CHECK (preview_ttl_days IS NULL OR preview_ttl_days >= 0);
+
+COMMENT ON COLUMN public.projects.preview_ttl_days IS 'Days a closed preview is kept before it is torn down, NULL to use the platform default';
+
+ALTER TABLE public.preview_environments
+    ADD COLUMN IF NOT EXISTS cleaned_up_at timestamp with time zone;
+
+COMMENT ON COLUMN public.preview_environments.cleaned_up_at IS 'When the namespace, releases and images of the closed preview were removed, NULL until then';
+
+CREATE INDEX IF NOT EXISTS idx_preview_environments_cleanup
+    ON public.preview_environments (closed_at)
+    WHERE status = 'closed' AND cleaned_up_at IS NULL;
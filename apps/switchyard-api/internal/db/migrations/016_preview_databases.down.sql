@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.idx_preview_databases_in_progress;
+
+DROP TABLE IF EXISTS public.preview_databases;
+
+DROP TABLE IF EXISTS public.preview_database_configs;
@@ -0,0 +1,44 @@
+-- Ephemeral databases for preview environments, cloned or seeded from a template addon
+
+CREATE TABLE IF NOT EXISTS public.preview_database_configs (
+    service_id uuid NOT NULL,
+    template_addon_id uuid NOT NULL,
+    mode character varying(20) DEFAULT 'clone'::character varying NOT NULL,
+    backup_id uuid,
+    seed_command text,
+    env_var_name character varying(255) DEFAULT 'DATABASE_URL'::character varying NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT preview_database_configs_pkey PRIMARY KEY (service_id),
+    CONSTRAINT preview_database_configs_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE,
+    CONSTRAINT preview_database_configs_template_addon_id_fkey FOREIGN KEY (template_addon_id) REFERENCES public.database_addons(id) ON DELETE CASCADE,
+    CONSTRAINT preview_database_configs_backup_id_fkey FOREIGN KEY (backup_id) REFERENCES public.database_addon_backups(id) ON DELETE SET NULL,
+    CONSTRAINT valid_preview_database_mode CHECK (((mode)::text = ANY ((ARRAY['clone'::character varying, 'seed'::character varying])::text[])))
+);
+
+COMMENT ON TABLE public.preview_database_configs IS 'Per-service template a preview environment''s ephemeral database is created from';
+COMMENT ON COLUMN public.preview_database_configs.backup_id IS 'Backup a clone restores, NULL for the latest completed backup of the template';
+
+CREATE TABLE IF NOT EXISTS public.preview_databases (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    preview_id uuid NOT NULL,
+    addon_id uuid NOT NULL,
+    mode character varying(20) NOT NULL,
+    env_var_name character varying(255) NOT NULL,
+    status character varying(50) DEFAULT 'provisioning'::character varying NOT NULL,
+    status_message text,
+    restore_id uuid,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    ready_at timestamp with time zone,
+    CONSTRAINT preview_databases_pkey PRIMARY KEY (id),
+    CONSTRAINT preview_databases_preview_id_key UNIQUE (preview_id),
+    CONSTRAINT preview_databases_preview_id_fkey FOREIGN KEY (preview_id) REFERENCES public.preview_environments(id) ON DELETE CASCADE,
+    CONSTRAINT preview_databases_addon_id_fkey FOREIGN KEY (addon_id) REFERENCES public.database_addons(id) ON DELETE CASCADE,
+    CONSTRAINT preview_databases_restore_id_fkey FOREIGN KEY (restore_id) REFERENCES public.database_addon_restores(id) ON DELETE SET NULL,
+    CONSTRAINT valid_preview_database_status CHECK (((status)::text = ANY ((ARRAY['provisioning'::character varying, 'restoring'::character varying, 'seeding'::character varying, 'ready'::character varying, 'failed'::character varying])::text[])))
+);
+
+CREATE INDEX IF NOT EXISTS idx_preview_databases_in_progress ON public.preview_databases USING btree (created_at) WHERE ((status)::text = ANY ((ARRAY['provisioning'::character varying, 'restoring'::character varying, 'seeding'::character varying])::text[]));
+
+COMMENT ON TABLE public.preview_databases IS 'Ephemeral database addon of a preview environment, removed with the preview';
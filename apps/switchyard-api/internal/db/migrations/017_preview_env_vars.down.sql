@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.preview_env_vars;
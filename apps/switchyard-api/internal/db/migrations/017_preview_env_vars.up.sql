@@ -0,0 +1,19 @@
+-- Environment variable overrides applied only to a service's preview environments
+
+CREATE TABLE IF NOT EXISTS public.preview_env_vars (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    service_id uuid NOT NULL,
+    key character varying(255) NOT NULL,
+    value_encrypted text NOT NULL,
+    is_secret boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    created_by uuid,
+    created_by_email character varying(255),
+    CONSTRAINT preview_env_vars_pkey PRIMARY KEY (id),
+    CONSTRAINT preview_env_vars_service_id_key_key UNIQUE (service_id, key),
+    CONSTRAINT preview_env_vars_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.preview_env_vars IS 'Per-service environment variable overrides for preview environments, e.g. sandbox API endpoints';
+COMMENT ON COLUMN public.preview_env_vars.value_encrypted IS 'Encrypted like environment_variables.value_encrypted';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.deployment_soaks;
+DROP TABLE IF EXISTS public.environment_soak_policies;
@@ -0,0 +1,50 @@
+-- Per-environment soak policies and the soak of each deployment under them
+
+CREATE TABLE IF NOT EXISTS public.environment_soak_policies (
+    environment_id uuid NOT NULL,
+    duration_minutes integer NOT NULL,
+    max_restarts integer DEFAULT 0 NOT NULL,
+    max_error_rate double precision,
+    auto_rollback boolean DEFAULT true NOT NULL,
+    require_stable boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT environment_soak_policies_pkey PRIMARY KEY (environment_id),
+    CONSTRAINT environment_soak_policies_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT environment_soak_policies_duration_check CHECK (duration_minutes >= 0),
+    CONSTRAINT environment_soak_policies_max_restarts_check CHECK (max_restarts >= 0),
+    CONSTRAINT environment_soak_policies_max_error_rate_check CHECK (max_error_rate IS NULL OR (max_error_rate >= 0 AND max_error_rate <= 1))
+);
+
+COMMENT ON TABLE public.environment_soak_policies IS 'How long a deployment soaks in an environment before its release is marked stable';
+COMMENT ON COLUMN public.environment_soak_policies.max_error_rate IS 'Highest allowed fraction of 5xx responses; NULL = error rate is not checked';
+COMMENT ON COLUMN public.environment_soak_policies.require_stable IS 'Deploys into this environment only accept releases that passed a soak elsewhere';
+
+CREATE TABLE IF NOT EXISTS public.deployment_soaks (
+    deployment_id uuid NOT NULL,
+    environment_id uuid NOT NULL,
+    release_id uuid NOT NULL,
+    status character varying(20) DEFAULT 'soaking'::character varying NOT NULL,
+    started_at timestamp with time zone DEFAULT now() NOT NULL,
+    ends_at timestamp with time zone NOT NULL,
+    baseline_restarts integer DEFAULT 0 NOT NULL,
+    restarts integer DEFAULT 0 NOT NULL,
+    error_rate double precision,
+    message text,
+    rollback_deployment_id uuid,
+    completed_at timestamp with time zone,
+    CONSTRAINT deployment_soaks_pkey PRIMARY KEY (deployment_id),
+    CONSTRAINT deployment_soaks_deployment_id_fkey FOREIGN KEY (deployment_id) REFERENCES public.deployments(id) ON DELETE CASCADE,
+    CONSTRAINT deployment_soaks_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT deployment_soaks_release_id_fkey FOREIGN KEY (release_id) REFERENCES public.releases(id) ON DELETE CASCADE,
+    CONSTRAINT deployment_soaks_rollback_deployment_id_fkey FOREIGN KEY (rollback_deployment_id) REFERENCES public.deployments(id) ON DELETE SET NULL,
+    CONSTRAINT deployment_soaks_status_check CHECK (status IN ('soaking', 'passed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_deployment_soaks_status ON public.deployment_soaks USING btree (status);
+CREATE INDEX IF NOT EXISTS idx_deployment_soaks_release_id ON public.deployment_soaks USING btree (release_id);
+CREATE INDEX IF NOT EXISTS idx_deployment_soaks_rollback_deployment_id ON public.deployment_soaks USING btree (rollback_deployment_id);
+
+COMMENT ON TABLE public.deployment_soaks IS 'Soak of a deployment; a release is stable once one of its deployments passed';
+COMMENT ON COLUMN public.deployment_soaks.baseline_restarts IS 'Container restarts of the deployment pods when the soak started';
+COMMENT ON COLUMN public.deployment_soaks.rollback_deployment_id IS 'Deployment of the previous release created when the soak failed';
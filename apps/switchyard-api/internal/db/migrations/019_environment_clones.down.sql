@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.idx_environment_addon_copies_in_progress;
+DROP INDEX IF EXISTS public.idx_environment_addon_copies_environment_id;
+
+DROP TABLE IF EXISTS public.environment_addon_copies;
@@ -0,0 +1,29 @@
+-- Addons created for a cloned environment from the addons of its source
+
+CREATE TABLE IF NOT EXISTS public.environment_addon_copies (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    environment_id uuid NOT NULL,
+    source_addon_id uuid,
+    addon_id uuid NOT NULL,
+    copy_data boolean DEFAULT false NOT NULL,
+    status character varying(50) DEFAULT 'provisioning'::character varying NOT NULL,
+    status_message text,
+    restore_id uuid,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    ready_at timestamp with time zone,
+    CONSTRAINT environment_addon_copies_pkey PRIMARY KEY (id),
+    CONSTRAINT environment_addon_copies_addon_id_key UNIQUE (addon_id),
+    CONSTRAINT environment_addon_copies_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT environment_addon_copies_source_addon_id_fkey FOREIGN KEY (source_addon_id) REFERENCES public.database_addons(id) ON DELETE SET NULL,
+    CONSTRAINT environment_addon_copies_addon_id_fkey FOREIGN KEY (addon_id) REFERENCES public.database_addons(id) ON DELETE CASCADE,
+    CONSTRAINT environment_addon_copies_restore_id_fkey FOREIGN KEY (restore_id) REFERENCES public.database_addon_restores(id) ON DELETE SET NULL,
+    CONSTRAINT valid_environment_addon_copy_status CHECK (((status)::text = ANY ((ARRAY['provisioning'::character varying, 'restoring'::character varying, 'ready'::character varying, 'failed'::character varying])::text[])))
+);
+
+CREATE INDEX IF NOT EXISTS idx_environment_addon_copies_environment_id ON public.environment_addon_copies USING btree (environment_id);
+CREATE INDEX IF NOT EXISTS idx_environment_addon_copies_in_progress ON public.environment_addon_copies USING btree (created_at) WHERE ((status)::text = ANY ((ARRAY['provisioning'::character varying, 'restoring'::character varying])::text[]));
+
+COMMENT ON TABLE public.environment_addon_copies IS 'Addon created for a cloned environment from an addon of the source environment, bound to the same services once ready';
+COMMENT ON COLUMN public.environment_addon_copies.source_addon_id IS 'Addon the copy was made from, NULL once it is deleted';
+COMMENT ON COLUMN public.environment_addon_copies.copy_data IS 'Whether the data of the source addon is cloned or restored into the copy';
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS public.idx_api_tokens_bot_id;
+
+ALTER TABLE public.api_tokens
+    DROP CONSTRAINT IF EXISTS api_tokens_bot_id_fkey,
+    DROP COLUMN IF EXISTS bot_id;
+
+DROP INDEX IF EXISTS public.idx_bot_grants_unique;
+
+DROP TABLE IF EXISTS public.bot_grants;
+DROP TABLE IF EXISTS public.bots;
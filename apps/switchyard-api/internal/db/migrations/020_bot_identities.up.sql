@@ -0,0 +1,47 @@
+-- Bot identities for CI pipelines, and the projects and environments their tokens may act on
+
+CREATE TABLE IF NOT EXISTS public.bots (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    name character varying(100) NOT NULL,
+    description text,
+    role character varying(50) DEFAULT 'developer'::character varying NOT NULL,
+    disabled boolean DEFAULT false NOT NULL,
+    created_by uuid,
+    created_by_email character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT bots_pkey PRIMARY KEY (id),
+    CONSTRAINT bots_name_key UNIQUE (name),
+    CONSTRAINT bots_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id) ON DELETE SET NULL,
+    CONSTRAINT valid_bot_role CHECK (((role)::text = ANY ((ARRAY['developer'::character varying, 'viewer'::character varying])::text[])))
+);
+
+COMMENT ON TABLE public.bots IS 'Non-human identities whose API tokens only reach the projects and environments granted to them';
+COMMENT ON COLUMN public.bots.role IS 'Role the bot''s tokens act with; bots are never admins';
+
+CREATE TABLE IF NOT EXISTS public.bot_grants (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    bot_id uuid NOT NULL,
+    project_id uuid NOT NULL,
+    environment_id uuid,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT bot_grants_pkey PRIMARY KEY (id),
+    CONSTRAINT bot_grants_bot_id_fkey FOREIGN KEY (bot_id) REFERENCES public.bots(id) ON DELETE CASCADE,
+    CONSTRAINT bot_grants_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT bot_grants_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bot_grants_unique ON public.bot_grants USING btree (bot_id, project_id, COALESCE(environment_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+COMMENT ON TABLE public.bot_grants IS 'Projects and environments a bot may deploy to, roll back, scale and configure';
+COMMENT ON COLUMN public.bot_grants.environment_id IS 'Environment the grant covers; NULL = every environment of the project';
+
+ALTER TABLE public.api_tokens
+    ADD COLUMN IF NOT EXISTS bot_id uuid;
+
+ALTER TABLE public.api_tokens
+    ADD CONSTRAINT api_tokens_bot_id_fkey FOREIGN KEY (bot_id) REFERENCES public.bots(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_bot_id ON public.api_tokens USING btree (bot_id) WHERE (bot_id IS NOT NULL);
+
+COMMENT ON COLUMN public.api_tokens.bot_id IS 'Bot the token acts as; user_id is then the admin who issued it. NULL = the token acts as its user';
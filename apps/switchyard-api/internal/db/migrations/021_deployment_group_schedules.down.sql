@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS public.idx_environment_freeze_windows_environment_id;
+
+DROP TABLE IF EXISTS public.environment_freeze_windows;
+
+DROP INDEX IF EXISTS public.idx_deployment_groups_scheduled;
+
+ALTER TABLE public.deployment_groups
+    DROP COLUMN IF EXISTS cancelled_by,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS reminder_sent_at,
+    DROP COLUMN IF EXISTS respect_freeze_windows,
+    DROP COLUMN IF EXISTS scheduled_at;
@@ -0,0 +1,31 @@
+-- Scheduled execution of deployment groups and per-environment freeze windows
+
+ALTER TABLE public.deployment_groups
+    ADD COLUMN IF NOT EXISTS scheduled_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS respect_freeze_windows boolean DEFAULT false NOT NULL,
+    ADD COLUMN IF NOT EXISTS reminder_sent_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS cancelled_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS cancelled_by character varying(255);
+
+CREATE INDEX IF NOT EXISTS idx_deployment_groups_scheduled ON public.deployment_groups USING btree (scheduled_at) WHERE ((status)::text = 'scheduled'::text);
+
+COMMENT ON COLUMN public.deployment_groups.scheduled_at IS 'When a scheduled group is executed; NULL = executed on request';
+COMMENT ON COLUMN public.deployment_groups.respect_freeze_windows IS 'A scheduled group due inside a freeze window of its environment waits for the window to end';
+COMMENT ON COLUMN public.deployment_groups.reminder_sent_at IS 'When the reminder before the scheduled execution was sent';
+
+CREATE TABLE IF NOT EXISTS public.environment_freeze_windows (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    environment_id uuid NOT NULL,
+    starts_at timestamp with time zone NOT NULL,
+    ends_at timestamp with time zone NOT NULL,
+    reason text,
+    created_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT environment_freeze_windows_pkey PRIMARY KEY (id),
+    CONSTRAINT environment_freeze_windows_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT environment_freeze_windows_range_check CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_environment_freeze_windows_environment_id ON public.environment_freeze_windows USING btree (environment_id, ends_at);
+
+COMMENT ON TABLE public.environment_freeze_windows IS 'Periods in which scheduled deployment groups of an environment are held back';
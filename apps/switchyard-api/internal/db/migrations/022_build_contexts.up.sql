@@ -0,0 +1,28 @@
+-- Build contexts uploaded from a local working directory, built by Roundhouse
+-- instead of a git checkout
+
+CREATE TABLE IF NOT EXISTS public.build_contexts (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    service_id uuid NOT NULL,
+    object_key text NOT NULL,
+    digest character varying(64) NOT NULL,
+    size_bytes bigint NOT NULL,
+    release_id uuid,
+    uploaded_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    purged_at timestamp with time zone,
+    CONSTRAINT build_contexts_pkey PRIMARY KEY (id),
+    CONSTRAINT build_contexts_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE,
+    CONSTRAINT build_contexts_release_id_fkey FOREIGN KEY (release_id) REFERENCES public.releases(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_build_contexts_service_id ON public.build_contexts USING btree (service_id);
+CREATE INDEX IF NOT EXISTS idx_build_contexts_release_id ON public.build_contexts USING btree (release_id) WHERE (release_id IS NOT NULL);
+CREATE INDEX IF NOT EXISTS idx_build_contexts_expires_at ON public.build_contexts USING btree (expires_at) WHERE (purged_at IS NULL);
+
+COMMENT ON TABLE public.build_contexts IS 'Gzipped tarballs of local working directories uploaded for a build';
+COMMENT ON COLUMN public.build_contexts.digest IS 'SHA-256 of the uploaded tarball, hex encoded';
+COMMENT ON COLUMN public.build_contexts.release_id IS 'Release built from the context; such releases are not deployed to production';
+COMMENT ON COLUMN public.build_contexts.expires_at IS 'When the tarball is deleted from object storage';
+COMMENT ON COLUMN public.build_contexts.purged_at IS 'When the tarball was deleted; the row stays to mark the release built from it';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS public.idx_audit_logs_project_timestamp;
+
+DROP TABLE IF EXISTS public.project_retention_policies;
@@ -0,0 +1,22 @@
+-- Per-project retention of build logs, access logs, audit entries, and usage detail
+
+CREATE TABLE IF NOT EXISTS public.project_retention_policies (
+    project_id uuid NOT NULL,
+    build_log_days integer,
+    access_log_days integer,
+    audit_log_days integer,
+    usage_detail_days integer,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT project_retention_policies_pkey PRIMARY KEY (project_id),
+    CONSTRAINT project_retention_policies_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT project_retention_policies_days_check CHECK (
+        COALESCE(build_log_days, 1) > 0 AND COALESCE(access_log_days, 1) > 0 AND
+        COALESCE(audit_log_days, 1) > 0 AND COALESCE(usage_detail_days, 1) > 0
+    )
+);
+
+COMMENT ON TABLE public.project_retention_policies IS 'How many days a project keeps each class of data; NULL columns use the plan default';
+COMMENT ON COLUMN public.project_retention_policies.usage_detail_days IS 'Days raw usage events and hourly usage are kept; daily usage is kept for billing';
+
+-- Purges delete audit entries of one project at a time, oldest first
+CREATE INDEX IF NOT EXISTS idx_audit_logs_project_timestamp ON public.audit_logs USING btree (project_id, "timestamp");
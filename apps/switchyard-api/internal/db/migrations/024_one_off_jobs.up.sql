@@ -0,0 +1,29 @@
+-- Commands run once in a service's deployed image, such as migrations
+
+CREATE TABLE IF NOT EXISTS public.one_off_jobs (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    service_id uuid NOT NULL,
+    environment_id uuid NOT NULL,
+    command text[] NOT NULL,
+    status character varying(20) DEFAULT 'pending'::character varying NOT NULL,
+    exit_code integer,
+    message text,
+    image text,
+    k8s_namespace character varying(255) NOT NULL,
+    k8s_job_name character varying(63) NOT NULL,
+    timeout_seconds integer NOT NULL,
+    created_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone,
+    CONSTRAINT one_off_jobs_pkey PRIMARY KEY (id),
+    CONSTRAINT one_off_jobs_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE,
+    CONSTRAINT one_off_jobs_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT one_off_jobs_status_check CHECK (status IN ('pending', 'running', 'succeeded', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_one_off_jobs_service_created ON public.one_off_jobs USING btree (service_id, created_at DESC);
+
+COMMENT ON TABLE public.one_off_jobs IS 'Commands run once as Kubernetes Jobs from the pod template of a service deployment';
+COMMENT ON COLUMN public.one_off_jobs.exit_code IS 'Exit code of the command, once it has terminated';
+COMMENT ON COLUMN public.one_off_jobs.created_by IS 'Email of the user who ran the command';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.signup_invites;
+DROP TABLE IF EXISTS public.email_verification_tokens;
+ALTER TABLE public.users DROP COLUMN IF EXISTS email_verified_at;
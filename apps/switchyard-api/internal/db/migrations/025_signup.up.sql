@@ -0,0 +1,46 @@
+-- Email verification and invite-only signup for local accounts
+
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS email_verified_at timestamp with time zone;
+
+-- Accounts created before verification existed are trusted as they are
+UPDATE public.users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+COMMENT ON COLUMN public.users.email_verified_at IS 'When the user proved they own their email address; NULL = unverified';
+
+CREATE TABLE IF NOT EXISTS public.email_verification_tokens (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    token_hash character varying(64) NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT email_verification_tokens_pkey PRIMARY KEY (id),
+    CONSTRAINT email_verification_tokens_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT email_verification_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_created ON public.email_verification_tokens USING btree (user_id, created_at DESC);
+
+COMMENT ON TABLE public.email_verification_tokens IS 'Single-use links sent to verify the email address of a new account';
+COMMENT ON COLUMN public.email_verification_tokens.token_hash IS 'SHA-256 of the token; the token itself is only sent by email';
+
+CREATE TABLE IF NOT EXISTS public.signup_invites (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    code_hash character varying(64) NOT NULL,
+    prefix character varying(16) NOT NULL,
+    email character varying(255),
+    note text,
+    max_uses integer DEFAULT 1 NOT NULL,
+    uses integer DEFAULT 0 NOT NULL,
+    expires_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    created_by uuid,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT signup_invites_pkey PRIMARY KEY (id),
+    CONSTRAINT signup_invites_code_hash_key UNIQUE (code_hash),
+    CONSTRAINT signup_invites_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id) ON DELETE SET NULL,
+    CONSTRAINT signup_invites_uses_check CHECK (max_uses > 0 AND uses >= 0 AND uses <= max_uses)
+);
+
+COMMENT ON TABLE public.signup_invites IS 'Invite codes platform admins hand out when signup is invite-only';
+COMMENT ON COLUMN public.signup_invites.email IS 'Only this address may sign up with the code; NULL = any address';
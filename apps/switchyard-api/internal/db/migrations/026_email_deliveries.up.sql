@@ -0,0 +1,26 @@
+-- Delivery status of transactional emails (invitations, verification, alerts)
+
+CREATE TABLE IF NOT EXISTS public.email_deliveries (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    template character varying(64) NOT NULL,
+    recipient character varying(255) NOT NULL,
+    subject text NOT NULL,
+    provider character varying(32) NOT NULL,
+    status character varying(20) DEFAULT 'queued'::character varying NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    last_error text,
+    provider_message_id character varying(255),
+    next_attempt_at timestamp with time zone,
+    sent_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT email_deliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT valid_email_delivery_status CHECK (((status)::text = ANY ((ARRAY['queued'::character varying, 'sending'::character varying, 'retrying'::character varying, 'sent'::character varying, 'failed'::character varying])::text[])))
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_deliveries_created ON public.email_deliveries USING btree (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_email_deliveries_status ON public.email_deliveries USING btree (status, updated_at) WHERE ((status)::text <> ALL ((ARRAY['sent'::character varying, 'failed'::character varying])::text[]));
+
+COMMENT ON TABLE public.email_deliveries IS 'Delivery status of transactional emails; message bodies are not stored since they carry tokens';
+COMMENT ON COLUMN public.email_deliveries.template IS 'Template the email was rendered from, e.g. team_invitation';
+COMMENT ON COLUMN public.email_deliveries.provider_message_id IS 'Message ID assigned by the mail provider, for tracing in its logs';
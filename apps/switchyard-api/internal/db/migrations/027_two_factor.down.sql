@@ -0,0 +1,4 @@
+ALTER TABLE public.teams DROP CONSTRAINT IF EXISTS valid_two_factor_policy;
+ALTER TABLE public.teams DROP COLUMN IF EXISTS two_factor_policy;
+DROP TABLE IF EXISTS public.user_recovery_codes;
+DROP TABLE IF EXISTS public.user_two_factor;
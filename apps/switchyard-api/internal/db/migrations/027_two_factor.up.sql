@@ -0,0 +1,36 @@
+-- TOTP two-factor authentication for local accounts, and team policies that require it
+
+CREATE TABLE IF NOT EXISTS public.user_two_factor (
+    user_id uuid NOT NULL,
+    secret_encrypted text NOT NULL,
+    enabled_at timestamp with time zone,
+    last_used_step bigint DEFAULT 0 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT user_two_factor_pkey PRIMARY KEY (user_id),
+    CONSTRAINT user_two_factor_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.user_two_factor IS 'TOTP authenticators of users; a row without enabled_at is an enrollment awaiting its first code';
+COMMENT ON COLUMN public.user_two_factor.secret_encrypted IS 'TOTP secret, AES-256-GCM encrypted with the platform key';
+COMMENT ON COLUMN public.user_two_factor.last_used_step IS 'Time step of the last accepted code, so a code cannot be used twice';
+
+CREATE TABLE IF NOT EXISTS public.user_recovery_codes (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    code_hash character varying(64) NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT user_recovery_codes_pkey PRIMARY KEY (id),
+    CONSTRAINT user_recovery_codes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user ON public.user_recovery_codes USING btree (user_id, code_hash);
+
+COMMENT ON TABLE public.user_recovery_codes IS 'Single-use codes that stand in for a TOTP code when the authenticator is lost';
+COMMENT ON COLUMN public.user_recovery_codes.code_hash IS 'SHA-256 of the code; the codes are only shown when generated';
+
+ALTER TABLE public.teams ADD COLUMN IF NOT EXISTS two_factor_policy character varying(16) DEFAULT 'off'::character varying NOT NULL;
+ALTER TABLE public.teams ADD CONSTRAINT valid_two_factor_policy CHECK (((two_factor_policy)::text = ANY ((ARRAY['off'::character varying, 'production'::character varying, 'all'::character varying])::text[])));
+
+COMMENT ON COLUMN public.teams.two_factor_policy IS 'Operations in team projects that need a recent second factor: off, production (production deploys), or all (production deploys and secret reads)';
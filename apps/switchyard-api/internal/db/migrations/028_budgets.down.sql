@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.budget_alerts;
+DROP TABLE IF EXISTS public.budgets;
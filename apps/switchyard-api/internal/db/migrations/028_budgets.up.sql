@@ -0,0 +1,46 @@
+-- Monthly budgets of teams and projects, evaluated by Waybill after each hourly aggregation
+
+CREATE TABLE IF NOT EXISTS public.budgets (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    team_id uuid,
+    project_id uuid,
+    monthly_limit numeric(10,2) NOT NULL,
+    hard_limit numeric(10,2),
+    currency character varying(3) DEFAULT 'USD'::character varying NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT budgets_pkey PRIMARY KEY (id),
+    CONSTRAINT budgets_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE,
+    CONSTRAINT budgets_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT budgets_one_scope CHECK (((team_id IS NULL) <> (project_id IS NULL))),
+    CONSTRAINT budgets_positive_limit CHECK ((monthly_limit > (0)::numeric)),
+    CONSTRAINT budgets_hard_limit_above_monthly CHECK (((hard_limit IS NULL) OR (hard_limit >= monthly_limit)))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_team ON public.budgets USING btree (team_id) WHERE (team_id IS NOT NULL);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_project ON public.budgets USING btree (project_id) WHERE (project_id IS NOT NULL);
+
+COMMENT ON TABLE public.budgets IS 'Monthly spending limits of a team (all its projects) or a single project';
+COMMENT ON COLUMN public.budgets.monthly_limit IS 'Soft limit; alerts are sent at 50, 80 and 100 percent of it';
+COMMENT ON COLUMN public.budgets.hard_limit IS 'Spend at which non-production services are scaled to zero; NULL for no cap';
+
+CREATE TABLE IF NOT EXISTS public.budget_alerts (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    budget_id uuid NOT NULL,
+    period_start date NOT NULL,
+    kind character varying(20) NOT NULL,
+    threshold integer NOT NULL,
+    spend numeric(10,2) NOT NULL,
+    notified_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT budget_alerts_pkey PRIMARY KEY (id),
+    CONSTRAINT budget_alerts_budget_id_fkey FOREIGN KEY (budget_id) REFERENCES public.budgets(id) ON DELETE CASCADE,
+    CONSTRAINT budget_alerts_once UNIQUE (budget_id, period_start, kind, threshold),
+    CONSTRAINT valid_budget_alert_kind CHECK (((kind)::text = ANY ((ARRAY['threshold'::character varying, 'hard_cap'::character varying])::text[])))
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_alerts_pending ON public.budget_alerts USING btree (period_start) WHERE (notified_at IS NULL);
+
+COMMENT ON TABLE public.budget_alerts IS 'Budget thresholds crossed per billing period, so each alert fires once';
+COMMENT ON COLUMN public.budget_alerts.threshold IS 'Percent of the monthly limit (threshold alerts), or 100 for the hard cap';
+COMMENT ON COLUMN public.budget_alerts.notified_at IS 'When switchyard-api accepted the alert; NULL alerts are retried';
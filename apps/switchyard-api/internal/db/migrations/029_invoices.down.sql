@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.invoice_line_items;
+DROP TABLE IF EXISTS public.invoices;
+DROP SEQUENCE IF EXISTS public.invoice_number_seq;
+DROP TABLE IF EXISTS public.hourly_resource_usage;
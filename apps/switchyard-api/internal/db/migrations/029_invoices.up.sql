@@ -0,0 +1,77 @@
+-- Invoices generated by Waybill from aggregated usage, with per-service usage to itemize them
+
+CREATE TABLE IF NOT EXISTS public.hourly_resource_usage (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    project_id uuid NOT NULL,
+    resource_type character varying(50) NOT NULL,
+    resource_id uuid NOT NULL,
+    resource_name character varying(255),
+    metric_type character varying(50) NOT NULL,
+    value numeric(20,6) DEFAULT 0 NOT NULL,
+    hour timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT hourly_resource_usage_pkey PRIMARY KEY (id),
+    CONSTRAINT hourly_resource_usage_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT hourly_resource_usage_resource_metric_hour_key UNIQUE (resource_id, metric_type, hour)
+);
+
+CREATE INDEX IF NOT EXISTS idx_hourly_resource_usage_lookup ON public.hourly_resource_usage USING btree (project_id, hour, metric_type);
+
+COMMENT ON TABLE public.hourly_resource_usage IS 'Hourly usage per service or other resource; sums to hourly_usage for hours aggregated since it was added';
+
+CREATE SEQUENCE IF NOT EXISTS public.invoice_number_seq;
+
+CREATE TABLE IF NOT EXISTS public.invoices (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    number character varying(50),
+    team_id uuid,
+    project_id uuid,
+    period_start date NOT NULL,
+    period_end date NOT NULL,
+    status character varying(20) DEFAULT 'draft'::character varying NOT NULL,
+    currency character varying(3) DEFAULT 'USD'::character varying NOT NULL,
+    total numeric(10,2) DEFAULT 0 NOT NULL,
+    stripe_invoice_id character varying(255),
+    finalized_at timestamp with time zone,
+    paid_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT invoices_pkey PRIMARY KEY (id),
+    CONSTRAINT invoices_number_key UNIQUE (number),
+    CONSTRAINT invoices_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE,
+    CONSTRAINT invoices_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT invoices_one_scope CHECK (((team_id IS NULL) <> (project_id IS NULL))),
+    CONSTRAINT valid_invoice_status CHECK (((status)::text = ANY ((ARRAY['draft'::character varying, 'finalized'::character varying, 'paid'::character varying])::text[])))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_team_period ON public.invoices USING btree (team_id, period_start) WHERE (team_id IS NOT NULL);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_project_period ON public.invoices USING btree (project_id, period_start) WHERE (project_id IS NOT NULL);
+CREATE INDEX IF NOT EXISTS idx_invoices_unpaid ON public.invoices USING btree (status) WHERE ((status)::text = 'finalized'::text);
+
+COMMENT ON TABLE public.invoices IS 'Monthly invoices of a team (all its projects) or a project without a team';
+COMMENT ON COLUMN public.invoices.number IS 'Assigned when the invoice is finalized';
+COMMENT ON COLUMN public.invoices.status IS 'draft (regenerated from usage) -> finalized (immutable) -> paid';
+
+CREATE TABLE IF NOT EXISTS public.invoice_line_items (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    invoice_id uuid NOT NULL,
+    project_id uuid NOT NULL,
+    resource_id uuid,
+    resource_name character varying(255),
+    kind character varying(20) NOT NULL,
+    metric_type character varying(50),
+    description text NOT NULL,
+    quantity numeric(20,6) DEFAULT 0 NOT NULL,
+    unit_price numeric(20,8) DEFAULT 0 NOT NULL,
+    amount numeric(10,2) DEFAULT 0 NOT NULL,
+    period_start timestamp with time zone NOT NULL,
+    period_end timestamp with time zone NOT NULL,
+    position integer DEFAULT 0 NOT NULL,
+    CONSTRAINT invoice_line_items_pkey PRIMARY KEY (id),
+    CONSTRAINT invoice_line_items_invoice_id_fkey FOREIGN KEY (invoice_id) REFERENCES public.invoices(id) ON DELETE CASCADE,
+    CONSTRAINT valid_invoice_line_item_kind CHECK (((kind)::text = ANY ((ARRAY['plan'::character varying, 'usage'::character varying])::text[])))
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_line_items_invoice ON public.invoice_line_items USING btree (invoice_id, position);
+
+COMMENT ON COLUMN public.invoice_line_items.period_start IS 'Part of the invoice period the line covers; shorter than the period for prorated plans';
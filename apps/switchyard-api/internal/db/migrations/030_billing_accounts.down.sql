@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.stripe_webhook_events;
+DROP TABLE IF EXISTS public.billing_accounts;
@@ -0,0 +1,38 @@
+-- Payment standing of the Stripe customers of teams and projects, kept
+-- current by Waybill from Stripe webhooks
+
+CREATE TABLE IF NOT EXISTS public.billing_accounts (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    stripe_customer_id character varying(255) NOT NULL,
+    team_id uuid,
+    project_id uuid,
+    status character varying(20) DEFAULT 'active'::character varying NOT NULL,
+    failed_payments integer DEFAULT 0 NOT NULL,
+    last_payment_error text,
+    past_due_since timestamp with time zone,
+    suspended_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT billing_accounts_pkey PRIMARY KEY (id),
+    CONSTRAINT billing_accounts_stripe_customer_id_key UNIQUE (stripe_customer_id),
+    CONSTRAINT billing_accounts_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE,
+    CONSTRAINT billing_accounts_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT billing_accounts_one_scope CHECK (((team_id IS NULL) <> (project_id IS NULL))),
+    CONSTRAINT valid_billing_account_status CHECK (((status)::text = ANY ((ARRAY['active'::character varying, 'past_due'::character varying, 'suspended'::character varying])::text[])))
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_accounts_team ON public.billing_accounts USING btree (team_id) WHERE (team_id IS NOT NULL);
+CREATE INDEX IF NOT EXISTS idx_billing_accounts_project ON public.billing_accounts USING btree (project_id) WHERE (project_id IS NOT NULL);
+
+COMMENT ON TABLE public.billing_accounts IS 'Stripe customer a team, or a project without a team, pays with';
+COMMENT ON COLUMN public.billing_accounts.status IS 'active, past_due after a failed payment, suspended once payments keep failing';
+COMMENT ON COLUMN public.billing_accounts.failed_payments IS 'Failed payments since the last successful one';
+
+CREATE TABLE IF NOT EXISTS public.stripe_webhook_events (
+    id character varying(255) NOT NULL,
+    type character varying(100) NOT NULL,
+    processed_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT stripe_webhook_events_pkey PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE public.stripe_webhook_events IS 'Stripe events already applied, as Stripe may deliver an event more than once';
@@ -0,0 +1,21 @@
+DELETE FROM public.invoice_line_items WHERE kind IN ('allowance', 'credit');
+ALTER TABLE public.invoice_line_items DROP CONSTRAINT IF EXISTS valid_invoice_line_item_kind;
+ALTER TABLE public.invoice_line_items
+    ADD CONSTRAINT valid_invoice_line_item_kind CHECK (((kind)::text = ANY ((ARRAY['plan'::character varying, 'usage'::character varying])::text[])));
+ALTER TABLE public.invoice_line_items DROP CONSTRAINT IF EXISTS invoice_line_items_credit_id_fkey;
+ALTER TABLE public.invoice_line_items DROP COLUMN IF EXISTS credit_id;
+ALTER TABLE public.invoice_line_items ALTER COLUMN project_id SET NOT NULL;
+
+DROP TABLE IF EXISTS public.credit_transactions;
+
+DELETE FROM public.credits WHERE team_id IS NOT NULL;
+DROP INDEX IF EXISTS public.idx_credits_team;
+ALTER TABLE public.credits DROP CONSTRAINT IF EXISTS valid_credit_kind;
+ALTER TABLE public.credits DROP CONSTRAINT IF EXISTS credits_not_overdrawn;
+ALTER TABLE public.credits DROP CONSTRAINT IF EXISTS credits_one_scope;
+ALTER TABLE public.credits DROP CONSTRAINT IF EXISTS credits_team_id_fkey;
+ALTER TABLE public.credits DROP COLUMN IF EXISTS kind;
+ALTER TABLE public.credits DROP COLUMN IF EXISTS team_id;
+ALTER TABLE public.credits ALTER COLUMN project_id SET NOT NULL;
+ALTER TABLE public.credits ALTER COLUMN used_amount DROP NOT NULL;
+ALTER TABLE public.credits ALTER COLUMN id DROP DEFAULT;
@@ -0,0 +1,48 @@
+-- Prepaid and promotional credits of teams and projects, consumed by Waybill
+-- invoices when they are finalized
+
+ALTER TABLE public.credits ALTER COLUMN id SET DEFAULT gen_random_uuid();
+ALTER TABLE public.credits ALTER COLUMN project_id DROP NOT NULL;
+UPDATE public.credits SET used_amount = 0 WHERE used_amount IS NULL;
+ALTER TABLE public.credits ALTER COLUMN used_amount SET NOT NULL;
+ALTER TABLE public.credits ADD COLUMN IF NOT EXISTS team_id uuid;
+ALTER TABLE public.credits ADD COLUMN IF NOT EXISTS kind character varying(20) DEFAULT 'promotional'::character varying NOT NULL;
+
+ALTER TABLE public.credits
+    ADD CONSTRAINT credits_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE;
+ALTER TABLE public.credits
+    ADD CONSTRAINT credits_one_scope CHECK (((team_id IS NULL) <> (project_id IS NULL)));
+ALTER TABLE public.credits
+    ADD CONSTRAINT credits_not_overdrawn CHECK ((used_amount <= amount));
+ALTER TABLE public.credits
+    ADD CONSTRAINT valid_credit_kind CHECK (((kind)::text = ANY ((ARRAY['promotional'::character varying, 'prepaid'::character varying])::text[])));
+
+CREATE INDEX IF NOT EXISTS idx_credits_team ON public.credits USING btree (team_id) WHERE (team_id IS NOT NULL);
+
+COMMENT ON COLUMN public.credits.kind IS 'promotional (granted) or prepaid (purchased)';
+COMMENT ON COLUMN public.credits.used_amount IS 'Consumed by finalized invoices; see credit_transactions';
+
+CREATE TABLE IF NOT EXISTS public.credit_transactions (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    credit_id uuid NOT NULL,
+    invoice_id uuid,
+    amount numeric(10,2) NOT NULL,
+    description text,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT credit_transactions_pkey PRIMARY KEY (id),
+    CONSTRAINT credit_transactions_credit_id_fkey FOREIGN KEY (credit_id) REFERENCES public.credits(id) ON DELETE CASCADE,
+    CONSTRAINT credit_transactions_invoice_id_fkey FOREIGN KEY (invoice_id) REFERENCES public.invoices(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_transactions_credit ON public.credit_transactions USING btree (credit_id, created_at);
+
+COMMENT ON TABLE public.credit_transactions IS 'Ledger of credit consumed by invoices';
+
+-- Credit lines apply to the whole invoice; allowance lines to a project
+ALTER TABLE public.invoice_line_items ALTER COLUMN project_id DROP NOT NULL;
+ALTER TABLE public.invoice_line_items ADD COLUMN IF NOT EXISTS credit_id uuid;
+ALTER TABLE public.invoice_line_items
+    ADD CONSTRAINT invoice_line_items_credit_id_fkey FOREIGN KEY (credit_id) REFERENCES public.credits(id) ON DELETE SET NULL;
+ALTER TABLE public.invoice_line_items DROP CONSTRAINT IF EXISTS valid_invoice_line_item_kind;
+ALTER TABLE public.invoice_line_items
+    ADD CONSTRAINT valid_invoice_line_item_kind CHECK (((kind)::text = ANY ((ARRAY['plan'::character varying, 'usage'::character varying, 'allowance'::character varying, 'credit'::character varying])::text[])));
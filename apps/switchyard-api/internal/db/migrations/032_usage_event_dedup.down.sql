@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS public.usage_corrections;
+
+DROP INDEX IF EXISTS public.idx_usage_events_unprocessed;
+DROP INDEX IF EXISTS public.idx_usage_events_fingerprint;
+DROP INDEX IF EXISTS public.idx_usage_events_idempotency_key;
+ALTER TABLE public.usage_events DROP COLUMN IF EXISTS fingerprint;
+ALTER TABLE public.usage_events DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,34 @@
+-- Idempotent usage event ingestion, and corrections recorded when Waybill
+-- re-aggregates hours that late events arrived for
+
+ALTER TABLE public.usage_events ADD COLUMN IF NOT EXISTS idempotency_key character varying(255);
+ALTER TABLE public.usage_events ADD COLUMN IF NOT EXISTS fingerprint character varying(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_usage_events_idempotency_key ON public.usage_events USING btree (idempotency_key) WHERE (idempotency_key IS NOT NULL);
+CREATE INDEX IF NOT EXISTS idx_usage_events_fingerprint ON public.usage_events USING btree (fingerprint, created_at);
+CREATE INDEX IF NOT EXISTS idx_usage_events_unprocessed ON public.usage_events USING btree ("timestamp") WHERE (processed_at IS NULL);
+
+COMMENT ON COLUMN public.usage_events.idempotency_key IS 'Set by the sender; a repeated key is not recorded again';
+COMMENT ON COLUMN public.usage_events.fingerprint IS 'SHA-256 of the event content; repeats within the dedup window are dropped';
+COMMENT ON COLUMN public.usage_events.processed_at IS 'When the hour of the event was aggregated with it';
+
+-- Hours before the last one were aggregated with every event they had
+UPDATE public.usage_events SET processed_at = NOW()
+WHERE processed_at IS NULL AND "timestamp" < date_trunc('hour', NOW()) - interval '1 hour';
+
+CREATE TABLE IF NOT EXISTS public.usage_corrections (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    project_id uuid NOT NULL,
+    metric_type character varying(50) NOT NULL,
+    hour timestamp with time zone NOT NULL,
+    previous_value numeric(20,6) NOT NULL,
+    value numeric(20,6) NOT NULL,
+    delta numeric(20,6) NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT usage_corrections_pkey PRIMARY KEY (id),
+    CONSTRAINT usage_corrections_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_corrections_project ON public.usage_corrections USING btree (project_id, hour);
+
+COMMENT ON TABLE public.usage_corrections IS 'Changes to hourly_usage from re-aggregating an hour after late events';
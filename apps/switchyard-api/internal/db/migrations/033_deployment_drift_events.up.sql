@@ -0,0 +1,23 @@
+-- Changes made to managed Kubernetes resources outside Enclii, found when
+-- deployments are reconciled with server-side apply
+
+CREATE TABLE IF NOT EXISTS public.deployment_drift_events (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    deployment_id uuid NOT NULL,
+    kind character varying(50) NOT NULL,
+    namespace character varying(253) NOT NULL,
+    name character varying(253) NOT NULL,
+    manager character varying(255) NOT NULL,
+    fields text[] DEFAULT '{}'::text[] NOT NULL,
+    action character varying(20) NOT NULL,
+    detected_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT deployment_drift_events_pkey PRIMARY KEY (id),
+    CONSTRAINT deployment_drift_events_deployment_id_fkey FOREIGN KEY (deployment_id) REFERENCES public.deployments(id) ON DELETE CASCADE,
+    CONSTRAINT deployment_drift_events_action_check CHECK (action IN ('overwrite', 'preserve'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_deployment_drift_events_deployment ON public.deployment_drift_events USING btree (deployment_id, detected_at DESC);
+
+COMMENT ON TABLE public.deployment_drift_events IS 'Fields of managed Kubernetes resources changed outside Enclii, per deployment';
+COMMENT ON COLUMN public.deployment_drift_events.manager IS 'Kubernetes field manager that made the change, e.g. kubectl-edit';
+COMMENT ON COLUMN public.deployment_drift_events.action IS 'overwrite = restored by the reconcile; preserve = manual values kept';
@@ -0,0 +1,31 @@
+-- Durable reconciliation work queue. Replicas claim ready jobs with
+-- FOR UPDATE SKIP LOCKED, so queued work survives restarts and unfinished
+-- claims are picked up again once their lease expires.
+
+CREATE TABLE IF NOT EXISTS public.reconcile_jobs (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    deployment_id uuid NOT NULL,
+    class character varying(20) DEFAULT 'standard'::character varying NOT NULL,
+    environment_id uuid,
+    priority integer DEFAULT 0 NOT NULL,
+    attempt integer DEFAULT 1 NOT NULL,
+    drift_policy character varying(20),
+    run_after timestamp with time zone DEFAULT now() NOT NULL,
+    enqueued_at timestamp with time zone DEFAULT now() NOT NULL,
+    claimed_by character varying(255),
+    claimed_until timestamp with time zone,
+    rerun boolean DEFAULT false NOT NULL,
+    CONSTRAINT reconcile_jobs_pkey PRIMARY KEY (id),
+    CONSTRAINT reconcile_jobs_deployment_id_key UNIQUE (deployment_id),
+    CONSTRAINT reconcile_jobs_deployment_id_fkey FOREIGN KEY (deployment_id) REFERENCES public.deployments(id) ON DELETE CASCADE,
+    CONSTRAINT reconcile_jobs_class_check CHECK (class IN ('production', 'standard', 'preview')),
+    CONSTRAINT reconcile_jobs_drift_policy_check CHECK (drift_policy IS NULL OR drift_policy IN ('overwrite', 'preserve'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconcile_jobs_run_after ON public.reconcile_jobs USING btree (run_after);
+CREATE INDEX IF NOT EXISTS idx_reconcile_jobs_claimed_by ON public.reconcile_jobs USING btree (claimed_by) WHERE claimed_by IS NOT NULL;
+
+COMMENT ON TABLE public.reconcile_jobs IS 'Queued deployment reconciliations, one per deployment';
+COMMENT ON COLUMN public.reconcile_jobs.run_after IS 'Not claimed before this time; set for retries';
+COMMENT ON COLUMN public.reconcile_jobs.claimed_by IS 'Replica working on the job; the claim lapses at claimed_until unless renewed';
+COMMENT ON COLUMN public.reconcile_jobs.rerun IS 'Queued again while claimed; run once more instead of completing';
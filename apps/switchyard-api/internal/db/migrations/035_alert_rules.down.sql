@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.alerts;
+DROP TABLE IF EXISTS public.alert_rules;
@@ -0,0 +1,56 @@
+-- Alert rules watch services for unhealthy conditions, such as crash loops
+-- or a high error rate. Each time a rule's condition is met in an
+-- environment an alert is opened; it fires once the condition has held for
+-- the rule's for_minutes and resolves when the condition clears.
+
+CREATE TABLE IF NOT EXISTS public.alert_rules (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    service_id uuid NOT NULL,
+    environment_id uuid,
+    name character varying(255) NOT NULL,
+    kind character varying(30) NOT NULL,
+    threshold double precision DEFAULT 0 NOT NULL,
+    for_minutes integer DEFAULT 0 NOT NULL,
+    notify_emails text[] DEFAULT '{}'::text[] NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    created_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT alert_rules_pkey PRIMARY KEY (id),
+    CONSTRAINT alert_rules_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE,
+    CONSTRAINT alert_rules_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT alert_rules_kind_check CHECK (kind IN ('crash_loop', 'restart_count', 'oom_killed', 'readiness_failing', 'hpa_at_max', 'error_rate')),
+    CONSTRAINT alert_rules_threshold_check CHECK (threshold >= 0),
+    CONSTRAINT alert_rules_for_minutes_check CHECK (for_minutes >= 0 AND for_minutes <= 1440)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_service ON public.alert_rules USING btree (service_id);
+
+CREATE TABLE IF NOT EXISTS public.alerts (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    rule_id uuid NOT NULL,
+    environment_id uuid NOT NULL,
+    status character varying(20) DEFAULT 'pending'::character varying NOT NULL,
+    summary text NOT NULL,
+    value double precision,
+    started_at timestamp with time zone DEFAULT now() NOT NULL,
+    fired_at timestamp with time zone,
+    last_seen_at timestamp with time zone DEFAULT now() NOT NULL,
+    resolved_at timestamp with time zone,
+    CONSTRAINT alerts_pkey PRIMARY KEY (id),
+    CONSTRAINT alerts_rule_id_fkey FOREIGN KEY (rule_id) REFERENCES public.alert_rules(id) ON DELETE CASCADE,
+    CONSTRAINT alerts_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT alerts_status_check CHECK (status IN ('pending', 'firing', 'resolved'))
+);
+
+-- At most one open alert per rule and environment, so a condition that
+-- persists is notified once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_open ON public.alerts USING btree (rule_id, environment_id) WHERE status <> 'resolved';
+CREATE INDEX IF NOT EXISTS idx_alerts_rule_started ON public.alerts USING btree (rule_id, started_at DESC);
+
+COMMENT ON TABLE public.alert_rules IS 'Conditions services are watched for, per service and optionally environment';
+COMMENT ON COLUMN public.alert_rules.environment_id IS 'NULL = every environment of the service''s project';
+COMMENT ON COLUMN public.alert_rules.threshold IS 'Restarts for restart_count, a ratio of failed requests for error_rate; unused otherwise';
+COMMENT ON COLUMN public.alert_rules.for_minutes IS 'How long the condition holds before the alert fires';
+COMMENT ON TABLE public.alerts IS 'Occurrences of alert rule conditions, one open per rule and environment';
+COMMENT ON COLUMN public.alerts.status IS 'pending = waiting out for_minutes; firing = notified; resolved = condition cleared';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.uptime_check_results;
+DROP TABLE IF EXISTS public.uptime_checks;
@@ -0,0 +1,61 @@
+-- Uptime checks request an HTTP endpoint of a service on an interval: a
+-- custom domain of the service, or the service inside the cluster. Each
+-- run is recorded so uptime percentages can be computed, and the check
+-- keeps its latest state so the status can be shown without reading the
+-- results.
+
+CREATE TABLE IF NOT EXISTS public.uptime_checks (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    service_id uuid NOT NULL,
+    environment_id uuid NOT NULL,
+    custom_domain_id uuid,
+    path character varying(2048) DEFAULT '/'::character varying NOT NULL,
+    expected_status integer DEFAULT 200 NOT NULL,
+    interval_seconds integer DEFAULT 60 NOT NULL,
+    timeout_seconds integer DEFAULT 10 NOT NULL,
+    failure_threshold integer DEFAULT 2 NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    status character varying(20) DEFAULT 'unknown'::character varying NOT NULL,
+    status_changed_at timestamp with time zone,
+    consecutive_failures integer DEFAULT 0 NOT NULL,
+    last_checked_at timestamp with time zone,
+    last_status_code integer,
+    last_latency_ms integer,
+    last_error text,
+    created_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT uptime_checks_pkey PRIMARY KEY (id),
+    CONSTRAINT uptime_checks_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE,
+    CONSTRAINT uptime_checks_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT uptime_checks_custom_domain_id_fkey FOREIGN KEY (custom_domain_id) REFERENCES public.custom_domains(id) ON DELETE CASCADE,
+    CONSTRAINT uptime_checks_status_check CHECK (status IN ('unknown', 'up', 'down')),
+    CONSTRAINT uptime_checks_expected_status_check CHECK (expected_status >= 100 AND expected_status <= 599),
+    CONSTRAINT uptime_checks_interval_check CHECK (interval_seconds >= 30 AND interval_seconds <= 3600),
+    CONSTRAINT uptime_checks_timeout_check CHECK (timeout_seconds >= 1 AND timeout_seconds <= 60),
+    CONSTRAINT uptime_checks_failure_threshold_check CHECK (failure_threshold >= 1 AND failure_threshold <= 10)
+);
+
+CREATE INDEX IF NOT EXISTS idx_uptime_checks_service ON public.uptime_checks USING btree (service_id);
+CREATE INDEX IF NOT EXISTS idx_uptime_checks_due ON public.uptime_checks USING btree (last_checked_at) WHERE enabled = true;
+
+CREATE TABLE IF NOT EXISTS public.uptime_check_results (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    check_id uuid NOT NULL,
+    checked_at timestamp with time zone DEFAULT now() NOT NULL,
+    up boolean NOT NULL,
+    status_code integer,
+    latency_ms integer DEFAULT 0 NOT NULL,
+    error text,
+    CONSTRAINT uptime_check_results_pkey PRIMARY KEY (id),
+    CONSTRAINT uptime_check_results_check_id_fkey FOREIGN KEY (check_id) REFERENCES public.uptime_checks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_uptime_check_results_check_time ON public.uptime_check_results USING btree (check_id, checked_at DESC);
+CREATE INDEX IF NOT EXISTS idx_uptime_check_results_checked_at ON public.uptime_check_results USING btree (checked_at);
+
+COMMENT ON TABLE public.uptime_checks IS 'HTTP checks of service endpoints, with their latest state';
+COMMENT ON COLUMN public.uptime_checks.custom_domain_id IS 'NULL = the service inside the cluster';
+COMMENT ON COLUMN public.uptime_checks.failure_threshold IS 'Failed checks in a row before the check is down';
+COMMENT ON COLUMN public.uptime_checks.status IS 'unknown = not checked yet; up; down = failed failure_threshold checks in a row';
+COMMENT ON TABLE public.uptime_check_results IS 'Outcome of each uptime check run, kept for 30 days';
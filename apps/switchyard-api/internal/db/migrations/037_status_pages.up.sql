@@ -0,0 +1,24 @@
+-- Status pages publish the health of a project's services in one
+-- environment, with recent incidents and uptime history, without
+-- authentication. They are opt-in: a project has no page until one is
+-- created and enabled.
+
+CREATE TABLE IF NOT EXISTS public.status_pages (
+    project_id uuid NOT NULL,
+    enabled boolean DEFAULT false NOT NULL,
+    title character varying(255) NOT NULL,
+    description text,
+    environment_id uuid NOT NULL,
+    custom_domain character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT status_pages_pkey PRIMARY KEY (project_id),
+    CONSTRAINT status_pages_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT status_pages_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_status_pages_custom_domain ON public.status_pages USING btree (custom_domain) WHERE custom_domain IS NOT NULL;
+
+COMMENT ON TABLE public.status_pages IS 'Public status pages of projects, served at /status/:slug';
+COMMENT ON COLUMN public.status_pages.environment_id IS 'Environment whose services the page shows';
+COMMENT ON COLUMN public.status_pages.custom_domain IS 'Hostname routed to switchyard-api that serves the page at /';
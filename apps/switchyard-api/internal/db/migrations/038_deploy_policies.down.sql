@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.environment_deploy_policies;
@@ -0,0 +1,16 @@
+-- Per-environment deploy policies: weekly deploy windows and emergency overrides
+
+CREATE TABLE IF NOT EXISTS public.environment_deploy_policies (
+    environment_id uuid NOT NULL,
+    timezone character varying(64) DEFAULT 'UTC' NOT NULL,
+    windows jsonb DEFAULT '[]'::jsonb NOT NULL,
+    allow_override boolean DEFAULT true NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT environment_deploy_policies_pkey PRIMARY KEY (environment_id),
+    CONSTRAINT environment_deploy_policies_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.environment_deploy_policies IS 'When releases may be deployed to an environment; freeze windows of the environment block deploys while it has one';
+COMMENT ON COLUMN public.environment_deploy_policies.windows IS 'Weekly deploy windows as [{"days": ["mon"], "start": "09:00", "end": "17:00"}]; empty = any time outside freeze windows';
+COMMENT ON COLUMN public.environment_deploy_policies.allow_override IS 'Admins may deploy outside the policy by giving a justification, which is audited';
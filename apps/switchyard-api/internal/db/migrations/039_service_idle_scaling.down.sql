@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.service_idle_scaling;
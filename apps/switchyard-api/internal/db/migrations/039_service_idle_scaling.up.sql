@@ -0,0 +1,30 @@
+-- Scale-to-zero of idle services in non-production environments
+
+CREATE TABLE IF NOT EXISTS public.service_idle_scaling (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    service_id uuid NOT NULL,
+    environment_id uuid NOT NULL,
+    idle_minutes integer DEFAULT 30 NOT NULL,
+    state character varying(16) DEFAULT 'awake' NOT NULL,
+    replicas integer DEFAULT 0 NOT NULL,
+    replica_gb double precision DEFAULT 0 NOT NULL,
+    last_active_at timestamp with time zone DEFAULT now() NOT NULL,
+    asleep_since timestamp with time zone,
+    saved_gb_hours double precision DEFAULT 0 NOT NULL,
+    savings_reported_until timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT service_idle_scaling_pkey PRIMARY KEY (id),
+    CONSTRAINT service_idle_scaling_service_environment_key UNIQUE (service_id, environment_id),
+    CONSTRAINT service_idle_scaling_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE,
+    CONSTRAINT service_idle_scaling_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT service_idle_scaling_idle_minutes_check CHECK (idle_minutes BETWEEN 5 AND 10080),
+    CONSTRAINT service_idle_scaling_state_check CHECK (state IN ('awake', 'asleep'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_idle_scaling_state ON public.service_idle_scaling USING btree (state);
+
+COMMENT ON TABLE public.service_idle_scaling IS 'Services scaled to zero after a period without requests and woken by their next request';
+COMMENT ON COLUMN public.service_idle_scaling.replicas IS 'Replicas the service had when it went to sleep, and wakes up with';
+COMMENT ON COLUMN public.service_idle_scaling.replica_gb IS 'GB-equivalent of one replica (the larger of its memory in GB and CPU cores), as Waybill bills compute';
+COMMENT ON COLUMN public.service_idle_scaling.savings_reported_until IS 'Compute saved while asleep has been reported to Waybill up to this time';
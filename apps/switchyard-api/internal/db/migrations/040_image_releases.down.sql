@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS public.registry_credentials;
+
+ALTER TABLE public.releases
+    DROP COLUMN IF EXISTS image_digest,
+    DROP COLUMN IF EXISTS source;
@@ -0,0 +1,27 @@
+-- Releases of existing registry images deployed without a build, and the
+-- credentials pods use to pull images from private registries
+
+ALTER TABLE public.releases
+    ADD COLUMN IF NOT EXISTS source character varying(16) DEFAULT 'build' NOT NULL,
+    ADD COLUMN IF NOT EXISTS image_digest character varying(100);
+
+COMMENT ON COLUMN public.releases.source IS 'How the image was produced: build (git or build context) or image (an existing registry image)';
+COMMENT ON COLUMN public.releases.image_digest IS 'Digest an image release is pinned to, NULL if it follows a tag';
+
+CREATE TABLE IF NOT EXISTS public.registry_credentials (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    project_id uuid NOT NULL,
+    registry character varying(255) NOT NULL,
+    username character varying(255) NOT NULL,
+    password_encrypted text NOT NULL,
+    created_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT registry_credentials_pkey PRIMARY KEY (id),
+    CONSTRAINT registry_credentials_project_registry_key UNIQUE (project_id, registry),
+    CONSTRAINT registry_credentials_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.registry_credentials IS 'Private registry credentials of a project, mounted as imagePullSecrets of its pods';
+COMMENT ON COLUMN public.registry_credentials.registry IS 'Normalized registry host; docker.io for Docker Hub';
+COMMENT ON COLUMN public.registry_credentials.password_encrypted IS 'Password or token, AES-256-GCM encrypted with the environment variable key';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS public.idx_registry_credentials_push_repository;
+ALTER TABLE public.registry_credentials DROP COLUMN IF EXISTS push_repository;
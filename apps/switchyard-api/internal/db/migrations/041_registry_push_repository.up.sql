@@ -0,0 +1,10 @@
+-- Customer-owned registries project builds push their images to
+
+ALTER TABLE public.registry_credentials
+    ADD COLUMN IF NOT EXISTS push_repository character varying(255);
+
+-- A project pushes its images to at most one repository
+CREATE UNIQUE INDEX IF NOT EXISTS idx_registry_credentials_push_repository
+    ON public.registry_credentials (project_id) WHERE push_repository IS NOT NULL;
+
+COMMENT ON COLUMN public.registry_credentials.push_repository IS 'Repository on this registry builds of the project push images to, NULL to push to the platform registry';
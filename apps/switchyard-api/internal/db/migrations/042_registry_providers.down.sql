@@ -0,0 +1,10 @@
+DELETE FROM public.registry_credentials WHERE environment_id IS NOT NULL;
+DROP INDEX IF EXISTS public.idx_registry_credentials_scope;
+ALTER TABLE public.registry_credentials
+    ADD CONSTRAINT registry_credentials_project_registry_key UNIQUE (project_id, registry);
+
+ALTER TABLE public.registry_credentials
+    DROP CONSTRAINT IF EXISTS registry_credentials_push_scope_check,
+    DROP CONSTRAINT IF EXISTS registry_credentials_environment_id_fkey,
+    DROP COLUMN IF EXISTS environment_id,
+    DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,18 @@
+-- Registry backends whose credentials are exchanged for short-lived tokens
+-- (ECR, Google Artifact Registry) and credentials scoped to one environment
+
+ALTER TABLE public.registry_credentials
+    ADD COLUMN IF NOT EXISTS provider character varying(16) DEFAULT 'basic' NOT NULL,
+    ADD COLUMN IF NOT EXISTS environment_id uuid;
+
+ALTER TABLE public.registry_credentials
+    ADD CONSTRAINT registry_credentials_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    ADD CONSTRAINT registry_credentials_push_scope_check CHECK (push_repository IS NULL OR environment_id IS NULL);
+
+-- A registry has one project-wide credential and one per environment
+ALTER TABLE public.registry_credentials DROP CONSTRAINT IF EXISTS registry_credentials_project_registry_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_registry_credentials_scope
+    ON public.registry_credentials (project_id, registry, (COALESCE(environment_id, '00000000-0000-0000-0000-000000000000'::uuid)));
+
+COMMENT ON COLUMN public.registry_credentials.provider IS 'basic, ecr (access key exchanged for 12h tokens), gcr (service account key exchanged for access tokens) or harbor (robot account)';
+COMMENT ON COLUMN public.registry_credentials.environment_id IS 'Environment the credential applies to instead of the project-wide one, NULL for every environment';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.idx_environments_cluster_id;
+ALTER TABLE public.environments DROP CONSTRAINT IF EXISTS environments_cluster_id_fkey;
+ALTER TABLE public.environments DROP COLUMN IF EXISTS cluster_id;
+DROP TABLE IF EXISTS public.clusters;
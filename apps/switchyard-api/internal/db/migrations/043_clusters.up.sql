@@ -0,0 +1,31 @@
+-- Kubernetes clusters besides the control plane's own, and the environments
+-- deployed to them
+
+CREATE TABLE IF NOT EXISTS public.clusters (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    name character varying(63) NOT NULL,
+    description text,
+    api_server character varying(255) NOT NULL,
+    kube_context character varying(255),
+    kubeconfig_encrypted text NOT NULL,
+    created_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT clusters_pkey PRIMARY KEY (id),
+    CONSTRAINT clusters_name_key UNIQUE (name)
+);
+
+ALTER TABLE public.environments
+    ADD COLUMN IF NOT EXISTS cluster_id uuid;
+
+-- A cluster cannot be removed while environments are deployed to it
+ALTER TABLE public.environments
+    ADD CONSTRAINT environments_cluster_id_fkey FOREIGN KEY (cluster_id) REFERENCES public.clusters(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_environments_cluster_id ON public.environments USING btree (cluster_id) WHERE cluster_id IS NOT NULL;
+
+COMMENT ON TABLE public.clusters IS 'Kubernetes clusters environments can be deployed to besides the one the API runs in';
+COMMENT ON COLUMN public.clusters.api_server IS 'API server URL of the kubeconfig context, for display';
+COMMENT ON COLUMN public.clusters.kube_context IS 'Context of the kubeconfig to use, NULL for its current context';
+COMMENT ON COLUMN public.clusters.kubeconfig_encrypted IS 'Kubeconfig, AES-256-GCM encrypted with the environment variable key';
+COMMENT ON COLUMN public.environments.cluster_id IS 'Cluster the environment is deployed to, NULL for the cluster the API runs in';
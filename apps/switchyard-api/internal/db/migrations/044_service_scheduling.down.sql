@@ -0,0 +1 @@
+ALTER TABLE public.services DROP COLUMN IF EXISTS scheduling;
@@ -0,0 +1,6 @@
+-- Node selectors, tolerations and topology spread constraints of a service's pods
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS scheduling jsonb;
+
+COMMENT ON COLUMN public.services.scheduling IS 'Pod placement, e.g. {"node_selector": {"cloud.google.com/gke-nodepool": "gpu"}, "tolerations": [...], "topology_spread": [...]}; NULL to schedule anywhere';
@@ -0,0 +1,3 @@
+ALTER TABLE public.teams DROP CONSTRAINT IF EXISTS valid_gpu_quota;
+ALTER TABLE public.teams DROP COLUMN IF EXISTS gpu_quota;
+ALTER TABLE public.services DROP COLUMN IF EXISTS gpu;
@@ -0,0 +1,13 @@
+-- GPU requests of services and GPU quotas of teams
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS gpu jsonb;
+
+COMMENT ON COLUMN public.services.gpu IS 'NVIDIA GPUs per pod, e.g. {"count": 1} or {"count": 2, "mig_profile": "1g.5gb"}; NULL for none';
+
+ALTER TABLE public.teams
+    ADD COLUMN IF NOT EXISTS gpu_quota integer;
+
+ALTER TABLE public.teams ADD CONSTRAINT valid_gpu_quota CHECK (gpu_quota >= 0);
+
+COMMENT ON COLUMN public.teams.gpu_quota IS 'Most GPUs (or MIG slices) the services of team projects may hold across environments; NULL for no limit';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.service_environment_commands;
+ALTER TABLE public.services DROP COLUMN IF EXISTS command;
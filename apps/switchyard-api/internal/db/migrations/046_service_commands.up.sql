@@ -0,0 +1,22 @@
+-- Custom command, args and working directory of services, and their
+-- per-environment overrides
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS command jsonb;
+
+COMMENT ON COLUMN public.services.command IS 'Overrides of the image entrypoint, e.g. {"command": ["bundle", "exec"], "args": ["sidekiq"], "working_dir": "/app"}; NULL runs the image as built';
+
+CREATE TABLE IF NOT EXISTS public.service_environment_commands (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    service_id uuid NOT NULL,
+    environment_id uuid NOT NULL,
+    command jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT service_environment_commands_pkey PRIMARY KEY (id),
+    CONSTRAINT service_environment_commands_service_environment_key UNIQUE (service_id, environment_id),
+    CONSTRAINT service_environment_commands_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE,
+    CONSTRAINT service_environment_commands_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.service_environment_commands IS 'Commands services run in one environment instead of their own';
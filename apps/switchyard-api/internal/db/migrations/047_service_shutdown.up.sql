@@ -0,0 +1,6 @@
+-- Graceful shutdown settings of services
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS shutdown jsonb;
+
+COMMENT ON COLUMN public.services.shutdown IS 'Termination grace period, preStop hook and rolling update surge/unavailability, e.g. {"termination_grace_period_seconds": 60, "pre_stop": {"sleep_seconds": 10}, "max_unavailable": "0"}; NULL for the defaults';
@@ -0,0 +1,6 @@
+-- High availability of services: pod disruption budgets and anti-affinity
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS high_availability boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN public.services.high_availability IS 'Services with more than one replica get a PodDisruptionBudget and preferred pod anti-affinity across nodes and zones';
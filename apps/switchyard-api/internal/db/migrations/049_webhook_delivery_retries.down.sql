@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS public.idx_webhook_deliveries_due;
+
+ALTER TABLE public.webhook_deliveries
+    DROP COLUMN IF EXISTS replay_of,
+    DROP COLUMN IF EXISTS next_attempt_at;
+
+UPDATE public.webhook_deliveries SET status = 'failed' WHERE status IN ('retrying', 'dead');
+
+ALTER TABLE public.webhook_deliveries DROP CONSTRAINT IF EXISTS valid_delivery_status;
+ALTER TABLE public.webhook_deliveries
+    ADD CONSTRAINT valid_delivery_status CHECK (((status)::text = ANY ((ARRAY['pending'::character varying, 'success'::character varying, 'failed'::character varying])::text[])));
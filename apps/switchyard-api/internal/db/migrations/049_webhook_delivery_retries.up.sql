@@ -0,0 +1,15 @@
+-- Webhook delivery retries: failed deliveries are retried with backoff and
+-- dead-lettered once their attempts run out
+
+ALTER TABLE public.webhook_deliveries DROP CONSTRAINT IF EXISTS valid_delivery_status;
+ALTER TABLE public.webhook_deliveries
+    ADD CONSTRAINT valid_delivery_status CHECK (((status)::text = ANY ((ARRAY['pending'::character varying, 'success'::character varying, 'failed'::character varying, 'retrying'::character varying, 'dead'::character varying])::text[])));
+
+ALTER TABLE public.webhook_deliveries
+    ADD COLUMN IF NOT EXISTS next_attempt_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS replay_of uuid REFERENCES public.webhook_deliveries(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN public.webhook_deliveries.next_attempt_at IS 'When a retrying delivery is attempted again';
+COMMENT ON COLUMN public.webhook_deliveries.replay_of IS 'Delivery whose payload this delivery replayed';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON public.webhook_deliveries USING btree (next_attempt_at) WHERE ((status)::text = 'retrying'::text);
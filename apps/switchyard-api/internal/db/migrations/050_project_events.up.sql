@@ -0,0 +1,22 @@
+-- Event history of projects, behind the activity feed of the dashboard
+
+CREATE TABLE IF NOT EXISTS public.project_events (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    project_id uuid NOT NULL,
+    type character varying(64) NOT NULL,
+    resource_type character varying(50),
+    resource_id uuid,
+    actor_id uuid,
+    actor_email character varying(255),
+    message text DEFAULT ''::text NOT NULL,
+    data jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT project_events_pkey PRIMARY KEY (id),
+    CONSTRAINT project_events_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT project_events_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES public.users(id) ON DELETE SET NULL
+);
+
+COMMENT ON TABLE public.project_events IS 'Domain events of projects, such as deployments, builds, env var changes and new members, newest read first';
+COMMENT ON COLUMN public.project_events.type IS 'Event type, e.g. deployment.succeeded or env_var.changed; webhook events keep their webhook type';
+
+CREATE INDEX IF NOT EXISTS idx_project_events_feed ON public.project_events USING btree (project_id, created_at DESC, id DESC);
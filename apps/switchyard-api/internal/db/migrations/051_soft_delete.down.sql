@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS public.idx_services_trashed;
+DROP INDEX IF EXISTS public.idx_projects_trashed;
+
+ALTER TABLE public.services
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE public.projects
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
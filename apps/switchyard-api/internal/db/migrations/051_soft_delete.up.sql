@@ -0,0 +1,18 @@
+-- Soft delete of projects and services: deleted ones stay restorable for a
+-- retention window before their resources are purged
+
+ALTER TABLE public.projects
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS deleted_by uuid;
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS deleted_by uuid;
+
+COMMENT ON COLUMN public.projects.deleted_at IS 'When the project was moved to the trash; its resources are purged once the retention window passes';
+COMMENT ON COLUMN public.services.deleted_at IS 'When the service was moved to the trash; services trashed with their project share its deleted_at';
+COMMENT ON COLUMN public.projects.deleted_by IS 'User who deleted the project; OIDC users have no local row, so this is not a foreign key';
+COMMENT ON COLUMN public.services.deleted_by IS 'User who deleted the service';
+
+CREATE INDEX IF NOT EXISTS idx_projects_trashed ON public.projects USING btree (deleted_at) WHERE (deleted_at IS NOT NULL);
+CREATE INDEX IF NOT EXISTS idx_services_trashed ON public.services USING btree (deleted_at) WHERE (deleted_at IS NOT NULL);
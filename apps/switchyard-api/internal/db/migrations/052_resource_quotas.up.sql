@@ -0,0 +1,30 @@
+-- Resource quotas of teams and projects: caps on services, CPU and memory
+-- requests, addon storage and preview environments
+
+CREATE TABLE IF NOT EXISTS public.resource_quotas (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    team_id uuid,
+    project_id uuid,
+    max_services integer,
+    max_cpu_millicores integer,
+    max_memory_mb integer,
+    max_addon_storage_gb integer,
+    max_preview_environments integer,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT resource_quotas_pkey PRIMARY KEY (id),
+    CONSTRAINT resource_quotas_team_id_key UNIQUE (team_id),
+    CONSTRAINT resource_quotas_project_id_key UNIQUE (project_id),
+    CONSTRAINT resource_quotas_team_id_fkey FOREIGN KEY (team_id) REFERENCES public.teams(id) ON DELETE CASCADE,
+    CONSTRAINT resource_quotas_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE,
+    CONSTRAINT resource_quotas_one_scope CHECK ((team_id IS NULL) <> (project_id IS NULL)),
+    CONSTRAINT valid_resource_quota_limits CHECK (
+        max_services >= 0 AND max_cpu_millicores >= 0 AND max_memory_mb >= 0
+        AND max_addon_storage_gb >= 0 AND max_preview_environments >= 0
+    )
+);
+
+COMMENT ON TABLE public.resource_quotas IS 'Quota of a team or a project; each limit is NULL for no limit';
+COMMENT ON COLUMN public.resource_quotas.max_cpu_millicores IS 'Most CPU the pods of the latest pending or running deployments may request, across environments';
+COMMENT ON COLUMN public.resource_quotas.max_memory_mb IS 'Most memory, in MiB, the pods of the latest pending or running deployments may request, across environments';
+COMMENT ON COLUMN public.resource_quotas.max_addon_storage_gb IS 'Most storage the database addons that are not deleted may hold';
+COMMENT ON COLUMN public.resource_quotas.max_preview_environments IS 'Most preview environments that are not closed';
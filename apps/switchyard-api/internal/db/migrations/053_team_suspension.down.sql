@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.idx_teams_suspended;
+ALTER TABLE public.teams DROP COLUMN IF EXISTS suspended_by;
+ALTER TABLE public.teams DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE public.teams DROP COLUMN IF EXISTS suspended_at;
@@ -0,0 +1,10 @@
+-- Suspension of abusive teams by platform operators
+
+ALTER TABLE public.teams ADD COLUMN IF NOT EXISTS suspended_at timestamp with time zone;
+ALTER TABLE public.teams ADD COLUMN IF NOT EXISTS suspension_reason text;
+ALTER TABLE public.teams ADD COLUMN IF NOT EXISTS suspended_by character varying(255);
+
+CREATE INDEX IF NOT EXISTS idx_teams_suspended ON public.teams USING btree (suspended_at) WHERE (suspended_at IS NOT NULL);
+
+COMMENT ON COLUMN public.teams.suspended_at IS 'When a platform operator suspended the team: its services are scaled to zero and its projects take no changes';
+COMMENT ON COLUMN public.teams.suspended_by IS 'Email of the operator who suspended the team';
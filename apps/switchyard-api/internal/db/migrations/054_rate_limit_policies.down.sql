@@ -0,0 +1,2 @@
+ALTER TABLE public.api_tokens DROP COLUMN IF EXISTS rate_limit_per_minute;
+DROP TABLE IF EXISTS public.rate_limit_policies;
@@ -0,0 +1,38 @@
+-- Rate limit policies: tighter budgets for expensive routes, on top of the
+-- platform-wide limits per IP, user and API token
+
+CREATE TABLE IF NOT EXISTS public.rate_limit_policies (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    name character varying(100) NOT NULL,
+    method character varying(10) NOT NULL,
+    route character varying(255) NOT NULL,
+    scope character varying(10) DEFAULT 'user'::character varying NOT NULL,
+    requests integer NOT NULL,
+    window_seconds integer DEFAULT 60 NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    description text,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT rate_limit_policies_pkey PRIMARY KEY (id),
+    CONSTRAINT rate_limit_policies_name_key UNIQUE (name),
+    CONSTRAINT valid_rate_limit_policy_scope CHECK (scope IN ('user', 'token', 'ip')),
+    CONSTRAINT valid_rate_limit_policy_budget CHECK (requests > 0 AND window_seconds > 0)
+);
+
+COMMENT ON TABLE public.rate_limit_policies IS 'Budgets of expensive routes, reloaded by every replica within 30 seconds of a change';
+COMMENT ON COLUMN public.rate_limit_policies.method IS 'HTTP method of the route, or * for any';
+COMMENT ON COLUMN public.rate_limit_policies.route IS 'Route pattern as registered, e.g. /v1/services/:id/build';
+COMMENT ON COLUMN public.rate_limit_policies.scope IS 'Who the budget is counted for: each user, each API token or each client IP';
+
+INSERT INTO public.rate_limit_policies (name, method, route, scope, requests, window_seconds, description) VALUES
+    ('build', 'POST', '/v1/services/:id/build', 'user', 30, 3600, 'Builds started by hand'),
+    ('deploy', 'POST', '/v1/services/:id/deploy', 'user', 60, 3600, 'Deploys of a release'),
+    ('rollback', 'POST', '/v1/deployments/:id/rollback', 'user', 30, 3600, 'Rollbacks'),
+    ('deployment-group-execute', 'POST', '/v1/projects/:slug/deployment-groups/:group_id/execute', 'user', 20, 3600, 'Deployment group runs'),
+    ('template-deploy', 'POST', '/v1/templates/:slug/deploy', 'user', 10, 3600, 'Projects created from templates')
+ON CONFLICT (name) DO NOTHING;
+
+-- Custom quota of an API token, in place of the platform-wide token limit
+ALTER TABLE public.api_tokens ADD COLUMN IF NOT EXISTS rate_limit_per_minute integer;
+
+COMMENT ON COLUMN public.api_tokens.rate_limit_per_minute IS 'Requests per minute the token may make; NULL for the platform default';
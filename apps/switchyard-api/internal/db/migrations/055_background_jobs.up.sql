@@ -0,0 +1,34 @@
+-- Background jobs: asynchronous work such as preview teardown, addon
+-- provisioning and notification delivery. Every replica claims ready jobs
+-- with FOR UPDATE SKIP LOCKED, so jobs survive restarts, and jobs of a
+-- replica that died are claimed again once their lease expires.
+
+CREATE TABLE IF NOT EXISTS public.background_jobs (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    kind character varying(100) NOT NULL,
+    payload jsonb DEFAULT '{}'::jsonb NOT NULL,
+    status character varying(20) DEFAULT 'queued'::character varying NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    max_attempts integer DEFAULT 5 NOT NULL,
+    run_after timestamp with time zone DEFAULT now() NOT NULL,
+    claimed_by character varying(255),
+    claimed_until timestamp with time zone,
+    last_error text,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT background_jobs_pkey PRIMARY KEY (id),
+    CONSTRAINT background_jobs_status_check CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    CONSTRAINT background_jobs_max_attempts_check CHECK (max_attempts > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_background_jobs_runnable ON public.background_jobs USING btree (run_after) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_background_jobs_created_at ON public.background_jobs USING btree (created_at);
+CREATE INDEX IF NOT EXISTS idx_background_jobs_kind ON public.background_jobs USING btree (kind, created_at);
+
+COMMENT ON TABLE public.background_jobs IS 'Asynchronous work run by any replica, retried with backoff; finished jobs are pruned after 7 days';
+COMMENT ON COLUMN public.background_jobs.kind IS 'Handler of the job, e.g. preview.cleanup';
+COMMENT ON COLUMN public.background_jobs.attempts IS 'Attempts started so far, including one that is running';
+COMMENT ON COLUMN public.background_jobs.run_after IS 'Not claimed before this time; set for scheduled jobs and retries';
+COMMENT ON COLUMN public.background_jobs.claimed_by IS 'Replica running the job; the claim lapses at claimed_until unless renewed';
@@ -0,0 +1,19 @@
+-- Build artifacts: files a build published from the artifacts directory of
+-- its image, e.g. test reports or compiled binaries, uploaded by Roundhouse
+-- to object storage
+
+CREATE TABLE IF NOT EXISTS public.build_artifacts (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    release_id uuid NOT NULL,
+    path text NOT NULL,
+    size_bytes bigint NOT NULL,
+    object_key text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT build_artifacts_pkey PRIMARY KEY (id),
+    CONSTRAINT build_artifacts_release_id_fkey FOREIGN KEY (release_id) REFERENCES public.releases(id) ON DELETE CASCADE,
+    CONSTRAINT build_artifacts_release_path_key UNIQUE (release_id, path)
+);
+
+COMMENT ON TABLE public.build_artifacts IS 'Files published by a build, stored in the build artifacts bucket';
+COMMENT ON COLUMN public.build_artifacts.path IS 'Path relative to the artifacts directory of the build';
+COMMENT ON COLUMN public.build_artifacts.object_key IS 'Key of the file in the build artifacts bucket';
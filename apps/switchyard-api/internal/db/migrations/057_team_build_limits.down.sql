@@ -0,0 +1 @@
+ALTER TABLE public.teams DROP COLUMN IF EXISTS build_limits;
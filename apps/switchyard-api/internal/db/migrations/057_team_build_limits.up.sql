@@ -0,0 +1,6 @@
+-- Build limits of teams, bounding the build resources their services set
+
+ALTER TABLE public.teams
+    ADD COLUMN IF NOT EXISTS build_limits jsonb;
+
+COMMENT ON COLUMN public.teams.build_limits IS 'Largest build resources team services may set, e.g. {"max_cpu": "8", "max_memory": "16Gi", "max_timeout_minutes": 120}; NULL for no limits';
@@ -0,0 +1,18 @@
+-- Secrets of projects that only their builds see
+
+CREATE TABLE IF NOT EXISTS public.build_secrets (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    project_id uuid NOT NULL,
+    name character varying(128) NOT NULL,
+    value_encrypted text NOT NULL,
+    created_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT build_secrets_pkey PRIMARY KEY (id),
+    CONSTRAINT build_secrets_project_name_key UNIQUE (project_id, name),
+    CONSTRAINT build_secrets_project_id_fkey FOREIGN KEY (project_id) REFERENCES public.projects(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.build_secrets IS 'Secrets mounted into the builds of a project, e.g. private package registry tokens; never passed to running pods';
+COMMENT ON COLUMN public.build_secrets.name IS 'Environment variable style name; the file name under /run/secrets in builds';
+COMMENT ON COLUMN public.build_secrets.value_encrypted IS 'Value, AES-256-GCM encrypted with the environment variable key';
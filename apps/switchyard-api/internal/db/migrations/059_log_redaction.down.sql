@@ -0,0 +1 @@
+ALTER TABLE public.projects DROP COLUMN IF EXISTS log_redaction_disabled;
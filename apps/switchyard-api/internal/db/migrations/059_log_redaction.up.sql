@@ -0,0 +1,6 @@
+-- Per-project switch for redacting token patterns in build and runtime logs
+
+ALTER TABLE public.projects
+    ADD COLUMN IF NOT EXISTS log_redaction_disabled boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN public.projects.log_redaction_disabled IS 'Stop masking token patterns and secret env var values in the project''s logs; build secrets are masked regardless';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.env_var_rotations;
@@ -0,0 +1,23 @@
+-- Managed rotation of secret environment variables: a new value is staged
+-- next to the old one, both are exposed under suffixed names until the
+-- rotation is finalized or aborted
+
+CREATE TABLE IF NOT EXISTS public.env_var_rotations (
+    env_var_id uuid NOT NULL,
+    max_age_days integer,
+    rotated_at timestamp with time zone,
+    pending_value_encrypted text,
+    started_at timestamp with time zone,
+    started_by_email character varying(255),
+    reminded_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT env_var_rotations_pkey PRIMARY KEY (env_var_id),
+    CONSTRAINT env_var_rotations_env_var_id_fkey FOREIGN KEY (env_var_id) REFERENCES public.environment_variables(id) ON DELETE CASCADE,
+    CONSTRAINT env_var_rotations_max_age_days_check CHECK (max_age_days IS NULL OR max_age_days > 0)
+);
+
+COMMENT ON TABLE public.env_var_rotations IS 'Secret environment variables marked rotatable, and the rotation in progress of each';
+COMMENT ON COLUMN public.env_var_rotations.max_age_days IS 'Days after the last rotation at which reminders are sent; NULL for no reminders';
+COMMENT ON COLUMN public.env_var_rotations.rotated_at IS 'When the last rotation was finalized; NULL until the first one, when the variable''s updated_at counts';
+COMMENT ON COLUMN public.env_var_rotations.pending_value_encrypted IS 'Staged new value, encrypted like the variable''s; NULL when no rotation is in progress';
+COMMENT ON COLUMN public.env_var_rotations.reminded_at IS 'When the last reminder that the secret is older than max_age_days was sent';
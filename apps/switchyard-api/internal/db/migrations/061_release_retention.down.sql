@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS public.idx_releases_service_created;
+
+ALTER TABLE public.releases DROP COLUMN IF EXISTS pruned_at;
+
+ALTER TABLE public.project_retention_policies
+    DROP CONSTRAINT IF EXISTS project_retention_policies_keep_releases_check,
+    DROP COLUMN IF EXISTS keep_releases;
@@ -0,0 +1,16 @@
+-- Release retention: how many releases of each service a project keeps, and
+-- the pruned releases that remain as history without their image
+
+ALTER TABLE public.project_retention_policies
+    ADD COLUMN IF NOT EXISTS keep_releases integer,
+    ADD CONSTRAINT project_retention_policies_keep_releases_check CHECK (COALESCE(keep_releases, 1) > 0);
+
+COMMENT ON COLUMN public.project_retention_policies.keep_releases IS 'Releases kept per service besides those of active deployments; NULL uses the default';
+
+ALTER TABLE public.releases
+    ADD COLUMN IF NOT EXISTS pruned_at timestamp with time zone;
+
+COMMENT ON COLUMN public.releases.pruned_at IS 'When the release was pruned: its image was deleted and its finished deployments removed';
+
+-- Pruning ranks the releases of a service newest first
+CREATE INDEX IF NOT EXISTS idx_releases_service_created ON public.releases USING btree (service_id, created_at DESC);
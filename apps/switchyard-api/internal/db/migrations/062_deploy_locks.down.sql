@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS public.idx_deployments_environment_pending;
+
+DROP TABLE IF EXISTS public.deploy_locks;
+
+ALTER TABLE public.environments
+    DROP CONSTRAINT IF EXISTS environments_deploy_concurrency_check,
+    DROP COLUMN IF EXISTS deploy_concurrency;
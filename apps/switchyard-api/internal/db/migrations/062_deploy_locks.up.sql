@@ -0,0 +1,27 @@
+-- Deploy locks: one reconciliation of a service in an environment at a time,
+-- and what a new deployment does about pending ones of the same service
+
+ALTER TABLE public.environments
+    ADD COLUMN IF NOT EXISTS deploy_concurrency character varying(16) DEFAULT 'supersede' NOT NULL,
+    ADD CONSTRAINT environments_deploy_concurrency_check CHECK (deploy_concurrency IN ('supersede', 'queue'));
+
+COMMENT ON COLUMN public.environments.deploy_concurrency IS 'supersede: the newest pending deployment of a service wins and older ones are superseded; queue: pending deployments roll out oldest first';
+
+CREATE TABLE IF NOT EXISTS public.deploy_locks (
+    service_id uuid NOT NULL,
+    environment_id uuid NOT NULL,
+    deployment_id uuid NOT NULL,
+    owner character varying(255) NOT NULL,
+    locked_until timestamp with time zone NOT NULL,
+    acquired_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT deploy_locks_pkey PRIMARY KEY (service_id, environment_id),
+    CONSTRAINT deploy_locks_service_id_fkey FOREIGN KEY (service_id) REFERENCES public.services(id) ON DELETE CASCADE,
+    CONSTRAINT deploy_locks_environment_id_fkey FOREIGN KEY (environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT deploy_locks_deployment_id_fkey FOREIGN KEY (deployment_id) REFERENCES public.deployments(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.deploy_locks IS 'Held while a replica reconciles a deployment of the service in the environment; expired locks are free';
+COMMENT ON COLUMN public.deploy_locks.owner IS 'Replica holding the lock';
+
+-- Concurrency checks look up the pending deployments of an environment
+CREATE INDEX IF NOT EXISTS idx_deployments_environment_pending ON public.deployments USING btree (environment_id, created_at) WHERE ((status)::text = 'pending'::text);
@@ -0,0 +1,17 @@
+-- Deployment phases: which step of its rollout a deployment reached, and when
+
+CREATE TABLE IF NOT EXISTS public.deployment_phases (
+    deployment_id uuid NOT NULL,
+    phase character varying(32) NOT NULL,
+    started_at timestamp with time zone DEFAULT now() NOT NULL,
+    finished_at timestamp with time zone,
+    ready_replicas integer DEFAULT 0 NOT NULL,
+    desired_replicas integer DEFAULT 0 NOT NULL,
+    CONSTRAINT deployment_phases_pkey PRIMARY KEY (deployment_id, phase),
+    CONSTRAINT deployment_phases_phase_check CHECK (phase IN ('image-verifying', 'applying', 'waiting-rollout', 'hooks-running', 'done')),
+    CONSTRAINT deployment_phases_deployment_id_fkey FOREIGN KEY (deployment_id) REFERENCES public.deployments(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.deployment_phases IS 'Phases a deployment entered; a deployment without any is queued. A phase entered again on a retry keeps its latest times';
+COMMENT ON COLUMN public.deployment_phases.finished_at IS 'NULL while the deployment is in the phase';
+COMMENT ON COLUMN public.deployment_phases.ready_replicas IS 'Ready replicas of the new rollout, kept for waiting-rollout';
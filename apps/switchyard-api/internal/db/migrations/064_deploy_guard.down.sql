@@ -0,0 +1 @@
+ALTER TABLE public.environments DROP COLUMN IF EXISTS deploy_guard_disabled;
@@ -0,0 +1,7 @@
+-- Deploy guard: environments without a soak policy still watch new
+-- deployments for a while and roll back the ones that crash, unless opted out
+
+ALTER TABLE public.environments
+    ADD COLUMN IF NOT EXISTS deploy_guard_disabled boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN public.environments.deploy_guard_disabled IS 'Opts out of the default post-deploy guard; a soak policy of the environment applies either way';
@@ -0,0 +1,2 @@
+ALTER TABLE public.releases DROP COLUMN IF EXISTS deploy_env;
+ALTER TABLE public.services DROP COLUMN IF EXISTS auto_deploy_rules;
@@ -0,0 +1,12 @@
+-- Auto-deploy rules: per-service branch and tag filters and ignore paths for
+-- push builds, and where builds of tag pushes deploy
+
+ALTER TABLE public.services
+    ADD COLUMN IF NOT EXISTS auto_deploy_rules jsonb;
+
+COMMENT ON COLUMN public.services.auto_deploy_rules IS 'Which pushes build and deploy the service, e.g. {"mode": "auto", "branches": ["main", "release/*"], "ignore_paths": ["docs/**", "*.md"], "tags": [{"pattern": "v*", "environment": "production"}]}; NULL builds pushes to main and master';
+
+ALTER TABLE public.releases
+    ADD COLUMN IF NOT EXISTS deploy_env character varying(255);
+
+COMMENT ON COLUMN public.releases.deploy_env IS 'Environment a build of a tag push deploys to, over the auto-deploy environment of the service';
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS public.release_promotions;
+ALTER TABLE public.environments
+    DROP CONSTRAINT IF EXISTS environments_promotes_to_check,
+    DROP CONSTRAINT IF EXISTS environments_promotes_to_fkey,
+    DROP COLUMN IF EXISTS auto_promote,
+    DROP COLUMN IF EXISTS promotes_to;
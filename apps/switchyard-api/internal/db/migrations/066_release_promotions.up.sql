@@ -0,0 +1,35 @@
+-- Release promotions: environments form a pipeline (dev -> staging -> prod)
+-- that releases validated in one environment are promoted along
+
+ALTER TABLE public.environments
+    ADD COLUMN IF NOT EXISTS promotes_to uuid,
+    ADD COLUMN IF NOT EXISTS auto_promote boolean DEFAULT false NOT NULL,
+    ADD CONSTRAINT environments_promotes_to_fkey FOREIGN KEY (promotes_to) REFERENCES public.environments(id) ON DELETE SET NULL,
+    ADD CONSTRAINT environments_promotes_to_check CHECK (promotes_to <> id);
+
+COMMENT ON COLUMN public.environments.promotes_to IS 'Next environment of the promotion pipeline; NULL at its end';
+COMMENT ON COLUMN public.environments.auto_promote IS 'Promotes releases to promotes_to once they pass their soak here';
+
+CREATE TABLE IF NOT EXISTS public.release_promotions (
+    id uuid NOT NULL,
+    release_id uuid NOT NULL,
+    from_environment_id uuid NOT NULL,
+    to_environment_id uuid NOT NULL,
+    deployment_id uuid,
+    image_uri text NOT NULL,
+    image_digest character varying(255),
+    auto boolean DEFAULT false NOT NULL,
+    promoted_by character varying(255),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT release_promotions_pkey PRIMARY KEY (id),
+    CONSTRAINT release_promotions_release_id_fkey FOREIGN KEY (release_id) REFERENCES public.releases(id) ON DELETE CASCADE,
+    CONSTRAINT release_promotions_from_environment_id_fkey FOREIGN KEY (from_environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT release_promotions_to_environment_id_fkey FOREIGN KEY (to_environment_id) REFERENCES public.environments(id) ON DELETE CASCADE,
+    CONSTRAINT release_promotions_deployment_id_fkey FOREIGN KEY (deployment_id) REFERENCES public.deployments(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_release_promotions_release_id ON public.release_promotions USING btree (release_id, created_at);
+
+COMMENT ON TABLE public.release_promotions IS 'Promotions of releases between environments; those of a release form its promotion chain';
+COMMENT ON COLUMN public.release_promotions.image_digest IS 'Digest of the promoted image, when the build or image release reported one';
+COMMENT ON COLUMN public.release_promotions.promoted_by IS 'Email of the user who promoted the release; NULL for automatic promotions';
@@ -0,0 +1,9 @@
+-- Lock two-factor verification after repeated wrong codes, so a stolen
+-- password cannot be paired with guessed TOTP codes
+
+ALTER TABLE public.user_two_factor
+    ADD COLUMN IF NOT EXISTS failed_attempts integer DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS locked_until timestamp with time zone;
+
+COMMENT ON COLUMN public.user_two_factor.failed_attempts IS 'Wrong codes since the last accepted one or the last lockout';
+COMMENT ON COLUMN public.user_two_factor.locked_until IS 'No code is accepted before this time';
@@ -0,0 +1 @@
+ALTER TABLE public.status_pages DROP COLUMN IF EXISTS domain_verified_at;
@@ -0,0 +1,6 @@
+-- Serve status pages at custom domains only once their owner proved control
+-- of the domain with a TXT record
+
+ALTER TABLE public.status_pages ADD COLUMN IF NOT EXISTS domain_verified_at timestamp with time zone;
+
+COMMENT ON COLUMN public.status_pages.domain_verified_at IS 'When the TXT record of custom_domain was checked; the page is only served and routed there after';
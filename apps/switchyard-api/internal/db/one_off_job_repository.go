@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// OneOffJobRepository handles commands run once in a service's deployed image
+type OneOffJobRepository struct {
+	db DBTX
+}
+
+func NewOneOffJobRepository(db DBTX) *OneOffJobRepository {
+	return &OneOffJobRepository{db: db}
+}
+
+const oneOffJobColumns = `
+	id, service_id, environment_id, command, status, exit_code, message, image,
+	k8s_namespace, k8s_job_name, timeout_seconds, created_by, created_at, started_at, finished_at
+`
+
+// Create inserts a new one-off job
+func (r *OneOffJobRepository) Create(ctx context.Context, job *types.OneOffJob) error {
+	if job.ID == uuid.Nil {
+		job.ID = uuid.New()
+	}
+	if job.Status == "" {
+		job.Status = types.OneOffJobStatusPending
+	}
+	job.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO one_off_jobs (
+			id, service_id, environment_id, command, status, k8s_namespace, k8s_job_name,
+			timeout_seconds, created_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		job.ID, job.ServiceID, job.EnvironmentID, pq.Array(job.Command), job.Status,
+		job.K8sNamespace, job.K8sJobName, job.TimeoutSeconds, job.CreatedBy, job.CreatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a one-off job
+func (r *OneOffJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.OneOffJob, error) {
+	query := `SELECT ` + oneOffJobColumns + ` FROM one_off_jobs WHERE id = $1`
+	return scanOneOffJob(r.db.QueryRowContext(ctx, query, id))
+}
+
+// UpdateStatus records the progress of a job
+func (r *OneOffJobRepository) UpdateStatus(ctx context.Context, job *types.OneOffJob) error {
+	query := `
+		UPDATE one_off_jobs
+		SET status = $1, exit_code = $2, message = NULLIF($3, ''), image = NULLIF($4, ''),
+			started_at = $5, finished_at = $6
+		WHERE id = $7
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		job.Status, job.ExitCode, job.Message, job.Image, job.StartedAt, job.FinishedAt, job.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanOneOffJob(row interface{ Scan(...interface{}) error }) (*types.OneOffJob, error) {
+	job := &types.OneOffJob{}
+	var exitCode sql.NullInt64
+	var message, image, createdBy sql.NullString
+	var startedAt, finishedAt sql.NullTime
+	err := row.Scan(
+		&job.ID, &job.ServiceID, &job.EnvironmentID, pq.Array(&job.Command), &job.Status,
+		&exitCode, &message, &image, &job.K8sNamespace, &job.K8sJobName, &job.TimeoutSeconds,
+		&createdBy, &job.CreatedAt, &startedAt, &finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if exitCode.Valid {
+		code := int(exitCode.Int64)
+		job.ExitCode = &code
+	}
+	job.Message = message.String
+	job.Image = image.String
+	job.CreatedBy = createdBy.String
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
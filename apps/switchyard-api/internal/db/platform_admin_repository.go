@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// PlatformAdminRepository reads across all teams and projects for platform
+// operators
+type PlatformAdminRepository struct {
+	db DBTX
+	readRouter
+}
+
+func NewPlatformAdminRepository(db DBTX) *PlatformAdminRepository {
+	return &PlatformAdminRepository{db: db}
+}
+
+// Team suspension filters of ListTeams, given as ListParams.Status
+const (
+	TeamStatusActive    = "active"
+	TeamStatusSuspended = "suspended"
+)
+
+var adminTeamListColumns = listColumns{
+	id:      "t.id",
+	sorts:   map[string]string{"name": "t.name", "created_at": "t.created_at"},
+	created: "t.created_at",
+}
+
+// ListTeams returns a page of all teams with their member and live project
+// counts. params.Status filters on TeamStatusActive or TeamStatusSuspended.
+func (r *PlatformAdminRepository) ListTeams(ctx context.Context, params ListParams) ([]*types.AdminTeam, error) {
+	var conds []string
+	switch params.Status {
+	case TeamStatusActive:
+		conds = append(conds, "t.suspended_at IS NULL")
+	case TeamStatusSuspended:
+		conds = append(conds, "t.suspended_at IS NOT NULL")
+	}
+	where, args := params.where(adminTeamListColumns, conds, nil)
+
+	query := `
+		SELECT t.id, t.name, t.slug,
+			(SELECT COUNT(*) FROM team_members tm WHERE tm.team_id = t.id),
+			(SELECT COUNT(*) FROM projects p WHERE p.team_id = t.id AND p.deleted_at IS NULL),
+			t.suspended_at, COALESCE(t.suspension_reason, ''), COALESCE(t.suspended_by, ''),
+			t.created_at
+		FROM teams t` + where
+	rows, err := r.reader(r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []*types.AdminTeam
+	for rows.Next() {
+		team := &types.AdminTeam{}
+		var suspendedAt sql.NullTime
+		var reason, suspendedBy string
+		err := rows.Scan(&team.ID, &team.Name, &team.Slug, &team.Members, &team.Projects,
+			&suspendedAt, &reason, &suspendedBy, &team.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if suspendedAt.Valid {
+			team.Suspension = &types.TeamSuspension{
+				SuspendedAt: suspendedAt.Time,
+				Reason:      reason,
+				SuspendedBy: suspendedBy,
+			}
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
+}
+
+var adminProjectListColumns = listColumns{
+	id:      "p.id",
+	sorts:   map[string]string{"name": "p.name", "created_at": "p.created_at"},
+	created: "p.created_at",
+	deleted: "p.deleted_at",
+}
+
+// ListProjects returns a page of all live projects, or of those of one team
+// when teamID is set, with their team and live service count
+func (r *PlatformAdminRepository) ListProjects(ctx context.Context, teamID *uuid.UUID, params ListParams) ([]*types.AdminProject, error) {
+	var conds []string
+	var args []any
+	if teamID != nil {
+		conds = append(conds, "p.team_id = $1")
+		args = append(args, *teamID)
+	}
+	where, args := params.where(adminProjectListColumns, conds, args)
+
+	query := `
+		SELECT p.id, p.name, p.slug, p.team_id, COALESCE(t.slug, ''),
+			(SELECT COUNT(*) FROM services s WHERE s.project_id = p.id AND s.deleted_at IS NULL),
+			t.suspended_at IS NOT NULL,
+			p.created_at
+		FROM projects p
+		LEFT JOIN teams t ON t.id = p.team_id` + where
+	rows, err := r.reader(r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []*types.AdminProject
+	for rows.Next() {
+		project := &types.AdminProject{}
+		var teamID uuid.NullUUID
+		err := rows.Scan(&project.ID, &project.Name, &project.Slug, &teamID, &project.TeamSlug,
+			&project.Services, &project.TeamSuspended, &project.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if teamID.Valid {
+			project.TeamID = &teamID.UUID
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+// Metrics counts what the platform holds, the build jobs in the queue, and
+// the build jobs that finished since a time. The window of the result is
+// left for the caller to name.
+func (r *PlatformAdminRepository) Metrics(ctx context.Context, since time.Time) (*types.PlatformMetrics, error) {
+	metrics := &types.PlatformMetrics{GeneratedAt: time.Now()}
+
+	err := r.reader(r.db).QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM teams),
+			(SELECT COUNT(*) FROM teams WHERE suspended_at IS NOT NULL),
+			(SELECT COUNT(*) FROM projects WHERE deleted_at IS NULL),
+			(SELECT COUNT(*) FROM services s JOIN projects p ON p.id = s.project_id
+				WHERE s.deleted_at IS NULL AND p.deleted_at IS NULL)
+	`).Scan(&metrics.Teams, &metrics.SuspendedTeams, &metrics.Projects, &metrics.Services)
+	if err != nil {
+		return nil, err
+	}
+
+	var oldest sql.NullTime
+	err = r.reader(r.db).QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'queued'),
+			COUNT(*) FILTER (WHERE status = 'building'),
+			MIN(queued_at) FILTER (WHERE status = 'queued')
+		FROM build_jobs
+		WHERE status IN ('queued', 'building')
+	`).Scan(&metrics.Queue.Queued, &metrics.Queue.Building, &oldest)
+	if err != nil {
+		return nil, err
+	}
+	if oldest.Valid {
+		metrics.Queue.OldestQueuedAt = &oldest.Time
+	}
+
+	builds := &metrics.Builds
+	err = r.reader(r.db).QueryRowContext(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'completed'),
+			COUNT(*) FILTER (WHERE status = 'failed'),
+			COUNT(*) FILTER (WHERE status = 'cancelled'),
+			COALESCE(AVG(duration_seconds), 0),
+			COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY duration_seconds), 0),
+			COALESCE(AVG(EXTRACT(EPOCH FROM started_at - queued_at)), 0)
+		FROM build_jobs
+		WHERE status IN ('completed', 'failed', 'cancelled') AND completed_at >= $1
+	`, since).Scan(&builds.Completed, &builds.Failed, &builds.Cancelled,
+		&builds.AvgDurationSeconds, &builds.P95DurationSeconds, &builds.AvgQueueWaitSeconds)
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
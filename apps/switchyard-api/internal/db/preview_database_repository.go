@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// PreviewDatabaseRepository handles per-service preview database configs and
+// the ephemeral databases created from them
+type PreviewDatabaseRepository struct {
+	db DBTX
+}
+
+func NewPreviewDatabaseRepository(db DBTX) *PreviewDatabaseRepository {
+	return &PreviewDatabaseRepository{db: db}
+}
+
+// NewPreviewDatabaseRepositoryWithTx creates a repository using a transaction
+func NewPreviewDatabaseRepositoryWithTx(tx DBTX) *PreviewDatabaseRepository {
+	return &PreviewDatabaseRepository{db: tx}
+}
+
+const previewDatabaseColumns = `
+	id, preview_id, addon_id, mode, env_var_name, status, status_message,
+	restore_id, created_at, updated_at, ready_at
+`
+
+// GetConfig retrieves the preview database config of a service
+func (r *PreviewDatabaseRepository) GetConfig(ctx context.Context, serviceID uuid.UUID) (*types.PreviewDatabaseConfig, error) {
+	query := `
+		SELECT service_id, template_addon_id, mode, backup_id, seed_command, env_var_name, created_at, updated_at
+		FROM preview_database_configs
+		WHERE service_id = $1
+	`
+
+	config := &types.PreviewDatabaseConfig{}
+	var backupID uuid.NullUUID
+	var seedCommand sql.NullString
+	err := r.db.QueryRowContext(ctx, query, serviceID).Scan(
+		&config.ServiceID, &config.TemplateAddonID, &config.Mode, &backupID, &seedCommand,
+		&config.EnvVarName, &config.CreatedAt, &config.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if backupID.Valid {
+		config.BackupID = &backupID.UUID
+	}
+	config.SeedCommand = seedCommand.String
+
+	return config, nil
+}
+
+// UpsertConfig creates or replaces the preview database config of a service.
+// Previews that already have a database keep it.
+func (r *PreviewDatabaseRepository) UpsertConfig(ctx context.Context, config *types.PreviewDatabaseConfig) error {
+	now := time.Now()
+	config.UpdatedAt = now
+
+	query := `
+		INSERT INTO preview_database_configs (
+			service_id, template_addon_id, mode, backup_id, seed_command, env_var_name, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $7)
+		ON CONFLICT (service_id) DO UPDATE SET
+			template_addon_id = EXCLUDED.template_addon_id,
+			mode = EXCLUDED.mode,
+			backup_id = EXCLUDED.backup_id,
+			seed_command = EXCLUDED.seed_command,
+			env_var_name = EXCLUDED.env_var_name,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		config.ServiceID, config.TemplateAddonID, config.Mode, config.BackupID,
+		config.SeedCommand, config.EnvVarName, now,
+	).Scan(&config.CreatedAt)
+}
+
+// DeleteConfig removes the preview database config of a service
+func (r *PreviewDatabaseRepository) DeleteConfig(ctx context.Context, serviceID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM preview_database_configs WHERE service_id = $1`, serviceID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Create records the ephemeral database of a preview
+func (r *PreviewDatabaseRepository) Create(ctx context.Context, database *types.PreviewDatabase) error {
+	if database.ID == uuid.Nil {
+		database.ID = uuid.New()
+	}
+	database.CreatedAt = time.Now()
+	database.UpdatedAt = database.CreatedAt
+
+	query := `
+		INSERT INTO preview_databases (
+			id, preview_id, addon_id, mode, env_var_name, status, status_message, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		database.ID, database.PreviewID, database.AddonID, database.Mode, database.EnvVarName,
+		database.Status, database.StatusMessage, database.CreatedAt, database.UpdatedAt,
+	)
+	return err
+}
+
+// GetByPreview retrieves the database of a preview
+func (r *PreviewDatabaseRepository) GetByPreview(ctx context.Context, previewID uuid.UUID) (*types.PreviewDatabase, error) {
+	query := `SELECT ` + previewDatabaseColumns + ` FROM preview_databases WHERE preview_id = $1`
+	return scanPreviewDatabase(r.db.QueryRowContext(ctx, query, previewID))
+}
+
+// ListInProgress retrieves preview databases that are still being provisioned or filled
+func (r *PreviewDatabaseRepository) ListInProgress(ctx context.Context) ([]*types.PreviewDatabase, error) {
+	query := `
+		SELECT ` + previewDatabaseColumns + `
+		FROM preview_databases
+		WHERE status IN ('provisioning', 'restoring', 'seeding')
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var databases []*types.PreviewDatabase
+	for rows.Next() {
+		database, err := scanPreviewDatabase(rows)
+		if err != nil {
+			return nil, err
+		}
+		databases = append(databases, database)
+	}
+
+	return databases, rows.Err()
+}
+
+// Update saves the status and restore of a preview database. ready_at is set
+// the first time it becomes ready.
+func (r *PreviewDatabaseRepository) Update(ctx context.Context, database *types.PreviewDatabase) error {
+	database.UpdatedAt = time.Now()
+	if database.Status == types.PreviewDatabaseStatusReady && database.ReadyAt == nil {
+		database.ReadyAt = &database.UpdatedAt
+	}
+
+	query := `
+		UPDATE preview_databases
+		SET status = $1, status_message = $2, restore_id = $3, ready_at = $4, updated_at = $5
+		WHERE id = $6
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		database.Status, database.StatusMessage, database.RestoreID, database.ReadyAt, database.UpdatedAt, database.ID,
+	)
+	return err
+}
+
+// Delete removes the record of a preview database
+func (r *PreviewDatabaseRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM preview_databases WHERE id = $1`, id)
+	return err
+}
+
+// scanPreviewDatabase scans a preview database from a row
+func scanPreviewDatabase(row interface{ Scan(...interface{}) error }) (*types.PreviewDatabase, error) {
+	database := &types.PreviewDatabase{}
+	var statusMessage sql.NullString
+	var restoreID uuid.NullUUID
+	var readyAt sql.NullTime
+
+	err := row.Scan(
+		&database.ID, &database.PreviewID, &database.AddonID, &database.Mode, &database.EnvVarName,
+		&database.Status, &statusMessage, &restoreID, &database.CreatedAt, &database.UpdatedAt, &readyAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	database.StatusMessage = statusMessage.String
+	if restoreID.Valid {
+		database.RestoreID = &restoreID.UUID
+	}
+	if readyAt.Valid {
+		database.ReadyAt = &readyAt.Time
+	}
+
+	return database, nil
+}
@@ -59,7 +59,7 @@ func (r *PreviewEnvironmentRepository) GetByID(ctx context.Context, id uuid.UUID
 		SELECT id, project_id, service_id, pr_number, pr_title, pr_url, pr_author,
 		       pr_branch, pr_base_branch, commit_sha, preview_subdomain, preview_url,
 		       status, status_message, auto_sleep_after, last_accessed_at, sleeping_since,
-		       deployment_id, build_logs_url, created_at, updated_at, closed_at
+		       deployment_id, build_logs_url, created_at, updated_at, closed_at, cleaned_up_at
 		FROM preview_environments
 		WHERE id = $1
 	`
@@ -74,7 +74,7 @@ func (r *PreviewEnvironmentRepository) GetByID(ctx context.Context, id uuid.UUID
 		&preview.CommitSHA, &preview.PreviewSubdomain, &preview.PreviewURL,
 		&preview.Status, &statusMessage, &preview.AutoSleepAfter, &lastAccessedAt,
 		&sleepingSince, &deploymentID, &buildLogsURL, &preview.CreatedAt,
-		&preview.UpdatedAt, &closedAt,
+		&preview.UpdatedAt, &closedAt, &preview.CleanedUpAt,
 	)
 	if err != nil {
 		return nil, err
@@ -120,7 +120,7 @@ func (r *PreviewEnvironmentRepository) GetByServiceAndPR(ctx context.Context, se
 		SELECT id, project_id, service_id, pr_number, pr_title, pr_url, pr_author,
 		       pr_branch, pr_base_branch, commit_sha, preview_subdomain, preview_url,
 		       status, status_message, auto_sleep_after, last_accessed_at, sleeping_since,
-		       deployment_id, build_logs_url, created_at, updated_at, closed_at
+		       deployment_id, build_logs_url, created_at, updated_at, closed_at, cleaned_up_at
 		FROM preview_environments
 		WHERE service_id = $1 AND pr_number = $2
 	`
@@ -135,7 +135,7 @@ func (r *PreviewEnvironmentRepository) GetByServiceAndPR(ctx context.Context, se
 		&preview.CommitSHA, &preview.PreviewSubdomain, &preview.PreviewURL,
 		&preview.Status, &statusMessage, &preview.AutoSleepAfter, &lastAccessedAt,
 		&sleepingSince, &deploymentID, &buildLogsURL, &preview.CreatedAt,
-		&preview.UpdatedAt, &closedAt,
+		&preview.UpdatedAt, &closedAt, &preview.CleanedUpAt,
 	)
 	if err != nil {
 		return nil, err
@@ -173,13 +173,35 @@ func (r *PreviewEnvironmentRepository) GetByServiceAndPR(ctx context.Context, se
 	return preview, nil
 }
 
+// GetByDeployment retrieves the preview environment a deployment belongs to
+func (r *PreviewEnvironmentRepository) GetByDeployment(ctx context.Context, deploymentID uuid.UUID) (*types.PreviewEnvironment, error) {
+	query := `
+		SELECT id, project_id, service_id, pr_number, pr_title, pr_url, pr_author,
+		       pr_branch, pr_base_branch, commit_sha, preview_subdomain, preview_url,
+		       status, status_message, auto_sleep_after, last_accessed_at, sleeping_since,
+		       deployment_id, build_logs_url, created_at, updated_at, closed_at, cleaned_up_at
+		FROM preview_environments
+		WHERE deployment_id = $1
+		LIMIT 1
+	`
+
+	previews, err := r.queryPreviews(ctx, query, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(previews) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return previews[0], nil
+}
+
 // ListByService retrieves all preview environments for a service
 func (r *PreviewEnvironmentRepository) ListByService(ctx context.Context, serviceID uuid.UUID) ([]*types.PreviewEnvironment, error) {
 	query := `
 		SELECT id, project_id, service_id, pr_number, pr_title, pr_url, pr_author,
 		       pr_branch, pr_base_branch, commit_sha, preview_subdomain, preview_url,
 		       status, status_message, auto_sleep_after, last_accessed_at, sleeping_since,
-		       deployment_id, build_logs_url, created_at, updated_at, closed_at
+		       deployment_id, build_logs_url, created_at, updated_at, closed_at, cleaned_up_at
 		FROM preview_environments
 		WHERE service_id = $1
 		ORDER BY created_at DESC
@@ -188,13 +210,39 @@ func (r *PreviewEnvironmentRepository) ListByService(ctx context.Context, servic
 	return r.queryPreviews(ctx, query, serviceID)
 }
 
+const previewColumns = `
+		SELECT id, project_id, service_id, pr_number, pr_title, pr_url, pr_author,
+		       pr_branch, pr_base_branch, commit_sha, preview_subdomain, preview_url,
+		       status, status_message, auto_sleep_after, last_accessed_at, sleeping_since,
+		       deployment_id, build_logs_url, created_at, updated_at, closed_at, cleaned_up_at
+		FROM preview_environments`
+
+var previewListColumns = listColumns{
+	id:      "id",
+	sorts:   map[string]string{"created_at": "created_at", "updated_at": "updated_at"},
+	status:  "status",
+	created: "created_at",
+}
+
+// ListPageByService retrieves a page of the preview environments of a service
+func (r *PreviewEnvironmentRepository) ListPageByService(ctx context.Context, serviceID uuid.UUID, params ListParams) ([]*types.PreviewEnvironment, error) {
+	where, args := params.where(previewListColumns, []string{"service_id = $1"}, []any{serviceID})
+	return r.queryPreviews(ctx, previewColumns+where, args...)
+}
+
+// ListPageByProject retrieves a page of the preview environments of a project
+func (r *PreviewEnvironmentRepository) ListPageByProject(ctx context.Context, projectID uuid.UUID, params ListParams) ([]*types.PreviewEnvironment, error) {
+	where, args := params.where(previewListColumns, []string{"project_id = $1"}, []any{projectID})
+	return r.queryPreviews(ctx, previewColumns+where, args...)
+}
+
 // ListByProject retrieves all preview environments for a project
 func (r *PreviewEnvironmentRepository) ListByProject(ctx context.Context, projectID uuid.UUID) ([]*types.PreviewEnvironment, error) {
 	query := `
 		SELECT id, project_id, service_id, pr_number, pr_title, pr_url, pr_author,
 		       pr_branch, pr_base_branch, commit_sha, preview_subdomain, preview_url,
 		       status, status_message, auto_sleep_after, last_accessed_at, sleeping_since,
-		       deployment_id, build_logs_url, created_at, updated_at, closed_at
+		       deployment_id, build_logs_url, created_at, updated_at, closed_at, cleaned_up_at
 		FROM preview_environments
 		WHERE project_id = $1
 		ORDER BY created_at DESC
@@ -209,7 +257,7 @@ func (r *PreviewEnvironmentRepository) ListActive(ctx context.Context) ([]*types
 		SELECT id, project_id, service_id, pr_number, pr_title, pr_url, pr_author,
 		       pr_branch, pr_base_branch, commit_sha, preview_subdomain, preview_url,
 		       status, status_message, auto_sleep_after, last_accessed_at, sleeping_since,
-		       deployment_id, build_logs_url, created_at, updated_at, closed_at
+		       deployment_id, build_logs_url, created_at, updated_at, closed_at, cleaned_up_at
 		FROM preview_environments
 		WHERE status IN ('pending', 'building', 'deploying', 'active', 'sleeping')
 		ORDER BY created_at DESC
@@ -224,7 +272,7 @@ func (r *PreviewEnvironmentRepository) ListSleepCandidates(ctx context.Context)
 		SELECT id, project_id, service_id, pr_number, pr_title, pr_url, pr_author,
 		       pr_branch, pr_base_branch, commit_sha, preview_subdomain, preview_url,
 		       status, status_message, auto_sleep_after, last_accessed_at, sleeping_since,
-		       deployment_id, build_logs_url, created_at, updated_at, closed_at
+		       deployment_id, build_logs_url, created_at, updated_at, closed_at, cleaned_up_at
 		FROM preview_environments
 		WHERE status = 'active'
 		  AND auto_sleep_after > 0
@@ -235,6 +283,38 @@ func (r *PreviewEnvironmentRepository) ListSleepCandidates(ctx context.Context)
 	return r.queryPreviews(ctx, query)
 }
 
+// ListCleanupCandidates retrieves closed previews that have outlived their
+// project's TTL and have not been torn down yet. Projects without a TTL use
+// defaultTTLDays. A nil projectID lists candidates across all projects.
+func (r *PreviewEnvironmentRepository) ListCleanupCandidates(ctx context.Context, defaultTTLDays int, projectID *uuid.UUID) ([]*types.PreviewEnvironment, error) {
+	query := `
+		SELECT pe.id, pe.project_id, pe.service_id, pe.pr_number, pe.pr_title, pe.pr_url, pe.pr_author,
+		       pe.pr_branch, pe.pr_base_branch, pe.commit_sha, pe.preview_subdomain, pe.preview_url,
+		       pe.status, pe.status_message, pe.auto_sleep_after, pe.last_accessed_at, pe.sleeping_since,
+		       pe.deployment_id, pe.build_logs_url, pe.created_at, pe.updated_at, pe.closed_at, pe.cleaned_up_at
+		FROM preview_environments pe
+		JOIN projects p ON p.id = pe.project_id
+		WHERE pe.status = 'closed'
+		  AND pe.cleaned_up_at IS NULL
+		  AND pe.closed_at < NOW() - make_interval(days => COALESCE(p.preview_ttl_days, $1))
+		  AND ($2::uuid IS NULL OR pe.project_id = $2)
+		ORDER BY pe.closed_at ASC
+	`
+
+	return r.queryPreviews(ctx, query, defaultTTLDays, projectID)
+}
+
+// MarkCleanedUp records that a closed preview has been torn down
+func (r *PreviewEnvironmentRepository) MarkCleanedUp(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE preview_environments
+		SET cleaned_up_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
 // UpdateStatus updates the status of a preview environment
 func (r *PreviewEnvironmentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status types.PreviewEnvironmentStatus, message string) error {
 	query := `
@@ -317,7 +397,7 @@ func (r *PreviewEnvironmentRepository) Wake(ctx context.Context, id uuid.UUID) e
 func (r *PreviewEnvironmentRepository) Close(ctx context.Context, id uuid.UUID) error {
 	query := `
 		UPDATE preview_environments
-		SET status = 'closed', closed_at = NOW(), updated_at = NOW()
+		SET status = 'closed', closed_at = NOW(), cleaned_up_at = NULL, updated_at = NOW()
 		WHERE id = $1
 	`
 	_, err := r.db.ExecContext(ctx, query, id)
@@ -364,7 +444,7 @@ func (r *PreviewEnvironmentRepository) queryPreviews(ctx context.Context, query
 			&preview.CommitSHA, &preview.PreviewSubdomain, &preview.PreviewURL,
 			&preview.Status, &statusMessage, &preview.AutoSleepAfter, &lastAccessedAt,
 			&sleepingSince, &deploymentID, &buildLogsURL, &preview.CreatedAt,
-			&preview.UpdatedAt, &closedAt,
+			&preview.UpdatedAt, &closedAt, &preview.CleanedUpAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan preview: %w", err)
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ProjectEventRepository stores the event history of projects
+type ProjectEventRepository struct {
+	db DBTX
+	readRouter
+}
+
+func NewProjectEventRepository(db DBTX) *ProjectEventRepository {
+	return &ProjectEventRepository{db: db}
+}
+
+var projectEventListColumns = listColumns{
+	id:      "id",
+	sorts:   map[string]string{"created_at": "created_at"},
+	created: "created_at",
+}
+
+// Create records an event of a project
+func (r *ProjectEventRepository) Create(ctx context.Context, event *types.ProjectEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	dataJSON, err := marshalEventData(event.Data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO project_events (id, project_id, type, resource_type, resource_id, actor_id, actor_email, message, data, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, NULLIF($7, ''), $8, $9, $10)
+		ON CONFLICT (id) DO NOTHING
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		event.ID, event.ProjectID, event.Type, event.ResourceType, event.ResourceID,
+		event.ActorID, event.ActorEmail, event.Message, dataJSON, event.CreatedAt,
+	)
+	return err
+}
+
+// CreateForTeam records an event in each project of a team, e.g. when a
+// member joins it
+func (r *ProjectEventRepository) CreateForTeam(ctx context.Context, teamID uuid.UUID, event *types.ProjectEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	dataJSON, err := marshalEventData(event.Data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO project_events (project_id, type, resource_type, resource_id, actor_id, actor_email, message, data, created_at)
+		SELECT id, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''), $7, $8, $9
+		FROM projects
+		WHERE team_id = $1
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		teamID, event.Type, event.ResourceType, event.ResourceID,
+		event.ActorID, event.ActorEmail, event.Message, dataJSON, event.CreatedAt,
+	)
+	return err
+}
+
+// ListByProject returns a page of the events of a project. eventTypes are
+// types such as deployment.failed, or categories such as deployment that
+// match every type starting with them; none matches all events.
+func (r *ProjectEventRepository) ListByProject(ctx context.Context, projectID uuid.UUID, eventTypes []string, params ListParams) ([]*types.ProjectEvent, error) {
+	conds := []string{"project_id = $1"}
+	args := []any{projectID}
+	if len(eventTypes) > 0 {
+		conds = append(conds, "(type = ANY($2) OR split_part(type, '.', 1) = ANY($2))")
+		args = append(args, pq.Array(eventTypes))
+	}
+	where, args := params.where(projectEventListColumns, conds, args)
+
+	query := `
+		SELECT id, project_id, type, COALESCE(resource_type, ''), resource_id,
+		       actor_id, COALESCE(actor_email, ''), message, data, created_at
+		FROM project_events` + where
+
+	rows, err := r.reader(r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*types.ProjectEvent
+	for rows.Next() {
+		event := &types.ProjectEvent{}
+		var dataJSON []byte
+		err := rows.Scan(
+			&event.ID, &event.ProjectID, &event.Type, &event.ResourceType, &event.ResourceID,
+			&event.ActorID, &event.ActorEmail, &event.Message, &dataJSON, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(dataJSON, &event.Data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func marshalEventData(data map[string]any) ([]byte, error) {
+	if data == nil {
+		return []byte("{}"), nil
+	}
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+	return dataJSON, nil
+}
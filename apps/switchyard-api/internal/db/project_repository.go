@@ -6,12 +6,15 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // ProjectRepository handles project CRUD operations
 type ProjectRepository struct {
-	db DBTX
+	db    DBTX
+	cache entityCache
 }
 
 func NewProjectRepository(db DBTX) *ProjectRepository {
@@ -31,40 +34,97 @@ func (r *ProjectRepository) Create(project *types.Project) error {
 	return err
 }
 
+const projectColumns = `SELECT id, name, slug, preview_ttl_days, log_redaction_disabled, deleted_at, created_at, updated_at FROM projects`
+
+// GetByID retrieves a project by ID, including a trashed one; callers check
+// DeletedAt
 func (r *ProjectRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.Project, error) {
+	key := cache.ProjectKey(id.String())
+	var cached types.Project
+	if r.cache.get(ctx, key, &cached) {
+		return &cached, nil
+	}
+
 	project := &types.Project{}
-	query := `SELECT id, name, slug, created_at, updated_at FROM projects WHERE id = $1`
+	query := projectColumns + ` WHERE id = $1`
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&project.ID, &project.Name, &project.Slug,
-		&project.CreatedAt, &project.UpdatedAt,
+		&project.ID, &project.Name, &project.Slug, &project.PreviewTTLDays, &project.LogRedactionDisabled,
+		&project.DeletedAt, &project.CreatedAt, &project.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	r.cache.set(ctx, key, project, projectCacheTTL)
 	return project, nil
 }
 
+// GetBySlug retrieves a project by slug; trashed projects are not found
 func (r *ProjectRepository) GetBySlug(slug string) (*types.Project, error) {
+	// Slugs never change, so the cache maps a slug to its project's ID and
+	// the project is read through GetByID. The ID of a project deleted or
+	// trashed since is dropped.
+	ctx := context.Background()
+	slugKey := cache.ProjectSlugKey(slug)
+	var id uuid.UUID
+	if r.cache.get(ctx, slugKey, &id) {
+		project, err := r.GetByID(ctx, id)
+		if err == nil && project.Slug == slug && project.DeletedAt == nil {
+			return project, nil
+		}
+		r.cache.forget(ctx, slugKey)
+	}
+
 	project := &types.Project{}
-	query := `SELECT id, name, slug, created_at, updated_at FROM projects WHERE slug = $1`
+	query := projectColumns + ` WHERE slug = $1 AND deleted_at IS NULL`
 
 	err := r.db.QueryRow(query, slug).Scan(
-		&project.ID, &project.Name, &project.Slug,
-		&project.CreatedAt, &project.UpdatedAt,
+		&project.ID, &project.Name, &project.Slug, &project.PreviewTTLDays, &project.LogRedactionDisabled,
+		&project.DeletedAt, &project.CreatedAt, &project.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	r.cache.set(ctx, slugKey, project.ID, projectCacheTTL)
+	r.cache.set(ctx, cache.ProjectKey(project.ID.String()), project, projectCacheTTL)
 	return project, nil
 }
 
+// GetTrashedBySlug retrieves a project in the trash by slug
+func (r *ProjectRepository) GetTrashedBySlug(ctx context.Context, slug string) (*types.Project, error) {
+	project := &types.Project{}
+	query := projectColumns + ` WHERE slug = $1 AND deleted_at IS NOT NULL`
+
+	err := r.db.QueryRowContext(ctx, query, slug).Scan(
+		&project.ID, &project.Name, &project.Slug, &project.PreviewTTLDays, &project.LogRedactionDisabled,
+		&project.DeletedAt, &project.CreatedAt, &project.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// List returns the projects that are not in the trash
 func (r *ProjectRepository) List() ([]*types.Project, error) {
-	query := `SELECT id, name, slug, created_at, updated_at FROM projects ORDER BY created_at DESC`
+	return r.queryProjects(context.Background(), projectColumns+` WHERE deleted_at IS NULL ORDER BY created_at DESC`)
+}
 
-	rows, err := r.db.Query(query)
+// ListTrashed returns the projects in the trash, most recently deleted first
+func (r *ProjectRepository) ListTrashed(ctx context.Context) ([]*types.Project, error) {
+	return r.queryProjects(ctx, projectColumns+` WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+}
+
+// ListPurgeable returns the projects trashed before a time, oldest first
+func (r *ProjectRepository) ListPurgeable(ctx context.Context, before time.Time) ([]*types.Project, error) {
+	return r.queryProjects(ctx, projectColumns+` WHERE deleted_at < $1 ORDER BY deleted_at`, before)
+}
+
+func (r *ProjectRepository) queryProjects(ctx context.Context, query string, args ...any) ([]*types.Project, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,25 +133,137 @@ func (r *ProjectRepository) List() ([]*types.Project, error) {
 	var projects []*types.Project
 	for rows.Next() {
 		project := &types.Project{}
-		err := rows.Scan(&project.ID, &project.Name, &project.Slug, &project.CreatedAt, &project.UpdatedAt)
+		err := rows.Scan(&project.ID, &project.Name, &project.Slug, &project.PreviewTTLDays, &project.LogRedactionDisabled, &project.DeletedAt, &project.CreatedAt, &project.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
 		projects = append(projects, project)
 	}
 
-	return projects, nil
+	return projects, rows.Err()
+}
+
+// Trash moves a project and its services to the trash. The services are
+// stamped with the project's deleted_at, so a restore brings back exactly
+// those and not the ones trashed on their own before.
+func (r *ProjectRepository) Trash(ctx context.Context, id uuid.UUID, deletedBy *uuid.UUID) (time.Time, error) {
+	keys := r.cacheKeys(ctx, id)
+	deletedAt := time.Now().UTC()
+	query := `
+		WITH trashed AS (
+			UPDATE projects SET deleted_at = $2, deleted_by = $3 WHERE id = $1 AND deleted_at IS NULL RETURNING id
+		), services AS (
+			UPDATE services SET deleted_at = $2, deleted_by = $3
+			WHERE project_id IN (SELECT id FROM trashed) AND deleted_at IS NULL
+		)
+		SELECT COUNT(*) FROM trashed
+	`
+	var trashed int
+	if err := r.db.QueryRowContext(ctx, query, id, deletedAt, deletedBy).Scan(&trashed); err != nil {
+		return time.Time{}, err
+	}
+	r.cache.forget(ctx, keys...)
+	if trashed == 0 {
+		return time.Time{}, sql.ErrNoRows
+	}
+	return deletedAt, nil
+}
+
+// Restore takes a project and the services trashed with it out of the trash
+func (r *ProjectRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	keys := r.cacheKeys(ctx, id)
+	// Every part of the statement sees the project's deleted_at from before
+	// the update
+	query := `
+		WITH restored AS (
+			UPDATE projects SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id
+		), services AS (
+			UPDATE services SET deleted_at = NULL, deleted_by = NULL
+			WHERE project_id IN (SELECT id FROM restored)
+			  AND deleted_at = (SELECT deleted_at FROM projects WHERE id = $1)
+		)
+		SELECT COUNT(*) FROM restored
+	`
+	var restored int
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&restored); err != nil {
+		return err
+	}
+	r.cache.forget(ctx, keys...)
+	if restored == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetPreviewTTL sets how many days closed previews of the project are kept.
+// A nil ttlDays falls back to the platform default.
+func (r *ProjectRepository) SetPreviewTTL(ctx context.Context, id uuid.UUID, ttlDays *int) error {
+	query := `UPDATE projects SET preview_ttl_days = $1, updated_at = NOW() WHERE id = $2`
+	result, err := r.db.ExecContext(ctx, query, ttlDays, id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ProjectKey(id.String()))
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetLogRedaction turns the redaction of token patterns and secret env var
+// values in the project's logs on or off
+func (r *ProjectRepository) SetLogRedaction(ctx context.Context, id uuid.UUID, enabled bool) error {
+	query := `UPDATE projects SET log_redaction_disabled = $1, updated_at = NOW() WHERE id = $2`
+	result, err := r.db.ExecContext(ctx, query, !enabled, id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ProjectKey(id.String()))
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
-// Delete removes a project by ID
+// UpdateName renames a project. The slug is its stable identifier and never
+// changes.
+func (r *ProjectRepository) UpdateName(ctx context.Context, id uuid.UUID, name string) error {
+	query := `UPDATE projects SET name = $1, updated_at = NOW() WHERE id = $2`
+	result, err := r.db.ExecContext(ctx, query, name, id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ProjectKey(id.String()))
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Delete permanently removes a project by ID
 // Note: All related records (services, environments, etc.) are automatically
 // deleted via ON DELETE CASCADE foreign key constraints
 func (r *ProjectRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	keys := r.cacheKeys(ctx, id)
 	query := `DELETE FROM projects WHERE id = $1`
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
+	r.cache.forget(ctx, keys...)
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
@@ -101,3 +273,38 @@ func (r *ProjectRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// cacheKeys returns the cache keys of a project and of its services and
+// environments, which trashing, restoring and deleting it change too. They
+// are read before the write, as a delete cascades to the rows.
+func (r *ProjectRepository) cacheKeys(ctx context.Context, id uuid.UUID) []string {
+	if !r.cache.enabled() {
+		return nil
+	}
+	keys := []string{cache.ProjectKey(id.String())}
+
+	query := `
+		SELECT 'service', id, '' FROM services WHERE project_id = $1
+		UNION ALL
+		SELECT 'environment', id, name FROM environments WHERE project_id = $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return keys
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind, name string
+		var childID uuid.UUID
+		if err := rows.Scan(&kind, &childID, &name); err != nil {
+			break
+		}
+		if kind == "service" {
+			keys = append(keys, cache.ServiceKey(childID.String()))
+		} else {
+			keys = append(keys, cache.EnvironmentKey(childID.String()), cache.ProjectEnvironmentKey(id.String(), name))
+		}
+	}
+	return keys
+}
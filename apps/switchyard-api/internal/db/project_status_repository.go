@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ProjectStatusRepository reads the summaries live project status views are
+// built from
+type ProjectStatusRepository struct {
+	db DBTX
+	readRouter
+}
+
+func NewProjectStatusRepository(db DBTX) *ProjectStatusRepository {
+	return &ProjectStatusRepository{db: db}
+}
+
+// LatestDeployments retrieves the latest deployment of every service of a
+// project in every environment it was deployed to
+func (r *ProjectStatusRepository) LatestDeployments(ctx context.Context, projectID uuid.UUID) ([]types.DeploymentStatusEntry, error) {
+	query := `
+		SELECT DISTINCT ON (rel.service_id, d.environment_id)
+			rel.service_id, e.name, d.id, rel.version, d.status, d.health, d.replicas, d.updated_at
+		FROM deployments d
+		JOIN releases rel ON d.release_id = rel.id
+		JOIN environments e ON d.environment_id = e.id
+		WHERE e.project_id = $1
+		ORDER BY rel.service_id, d.environment_id, d.created_at DESC
+	`
+
+	rows, err := r.reader(r.db).QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []types.DeploymentStatusEntry
+	for rows.Next() {
+		var entry types.DeploymentStatusEntry
+		if err := rows.Scan(
+			&entry.ServiceID, &entry.Environment, &entry.DeploymentID, &entry.Version,
+			&entry.Status, &entry.Health, &entry.Replicas, &entry.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// RecentBuilds retrieves the newest releases built for a project's services
+func (r *ProjectStatusRepository) RecentBuilds(ctx context.Context, projectID uuid.UUID, limit int) ([]types.BuildStatusEntry, error) {
+	query := `
+		SELECT rel.id, s.name, rel.version, rel.git_sha, rel.status, rel.created_at, rel.updated_at
+		FROM releases rel
+		JOIN services s ON rel.service_id = s.id
+		WHERE s.project_id = $1
+		ORDER BY rel.created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.reader(r.db).QueryContext(ctx, query, projectID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []types.BuildStatusEntry
+	for rows.Next() {
+		var entry types.BuildStatusEntry
+		if err := rows.Scan(
+			&entry.ReleaseID, &entry.ServiceName, &entry.Version, &entry.GitSHA,
+			&entry.Status, &entry.CreatedAt, &entry.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// BuildQueue counts the queued and running build jobs of the platform and of
+// a project
+func (r *ProjectStatusRepository) BuildQueue(ctx context.Context, projectID uuid.UUID) (*types.BuildQueueStats, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'queued'),
+			COUNT(*) FILTER (WHERE status = 'building'),
+			COUNT(*) FILTER (WHERE status = 'queued' AND project_id = $1),
+			COUNT(*) FILTER (WHERE status = 'building' AND project_id = $1),
+			MIN(queued_at) FILTER (WHERE status = 'queued' AND project_id = $1)
+		FROM build_jobs
+		WHERE status IN ('queued', 'building')
+	`
+
+	stats := &types.BuildQueueStats{}
+	var oldest sql.NullTime
+	err := r.reader(r.db).QueryRowContext(ctx, query, projectID).Scan(
+		&stats.Queued, &stats.Building, &stats.ProjectQueued, &stats.ProjectBuilding, &oldest,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if oldest.Valid {
+		stats.OldestQueuedAt = &oldest.Time
+	}
+	return stats, nil
+}
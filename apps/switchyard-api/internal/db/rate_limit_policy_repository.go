@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// RateLimitPolicyRepository stores the budgets of expensive routes
+type RateLimitPolicyRepository struct {
+	db DBTX
+}
+
+func NewRateLimitPolicyRepository(db DBTX) *RateLimitPolicyRepository {
+	return &RateLimitPolicyRepository{db: db}
+}
+
+const rateLimitPolicyColumns = `id, name, method, route, scope, requests, window_seconds,
+		enabled, description, created_at, updated_at`
+
+// List returns every policy, enabled or not, by name
+func (r *RateLimitPolicyRepository) List(ctx context.Context) ([]*types.RateLimitPolicy, error) {
+	query := `SELECT ` + rateLimitPolicyColumns + ` FROM rate_limit_policies ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []*types.RateLimitPolicy
+	for rows.Next() {
+		policy, err := scanRateLimitPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+// GetByID returns a policy
+func (r *RateLimitPolicyRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.RateLimitPolicy, error) {
+	query := `SELECT ` + rateLimitPolicyColumns + ` FROM rate_limit_policies WHERE id = $1`
+	return scanRateLimitPolicy(r.db.QueryRowContext(ctx, query, id))
+}
+
+// Create stores a new policy
+func (r *RateLimitPolicyRepository) Create(ctx context.Context, policy *types.RateLimitPolicy) error {
+	policy.ID = uuid.New()
+	policy.CreatedAt = time.Now()
+	policy.UpdatedAt = policy.CreatedAt
+	query := `
+		INSERT INTO rate_limit_policies (id, name, method, route, scope, requests, window_seconds,
+			enabled, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		policy.ID, policy.Name, policy.Method, policy.Route, policy.Scope, policy.Requests,
+		policy.WindowSeconds, policy.Enabled, nullString(policy.Description), policy.CreatedAt, policy.UpdatedAt,
+	)
+	return err
+}
+
+// Update saves the changes to a policy
+func (r *RateLimitPolicyRepository) Update(ctx context.Context, policy *types.RateLimitPolicy) error {
+	policy.UpdatedAt = time.Now()
+	query := `
+		UPDATE rate_limit_policies
+		SET name = $2, method = $3, route = $4, scope = $5, requests = $6, window_seconds = $7,
+			enabled = $8, description = $9, updated_at = $10
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		policy.ID, policy.Name, policy.Method, policy.Route, policy.Scope, policy.Requests,
+		policy.WindowSeconds, policy.Enabled, nullString(policy.Description), policy.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// Delete removes a policy
+func (r *RateLimitPolicyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit_policies WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+func scanRateLimitPolicy(row interface{ Scan(...interface{}) error }) (*types.RateLimitPolicy, error) {
+	policy := &types.RateLimitPolicy{}
+	var description sql.NullString
+	err := row.Scan(
+		&policy.ID, &policy.Name, &policy.Method, &policy.Route, &policy.Scope, &policy.Requests,
+		&policy.WindowSeconds, &policy.Enabled, &description, &policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	policy.Description = description.String
+	return policy, nil
+}
@@ -0,0 +1,16 @@
+package db
+
+// readRouter sends the heavy list and report queries of a repository to a
+// read replica. The zero value, as in transaction-scoped repositories, reads
+// from the primary.
+type readRouter struct {
+	replica DBTX
+}
+
+// reader returns the replica, or the primary when there is none
+func (r readRouter) reader(primary DBTX) DBTX {
+	if r.replica != nil {
+		return r.replica
+	}
+	return primary
+}
@@ -0,0 +1,257 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReconcileJob is a queued deployment reconciliation
+type ReconcileJob struct {
+	ID            uuid.UUID
+	DeploymentID  uuid.UUID
+	Class         string // production, standard or preview
+	EnvironmentID *uuid.UUID
+	Priority      int
+	Attempt       int
+	DriftPolicy   string // Empty means overwrite
+	RunAfter      time.Time
+	EnqueuedAt    time.Time
+	ClaimedBy     *string
+	ClaimedUntil  *time.Time
+	Rerun         bool
+}
+
+// ReconcileBacklog describes the queued reconciliations
+type ReconcileBacklog struct {
+	Ready         int            // Runnable now and not claimed
+	Scheduled     int            // Waiting for their retry time
+	Claimed       int            // Being worked on by a replica
+	ByClass       map[string]int // Ready jobs by class
+	ByEnvironment map[string]int // Ready jobs by environment ID
+	OldestReady   time.Duration  // How long the oldest ready job has been runnable
+}
+
+// ReconcileJobRepository is the durable reconciliation work queue
+type ReconcileJobRepository struct {
+	db DBTX
+}
+
+func NewReconcileJobRepository(db DBTX) *ReconcileJobRepository {
+	return &ReconcileJobRepository{db: db}
+}
+
+// NewReconcileJobRepositoryWithTx creates a repository using a transaction
+func NewReconcileJobRepositoryWithTx(tx DBTX) *ReconcileJobRepository {
+	return &ReconcileJobRepository{db: tx}
+}
+
+// Enqueue queues a job. A deployment has at most one job: queueing it again
+// raises the job's priority, brings its run time forward and sets its drift
+// policy. If a replica is working on the job, it is run once more after.
+func (r *ReconcileJobRepository) Enqueue(ctx context.Context, job *ReconcileJob) error {
+	query := `
+		INSERT INTO reconcile_jobs (deployment_id, class, environment_id, priority, attempt, drift_policy, run_after)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+		ON CONFLICT (deployment_id) DO UPDATE SET
+			priority = GREATEST(reconcile_jobs.priority, EXCLUDED.priority),
+			run_after = LEAST(reconcile_jobs.run_after, EXCLUDED.run_after),
+			drift_policy = COALESCE(EXCLUDED.drift_policy, reconcile_jobs.drift_policy),
+			rerun = reconcile_jobs.rerun OR COALESCE(reconcile_jobs.claimed_until > NOW(), false)
+		RETURNING id, enqueued_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		job.DeploymentID, job.Class, job.EnvironmentID, job.Priority, job.Attempt, job.DriftPolicy, job.RunAfter,
+	).Scan(&job.ID, &job.EnqueuedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue reconcile job: %w", err)
+	}
+	return nil
+}
+
+// EnqueueIfAbsent queues a job unless the deployment already has one. It
+// reports whether the job was queued.
+func (r *ReconcileJobRepository) EnqueueIfAbsent(ctx context.Context, job *ReconcileJob) (bool, error) {
+	query := `
+		INSERT INTO reconcile_jobs (deployment_id, class, environment_id, priority, attempt, drift_policy, run_after)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+		ON CONFLICT (deployment_id) DO NOTHING
+		RETURNING id, enqueued_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		job.DeploymentID, job.Class, job.EnvironmentID, job.Priority, job.Attempt, job.DriftPolicy, job.RunAfter,
+	).Scan(&job.ID, &job.EnqueuedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue reconcile job: %w", err)
+	}
+	return true, nil
+}
+
+// Claim takes up to limit ready jobs for owner until the lease runs out.
+// Jobs are taken by class, production first, with jobs that waited past
+// starvationAge promoted a class for each age they waited (never to
+// production), then by priority and age. Jobs other replicas are claiming
+// at the same time are skipped.
+func (r *ReconcileJobRepository) Claim(ctx context.Context, owner string, limit int, lease, starvationAge time.Duration) ([]*ReconcileJob, error) {
+	query := `
+		WITH next AS (
+			SELECT id
+			FROM reconcile_jobs
+			WHERE run_after <= NOW()
+			  AND (claimed_until IS NULL OR claimed_until < NOW())
+			ORDER BY
+				GREATEST(
+					CASE class WHEN 'production' THEN 0 WHEN 'preview' THEN 2 ELSE 1 END
+						- FLOOR(EXTRACT(EPOCH FROM NOW() - enqueued_at) / $4)::int,
+					CASE class WHEN 'production' THEN 0 ELSE 1 END
+				),
+				priority DESC,
+				enqueued_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE reconcile_jobs j
+		SET claimed_by = $1, claimed_until = NOW() + make_interval(secs => $3)
+		FROM next
+		WHERE j.id = next.id
+		RETURNING j.id, j.deployment_id, j.class, j.environment_id, j.priority, j.attempt,
+			COALESCE(j.drift_policy, ''), j.run_after, j.enqueued_at, j.claimed_by, j.claimed_until, j.rerun
+	`
+	rows, err := r.db.QueryContext(ctx, query, owner, limit, lease.Seconds(), max(starvationAge.Seconds(), 1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reconcile jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*ReconcileJob
+	for rows.Next() {
+		job := &ReconcileJob{}
+		if err := rows.Scan(
+			&job.ID, &job.DeploymentID, &job.Class, &job.EnvironmentID, &job.Priority, &job.Attempt,
+			&job.DriftPolicy, &job.RunAfter, &job.EnqueuedAt, &job.ClaimedBy, &job.ClaimedUntil, &job.Rerun,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan reconcile job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// RenewClaims extends the lease on every job owner holds
+func (r *ReconcileJobRepository) RenewClaims(ctx context.Context, owner string, lease time.Duration) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE reconcile_jobs
+		SET claimed_until = NOW() + make_interval(secs => $2)
+		WHERE claimed_by = $1
+	`, owner, lease.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to renew reconcile job claims: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Complete removes a finished job, unless it was queued again while owner
+// worked on it; then it is released to run once more as a first attempt
+func (r *ReconcileJobRepository) Complete(ctx context.Context, id uuid.UUID, owner string) error {
+	_, err := r.db.ExecContext(ctx, `
+		WITH released AS (
+			UPDATE reconcile_jobs
+			SET claimed_by = NULL, claimed_until = NULL, rerun = false,
+				attempt = 1, run_after = NOW(), enqueued_at = NOW()
+			WHERE id = $1 AND claimed_by = $2 AND rerun
+			RETURNING id
+		)
+		DELETE FROM reconcile_jobs
+		WHERE id = $1 AND claimed_by = $2 AND NOT rerun
+	`, id, owner)
+	if err != nil {
+		return fmt.Errorf("failed to complete reconcile job: %w", err)
+	}
+	return nil
+}
+
+// Retry releases a job to run again as its next attempt at runAfter, or
+// right away if it was queued again in the meantime
+func (r *ReconcileJobRepository) Retry(ctx context.Context, id uuid.UUID, owner string, runAfter time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE reconcile_jobs
+		SET claimed_by = NULL, claimed_until = NULL, rerun = false,
+			attempt = attempt + 1, priority = priority + 1,
+			run_after = CASE WHEN rerun THEN NOW() ELSE $3 END,
+			enqueued_at = CASE WHEN rerun THEN NOW() ELSE $3 END
+		WHERE id = $1 AND claimed_by = $2
+	`, id, owner, runAfter)
+	if err != nil {
+		return fmt.Errorf("failed to retry reconcile job: %w", err)
+	}
+	return nil
+}
+
+// ReleaseAll gives up every claim owner holds, so other replicas can take
+// the jobs without waiting for the leases to run out
+func (r *ReconcileJobRepository) ReleaseAll(ctx context.Context, owner string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE reconcile_jobs
+		SET claimed_by = NULL, claimed_until = NULL
+		WHERE claimed_by = $1
+	`, owner)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release reconcile job claims: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Backlog summarizes the queued jobs
+func (r *ReconcileJobRepository) Backlog(ctx context.Context) (*ReconcileBacklog, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			CASE
+				WHEN claimed_until > NOW() THEN 'claimed'
+				WHEN run_after > NOW() THEN 'scheduled'
+				ELSE 'ready'
+			END AS state,
+			class,
+			COALESCE(environment_id::text, ''),
+			COUNT(*),
+			COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(run_after)), 0)
+		FROM reconcile_jobs
+		GROUP BY 1, 2, 3
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize reconcile jobs: %w", err)
+	}
+	defer rows.Close()
+
+	backlog := &ReconcileBacklog{
+		ByClass:       make(map[string]int),
+		ByEnvironment: make(map[string]int),
+	}
+	for rows.Next() {
+		var state, class, environmentID string
+		var count int
+		var waitSeconds float64
+		if err := rows.Scan(&state, &class, &environmentID, &count, &waitSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan reconcile backlog: %w", err)
+		}
+		switch state {
+		case "claimed":
+			backlog.Claimed += count
+		case "scheduled":
+			backlog.Scheduled += count
+		default:
+			backlog.Ready += count
+			backlog.ByClass[class] += count
+			if environmentID != "" {
+				backlog.ByEnvironment[environmentID] += count
+			}
+			backlog.OldestReady = max(backlog.OldestReady, time.Duration(waitSeconds*float64(time.Second)))
+		}
+	}
+	return backlog, rows.Err()
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// RegistryCredentialRepository handles the private registry credentials of
+// projects. Passwords are encrypted with the environment variable key.
+type RegistryCredentialRepository struct {
+	db            DBTX
+	encryptionKey []byte // 32-byte AES-256 key
+}
+
+// NewRegistryCredentialRepository creates a new RegistryCredentialRepository
+func NewRegistryCredentialRepository(db DBTX) *RegistryCredentialRepository {
+	return &RegistryCredentialRepository{db: db, encryptionKey: getEncryptionKey()}
+}
+
+// NewRegistryCredentialRepositoryWithTx creates a repository using a transaction
+func NewRegistryCredentialRepositoryWithTx(tx DBTX) *RegistryCredentialRepository {
+	return &RegistryCredentialRepository{db: tx, encryptionKey: getEncryptionKey()}
+}
+
+// RegistryAuth is a decrypted registry credential. For token providers the
+// username and password are exchanged for a token before they are written
+// to a Docker config.
+type RegistryAuth struct {
+	Registry       string
+	Provider       types.RegistryProvider
+	Username       string
+	Password       string
+	PushRepository string // Empty unless builds push to this registry
+}
+
+const registryCredentialColumns = `rc.id, rc.project_id, rc.environment_id, COALESCE(e.name, ''), rc.registry, rc.provider, rc.username,
+	COALESCE(rc.push_repository, ''), COALESCE(rc.created_by, ''), rc.created_at, rc.updated_at`
+
+const registryCredentialFrom = ` FROM registry_credentials rc LEFT JOIN environments e ON e.id = rc.environment_id`
+
+func scanRegistryCredential(row interface{ Scan(...interface{}) error }, cred *types.RegistryCredential) error {
+	var environmentID uuid.NullUUID
+	err := row.Scan(&cred.ID, &cred.ProjectID, &environmentID, &cred.Environment, &cred.Registry, &cred.Provider, &cred.Username,
+		&cred.PushRepository, &cred.CreatedBy, &cred.CreatedAt, &cred.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	cred.EnvironmentID = nil
+	if environmentID.Valid {
+		cred.EnvironmentID = &environmentID.UUID
+	}
+	return nil
+}
+
+// Upsert stores the credential of a project for a registry, replacing the
+// one it had in the same scope: the project, or one of its environments
+func (r *RegistryCredentialRepository) Upsert(ctx context.Context, cred *types.RegistryCredential, password string) error {
+	encrypted, err := sealAESGCM(r.encryptionKey, password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt registry password: %w", err)
+	}
+	if cred.Provider == "" {
+		cred.Provider = types.RegistryProviderBasic
+	}
+
+	query := `
+		WITH saved AS (
+			INSERT INTO registry_credentials (project_id, environment_id, registry, provider, username, password_encrypted, push_repository, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''))
+			ON CONFLICT (project_id, registry, (COALESCE(environment_id, '00000000-0000-0000-0000-000000000000'::uuid))) DO UPDATE SET
+				provider = EXCLUDED.provider,
+				username = EXCLUDED.username,
+				password_encrypted = EXCLUDED.password_encrypted,
+				push_repository = EXCLUDED.push_repository,
+				created_by = EXCLUDED.created_by,
+				updated_at = NOW()
+			RETURNING *
+		)
+		SELECT ` + registryCredentialColumns + ` FROM saved rc LEFT JOIN environments e ON e.id = rc.environment_id`
+	row := r.db.QueryRowContext(ctx, query, cred.ProjectID, cred.EnvironmentID, cred.Registry, cred.Provider, cred.Username,
+		encrypted, cred.PushRepository, cred.CreatedBy)
+	if err := scanRegistryCredential(row, cred); err != nil {
+		return fmt.Errorf("failed to save registry credential: %w", err)
+	}
+	return nil
+}
+
+// ListByProject retrieves the registry credentials of a project, without
+// their passwords
+func (r *RegistryCredentialRepository) ListByProject(ctx context.Context, projectID uuid.UUID) ([]*types.RegistryCredential, error) {
+	query := `SELECT ` + registryCredentialColumns + registryCredentialFrom + `
+		WHERE rc.project_id = $1 ORDER BY rc.registry, e.name NULLS FIRST`
+	rows, err := r.db.QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registry credentials: %w", err)
+	}
+	defer rows.Close()
+
+	creds := []*types.RegistryCredential{}
+	for rows.Next() {
+		cred := &types.RegistryCredential{}
+		if err := scanRegistryCredential(rows, cred); err != nil {
+			return nil, fmt.Errorf("failed to scan registry credential: %w", err)
+		}
+		creds = append(creds, cred)
+	}
+	return creds, rows.Err()
+}
+
+// ListDecrypted retrieves the registry credentials of a project with their
+// passwords. With an environment, its own credentials replace the
+// project-wide ones for the same registry; without one only the project-wide
+// credentials are returned.
+func (r *RegistryCredentialRepository) ListDecrypted(ctx context.Context, projectID uuid.UUID, environmentID *uuid.UUID) ([]RegistryAuth, error) {
+	query := `
+		SELECT DISTINCT ON (registry) registry, provider, username, password_encrypted, COALESCE(push_repository, '')
+		FROM registry_credentials
+		WHERE project_id = $1 AND (environment_id IS NULL OR environment_id = $2)
+		ORDER BY registry, environment_id NULLS LAST`
+	rows, err := r.db.QueryContext(ctx, query, projectID, environmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registry credentials: %w", err)
+	}
+	defer rows.Close()
+
+	var auths []RegistryAuth
+	for rows.Next() {
+		var auth RegistryAuth
+		var encrypted string
+		if err := rows.Scan(&auth.Registry, &auth.Provider, &auth.Username, &encrypted, &auth.PushRepository); err != nil {
+			return nil, fmt.Errorf("failed to scan registry credential: %w", err)
+		}
+		auth.Password, err = openAESGCM(r.encryptionKey, encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt registry credential for %s: %w", auth.Registry, err)
+		}
+		auths = append(auths, auth)
+	}
+	return auths, rows.Err()
+}
+
+// ListTokenProjects returns the projects with credentials of token
+// providers, whose pull secrets expire unless they are refreshed
+func (r *RegistryCredentialRepository) ListTokenProjects(ctx context.Context) ([]uuid.UUID, error) {
+	query := `SELECT DISTINCT project_id FROM registry_credentials WHERE provider IN ($1, $2)`
+	rows, err := r.db.QueryContext(ctx, query, types.RegistryProviderECR, types.RegistryProviderGCR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects with registry tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var projectIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan project ID: %w", err)
+		}
+		projectIDs = append(projectIDs, id)
+	}
+	return projectIDs, rows.Err()
+}
+
+// Delete removes a registry credential of a project
+func (r *RegistryCredentialRepository) Delete(ctx context.Context, projectID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM registry_credentials WHERE id = $1 AND project_id = $2`, id, projectID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ReleasePromotionRepository records the promotions of releases between
+// environments
+type ReleasePromotionRepository struct {
+	db DBTX
+}
+
+// NewReleasePromotionRepository creates a new ReleasePromotionRepository
+func NewReleasePromotionRepository(db DBTX) *ReleasePromotionRepository {
+	return &ReleasePromotionRepository{db: db}
+}
+
+// NewReleasePromotionRepositoryWithTx creates a repository using a transaction
+func NewReleasePromotionRepositoryWithTx(tx DBTX) *ReleasePromotionRepository {
+	return &ReleasePromotionRepository{db: tx}
+}
+
+// Create records a promotion
+func (r *ReleasePromotionRepository) Create(ctx context.Context, promotion *types.ReleasePromotion) error {
+	promotion.ID = uuid.New()
+	promotion.CreatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO release_promotions (id, release_id, from_environment_id, to_environment_id, deployment_id,
+			image_uri, image_digest, auto, promoted_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, promotion.ID, promotion.ReleaseID, promotion.FromEnvironmentID, promotion.ToEnvironmentID, promotion.DeploymentID,
+		promotion.ImageURI, sql.NullString{String: promotion.ImageDigest, Valid: promotion.ImageDigest != ""},
+		promotion.Auto, sql.NullString{String: promotion.PromotedBy, Valid: promotion.PromotedBy != ""}, promotion.CreatedAt)
+	return err
+}
+
+// ListByRelease returns the promotion chain of a release, oldest first
+func (r *ReleasePromotionRepository) ListByRelease(ctx context.Context, releaseID uuid.UUID) ([]*types.ReleasePromotion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.release_id, p.from_environment_id, f.name, p.to_environment_id, t.name, p.deployment_id,
+			p.image_uri, COALESCE(p.image_digest, ''), p.auto, COALESCE(p.promoted_by, ''), p.created_at
+		FROM release_promotions p
+		JOIN environments f ON f.id = p.from_environment_id
+		JOIN environments t ON t.id = p.to_environment_id
+		WHERE p.release_id = $1
+		ORDER BY p.created_at, p.id
+	`, releaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []*types.ReleasePromotion{}
+	for rows.Next() {
+		promotion := &types.ReleasePromotion{}
+		if err := rows.Scan(&promotion.ID, &promotion.ReleaseID, &promotion.FromEnvironmentID, &promotion.FromEnvironment,
+			&promotion.ToEnvironmentID, &promotion.ToEnvironment, &promotion.DeploymentID,
+			&promotion.ImageURI, &promotion.ImageDigest, &promotion.Auto, &promotion.PromotedBy, &promotion.CreatedAt); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+	return promotions, rows.Err()
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,24 +23,27 @@ func (r *ReleaseRepository) Create(release *types.Release) error {
 	release.ID = uuid.New()
 	release.CreatedAt = time.Now()
 	release.UpdatedAt = time.Now()
+	if release.Source == "" {
+		release.Source = types.ReleaseSourceBuild
+	}
 
 	query := `
-		INSERT INTO releases (id, service_id, version, image_uri, git_sha, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO releases (id, service_id, version, image_uri, git_sha, source, image_digest, status, deploy_env, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
-	_, err := r.db.Exec(query, release.ID, release.ServiceID, release.Version, release.ImageURI, release.GitSHA, release.Status, release.CreatedAt, release.UpdatedAt)
+	_, err := r.db.Exec(query, release.ID, release.ServiceID, release.Version, release.ImageURI, release.GitSHA, release.Source, sql.NullString{String: release.ImageDigest, Valid: release.ImageDigest != ""}, release.Status, sql.NullString{String: release.DeployEnv, Valid: release.DeployEnv != ""}, release.CreatedAt, release.UpdatedAt)
 	return err
 }
 
 func (r *ReleaseRepository) UpdateStatus(id uuid.UUID, status types.ReleaseStatus) error {
-	query := `UPDATE releases SET status = $1, updated_at = NOW() WHERE id = $2`
+	query := `UPDATE releases SET status = $1, stalled_at = CASE WHEN status = $1 THEN stalled_at END, updated_at = NOW() WHERE id = $2`
 	_, err := r.db.Exec(query, status, id)
 	return err
 }
 
 // UpdateStatusWithError updates release status and stores error message for failed builds
 func (r *ReleaseRepository) UpdateStatusWithError(id uuid.UUID, status types.ReleaseStatus, errorMsg *string) error {
-	query := `UPDATE releases SET status = $1, error_message = $2, updated_at = NOW() WHERE id = $3`
+	query := `UPDATE releases SET status = $1, error_message = $2, stalled_at = CASE WHEN status = $1 THEN stalled_at END, updated_at = NOW() WHERE id = $3`
 	_, err := r.db.Exec(query, status, errorMsg, id)
 	return err
 }
@@ -50,6 +54,13 @@ func (r *ReleaseRepository) UpdateImageURI(id uuid.UUID, imageURI string) error
 	return err
 }
 
+// UpdateImageDigest records the digest a build pushed its image as
+func (r *ReleaseRepository) UpdateImageDigest(ctx context.Context, id uuid.UUID, digest string) error {
+	query := `UPDATE releases SET image_digest = $1, updated_at = NOW() WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, digest, id)
+	return err
+}
+
 func (r *ReleaseRepository) UpdateSBOM(ctx context.Context, id uuid.UUID, sbom, sbomFormat string) error {
 	query := `UPDATE releases SET sbom = $1, sbom_format = $2, updated_at = NOW() WHERE id = $3`
 	_, err := r.db.ExecContext(ctx, query, sbom, sbomFormat, id)
@@ -62,20 +73,56 @@ func (r *ReleaseRepository) UpdateSignature(ctx context.Context, id uuid.UUID, s
 	return err
 }
 
+// UpdateBuildMetrics records image size and build duration for a completed build.
+// A nil imageSizeBytes leaves the stored size untouched.
+func (r *ReleaseRepository) UpdateBuildMetrics(ctx context.Context, id uuid.UUID, imageSizeBytes *int64, durationSecs float64) error {
+	query := `UPDATE releases SET image_size_bytes = COALESCE($1, image_size_bytes), build_duration_seconds = $2, updated_at = NOW() WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, imageSizeBytes, durationSecs, id)
+	return err
+}
+
+// GetAverageBuildDuration returns the mean build duration of ready releases for a service,
+// excluding the given release. Returns nil if there is no build history.
+func (r *ReleaseRepository) GetAverageBuildDuration(ctx context.Context, serviceID, excludeReleaseID uuid.UUID) (*float64, error) {
+	query := `
+		SELECT AVG(build_duration_seconds)
+		FROM releases
+		WHERE service_id = $1 AND id != $2 AND status = $3 AND build_duration_seconds IS NOT NULL
+	`
+	var avg sql.NullFloat64
+	if err := r.db.QueryRowContext(ctx, query, serviceID, excludeReleaseID, types.ReleaseStatusReady).Scan(&avg); err != nil {
+		return nil, err
+	}
+	if !avg.Valid {
+		return nil, nil
+	}
+	return &avg.Float64, nil
+}
+
 func (r *ReleaseRepository) GetByID(id uuid.UUID) (*types.Release, error) {
 	release := &types.Release{}
-	query := `SELECT id, service_id, version, image_uri, git_sha, status, sbom, sbom_format, image_signature, signature_verified_at, error_message, created_at, updated_at FROM releases WHERE id = $1`
+	query := `SELECT id, service_id, version, image_uri, git_sha, source, image_digest, status, sbom, sbom_format, image_signature, signature_verified_at, error_message, image_size_bytes, build_duration_seconds, stalled_at, pruned_at, deploy_env, created_at, updated_at FROM releases WHERE id = $1`
 
-	var sbom, sbomFormat, imageSignature, errorMessage sql.NullString
+	var imageDigest, sbom, sbomFormat, imageSignature, errorMessage, deployEnv sql.NullString
 	var signatureVerifiedAt sql.NullTime
+	var imageSizeBytes sql.NullInt64
+	var buildDuration sql.NullFloat64
 	err := r.db.QueryRow(query, id).Scan(
 		&release.ID, &release.ServiceID, &release.Version, &release.ImageURI,
-		&release.GitSHA, &release.Status, &sbom, &sbomFormat, &imageSignature, &signatureVerifiedAt, &errorMessage, &release.CreatedAt, &release.UpdatedAt,
+		&release.GitSHA, &release.Source, &imageDigest, &release.Status, &sbom, &sbomFormat, &imageSignature, &signatureVerifiedAt, &errorMessage,
+		&imageSizeBytes, &buildDuration, &release.StalledAt, &release.PrunedAt, &deployEnv, &release.CreatedAt, &release.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if imageDigest.Valid {
+		release.ImageDigest = imageDigest.String
+	}
+	if deployEnv.Valid {
+		release.DeployEnv = deployEnv.String
+	}
+
 	// Handle nullable SBOM fields
 	if sbom.Valid {
 		release.SBOM = sbom.String
@@ -97,13 +144,33 @@ func (r *ReleaseRepository) GetByID(id uuid.UUID) (*types.Release, error) {
 		release.ErrorMessage = &errorMessage.String
 	}
 
+	// Handle nullable build metrics
+	if imageSizeBytes.Valid {
+		release.ImageSizeBytes = &imageSizeBytes.Int64
+	}
+	if buildDuration.Valid {
+		release.BuildDurationSecs = &buildDuration.Float64
+	}
+
 	return release, nil
 }
 
+// ListByService returns the releases of a service, newest first. Pruned
+// releases are left out.
 func (r *ReleaseRepository) ListByService(serviceID uuid.UUID) ([]*types.Release, error) {
-	query := `SELECT id, service_id, version, image_uri, git_sha, status, sbom, sbom_format, image_signature, signature_verified_at, error_message, created_at, updated_at FROM releases WHERE service_id = $1 ORDER BY created_at DESC`
+	return r.listByService(serviceID, false)
+}
+
+// ListHistoryByService returns the releases of a service including pruned
+// ones, newest first
+func (r *ReleaseRepository) ListHistoryByService(serviceID uuid.UUID) ([]*types.Release, error) {
+	return r.listByService(serviceID, true)
+}
 
-	rows, err := r.db.Query(query, serviceID)
+func (r *ReleaseRepository) listByService(serviceID uuid.UUID, includePruned bool) ([]*types.Release, error) {
+	query := `SELECT id, service_id, version, image_uri, git_sha, source, image_digest, status, sbom, sbom_format, image_signature, signature_verified_at, error_message, image_size_bytes, build_duration_seconds, stalled_at, pruned_at, created_at, updated_at FROM releases WHERE service_id = $1 AND ($2 OR pruned_at IS NULL) ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, serviceID, includePruned)
 	if err != nil {
 		return nil, err
 	}
@@ -112,14 +179,20 @@ func (r *ReleaseRepository) ListByService(serviceID uuid.UUID) ([]*types.Release
 	var releases []*types.Release
 	for rows.Next() {
 		release := &types.Release{}
-		var sbom, sbomFormat, imageSignature, errorMessage sql.NullString
+		var imageDigest, sbom, sbomFormat, imageSignature, errorMessage sql.NullString
 		var signatureVerifiedAt sql.NullTime
+		var imageSizeBytes sql.NullInt64
+		var buildDuration sql.NullFloat64
 
-		err := rows.Scan(&release.ID, &release.ServiceID, &release.Version, &release.ImageURI, &release.GitSHA, &release.Status, &sbom, &sbomFormat, &imageSignature, &signatureVerifiedAt, &errorMessage, &release.CreatedAt, &release.UpdatedAt)
+		err := rows.Scan(&release.ID, &release.ServiceID, &release.Version, &release.ImageURI, &release.GitSHA, &release.Source, &imageDigest, &release.Status, &sbom, &sbomFormat, &imageSignature, &signatureVerifiedAt, &errorMessage, &imageSizeBytes, &buildDuration, &release.StalledAt, &release.PrunedAt, &release.CreatedAt, &release.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
 
+		if imageDigest.Valid {
+			release.ImageDigest = imageDigest.String
+		}
+
 		// Handle nullable SBOM fields
 		if sbom.Valid {
 			release.SBOM = sbom.String
@@ -141,8 +214,129 @@ func (r *ReleaseRepository) ListByService(serviceID uuid.UUID) ([]*types.Release
 			release.ErrorMessage = &errorMessage.String
 		}
 
+		// Handle nullable build metrics
+		if imageSizeBytes.Valid {
+			release.ImageSizeBytes = &imageSizeBytes.Int64
+		}
+		if buildDuration.Valid {
+			release.BuildDurationSecs = &buildDuration.Float64
+		}
+
 		releases = append(releases, release)
 	}
 
 	return releases, nil
 }
+
+// ListLatestByProjectCommit returns the latest release of each service of a
+// project built from a commit. sha may be an abbreviated commit SHA.
+func (r *ReleaseRepository) ListLatestByProjectCommit(ctx context.Context, projectID uuid.UUID, sha string) ([]*types.Release, error) {
+	query := `
+		SELECT DISTINCT ON (r.service_id)
+			r.id, r.service_id, r.version, r.image_uri, r.git_sha, r.source, r.status,
+			r.error_message, r.build_duration_seconds, r.created_at, r.updated_at
+		FROM releases r
+		JOIN services s ON s.id = r.service_id
+		WHERE s.project_id = $1 AND s.deleted_at IS NULL AND r.git_sha LIKE $2 || '%'
+		ORDER BY r.service_id, r.created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, projectID, sha)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []*types.Release
+	for rows.Next() {
+		release := &types.Release{}
+		var errorMessage sql.NullString
+		var buildDuration sql.NullFloat64
+		err := rows.Scan(&release.ID, &release.ServiceID, &release.Version, &release.ImageURI, &release.GitSHA,
+			&release.Source, &release.Status, &errorMessage, &buildDuration, &release.CreatedAt, &release.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if errorMessage.Valid {
+			release.ErrorMessage = &errorMessage.String
+		}
+		if buildDuration.Valid {
+			release.BuildDurationSecs = &buildDuration.Float64
+		}
+		releases = append(releases, release)
+	}
+	return releases, rows.Err()
+}
+
+// ListStalledBuilds returns releases that have been building since before the
+// cutoff and are not yet flagged as stalled
+func (r *ReleaseRepository) ListStalledBuilds(ctx context.Context, cutoff time.Time) ([]*types.Release, error) {
+	query := `SELECT id, service_id, version, git_sha, status, created_at FROM releases
+	          WHERE status = $1 AND created_at < $2 AND stalled_at IS NULL ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, types.ReleaseStatusBuilding, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []*types.Release
+	for rows.Next() {
+		release := &types.Release{}
+		if err := rows.Scan(&release.ID, &release.ServiceID, &release.Version, &release.GitSHA, &release.Status, &release.CreatedAt); err != nil {
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+
+	return releases, rows.Err()
+}
+
+// MarkStalled flags a building release as stalled. It returns false if the
+// release was already flagged or is no longer building, so callers notify only once.
+func (r *ReleaseRepository) MarkStalled(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE releases SET stalled_at = NOW() WHERE id = $1 AND status = $2 AND stalled_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id, types.ReleaseStatusBuilding)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ListPreviewReleases returns the releases built for a pull request's preview
+// environment, newest first
+func (r *ReleaseRepository) ListPreviewReleases(ctx context.Context, serviceID uuid.UUID, prNumber int) ([]*types.Release, error) {
+	query := `SELECT id, service_id, version, image_uri, status, image_size_bytes, created_at FROM releases
+	          WHERE service_id = $1 AND version LIKE $2 ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, fmt.Sprintf("preview-pr-%d-%%", prNumber))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []*types.Release
+	for rows.Next() {
+		release := &types.Release{}
+		var imageSizeBytes sql.NullInt64
+		if err := rows.Scan(&release.ID, &release.ServiceID, &release.Version, &release.ImageURI, &release.Status, &imageSizeBytes, &release.CreatedAt); err != nil {
+			return nil, err
+		}
+		if imageSizeBytes.Valid {
+			release.ImageSizeBytes = &imageSizeBytes.Int64
+		}
+		releases = append(releases, release)
+	}
+
+	return releases, rows.Err()
+}
+
+// Delete removes a release. Its deployments and build jobs are removed with
+// it via ON DELETE CASCADE.
+func (r *ReleaseRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM releases WHERE id = $1`, id)
+	return err
+}
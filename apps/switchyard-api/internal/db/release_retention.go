@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// prunableReleasesQuery selects the releases of the services matched by
+// scope that fall outside the newest $2 of their service. Preview releases
+// are left to preview cleanup, and releases still building or referenced by
+// an active deployment (pending, or the latest running one of an
+// environment) are never prunable.
+const prunableReleasesQuery = `
+	WITH ranked AS (
+		SELECT r.id, r.service_id, r.version, r.image_uri, r.source, r.status, r.pruned_at, r.created_at,
+		       ROW_NUMBER() OVER (PARTITION BY r.service_id ORDER BY r.created_at DESC) AS position
+		FROM releases r
+		WHERE %s AND r.version NOT LIKE 'preview-pr-%%'
+	),
+	active AS (
+		SELECT d.release_id FROM deployments d
+		JOIN releases r ON r.id = d.release_id
+		WHERE %s AND d.status = 'pending'
+		UNION
+		SELECT latest.release_id FROM (
+			SELECT DISTINCT ON (r.service_id, d.environment_id) d.release_id
+			FROM deployments d
+			JOIN releases r ON r.id = d.release_id
+			WHERE %s AND d.status = 'running'
+			ORDER BY r.service_id, d.environment_id, d.created_at DESC
+		) latest
+	)
+	SELECT ranked.id, ranked.service_id, ranked.version, ranked.image_uri, ranked.source, ranked.status, ranked.created_at
+	FROM ranked
+	WHERE ranked.position > $2 AND ranked.pruned_at IS NULL AND ranked.status <> 'building'
+	  AND ranked.id NOT IN (SELECT release_id FROM active)`
+
+// ListPrunable returns up to limit releases of a service beyond the newest
+// keep, oldest first
+func (r *ReleaseRepository) ListPrunable(ctx context.Context, serviceID uuid.UUID, keep, limit int) ([]*types.Release, error) {
+	const scope = "r.service_id = $1"
+	query := sprintfScope(prunableReleasesQuery, scope) + ` ORDER BY ranked.created_at ASC LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, serviceID, keep, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []*types.Release
+	for rows.Next() {
+		release := &types.Release{}
+		if err := rows.Scan(&release.ID, &release.ServiceID, &release.Version, &release.ImageURI,
+			&release.Source, &release.Status, &release.CreatedAt); err != nil {
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+	return releases, rows.Err()
+}
+
+// CountPrunable counts the releases of a project's services beyond the
+// newest keep of each
+func (r *ReleaseRepository) CountPrunable(ctx context.Context, projectID uuid.UUID, keep int) (int64, error) {
+	const scope = "r.service_id IN (SELECT id FROM services WHERE project_id = $1)"
+	query := `SELECT COUNT(*) FROM (` + sprintfScope(prunableReleasesQuery, scope) + `) prunable`
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, query, projectID, keep).Scan(&count)
+	return count, err
+}
+
+// ImagesInUse returns which of the given images an unpruned release other
+// than the excluded ones still points at. Images are shared by releases that
+// were promoted or redeployed without a rebuild.
+func (r *ReleaseRepository) ImagesInUse(ctx context.Context, images []string, excluding []uuid.UUID) (map[string]bool, error) {
+	query := `SELECT DISTINCT image_uri FROM releases
+	          WHERE image_uri = ANY($1) AND pruned_at IS NULL AND NOT (id::text = ANY($2))`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(images), pq.Array(uuidStrings(excluding)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inUse := make(map[string]bool)
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+		inUse[image] = true
+	}
+	return inUse, rows.Err()
+}
+
+// MarkPruned marks releases pruned and deletes their superseded deployments.
+// The release rows stay as history; their SBOM is dropped with the image.
+// It returns the number of deployments deleted.
+func (r *ReleaseRepository) MarkPruned(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	query := `
+		WITH pruned AS (
+			UPDATE releases SET pruned_at = NOW(), sbom = NULL, updated_at = NOW()
+			WHERE id::text = ANY($1) AND pruned_at IS NULL
+			RETURNING id
+		)
+		DELETE FROM deployments
+		WHERE release_id IN (SELECT id FROM pruned) AND status <> 'pending'
+	`
+	result, err := r.db.ExecContext(ctx, query, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// sprintfScope fills the scope placeholders of prunableReleasesQuery
+func sprintfScope(query, scope string) string {
+	return fmt.Sprintf(query, scope, scope, scope)
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return strs
+}
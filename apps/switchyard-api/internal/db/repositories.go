@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 )
 
 // Repositories provides access to all repository types
@@ -14,6 +16,14 @@ type Repositories struct {
 	Services            *ServiceRepository
 	Releases            *ReleaseRepository
 	Deployments         *DeploymentRepository
+	DeploymentSnapshots *DeploymentSnapshotRepository
+	DriftEvents         *DriftEventRepository
+	ReconcileJobs       *ReconcileJobRepository
+	BackgroundJobs      *BackgroundJobRepository
+	AlertRules          *AlertRuleRepository
+	Alerts              *AlertRepository
+	UptimeChecks        *UptimeCheckRepository
+	StatusPages         *StatusPageRepository
 	Users               *UserRepository
 	ProjectAccess       *ProjectAccessRepository
 	AuditLogs           *AuditLogRepository
@@ -23,19 +33,50 @@ type Repositories struct {
 	Routes              *RouteRepository
 	DeploymentGroups    *DeploymentGroupRepository
 	ServiceDependencies *ServiceDependencyRepository
+	FreezeWindows       *FreezeWindowRepository
+	BuildContexts       *BuildContextRepository
+	BuildArtifacts      *BuildArtifactRepository
+	BuildSecrets        *BuildSecretRepository
 	EnvVars             *EnvVarRepository
 	PreviewEnvironments *PreviewEnvironmentRepository
+	PreviewDatabases    *PreviewDatabaseRepository
 	PreviewComments     *PreviewCommentRepository
 	PreviewAccessLogs   *PreviewAccessLogRepository
+	Soaks               *SoakRepository
+	DeployPolicies      *DeployPolicyRepository
+	DeployLocks         *DeployLockRepository
+	DeploymentPhases    *DeploymentPhaseRepository
+	ReleasePromotions   *ReleasePromotionRepository
+	IdleScaling         *IdleScalingRepository
+	ServiceCommands     *ServiceCommandRepository
+	RegistryCredentials *RegistryCredentialRepository
+	Clusters            *ClusterRepository
+	Retention           *RetentionRepository
+	ProjectStatus       *ProjectStatusRepository
+	Search              *SearchRepository
+	OneOffJobs          *OneOffJobRepository
+	AddonCopies         *AddonCopyRepository
+	Bots                *BotRepository
 	Teams               *TeamRepository
+	TeamEncryptionKeys  *TeamEncryptionKeyRepository
+	TeamDNSProviders    *TeamDNSProviderRepository
 	TeamMembers         *TeamMemberRepository
 	TeamInvitations     *TeamInvitationRepository
 	APITokens           *APITokenRepository
+	SignupInvites       *SignupInviteRepository
+	EmailVerifications  *EmailVerificationRepository
+	TwoFactor           *TwoFactorRepository
+	EmailDeliveries     *EmailDeliveryRepository
+	ProjectEvents       *ProjectEventRepository
 	DatabaseAddons      *DatabaseAddonRepository
 	Templates           *TemplateRepository
 	Webhooks            *WebhookRepository
 	CIRuns              *CIRunRepository
 	Functions           *FunctionRepository
+	ResourceQuotas      *ResourceQuotaRepository
+	PlatformAdmin       *PlatformAdminRepository
+	RateLimitPolicies   *RateLimitPolicyRepository
+	BillingAccounts     *BillingAccountRepository
 }
 
 // Ping checks database connectivity for health probes
@@ -46,6 +87,35 @@ func (r *Repositories) Ping(ctx context.Context) error {
 	return r.db.PingContext(ctx)
 }
 
+// UseReadReplica sends heavy list and report queries to a read replica:
+// audit log searches, platform admin listings and metrics, project activity
+// feeds, project status pages and searches. These may lag the primary by
+// the replica's replication delay; writes, and reads that must see them,
+// stay on the primary.
+func (r *Repositories) UseReadReplica(replica *sql.DB) {
+	r.AuditLogs.replica = replica
+	r.PlatformAdmin.replica = replica
+	r.ProjectEvents.replica = replica
+	r.ProjectStatus.replica = replica
+	r.Search.replica = replica
+}
+
+// UseCache keeps the hot reads of projects, services and environments by ID,
+// and of projects by slug and environments by name, in a shared cache such
+// as Redis, so reconcile storms don't each hit the database. Writes through
+// the repositories evict the entries on every replica.
+func (r *Repositories) UseCache(c cache.CacheService) {
+	r.Projects.cache = newEntityCache(c, "project")
+	r.Services.cache = newEntityCache(c, "service")
+	r.Environments.cache = newEntityCache(c, "environment")
+}
+
+// SetEnvVarKeyring enables customer-managed encryption keys for environment
+// variables. Values of teams without a key stay under the platform key.
+func (r *Repositories) SetEnvVarKeyring(keyring EnvVarKeyring) {
+	r.EnvVars.keyring = keyring
+}
+
 // WithTransaction executes the given function within a database transaction.
 // If the function returns an error, the transaction is rolled back.
 // If the function succeeds, the transaction is committed.
@@ -58,11 +128,19 @@ func (r *Repositories) WithTransaction(ctx context.Context, fn func(txRepos *Rep
 	// Create transaction-scoped repositories
 	txRepos := &Repositories{
 		db:                  r.db, // Keep original db for nested transaction prevention
-		Projects:            &ProjectRepository{db: tx},
-		Environments:        &EnvironmentRepository{db: tx},
-		Services:            &ServiceRepository{db: tx},
+		Projects:            &ProjectRepository{db: tx, cache: r.Projects.cache.evicting()},
+		Environments:        &EnvironmentRepository{db: tx, cache: r.Environments.cache.evicting()},
+		Services:            &ServiceRepository{db: tx, cache: r.Services.cache.evicting()},
 		Releases:            &ReleaseRepository{db: tx},
 		Deployments:         &DeploymentRepository{db: tx},
+		DeploymentSnapshots: NewDeploymentSnapshotRepositoryWithTx(tx),
+		DriftEvents:         NewDriftEventRepositoryWithTx(tx),
+		ReconcileJobs:       NewReconcileJobRepositoryWithTx(tx),
+		BackgroundJobs:      NewBackgroundJobRepository(tx),
+		AlertRules:          NewAlertRuleRepositoryWithTx(tx),
+		Alerts:              NewAlertRepositoryWithTx(tx),
+		UptimeChecks:        NewUptimeCheckRepositoryWithTx(tx),
+		StatusPages:         NewStatusPageRepositoryWithTx(tx),
 		Users:               &UserRepository{db: tx},
 		ProjectAccess:       &ProjectAccessRepository{db: tx},
 		AuditLogs:           &AuditLogRepository{db: tx},
@@ -72,21 +150,54 @@ func (r *Repositories) WithTransaction(ctx context.Context, fn func(txRepos *Rep
 		Routes:              NewRouteRepositoryWithTx(tx),
 		DeploymentGroups:    NewDeploymentGroupRepositoryWithTx(tx),
 		ServiceDependencies: NewServiceDependencyRepositoryWithTx(tx),
+		FreezeWindows:       NewFreezeWindowRepositoryWithTx(tx),
+		BuildContexts:       NewBuildContextRepository(tx),
+		BuildArtifacts:      NewBuildArtifactRepository(tx),
+		BuildSecrets:        NewBuildSecretRepository(tx),
 		EnvVars:             NewEnvVarRepositoryWithTx(tx),
 		PreviewEnvironments: NewPreviewEnvironmentRepositoryWithTx(tx),
+		PreviewDatabases:    NewPreviewDatabaseRepositoryWithTx(tx),
 		PreviewComments:     NewPreviewCommentRepositoryWithTx(tx),
 		PreviewAccessLogs:   NewPreviewAccessLogRepositoryWithTx(tx),
+		Soaks:               NewSoakRepositoryWithTx(tx),
+		DeployPolicies:      NewDeployPolicyRepositoryWithTx(tx),
+		DeployLocks:         NewDeployLockRepositoryWithTx(tx),
+		DeploymentPhases:    NewDeploymentPhaseRepositoryWithTx(tx),
+		ReleasePromotions:   NewReleasePromotionRepositoryWithTx(tx),
+		IdleScaling:         NewIdleScalingRepositoryWithTx(tx),
+		ServiceCommands:     NewServiceCommandRepositoryWithTx(tx),
+		RegistryCredentials: NewRegistryCredentialRepositoryWithTx(tx),
+		Clusters:            NewClusterRepositoryWithTx(tx),
+		Retention:           NewRetentionRepository(tx),
+		ProjectStatus:       NewProjectStatusRepository(tx),
+		Search:              NewSearchRepository(tx),
+		OneOffJobs:          NewOneOffJobRepository(tx),
+		AddonCopies:         NewAddonCopyRepositoryWithTx(tx),
+		Bots:                NewBotRepositoryWithTx(tx),
 		Teams:               NewTeamRepositoryWithTx(tx),
+		TeamEncryptionKeys:  NewTeamEncryptionKeyRepositoryWithTx(tx),
+		TeamDNSProviders:    NewTeamDNSProviderRepositoryWithTx(tx),
 		TeamMembers:         NewTeamMemberRepositoryWithTx(tx),
 		TeamInvitations:     NewTeamInvitationRepositoryWithTx(tx),
 		APITokens:           NewAPITokenRepositoryWithTx(tx),
+		SignupInvites:       NewSignupInviteRepository(tx),
+		EmailVerifications:  NewEmailVerificationRepository(tx),
+		TwoFactor:           NewTwoFactorRepository(tx),
+		EmailDeliveries:     NewEmailDeliveryRepository(tx),
+		ProjectEvents:       NewProjectEventRepository(tx),
 		DatabaseAddons:      NewDatabaseAddonRepositoryWithTx(tx),
 		Templates:           NewTemplateRepositoryWithTx(tx),
 		Webhooks:            NewWebhookRepositoryWithTx(tx),
 		CIRuns:              NewCIRunRepositoryWithTx(tx),
 		Functions:           NewFunctionRepositoryWithTx(tx),
+		ResourceQuotas:      NewResourceQuotaRepository(tx),
+		PlatformAdmin:       NewPlatformAdminRepository(tx),
+		RateLimitPolicies:   NewRateLimitPolicyRepository(tx),
+		BillingAccounts:     NewBillingAccountRepository(tx),
 	}
 
+	txRepos.EnvVars.keyring = r.EnvVars.keyring
+
 	// Execute the function with transaction repositories
 	if err := fn(txRepos); err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
@@ -111,6 +222,14 @@ func NewRepositories(db *sql.DB) *Repositories {
 		Services:            NewServiceRepository(db),
 		Releases:            NewReleaseRepository(db),
 		Deployments:         NewDeploymentRepository(db),
+		DeploymentSnapshots: NewDeploymentSnapshotRepository(db),
+		DriftEvents:         NewDriftEventRepository(db),
+		ReconcileJobs:       NewReconcileJobRepository(db),
+		BackgroundJobs:      NewBackgroundJobRepository(db),
+		AlertRules:          NewAlertRuleRepository(db),
+		Alerts:              NewAlertRepository(db),
+		UptimeChecks:        NewUptimeCheckRepository(db),
+		StatusPages:         NewStatusPageRepository(db),
 		Users:               NewUserRepository(db),
 		ProjectAccess:       NewProjectAccessRepository(db),
 		AuditLogs:           NewAuditLogRepository(db),
@@ -120,18 +239,49 @@ func NewRepositories(db *sql.DB) *Repositories {
 		Routes:              NewRouteRepository(db),
 		DeploymentGroups:    NewDeploymentGroupRepository(db),
 		ServiceDependencies: NewServiceDependencyRepository(db),
+		FreezeWindows:       NewFreezeWindowRepository(db),
+		BuildContexts:       NewBuildContextRepository(db),
+		BuildArtifacts:      NewBuildArtifactRepository(db),
+		BuildSecrets:        NewBuildSecretRepository(db),
 		EnvVars:             NewEnvVarRepository(db),
 		PreviewEnvironments: NewPreviewEnvironmentRepository(db),
+		PreviewDatabases:    NewPreviewDatabaseRepository(db),
 		PreviewComments:     NewPreviewCommentRepository(db),
 		PreviewAccessLogs:   NewPreviewAccessLogRepository(db),
+		Soaks:               NewSoakRepository(db),
+		DeployPolicies:      NewDeployPolicyRepository(db),
+		DeployLocks:         NewDeployLockRepository(db),
+		DeploymentPhases:    NewDeploymentPhaseRepository(db),
+		ReleasePromotions:   NewReleasePromotionRepository(db),
+		IdleScaling:         NewIdleScalingRepository(db),
+		ServiceCommands:     NewServiceCommandRepository(db),
+		RegistryCredentials: NewRegistryCredentialRepository(db),
+		Clusters:            NewClusterRepository(db),
+		Retention:           NewRetentionRepository(db),
+		ProjectStatus:       NewProjectStatusRepository(db),
+		Search:              NewSearchRepository(db),
+		OneOffJobs:          NewOneOffJobRepository(db),
+		AddonCopies:         NewAddonCopyRepository(db),
+		Bots:                NewBotRepository(db),
 		Teams:               NewTeamRepository(db),
+		TeamEncryptionKeys:  NewTeamEncryptionKeyRepository(db),
+		TeamDNSProviders:    NewTeamDNSProviderRepository(db),
 		TeamMembers:         NewTeamMemberRepository(db),
 		TeamInvitations:     NewTeamInvitationRepository(db),
 		APITokens:           NewAPITokenRepository(db),
+		SignupInvites:       NewSignupInviteRepository(db),
+		EmailVerifications:  NewEmailVerificationRepository(db),
+		TwoFactor:           NewTwoFactorRepository(db),
+		EmailDeliveries:     NewEmailDeliveryRepository(db),
+		ProjectEvents:       NewProjectEventRepository(db),
 		DatabaseAddons:      NewDatabaseAddonRepository(db),
 		Templates:           NewTemplateRepository(db),
 		Webhooks:            NewWebhookRepository(db),
 		CIRuns:              NewCIRunRepository(db),
 		Functions:           NewFunctionRepository(db),
+		ResourceQuotas:      NewResourceQuotaRepository(db),
+		PlatformAdmin:       NewPlatformAdminRepository(db),
+		RateLimitPolicies:   NewRateLimitPolicyRepository(db),
+		BillingAccounts:     NewBillingAccountRepository(db),
 	}
 }
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ResourceQuotaRepository stores the resource quotas of teams and projects
+// and counts what they hold against them
+type ResourceQuotaRepository struct {
+	db DBTX
+}
+
+func NewResourceQuotaRepository(db DBTX) *ResourceQuotaRepository {
+	return &ResourceQuotaRepository{db: db}
+}
+
+// ResourceCounts is what the live projects of a team, or a live project,
+// hold. Pods are the replicas of the latest pending or running deployment
+// of each service in each environment.
+type ResourceCounts struct {
+	Services            int
+	Pods                int
+	AddonStorageGB      int
+	PreviewEnvironments int
+}
+
+// GetForTeam returns the resource quota of a team; a team without one gets
+// an empty quota, which limits nothing
+func (r *ResourceQuotaRepository) GetForTeam(ctx context.Context, teamID uuid.UUID) (*types.ResourceQuota, error) {
+	return r.get(ctx, "team_id", teamID)
+}
+
+// GetForProject returns the resource quota of a project; a project without
+// one gets an empty quota, which limits nothing
+func (r *ResourceQuotaRepository) GetForProject(ctx context.Context, projectID uuid.UUID) (*types.ResourceQuota, error) {
+	return r.get(ctx, "project_id", projectID)
+}
+
+func (r *ResourceQuotaRepository) get(ctx context.Context, column string, id uuid.UUID) (*types.ResourceQuota, error) {
+	query := `
+		SELECT max_services, max_cpu_millicores, max_memory_mb,
+			max_addon_storage_gb, max_preview_environments, updated_at
+		FROM resource_quotas
+		WHERE ` + column + ` = $1
+	`
+	var limits [5]sql.NullInt32
+	var updatedAt time.Time
+	quota := &types.ResourceQuota{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&limits[0], &limits[1], &limits[2], &limits[3], &limits[4], &updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return quota, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, target := range []**int{
+		&quota.MaxServices, &quota.MaxCPUMillicores, &quota.MaxMemoryMB,
+		&quota.MaxAddonStorageGB, &quota.MaxPreviewEnvironments,
+	} {
+		if limits[i].Valid {
+			value := int(limits[i].Int32)
+			*target = &value
+		}
+	}
+	quota.UpdatedAt = &updatedAt
+	return quota, nil
+}
+
+// SetForTeam sets the resource quota of a team
+func (r *ResourceQuotaRepository) SetForTeam(ctx context.Context, teamID uuid.UUID, quota *types.ResourceQuota) error {
+	return r.set(ctx, "team_id", teamID, quota)
+}
+
+// SetForProject sets the resource quota of a project
+func (r *ResourceQuotaRepository) SetForProject(ctx context.Context, projectID uuid.UUID, quota *types.ResourceQuota) error {
+	return r.set(ctx, "project_id", projectID, quota)
+}
+
+func (r *ResourceQuotaRepository) set(ctx context.Context, column string, id uuid.UUID, quota *types.ResourceQuota) error {
+	now := time.Now()
+	quota.UpdatedAt = &now
+	query := `
+		INSERT INTO resource_quotas (` + column + `, max_services, max_cpu_millicores, max_memory_mb,
+			max_addon_storage_gb, max_preview_environments, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (` + column + `) DO UPDATE SET
+			max_services = EXCLUDED.max_services,
+			max_cpu_millicores = EXCLUDED.max_cpu_millicores,
+			max_memory_mb = EXCLUDED.max_memory_mb,
+			max_addon_storage_gb = EXCLUDED.max_addon_storage_gb,
+			max_preview_environments = EXCLUDED.max_preview_environments,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, id,
+		quota.MaxServices, quota.MaxCPUMillicores, quota.MaxMemoryMB,
+		quota.MaxAddonStorageGB, quota.MaxPreviewEnvironments, quota.UpdatedAt,
+	)
+	return err
+}
+
+// CountForTeam counts what the live projects of a team hold. The service
+// and environment of a deploy being checked are left out of the pods, since
+// the deploy replaces their deployment; pass uuid.Nil to count everything.
+func (r *ResourceQuotaRepository) CountForTeam(ctx context.Context, teamID, serviceID, environmentID uuid.UUID) (*ResourceCounts, error) {
+	return r.count(ctx, "p.team_id", teamID, serviceID, environmentID)
+}
+
+// CountForProject counts what a project holds, leaving out the pods of a
+// service in an environment like CountForTeam
+func (r *ResourceQuotaRepository) CountForProject(ctx context.Context, projectID, serviceID, environmentID uuid.UUID) (*ResourceCounts, error) {
+	return r.count(ctx, "p.id", projectID, serviceID, environmentID)
+}
+
+func (r *ResourceQuotaRepository) count(ctx context.Context, column string, id, serviceID, environmentID uuid.UUID) (*ResourceCounts, error) {
+	scope := column + ` = $1 AND p.deleted_at IS NULL`
+	query := `
+		SELECT
+			(SELECT COUNT(*)
+				FROM services s
+				JOIN projects p ON s.project_id = p.id
+				WHERE ` + scope + ` AND s.deleted_at IS NULL),
+			(SELECT COALESCE(SUM(GREATEST(latest.replicas, 0)), 0)
+				FROM (
+					SELECT DISTINCT ON (rel.service_id, d.environment_id)
+						rel.service_id, d.environment_id, d.status, d.replicas
+					FROM deployments d
+					JOIN releases rel ON d.release_id = rel.id
+					JOIN services s ON rel.service_id = s.id
+					JOIN projects p ON s.project_id = p.id
+					WHERE ` + scope + ` AND s.deleted_at IS NULL
+					ORDER BY rel.service_id, d.environment_id, d.created_at DESC
+				) latest
+				WHERE latest.status IN ($2, $3)
+				  AND NOT (latest.service_id = $4 AND latest.environment_id = $5)),
+			(SELECT COALESCE(SUM(COALESCE((a.config->>'storage_gb')::int, 0)), 0)
+				FROM database_addons a
+				JOIN projects p ON a.project_id = p.id
+				WHERE ` + scope + ` AND a.deleted_at IS NULL AND a.status <> 'deleted'),
+			(SELECT COUNT(*)
+				FROM preview_environments pe
+				JOIN projects p ON pe.project_id = p.id
+				WHERE ` + scope + ` AND pe.status <> $6)
+	`
+	counts := &ResourceCounts{}
+	err := r.db.QueryRowContext(ctx, query, id,
+		types.DeploymentStatusPending, types.DeploymentStatusRunning, serviceID, environmentID,
+		types.PreviewStatusClosed,
+	).Scan(&counts.Services, &counts.Pods, &counts.AddonStorageGB, &counts.PreviewEnvironments)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// TeamOfProject returns the team that owns a project, or nil for a project
+// without a team
+func (r *ResourceQuotaRepository) TeamOfProject(ctx context.Context, projectID uuid.UUID) (*uuid.UUID, error) {
+	var teamID uuid.NullUUID
+	err := r.db.QueryRowContext(ctx, `SELECT team_id FROM projects WHERE id = $1`, projectID).Scan(&teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !teamID.Valid {
+		return nil, nil
+	}
+	return &teamID.UUID, nil
+}
+
+// ListProjectsWithQuotas returns the live projects held to a resource quota
+// of their own or of their team
+func (r *ResourceQuotaRepository) ListProjectsWithQuotas(ctx context.Context) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT p.id
+		FROM projects p
+		JOIN resource_quotas q ON q.project_id = p.id OR q.team_id = p.team_id
+		WHERE p.deleted_at IS NULL
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return scanProjectIDs(rows)
+}
+
+// ListTeamProjects returns the live projects of a team
+func (r *ResourceQuotaRepository) ListTeamProjects(ctx context.Context, teamID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM projects WHERE team_id = $1 AND deleted_at IS NULL`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return scanProjectIDs(rows)
+}
+
+func scanProjectIDs(rows *sql.Rows) ([]uuid.UUID, error) {
+	defer rows.Close()
+
+	var projectIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		projectIDs = append(projectIDs, id)
+	}
+	return projectIDs, rows.Err()
+}
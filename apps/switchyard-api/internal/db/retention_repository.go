@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// RetentionRepository handles per-project retention policies and the
+// counting and purging of the data they cover
+type RetentionRepository struct {
+	db DBTX
+}
+
+func NewRetentionRepository(db DBTX) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+// retentionTarget is a table holding one class of project data. scope
+// restricts rows to project $1 and age to rows older than $2.
+type retentionTarget struct {
+	table string
+	scope string
+}
+
+// retentionTargets lists the tables purged for each data class
+var retentionTargets = map[types.RetentionDataClass][]retentionTarget{
+	types.RetentionBuildLogs: {{
+		table: "build_jobs",
+		scope: `project_id = $1 AND status IN ('completed', 'failed', 'cancelled') AND COALESCE(completed_at, queued_at) < $2`,
+	}},
+	types.RetentionAccessLogs: {{
+		table: "preview_access_logs",
+		scope: `preview_id IN (SELECT id FROM preview_environments WHERE project_id = $1) AND accessed_at < $2`,
+	}},
+	types.RetentionAuditLogs: {{
+		table: "audit_logs",
+		scope: `project_id = $1 AND "timestamp" < $2`,
+	}},
+	types.RetentionUsageDetail: {
+		{table: "hourly_usage", scope: `project_id = $1 AND hour < $2`},
+		// Unprocessed events have not been aggregated yet and are never purged
+		{table: "usage_events", scope: `project_id = $1 AND "timestamp" < $2 AND processed_at IS NOT NULL`},
+	},
+}
+
+// GetPolicy retrieves the retention policy of a project
+func (r *RetentionRepository) GetPolicy(ctx context.Context, projectID uuid.UUID) (*types.RetentionPolicy, error) {
+	query := `
+		SELECT project_id, build_log_days, access_log_days, audit_log_days, usage_detail_days, keep_releases, updated_at
+		FROM project_retention_policies
+		WHERE project_id = $1
+	`
+
+	policy := &types.RetentionPolicy{}
+	var buildLogDays, accessLogDays, auditLogDays, usageDetailDays, keepReleases sql.NullInt32
+	err := r.db.QueryRowContext(ctx, query, projectID).Scan(
+		&policy.ProjectID, &buildLogDays, &accessLogDays, &auditLogDays, &usageDetailDays, &keepReleases, &policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.BuildLogDays = nullInt32Ptr(buildLogDays)
+	policy.AccessLogDays = nullInt32Ptr(accessLogDays)
+	policy.AuditLogDays = nullInt32Ptr(auditLogDays)
+	policy.UsageDetailDays = nullInt32Ptr(usageDetailDays)
+	policy.KeepReleases = nullInt32Ptr(keepReleases)
+
+	return policy, nil
+}
+
+// UpsertPolicy creates or replaces the retention policy of a project
+func (r *RetentionRepository) UpsertPolicy(ctx context.Context, policy *types.RetentionPolicy) error {
+	policy.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO project_retention_policies (
+			project_id, build_log_days, access_log_days, audit_log_days, usage_detail_days, keep_releases, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (project_id) DO UPDATE SET
+			build_log_days = EXCLUDED.build_log_days,
+			access_log_days = EXCLUDED.access_log_days,
+			audit_log_days = EXCLUDED.audit_log_days,
+			usage_detail_days = EXCLUDED.usage_detail_days,
+			keep_releases = EXCLUDED.keep_releases,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		policy.ProjectID, policy.BuildLogDays, policy.AccessLogDays, policy.AuditLogDays,
+		policy.UsageDetailDays, policy.KeepReleases, policy.UpdatedAt,
+	)
+	return err
+}
+
+// GetPlanID retrieves the plan of a project's current subscription. Projects
+// without one return an empty plan.
+func (r *RetentionRepository) GetPlanID(ctx context.Context, projectID uuid.UUID) (string, error) {
+	query := `
+		SELECT plan_id
+		FROM subscriptions
+		WHERE project_id = $1 AND status IN ('active', 'trialing', 'past_due')
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var planID string
+	err := r.db.QueryRowContext(ctx, query, projectID).Scan(&planID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return planID, err
+}
+
+// CountBefore counts the records of a data class older than cutoff
+func (r *RetentionRepository) CountBefore(ctx context.Context, class types.RetentionDataClass, projectID uuid.UUID, cutoff time.Time) (int64, error) {
+	targets, ok := retentionTargets[class]
+	if !ok {
+		return 0, fmt.Errorf("unknown retention data class: %s", class)
+	}
+
+	var total int64
+	for _, t := range targets {
+		var count int64
+		query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s`, t.table, t.scope)
+		if err := r.db.QueryRowContext(ctx, query, projectID, cutoff).Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed to count %s: %w", t.table, err)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// DeleteBefore deletes up to limit records per table of a data class older
+// than cutoff, and returns how many were deleted
+func (r *RetentionRepository) DeleteBefore(ctx context.Context, class types.RetentionDataClass, projectID uuid.UUID, cutoff time.Time, limit int) (int64, error) {
+	targets, ok := retentionTargets[class]
+	if !ok {
+		return 0, fmt.Errorf("unknown retention data class: %s", class)
+	}
+
+	var total int64
+	for _, t := range targets {
+		query := fmt.Sprintf(`
+			DELETE FROM %[1]s
+			WHERE id IN (SELECT id FROM %[1]s WHERE %[2]s LIMIT $3)
+		`, t.table, t.scope)
+		result, err := r.db.ExecContext(ctx, query, projectID, cutoff, limit)
+		if err != nil {
+			return total, fmt.Errorf("failed to purge %s: %w", t.table, err)
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+	}
+	return total, nil
+}
+
+func nullInt32Ptr(v sql.NullInt32) *int {
+	if !v.Valid {
+		return nil
+	}
+	days := int(v.Int32)
+	return &days
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SearchCandidate is a resource a search may return, with the values of its
+// searchable fields by field name. Candidates are matched loosely; callers
+// score and rank them.
+type SearchCandidate struct {
+	Result        types.SearchResult
+	EnvironmentID *uuid.UUID
+	Fields        map[string]string
+}
+
+// SearchRepository finds the projects, services, deployments and domains
+// whose names, slugs, domains, repos or images match a search
+type SearchRepository struct {
+	db DBTX
+	readRouter
+}
+
+func NewSearchRepository(db DBTX) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// Candidates returns up to limit candidates of each type with a field
+// matching pattern, an ILIKE pattern. Without projectIDs every project is
+// searched. Trashed projects and services are left out, and only the latest
+// deployment of each service in each environment is returned.
+func (r *SearchRepository) Candidates(ctx context.Context, pattern string, projectIDs []uuid.UUID, limit int) ([]*SearchCandidate, error) {
+	all := projectIDs == nil
+	ids := pq.Array(projectIDs)
+	if all {
+		ids = pq.Array([]uuid.UUID{})
+	}
+
+	var candidates []*SearchCandidate
+	for _, search := range []func(context.Context, string, bool, interface{}, int) ([]*SearchCandidate, error){
+		r.projects, r.services, r.deployments, r.domains,
+	} {
+		found, err := search(ctx, pattern, all, ids, limit)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, found...)
+	}
+	return candidates, nil
+}
+
+func (r *SearchRepository) projects(ctx context.Context, pattern string, all bool, ids interface{}, limit int) ([]*SearchCandidate, error) {
+	rows, err := r.reader(r.db).QueryContext(ctx, `
+		SELECT p.id, p.name, p.slug
+		FROM projects p
+		WHERE p.deleted_at IS NULL
+			AND ($2 OR p.id = ANY($3))
+			AND (p.name ILIKE $1 OR p.slug ILIKE $1)
+		ORDER BY p.updated_at DESC
+		LIMIT $4
+	`, pattern, all, ids, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search projects: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*SearchCandidate
+	for rows.Next() {
+		var id uuid.UUID
+		var name, slug string
+		if err := rows.Scan(&id, &name, &slug); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, &SearchCandidate{
+			Result: types.SearchResult{
+				Type:        types.SearchResultProject,
+				ID:          id,
+				Title:       name,
+				Subtitle:    slug,
+				ProjectID:   id,
+				ProjectSlug: slug,
+				Path:        "/v1/projects/" + slug,
+			},
+			Fields: map[string]string{"name": name, "slug": slug},
+		})
+	}
+	return candidates, rows.Err()
+}
+
+func (r *SearchRepository) services(ctx context.Context, pattern string, all bool, ids interface{}, limit int) ([]*SearchCandidate, error) {
+	rows, err := r.reader(r.db).QueryContext(ctx, `
+		SELECT s.id, s.name, COALESCE(s.git_repo, ''), p.id, p.slug
+		FROM services s
+		JOIN projects p ON s.project_id = p.id
+		WHERE s.deleted_at IS NULL AND p.deleted_at IS NULL
+			AND ($2 OR p.id = ANY($3))
+			AND (s.name ILIKE $1 OR s.git_repo ILIKE $1)
+		ORDER BY s.updated_at DESC
+		LIMIT $4
+	`, pattern, all, ids, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search services: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*SearchCandidate
+	for rows.Next() {
+		var id, projectID uuid.UUID
+		var name, gitRepo, projectSlug string
+		if err := rows.Scan(&id, &name, &gitRepo, &projectID, &projectSlug); err != nil {
+			return nil, err
+		}
+		serviceID := id
+		candidates = append(candidates, &SearchCandidate{
+			Result: types.SearchResult{
+				Type:        types.SearchResultService,
+				ID:          id,
+				Title:       name,
+				Subtitle:    projectSlug,
+				ProjectID:   projectID,
+				ProjectSlug: projectSlug,
+				ServiceID:   &serviceID,
+				Path:        "/v1/services/" + id.String(),
+			},
+			Fields: map[string]string{"name": name, "git_repo": gitRepo},
+		})
+	}
+	return candidates, rows.Err()
+}
+
+func (r *SearchRepository) deployments(ctx context.Context, pattern string, all bool, ids interface{}, limit int) ([]*SearchCandidate, error) {
+	rows, err := r.reader(r.db).QueryContext(ctx, `
+		SELECT * FROM (
+			SELECT DISTINCT ON (rel.service_id, d.environment_id)
+				d.id, d.status, d.created_at, rel.version, rel.image_uri, s.id, s.name, e.id, e.name, p.id, p.slug
+			FROM deployments d
+			JOIN releases rel ON d.release_id = rel.id
+			JOIN services s ON rel.service_id = s.id
+			JOIN environments e ON d.environment_id = e.id
+			JOIN projects p ON s.project_id = p.id
+			WHERE s.deleted_at IS NULL AND p.deleted_at IS NULL
+				AND ($2 OR p.id = ANY($3))
+			ORDER BY rel.service_id, d.environment_id, d.created_at DESC
+		) latest
+		WHERE latest.version ILIKE $1 OR latest.image_uri ILIKE $1
+		ORDER BY latest.created_at DESC
+		LIMIT $4
+	`, pattern, all, ids, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search deployments: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*SearchCandidate
+	for rows.Next() {
+		var id, serviceID, envID, projectID uuid.UUID
+		var status types.DeploymentStatus
+		var createdAt time.Time
+		var version, imageURI, serviceName, envName, projectSlug string
+		if err := rows.Scan(&id, &status, &createdAt, &version, &imageURI, &serviceID, &serviceName,
+			&envID, &envName, &projectID, &projectSlug); err != nil {
+			return nil, err
+		}
+		environmentID := envID
+		candidates = append(candidates, &SearchCandidate{
+			Result: types.SearchResult{
+				Type:        types.SearchResultDeployment,
+				ID:          id,
+				Title:       serviceName + "@" + version,
+				Subtitle:    fmt.Sprintf("%s · %s · %s", projectSlug, envName, status),
+				ProjectID:   projectID,
+				ProjectSlug: projectSlug,
+				ServiceID:   &serviceID,
+				Environment: envName,
+				Path:        "/v1/deployments/" + id.String(),
+			},
+			EnvironmentID: &environmentID,
+			Fields:        map[string]string{"version": version, "image_uri": imageURI},
+		})
+	}
+	return candidates, rows.Err()
+}
+
+func (r *SearchRepository) domains(ctx context.Context, pattern string, all bool, ids interface{}, limit int) ([]*SearchCandidate, error) {
+	rows, err := r.reader(r.db).QueryContext(ctx, `
+		SELECT cd.id, cd.domain, s.id, s.name, e.id, e.name, p.id, p.slug
+		FROM custom_domains cd
+		JOIN services s ON cd.service_id = s.id
+		JOIN environments e ON cd.environment_id = e.id
+		JOIN projects p ON s.project_id = p.id
+		WHERE s.deleted_at IS NULL AND p.deleted_at IS NULL
+			AND ($2 OR p.id = ANY($3))
+			AND cd.domain ILIKE $1
+		ORDER BY cd.updated_at DESC
+		LIMIT $4
+	`, pattern, all, ids, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search domains: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*SearchCandidate
+	for rows.Next() {
+		var id, serviceID, envID, projectID uuid.UUID
+		var domain, serviceName, envName, projectSlug string
+		if err := rows.Scan(&id, &domain, &serviceID, &serviceName, &envID, &envName, &projectID, &projectSlug); err != nil {
+			return nil, err
+		}
+		environmentID := envID
+		candidates = append(candidates, &SearchCandidate{
+			Result: types.SearchResult{
+				Type:        types.SearchResultDomain,
+				ID:          id,
+				Title:       domain,
+				Subtitle:    fmt.Sprintf("%s · %s · %s", projectSlug, serviceName, envName),
+				ProjectID:   projectID,
+				ProjectSlug: projectSlug,
+				ServiceID:   &serviceID,
+				Environment: envName,
+				Path:        fmt.Sprintf("/v1/services/%s/domains", serviceID),
+			},
+			EnvironmentID: &environmentID,
+			Fields:        map[string]string{"domain": domain},
+		})
+	}
+	return candidates, rows.Err()
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ServiceCommandRepository handles the commands services run in one
+// environment instead of their own
+type ServiceCommandRepository struct {
+	db DBTX
+}
+
+// NewServiceCommandRepository creates a new ServiceCommandRepository
+func NewServiceCommandRepository(db DBTX) *ServiceCommandRepository {
+	return &ServiceCommandRepository{db: db}
+}
+
+// NewServiceCommandRepositoryWithTx creates a repository using a transaction
+func NewServiceCommandRepositoryWithTx(tx DBTX) *ServiceCommandRepository {
+	return &ServiceCommandRepository{db: tx}
+}
+
+const serviceCommandColumns = `
+	c.id, c.service_id, c.environment_id, e.name, c.command, c.created_at, c.updated_at
+	FROM service_environment_commands c
+	JOIN environments e ON e.id = c.environment_id
+`
+
+// Upsert sets the command of a service in an environment
+func (r *ServiceCommandRepository) Upsert(ctx context.Context, override *types.ServiceEnvironmentCommand) error {
+	commandJSON, err := json.Marshal(override.Command)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command: %w", err)
+	}
+
+	query := `
+		INSERT INTO service_environment_commands (service_id, environment_id, command)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (service_id, environment_id) DO UPDATE SET
+			command = EXCLUDED.command,
+			updated_at = NOW()
+		RETURNING id
+	`
+	var id uuid.UUID
+	if err := r.db.QueryRowContext(ctx, query, override.ServiceID, override.EnvironmentID, commandJSON).Scan(&id); err != nil {
+		return fmt.Errorf("failed to save service command: %w", err)
+	}
+
+	saved, err := scanServiceCommand(r.db.QueryRowContext(ctx, `SELECT `+serviceCommandColumns+` WHERE c.id = $1`, id))
+	if err != nil {
+		return err
+	}
+	*override = *saved
+	return nil
+}
+
+// Get retrieves the command of a service in an environment
+func (r *ServiceCommandRepository) Get(ctx context.Context, serviceID, environmentID uuid.UUID) (*types.ServiceEnvironmentCommand, error) {
+	query := `SELECT ` + serviceCommandColumns + ` WHERE c.service_id = $1 AND c.environment_id = $2`
+	return scanServiceCommand(r.db.QueryRowContext(ctx, query, serviceID, environmentID))
+}
+
+// ListByService retrieves the per-environment commands of a service
+func (r *ServiceCommandRepository) ListByService(ctx context.Context, serviceID uuid.UUID) ([]*types.ServiceEnvironmentCommand, error) {
+	query := `SELECT ` + serviceCommandColumns + ` WHERE c.service_id = $1 ORDER BY e.name`
+	rows, err := r.db.QueryContext(ctx, query, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service commands: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []*types.ServiceEnvironmentCommand{}
+	for rows.Next() {
+		override, err := scanServiceCommand(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service command: %w", err)
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, rows.Err()
+}
+
+// Resolve returns the command a service runs in an environment: its
+// override there, or else the service's own. Nil runs the image as built.
+func (r *ServiceCommandRepository) Resolve(ctx context.Context, service *types.Service, environmentID uuid.UUID) (*types.ContainerCommand, error) {
+	override, err := r.Get(ctx, service.ID, environmentID)
+	if err == sql.ErrNoRows {
+		return service.Command, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &override.Command, nil
+}
+
+// Delete removes the command of a service in an environment, so it runs
+// the service's own again
+func (r *ServiceCommandRepository) Delete(ctx context.Context, serviceID, environmentID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM service_environment_commands WHERE service_id = $1 AND environment_id = $2`, serviceID, environmentID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func scanServiceCommand(row interface{ Scan(...interface{}) error }) (*types.ServiceEnvironmentCommand, error) {
+	c := &types.ServiceEnvironmentCommand{}
+	var commandJSON []byte
+	err := row.Scan(&c.ID, &c.ServiceID, &c.EnvironmentID, &c.Environment, &commandJSON, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(commandJSON, &c.Command); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal command: %w", err)
+	}
+	return c, nil
+}
@@ -9,12 +9,15 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // ServiceRepository handles service CRUD operations
 type ServiceRepository struct {
-	db DBTX
+	db    DBTX
+	cache entityCache
 }
 
 func NewServiceRepository(db DBTX) *ServiceRepository {
@@ -50,19 +53,35 @@ func (r *ServiceRepository) Create(service *types.Service) error {
 	return err
 }
 
+// GetByID retrieves a service by ID; trashed services are not found
 func (r *ServiceRepository) GetByID(id uuid.UUID) (*types.Service, error) {
+	ctx := context.Background()
+	key := cache.ServiceKey(id.String())
+	var cached types.Service
+	if r.cache.get(ctx, key, &cached) {
+		return &cached, nil
+	}
+
 	service := &types.Service{}
 	var buildConfigJSON []byte
 	var appPath sql.NullString
+	var runtimeJSON []byte
+
+	var labelsJSON []byte
+	var schedulingJSON []byte
+	var gpuJSON []byte
+	var commandJSON []byte
+	var shutdownJSON []byte
+	var autoDeployRulesJSON []byte
 
 	query := `SELECT id, project_id, name, git_repo, COALESCE(app_path, '') as app_path, build_config,
-		auto_deploy, auto_deploy_branch, auto_deploy_env, created_at, updated_at
-		FROM services WHERE id = $1`
+		auto_deploy, auto_deploy_branch, auto_deploy_env, auto_deploy_rules, runtime, labels, scheduling, gpu, command, shutdown, high_availability, created_at, updated_at
+		FROM services WHERE id = $1 AND deleted_at IS NULL`
 
 	err := r.db.QueryRow(query, id).Scan(
 		&service.ID, &service.ProjectID, &service.Name, &service.GitRepo,
 		&appPath, &buildConfigJSON, &service.AutoDeploy, &service.AutoDeployBranch,
-		&service.AutoDeployEnv, &service.CreatedAt, &service.UpdatedAt,
+		&service.AutoDeployEnv, &autoDeployRulesJSON, &runtimeJSON, &labelsJSON, &schedulingJSON, &gpuJSON, &commandJSON, &shutdownJSON, &service.HighAvailability, &service.CreatedAt, &service.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -75,7 +94,29 @@ func (r *ServiceRepository) GetByID(id uuid.UUID) (*types.Service, error) {
 	if err := json.Unmarshal(buildConfigJSON, &service.BuildConfig); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal build config: %w", err)
 	}
+	if err := unmarshalServiceRuntime(runtimeJSON, service); err != nil {
+		return nil, err
+	}
+	if err := unmarshalServiceLabels(labelsJSON, service); err != nil {
+		return nil, err
+	}
+	if err := unmarshalServiceScheduling(schedulingJSON, service); err != nil {
+		return nil, err
+	}
+	if err := unmarshalServiceGPU(gpuJSON, service); err != nil {
+		return nil, err
+	}
+	if err := unmarshalServiceCommand(commandJSON, service); err != nil {
+		return nil, err
+	}
+	if err := unmarshalServiceShutdown(shutdownJSON, service); err != nil {
+		return nil, err
+	}
+	if err := unmarshalServiceAutoDeployRules(autoDeployRulesJSON, service); err != nil {
+		return nil, err
+	}
 
+	r.cache.set(ctx, key, service, serviceCacheTTL)
 	return service, nil
 }
 
@@ -84,15 +125,16 @@ func (r *ServiceRepository) GetByName(name string) (*types.Service, error) {
 	service := &types.Service{}
 	var buildConfigJSON []byte
 	var appPath sql.NullString
+	var runtimeJSON []byte
 
 	query := `SELECT id, project_id, name, git_repo, COALESCE(app_path, '') as app_path, build_config,
-		auto_deploy, auto_deploy_branch, auto_deploy_env, created_at, updated_at
-		FROM services WHERE name = $1`
+		auto_deploy, auto_deploy_branch, auto_deploy_env, runtime, created_at, updated_at
+		FROM services WHERE name = $1 AND deleted_at IS NULL`
 
 	err := r.db.QueryRow(query, name).Scan(
 		&service.ID, &service.ProjectID, &service.Name, &service.GitRepo,
 		&appPath, &buildConfigJSON, &service.AutoDeploy, &service.AutoDeployBranch,
-		&service.AutoDeployEnv, &service.CreatedAt, &service.UpdatedAt,
+		&service.AutoDeployEnv, &runtimeJSON, &service.CreatedAt, &service.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -105,14 +147,17 @@ func (r *ServiceRepository) GetByName(name string) (*types.Service, error) {
 	if err := json.Unmarshal(buildConfigJSON, &service.BuildConfig); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal build config: %w", err)
 	}
+	if err := unmarshalServiceRuntime(runtimeJSON, service); err != nil {
+		return nil, err
+	}
 
 	return service, nil
 }
 
 func (r *ServiceRepository) ListAll(ctx context.Context) ([]*types.Service, error) {
 	query := `SELECT id, project_id, name, git_repo, COALESCE(app_path, '') as app_path, build_config,
-		auto_deploy, auto_deploy_branch, auto_deploy_env, created_at, updated_at
-		FROM services ORDER BY created_at DESC`
+		auto_deploy, auto_deploy_branch, auto_deploy_env, runtime, created_at, updated_at
+		FROM services WHERE deleted_at IS NULL ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -125,11 +170,12 @@ func (r *ServiceRepository) ListAll(ctx context.Context) ([]*types.Service, erro
 		service := &types.Service{}
 		var buildConfigJSON []byte
 		var appPath sql.NullString
+		var runtimeJSON []byte
 
 		err := rows.Scan(
 			&service.ID, &service.ProjectID, &service.Name, &service.GitRepo,
 			&appPath, &buildConfigJSON, &service.AutoDeploy, &service.AutoDeployBranch,
-			&service.AutoDeployEnv, &service.CreatedAt, &service.UpdatedAt,
+			&service.AutoDeployEnv, &runtimeJSON, &service.CreatedAt, &service.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -142,6 +188,9 @@ func (r *ServiceRepository) ListAll(ctx context.Context) ([]*types.Service, erro
 		if err := json.Unmarshal(buildConfigJSON, &service.BuildConfig); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal build config: %w", err)
 		}
+		if err := unmarshalServiceRuntime(runtimeJSON, service); err != nil {
+			return nil, err
+		}
 
 		services = append(services, service)
 	}
@@ -149,15 +198,63 @@ func (r *ServiceRepository) ListAll(ctx context.Context) ([]*types.Service, erro
 	return services, nil
 }
 
-func (r *ServiceRepository) ListByProject(projectID uuid.UUID) ([]*types.Service, error) {
-	query := `SELECT id, project_id, name, git_repo, COALESCE(app_path, '') as app_path, build_config,
-		auto_deploy, auto_deploy_branch, auto_deploy_env,
+const projectServiceColumns = `SELECT id, project_id, name, git_repo, COALESCE(app_path, '') as app_path, build_config,
+		auto_deploy, auto_deploy_branch, auto_deploy_env, auto_deploy_rules,
 		k8s_namespace, COALESCE(health, 'unknown') as health, COALESCE(status, 'unknown') as status,
 		COALESCE(desired_replicas, 0) as desired_replicas, COALESCE(ready_replicas, 0) as ready_replicas,
-		last_health_check, created_at, updated_at
-		FROM services WHERE project_id = $1 ORDER BY created_at DESC`
+		last_health_check, runtime, labels, scheduling, gpu, command, shutdown, high_availability, deleted_at, created_at, updated_at
+		FROM services`
+
+var serviceListColumns = listColumns{
+	id:      "id",
+	sorts:   map[string]string{"created_at": "created_at", "updated_at": "updated_at", "name": "name"},
+	status:  "status",
+	created: "created_at",
+	deleted: "deleted_at",
+}
+
+func (r *ServiceRepository) ListByProject(projectID uuid.UUID) ([]*types.Service, error) {
+	return r.queryProjectServices(context.Background(),
+		projectServiceColumns+` WHERE project_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC`, projectID)
+}
+
+// ListTrashedByProject returns the services of a project in the trash,
+// including those trashed with the project itself
+func (r *ServiceRepository) ListTrashedByProject(ctx context.Context, projectID uuid.UUID) ([]*types.Service, error) {
+	return r.queryProjectServices(ctx,
+		projectServiceColumns+` WHERE project_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC`, projectID)
+}
+
+// ListPurgeable returns the services trashed on their own before a time,
+// oldest first. Services of trashed projects are purged with their project.
+func (r *ServiceRepository) ListPurgeable(ctx context.Context, before time.Time) ([]*types.Service, error) {
+	return r.queryProjectServices(ctx, projectServiceColumns+`
+		WHERE deleted_at < $1
+		  AND project_id IN (SELECT id FROM projects WHERE deleted_at IS NULL)
+		ORDER BY deleted_at`, before)
+}
 
-	rows, err := r.db.Query(query, projectID)
+// GetTrashed retrieves a service in the trash by ID
+func (r *ServiceRepository) GetTrashed(ctx context.Context, id uuid.UUID) (*types.Service, error) {
+	services, err := r.queryProjectServices(ctx, projectServiceColumns+` WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return services[0], nil
+}
+
+// ListPageByProject returns a page of the services of a project; params.Trashed
+// lists those in the trash instead
+func (r *ServiceRepository) ListPageByProject(ctx context.Context, projectID uuid.UUID, params ListParams) ([]*types.Service, error) {
+	where, args := params.where(serviceListColumns, []string{"project_id = $1"}, []any{projectID})
+	return r.queryProjectServices(ctx, projectServiceColumns+where, args...)
+}
+
+func (r *ServiceRepository) queryProjectServices(ctx context.Context, query string, args ...any) ([]*types.Service, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -168,14 +265,21 @@ func (r *ServiceRepository) ListByProject(projectID uuid.UUID) ([]*types.Service
 		service := &types.Service{}
 		var buildConfigJSON []byte
 		var appPath sql.NullString
+		var runtimeJSON []byte
 		var k8sNamespace sql.NullString
 		var lastHealthCheck sql.NullTime
+		var labelsJSON []byte
+		var schedulingJSON []byte
+		var gpuJSON []byte
+		var commandJSON []byte
+		var shutdownJSON []byte
+		var autoDeployRulesJSON []byte
 
 		err := rows.Scan(&service.ID, &service.ProjectID, &service.Name, &service.GitRepo, &appPath, &buildConfigJSON,
-			&service.AutoDeploy, &service.AutoDeployBranch, &service.AutoDeployEnv,
+			&service.AutoDeploy, &service.AutoDeployBranch, &service.AutoDeployEnv, &autoDeployRulesJSON,
 			&k8sNamespace, &service.Health, &service.Status,
-			&service.DesiredReplicas, &service.ReadyReplicas, &lastHealthCheck,
-			&service.CreatedAt, &service.UpdatedAt)
+			&service.DesiredReplicas, &service.ReadyReplicas, &lastHealthCheck, &runtimeJSON, &labelsJSON,
+			&schedulingJSON, &gpuJSON, &commandJSON, &shutdownJSON, &service.HighAvailability, &service.DeletedAt, &service.CreatedAt, &service.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -193,6 +297,27 @@ func (r *ServiceRepository) ListByProject(projectID uuid.UUID) ([]*types.Service
 		if err := json.Unmarshal(buildConfigJSON, &service.BuildConfig); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal build config: %w", err)
 		}
+		if err := unmarshalServiceRuntime(runtimeJSON, service); err != nil {
+			return nil, err
+		}
+		if err := unmarshalServiceLabels(labelsJSON, service); err != nil {
+			return nil, err
+		}
+		if err := unmarshalServiceScheduling(schedulingJSON, service); err != nil {
+			return nil, err
+		}
+		if err := unmarshalServiceGPU(gpuJSON, service); err != nil {
+			return nil, err
+		}
+		if err := unmarshalServiceCommand(commandJSON, service); err != nil {
+			return nil, err
+		}
+		if err := unmarshalServiceShutdown(shutdownJSON, service); err != nil {
+			return nil, err
+		}
+		if err := unmarshalServiceAutoDeployRules(autoDeployRulesJSON, service); err != nil {
+			return nil, err
+		}
 
 		services = append(services, service)
 	}
@@ -206,15 +331,16 @@ func (r *ServiceRepository) GetByGitRepo(gitRepoURL string) (*types.Service, err
 	service := &types.Service{}
 	var buildConfigJSON []byte
 	var appPath sql.NullString
+	var runtimeJSON []byte
 
 	query := `SELECT id, project_id, name, git_repo, COALESCE(app_path, '') as app_path, build_config,
-		auto_deploy, auto_deploy_branch, auto_deploy_env, created_at, updated_at
-		FROM services WHERE git_repo = $1`
+		auto_deploy, auto_deploy_branch, auto_deploy_env, runtime, created_at, updated_at
+		FROM services WHERE git_repo = $1 AND deleted_at IS NULL`
 
 	err := r.db.QueryRow(query, gitRepoURL).Scan(
 		&service.ID, &service.ProjectID, &service.Name, &service.GitRepo,
 		&appPath, &buildConfigJSON, &service.AutoDeploy, &service.AutoDeployBranch,
-		&service.AutoDeployEnv, &service.CreatedAt, &service.UpdatedAt,
+		&service.AutoDeployEnv, &runtimeJSON, &service.CreatedAt, &service.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -227,6 +353,9 @@ func (r *ServiceRepository) GetByGitRepo(gitRepoURL string) (*types.Service, err
 	if err := json.Unmarshal(buildConfigJSON, &service.BuildConfig); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal build config: %w", err)
 	}
+	if err := unmarshalServiceRuntime(runtimeJSON, service); err != nil {
+		return nil, err
+	}
 
 	return service, nil
 }
@@ -240,11 +369,12 @@ func (r *ServiceRepository) ListByGitRepo(gitRepoURL string) ([]*types.Service,
 
 	// Query with normalized URL matching (handles .git suffix variations)
 	query := `SELECT id, project_id, name, git_repo, COALESCE(app_path, '') as app_path, build_config,
-		auto_deploy, auto_deploy_branch, auto_deploy_env, created_at, updated_at
+		auto_deploy, auto_deploy_branch, auto_deploy_env, auto_deploy_rules, runtime, created_at, updated_at
 		FROM services
-		WHERE REPLACE(REPLACE(git_repo, '.git', ''), 'https://github.com/', '') = $1
+		WHERE deleted_at IS NULL
+		  AND (REPLACE(REPLACE(git_repo, '.git', ''), 'https://github.com/', '') = $1
 		   OR git_repo = $2
-		   OR git_repo = $3`
+		   OR git_repo = $3)`
 
 	// Try with and without .git suffix
 	urlWithGit := normalizedURL
@@ -267,11 +397,13 @@ func (r *ServiceRepository) ListByGitRepo(gitRepoURL string) ([]*types.Service,
 		service := &types.Service{}
 		var buildConfigJSON []byte
 		var appPath sql.NullString
+		var runtimeJSON []byte
+		var autoDeployRulesJSON []byte
 
 		if err := rows.Scan(
 			&service.ID, &service.ProjectID, &service.Name, &service.GitRepo,
 			&appPath, &buildConfigJSON, &service.AutoDeploy, &service.AutoDeployBranch,
-			&service.AutoDeployEnv, &service.CreatedAt, &service.UpdatedAt,
+			&service.AutoDeployEnv, &autoDeployRulesJSON, &runtimeJSON, &service.CreatedAt, &service.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -283,6 +415,12 @@ func (r *ServiceRepository) ListByGitRepo(gitRepoURL string) ([]*types.Service,
 		if err := json.Unmarshal(buildConfigJSON, &service.BuildConfig); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal build config: %w", err)
 		}
+		if err := unmarshalServiceRuntime(runtimeJSON, service); err != nil {
+			return nil, err
+		}
+		if err := unmarshalServiceAutoDeployRules(autoDeployRulesJSON, service); err != nil {
+			return nil, err
+		}
 
 		services = append(services, service)
 	}
@@ -311,6 +449,335 @@ func (r *ServiceRepository) Update(ctx context.Context, service *types.Service)
 	if err != nil {
 		return err
 	}
+	r.cache.forget(ctx, cache.ServiceKey(service.ID.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateRuntime stores the detected language/framework metadata for a service
+func (r *ServiceRepository) UpdateRuntime(ctx context.Context, id uuid.UUID, runtime *types.ServiceRuntime) error {
+	runtimeJSON, err := json.Marshal(runtime)
+	if err != nil {
+		return fmt.Errorf("failed to marshal runtime: %w", err)
+	}
+
+	query := `UPDATE services SET runtime = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, runtimeJSON, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// unmarshalServiceRuntime decodes the nullable runtime column into a service
+func unmarshalServiceRuntime(raw []byte, service *types.Service) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var runtime types.ServiceRuntime
+	if err := json.Unmarshal(raw, &runtime); err != nil {
+		return fmt.Errorf("failed to unmarshal runtime: %w", err)
+	}
+	service.Runtime = &runtime
+	return nil
+}
+
+// UpdateLabels replaces the labels of a service
+func (r *ServiceRepository) UpdateLabels(ctx context.Context, id uuid.UUID, labels map[string]string) error {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labelsJSON, err := json.Marshal(labels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels: %w", err)
+	}
+
+	query := `UPDATE services SET labels = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, labelsJSON, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// unmarshalServiceLabels decodes the labels column into a service
+func unmarshalServiceLabels(raw []byte, service *types.Service) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &service.Labels); err != nil {
+		return fmt.Errorf("failed to unmarshal labels: %w", err)
+	}
+	return nil
+}
+
+// UpdateScheduling replaces the pod placement of a service; nil clears it
+func (r *ServiceRepository) UpdateScheduling(ctx context.Context, id uuid.UUID, scheduling *types.SchedulingConfig) error {
+	var schedulingJSON []byte
+	if scheduling != nil {
+		var err error
+		if schedulingJSON, err = json.Marshal(scheduling); err != nil {
+			return fmt.Errorf("failed to marshal scheduling: %w", err)
+		}
+	}
+
+	query := `UPDATE services SET scheduling = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, schedulingJSON, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// unmarshalServiceScheduling decodes the scheduling column into a service
+func unmarshalServiceScheduling(raw []byte, service *types.Service) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &service.Scheduling); err != nil {
+		return fmt.Errorf("failed to unmarshal scheduling: %w", err)
+	}
+	return nil
+}
+
+// UpdateGPU replaces the GPU request of a service; nil clears it
+func (r *ServiceRepository) UpdateGPU(ctx context.Context, id uuid.UUID, gpu *types.GPUConfig) error {
+	var gpuJSON []byte
+	if gpu != nil {
+		var err error
+		if gpuJSON, err = json.Marshal(gpu); err != nil {
+			return fmt.Errorf("failed to marshal gpu: %w", err)
+		}
+	}
+
+	query := `UPDATE services SET gpu = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, gpuJSON, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// unmarshalServiceGPU decodes the gpu column into a service
+func unmarshalServiceGPU(raw []byte, service *types.Service) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &service.GPU); err != nil {
+		return fmt.Errorf("failed to unmarshal gpu: %w", err)
+	}
+	return nil
+}
+
+// UpdateCommand replaces the command of a service; nil runs the image as
+// built
+func (r *ServiceRepository) UpdateCommand(ctx context.Context, id uuid.UUID, command *types.ContainerCommand) error {
+	var commandJSON []byte
+	if command != nil {
+		var err error
+		if commandJSON, err = json.Marshal(command); err != nil {
+			return fmt.Errorf("failed to marshal command: %w", err)
+		}
+	}
+
+	query := `UPDATE services SET command = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, commandJSON, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// unmarshalServiceCommand decodes the command column into a service
+func unmarshalServiceCommand(raw []byte, service *types.Service) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &service.Command); err != nil {
+		return fmt.Errorf("failed to unmarshal command: %w", err)
+	}
+	return nil
+}
+
+// UpdateShutdown replaces the shutdown settings of a service; nil restores
+// the defaults
+func (r *ServiceRepository) UpdateShutdown(ctx context.Context, id uuid.UUID, shutdown *types.ShutdownConfig) error {
+	var shutdownJSON []byte
+	if shutdown != nil {
+		var err error
+		if shutdownJSON, err = json.Marshal(shutdown); err != nil {
+			return fmt.Errorf("failed to marshal shutdown: %w", err)
+		}
+	}
+
+	query := `UPDATE services SET shutdown = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, shutdownJSON, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// unmarshalServiceShutdown decodes the shutdown column into a service
+func unmarshalServiceShutdown(raw []byte, service *types.Service) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &service.Shutdown); err != nil {
+		return fmt.Errorf("failed to unmarshal shutdown: %w", err)
+	}
+	return nil
+}
+
+// UpdateAutoDeployRules replaces the auto-deploy rules of a service; nil
+// restores the defaults
+func (r *ServiceRepository) UpdateAutoDeployRules(ctx context.Context, id uuid.UUID, rules *types.AutoDeployRules) error {
+	var rulesJSON []byte
+	if rules != nil {
+		var err error
+		if rulesJSON, err = json.Marshal(rules); err != nil {
+			return fmt.Errorf("failed to marshal auto-deploy rules: %w", err)
+		}
+	}
+
+	query := `UPDATE services SET auto_deploy_rules = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, rulesJSON, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// unmarshalServiceAutoDeployRules decodes the auto_deploy_rules column into
+// a service
+func unmarshalServiceAutoDeployRules(raw []byte, service *types.Service) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &service.AutoDeployRules); err != nil {
+		return fmt.Errorf("failed to unmarshal auto-deploy rules: %w", err)
+	}
+	return nil
+}
+
+// UpdateHighAvailability sets whether a service gets a pod disruption budget
+// and anti-affinity
+func (r *ServiceRepository) UpdateHighAvailability(ctx context.Context, id uuid.UUID, enabled bool) error {
+	query := `UPDATE services SET high_availability = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, enabled, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Trash moves a service to the trash and returns when it was deleted
+func (r *ServiceRepository) Trash(ctx context.Context, id uuid.UUID, deletedBy *uuid.UUID) (time.Time, error) {
+	var deletedAt time.Time
+	query := `UPDATE services SET deleted_at = NOW(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL RETURNING deleted_at`
+	if err := r.db.QueryRowContext(ctx, query, id, deletedBy).Scan(&deletedAt); err != nil {
+		return time.Time{}, err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
+	return deletedAt, nil
+}
+
+// Restore takes a service out of the trash
+func (r *ServiceRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE services SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW() WHERE id = $1 AND deleted_at IS NOT NULL`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
@@ -323,13 +790,14 @@ func (r *ServiceRepository) Update(ctx context.Context, service *types.Service)
 	return nil
 }
 
-// Delete removes a service by ID
+// Delete permanently removes a service by ID
 func (r *ServiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM services WHERE id = $1`
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
+	r.cache.forget(ctx, cache.ServiceKey(id.String()))
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SignupInviteRepository handles the invite codes of invite-only signup
+type SignupInviteRepository struct {
+	db DBTX
+}
+
+func NewSignupInviteRepository(db DBTX) *SignupInviteRepository {
+	return &SignupInviteRepository{db: db}
+}
+
+// inviteCodeEncoding spells invite codes without padding, so they can be
+// read out and typed
+var inviteCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateInviteCode creates a random code in groups of four characters,
+// such as ABCD-EFGH-IJKL-MNOP
+func generateInviteCode() (string, error) {
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate invite code: %w", err)
+	}
+	raw := inviteCodeEncoding.EncodeToString(bytes)
+
+	groups := make([]string, 0, len(raw)/4)
+	for i := 0; i < len(raw); i += 4 {
+		groups = append(groups, raw[i:i+4])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// HashInviteCode hashes an invite code for storage and lookup. Case and
+// dashes are ignored, so codes can be typed loosely.
+func HashInviteCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hashBytes := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hashBytes[:])
+}
+
+const signupInviteColumns = `
+	id, prefix, COALESCE(email, ''), COALESCE(note, ''), max_uses, uses, expires_at, revoked_at, created_by, created_at
+`
+
+// Create generates the code of a new invite. The code is set on the invite
+// and is not stored.
+func (r *SignupInviteRepository) Create(ctx context.Context, invite *types.SignupInvite) error {
+	code, err := generateInviteCode()
+	if err != nil {
+		return err
+	}
+
+	invite.ID = uuid.New()
+	invite.Code = code
+	invite.Prefix = code[:4]
+	invite.Uses = 0
+	invite.CreatedAt = time.Now()
+	if invite.MaxUses <= 0 {
+		invite.MaxUses = 1
+	}
+
+	query := `
+		INSERT INTO signup_invites (id, code_hash, prefix, email, note, max_uses, uses, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, 0, $7, $8, $9)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		invite.ID, HashInviteCode(code), invite.Prefix, invite.Email, invite.Note,
+		invite.MaxUses, invite.ExpiresAt, invite.CreatedBy, invite.CreatedAt,
+	)
+	return err
+}
+
+// List returns all invites, newest first
+func (r *SignupInviteRepository) List(ctx context.Context) ([]*types.SignupInvite, error) {
+	query := `SELECT ` + signupInviteColumns + ` FROM signup_invites ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []*types.SignupInvite
+	for rows.Next() {
+		invite, err := scanSignupInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+// Revoke stops an invite from creating more accounts. It returns
+// sql.ErrNoRows for an unknown or already revoked invite.
+func (r *SignupInviteRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE signup_invites SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Redeem uses up one use of an invite code for an email address. It returns
+// sql.ErrNoRows when the code is unknown, revoked, expired, used up, or
+// meant for another address.
+func (r *SignupInviteRepository) Redeem(ctx context.Context, code, email string) (*types.SignupInvite, error) {
+	query := `
+		UPDATE signup_invites SET uses = uses + 1
+		WHERE code_hash = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+			AND uses < max_uses
+			AND (email IS NULL OR LOWER(email) = LOWER($2))
+		RETURNING ` + signupInviteColumns
+	return scanSignupInvite(r.db.QueryRowContext(ctx, query, HashInviteCode(code), email))
+}
+
+func scanSignupInvite(row interface{ Scan(...interface{}) error }) (*types.SignupInvite, error) {
+	invite := &types.SignupInvite{}
+	err := row.Scan(
+		&invite.ID, &invite.Prefix, &invite.Email, &invite.Note, &invite.MaxUses, &invite.Uses,
+		&invite.ExpiresAt, &invite.RevokedAt, &invite.CreatedBy, &invite.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// EmailVerificationRepository handles the single-use tokens that verify the
+// email address of a new account
+type EmailVerificationRepository struct {
+	db DBTX
+}
+
+func NewEmailVerificationRepository(db DBTX) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+// Create issues a verification token for a user. Only its hash is stored;
+// the returned token is sent to the user.
+func (r *EmailVerificationRepository) Create(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO email_verification_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := r.db.ExecContext(ctx, query, uuid.New(), userID, hashAPIToken(token), now.Add(ttl), now); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume marks a token used and returns its user. It returns sql.ErrNoRows
+// for an unknown, used, or expired token.
+func (r *EmailVerificationRepository) Consume(ctx context.Context, token string) (uuid.UUID, error) {
+	query := `
+		UPDATE email_verification_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	var userID uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, hashAPIToken(token)).Scan(&userID)
+	return userID, err
+}
+
+// LastSentAt returns when the newest token of a user was issued, or nil when
+// none was
+func (r *EmailVerificationRepository) LastSentAt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	var sentAt sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT MAX(created_at) FROM email_verification_tokens WHERE user_id = $1`, userID).Scan(&sentAt)
+	if err != nil || !sentAt.Valid {
+		return nil, err
+	}
+	return &sentAt.Time, nil
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SoakRepository handles per-environment soak policies and the soaks of
+// deployments under them
+type SoakRepository struct {
+	db DBTX
+}
+
+func NewSoakRepository(db DBTX) *SoakRepository {
+	return &SoakRepository{db: db}
+}
+
+// NewSoakRepositoryWithTx creates a repository using a transaction
+func NewSoakRepositoryWithTx(tx DBTX) *SoakRepository {
+	return &SoakRepository{db: tx}
+}
+
+const deploymentSoakColumns = `
+	deployment_id, environment_id, release_id, status, started_at, ends_at,
+	baseline_restarts, restarts, error_rate, message, rollback_deployment_id, completed_at
+`
+
+// GetPolicy retrieves the soak policy of an environment
+func (r *SoakRepository) GetPolicy(ctx context.Context, environmentID uuid.UUID) (*types.SoakPolicy, error) {
+	query := `
+		SELECT environment_id, duration_minutes, max_restarts, max_error_rate, auto_rollback, require_stable, created_at, updated_at
+		FROM environment_soak_policies
+		WHERE environment_id = $1
+	`
+
+	policy := &types.SoakPolicy{}
+	var maxErrorRate sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, query, environmentID).Scan(
+		&policy.EnvironmentID, &policy.DurationMinutes, &policy.MaxRestarts, &maxErrorRate,
+		&policy.AutoRollback, &policy.RequireStable, &policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxErrorRate.Valid {
+		policy.MaxErrorRate = &maxErrorRate.Float64
+	}
+
+	return policy, nil
+}
+
+// UpsertPolicy creates or replaces the soak policy of an environment.
+// Soaks already running keep the end time they started with.
+func (r *SoakRepository) UpsertPolicy(ctx context.Context, policy *types.SoakPolicy) error {
+	now := time.Now()
+	policy.UpdatedAt = now
+
+	query := `
+		INSERT INTO environment_soak_policies (
+			environment_id, duration_minutes, max_restarts, max_error_rate, auto_rollback, require_stable, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (environment_id) DO UPDATE SET
+			duration_minutes = EXCLUDED.duration_minutes,
+			max_restarts = EXCLUDED.max_restarts,
+			max_error_rate = EXCLUDED.max_error_rate,
+			auto_rollback = EXCLUDED.auto_rollback,
+			require_stable = EXCLUDED.require_stable,
+			updated_at = EXCLUDED.updated_at
+		RETURNING created_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		policy.EnvironmentID, policy.DurationMinutes, policy.MaxRestarts, policy.MaxErrorRate,
+		policy.AutoRollback, policy.RequireStable, now,
+	).Scan(&policy.CreatedAt)
+}
+
+// DeletePolicy removes the soak policy of an environment
+func (r *SoakRepository) DeletePolicy(ctx context.Context, environmentID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM environment_soak_policies WHERE environment_id = $1`, environmentID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Begin records the start of a deployment's soak. It reports false when the
+// deployment already has one, so a deployment is only soaked once.
+func (r *SoakRepository) Begin(ctx context.Context, soak *types.DeploymentSoak) (bool, error) {
+	soak.Status = types.SoakStatusSoaking
+
+	query := `
+		INSERT INTO deployment_soaks (
+			deployment_id, environment_id, release_id, status, started_at, ends_at, baseline_restarts
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (deployment_id) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query,
+		soak.DeploymentID, soak.EnvironmentID, soak.ReleaseID, soak.Status,
+		soak.StartedAt, soak.EndsAt, soak.BaselineRestarts,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// Get retrieves the soak of a deployment
+func (r *SoakRepository) Get(ctx context.Context, deploymentID uuid.UUID) (*types.DeploymentSoak, error) {
+	query := `SELECT ` + deploymentSoakColumns + ` FROM deployment_soaks WHERE deployment_id = $1`
+	return scanDeploymentSoak(r.db.QueryRowContext(ctx, query, deploymentID))
+}
+
+// ListSoaking retrieves the soaks that are still running
+func (r *SoakRepository) ListSoaking(ctx context.Context) ([]*types.DeploymentSoak, error) {
+	query := `
+		SELECT ` + deploymentSoakColumns + `
+		FROM deployment_soaks
+		WHERE status = 'soaking'
+		ORDER BY started_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var soaks []*types.DeploymentSoak
+	for rows.Next() {
+		soak, err := scanDeploymentSoak(rows)
+		if err != nil {
+			return nil, err
+		}
+		soaks = append(soaks, soak)
+	}
+
+	return soaks, rows.Err()
+}
+
+// Update saves the observations and outcome of a soak. completed_at is set
+// when it leaves the soaking status.
+func (r *SoakRepository) Update(ctx context.Context, soak *types.DeploymentSoak) error {
+	if soak.Status != types.SoakStatusSoaking && soak.CompletedAt == nil {
+		now := time.Now()
+		soak.CompletedAt = &now
+	}
+
+	query := `
+		UPDATE deployment_soaks
+		SET status = $1, restarts = $2, error_rate = $3, message = NULLIF($4, ''),
+			rollback_deployment_id = $5, completed_at = $6
+		WHERE deployment_id = $7
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		soak.Status, soak.Restarts, soak.ErrorRate, soak.Message,
+		soak.RollbackDeploymentID, soak.CompletedAt, soak.DeploymentID,
+	)
+	return err
+}
+
+// IsReleaseStable reports whether a deployment of the release passed a soak
+// in an environment other than the excluded one
+func (r *SoakRepository) IsReleaseStable(ctx context.Context, releaseID, excludeEnvironmentID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM deployment_soaks
+			WHERE release_id = $1 AND environment_id != $2 AND status = 'passed'
+		)
+	`
+
+	var stable bool
+	err := r.db.QueryRowContext(ctx, query, releaseID, excludeEnvironmentID).Scan(&stable)
+	return stable, err
+}
+
+// HasPassed reports whether the release already passed a soak in the environment
+func (r *SoakRepository) HasPassed(ctx context.Context, releaseID, environmentID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM deployment_soaks
+			WHERE release_id = $1 AND environment_id = $2 AND status = 'passed'
+		)
+	`
+
+	var passed bool
+	err := r.db.QueryRowContext(ctx, query, releaseID, environmentID).Scan(&passed)
+	return passed, err
+}
+
+// IsRollback reports whether the deployment was created to roll back a failed soak
+func (r *SoakRepository) IsRollback(ctx context.Context, deploymentID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM deployment_soaks WHERE rollback_deployment_id = $1)`
+
+	var rollback bool
+	err := r.db.QueryRowContext(ctx, query, deploymentID).Scan(&rollback)
+	return rollback, err
+}
+
+// scanDeploymentSoak scans a deployment soak from a row
+func scanDeploymentSoak(row interface{ Scan(...interface{}) error }) (*types.DeploymentSoak, error) {
+	soak := &types.DeploymentSoak{}
+	var errorRate sql.NullFloat64
+	var message sql.NullString
+	var rollbackID uuid.NullUUID
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&soak.DeploymentID, &soak.EnvironmentID, &soak.ReleaseID, &soak.Status, &soak.StartedAt, &soak.EndsAt,
+		&soak.BaselineRestarts, &soak.Restarts, &errorRate, &message, &rollbackID, &completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if errorRate.Valid {
+		soak.ErrorRate = &errorRate.Float64
+	}
+	soak.Message = message.String
+	if rollbackID.Valid {
+		soak.RollbackDeploymentID = &rollbackID.UUID
+	}
+	if completedAt.Valid {
+		soak.CompletedAt = &completedAt.Time
+	}
+
+	return soak, nil
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// StatusPageRepository handles the public status pages of projects
+type StatusPageRepository struct {
+	db DBTX
+}
+
+// NewStatusPageRepository creates a new StatusPageRepository
+func NewStatusPageRepository(db DBTX) *StatusPageRepository {
+	return &StatusPageRepository{db: db}
+}
+
+// NewStatusPageRepositoryWithTx creates a repository using a transaction
+func NewStatusPageRepositoryWithTx(tx DBTX) *StatusPageRepository {
+	return &StatusPageRepository{db: tx}
+}
+
+const statusPageColumns = `
+	s.project_id, s.enabled, s.title, COALESCE(s.description, ''), s.environment_id, e.name,
+	COALESCE(s.custom_domain, ''), s.domain_verified_at, s.created_at, s.updated_at
+`
+
+const statusPageJoins = `
+	FROM status_pages s
+	JOIN environments e ON e.id = s.environment_id
+`
+
+// Get retrieves the status page of a project
+func (r *StatusPageRepository) Get(ctx context.Context, projectID uuid.UUID) (*types.StatusPage, error) {
+	query := `SELECT ` + statusPageColumns + statusPageJoins + ` WHERE s.project_id = $1`
+	return scanStatusPage(r.db.QueryRowContext(ctx, query, projectID))
+}
+
+// GetEnabledBySlug retrieves the enabled status page of the project with a
+// slug
+func (r *StatusPageRepository) GetEnabledBySlug(ctx context.Context, slug string) (*types.StatusPage, error) {
+	query := `
+		SELECT ` + statusPageColumns + statusPageJoins + `
+		JOIN projects p ON p.id = s.project_id
+		WHERE p.slug = $1 AND s.enabled = true
+	`
+	return scanStatusPage(r.db.QueryRowContext(ctx, query, slug))
+}
+
+// GetEnabledByDomain retrieves the enabled status page served at a verified
+// custom domain
+func (r *StatusPageRepository) GetEnabledByDomain(ctx context.Context, domain string) (*types.StatusPage, error) {
+	query := `SELECT ` + statusPageColumns + statusPageJoins + `
+		WHERE s.custom_domain = $1 AND s.enabled = true AND s.domain_verified_at IS NOT NULL
+	`
+	return scanStatusPage(r.db.QueryRowContext(ctx, query, domain))
+}
+
+// DomainInUse reports whether another project's status page uses a custom
+// domain
+func (r *StatusPageRepository) DomainInUse(ctx context.Context, domain string, projectID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM status_pages WHERE custom_domain = $1 AND project_id <> $2)`,
+		domain, projectID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check status page domain: %w", err)
+	}
+	return exists, nil
+}
+
+func scanStatusPage(row interface{ Scan(...interface{}) error }) (*types.StatusPage, error) {
+	page := &types.StatusPage{}
+	err := row.Scan(
+		&page.ProjectID, &page.Enabled, &page.Title, &page.Description, &page.EnvironmentID,
+		&page.Environment, &page.CustomDomain, &page.DomainVerifiedAt, &page.CreatedAt, &page.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// Upsert creates or replaces the status page of a project
+func (r *StatusPageRepository) Upsert(ctx context.Context, page *types.StatusPage) error {
+	query := `
+		INSERT INTO status_pages (project_id, enabled, title, description, environment_id, custom_domain, domain_verified_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7)
+		ON CONFLICT (project_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			environment_id = EXCLUDED.environment_id,
+			custom_domain = EXCLUDED.custom_domain,
+			domain_verified_at = EXCLUDED.domain_verified_at,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		page.ProjectID, page.Enabled, page.Title, page.Description, page.EnvironmentID, page.CustomDomain, page.DomainVerifiedAt,
+	).Scan(&page.CreatedAt, &page.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save status page: %w", err)
+	}
+	return nil
+}
+
+// VerifyDomain records that the TXT record of a status page's custom
+// domain was checked. It returns sql.ErrNoRows when the page no longer has
+// that domain.
+func (r *StatusPageRepository) VerifyDomain(ctx context.Context, projectID uuid.UUID, domain string, at time.Time) error {
+	query := `
+		UPDATE status_pages SET domain_verified_at = $3, updated_at = NOW()
+		WHERE project_id = $1 AND custom_domain = $2
+	`
+	return expectRow(r.db.ExecContext(ctx, query, projectID, domain, at))
+}
+
+// Delete removes the status page of a project
+func (r *StatusPageRepository) Delete(ctx context.Context, projectID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_pages WHERE project_id = $1`, projectID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeployFailure is a failed deployment of a service in an environment
+type DeployFailure struct {
+	ServiceID  uuid.UUID
+	FailedAt   time.Time
+	ResolvedAt *time.Time // When a later deployment of the service did not fail; nil while none has
+}
+
+// ListDeployFailures retrieves the deployments of an environment that
+// failed since a time, newest first
+func (r *StatusPageRepository) ListDeployFailures(ctx context.Context, environmentID uuid.UUID, since time.Time, limit int) ([]DeployFailure, error) {
+	query := `
+		SELECT r.service_id, d.updated_at,
+		       (SELECT MIN(d2.created_at)
+		        FROM deployments d2
+		        JOIN releases r2 ON r2.id = d2.release_id
+		        WHERE r2.service_id = r.service_id AND d2.environment_id = d.environment_id
+		          AND d2.created_at > d.created_at AND d2.status <> 'failed')
+		FROM deployments d
+		JOIN releases r ON r.id = d.release_id
+		WHERE d.environment_id = $1 AND d.status = 'failed' AND d.updated_at >= $2
+		ORDER BY d.updated_at DESC
+		LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, environmentID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deploy failures: %w", err)
+	}
+	defer rows.Close()
+
+	failures := []DeployFailure{}
+	for rows.Next() {
+		var failure DeployFailure
+		if err := rows.Scan(&failure.ServiceID, &failure.FailedAt, &failure.ResolvedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deploy failure: %w", err)
+		}
+		failures = append(failures, failure)
+	}
+	return failures, rows.Err()
+}
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DNS providers and statuses
+const (
+	DNSProviderCloudflare = "cloudflare"
+	DNSProviderRoute53    = "route53"
+
+	DNSProviderStatusHealthy = "healthy"
+	DNSProviderStatusInvalid = "invalid"
+)
+
+// DNSProviderCredentials are the API credentials for a team's DNS provider
+type DNSProviderCredentials struct {
+	APIToken        string `json:"api_token,omitempty"`         // Cloudflare
+	AccessKeyID     string `json:"access_key_id,omitempty"`     // Route53
+	SecretAccessKey string `json:"secret_access_key,omitempty"` // Route53
+}
+
+// TeamDNSProvider is a DNS zone a team lets Enclii manage records in
+type TeamDNSProvider struct {
+	ID             uuid.UUID              `json:"id"`
+	TeamID         uuid.UUID              `json:"team_id"`
+	Provider       string                 `json:"provider"` // 'cloudflare', 'route53'
+	Zone           string                 `json:"zone"`     // e.g. "example.com"
+	ZoneID         string                 `json:"zone_id"`  // Provider's zone / hosted zone ID
+	Credentials    DNSProviderCredentials `json:"-"`
+	Status         string                 `json:"status"` // 'healthy', 'invalid'
+	StatusMessage  *string                `json:"status_message,omitempty"`
+	LastCheckedAt  *time.Time             `json:"last_checked_at,omitempty"`
+	CreatedBy      *uuid.UUID             `json:"created_by,omitempty"`
+	CreatedByEmail *string                `json:"created_by_email,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// TeamDNSProviderRepository handles team DNS provider records. Credentials
+// are encrypted with the platform key.
+type TeamDNSProviderRepository struct {
+	db            DBTX
+	encryptionKey []byte
+}
+
+func NewTeamDNSProviderRepository(db DBTX) *TeamDNSProviderRepository {
+	return &TeamDNSProviderRepository{db: db, encryptionKey: getEncryptionKey()}
+}
+
+// NewTeamDNSProviderRepositoryWithTx creates a repository using a transaction
+func NewTeamDNSProviderRepositoryWithTx(tx DBTX) *TeamDNSProviderRepository {
+	return &TeamDNSProviderRepository{db: tx, encryptionKey: getEncryptionKey()}
+}
+
+const teamDNSProviderColumns = `
+	p.id, p.team_id, p.provider, p.zone, p.zone_id, p.credentials_encrypted, p.status,
+	p.status_message, p.last_checked_at, p.created_by, p.created_by_email, p.created_at, p.updated_at
+`
+
+// Create stores a DNS provider for a team. A team has at most one provider
+// per zone.
+func (r *TeamDNSProviderRepository) Create(ctx context.Context, provider *TeamDNSProvider) error {
+	creds, err := json.Marshal(provider.Credentials)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	encrypted, err := sealAESGCM(r.encryptionKey, string(creds))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+
+	provider.ID = uuid.New()
+	provider.CreatedAt = time.Now()
+	provider.UpdatedAt = provider.CreatedAt
+
+	query := `
+		INSERT INTO team_dns_providers (
+			id, team_id, provider, zone, zone_id, credentials_encrypted, status,
+			status_message, last_checked_at, created_by, created_by_email, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	_, err = r.db.ExecContext(ctx, query,
+		provider.ID, provider.TeamID, provider.Provider, provider.Zone, provider.ZoneID, encrypted, provider.Status,
+		provider.StatusMessage, provider.LastCheckedAt, provider.CreatedBy, provider.CreatedByEmail, provider.CreatedAt, provider.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a provider by ID
+func (r *TeamDNSProviderRepository) GetByID(ctx context.Context, id uuid.UUID) (*TeamDNSProvider, error) {
+	query := `SELECT ` + teamDNSProviderColumns + ` FROM team_dns_providers p WHERE p.id = $1`
+	return r.scan(r.db.QueryRowContext(ctx, query, id))
+}
+
+// ListByTeam retrieves the providers of a team
+func (r *TeamDNSProviderRepository) ListByTeam(ctx context.Context, teamID uuid.UUID) ([]*TeamDNSProvider, error) {
+	query := `SELECT ` + teamDNSProviderColumns + ` FROM team_dns_providers p WHERE p.team_id = $1 ORDER BY p.zone`
+
+	rows, err := r.db.QueryContext(ctx, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var providers []*TeamDNSProvider
+	for rows.Next() {
+		provider, err := r.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	return providers, rows.Err()
+}
+
+// GetForServiceDomain finds the provider whose zone holds a domain, among
+// those of the team that owns a service. The most specific zone wins.
+func (r *TeamDNSProviderRepository) GetForServiceDomain(ctx context.Context, serviceID uuid.UUID, domain string) (*TeamDNSProvider, error) {
+	query := `
+		SELECT ` + teamDNSProviderColumns + `
+		FROM team_dns_providers p
+		JOIN projects pr ON pr.team_id = p.team_id
+		JOIN services s ON s.project_id = pr.id
+		WHERE s.id = $1 AND ($2 = p.zone OR $2 LIKE '%.' || p.zone)
+		ORDER BY length(p.zone) DESC
+		LIMIT 1
+	`
+	return r.scan(r.db.QueryRowContext(ctx, query, serviceID, domain))
+}
+
+// UpdateStatus records the result of a credentials check
+func (r *TeamDNSProviderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string, message *string, checkedAt time.Time) error {
+	query := `
+		UPDATE team_dns_providers
+		SET status = $1, status_message = $2, last_checked_at = $3, updated_at = NOW()
+		WHERE id = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, status, message, checkedAt, id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Delete removes a provider. Domains it managed keep their records and fall
+// back to manual DNS.
+func (r *TeamDNSProviderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM team_dns_providers WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE custom_domains
+		SET dns_mode = 'manual', dns_record_id = NULL, dns_status = NULL, dns_status_message = NULL, updated_at = NOW()
+		WHERE dns_mode = 'managed' AND dns_provider_id IS NULL
+	`)
+	return err
+}
+
+// scan scans a provider from a row and decrypts its credentials
+func (r *TeamDNSProviderRepository) scan(row interface{ Scan(...interface{}) error }) (*TeamDNSProvider, error) {
+	provider := &TeamDNSProvider{}
+	var encrypted string
+	var statusMessage, createdByEmail sql.NullString
+	var lastCheckedAt sql.NullTime
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(
+		&provider.ID, &provider.TeamID, &provider.Provider, &provider.Zone, &provider.ZoneID, &encrypted, &provider.Status,
+		&statusMessage, &lastCheckedAt, &createdBy, &createdByEmail, &provider.CreatedAt, &provider.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := openAESGCM(r.encryptionKey, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt DNS provider credentials: %w", err)
+	}
+	if err := json.Unmarshal([]byte(creds), &provider.Credentials); err != nil {
+		return nil, fmt.Errorf("failed to decode DNS provider credentials: %w", err)
+	}
+
+	if statusMessage.Valid {
+		provider.StatusMessage = &statusMessage.String
+	}
+	if lastCheckedAt.Valid {
+		provider.LastCheckedAt = &lastCheckedAt.Time
+	}
+	if createdBy.Valid {
+		provider.CreatedBy = &createdBy.UUID
+	}
+	if createdByEmail.Valid {
+		provider.CreatedByEmail = &createdByEmail.String
+	}
+
+	return provider, nil
+}
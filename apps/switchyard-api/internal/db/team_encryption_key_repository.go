@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Encryption key providers and statuses
+const (
+	EncryptionKeyProviderAWSKMS = "aws_kms"
+
+	EncryptionKeyStatusHealthy     = "healthy"
+	EncryptionKeyStatusUnreachable = "unreachable"
+	EncryptionKeyStatusRevoked     = "revoked"
+)
+
+// TeamEncryptionKey is a customer-managed KMS key that wraps the data key
+// protecting a team's secrets and backups
+type TeamEncryptionKey struct {
+	ID             uuid.UUID  `json:"id"`
+	TeamID         uuid.UUID  `json:"team_id"`
+	Provider       string     `json:"provider"` // 'aws_kms'
+	KeyARN         string     `json:"key_arn"`
+	Region         string     `json:"region"`
+	WrappedDataKey string     `json:"-"`      // Team data key encrypted by the customer key
+	Status         string     `json:"status"` // 'healthy', 'unreachable', 'revoked'
+	StatusMessage  *string    `json:"status_message,omitempty"`
+	LastCheckedAt  *time.Time `json:"last_checked_at,omitempty"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	CreatedByEmail *string    `json:"created_by_email,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TeamEncryptionKeyRepository handles customer-managed key records
+type TeamEncryptionKeyRepository struct {
+	db DBTX
+}
+
+func NewTeamEncryptionKeyRepository(db DBTX) *TeamEncryptionKeyRepository {
+	return &TeamEncryptionKeyRepository{db: db}
+}
+
+// NewTeamEncryptionKeyRepositoryWithTx creates a repository using a transaction
+func NewTeamEncryptionKeyRepositoryWithTx(tx DBTX) *TeamEncryptionKeyRepository {
+	return &TeamEncryptionKeyRepository{db: tx}
+}
+
+const teamEncryptionKeyColumns = `
+	k.id, k.team_id, k.provider, k.key_arn, k.region, k.wrapped_data_key, k.status,
+	k.status_message, k.last_checked_at, k.created_by, k.created_by_email, k.created_at, k.updated_at
+`
+
+// Create stores the key of a team. A team has at most one key.
+func (r *TeamEncryptionKeyRepository) Create(ctx context.Context, key *TeamEncryptionKey) error {
+	key.ID = uuid.New()
+	key.CreatedAt = time.Now()
+	key.UpdatedAt = key.CreatedAt
+
+	query := `
+		INSERT INTO team_encryption_keys (
+			id, team_id, provider, key_arn, region, wrapped_data_key, status,
+			status_message, last_checked_at, created_by, created_by_email, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		key.ID, key.TeamID, key.Provider, key.KeyARN, key.Region, key.WrappedDataKey, key.Status,
+		key.StatusMessage, key.LastCheckedAt, key.CreatedBy, key.CreatedByEmail, key.CreatedAt, key.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a key by ID
+func (r *TeamEncryptionKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*TeamEncryptionKey, error) {
+	query := `SELECT ` + teamEncryptionKeyColumns + ` FROM team_encryption_keys k WHERE k.id = $1`
+	return scanTeamEncryptionKey(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetByTeam retrieves the key of a team
+func (r *TeamEncryptionKeyRepository) GetByTeam(ctx context.Context, teamID uuid.UUID) (*TeamEncryptionKey, error) {
+	query := `SELECT ` + teamEncryptionKeyColumns + ` FROM team_encryption_keys k WHERE k.team_id = $1`
+	return scanTeamEncryptionKey(r.db.QueryRowContext(ctx, query, teamID))
+}
+
+// GetByProject retrieves the key of the team that owns a project
+func (r *TeamEncryptionKeyRepository) GetByProject(ctx context.Context, projectID uuid.UUID) (*TeamEncryptionKey, error) {
+	query := `
+		SELECT ` + teamEncryptionKeyColumns + `
+		FROM team_encryption_keys k
+		JOIN projects p ON p.team_id = k.team_id
+		WHERE p.id = $1
+	`
+	return scanTeamEncryptionKey(r.db.QueryRowContext(ctx, query, projectID))
+}
+
+// GetByService retrieves the key of the team that owns a service's project
+func (r *TeamEncryptionKeyRepository) GetByService(ctx context.Context, serviceID uuid.UUID) (*TeamEncryptionKey, error) {
+	query := `
+		SELECT ` + teamEncryptionKeyColumns + `
+		FROM team_encryption_keys k
+		JOIN projects p ON p.team_id = k.team_id
+		JOIN services s ON s.project_id = p.id
+		WHERE s.id = $1
+	`
+	return scanTeamEncryptionKey(r.db.QueryRowContext(ctx, query, serviceID))
+}
+
+// List retrieves all keys for health checks
+func (r *TeamEncryptionKeyRepository) List(ctx context.Context) ([]*TeamEncryptionKey, error) {
+	query := `SELECT ` + teamEncryptionKeyColumns + ` FROM team_encryption_keys k ORDER BY k.created_at`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*TeamEncryptionKey
+	for rows.Next() {
+		key, err := scanTeamEncryptionKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// UpdateStatus records the result of a key health check
+func (r *TeamEncryptionKeyRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string, message *string, checkedAt time.Time) error {
+	query := `
+		UPDATE team_encryption_keys
+		SET status = $1, status_message = $2, last_checked_at = $3, updated_at = NOW()
+		WHERE id = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, status, message, checkedAt, id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Delete removes a key record
+func (r *TeamEncryptionKeyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM team_encryption_keys WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// scanTeamEncryptionKey scans a key from a row
+func scanTeamEncryptionKey(row interface{ Scan(...interface{}) error }) (*TeamEncryptionKey, error) {
+	key := &TeamEncryptionKey{}
+	var statusMessage, createdByEmail sql.NullString
+	var lastCheckedAt sql.NullTime
+	var createdBy uuid.NullUUID
+
+	err := row.Scan(
+		&key.ID, &key.TeamID, &key.Provider, &key.KeyARN, &key.Region, &key.WrappedDataKey, &key.Status,
+		&statusMessage, &lastCheckedAt, &createdBy, &createdByEmail, &key.CreatedAt, &key.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if statusMessage.Valid {
+		key.StatusMessage = &statusMessage.String
+	}
+	if lastCheckedAt.Valid {
+		key.LastCheckedAt = &lastCheckedAt.Time
+	}
+	if createdBy.Valid {
+		key.CreatedBy = &createdBy.UUID
+	}
+	if createdByEmail.Valid {
+		key.CreatedByEmail = &createdByEmail.String
+	}
+
+	return key, nil
+}
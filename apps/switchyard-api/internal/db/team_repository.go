@@ -10,20 +10,22 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // Team represents a team/organization in the system
 type Team struct {
-	ID           uuid.UUID       `json:"id"`
-	Name         string          `json:"name"`
-	Slug         string          `json:"slug"`
-	Description  *string         `json:"description,omitempty"`
-	AvatarURL    *string         `json:"avatar_url,omitempty"`
-	BillingEmail *string         `json:"billing_email,omitempty"`
-	OwnerID      *uuid.UUID      `json:"owner_id,omitempty"`
-	Settings     json.RawMessage `json:"settings,omitempty"`
-	CreatedAt    time.Time       `json:"created_at"`
-	UpdatedAt    time.Time       `json:"updated_at"`
+	ID              uuid.UUID             `json:"id"`
+	Name            string                `json:"name"`
+	Slug            string                `json:"slug"`
+	Description     *string               `json:"description,omitempty"`
+	AvatarURL       *string               `json:"avatar_url,omitempty"`
+	BillingEmail    *string               `json:"billing_email,omitempty"`
+	OwnerID         *uuid.UUID            `json:"owner_id,omitempty"`
+	Settings        json.RawMessage       `json:"settings,omitempty"`
+	TwoFactorPolicy types.TwoFactorPolicy `json:"two_factor_policy"`
+	CreatedAt       time.Time             `json:"created_at"`
+	UpdatedAt       time.Time             `json:"updated_at"`
 }
 
 // TeamMember represents a user's membership in a team
@@ -91,14 +93,17 @@ func (r *TeamRepository) Create(ctx context.Context, team *Team) error {
 	if team.Settings == nil {
 		team.Settings = json.RawMessage("{}")
 	}
+	if team.TwoFactorPolicy == "" {
+		team.TwoFactorPolicy = types.TwoFactorPolicyOff
+	}
 
 	query := `
-		INSERT INTO teams (id, name, slug, description, avatar_url, billing_email, owner_id, settings, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO teams (id, name, slug, description, avatar_url, billing_email, owner_id, settings, two_factor_policy, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		team.ID, team.Name, team.Slug, team.Description, team.AvatarURL,
-		team.BillingEmail, team.OwnerID, team.Settings, team.CreatedAt, team.UpdatedAt,
+		team.BillingEmail, team.OwnerID, team.Settings, team.TwoFactorPolicy, team.CreatedAt, team.UpdatedAt,
 	)
 	return err
 }
@@ -107,13 +112,13 @@ func (r *TeamRepository) Create(ctx context.Context, team *Team) error {
 func (r *TeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*Team, error) {
 	team := &Team{}
 	query := `
-		SELECT id, name, slug, description, avatar_url, billing_email, owner_id, settings, created_at, updated_at
+		SELECT id, name, slug, description, avatar_url, billing_email, owner_id, settings, two_factor_policy, created_at, updated_at
 		FROM teams WHERE id = $1
 	`
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&team.ID, &team.Name, &team.Slug, &team.Description, &team.AvatarURL,
-		&team.BillingEmail, &team.OwnerID, &team.Settings, &team.CreatedAt, &team.UpdatedAt,
+		&team.BillingEmail, &team.OwnerID, &team.Settings, &team.TwoFactorPolicy, &team.CreatedAt, &team.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -126,13 +131,13 @@ func (r *TeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*Team, erro
 func (r *TeamRepository) GetBySlug(ctx context.Context, slug string) (*Team, error) {
 	team := &Team{}
 	query := `
-		SELECT id, name, slug, description, avatar_url, billing_email, owner_id, settings, created_at, updated_at
+		SELECT id, name, slug, description, avatar_url, billing_email, owner_id, settings, two_factor_policy, created_at, updated_at
 		FROM teams WHERE slug = $1
 	`
 
 	err := r.db.QueryRowContext(ctx, query, slug).Scan(
 		&team.ID, &team.Name, &team.Slug, &team.Description, &team.AvatarURL,
-		&team.BillingEmail, &team.OwnerID, &team.Settings, &team.CreatedAt, &team.UpdatedAt,
+		&team.BillingEmail, &team.OwnerID, &team.Settings, &team.TwoFactorPolicy, &team.CreatedAt, &team.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -148,12 +153,12 @@ func (r *TeamRepository) Update(ctx context.Context, team *Team) error {
 	query := `
 		UPDATE teams
 		SET name = $1, slug = $2, description = $3, avatar_url = $4, billing_email = $5,
-		    owner_id = $6, settings = $7, updated_at = $8
-		WHERE id = $9
+		    owner_id = $6, settings = $7, two_factor_policy = $8, updated_at = $9
+		WHERE id = $10
 	`
 	result, err := r.db.ExecContext(ctx, query,
 		team.Name, team.Slug, team.Description, team.AvatarURL, team.BillingEmail,
-		team.OwnerID, team.Settings, team.UpdatedAt, team.ID,
+		team.OwnerID, team.Settings, team.TwoFactorPolicy, team.UpdatedAt, team.ID,
 	)
 	if err != nil {
 		return err
@@ -189,17 +194,32 @@ func (r *TeamRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+const userTeamColumns = `
+		SELECT t.id, t.name, t.slug, t.description, t.avatar_url, t.billing_email, t.owner_id, t.settings, t.two_factor_policy, t.created_at, t.updated_at
+		FROM teams t
+		JOIN team_members tm ON t.id = tm.team_id`
+
+var teamListColumns = listColumns{
+	id:      "t.id",
+	sorts:   map[string]string{"name": "t.name", "created_at": "t.created_at", "updated_at": "t.updated_at"},
+	created: "t.created_at",
+}
+
 // ListByUser returns all teams a user is a member of
 func (r *TeamRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*Team, error) {
-	query := `
-		SELECT t.id, t.name, t.slug, t.description, t.avatar_url, t.billing_email, t.owner_id, t.settings, t.created_at, t.updated_at
-		FROM teams t
-		JOIN team_members tm ON t.id = tm.team_id
+	return r.queryTeams(ctx, userTeamColumns+`
 		WHERE tm.user_id = $1
-		ORDER BY t.name ASC
-	`
+		ORDER BY t.name ASC`, userID)
+}
+
+// ListPageByUser returns a page of the teams a user is a member of
+func (r *TeamRepository) ListPageByUser(ctx context.Context, userID uuid.UUID, params ListParams) ([]*Team, error) {
+	where, args := params.where(teamListColumns, []string{"tm.user_id = $1"}, []any{userID})
+	return r.queryTeams(ctx, userTeamColumns+where, args...)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+func (r *TeamRepository) queryTeams(ctx context.Context, query string, args ...any) ([]*Team, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -210,7 +230,7 @@ func (r *TeamRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*T
 		team := &Team{}
 		err := rows.Scan(
 			&team.ID, &team.Name, &team.Slug, &team.Description, &team.AvatarURL,
-			&team.BillingEmail, &team.OwnerID, &team.Settings, &team.CreatedAt, &team.UpdatedAt,
+			&team.BillingEmail, &team.OwnerID, &team.Settings, &team.TwoFactorPolicy, &team.CreatedAt, &team.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -221,6 +241,276 @@ func (r *TeamRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*T
 	return teams, nil
 }
 
+// GetTwoFactorPolicyByProject returns the two-factor policy of the team that
+// owns a project. Projects without a team have none.
+func (r *TeamRepository) GetTwoFactorPolicyByProject(ctx context.Context, projectID uuid.UUID) (types.TwoFactorPolicy, error) {
+	query := `
+		SELECT t.two_factor_policy
+		FROM projects p
+		JOIN teams t ON t.id = p.team_id
+		WHERE p.id = $1
+	`
+	var policy types.TwoFactorPolicy
+	err := r.db.QueryRowContext(ctx, query, projectID).Scan(&policy)
+	if err == sql.ErrNoRows {
+		return types.TwoFactorPolicyOff, nil
+	}
+	return policy, err
+}
+
+// GetGPUQuota returns the GPU quota of a team; nil means no limit
+func (r *TeamRepository) GetGPUQuota(ctx context.Context, teamID uuid.UUID) (*int, error) {
+	var quota sql.NullInt32
+	err := r.db.QueryRowContext(ctx, `SELECT gpu_quota FROM teams WHERE id = $1`, teamID).Scan(&quota)
+	if err != nil {
+		return nil, err
+	}
+	if !quota.Valid {
+		return nil, nil
+	}
+	value := int(quota.Int32)
+	return &value, nil
+}
+
+// SetGPUQuota sets the GPU quota of a team; nil removes the limit
+func (r *TeamRepository) SetGPUQuota(ctx context.Context, teamID uuid.UUID, quota *int) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE teams SET gpu_quota = $1, updated_at = $2 WHERE id = $3`,
+		quota, time.Now(), teamID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetGPUQuotaByProject returns the team that owns a project and its GPU
+// quota. Returns sql.ErrNoRows for projects without a team.
+func (r *TeamRepository) GetGPUQuotaByProject(ctx context.Context, projectID uuid.UUID) (uuid.UUID, *int, error) {
+	query := `
+		SELECT t.id, t.gpu_quota
+		FROM projects p
+		JOIN teams t ON t.id = p.team_id
+		WHERE p.id = $1
+	`
+	var teamID uuid.UUID
+	var quota sql.NullInt32
+	if err := r.db.QueryRowContext(ctx, query, projectID).Scan(&teamID, &quota); err != nil {
+		return uuid.Nil, nil, err
+	}
+	if !quota.Valid {
+		return teamID, nil, nil
+	}
+	value := int(quota.Int32)
+	return teamID, &value, nil
+}
+
+// GetBuildLimits returns the build limits of a team; nil means no limits
+func (r *TeamRepository) GetBuildLimits(ctx context.Context, teamID uuid.UUID) (*types.TeamBuildLimits, error) {
+	var raw []byte
+	err := r.db.QueryRowContext(ctx, `SELECT build_limits FROM teams WHERE id = $1`, teamID).Scan(&raw)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBuildLimits(raw)
+}
+
+// SetBuildLimits sets the build limits of a team; nil removes them
+func (r *TeamRepository) SetBuildLimits(ctx context.Context, teamID uuid.UUID, limits *types.TeamBuildLimits) error {
+	var raw []byte
+	if limits != nil {
+		var err error
+		if raw, err = json.Marshal(limits); err != nil {
+			return fmt.Errorf("failed to marshal build limits: %w", err)
+		}
+	}
+	result, err := r.db.ExecContext(ctx, `UPDATE teams SET build_limits = $1, updated_at = $2 WHERE id = $3`,
+		raw, time.Now(), teamID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetBuildLimitsByProject returns the build limits of the team that owns a
+// project. Returns sql.ErrNoRows for projects without a team.
+func (r *TeamRepository) GetBuildLimitsByProject(ctx context.Context, projectID uuid.UUID) (*types.TeamBuildLimits, error) {
+	query := `
+		SELECT t.build_limits
+		FROM projects p
+		JOIN teams t ON t.id = p.team_id
+		WHERE p.id = $1
+	`
+	var raw []byte
+	if err := r.db.QueryRowContext(ctx, query, projectID).Scan(&raw); err != nil {
+		return nil, err
+	}
+	return decodeBuildLimits(raw)
+}
+
+func decodeBuildLimits(raw []byte) (*types.TeamBuildLimits, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var limits types.TeamBuildLimits
+	if err := json.Unmarshal(raw, &limits); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal build limits: %w", err)
+	}
+	return &limits, nil
+}
+
+// GPUsInUse adds up the GPUs the services of a team's projects hold: each
+// service's GPUs per pod times the replicas of its latest deployment in an
+// environment, while that deployment is pending or running. The service and
+// environment of a deploy being checked are left out, since the deploy
+// replaces their deployment; pass uuid.Nil to count everything.
+func (r *TeamRepository) GPUsInUse(ctx context.Context, teamID, serviceID, environmentID uuid.UUID) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(latest.gpus * latest.replicas), 0)
+		FROM (
+			SELECT DISTINCT ON (rel.service_id, d.environment_id)
+				rel.service_id, d.environment_id, d.status, d.replicas,
+				COALESCE((s.gpu->>'count')::int, 0) AS gpus
+			FROM deployments d
+			JOIN releases rel ON d.release_id = rel.id
+			JOIN services s ON rel.service_id = s.id
+			JOIN projects p ON s.project_id = p.id
+			WHERE p.team_id = $1 AND s.gpu IS NOT NULL
+			ORDER BY rel.service_id, d.environment_id, d.created_at DESC
+		) latest
+		WHERE latest.status IN ($2, $3)
+		  AND NOT (latest.service_id = $4 AND latest.environment_id = $5)
+	`
+	var used int
+	err := r.db.QueryRowContext(ctx, query, teamID,
+		types.DeploymentStatusPending, types.DeploymentStatusRunning, serviceID, environmentID,
+	).Scan(&used)
+	return used, err
+}
+
+// GetSuspension returns the suspension of a team, or nil when it is not
+// suspended
+func (r *TeamRepository) GetSuspension(ctx context.Context, teamID uuid.UUID) (*types.TeamSuspension, error) {
+	return r.getSuspension(ctx, `$1::uuid`, teamID)
+}
+
+// GetSuspensionByProject returns the suspension of the team that owns a
+// project, or nil when the project has no team or its team is not suspended
+func (r *TeamRepository) GetSuspensionByProject(ctx context.Context, projectID uuid.UUID) (*types.TeamSuspension, error) {
+	return r.getSuspension(ctx, `SELECT team_id FROM projects WHERE id = $1`, projectID)
+}
+
+// suspensionOwners find the team of a resource from the key routes address
+// it by: the slug of a team or project, or the ID of anything else. A
+// member's key is their user ID, and finds a suspended team of theirs.
+var suspensionOwners = map[string]string{
+	"team":     `SELECT id FROM teams WHERE slug = $1`,
+	"project":  `SELECT team_id FROM projects WHERE slug = $1`,
+	"service":  `SELECT p.team_id FROM services s JOIN projects p ON p.id = s.project_id WHERE s.id::text = $1`,
+	"addon":    `SELECT p.team_id FROM database_addons a JOIN projects p ON p.id = a.project_id WHERE a.id::text = $1`,
+	"preview":  `SELECT p.team_id FROM preview_environments pe JOIN projects p ON p.id = pe.project_id WHERE pe.id::text = $1`,
+	"function": `SELECT p.team_id FROM functions f JOIN projects p ON p.id = f.project_id WHERE f.id::text = $1`,
+	"deployment": `SELECT p.team_id FROM deployments d
+		JOIN releases rel ON rel.id = d.release_id
+		JOIN services s ON s.id = rel.service_id
+		JOIN projects p ON p.id = s.project_id
+		WHERE d.id::text = $1`,
+	"release": `SELECT p.team_id FROM releases rel JOIN services s ON s.id = rel.service_id JOIN projects p ON p.id = s.project_id WHERE rel.id::text = $1`,
+	"domain": `SELECT p.team_id FROM custom_domains cd
+		JOIN services s ON s.id = cd.service_id
+		JOIN projects p ON p.id = s.project_id
+		WHERE cd.id::text = $1`,
+	"webhook": `SELECT p.team_id FROM webhook_destinations w JOIN projects p ON p.id = w.project_id WHERE w.id::text = $1`,
+	"member": `SELECT tm.team_id FROM team_members tm JOIN teams t ON t.id = tm.team_id
+		WHERE tm.user_id::text = $1 AND t.suspended_at IS NOT NULL LIMIT 1`,
+}
+
+// GetSuspensionOf returns the suspension of the team that owns a resource
+// of a kind in suspensionOwners, or nil when there is none
+func (r *TeamRepository) GetSuspensionOf(ctx context.Context, kind, key string) (*types.TeamSuspension, error) {
+	owner, ok := suspensionOwners[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource kind %q", kind)
+	}
+	return r.getSuspension(ctx, owner, key)
+}
+
+func (r *TeamRepository) getSuspension(ctx context.Context, owner string, key any) (*types.TeamSuspension, error) {
+	query := `
+		SELECT suspended_at, COALESCE(suspension_reason, ''), COALESCE(suspended_by, '')
+		FROM teams
+		WHERE id = (` + owner + `) AND suspended_at IS NOT NULL
+	`
+	suspension := &types.TeamSuspension{}
+	err := r.db.QueryRowContext(ctx, query, key).Scan(&suspension.SuspendedAt, &suspension.Reason, &suspension.SuspendedBy)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return suspension, nil
+}
+
+// Suspend marks a team suspended; suspending a suspended team updates its
+// reason
+func (r *TeamRepository) Suspend(ctx context.Context, teamID uuid.UUID, suspension *types.TeamSuspension) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE teams
+		SET suspended_at = COALESCE(suspended_at, $1), suspension_reason = $2, suspended_by = $3, updated_at = $1
+		WHERE id = $4
+	`, suspension.SuspendedAt, suspension.Reason, suspension.SuspendedBy, teamID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Unsuspend lifts the suspension of a team
+func (r *TeamRepository) Unsuspend(ctx context.Context, teamID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE teams
+		SET suspended_at = NULL, suspension_reason = NULL, suspended_by = NULL, updated_at = $1
+		WHERE id = $2
+	`, time.Now(), teamID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // TeamMemberRepository handles team membership operations
 type TeamMemberRepository struct {
 	db DBTX
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCodeCount is how many recovery codes a user is given at a time
+const RecoveryCodeCount = 10
+
+// UserTwoFactor is the TOTP authenticator of a user. It is pending until a
+// first code confirms it and EnabledAt is set.
+type UserTwoFactor struct {
+	UserID       uuid.UUID
+	Secret       string // Base32 TOTP secret
+	EnabledAt    *time.Time
+	LastUsedStep int64
+	// FailedAttempts counts wrong codes since the last accepted one; at the
+	// limit verification is locked until LockedUntil
+	FailedAttempts int
+	LockedUntil    *time.Time
+	CreatedAt      time.Time
+}
+
+// TwoFactorRepository handles the TOTP authenticators and recovery codes of
+// users. Secrets are encrypted with the platform key.
+type TwoFactorRepository struct {
+	db            DBTX
+	encryptionKey []byte
+}
+
+func NewTwoFactorRepository(db DBTX) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db, encryptionKey: getEncryptionKey()}
+}
+
+// Get returns the authenticator of a user. It returns sql.ErrNoRows when the
+// user has none.
+func (r *TwoFactorRepository) Get(ctx context.Context, userID uuid.UUID) (*UserTwoFactor, error) {
+	query := `
+		SELECT user_id, secret_encrypted, enabled_at, last_used_step, failed_attempts, locked_until, created_at
+		FROM user_two_factor WHERE user_id = $1
+	`
+	tf := &UserTwoFactor{}
+	var encrypted string
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&tf.UserID, &encrypted, &tf.EnabledAt, &tf.LastUsedStep, &tf.FailedAttempts, &tf.LockedUntil, &tf.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if tf.Secret, err = openAESGCM(r.encryptionKey, encrypted); err != nil {
+		return nil, fmt.Errorf("failed to decrypt two-factor secret: %w", err)
+	}
+	return tf, nil
+}
+
+// IsEnabled reports whether a user has confirmed an authenticator
+func (r *TwoFactorRepository) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var enabled bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM user_two_factor WHERE user_id = $1 AND enabled_at IS NOT NULL)`, userID,
+	).Scan(&enabled)
+	return enabled, err
+}
+
+// SetPending stores a new secret for a user, replacing an unconfirmed one.
+// It returns sql.ErrNoRows when the user already has two-factor
+// authentication enabled.
+func (r *TwoFactorRepository) SetPending(ctx context.Context, userID uuid.UUID, secret string) error {
+	encrypted, err := sealAESGCM(r.encryptionKey, secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt two-factor secret: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_two_factor (user_id, secret_encrypted, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, last_used_step = 0, created_at = NOW(), updated_at = NOW()
+		WHERE user_two_factor.enabled_at IS NULL
+	`
+	return expectRow(r.db.ExecContext(ctx, query, userID, encrypted))
+}
+
+// Enable confirms a pending authenticator with the time step of its first
+// code. It returns sql.ErrNoRows when there is no pending authenticator.
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `
+		UPDATE user_two_factor SET enabled_at = NOW(), last_used_step = $2, updated_at = NOW()
+		WHERE user_id = $1 AND enabled_at IS NULL
+	`
+	return expectRow(r.db.ExecContext(ctx, query, userID, step))
+}
+
+// UseStep records that a code of a time step was accepted. It returns
+// sql.ErrNoRows when a code of that or a later step was already used, so
+// every code works once.
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `
+		UPDATE user_two_factor SET last_used_step = $2, updated_at = NOW()
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2
+	`
+	return expectRow(r.db.ExecContext(ctx, query, userID, step))
+}
+
+// RecordFailure counts a wrong code. The maxAttempts-th wrong code locks
+// verification for lockout and starts the count again.
+func (r *TwoFactorRepository) RecordFailure(ctx context.Context, userID uuid.UUID, maxAttempts int, lockout time.Duration) error {
+	query := `
+		UPDATE user_two_factor SET
+			failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN NOW() + make_interval(secs => $3) ELSE locked_until END,
+			updated_at = NOW()
+		WHERE user_id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, maxAttempts, lockout.Seconds())
+	return err
+}
+
+// ResetFailures clears the wrong codes of a user after an accepted one
+func (r *TwoFactorRepository) ResetFailures(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE user_two_factor SET failed_attempts = 0, updated_at = NOW() WHERE user_id = $1`, userID)
+	return err
+}
+
+// Delete removes the authenticator and recovery codes of a user
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return expectRow(r.db.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID))
+}
+
+// generateRecoveryCode creates a random code such as 7k2xq-m9fzt
+func generateRecoveryCode() (string, error) {
+	bytes := make([]byte, 7)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	raw := strings.ToLower(inviteCodeEncoding.EncodeToString(bytes))[:10]
+	return raw[:5] + "-" + raw[5:], nil
+}
+
+// HashRecoveryCode hashes a recovery code for storage and lookup. Case,
+// spaces and dashes are ignored.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hashBytes := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hashBytes[:])
+}
+
+// ReplaceRecoveryCodes gives a user a new set of recovery codes, voiding
+// their old ones. The codes are returned and only their hashes are stored.
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.db.ExecContext(ctx,
+			`INSERT INTO user_recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, NOW())`,
+			uuid.New(), userID, HashRecoveryCode(code))
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode marks a recovery code used. It returns sql.ErrNoRows for
+// an unknown or used code.
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	query := `
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	return expectRow(r.db.ExecContext(ctx, query, userID, HashRecoveryCode(code)))
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID,
+	).Scan(&count)
+	return count, err
+}
+
+// expectRow turns an update that changed no rows into sql.ErrNoRows
+func expectRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// UptimeCheckRepository handles the uptime checks of services and their
+// results
+type UptimeCheckRepository struct {
+	db DBTX
+}
+
+// NewUptimeCheckRepository creates a new UptimeCheckRepository
+func NewUptimeCheckRepository(db DBTX) *UptimeCheckRepository {
+	return &UptimeCheckRepository{db: db}
+}
+
+// NewUptimeCheckRepositoryWithTx creates a repository using a transaction
+func NewUptimeCheckRepositoryWithTx(tx DBTX) *UptimeCheckRepository {
+	return &UptimeCheckRepository{db: tx}
+}
+
+const uptimeCheckColumns = `
+	u.id, u.service_id, u.environment_id, e.name, u.custom_domain_id, COALESCE(d.domain, ''),
+	u.path, u.expected_status, u.interval_seconds, u.timeout_seconds, u.failure_threshold, u.enabled,
+	u.status, u.status_changed_at, u.consecutive_failures, u.last_checked_at, u.last_status_code,
+	u.last_latency_ms, COALESCE(u.last_error, ''), COALESCE(u.created_by, ''), u.created_at, u.updated_at
+`
+
+const uptimeCheckJoins = `
+	FROM uptime_checks u
+	JOIN environments e ON e.id = u.environment_id
+	LEFT JOIN custom_domains d ON d.id = u.custom_domain_id
+`
+
+// Create inserts a new uptime check
+func (r *UptimeCheckRepository) Create(ctx context.Context, check *types.UptimeCheck) error {
+	query := `
+		INSERT INTO uptime_checks (
+			service_id, environment_id, custom_domain_id, path, expected_status,
+			interval_seconds, timeout_seconds, failure_threshold, enabled, created_by
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
+		RETURNING id, status, created_at, updated_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		check.ServiceID, check.EnvironmentID, check.CustomDomainID, check.Path, check.ExpectedStatus,
+		check.IntervalSeconds, check.TimeoutSeconds, check.FailureThreshold, check.Enabled, check.CreatedBy,
+	).Scan(&check.ID, &check.Status, &check.CreatedAt, &check.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create uptime check: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an uptime check of a service
+func (r *UptimeCheckRepository) GetByID(ctx context.Context, serviceID, id uuid.UUID) (*types.UptimeCheck, error) {
+	query := `SELECT ` + uptimeCheckColumns + uptimeCheckJoins + ` WHERE u.id = $1 AND u.service_id = $2`
+	return scanUptimeCheck(r.db.QueryRowContext(ctx, query, id, serviceID))
+}
+
+// ListByService retrieves the uptime checks of a service, oldest first
+func (r *UptimeCheckRepository) ListByService(ctx context.Context, serviceID uuid.UUID) ([]*types.UptimeCheck, error) {
+	query := `SELECT ` + uptimeCheckColumns + uptimeCheckJoins + ` WHERE u.service_id = $1 ORDER BY u.created_at ASC`
+	return r.list(ctx, query, serviceID)
+}
+
+// ListEnabled retrieves every enabled uptime check
+func (r *UptimeCheckRepository) ListEnabled(ctx context.Context) ([]*types.UptimeCheck, error) {
+	query := `SELECT ` + uptimeCheckColumns + uptimeCheckJoins + ` WHERE u.enabled = true ORDER BY u.service_id, u.created_at`
+	return r.list(ctx, query)
+}
+
+// ListByEnvironment retrieves the enabled uptime checks of an environment
+func (r *UptimeCheckRepository) ListByEnvironment(ctx context.Context, environmentID uuid.UUID) ([]*types.UptimeCheck, error) {
+	query := `SELECT ` + uptimeCheckColumns + uptimeCheckJoins + ` WHERE u.environment_id = $1 AND u.enabled = true ORDER BY u.service_id, u.created_at`
+	return r.list(ctx, query, environmentID)
+}
+
+// ListDue retrieves the enabled uptime checks whose interval has passed
+// since they last ran, longest waiting first
+func (r *UptimeCheckRepository) ListDue(ctx context.Context, now time.Time) ([]*types.UptimeCheck, error) {
+	query := `
+		SELECT ` + uptimeCheckColumns + uptimeCheckJoins + `
+		WHERE u.enabled = true
+		  AND (u.last_checked_at IS NULL OR u.last_checked_at + make_interval(secs => u.interval_seconds) <= $1)
+		ORDER BY u.last_checked_at ASC NULLS FIRST
+	`
+	return r.list(ctx, query, now)
+}
+
+func (r *UptimeCheckRepository) list(ctx context.Context, query string, args ...interface{}) ([]*types.UptimeCheck, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uptime checks: %w", err)
+	}
+	defer rows.Close()
+
+	checks := []*types.UptimeCheck{}
+	for rows.Next() {
+		check, err := scanUptimeCheck(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan uptime check: %w", err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, rows.Err()
+}
+
+func scanUptimeCheck(row interface{ Scan(...interface{}) error }) (*types.UptimeCheck, error) {
+	check := &types.UptimeCheck{}
+	err := row.Scan(
+		&check.ID, &check.ServiceID, &check.EnvironmentID, &check.Environment, &check.CustomDomainID,
+		&check.Domain, &check.Path, &check.ExpectedStatus, &check.IntervalSeconds, &check.TimeoutSeconds,
+		&check.FailureThreshold, &check.Enabled, &check.Status, &check.StatusChangedAt,
+		&check.ConsecutiveFailures, &check.LastCheckedAt, &check.LastStatusCode, &check.LastLatencyMs,
+		&check.LastError, &check.CreatedBy, &check.CreatedAt, &check.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// Update saves the settings of an uptime check. Its environment and
+// domain cannot change.
+func (r *UptimeCheckRepository) Update(ctx context.Context, check *types.UptimeCheck) error {
+	query := `
+		UPDATE uptime_checks
+		SET path = $3, expected_status = $4, interval_seconds = $5, timeout_seconds = $6,
+		    failure_threshold = $7, enabled = $8, updated_at = NOW()
+		WHERE id = $1 AND service_id = $2
+		RETURNING updated_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		check.ID, check.ServiceID, check.Path, check.ExpectedStatus, check.IntervalSeconds,
+		check.TimeoutSeconds, check.FailureThreshold, check.Enabled,
+	).Scan(&check.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update uptime check: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an uptime check of a service along with its results
+func (r *UptimeCheckRepository) Delete(ctx context.Context, serviceID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM uptime_checks WHERE id = $1 AND service_id = $2`, id, serviceID)
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RecordResult stores the outcome of a run along with the check's new
+// state, which the caller has set on check
+func (r *UptimeCheckRepository) RecordResult(ctx context.Context, check *types.UptimeCheck, result *types.UptimeCheckResult) error {
+	query := `
+		WITH result AS (
+			INSERT INTO uptime_check_results (check_id, checked_at, up, status_code, latency_ms, error)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+			RETURNING id
+		)
+		UPDATE uptime_checks
+		SET status = $7, status_changed_at = $8, consecutive_failures = $9,
+		    last_checked_at = $2, last_status_code = $4, last_latency_ms = $5, last_error = NULLIF($6, '')
+		WHERE id = $1
+		RETURNING (SELECT id FROM result)
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		check.ID, result.CheckedAt, result.Up, result.StatusCode, result.LatencyMs, result.Error,
+		check.Status, check.StatusChangedAt, check.ConsecutiveFailures,
+	).Scan(&result.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record uptime check result: %w", err)
+	}
+	result.CheckID = check.ID
+	return nil
+}
+
+// ListResults retrieves the latest results of an uptime check since a
+// time, newest first
+func (r *UptimeCheckRepository) ListResults(ctx context.Context, checkID uuid.UUID, since time.Time, limit int) ([]*types.UptimeCheckResult, error) {
+	query := `
+		SELECT id, check_id, checked_at, up, status_code, latency_ms, COALESCE(error, '')
+		FROM uptime_check_results
+		WHERE check_id = $1 AND checked_at >= $2
+		ORDER BY checked_at DESC
+		LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, checkID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uptime check results: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*types.UptimeCheckResult{}
+	for rows.Next() {
+		result := &types.UptimeCheckResult{}
+		if err := rows.Scan(
+			&result.ID, &result.CheckID, &result.CheckedAt, &result.Up,
+			&result.StatusCode, &result.LatencyMs, &result.Error,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan uptime check result: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// UptimeByService computes the uptime percentages of a service's checks
+// over the last 24 hours, 7 days and 30 days, by check. Checks without
+// results are left out.
+func (r *UptimeCheckRepository) UptimeByService(ctx context.Context, serviceID uuid.UUID, now time.Time) (map[uuid.UUID]*types.UptimePercentages, error) {
+	query := `
+		SELECT res.check_id,
+		       100.0 * COUNT(*) FILTER (WHERE res.up AND res.checked_at >= $2 - INTERVAL '24 hours')
+		             / NULLIF(COUNT(*) FILTER (WHERE res.checked_at >= $2 - INTERVAL '24 hours'), 0),
+		       100.0 * COUNT(*) FILTER (WHERE res.up AND res.checked_at >= $2 - INTERVAL '7 days')
+		             / NULLIF(COUNT(*) FILTER (WHERE res.checked_at >= $2 - INTERVAL '7 days'), 0),
+		       100.0 * COUNT(*) FILTER (WHERE res.up) / COUNT(*)
+		FROM uptime_check_results res
+		JOIN uptime_checks u ON u.id = res.check_id
+		WHERE u.service_id = $1 AND res.checked_at >= $2 - INTERVAL '30 days'
+		GROUP BY res.check_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, serviceID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute uptime: %w", err)
+	}
+	defer rows.Close()
+
+	uptime := make(map[uuid.UUID]*types.UptimePercentages)
+	for rows.Next() {
+		var checkID uuid.UUID
+		percentages := &types.UptimePercentages{}
+		if err := rows.Scan(&checkID, &percentages.Last24h, &percentages.Last7d, &percentages.Last30d); err != nil {
+			return nil, fmt.Errorf("failed to scan uptime: %w", err)
+		}
+		uptime[checkID] = percentages
+	}
+	return uptime, rows.Err()
+}
+
+// ServiceUptimeDay is the uptime of a service's checks on one UTC day
+type ServiceUptimeDay struct {
+	ServiceID uuid.UUID
+	Day       time.Time
+	Uptime    float64
+}
+
+// DailyUptimeByEnvironment computes the uptime percentage of each service
+// of an environment by UTC day since a time, over the results of its
+// enabled checks. Days without results are left out.
+func (r *UptimeCheckRepository) DailyUptimeByEnvironment(ctx context.Context, environmentID uuid.UUID, since time.Time) ([]ServiceUptimeDay, error) {
+	query := `
+		SELECT u.service_id, date_trunc('day', res.checked_at AT TIME ZONE 'UTC'),
+		       100.0 * COUNT(*) FILTER (WHERE res.up) / COUNT(*)
+		FROM uptime_check_results res
+		JOIN uptime_checks u ON u.id = res.check_id
+		WHERE u.environment_id = $1 AND u.enabled = true AND res.checked_at >= $2
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	rows, err := r.db.QueryContext(ctx, query, environmentID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute daily uptime: %w", err)
+	}
+	defer rows.Close()
+
+	days := []ServiceUptimeDay{}
+	for rows.Next() {
+		var day ServiceUptimeDay
+		if err := rows.Scan(&day.ServiceID, &day.Day, &day.Uptime); err != nil {
+			return nil, fmt.Errorf("failed to scan daily uptime: %w", err)
+		}
+		day.Day = time.Date(day.Day.Year(), day.Day.Month(), day.Day.Day(), 0, 0, 0, 0, time.UTC)
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// PruneResults deletes results older than a time and returns how many
+// were deleted
+func (r *UptimeCheckRepository) PruneResults(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM uptime_check_results WHERE checked_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune uptime check results: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -23,12 +23,12 @@ func (r *UserRepository) Create(ctx context.Context, user *types.User) error {
 	user.UpdatedAt = time.Now()
 
 	query := `
-		INSERT INTO users (id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO users (id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		user.ID, user.Email, user.PasswordHash, user.Name, user.Role,
-		user.OIDCSubject, user.OIDCIssuer, user.Active, user.CreatedAt, user.UpdatedAt,
+		user.OIDCSubject, user.OIDCIssuer, user.Active, user.CreatedAt, user.UpdatedAt, user.EmailVerifiedAt,
 	)
 	return err
 }
@@ -36,13 +36,13 @@ func (r *UserRepository) Create(ctx context.Context, user *types.User) error {
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*types.User, error) {
 	user := &types.User{}
 	query := `
-		SELECT id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, last_login_at
+		SELECT id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, last_login_at, email_verified_at
 		FROM users WHERE email = $1
 	`
 
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role,
-		&user.OIDCSubject, &user.OIDCIssuer, &user.Active, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+		&user.OIDCSubject, &user.OIDCIssuer, &user.Active, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt, &user.EmailVerifiedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -55,13 +55,13 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*types.U
 func (r *UserRepository) GetByOIDCIdentity(ctx context.Context, issuer string, subject string) (*types.User, error) {
 	user := &types.User{}
 	query := `
-		SELECT id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, last_login_at
+		SELECT id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, last_login_at, email_verified_at
 		FROM users WHERE oidc_issuer = $1 AND oidc_subject = $2
 	`
 
 	err := r.db.QueryRowContext(ctx, query, issuer, subject).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role,
-		&user.OIDCSubject, &user.OIDCIssuer, &user.Active, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+		&user.OIDCSubject, &user.OIDCIssuer, &user.Active, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt, &user.EmailVerifiedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -73,13 +73,13 @@ func (r *UserRepository) GetByOIDCIdentity(ctx context.Context, issuer string, s
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*types.User, error) {
 	user := &types.User{}
 	query := `
-		SELECT id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, last_login_at
+		SELECT id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, last_login_at, email_verified_at
 		FROM users WHERE id = $1
 	`
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role,
-		&user.OIDCSubject, &user.OIDCIssuer, &user.Active, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+		&user.OIDCSubject, &user.OIDCIssuer, &user.Active, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt, &user.EmailVerifiedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -109,9 +109,16 @@ func (r *UserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID) erro
 	return err
 }
 
+// MarkEmailVerified records that a user proved they own their email address
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1 AND email_verified_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
 func (r *UserRepository) List(ctx context.Context) ([]*types.User, error) {
 	query := `
-		SELECT id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, last_login_at
+		SELECT id, email, password_hash, name, role, oidc_subject, oidc_issuer, active, created_at, updated_at, last_login_at, email_verified_at
 		FROM users ORDER BY created_at DESC
 	`
 
@@ -126,7 +133,7 @@ func (r *UserRepository) List(ctx context.Context) ([]*types.User, error) {
 		user := &types.User{}
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role,
-			&user.OIDCSubject, &user.OIDCIssuer, &user.Active, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+			&user.OIDCSubject, &user.OIDCIssuer, &user.Active, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt, &user.EmailVerifiedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -320,14 +321,14 @@ func (r *WebhookRepository) Update(ctx context.Context, webhook *types.WebhookDe
 			name = $2, webhook_url = $3,
 			telegram_bot_token = $4, telegram_chat_id = $5,
 			custom_headers = $6, events = $7, enabled = $8,
-			updated_at = $9
+			updated_at = $9, signing_secret = $10
 		WHERE id = $1
 	`
 	_, err = r.db.ExecContext(ctx, query,
 		webhook.ID, webhook.Name, webhook.WebhookURL,
 		nullString(webhook.TelegramBotToken), nullString(webhook.TelegramChatID),
 		headersJSON, eventsJSON, webhook.Enabled,
-		webhook.UpdatedAt,
+		webhook.UpdatedAt, nullString(webhook.SigningSecret),
 	)
 	return err
 }
@@ -392,6 +393,9 @@ func (r *WebhookRepository) ResetFailures(ctx context.Context, id uuid.UUID) err
 // Webhook Delivery Repository
 // ============================================================================
 
+// webhookDeliveryColumns are the columns scanned by scanWebhookDelivery
+const webhookDeliveryColumns = `id, webhook_id, event_type, event_id, payload, status, status_code, response_body, error_message, attempted_at, completed_at, duration_ms, attempt_number, next_attempt_at, replay_of`
+
 // CreateDelivery creates a new webhook delivery record
 func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
 	delivery.ID = uuid.New()
@@ -406,50 +410,102 @@ func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *types.
 		INSERT INTO webhook_deliveries (
 			id, webhook_id, event_type, event_id, payload,
 			status, status_code, response_body, error_message,
-			attempted_at, completed_at, duration_ms, attempt_number
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			attempted_at, completed_at, duration_ms, attempt_number, next_attempt_at, replay_of
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 	_, err = r.db.ExecContext(ctx, query,
 		delivery.ID, delivery.WebhookID, delivery.EventType, delivery.EventID, payloadJSON,
 		delivery.Status, delivery.StatusCode, nullString(delivery.ResponseBody), nullString(delivery.ErrorMessage),
 		delivery.AttemptedAt, delivery.CompletedAt, delivery.DurationMs, delivery.AttemptNumber,
+		delivery.NextAttemptAt, delivery.ReplayOf,
 	)
 	return err
 }
 
-// UpdateDelivery updates a webhook delivery record
+// UpdateDelivery updates a webhook delivery record after an attempt
 func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *types.WebhookDelivery) error {
 	query := `
 		UPDATE webhook_deliveries SET
 			status = $2, status_code = $3, response_body = $4, error_message = $5,
-			completed_at = $6, duration_ms = $7
+			attempted_at = $6, completed_at = $7, duration_ms = $8,
+			attempt_number = $9, next_attempt_at = $10
 		WHERE id = $1
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		delivery.ID, delivery.Status, delivery.StatusCode,
 		nullString(delivery.ResponseBody), nullString(delivery.ErrorMessage),
-		delivery.CompletedAt, delivery.DurationMs,
+		delivery.AttemptedAt, delivery.CompletedAt, delivery.DurationMs,
+		delivery.AttemptNumber, delivery.NextAttemptAt,
 	)
 	return err
 }
 
-// ListDeliveries retrieves recent deliveries for a webhook
-func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]*types.WebhookDelivery, error) {
+// ListDeliveries retrieves recent deliveries for a webhook, newest first,
+// optionally only those with status
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status types.WebhookDeliveryStatus, limit int) ([]*types.WebhookDelivery, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 
 	query := `
-		SELECT id, webhook_id, event_type, event_id, payload,
-		       status, status_code, response_body, error_message,
-		       attempted_at, completed_at, duration_ms, attempt_number
+		SELECT ` + webhookDeliveryColumns + `
 		FROM webhook_deliveries
-		WHERE webhook_id = $1
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
 		ORDER BY attempted_at DESC
-		LIMIT $2
+		LIMIT $3
+	`
+	return r.queryDeliveries(ctx, query, webhookID, string(status), limit)
+}
+
+// ListDeadLetters retrieves the deliveries of a project's webhooks that ran
+// out of attempts, newest first
+func (r *WebhookRepository) ListDeadLetters(ctx context.Context, projectID uuid.UUID, limit int) ([]*types.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT d.` + strings.ReplaceAll(webhookDeliveryColumns, ", ", ", d.") + `
+		FROM webhook_deliveries d
+		JOIN webhook_destinations w ON w.id = d.webhook_id
+		WHERE w.project_id = $1 AND d.status = $2
+		ORDER BY d.attempted_at DESC
+		LIMIT $3
+	`
+	return r.queryDeliveries(ctx, query, projectID, types.WebhookDeliveryStatusDead, limit)
+}
+
+// ClaimDueDeliveries takes up to limit retrying deliveries whose next
+// attempt is due. Their next attempt is pushed back by lease, so a claim
+// lost with its instance is picked up again once the lease runs out, and
+// other replicas skip deliveries being claimed at the same time.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*types.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $3)
+		FROM due
+		WHERE d.id = due.id
+		RETURNING d.` + strings.ReplaceAll(webhookDeliveryColumns, ", ", ", d.") + `
 	`
+	return r.queryDeliveries(ctx, query, types.WebhookDeliveryStatusRetrying, limit, lease.Seconds())
+}
 
-	rows, err := r.db.QueryContext(ctx, query, webhookID, limit)
+// GetDelivery retrieves a specific delivery by ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*types.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	return scanWebhookDelivery(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *WebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*types.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -457,102 +513,68 @@ func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.U
 
 	var deliveries []*types.WebhookDelivery
 	for rows.Next() {
-		delivery := &types.WebhookDelivery{}
-		var payloadJSON []byte
-		var eventID sql.NullString
-		var statusCode sql.NullInt64
-		var responseBody, errorMessage sql.NullString
-		var completedAt sql.NullTime
-		var durationMs sql.NullInt64
-
-		err := rows.Scan(
-			&delivery.ID, &delivery.WebhookID, &delivery.EventType, &eventID, &payloadJSON,
-			&delivery.Status, &statusCode, &responseBody, &errorMessage,
-			&delivery.AttemptedAt, &completedAt, &durationMs, &delivery.AttemptNumber,
-		)
+		delivery, err := scanWebhookDelivery(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		// Parse JSON payload
-		json.Unmarshal(payloadJSON, &delivery.Payload)
-
-		// Parse nullable fields
-		if eventID.Valid {
-			parsed, _ := uuid.Parse(eventID.String)
-			delivery.EventID = &parsed
-		}
-		if statusCode.Valid {
-			code := int(statusCode.Int64)
-			delivery.StatusCode = &code
-		}
-		if responseBody.Valid {
-			delivery.ResponseBody = responseBody.String
-		}
-		if errorMessage.Valid {
-			delivery.ErrorMessage = errorMessage.String
-		}
-		if completedAt.Valid {
-			delivery.CompletedAt = &completedAt.Time
-		}
-		if durationMs.Valid {
-			ms := int(durationMs.Int64)
-			delivery.DurationMs = &ms
-		}
-
 		deliveries = append(deliveries, delivery)
 	}
-
 	return deliveries, rows.Err()
 }
 
-// GetDelivery retrieves a specific delivery by ID
-func (r *WebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*types.WebhookDelivery, error) {
-	query := `
-		SELECT id, webhook_id, event_type, event_id, payload, status, status_code,
-		       response_body, error_message, attempted_at, completed_at, duration_ms, attempt_number
-		FROM webhook_deliveries
-		WHERE id = $1`
-
-	var delivery types.WebhookDelivery
-	var eventID sql.NullString
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (*types.WebhookDelivery, error) {
+	delivery := &types.WebhookDelivery{}
+	var payloadJSON []byte
+	var eventID, replayOf sql.NullString
 	var statusCode sql.NullInt64
-	var completedAt sql.NullTime
+	var responseBody, errorMessage sql.NullString
+	var completedAt, nextAttemptAt sql.NullTime
 	var durationMs sql.NullInt64
-	var payloadBytes []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&delivery.ID, &delivery.WebhookID, &delivery.EventType, &eventID, &payloadBytes,
-		&delivery.Status, &statusCode, &delivery.ResponseBody, &delivery.ErrorMessage,
-		&delivery.AttemptedAt, &completedAt, &durationMs, &delivery.AttemptNumber,
+	err := row.Scan(
+		&delivery.ID, &delivery.WebhookID, &delivery.EventType, &eventID, &payloadJSON,
+		&delivery.Status, &statusCode, &responseBody, &errorMessage,
+		&delivery.AttemptedAt, &completedAt, &durationMs, &delivery.AttemptNumber, &nextAttemptAt, &replayOf,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse JSON payload
-	if len(payloadBytes) > 0 {
-		if err := json.Unmarshal(payloadBytes, &delivery.Payload); err != nil {
+	if len(payloadJSON) > 0 {
+		if err := json.Unmarshal(payloadJSON, &delivery.Payload); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
 		}
 	}
 
-	// Handle nullable fields
+	// Parse nullable fields
 	if eventID.Valid {
-		eid, _ := uuid.Parse(eventID.String)
-		delivery.EventID = &eid
+		parsed, _ := uuid.Parse(eventID.String)
+		delivery.EventID = &parsed
+	}
+	if replayOf.Valid {
+		parsed, _ := uuid.Parse(replayOf.String)
+		delivery.ReplayOf = &parsed
 	}
 	if statusCode.Valid {
 		code := int(statusCode.Int64)
 		delivery.StatusCode = &code
 	}
+	if responseBody.Valid {
+		delivery.ResponseBody = responseBody.String
+	}
+	if errorMessage.Valid {
+		delivery.ErrorMessage = errorMessage.String
+	}
 	if completedAt.Valid {
 		delivery.CompletedAt = &completedAt.Time
 	}
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
 	if durationMs.Valid {
 		ms := int(durationMs.Int64)
 		delivery.DurationMs = &ms
 	}
 
-	return &delivery, nil
+	return delivery, nil
 }
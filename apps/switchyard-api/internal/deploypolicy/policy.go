@@ -0,0 +1,283 @@
+// Package deploypolicy enforces the deploy policies of environments. While
+// an environment has a policy, deploys into it are allowed inside one of its
+// weekly deploy windows (or at any time when it has none) and outside the
+// environment's freeze windows. Admins can override a blocked deploy in an
+// emergency with a justification, which is recorded in the audit log.
+// Rollbacks are not subject to policies.
+package deploypolicy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// MinJustificationLength is the shortest justification an override takes
+const MinJustificationLength = 10
+
+var (
+	// ErrOverrideNotAllowed is returned when the policy of the environment
+	// does not allow overrides
+	ErrOverrideNotAllowed = errors.New("the deploy policy of this environment does not allow overrides")
+
+	// ErrOverrideForbidden is returned when someone other than an admin
+	// tries to override a policy
+	ErrOverrideForbidden = errors.New("only admins can override a deploy policy")
+
+	// ErrJustificationRequired is returned when an override's justification
+	// is too short
+	ErrJustificationRequired = fmt.Errorf("an override needs a justification of at least %d characters", MinJustificationLength)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Decision is whether a deploy into an environment may go ahead now
+type Decision struct {
+	Allowed       bool       `json:"allowed"`
+	Reason        string     `json:"reason,omitempty"`          // Why the deploy is blocked
+	NextAllowedAt *time.Time `json:"next_allowed_at,omitempty"` // When deploys are next allowed
+	AllowOverride bool       `json:"allow_override"`            // Whether an admin can override the block
+}
+
+// ValidatePolicy checks the timezone and windows of a policy
+func ValidatePolicy(policy *types.DeployPolicy) error {
+	if _, err := time.LoadLocation(policy.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", policy.Timezone)
+	}
+	for i, window := range policy.Windows {
+		if len(window.Days) == 0 {
+			return fmt.Errorf("window %d has no days", i+1)
+		}
+		for _, day := range window.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("window %d has an unknown day %q; use mon, tue, wed, thu, fri, sat or sun", i+1, day)
+			}
+		}
+		start, err := parseClock(window.Start)
+		if err != nil || start == 24*time.Hour {
+			return fmt.Errorf("window %d has an invalid start %q; use HH:MM", i+1, window.Start)
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return fmt.Errorf("window %d has an invalid end %q; use HH:MM", i+1, window.End)
+		}
+		if end <= start {
+			return fmt.Errorf("window %d must end after it starts; split windows that cross midnight in two", i+1)
+		}
+	}
+	return nil
+}
+
+// parseClock parses an HH:MM time of day, allowing 24:00, into the time
+// since midnight
+func parseClock(value string) (time.Duration, error) {
+	if len(value) != 5 || value[2] != ':' {
+		return 0, fmt.Errorf("invalid time of day %q", value)
+	}
+	hours, err := strconv.Atoi(value[:2])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.Atoi(value[3:])
+	if err != nil {
+		return 0, err
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes != 0) {
+		return 0, fmt.Errorf("invalid time of day %q", value)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// Check decides whether a deploy into an environment may go ahead at a
+// time. Environments without a policy always allow deploys.
+func Check(ctx context.Context, repos *db.Repositories, environmentID uuid.UUID, at time.Time) (*Decision, error) {
+	policy, err := repos.DeployPolicies.Get(ctx, environmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Decision{Allowed: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deploy policy: %w", err)
+	}
+
+	freezes, err := repos.FreezeWindows.ListEndingAfter(ctx, environmentID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list freeze windows: %w", err)
+	}
+
+	return Evaluate(policy, freezes, at), nil
+}
+
+// Evaluate decides whether a policy and the freeze windows of its
+// environment allow a deploy at a time
+func Evaluate(policy *types.DeployPolicy, freezes []*db.FreezeWindow, at time.Time) *Decision {
+	decision := &Decision{AllowOverride: policy.AllowOverride}
+
+	if freeze := activeFreeze(freezes, at); freeze != nil {
+		decision.Reason = "Deploys are frozen"
+		if freeze.Reason != "" {
+			decision.Reason += ": " + freeze.Reason
+		}
+	} else if !inWindow(policy, at) {
+		decision.Reason = "Outside the deploy windows of the environment"
+	} else {
+		decision.Allowed = true
+		return decision
+	}
+
+	decision.NextAllowedAt = nextAllowed(policy, freezes, at)
+	return decision
+}
+
+// CheckOverride checks that an actor may override a blocked deploy with a
+// justification
+func CheckOverride(decision *Decision, role types.Role, justification string) error {
+	if !decision.AllowOverride {
+		return ErrOverrideNotAllowed
+	}
+	if role != types.RoleAdmin {
+		return ErrOverrideForbidden
+	}
+	if len(strings.TrimSpace(justification)) < MinJustificationLength {
+		return ErrJustificationRequired
+	}
+	return nil
+}
+
+// RecordOverride records an emergency override in the audit log. entry
+// names the actor and the deployment; its action and outcome are set here.
+func RecordOverride(ctx context.Context, repos *db.Repositories, entry *types.AuditLog, decision *Decision, justification string) {
+	entry.Action = "deployment.policy_overridden"
+	entry.Outcome = "success"
+	if entry.Context == nil {
+		entry.Context = map[string]interface{}{}
+	}
+	entry.Context["justification"] = strings.TrimSpace(justification)
+	entry.Context["blocked_reason"] = decision.Reason
+	if decision.NextAllowedAt != nil {
+		entry.Context["next_allowed_at"] = *decision.NextAllowedAt
+	}
+	repos.AuditLogs.Log(ctx, entry)
+}
+
+// activeFreeze returns the freeze window a time falls in, if any
+func activeFreeze(freezes []*db.FreezeWindow, at time.Time) *db.FreezeWindow {
+	for _, freeze := range freezes {
+		if !freeze.StartsAt.After(at) && freeze.EndsAt.After(at) {
+			return freeze
+		}
+	}
+	return nil
+}
+
+// inWindow reports whether a time is inside one of the deploy windows of a
+// policy. A policy without windows allows any time.
+func inWindow(policy *types.DeployPolicy, at time.Time) bool {
+	if len(policy.Windows) == 0 {
+		return true
+	}
+	local := at.In(location(policy))
+	sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	for _, window := range policy.Windows {
+		if !onDay(window, local.Weekday()) {
+			continue
+		}
+		start, _ := parseClock(window.Start)
+		end, _ := parseClock(window.End)
+		if sinceMidnight >= start && sinceMidnight < end {
+			return true
+		}
+	}
+	return false
+}
+
+// nextWindowStart returns the first time at or after a time that is inside
+// a deploy window, looking a week ahead
+func nextWindowStart(policy *types.DeployPolicy, at time.Time) (time.Time, bool) {
+	if inWindow(policy, at) {
+		return at, true
+	}
+	loc := location(policy)
+	local := at.In(loc)
+	var next time.Time
+	for day := 0; day <= 7; day++ {
+		date := time.Date(local.Year(), local.Month(), local.Day()+day, 0, 0, 0, 0, loc)
+		for _, window := range policy.Windows {
+			if !onDay(window, date.Weekday()) {
+				continue
+			}
+			start, _ := parseClock(window.Start)
+			candidate := date.Add(start)
+			if candidate.Before(at) {
+				continue
+			}
+			if next.IsZero() || candidate.Before(next) {
+				next = candidate
+			}
+		}
+		if !next.IsZero() {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextAllowed returns when deploys are next allowed: the first time inside
+// a deploy window and outside every freeze window
+func nextAllowed(policy *types.DeployPolicy, freezes []*db.FreezeWindow, at time.Time) *time.Time {
+	next := at
+	// Each step moves past a freeze or to the start of a window, and every
+	// freeze is passed at most once
+	for i := 0; i < 2*len(freezes)+2; i++ {
+		if freeze := activeFreeze(freezes, next); freeze != nil {
+			next = freeze.EndsAt
+			continue
+		}
+		start, ok := nextWindowStart(policy, next)
+		if !ok {
+			return nil
+		}
+		if start.Equal(next) {
+			return &next
+		}
+		next = start
+	}
+	if activeFreeze(freezes, next) == nil && inWindow(policy, next) {
+		return &next
+	}
+	return nil
+}
+
+func onDay(window types.DeployWindow, weekday time.Weekday) bool {
+	for _, day := range window.Days {
+		if weekdays[strings.ToLower(day)] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func location(policy *types.DeployPolicy) *time.Location {
+	loc, err := time.LoadLocation(policy.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
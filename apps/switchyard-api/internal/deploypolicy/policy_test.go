@@ -0,0 +1,167 @@
+package deploypolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func weekdaysNineToFive() *types.DeployPolicy {
+	return &types.DeployPolicy{
+		Timezone:      "UTC",
+		AllowOverride: true,
+		Windows: []types.DeployWindow{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00"},
+		},
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	if err := ValidatePolicy(weekdaysNineToFive()); err != nil {
+		t.Errorf("ValidatePolicy() error = %v", err)
+	}
+	if err := ValidatePolicy(&types.DeployPolicy{Timezone: "Europe/Berlin"}); err != nil {
+		t.Errorf("ValidatePolicy() without windows error = %v", err)
+	}
+	allDay := &types.DeployPolicy{Timezone: "UTC", Windows: []types.DeployWindow{{Days: []string{"Sat"}, Start: "00:00", End: "24:00"}}}
+	if err := ValidatePolicy(allDay); err != nil {
+		t.Errorf("ValidatePolicy() of a whole day error = %v", err)
+	}
+
+	for name, change := range map[string]func(*types.DeployPolicy){
+		"unknown timezone":     func(p *types.DeployPolicy) { p.Timezone = "Mars/Olympus" },
+		"no days":              func(p *types.DeployPolicy) { p.Windows[0].Days = nil },
+		"unknown day":          func(p *types.DeployPolicy) { p.Windows[0].Days = []string{"monday"} },
+		"malformed start":      func(p *types.DeployPolicy) { p.Windows[0].Start = "9:00" },
+		"minutes out of range": func(p *types.DeployPolicy) { p.Windows[0].End = "17:60" },
+		"end before start":     func(p *types.DeployPolicy) { p.Windows[0].Start, p.Windows[0].End = "22:00", "02:00" },
+		"start at 24:00":       func(p *types.DeployPolicy) { p.Windows[0].Start = "24:00" },
+	} {
+		policy := weekdaysNineToFive()
+		change(policy)
+		if err := ValidatePolicy(policy); err == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	// Friday 2026-10-16
+	friday := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 16, hour, minute, 0, 0, time.UTC)
+	}
+	monday9 := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		policy      *types.DeployPolicy
+		freezes     []*db.FreezeWindow
+		at          time.Time
+		wantAllowed bool
+		wantNext    *time.Time
+	}{
+		{
+			name:        "inside a window",
+			policy:      weekdaysNineToFive(),
+			at:          friday(10, 30),
+			wantAllowed: true,
+		},
+		{
+			name:     "after hours waits for the next weekday",
+			policy:   weekdaysNineToFive(),
+			at:       friday(17, 0),
+			wantNext: &monday9,
+		},
+		{
+			name:     "before hours waits for the window to open",
+			policy:   weekdaysNineToFive(),
+			at:       friday(8, 15),
+			wantNext: timePtr(friday(9, 0)),
+		},
+		{
+			name:        "no windows allows any time",
+			policy:      &types.DeployPolicy{Timezone: "UTC"},
+			at:          friday(23, 0),
+			wantAllowed: true,
+		},
+		{
+			name:   "freeze inside a window",
+			policy: weekdaysNineToFive(),
+			freezes: []*db.FreezeWindow{
+				{StartsAt: friday(9, 0), EndsAt: friday(12, 0), Reason: "Launch"},
+			},
+			at:       friday(10, 0),
+			wantNext: timePtr(friday(12, 0)),
+		},
+		{
+			name:   "freeze past the end of the window waits for the next window",
+			policy: weekdaysNineToFive(),
+			freezes: []*db.FreezeWindow{
+				{StartsAt: friday(16, 0), EndsAt: monday9.Add(2 * time.Hour)},
+			},
+			at:       friday(16, 30),
+			wantNext: timePtr(monday9.Add(2 * time.Hour)),
+		},
+		{
+			name: "windows are in the policy's timezone",
+			policy: &types.DeployPolicy{
+				Timezone: "America/Mexico_City", // UTC-6
+				Windows:  []types.DeployWindow{{Days: []string{"fri"}, Start: "09:00", End: "17:00"}},
+			},
+			at:          friday(16, 0), // 10:00 in Mexico City
+			wantAllowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Evaluate(tt.policy, tt.freezes, tt.at)
+			if decision.Allowed != tt.wantAllowed {
+				t.Fatalf("Evaluate() allowed = %v (%s), want %v", decision.Allowed, decision.Reason, tt.wantAllowed)
+			}
+			if tt.wantAllowed {
+				return
+			}
+			if decision.Reason == "" {
+				t.Error("a blocked deploy has no reason")
+			}
+			if decision.NextAllowedAt == nil || !decision.NextAllowedAt.Equal(*tt.wantNext) {
+				t.Errorf("next allowed at = %v, want %v", decision.NextAllowedAt, *tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestEvaluateFreezeReason(t *testing.T) {
+	at := time.Date(2026, 12, 24, 12, 0, 0, 0, time.UTC)
+	freezes := []*db.FreezeWindow{{StartsAt: at.Add(-time.Hour), EndsAt: at.Add(time.Hour), Reason: "Holidays"}}
+
+	decision := Evaluate(&types.DeployPolicy{Timezone: "UTC"}, freezes, at)
+	if decision.Allowed || decision.Reason != "Deploys are frozen: Holidays" {
+		t.Errorf("Evaluate() = %+v, want frozen for the holidays", decision)
+	}
+}
+
+func TestCheckOverride(t *testing.T) {
+	blocked := &Decision{Reason: "Deploys are frozen", AllowOverride: true}
+
+	if err := CheckOverride(blocked, types.RoleAdmin, "Hotfix for checkout outage"); err != nil {
+		t.Errorf("CheckOverride() by an admin error = %v", err)
+	}
+	if err := CheckOverride(blocked, types.RoleDeveloper, "Hotfix for checkout outage"); err != ErrOverrideForbidden {
+		t.Errorf("CheckOverride() by a developer error = %v, want %v", err, ErrOverrideForbidden)
+	}
+	if err := CheckOverride(blocked, types.RoleAdmin, " fix "); err != ErrJustificationRequired {
+		t.Errorf("CheckOverride() with a short justification error = %v, want %v", err, ErrJustificationRequired)
+	}
+	strict := &Decision{Reason: "Deploys are frozen"}
+	if err := CheckOverride(strict, types.RoleAdmin, "Hotfix for checkout outage"); err != ErrOverrideNotAllowed {
+		t.Errorf("CheckOverride() of a strict policy error = %v, want %v", err, ErrOverrideNotAllowed)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
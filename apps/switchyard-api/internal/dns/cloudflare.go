@@ -0,0 +1,109 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cloudflare"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// cloudflareProvider manages records in a Cloudflare zone with a team's API
+// token. The token needs Zone:Read and DNS:Edit on the zone.
+type cloudflareProvider struct {
+	client *cloudflare.Client
+}
+
+func newCloudflareProvider(p *db.TeamDNSProvider, baseURL string) (*cloudflareProvider, error) {
+	client, err := cloudflare.NewZoneClient(&cloudflare.Config{
+		APIToken: p.Credentials.APIToken,
+		ZoneID:   p.ZoneID,
+		BaseURL:  baseURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cloudflareProvider{client: client}, nil
+}
+
+// Zone returns the zone name
+func (p *cloudflareProvider) Zone(ctx context.Context) (string, error) {
+	return p.client.GetZone(ctx)
+}
+
+// FindRecord returns the record of a type at a name
+func (p *cloudflareProvider) FindRecord(ctx context.Context, name, recordType string) (*Record, error) {
+	found, err := p.client.GetDNSRecordByType(ctx, NormalizeName(name), recordType)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	return fromCloudflare(found), nil
+}
+
+// UpsertRecord creates or overwrites a record. Records are not proxied so
+// that traffic reaches the platform's tunnel unchanged.
+func (p *cloudflareProvider) UpsertRecord(ctx context.Context, record Record) (*Record, error) {
+	input := cloudflare.DNSRecordInput{
+		Type:    record.Type,
+		Name:    NormalizeName(record.Name),
+		Content: NormalizeName(record.Content),
+		TTL:     record.TTL,
+		Comment: ManagedRecordComment,
+	}
+	if input.TTL == 0 {
+		input.TTL = 1 // Automatic
+	}
+
+	id := record.ID
+	if id == "" {
+		existing, err := p.FindRecord(ctx, record.Name, record.Type)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			id = existing.ID
+		}
+	}
+
+	var written *cloudflare.DNSRecord
+	var err error
+	if id != "" {
+		written, err = p.client.UpdateDNSRecord(ctx, id, input)
+	} else {
+		written, err = p.client.CreateDNSRecord(ctx, input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fromCloudflare(written), nil
+}
+
+// DeleteRecord deletes a record by its ID, or by name and type if it has none
+func (p *cloudflareProvider) DeleteRecord(ctx context.Context, record Record) error {
+	id := record.ID
+	if id == "" {
+		existing, err := p.FindRecord(ctx, record.Name, record.Type)
+		if err != nil || existing == nil {
+			return err
+		}
+		id = existing.ID
+	}
+
+	if err := p.client.DeleteDNSRecord(ctx, id); err != nil {
+		// A stale ID fails; the record is gone if nothing is left at the name
+		if existing, findErr := p.FindRecord(ctx, record.Name, record.Type); findErr == nil && existing == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func fromCloudflare(r *cloudflare.DNSRecord) *Record {
+	return &Record{
+		ID:      r.ID,
+		Type:    r.Type,
+		Name:    r.Name,
+		Content: r.Content,
+		TTL:     r.TTL,
+	}
+}
@@ -0,0 +1,123 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// newTestCloudflare returns a Cloudflare provider for zone zone-1 whose API
+// is answered by handler
+func newTestCloudflare(t *testing.T, handler http.HandlerFunc) *cloudflareProvider {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer team-token" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := newCloudflareProvider(&db.TeamDNSProvider{
+		Provider:    db.DNSProviderCloudflare,
+		ZoneID:      "zone-1",
+		Credentials: db.DNSProviderCredentials{APIToken: "team-token"},
+	}, server.URL)
+	if err != nil {
+		t.Fatalf("newCloudflareProvider() error = %v", err)
+	}
+	return p
+}
+
+func TestCloudflareUpsertRecord(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     Record
+		existing   string
+		wantMethod string
+		wantPath   string
+	}{
+		{
+			name:       "creates a new record",
+			record:     Record{Type: "CNAME", Name: "api.example.com", Content: "tunnel.enclii.dev"},
+			wantMethod: http.MethodPost,
+			wantPath:   "/zones/zone-1/dns_records",
+		},
+		{
+			name:       "overwrites a record found by name",
+			record:     Record{Type: "CNAME", Name: "api.example.com", Content: "tunnel.enclii.dev"},
+			existing:   `{"id":"rec-9","type":"CNAME","name":"api.example.com","content":"old.example.net"}`,
+			wantMethod: http.MethodPut,
+			wantPath:   "/zones/zone-1/dns_records/rec-9",
+		},
+		{
+			name:       "overwrites a record by stored ID",
+			record:     Record{ID: "rec-1", Type: "CNAME", Name: "api.example.com", Content: "tunnel.enclii.dev"},
+			wantMethod: http.MethodPut,
+			wantPath:   "/zones/zone-1/dns_records/rec-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written map[string]interface{}
+			p := newTestCloudflare(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					fmt.Fprintf(w, `{"success":true,"result":[%s]}`, tt.existing)
+					return
+				}
+				if r.Method != tt.wantMethod || r.URL.Path != tt.wantPath {
+					t.Errorf("request = %s %s, want %s %s", r.Method, r.URL.Path, tt.wantMethod, tt.wantPath)
+				}
+				_ = json.NewDecoder(r.Body).Decode(&written)
+				fmt.Fprint(w, `{"success":true,"result":{"id":"rec-1","type":"CNAME","name":"api.example.com","content":"tunnel.enclii.dev","ttl":1}}`)
+			})
+
+			got, err := p.UpsertRecord(context.Background(), tt.record)
+			if err != nil {
+				t.Fatalf("UpsertRecord() error = %v", err)
+			}
+			if got.ID != "rec-1" || got.Content != "tunnel.enclii.dev" {
+				t.Errorf("UpsertRecord() = %+v", got)
+			}
+			if written["proxied"] != false || written["comment"] != ManagedRecordComment || written["ttl"] != float64(1) {
+				t.Errorf("written record = %v", written)
+			}
+		})
+	}
+}
+
+func TestCloudflareDeleteRecord(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining string
+		wantErr   bool
+	}{
+		{name: "stale ID, record gone", remaining: ""},
+		{name: "delete failed, record still there", remaining: `{"id":"rec-2","type":"CNAME","name":"api.example.com"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestCloudflare(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"success":false,"errors":[{"code":81044,"message":"Record does not exist."}]}`)
+					return
+				}
+				fmt.Fprintf(w, `{"success":true,"result":[%s]}`, tt.remaining)
+			})
+
+			err := p.DeleteRecord(context.Background(), Record{ID: "rec-1", Type: "CNAME", Name: "api.example.com"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DeleteRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package dns manages DNS records in the zones teams connect to Enclii, so
+// that domains assigned to services can be pointed at the platform without
+// manual DNS changes.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// ManagedRecordComment marks records created by Enclii at providers that
+// support comments
+const ManagedRecordComment = "Managed by Enclii"
+
+// Record is a DNS record at a provider
+type Record struct {
+	ID      string `json:"id,omitempty"` // Provider's record ID
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl,omitempty"`
+}
+
+// Provider manages records in one zone of a DNS provider account
+type Provider interface {
+	// Zone returns the name of the zone, failing if the credentials cannot
+	// reach it
+	Zone(ctx context.Context) (string, error)
+
+	// FindRecord returns the record of a type at a name, or nil if there is none
+	FindRecord(ctx context.Context, name, recordType string) (*Record, error)
+
+	// UpsertRecord creates a record or overwrites the one of the same name and
+	// type, and returns it with its provider ID
+	UpsertRecord(ctx context.Context, record Record) (*Record, error)
+
+	// DeleteRecord deletes a record. Deleting a missing record is not an error.
+	DeleteRecord(ctx context.Context, record Record) error
+}
+
+// ProviderFactory returns the provider for a team's DNS zone
+type ProviderFactory func(provider *db.TeamDNSProvider) (Provider, error)
+
+// Config holds API endpoint overrides, e.g. for tests or proxies
+type Config struct {
+	CloudflareBaseURL string
+	Route53Endpoint   string
+}
+
+// NewProviderFactory returns a ProviderFactory for all supported providers
+func NewProviderFactory(cfg Config) ProviderFactory {
+	return func(p *db.TeamDNSProvider) (Provider, error) {
+		switch p.Provider {
+		case db.DNSProviderCloudflare:
+			return newCloudflareProvider(p, cfg.CloudflareBaseURL)
+		case db.DNSProviderRoute53:
+			return newRoute53Provider(p, cfg.Route53Endpoint)
+		default:
+			return nil, fmt.Errorf("unsupported DNS provider: %s", p.Provider)
+		}
+	}
+}
+
+// ValidateCredentials checks that the credentials a provider needs are set
+func ValidateCredentials(provider string, creds db.DNSProviderCredentials) error {
+	switch provider {
+	case db.DNSProviderCloudflare:
+		if creds.APIToken == "" {
+			return fmt.Errorf("cloudflare requires api_token")
+		}
+	case db.DNSProviderRoute53:
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return fmt.Errorf("route53 requires access_key_id and secret_access_key")
+		}
+	default:
+		return fmt.Errorf("unsupported DNS provider %q (supported: %s, %s)", provider, db.DNSProviderCloudflare, db.DNSProviderRoute53)
+	}
+	return nil
+}
+
+// NormalizeName lowercases a DNS name and strips its trailing dot
+func NormalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// InZone reports whether a domain is the zone apex or below it
+func InZone(domain, zone string) bool {
+	domain, zone = NormalizeName(domain), NormalizeName(zone)
+	return domain == zone || strings.HasSuffix(domain, "."+zone)
+}
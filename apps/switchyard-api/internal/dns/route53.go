@@ -0,0 +1,233 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+const (
+	route53APIVersion      = "2013-04-01"
+	route53DefaultEndpoint = "https://route53.amazonaws.com"
+	route53Namespace       = "https://route53.amazonaws.com/doc/2013-04-01/"
+	route53DefaultTTL      = 300
+)
+
+// route53Provider manages records in a Route53 hosted zone with a team's
+// access key. The key needs route53:GetHostedZone,
+// route53:ListResourceRecordSets, and route53:ChangeResourceRecordSets on
+// the zone.
+type route53Provider struct {
+	zoneID      string
+	endpoint    string
+	credentials aws.Credentials
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+func newRoute53Provider(p *db.TeamDNSProvider, endpoint string) (*route53Provider, error) {
+	if p.Credentials.AccessKeyID == "" || p.Credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("route53: access key ID and secret access key are required")
+	}
+	zoneID := strings.TrimPrefix(p.ZoneID, "/hostedzone/")
+	if zoneID == "" {
+		return nil, fmt.Errorf("route53: hosted zone ID is required")
+	}
+	if endpoint == "" {
+		endpoint = route53DefaultEndpoint
+	}
+
+	return &route53Provider{
+		zoneID:   zoneID,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		credentials: aws.Credentials{
+			AccessKeyID:     p.Credentials.AccessKeyID,
+			SecretAccessKey: p.Credentials.SecretAccessKey,
+		},
+		signer:     v4.NewSigner(),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+type route53ResourceRecordSet struct {
+	Name            string   `xml:"Name"`
+	Type            string   `xml:"Type"`
+	TTL             int      `xml:"TTL,omitempty"`
+	ResourceRecords []string `xml:"ResourceRecords>ResourceRecord>Value"`
+}
+
+type route53Change struct {
+	Action            string                   `xml:"Action"`
+	ResourceRecordSet route53ResourceRecordSet `xml:"ResourceRecordSet"`
+}
+
+type route53ChangeRequest struct {
+	XMLName xml.Name        `xml:"ChangeResourceRecordSetsRequest"`
+	Xmlns   string          `xml:"xmlns,attr"`
+	Comment string          `xml:"ChangeBatch>Comment,omitempty"`
+	Changes []route53Change `xml:"ChangeBatch>Changes>Change"`
+}
+
+// route53Error is an error response from the Route53 API
+type route53Error struct {
+	Status  int
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+func (e *route53Error) Error() string {
+	return fmt.Sprintf("route53: request failed (%d %s): %s", e.Status, e.Code, e.Message)
+}
+
+// Zone returns the hosted zone name
+func (p *route53Provider) Zone(ctx context.Context) (string, error) {
+	var out struct {
+		Name string `xml:"HostedZone>Name"`
+	}
+	if err := p.call(ctx, http.MethodGet, "/hostedzone/"+p.zoneID, nil, nil, &out); err != nil {
+		return "", err
+	}
+	return NormalizeName(out.Name), nil
+}
+
+// FindRecord returns the record of a type at a name. Route53 lists record
+// sets in order starting at the given name, so the first result is checked.
+func (p *route53Provider) FindRecord(ctx context.Context, name, recordType string) (*Record, error) {
+	query := url.Values{}
+	query.Set("name", NormalizeName(name)+".")
+	query.Set("type", recordType)
+	query.Set("maxitems", "1")
+
+	var out struct {
+		RecordSets []route53ResourceRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+	}
+	if err := p.call(ctx, http.MethodGet, "/hostedzone/"+p.zoneID+"/rrset", query, nil, &out); err != nil {
+		return nil, err
+	}
+
+	for _, set := range out.RecordSets {
+		if NormalizeName(set.Name) != NormalizeName(name) || set.Type != recordType {
+			continue
+		}
+		record := &Record{Type: set.Type, Name: NormalizeName(set.Name), TTL: set.TTL}
+		record.ID = route53RecordID(record.Name, record.Type)
+		if len(set.ResourceRecords) > 0 {
+			record.Content = NormalizeName(set.ResourceRecords[0])
+		}
+		return record, nil
+	}
+	return nil, nil
+}
+
+// UpsertRecord creates or overwrites a record set
+func (p *route53Provider) UpsertRecord(ctx context.Context, record Record) (*Record, error) {
+	if record.TTL == 0 {
+		record.TTL = route53DefaultTTL
+	}
+	record.Name = NormalizeName(record.Name)
+	record.Content = NormalizeName(record.Content)
+
+	if err := p.change(ctx, "UPSERT", record); err != nil {
+		return nil, err
+	}
+	record.ID = route53RecordID(record.Name, record.Type)
+	return &record, nil
+}
+
+// DeleteRecord deletes a record set. Route53 only deletes a record set that
+// matches exactly, so the current one is looked up first.
+func (p *route53Provider) DeleteRecord(ctx context.Context, record Record) error {
+	existing, err := p.FindRecord(ctx, record.Name, record.Type)
+	if err != nil || existing == nil {
+		return err
+	}
+	return p.change(ctx, "DELETE", *existing)
+}
+
+// change submits a single-record change batch
+func (p *route53Provider) change(ctx context.Context, action string, record Record) error {
+	req := route53ChangeRequest{
+		Xmlns:   route53Namespace,
+		Comment: ManagedRecordComment,
+		Changes: []route53Change{{
+			Action: action,
+			ResourceRecordSet: route53ResourceRecordSet{
+				Name:            record.Name + ".",
+				Type:            record.Type,
+				TTL:             record.TTL,
+				ResourceRecords: []string{record.Content},
+			},
+		}},
+	}
+
+	body, err := xml.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("route53: failed to encode change batch: %w", err)
+	}
+	return p.call(ctx, http.MethodPost, "/hostedzone/"+p.zoneID+"/rrset/", nil, append([]byte(xml.Header), body...), nil)
+}
+
+// call invokes a Route53 REST API operation signed with SigV4. Route53 is a
+// global service, so requests are always signed for us-east-1.
+func (p *route53Provider) call(ctx context.Context, method, path string, query url.Values, body []byte, out interface{}) error {
+	reqURL := p.endpoint + "/" + route53APIVersion + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("route53: failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+
+	hash := sha256.Sum256(body)
+	if err := p.signer.SignHTTP(ctx, p.credentials, req, hex.EncodeToString(hash[:]), "route53", "us-east-1", time.Now()); err != nil {
+		return fmt.Errorf("route53: failed to sign request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("route53: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("route53: failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &route53Error{Status: resp.StatusCode}
+		_ = xml.Unmarshal(respBody, apiErr)
+		return apiErr
+	}
+
+	if out != nil {
+		if err := xml.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("route53: failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// route53RecordID identifies a record set; Route53 record sets have no ID
+// of their own
+func route53RecordID(name, recordType string) string {
+	return recordType + " " + name
+}
@@ -0,0 +1,190 @@
+package dns
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// newTestRoute53 returns a Route53 provider for hosted zone Z123 whose API
+// is answered by handler
+func newTestRoute53(t *testing.T, handler http.HandlerFunc) *route53Provider {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "/us-east-1/route53/aws4_request") {
+			t.Errorf("request is not SigV4-signed for route53: %q", auth)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := newRoute53Provider(&db.TeamDNSProvider{
+		Provider: db.DNSProviderRoute53,
+		ZoneID:   "/hostedzone/Z123",
+		Credentials: db.DNSProviderCredentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+		},
+	}, server.URL)
+	if err != nil {
+		t.Fatalf("newRoute53Provider() error = %v", err)
+	}
+	return p
+}
+
+func TestRoute53Zone(t *testing.T) {
+	p := newTestRoute53(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2013-04-01/hostedzone/Z123" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `<GetHostedZoneResponse><HostedZone><Id>/hostedzone/Z123</Id><Name>Example.com.</Name></HostedZone></GetHostedZoneResponse>`)
+	})
+
+	zone, err := p.Zone(context.Background())
+	if err != nil {
+		t.Fatalf("Zone() error = %v", err)
+	}
+	if zone != "example.com" {
+		t.Errorf("Zone() = %q, want example.com", zone)
+	}
+}
+
+func TestRoute53FindRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     *Record
+	}{
+		{
+			name:     "found",
+			response: `<ResourceRecordSet><Name>api.example.com.</Name><Type>CNAME</Type><TTL>300</TTL><ResourceRecords><ResourceRecord><Value>tunnel.enclii.dev</Value></ResourceRecord></ResourceRecords></ResourceRecordSet>`,
+			want:     &Record{ID: "CNAME api.example.com", Type: "CNAME", Name: "api.example.com", Content: "tunnel.enclii.dev", TTL: 300},
+		},
+		{
+			name:     "next record in the zone",
+			response: `<ResourceRecordSet><Name>www.example.com.</Name><Type>CNAME</Type><TTL>300</TTL><ResourceRecords><ResourceRecord><Value>example.com</Value></ResourceRecord></ResourceRecords></ResourceRecordSet>`,
+		},
+		{
+			name: "empty zone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestRoute53(t, func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if q.Get("name") != "api.example.com." || q.Get("type") != "CNAME" {
+					t.Errorf("unexpected query %s", r.URL.RawQuery)
+				}
+				fmt.Fprintf(w, `<ListResourceRecordSetsResponse><ResourceRecordSets>%s</ResourceRecordSets></ListResourceRecordSetsResponse>`, tt.response)
+			})
+
+			got, err := p.FindRecord(context.Background(), "api.example.com", "CNAME")
+			if err != nil {
+				t.Fatalf("FindRecord() error = %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("FindRecord() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoute53UpsertRecord(t *testing.T) {
+	var change route53ChangeRequest
+	p := newTestRoute53(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/2013-04-01/hostedzone/Z123/rrset/" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := xml.Unmarshal(body, &change); err != nil {
+			t.Errorf("invalid change batch: %v", err)
+		}
+		fmt.Fprint(w, `<ChangeResourceRecordSetsResponse><ChangeInfo><Id>/change/C1</Id><Status>PENDING</Status></ChangeInfo></ChangeResourceRecordSetsResponse>`)
+	})
+
+	got, err := p.UpsertRecord(context.Background(), Record{Type: "CNAME", Name: "api.example.com", Content: "tunnel.enclii.dev."})
+	if err != nil {
+		t.Fatalf("UpsertRecord() error = %v", err)
+	}
+	if got.ID != "CNAME api.example.com" || got.TTL != route53DefaultTTL {
+		t.Errorf("UpsertRecord() = %+v", got)
+	}
+
+	if len(change.Changes) != 1 {
+		t.Fatalf("got %d changes, want 1", len(change.Changes))
+	}
+	c := change.Changes[0]
+	if c.Action != "UPSERT" || c.ResourceRecordSet.Name != "api.example.com." || c.ResourceRecordSet.Type != "CNAME" {
+		t.Errorf("unexpected change %+v", c)
+	}
+	if len(c.ResourceRecordSet.ResourceRecords) != 1 || c.ResourceRecordSet.ResourceRecords[0] != "tunnel.enclii.dev" {
+		t.Errorf("ResourceRecords = %v", c.ResourceRecordSet.ResourceRecords)
+	}
+}
+
+func TestRoute53DeleteRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    string
+		wantDeleted bool
+	}{
+		{
+			name:        "deletes the current record set",
+			existing:    `<ResourceRecordSet><Name>api.example.com.</Name><Type>CNAME</Type><TTL>60</TTL><ResourceRecords><ResourceRecord><Value>old.example.net</Value></ResourceRecord></ResourceRecords></ResourceRecordSet>`,
+			wantDeleted: true,
+		},
+		{
+			name: "already gone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			p := newTestRoute53(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					fmt.Fprintf(w, `<ListResourceRecordSetsResponse><ResourceRecordSets>%s</ResourceRecordSets></ListResourceRecordSetsResponse>`, tt.existing)
+					return
+				}
+				var change route53ChangeRequest
+				body, _ := io.ReadAll(r.Body)
+				_ = xml.Unmarshal(body, &change)
+				// Route53 rejects a DELETE unless it matches the record set exactly
+				set := change.Changes[0].ResourceRecordSet
+				if change.Changes[0].Action != "DELETE" || set.TTL != 60 || set.ResourceRecords[0] != "old.example.net" {
+					t.Errorf("unexpected change %+v", change.Changes[0])
+				}
+				deleted = true
+				fmt.Fprint(w, `<ChangeResourceRecordSetsResponse/>`)
+			})
+
+			if err := p.DeleteRecord(context.Background(), Record{Type: "CNAME", Name: "api.example.com"}); err != nil {
+				t.Fatalf("DeleteRecord() error = %v", err)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestRoute53Error(t *testing.T) {
+	p := newTestRoute53(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>not authorized</Message></Error></ErrorResponse>`)
+	})
+
+	_, err := p.Zone(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Zone() error = %v, want AccessDenied", err)
+	}
+}
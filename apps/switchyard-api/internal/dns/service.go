@@ -0,0 +1,287 @@
+package dns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// DefaultTunnelCNAME is the record target when a domain has none stored
+const DefaultTunnelCNAME = "tunnel.enclii.dev"
+
+// CheckInterval is how often managed records are checked for drift
+const CheckInterval = 10 * time.Minute
+
+var (
+	// ErrNoProvider is returned when a domain is not in any zone its team
+	// has connected
+	ErrNoProvider = errors.New("no DNS provider is connected for this domain's zone")
+
+	// ErrZoneUnreachable is returned when provider credentials cannot reach
+	// the configured zone
+	ErrZoneUnreachable = errors.New("DNS provider zone is unreachable with these credentials")
+
+	// ErrZoneMismatch is returned when provider credentials reach a zone
+	// other than the one configured
+	ErrZoneMismatch = errors.New("DNS provider zone does not match")
+)
+
+// Service creates, removes, and checks the CNAME records of domains whose
+// DNS is managed through their team's DNS provider
+type Service struct {
+	repos       *db.Repositories
+	providers   ProviderFactory
+	logger      *logrus.Logger
+	tunnelCNAME string
+}
+
+// NewService creates a managed DNS service
+func NewService(repos *db.Repositories, providers ProviderFactory, logger *logrus.Logger) *Service {
+	return &Service{
+		repos:       repos,
+		providers:   providers,
+		logger:      logger,
+		tunnelCNAME: DefaultTunnelCNAME,
+	}
+}
+
+// SetTunnelCNAME sets the target for domains that have none stored
+func (s *Service) SetTunnelCNAME(cname string) {
+	s.tunnelCNAME = cname
+}
+
+// Connect checks a team's provider credentials against its zone and stores
+// the provider. The zone name is taken from the provider when not given.
+func (s *Service) Connect(ctx context.Context, provider *db.TeamDNSProvider) error {
+	if err := ValidateCredentials(provider.Provider, provider.Credentials); err != nil {
+		return err
+	}
+
+	p, err := s.providers(provider)
+	if err != nil {
+		return err
+	}
+	zone, err := p.Zone(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrZoneUnreachable, provider.ZoneID, err)
+	}
+	if provider.Zone == "" {
+		provider.Zone = NormalizeName(zone)
+	} else if NormalizeName(provider.Zone) != NormalizeName(zone) {
+		return fmt.Errorf("%w: zone %s is %s, not %s", ErrZoneMismatch, provider.ZoneID, NormalizeName(zone), provider.Zone)
+	}
+	provider.Zone = NormalizeName(provider.Zone)
+
+	now := time.Now()
+	provider.Status = db.DNSProviderStatusHealthy
+	provider.LastCheckedAt = &now
+	return s.repos.TeamDNSProviders.Create(ctx, provider)
+}
+
+// CheckProvider checks that a provider's credentials still reach its zone
+// and records the result
+func (s *Service) CheckProvider(ctx context.Context, provider *db.TeamDNSProvider) *db.TeamDNSProvider {
+	status, message := db.DNSProviderStatusHealthy, (*string)(nil)
+
+	p, err := s.providers(provider)
+	if err == nil {
+		_, err = p.Zone(ctx)
+	}
+	if err != nil {
+		status = db.DNSProviderStatusInvalid
+		msg := err.Error()
+		message = &msg
+	}
+
+	now := time.Now()
+	if err := s.repos.TeamDNSProviders.UpdateStatus(ctx, provider.ID, status, message, now); err != nil {
+		s.logger.WithError(err).WithField("provider_id", provider.ID).Error("Failed to record DNS provider status")
+	}
+
+	provider.Status = status
+	provider.StatusMessage = message
+	provider.LastCheckedAt = &now
+	return provider
+}
+
+// FindProvider returns the provider of the service's team whose zone holds
+// the domain, or ErrNoProvider
+func (s *Service) FindProvider(ctx context.Context, serviceID uuid.UUID, domain string) (*db.TeamDNSProvider, error) {
+	provider, err := s.repos.TeamDNSProviders.GetForServiceDomain(ctx, serviceID, NormalizeName(domain))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoProvider
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up DNS provider: %w", err)
+	}
+	return provider, nil
+}
+
+// DesiredRecord is the record a managed domain should have
+func (s *Service) DesiredRecord(domain *types.CustomDomain) Record {
+	target := domain.DNSCNAME
+	if target == "" {
+		target = s.tunnelCNAME
+	}
+	return Record{
+		ID:      domain.DNSRecordID,
+		Type:    "CNAME",
+		Name:    NormalizeName(domain.Domain),
+		Content: NormalizeName(target),
+	}
+}
+
+// Apply creates or overwrites the record of a managed domain and records
+// the result. The team controls the zone, so the domain counts as verified
+// once its record is written.
+func (s *Service) Apply(ctx context.Context, domain *types.CustomDomain) error {
+	now := time.Now()
+	domain.DNSCheckedAt = &now
+
+	written, err := s.upsert(ctx, domain)
+	if err != nil {
+		domain.DNSStatus = types.DNSStatusError
+		domain.DNSStatusMessage = err.Error()
+		if updateErr := s.repos.CustomDomains.UpdateDNS(ctx, domain); updateErr != nil {
+			s.logger.WithError(updateErr).WithField("domain", domain.Domain).Error("Failed to record DNS status")
+		}
+		return err
+	}
+
+	domain.DNSRecordID = written.ID
+	domain.DNSStatus = types.DNSStatusInSync
+	domain.DNSStatusMessage = ""
+	if err := s.repos.CustomDomains.UpdateDNS(ctx, domain); err != nil {
+		return err
+	}
+
+	if !domain.Verified {
+		domain.Verified = true
+		domain.VerifiedAt = &now
+		if err := s.repos.CustomDomains.Update(ctx, domain); err != nil {
+			return err
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"domain": domain.Domain,
+		"target": written.Content,
+	}).Info("Managed DNS record written")
+	return nil
+}
+
+// upsert writes the desired record of a domain at its provider
+func (s *Service) upsert(ctx context.Context, domain *types.CustomDomain) (*Record, error) {
+	p, err := s.domainProvider(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	return p.UpsertRecord(ctx, s.DesiredRecord(domain))
+}
+
+// Remove deletes the record of a managed domain, e.g. when the domain is
+// removed from its service
+func (s *Service) Remove(ctx context.Context, domain *types.CustomDomain) error {
+	p, err := s.domainProvider(ctx, domain)
+	if err != nil {
+		return err
+	}
+	return p.DeleteRecord(ctx, s.DesiredRecord(domain))
+}
+
+// Check compares the record of a managed domain at its provider with the
+// desired one and records whether it is in sync. It returns the record found.
+func (s *Service) Check(ctx context.Context, domain *types.CustomDomain) (*Record, error) {
+	want := s.DesiredRecord(domain)
+
+	var got *Record
+	p, err := s.domainProvider(ctx, domain)
+	if err == nil {
+		got, err = p.FindRecord(ctx, want.Name, want.Type)
+	}
+
+	previous := domain.DNSStatus
+	if err != nil {
+		domain.DNSStatus = types.DNSStatusError
+		domain.DNSStatusMessage = err.Error()
+	} else {
+		domain.DNSStatus, domain.DNSStatusMessage = compareRecord(want, got)
+		if got != nil {
+			domain.DNSRecordID = got.ID
+		}
+	}
+	now := time.Now()
+	domain.DNSCheckedAt = &now
+
+	if domain.DNSStatus == types.DNSStatusDrifted && previous != types.DNSStatusDrifted {
+		s.logger.WithFields(logrus.Fields{
+			"domain": domain.Domain,
+			"drift":  domain.DNSStatusMessage,
+		}).Warn("Managed DNS record has drifted")
+	}
+
+	if updateErr := s.repos.CustomDomains.UpdateDNS(ctx, domain); updateErr != nil {
+		return got, updateErr
+	}
+	return got, err
+}
+
+// CheckAll checks every managed record. Records that failed to be written
+// are retried; drifted records are only reported, since the change may have
+// been made on purpose at the provider.
+func (s *Service) CheckAll(ctx context.Context) {
+	domains, err := s.repos.CustomDomains.ListManagedDNS(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list managed DNS domains")
+		return
+	}
+
+	for i := range domains {
+		domain := &domains[i]
+		if domain.DNSStatus == types.DNSStatusError && domain.DNSRecordID == "" {
+			if err := s.Apply(ctx, domain); err != nil {
+				s.logger.WithError(err).WithField("domain", domain.Domain).Warn("Failed to write managed DNS record")
+			}
+			continue
+		}
+		if _, err := s.Check(ctx, domain); err != nil {
+			s.logger.WithError(err).WithField("domain", domain.Domain).Warn("Failed to check managed DNS record")
+		}
+	}
+}
+
+// domainProvider returns the provider client for a managed domain
+func (s *Service) domainProvider(ctx context.Context, domain *types.CustomDomain) (Provider, error) {
+	if domain.DNSProviderID == nil {
+		return nil, ErrNoProvider
+	}
+	provider, err := s.repos.TeamDNSProviders.GetByID(ctx, *domain.DNSProviderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoProvider
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DNS provider: %w", err)
+	}
+	return s.providers(provider)
+}
+
+// compareRecord reports whether the record at the provider matches the
+// desired one, and what differs if not
+func compareRecord(want Record, got *Record) (status, message string) {
+	if got == nil {
+		return types.DNSStatusDrifted, fmt.Sprintf("%s record for %s is missing", want.Type, want.Name)
+	}
+	if NormalizeName(got.Content) != NormalizeName(want.Content) {
+		return types.DNSStatusDrifted, fmt.Sprintf("%s record for %s points to %s, expected %s",
+			want.Type, want.Name, NormalizeName(got.Content), NormalizeName(want.Content))
+	}
+	return types.DNSStatusInSync, ""
+}
@@ -0,0 +1,93 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestCompareRecord(t *testing.T) {
+	want := Record{Type: "CNAME", Name: "api.example.com", Content: "tunnel.enclii.dev"}
+
+	tests := []struct {
+		name       string
+		got        *Record
+		wantStatus string
+	}{
+		{name: "missing", got: nil, wantStatus: types.DNSStatusDrifted},
+		{name: "points elsewhere", got: &Record{Content: "other.example.net"}, wantStatus: types.DNSStatusDrifted},
+		{name: "in sync", got: &Record{Content: "tunnel.enclii.dev"}, wantStatus: types.DNSStatusInSync},
+		{name: "trailing dot and case", got: &Record{Content: "Tunnel.Enclii.dev."}, wantStatus: types.DNSStatusInSync},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := compareRecord(want, tt.got)
+			if status != tt.wantStatus {
+				t.Errorf("compareRecord() status = %q, want %q", status, tt.wantStatus)
+			}
+			if (message == "") != (tt.wantStatus == types.DNSStatusInSync) {
+				t.Errorf("compareRecord() message = %q", message)
+			}
+		})
+	}
+}
+
+func TestDesiredRecord(t *testing.T) {
+	s := NewService(nil, nil, nil)
+
+	got := s.DesiredRecord(&types.CustomDomain{Domain: "API.example.com", DNSCNAME: "tunnel.example.dev", DNSRecordID: "rec-1"})
+	if got != (Record{ID: "rec-1", Type: "CNAME", Name: "api.example.com", Content: "tunnel.example.dev"}) {
+		t.Errorf("DesiredRecord() = %+v", got)
+	}
+
+	got = s.DesiredRecord(&types.CustomDomain{Domain: "api.example.com"})
+	if got.Content != DefaultTunnelCNAME {
+		t.Errorf("DesiredRecord() without a stored target = %q, want %q", got.Content, DefaultTunnelCNAME)
+	}
+}
+
+func TestInZone(t *testing.T) {
+	tests := []struct {
+		domain string
+		zone   string
+		want   bool
+	}{
+		{domain: "example.com", zone: "example.com", want: true},
+		{domain: "api.example.com", zone: "example.com.", want: true},
+		{domain: "api.eu.Example.com", zone: "example.com", want: true},
+		{domain: "badexample.com", zone: "example.com", want: false},
+		{domain: "example.com", zone: "api.example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := InZone(tt.domain, tt.zone); got != tt.want {
+			t.Errorf("InZone(%q, %q) = %v, want %v", tt.domain, tt.zone, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		creds    db.DNSProviderCredentials
+		wantErr  bool
+	}{
+		{name: "cloudflare", provider: db.DNSProviderCloudflare, creds: db.DNSProviderCredentials{APIToken: "token"}},
+		{name: "cloudflare without token", provider: db.DNSProviderCloudflare, creds: db.DNSProviderCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, wantErr: true},
+		{name: "route53", provider: db.DNSProviderRoute53, creds: db.DNSProviderCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}},
+		{name: "route53 without secret", provider: db.DNSProviderRoute53, creds: db.DNSProviderCredentials{AccessKeyID: "AKID"}, wantErr: true},
+		{name: "unsupported", provider: "gandi", creds: db.DNSProviderCredentials{APIToken: "token"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCredentials(tt.provider, tt.creds)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
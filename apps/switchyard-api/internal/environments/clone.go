@@ -0,0 +1,464 @@
+package environments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SyncInterval is how often addon copies of cloned environments are moved along
+const SyncInterval = 30 * time.Second
+
+// ErrEnvironmentExists is returned when the clone's name is already taken in the project
+var ErrEnvironmentExists = errors.New("environment already exists")
+
+// Namespace returns the Kubernetes namespace an environment gets when none is
+// given: enclii-{project_slug}-{env_name}
+func Namespace(projectSlug, envName string) string {
+	return fmt.Sprintf("enclii-%s-%s", projectSlug, normalizeName(envName))
+}
+
+// normalizeName lowercases an environment name and replaces underscores so
+// it can be used in Kubernetes names
+func normalizeName(envName string) string {
+	return strings.ToLower(strings.ReplaceAll(envName, "_", "-"))
+}
+
+// CloneRequest describes the environment to create from a source environment
+type CloneRequest struct {
+	Name          string
+	KubeNamespace string // Empty for the default namespace of the name
+	IncludeAddons bool   // Copy the addons scoped to the source environment
+	CopyAddonData bool   // Load the data of the source addons into the copies
+	UserID        *uuid.UUID
+	UserEmail     string
+}
+
+// CloneResult reports what was copied into a cloned environment
+type CloneResult struct {
+	Environment      *types.Environment            `json:"environment"`
+	EnvVarsCopied    int                           `json:"env_vars_copied"`
+	RoutesCopied     int                           `json:"routes_copied"`
+	SoakPolicyCopied bool                          `json:"soak_policy_copied"`
+	Addons           []*types.EnvironmentAddonCopy `json:"addons"`
+	Warnings         []string                      `json:"warnings,omitempty"`
+}
+
+// Cloner creates environments from existing ones. The environment, its
+// variables, routes and soak policy are copied at once; addon copies are
+// provisioned in the background and bound to the source's services when ready.
+type Cloner struct {
+	repos  *db.Repositories
+	addons *addons.AddonService
+	logger *logrus.Logger
+}
+
+// NewCloner creates the environment cloner
+func NewCloner(repos *db.Repositories, addonService *addons.AddonService, logger *logrus.Logger) *Cloner {
+	return &Cloner{
+		repos:  repos,
+		addons: addonService,
+		logger: logger,
+	}
+}
+
+// CanCopyData reports why the data of an addon cannot be copied, or nil.
+// PostgreSQL addons are cloned from their WAL archive; MySQL and MongoDB
+// addons restore their latest backup.
+func CanCopyData(addon *types.DatabaseAddon) error {
+	switch {
+	case addon.Type == types.DatabaseAddonTypePostgres:
+		if !addon.Config.PITREnabled {
+			return fmt.Errorf("point-in-time recovery is not enabled on %s", addon.Name)
+		}
+		return nil
+	case addons.SupportsBackupJobs(addon.Type):
+		return nil
+	default:
+		return fmt.Errorf("data copy is not supported for %s addons", addon.Type)
+	}
+}
+
+// environmentVars returns the variables set for the source environment
+// itself, ready to be written to the clone. Variables shared by all
+// environments already apply to the clone and are left out.
+func environmentVars(serviceID uuid.UUID, vars []*types.EnvironmentVariable, userID *uuid.UUID, userEmail string) []types.EnvironmentVariable {
+	var copies []types.EnvironmentVariable
+	for _, ev := range vars {
+		if ev.EnvironmentID == nil {
+			continue
+		}
+		copies = append(copies, types.EnvironmentVariable{
+			ServiceID:      serviceID,
+			Key:            ev.Key,
+			Value:          ev.Value,
+			IsSecret:       ev.IsSecret,
+			CreatedBy:      userID,
+			CreatedByEmail: userEmail,
+		})
+	}
+	return copies
+}
+
+// addonCopyName names the copy of an addon. Addon names stay reserved after
+// deletion, so the record ID keeps copies into a recreated environment unique.
+func addonCopyName(source *types.DatabaseAddon, envName string, copyID uuid.UUID) string {
+	return fmt.Sprintf("%s-%s-%s", source.Name, normalizeName(envName), copyID.String()[:8])
+}
+
+// Clone creates an environment from source. Environment variables are
+// decrypted and encrypted again for the new rows. Custom domains are not
+// copied since a domain routes to a single environment. The new environment
+// runs in the source's cluster.
+func (c *Cloner) Clone(ctx context.Context, project *types.Project, source *types.Environment, req *CloneRequest) (*CloneResult, error) {
+	if existing, err := c.repos.Environments.GetByProjectAndName(project.ID, req.Name); err == nil && existing != nil {
+		return nil, ErrEnvironmentExists
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check environment: %w", err)
+	}
+
+	services, err := c.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	kubeNamespace := req.KubeNamespace
+	if kubeNamespace == "" {
+		kubeNamespace = Namespace(project.Slug, req.Name)
+	}
+	env := &types.Environment{
+		ProjectID:     project.ID,
+		Name:          req.Name,
+		KubeNamespace: kubeNamespace,
+		ClusterID:     source.ClusterID,
+		Cluster:       source.Cluster,
+	}
+	result := &CloneResult{Environment: env}
+
+	err = c.repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+		if err := tx.Environments.Create(env); err != nil {
+			return fmt.Errorf("failed to create environment: %w", err)
+		}
+
+		for _, service := range services {
+			vars, err := tx.EnvVars.List(ctx, service.ID, &source.ID)
+			if err != nil {
+				return fmt.Errorf("failed to list environment variables of %s: %w", service.Name, err)
+			}
+			copies := environmentVars(service.ID, vars, req.UserID, req.UserEmail)
+			if err := tx.EnvVars.BulkUpsert(ctx, service.ID, &env.ID, copies); err != nil {
+				return fmt.Errorf("failed to copy environment variables of %s: %w", service.Name, err)
+			}
+			result.EnvVarsCopied += len(copies)
+
+			routes, err := tx.Routes.GetByServiceAndEnvironment(ctx, service.ID.String(), source.ID.String())
+			if err != nil {
+				return fmt.Errorf("failed to list routes of %s: %w", service.Name, err)
+			}
+			for i := range routes {
+				route := &routes[i]
+				route.EnvironmentID = env.ID
+				if err := tx.Routes.Create(ctx, route); err != nil {
+					return fmt.Errorf("failed to copy route %s of %s: %w", route.Path, service.Name, err)
+				}
+			}
+			result.RoutesCopied += len(routes)
+		}
+
+		policy, err := tx.Soaks.GetPolicy(ctx, source.ID)
+		switch {
+		case err == nil:
+			policy.EnvironmentID = env.ID
+			if err := tx.Soaks.UpsertPolicy(ctx, policy); err != nil {
+				return fmt.Errorf("failed to copy soak policy: %w", err)
+			}
+			result.SoakPolicyCopied = true
+		case !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("failed to get soak policy: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"project":     project.Slug,
+		"source":      source.Name,
+		"environment": env.Name,
+		"env_vars":    result.EnvVarsCopied,
+		"routes":      result.RoutesCopied,
+	}).Info("Cloned environment")
+
+	if req.IncludeAddons {
+		c.copyAddons(ctx, project, source, env, req, result)
+	}
+	if result.Addons == nil {
+		result.Addons = []*types.EnvironmentAddonCopy{}
+	}
+
+	return result, nil
+}
+
+// copyAddons starts a copy of each addon scoped to the source environment.
+// Addons shared by all environments already serve the clone. An addon that
+// cannot be copied is reported without undoing the clone.
+func (c *Cloner) copyAddons(ctx context.Context, project *types.Project, source, env *types.Environment, req *CloneRequest, result *CloneResult) {
+	if c.addons == nil {
+		result.Warnings = append(result.Warnings, "Addons were not copied: the addon service is not configured")
+		return
+	}
+
+	projectAddons, err := c.repos.DatabaseAddons.ListByProject(ctx, project.ID)
+	if err != nil {
+		c.logger.WithError(err).WithField("project", project.Slug).Warn("Failed to list addons to copy")
+		result.Warnings = append(result.Warnings, "Addons were not copied: failed to list the project's addons")
+		return
+	}
+
+	for _, addon := range projectAddons {
+		if addon.EnvironmentID == nil || *addon.EnvironmentID != source.ID {
+			continue
+		}
+		if addon.Status == types.DatabaseAddonStatusFailed || addon.Status == types.DatabaseAddonStatusDeleting {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Addon %s was not copied: it is %s", addon.Name, addon.Status))
+			continue
+		}
+
+		addonCopy, err := c.copyAddon(ctx, env, addon, req)
+		if err != nil {
+			c.logger.WithError(err).WithField("addon_id", addon.ID).Warn("Failed to copy addon into cloned environment")
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Addon %s was not copied: %v", addon.Name, err))
+			continue
+		}
+		result.Addons = append(result.Addons, addonCopy)
+	}
+}
+
+// copyAddon creates the copy of one addon, sized and configured like its
+// source, and records it for the sync loop
+func (c *Cloner) copyAddon(ctx context.Context, env *types.Environment, source *types.DatabaseAddon, req *CloneRequest) (*types.EnvironmentAddonCopy, error) {
+	addonCopy := &types.EnvironmentAddonCopy{
+		ID:            uuid.New(),
+		EnvironmentID: env.ID,
+		SourceAddonID: &source.ID,
+		Status:        types.EnvironmentAddonCopyStatusProvisioning,
+		StatusMessage: fmt.Sprintf("Provisioning %s addon from %s", source.Type, source.Name),
+	}
+
+	createReq := &addons.CreateAddonRequest{
+		ProjectID:     env.ProjectID,
+		EnvironmentID: &env.ID,
+		Type:          source.Type,
+		Name:          addonCopyName(source, env.Name, addonCopy.ID),
+		Config:        source.Config,
+		UserID:        req.UserID,
+		UserEmail:     req.UserEmail,
+	}
+
+	if req.CopyAddonData {
+		if err := c.dataCopyBlocker(ctx, source); err != nil {
+			addonCopy.StatusMessage = fmt.Sprintf("Provisioning empty %s addon: %v", source.Type, err)
+		} else {
+			addonCopy.CopyData = true
+			if source.Type == types.DatabaseAddonTypePostgres {
+				createReq.CloneFrom = source
+			}
+		}
+	}
+
+	addon, err := c.addons.CreateAddon(ctx, createReq)
+	if err != nil {
+		return nil, err
+	}
+	addonCopy.AddonID = addon.ID
+
+	if err := c.repos.AddonCopies.Create(ctx, addonCopy); err != nil {
+		if delErr := c.addons.DeleteAddon(ctx, addon.ID); delErr != nil {
+			c.logger.WithError(delErr).WithField("addon_id", addon.ID).Warn("Failed to remove unrecorded addon copy")
+		}
+		return nil, fmt.Errorf("failed to record addon copy: %w", err)
+	}
+
+	return addonCopy, nil
+}
+
+// dataCopyBlocker reports why the data of an addon cannot be copied now,
+// including a missing backup to restore, or nil
+func (c *Cloner) dataCopyBlocker(ctx context.Context, source *types.DatabaseAddon) error {
+	if err := CanCopyData(source); err != nil {
+		return err
+	}
+	if source.Type == types.DatabaseAddonTypePostgres {
+		return nil
+	}
+	backup, err := c.latestBackup(ctx, source.ID)
+	if err != nil {
+		return err
+	}
+	if backup == nil {
+		return fmt.Errorf("%s has no completed backup to restore", source.Name)
+	}
+	return nil
+}
+
+// latestBackup returns the newest completed backup of an addon, or nil
+func (c *Cloner) latestBackup(ctx context.Context, addonID uuid.UUID) (*types.DatabaseAddonBackup, error) {
+	backups, err := c.repos.DatabaseAddons.GetBackupsByAddon(ctx, addonID, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	for _, backup := range backups {
+		if backup.Status == types.DatabaseAddonBackupStatusCompleted {
+			return backup, nil
+		}
+	}
+	return nil, nil
+}
+
+// Sync moves every addon copy that is still being prepared along: once its
+// addon is ready the source's backup is restored into it, and once it holds
+// its data it is bound to the services of the source addon.
+func (c *Cloner) Sync(ctx context.Context) {
+	copies, err := c.repos.AddonCopies.ListInProgress(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list addon copies in progress")
+		return
+	}
+
+	for _, addonCopy := range copies {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := c.sync(ctx, addonCopy); err != nil {
+			c.logger.WithError(err).WithField("addon_copy_id", addonCopy.ID).Warn("Failed to sync addon copy")
+		}
+	}
+}
+
+// sync advances one addon copy. Errors are retried on the next sync;
+// outcomes that cannot change mark the copy failed.
+func (c *Cloner) sync(ctx context.Context, addonCopy *types.EnvironmentAddonCopy) error {
+	addon, err := c.repos.DatabaseAddons.GetByID(ctx, addonCopy.AddonID)
+	if err != nil {
+		return fmt.Errorf("failed to get addon: %w", err)
+	}
+
+	switch addonCopy.Status {
+	case types.EnvironmentAddonCopyStatusProvisioning:
+		switch {
+		case addon.Status == types.DatabaseAddonStatusFailed:
+			return c.fail(ctx, addonCopy, "Provisioning failed: "+addon.StatusMessage)
+		case !addon.IsAvailable():
+			return nil
+		case addonCopy.CopyData && addon.Type != types.DatabaseAddonTypePostgres:
+			return c.restore(ctx, addonCopy, addon)
+		case addonCopy.CopyData:
+			return c.ready(ctx, addonCopy, "Cloned from the source's WAL archive")
+		}
+		return c.ready(ctx, addonCopy, "Provisioned")
+
+	case types.EnvironmentAddonCopyStatusRestoring:
+		if addonCopy.RestoreID == nil {
+			return c.fail(ctx, addonCopy, "Restore record is missing")
+		}
+		restore, err := c.addons.GetRestore(ctx, addon.ID, *addonCopy.RestoreID)
+		if err != nil {
+			return err
+		}
+		switch restore.Status {
+		case types.DatabaseAddonRestoreStatusCompleted:
+			return c.ready(ctx, addonCopy, "Restored from the source's latest backup")
+		case types.DatabaseAddonRestoreStatusFailed:
+			return c.fail(ctx, addonCopy, "Restore failed: "+restore.StatusMessage)
+		}
+	}
+
+	return nil
+}
+
+// restore loads the latest backup of the source addon into its copy
+func (c *Cloner) restore(ctx context.Context, addonCopy *types.EnvironmentAddonCopy, addon *types.DatabaseAddon) error {
+	if addonCopy.SourceAddonID == nil {
+		return c.fail(ctx, addonCopy, "Source addon was deleted before its data was copied")
+	}
+	backup, err := c.latestBackup(ctx, *addonCopy.SourceAddonID)
+	if err != nil {
+		return err
+	}
+	if backup == nil {
+		return c.fail(ctx, addonCopy, "Source addon has no completed backup to restore")
+	}
+
+	restore, err := c.addons.RestoreBackupFrom(ctx, addon.ID, backup.ID, nil, "")
+	if err != nil {
+		return c.fail(ctx, addonCopy, err.Error())
+	}
+
+	addonCopy.Status = types.EnvironmentAddonCopyStatusRestoring
+	addonCopy.StatusMessage = "Restoring the source's latest backup"
+	addonCopy.RestoreID = &restore.ID
+	return c.repos.AddonCopies.Update(ctx, addonCopy)
+}
+
+// ready binds an addon copy to the services of its source, under the same
+// variable names, and marks it ready. The services pick the binding up on
+// their next deploy to the cloned environment.
+func (c *Cloner) ready(ctx context.Context, addonCopy *types.EnvironmentAddonCopy, message string) error {
+	bound := 0
+	if addonCopy.SourceAddonID != nil {
+		bindings, err := c.repos.DatabaseAddons.GetBindingsByAddon(ctx, *addonCopy.SourceAddonID)
+		if err != nil {
+			return fmt.Errorf("failed to list bindings of the source addon: %w", err)
+		}
+		for _, binding := range bindings {
+			if binding.Status != types.DatabaseAddonBindingStatusActive {
+				continue
+			}
+			if _, err := c.addons.CreateBinding(ctx, addonCopy.AddonID, binding.ServiceID, binding.EnvVarName); err != nil {
+				c.logger.WithError(err).WithFields(logrus.Fields{
+					"addon_id":   addonCopy.AddonID,
+					"service_id": binding.ServiceID,
+				}).Warn("Failed to bind addon copy")
+				continue
+			}
+			bound++
+		}
+	}
+
+	addonCopy.Status = types.EnvironmentAddonCopyStatusReady
+	addonCopy.StatusMessage = fmt.Sprintf("%s, bound to %d services", message, bound)
+	if err := c.repos.AddonCopies.Update(ctx, addonCopy); err != nil {
+		return err
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"environment_id": addonCopy.EnvironmentID,
+		"addon_id":       addonCopy.AddonID,
+		"bindings":       bound,
+	}).Info("Addon copy ready")
+	return nil
+}
+
+// fail marks an addon copy failed. The addon is left in place to be filled
+// or deleted by hand.
+func (c *Cloner) fail(ctx context.Context, addonCopy *types.EnvironmentAddonCopy, message string) error {
+	addonCopy.Status = types.EnvironmentAddonCopyStatusFailed
+	addonCopy.StatusMessage = message
+	c.logger.WithFields(logrus.Fields{
+		"environment_id": addonCopy.EnvironmentID,
+		"addon_id":       addonCopy.AddonID,
+	}).Warn("Addon copy failed: " + message)
+	return c.repos.AddonCopies.Update(ctx, addonCopy)
+}
@@ -0,0 +1,106 @@
+package environments
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestNamespace(t *testing.T) {
+	tests := []struct {
+		slug    string
+		envName string
+		want    string
+	}{
+		{slug: "shop", envName: "staging", want: "enclii-shop-staging"},
+		{slug: "shop", envName: "Staging2", want: "enclii-shop-staging2"},
+		{slug: "shop", envName: "load_test", want: "enclii-shop-load-test"},
+	}
+
+	for _, tt := range tests {
+		if got := Namespace(tt.slug, tt.envName); got != tt.want {
+			t.Errorf("Namespace(%q, %q) = %q, want %q", tt.slug, tt.envName, got, tt.want)
+		}
+	}
+}
+
+func TestCanCopyData(t *testing.T) {
+	tests := []struct {
+		name    string
+		addon   *types.DatabaseAddon
+		wantErr bool
+	}{
+		{
+			name:  "postgres with PITR",
+			addon: &types.DatabaseAddon{Name: "db", Type: types.DatabaseAddonTypePostgres, Config: types.DatabaseAddonConfig{PITREnabled: true}},
+		},
+		{
+			name:    "postgres without PITR",
+			addon:   &types.DatabaseAddon{Name: "db", Type: types.DatabaseAddonTypePostgres},
+			wantErr: true,
+		},
+		{name: "mysql", addon: &types.DatabaseAddon{Name: "db", Type: types.DatabaseAddonTypeMySQL}},
+		{name: "mongodb", addon: &types.DatabaseAddon{Name: "db", Type: types.DatabaseAddonTypeMongoDB}},
+		{name: "redis", addon: &types.DatabaseAddon{Name: "cache", Type: types.DatabaseAddonTypeRedis}, wantErr: true},
+		{name: "bucket", addon: &types.DatabaseAddon{Name: "assets", Type: types.DatabaseAddonTypeBucket}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CanCopyData(tt.addon)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CanCopyData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvironmentVars(t *testing.T) {
+	sourceEnvID := uuid.New()
+	serviceID := uuid.New()
+	userID := uuid.New()
+
+	vars := []*types.EnvironmentVariable{
+		{ID: uuid.New(), ServiceID: serviceID, Key: "LOG_LEVEL", Value: "info"},
+		{ID: uuid.New(), ServiceID: serviceID, EnvironmentID: &sourceEnvID, Key: "API_URL", Value: "https://staging.example.com"},
+		{ID: uuid.New(), ServiceID: serviceID, EnvironmentID: &sourceEnvID, Key: "API_KEY", Value: "s3cret", IsSecret: true},
+	}
+
+	got := environmentVars(serviceID, vars, &userID, "dev@example.com")
+	if len(got) != 2 {
+		t.Fatalf("environmentVars() returned %d vars, want 2 (shared vars left out)", len(got))
+	}
+
+	for _, ev := range got {
+		if ev.ID != uuid.Nil {
+			t.Errorf("%s: ID = %s, want a new row", ev.Key, ev.ID)
+		}
+		if ev.ServiceID != serviceID {
+			t.Errorf("%s: ServiceID = %s, want %s", ev.Key, ev.ServiceID, serviceID)
+		}
+		if ev.CreatedBy == nil || *ev.CreatedBy != userID || ev.CreatedByEmail != "dev@example.com" {
+			t.Errorf("%s: created by %v %q, want the cloning user", ev.Key, ev.CreatedBy, ev.CreatedByEmail)
+		}
+	}
+
+	if got[1].Key != "API_KEY" || got[1].Value != "s3cret" || !got[1].IsSecret {
+		t.Errorf("secret var = %+v, want API_KEY copied as a secret", got[1])
+	}
+}
+
+func TestAddonCopyName(t *testing.T) {
+	source := &types.DatabaseAddon{Name: "orders-db"}
+	copyID := uuid.New()
+
+	got := addonCopyName(source, "Load_Test", copyID)
+	want := "orders-db-load-test-" + copyID.String()[:8]
+	if got != want {
+		t.Errorf("addonCopyName() = %q, want %q", got, want)
+	}
+	if strings.ToLower(got) != got {
+		t.Errorf("addonCopyName() = %q, want lowercase", got)
+	}
+}
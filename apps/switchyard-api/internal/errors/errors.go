@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/lib/pq"
 )
 
@@ -83,6 +84,11 @@ var (
 		Message:    "Deployment not found",
 		HTTPStatus: http.StatusNotFound,
 	}
+	ErrSessionNotFound = &AppError{
+		Code:       "SESSION_NOT_FOUND",
+		Message:    "Session not found",
+		HTTPStatus: http.StatusNotFound,
+	}
 
 	// Authentication errors (401)
 	ErrUnauthorized = &AppError{
@@ -110,6 +116,16 @@ var (
 		Message:    "Session has been revoked",
 		HTTPStatus: http.StatusUnauthorized,
 	}
+	ErrInvalidTwoFactorCode = &AppError{
+		Code:       "INVALID_TWO_FACTOR_CODE",
+		Message:    "Invalid or already used two-factor code",
+		HTTPStatus: http.StatusUnauthorized,
+	}
+	ErrTwoFactorLocked = &AppError{
+		Code:       "TWO_FACTOR_LOCKED",
+		Message:    "Too many invalid two-factor codes; try again later",
+		HTTPStatus: http.StatusTooManyRequests,
+	}
 
 	// Authorization errors (403)
 	ErrForbidden = &AppError{
@@ -122,6 +138,16 @@ var (
 		Message:    "Insufficient permissions for this operation",
 		HTTPStatus: http.StatusForbidden,
 	}
+	ErrEmailNotVerified = &AppError{
+		Code:       "EMAIL_NOT_VERIFIED",
+		Message:    "Email address has not been verified",
+		HTTPStatus: http.StatusForbidden,
+	}
+	ErrInviteRequired = &AppError{
+		Code:       "INVITE_REQUIRED",
+		Message:    "Signup requires a valid invite code",
+		HTTPStatus: http.StatusForbidden,
+	}
 
 	// Validation errors (400)
 	ErrValidation = &AppError{
@@ -145,6 +171,20 @@ var (
 		HTTPStatus: http.StatusBadRequest,
 	}
 
+	// Schema validation errors (422)
+	ErrSchemaValidation = &AppError{
+		Code:       "SCHEMA_VALIDATION_FAILED",
+		Message:    "Request does not match the API schema",
+		HTTPStatus: http.StatusUnprocessableEntity,
+	}
+
+	// Quota errors (422)
+	ErrQuotaExceeded = &AppError{
+		Code:       "QUOTA_EXCEEDED",
+		Message:    "Resource quota exceeded",
+		HTTPStatus: http.StatusUnprocessableEntity,
+	}
+
 	// Conflict errors (409)
 	ErrConflict = &AppError{
 		Code:       "CONFLICT",
@@ -166,6 +206,16 @@ var (
 		Message:    "Slug already in use",
 		HTTPStatus: http.StatusConflict,
 	}
+	ErrTwoFactorNotEnabled = &AppError{
+		Code:       "TWO_FACTOR_NOT_ENABLED",
+		Message:    "Two-factor authentication is not enabled",
+		HTTPStatus: http.StatusConflict,
+	}
+	ErrTwoFactorAlreadyEnabled = &AppError{
+		Code:       "TWO_FACTOR_ALREADY_ENABLED",
+		Message:    "Two-factor authentication is already enabled",
+		HTTPStatus: http.StatusConflict,
+	}
 
 	// Build errors (422)
 	ErrBuildFailed = &AppError{
@@ -366,54 +416,63 @@ func WrapDBError(err error, notFoundErr *AppError) *AppError {
 		return ErrDatabaseError.WithError(err)
 	}
 
-	// Check for PostgreSQL-specific errors
+	// Check for PostgreSQL-specific errors, from either driver
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return wrapPostgresError(err, pgErr.Code, pgErr.ConstraintName, pgErr.ColumnName)
+	}
 	var pqErr *pq.Error
 	if errors.As(err, &pqErr) {
-		return wrapPQError(pqErr)
+		return wrapPostgresError(err, string(pqErr.Code), pqErr.Constraint, pqErr.Column)
 	}
 
 	// Default to generic database error
 	return ErrDatabaseError.WithError(err)
 }
 
-// wrapPQError converts PostgreSQL errors to AppErrors
-func wrapPQError(pqErr *pq.Error) *AppError {
-	switch pqErr.Code {
+// wrapPostgresError converts PostgreSQL errors to AppErrors by their
+// SQLSTATE code
+func wrapPostgresError(err error, code, constraint, column string) *AppError {
+	switch code {
 	// Unique constraint violation
 	case "23505":
 		// Extract constraint name to provide more context
-		if strings.Contains(pqErr.Constraint, "email") {
-			return ErrEmailAlreadyExists.WithError(pqErr)
+		if strings.Contains(constraint, "email") {
+			return ErrEmailAlreadyExists.WithError(err)
 		}
-		if strings.Contains(pqErr.Constraint, "slug") {
-			return ErrSlugAlreadyExists.WithError(pqErr)
+		if strings.Contains(constraint, "slug") {
+			return ErrSlugAlreadyExists.WithError(err)
 		}
-		return ErrAlreadyExists.WithError(pqErr)
+		return ErrAlreadyExists.WithError(err)
 
 	// Foreign key violation
 	case "23503":
-		return ErrConflict.WithError(pqErr).WithDetails(map[string]string{
-			"constraint": pqErr.Constraint,
+		return ErrConflict.WithError(err).WithDetails(map[string]string{
+			"constraint": constraint,
 		})
 
 	// Not null violation
 	case "23502":
-		return ErrValidation.WithError(pqErr).WithDetails(map[string]string{
-			"column": pqErr.Column,
+		return ErrValidation.WithError(err).WithDetails(map[string]string{
+			"column": column,
 		})
 
 	// Connection errors
 	case "08000", "08003", "08006", "08001", "08004":
-		return ErrDatabaseConnectionFailed.WithError(pqErr)
+		return ErrDatabaseConnectionFailed.WithError(err)
 
 	// Deadlock
 	case "40P01":
-		return ErrDatabaseError.WithError(pqErr).WithDetails(map[string]string{
+		return ErrDatabaseError.WithError(err).WithDetails(map[string]string{
 			"reason": "deadlock_detected",
 		})
 
+	// Statement timeout
+	case "57014":
+		return ErrDatabaseTimeout.WithError(err)
+
 	default:
-		return ErrDatabaseError.WithError(pqErr)
+		return ErrDatabaseError.WithError(err)
 	}
 }
 
@@ -431,6 +490,10 @@ func IsNotFound(err error) bool {
 
 // IsUniqueViolation checks if an error is a unique constraint violation
 func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
 	var pqErr *pq.Error
 	if errors.As(err, &pqErr) {
 		return pqErr.Code == "23505"
@@ -2,8 +2,12 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
 )
 
 func TestAppError_Error(t *testing.T) {
@@ -252,3 +256,48 @@ func TestPredefinedErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestWrapDBError_PostgresDrivers(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected *AppError
+	}{
+		{
+			name:     "pgx unique violation on slug",
+			err:      fmt.Errorf("create project: %w", &pgconn.PgError{Code: "23505", ConstraintName: "projects_slug_key"}),
+			expected: ErrSlugAlreadyExists,
+		},
+		{
+			name:     "pq unique violation on email",
+			err:      &pq.Error{Code: "23505", Constraint: "users_email_key"},
+			expected: ErrEmailAlreadyExists,
+		},
+		{
+			name:     "pgx statement timeout",
+			err:      &pgconn.PgError{Code: "57014"},
+			expected: ErrDatabaseTimeout,
+		},
+		{
+			name:     "pgx foreign key violation",
+			err:      &pgconn.PgError{Code: "23503"},
+			expected: ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WrapDBError(tt.err, ErrNotFound)
+			if got.Code != tt.expected.Code {
+				t.Errorf("WrapDBError() code = %s, want %s", got.Code, tt.expected.Code)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Error("WrapDBError() should keep the driver error")
+			}
+		})
+	}
+
+	if !IsUniqueViolation(&pgconn.PgError{Code: "23505"}) || !IsUniqueViolation(&pq.Error{Code: "23505"}) {
+		t.Error("IsUniqueViolation() should recognize both drivers")
+	}
+}
@@ -0,0 +1,210 @@
+// Package events provides a fan-out broker for real-time status change events
+// (deployments, builds, addons) consumed by the dashboard via SSE.
+//
+// When Redis is available, events are published to a shared channel so every
+// switchyard-api replica receives them regardless of which replica emitted them.
+// Without Redis, events are delivered in-process only.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
+)
+
+// redisChannel is the Redis pub/sub channel shared by all API replicas
+const redisChannel = "enclii:events"
+
+// subscriberBuffer is the per-subscriber channel capacity; slow subscribers drop events
+const subscriberBuffer = 64
+
+// ResourceType identifies the kind of resource an event refers to
+type ResourceType string
+
+const (
+	ResourceDeployment ResourceType = "deployment"
+	ResourceBuild      ResourceType = "build"
+	ResourceAddon      ResourceType = "addon"
+)
+
+// Event is a status change notification pushed to stream subscribers
+type Event struct {
+	ID            uuid.UUID      `json:"id"`
+	Type          string         `json:"type"` // e.g., "deployment.status", "deployment.progress", "build.status", "addon.status"
+	ResourceType  ResourceType   `json:"resource_type"`
+	ResourceID    uuid.UUID      `json:"resource_id"`
+	ProjectID     *uuid.UUID     `json:"project_id,omitempty"`
+	EnvironmentID *uuid.UUID     `json:"environment_id,omitempty"` // nil for project-wide resources such as builds
+	ServiceID     *uuid.UUID     `json:"service_id,omitempty"`
+	Status        string         `json:"status"`
+	Message       string         `json:"message,omitempty"`
+	Data          map[string]any `json:"data,omitempty"`
+	Timestamp     time.Time      `json:"timestamp"`
+}
+
+// NewStatusEvent creates a status change event for a resource
+func NewStatusEvent(resourceType ResourceType, resourceID uuid.UUID, status string) *Event {
+	return &Event{
+		ID:           uuid.New(),
+		Type:         string(resourceType) + ".status",
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Status:       status,
+		Timestamp:    time.Now(),
+	}
+}
+
+// NewProgressEvent creates an event for a resource moving to another phase
+// of a rollout
+func NewProgressEvent(resourceType ResourceType, resourceID uuid.UUID, phase string) *Event {
+	event := NewStatusEvent(resourceType, resourceID, phase)
+	event.Type = string(resourceType) + ".progress"
+	return event
+}
+
+// Filter selects which events a subscriber receives. Zero values match everything.
+type Filter struct {
+	ProjectID     *uuid.UUID
+	ResourceTypes []ResourceType
+}
+
+// Matches reports whether the event passes the filter
+func (f Filter) Matches(e *Event) bool {
+	if f.ProjectID != nil && (e.ProjectID == nil || *e.ProjectID != *f.ProjectID) {
+		return false
+	}
+	if len(f.ResourceTypes) == 0 {
+		return true
+	}
+	for _, rt := range f.ResourceTypes {
+		if rt == e.ResourceType {
+			return true
+		}
+	}
+	return false
+}
+
+type subscriber struct {
+	ch     chan *Event
+	filter Filter
+}
+
+// Broker fans out events to local subscribers, optionally via Redis pub/sub
+type Broker struct {
+	cache  cache.CacheService // Optional - nil means in-process delivery only
+	logger *logrus.Logger
+
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]*subscriber
+}
+
+// NewBroker creates a new event broker. cacheService may be nil.
+func NewBroker(cacheService cache.CacheService, logger *logrus.Logger) *Broker {
+	return &Broker{
+		cache:       cacheService,
+		logger:      logger,
+		subscribers: make(map[uuid.UUID]*subscriber),
+	}
+}
+
+// Start relays events from Redis to local subscribers until ctx is cancelled.
+// It is a no-op when Redis is not configured.
+func (b *Broker) Start(ctx context.Context) {
+	if b.cache == nil {
+		return
+	}
+
+	msgs := b.cache.Subscribe(ctx, redisChannel)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var event Event
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					b.logger.WithError(err).Warn("Failed to decode event from Redis")
+					continue
+				}
+				b.dispatch(&event)
+			}
+		}
+	}()
+}
+
+// Publish emits an event to all subscribers across replicas.
+// Publishing never blocks the caller on slow subscribers.
+func (b *Broker) Publish(ctx context.Context, event *Event) {
+	if b == nil || event == nil {
+		return
+	}
+
+	if b.cache != nil {
+		err := b.cache.Publish(ctx, redisChannel, event)
+		if err == nil {
+			return
+		}
+		b.logger.WithError(err).Warn("Failed to publish event to Redis, delivering locally")
+	}
+
+	b.dispatch(event)
+}
+
+// Subscribe registers a subscriber and returns its event channel and an
+// unsubscribe function that must be called when the subscriber goes away.
+func (b *Broker) Subscribe(filter Filter) (<-chan *Event, func()) {
+	id := uuid.New()
+	sub := &subscriber{
+		ch:     make(chan *Event, subscriberBuffer),
+		filter: filter,
+	}
+
+	b.mu.Lock()
+	b.subscribers[id] = sub
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+
+	return sub.ch, unsubscribe
+}
+
+// SubscriberCount returns the number of active local subscribers
+func (b *Broker) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+// dispatch delivers an event to matching local subscribers
+func (b *Broker) dispatch(event *Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.logger.WithField("event_type", event.Type).Debug("Subscriber buffer full, dropping event")
+		}
+	}
+}
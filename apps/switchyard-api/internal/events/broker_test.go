@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFilterMatches(t *testing.T) {
+	projectA := uuid.New()
+	projectB := uuid.New()
+
+	event := NewStatusEvent(ResourceDeployment, uuid.New(), "running")
+	event.ProjectID = &projectA
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected bool
+	}{
+		{"empty filter matches", Filter{}, true},
+		{"same project", Filter{ProjectID: &projectA}, true},
+		{"other project", Filter{ProjectID: &projectB}, false},
+		{"matching resource type", Filter{ResourceTypes: []ResourceType{ResourceBuild, ResourceDeployment}}, true},
+		{"non-matching resource type", Filter{ResourceTypes: []ResourceType{ResourceAddon}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(event); got != tt.expected {
+				t.Errorf("Matches() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBrokerLocalDelivery(t *testing.T) {
+	broker := NewBroker(nil, logrus.New())
+
+	ch, unsubscribe := broker.Subscribe(Filter{ResourceTypes: []ResourceType{ResourceBuild}})
+	defer unsubscribe()
+
+	broker.Publish(context.Background(), NewStatusEvent(ResourceDeployment, uuid.New(), "running"))
+	broker.Publish(context.Background(), NewStatusEvent(ResourceBuild, uuid.New(), "ready"))
+
+	select {
+	case e := <-ch:
+		if e.ResourceType != ResourceBuild || e.Type != "build.status" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected build event")
+	}
+
+	select {
+	case e := <-ch:
+		t.Errorf("unexpected extra event %+v", e)
+	default:
+	}
+}
+
+func TestBrokerUnsubscribe(t *testing.T) {
+	broker := NewBroker(nil, logrus.New())
+
+	ch, unsubscribe := broker.Subscribe(Filter{})
+	if broker.SubscriberCount() != 1 {
+		t.Fatalf("expected 1 subscriber, got %d", broker.SubscriberCount())
+	}
+
+	unsubscribe()
+	unsubscribe() // idempotent
+
+	if broker.SubscriberCount() != 0 {
+		t.Errorf("expected 0 subscribers, got %d", broker.SubscriberCount())
+	}
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed")
+	}
+
+	// Publishing with no subscribers must not panic
+	broker.Publish(context.Background(), NewStatusEvent(ResourceAddon, uuid.New(), "ready"))
+}
@@ -0,0 +1,78 @@
+// Package gpuquota enforces the GPU quotas of teams. A team's quota bounds
+// the GPUs the services of its projects hold across all environments: each
+// service's GPUs per pod times the replicas of its latest pending or running
+// deployment in an environment. A MIG slice counts as one GPU. Projects
+// without a team and teams without a quota are not limited. Rollbacks are
+// not checked, since they restore what a team already held.
+package gpuquota
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ErrQuotaExceeded is returned when a deploy would take a team past its
+// GPU quota
+var ErrQuotaExceeded = errors.New("team GPU quota exceeded")
+
+// Usage is a team's GPU quota and the GPUs it holds
+type Usage struct {
+	TeamID uuid.UUID `json:"team_id"`
+	Quota  *int      `json:"quota"` // Nil means no limit
+	Used   int       `json:"used"`
+}
+
+// Check checks that deploying a service with the given replicas into an
+// environment keeps its team within the team's GPU quota. The service's
+// current deployment in the environment is replaced, so it is not counted.
+func Check(ctx context.Context, repos *db.Repositories, service *types.Service, environmentID uuid.UUID, replicas int) error {
+	if service.GPU == nil {
+		return nil
+	}
+
+	teamID, quota, err := repos.Teams.GetGPUQuotaByProject(ctx, service.ProjectID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get GPU quota: %w", err)
+	}
+	if quota == nil {
+		return nil
+	}
+
+	used, err := repos.Teams.GPUsInUse(ctx, teamID, service.ID, environmentID)
+	if err != nil {
+		return fmt.Errorf("failed to count GPUs in use: %w", err)
+	}
+	return Evaluate(&Usage{TeamID: teamID, Quota: quota, Used: used}, service.GPU, replicas)
+}
+
+// Evaluate checks that a deploy of pods with a GPU request fits in what is
+// left of a team's quota
+func Evaluate(usage *Usage, gpu *types.GPUConfig, replicas int) error {
+	needed := Requested(gpu, replicas)
+	if needed == 0 || usage.Quota == nil || usage.Used+needed <= *usage.Quota {
+		return nil
+	}
+	return fmt.Errorf("%w: the deploy needs %d GPUs and the team holds %d of its %d",
+		ErrQuotaExceeded, needed, usage.Used, *usage.Quota)
+}
+
+// Requested is the number of GPUs the replicas of a service hold
+func Requested(gpu *types.GPUConfig, replicas int) int {
+	if gpu == nil {
+		return 0
+	}
+	if replicas < 1 {
+		replicas = 1
+	}
+	return gpu.Count * replicas
+}
@@ -0,0 +1,37 @@
+package gpuquota
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestEvaluate(t *testing.T) {
+	quota := 8
+	tests := []struct {
+		name     string
+		usage    Usage
+		gpu      *types.GPUConfig
+		replicas int
+		wantErr  bool
+	}{
+		{name: "no GPUs", usage: Usage{Quota: &quota, Used: 8}},
+		{name: "no quota", usage: Usage{Used: 100}, gpu: &types.GPUConfig{Count: 4}, replicas: 3},
+		{name: "fits", usage: Usage{Quota: &quota, Used: 4}, gpu: &types.GPUConfig{Count: 2}, replicas: 2},
+		{name: "exceeds", usage: Usage{Quota: &quota, Used: 4}, gpu: &types.GPUConfig{Count: 2}, replicas: 3, wantErr: true},
+		{name: "MIG slices count as GPUs", usage: Usage{Quota: &quota, Used: 7}, gpu: &types.GPUConfig{Count: 2, MIGProfile: "1g.5gb"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Evaluate(&tt.usage, tt.gpu, tt.replicas)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Evaluate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrQuotaExceeded) {
+				t.Errorf("Evaluate() error = %v, want ErrQuotaExceeded", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Package hosts tells the hostnames the platform itself is served on from
+// those projects may claim as custom domains or status page domains.
+// Requests are routed to services and status pages by Host, so a project
+// claiming the API's hostname would be handed the API's traffic.
+package hosts
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ErrReserved is returned for hostnames projects may not claim
+var ErrReserved = errors.New("hostname is reserved for the platform")
+
+// Reserved is the hostnames of the API and dashboard, and the platform
+// domain services are given subdomains of
+type Reserved struct {
+	api            map[string]bool
+	platformDomain string
+}
+
+// NewReserved creates the reserved hostnames from the API's hostnames, which
+// may be given as URLs, and the platform domain
+func NewReserved(apiHosts []string, platformDomain string) *Reserved {
+	r := &Reserved{
+		api:            make(map[string]bool, len(apiHosts)),
+		platformDomain: Normalize(platformDomain),
+	}
+	for _, host := range apiHosts {
+		if u, err := url.Parse(host); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		if host = Normalize(host); host != "" {
+			r.api[host] = true
+		}
+	}
+	return r
+}
+
+// Normalize lowercases a hostname and drops its port and trailing dot
+func Normalize(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// IsAPIHost reports whether requests to host are for the API or dashboard
+func (r *Reserved) IsAPIHost(host string) bool {
+	return r != nil && r.api[Normalize(host)]
+}
+
+// CheckClaim returns ErrReserved when a project may not claim a domain: the
+// API's hostnames always, and the platform domain and its subdomains unless
+// the platform generated the domain for a service
+func (r *Reserved) CheckClaim(domain string, platform bool) error {
+	if r == nil {
+		return nil
+	}
+	domain = Normalize(domain)
+	if r.api[domain] {
+		return fmt.Errorf("%w: %s serves the API", ErrReserved, domain)
+	}
+	if !platform && r.platformDomain != "" &&
+		(domain == r.platformDomain || strings.HasSuffix(domain, "."+r.platformDomain)) {
+		return fmt.Errorf("%w: %s is under the platform domain %s", ErrReserved, domain, r.platformDomain)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package hosts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReserved(t *testing.T) {
+	r := NewReserved([]string{"https://api.enclii.dev/v1/auth/callback", "app.enclii.dev", "http://switchyard-api:4200"}, "enclii.dev")
+
+	for _, host := range []string{"api.enclii.dev", "API.enclii.dev:443", "app.enclii.dev.", "switchyard-api"} {
+		if !r.IsAPIHost(host) {
+			t.Errorf("IsAPIHost(%q) = false, want true", host)
+		}
+	}
+	if r.IsAPIHost("shop.example.com") {
+		t.Error("IsAPIHost(shop.example.com) = true, want false")
+	}
+
+	tests := []struct {
+		domain   string
+		platform bool
+		reserved bool
+	}{
+		{"shop.example.com", false, false},
+		{"api.enclii.dev", false, true},
+		{"api.enclii.dev", true, true},
+		{"Api.Enclii.Dev", true, true},
+		{"web-staging.enclii.dev", false, true},
+		{"enclii.dev", false, true},
+		{"web-staging.enclii.dev", true, false},
+		{"notenclii.dev", false, false},
+	}
+	for _, tt := range tests {
+		err := r.CheckClaim(tt.domain, tt.platform)
+		if got := errors.Is(err, ErrReserved); got != tt.reserved {
+			t.Errorf("CheckClaim(%q, %v) = %v, want reserved %v", tt.domain, tt.platform, err, tt.reserved)
+		}
+	}
+
+	var none *Reserved
+	if none.IsAPIHost("api.enclii.dev") || none.CheckClaim("api.enclii.dev", false) != nil {
+		t.Error("nil Reserved should reserve nothing")
+	}
+}
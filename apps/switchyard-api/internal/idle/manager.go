@@ -0,0 +1,506 @@
+// Package idle scales services in non-production environments to zero
+// replicas once they served no requests for their idle period, and wakes
+// them on the next request. While a service sleeps, the tunnel routes of its
+// custom domains point at the API, whose Waker scales the service back up,
+// holds the request until a replica is ready and proxies it through. The
+// compute saved while asleep is reported to Waybill.
+package idle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/clients"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// SyncInterval is how often services are checked for idleness
+	SyncInterval = time.Minute
+
+	// WakeTimeout is how long a wake waits for a replica to be ready
+	WakeTimeout = 2 * time.Minute
+
+	// SavingsReportInterval is how often the savings of sleeping services
+	// are reported to Waybill
+	SavingsReportInterval = time.Hour
+
+	// MinIdleMinutes and MaxIdleMinutes bound the idle period of a service
+	MinIdleMinutes = 5
+	MaxIdleMinutes = 7 * 24 * 60
+
+	// readyPollInterval is how often a waking deployment is checked
+	readyPollInterval = time.Second
+
+	// defaultReplicaGB is the GB-equivalent of a replica without resource
+	// requests, the platform's default 128Mi memory request
+	defaultReplicaGB = 0.125
+)
+
+// ErrWakeTimeout is returned when no replica of a waking service became
+// ready within WakeTimeout
+var ErrWakeTimeout = errors.New("service did not become ready in time")
+
+// UsageRecorder reports usage to Waybill
+type UsageRecorder interface {
+	RecordEvent(ctx context.Context, event *clients.WaybillEvent) error
+}
+
+// Manager puts idle services to sleep and wakes them
+type Manager struct {
+	repos         *db.Repositories
+	kube          kubernetes.Interface
+	activity      ActivitySource
+	routes        services.TunnelRoutesManager
+	usage         UsageRecorder
+	wakeService   string
+	wakeNamespace string
+	logger        *logrus.Logger
+	wakes         singleflight.Group
+}
+
+// NewManager creates a manager. While services sleep, their custom domains
+// are routed to wakeService in wakeNamespace, the Service of the API.
+func NewManager(repos *db.Repositories, kube kubernetes.Interface, wakeService, wakeNamespace string, logger *logrus.Logger) *Manager {
+	return &Manager{
+		repos:         repos,
+		kube:          kube,
+		wakeService:   wakeService,
+		wakeNamespace: wakeNamespace,
+		logger:        logger,
+	}
+}
+
+// SetActivitySource sets where request counts are read from. Without one,
+// no service is put to sleep.
+func (m *Manager) SetActivitySource(activity ActivitySource) {
+	m.activity = activity
+}
+
+// SetTunnelRoutes sets the manager of the tunnel routes of custom domains.
+// Without one, services are not put to sleep, as nothing could wake them on
+// a request.
+func (m *Manager) SetTunnelRoutes(routes services.TunnelRoutesManager) {
+	m.routes = routes
+}
+
+// SetUsageRecorder sets where savings are reported. Without one, they are
+// only totalled on each service.
+func (m *Manager) SetUsageRecorder(usage UsageRecorder) {
+	m.usage = usage
+}
+
+// CanSleep reports whether services are put to sleep: it takes request
+// counts and managed tunnel routes
+func (m *Manager) CanSleep() bool {
+	return m.activity != nil && m.routes != nil
+}
+
+// Disable turns scale-to-zero of a service in an environment off. A
+// sleeping service is scaled back up and its domains routed back to it
+// without waiting for a replica to be ready.
+func (m *Manager) Disable(ctx context.Context, scaling *types.ServiceIdleScaling) error {
+	if scaling.State == types.IdleStateAsleep {
+		target, err := m.repos.IdleScaling.GetTarget(ctx, scaling.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get idle scaling: %w", err)
+		}
+		savings, err := m.repos.IdleScaling.MarkAwake(ctx, scaling.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if savings != nil {
+			if err := m.scaleUp(ctx, target, int32(savings.Replicas)); err != nil {
+				return err
+			}
+			m.restoreRoutes(ctx, target)
+			m.reportSavings(ctx, target, savings)
+		}
+	}
+	return m.repos.IdleScaling.Delete(ctx, scaling.ServiceID, scaling.EnvironmentID)
+}
+
+// Sync puts services that were idle for their idle period to sleep, notices
+// sleeping services that were scaled up by a deploy, and reports savings
+func (m *Manager) Sync(ctx context.Context) error {
+	targets, err := m.repos.IdleScaling.ListTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, target := range targets {
+		logger := m.logger.WithFields(logrus.Fields{
+			"service":     target.ServiceName,
+			"environment": target.Scaling.Environment,
+		})
+
+		var err error
+		switch target.Scaling.State {
+		case types.IdleStateAwake:
+			err = m.checkIdle(ctx, target, now)
+		case types.IdleStateAsleep:
+			err = m.checkAsleep(ctx, target, now)
+		}
+		if err != nil {
+			logger.WithError(err).Warn("Failed to sync idle scaling")
+		}
+	}
+	return nil
+}
+
+// checkIdle records whether an awake service served requests, and puts it to
+// sleep once it served none for its idle period
+func (m *Manager) checkIdle(ctx context.Context, target *db.IdleScalingTarget, now time.Time) error {
+	if !m.CanSleep() {
+		return nil
+	}
+	scaling := target.Scaling
+	idlePeriod := time.Duration(scaling.IdleMinutes) * time.Minute
+
+	requests, err := m.activity.Requests(ctx, target.Namespace, target.ServiceName, idlePeriod)
+	if err != nil {
+		return fmt.Errorf("failed to read requests: %w", err)
+	}
+	if requests > 0 {
+		return m.repos.IdleScaling.MarkActive(ctx, scaling.ID, now)
+	}
+	if now.Sub(scaling.LastActiveAt) < idlePeriod {
+		return nil
+	}
+
+	return m.Sleep(ctx, target)
+}
+
+// checkAsleep marks a sleeping service that a deploy scaled back up as
+// awake, and reports the savings of the others once per
+// SavingsReportInterval
+func (m *Manager) checkAsleep(ctx context.Context, target *db.IdleScalingTarget, now time.Time) error {
+	scaling := target.Scaling
+
+	deployment, err := m.kube.AppsV1().Deployments(target.Namespace).Get(ctx, target.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if replicasOf(deployment) > 0 {
+		m.logger.WithFields(logrus.Fields{
+			"service":     target.ServiceName,
+			"environment": scaling.Environment,
+		}).Info("Sleeping service was scaled up; routing its domains back to it")
+		savings, err := m.repos.IdleScaling.MarkAwake(ctx, scaling.ID, now)
+		if err != nil || savings == nil {
+			return err
+		}
+		m.restoreRoutes(ctx, target)
+		m.reportSavings(ctx, target, savings)
+		return nil
+	}
+
+	from := now
+	if scaling.SavingsUntil != nil {
+		from = *scaling.SavingsUntil
+	} else if scaling.AsleepSince != nil {
+		from = *scaling.AsleepSince
+	}
+	if now.Sub(from) < SavingsReportInterval {
+		return nil
+	}
+
+	savings := &db.UnreportedSavings{
+		From:      from,
+		Until:     now,
+		GBHours:   savedGBHours(scaling.Replicas, scaling.ReplicaGB, now.Sub(from)),
+		Replicas:  scaling.Replicas,
+		ReplicaGB: scaling.ReplicaGB,
+	}
+	added, err := m.repos.IdleScaling.AddSavings(ctx, scaling.ID, from, now, savings.GBHours)
+	if err != nil || !added {
+		return err
+	}
+	m.reportSavings(ctx, target, savings)
+	return nil
+}
+
+// Sleep scales a service to zero and routes its custom domains to the API
+// so the next request wakes it
+func (m *Manager) Sleep(ctx context.Context, target *db.IdleScalingTarget) error {
+	scaling := target.Scaling
+	deployments := m.kube.AppsV1().Deployments(target.Namespace)
+
+	deployment, err := deployments.Get(ctx, target.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	replicas := replicasOf(deployment)
+	if replicas == 0 {
+		// Scaled down by hand; there is nothing to save
+		return nil
+	}
+
+	now := time.Now()
+	asleep, err := m.repos.IdleScaling.MarkAsleep(ctx, scaling.ID, int(replicas), replicaGB(deployment.Spec.Template.Spec), now)
+	if err != nil || !asleep {
+		return err
+	}
+
+	domains := m.routeToWaker(ctx, target)
+
+	zero := int32(0)
+	deployment.Spec.Replicas = &zero
+	if _, err := deployments.Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		// Stay awake rather than leave domains pointing at the API
+		if _, wakeErr := m.repos.IdleScaling.MarkAwake(ctx, scaling.ID, now); wakeErr == nil {
+			m.restoreRoutes(ctx, target)
+		}
+		return fmt.Errorf("failed to scale deployment to zero: %w", err)
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"service":     target.ServiceName,
+		"environment": scaling.Environment,
+		"replicas":    replicas,
+		"domains":     domains,
+	}).Info("Scaled idle service to zero")
+
+	// A request may have woken the service while it was being scaled down
+	current, err := m.repos.IdleScaling.GetByID(ctx, scaling.ID)
+	if err == nil && current.State == types.IdleStateAwake {
+		if err := m.scaleUp(ctx, target, int32(current.Replicas)); err != nil {
+			return err
+		}
+		m.restoreRoutes(ctx, target)
+	}
+	return nil
+}
+
+// Wake scales a sleeping service back up, waits for a replica to be ready
+// and routes its custom domains back to it. Concurrent wakes of the same
+// service share one wake; a service that is already awake is only waited
+// for.
+func (m *Manager) Wake(ctx context.Context, id uuid.UUID) (*db.IdleScalingTarget, error) {
+	result := m.wakes.DoChan(id.String(), func() (interface{}, error) {
+		// The wake outlives the request that started it, so domains are
+		// routed back even when that request gives up
+		wakeCtx, cancel := context.WithTimeout(context.Background(), WakeTimeout)
+		defer cancel()
+		return m.wake(wakeCtx, id)
+	})
+
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*db.IdleScalingTarget), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *Manager) wake(ctx context.Context, id uuid.UUID) (*db.IdleScalingTarget, error) {
+	target, err := m.repos.IdleScaling.GetTarget(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idle scaling: %w", err)
+	}
+
+	savings, err := m.repos.IdleScaling.MarkAwake(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if savings == nil {
+		// Woken elsewhere; wait for it like the request that woke it
+		return target, m.waitReady(ctx, target)
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"service":     target.ServiceName,
+		"environment": target.Scaling.Environment,
+		"replicas":    savings.Replicas,
+	}).Info("Waking idle service")
+
+	if err := m.scaleUp(ctx, target, int32(savings.Replicas)); err != nil {
+		return nil, err
+	}
+	m.reportSavings(ctx, target, savings)
+
+	readyErr := m.waitReady(ctx, target)
+	// Route domains back even when no replica is ready yet, or they would
+	// keep pointing at the API while the service is awake
+	m.restoreRoutes(context.WithoutCancel(ctx), target)
+	return target, readyErr
+}
+
+// scaleUp scales the deployment of a service to replicas, at least one
+func (m *Manager) scaleUp(ctx context.Context, target *db.IdleScalingTarget, replicas int32) error {
+	if replicas < 1 {
+		replicas = 1
+	}
+	deployments := m.kube.AppsV1().Deployments(target.Namespace)
+	deployment, err := deployments.Get(ctx, target.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if replicasOf(deployment) >= replicas {
+		return nil
+	}
+	deployment.Spec.Replicas = &replicas
+	if _, err := deployments.Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to scale deployment up: %w", err)
+	}
+	return nil
+}
+
+// waitReady waits until the deployment of a service has a ready replica
+func (m *Manager) waitReady(ctx context.Context, target *db.IdleScalingTarget) error {
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		deployment, err := m.kube.AppsV1().Deployments(target.Namespace).Get(ctx, target.ServiceName, metav1.GetOptions{})
+		if err == nil && deployment.Status.ReadyReplicas > 0 {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ErrWakeTimeout
+		}
+	}
+}
+
+// routeToWaker points the tunnel routes of a service's custom domains at the
+// API and returns the domains
+func (m *Manager) routeToWaker(ctx context.Context, target *db.IdleScalingTarget) []string {
+	return m.setRoutes(ctx, target, m.wakeService, m.wakeNamespace)
+}
+
+// restoreRoutes points the tunnel routes of a service's custom domains back
+// at the service
+func (m *Manager) restoreRoutes(ctx context.Context, target *db.IdleScalingTarget) {
+	m.setRoutes(ctx, target, target.ServiceName, target.Namespace)
+}
+
+func (m *Manager) setRoutes(ctx context.Context, target *db.IdleScalingTarget, serviceName, namespace string) []string {
+	if m.routes == nil {
+		return nil
+	}
+	domains, err := m.repos.CustomDomains.GetByServiceAndEnvironment(ctx,
+		target.Scaling.ServiceID.String(), target.Scaling.EnvironmentID.String())
+	if err != nil {
+		m.logger.WithError(err).WithField("service", target.ServiceName).Warn("Failed to list custom domains of idle service")
+		return nil
+	}
+
+	var routed []string
+	for _, domain := range domains {
+		err := m.routes.AddRoute(ctx, &services.RouteSpec{
+			Hostname:         domain.Domain,
+			ServiceName:      serviceName,
+			ServiceNamespace: namespace,
+			ServicePort:      80,
+			ConnectTimeout:   "30s",
+			KeepAliveTimeout: "90s",
+		})
+		if err != nil {
+			m.logger.WithError(err).WithFields(logrus.Fields{
+				"domain":  domain.Domain,
+				"service": serviceName,
+			}).Warn("Failed to update tunnel route of idle service")
+			continue
+		}
+		routed = append(routed, domain.Domain)
+	}
+	return routed
+}
+
+// reportSavings sends the compute a service saved while asleep to Waybill
+func (m *Manager) reportSavings(ctx context.Context, target *db.IdleScalingTarget, savings *db.UnreportedSavings) {
+	if m.usage == nil || savings.GBHours <= 0 {
+		return
+	}
+	if err := m.usage.RecordEvent(ctx, savingsEvent(target, savings)); err != nil {
+		m.logger.WithError(err).WithField("service", target.ServiceName).Warn("Failed to report idle savings to Waybill")
+	}
+}
+
+// savingsEvent builds the Waybill event of compute a service saved
+func savingsEvent(target *db.IdleScalingTarget, savings *db.UnreportedSavings) *clients.WaybillEvent {
+	until := savings.Until
+	return &clients.WaybillEvent{
+		EventType:    clients.WaybillEventIdleSavings,
+		ProjectID:    target.ProjectID,
+		ResourceType: "service",
+		ResourceID:   target.Scaling.ServiceID,
+		ResourceName: target.ServiceName,
+		Metrics: map[string]float64{
+			"saved_gb_hours": savings.GBHours,
+			"replicas":       float64(savings.Replicas),
+		},
+		Metadata: map[string]string{
+			"environment": target.Scaling.Environment,
+			"from":        savings.From.UTC().Format(time.RFC3339),
+		},
+		Timestamp:      &until,
+		IdempotencyKey: fmt.Sprintf("compute.idle_savings:%s:%d", target.Scaling.ID, savings.From.Unix()),
+	}
+}
+
+// savedGBHours is the compute replicas of replicaGB each would have used
+// over a period
+func savedGBHours(replicas int, replicaGB float64, period time.Duration) float64 {
+	if period <= 0 {
+		return 0
+	}
+	return float64(replicas) * replicaGB * period.Hours()
+}
+
+// replicaGB is the GB-equivalent of one replica of a pod spec as Waybill
+// bills compute: the larger of its memory in GB and its CPU cores, from
+// requests, else limits
+func replicaGB(spec corev1.PodSpec) float64 {
+	var memory, cpu resource.Quantity
+	for _, container := range spec.Containers {
+		if q, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+			memory.Add(q)
+		} else if q, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+			memory.Add(q)
+		}
+		if q, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+			cpu.Add(q)
+		} else if q, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+			cpu.Add(q)
+		}
+	}
+
+	memoryGB := float64(memory.Value()) / (1024 * 1024 * 1024)
+	cpuCores := float64(cpu.MilliValue()) / 1000
+	gb := memoryGB
+	if cpuCores > gb {
+		gb = cpuCores
+	}
+	if gb == 0 {
+		return defaultReplicaGB
+	}
+	return gb
+}
+
+// replicasOf returns the desired replicas of a deployment
+func replicasOf(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
@@ -0,0 +1,100 @@
+package idle
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestReplicaGB(t *testing.T) {
+	container := func(requests, limits corev1.ResourceList) corev1.Container {
+		return corev1.Container{Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits}}
+	}
+
+	tests := []struct {
+		name string
+		spec corev1.PodSpec
+		want float64
+	}{
+		{
+			name: "memory requests",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				container(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi"), corev1.ResourceCPU: resource.MustParse("250m")}, nil),
+				container(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}, nil),
+			}},
+			want: 1,
+		},
+		{
+			name: "CPU outweighs memory",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				container(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi"), corev1.ResourceCPU: resource.MustParse("2")}, nil),
+			}},
+			want: 2,
+		},
+		{
+			name: "limits without requests",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				container(nil, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}),
+			}},
+			want: 2,
+		},
+		{
+			name: "no resources",
+			spec: corev1.PodSpec{Containers: []corev1.Container{{}}},
+			want: defaultReplicaGB,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replicaGB(tt.spec); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("replicaGB() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSavingsEvent(t *testing.T) {
+	from := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	until := from.Add(90 * time.Minute)
+	target := &db.IdleScalingTarget{
+		Scaling: &types.ServiceIdleScaling{
+			ID:          uuid.New(),
+			ServiceID:   uuid.New(),
+			Environment: "staging",
+		},
+		ServiceName: "api",
+		ProjectID:   uuid.New(),
+	}
+	savings := &db.UnreportedSavings{
+		From:     from,
+		Until:    until,
+		GBHours:  savedGBHours(2, 0.5, until.Sub(from)),
+		Replicas: 2,
+	}
+
+	event := savingsEvent(target, savings)
+	if savings.GBHours != 1.5 || event.Metrics["saved_gb_hours"] != 1.5 {
+		t.Errorf("saved GB-hours = %v, want 1.5 for 2 replicas of 0.5 GB over 90 minutes", event.Metrics["saved_gb_hours"])
+	}
+	if event.ResourceID != target.Scaling.ServiceID || event.ProjectID != target.ProjectID || !event.Timestamp.Equal(until) {
+		t.Errorf("event = %+v, want the service at the end of the period", event)
+	}
+	if event.Metadata["environment"] != "staging" {
+		t.Errorf("environment = %q, want staging", event.Metadata["environment"])
+	}
+	// A period is reported once however often it is retried
+	if again := savingsEvent(target, savings); again.IdempotencyKey != event.IdempotencyKey {
+		t.Errorf("idempotency keys %q and %q differ", event.IdempotencyKey, again.IdempotencyKey)
+	}
+	if savedGBHours(2, 0.5, -time.Minute) != 0 {
+		t.Error("a negative period saved compute")
+	}
+}
@@ -0,0 +1,57 @@
+package idle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/promquery"
+)
+
+// DefaultActivityQuery is the number of requests the ingress controller
+// served for a service. $namespace, $service and $window are replaced
+// before the query runs.
+const DefaultActivityQuery = `sum(increase(nginx_ingress_controller_requests{exported_namespace="$namespace",exported_service="$service"}[$window]))`
+
+// ActivitySource reports how many requests a service served over a window
+// up to now
+type ActivitySource interface {
+	Requests(ctx context.Context, namespace, service string, window time.Duration) (float64, error)
+}
+
+// PrometheusActivity reads request counts from a Prometheus server
+type PrometheusActivity struct {
+	client *promquery.Client
+	query  string
+}
+
+// NewPrometheusActivity creates an activity source for the Prometheus
+// server at baseURL. An empty query uses DefaultActivityQuery.
+func NewPrometheusActivity(baseURL, query string) *PrometheusActivity {
+	if query == "" {
+		query = DefaultActivityQuery
+	}
+	return &PrometheusActivity{client: promquery.NewClient(baseURL), query: query}
+}
+
+// Requests runs the activity query for a service over a window
+func (p *PrometheusActivity) Requests(ctx context.Context, namespace, service string, window time.Duration) (float64, error) {
+	query := strings.NewReplacer(
+		"$namespace", namespace,
+		"$service", service,
+		"$window", fmt.Sprintf("%ds", int(window.Seconds())),
+	).Replace(p.query)
+
+	samples, err := p.client.Query(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	// No series (a service that never served a request) or NaN counts as
+	// no requests
+	count, err := promquery.Scalar(samples)
+	if err != nil || count == nil {
+		return 0, err
+	}
+	return *count, nil
+}
@@ -0,0 +1,37 @@
+package idle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusActivityQuery(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1760616000,"3"]}]}}`))
+	}))
+	defer server.Close()
+
+	source := NewPrometheusActivity(server.URL, "")
+	requests, err := source.Requests(context.Background(), "enclii-shop-staging", "api", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Requests() error = %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Requests() = %v, want 3", requests)
+	}
+
+	for _, want := range []string{`exported_namespace="enclii-shop-staging"`, `exported_service="api"`, `[1800s]`} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query %q does not contain %s", query, want)
+		}
+	}
+	if strings.Contains(query, "$") {
+		t.Errorf("query %q has unreplaced placeholders", query)
+	}
+}
@@ -0,0 +1,169 @@
+package idle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/hosts"
+)
+
+const (
+	// hostRefreshInterval is how often the domains of sleeping services are
+	// reloaded
+	hostRefreshInterval = 10 * time.Second
+
+	// wakeRetryAfter is the Retry-After of a request whose service did not
+	// wake in time
+	wakeRetryAfter = "10"
+
+	// proxyWriteTimeout bounds writing the response of a woken service
+	proxyWriteTimeout = time.Minute
+
+	// accessAssertionHeader carries the Cloudflare Access token of requests
+	// that passed a domain's Zero Trust policy
+	accessAssertionHeader = "Cf-Access-Jwt-Assertion"
+)
+
+// Waker serves requests for the verified custom domains of sleeping
+// services, which are routed to the API: it wakes the service, holds the
+// request until a replica is ready and proxies it through
+type Waker struct {
+	manager  *Manager
+	repos    *db.Repositories
+	reserved *hosts.Reserved
+	logger   *logrus.Logger
+
+	mu       sync.RWMutex
+	hosts    map[string]db.AsleepDomain
+	loadedAt time.Time
+	loading  sync.Mutex
+}
+
+// NewWaker creates a waker for the services of a manager. Requests to the
+// reserved API hosts are never proxied.
+func NewWaker(manager *Manager, repos *db.Repositories, reserved *hosts.Reserved, logger *logrus.Logger) *Waker {
+	return &Waker{
+		manager:  manager,
+		repos:    repos,
+		reserved: reserved,
+		logger:   logger,
+	}
+}
+
+// Middleware wakes sleeping services on requests to their custom domains.
+// Requests to the API's hosts and any other host go on to the API. A woken
+// service stays known until the next refresh, so requests that reach the
+// API before its tunnel routes are updated are still proxied.
+func (w *Waker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if w.reserved.IsAPIHost(c.Request.Host) {
+			c.Next()
+			return
+		}
+		domain, ok := w.lookup(c.Request.Context(), c.Request.Host)
+		if !ok {
+			c.Next()
+			return
+		}
+		c.Abort()
+
+		// Zero Trust domains only take requests that passed Cloudflare Access
+		if domain.ZeroTrust && c.GetHeader(accessAssertionHeader) == "" {
+			c.String(http.StatusForbidden, "Forbidden\n")
+			return
+		}
+
+		// Waking takes longer than the server's WriteTimeout allows
+		if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(WakeTimeout + proxyWriteTimeout)); err != nil {
+			w.logger.WithError(err).Warn("Failed to extend write deadline for waking service")
+		}
+
+		target, err := w.manager.Wake(c.Request.Context(), domain.IdleScalingID)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return // The client gave up
+			}
+			if errors.Is(err, ErrWakeTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				c.Header("Retry-After", wakeRetryAfter)
+				c.String(http.StatusServiceUnavailable, "Service is waking up, retry shortly\n")
+				return
+			}
+			w.logger.WithError(err).WithField("host", c.Request.Host).Error("Failed to wake idle service")
+			c.String(http.StatusBadGateway, "Service could not be woken\n")
+			return
+		}
+
+		proxyTo(target).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// lookup returns the domain of a sleeping service the host belongs to
+func (w *Waker) lookup(ctx context.Context, host string) (db.AsleepDomain, bool) {
+	host = hosts.Normalize(host)
+
+	w.mu.RLock()
+	stale := time.Since(w.loadedAt) >= hostRefreshInterval
+	w.mu.RUnlock()
+	if stale {
+		w.refresh(ctx)
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	domain, ok := w.hosts[host]
+	return domain, ok
+}
+
+// refresh reloads the domains of sleeping services, once for concurrent
+// requests. On failure the previous domains are kept until the next try.
+func (w *Waker) refresh(ctx context.Context) {
+	w.loading.Lock()
+	defer w.loading.Unlock()
+
+	w.mu.RLock()
+	fresh := time.Since(w.loadedAt) < hostRefreshInterval
+	w.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	domains, err := w.repos.IdleScaling.ListAsleepDomains(ctx)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.loadedAt = time.Now()
+	if err != nil {
+		w.logger.WithError(err).Warn("Failed to load domains of sleeping services")
+		return
+	}
+	normalized := make(map[string]db.AsleepDomain, len(domains))
+	for host, domain := range domains {
+		normalized[hosts.Normalize(host)] = domain
+	}
+	w.hosts = normalized
+}
+
+// proxyTo returns a proxy to the Service of a woken service that keeps the
+// original Host header
+func proxyTo(target *db.IdleScalingTarget) *httputil.ReverseProxy {
+	upstream := &url.URL{
+		Scheme: "http",
+		Host:   fmt.Sprintf("%s.%s.svc.cluster.local:80", target.ServiceName, target.Namespace),
+	}
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			r.SetXForwarded()
+			r.Out.Host = r.In.Host
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package idle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/hosts"
+)
+
+func TestWakerPassesOtherHostsThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	id := uuid.New()
+	waker := NewWaker(nil, nil, hosts.NewReserved([]string{"https://api.enclii.dev"}, "enclii.dev"), logrus.New())
+	waker.hosts = map[string]db.AsleepDomain{
+		"staging.shop.example": {IdleScalingID: id},
+		"admin.shop.example":   {IdleScalingID: uuid.New(), ZeroTrust: true},
+		"api.enclii.dev":       {IdleScalingID: uuid.New()},
+	}
+	waker.loadedAt = time.Now()
+
+	if got, ok := waker.lookup(t.Context(), "Staging.Shop.Example:443"); !ok || got.IdleScalingID != id {
+		t.Errorf("lookup() = %v, %v, want the sleeping service regardless of case and port", got, ok)
+	}
+
+	router := gin.New()
+	router.Use(waker.Middleware())
+	router.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		host     string
+		wantCode int
+		wantBody string
+	}{
+		{"api.enclii.dev", http.StatusOK, "ok"},
+		{"example.com", http.StatusOK, "ok"},
+		{"admin.shop.example", http.StatusForbidden, "Forbidden\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Host = tt.host
+		router.ServeHTTP(w, req)
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+			t.Errorf("request to %s = %d %q, want %d %q", tt.host, w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+// Package jobs runs background jobs: asynchronous work, such as tearing down
+// a closed preview, that is stored in the database so it survives restarts.
+// Every replica runs the jobs it claims; failed attempts are retried with
+// backoff, and jobs may be scheduled to run later.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Runner timings and limits
+const (
+	// DefaultMaxAttempts is how often a job is tried unless it sets its own
+	DefaultMaxAttempts = 5
+	// DefaultWorkers is how many jobs a replica runs at once
+	DefaultWorkers = 4
+
+	// claimLease is how long a claim lasts without renewal; jobs of a
+	// replica that died are claimed again after this
+	claimLease = 2 * time.Minute
+	// claimInterval is how often the runner polls for jobs queued by other
+	// replicas or due for their scheduled run
+	claimInterval = 2 * time.Second
+	// renewInterval is how often claims on running jobs are renewed
+	renewInterval = 30 * time.Second
+	// attemptTimeout bounds a single attempt of a job
+	attemptTimeout = 15 * time.Minute
+
+	// retryBaseDelay is the wait before the first retry, doubled for each
+	// further one up to retryMaxDelay
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+
+	// maintenanceInterval is how often finished jobs are pruned
+	maintenanceInterval = time.Hour
+	// retention is how long finished jobs stay visible
+	retention = 7 * 24 * time.Hour
+)
+
+var jobAttempts = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "enclii_background_job_attempts_total",
+		Help: "Attempts of background jobs by kind and result (succeeded, retrying, failed)",
+	},
+	[]string{"kind", "result"},
+)
+
+// Handler runs one attempt of a job. A returned error retries the job with
+// backoff until it runs out of attempts, unless it is Permanent.
+type Handler func(ctx context.Context, job *types.BackgroundJob) error
+
+// Store is the durable job queue. db.BackgroundJobRepository is one.
+type Store interface {
+	Enqueue(ctx context.Context, job *types.BackgroundJob) error
+	Claim(ctx context.Context, owner string, kinds []string, limit int, lease time.Duration) ([]*types.BackgroundJob, error)
+	RenewClaims(ctx context.Context, owner string, lease time.Duration) (int64, error)
+	Complete(ctx context.Context, id uuid.UUID, owner string) error
+	Retry(ctx context.Context, id uuid.UUID, owner string, runAfter time.Time, lastError string) error
+	Fail(ctx context.Context, id uuid.UUID, owner string, lastError string) error
+	ReleaseAll(ctx context.Context, owner string) (int64, error)
+	FailAbandoned(ctx context.Context) (int64, error)
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// permanentError fails a job without using up its attempts
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error retrying cannot fix, such as a job whose subject
+// was deleted, so the job fails at once
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether an error was marked Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Option sets up a job being queued
+type Option func(*types.BackgroundJob)
+
+// RunAt schedules a job to run at a time rather than right away
+func RunAt(t time.Time) Option {
+	return func(job *types.BackgroundJob) { job.RunAfter = t }
+}
+
+// MaxAttempts sets how often a job is tried before it fails
+func MaxAttempts(n int) Option {
+	return func(job *types.BackgroundJob) { job.MaxAttempts = n }
+}
+
+// LastAttempt reports whether a failure of the running attempt of a job
+// fails the job, so handlers can record the failure on their subject
+func LastAttempt(job *types.BackgroundJob) bool {
+	return job.Attempts >= job.MaxAttempts
+}
+
+// Runner claims background jobs from the store and runs them with the
+// handlers registered for their kind
+type Runner struct {
+	store   Store
+	logger  *logrus.Logger
+	owner   string
+	workers int
+
+	handlersMu sync.RWMutex
+	handlers   map[string]Handler
+
+	mu      sync.Mutex
+	started bool
+	slots   chan struct{}
+	wakeCh  chan struct{}
+	stopCh  chan struct{}
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewRunner creates a runner of the jobs in a store
+func NewRunner(store Store, logger *logrus.Logger) *Runner {
+	return &Runner{
+		store:    store,
+		logger:   logger,
+		owner:    claimOwner(),
+		workers:  DefaultWorkers,
+		handlers: make(map[string]Handler),
+		wakeCh:   make(chan struct{}, 1),
+	}
+}
+
+// SetWorkers sets how many jobs the runner runs at once. It must be called
+// before Start.
+func (r *Runner) SetWorkers(workers int) {
+	if workers > 0 {
+		r.workers = workers
+	}
+}
+
+// Register sets the handler of a kind of job, e.g. "preview.cleanup". Every
+// replica registers the same handlers; a replica only claims the kinds it
+// has a handler for.
+func (r *Runner) Register(kind string, handler Handler) {
+	r.handlersMu.Lock()
+	defer r.handlersMu.Unlock()
+	r.handlers[kind] = handler
+}
+
+// Enqueue queues a job of a registered kind with a payload marshaled to
+// JSON. The job runs right away unless it is scheduled with RunAt.
+func (r *Runner) Enqueue(ctx context.Context, kind string, payload interface{}, opts ...Option) (*types.BackgroundJob, error) {
+	if r.handler(kind) == nil {
+		return nil, fmt.Errorf("no handler registered for job kind %q", kind)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s job payload: %w", kind, err)
+	}
+
+	job := &types.BackgroundJob{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: DefaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = 1
+	}
+	if err := r.store.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"job_id":    job.ID,
+		"kind":      kind,
+		"run_after": job.RunAfter,
+	}).Debug("Queued background job")
+	r.wake()
+	return job, nil
+}
+
+// Start begins claiming and running jobs
+func (r *Runner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		return fmt.Errorf("job runner already started")
+	}
+	r.started = true
+	r.slots = make(chan struct{}, r.workers)
+	r.stopCh = make(chan struct{})
+
+	// Attempts are cancelled on Stop rather than with ctx, so they can be
+	// released instead of counted as failures
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go r.claimer(ctx, runCtx)
+
+	r.logger.WithFields(logrus.Fields{
+		"owner":   r.owner,
+		"workers": r.workers,
+		"kinds":   r.kinds(),
+	}).Info("Job runner started")
+	return nil
+}
+
+// Stop cancels the running attempts and releases their jobs, so other
+// replicas run them without waiting for the leases to run out
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.started {
+		return
+	}
+
+	close(r.stopCh)
+	r.cancel()
+	r.wg.Wait()
+	r.releaseClaims()
+	r.started = false
+	r.logger.Info("Job runner stopped")
+}
+
+// claimer claims jobs while workers are free and keeps the claims on
+// running jobs alive
+func (r *Runner) claimer(ctx, runCtx context.Context) {
+	defer r.wg.Done()
+
+	claimTicker := time.NewTicker(claimInterval)
+	defer claimTicker.Stop()
+	renewTicker := time.NewTicker(renewInterval)
+	defer renewTicker.Stop()
+	maintenanceTicker := time.NewTicker(maintenanceInterval)
+	defer maintenanceTicker.Stop()
+
+	r.maintain(ctx)
+	r.claim(ctx, runCtx)
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-r.wakeCh:
+			r.claim(ctx, runCtx)
+		case <-claimTicker.C:
+			r.claim(ctx, runCtx)
+		case <-renewTicker.C:
+			if _, err := r.store.RenewClaims(ctx, r.owner, claimLease); err != nil {
+				r.logger.WithError(err).Error("Failed to renew background job claims")
+			}
+		case <-maintenanceTicker.C:
+			r.maintain(ctx)
+		}
+	}
+}
+
+// claim claims as many jobs as there are free workers and starts them
+func (r *Runner) claim(ctx, runCtx context.Context) {
+	free := cap(r.slots) - len(r.slots)
+	kinds := r.kinds()
+	if free == 0 || len(kinds) == 0 {
+		return
+	}
+
+	jobs, err := r.store.Claim(ctx, r.owner, kinds, free, claimLease)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to claim background jobs")
+		return
+	}
+
+	for _, job := range jobs {
+		// Only the claimer takes slots, so there is one for every claimed job
+		r.slots <- struct{}{}
+		r.wg.Add(1)
+		go func(job *types.BackgroundJob) {
+			defer r.wg.Done()
+			defer func() {
+				<-r.slots
+				r.wake()
+			}()
+			r.run(runCtx, job)
+		}(job)
+	}
+}
+
+// run runs one attempt of a job and records its outcome
+func (r *Runner) run(ctx context.Context, job *types.BackgroundJob) {
+	logger := r.logger.WithFields(logrus.Fields{
+		"job_id":  job.ID,
+		"kind":    job.Kind,
+		"attempt": job.Attempts,
+	})
+
+	err := r.attempt(ctx, job)
+	if ctx.Err() != nil {
+		// Stopping; the job is released rather than retried
+		return
+	}
+
+	// Outcomes are recorded even when the attempt ran out of time
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	switch {
+	case err == nil:
+		jobAttempts.WithLabelValues(job.Kind, "succeeded").Inc()
+		logger.Debug("Background job succeeded")
+		if err := r.store.Complete(recordCtx, job.ID, r.owner); err != nil {
+			logger.WithError(err).Error("Failed to complete background job")
+		}
+	case IsPermanent(err) || LastAttempt(job):
+		jobAttempts.WithLabelValues(job.Kind, "failed").Inc()
+		logger.WithError(err).Error("Background job failed")
+		if err := r.store.Fail(recordCtx, job.ID, r.owner, err.Error()); err != nil {
+			logger.WithError(err).Error("Failed to record background job failure")
+		}
+	default:
+		runAfter := time.Now().Add(RetryDelay(job.Attempts))
+		jobAttempts.WithLabelValues(job.Kind, "retrying").Inc()
+		logger.WithError(err).WithField("run_after", runAfter).Warn("Background job failed, will retry")
+		if err := r.store.Retry(recordCtx, job.ID, r.owner, runAfter, err.Error()); err != nil {
+			logger.WithError(err).Error("Failed to reschedule background job")
+		}
+	}
+}
+
+// attempt calls the handler of a job, turning a panic into an error
+func (r *Runner) attempt(ctx context.Context, job *types.BackgroundJob) (err error) {
+	handler := r.handler(job.Kind)
+	if handler == nil {
+		return Permanent(fmt.Errorf("no handler registered for job kind %q", job.Kind))
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, attemptTimeout)
+	defer cancel()
+	return handler(ctx, job)
+}
+
+// maintain fails the jobs abandoned during their last attempt and prunes
+// the jobs that finished past the retention
+func (r *Runner) maintain(ctx context.Context) {
+	if failed, err := r.store.FailAbandoned(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to fail abandoned background jobs")
+	} else if failed > 0 {
+		r.logger.WithField("count", failed).Warn("Failed background jobs abandoned during their last attempt")
+	}
+
+	if pruned, err := r.store.Prune(ctx, time.Now().Add(-retention)); err != nil {
+		r.logger.WithError(err).Error("Failed to prune background jobs")
+	} else if pruned > 0 {
+		r.logger.WithField("count", pruned).Debug("Pruned finished background jobs")
+	}
+}
+
+// releaseClaims gives up this replica's running jobs on shutdown
+func (r *Runner) releaseClaims() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	released, err := r.store.ReleaseAll(ctx, r.owner)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to release background job claims")
+		return
+	}
+	if released > 0 {
+		r.logger.WithField("count", released).Info("Released background job claims")
+	}
+}
+
+// wake makes the claimer look for jobs without blocking
+func (r *Runner) wake() {
+	select {
+	case r.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Runner) handler(kind string) Handler {
+	r.handlersMu.RLock()
+	defer r.handlersMu.RUnlock()
+	return r.handlers[kind]
+}
+
+// kinds lists the kinds of job with a handler
+func (r *Runner) kinds() []string {
+	r.handlersMu.RLock()
+	defer r.handlersMu.RUnlock()
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// RetryDelay is the wait before retrying a job whose attempt failed
+func RetryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// Decode unmarshals the payload of a job. A payload that does not decode
+// is a permanent error.
+func Decode(job *types.BackgroundJob, dest interface{}) error {
+	if err := json.Unmarshal(job.Payload, dest); err != nil {
+		return Permanent(fmt.Errorf("invalid %s job payload: %w", job.Kind, err))
+	}
+	return nil
+}
+
+// claimOwner identifies this replica's claims, unique per process
+func claimOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = fmt.Sprintf("pid-%d", os.Getpid())
+	}
+	return hostname + "-" + uuid.NewString()[:8]
+}
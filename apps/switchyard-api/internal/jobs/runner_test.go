@@ -0,0 +1,258 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// fakeStore keeps jobs in memory like the repository, reporting each
+// recorded outcome on done
+type fakeStore struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*types.BackgroundJob
+	done chan *types.BackgroundJob
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		jobs: make(map[uuid.UUID]*types.BackgroundJob),
+		done: make(chan *types.BackgroundJob, 10),
+	}
+}
+
+func (s *fakeStore) Enqueue(_ context.Context, job *types.BackgroundJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.ID = uuid.New()
+	job.Status = types.BackgroundJobStatusQueued
+	if job.RunAfter.IsZero() {
+		job.RunAfter = time.Now()
+	}
+	stored := *job
+	s.jobs[job.ID] = &stored
+	return nil
+}
+
+func (s *fakeStore) Claim(_ context.Context, owner string, kinds []string, limit int, _ time.Duration) ([]*types.BackgroundJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claimed []*types.BackgroundJob
+	for _, job := range s.jobs {
+		if len(claimed) == limit {
+			break
+		}
+		if job.Status != types.BackgroundJobStatusQueued || job.RunAfter.After(time.Now()) || !contains(kinds, job.Kind) {
+			continue
+		}
+		job.Status = types.BackgroundJobStatusRunning
+		job.Attempts++
+		job.ClaimedBy = owner
+		copied := *job
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (s *fakeStore) RenewClaims(context.Context, string, time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (s *fakeStore) Complete(_ context.Context, id uuid.UUID, _ string) error {
+	return s.finish(id, types.BackgroundJobStatusSucceeded, time.Time{}, "")
+}
+
+func (s *fakeStore) Retry(_ context.Context, id uuid.UUID, _ string, runAfter time.Time, lastError string) error {
+	return s.finish(id, types.BackgroundJobStatusQueued, runAfter, lastError)
+}
+
+func (s *fakeStore) Fail(_ context.Context, id uuid.UUID, _ string, lastError string) error {
+	return s.finish(id, types.BackgroundJobStatusFailed, time.Time{}, lastError)
+}
+
+func (s *fakeStore) ReleaseAll(context.Context, string) (int64, error) { return 0, nil }
+func (s *fakeStore) FailAbandoned(context.Context) (int64, error)      { return 0, nil }
+func (s *fakeStore) Prune(context.Context, time.Time) (int64, error)   { return 0, nil }
+
+func (s *fakeStore) finish(id uuid.UUID, status types.BackgroundJobStatus, runAfter time.Time, lastError string) error {
+	s.mu.Lock()
+	job := s.jobs[id]
+	job.Status = status
+	job.ClaimedBy = ""
+	job.LastError = lastError
+	if !runAfter.IsZero() {
+		job.RunAfter = runAfter
+	}
+	copied := *job
+	s.mu.Unlock()
+	s.done <- &copied
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// runOnce queues a job, runs the runner until the job's attempt is
+// recorded, and returns the job as stored after it
+func runOnce(t *testing.T, handler Handler, opts ...Option) *types.BackgroundJob {
+	t.Helper()
+	store := newFakeStore()
+	runner := NewRunner(store, logrus.New())
+	runner.Register("test.job", handler)
+
+	if _, err := runner.Enqueue(context.Background(), "test.job", map[string]string{"id": "42"}, opts...); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := runner.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer runner.Stop()
+
+	select {
+	case job := <-store.done:
+		return job
+	case <-time.After(5 * time.Second):
+		t.Fatal("job attempt was not recorded")
+		return nil
+	}
+}
+
+func TestRunnerCompletesJob(t *testing.T) {
+	var got struct{ ID string }
+	job := runOnce(t, func(_ context.Context, job *types.BackgroundJob) error {
+		return Decode(job, &got)
+	})
+
+	if job.Status != types.BackgroundJobStatusSucceeded {
+		t.Errorf("status = %s, want %s", job.Status, types.BackgroundJobStatusSucceeded)
+	}
+	if got.ID != "42" {
+		t.Errorf("payload id = %q, want %q", got.ID, "42")
+	}
+}
+
+func TestRunnerRetriesFailedAttempt(t *testing.T) {
+	before := time.Now()
+	job := runOnce(t, func(context.Context, *types.BackgroundJob) error {
+		return errors.New("registry unavailable")
+	})
+
+	if job.Status != types.BackgroundJobStatusQueued {
+		t.Fatalf("status = %s, want the job queued again", job.Status)
+	}
+	if job.LastError != "registry unavailable" {
+		t.Errorf("last error = %q, want the attempt's error", job.LastError)
+	}
+	if job.RunAfter.Before(before.Add(retryBaseDelay)) {
+		t.Errorf("run after = %v, want the retry at least %v out", job.RunAfter, retryBaseDelay)
+	}
+}
+
+func TestRunnerFailsJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler Handler
+		opts    []Option
+	}{
+		{
+			name: "permanent error",
+			handler: func(context.Context, *types.BackgroundJob) error {
+				return Permanent(errors.New("preview not found"))
+			},
+		},
+		{
+			name: "last attempt",
+			handler: func(context.Context, *types.BackgroundJob) error {
+				return errors.New("registry unavailable")
+			},
+			opts: []Option{MaxAttempts(1)},
+		},
+		{
+			name: "panic",
+			handler: func(context.Context, *types.BackgroundJob) error {
+				panic("nil map")
+			},
+			opts: []Option{MaxAttempts(1)},
+		},
+		{
+			name: "invalid payload",
+			handler: func(_ context.Context, job *types.BackgroundJob) error {
+				var id int
+				return Decode(job, &id)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := runOnce(t, tt.handler, tt.opts...)
+			if job.Status != types.BackgroundJobStatusFailed {
+				t.Errorf("status = %s, want %s", job.Status, types.BackgroundJobStatusFailed)
+			}
+			if job.LastError == "" {
+				t.Error("last error is empty, want the failure recorded")
+			}
+		})
+	}
+}
+
+func TestRunnerScheduledJobWaits(t *testing.T) {
+	store := newFakeStore()
+	runner := NewRunner(store, logrus.New())
+	runner.Register("test.job", func(context.Context, *types.BackgroundJob) error { return nil })
+
+	job, err := runner.Enqueue(context.Background(), "test.job", nil, RunAt(time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := runner.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer runner.Stop()
+
+	select {
+	case <-store.done:
+		t.Fatal("scheduled job ran before its time")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if job.MaxAttempts != DefaultMaxAttempts {
+		t.Errorf("max attempts = %d, want %d", job.MaxAttempts, DefaultMaxAttempts)
+	}
+}
+
+func TestEnqueueUnknownKind(t *testing.T) {
+	runner := NewRunner(newFakeStore(), logrus.New())
+	if _, err := runner.Enqueue(context.Background(), "test.unknown", nil); err == nil {
+		t.Error("Enqueue() error = nil, want an error for a kind without handler")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := RetryDelay(tt.attempt); got != tt.want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
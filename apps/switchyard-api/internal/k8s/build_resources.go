@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// =============================================================================
+// Build Resources
+// =============================================================================
+
+const (
+	// MaxBuildTimeoutMinutes bounds how long a single build may run
+	MaxBuildTimeoutMinutes = 360
+
+	// MaxBuildCacheTTLHours bounds how long cached build layers are reused
+	MaxBuildCacheTTLHours = 720
+)
+
+// ValidateBuildResources checks the resources, timeout and cache settings
+// of a service's builds
+func ValidateBuildResources(r *types.BuildResources) error {
+	if r == nil {
+		return nil
+	}
+	if err := validateRequestAndLimit("cpu", r.CPURequest, r.CPULimit); err != nil {
+		return err
+	}
+	if err := validateRequestAndLimit("memory", r.MemoryRequest, r.MemoryLimit); err != nil {
+		return err
+	}
+	if r.TimeoutMinutes < 0 || r.TimeoutMinutes > MaxBuildTimeoutMinutes {
+		return fmt.Errorf("timeout_minutes must be between 1 and %d", MaxBuildTimeoutMinutes)
+	}
+	if r.CacheTTLHours < 0 || r.CacheTTLHours > MaxBuildCacheTTLHours {
+		return fmt.Errorf("cache_ttl_hours must be between 1 and %d", MaxBuildCacheTTLHours)
+	}
+	return nil
+}
+
+func validateRequestAndLimit(name, request, limit string) error {
+	req, err := ParsePositiveQuantity(request)
+	if err != nil {
+		return fmt.Errorf("%s_request: %w", name, err)
+	}
+	lim, err := ParsePositiveQuantity(limit)
+	if err != nil {
+		return fmt.Errorf("%s_limit: %w", name, err)
+	}
+	if req != nil && lim != nil && req.Cmp(*lim) > 0 {
+		return fmt.Errorf("%s_request %s exceeds %s_limit %s", name, request, name, limit)
+	}
+	return nil
+}
+
+// ParsePositiveQuantity parses a resource quantity such as "2" or "4Gi";
+// an empty value is nil
+func ParsePositiveQuantity(value string) (*resource.Quantity, error) {
+	if value == "" {
+		return nil, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a resource quantity such as 2 or 4Gi", value)
+	}
+	if quantity.Sign() <= 0 {
+		return nil, fmt.Errorf("%q must be positive", value)
+	}
+	return &quantity, nil
+}
@@ -0,0 +1,40 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateBuildResources(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources *types.BuildResources
+		wantErr   string
+	}{
+		{name: "none"},
+		{name: "sized", resources: &types.BuildResources{CPURequest: "2", MemoryRequest: "4Gi", CPULimit: "8", MemoryLimit: "16Gi", TimeoutMinutes: 90}},
+		{name: "request only", resources: &types.BuildResources{MemoryRequest: "12Gi"}},
+		{name: "invalid quantity", resources: &types.BuildResources{CPULimit: "lots"}, wantErr: "cpu_limit"},
+		{name: "zero", resources: &types.BuildResources{MemoryRequest: "0"}, wantErr: "memory_request"},
+		{name: "request over limit", resources: &types.BuildResources{MemoryRequest: "8Gi", MemoryLimit: "4Gi"}, wantErr: "exceeds"},
+		{name: "timeout too long", resources: &types.BuildResources{TimeoutMinutes: MaxBuildTimeoutMinutes + 1}, wantErr: "timeout_minutes"},
+		{name: "negative cache TTL", resources: &types.BuildResources{CacheTTLHours: -1}, wantErr: "cache_ttl_hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBuildResources(tt.resources)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateBuildResources() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateBuildResources() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// oneOffJobTTL is how long finished one-off jobs and their pods are kept,
+// so their logs and exit codes can still be read
+const oneOffJobTTL = int32(time.Hour / time.Second)
+
+// OneOffJobSpec describes a command to run once in a service's deployed
+// image and configuration
+type OneOffJobSpec struct {
+	Namespace   string
+	ServiceName string
+	JobName     string
+	Command     []string
+	Timeout     time.Duration
+	Labels      map[string]string
+}
+
+// OneOffJobResult is the progress of a one-off job
+type OneOffJobResult struct {
+	Pod      string
+	Started  bool
+	Finished bool
+	ExitCode *int
+	Message  string
+}
+
+// RunOneOffJob starts a command as a Job built from the pod template of the
+// service's Deployment, so it runs with the same image, environment, secrets,
+// and volumes as the service. It returns the image the command runs in.
+func (c *Client) RunOneOffJob(ctx context.Context, spec *OneOffJobSpec) (string, error) {
+	if !c.IsValid() {
+		return "", fmt.Errorf("kubernetes client not initialized")
+	}
+
+	deployment, err := c.Clientset.AppsV1().Deployments(spec.Namespace).Get(ctx, spec.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get deployment %s: %w", spec.ServiceName, err)
+	}
+
+	job, err := BuildOneOffJob(deployment, spec)
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.Clientset.BatchV1().Jobs(spec.Namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return job.Spec.Template.Spec.Containers[0].Image, nil
+}
+
+// BuildOneOffJob builds the Job of a one-off command from a Deployment. Only
+// the service's own container is kept, without probes or ports, since
+// sidecars would keep the Job from completing. Failed commands are not
+// retried.
+func BuildOneOffJob(deployment *appsv1.Deployment, spec *OneOffJobSpec) (*batchv1.Job, error) {
+	podSpec := *deployment.Spec.Template.Spec.DeepCopy()
+
+	var container *corev1.Container
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == spec.ServiceName {
+			container = &podSpec.Containers[i]
+			break
+		}
+	}
+	if container == nil {
+		if len(podSpec.Containers) == 0 {
+			return nil, fmt.Errorf("deployment %s has no containers", deployment.Name)
+		}
+		container = &podSpec.Containers[0]
+	}
+
+	container.Command = spec.Command
+	container.Args = nil
+	container.Ports = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Lifecycle = nil
+	podSpec.Containers = []corev1.Container{*container}
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+
+	ttl := oneOffJobTTL
+	backoffLimit := int32(0)
+	deadline := int64(spec.Timeout / time.Second)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      spec.JobName,
+			Namespace: spec.Namespace,
+			Labels:    spec.Labels,
+		},
+		Spec: batchv1.JobSpec{
+			TTLSecondsAfterFinished: &ttl,
+			BackoffLimit:            &backoffLimit,
+			ActiveDeadlineSeconds:   &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: spec.Labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}, nil
+}
+
+// GetOneOffJobResult reports the progress of a one-off job from its pod, or
+// from the Job when its pod is gone or never started
+func (c *Client) GetOneOffJobResult(ctx context.Context, namespace, jobName string) (*OneOffJobResult, error) {
+	if !c.IsValid() {
+		return nil, fmt.Errorf("kubernetes client not initialized")
+	}
+
+	job, err := c.Clientset.BatchV1().Jobs(namespace).Get(ctx, jobName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %s: %w", jobName, err)
+	}
+
+	result := &OneOffJobResult{}
+	pods, err := c.ListPods(ctx, namespace, "job-name="+jobName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job pods: %w", err)
+	}
+	if len(pods.Items) > 0 {
+		pod := &pods.Items[0]
+		result.Pod = pod.Name
+		for _, status := range pod.Status.ContainerStatuses {
+			switch {
+			case status.State.Terminated != nil:
+				code := int(status.State.Terminated.ExitCode)
+				result.Started = true
+				result.Finished = true
+				result.ExitCode = &code
+				result.Message = status.State.Terminated.Reason
+			case status.State.Running != nil:
+				result.Started = true
+			case status.State.Waiting != nil:
+				result.Message = status.State.Waiting.Reason
+			}
+		}
+	}
+
+	// The Job fails on its own when it exceeds its deadline or its pod cannot
+	// be created, without the container reporting an exit code
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			result.Finished = true
+			if result.ExitCode == nil {
+				result.Message = condition.Message
+			}
+		}
+	}
+
+	return result, nil
+}
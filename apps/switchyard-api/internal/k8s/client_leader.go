@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// =============================================================================
+// Leader Election (one replica runs the controllers)
+// =============================================================================
+
+// LeaderElectionConfig configures leader election over a coordination.k8s.io
+// Lease
+type LeaderElectionConfig struct {
+	Namespace string // Namespace of the Lease
+	LeaseName string
+	Identity  string // Unique per replica, e.g. the pod name
+
+	LeaseDuration time.Duration // How long followers wait before taking over an unrenewed lease
+	RenewDeadline time.Duration // How long the leader keeps retrying a renewal before giving up
+	RetryPeriod   time.Duration // How often to try to acquire or renew the lease
+}
+
+// LeaderCallbacks are called as the replica gains and loses leadership
+type LeaderCallbacks struct {
+	// OnStartedLeading runs in its own goroutine once the lease is acquired.
+	// Its context is cancelled when leadership is lost.
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading runs when the replica stops leading, including when
+	// ctx is cancelled while it leads. It does not run if it never led.
+	OnStoppedLeading func()
+	// OnNewLeader runs when another replica is observed to hold the lease
+	OnNewLeader func(identity string)
+}
+
+// RunLeaderElection campaigns for the lease and blocks until ctx is
+// cancelled or leadership, once acquired, is lost. The lease is released on
+// cancellation so another replica takes over without waiting for it to
+// expire.
+func (c *Client) RunLeaderElection(ctx context.Context, cfg LeaderElectionConfig, callbacks LeaderCallbacks) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      cfg.LeaseName,
+			Namespace: cfg.Namespace,
+		},
+		Client:     c.Clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: cfg.Identity},
+	}
+
+	var leading atomic.Bool
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            cfg.LeaseName,
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				leading.Store(true)
+				callbacks.OnStartedLeading(ctx)
+			},
+			OnStoppedLeading: func() {
+				// client-go also calls this when the lease was never acquired
+				if leading.Load() && callbacks.OnStoppedLeading != nil {
+					callbacks.OnStoppedLeading()
+				}
+			},
+			OnNewLeader: func(identity string) {
+				if identity != cfg.Identity && callbacks.OnNewLeader != nil {
+					callbacks.OnNewLeader(identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to configure leader election: %w", err)
+	}
+
+	elector.Run(ctx)
+	return nil
+}
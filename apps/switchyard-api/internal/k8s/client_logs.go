@@ -100,6 +100,8 @@ type LogStreamOptions struct {
 	TailLines     int64
 	Follow        bool
 	Timestamps    bool
+	// SinceTime limits logs to lines written at or after it, when set
+	SinceTime *time.Time
 }
 
 // LogLine represents a single log line with metadata
@@ -155,6 +157,9 @@ func (c *Client) streamPodLogs(ctx context.Context, opts LogStreamOptions, podNa
 	if opts.TailLines > 0 {
 		podLogOpts.TailLines = &opts.TailLines
 	}
+	if opts.SinceTime != nil {
+		podLogOpts.SinceTime = &metav1.Time{Time: *opts.SinceTime}
+	}
 
 	req := c.Clientset.CoreV1().Pods(opts.Namespace).GetLogs(podName, podLogOpts)
 	stream, err := req.Stream(ctx)
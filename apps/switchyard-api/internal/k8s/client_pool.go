@@ -0,0 +1,200 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// =============================================================================
+// Multiple Clusters (Client Pool, Health and Capacity)
+// =============================================================================
+
+// NewClientFromKubeconfig creates a client from the contents of a kubeconfig,
+// using the given context or the kubeconfig's current one when it is empty
+func NewClientFromKubeconfig(kubeconfig []byte, kubeContext string) (*Client, error) {
+	clientConfig, err := clientcmd.NewClientConfigFromBytes(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	if kubeContext != "" {
+		raw, err := clientConfig.RawConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+		}
+		clientConfig = clientcmd.NewNonInteractiveClientConfig(raw, kubeContext, &clientcmd.ConfigOverrides{}, nil)
+	}
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	config.Timeout = 30 * time.Second
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return &Client{
+		Clientset: clientset,
+		config:    config,
+	}, nil
+}
+
+// APIServer returns the URL of the cluster's API server
+func (c *Client) APIServer() string {
+	if c == nil || c.config == nil {
+		return ""
+	}
+	return c.config.Host
+}
+
+// KubeconfigLoader returns the kubeconfig and context of a registered cluster
+type KubeconfigLoader func(ctx context.Context, clusterID uuid.UUID) (kubeconfig, kubeContext string, err error)
+
+// ClientPool holds a client for each cluster environments are deployed to:
+// the cluster the API runs in, and registered clusters whose clients are
+// created on first use
+type ClientPool struct {
+	local *Client
+	load  KubeconfigLoader
+
+	mu      sync.Mutex
+	clients map[uuid.UUID]*Client
+}
+
+// NewClientPool creates a pool around the client of the API's own cluster
+func NewClientPool(local *Client, load KubeconfigLoader) *ClientPool {
+	return &ClientPool{
+		local:   local,
+		load:    load,
+		clients: make(map[uuid.UUID]*Client),
+	}
+}
+
+// Local returns the client of the cluster the API runs in
+func (p *ClientPool) Local() *Client {
+	return p.local
+}
+
+// Get returns the client of a cluster, or of the API's own cluster when
+// clusterID is nil
+func (p *ClientPool) Get(ctx context.Context, clusterID *uuid.UUID) (*Client, error) {
+	if p == nil {
+		return nil, fmt.Errorf("no cluster clients configured")
+	}
+	if clusterID == nil {
+		return p.local, nil
+	}
+
+	p.mu.Lock()
+	client, ok := p.clients[*clusterID]
+	p.mu.Unlock()
+	if ok {
+		return client, nil
+	}
+
+	kubeconfig, kubeContext, err := p.load(ctx, *clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig of cluster %s: %w", clusterID, err)
+	}
+	client, err = NewClientFromKubeconfig([]byte(kubeconfig), kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s: %w", clusterID, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.clients[*clusterID]; ok {
+		return existing, nil
+	}
+	p.clients[*clusterID] = client
+	return client, nil
+}
+
+// Forget drops the client of a cluster that was removed
+func (p *ClientPool) Forget(clusterID uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clients, clusterID)
+}
+
+// Health reports whether the cluster is reachable, and the capacity and
+// requests of its nodes and pods
+func (c *Client) Health(ctx context.Context, name string) *types.ClusterHealth {
+	health := &types.ClusterHealth{Cluster: name, CheckedAt: time.Now()}
+	if !c.IsValid() {
+		health.Error = "no client for the cluster"
+		return health
+	}
+
+	version, err := c.Clientset.Discovery().ServerVersion()
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	health.Version = version.GitVersion
+
+	if err := clusterCapacity(ctx, c.Clientset, health); err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	health.Reachable = true
+	return health
+}
+
+// clusterCapacity adds up the allocatable resources of schedulable nodes and
+// the requests of the pods that are not finished
+func clusterCapacity(ctx context.Context, kube kubernetes.Interface, health *types.ClusterHealth) error {
+	nodes, err := kube.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes.Items {
+		health.Nodes++
+		if !nodeReady(&node) || node.Spec.Unschedulable {
+			continue
+		}
+		health.ReadyNodes++
+		health.CPUAllocatable += node.Status.Allocatable.Cpu().MilliValue()
+		health.MemoryAllocatable += node.Status.Allocatable.Memory().Value()
+		health.PodCapacity += node.Status.Allocatable.Pods().Value()
+	}
+
+	pods, err := kube.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		health.Pods++
+		for _, container := range pod.Spec.Containers {
+			health.CPURequested += container.Resources.Requests.Cpu().MilliValue()
+			health.MemoryRequested += container.Resources.Requests.Memory().Value()
+		}
+	}
+	return nil
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
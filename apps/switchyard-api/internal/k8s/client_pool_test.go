@@ -0,0 +1,132 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: staging
+  cluster:
+    server: https://staging.example.com:6443
+- name: production
+  cluster:
+    server: https://production.example.com:6443
+users:
+- name: deployer
+  user:
+    token: secret
+contexts:
+- name: staging
+  context: {cluster: staging, user: deployer}
+- name: production
+  context: {cluster: production, user: deployer}
+current-context: staging
+`
+
+func TestNewClientFromKubeconfig(t *testing.T) {
+	tests := []struct {
+		context string
+		want    string
+	}{
+		{context: "", want: "https://staging.example.com:6443"},
+		{context: "production", want: "https://production.example.com:6443"},
+	}
+	for _, tt := range tests {
+		client, err := NewClientFromKubeconfig([]byte(testKubeconfig), tt.context)
+		if err != nil {
+			t.Fatalf("NewClientFromKubeconfig(%q) error = %v", tt.context, err)
+		}
+		if got := client.APIServer(); got != tt.want {
+			t.Errorf("context %q API server = %q, want %q", tt.context, got, tt.want)
+		}
+	}
+
+	if _, err := NewClientFromKubeconfig([]byte(testKubeconfig), "missing"); err == nil {
+		t.Error("NewClientFromKubeconfig() with an unknown context succeeded")
+	}
+}
+
+func TestClientPoolGet(t *testing.T) {
+	local := &Client{}
+	loads := 0
+	pool := NewClientPool(local, func(_ context.Context, _ uuid.UUID) (string, string, error) {
+		loads++
+		return testKubeconfig, "production", nil
+	})
+
+	if got, _ := pool.Get(context.Background(), nil); got != local {
+		t.Error("Get(nil) did not return the local client")
+	}
+
+	id := uuid.New()
+	first, err := pool.Get(context.Background(), &id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	second, _ := pool.Get(context.Background(), &id)
+	if first != second || loads != 1 {
+		t.Errorf("Get() twice loaded the kubeconfig %d times, want the client reused", loads)
+	}
+
+	pool.Forget(id)
+	if _, err := pool.Get(context.Background(), &id); err != nil || loads != 2 {
+		t.Errorf("Get() after Forget() loaded %d times, err %v", loads, err)
+	}
+}
+
+func TestClusterCapacity(t *testing.T) {
+	node := func(name string, ready bool) *corev1.Node {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+					corev1.ResourcePods:   resource.MustParse("110"),
+				},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+			},
+		}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "enclii-acme-production"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "api",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+			}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	kube := fake.NewSimpleClientset(node("a", true), node("b", false), pod)
+
+	health := &types.ClusterHealth{}
+	if err := clusterCapacity(context.Background(), kube, health); err != nil {
+		t.Fatalf("clusterCapacity() error = %v", err)
+	}
+	if health.Nodes != 2 || health.ReadyNodes != 1 {
+		t.Errorf("nodes = %d ready %d, want 2 ready 1", health.Nodes, health.ReadyNodes)
+	}
+	if health.CPUAllocatable != 4000 || health.MemoryAllocatable != 8<<30 || health.PodCapacity != 110 {
+		t.Errorf("allocatable = %dm %d bytes %d pods, want only the ready node", health.CPUAllocatable, health.MemoryAllocatable, health.PodCapacity)
+	}
+	if health.Pods != 1 || health.CPURequested != 250 || health.MemoryRequested != 512<<20 {
+		t.Errorf("requests = %d pods %dm %d bytes", health.Pods, health.CPURequested, health.MemoryRequested)
+	}
+}
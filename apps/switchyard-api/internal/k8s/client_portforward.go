@@ -0,0 +1,189 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForwardTarget is a pod port that a Kubernetes Service port resolves to
+type PortForwardTarget struct {
+	Pod         string
+	PodPort     int
+	ServicePort int
+}
+
+// ResolveServicePort resolves a port of a Kubernetes Service to a ready pod
+// behind it, the way kubectl port-forward does for svc/NAME. A zero port
+// selects the first port of the Service.
+func (c *Client) ResolveServicePort(ctx context.Context, namespace, serviceName string, port int) (*PortForwardTarget, error) {
+	if !c.IsValid() {
+		return nil, fmt.Errorf("kubernetes client not initialized")
+	}
+
+	service, err := c.Clientset.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", serviceName, err)
+	}
+	if len(service.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %s has no pod selector", serviceName)
+	}
+
+	servicePort, err := findServicePort(service, port)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := c.ListPods(ctx, namespace, labels.SelectorFromSet(service.Spec.Selector).String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !podReady(pod) {
+			continue
+		}
+		podPort, err := resolveTargetPort(pod, servicePort)
+		if err != nil {
+			return nil, err
+		}
+		return &PortForwardTarget{Pod: pod.Name, PodPort: podPort, ServicePort: int(servicePort.Port)}, nil
+	}
+
+	return nil, fmt.Errorf("no ready pods behind service %s", serviceName)
+}
+
+// PortForward connects local to a port of a pod until either side closes
+// the connection or ctx is cancelled
+func (c *Client) PortForward(ctx context.Context, namespace, podName string, port int, local io.ReadWriter) error {
+	if !c.IsValid() {
+		return fmt.Errorf("kubernetes client not initialized")
+	}
+
+	req := c.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("portforward")
+
+	transport, upgrader, err := spdy.RoundTripperFor(c.config)
+	if err != nil {
+		return fmt.Errorf("failed to create port-forward transport: %w", err)
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	streamConn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return fmt.Errorf("failed to connect to pod %s: %w", podName, err)
+	}
+	defer streamConn.Close()
+
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return fmt.Errorf("failed to create error stream: %w", err)
+	}
+	// Only the kubelet writes to the error stream
+	errorStream.Close()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return fmt.Errorf("failed to create data stream: %w", err)
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		switch {
+		case err != nil:
+			errChan <- fmt.Errorf("failed to read error stream: %w", err)
+		case len(message) > 0:
+			errChan <- fmt.Errorf("port-forward to %s:%d failed: %s", podName, port, message)
+		}
+	}()
+
+	go func() {
+		// Half-close once the local side is done, so the response still arrives
+		io.Copy(dataStream, local)
+		dataStream.Close()
+	}()
+
+	remoteDone := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(local, dataStream)
+		remoteDone <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errChan:
+		return err
+	case err := <-remoteDone:
+		return err
+	}
+}
+
+// findServicePort returns the port of a Service with the given number, or
+// its first port when port is zero
+func findServicePort(service *corev1.Service, port int) (*corev1.ServicePort, error) {
+	if len(service.Spec.Ports) == 0 {
+		return nil, fmt.Errorf("service %s exposes no ports", service.Name)
+	}
+	if port == 0 {
+		return &service.Spec.Ports[0], nil
+	}
+	for i := range service.Spec.Ports {
+		if int(service.Spec.Ports[i].Port) == port {
+			return &service.Spec.Ports[i], nil
+		}
+	}
+	return nil, fmt.Errorf("service %s does not expose port %d", service.Name, port)
+}
+
+// resolveTargetPort returns the container port of a pod that a Service port
+// targets, looking up named target ports in the pod's containers
+func resolveTargetPort(pod *corev1.Pod, servicePort *corev1.ServicePort) (int, error) {
+	switch {
+	case servicePort.TargetPort.Type == intstr.Int && servicePort.TargetPort.IntVal != 0:
+		return int(servicePort.TargetPort.IntVal), nil
+	case servicePort.TargetPort.Type == intstr.String && servicePort.TargetPort.StrVal != "":
+		for _, container := range pod.Spec.Containers {
+			for _, containerPort := range container.Ports {
+				if containerPort.Name == servicePort.TargetPort.StrVal {
+					return int(containerPort.ContainerPort), nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("pod %s has no port named %s", pod.Name, servicePort.TargetPort.StrVal)
+	default:
+		// An unset target port is the service port itself
+		return int(servicePort.Port), nil
+	}
+}
+
+// podReady reports whether a pod is running and passes its readiness checks
+func podReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
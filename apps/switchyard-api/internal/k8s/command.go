@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// =============================================================================
+// Container Commands
+// =============================================================================
+
+// MaxCommandArgs bounds the entries of a command and of its args
+const MaxCommandArgs = 128
+
+// ValidateCommand checks a service's command override
+func ValidateCommand(c *types.ContainerCommand) error {
+	if c == nil {
+		return nil
+	}
+	if err := validateArgv("command", c.Command); err != nil {
+		return err
+	}
+	if err := validateArgv("args", c.Args); err != nil {
+		return err
+	}
+	if c.WorkingDir != "" {
+		if !path.IsAbs(c.WorkingDir) {
+			return fmt.Errorf("working_dir %q must be an absolute path", c.WorkingDir)
+		}
+		if strings.ContainsRune(c.WorkingDir, 0) {
+			return fmt.Errorf("working_dir must not contain NUL characters")
+		}
+	}
+	return nil
+}
+
+func validateArgv(field string, argv []string) error {
+	if len(argv) > MaxCommandArgs {
+		return fmt.Errorf("%s has %d entries, at most %d are allowed", field, len(argv), MaxCommandArgs)
+	}
+	for i, arg := range argv {
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("%s[%d] must not contain NUL characters", field, i)
+		}
+	}
+	if len(argv) > 0 && strings.TrimSpace(argv[0]) == "" {
+		return fmt.Errorf("%s[0] must not be empty", field)
+	}
+	return nil
+}
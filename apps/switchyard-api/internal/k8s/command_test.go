@@ -0,0 +1,39 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command *types.ContainerCommand
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "worker", command: &types.ContainerCommand{Command: []string{"bundle", "exec"}, Args: []string{"sidekiq"}, WorkingDir: "/app"}},
+		{name: "args only", command: &types.ContainerCommand{Args: []string{"--queue", ""}}},
+		{name: "empty executable", command: &types.ContainerCommand{Command: []string{" "}}, wantErr: "command[0]"},
+		{name: "NUL in args", command: &types.ContainerCommand{Args: []string{"a\x00b"}}, wantErr: "args[0]"},
+		{name: "too many args", command: &types.ContainerCommand{Args: make([]string, MaxCommandArgs+1)}, wantErr: "args has"},
+		{name: "relative working dir", command: &types.ContainerCommand{WorkingDir: "app"}, wantErr: "working_dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCommand(tt.command)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateCommand() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateCommand() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package k8s
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// =============================================================================
+// GPU Workloads
+// =============================================================================
+
+const (
+	// GPUResource is the extended resource the NVIDIA device plugin
+	// advertises whole GPUs as
+	GPUResource corev1.ResourceName = "nvidia.com/gpu"
+
+	// GPUTaintKey is the taint that keeps other workloads off GPU nodes
+	GPUTaintKey = "nvidia.com/gpu"
+
+	// DefaultGPURuntimeClass is the runtime class the NVIDIA container
+	// toolkit registers on k3s and the GPU operator creates
+	DefaultGPURuntimeClass = "nvidia"
+
+	// MaxGPUsPerPod bounds a single pod's request, beyond what any node has
+	MaxGPUsPerPod = 16
+)
+
+// migProfilePattern matches MIG profiles such as 1g.5gb, 3g.40gb or 1g.10gb+me
+var migProfilePattern = regexp.MustCompile(`^[1-7]g\.[0-9]+gb(\+me)?$`)
+
+// ValidateGPU checks a service's GPU request
+func ValidateGPU(g *types.GPUConfig) error {
+	if g == nil {
+		return nil
+	}
+	if g.Count < 1 || g.Count > MaxGPUsPerPod {
+		return fmt.Errorf("count must be between 1 and %d", MaxGPUsPerPod)
+	}
+	if g.MIGProfile != "" && !migProfilePattern.MatchString(g.MIGProfile) {
+		return fmt.Errorf("mig_profile %q is not a MIG profile such as 1g.5gb", g.MIGProfile)
+	}
+	if g.RuntimeClass != "" {
+		if errs := validation.IsDNS1123Subdomain(g.RuntimeClass); len(errs) > 0 {
+			return fmt.Errorf("runtime_class %q: %s", g.RuntimeClass, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// GPUResourceName is the resource a GPU request is made in: whole GPUs, or
+// the slices of a MIG profile the device plugin advertises in mixed strategy
+func GPUResourceName(g *types.GPUConfig) corev1.ResourceName {
+	if g.MIGProfile != "" {
+		return corev1.ResourceName("nvidia.com/mig-" + g.MIGProfile)
+	}
+	return GPUResource
+}
+
+// AddGPUs adds a GPU request to a container's resources. Extended resources
+// cannot be overcommitted, so the limit is also the request.
+func AddGPUs(resources *corev1.ResourceRequirements, g *types.GPUConfig) {
+	if g == nil || g.Count < 1 {
+		return
+	}
+	count := *resource.NewQuantity(int64(g.Count), resource.DecimalSI)
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+	resources.Limits[GPUResourceName(g)] = count
+}
+
+// GPURuntimeClass returns the runtime class of a pod with GPUs, or nil
+// without any
+func GPURuntimeClass(g *types.GPUConfig) *string {
+	if g == nil {
+		return nil
+	}
+	runtimeClass := g.RuntimeClass
+	if runtimeClass == "" {
+		runtimeClass = DefaultGPURuntimeClass
+	}
+	return &runtimeClass
+}
+
+// WithGPU returns a service's placement with the toleration of the GPU taint
+// added when it requests GPUs, so its pods can run on GPU nodes. The
+// service's own placement is not changed.
+func WithGPU(s *types.SchedulingConfig, g *types.GPUConfig) *types.SchedulingConfig {
+	if g == nil {
+		return s
+	}
+	gpuToleration := types.Toleration{Key: GPUTaintKey, Operator: string(corev1.TolerationOpExists), Effect: string(corev1.TaintEffectNoSchedule)}
+
+	placement := &types.SchedulingConfig{}
+	if s != nil {
+		*placement = *s
+		for _, t := range Tolerations(s) {
+			if t.ToleratesTaint(&corev1.Taint{Key: GPUTaintKey, Value: "present", Effect: corev1.TaintEffectNoSchedule}) {
+				return s
+			}
+		}
+	}
+	placement.Tolerations = append(append([]types.Toleration{}, placement.Tolerations...), gpuToleration)
+	return placement
+}
@@ -0,0 +1,84 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateGPU(t *testing.T) {
+	tests := []struct {
+		name    string
+		gpu     *types.GPUConfig
+		wantErr string
+	}{
+		{name: "none"},
+		{name: "whole GPUs", gpu: &types.GPUConfig{Count: 2}},
+		{name: "MIG slices", gpu: &types.GPUConfig{Count: 1, MIGProfile: "3g.40gb", RuntimeClass: "nvidia-cdi"}},
+		{name: "no GPUs", gpu: &types.GPUConfig{}, wantErr: "count"},
+		{name: "too many", gpu: &types.GPUConfig{Count: MaxGPUsPerPod + 1}, wantErr: "count"},
+		{name: "unknown profile", gpu: &types.GPUConfig{Count: 1, MIGProfile: "half"}, wantErr: "mig_profile"},
+		{name: "invalid runtime class", gpu: &types.GPUConfig{Count: 1, RuntimeClass: "NVIDIA"}, wantErr: "runtime_class"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGPU(tt.gpu)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateGPU() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateGPU() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddGPUs(t *testing.T) {
+	resources := corev1.ResourceRequirements{}
+	AddGPUs(&resources, &types.GPUConfig{Count: 2})
+	if limit := resources.Limits[GPUResource]; limit.Value() != 2 {
+		t.Errorf("GPU limit = %s, want 2", limit.String())
+	}
+
+	resources = corev1.ResourceRequirements{}
+	AddGPUs(&resources, nil)
+	if len(resources.Limits) != 0 {
+		t.Errorf("limits without GPUs = %v", resources.Limits)
+	}
+}
+
+func TestWithGPU(t *testing.T) {
+	spot := &types.SchedulingConfig{
+		NodeSelector: map[string]string{"karpenter.sh/nodepool": "gpu-spot"},
+		Tolerations:  []types.Toleration{{Key: "spot", Value: "true", Effect: "NoSchedule"}},
+	}
+
+	if got := WithGPU(spot, nil); got != spot {
+		t.Error("WithGPU() without GPUs changed the placement")
+	}
+
+	got := WithGPU(spot, &types.GPUConfig{Count: 1})
+	if len(got.Tolerations) != 2 || got.Tolerations[1].Key != GPUTaintKey || got.NodeSelector["karpenter.sh/nodepool"] != "gpu-spot" {
+		t.Errorf("WithGPU() = %+v, want the spot placement plus the GPU toleration", got)
+	}
+	if len(spot.Tolerations) != 1 {
+		t.Error("WithGPU() changed the service's own tolerations")
+	}
+
+	tolerated := &types.SchedulingConfig{Tolerations: []types.Toleration{{Key: GPUTaintKey, Operator: "Exists"}}}
+	if got := WithGPU(tolerated, &types.GPUConfig{Count: 1}); len(got.Tolerations) != 1 {
+		t.Errorf("WithGPU() added a second GPU toleration: %+v", got.Tolerations)
+	}
+
+	pools := []types.NodePool{{Name: "gpu", Nodes: 1, Labels: map[string]string{}, Taints: []types.Taint{{Key: GPUTaintKey, Value: "present", Effect: "NoSchedule"}}}}
+	if err := CheckPlacement(pools, WithGPU(nil, &types.GPUConfig{Count: 1})); err != nil {
+		t.Errorf("CheckPlacement() of a GPU service on the GPU pool error = %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// =============================================================================
+// Node Pools and Workload Placement
+// =============================================================================
+
+// NodePoolLabel is the label nodes can be grouped into a pool with on
+// clusters whose provider does not label them
+const NodePoolLabel = "enclii.dev/node-pool"
+
+// nodePoolLabels are the labels a node's pool is named by, in order of
+// preference
+var nodePoolLabels = []string{
+	NodePoolLabel,
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"karpenter.sh/nodepool",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"doks.digitalocean.com/node-pool",
+}
+
+// DefaultNodePool names the pool of nodes without a pool label
+const DefaultNodePool = "default"
+
+// NodePools groups the cluster's nodes into pools
+func (c *Client) NodePools(ctx context.Context) ([]types.NodePool, error) {
+	nodes, err := c.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return nodePools(nodes.Items), nil
+}
+
+// nodePools groups nodes by their pool label. A pool's labels and taints are
+// those all of its nodes share, leaving out per-node labels and the
+// transient taints Kubernetes sets on unhealthy nodes.
+func nodePools(nodes []corev1.Node) []types.NodePool {
+	pools := make(map[string]*types.NodePool)
+	var names []string
+	for i := range nodes {
+		node := &nodes[i]
+		label, name := "", DefaultNodePool
+		for _, key := range nodePoolLabels {
+			if value := node.Labels[key]; value != "" {
+				label, name = key, value
+				break
+			}
+		}
+
+		labels := make(map[string]string, len(node.Labels))
+		for key, value := range node.Labels {
+			if key != corev1.LabelHostname {
+				labels[key] = value
+			}
+		}
+		var taints []types.Taint
+		for _, taint := range node.Spec.Taints {
+			if strings.HasPrefix(taint.Key, "node.kubernetes.io/") {
+				continue
+			}
+			taints = append(taints, types.Taint{Key: taint.Key, Value: taint.Value, Effect: string(taint.Effect)})
+		}
+
+		id := label + "=" + name
+		pool, ok := pools[id]
+		if !ok {
+			pool = &types.NodePool{Name: name, Label: label, Labels: labels, Taints: taints}
+			pools[id] = pool
+			names = append(names, id)
+		} else {
+			for key, value := range pool.Labels {
+				if labels[key] != value {
+					delete(pool.Labels, key)
+				}
+			}
+			pool.Taints = sharedTaints(pool.Taints, taints)
+		}
+
+		pool.Nodes++
+		if nodeReady(node) {
+			pool.ReadyNodes++
+		}
+		if instanceType := node.Labels[corev1.LabelInstanceTypeStable]; instanceType != "" && !containsString(pool.InstanceTypes, instanceType) {
+			pool.InstanceTypes = append(pool.InstanceTypes, instanceType)
+		}
+	}
+
+	sort.Strings(names)
+	result := make([]types.NodePool, 0, len(names))
+	for _, id := range names {
+		pool := pools[id]
+		if pool.Taints == nil {
+			pool.Taints = []types.Taint{}
+		}
+		sort.Strings(pool.InstanceTypes)
+		result = append(result, *pool)
+	}
+	return result
+}
+
+func sharedTaints(a, b []types.Taint) []types.Taint {
+	var shared []types.Taint
+	for _, taint := range a {
+		for _, other := range b {
+			if taint == other {
+				shared = append(shared, taint)
+				break
+			}
+		}
+	}
+	return shared
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateScheduling checks a service's placement on its own, before it is
+// checked against the node pools of clusters
+func ValidateScheduling(s *types.SchedulingConfig) error {
+	if s == nil {
+		return nil
+	}
+
+	for key, value := range s.NodeSelector {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("node_selector key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("node_selector value %q: %s", value, strings.Join(errs, "; "))
+		}
+	}
+
+	for i, t := range s.Tolerations {
+		field := fmt.Sprintf("tolerations[%d]", i)
+		switch corev1.TolerationOperator(t.Operator) {
+		case "", corev1.TolerationOpEqual:
+			if t.Key == "" {
+				return fmt.Errorf("%s: operator Equal needs a key", field)
+			}
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				return fmt.Errorf("%s: operator Exists takes no value", field)
+			}
+		default:
+			return fmt.Errorf("%s: operator must be Equal or Exists", field)
+		}
+		if t.Key != "" {
+			if errs := validation.IsQualifiedName(t.Key); len(errs) > 0 {
+				return fmt.Errorf("%s: key %q: %s", field, t.Key, strings.Join(errs, "; "))
+			}
+		}
+		switch corev1.TaintEffect(t.Effect) {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("%s: effect must be NoSchedule, PreferNoSchedule or NoExecute", field)
+		}
+		if t.TolerationSeconds != nil && corev1.TaintEffect(t.Effect) != corev1.TaintEffectNoExecute {
+			return fmt.Errorf("%s: toleration_seconds only applies to NoExecute", field)
+		}
+	}
+
+	keys := make(map[string]bool)
+	for i, spread := range s.TopologySpread {
+		field := fmt.Sprintf("topology_spread[%d]", i)
+		if errs := validation.IsQualifiedName(spread.TopologyKey); len(errs) > 0 {
+			return fmt.Errorf("%s: topology_key %q: %s", field, spread.TopologyKey, strings.Join(errs, "; "))
+		}
+		if keys[spread.TopologyKey] {
+			return fmt.Errorf("%s: duplicate topology_key %s", field, spread.TopologyKey)
+		}
+		keys[spread.TopologyKey] = true
+		if spread.MaxSkew < 0 {
+			return fmt.Errorf("%s: max_skew must be at least 1", field)
+		}
+		switch corev1.UnsatisfiableConstraintAction(spread.WhenUnsatisfiable) {
+		case "", corev1.DoNotSchedule, corev1.ScheduleAnyway:
+		default:
+			return fmt.Errorf("%s: when_unsatisfiable must be DoNotSchedule or ScheduleAnyway", field)
+		}
+	}
+	return nil
+}
+
+// SchedulesAnywhere reports whether a placement leaves pods free to run on
+// any node, so it need not be stored
+func SchedulesAnywhere(s *types.SchedulingConfig) bool {
+	return s == nil || (len(s.NodeSelector) == 0 && len(s.Tolerations) == 0 && len(s.TopologySpread) == 0)
+}
+
+// CheckPlacement checks that some node pool can run the service's pods:
+// its nodes have the labels of the node selector, and the pods tolerate
+// the taints that keep pods off them. A cluster without nodes is not
+// checked, since its pools may be scaled to zero.
+func CheckPlacement(pools []types.NodePool, s *types.SchedulingConfig) error {
+	if len(pools) == 0 {
+		return nil
+	}
+	var selector map[string]string
+	if s != nil {
+		selector = s.NodeSelector
+	}
+	tolerations := Tolerations(s)
+
+	var selected []string
+	var untolerated []string
+	for _, pool := range pools {
+		if pool.Nodes == 0 || !matchesSelector(pool.Labels, selector) {
+			continue
+		}
+		selected = append(selected, pool.Name)
+
+		tolerated := true
+		for _, taint := range pool.Taints {
+			if !toleratesTaint(tolerations, taint) {
+				untolerated = append(untolerated, fmt.Sprintf("%s (%s)", pool.Name, formatTaint(taint)))
+				tolerated = false
+				break
+			}
+		}
+		if tolerated {
+			return nil
+		}
+	}
+
+	if len(selected) == 0 {
+		return fmt.Errorf("no node pool has the labels %s", formatSelector(selector))
+	}
+	return fmt.Errorf("the selected node pools have taints the service does not tolerate: %s", strings.Join(untolerated, ", "))
+}
+
+func matchesSelector(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// toleratesTaint reports whether a taint that keeps pods off nodes is
+// tolerated. PreferNoSchedule taints only make the scheduler avoid nodes.
+func toleratesTaint(tolerations []corev1.Toleration, taint types.Taint) bool {
+	effect := corev1.TaintEffect(taint.Effect)
+	if effect == corev1.TaintEffectPreferNoSchedule {
+		return true
+	}
+	t := &corev1.Taint{Key: taint.Key, Value: taint.Value, Effect: effect}
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatTaint(taint types.Taint) string {
+	if taint.Value == "" {
+		return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect)
+}
+
+func formatSelector(selector map[string]string) string {
+	pairs := make([]string, 0, len(selector))
+	for key, value := range selector {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Tolerations converts a service's tolerations for its pod spec
+func Tolerations(s *types.SchedulingConfig) []corev1.Toleration {
+	if s == nil || len(s.Tolerations) == 0 {
+		return nil
+	}
+	tolerations := make([]corev1.Toleration, 0, len(s.Tolerations))
+	for _, t := range s.Tolerations {
+		operator := corev1.TolerationOperator(t.Operator)
+		if operator == "" {
+			operator = corev1.TolerationOpEqual
+		}
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:               t.Key,
+			Operator:          operator,
+			Value:             t.Value,
+			Effect:            corev1.TaintEffect(t.Effect),
+			TolerationSeconds: t.TolerationSeconds,
+		})
+	}
+	return tolerations
+}
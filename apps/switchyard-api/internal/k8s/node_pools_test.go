@@ -0,0 +1,138 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func testNode(name string, labels map[string]string, taints ...corev1.Taint) corev1.Node {
+	labels[corev1.LabelHostname] = name
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func TestNodePools(t *testing.T) {
+	gpuTaint := corev1.Taint{Key: "nvidia.com/gpu", Value: "present", Effect: corev1.TaintEffectNoSchedule}
+	nodes := []corev1.Node{
+		testNode("gpu-1", map[string]string{"cloud.google.com/gke-nodepool": "gpu", "topology.kubernetes.io/zone": "a", corev1.LabelInstanceTypeStable: "a2-highgpu-1g"}, gpuTaint),
+		testNode("gpu-2", map[string]string{"cloud.google.com/gke-nodepool": "gpu", "topology.kubernetes.io/zone": "b", corev1.LabelInstanceTypeStable: "a2-highgpu-1g"},
+			gpuTaint, corev1.Taint{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute}),
+		testNode("plain", map[string]string{"kubernetes.io/os": "linux"}),
+	}
+
+	pools := nodePools(nodes)
+	if len(pools) != 2 {
+		t.Fatalf("got %d pools, want 2: %+v", len(pools), pools)
+	}
+
+	gpu := pools[1]
+	if gpu.Name != "gpu" || gpu.Label != "cloud.google.com/gke-nodepool" || gpu.Nodes != 2 || gpu.ReadyNodes != 2 {
+		t.Errorf("gpu pool = %+v", gpu)
+	}
+	if _, ok := gpu.Labels["topology.kubernetes.io/zone"]; ok {
+		t.Error("gpu pool labels include the zone its nodes differ in")
+	}
+	if _, ok := gpu.Labels[corev1.LabelHostname]; ok {
+		t.Error("gpu pool labels include the hostname")
+	}
+	if len(gpu.Taints) != 1 || gpu.Taints[0].Key != "nvidia.com/gpu" {
+		t.Errorf("gpu pool taints = %+v, want the shared GPU taint only", gpu.Taints)
+	}
+	if len(gpu.InstanceTypes) != 1 {
+		t.Errorf("gpu pool instance types = %v", gpu.InstanceTypes)
+	}
+
+	if pools[0].Name != DefaultNodePool || pools[0].Label != "" {
+		t.Errorf("unpooled nodes = %+v, want the default pool", pools[0])
+	}
+}
+
+func TestValidateScheduling(t *testing.T) {
+	seconds := int64(60)
+	tests := []struct {
+		name       string
+		scheduling *types.SchedulingConfig
+		wantErr    string
+	}{
+		{name: "none"},
+		{name: "valid", scheduling: &types.SchedulingConfig{
+			NodeSelector:   map[string]string{"cloud.google.com/gke-nodepool": "gpu"},
+			Tolerations:    []types.Toleration{{Key: "nvidia.com/gpu", Operator: "Exists", Effect: "NoSchedule"}},
+			TopologySpread: []types.TopologySpreadConstraint{{TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 1, WhenUnsatisfiable: "DoNotSchedule"}},
+		}},
+		{name: "invalid selector value", scheduling: &types.SchedulingConfig{NodeSelector: map[string]string{"pool": "not valid"}}, wantErr: "node_selector value"},
+		{name: "exists with value", scheduling: &types.SchedulingConfig{Tolerations: []types.Toleration{{Key: "spot", Operator: "Exists", Value: "true"}}}, wantErr: "takes no value"},
+		{name: "equal without key", scheduling: &types.SchedulingConfig{Tolerations: []types.Toleration{{Value: "true"}}}, wantErr: "needs a key"},
+		{name: "unknown effect", scheduling: &types.SchedulingConfig{Tolerations: []types.Toleration{{Key: "spot", Effect: "Evict"}}}, wantErr: "effect"},
+		{name: "seconds without NoExecute", scheduling: &types.SchedulingConfig{Tolerations: []types.Toleration{{Key: "spot", Effect: "NoSchedule", TolerationSeconds: &seconds}}}, wantErr: "toleration_seconds"},
+		{name: "spread without key", scheduling: &types.SchedulingConfig{TopologySpread: []types.TopologySpreadConstraint{{MaxSkew: 1}}}, wantErr: "topology_key"},
+		{name: "unknown spread action", scheduling: &types.SchedulingConfig{TopologySpread: []types.TopologySpreadConstraint{{TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: "Wait"}}}, wantErr: "when_unsatisfiable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateScheduling(tt.scheduling)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateScheduling() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateScheduling() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPlacement(t *testing.T) {
+	pools := []types.NodePool{
+		{Name: "default", Nodes: 3, Labels: map[string]string{"kubernetes.io/os": "linux"}, Taints: []types.Taint{}},
+		{Name: "spot", Nodes: 2, Labels: map[string]string{"karpenter.sh/nodepool": "spot", "kubernetes.io/os": "linux"},
+			Taints: []types.Taint{{Key: "spot", Value: "true", Effect: "NoSchedule"}}},
+	}
+	spot := map[string]string{"karpenter.sh/nodepool": "spot"}
+
+	tests := []struct {
+		name       string
+		scheduling *types.SchedulingConfig
+		wantErr    string
+	}{
+		{name: "anywhere"},
+		{name: "spot with toleration", scheduling: &types.SchedulingConfig{
+			NodeSelector: spot,
+			Tolerations:  []types.Toleration{{Key: "spot", Value: "true", Effect: "NoSchedule"}},
+		}},
+		{name: "spot without toleration", scheduling: &types.SchedulingConfig{NodeSelector: spot}, wantErr: "spot (spot=true:NoSchedule)"},
+		{name: "missing pool", scheduling: &types.SchedulingConfig{NodeSelector: map[string]string{"karpenter.sh/nodepool": "gpu"}}, wantErr: "no node pool has the labels karpenter.sh/nodepool=gpu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPlacement(pools, tt.scheduling)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckPlacement() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckPlacement() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := CheckPlacement(nil, &types.SchedulingConfig{NodeSelector: spot}); err != nil {
+		t.Errorf("CheckPlacement() without nodes error = %v, want it skipped", err)
+	}
+}
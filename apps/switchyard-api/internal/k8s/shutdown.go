@@ -0,0 +1,141 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// =============================================================================
+// Graceful Shutdown
+// =============================================================================
+
+const (
+	// DefaultTerminationGracePeriod is how long pods have to stop unless a
+	// service sets its own
+	DefaultTerminationGracePeriod int64 = 30
+
+	// MaxTerminationGracePeriod bounds how long a deploy waits on one pod
+	MaxTerminationGracePeriod int64 = 3600
+
+	// DefaultRollingUpdatePercent is the surge and unavailability of deploys
+	// unless a service sets its own
+	DefaultRollingUpdatePercent = "25%"
+)
+
+// ValidateShutdown checks a service's shutdown settings
+func ValidateShutdown(s *types.ShutdownConfig) error {
+	if s == nil {
+		return nil
+	}
+
+	grace := TerminationGracePeriod(s)
+	if *grace < 0 || *grace > MaxTerminationGracePeriod {
+		return fmt.Errorf("termination_grace_period_seconds must be between 0 and %d", MaxTerminationGracePeriod)
+	}
+
+	if hook := s.PreStop; hook != nil {
+		switch {
+		case hook.SleepSeconds != 0 && hook.HTTPPath != "":
+			return fmt.Errorf("pre_stop takes either sleep_seconds or http_path, not both")
+		case hook.SleepSeconds < 0:
+			return fmt.Errorf("pre_stop.sleep_seconds must be positive")
+		case hook.SleepSeconds >= *grace:
+			return fmt.Errorf("pre_stop.sleep_seconds must be shorter than the termination grace period of %ds", *grace)
+		case hook.HTTPPath != "" && !strings.HasPrefix(hook.HTTPPath, "/"):
+			return fmt.Errorf("pre_stop.http_path %q must start with /", hook.HTTPPath)
+		case hook.SleepSeconds == 0 && hook.HTTPPath == "":
+			return fmt.Errorf("pre_stop needs sleep_seconds or http_path")
+		}
+		if hook.Port < 0 || hook.Port > 65535 {
+			return fmt.Errorf("pre_stop.port must be between 1 and 65535")
+		}
+	}
+
+	surge, err := parseRollingUpdateValue("max_surge", s.MaxSurge)
+	if err != nil {
+		return err
+	}
+	unavailable, err := parseRollingUpdateValue("max_unavailable", s.MaxUnavailable)
+	if err != nil {
+		return err
+	}
+	if isZero(surge) && isZero(unavailable) {
+		return fmt.Errorf("max_surge and max_unavailable cannot both be 0")
+	}
+	return nil
+}
+
+// parseRollingUpdateValue parses a percentage or a number of pods, with the
+// default for an empty value
+func parseRollingUpdateValue(field, value string) (intstr.IntOrString, error) {
+	if value == "" {
+		return intstr.FromString(DefaultRollingUpdatePercent), nil
+	}
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(percent)
+		if err != nil || n < 0 || n > 100 {
+			return intstr.IntOrString{}, fmt.Errorf("%s %q must be a percentage between 0%% and 100%%", field, value)
+		}
+		return intstr.FromString(value), nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return intstr.IntOrString{}, fmt.Errorf("%s %q must be a percentage or a number of pods", field, value)
+	}
+	return intstr.FromInt32(int32(n)), nil
+}
+
+func isZero(v intstr.IntOrString) bool {
+	if v.Type == intstr.Int {
+		return v.IntVal == 0
+	}
+	return v.StrVal == "0%"
+}
+
+// TerminationGracePeriod is how long a service's pods have to stop
+func TerminationGracePeriod(s *types.ShutdownConfig) *int64 {
+	grace := DefaultTerminationGracePeriod
+	if s != nil && s.TerminationGracePeriodSeconds != nil {
+		grace = *s.TerminationGracePeriodSeconds
+	}
+	return &grace
+}
+
+// PreStop builds the lifecycle hooks of a service's container, or nil
+// without a preStop hook
+func PreStop(s *types.ShutdownConfig, containerPort int32) *corev1.Lifecycle {
+	if s == nil || s.PreStop == nil {
+		return nil
+	}
+	hook := s.PreStop
+	if hook.HTTPPath != "" {
+		port := containerPort
+		if hook.Port > 0 {
+			port = int32(hook.Port)
+		}
+		return &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: hook.HTTPPath, Port: intstr.FromInt32(port)},
+		}}
+	}
+	return &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{
+		Sleep: &corev1.SleepAction{Seconds: hook.SleepSeconds},
+	}}
+}
+
+// RollingUpdate builds the rolling update of a service's Deployment
+func RollingUpdate(s *types.ShutdownConfig) *appsv1.RollingUpdateDeployment {
+	var surge, unavailable string
+	if s != nil {
+		surge, unavailable = s.MaxSurge, s.MaxUnavailable
+	}
+	maxSurge, _ := parseRollingUpdateValue("max_surge", surge)
+	maxUnavailable, _ := parseRollingUpdateValue("max_unavailable", unavailable)
+	return &appsv1.RollingUpdateDeployment{MaxSurge: &maxSurge, MaxUnavailable: &maxUnavailable}
+}
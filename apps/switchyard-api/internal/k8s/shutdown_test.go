@@ -0,0 +1,82 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateShutdown(t *testing.T) {
+	grace := func(seconds int64) *int64 { return &seconds }
+	tests := []struct {
+		name     string
+		shutdown *types.ShutdownConfig
+		wantErr  string
+	}{
+		{name: "none"},
+		{name: "defaults", shutdown: &types.ShutdownConfig{}},
+		{
+			name: "drain with sleep",
+			shutdown: &types.ShutdownConfig{
+				TerminationGracePeriodSeconds: grace(60),
+				PreStop:                       &types.PreStopHook{SleepSeconds: 15},
+				MaxSurge:                      "100%",
+				MaxUnavailable:                "0",
+			},
+		},
+		{name: "drain endpoint", shutdown: &types.ShutdownConfig{PreStop: &types.PreStopHook{HTTPPath: "/drain", Port: 9090}}},
+		{name: "negative grace period", shutdown: &types.ShutdownConfig{TerminationGracePeriodSeconds: grace(-1)}, wantErr: "termination_grace_period_seconds"},
+		{name: "sleep past grace period", shutdown: &types.ShutdownConfig{PreStop: &types.PreStopHook{SleepSeconds: 30}}, wantErr: "shorter than"},
+		{name: "sleep and request", shutdown: &types.ShutdownConfig{PreStop: &types.PreStopHook{SleepSeconds: 5, HTTPPath: "/drain"}}, wantErr: "not both"},
+		{name: "empty hook", shutdown: &types.ShutdownConfig{PreStop: &types.PreStopHook{}}, wantErr: "needs"},
+		{name: "relative path", shutdown: &types.ShutdownConfig{PreStop: &types.PreStopHook{HTTPPath: "drain"}}, wantErr: "http_path"},
+		{name: "percentage over 100", shutdown: &types.ShutdownConfig{MaxSurge: "150%"}, wantErr: "max_surge"},
+		{name: "not a number", shutdown: &types.ShutdownConfig{MaxUnavailable: "half"}, wantErr: "max_unavailable"},
+		{name: "no progress", shutdown: &types.ShutdownConfig{MaxSurge: "0%", MaxUnavailable: "0"}, wantErr: "both be 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateShutdown(tt.shutdown)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateShutdown() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateShutdown() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShutdownManifest(t *testing.T) {
+	if got := *TerminationGracePeriod(nil); got != DefaultTerminationGracePeriod {
+		t.Errorf("TerminationGracePeriod(nil) = %d, want %d", got, DefaultTerminationGracePeriod)
+	}
+	if PreStop(nil, 8080) != nil {
+		t.Error("PreStop(nil) added a hook")
+	}
+
+	update := RollingUpdate(nil)
+	if update.MaxSurge.String() != "25%" || update.MaxUnavailable.String() != "25%" {
+		t.Errorf("RollingUpdate(nil) = %s/%s, want 25%%/25%%", update.MaxSurge.String(), update.MaxUnavailable.String())
+	}
+	update = RollingUpdate(&types.ShutdownConfig{MaxSurge: "1", MaxUnavailable: "0%"})
+	if *update.MaxSurge != intstr.FromInt32(1) || *update.MaxUnavailable != intstr.FromString("0%") {
+		t.Errorf("RollingUpdate() = %s/%s, want 1/0%%", update.MaxSurge.String(), update.MaxUnavailable.String())
+	}
+
+	lifecycle := PreStop(&types.ShutdownConfig{PreStop: &types.PreStopHook{HTTPPath: "/drain"}}, 3000)
+	if get := lifecycle.PreStop.HTTPGet; get == nil || get.Path != "/drain" || get.Port.IntValue() != 3000 {
+		t.Errorf("PreStop() = %+v, want GET /drain on the container port", lifecycle.PreStop)
+	}
+	lifecycle = PreStop(&types.ShutdownConfig{PreStop: &types.PreStopHook{SleepSeconds: 10}}, 3000)
+	if sleep := lifecycle.PreStop.Sleep; sleep == nil || sleep.Seconds != 10 {
+		t.Errorf("PreStop() = %+v, want a 10s sleep", lifecycle.PreStop)
+	}
+}
@@ -0,0 +1,219 @@
+package logshipping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Labels the shipper attaches to every log stream
+const (
+	LabelProject     = "project"
+	LabelEnvironment = "environment"
+	LabelNamespace   = "namespace"
+	LabelApp         = "app"
+	LabelPod         = "pod"
+	LabelContainer   = "container"
+	LabelStream      = "stream"
+)
+
+// FilterLabels are the labels a search may additionally filter on
+var FilterLabels = []string{LabelPod, LabelContainer, LabelStream}
+
+// MaxSearchLimit is the most entries one search returns
+const MaxSearchLimit = 5000
+
+// SearchQuery selects the logs of one service over a time range
+type SearchQuery struct {
+	Project     string
+	Environment string
+	App         string
+	Labels      map[string]string // Values for FilterLabels
+	Contains    string            // Case-insensitive substring
+	Regexp      string            // RE2 pattern lines must match
+	Start       time.Time
+	End         time.Time
+	Limit       int
+	Forward     bool // Oldest first; newest first otherwise
+}
+
+// BuildQuery renders the LogQL for a search. Labels outside FilterLabels
+// are rejected, and the pattern must compile.
+func BuildQuery(q SearchQuery) (string, error) {
+	matchers := []string{
+		matcher(LabelProject, q.Project),
+		matcher(LabelEnvironment, q.Environment),
+		matcher(LabelApp, q.App),
+	}
+
+	names := make([]string, 0, len(q.Labels))
+	for name := range q.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !isFilterLabel(name) {
+			return "", fmt.Errorf("cannot filter on label %q", name)
+		}
+		if q.Labels[name] != "" {
+			matchers = append(matchers, matcher(name, q.Labels[name]))
+		}
+	}
+
+	var query strings.Builder
+	query.WriteString("{" + strings.Join(matchers, ",") + "}")
+	if q.Contains != "" {
+		query.WriteString(" |~ " + strconv.Quote("(?i)"+regexp.QuoteMeta(q.Contains)))
+	}
+	if q.Regexp != "" {
+		if _, err := regexp.Compile(q.Regexp); err != nil {
+			return "", fmt.Errorf("invalid pattern: %w", err)
+		}
+		query.WriteString(" |~ " + strconv.Quote(q.Regexp))
+	}
+	return query.String(), nil
+}
+
+func matcher(name, value string) string {
+	return name + "=" + strconv.Quote(value)
+}
+
+func isFilterLabel(name string) bool {
+	for _, label := range FilterLabels {
+		if name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// LokiClient searches the logs the shipper sent to Loki
+type LokiClient struct {
+	baseURL    string
+	tenantID   string
+	httpClient *http.Client
+}
+
+// NewLokiClient creates a client for the Loki server at baseURL. tenantID
+// is sent as X-Scope-OrgID when set.
+func NewLokiClient(baseURL, tenantID string) *LokiClient {
+	return &LokiClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		tenantID:   tenantID,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Search runs a search and returns the LogQL that ran with its entries
+func (c *LokiClient) Search(ctx context.Context, q SearchQuery) (string, []types.LogEntry, error) {
+	query, err := BuildQuery(q)
+	if err != nil {
+		return "", nil, err
+	}
+
+	direction := "backward"
+	if q.Forward {
+		direction = "forward"
+	}
+	params := url.Values{
+		"query":     {query},
+		"start":     {strconv.FormatInt(q.Start.UnixNano(), 10)},
+		"end":       {strconv.FormatInt(q.End.UnixNano(), 10)},
+		"limit":     {strconv.Itoa(q.Limit)},
+		"direction": {direction},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/loki/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return query, nil, err
+	}
+	if c.tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", c.tenantID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return query, nil, fmt.Errorf("failed to query loki: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return query, nil, fmt.Errorf("failed to read loki response: %w", err)
+	}
+
+	entries, err := parseStreams(resp.StatusCode, body, q.Forward, q.Limit)
+	return query, entries, err
+}
+
+// lokiQueryResponse is the part of a Loki query_range response that is read
+type lokiQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// parseStreams merges the streams of a query_range response into entries
+// ordered by time, newest first unless forward, keeping at most limit
+func parseStreams(statusCode int, body []byte, forward bool, limit int) ([]types.LogEntry, error) {
+	if statusCode != http.StatusOK {
+		// Loki answers errors in plain text
+		return nil, fmt.Errorf("loki returned %d: %s", statusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result lokiQueryResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("loki returned an invalid response: %w", err)
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("loki query failed: %s", result.Error)
+	}
+	if result.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("loki returned %s, expected streams", result.Data.ResultType)
+	}
+
+	entries := []types.LogEntry{}
+	for _, stream := range result.Data.Result {
+		for _, value := range stream.Values {
+			nanos, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("loki returned an invalid timestamp %q", value[0])
+			}
+			entries = append(entries, types.LogEntry{
+				Timestamp: time.Unix(0, nanos).UTC(),
+				Pod:       stream.Stream[LabelPod],
+				Container: stream.Stream[LabelContainer],
+				Stream:    stream.Stream[LabelStream],
+				Line:      value[1],
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if forward {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		}
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
@@ -0,0 +1,149 @@
+package logshipping
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildQuery(t *testing.T) {
+	base := SearchQuery{Project: "shop", Environment: "production", App: "api"}
+
+	tests := []struct {
+		name    string
+		mutate  func(q *SearchQuery)
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "service only",
+			want: `{project="shop",environment="production",app="api"}`,
+		},
+		{
+			name: "label filters sorted, empty skipped",
+			mutate: func(q *SearchQuery) {
+				q.Labels = map[string]string{LabelStream: "stderr", LabelPod: "api-1", LabelContainer: ""}
+			},
+			want: `{project="shop",environment="production",app="api",pod="api-1",stream="stderr"}`,
+		},
+		{
+			name: "text is matched literally and case-insensitively",
+			mutate: func(q *SearchQuery) {
+				q.Contains = `GET /v1 (500)`
+				q.Regexp = `status=5\d\d`
+			},
+			want: `{project="shop",environment="production",app="api"} |~ "(?i)GET /v1 \\(500\\)" |~ "status=5\\d\\d"`,
+		},
+		{
+			name:    "label outside the filters",
+			mutate:  func(q *SearchQuery) { q.Labels = map[string]string{LabelProject: "other"} },
+			wantErr: true,
+		},
+		{
+			name:    "invalid pattern",
+			mutate:  func(q *SearchQuery) { q.Regexp = "(" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		q := base
+		if tt.mutate != nil {
+			tt.mutate(&q)
+		}
+		got, err := BuildQuery(q)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: BuildQuery() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: BuildQuery() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+const streamsResponse = `{
+	"status": "success",
+	"data": {
+		"resultType": "streams",
+		"result": [
+			{"stream": {"pod": "api-1", "container": "api", "stream": "stdout"},
+			 "values": [["1700000003000000000", "third"], ["1700000001000000000", "first"]]},
+			{"stream": {"pod": "api-2", "container": "api", "stream": "stderr"},
+			 "values": [["1700000002000000000", "second"]]}
+		]
+	}
+}`
+
+func TestParseStreams(t *testing.T) {
+	entries, err := parseStreams(http.StatusOK, []byte(streamsResponse), false, 0)
+	if err != nil {
+		t.Fatalf("parseStreams() error = %v", err)
+	}
+	var lines []string
+	for _, entry := range entries {
+		lines = append(lines, entry.Line)
+	}
+	if got := strings.Join(lines, ","); got != "third,second,first" {
+		t.Errorf("backward order = %s, want third,second,first", got)
+	}
+	if entries[1].Pod != "api-2" || entries[1].Stream != "stderr" {
+		t.Errorf("entry labels = %+v, want pod api-2 on stderr", entries[1])
+	}
+
+	entries, err = parseStreams(http.StatusOK, []byte(streamsResponse), true, 2)
+	if err != nil {
+		t.Fatalf("parseStreams() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Line != "first" || entries[1].Line != "second" {
+		t.Errorf("forward with limit = %+v, want first, second", entries)
+	}
+
+	if _, err := parseStreams(http.StatusBadRequest, []byte("parse error"), false, 0); err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("error response: got %v, want Loki's message", err)
+	}
+	if _, err := parseStreams(http.StatusOK, []byte(`{"status":"success","data":{"resultType":"matrix"}}`), false, 0); err == nil {
+		t.Error("matrix result: expected an error")
+	}
+}
+
+func TestLokiClientSearch(t *testing.T) {
+	end := time.Unix(1700000010, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/query_range" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Scope-OrgID"); got != "tenant-a" {
+			t.Errorf("X-Scope-OrgID = %q, want tenant-a", got)
+		}
+		query := r.URL.Query()
+		if got := query.Get("query"); got != `{project="shop",environment="production",app="api"}` {
+			t.Errorf("query = %s", got)
+		}
+		if query.Get("end") != "1700000010000000000" || query.Get("limit") != "10" || query.Get("direction") != "forward" {
+			t.Errorf("range params = %v", query)
+		}
+		w.Write([]byte(streamsResponse))
+	}))
+	defer server.Close()
+
+	client := NewLokiClient(server.URL+"/", "tenant-a")
+	_, entries, err := client.Search(context.Background(), SearchQuery{
+		Project:     "shop",
+		Environment: "production",
+		App:         "api",
+		Start:       end.Add(-time.Hour),
+		End:         end,
+		Limit:       10,
+		Forward:     true,
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(entries) != 3 || entries[0].Line != "first" {
+		t.Errorf("Search() entries = %+v", entries)
+	}
+}
@@ -0,0 +1,183 @@
+// Package logshipping aggregates service logs in Loki: it runs a Vector
+// DaemonSet that ships the container logs of environment namespaces, and
+// searches the shipped logs.
+package logshipping
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SyncInterval is how often the shipper and namespace labels are synced
+const SyncInterval = 5 * time.Minute
+
+// Config configures log shipping
+type Config struct {
+	Namespace string // Namespace the DaemonSet runs in
+	Image     string // Vector image
+	LokiURL   string
+	TenantID  string
+}
+
+// Shipper keeps the Vector DaemonSet in place and labels the namespaces of
+// environments so their logs are shipped
+type Shipper struct {
+	repos  *db.Repositories
+	kube   kubernetes.Interface
+	config Config
+	logger *logrus.Logger
+}
+
+// NewShipper creates a log shipper
+func NewShipper(repos *db.Repositories, kube kubernetes.Interface, config Config, logger *logrus.Logger) *Shipper {
+	return &Shipper{
+		repos:  repos,
+		kube:   kube,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Sync applies the Vector configuration and DaemonSet, then labels the
+// namespaces of all environments. Namespaces labelled disabled are left
+// out.
+func (s *Shipper) Sync(ctx context.Context) error {
+	if err := s.applyShipper(ctx); err != nil {
+		return err
+	}
+
+	labelled, err := s.labelNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	if labelled > 0 {
+		s.logger.WithField("namespaces", labelled).Info("Enabled log shipping for namespaces")
+	}
+	return nil
+}
+
+// applyShipper creates or updates the ConfigMap and DaemonSet
+func (s *Shipper) applyShipper(ctx context.Context) error {
+	config := vectorConfig(s.config.LokiURL, s.config.TenantID)
+
+	desiredConfig := shipperConfigMap(s.config.Namespace, config)
+	configMaps := s.kube.CoreV1().ConfigMaps(s.config.Namespace)
+	existingConfig, err := configMaps.Get(ctx, ShipperName, metav1.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+		if _, err := configMaps.Create(ctx, desiredConfig, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create log shipper config: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get log shipper config: %w", err)
+	default:
+		existingConfig.Labels = desiredConfig.Labels
+		existingConfig.Data = desiredConfig.Data
+		if _, err := configMaps.Update(ctx, existingConfig, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update log shipper config: %w", err)
+		}
+	}
+
+	desired := shipperDaemonSet(s.config.Namespace, s.config.Image, configHash(config))
+	daemonSets := s.kube.AppsV1().DaemonSets(s.config.Namespace)
+	existing, err := daemonSets.Get(ctx, ShipperName, metav1.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+		if _, err := daemonSets.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create log shipper: %w", err)
+		}
+		s.logger.WithField("namespace", s.config.Namespace).Info("Created log shipper DaemonSet")
+	case err != nil:
+		return fmt.Errorf("failed to get log shipper: %w", err)
+	default:
+		existing.Labels = desired.Labels
+		existing.Spec.Template = desired.Spec.Template
+		if _, err := daemonSets.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update log shipper: %w", err)
+		}
+	}
+	return nil
+}
+
+// labelNamespaces labels each environment's namespace for shipping with its
+// project and environment, returning how many namespaces changed
+func (s *Shipper) labelNamespaces(ctx context.Context) (int, error) {
+	environments, err := s.repos.Environments.ListAll()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list environments: %w", err)
+	}
+	projects, err := s.repos.Projects.List()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list projects: %w", err)
+	}
+	slugs := make(map[string]string, len(projects))
+	for _, project := range projects {
+		slugs[project.ID.String()] = project.Slug
+	}
+
+	labelled := 0
+	for _, env := range environments {
+		slug, ok := slugs[env.ProjectID.String()]
+		if !ok || env.KubeNamespace == "" {
+			continue
+		}
+		changed, err := s.labelNamespace(ctx, env, slug)
+		if err != nil {
+			s.logger.WithError(err).WithField("namespace", env.KubeNamespace).Warn("Failed to label namespace for log shipping")
+			continue
+		}
+		if changed {
+			labelled++
+		}
+	}
+	return labelled, nil
+}
+
+func (s *Shipper) labelNamespace(ctx context.Context, env *types.Environment, projectSlug string) (bool, error) {
+	namespaces := s.kube.CoreV1().Namespaces()
+	ns, err := namespaces.Get(ctx, env.KubeNamespace, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		// Created on the environment's first deployment
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	want := map[string]string{
+		NamespaceShippingLabel:    "enabled",
+		NamespaceProjectLabel:     projectSlug,
+		NamespaceEnvironmentLabel: env.Name,
+	}
+	if ns.Labels[NamespaceShippingLabel] == "disabled" {
+		return false, nil
+	}
+
+	changed := false
+	if ns.Labels == nil {
+		ns.Labels = make(map[string]string, len(want))
+	}
+	for key, value := range want {
+		if ns.Labels[key] != value {
+			ns.Labels[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if _, err := namespaces.Update(ctx, ns, metav1.UpdateOptions{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
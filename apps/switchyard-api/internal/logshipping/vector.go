@@ -0,0 +1,193 @@
+package logshipping
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Names of the shipper's Kubernetes resources
+const (
+	ShipperName           = "enclii-log-shipper" // DaemonSet, ConfigMap and ServiceAccount
+	configHashAnnotation  = "enclii.dev/config-hash"
+	configFileName        = "vector.yaml"
+	vectorDataDir         = "/vector-data-dir"
+	vectorConfigMountPath = "/etc/vector"
+)
+
+// Namespace labels that decide which namespaces are shipped, and how their
+// logs are labelled in Loki
+const (
+	NamespaceShippingLabel    = "enclii.dev/log-shipping" // enabled, or disabled to opt a namespace out
+	NamespaceProjectLabel     = "enclii.dev/project"
+	NamespaceEnvironmentLabel = "enclii.dev/environment"
+)
+
+// vectorConfig renders the Vector configuration: container logs of pods in
+// namespaces labelled for shipping go to Loki, labelled with the project
+// and environment of their namespace
+func vectorConfig(lokiURL, tenantID string) string {
+	tenant := ""
+	if tenantID != "" {
+		tenant = "\n    tenant_id: " + strconv.Quote(tenantID)
+	}
+
+	return fmt.Sprintf(`data_dir: %s
+api:
+  enabled: false
+sources:
+  kubernetes:
+    type: kubernetes_logs
+    extra_namespace_label_selector: "%s=enabled"
+transforms:
+  labelled:
+    type: remap
+    inputs: [kubernetes]
+    source: |
+      .%s = .kubernetes.namespace_labels."%s" ?? "unknown"
+      .%s = .kubernetes.namespace_labels."%s" ?? "unknown"
+      .%s = .kubernetes.pod_labels.app ?? .kubernetes.container_name
+sinks:
+  loki:
+    type: loki
+    inputs: [labelled]
+    endpoint: %s%s
+    encoding:
+      codec: text
+    out_of_order_action: accept
+    labels:
+      %s: "{{ %s }}"
+      %s: "{{ %s }}"
+      %s: "{{ kubernetes.pod_namespace }}"
+      %s: "{{ %s }}"
+      %s: "{{ kubernetes.pod_name }}"
+      %s: "{{ kubernetes.container_name }}"
+      %s: "{{ stream }}"
+`,
+		vectorDataDir,
+		NamespaceShippingLabel,
+		LabelProject, NamespaceProjectLabel,
+		LabelEnvironment, NamespaceEnvironmentLabel,
+		LabelApp,
+		strconv.Quote(lokiURL), tenant,
+		LabelProject, LabelProject,
+		LabelEnvironment, LabelEnvironment,
+		LabelNamespace,
+		LabelApp, LabelApp,
+		LabelPod,
+		LabelContainer,
+		LabelStream,
+	)
+}
+
+// configHash fingerprints a configuration so the DaemonSet rolls when it changes
+func configHash(config string) string {
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:8])
+}
+
+func shipperLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       ShipperName,
+		"app.kubernetes.io/component":  "log-shipping",
+		"app.kubernetes.io/part-of":    "enclii",
+		"app.kubernetes.io/managed-by": "switchyard-api",
+	}
+}
+
+// shipperConfigMap holds the Vector configuration
+func shipperConfigMap(namespace, config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ShipperName,
+			Namespace: namespace,
+			Labels:    shipperLabels(),
+		},
+		Data: map[string]string{configFileName: config},
+	}
+}
+
+// shipperDaemonSet runs Vector on every node, reading container logs from
+// the node's /var/log
+func shipperDaemonSet(namespace, image, hash string) *appsv1.DaemonSet {
+	labels := shipperLabels()
+	selector := map[string]string{"app.kubernetes.io/name": ShipperName}
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ShipperName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: map[string]string{configHashAnnotation: hash},
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: ShipperName,
+					// Ship logs from every node, including tainted ones
+					Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					Containers: []corev1.Container{{
+						Name:  "vector",
+						Image: image,
+						Args:  []string{"--config-dir", vectorConfigMountPath},
+						Env: []corev1.EnvVar{
+							fieldEnv("VECTOR_SELF_NODE_NAME", "spec.nodeName"),
+							fieldEnv("VECTOR_SELF_POD_NAME", "metadata.name"),
+							fieldEnv("VECTOR_SELF_POD_NAMESPACE", "metadata.namespace"),
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("50m"),
+								corev1.ResourceMemory: resource.MustParse("64Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("500m"),
+								corev1.ResourceMemory: resource.MustParse("256Mi"),
+							},
+						},
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "config", MountPath: vectorConfigMountPath, ReadOnly: true},
+							{Name: "data", MountPath: vectorDataDir},
+							{Name: "var-log", MountPath: "/var/log", ReadOnly: true},
+							{Name: "var-lib", MountPath: "/var/lib", ReadOnly: true},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "config", VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: ShipperName},
+							},
+						}},
+						hostPathVolume("data", "/var/lib/vector"),
+						hostPathVolume("var-log", "/var/log"),
+						hostPathVolume("var-lib", "/var/lib"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func fieldEnv(name, fieldPath string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name:      name,
+		ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: fieldPath}},
+	}
+}
+
+func hostPathVolume(name, path string) corev1.Volume {
+	return corev1.Volume{
+		Name:         name,
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: path}},
+	}
+}
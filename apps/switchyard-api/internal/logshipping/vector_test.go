@@ -0,0 +1,63 @@
+package logshipping
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVectorConfig(t *testing.T) {
+	config := vectorConfig("http://loki.monitoring:3100", "tenant-a")
+
+	for _, want := range []string{
+		`extra_namespace_label_selector: "enclii.dev/log-shipping=enabled"`,
+		`endpoint: "http://loki.monitoring:3100"`,
+		`tenant_id: "tenant-a"`,
+		`project: "{{ project }}"`,
+		`pod: "{{ kubernetes.pod_name }}"`,
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config is missing %s:\n%s", want, config)
+		}
+	}
+	if strings.Contains(vectorConfig("http://loki:3100", ""), "tenant_id") {
+		t.Error("tenant_id set without a tenant")
+	}
+}
+
+func TestApplyShipperRollsOnConfigChange(t *testing.T) {
+	ctx := context.Background()
+	kube := fake.NewSimpleClientset()
+	config := Config{Namespace: "enclii", Image: "timberio/vector:0.39.0", LokiURL: "http://loki:3100"}
+
+	shipper := NewShipper(nil, kube, config, logrus.New())
+	if err := shipper.applyShipper(ctx); err != nil {
+		t.Fatalf("applyShipper() error = %v", err)
+	}
+	ds, err := kube.AppsV1().DaemonSets("enclii").Get(ctx, ShipperName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("DaemonSet not created: %v", err)
+	}
+	firstHash := ds.Spec.Template.Annotations[configHashAnnotation]
+
+	config.TenantID = "tenant-a"
+	shipper = NewShipper(nil, kube, config, logrus.New())
+	if err := shipper.applyShipper(ctx); err != nil {
+		t.Fatalf("applyShipper() update error = %v", err)
+	}
+	cm, err := kube.CoreV1().ConfigMaps("enclii").Get(ctx, ShipperName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("ConfigMap not created: %v", err)
+	}
+	if !strings.Contains(cm.Data[configFileName], "tenant-a") {
+		t.Error("ConfigMap not updated with the tenant")
+	}
+	ds, _ = kube.AppsV1().DaemonSets("enclii").Get(ctx, ShipperName, metav1.GetOptions{})
+	if ds.Spec.Template.Annotations[configHashAnnotation] == firstHash {
+		t.Error("pod template hash unchanged, DaemonSet would not roll")
+	}
+}
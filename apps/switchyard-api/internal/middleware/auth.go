@@ -159,6 +159,9 @@ func (a *AuthMiddleware) Middleware() gin.HandlerFunc {
 			c.Set("user_id", apiToken.UserID.String())
 			c.Set("auth_type", "api_token")
 			c.Set("api_token_id", apiToken.ID)
+			if apiToken.RateLimit != nil {
+				c.Set("api_token_rate_limit", *apiToken.RateLimit)
+			}
 
 			// API tokens get developer role by default (scoped by token scopes if needed)
 			rolesStr := []string{"developer"}
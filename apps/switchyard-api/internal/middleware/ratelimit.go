@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
@@ -12,11 +14,18 @@ import (
 
 // RateLimitConfig configures rate limiting behavior
 type RateLimitConfig struct {
+	// Name tells the limiter's keys apart from other limiters' in a shared
+	// store, e.g. "auth"
+	Name string
 	// Requests per window
 	Limit int
+	// LimitFunc gives a request its own limit, such as an API token's
+	// custom quota. It returns 0 to keep Limit.
+	LimitFunc func(*gin.Context) int
 	// Time window duration
 	Window time.Duration
-	// Key function to identify clients (returns key string)
+	// Key function to identify clients (returns key string). An empty key
+	// leaves the request out of this limit.
 	KeyFunc func(*gin.Context) string
 	// Whether to skip successful requests (only count failures)
 	SkipSuccessful bool
@@ -28,11 +37,21 @@ type visitor struct {
 	windowEnd time.Time
 }
 
-// RateLimiter implements token bucket rate limiting
+// RateStore counts requests in a store shared by every replica, such as
+// Redis, so a limit holds across the whole deployment. cache.CacheService
+// is one.
+type RateStore interface {
+	AllowRate(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error)
+}
+
+// RateLimiter limits the requests of each client in a window. With a
+// RateStore the window is shared by every replica; without one, or while
+// the store fails, each replica counts on its own.
 type RateLimiter struct {
 	mu       sync.RWMutex
 	visitors map[string]*visitor
 	config   RateLimitConfig
+	store    RateStore
 	// Cleanup goroutine control
 	stopCleanup chan struct{}
 }
@@ -51,6 +70,13 @@ func NewRateLimiter(config RateLimitConfig) *RateLimiter {
 	return rl
 }
 
+// WithStore makes the limiter count in a shared store. A nil store keeps
+// counting in memory.
+func (rl *RateLimiter) WithStore(store RateStore) *RateLimiter {
+	rl.store = store
+	return rl
+}
+
 // cleanupLoop periodically removes expired visitors
 func (rl *RateLimiter) cleanupLoop() {
 	ticker := time.NewTicker(time.Minute)
@@ -84,9 +110,36 @@ func (rl *RateLimiter) Stop() {
 	close(rl.stopCleanup)
 }
 
-// Allow checks if a request should be allowed
+// limitFor returns the limit of a request
+func (rl *RateLimiter) limitFor(c *gin.Context) int {
+	if rl.config.LimitFunc != nil {
+		if limit := rl.config.LimitFunc(c); limit > 0 {
+			return limit
+		}
+	}
+	return rl.config.Limit
+}
+
+// allow checks a request in the shared store, falling back to memory when
+// there is none or it fails
+func (rl *RateLimiter) allow(ctx context.Context, key string, limit int) (bool, int, time.Time) {
+	if rl.store != nil {
+		allowed, remaining, resetTime, err := rl.store.AllowRate(ctx, rl.config.Name+":"+key, limit, rl.config.Window)
+		if err == nil {
+			return allowed, remaining, resetTime
+		}
+		logrus.WithError(err).WithField("limiter", rl.config.Name).Debug("Rate limit store failed, counting in memory")
+	}
+	return rl.allowInMemory(key, limit)
+}
+
+// Allow checks if a request should be allowed, counting in memory
 // Returns: allowed (bool), remaining requests (int), reset time (time.Time)
 func (rl *RateLimiter) Allow(key string) (bool, int, time.Time) {
+	return rl.allowInMemory(key, rl.config.Limit)
+}
+
+func (rl *RateLimiter) allowInMemory(key string, limit int) (bool, int, time.Time) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -99,62 +152,123 @@ func (rl *RateLimiter) Allow(key string) (bool, int, time.Time) {
 			count:     1,
 			windowEnd: now.Add(rl.config.Window),
 		}
-		return true, rl.config.Limit - 1, now.Add(rl.config.Window)
+		return true, limit - 1, now.Add(rl.config.Window)
 	}
 
 	// Check if limit exceeded
-	if v.count >= rl.config.Limit {
+	if v.count >= limit {
 		return false, 0, v.windowEnd
 	}
 
 	// Increment count
 	v.count++
-	return true, rl.config.Limit - v.count, v.windowEnd
+	return true, limit - v.count, v.windowEnd
 }
 
 // Middleware returns a Gin middleware function for rate limiting
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get client key
-		key := rl.config.KeyFunc(c)
+		if rl.Check(c) {
+			c.Next()
+		}
+	}
+}
+
+// Check counts a request against the limit. Over the limit, it responds
+// with 429 and a structured error telling when to retry, aborts the request
+// and returns false.
+func (rl *RateLimiter) Check(c *gin.Context) bool {
+	// Get client key
+	key := rl.config.KeyFunc(c)
+	if key == "" {
+		return true
+	}
 
-		// Check rate limit
-		allowed, remaining, resetTime := rl.Allow(key)
+	// Check rate limit
+	limit := rl.limitFor(c)
+	allowed, remaining, resetTime := rl.allow(c.Request.Context(), key, limit)
 
-		// Set rate limit headers
-		c.Header("X-RateLimit-Limit", strconv.Itoa(rl.config.Limit))
+	// Set rate limit headers. Under several limits, the headers tell
+	// the one with the fewest requests left.
+	if !tighterLimitSet(c, remaining) {
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
 		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
 		c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
+	}
 
-		if !allowed {
-			// Calculate retry-after in seconds
-			retryAfter := int(time.Until(resetTime).Seconds())
-			if retryAfter < 1 {
-				retryAfter = 1
-			}
-
-			c.Header("Retry-After", strconv.Itoa(retryAfter))
-
-			logrus.WithFields(logrus.Fields{
-				"key":         key,
-				"limit":       rl.config.Limit,
-				"window":      rl.config.Window.String(),
-				"retry_after": retryAfter,
-				"path":        c.Request.URL.Path,
-				"method":      c.Request.Method,
-			}).Warn("Rate limit exceeded")
-
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Too many requests",
-				"retry_after": retryAfter,
-				"message":     "Rate limit exceeded. Please try again later.",
-			})
-			c.Abort()
-			return
-		}
+	if allowed {
+		return true
+	}
+
+	// Calculate retry-after in seconds
+	retryAfter := int(time.Until(resetTime).Seconds())
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	logrus.WithFields(logrus.Fields{
+		"limiter":     rl.config.Name,
+		"key":         key,
+		"limit":       limit,
+		"window":      rl.config.Window.String(),
+		"retry_after": retryAfter,
+		"path":        c.Request.URL.Path,
+		"method":      c.Request.Method,
+	}).Warn("Rate limit exceeded")
+
+	c.JSON(http.StatusTooManyRequests, RateLimitError{
+		Error:         "Too many requests",
+		Code:          RateLimitExceededCode,
+		Message:       fmt.Sprintf("Rate limit of %d requests per %s exceeded. Retry in %d seconds.", limit, windowText(rl.config.Window), retryAfter),
+		Limiter:       rl.config.Name,
+		Limit:         limit,
+		WindowSeconds: int(rl.config.Window.Seconds()),
+		RetryAfter:    retryAfter,
+		ResetAt:       resetTime.UTC().Truncate(time.Second),
+	})
+	c.Abort()
+	return false
+}
+
+// RateLimitExceededCode is the code of the error of a request over a limit
+const RateLimitExceededCode = "RATE_LIMIT_EXCEEDED"
+
+// RateLimitError is the body of a 429 response: which limit the request
+// went over and when to retry
+type RateLimitError struct {
+	Error         string    `json:"error"`
+	Code          string    `json:"code"`
+	Message       string    `json:"message"`
+	Limiter       string    `json:"limiter"` // e.g. "token", or "policy:build" for a route policy
+	Limit         int       `json:"limit"`
+	WindowSeconds int       `json:"window_seconds"`
+	RetryAfter    int       `json:"retry_after"` // Seconds, as in the Retry-After header
+	ResetAt       time.Time `json:"reset_at"`
+}
+
+// windowText spells out a window for error messages, e.g. "minute" or
+// "3600 seconds"
+func windowText(window time.Duration) string {
+	switch window {
+	case time.Minute:
+		return "minute"
+	case time.Hour:
+		return "hour"
+	}
+	return fmt.Sprintf("%d seconds", int(window.Seconds()))
+}
 
-		c.Next()
+// tighterLimitSet reports whether an earlier limit of the request left
+// fewer requests than remaining
+func tighterLimitSet(c *gin.Context, remaining int) bool {
+	previous := c.Writer.Header().Get("X-RateLimit-Remaining")
+	if previous == "" {
+		return false
 	}
+	n, err := strconv.Atoi(previous)
+	return err == nil && n < remaining
 }
 
 // =============================================================================
@@ -179,10 +293,42 @@ func UserKeyFunc(c *gin.Context) string {
 	return "anon:" + c.ClientIP()
 }
 
+// TokenKeyFunc returns the API token as the rate limit key, and no key for
+// requests not made with one (requires auth middleware)
+func TokenKeyFunc(c *gin.Context) string {
+	if tokenID, exists := c.Get("api_token_id"); exists {
+		if id, ok := tokenID.(fmt.Stringer); ok {
+			return "token:" + id.String()
+		}
+	}
+	return ""
+}
+
+// TokenLimitFunc returns the custom quota of the API token of a request, or
+// 0 when it has none (requires auth middleware)
+func TokenLimitFunc(c *gin.Context) int {
+	if limit, ok := c.Get("api_token_rate_limit"); ok {
+		if n, ok := limit.(int); ok {
+			return n
+		}
+	}
+	return 0
+}
+
+// AuthenticatedUserKeyFunc returns the user ID as the rate limit key, and
+// no key for anonymous requests (requires auth middleware)
+func AuthenticatedUserKeyFunc(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	return ""
+}
+
 // NewAuthRateLimiter creates a rate limiter for authentication endpoints
 // Default: 10 requests per minute per IP
 func NewAuthRateLimiter() *RateLimiter {
 	return NewRateLimiter(RateLimitConfig{
+		Name:    "auth",
 		Limit:   10,
 		Window:  time.Minute,
 		KeyFunc: IPKeyFunc,
@@ -193,6 +339,7 @@ func NewAuthRateLimiter() *RateLimiter {
 // Default: 100 requests per minute per user
 func NewAPIRateLimiter() *RateLimiter {
 	return NewRateLimiter(RateLimitConfig{
+		Name:    "api",
 		Limit:   100,
 		Window:  time.Minute,
 		KeyFunc: UserKeyFunc,
@@ -203,12 +350,61 @@ func NewAPIRateLimiter() *RateLimiter {
 // Default: 5 requests per minute per IP (for login failures, password reset, etc.)
 func NewStrictAuthRateLimiter() *RateLimiter {
 	return NewRateLimiter(RateLimitConfig{
+		Name:    "auth-strict",
 		Limit:   5,
 		Window:  time.Minute,
 		KeyFunc: IPKeyFunc,
 	})
 }
 
+// NewPublicPageRateLimiter creates a rate limiter for unauthenticated pages
+// such as project status pages
+// Default: 120 requests per minute per IP
+func NewPublicPageRateLimiter() *RateLimiter {
+	return NewRateLimiter(RateLimitConfig{
+		Name:    "public-page",
+		Limit:   120,
+		Window:  time.Minute,
+		KeyFunc: IPKeyFunc,
+	})
+}
+
+// NewIPRateLimiter creates a rate limiter allowing each client IP limit
+// requests per minute
+func NewIPRateLimiter(limit int) *RateLimiter {
+	return NewRateLimiter(RateLimitConfig{
+		Name:    "ip",
+		Limit:   limit,
+		Window:  time.Minute,
+		KeyFunc: IPKeyFunc,
+	})
+}
+
+// NewUserRateLimiter creates a rate limiter allowing each signed-in user
+// limit requests per minute. Anonymous requests are not counted.
+func NewUserRateLimiter(limit int) *RateLimiter {
+	return NewRateLimiter(RateLimitConfig{
+		Name:    "user",
+		Limit:   limit,
+		Window:  time.Minute,
+		KeyFunc: AuthenticatedUserKeyFunc,
+	})
+}
+
+// NewTokenRateLimiter creates a rate limiter allowing each API token limit
+// requests per minute, so one CI job cannot use up its owner's limit. A
+// token with a custom quota gets that instead. Requests without a token are
+// not counted.
+func NewTokenRateLimiter(limit int) *RateLimiter {
+	return NewRateLimiter(RateLimitConfig{
+		Name:      "token",
+		Limit:     limit,
+		Window:    time.Minute,
+		KeyFunc:   TokenKeyFunc,
+		LimitFunc: TokenLimitFunc,
+	})
+}
+
 // =============================================================================
 // Gin middleware factory functions
 // =============================================================================
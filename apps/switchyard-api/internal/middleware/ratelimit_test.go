@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeRateStore counts requests per key like a shared Redis would
+type fakeRateStore struct {
+	mu     sync.Mutex
+	counts map[string]int
+	keys   []string
+	err    error
+}
+
+func (s *fakeRateStore) AllowRate(_ context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return false, 0, time.Time{}, s.err
+	}
+	if s.counts == nil {
+		s.counts = map[string]int{}
+	}
+	s.keys = append(s.keys, key)
+	reset := time.Now().Add(window)
+	if s.counts[key] >= limit {
+		return false, 0, reset, nil
+	}
+	s.counts[key]++
+	return true, limit - s.counts[key], reset, nil
+}
+
+func serveLimited(handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/", append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })...)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestRateLimiter_SharedStoreAcrossReplicas(t *testing.T) {
+	store := &fakeRateStore{}
+	replicaA := NewIPRateLimiter(2).WithStore(store)
+	defer replicaA.Stop()
+	replicaB := NewIPRateLimiter(2).WithStore(store)
+	defer replicaB.Stop()
+
+	codes := []int{
+		serveLimited(replicaA.Middleware()).Code,
+		serveLimited(replicaB.Middleware()).Code,
+		serveLimited(replicaA.Middleware()).Code,
+	}
+	want := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Errorf("request %d status = %d, want %d", i, codes[i], want[i])
+		}
+	}
+	if len(store.keys) == 0 || store.keys[0] != "ip:192.0.2.1" {
+		t.Errorf("store keys = %v, want them prefixed with the limiter name", store.keys)
+	}
+}
+
+func TestRateLimiter_FallsBackToMemory(t *testing.T) {
+	limiter := NewIPRateLimiter(1).WithStore(&fakeRateStore{err: errors.New("connection refused")})
+	defer limiter.Stop()
+
+	if w := serveLimited(limiter.Middleware()); w.Code != http.StatusOK {
+		t.Errorf("first request status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serveLimited(limiter.Middleware()); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second request status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimiter_TokenLimit(t *testing.T) {
+	store := &fakeRateStore{}
+	limiter := NewTokenRateLimiter(1).WithStore(store)
+	defer limiter.Stop()
+	withToken := func(c *gin.Context) {
+		c.Set("api_token_id", uuid.MustParse("8d7f1c3e-4c2b-4a8e-9f55-2f1b7c9e0a11"))
+		c.Next()
+	}
+
+	// Requests without a token are not counted
+	for i := 0; i < 3; i++ {
+		if w := serveLimited(limiter.Middleware()); w.Code != http.StatusOK {
+			t.Fatalf("request without token status = %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+	if w := serveLimited(withToken, limiter.Middleware()); w.Code != http.StatusOK {
+		t.Errorf("first token request status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serveLimited(withToken, limiter.Middleware()); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second token request status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimiter_HeadersTellTightestLimit(t *testing.T) {
+	store := &fakeRateStore{}
+	ip := NewIPRateLimiter(100).WithStore(store)
+	defer ip.Stop()
+	user := NewUserRateLimiter(5).WithStore(store)
+	defer user.Stop()
+	signedIn := func(c *gin.Context) {
+		c.Set("user_id", "42")
+		c.Next()
+	}
+
+	w := serveLimited(signedIn, user.Middleware(), ip.Middleware())
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "4" {
+		t.Errorf("X-RateLimit-Remaining = %q, want %q", got, "4")
+	}
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "5" {
+		t.Errorf("X-RateLimit-Limit = %q, want %q", got, "5")
+	}
+}
+
+func TestRateLimiter_TokenCustomQuota(t *testing.T) {
+	limiter := NewTokenRateLimiter(1).WithStore(&fakeRateStore{})
+	defer limiter.Stop()
+	withQuota := func(c *gin.Context) {
+		c.Set("api_token_id", uuid.MustParse("8d7f1c3e-4c2b-4a8e-9f55-2f1b7c9e0a11"))
+		c.Set("api_token_rate_limit", 2)
+		c.Next()
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := serveLimited(withQuota, limiter.Middleware()); w.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+	w := serveLimited(withQuota, limiter.Middleware())
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Errorf("X-RateLimit-Limit = %q, want the token's quota %q", got, "2")
+	}
+}
+
+func TestRateLimiter_StructuredError(t *testing.T) {
+	limiter := NewIPRateLimiter(1)
+	defer limiter.Stop()
+
+	serveLimited(limiter.Middleware())
+	w := serveLimited(limiter.Middleware())
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	var body RateLimitError
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if body.Code != RateLimitExceededCode || body.Limiter != "ip" || body.Limit != 1 || body.WindowSeconds != 60 {
+		t.Errorf("body = %+v, want the ip limit of 1 per 60 seconds", body)
+	}
+	if body.RetryAfter < 1 || w.Header().Get("Retry-After") != strconv.Itoa(body.RetryAfter) {
+		t.Errorf("retry_after = %d, Retry-After = %q, want the same positive seconds", body.RetryAfter, w.Header().Get("Retry-After"))
+	}
+	if body.ResetAt.Before(time.Now().Add(-time.Second)) {
+		t.Errorf("reset_at = %v, want it in the future", body.ResetAt)
+	}
+}
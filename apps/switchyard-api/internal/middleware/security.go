@@ -77,6 +77,9 @@ func NewSecurityMiddleware(config *SecurityConfig) *SecurityMiddleware {
 }
 
 // Rate limiting middleware with bounded cache
+//
+// Deprecated: limits counted here reset per replica. Use a RateLimiter with
+// a RateStore, which the API routes do, to share limits across replicas.
 func (s *SecurityMiddleware) RateLimitMiddleware() gin.HandlerFunc {
 	const maxLimiters = 100000 // Maximum number of IP-based rate limiters to prevent memory exhaustion
 
@@ -1,6 +1,7 @@
 package monitoring
 
 import (
+	"database/sql"
 	"net/http"
 	"strconv"
 	"sync"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -97,7 +99,7 @@ var (
 			Name: "enclii_builds_total",
 			Help: "Total number of builds",
 		},
-		[]string{"status", "build_type"},
+		[]string{"project", "status", "build_type"}, // build_type: roundhouse|inline
 	)
 
 	buildDuration = prometheus.NewHistogramVec(
@@ -106,7 +108,7 @@ var (
 			Help:    "Build duration in seconds",
 			Buckets: []float64{10, 30, 60, 120, 300, 600, 1200, 1800}, // 10s to 30m
 		},
-		[]string{"build_type"},
+		[]string{"project", "status", "build_type"},
 	)
 
 	// Deployment metrics
@@ -181,8 +183,13 @@ var (
 // MetricsCollector handles metrics collection and registration
 type MetricsCollector struct {
 	registry *prometheus.Registry
+	gatherer prometheus.Gatherer // registry plus the promauto metrics
 }
 
+// gatherer is what the metrics history samples; NewMetricsCollector widens
+// it to the collector's registry
+var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+
 func NewMetricsCollector() *MetricsCollector {
 	registry := prometheus.NewRegistry()
 
@@ -213,14 +220,17 @@ func NewMetricsCollector() *MetricsCollector {
 		registry.MustRegister(metric)
 	}
 
-	// Add Go runtime metrics
-	registry.MustRegister(prometheus.NewGoCollector())
-	registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
-
+	// Metrics declared with promauto, and the Go runtime and process
+	// metrics, live in the default registry
 	collector := &MetricsCollector{
 		registry: registry,
+		gatherer: prometheus.Gatherers{registry, prometheus.DefaultGatherer},
 	}
 
+	historyBuffer.mu.Lock()
+	gatherer = collector.gatherer
+	historyBuffer.mu.Unlock()
+
 	// Start background metrics collection
 	go collector.collectSystemMetrics()
 
@@ -228,11 +238,17 @@ func NewMetricsCollector() *MetricsCollector {
 }
 
 func (mc *MetricsCollector) Handler() http.Handler {
-	return promhttp.HandlerFor(mc.registry, promhttp.HandlerOpts{
+	return promhttp.HandlerFor(mc.gatherer, promhttp.HandlerOpts{
 		EnableOpenMetrics: true,
 	})
 }
 
+// RegisterDB exports the connection pool statistics of database as
+// go_sql_* metrics labelled with name
+func (mc *MetricsCollector) RegisterDB(database *sql.DB, name string) {
+	mc.registry.MustRegister(collectors.NewDBStatsCollector(database, name))
+}
+
 // HTTP Middleware
 func (mc *MetricsCollector) HTTPMetricsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -243,8 +259,14 @@ func (mc *MetricsCollector) HTTPMetricsMiddleware() gin.HandlerFunc {
 		duration := time.Since(start)
 		status := strconv.Itoa(c.Writer.Status())
 
-		httpRequestsTotal.WithLabelValues(c.Request.Method, c.FullPath(), status).Inc()
-		httpRequestDuration.WithLabelValues(c.Request.Method, c.FullPath()).Observe(duration.Seconds())
+		// Label by route template so IDs don't multiply the series
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route).Observe(duration.Seconds())
 	}
 }
 
@@ -279,10 +301,11 @@ func RecordCacheOperation(operation, cacheName string, duration time.Duration) {
 	cacheOperationDuration.WithLabelValues(operation, cacheName).Observe(duration.Seconds())
 }
 
-func RecordBuild(status, buildType string, duration time.Duration) {
-	buildsTotal.WithLabelValues(status, buildType).Inc()
+// RecordBuild records a finished build of a project's service
+func RecordBuild(project, status, buildType string, duration time.Duration) {
+	buildsTotal.WithLabelValues(project, status, buildType).Inc()
 	if duration > 0 {
-		buildDuration.WithLabelValues(buildType).Observe(duration.Seconds())
+		buildDuration.WithLabelValues(project, status, buildType).Observe(duration.Seconds())
 	}
 }
 
@@ -428,7 +451,7 @@ type KubernetesMetrics struct {
 
 func (mc *MetricsCollector) GetSnapshot() (*MetricsSnapshot, error) {
 	// Gather metrics from the registry
-	metricFamilies, err := mc.registry.Gather()
+	metricFamilies, err := mc.gatherer.Gather()
 	if err != nil {
 		return nil, err
 	}
@@ -598,7 +621,7 @@ func collectHistoryDataPoint() {
 	defer historyBuffer.mu.Unlock()
 
 	// Collect current values from Prometheus metrics
-	metricFamilies, err := prometheus.DefaultGatherer.Gather()
+	metricFamilies, err := gatherer.Gather()
 	if err != nil {
 		return
 	}
@@ -0,0 +1,67 @@
+package monitoring
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Preview environment cleanup Prometheus metrics
+var (
+	// Counter: Preview teardowns
+	previewCleanupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "enclii_preview_cleanups_total",
+			Help: "Total number of closed preview environments torn down",
+		},
+		[]string{"status"}, // status: success|partial|failure
+	)
+
+	// Counter: Resources removed by preview teardowns
+	previewReclaimedResourcesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "enclii_preview_reclaimed_resources_total",
+			Help: "Total number of resources removed by preview teardowns",
+		},
+		[]string{"resource"}, // resource: namespace|ingress|database|release|image
+	)
+
+	// Counter: Registry storage reclaimed
+	previewReclaimedImageBytesTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "enclii_preview_reclaimed_image_bytes_total",
+			Help: "Total size of preview container images deleted from the registry",
+		},
+	)
+
+	// Gauge: Closed previews past their TTL
+	previewCleanupPending = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "enclii_preview_cleanup_pending",
+			Help: "Number of closed preview environments past their TTL awaiting teardown",
+		},
+	)
+)
+
+// RecordPreviewCleanup records the outcome of a preview teardown
+func RecordPreviewCleanup(status string) {
+	previewCleanupsTotal.WithLabelValues(status).Inc()
+}
+
+// RecordPreviewReclaimed records resources removed by a preview teardown
+func RecordPreviewReclaimed(resource string, count int) {
+	if count > 0 {
+		previewReclaimedResourcesTotal.WithLabelValues(resource).Add(float64(count))
+	}
+}
+
+// RecordPreviewReclaimedImageBytes records registry storage freed by a preview teardown
+func RecordPreviewReclaimedImageBytes(bytes int64) {
+	if bytes > 0 {
+		previewReclaimedImageBytesTotal.Add(float64(bytes))
+	}
+}
+
+// SetPreviewCleanupPending sets the number of previews awaiting teardown
+func SetPreviewCleanupPending(count int) {
+	previewCleanupPending.Set(float64(count))
+}
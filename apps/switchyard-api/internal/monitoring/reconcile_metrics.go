@@ -0,0 +1,96 @@
+package monitoring
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reconciler Prometheus metrics
+var (
+	// Histogram: Reconciliation duration
+	reconcileDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "enclii_reconcile_duration_seconds",
+			Help:    "Deployment reconciliation duration in seconds",
+			Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		},
+		[]string{"project", "environment", "class", "outcome"}, // outcome: success|retry|failure
+	)
+
+	// Gauge: Claimed work waiting for a worker on this replica
+	reconcileQueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "enclii_reconcile_queue_depth",
+			Help: "Number of reconciliations waiting for a worker on this replica",
+		},
+		[]string{"class"}, // class: production|standard|preview
+	)
+
+	// Counter: Work dropped by a full in-memory queue
+	reconcileDroppedWorkTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "enclii_reconcile_dropped_work_total",
+			Help: "Total number of reconciliations dropped because the work queue was full",
+		},
+		[]string{"class"},
+	)
+
+	// Gauge: Queued reconciliations by state
+	reconcileBacklog = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "enclii_reconcile_backlog",
+			Help: "Number of queued deployment reconciliations",
+		},
+		[]string{"state"}, // state: ready|scheduled|claimed
+	)
+
+	// Gauge: Ready reconciliations by work class
+	reconcileBacklogReady = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "enclii_reconcile_backlog_ready",
+			Help: "Number of runnable, unclaimed deployment reconciliations by work class",
+		},
+		[]string{"class"}, // class: production|standard|preview
+	)
+
+	// Gauge: Age of the oldest ready reconciliation
+	reconcileBacklogOldestReady = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "enclii_reconcile_backlog_oldest_ready_seconds",
+			Help: "How long the oldest runnable deployment reconciliation has waited to be claimed",
+		},
+	)
+)
+
+// SetReconcileBacklog records the current reconcile queue backlog
+func SetReconcileBacklog(ready, scheduled, claimed int, readyByClass map[string]int, oldestReady time.Duration) {
+	reconcileBacklog.WithLabelValues("ready").Set(float64(ready))
+	reconcileBacklog.WithLabelValues("scheduled").Set(float64(scheduled))
+	reconcileBacklog.WithLabelValues("claimed").Set(float64(claimed))
+
+	reconcileBacklogReady.Reset()
+	for class, count := range readyByClass {
+		reconcileBacklogReady.WithLabelValues(class).Set(float64(count))
+	}
+	reconcileBacklogOldestReady.Set(oldestReady.Seconds())
+}
+
+// RecordReconcile records a finished reconciliation
+func RecordReconcile(project, environment, class, outcome string, duration time.Duration) {
+	reconcileDuration.WithLabelValues(project, environment, class, outcome).Observe(duration.Seconds())
+}
+
+// SetReconcileQueueDepth records the work waiting for a worker by class
+func SetReconcileQueueDepth(byClass map[string]int) {
+	reconcileQueueDepth.Reset()
+	for class, count := range byClass {
+		reconcileQueueDepth.WithLabelValues(class).Set(float64(count))
+	}
+}
+
+// RecordReconcileDropped records reconciliation work dropped by a full queue
+func RecordReconcileDropped(class string) {
+	reconcileDroppedWorkTotal.WithLabelValues(class).Inc()
+}
@@ -0,0 +1,27 @@
+package monitoring
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Log redaction Prometheus metrics
+var (
+	// Counter: Secrets masked in logs
+	logRedactionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "enclii_log_redactions_total",
+			Help: "Total number of secrets masked in build and runtime logs",
+		},
+		[]string{"source", "kind"}, // source: build|runtime; kind: secret|aws_access_key|aws_secret_key|bearer_token|github_token
+	)
+)
+
+// RecordLogRedactions records the secrets masked in logs, by kind
+func RecordLogRedactions(source string, counts map[string]int) {
+	for kind, n := range counts {
+		if n > 0 {
+			logRedactionsTotal.WithLabelValues(source, kind).Add(float64(n))
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package monitoring
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Notification webhook Prometheus metrics
+var (
+	// Counter: Webhook deliveries
+	webhookDeliveriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "enclii_webhook_deliveries_total",
+			Help: "Total number of notification webhook deliveries",
+		},
+		[]string{"project", "webhook_type", "event_type", "status"}, // status: success|failure
+	)
+
+	// Histogram: Webhook delivery duration
+	webhookDeliveryDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "enclii_webhook_delivery_duration_seconds",
+			Help:    "Notification webhook delivery duration in seconds",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"webhook_type"},
+	)
+)
+
+// RecordWebhookDelivery records the outcome of a notification webhook delivery
+func RecordWebhookDelivery(project, webhookType, eventType string, success bool, duration time.Duration) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	webhookDeliveriesTotal.WithLabelValues(project, webhookType, eventType, status).Inc()
+	webhookDeliveryDuration.WithLabelValues(webhookType).Observe(duration.Seconds())
+}
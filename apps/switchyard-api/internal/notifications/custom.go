@@ -37,14 +37,19 @@ func NewCustomSender(logger *logrus.Logger, customHeaders map[string]string, sig
 
 // CustomWebhookPayload represents the payload sent to custom webhooks
 type CustomWebhookPayload struct {
-	ID         string                       `json:"id"`
-	Type       types.WebhookEventType       `json:"type"`
-	Timestamp  time.Time                    `json:"timestamp"`
-	Project    types.WebhookProjectInfo     `json:"project"`
-	Deployment *types.WebhookDeploymentInfo `json:"deployment,omitempty"`
-	Build      *types.WebhookBuildInfo      `json:"build,omitempty"`
-	Service    *types.WebhookServiceInfo    `json:"service,omitempty"`
-	Database   *types.WebhookDatabaseInfo   `json:"database,omitempty"`
+	ID              string                            `json:"id"`
+	Type            types.WebhookEventType            `json:"type"`
+	Timestamp       time.Time                         `json:"timestamp"`
+	Project         types.WebhookProjectInfo          `json:"project"`
+	Deployment      *types.WebhookDeploymentInfo      `json:"deployment,omitempty"`
+	Build           *types.WebhookBuildInfo           `json:"build,omitempty"`
+	Service         *types.WebhookServiceInfo         `json:"service,omitempty"`
+	Database        *types.WebhookDatabaseInfo        `json:"database,omitempty"`
+	DeploymentGroup *types.WebhookDeploymentGroupInfo `json:"deployment_group,omitempty"`
+	Budget          *types.WebhookBudgetInfo          `json:"budget,omitempty"`
+	Alert           *types.WebhookAlertInfo           `json:"alert,omitempty"`
+	Uptime          *types.WebhookUptimeInfo          `json:"uptime,omitempty"`
+	Secret          *types.WebhookSecretInfo          `json:"secret,omitempty"`
 }
 
 // Send sends an event to a custom webhook URL
@@ -98,14 +103,19 @@ func (c *CustomSender) Send(ctx context.Context, webhookURL string, event *types
 // buildPayload creates the webhook payload from an event
 func (c *CustomSender) buildPayload(event *types.WebhookEvent) *CustomWebhookPayload {
 	return &CustomWebhookPayload{
-		ID:         event.ID.String(),
-		Type:       event.Type,
-		Timestamp:  event.Timestamp,
-		Project:    event.Project,
-		Deployment: event.Deployment,
-		Build:      event.Build,
-		Service:    event.Service,
-		Database:   event.Database,
+		ID:              event.ID.String(),
+		Type:            event.Type,
+		Timestamp:       event.Timestamp,
+		Project:         event.Project,
+		Deployment:      event.Deployment,
+		Build:           event.Build,
+		Service:         event.Service,
+		Database:        event.Database,
+		DeploymentGroup: event.DeploymentGroup,
+		Budget:          event.Budget,
+		Alert:           event.Alert,
+		Uptime:          event.Uptime,
+		Secret:          event.Secret,
 	}
 }
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -137,6 +138,21 @@ func (d *DiscordSender) buildMessage(event *types.WebhookEvent) *DiscordMessage
 	if event.Database != nil {
 		embed.Fields = append(embed.Fields, d.buildDatabaseFields(event.Database)...)
 	}
+	if event.DeploymentGroup != nil {
+		embed.Fields = append(embed.Fields, d.buildDeploymentGroupFields(event.DeploymentGroup)...)
+	}
+	if event.Budget != nil {
+		embed.Fields = append(embed.Fields, d.buildBudgetFields(event.Budget)...)
+	}
+	if event.Alert != nil {
+		embed.Fields = append(embed.Fields, d.buildAlertFields(event.Alert)...)
+	}
+	if event.Uptime != nil {
+		embed.Fields = append(embed.Fields, d.buildUptimeFields(event.Uptime)...)
+	}
+	if event.Secret != nil {
+		embed.Fields = append(embed.Fields, d.buildSecretFields(event.Secret)...)
+	}
 
 	return &DiscordMessage{
 		Username:  "Enclii",
@@ -156,12 +172,20 @@ func (d *DiscordSender) getEventMeta(eventType types.WebhookEventType) (emoji st
 		return "❌", 0xdc3545, "Deployment Failed"
 	case types.WebhookEventDeploymentCancelled:
 		return "⏹️", 0x6c757d, "Deployment Cancelled"
+	case types.WebhookEventDeploymentStalled:
+		return "⏳", 0xffc107, "Deployment Stalled"
+	case types.WebhookEventDeploymentRolledBack:
+		return "⏪", 0xdc3545, "Deployment Rolled Back"
+	case types.WebhookEventDeploymentGroupReminder:
+		return "⏰", 0x3AA3E3, "Scheduled Deployment Upcoming"
 	case types.WebhookEventBuildStarted:
 		return "🔨", 0x3AA3E3, "Build Started"
 	case types.WebhookEventBuildSucceeded:
 		return "✅", 0x36a64f, "Build Succeeded"
 	case types.WebhookEventBuildFailed:
 		return "❌", 0xdc3545, "Build Failed"
+	case types.WebhookEventBuildStalled:
+		return "⏳", 0xffc107, "Build Stalled"
 	case types.WebhookEventServiceCreated:
 		return "➕", 0x36a64f, "Service Created"
 	case types.WebhookEventServiceDeleted:
@@ -176,6 +200,20 @@ func (d *DiscordSender) getEventMeta(eventType types.WebhookEventType) (emoji st
 		return "🗄️", 0x36a64f, "Database Ready"
 	case types.WebhookEventDatabaseFailed:
 		return "❌", 0xdc3545, "Database Failed"
+	case types.WebhookEventBudgetThreshold:
+		return "💰", 0xffc107, "Budget Threshold Reached"
+	case types.WebhookEventBudgetCapExceeded:
+		return "🛑", 0xdc3545, "Budget Hard Cap Exceeded"
+	case types.WebhookEventAlertFiring:
+		return "🚨", 0xdc3545, "Alert Firing"
+	case types.WebhookEventAlertResolved:
+		return "✅", 0x36a64f, "Alert Resolved"
+	case types.WebhookEventUptimeDown:
+		return "🔻", 0xdc3545, "Endpoint Down"
+	case types.WebhookEventUptimeRecovered:
+		return "✅", 0x36a64f, "Endpoint Recovered"
+	case types.WebhookEventSecretRotationDue:
+		return "🔑", 0xffc107, "Secret Rotation Due"
 	default:
 		return "📢", 0x6c757d, string(eventType)
 	}
@@ -248,3 +286,78 @@ func (d *DiscordSender) buildDatabaseFields(db *types.WebhookDatabaseInfo) []Dis
 
 	return fields
 }
+
+func (d *DiscordSender) buildDeploymentGroupFields(g *types.WebhookDeploymentGroupInfo) []DiscordEmbedField {
+	fields := []DiscordEmbedField{
+		{Name: "Deployment Group", Value: g.Name, Inline: true},
+		{Name: "Environment", Value: g.Environment, Inline: true},
+		{Name: "Strategy", Value: g.Strategy, Inline: true},
+	}
+
+	if g.ScheduledAt != nil {
+		fields = append(fields, DiscordEmbedField{Name: "Scheduled For", Value: fmt.Sprintf("<t:%d:F>", g.ScheduledAt.Unix()), Inline: true})
+	}
+	if g.ScheduledBy != "" {
+		fields = append(fields, DiscordEmbedField{Name: "Scheduled By", Value: g.ScheduledBy, Inline: true})
+	}
+	if len(g.CommitSHA) >= 7 {
+		fields = append(fields, DiscordEmbedField{Name: "Commit", Value: fmt.Sprintf("`%s`", g.CommitSHA[:7]), Inline: true})
+	}
+
+	return fields
+}
+
+func (d *DiscordSender) buildBudgetFields(b *types.WebhookBudgetInfo) []DiscordEmbedField {
+	fields := []DiscordEmbedField{
+		{Name: "Budget", Value: fmt.Sprintf("%s (%s)", b.Name, b.Scope), Inline: true},
+		{Name: "Used", Value: fmt.Sprintf("%d%%", b.Threshold), Inline: true},
+		{Name: "Spend", Value: fmt.Sprintf("%.2f %s", b.Spend, b.Currency), Inline: true},
+		{Name: "Limit", Value: fmt.Sprintf("%.2f %s", b.Limit, b.Currency), Inline: true},
+	}
+
+	if len(b.ScaledToZero) > 0 {
+		fields = append(fields, DiscordEmbedField{Name: "Stopped", Value: strings.Join(b.ScaledToZero, ", "), Inline: false})
+	}
+
+	return fields
+}
+
+func (d *DiscordSender) buildAlertFields(a *types.WebhookAlertInfo) []DiscordEmbedField {
+	fields := []DiscordEmbedField{
+		{Name: "Alert", Value: a.RuleName, Inline: true},
+		{Name: "Service", Value: a.ServiceName, Inline: true},
+		{Name: "Environment", Value: a.Environment, Inline: true},
+		{Name: "Details", Value: a.Summary, Inline: false},
+	}
+
+	if a.ResolvedAt != nil {
+		fields = append(fields, DiscordEmbedField{Name: "Lasted", Value: a.ResolvedAt.Sub(a.StartedAt).Round(time.Minute).String(), Inline: true})
+	}
+
+	return fields
+}
+
+func (d *DiscordSender) buildUptimeFields(u *types.WebhookUptimeInfo) []DiscordEmbedField {
+	fields := []DiscordEmbedField{
+		{Name: "Service", Value: u.ServiceName, Inline: true},
+		{Name: "Environment", Value: u.Environment, Inline: true},
+		{Name: "URL", Value: u.URL, Inline: false},
+	}
+
+	if u.DownSeconds > 0 {
+		fields = append(fields, DiscordEmbedField{Name: "Downtime", Value: (time.Duration(u.DownSeconds) * time.Second).String(), Inline: true})
+	} else if u.Error != "" {
+		fields = append(fields, DiscordEmbedField{Name: "Error", Value: u.Error, Inline: false})
+	}
+
+	return fields
+}
+
+func (d *DiscordSender) buildSecretFields(sec *types.WebhookSecretInfo) []DiscordEmbedField {
+	return []DiscordEmbedField{
+		{Name: "Service", Value: sec.ServiceName, Inline: true},
+		{Name: "Environment", Value: sec.Environment, Inline: true},
+		{Name: "Secret", Value: "`" + sec.Key + "`", Inline: true},
+		{Name: "Age", Value: fmt.Sprintf("%d days (max %d)", sec.AgeDays, sec.MaxAgeDays), Inline: true},
+	}
+}
@@ -1,51 +1,93 @@
 package notifications
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/mail"
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 )
 
 // EmailService handles transactional email delivery
 type EmailService struct {
 	logger     *logrus.Logger
-	apiKey     string // Resend API key
-	fromEmail  string // Default from email
-	fromName   string // Default from name
-	baseURL    string // App base URL for links
-	enabled    bool   // Whether email is configured
-	httpClient *http.Client
+	provider   EmailProvider               // Nil when email is not configured; emails are logged only
+	deliveries *db.EmailDeliveryRepository // Delivery status tracking (nil = untracked)
+	from       mail.Address                // Default sender
+	baseURL    string                      // App base URL for links
+	queue      chan *emailJob
+	retryDelay time.Duration // Delay before the first retry; doubles after each attempt
 }
 
 // EmailConfig holds email service configuration
 type EmailConfig struct {
-	APIKey    string // RESEND_API_KEY
-	FromEmail string // EMAIL_FROM_ADDRESS (default: noreply@enclii.dev)
-	FromName  string // EMAIL_FROM_NAME (default: Enclii)
-	BaseURL   string // APP_BASE_URL (e.g., https://app.enclii.dev)
+	Provider       string     // EMAIL_PROVIDER - resend (default), smtp, ses, or sendgrid
+	APIKey         string     // RESEND_API_KEY
+	SendGridAPIKey string     // SENDGRID_API_KEY
+	SMTP           SMTPConfig // SMTP_* settings
+	SES            SESConfig  // SES_* settings
+	FromEmail      string     // EMAIL_FROM_ADDRESS (default: noreply@enclii.dev)
+	FromName       string     // EMAIL_FROM_NAME (default: Enclii)
+	BaseURL        string     // APP_BASE_URL (e.g., https://app.enclii.dev)
 }
 
-// NewEmailService creates a new email service
-func NewEmailService(cfg EmailConfig, logger *logrus.Logger) *EmailService {
-	enabled := cfg.APIKey != ""
+// NewEmailService creates a new email service. Emails are only sent once
+// Start runs.
+func NewEmailService(ctx context.Context, cfg EmailConfig, deliveries *db.EmailDeliveryRepository, logger *logrus.Logger) (*EmailService, error) {
+	provider, err := newEmailProvider(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	if !enabled {
-		logger.Warn("Email service not configured - emails will be logged only. Set RESEND_API_KEY to enable.")
+	if provider == nil {
+		logger.Warn("Email service not configured - emails will be logged only. Set RESEND_API_KEY or EMAIL_PROVIDER to enable.")
 	}
 
 	return &EmailService{
 		logger:     logger,
-		apiKey:     cfg.APIKey,
-		fromEmail:  withDefault(cfg.FromEmail, "noreply@enclii.dev"),
-		fromName:   withDefault(cfg.FromName, "Enclii"),
+		provider:   provider,
+		deliveries: deliveries,
+		from: mail.Address{
+			Name:    withDefault(cfg.FromName, "Enclii"),
+			Address: withDefault(cfg.FromEmail, "noreply@enclii.dev"),
+		},
 		baseURL:    withDefault(cfg.BaseURL, "https://app.enclii.dev"),
-		enabled:    enabled,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		queue:      make(chan *emailJob, emailQueueSize),
+		retryDelay: emailRetryBaseDelay,
+	}, nil
+}
+
+// newEmailProvider creates the configured provider, or nil when Resend is
+// selected without an API key
+func newEmailProvider(ctx context.Context, cfg EmailConfig) (EmailProvider, error) {
+	switch cfg.Provider {
+	case "", EmailProviderResend:
+		if cfg.APIKey == "" {
+			return nil, nil
+		}
+		return NewResendProvider(cfg.APIKey), nil
+	case EmailProviderSMTP:
+		provider, err := NewSMTPProvider(cfg.SMTP)
+		if err != nil {
+			return nil, err
+		}
+		return provider, nil
+	case EmailProviderSES:
+		provider, err := NewSESProvider(ctx, cfg.SES)
+		if err != nil {
+			return nil, err
+		}
+		return provider, nil
+	case EmailProviderSendGrid:
+		if cfg.SendGridAPIKey == "" {
+			return nil, fmt.Errorf("SENDGRID_API_KEY is required for the sendgrid email provider")
+		}
+		return NewSendGridProvider(cfg.SendGridAPIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown email provider %q (must be resend, smtp, ses, or sendgrid)", cfg.Provider)
 	}
 }
 
@@ -70,133 +112,297 @@ type TeamInvitationData struct {
 
 // SendTeamInvitation sends a team invitation email
 func (s *EmailService) SendTeamInvitation(ctx context.Context, data TeamInvitationData) error {
-	inviteURL := fmt.Sprintf("%s/invitations/accept?token=%s", s.baseURL, data.InvitationToken)
-
-	subject := fmt.Sprintf("You've been invited to join %s on Enclii", data.TeamName)
-
-	htmlBody := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="utf-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .button { display: inline-block; background: #0066cc; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
-        .footer { margin-top: 40px; font-size: 12px; color: #666; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <h1>You're invited to join %s</h1>
-        <p>Hi,</p>
-        <p><strong>%s</strong> (%s) has invited you to join the <strong>%s</strong> team on Enclii as a <strong>%s</strong>.</p>
-        <p>Click the button below to accept this invitation:</p>
-        <a href="%s" class="button">Accept Invitation</a>
-        <p>Or copy and paste this link into your browser:</p>
-        <p style="word-break: break-all;">%s</p>
-        <p>This invitation expires on %s.</p>
-        <div class="footer">
-            <p>If you weren't expecting this invitation, you can safely ignore this email.</p>
-            <p>&copy; Enclii - Self-hosted DevOps Platform</p>
-        </div>
-    </div>
-</body>
-</html>`,
-		data.TeamName,
-		data.InviterName, data.InviterEmail, data.TeamName, data.Role,
-		inviteURL, inviteURL,
-		data.ExpiresAt.Format("January 2, 2006 at 3:04 PM UTC"),
-	)
-
-	textBody := fmt.Sprintf(`You're invited to join %s on Enclii
-
-Hi,
-
-%s (%s) has invited you to join the %s team on Enclii as a %s.
-
-Accept your invitation by visiting:
-%s
-
-This invitation expires on %s.
-
-If you weren't expecting this invitation, you can safely ignore this email.
-`,
-		data.TeamName,
-		data.InviterName, data.InviterEmail, data.TeamName, data.Role,
-		inviteURL,
-		data.ExpiresAt.Format("January 2, 2006 at 3:04 PM UTC"),
-	)
-
-	return s.send(ctx, data.InviteeEmail, subject, htmlBody, textBody)
-}
-
-// resendEmail represents the Resend API email payload
-type resendEmail struct {
-	From    string   `json:"from"`
-	To      []string `json:"to"`
-	Subject string   `json:"subject"`
-	HTML    string   `json:"html,omitempty"`
-	Text    string   `json:"text,omitempty"`
-}
-
-// send sends an email via the Resend API
-func (s *EmailService) send(ctx context.Context, to, subject, htmlBody, textBody string) error {
-	logger := s.logger.WithFields(logrus.Fields{
-		"to":      to,
-		"subject": subject,
-	})
+	return s.deliver(ctx, teamInvitationEmail, data.InviteeEmail, struct {
+		TeamInvitationData
+		URL string
+	}{data, fmt.Sprintf("%s/invitations/accept?token=%s", s.baseURL, data.InvitationToken)})
+}
 
-	// If email not configured, just log
-	if !s.enabled {
-		logger.Info("Email would be sent (email service not configured)")
-		logger.WithField("text_body", textBody).Debug("Email content")
-		return nil
-	}
+// EmailVerificationData contains data for email verification emails
+type EmailVerificationData struct {
+	Email     string
+	Name      string
+	Token     string
+	ExpiresAt time.Time
+}
 
-	email := resendEmail{
-		From:    fmt.Sprintf("%s <%s>", s.fromName, s.fromEmail),
-		To:      []string{to},
-		Subject: subject,
-		HTML:    htmlBody,
-		Text:    textBody,
-	}
+// SendEmailVerification sends the link that verifies the email address of a
+// new account
+func (s *EmailService) SendEmailVerification(ctx context.Context, data EmailVerificationData) error {
+	return s.deliver(ctx, emailVerificationEmail, data.Email, struct {
+		EmailVerificationData
+		URL string
+	}{data, fmt.Sprintf("%s/verify-email?token=%s", s.baseURL, data.Token)})
+}
 
-	body, err := json.Marshal(email)
-	if err != nil {
-		return fmt.Errorf("failed to marshal email: %w", err)
+// SignupInviteData contains data for signup invite emails
+type SignupInviteData struct {
+	Email       string
+	InviterName string
+	Code        string
+	ExpiresAt   *time.Time
+}
+
+// SendSignupInvite sends an invite code for invite-only signup
+func (s *EmailService) SendSignupInvite(ctx context.Context, data SignupInviteData) error {
+	return s.deliver(ctx, signupInviteEmail, data.Email, struct {
+		SignupInviteData
+		URL string
+	}{data, fmt.Sprintf("%s/signup?invite=%s", s.baseURL, data.Code)})
+}
+
+// DeploymentFailedData contains data for deployment failure alerts
+type DeploymentFailedData struct {
+	Email       string
+	ProjectName string
+	ProjectSlug string
+	ServiceName string
+	Environment string
+	CommitSHA   string
+	Error       string
+}
+
+// SendDeploymentFailed alerts that a deployment failed
+func (s *EmailService) SendDeploymentFailed(ctx context.Context, data DeploymentFailedData) error {
+	return s.deliver(ctx, deploymentFailedEmail, data.Email, struct {
+		DeploymentFailedData
+		URL string
+	}{data, fmt.Sprintf("%s/projects/%s", s.baseURL, data.ProjectSlug)})
+}
+
+// BudgetExceededData contains data for budget alerts
+type BudgetExceededData struct {
+	Email       string
+	ProjectName string
+	Period      string // e.g. "October 2026"
+	Budget      float64
+	Spend       float64
+	Currency    string
+}
+
+// SendBudgetExceeded alerts that a project spent more than its budget
+func (s *EmailService) SendBudgetExceeded(ctx context.Context, data BudgetExceededData) error {
+	return s.deliver(ctx, budgetExceededEmail, data.Email, struct {
+		BudgetExceededData
+		URL string
+	}{data, s.baseURL + "/usage"})
+}
+
+// BudgetWarningData contains data for alerts that spend crossed a share
+// of the budget
+type BudgetWarningData struct {
+	Email       string
+	ProjectName string // Project or team the budget is for
+	Period      string
+	Threshold   int // Percent of the budget, e.g. 80
+	Budget      float64
+	Spend       float64
+	Currency    string
+}
+
+// SendBudgetWarning alerts that spend crossed a share of the budget
+func (s *EmailService) SendBudgetWarning(ctx context.Context, data BudgetWarningData) error {
+	return s.deliver(ctx, budgetWarningEmail, data.Email, struct {
+		BudgetWarningData
+		URL string
+	}{data, s.baseURL + "/usage"})
+}
+
+// BudgetCapReachedData contains data for hard cap alerts
+type BudgetCapReachedData struct {
+	Email           string
+	ProjectName     string // Project or team the budget is for
+	Period          string
+	HardLimit       float64
+	Spend           float64
+	Currency        string
+	StoppedServices []string
+}
+
+// SendBudgetCapReached alerts that spend reached the hard cap and
+// non-production services were stopped
+func (s *EmailService) SendBudgetCapReached(ctx context.Context, data BudgetCapReachedData) error {
+	return s.deliver(ctx, budgetCapReachedEmail, data.Email, struct {
+		BudgetCapReachedData
+		URL string
+	}{data, s.baseURL + "/usage"})
+}
+
+// PaymentFailedData contains data for dunning emails
+type PaymentFailedData struct {
+	Email          string
+	AccountName    string // Team or project that pays
+	Amount         float64
+	Currency       string
+	FailedPayments int        // In a row, including this one
+	SuspendAfter   int        // Failed payments that suspend services
+	NextAttempt    *time.Time // When Stripe retries; nil when it will not
+	InvoiceURL     string     // Stripe page to pay the invoice; optional
+}
+
+// SendPaymentFailed alerts that a payment failed and services will be
+// suspended if payments keep failing
+func (s *EmailService) SendPaymentFailed(ctx context.Context, data PaymentFailedData) error {
+	return s.deliver(ctx, paymentFailedEmail, data.Email, struct {
+		PaymentFailedData
+		URL string
+	}{data, s.baseURL + "/billing"})
+}
+
+// ServicesSuspendedData contains data for suspension emails
+type ServicesSuspendedData struct {
+	Email           string
+	AccountName     string
+	Amount          float64
+	Currency        string
+	InvoiceURL      string
+	StoppedServices []string
+}
+
+// SendServicesSuspended alerts that payments kept failing and services were
+// stopped
+func (s *EmailService) SendServicesSuspended(ctx context.Context, data ServicesSuspendedData) error {
+	return s.deliver(ctx, servicesSuspendedEmail, data.Email, struct {
+		ServicesSuspendedData
+		URL string
+	}{data, s.baseURL + "/billing"})
+}
+
+// PaymentRecoveredData contains data for emails confirming an overdue
+// payment
+type PaymentRecoveredData struct {
+	Email           string
+	AccountName     string
+	Amount          float64
+	Currency        string
+	ResumedServices []string // Set when services were suspended
+}
+
+// SendPaymentRecovered confirms that an overdue payment succeeded
+func (s *EmailService) SendPaymentRecovered(ctx context.Context, data PaymentRecoveredData) error {
+	return s.deliver(ctx, paymentRecoveredEmail, data.Email, struct {
+		PaymentRecoveredData
+		URL string
+	}{data, s.baseURL + "/billing"})
+}
+
+// CardExpiringData contains data for card expiry reminders
+type CardExpiringData struct {
+	Email       string
+	AccountName string
+	Brand       string // e.g. "Visa"
+	Last4       string
+	ExpMonth    int
+	ExpYear     int
+}
+
+// SendCardExpiring reminds that the card payments are charged to expires
+// at the end of the month
+func (s *EmailService) SendCardExpiring(ctx context.Context, data CardExpiringData) error {
+	return s.deliver(ctx, cardExpiringEmail, data.Email, struct {
+		CardExpiringData
+		URL string
+	}{data, s.baseURL + "/billing"})
+}
+
+// SubscriptionCanceledData contains data for cancellation emails
+type SubscriptionCanceledData struct {
+	Email       string
+	AccountName string
+	PlanName    string
+}
+
+// SendSubscriptionCanceled confirms that a subscription ended
+func (s *EmailService) SendSubscriptionCanceled(ctx context.Context, data SubscriptionCanceledData) error {
+	return s.deliver(ctx, subscriptionCanceledEmail, data.Email, struct {
+		SubscriptionCanceledData
+		URL string
+	}{data, s.baseURL + "/billing"})
+}
+
+// CertificateExpiringData contains data for certificate expiry alerts
+type CertificateExpiringData struct {
+	Email       string
+	Domain      string
+	ProjectName string // Optional
+	ExpiresAt   time.Time
+}
+
+// SendCertificateExpiring alerts that the TLS certificate of a domain is
+// about to expire
+func (s *EmailService) SendCertificateExpiring(ctx context.Context, data CertificateExpiringData) error {
+	return s.deliver(ctx, certificateExpiringEmail, data.Email, struct {
+		CertificateExpiringData
+		URL string
+	}{data, s.baseURL + "/domains"})
+}
+
+// AlertData contains data for service alert emails
+type AlertData struct {
+	Email       string
+	ProjectName string
+	ProjectSlug string
+	ServiceName string
+	Environment string
+	RuleName    string
+	Summary     string
+	StartedAt   time.Time
+	ResolvedAt  *time.Time // Set once the alert resolved
+}
+
+// SendAlert alerts that a service met an alert rule's condition, or that
+// the condition cleared when ResolvedAt is set
+func (s *EmailService) SendAlert(ctx context.Context, data AlertData) error {
+	tmpl := alertFiringEmail
+	if data.ResolvedAt != nil {
+		tmpl = alertResolvedEmail
 	}
+	return s.deliver(ctx, tmpl, data.Email, struct {
+		AlertData
+		URL string
+	}{data, fmt.Sprintf("%s/projects/%s", s.baseURL, data.ProjectSlug)})
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.resend.com/emails", bytes.NewReader(body))
+// deliver renders an email and queues it, or logs it when email is not
+// configured
+func (s *EmailService) deliver(ctx context.Context, tmpl *emailTemplate, to string, data any) error {
+	subject, html, text, err := tmpl.render(data)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	logger := s.logger.WithFields(logrus.Fields{
+		"to":       to,
+		"subject":  subject,
+		"template": tmpl.name,
+	})
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		logger.WithError(err).Error("Failed to send email")
-		return fmt.Errorf("failed to send email: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		var errResp map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errResp)
-		logger.WithFields(logrus.Fields{
-			"status_code": resp.StatusCode,
-			"response":    errResp,
-		}).Error("Email API returned error")
-		return fmt.Errorf("email API error: status %d", resp.StatusCode)
+	// If email not configured, just log
+	if s.provider == nil {
+		logger.Info("Email would be sent (email service not configured)")
+		logger.WithField("text_body", text).Debug("Email content")
+		return nil
 	}
 
-	logger.Info("Email sent successfully")
-	return nil
+	return s.enqueue(ctx, tmpl.name, &EmailMessage{
+		From:    s.from,
+		To:      to,
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	})
 }
 
 // IsEnabled returns whether email sending is configured
 func (s *EmailService) IsEnabled() bool {
-	return s.enabled
+	return s.provider != nil
+}
+
+// ProviderName returns the configured provider, or "" when email is not
+// configured
+func (s *EmailService) ProviderName() string {
+	if s.provider == nil {
+		return ""
+	}
+	return s.provider.Name()
 }
@@ -0,0 +1,68 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"time"
+)
+
+// Email providers
+const (
+	EmailProviderResend   = "resend"
+	EmailProviderSMTP     = "smtp"
+	EmailProviderSES      = "ses"
+	EmailProviderSendGrid = "sendgrid"
+)
+
+// EmailMessage is a rendered email ready for delivery
+type EmailMessage struct {
+	From    mail.Address
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// EmailProvider delivers emails through a mail service
+type EmailProvider interface {
+	// Name identifies the provider in delivery records
+	Name() string
+	// Send delivers a message and returns the provider's ID for it. Errors
+	// wrapped with permanentEmailError are not retried.
+	Send(ctx context.Context, msg *EmailMessage) (string, error)
+}
+
+// permanentEmailError marks a delivery failure that retrying cannot fix, such
+// as a rejected recipient or invalid credentials
+type permanentEmailError struct {
+	err error
+}
+
+func (e *permanentEmailError) Error() string { return e.err.Error() }
+func (e *permanentEmailError) Unwrap() error { return e.err }
+
+// isPermanentEmailError reports whether a delivery failure should not be retried
+func isPermanentEmailError(err error) bool {
+	var permanent *permanentEmailError
+	return errors.As(err, &permanent)
+}
+
+// httpStatusError classifies the error response of an HTTP mail API. Rate
+// limits and server errors are retried; other client errors are not.
+func httpStatusError(provider string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("%s API returned status %d: %s", provider, resp.StatusCode, string(body))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentEmailError{err: err}
+	}
+	return err
+}
+
+// newEmailHTTPClient returns the HTTP client of API-based providers
+func newEmailHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
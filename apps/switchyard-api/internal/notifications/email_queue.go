@@ -0,0 +1,144 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	emailQueueSize      = 1000
+	emailMaxAttempts    = 5
+	emailRetryBaseDelay = 30 * time.Second // 30s, 1m, 2m, 4m between attempts
+	emailSendTimeout    = 30 * time.Second
+
+	// emailStaleAfter is well past the longest gap between attempts, so
+	// deliveries untouched for this long were lost with their instance
+	emailStaleAfter = time.Hour
+)
+
+// emailJob is a rendered email waiting to be sent. Messages are queued in
+// memory rather than in the database since their bodies carry tokens.
+type emailJob struct {
+	msg      *EmailMessage
+	delivery *types.EmailDelivery
+}
+
+// enqueue records an email as queued and hands it to the sender loop
+func (s *EmailService) enqueue(ctx context.Context, template string, msg *EmailMessage) error {
+	delivery := &types.EmailDelivery{
+		Template:  template,
+		Recipient: msg.To,
+		Subject:   msg.Subject,
+		Provider:  s.provider.Name(),
+		Status:    types.EmailDeliveryStatusQueued,
+	}
+	if s.deliveries != nil {
+		if err := s.deliveries.Create(ctx, delivery); err != nil {
+			s.logger.WithError(err).Warn("Failed to record email delivery")
+		}
+	}
+
+	select {
+	case s.queue <- &emailJob{msg: msg, delivery: delivery}:
+		return nil
+	default:
+		delivery.Status = types.EmailDeliveryStatusFailed
+		delivery.LastError = "email queue is full"
+		s.track(ctx, delivery)
+		return fmt.Errorf("email queue is full")
+	}
+}
+
+// Start sends queued emails until ctx is cancelled. Failed attempts are
+// retried with exponential backoff unless the provider rejected the email
+// outright. Emails still queued at shutdown are lost; their deliveries are
+// marked failed when an instance next starts.
+func (s *EmailService) Start(ctx context.Context) {
+	if s.provider == nil {
+		return
+	}
+
+	if s.deliveries != nil {
+		if failed, err := s.deliveries.FailStale(ctx, time.Now().Add(-emailStaleAfter)); err != nil {
+			s.logger.WithError(err).Warn("Failed to mark interrupted email deliveries")
+		} else if failed > 0 {
+			s.logger.WithField("count", failed).Warn("Marked email deliveries interrupted by a restart as failed")
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			s.attempt(ctx, job)
+		}
+	}
+}
+
+// attempt sends a queued email once and schedules a retry if it failed
+func (s *EmailService) attempt(ctx context.Context, job *emailJob) {
+	delivery := job.delivery
+	delivery.Attempts++
+	delivery.Status = types.EmailDeliveryStatusSending
+	delivery.NextAttemptAt = nil
+	s.track(ctx, delivery)
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"to":       job.msg.To,
+		"subject":  job.msg.Subject,
+		"provider": delivery.Provider,
+		"attempt":  delivery.Attempts,
+	})
+
+	sendCtx, cancel := context.WithTimeout(ctx, emailSendTimeout)
+	messageID, err := s.provider.Send(sendCtx, job.msg)
+	cancel()
+
+	switch {
+	case err == nil:
+		sentAt := time.Now()
+		delivery.Status = types.EmailDeliveryStatusSent
+		delivery.SentAt = &sentAt
+		delivery.ProviderMessageID = messageID
+		delivery.LastError = ""
+		logger.Info("Email sent successfully")
+
+	case isPermanentEmailError(err) || delivery.Attempts >= emailMaxAttempts:
+		delivery.Status = types.EmailDeliveryStatusFailed
+		delivery.LastError = err.Error()
+		logger.WithError(err).Error("Failed to send email")
+
+	default:
+		delay := s.retryDelay << (delivery.Attempts - 1)
+		nextAttempt := time.Now().Add(delay)
+		delivery.Status = types.EmailDeliveryStatusRetrying
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = &nextAttempt
+		logger.WithError(err).WithField("retry_in", delay.String()).Warn("Failed to send email, will retry")
+
+		time.AfterFunc(delay, func() {
+			select {
+			case s.queue <- job:
+			case <-ctx.Done():
+			}
+		})
+	}
+
+	s.track(ctx, delivery)
+}
+
+// track saves the status of a delivery
+func (s *EmailService) track(ctx context.Context, delivery *types.EmailDelivery) {
+	if s.deliveries == nil {
+		return
+	}
+	if err := s.deliveries.Update(ctx, delivery); err != nil {
+		s.logger.WithError(err).WithField("delivery_id", delivery.ID).Warn("Failed to update email delivery")
+	}
+}
@@ -0,0 +1,76 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// resendEndpoint is the Resend API that sends an email
+const resendEndpoint = "https://api.resend.com/emails"
+
+// ResendProvider sends emails through the Resend API
+type ResendProvider struct {
+	apiKey     string
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewResendProvider creates a Resend provider
+func NewResendProvider(apiKey string) *ResendProvider {
+	return &ResendProvider{
+		apiKey:     apiKey,
+		endpoint:   resendEndpoint,
+		httpClient: newEmailHTTPClient(),
+	}
+}
+
+// resendEmail represents the Resend API email payload
+type resendEmail struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	HTML    string   `json:"html,omitempty"`
+	Text    string   `json:"text,omitempty"`
+}
+
+func (p *ResendProvider) Name() string { return EmailProviderResend }
+
+// Send sends an email via the Resend API
+func (p *ResendProvider) Send(ctx context.Context, msg *EmailMessage) (string, error) {
+	body, err := json.Marshal(resendEmail{
+		From:    msg.From.String(),
+		To:      []string{msg.To},
+		Subject: msg.Subject,
+		HTML:    msg.HTML,
+		Text:    msg.Text,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal email: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", httpStatusError("Resend", resp)
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return result.ID, nil
+}
@@ -0,0 +1,94 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// sendGridEndpoint is the SendGrid v3 API that sends an email
+const sendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridProvider sends emails through the SendGrid v3 API
+type SendGridProvider struct {
+	apiKey     string
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewSendGridProvider creates a SendGrid provider
+func NewSendGridProvider(apiKey string) *SendGridProvider {
+	return &SendGridProvider{
+		apiKey:     apiKey,
+		endpoint:   sendGridEndpoint,
+		httpClient: newEmailHTTPClient(),
+	}
+}
+
+// sendGridAddress is an email address in the SendGrid API
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// sendGridContent is one body of a SendGrid email
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// sendGridPersonalization lists the recipients of a SendGrid email
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+// sendGridEmail represents the SendGrid API email payload
+type sendGridEmail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+func (p *SendGridProvider) Name() string { return EmailProviderSendGrid }
+
+// Send sends an email via the SendGrid API
+func (p *SendGridProvider) Send(ctx context.Context, msg *EmailMessage) (string, error) {
+	email := sendGridEmail{
+		Personalizations: []sendGridPersonalization{
+			{To: []sendGridAddress{{Email: msg.To}}},
+		},
+		From:    sendGridAddress{Email: msg.From.Address, Name: msg.From.Name},
+		Subject: msg.Subject,
+		// SendGrid requires the plain text body before the HTML one
+		Content: []sendGridContent{
+			{Type: "text/plain", Value: msg.Text},
+			{Type: "text/html", Value: msg.HTML},
+		},
+	}
+
+	body, err := json.Marshal(email)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal email: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", httpStatusError("SendGrid", resp)
+	}
+	return resp.Header.Get("X-Message-Id"), nil
+}
@@ -0,0 +1,134 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// SESConfig configures sending through Amazon SES. Without an access key the
+// default AWS credential chain (environment, IRSA, instance profile) is used.
+type SESConfig struct {
+	Region          string // SES_REGION
+	AccessKeyID     string // SES_ACCESS_KEY_ID
+	SecretAccessKey string // SES_SECRET_ACCESS_KEY
+}
+
+// SESProvider sends emails through the Amazon SES v2 API
+type SESProvider struct {
+	region      string
+	endpoint    string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// NewSESProvider creates an SES provider for a region
+func NewSESProvider(ctx context.Context, cfg SESConfig) (*SESProvider, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("SES region is required")
+	}
+
+	var creds aws.CredentialsProvider
+	if cfg.AccessKeyID != "" {
+		creds = credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")
+	} else {
+		awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		if awsCfg.Credentials == nil {
+			return nil, fmt.Errorf("no AWS credentials found")
+		}
+		creds = awsCfg.Credentials
+	}
+
+	return &SESProvider{
+		region:      cfg.Region,
+		endpoint:    fmt.Sprintf("https://email.%s.amazonaws.com", cfg.Region),
+		credentials: creds,
+		signer:      v4.NewSigner(),
+		httpClient:  newEmailHTTPClient(),
+	}, nil
+}
+
+// sesContent is a text in the SES API
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+// sesEmail represents the SES v2 SendEmail payload
+type sesEmail struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Simple struct {
+			Subject sesContent `json:"Subject"`
+			Body    struct {
+				Text sesContent `json:"Text"`
+				HTML sesContent `json:"Html"`
+			} `json:"Body"`
+		} `json:"Simple"`
+	} `json:"Content"`
+}
+
+func (p *SESProvider) Name() string { return EmailProviderSES }
+
+// Send sends an email via the SES v2 API, signed with SigV4
+func (p *SESProvider) Send(ctx context.Context, msg *EmailMessage) (string, error) {
+	var email sesEmail
+	email.FromEmailAddress = msg.From.String()
+	email.Destination.ToAddresses = []string{msg.To}
+	email.Content.Simple.Subject = sesContent{Data: msg.Subject, Charset: "UTF-8"}
+	email.Content.Simple.Body.Text = sesContent{Data: msg.Text, Charset: "UTF-8"}
+	email.Content.Simple.Body.HTML = sesContent{Data: msg.HTML, Charset: "UTF-8"}
+
+	body, err := json.Marshal(email)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal email: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v2/email/outbound-emails", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "ses", p.region, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", httpStatusError("SES", resp)
+	}
+
+	var result struct {
+		MessageID string `json:"MessageId"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return result.MessageID, nil
+}
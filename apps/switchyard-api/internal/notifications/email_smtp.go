@@ -0,0 +1,209 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SMTP TLS modes
+const (
+	SMTPTLSStartTLS = "starttls" // Upgrade a plain connection, usually on port 587
+	SMTPTLSImplicit = "tls"      // TLS from the start, usually on port 465
+	SMTPTLSNone     = "none"     // No encryption, for local relays and mail catchers
+)
+
+// SMTPConfig configures sending through an SMTP server
+type SMTPConfig struct {
+	Host     string // SMTP_HOST
+	Port     int    // SMTP_PORT (default: 587)
+	Username string // SMTP_USERNAME (empty = no authentication)
+	Password string // SMTP_PASSWORD
+	TLS      string // SMTP_TLS - starttls (default), tls, or none
+}
+
+// SMTPProvider sends emails through an SMTP server
+type SMTPProvider struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPProvider creates an SMTP provider
+func NewSMTPProvider(cfg SMTPConfig) (*SMTPProvider, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	switch cfg.TLS {
+	case "":
+		cfg.TLS = SMTPTLSStartTLS
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return nil, fmt.Errorf("invalid SMTP TLS mode %q (must be starttls, tls, or none)", cfg.TLS)
+	}
+	return &SMTPProvider{cfg: cfg}, nil
+}
+
+func (p *SMTPProvider) Name() string { return EmailProviderSMTP }
+
+// Send delivers an email to the SMTP server
+func (p *SMTPProvider) Send(ctx context.Context, msg *EmailMessage) (string, error) {
+	messageID := fmt.Sprintf("<%s@%s>", uuid.New(), domainOf(msg.From.Address))
+	body, err := buildSMTPMessage(msg, messageID, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	client, err := p.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	if p.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", p.cfg.Username, p.cfg.Password, p.cfg.Host)); err != nil {
+			return "", smtpError("authentication failed", err)
+		}
+	}
+	if err := client.Mail(msg.From.Address); err != nil {
+		return "", smtpError("sender rejected", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return "", smtpError("recipient rejected", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return "", smtpError("failed to start message", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return "", smtpError("failed to write message", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", smtpError("message rejected", err)
+	}
+
+	client.Quit()
+	return messageID, nil
+}
+
+// dial connects to the SMTP server, encrypting the connection as configured
+func (p *SMTPProvider) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(p.cfg.Host, strconv.Itoa(p.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: p.cfg.Host}
+
+	var conn net.Conn
+	var err error
+	if p.cfg.TLS == SMTPTLSImplicit {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+
+	// The SMTP client has no context support; bound the whole session instead
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, p.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if p.cfg.TLS == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, &permanentEmailError{err: fmt.Errorf("SMTP server %s does not support STARTTLS", p.cfg.Host)}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// smtpError classifies an SMTP failure. Permanent (5xx) replies are not
+// retried; transient (4xx) replies and connection errors are.
+func smtpError(action string, err error) error {
+	wrapped := fmt.Errorf("%s: %w", action, err)
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return &permanentEmailError{err: wrapped}
+	}
+	return wrapped
+}
+
+// buildSMTPMessage renders an email as a MIME message with plain text and
+// HTML alternatives
+func buildSMTPMessage(msg *EmailMessage, messageID string, date time.Time) ([]byte, error) {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return nil, &permanentEmailError{err: fmt.Errorf("invalid recipient %q", msg.To)}
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + msg.From.String(),
+		"To: " + msg.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"Message-ID: " + messageID,
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q", mw.Boundary()),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build message: %w", err)
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("failed to build message: %w", err)
+		}
+		qw.Close()
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// domainOf returns the domain of an email address
+func domainOf(address string) string {
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		return address[at+1:]
+	}
+	return "localhost"
+}
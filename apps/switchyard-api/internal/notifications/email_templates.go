@@ -0,0 +1,440 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// emailFuncs are the helpers available to email templates
+var emailFuncs = map[string]any{
+	"date":      formatEmailDate,
+	"short":     shortSHA,
+	"money":     func(amount float64, currency string) string { return fmt.Sprintf("%.2f %s", amount, currency) },
+	"percent":   percentOf,
+	"join":      strings.Join,
+	"expiresIn": describeExpiry,
+}
+
+// emailLayout wraps the HTML of every email. Templates define "content"
+// and "footer".
+var emailLayout = htmltemplate.Must(htmltemplate.New("layout").Funcs(emailFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .button { display: inline-block; background: #0066cc; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 20px 0; }
+        .code { font-family: monospace; font-size: 18px; letter-spacing: 2px; }
+        .error { background: #fdf2f2; border-left: 4px solid #c81e1e; padding: 12px; white-space: pre-wrap; word-break: break-word; }
+        .footer { margin-top: 40px; font-size: 12px; color: #666; }
+    </style>
+</head>
+<body>
+    <div class="container">
+{{template "content" .}}
+        <div class="footer">
+            <p>{{template "footer" .}}</p>
+            <p>&copy; Enclii - Self-hosted DevOps Platform</p>
+        </div>
+    </div>
+</body>
+</html>`))
+
+// emailTemplate renders the subject and bodies of one kind of email
+type emailTemplate struct {
+	name    string
+	subject *texttemplate.Template
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+}
+
+func newEmailTemplate(name, subject, html, text string) *emailTemplate {
+	return &emailTemplate{
+		name:    name,
+		subject: texttemplate.Must(texttemplate.New(name).Funcs(emailFuncs).Parse(subject)),
+		html:    htmltemplate.Must(htmltemplate.Must(emailLayout.Clone()).Parse(html)),
+		text:    texttemplate.Must(texttemplate.New(name).Funcs(emailFuncs).Parse(text)),
+	}
+}
+
+// render fills the template in. HTML values are escaped.
+func (t *emailTemplate) render(data any) (subject, html, text string, err error) {
+	var subjectBuf, htmlBuf, textBuf bytes.Buffer
+	if err := t.subject.Execute(&subjectBuf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render %s subject: %w", t.name, err)
+	}
+	if err := t.html.ExecuteTemplate(&htmlBuf, "layout", data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render %s HTML: %w", t.name, err)
+	}
+	if err := t.text.Execute(&textBuf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render %s text: %w", t.name, err)
+	}
+	return subjectBuf.String(), htmlBuf.String(), textBuf.String(), nil
+}
+
+var teamInvitationEmail = newEmailTemplate("team_invitation",
+	`You've been invited to join {{.TeamName}} on Enclii`,
+	`{{define "content"}}
+        <h1>You're invited to join {{.TeamName}}</h1>
+        <p>Hi,</p>
+        <p><strong>{{.InviterName}}</strong> ({{.InviterEmail}}) has invited you to join the <strong>{{.TeamName}}</strong> team on Enclii as a <strong>{{.Role}}</strong>.</p>
+        <p>Click the button below to accept this invitation:</p>
+        <a href="{{.URL}}" class="button">Accept Invitation</a>
+        <p>Or copy and paste this link into your browser:</p>
+        <p style="word-break: break-all;">{{.URL}}</p>
+        <p>This invitation expires on {{date .ExpiresAt}}.</p>
+{{end}}{{define "footer"}}If you weren't expecting this invitation, you can safely ignore this email.{{end}}`,
+	`You're invited to join {{.TeamName}} on Enclii
+
+Hi,
+
+{{.InviterName}} ({{.InviterEmail}}) has invited you to join the {{.TeamName}} team on Enclii as a {{.Role}}.
+
+Accept your invitation by visiting:
+{{.URL}}
+
+This invitation expires on {{date .ExpiresAt}}.
+
+If you weren't expecting this invitation, you can safely ignore this email.
+`)
+
+var emailVerificationEmail = newEmailTemplate("email_verification",
+	`Verify your email address for Enclii`,
+	`{{define "content"}}
+        <h1>Verify your email address</h1>
+        <p>Hi {{.Name}},</p>
+        <p>Thanks for signing up for Enclii. Click the button below to verify your email address and sign in:</p>
+        <a href="{{.URL}}" class="button">Verify Email</a>
+        <p>Or copy and paste this link into your browser:</p>
+        <p style="word-break: break-all;">{{.URL}}</p>
+        <p>This link expires on {{date .ExpiresAt}}.</p>
+{{end}}{{define "footer"}}If you didn't create an Enclii account, you can safely ignore this email.{{end}}`,
+	`Verify your email address
+
+Hi {{.Name}},
+
+Thanks for signing up for Enclii. Verify your email address and sign in by visiting:
+{{.URL}}
+
+This link expires on {{date .ExpiresAt}}.
+
+If you didn't create an Enclii account, you can safely ignore this email.
+`)
+
+var signupInviteEmail = newEmailTemplate("signup_invite",
+	`You've been invited to Enclii`,
+	`{{define "content"}}
+        <h1>You're invited to Enclii</h1>
+        <p>Hi,</p>
+        <p><strong>{{.InviterName}}</strong> has invited you to create an account on Enclii.</p>
+        <p>Click the button below to sign up:</p>
+        <a href="{{.URL}}" class="button">Create Account</a>
+        <p>Or sign up with this invite code:</p>
+        <p class="code">{{.Code}}</p>
+        <p>{{if .ExpiresAt}}This invite expires on {{date .ExpiresAt}}.{{else}}This invite does not expire.{{end}}</p>
+{{end}}{{define "footer"}}If you weren't expecting this invitation, you can safely ignore this email.{{end}}`,
+	`You're invited to Enclii
+
+Hi,
+
+{{.InviterName}} has invited you to create an account on Enclii.
+
+Sign up by visiting:
+{{.URL}}
+
+Or sign up with this invite code: {{.Code}}
+
+{{if .ExpiresAt}}This invite expires on {{date .ExpiresAt}}.{{else}}This invite does not expire.{{end}}
+
+If you weren't expecting this invitation, you can safely ignore this email.
+`)
+
+var deploymentFailedEmail = newEmailTemplate("deployment_failed",
+	`Deployment of {{.ServiceName}} to {{.Environment}} failed`,
+	`{{define "content"}}
+        <h1>Deployment failed</h1>
+        <p>The deployment of <strong>{{.ServiceName}}</strong> in <strong>{{.ProjectName}}</strong> to <strong>{{.Environment}}</strong>{{if .CommitSHA}} at commit <code>{{short .CommitSHA}}</code>{{end}} failed.</p>
+        {{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+        <a href="{{.URL}}" class="button">View Project</a>
+{{end}}{{define "footer"}}You're receiving this because deployment alerts for {{.ProjectName}} are sent to this address.{{end}}`,
+	`Deployment failed
+
+The deployment of {{.ServiceName}} in {{.ProjectName}} to {{.Environment}}{{if .CommitSHA}} at commit {{short .CommitSHA}}{{end}} failed.
+{{if .Error}}
+{{.Error}}
+{{end}}
+View the project:
+{{.URL}}
+
+You're receiving this because deployment alerts for {{.ProjectName}} are sent to this address.
+`)
+
+var budgetExceededEmail = newEmailTemplate("budget_exceeded",
+	`{{.ProjectName}} has exceeded its {{.Period}} budget`,
+	`{{define "content"}}
+        <h1>Budget exceeded</h1>
+        <p><strong>{{.ProjectName}}</strong> has spent <strong>{{money .Spend .Currency}}</strong> of its <strong>{{money .Budget .Currency}}</strong> budget for {{.Period}} ({{percent .Spend .Budget}}).</p>
+        <a href="{{.URL}}" class="button">Review Usage</a>
+{{end}}{{define "footer"}}You're receiving this because budget alerts for {{.ProjectName}} are sent to this address.{{end}}`,
+	`Budget exceeded
+
+{{.ProjectName}} has spent {{money .Spend .Currency}} of its {{money .Budget .Currency}} budget for {{.Period}} ({{percent .Spend .Budget}}).
+
+Review usage:
+{{.URL}}
+
+You're receiving this because budget alerts for {{.ProjectName}} are sent to this address.
+`)
+
+var budgetWarningEmail = newEmailTemplate("budget_warning",
+	`{{.ProjectName}} has used {{.Threshold}}% of its {{.Period}} budget`,
+	`{{define "content"}}
+        <h1>{{.Threshold}}% of budget used</h1>
+        <p><strong>{{.ProjectName}}</strong> has spent <strong>{{money .Spend .Currency}}</strong> of its <strong>{{money .Budget .Currency}}</strong> budget for {{.Period}}.</p>
+        <a href="{{.URL}}" class="button">Review Usage</a>
+{{end}}{{define "footer"}}You're receiving this because budget alerts for {{.ProjectName}} are sent to this address.{{end}}`,
+	`{{.Threshold}}% of budget used
+
+{{.ProjectName}} has spent {{money .Spend .Currency}} of its {{money .Budget .Currency}} budget for {{.Period}}.
+
+Review usage:
+{{.URL}}
+
+You're receiving this because budget alerts for {{.ProjectName}} are sent to this address.
+`)
+
+var budgetCapReachedEmail = newEmailTemplate("budget_cap_reached",
+	`{{.ProjectName}} reached its {{.Period}} spending cap`,
+	`{{define "content"}}
+        <h1>Spending cap reached</h1>
+        <p><strong>{{.ProjectName}}</strong> has spent <strong>{{money .Spend .Currency}}</strong>, reaching its <strong>{{money .HardLimit .Currency}}</strong> spending cap for {{.Period}}.</p>
+        {{if .StoppedServices}}<p>Non-production services were scaled to zero: {{join .StoppedServices ", "}}. Production services keep running.</p>{{end}}
+        <p>Raise the cap or redeploy the services to start them again.</p>
+        <a href="{{.URL}}" class="button">Review Usage</a>
+{{end}}{{define "footer"}}You're receiving this because budget alerts for {{.ProjectName}} are sent to this address.{{end}}`,
+	`Spending cap reached
+
+{{.ProjectName}} has spent {{money .Spend .Currency}}, reaching its {{money .HardLimit .Currency}} spending cap for {{.Period}}.
+{{if .StoppedServices}}
+Non-production services were scaled to zero: {{join .StoppedServices ", "}}. Production services keep running.
+{{end}}
+Raise the cap or redeploy the services to start them again.
+
+Review usage:
+{{.URL}}
+
+You're receiving this because budget alerts for {{.ProjectName}} are sent to this address.
+`)
+
+var paymentFailedEmail = newEmailTemplate("payment_failed",
+	`Payment failed for {{.AccountName}}`,
+	`{{define "content"}}
+        <h1>Payment failed</h1>
+        <p>We couldn't charge <strong>{{money .Amount .Currency}}</strong> for <strong>{{.AccountName}}</strong>. This is failed payment {{.FailedPayments}} of {{.SuspendAfter}}.</p>
+        {{if .NextAttempt}}<p>We'll try again on {{date .NextAttempt}}.</p>{{end}}
+        <p>After {{.SuspendAfter}} failed payments in a row, all services are suspended until the balance is paid. Update your payment method to avoid interruption.</p>
+        <a href="{{if .InvoiceURL}}{{.InvoiceURL}}{{else}}{{.URL}}{{end}}" class="button">Update Payment</a>
+{{end}}{{define "footer"}}You're receiving this because billing emails for {{.AccountName}} are sent to this address.{{end}}`,
+	`Payment failed
+
+We couldn't charge {{money .Amount .Currency}} for {{.AccountName}}. This is failed payment {{.FailedPayments}} of {{.SuspendAfter}}.
+{{if .NextAttempt}}
+We'll try again on {{date .NextAttempt}}.
+{{end}}
+After {{.SuspendAfter}} failed payments in a row, all services are suspended until the balance is paid. Update your payment method to avoid interruption.
+
+Update payment:
+{{if .InvoiceURL}}{{.InvoiceURL}}{{else}}{{.URL}}{{end}}
+
+You're receiving this because billing emails for {{.AccountName}} are sent to this address.
+`)
+
+var servicesSuspendedEmail = newEmailTemplate("services_suspended",
+	`Services of {{.AccountName}} suspended for non-payment`,
+	`{{define "content"}}
+        <h1>Services suspended</h1>
+        <p>Payments for <strong>{{.AccountName}}</strong> kept failing, so its services were suspended. <strong>{{money .Amount .Currency}}</strong> is outstanding.</p>
+        {{if .StoppedServices}}<p>Scaled to zero: {{join .StoppedServices ", "}}.</p>{{end}}
+        <p>Services start again as soon as the payment succeeds.</p>
+        <a href="{{if .InvoiceURL}}{{.InvoiceURL}}{{else}}{{.URL}}{{end}}" class="button">Pay Now</a>
+{{end}}{{define "footer"}}You're receiving this because billing emails for {{.AccountName}} are sent to this address.{{end}}`,
+	`Services suspended
+
+Payments for {{.AccountName}} kept failing, so its services were suspended. {{money .Amount .Currency}} is outstanding.
+{{if .StoppedServices}}
+Scaled to zero: {{join .StoppedServices ", "}}.
+{{end}}
+Services start again as soon as the payment succeeds.
+
+Pay now:
+{{if .InvoiceURL}}{{.InvoiceURL}}{{else}}{{.URL}}{{end}}
+
+You're receiving this because billing emails for {{.AccountName}} are sent to this address.
+`)
+
+var paymentRecoveredEmail = newEmailTemplate("payment_recovered",
+	`Payment received for {{.AccountName}}`,
+	`{{define "content"}}
+        <h1>Payment received</h1>
+        <p>Thanks! We received <strong>{{money .Amount .Currency}}</strong> for <strong>{{.AccountName}}</strong> and your account is in good standing again.</p>
+        {{if .ResumedServices}}<p>Restarted: {{join .ResumedServices ", "}}.</p>{{end}}
+        <a href="{{.URL}}" class="button">View Billing</a>
+{{end}}{{define "footer"}}You're receiving this because billing emails for {{.AccountName}} are sent to this address.{{end}}`,
+	`Payment received
+
+Thanks! We received {{money .Amount .Currency}} for {{.AccountName}} and your account is in good standing again.
+{{if .ResumedServices}}
+Restarted: {{join .ResumedServices ", "}}.
+{{end}}
+View billing:
+{{.URL}}
+
+You're receiving this because billing emails for {{.AccountName}} are sent to this address.
+`)
+
+var cardExpiringEmail = newEmailTemplate("card_expiring",
+	`Your card for {{.AccountName}} expires soon`,
+	`{{define "content"}}
+        <h1>Card expiring</h1>
+        <p>The {{.Brand}} card ending in <strong>{{.Last4}}</strong> that pays for <strong>{{.AccountName}}</strong> expires at the end of {{printf "%02d" .ExpMonth}}/{{.ExpYear}}.</p>
+        <p>Add a new card so upcoming payments don't fail.</p>
+        <a href="{{.URL}}" class="button">Update Payment Method</a>
+{{end}}{{define "footer"}}You're receiving this because billing emails for {{.AccountName}} are sent to this address.{{end}}`,
+	`Card expiring
+
+The {{.Brand}} card ending in {{.Last4}} that pays for {{.AccountName}} expires at the end of {{printf "%02d" .ExpMonth}}/{{.ExpYear}}.
+
+Add a new card so upcoming payments don't fail.
+
+Update payment method:
+{{.URL}}
+
+You're receiving this because billing emails for {{.AccountName}} are sent to this address.
+`)
+
+var subscriptionCanceledEmail = newEmailTemplate("subscription_canceled",
+	`{{.AccountName}}'s {{.PlanName}} subscription was canceled`,
+	`{{define "content"}}
+        <h1>Subscription canceled</h1>
+        <p>The <strong>{{.PlanName}}</strong> subscription of <strong>{{.AccountName}}</strong> has ended. Usage until now will be on the final invoice.</p>
+        <a href="{{.URL}}" class="button">View Billing</a>
+{{end}}{{define "footer"}}You're receiving this because billing emails for {{.AccountName}} are sent to this address.{{end}}`,
+	`Subscription canceled
+
+The {{.PlanName}} subscription of {{.AccountName}} has ended. Usage until now will be on the final invoice.
+
+View billing:
+{{.URL}}
+
+You're receiving this because billing emails for {{.AccountName}} are sent to this address.
+`)
+
+var certificateExpiringEmail = newEmailTemplate("certificate_expiring",
+	`TLS certificate for {{.Domain}} {{expiresIn .ExpiresAt}}`,
+	`{{define "content"}}
+        <h1>TLS certificate {{expiresIn .ExpiresAt}}</h1>
+        <p>The TLS certificate for <strong>{{.Domain}}</strong>{{if .ProjectName}} in <strong>{{.ProjectName}}</strong>{{end}} expires on {{date .ExpiresAt}}.</p>
+        <p>Certificates renew automatically. If renewal keeps failing, check that the domain's DNS records still point at Enclii.</p>
+        <a href="{{.URL}}" class="button">View Domains</a>
+{{end}}{{define "footer"}}You're receiving this because certificate alerts for {{.Domain}} are sent to this address.{{end}}`,
+	`TLS certificate {{expiresIn .ExpiresAt}}
+
+The TLS certificate for {{.Domain}}{{if .ProjectName}} in {{.ProjectName}}{{end}} expires on {{date .ExpiresAt}}.
+
+Certificates renew automatically. If renewal keeps failing, check that the domain's DNS records still point at Enclii.
+
+View domains:
+{{.URL}}
+
+You're receiving this because certificate alerts for {{.Domain}} are sent to this address.
+`)
+
+var alertFiringEmail = newEmailTemplate("alert_firing",
+	`[Alert] {{.RuleName}}: {{.ServiceName}} in {{.Environment}}`,
+	`{{define "content"}}
+        <h1>Alert firing</h1>
+        <p><strong>{{.ServiceName}}</strong> in <strong>{{.ProjectName}}</strong> ({{.Environment}}) triggered <strong>{{.RuleName}}</strong>, starting {{date .StartedAt}}.</p>
+        <p class="error">{{.Summary}}</p>
+        <p>You'll get another email when the alert resolves.</p>
+        <a href="{{.URL}}" class="button">View Project</a>
+{{end}}{{define "footer"}}You're receiving this because the {{.RuleName}} alert of {{.ServiceName}} is sent to this address.{{end}}`,
+	`Alert firing
+
+{{.ServiceName}} in {{.ProjectName}} ({{.Environment}}) triggered {{.RuleName}}, starting {{date .StartedAt}}.
+
+{{.Summary}}
+
+You'll get another email when the alert resolves.
+
+View the project:
+{{.URL}}
+
+You're receiving this because the {{.RuleName}} alert of {{.ServiceName}} is sent to this address.
+`)
+
+var alertResolvedEmail = newEmailTemplate("alert_resolved",
+	`[Resolved] {{.RuleName}}: {{.ServiceName}} in {{.Environment}}`,
+	`{{define "content"}}
+        <h1>Alert resolved</h1>
+        <p><strong>{{.RuleName}}</strong> for <strong>{{.ServiceName}}</strong> in <strong>{{.ProjectName}}</strong> ({{.Environment}}) resolved on {{date .ResolvedAt}}. It started {{date .StartedAt}}.</p>
+        <a href="{{.URL}}" class="button">View Project</a>
+{{end}}{{define "footer"}}You're receiving this because the {{.RuleName}} alert of {{.ServiceName}} is sent to this address.{{end}}`,
+	`Alert resolved
+
+{{.RuleName}} for {{.ServiceName}} in {{.ProjectName}} ({{.Environment}}) resolved on {{date .ResolvedAt}}. It started {{date .StartedAt}}.
+
+View the project:
+{{.URL}}
+
+You're receiving this because the {{.RuleName}} alert of {{.ServiceName}} is sent to this address.
+`)
+
+// formatEmailDate formats a time or time pointer for email bodies
+func formatEmailDate(t any) string {
+	switch v := t.(type) {
+	case time.Time:
+		return v.UTC().Format("January 2, 2006 at 3:04 PM UTC")
+	case *time.Time:
+		if v != nil {
+			return v.UTC().Format("January 2, 2006 at 3:04 PM UTC")
+		}
+	}
+	return ""
+}
+
+// shortSHA abbreviates a commit SHA
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// percentOf formats part as a percentage of whole
+func percentOf(part, whole float64) string {
+	if whole <= 0 {
+		return "no budget set"
+	}
+	return fmt.Sprintf("%.0f%%", part/whole*100)
+}
+
+// describeExpiry says how soon a time passes, such as "expires in 3 days"
+func describeExpiry(t time.Time) string {
+	days := int(math.Floor(time.Until(t).Hours() / 24))
+	switch {
+	case time.Until(t) <= 0:
+		return "has expired"
+	case days == 0:
+		return "expires today"
+	case days == 1:
+		return "expires in 1 day"
+	default:
+		return fmt.Sprintf("expires in %d days", days)
+	}
+}
@@ -0,0 +1,361 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func testEmailMessage() *EmailMessage {
+	return &EmailMessage{
+		From:    mail.Address{Name: "Enclii", Address: "noreply@enclii.dev"},
+		To:      "dev@example.com",
+		Subject: "Deployment of api to production failed",
+		HTML:    "<p>Failed</p>",
+		Text:    "Failed",
+	}
+}
+
+func TestEmailTemplates_Render(t *testing.T) {
+	expiresAt := time.Date(2026, 10, 20, 15, 4, 0, 0, time.UTC)
+
+	subject, html, text, err := teamInvitationEmail.render(struct {
+		TeamInvitationData
+		URL string
+	}{TeamInvitationData{
+		TeamName:     `<script>alert("x")</script>`,
+		InviterName:  "Ana",
+		InviterEmail: "ana@example.com",
+		Role:         "member",
+		ExpiresAt:    expiresAt,
+	}, "https://app.enclii.dev/invitations/accept?token=abc"})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if subject != `You've been invited to join <script>alert("x")</script> on Enclii` {
+		t.Errorf("subject = %q", subject)
+	}
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "&lt;script&gt;") {
+		t.Error("HTML body should escape the team name")
+	}
+	if !strings.Contains(html, `href="https://app.enclii.dev/invitations/accept?token=abc"`) {
+		t.Error("HTML body should link to the invitation")
+	}
+	if !strings.Contains(text, "October 20, 2026 at 3:04 PM UTC") {
+		t.Errorf("text body should carry the expiry, got:\n%s", text)
+	}
+
+	subject, _, text, err = deploymentFailedEmail.render(struct {
+		DeploymentFailedData
+		URL string
+	}{DeploymentFailedData{
+		ProjectName: "Shop",
+		ServiceName: "api",
+		Environment: "production",
+		CommitSHA:   "0123456789abcdef",
+		Error:       "ImagePullBackOff",
+	}, "https://app.enclii.dev/projects/shop"})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if subject != "Deployment of api to production failed" || !strings.Contains(text, "at commit 0123456") || !strings.Contains(text, "ImagePullBackOff") {
+		t.Errorf("deployment failed email = %q\n%s", subject, text)
+	}
+
+	_, _, text, err = budgetExceededEmail.render(struct {
+		BudgetExceededData
+		URL string
+	}{BudgetExceededData{ProjectName: "Shop", Period: "October 2026", Budget: 200, Spend: 250, Currency: "USD"}, ""})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if !strings.Contains(text, "spent 250.00 USD of its 200.00 USD budget for October 2026 (125%)") {
+		t.Errorf("budget email text = %s", text)
+	}
+
+	subject, _, _, err = budgetWarningEmail.render(struct {
+		BudgetWarningData
+		URL string
+	}{BudgetWarningData{ProjectName: "Shop", Period: "October 2026", Threshold: 80, Budget: 200, Spend: 161, Currency: "USD"}, ""})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if subject != "Shop has used 80% of its October 2026 budget" {
+		t.Errorf("subject = %q", subject)
+	}
+
+	_, html, text, err = budgetCapReachedEmail.render(struct {
+		BudgetCapReachedData
+		URL string
+	}{BudgetCapReachedData{ProjectName: "Shop", Period: "October 2026", HardLimit: 300, Spend: 301, Currency: "USD", StoppedServices: []string{"api", "worker"}}, ""})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if !strings.Contains(text, "scaled to zero: api, worker") || !strings.Contains(html, "api, worker") {
+		t.Errorf("budget cap email text = %s", text)
+	}
+
+	nextAttempt := time.Date(2026, 10, 23, 9, 0, 0, 0, time.UTC)
+	subject, html, text, err = paymentFailedEmail.render(struct {
+		PaymentFailedData
+		URL string
+	}{PaymentFailedData{AccountName: "Acme", Amount: 49, Currency: "USD", FailedPayments: 2, SuspendAfter: 3, NextAttempt: &nextAttempt, InvoiceURL: "https://invoice.stripe.com/i/abc"}, "https://app.enclii.dev/billing"})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if subject != "Payment failed for Acme" || !strings.Contains(text, "failed payment 2 of 3") || !strings.Contains(text, "October 23, 2026") {
+		t.Errorf("payment failed email = %q\n%s", subject, text)
+	}
+	if !strings.Contains(html, `href="https://invoice.stripe.com/i/abc"`) {
+		t.Error("payment failed email should link to the Stripe invoice")
+	}
+
+	_, _, text, err = servicesSuspendedEmail.render(struct {
+		ServicesSuspendedData
+		URL string
+	}{ServicesSuspendedData{AccountName: "Acme", Amount: 49, Currency: "USD", StoppedServices: []string{"api (production)"}}, "https://app.enclii.dev/billing"})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if !strings.Contains(text, "Scaled to zero: api (production).") || !strings.Contains(text, "https://app.enclii.dev/billing") {
+		t.Errorf("services suspended email text = %s", text)
+	}
+
+	_, _, text, err = cardExpiringEmail.render(struct {
+		CardExpiringData
+		URL string
+	}{CardExpiringData{AccountName: "Acme", Brand: "Visa", Last4: "4242", ExpMonth: 3, ExpYear: 2027}, ""})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if !strings.Contains(text, "ending in 4242") || !strings.Contains(text, "end of 03/2027") {
+		t.Errorf("card expiring email text = %s", text)
+	}
+
+	startedAt := time.Date(2026, 10, 17, 8, 30, 0, 0, time.UTC)
+	subject, _, text, err = alertFiringEmail.render(struct {
+		AlertData
+		URL string
+	}{AlertData{ProjectName: "Shop", ServiceName: "api", Environment: "production", RuleName: "Crash looping", Summary: "Container web of pod api-7f9c is in CrashLoopBackOff", StartedAt: startedAt}, "https://app.enclii.dev/projects/shop"})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if subject != "[Alert] Crash looping: api in production" || !strings.Contains(text, "CrashLoopBackOff") {
+		t.Errorf("alert firing email = %q\n%s", subject, text)
+	}
+
+	resolvedAt := startedAt.Add(25 * time.Minute)
+	subject, _, text, err = alertResolvedEmail.render(struct {
+		AlertData
+		URL string
+	}{AlertData{ProjectName: "Shop", ServiceName: "api", Environment: "production", RuleName: "Crash looping", StartedAt: startedAt, ResolvedAt: &resolvedAt}, ""})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if subject != "[Resolved] Crash looping: api in production" || !strings.Contains(text, "resolved on October 17, 2026 at 8:55 AM UTC") {
+		t.Errorf("alert resolved email = %q\n%s", subject, text)
+	}
+
+	subject, _, _, err = certificateExpiringEmail.render(struct {
+		CertificateExpiringData
+		URL string
+	}{CertificateExpiringData{Domain: "shop.example.com", ExpiresAt: time.Now().Add(3*24*time.Hour + time.Hour)}, ""})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if subject != "TLS certificate for shop.example.com expires in 3 days" {
+		t.Errorf("subject = %q", subject)
+	}
+}
+
+func TestBuildSMTPMessage(t *testing.T) {
+	msg := testEmailMessage()
+	msg.Subject = "Déploiement échoué"
+
+	body, err := buildSMTPMessage(msg, "<id@enclii.dev>", time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("buildSMTPMessage() error = %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatalf("message does not parse: %v", err)
+	}
+	if got, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); got != msg.Subject {
+		t.Errorf("Subject = %q, want %q", got, msg.Subject)
+	}
+	if parsed.Header.Get("Message-ID") != "<id@enclii.dev>" || parsed.Header.Get("To") != "dev@example.com" {
+		t.Errorf("headers = %v", parsed.Header)
+	}
+	if !strings.HasPrefix(parsed.Header.Get("Content-Type"), "multipart/alternative") {
+		t.Errorf("Content-Type = %q", parsed.Header.Get("Content-Type"))
+	}
+	rest, _ := io.ReadAll(parsed.Body)
+	if !strings.Contains(string(rest), "text/plain") || !strings.Contains(string(rest), "text/html") {
+		t.Error("message should carry plain text and HTML parts")
+	}
+
+	msg.To = "dev@example.com\r\nBcc: victim@example.com"
+	if _, err := buildSMTPMessage(msg, "<id@enclii.dev>", time.Now()); !isPermanentEmailError(err) {
+		t.Errorf("expected a permanent error for a recipient with a line break, got %v", err)
+	}
+}
+
+func TestHTTPEmailProviders(t *testing.T) {
+	var received map[string]any
+	var status int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("X-Message-Id", "sg-1")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":"re-1"}`))
+	}))
+	defer server.Close()
+
+	resend := NewResendProvider("key")
+	resend.endpoint = server.URL
+	sendGrid := NewSendGridProvider("key")
+	sendGrid.endpoint = server.URL
+
+	status = http.StatusOK
+	if id, err := resend.Send(context.Background(), testEmailMessage()); err != nil || id != "re-1" {
+		t.Errorf("Resend Send() = %q, %v", id, err)
+	}
+	if received["from"] != `"Enclii" <noreply@enclii.dev>` {
+		t.Errorf("Resend from = %v", received["from"])
+	}
+
+	status = http.StatusAccepted
+	if id, err := sendGrid.Send(context.Background(), testEmailMessage()); err != nil || id != "sg-1" {
+		t.Errorf("SendGrid Send() = %q, %v", id, err)
+	}
+	if content := received["content"].([]any); content[0].(map[string]any)["type"] != "text/plain" {
+		t.Errorf("SendGrid content = %v, want plain text first", content)
+	}
+
+	status = http.StatusBadRequest
+	if _, err := resend.Send(context.Background(), testEmailMessage()); !isPermanentEmailError(err) {
+		t.Errorf("a 400 response should be permanent, got %v", err)
+	}
+	for _, retryable := range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+		status = retryable
+		if _, err := sendGrid.Send(context.Background(), testEmailMessage()); err == nil || isPermanentEmailError(err) {
+			t.Errorf("a %d response should be retried, got %v", retryable, err)
+		}
+	}
+}
+
+func TestSESProvider_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/email/outbound-emails" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "/us-east-1/ses/aws4_request") {
+			t.Errorf("Authorization = %q, want a SigV4 signature for SES", auth)
+		}
+		var email sesEmail
+		json.NewDecoder(r.Body).Decode(&email)
+		if email.Destination.ToAddresses[0] != "dev@example.com" || email.Content.Simple.Body.HTML.Data != "<p>Failed</p>" {
+			t.Errorf("email = %+v", email)
+		}
+		w.Write([]byte(`{"MessageId":"ses-1"}`))
+	}))
+	defer server.Close()
+
+	provider := &SESProvider{
+		region:      "us-east-1",
+		endpoint:    server.URL,
+		credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+		signer:      v4.NewSigner(),
+		httpClient:  server.Client(),
+	}
+	if id, err := provider.Send(context.Background(), testEmailMessage()); err != nil || id != "ses-1" {
+		t.Errorf("Send() = %q, %v", id, err)
+	}
+}
+
+// fakeEmailProvider fails with the queued errors, then succeeds
+type fakeEmailProvider struct {
+	errs  []error
+	sends int
+}
+
+func (p *fakeEmailProvider) Name() string { return "fake" }
+
+func (p *fakeEmailProvider) Send(ctx context.Context, msg *EmailMessage) (string, error) {
+	p.sends++
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return "", err
+	}
+	return "msg-1", nil
+}
+
+func TestEmailService_Attempt(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	provider := &fakeEmailProvider{errs: []error{errors.New("connection reset")}}
+	s := &EmailService{
+		logger:     logger,
+		provider:   provider,
+		queue:      make(chan *emailJob, 1),
+		retryDelay: time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := s.enqueue(ctx, "test", testEmailMessage()); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	job := <-s.queue
+
+	s.attempt(ctx, job)
+	if job.delivery.Status != types.EmailDeliveryStatusRetrying || job.delivery.NextAttemptAt == nil {
+		t.Fatalf("delivery = %+v, want a scheduled retry", job.delivery)
+	}
+
+	select {
+	case retried := <-s.queue:
+		s.attempt(ctx, retried)
+	case <-time.After(time.Second):
+		t.Fatal("the failed email was not queued again")
+	}
+	if job.delivery.Status != types.EmailDeliveryStatusSent || job.delivery.Attempts != 2 || job.delivery.ProviderMessageID != "msg-1" {
+		t.Errorf("delivery = %+v, want sent on the second attempt", job.delivery)
+	}
+
+	provider.errs = []error{&permanentEmailError{err: errors.New("550 mailbox unavailable")}}
+	if err := s.enqueue(ctx, "test", testEmailMessage()); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	job = <-s.queue
+	s.attempt(ctx, job)
+	if job.delivery.Status != types.EmailDeliveryStatusFailed || job.delivery.Attempts != 1 {
+		t.Errorf("delivery = %+v, want failed without a retry", job.delivery)
+	}
+
+	// The queue holds one email; another is refused rather than blocking
+	s.enqueue(ctx, "test", testEmailMessage())
+	if err := s.enqueue(ctx, "test", testEmailMessage()); err == nil {
+		t.Error("expected an error when the queue is full")
+	}
+}
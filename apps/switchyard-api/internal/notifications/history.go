@@ -0,0 +1,114 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SetEventRepository makes the service record every event it sends, and
+// those passed to RecordEvent, in the event history of their project
+func (s *Service) SetEventRepository(repo *db.ProjectEventRepository) {
+	s.events = repo
+}
+
+// RecordEvent adds an event that webhooks are not sent for to the history
+// of its project. Failures are logged, as history is not worth failing the
+// change it describes for.
+func (s *Service) RecordEvent(ctx context.Context, event *types.ProjectEvent) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Create(ctx, event); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"project_id": event.ProjectID,
+			"event_type": event.Type,
+		}).WithError(err).Warn("Failed to record project event")
+	}
+}
+
+// RecordTeamEvent adds an event to the history of every project of a team
+func (s *Service) RecordTeamEvent(ctx context.Context, teamID uuid.UUID, event *types.ProjectEvent) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.CreateForTeam(ctx, teamID, event); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"team_id":    teamID,
+			"event_type": event.Type,
+		}).WithError(err).Warn("Failed to record project event")
+	}
+}
+
+// projectEvent converts a webhook event into an entry of the history of
+// the project it was sent for
+func (s *Service) projectEvent(projectID uuid.UUID, event *types.WebhookEvent) *types.ProjectEvent {
+	_, _, title := s.slack.getEventMeta(event.Type)
+	entry := &types.ProjectEvent{
+		ProjectID: projectID,
+		Type:      string(event.Type),
+		Message:   title,
+		Data:      eventData(event),
+		CreatedAt: event.Timestamp,
+	}
+
+	var subject string
+	switch {
+	case event.Deployment != nil:
+		entry.ResourceType, entry.ResourceID = "deployment", &event.Deployment.ID
+		subject = fmt.Sprintf("%s to %s", event.Deployment.ServiceName, event.Deployment.Environment)
+	case event.Build != nil:
+		entry.ResourceType, entry.ResourceID = "build", &event.Build.ID
+		subject = event.Build.ServiceName
+	case event.Service != nil:
+		entry.ResourceType, entry.ResourceID = "service", &event.Service.ID
+		subject = event.Service.Name
+	case event.Database != nil:
+		entry.ResourceType, entry.ResourceID = "addon", &event.Database.ID
+		subject = event.Database.Name
+	case event.DeploymentGroup != nil:
+		entry.ResourceType, entry.ResourceID = "deployment_group", &event.DeploymentGroup.ID
+		subject = event.DeploymentGroup.Name
+	case event.Alert != nil:
+		entry.ResourceType, entry.ResourceID = "alert", &event.Alert.ID
+		subject = fmt.Sprintf("%s on %s", event.Alert.RuleName, event.Alert.ServiceName)
+	case event.Uptime != nil:
+		entry.ResourceType, entry.ResourceID = "uptime_check", &event.Uptime.CheckID
+		subject = event.Uptime.URL
+	case event.Secret != nil:
+		entry.ResourceType, entry.ResourceID = "env_var", &event.Secret.EnvVarID
+		subject = fmt.Sprintf("%s on %s", event.Secret.Key, event.Secret.ServiceName)
+	case event.Budget != nil:
+		entry.ResourceType = "budget"
+		subject = fmt.Sprintf("%d%% of %s", event.Budget.Threshold, event.Budget.Name)
+	}
+	if subject != "" {
+		entry.Message = title + ": " + subject
+	}
+	return entry
+}
+
+// eventData is the event-specific part of a webhook event, e.g. its
+// deployment, as stored in the event history
+func eventData(event *types.WebhookEvent) map[string]any {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil
+	}
+	// The ID is kept as event_id, which webhooks get as X-Enclii-Delivery
+	data["event_id"] = data["id"]
+	for _, key := range []string{"id", "type", "timestamp", "project_id", "project"} {
+		delete(data, key)
+	}
+	return data
+}
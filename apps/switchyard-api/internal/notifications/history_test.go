@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestProjectEvent(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s := NewService(nil, logger)
+
+	projectID := uuid.New()
+	deploymentID := uuid.New()
+	event := &types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      types.WebhookEventDeploymentSucceeded,
+		Timestamp: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+		Deployment: &types.WebhookDeploymentInfo{
+			ID:          deploymentID,
+			ServiceName: "api",
+			Environment: "production",
+			Status:      "running",
+		},
+	}
+
+	entry := s.projectEvent(projectID, event)
+
+	if entry.ProjectID != projectID || entry.Type != "deployment.succeeded" {
+		t.Errorf("got project %s type %q", entry.ProjectID, entry.Type)
+	}
+	if entry.ResourceType != "deployment" || entry.ResourceID == nil || *entry.ResourceID != deploymentID {
+		t.Errorf("got resource %q %v, want deployment %s", entry.ResourceType, entry.ResourceID, deploymentID)
+	}
+	if want := "Deployment Succeeded: api to production"; entry.Message != want {
+		t.Errorf("message = %q, want %q", entry.Message, want)
+	}
+	if !entry.CreatedAt.Equal(event.Timestamp) {
+		t.Errorf("created_at = %v, want %v", entry.CreatedAt, event.Timestamp)
+	}
+
+	if entry.Data["event_id"] != event.ID.String() {
+		t.Errorf("event_id = %v, want %s", entry.Data["event_id"], event.ID)
+	}
+	for _, key := range []string{"id", "type", "timestamp", "project_id", "project"} {
+		if _, ok := entry.Data[key]; ok {
+			t.Errorf("data keeps %q, which the event itself has", key)
+		}
+	}
+	if _, ok := entry.Data["deployment"].(map[string]any); !ok {
+		t.Errorf("data has no deployment: %v", entry.Data)
+	}
+}
+
+func TestRecordEventWithoutRepository(t *testing.T) {
+	s := NewService(nil, logrus.New())
+
+	// Recording is a no-op until an event repository is set
+	s.RecordEvent(t.Context(), &types.ProjectEvent{ProjectID: uuid.New(), Type: "env_var.changed"})
+	s.RecordTeamEvent(t.Context(), uuid.New(), &types.ProjectEvent{Type: "member.added"})
+}
@@ -2,6 +2,7 @@ package notifications
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -9,18 +10,32 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/jobs"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // Service handles notification webhook delivery
 type Service struct {
 	repo   *db.WebhookRepository
+	events *db.ProjectEventRepository // nil leaves events unrecorded
 	logger *logrus.Logger
 
 	// Senders for each webhook type
 	slack    *SlackSender
 	discord  *DiscordSender
 	telegram *TelegramSender
+
+	// jobRunner runs deliveries as background jobs; goroutines when nil
+	jobRunner *jobs.Runner
+}
+
+// webhookDeliveryJob is the background job that delivers an event to a webhook
+const webhookDeliveryJob = "notification.webhook"
+
+type webhookDeliveryPayload struct {
+	WebhookID uuid.UUID           `json:"webhook_id"`
+	Event     *types.WebhookEvent `json:"event"`
 }
 
 // NewService creates a new notification service
@@ -34,8 +49,18 @@ func NewService(repo *db.WebhookRepository, logger *logrus.Logger) *Service {
 	}
 }
 
-// SendEvent sends a webhook event to all subscribed destinations for a project
+// SetJobRunner delivers events as background jobs of a runner, so events
+// sent just before a restart still reach their webhooks
+func (s *Service) SetJobRunner(runner *jobs.Runner) {
+	s.jobRunner = runner
+	runner.Register(webhookDeliveryJob, s.runDeliveryJob)
+}
+
+// SendEvent records an event in the history of a project and sends it to
+// all subscribed destinations of the project
 func (s *Service) SendEvent(ctx context.Context, projectID uuid.UUID, event *types.WebhookEvent) error {
+	s.RecordEvent(ctx, s.projectEvent(projectID, event))
+
 	// Get all enabled webhooks subscribed to this event type
 	webhooks, err := s.repo.ListEnabledByEvent(ctx, projectID, event.Type)
 	if err != nil {
@@ -52,7 +77,7 @@ func (s *Service) SendEvent(ctx context.Context, projectID uuid.UUID, event *typ
 
 	// Send to each webhook asynchronously
 	for _, webhook := range webhooks {
-		go s.deliverToWebhook(context.Background(), webhook, event)
+		s.queueDelivery(ctx, webhook, event)
 	}
 
 	s.logger.WithFields(logrus.Fields{
@@ -64,16 +89,50 @@ func (s *Service) SendEvent(ctx context.Context, projectID uuid.UUID, event *typ
 	return nil
 }
 
-// deliverToWebhook sends an event to a single webhook destination
-func (s *Service) deliverToWebhook(ctx context.Context, webhook *types.WebhookDestination, event *types.WebhookEvent) {
-	logger := s.logger.WithFields(logrus.Fields{
-		"webhook_id":   webhook.ID,
-		"webhook_name": webhook.Name,
-		"webhook_type": webhook.Type,
-		"event_type":   event.Type,
-	})
+// queueDelivery queues the delivery of an event to a webhook as a background
+// job, so it survives restarts, or sends it in a goroutine without a runner
+func (s *Service) queueDelivery(ctx context.Context, webhook *types.WebhookDestination, event *types.WebhookEvent) {
+	if s.jobRunner != nil {
+		payload := webhookDeliveryPayload{WebhookID: webhook.ID, Event: event}
+		_, err := s.jobRunner.Enqueue(ctx, webhookDeliveryJob, payload)
+		if err == nil {
+			return
+		}
+		s.logger.WithField("webhook_id", webhook.ID).WithError(err).Warn("Failed to queue webhook delivery, sending in the background")
+	}
+	go s.deliverToWebhook(context.Background(), webhook, event)
+}
+
+// runDeliveryJob runs a queued webhook delivery. Once the delivery is
+// recorded, its retries are left to RetryDue.
+func (s *Service) runDeliveryJob(ctx context.Context, job *types.BackgroundJob) error {
+	var payload webhookDeliveryPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	if payload.Event == nil {
+		return jobs.Permanent(fmt.Errorf("webhook delivery job has no event"))
+	}
+
+	webhook, err := s.repo.GetByID(ctx, payload.WebhookID)
+	if err == sql.ErrNoRows {
+		return jobs.Permanent(fmt.Errorf("webhook %s not found", payload.WebhookID))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if !webhook.Enabled || webhook.AutoDisabledAt != nil {
+		// Disabled since the event was sent
+		return nil
+	}
 
-	// Create delivery record
+	return s.deliverToWebhook(ctx, webhook, payload.Event)
+}
+
+// deliverToWebhook sends an event to a single webhook destination. A failed
+// delivery is left retrying for RetryDue; only a delivery that could not be
+// recorded returns an error.
+func (s *Service) deliverToWebhook(ctx context.Context, webhook *types.WebhookDestination, event *types.WebhookEvent) error {
 	delivery := &types.WebhookDelivery{
 		WebhookID:     webhook.ID,
 		EventType:     event.Type,
@@ -84,49 +143,59 @@ func (s *Service) deliverToWebhook(ctx context.Context, webhook *types.WebhookDe
 	}
 
 	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
-		logger.WithError(err).Error("Failed to create delivery record")
-		return
+		s.logger.WithFields(logrus.Fields{
+			"webhook_id": webhook.ID,
+			"event_type": event.Type,
+		}).WithError(err).Error("Failed to create delivery record")
+		return fmt.Errorf("failed to create delivery record: %w", err)
 	}
 
-	// Send the webhook
+	s.attempt(ctx, webhook, delivery, event)
+	return nil
+}
+
+// attempt sends a delivery once and records the outcome: success, a retry
+// after backoff, or a dead letter once its attempts are used up
+func (s *Service) attempt(ctx context.Context, webhook *types.WebhookDestination, delivery *types.WebhookDelivery, event *types.WebhookEvent) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"webhook_id":   webhook.ID,
+		"webhook_name": webhook.Name,
+		"webhook_type": webhook.Type,
+		"event_type":   event.Type,
+		"delivery_id":  delivery.ID,
+		"attempt":      delivery.AttemptNumber,
+	})
+
 	startTime := time.Now()
-	var sendErr error
-	var statusCode int
+	statusCode, sendErr := s.send(ctx, webhook, event)
+	elapsed := time.Since(startTime)
 
-	switch webhook.Type {
-	case types.WebhookTypeSlack:
-		statusCode, sendErr = s.slack.Send(ctx, webhook.WebhookURL, event)
-	case types.WebhookTypeDiscord:
-		statusCode, sendErr = s.discord.Send(ctx, webhook.WebhookURL, event)
-	case types.WebhookTypeTelegram:
-		statusCode, sendErr = s.telegram.Send(ctx, webhook.TelegramBotToken, webhook.TelegramChatID, event)
-	case types.WebhookTypeCustom:
-		statusCode, sendErr = s.sendCustomWebhook(ctx, webhook, event)
-	default:
-		sendErr = fmt.Errorf("unsupported webhook type: %s", webhook.Type)
+	project := event.Project.Slug
+	if project == "" {
+		project = event.ProjectID.String()
 	}
+	monitoring.RecordWebhookDelivery(project, string(webhook.Type), string(event.Type), sendErr == nil, elapsed)
 
-	duration := int(time.Since(startTime).Milliseconds())
+	duration := int(elapsed.Milliseconds())
 	completedAt := time.Now()
-
-	// Update delivery record
+	delivery.AttemptedAt = startTime
 	delivery.DurationMs = &duration
 	delivery.CompletedAt = &completedAt
-	delivery.StatusCode = &statusCode
-
-	if sendErr != nil {
-		delivery.Status = types.WebhookDeliveryStatusFailed
-		delivery.ErrorMessage = sendErr.Error()
-		logger.WithError(sendErr).Error("Webhook delivery failed")
+	delivery.StatusCode = nil
+	if statusCode != 0 {
+		delivery.StatusCode = &statusCode
+	}
+	recordAttempt(delivery, statusCode, sendErr, completedAt)
 
-		// Update webhook failure tracking
-		s.repo.UpdateDeliveryStatus(ctx, webhook.ID, "failed", sendErr.Error(), true)
-	} else {
-		delivery.Status = types.WebhookDeliveryStatusSuccess
+	switch delivery.Status {
+	case types.WebhookDeliveryStatusSuccess:
 		logger.Info("Webhook delivery succeeded")
-
-		// Reset failure count on success
 		s.repo.UpdateDeliveryStatus(ctx, webhook.ID, "success", "", false)
+	case types.WebhookDeliveryStatusRetrying:
+		logger.WithError(sendErr).WithField("next_attempt_at", delivery.NextAttemptAt).Warn("Webhook delivery failed, will retry")
+	default:
+		logger.WithError(sendErr).Error("Webhook delivery failed, moved to dead letters")
+		s.repo.UpdateDeliveryStatus(ctx, webhook.ID, "failed", sendErr.Error(), true)
 	}
 
 	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
@@ -134,10 +203,26 @@ func (s *Service) deliverToWebhook(ctx context.Context, webhook *types.WebhookDe
 	}
 }
 
+// send delivers an event to a webhook in the format of its type
+func (s *Service) send(ctx context.Context, webhook *types.WebhookDestination, event *types.WebhookEvent) (int, error) {
+	switch webhook.Type {
+	case types.WebhookTypeSlack:
+		return s.slack.Send(ctx, webhook.WebhookURL, event)
+	case types.WebhookTypeDiscord:
+		return s.discord.Send(ctx, webhook.WebhookURL, event)
+	case types.WebhookTypeTelegram:
+		return s.telegram.Send(ctx, webhook.TelegramBotToken, webhook.TelegramChatID, event)
+	case types.WebhookTypeCustom:
+		return s.sendCustomWebhook(ctx, webhook, event)
+	default:
+		return 0, fmt.Errorf("unsupported webhook type: %s", webhook.Type)
+	}
+}
+
 // sendCustomWebhook sends to a custom webhook URL with optional headers
 func (s *Service) sendCustomWebhook(ctx context.Context, webhook *types.WebhookDestination, event *types.WebhookEvent) (int, error) {
-	// Custom webhooks use the same format as our internal events
-	// Could add signature verification here using webhook.SigningSecret
+	// Custom webhooks use the same format as our internal events, signed
+	// with the webhook's secret
 	sender := NewCustomSender(s.logger, webhook.CustomHeaders, webhook.SigningSecret)
 	return sender.Send(ctx, webhook.WebhookURL, event)
 }
@@ -158,7 +243,7 @@ func (s *Service) TestWebhook(ctx context.Context, webhook *types.WebhookDestina
 
 	// Add sample event data based on event type
 	switch {
-	case eventType == types.WebhookEventDeploymentSucceeded || eventType == types.WebhookEventDeploymentFailed:
+	case eventType == types.WebhookEventDeploymentSucceeded || eventType == types.WebhookEventDeploymentFailed || eventType == types.WebhookEventDeploymentStalled || eventType == types.WebhookEventDeploymentRolledBack:
 		testEvent.Deployment = &types.WebhookDeploymentInfo{
 			ID:            uuid.New(),
 			ServiceName:   "test-service",
@@ -169,7 +254,7 @@ func (s *Service) TestWebhook(ctx context.Context, webhook *types.WebhookDestina
 			Branch:        "main",
 			URL:           "https://test.example.com",
 		}
-	case eventType == types.WebhookEventBuildSucceeded || eventType == types.WebhookEventBuildFailed:
+	case eventType == types.WebhookEventBuildSucceeded || eventType == types.WebhookEventBuildFailed || eventType == types.WebhookEventBuildStalled:
 		testEvent.Build = &types.WebhookBuildInfo{
 			ID:          uuid.New(),
 			ServiceName: "test-service",
@@ -177,90 +262,99 @@ func (s *Service) TestWebhook(ctx context.Context, webhook *types.WebhookDestina
 			CommitSHA:   "abc123def",
 			ImageTag:    "v1.0.0",
 		}
+	case eventType == types.WebhookEventDeploymentGroupReminder:
+		scheduledAt := time.Now().Add(15 * time.Minute)
+		testEvent.DeploymentGroup = &types.WebhookDeploymentGroupInfo{
+			ID:          uuid.New(),
+			Name:        "deploy-test-project-20260101-020000",
+			Environment: "production",
+			Strategy:    "dependency_ordered",
+			Status:      "scheduled",
+			ScheduledAt: &scheduledAt,
+			ScheduledBy: "test@example.com",
+			CommitSHA:   "abc123def",
+		}
+	case eventType == types.WebhookEventBudgetThreshold || eventType == types.WebhookEventBudgetCapExceeded:
+		testEvent.Budget = &types.WebhookBudgetInfo{
+			Scope:       "project",
+			Name:        "Test Project",
+			Threshold:   80,
+			Spend:       80,
+			Limit:       100,
+			Currency:    "USD",
+			PeriodStart: time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC),
+		}
+		if eventType == types.WebhookEventBudgetCapExceeded {
+			testEvent.Budget.Threshold = 100
+			testEvent.Budget.Spend = 150
+			testEvent.Budget.Limit = 150
+			testEvent.Budget.ScaledToZero = []string{"test-service"}
+		}
+	case eventType == types.WebhookEventAlertFiring || eventType == types.WebhookEventAlertResolved:
+		value := 4.0
+		testEvent.Alert = &types.WebhookAlertInfo{
+			ID:          uuid.New(),
+			RuleID:      uuid.New(),
+			RuleName:    "Too many restarts",
+			Kind:        string(types.AlertRuleRestartCount),
+			ServiceName: "test-service",
+			Environment: "production",
+			Summary:     "Containers restarted 4 times, more than 3",
+			Value:       &value,
+			Threshold:   3,
+			StartedAt:   time.Now().Add(-10 * time.Minute),
+		}
+		if eventType == types.WebhookEventAlertResolved {
+			resolvedAt := time.Now()
+			testEvent.Alert.ResolvedAt = &resolvedAt
+		}
+	case eventType == types.WebhookEventUptimeDown || eventType == types.WebhookEventUptimeRecovered:
+		statusCode := 503
+		testEvent.Uptime = &types.WebhookUptimeInfo{
+			CheckID:     uuid.New(),
+			ServiceName: "test-service",
+			Environment: "production",
+			URL:         "https://test-service.example.com/healthz",
+			StatusCode:  &statusCode,
+			Error:       "expected status 200, got 503",
+			DownSince:   time.Now().Add(-10 * time.Minute),
+		}
+		if eventType == types.WebhookEventUptimeRecovered {
+			statusCode = 200
+			testEvent.Uptime.Error = ""
+			testEvent.Uptime.DownSeconds = 600
+		}
+	case eventType == types.WebhookEventSecretRotationDue:
+		testEvent.Secret = &types.WebhookSecretInfo{
+			EnvVarID:    uuid.New(),
+			ServiceName: "test-service",
+			Environment: "production",
+			Key:         "STRIPE_API_KEY",
+			AgeDays:     95,
+			MaxAgeDays:  90,
+			ValueSince:  time.Now().AddDate(0, 0, -95),
+		}
 	}
 
-	var err error
-	switch webhook.Type {
-	case types.WebhookTypeSlack:
-		_, err = s.slack.Send(ctx, webhook.WebhookURL, testEvent)
-	case types.WebhookTypeDiscord:
-		_, err = s.discord.Send(ctx, webhook.WebhookURL, testEvent)
-	case types.WebhookTypeTelegram:
-		_, err = s.telegram.Send(ctx, webhook.TelegramBotToken, webhook.TelegramChatID, testEvent)
-	case types.WebhookTypeCustom:
-		_, err = s.sendCustomWebhook(ctx, webhook, testEvent)
-	default:
-		err = fmt.Errorf("unsupported webhook type: %s", webhook.Type)
-	}
-
+	_, err := s.send(ctx, webhook, testEvent)
 	return err
 }
 
-// RetryDelivery retries a failed webhook delivery
-func (s *Service) RetryDelivery(ctx context.Context, webhook *types.WebhookDestination, delivery *types.WebhookDelivery) error {
-	// Reconstruct the event from the delivery payload
-	event := &types.WebhookEvent{
-		Type:      delivery.EventType,
-		Timestamp: delivery.AttemptedAt,
-		Project: types.WebhookProjectInfo{
-			ID: webhook.ProjectID,
-		},
-	}
-
-	// Increment attempt number
-	delivery.AttemptNumber++
-
-	var sendErr error
-	var statusCode int
-	startTime := time.Now()
-
-	switch webhook.Type {
-	case types.WebhookTypeSlack:
-		statusCode, sendErr = s.slack.Send(ctx, webhook.WebhookURL, event)
-	case types.WebhookTypeDiscord:
-		statusCode, sendErr = s.discord.Send(ctx, webhook.WebhookURL, event)
-	case types.WebhookTypeTelegram:
-		statusCode, sendErr = s.telegram.Send(ctx, webhook.TelegramBotToken, webhook.TelegramChatID, event)
-	case types.WebhookTypeCustom:
-		statusCode, sendErr = s.sendCustomWebhook(ctx, webhook, event)
-	default:
-		return fmt.Errorf("unsupported webhook type: %s", webhook.Type)
-	}
-
-	duration := int(time.Since(startTime).Milliseconds())
-	completedAt := time.Now()
-
-	delivery.DurationMs = &duration
-	delivery.CompletedAt = &completedAt
-	delivery.StatusCode = &statusCode
-
-	if sendErr != nil {
-		delivery.Status = types.WebhookDeliveryStatusFailed
-		delivery.ErrorMessage = sendErr.Error()
-		s.repo.UpdateDeliveryStatus(ctx, webhook.ID, "failed", sendErr.Error(), true)
-	} else {
-		delivery.Status = types.WebhookDeliveryStatusSuccess
-		delivery.ErrorMessage = ""
-		s.repo.UpdateDeliveryStatus(ctx, webhook.ID, "success", "", false)
-	}
-
-	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
-		s.logger.WithError(err).Error("Failed to update delivery record after retry")
-	}
-
-	return sendErr
-}
-
 // Helper to convert event to generic payload map
 func eventToPayload(event *types.WebhookEvent) map[string]any {
 	return map[string]any{
-		"id":         event.ID,
-		"type":       event.Type,
-		"timestamp":  event.Timestamp,
-		"project":    event.Project,
-		"deployment": event.Deployment,
-		"build":      event.Build,
-		"service":    event.Service,
-		"database":   event.Database,
+		"id":               event.ID,
+		"type":             event.Type,
+		"timestamp":        event.Timestamp,
+		"project":          event.Project,
+		"deployment":       event.Deployment,
+		"build":            event.Build,
+		"service":          event.Service,
+		"database":         event.Database,
+		"deployment_group": event.DeploymentGroup,
+		"budget":           event.Budget,
+		"alert":            event.Alert,
+		"uptime":           event.Uptime,
+		"secret":           event.Secret,
 	}
 }
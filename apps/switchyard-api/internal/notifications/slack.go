@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -129,6 +130,21 @@ func (s *SlackSender) buildMessage(event *types.WebhookEvent) *SlackMessage {
 	if event.Database != nil {
 		blocks = append(blocks, s.buildDatabaseBlocks(event.Database)...)
 	}
+	if event.DeploymentGroup != nil {
+		blocks = append(blocks, s.buildDeploymentGroupBlocks(event.DeploymentGroup)...)
+	}
+	if event.Budget != nil {
+		blocks = append(blocks, s.buildBudgetBlocks(event.Budget)...)
+	}
+	if event.Alert != nil {
+		blocks = append(blocks, s.buildAlertBlocks(event.Alert)...)
+	}
+	if event.Uptime != nil {
+		blocks = append(blocks, s.buildUptimeBlocks(event.Uptime)...)
+	}
+	if event.Secret != nil {
+		blocks = append(blocks, s.buildSecretBlocks(event.Secret)...)
+	}
 
 	// Add timestamp context
 	blocks = append(blocks, SlackBlock{
@@ -159,12 +175,20 @@ func (s *SlackSender) getEventMeta(eventType types.WebhookEventType) (emoji, col
 		return "❌", "#dc3545", "Deployment Failed"
 	case types.WebhookEventDeploymentCancelled:
 		return "⏹️", "#6c757d", "Deployment Cancelled"
+	case types.WebhookEventDeploymentStalled:
+		return "⏳", "#ffc107", "Deployment Stalled"
+	case types.WebhookEventDeploymentRolledBack:
+		return "⏪", "#dc3545", "Deployment Rolled Back"
+	case types.WebhookEventDeploymentGroupReminder:
+		return "⏰", "#3AA3E3", "Scheduled Deployment Upcoming"
 	case types.WebhookEventBuildStarted:
 		return "🔨", "#3AA3E3", "Build Started"
 	case types.WebhookEventBuildSucceeded:
 		return "✅", "#36a64f", "Build Succeeded"
 	case types.WebhookEventBuildFailed:
 		return "❌", "#dc3545", "Build Failed"
+	case types.WebhookEventBuildStalled:
+		return "⏳", "#ffc107", "Build Stalled"
 	case types.WebhookEventServiceCreated:
 		return "➕", "#36a64f", "Service Created"
 	case types.WebhookEventServiceDeleted:
@@ -179,6 +203,20 @@ func (s *SlackSender) getEventMeta(eventType types.WebhookEventType) (emoji, col
 		return "🗄️", "#36a64f", "Database Ready"
 	case types.WebhookEventDatabaseFailed:
 		return "❌", "#dc3545", "Database Failed"
+	case types.WebhookEventBudgetThreshold:
+		return "💰", "#ffc107", "Budget Threshold Reached"
+	case types.WebhookEventBudgetCapExceeded:
+		return "🛑", "#dc3545", "Budget Hard Cap Exceeded"
+	case types.WebhookEventAlertFiring:
+		return "🚨", "#dc3545", "Alert Firing"
+	case types.WebhookEventAlertResolved:
+		return "✅", "#36a64f", "Alert Resolved"
+	case types.WebhookEventUptimeDown:
+		return "🔻", "#dc3545", "Endpoint Down"
+	case types.WebhookEventUptimeRecovered:
+		return "✅", "#36a64f", "Endpoint Recovered"
+	case types.WebhookEventSecretRotationDue:
+		return "🔑", "#ffc107", "Secret Rotation Due"
 	default:
 		return "📢", "#6c757d", string(eventType)
 	}
@@ -299,3 +337,90 @@ func (s *SlackSender) buildDatabaseBlocks(db *types.WebhookDatabaseInfo) []Slack
 
 	return blocks
 }
+
+func (s *SlackSender) buildDeploymentGroupBlocks(g *types.WebhookDeploymentGroupInfo) []SlackBlock {
+	fields := []SlackTextBlock{
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Deployment Group:*\n%s", g.Name)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Environment:*\n%s", g.Environment)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Strategy:*\n%s", g.Strategy)},
+	}
+
+	if g.ScheduledAt != nil {
+		fields = append(fields, SlackTextBlock{Type: "mrkdwn", Text: fmt.Sprintf("*Scheduled For:*\n%s", g.ScheduledAt.UTC().Format(time.RFC822))})
+	}
+	if g.ScheduledBy != "" {
+		fields = append(fields, SlackTextBlock{Type: "mrkdwn", Text: fmt.Sprintf("*Scheduled By:*\n%s", g.ScheduledBy)})
+	}
+	if len(g.CommitSHA) >= 7 {
+		fields = append(fields, SlackTextBlock{Type: "mrkdwn", Text: fmt.Sprintf("*Commit:*\n`%s`", g.CommitSHA[:7])})
+	}
+
+	return []SlackBlock{
+		{Type: "section", Fields: fields},
+	}
+}
+
+func (s *SlackSender) buildBudgetBlocks(b *types.WebhookBudgetInfo) []SlackBlock {
+	fields := []SlackTextBlock{
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Budget:*\n%s (%s)", b.Name, b.Scope)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Used:*\n%d%%", b.Threshold)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Spend:*\n%.2f %s", b.Spend, b.Currency)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Limit:*\n%.2f %s", b.Limit, b.Currency)},
+	}
+
+	if len(b.ScaledToZero) > 0 {
+		fields = append(fields, SlackTextBlock{Type: "mrkdwn", Text: fmt.Sprintf("*Stopped:*\n%s", strings.Join(b.ScaledToZero, ", "))})
+	}
+
+	return []SlackBlock{
+		{Type: "section", Fields: fields},
+	}
+}
+
+func (s *SlackSender) buildAlertBlocks(a *types.WebhookAlertInfo) []SlackBlock {
+	fields := []SlackTextBlock{
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Alert:*\n%s", a.RuleName)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Service:*\n%s", a.ServiceName)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Environment:*\n%s", a.Environment)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Since:*\n%s", a.StartedAt.UTC().Format("Jan 2 15:04 MST"))},
+	}
+
+	if a.ResolvedAt != nil {
+		fields = append(fields, SlackTextBlock{Type: "mrkdwn", Text: fmt.Sprintf("*Lasted:*\n%s", a.ResolvedAt.Sub(a.StartedAt).Round(time.Minute))})
+	}
+
+	return []SlackBlock{
+		{Type: "section", Text: &SlackTextBlock{Type: "mrkdwn", Text: a.Summary}},
+		{Type: "section", Fields: fields},
+	}
+}
+
+func (s *SlackSender) buildUptimeBlocks(u *types.WebhookUptimeInfo) []SlackBlock {
+	fields := []SlackTextBlock{
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Service:*\n%s", u.ServiceName)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Environment:*\n%s", u.Environment)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*URL:*\n%s", u.URL)},
+		{Type: "mrkdwn", Text: fmt.Sprintf("*Down since:*\n%s", u.DownSince.UTC().Format("Jan 2 15:04 MST"))},
+	}
+
+	if u.DownSeconds > 0 {
+		fields = append(fields, SlackTextBlock{Type: "mrkdwn", Text: fmt.Sprintf("*Downtime:*\n%s", time.Duration(u.DownSeconds)*time.Second)})
+	} else if u.Error != "" {
+		fields = append(fields, SlackTextBlock{Type: "mrkdwn", Text: fmt.Sprintf("*Error:*\n%s", u.Error)})
+	}
+
+	return []SlackBlock{
+		{Type: "section", Fields: fields},
+	}
+}
+
+func (s *SlackSender) buildSecretBlocks(sec *types.WebhookSecretInfo) []SlackBlock {
+	return []SlackBlock{
+		{Type: "section", Fields: []SlackTextBlock{
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Service:*\n%s", sec.ServiceName)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Environment:*\n%s", sec.Environment)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Secret:*\n`%s`", sec.Key)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Age:*\n%d days (max %d)", sec.AgeDays, sec.MaxAgeDays)},
+		}},
+	}
+}
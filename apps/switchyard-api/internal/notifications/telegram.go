@@ -123,6 +123,21 @@ func (t *TelegramSender) buildMessage(chatID string, event *types.WebhookEvent)
 	if event.Database != nil {
 		t.appendDatabaseDetails(&sb, event.Database)
 	}
+	if event.DeploymentGroup != nil {
+		t.appendDeploymentGroupDetails(&sb, event.DeploymentGroup)
+	}
+	if event.Budget != nil {
+		t.appendBudgetDetails(&sb, event.Budget)
+	}
+	if event.Alert != nil {
+		t.appendAlertDetails(&sb, event.Alert)
+	}
+	if event.Uptime != nil {
+		t.appendUptimeDetails(&sb, event.Uptime)
+	}
+	if event.Secret != nil {
+		t.appendSecretDetails(&sb, event.Secret)
+	}
 
 	sb.WriteString(fmt.Sprintf("\n⏱ %s", event.Timestamp.Format("Jan 2, 2006 15:04 MST")))
 
@@ -168,12 +183,20 @@ func (t *TelegramSender) getEventMeta(eventType types.WebhookEventType) (emoji,
 		return "❌", "Deployment Failed"
 	case types.WebhookEventDeploymentCancelled:
 		return "⏹", "Deployment Cancelled"
+	case types.WebhookEventDeploymentStalled:
+		return "⏳", "Deployment Stalled"
+	case types.WebhookEventDeploymentRolledBack:
+		return "⏪", "Deployment Rolled Back"
+	case types.WebhookEventDeploymentGroupReminder:
+		return "⏰", "Scheduled Deployment Upcoming"
 	case types.WebhookEventBuildStarted:
 		return "🔨", "Build Started"
 	case types.WebhookEventBuildSucceeded:
 		return "✅", "Build Succeeded"
 	case types.WebhookEventBuildFailed:
 		return "❌", "Build Failed"
+	case types.WebhookEventBuildStalled:
+		return "⏳", "Build Stalled"
 	case types.WebhookEventServiceCreated:
 		return "➕", "Service Created"
 	case types.WebhookEventServiceDeleted:
@@ -188,6 +211,20 @@ func (t *TelegramSender) getEventMeta(eventType types.WebhookEventType) (emoji,
 		return "🗄", "Database Ready"
 	case types.WebhookEventDatabaseFailed:
 		return "❌", "Database Failed"
+	case types.WebhookEventBudgetThreshold:
+		return "💰", "Budget Threshold Reached"
+	case types.WebhookEventBudgetCapExceeded:
+		return "🛑", "Budget Hard Cap Exceeded"
+	case types.WebhookEventAlertFiring:
+		return "🚨", "Alert Firing"
+	case types.WebhookEventAlertResolved:
+		return "✅", "Alert Resolved"
+	case types.WebhookEventUptimeDown:
+		return "🔻", "Endpoint Down"
+	case types.WebhookEventUptimeRecovered:
+		return "✅", "Endpoint Recovered"
+	case types.WebhookEventSecretRotationDue:
+		return "🔑", "Secret Rotation Due"
 	default:
 		return "📢", string(eventType)
 	}
@@ -245,6 +282,62 @@ func (t *TelegramSender) appendDatabaseDetails(sb *strings.Builder, db *types.We
 	}
 }
 
+func (t *TelegramSender) appendDeploymentGroupDetails(sb *strings.Builder, g *types.WebhookDeploymentGroupInfo) {
+	sb.WriteString(fmt.Sprintf("📦 *Deployment Group:* %s\n", escapeMarkdown(g.Name)))
+	sb.WriteString(fmt.Sprintf("🌍 *Environment:* %s\n", escapeMarkdown(g.Environment)))
+	sb.WriteString(fmt.Sprintf("🔀 *Strategy:* %s\n", escapeMarkdown(g.Strategy)))
+
+	if g.ScheduledAt != nil {
+		sb.WriteString(fmt.Sprintf("⏰ *Scheduled For:* %s\n", escapeMarkdown(g.ScheduledAt.UTC().Format("Jan 2, 2006 15:04 MST"))))
+	}
+	if g.ScheduledBy != "" {
+		sb.WriteString(fmt.Sprintf("👤 *Scheduled By:* %s\n", escapeMarkdown(g.ScheduledBy)))
+	}
+	if len(g.CommitSHA) >= 7 {
+		sb.WriteString(fmt.Sprintf("📝 *Commit:* `%s`\n", escapeMarkdown(g.CommitSHA[:7])))
+	}
+}
+
+func (t *TelegramSender) appendBudgetDetails(sb *strings.Builder, b *types.WebhookBudgetInfo) {
+	sb.WriteString(fmt.Sprintf("💰 *Budget:* %s \\(%s\\)\n", escapeMarkdown(b.Name), escapeMarkdown(b.Scope)))
+	sb.WriteString(fmt.Sprintf("📊 *Used:* %d%%\n", b.Threshold))
+	sb.WriteString(fmt.Sprintf("💵 *Spend:* %s\n", escapeMarkdown(fmt.Sprintf("%.2f / %.2f %s", b.Spend, b.Limit, b.Currency))))
+
+	if len(b.ScaledToZero) > 0 {
+		sb.WriteString(fmt.Sprintf("🛑 *Stopped:* %s\n", escapeMarkdown(strings.Join(b.ScaledToZero, ", "))))
+	}
+}
+
+func (t *TelegramSender) appendAlertDetails(sb *strings.Builder, a *types.WebhookAlertInfo) {
+	sb.WriteString(fmt.Sprintf("🚨 *Alert:* %s\n", escapeMarkdown(a.RuleName)))
+	sb.WriteString(fmt.Sprintf("🔧 *Service:* %s\n", escapeMarkdown(a.ServiceName)))
+	sb.WriteString(fmt.Sprintf("🌍 *Environment:* %s\n", escapeMarkdown(a.Environment)))
+	sb.WriteString(fmt.Sprintf("📝 %s\n", escapeMarkdown(a.Summary)))
+
+	if a.ResolvedAt != nil {
+		sb.WriteString(fmt.Sprintf("⏳ *Lasted:* %s\n", escapeMarkdown(a.ResolvedAt.Sub(a.StartedAt).Round(time.Minute).String())))
+	}
+}
+
+func (t *TelegramSender) appendUptimeDetails(sb *strings.Builder, u *types.WebhookUptimeInfo) {
+	sb.WriteString(fmt.Sprintf("🔧 *Service:* %s\n", escapeMarkdown(u.ServiceName)))
+	sb.WriteString(fmt.Sprintf("🌍 *Environment:* %s\n", escapeMarkdown(u.Environment)))
+	sb.WriteString(fmt.Sprintf("🔗 *URL:* %s\n", escapeMarkdown(u.URL)))
+
+	if u.DownSeconds > 0 {
+		sb.WriteString(fmt.Sprintf("⏳ *Downtime:* %s\n", escapeMarkdown((time.Duration(u.DownSeconds) * time.Second).String())))
+	} else if u.Error != "" {
+		sb.WriteString(fmt.Sprintf("❌ *Error:* %s\n", escapeMarkdown(u.Error)))
+	}
+}
+
+func (t *TelegramSender) appendSecretDetails(sb *strings.Builder, s *types.WebhookSecretInfo) {
+	sb.WriteString(fmt.Sprintf("🔧 *Service:* %s\n", escapeMarkdown(s.ServiceName)))
+	sb.WriteString(fmt.Sprintf("🌍 *Environment:* %s\n", escapeMarkdown(s.Environment)))
+	sb.WriteString(fmt.Sprintf("🔑 *Secret:* %s\n", escapeMarkdown(s.Key)))
+	sb.WriteString(fmt.Sprintf("⏳ *Age:* %s\n", escapeMarkdown(fmt.Sprintf("%d days (max %d)", s.AgeDays, s.MaxAgeDays))))
+}
+
 // escapeMarkdown escapes special characters for Telegram MarkdownV2
 func escapeMarkdown(s string) string {
 	// MarkdownV2 requires escaping these characters: _ * [ ] ( ) ~ ` > # + - = | { } . !
@@ -0,0 +1,161 @@
+package notifications
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	webhookMaxAttempts    = 6
+	webhookRetryBaseDelay = 30 * time.Second // 30s, 1m, 2m, 4m, 8m between attempts
+	webhookRetryBatch     = 50
+
+	// webhookClaimLease outlasts an attempt, so a claimed delivery is only
+	// picked up again if the instance that claimed it went away
+	webhookClaimLease = 5 * time.Minute
+
+	// WebhookRetryInterval is how often due webhook retries are looked for
+	WebhookRetryInterval = 15 * time.Second
+
+	signingSecretPrefix = "whsec_"
+)
+
+// GenerateSigningSecret creates a random secret for signing the payloads of
+// a custom webhook
+func GenerateSigningSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate signing secret: %w", err)
+	}
+	return signingSecretPrefix + hex.EncodeToString(bytes), nil
+}
+
+// webhookRetryDelay is the wait after a delivery's attempt-th failed attempt
+func webhookRetryDelay(attempt int) time.Duration {
+	return webhookRetryBaseDelay << (attempt - 1)
+}
+
+// retryableStatus reports whether a failed attempt that got statusCode back
+// may succeed later. Other client errors mean the receiver rejected the
+// payload, which sending it again will not change.
+func retryableStatus(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// recordAttempt sets the status of a delivery after an attempt that ended
+// at now
+func recordAttempt(delivery *types.WebhookDelivery, statusCode int, sendErr error, now time.Time) {
+	delivery.NextAttemptAt = nil
+	switch {
+	case sendErr == nil:
+		delivery.Status = types.WebhookDeliveryStatusSuccess
+		delivery.ErrorMessage = ""
+	case retryableStatus(statusCode) && delivery.AttemptNumber < webhookMaxAttempts:
+		next := now.Add(webhookRetryDelay(delivery.AttemptNumber))
+		delivery.Status = types.WebhookDeliveryStatusRetrying
+		delivery.ErrorMessage = sendErr.Error()
+		delivery.NextAttemptAt = &next
+	default:
+		delivery.Status = types.WebhookDeliveryStatusDead
+		delivery.ErrorMessage = sendErr.Error()
+	}
+}
+
+// RetryDue attempts the retrying deliveries whose next attempt is due.
+// Deliveries to webhooks that were disabled meanwhile are dead-lettered.
+func (s *Service) RetryDue(ctx context.Context) error {
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, webhookRetryBatch, webhookClaimLease)
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		logger := s.logger.WithFields(logrus.Fields{
+			"webhook_id":  delivery.WebhookID,
+			"delivery_id": delivery.ID,
+		})
+
+		webhook, err := s.repo.GetByID(ctx, delivery.WebhookID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to get webhook for retry")
+			continue
+		}
+		if !webhook.Enabled || webhook.AutoDisabledAt != nil {
+			delivery.Status = types.WebhookDeliveryStatusDead
+			delivery.ErrorMessage = "webhook is disabled"
+			delivery.NextAttemptAt = nil
+			if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+				logger.WithError(err).Error("Failed to update delivery record")
+			}
+			continue
+		}
+
+		event, err := eventFromPayload(delivery.Payload)
+		if err != nil {
+			logger.WithError(err).Error("Failed to read stored webhook payload")
+			continue
+		}
+		event.ProjectID = webhook.ProjectID
+
+		delivery.AttemptNumber++
+		s.attempt(ctx, webhook, delivery, event)
+	}
+	return nil
+}
+
+// ReplayDelivery sends the stored payload of a delivery again as a new
+// delivery, which is retried like any other if it fails
+func (s *Service) ReplayDelivery(ctx context.Context, webhook *types.WebhookDestination, original *types.WebhookDelivery) (*types.WebhookDelivery, error) {
+	if !webhook.Enabled || webhook.AutoDisabledAt != nil {
+		return nil, errors.New("webhook is disabled")
+	}
+
+	event, err := eventFromPayload(original.Payload)
+	if err != nil {
+		return nil, err
+	}
+	event.ProjectID = webhook.ProjectID
+
+	delivery := &types.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventType:     original.EventType,
+		EventID:       original.EventID,
+		Payload:       original.Payload,
+		Status:        types.WebhookDeliveryStatusPending,
+		AttemptNumber: 1,
+		ReplayOf:      &original.ID,
+	}
+	if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to create delivery record: %w", err)
+	}
+
+	s.attempt(ctx, webhook, delivery, event)
+	return delivery, nil
+}
+
+// eventFromPayload rebuilds the event a delivery payload was made from
+func eventFromPayload(payload map[string]any) (*types.WebhookEvent, error) {
+	if len(payload) == 0 {
+		return nil, errors.New("delivery has no stored payload")
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	event := &types.WebhookEvent{}
+	if err := json.Unmarshal(raw, event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return event, nil
+}
@@ -0,0 +1,135 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestRecordAttempt(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	failure := errors.New("webhook returned status 503")
+
+	tests := []struct {
+		name       string
+		attempt    int
+		statusCode int
+		err        error
+		wantStatus types.WebhookDeliveryStatus
+		wantNext   time.Duration
+	}{
+		{"success", 1, http.StatusOK, nil, types.WebhookDeliveryStatusSuccess, 0},
+		{"server error retries after base delay", 1, http.StatusServiceUnavailable, failure, types.WebhookDeliveryStatusRetrying, 30 * time.Second},
+		{"backoff doubles", 3, http.StatusBadGateway, failure, types.WebhookDeliveryStatusRetrying, 2 * time.Minute},
+		{"network error retries", 2, 0, failure, types.WebhookDeliveryStatusRetrying, time.Minute},
+		{"rate limited retries", 1, http.StatusTooManyRequests, failure, types.WebhookDeliveryStatusRetrying, 30 * time.Second},
+		{"client error is dead at once", 1, http.StatusBadRequest, failure, types.WebhookDeliveryStatusDead, 0},
+		{"dead after last attempt", webhookMaxAttempts, http.StatusServiceUnavailable, failure, types.WebhookDeliveryStatusDead, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := &types.WebhookDelivery{AttemptNumber: tt.attempt, ErrorMessage: "previous"}
+			recordAttempt(delivery, tt.statusCode, tt.err, now)
+
+			if delivery.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", delivery.Status, tt.wantStatus)
+			}
+			if tt.wantNext == 0 {
+				if delivery.NextAttemptAt != nil {
+					t.Errorf("NextAttemptAt = %v, want nil", delivery.NextAttemptAt)
+				}
+			} else if delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(now.Add(tt.wantNext)) {
+				t.Errorf("NextAttemptAt = %v, want %v", delivery.NextAttemptAt, now.Add(tt.wantNext))
+			}
+			if tt.err == nil && delivery.ErrorMessage != "" {
+				t.Errorf("ErrorMessage = %q, want it cleared", delivery.ErrorMessage)
+			}
+			if tt.err != nil && delivery.ErrorMessage != tt.err.Error() {
+				t.Errorf("ErrorMessage = %q, want %q", delivery.ErrorMessage, tt.err.Error())
+			}
+		})
+	}
+}
+
+func TestEventFromPayload_RoundTrip(t *testing.T) {
+	event := &types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      types.WebhookEventDeploymentFailed,
+		Timestamp: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+		Project:   types.WebhookProjectInfo{Name: "Shop", Slug: "shop"},
+		Deployment: &types.WebhookDeploymentInfo{
+			ServiceName: "api",
+			Environment: "production",
+		},
+	}
+
+	got, err := eventFromPayload(eventToPayload(event))
+	if err != nil {
+		t.Fatalf("eventFromPayload() error = %v", err)
+	}
+	if got.ID != event.ID || got.Type != event.Type || !got.Timestamp.Equal(event.Timestamp) {
+		t.Errorf("event = %+v, want %+v", got, event)
+	}
+	if got.Project.Slug != "shop" || got.Deployment == nil || got.Deployment.ServiceName != "api" {
+		t.Errorf("event data = %+v / %+v, want project shop and service api", got.Project, got.Deployment)
+	}
+
+	if _, err := eventFromPayload(nil); err == nil {
+		t.Error("eventFromPayload(nil) error = nil, want an error")
+	}
+}
+
+func TestGenerateSigningSecret(t *testing.T) {
+	a, err := GenerateSigningSecret()
+	if err != nil {
+		t.Fatalf("GenerateSigningSecret() error = %v", err)
+	}
+	b, _ := GenerateSigningSecret()
+
+	if !strings.HasPrefix(a, signingSecretPrefix) || len(a) != len(signingSecretPrefix)+64 {
+		t.Errorf("secret = %q, want %s followed by 64 hex characters", a, signingSecretPrefix)
+	}
+	if a == b {
+		t.Error("two generated secrets are equal")
+	}
+}
+
+func TestCustomSender_SignsPayload(t *testing.T) {
+	const secret = "whsec_test"
+	var body []byte
+	var signature, signature256 string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get("X-Enclii-Signature")
+		signature256 = r.Header.Get("X-Enclii-Signature-256")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := NewCustomSender(logrus.New(), nil, secret)
+	event := &types.WebhookEvent{ID: uuid.New(), Type: types.WebhookEventBuildSucceeded, Timestamp: time.Now()}
+	if _, err := sender.Send(context.Background(), server.URL, event); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if !VerifySignature(body, signature, secret) {
+		t.Errorf("signature %q does not verify against the body", signature)
+	}
+	if signature256 != "sha256="+signature {
+		t.Errorf("X-Enclii-Signature-256 = %q, want sha256=%s", signature256, signature)
+	}
+	if VerifySignature(body, signature, "whsec_other") {
+		t.Error("signature verifies with the wrong secret")
+	}
+}
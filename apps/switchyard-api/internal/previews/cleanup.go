@@ -0,0 +1,391 @@
+// Package previews manages the resources of preview environments beyond the
+// deployment itself: their ephemeral databases, and the teardown of closed
+// previews once they have outlived their project's TTL (the preview namespace
+// and ingress, database, the releases built for the pull request, and their
+// container images).
+package previews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/registry"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// DefaultTTLDays is how long closed previews are kept when neither the
+	// platform nor the project configures a TTL
+	DefaultTTLDays = 7
+	// MaxTTLDays is the longest TTL a project can set
+	MaxTTLDays = 365
+	// CheckInterval is how often closed previews are checked against their TTL
+	CheckInterval = 30 * time.Minute
+)
+
+// WorkloadDeleter removes a service's deployment, service and volumes from a namespace
+type WorkloadDeleter interface {
+	Delete(ctx context.Context, namespace, serviceName string) error
+}
+
+// ImageDeleter removes a container image from its registry
+type ImageDeleter interface {
+	DeleteImage(ctx context.Context, image string) error
+}
+
+// credentialedImageDeleter is an ImageDeleter that can authenticate with a
+// project's own registry credentials
+type credentialedImageDeleter interface {
+	ImageDeleter
+	WithCredentials(auths []db.RegistryAuth) *RegistryClient
+}
+
+// NamespacePrefix starts the Kubernetes namespace of every preview
+const NamespacePrefix = "enclii-preview-"
+
+// Namespace returns the Kubernetes namespace a preview is deployed to
+func Namespace(preview *types.PreviewEnvironment) string {
+	return NamespacePrefix + preview.PreviewSubdomain
+}
+
+// PlannedRelease is a preview release a teardown will delete
+type PlannedRelease struct {
+	ID             uuid.UUID `json:"id"`
+	Version        string    `json:"version"`
+	ImageURI       string    `json:"image_uri"`
+	ImageSizeBytes *int64    `json:"image_size_bytes,omitempty"`
+}
+
+// Plan describes what tearing down a closed preview removes
+type Plan struct {
+	Preview    *types.PreviewEnvironment `json:"preview"`
+	Namespace  string                    `json:"namespace"`
+	TTLDays    int                       `json:"ttl_days"`
+	ExpiredAt  time.Time                 `json:"expired_at"`
+	Releases   []PlannedRelease          `json:"releases"`
+	ImageBytes int64                     `json:"image_bytes"` // Known size of the images; builds without a recorded size are not counted
+
+	DatabaseAddonID *uuid.UUID `json:"database_addon_id,omitempty"` // Ephemeral database of the preview
+}
+
+// Result is what a teardown removed
+type Result struct {
+	PreviewID  uuid.UUID `json:"preview_id"`
+	Namespaces int       `json:"namespaces"`
+	Ingresses  int       `json:"ingresses"`
+	Databases  int       `json:"databases"`
+	Releases   int       `json:"releases"`
+	Images     int       `json:"images"`
+	ImageBytes int64     `json:"image_bytes"`
+	Errors     []string  `json:"errors,omitempty"`
+}
+
+// Cleaner tears down closed previews
+type Cleaner struct {
+	repos          *db.Repositories
+	kube           kubernetes.Interface
+	workloads      WorkloadDeleter
+	images         ImageDeleter
+	databases      *Databases
+	defaultTTLDays int
+	logger         *logrus.Logger
+}
+
+// NewCleaner creates a preview cleaner. A nil images leaves container images
+// in the registry.
+func NewCleaner(repos *db.Repositories, kube kubernetes.Interface, workloads WorkloadDeleter, images ImageDeleter, logger *logrus.Logger) *Cleaner {
+	return &Cleaner{
+		repos:          repos,
+		kube:           kube,
+		workloads:      workloads,
+		images:         images,
+		defaultTTLDays: DefaultTTLDays,
+		logger:         logger,
+	}
+}
+
+// SetDatabases makes teardowns remove the ephemeral databases of previews
+func (c *Cleaner) SetDatabases(databases *Databases) {
+	c.databases = databases
+}
+
+// SetDefaultTTLDays sets the TTL of projects that do not configure one
+func (c *Cleaner) SetDefaultTTLDays(days int) {
+	if days >= 0 {
+		c.defaultTTLDays = days
+	}
+}
+
+// DefaultTTLDays returns the TTL of projects that do not configure one
+func (c *Cleaner) DefaultTTLDays() int {
+	return c.defaultTTLDays
+}
+
+// TTLDays returns the effective TTL of a project
+func (c *Cleaner) TTLDays(project *types.Project) int {
+	if project.PreviewTTLDays != nil {
+		return *project.PreviewTTLDays
+	}
+	return c.defaultTTLDays
+}
+
+// ListStale returns the teardown plans of closed previews past their TTL,
+// oldest first. A nil projectID lists them across all projects.
+func (c *Cleaner) ListStale(ctx context.Context, projectID *uuid.UUID) ([]*Plan, error) {
+	previews, err := c.repos.PreviewEnvironments.ListCleanupCandidates(ctx, c.defaultTTLDays, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale previews: %w", err)
+	}
+
+	ttls := make(map[uuid.UUID]int)
+	plans := make([]*Plan, 0, len(previews))
+	for _, preview := range previews {
+		ttl, ok := ttls[preview.ProjectID]
+		if !ok {
+			project, err := c.repos.Projects.GetByID(ctx, preview.ProjectID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get project %s: %w", preview.ProjectID, err)
+			}
+			ttl = c.TTLDays(project)
+			ttls[preview.ProjectID] = ttl
+		}
+
+		plan, err := c.Plan(ctx, preview, ttl)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// Plan works out what tearing down a closed preview removes
+func (c *Cleaner) Plan(ctx context.Context, preview *types.PreviewEnvironment, ttlDays int) (*Plan, error) {
+	releases, err := c.repos.Releases.ListPreviewReleases(ctx, preview.ServiceID, preview.PRNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases of preview %s: %w", preview.ID, err)
+	}
+
+	plan := &Plan{
+		Preview:   preview,
+		Namespace: Namespace(preview),
+		TTLDays:   ttlDays,
+		Releases:  make([]PlannedRelease, 0, len(releases)),
+	}
+	if preview.ClosedAt != nil {
+		plan.ExpiredAt = preview.ClosedAt.Add(time.Duration(ttlDays) * 24 * time.Hour)
+	}
+	if database, err := c.repos.PreviewDatabases.GetByPreview(ctx, preview.ID); err == nil {
+		plan.DatabaseAddonID = &database.AddonID
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get database of preview %s: %w", preview.ID, err)
+	}
+	for _, release := range releases {
+		plan.Releases = append(plan.Releases, PlannedRelease{
+			ID:             release.ID,
+			Version:        release.Version,
+			ImageURI:       release.ImageURI,
+			ImageSizeBytes: release.ImageSizeBytes,
+		})
+		if release.ImageSizeBytes != nil {
+			plan.ImageBytes += *release.ImageSizeBytes
+		}
+	}
+
+	return plan, nil
+}
+
+// StopWorkload removes a closed preview's deployment and service so it stops
+// using cluster resources. The rest is kept until the TTL passes.
+func (c *Cleaner) StopWorkload(ctx context.Context, preview *types.PreviewEnvironment) error {
+	service, err := c.repos.Services.GetByID(preview.ServiceID)
+	if err != nil {
+		return fmt.Errorf("failed to get service: %w", err)
+	}
+	return c.workloads.Delete(ctx, Namespace(preview), service.Name)
+}
+
+// Teardown removes everything a plan lists. Images are deleted before the
+// releases that reference them; if any step fails the preview is left for the
+// next sweep to retry, otherwise it is marked cleaned up.
+func (c *Cleaner) Teardown(ctx context.Context, plan *Plan) *Result {
+	preview := plan.Preview
+	result := &Result{PreviewID: preview.ID}
+	fail := func(format string, args ...interface{}) {
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+	}
+
+	if service, err := c.repos.Services.GetByID(preview.ServiceID); err != nil {
+		fail("failed to get service: %v", err)
+	} else {
+		if err := c.workloads.Delete(ctx, plan.Namespace, service.Name); err != nil {
+			fail("failed to delete workload: %v", err)
+		}
+
+		err := c.kube.NetworkingV1().Ingresses(plan.Namespace).Delete(ctx, service.Name, metav1.DeleteOptions{})
+		switch {
+		case err == nil:
+			result.Ingresses++
+		case !k8serrors.IsNotFound(err):
+			fail("failed to delete ingress: %v", err)
+		}
+	}
+
+	if c.databases != nil {
+		released, err := c.databases.Release(ctx, preview)
+		switch {
+		case err != nil:
+			fail("failed to delete database: %v", err)
+		case released:
+			result.Databases++
+		}
+	}
+
+	err := c.kube.CoreV1().Namespaces().Delete(ctx, plan.Namespace, metav1.DeleteOptions{})
+	switch {
+	case err == nil:
+		result.Namespaces++
+	case !k8serrors.IsNotFound(err):
+		fail("failed to delete namespace: %v", err)
+	}
+
+	imagesDeleted := c.deleteImages(ctx, plan, result)
+
+	// Release rows are the only record of the images, so keep them until
+	// the images are gone
+	if imagesDeleted {
+		for _, release := range plan.Releases {
+			if err := c.repos.Releases.Delete(ctx, release.ID); err != nil {
+				fail("failed to delete release %s: %v", release.Version, err)
+				continue
+			}
+			result.Releases++
+		}
+	}
+
+	status := "success"
+	if len(result.Errors) == 0 {
+		if err := c.repos.PreviewEnvironments.MarkCleanedUp(ctx, preview.ID); err != nil {
+			fail("failed to mark preview cleaned up: %v", err)
+		}
+	}
+	if len(result.Errors) > 0 {
+		status = "partial"
+		if result.Namespaces+result.Ingresses+result.Databases+result.Releases+result.Images == 0 {
+			status = "failure"
+		}
+	}
+
+	monitoring.RecordPreviewCleanup(status)
+	monitoring.RecordPreviewReclaimed("namespace", result.Namespaces)
+	monitoring.RecordPreviewReclaimed("ingress", result.Ingresses)
+	monitoring.RecordPreviewReclaimed("database", result.Databases)
+	monitoring.RecordPreviewReclaimed("release", result.Releases)
+	monitoring.RecordPreviewReclaimed("image", result.Images)
+	monitoring.RecordPreviewReclaimedImageBytes(result.ImageBytes)
+
+	entry := c.logger.WithFields(logrus.Fields{
+		"preview_id": preview.ID,
+		"pr_number":  preview.PRNumber,
+		"namespace":  plan.Namespace,
+		"releases":   result.Releases,
+		"images":     result.Images,
+	})
+	if len(result.Errors) > 0 {
+		entry.WithField("errors", result.Errors).Warn("Preview teardown incomplete, will retry")
+	} else {
+		entry.Info("Preview torn down")
+	}
+
+	return result
+}
+
+// deleteImages deletes the images of a plan's releases. It reports whether
+// none is left behind because of an error.
+func (c *Cleaner) deleteImages(ctx context.Context, plan *Plan, result *Result) bool {
+	if c.images == nil {
+		return true
+	}
+
+	images := c.images
+	if scoped, isScoped := c.images.(credentialedImageDeleter); isScoped && c.repos != nil && c.repos.RegistryCredentials != nil {
+		auths, err := c.projectRegistryAuths(ctx, plan.Preview.ProjectID)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			return false
+		}
+		images = scoped.WithCredentials(auths)
+	}
+
+	ok := true
+	seen := make(map[string]bool)
+	for _, release := range plan.Releases {
+		if release.ImageURI == "" || seen[release.ImageURI] {
+			continue
+		}
+		seen[release.ImageURI] = true
+
+		err := images.DeleteImage(ctx, release.ImageURI)
+		switch {
+		case err == nil:
+			result.Images++
+			if release.ImageSizeBytes != nil {
+				result.ImageBytes += *release.ImageSizeBytes
+			}
+		case errors.Is(err, ErrImageNotFound):
+		case errors.Is(err, ErrDeleteUnsupported):
+			// The registry's own retention policy has to reclaim it
+			c.logger.WithField("image", release.ImageURI).Debug("Registry does not support image deletion, skipping")
+		default:
+			result.Errors = append(result.Errors, fmt.Sprintf("failed to delete image %s: %v", release.ImageURI, err))
+			ok = false
+		}
+	}
+	return ok
+}
+
+// projectRegistryAuths returns the resolved registry credentials of a
+// project, which preview builds pushing to its own registry were pushed with
+func (c *Cleaner) projectRegistryAuths(ctx context.Context, projectID uuid.UUID) ([]db.RegistryAuth, error) {
+	auths, err := c.repos.RegistryCredentials.ListDecrypted(ctx, projectID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry credentials: %w", err)
+	}
+	if auths, err = registry.Resolve(ctx, auths); err != nil {
+		return nil, err
+	}
+	return auths, nil
+}
+
+// CleanupExpired tears down every closed preview past its TTL
+func (c *Cleaner) CleanupExpired(ctx context.Context) {
+	plans, err := c.ListStale(ctx, nil)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list stale previews")
+		return
+	}
+	monitoring.SetPreviewCleanupPending(len(plans))
+
+	remaining := len(plans)
+	for _, plan := range plans {
+		if ctx.Err() != nil {
+			return
+		}
+		if result := c.Teardown(ctx, plan); len(result.Errors) == 0 {
+			remaining--
+		}
+	}
+	monitoring.SetPreviewCleanupPending(remaining)
+}
@@ -0,0 +1,87 @@
+package previews
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+type fakeImageDeleter map[string]error
+
+func (f fakeImageDeleter) DeleteImage(ctx context.Context, image string) error {
+	return f[image]
+}
+
+func TestCleanerTTLDays(t *testing.T) {
+	c := NewCleaner(nil, nil, nil, nil, logrus.New())
+	c.SetDefaultTTLDays(3)
+	c.SetDefaultTTLDays(-1) // ignored
+
+	zero, ten := 0, 10
+	tests := []struct {
+		name    string
+		project *types.Project
+		want    int
+	}{
+		{name: "platform default", project: &types.Project{}, want: 3},
+		{name: "project override", project: &types.Project{PreviewTTLDays: &ten}, want: 10},
+		{name: "immediate teardown", project: &types.Project{PreviewTTLDays: &zero}, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := c.TTLDays(tt.project); got != tt.want {
+			t.Errorf("%s: TTLDays() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCleanerDeleteImages(t *testing.T) {
+	size := int64(1 << 20)
+	releases := []PlannedRelease{
+		{Version: "preview-pr-1-aaaaaaa", ImageURI: "ghcr.io/org/api:pr-1-aaaaaaa", ImageSizeBytes: &size},
+		{Version: "preview-pr-1-aaaaaaa-retry", ImageURI: "ghcr.io/org/api:pr-1-aaaaaaa", ImageSizeBytes: &size},
+		{Version: "preview-pr-1-bbbbbbb", ImageURI: "ghcr.io/org/api:pr-1-bbbbbbb"},
+	}
+
+	tests := []struct {
+		name       string
+		images     ImageDeleter
+		wantOK     bool
+		wantImages int
+		wantBytes  int64
+	}{
+		{name: "image deletion disabled", images: nil, wantOK: true},
+		{name: "all deleted, duplicates once", images: fakeImageDeleter{}, wantOK: true, wantImages: 2, wantBytes: size},
+		{
+			name:   "already gone or unsupported",
+			images: fakeImageDeleter{"ghcr.io/org/api:pr-1-aaaaaaa": ErrImageNotFound, "ghcr.io/org/api:pr-1-bbbbbbb": ErrDeleteUnsupported},
+			wantOK: true,
+		},
+		{
+			name:       "registry error keeps the releases",
+			images:     fakeImageDeleter{"ghcr.io/org/api:pr-1-bbbbbbb": errors.New("registry returned 500")},
+			wantOK:     false,
+			wantImages: 1,
+			wantBytes:  size,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCleaner(nil, nil, nil, tt.images, logrus.New())
+			result := &Result{}
+
+			ok := c.deleteImages(context.Background(), &Plan{Releases: releases}, result)
+			if ok != tt.wantOK {
+				t.Errorf("deleteImages() = %v, want %v (errors %v)", ok, tt.wantOK, result.Errors)
+			}
+			if result.Images != tt.wantImages || result.ImageBytes != tt.wantBytes {
+				t.Errorf("deleted %d images / %d bytes, want %d / %d", result.Images, result.ImageBytes, tt.wantImages, tt.wantBytes)
+			}
+		})
+	}
+}
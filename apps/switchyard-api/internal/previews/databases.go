@@ -0,0 +1,536 @@
+package previews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// DefaultDatabaseEnvVar is bound to the preview database when the service
+	// has no binding to the template addon to take the name from
+	DefaultDatabaseEnvVar = "DATABASE_URL"
+
+	// DatabaseSyncInterval is how often preview databases are moved along
+	DatabaseSyncInterval = 30 * time.Second
+
+	// seedJobBackoffLimit retries a failed seed command once
+	seedJobBackoffLimit int32 = 1
+	// seedJobTTLSeconds keeps a finished seed job around for its logs
+	seedJobTTLSeconds int32 = 3600
+)
+
+var envVarNameRegex = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// PreviewRedeployer redeploys a preview so it picks up its database binding
+type PreviewRedeployer interface {
+	RedeployPreview(ctx context.Context, preview *types.PreviewEnvironment) error
+}
+
+// Databases gives previews their own database, cloned or seeded from a
+// template addon, and removes it with the preview
+type Databases struct {
+	repos      *db.Repositories
+	addons     *addons.AddonService
+	kube       kubernetes.Interface
+	redeployer PreviewRedeployer
+	logger     *logrus.Logger
+}
+
+// NewDatabases creates the preview database manager
+func NewDatabases(repos *db.Repositories, addonService *addons.AddonService, kube kubernetes.Interface, logger *logrus.Logger) *Databases {
+	return &Databases{
+		repos:  repos,
+		addons: addonService,
+		kube:   kube,
+		logger: logger,
+	}
+}
+
+// SetRedeployer sets what redeploys a preview once its database is ready.
+// Without one, the binding is picked up on the preview's next deploy.
+func (d *Databases) SetRedeployer(redeployer PreviewRedeployer) {
+	d.redeployer = redeployer
+}
+
+// SupportsTemplate reports whether previews can get a database shaped after an addon type
+func SupportsTemplate(addonType types.DatabaseAddonType) bool {
+	switch addonType {
+	case types.DatabaseAddonTypePostgres, types.DatabaseAddonTypeMySQL, types.DatabaseAddonTypeMongoDB:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateConfig checks a preview database config against its template addon
+// and, when one is pinned, the backup it clones
+func ValidateConfig(config *types.PreviewDatabaseConfig, template *types.DatabaseAddon, backup *types.DatabaseAddonBackup) error {
+	if !SupportsTemplate(template.Type) {
+		return fmt.Errorf("preview databases are not supported for addon type: %s", template.Type)
+	}
+	if !envVarNameRegex.MatchString(config.EnvVarName) {
+		return fmt.Errorf("env_var_name must be uppercase letters, digits and underscores")
+	}
+
+	switch config.Mode {
+	case types.PreviewDatabaseModeClone:
+		if config.SeedCommand != "" {
+			return fmt.Errorf("seed_command is only used in seed mode")
+		}
+		if template.Type == types.DatabaseAddonTypePostgres {
+			if config.BackupID != nil {
+				return fmt.Errorf("PostgreSQL previews clone the latest point of the template's WAL archive; backup_id is not supported")
+			}
+			if !template.Config.PITREnabled {
+				return fmt.Errorf("cloning a PostgreSQL addon requires point-in-time recovery to be enabled on it")
+			}
+			return nil
+		}
+		if backup == nil {
+			return nil
+		}
+		if backup.AddonID != template.ID {
+			return fmt.Errorf("backup does not belong to the template addon")
+		}
+		if backup.Status != types.DatabaseAddonBackupStatusCompleted {
+			return fmt.Errorf("backup is not restorable (status: %s)", backup.Status)
+		}
+	case types.PreviewDatabaseModeSeed:
+		if config.SeedCommand == "" {
+			return fmt.Errorf("seed_command is required in seed mode")
+		}
+		if config.BackupID != nil {
+			return fmt.Errorf("backup_id is only used in clone mode")
+		}
+	default:
+		return fmt.Errorf("mode must be %q or %q", types.PreviewDatabaseModeClone, types.PreviewDatabaseModeSeed)
+	}
+
+	return nil
+}
+
+// DefaultEnvVarName returns the name the service already binds the template
+// under, so the preview database takes its place, or DATABASE_URL
+func (d *Databases) DefaultEnvVarName(ctx context.Context, serviceID, templateID uuid.UUID) string {
+	bindings, err := d.repos.DatabaseAddons.GetBindingsByService(ctx, serviceID)
+	if err == nil {
+		for _, binding := range bindings {
+			if binding.AddonID == templateID && binding.EnvVarName != "" {
+				return binding.EnvVarName
+			}
+		}
+	}
+	return DefaultDatabaseEnvVar
+}
+
+// databaseName names the ephemeral addon of a preview. Addon names stay
+// reserved after deletion, so the record ID keeps recreated ones unique.
+func databaseName(preview *types.PreviewEnvironment, template *types.DatabaseAddon, databaseID uuid.UUID) string {
+	return fmt.Sprintf("%s-pr-%d-%s", template.Name, preview.PRNumber, databaseID.String()[:8])
+}
+
+// databaseConfig sizes the preview database after its template, without the
+// high availability and backups a throwaway copy does not need
+func databaseConfig(template types.DatabaseAddonConfig) types.DatabaseAddonConfig {
+	return types.DatabaseAddonConfig{
+		Version:   template.Version,
+		StorageGB: template.StorageGB,
+		CPU:       template.CPU,
+		Memory:    template.Memory,
+	}
+}
+
+// Provision creates the database of a preview if its service has a preview
+// database config. A preview keeps its database across pushes; a failed one
+// is replaced. It returns nil when the service has no config.
+func (d *Databases) Provision(ctx context.Context, preview *types.PreviewEnvironment) (*types.PreviewDatabase, error) {
+	config, err := d.repos.PreviewDatabases.GetConfig(ctx, preview.ServiceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get preview database config: %w", err)
+	}
+
+	existing, err := d.repos.PreviewDatabases.GetByPreview(ctx, preview.ID)
+	switch {
+	case err == nil && existing.Status != types.PreviewDatabaseStatusFailed:
+		return existing, nil
+	case err == nil:
+		if _, err := d.Release(ctx, preview); err != nil {
+			return nil, fmt.Errorf("failed to remove failed preview database: %w", err)
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to get preview database: %w", err)
+	}
+
+	template, err := d.repos.DatabaseAddons.GetByID(ctx, config.TemplateAddonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template addon: %w", err)
+	}
+
+	req := &addons.CreateAddonRequest{
+		ProjectID: preview.ProjectID,
+		Type:      template.Type,
+		Config:    databaseConfig(template.Config),
+		Namespace: Namespace(preview),
+		Ephemeral: true,
+	}
+	if config.Mode == types.PreviewDatabaseModeClone && template.Type == types.DatabaseAddonTypePostgres {
+		req.CloneFrom = template
+	}
+
+	database := &types.PreviewDatabase{
+		ID:            uuid.New(),
+		PreviewID:     preview.ID,
+		Mode:          config.Mode,
+		EnvVarName:    config.EnvVarName,
+		Status:        types.PreviewDatabaseStatusProvisioning,
+		StatusMessage: fmt.Sprintf("Provisioning %s database from %s", template.Type, template.Name),
+	}
+	req.Name = databaseName(preview, template, database.ID)
+
+	addon, err := d.addons.CreateAddon(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create preview database: %w", err)
+	}
+	database.AddonID = addon.ID
+
+	if err := d.repos.PreviewDatabases.Create(ctx, database); err != nil {
+		if delErr := d.addons.DeleteAddon(ctx, addon.ID); delErr != nil {
+			d.logger.WithError(delErr).WithField("addon_id", addon.ID).Warn("Failed to remove unrecorded preview database")
+		}
+		return nil, fmt.Errorf("failed to record preview database: %w", err)
+	}
+
+	d.logger.WithFields(logrus.Fields{
+		"preview_id": preview.ID,
+		"addon_id":   addon.ID,
+		"template":   template.Name,
+		"mode":       config.Mode,
+	}).Info("Provisioning preview database")
+
+	return database, nil
+}
+
+// EnvVars returns the binding of a preview's database once it is ready
+func (d *Databases) EnvVars(ctx context.Context, preview *types.PreviewEnvironment) (map[string]string, error) {
+	database, err := d.repos.PreviewDatabases.GetByPreview(ctx, preview.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if database.Status != types.PreviewDatabaseStatusReady {
+		return nil, nil
+	}
+
+	creds, err := d.addons.GetCredentials(ctx, database.AddonID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preview database credentials: %w", err)
+	}
+
+	return map[string]string{database.EnvVarName: creds.ConnectionURI}, nil
+}
+
+// Release deletes the database of a preview. It reports whether the preview
+// had one.
+func (d *Databases) Release(ctx context.Context, preview *types.PreviewEnvironment) (bool, error) {
+	database, err := d.repos.PreviewDatabases.GetByPreview(ctx, preview.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	err = d.kube.BatchV1().Jobs(Namespace(preview)).Delete(ctx, SeedJobName(database), metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return true, fmt.Errorf("failed to delete seed job: %w", err)
+	}
+
+	if err := d.addons.DeleteAddon(ctx, database.AddonID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return true, err
+	}
+
+	return true, d.repos.PreviewDatabases.Delete(ctx, database.ID)
+}
+
+// Sync moves every preview database that is still being prepared along:
+// once its addon is ready the snapshot is restored or the seed job started,
+// and once that finishes the preview is redeployed with the binding.
+func (d *Databases) Sync(ctx context.Context) {
+	databases, err := d.repos.PreviewDatabases.ListInProgress(ctx)
+	if err != nil {
+		d.logger.WithError(err).Error("Failed to list preview databases in progress")
+		return
+	}
+
+	for _, database := range databases {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := d.sync(ctx, database); err != nil {
+			d.logger.WithError(err).WithField("preview_database_id", database.ID).Warn("Failed to sync preview database")
+		}
+	}
+}
+
+// sync advances one preview database. Errors are retried on the next sync;
+// outcomes that cannot change mark the database failed.
+func (d *Databases) sync(ctx context.Context, database *types.PreviewDatabase) error {
+	addon, err := d.repos.DatabaseAddons.GetByID(ctx, database.AddonID)
+	if err != nil {
+		return fmt.Errorf("failed to get preview database addon: %w", err)
+	}
+	preview, err := d.repos.PreviewEnvironments.GetByID(ctx, database.PreviewID)
+	if err != nil {
+		return fmt.Errorf("failed to get preview: %w", err)
+	}
+
+	switch database.Status {
+	case types.PreviewDatabaseStatusProvisioning:
+		switch {
+		case addon.Status == types.DatabaseAddonStatusFailed:
+			return d.fail(ctx, database, "Provisioning failed: "+addon.StatusMessage)
+		case addon.IsAvailable():
+			return d.fill(ctx, preview, database, addon)
+		}
+		return nil
+
+	case types.PreviewDatabaseStatusRestoring:
+		if database.RestoreID == nil {
+			return d.fail(ctx, database, "Restore record is missing")
+		}
+		restore, err := d.addons.GetRestore(ctx, addon.ID, *database.RestoreID)
+		if err != nil {
+			return err
+		}
+		switch restore.Status {
+		case types.DatabaseAddonRestoreStatusCompleted:
+			return d.ready(ctx, preview, database, "Snapshot restored")
+		case types.DatabaseAddonRestoreStatusFailed:
+			return d.fail(ctx, database, "Restore failed: "+restore.StatusMessage)
+		}
+		return nil
+
+	case types.PreviewDatabaseStatusSeeding:
+		job, err := d.kube.BatchV1().Jobs(addon.K8sNamespace).Get(ctx, SeedJobName(database), metav1.GetOptions{})
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return d.fail(ctx, database, "Seed job not found")
+			}
+			return err
+		}
+		switch {
+		case job.Status.Succeeded > 0:
+			return d.ready(ctx, preview, database, "Seed command completed")
+		case job.Status.Failed > seedJobBackoffLimit:
+			return d.fail(ctx, database, fmt.Sprintf("Seed job %s failed, see its logs", job.Name))
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// fill loads data into a newly provisioned preview database
+func (d *Databases) fill(ctx context.Context, preview *types.PreviewEnvironment, database *types.PreviewDatabase, addon *types.DatabaseAddon) error {
+	// PostgreSQL clones are bootstrapped from the template's archive
+	if database.Mode == types.PreviewDatabaseModeClone && addon.Type == types.DatabaseAddonTypePostgres {
+		return d.ready(ctx, preview, database, "Cloned from the template's WAL archive")
+	}
+
+	config, err := d.repos.PreviewDatabases.GetConfig(ctx, preview.ServiceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return d.fail(ctx, database, "Preview database config was removed")
+		}
+		return err
+	}
+
+	if database.Mode == types.PreviewDatabaseModeSeed {
+		return d.seed(ctx, preview, database, addon, config)
+	}
+
+	backupID := config.BackupID
+	if backupID == nil {
+		backup, err := d.latestBackup(ctx, config.TemplateAddonID)
+		if err != nil {
+			return err
+		}
+		if backup == nil {
+			return d.fail(ctx, database, "Template addon has no completed backup to clone")
+		}
+		backupID = &backup.ID
+	}
+
+	restore, err := d.addons.RestoreBackupFrom(ctx, addon.ID, *backupID, nil, "")
+	if err != nil {
+		return d.fail(ctx, database, err.Error())
+	}
+
+	database.Status = types.PreviewDatabaseStatusRestoring
+	database.StatusMessage = "Restoring snapshot of the template addon"
+	database.RestoreID = &restore.ID
+	return d.repos.PreviewDatabases.Update(ctx, database)
+}
+
+// latestBackup returns the newest completed backup of an addon, or nil
+func (d *Databases) latestBackup(ctx context.Context, addonID uuid.UUID) (*types.DatabaseAddonBackup, error) {
+	backups, err := d.repos.DatabaseAddons.GetBackupsByAddon(ctx, addonID, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template backups: %w", err)
+	}
+	for _, backup := range backups {
+		if backup.Status == types.DatabaseAddonBackupStatusCompleted {
+			return backup, nil
+		}
+	}
+	return nil, nil
+}
+
+// seed starts the seed job once the preview has an image to run it in
+func (d *Databases) seed(ctx context.Context, preview *types.PreviewEnvironment, database *types.PreviewDatabase, addon *types.DatabaseAddon, config *types.PreviewDatabaseConfig) error {
+	releases, err := d.repos.Releases.ListPreviewReleases(ctx, preview.ServiceID, preview.PRNumber)
+	if err != nil {
+		return fmt.Errorf("failed to list preview releases: %w", err)
+	}
+
+	var image string
+	for _, release := range releases {
+		if release.Status == types.ReleaseStatusReady && release.ImageURI != "" {
+			image = release.ImageURI
+			break
+		}
+	}
+	if image == "" {
+		const waiting = "Waiting for the preview build to run the seed command"
+		if database.StatusMessage == waiting {
+			return nil
+		}
+		database.StatusMessage = waiting
+		return d.repos.PreviewDatabases.Update(ctx, database)
+	}
+
+	job := BuildSeedJob(database, addon, image, config.SeedCommand)
+	if _, err := d.kube.BatchV1().Jobs(addon.K8sNamespace).Create(ctx, job, metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create seed job: %w", err)
+	}
+
+	database.Status = types.PreviewDatabaseStatusSeeding
+	database.StatusMessage = fmt.Sprintf("Seed job %s started", job.Name)
+	return d.repos.PreviewDatabases.Update(ctx, database)
+}
+
+// ready marks a preview database ready and redeploys the preview with it
+func (d *Databases) ready(ctx context.Context, preview *types.PreviewEnvironment, database *types.PreviewDatabase, message string) error {
+	database.Status = types.PreviewDatabaseStatusReady
+	database.StatusMessage = message
+	if err := d.repos.PreviewDatabases.Update(ctx, database); err != nil {
+		return err
+	}
+
+	d.logger.WithFields(logrus.Fields{
+		"preview_id": preview.ID,
+		"addon_id":   database.AddonID,
+	}).Info("Preview database ready")
+
+	if d.redeployer == nil || preview.Status == types.PreviewStatusClosed || preview.DeploymentID == nil {
+		return nil
+	}
+	if err := d.redeployer.RedeployPreview(ctx, preview); err != nil {
+		d.logger.WithError(err).WithField("preview_id", preview.ID).Warn("Failed to redeploy preview with its database")
+	}
+	return nil
+}
+
+// fail marks a preview database failed. It is replaced on the preview's next build.
+func (d *Databases) fail(ctx context.Context, database *types.PreviewDatabase, message string) error {
+	database.Status = types.PreviewDatabaseStatusFailed
+	database.StatusMessage = message
+	d.logger.WithFields(logrus.Fields{
+		"preview_id": database.PreviewID,
+		"addon_id":   database.AddonID,
+	}).Warn("Preview database failed: " + message)
+	return d.repos.PreviewDatabases.Update(ctx, database)
+}
+
+// SeedJobName returns the name of the job that seeds a preview database
+func SeedJobName(database *types.PreviewDatabase) string {
+	return "preview-db-seed-" + database.ID.String()[:8]
+}
+
+// BuildSeedJob builds the job that runs a service's seed command in its
+// preview image, with the preview database bound as it will be at runtime
+func BuildSeedJob(database *types.PreviewDatabase, addon *types.DatabaseAddon, image, command string) *batchv1.Job {
+	ttl := seedJobTTLSeconds
+	backoffLimit := seedJobBackoffLimit
+	labels := map[string]string{
+		addons.LabelManagedBy:        addons.LabelManagedValue,
+		addons.LabelAddonID:          addon.ID.String(),
+		addons.LabelProjectID:        addon.ProjectID.String(),
+		"enclii.dev/type":            "preview-db-seed",
+		"enclii.dev/preview-id":      database.PreviewID.String(),
+		"enclii.dev/preview-db-mode": string(database.Mode),
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SeedJobName(database),
+			Namespace: addon.K8sNamespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			TTLSecondsAfterFinished: &ttl,
+			BackoffLimit:            &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "seed",
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", command},
+							Env: []corev1.EnvVar{
+								{
+									Name: database.EnvVarName,
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{Name: addon.ConnectionSecret},
+											Key:                  "uri",
+										},
+									},
+								},
+								{Name: "ENCLII_IS_PREVIEW", Value: "true"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,180 @@
+package previews
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestValidateConfig(t *testing.T) {
+	postgres := &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypePostgres, Config: types.DatabaseAddonConfig{PITREnabled: true}}
+	postgresNoPITR := &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypePostgres}
+	mysql := &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeMySQL}
+	redis := &types.DatabaseAddon{ID: uuid.New(), Type: types.DatabaseAddonTypeRedis}
+
+	backupID := uuid.New()
+	completed := &types.DatabaseAddonBackup{ID: backupID, AddonID: mysql.ID, Status: types.DatabaseAddonBackupStatusCompleted}
+	running := &types.DatabaseAddonBackup{ID: backupID, AddonID: mysql.ID, Status: types.DatabaseAddonBackupStatusInProgress}
+	foreign := &types.DatabaseAddonBackup{ID: backupID, AddonID: uuid.New(), Status: types.DatabaseAddonBackupStatusCompleted}
+
+	clone := func() *types.PreviewDatabaseConfig {
+		return &types.PreviewDatabaseConfig{Mode: types.PreviewDatabaseModeClone, EnvVarName: "DATABASE_URL"}
+	}
+
+	tests := []struct {
+		name     string
+		config   func() *types.PreviewDatabaseConfig
+		template *types.DatabaseAddon
+		backup   *types.DatabaseAddonBackup
+		wantErr  bool
+	}{
+		{name: "postgres clone", config: clone, template: postgres},
+		{name: "postgres clone without PITR", config: clone, template: postgresNoPITR, wantErr: true},
+		{
+			name: "postgres clone of a pinned backup",
+			config: func() *types.PreviewDatabaseConfig {
+				c := clone()
+				c.BackupID = &backupID
+				return c
+			},
+			template: postgres,
+			wantErr:  true,
+		},
+		{name: "mysql clone of the latest backup", config: clone, template: mysql},
+		{name: "mysql clone of a pinned backup", config: clone, template: mysql, backup: completed},
+		{name: "mysql clone of an unfinished backup", config: clone, template: mysql, backup: running, wantErr: true},
+		{name: "mysql clone of another addon's backup", config: clone, template: mysql, backup: foreign, wantErr: true},
+		{name: "unsupported template", config: clone, template: redis, wantErr: true},
+		{
+			name: "clone with seed command",
+			config: func() *types.PreviewDatabaseConfig {
+				c := clone()
+				c.SeedCommand = "npm run seed"
+				return c
+			},
+			template: mysql,
+			wantErr:  true,
+		},
+		{
+			name: "seed",
+			config: func() *types.PreviewDatabaseConfig {
+				return &types.PreviewDatabaseConfig{Mode: types.PreviewDatabaseModeSeed, SeedCommand: "npm run seed", EnvVarName: "DATABASE_URL"}
+			},
+			template: postgresNoPITR,
+		},
+		{
+			name: "seed without command",
+			config: func() *types.PreviewDatabaseConfig {
+				return &types.PreviewDatabaseConfig{Mode: types.PreviewDatabaseModeSeed, EnvVarName: "DATABASE_URL"}
+			},
+			template: postgres,
+			wantErr:  true,
+		},
+		{
+			name: "invalid env var name",
+			config: func() *types.PreviewDatabaseConfig {
+				c := clone()
+				c.EnvVarName = "database-url"
+				return c
+			},
+			template: postgres,
+			wantErr:  true,
+		},
+		{
+			name: "unknown mode",
+			config: func() *types.PreviewDatabaseConfig {
+				return &types.PreviewDatabaseConfig{Mode: "snapshot", EnvVarName: "DATABASE_URL"}
+			},
+			template: postgres,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(tt.config(), tt.template, tt.backup)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatabaseNameAndConfig(t *testing.T) {
+	preview := &types.PreviewEnvironment{PRNumber: 42}
+	template := &types.DatabaseAddon{
+		Name: "main",
+		Config: types.DatabaseAddonConfig{
+			Version:             "16",
+			StorageGB:           20,
+			CPU:                 "1000m",
+			Memory:              "2Gi",
+			Replicas:            3,
+			HAEnabled:           true,
+			BackupSchedule:      "0 2 * * *",
+			BackupRetentionDays: 30,
+			PITREnabled:         true,
+		},
+	}
+	id := uuid.MustParse("abcdef12-3456-7890-abcd-ef1234567890")
+
+	if got, want := databaseName(preview, template, id), "main-pr-42-abcdef12"; got != want {
+		t.Errorf("databaseName() = %q, want %q", got, want)
+	}
+
+	got := databaseConfig(template.Config)
+	want := types.DatabaseAddonConfig{Version: "16", StorageGB: 20, CPU: "1000m", Memory: "2Gi"}
+	if got != want {
+		t.Errorf("databaseConfig() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildSeedJob(t *testing.T) {
+	database := &types.PreviewDatabase{
+		ID:         uuid.MustParse("abcdef12-3456-7890-abcd-ef1234567890"),
+		PreviewID:  uuid.New(),
+		Mode:       types.PreviewDatabaseModeSeed,
+		EnvVarName: "PG_URL",
+	}
+	addon := &types.DatabaseAddon{
+		ID:               uuid.New(),
+		ProjectID:        uuid.New(),
+		K8sNamespace:     "enclii-preview-pr-42-api",
+		ConnectionSecret: "main-pr-42-credentials",
+	}
+
+	job := BuildSeedJob(database, addon, "ghcr.io/org/api:pr-42-abcdef1", "npm run seed")
+
+	if job.Name != "preview-db-seed-abcdef12" || job.Namespace != addon.K8sNamespace {
+		t.Errorf("job = %s/%s, want %s/preview-db-seed-abcdef12", job.Namespace, job.Name, addon.K8sNamespace)
+	}
+	if job.Labels["enclii.dev/preview-id"] != database.PreviewID.String() {
+		t.Errorf("preview-id label = %q, want %q", job.Labels["enclii.dev/preview-id"], database.PreviewID)
+	}
+
+	containers := job.Spec.Template.Spec.Containers
+	if len(containers) != 1 {
+		t.Fatalf("containers = %d, want 1", len(containers))
+	}
+	c := containers[0]
+	if c.Image != "ghcr.io/org/api:pr-42-abcdef1" {
+		t.Errorf("image = %q, want the preview image", c.Image)
+	}
+	if len(c.Command) != 3 || c.Command[2] != "npm run seed" {
+		t.Errorf("command = %v, want the seed command run by the shell", c.Command)
+	}
+
+	var bound bool
+	for _, env := range c.Env {
+		if env.Name != "PG_URL" {
+			continue
+		}
+		ref := env.ValueFrom.SecretKeyRef
+		bound = ref != nil && ref.Name == addon.ConnectionSecret && ref.Key == "uri"
+	}
+	if !bound {
+		t.Errorf("env = %+v, want PG_URL bound to the %s uri", c.Env, addon.ConnectionSecret)
+	}
+}
@@ -0,0 +1,134 @@
+package previews
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    imageRef
+		wantErr bool
+	}{
+		{image: "ghcr.io/madfam-org/api:pr-12-abc1234", want: imageRef{host: "ghcr.io", repository: "madfam-org/api", reference: "pr-12-abc1234"}},
+		{image: "localhost:5000/api:v1", want: imageRef{host: "localhost:5000", repository: "api", reference: "v1"}},
+		{image: "registry.example.com/team/api@sha256:abc", want: imageRef{host: "registry.example.com", repository: "team/api", reference: "sha256:abc"}},
+		{image: "nginx:1.27", want: imageRef{host: "registry-1.docker.io", repository: "library/nginx", reference: "1.27"}},
+		{image: "madfam/api:latest", want: imageRef{host: "registry-1.docker.io", repository: "madfam/api", reference: "latest"}},
+		{image: "ghcr.io/madfam-org/api", wantErr: true},
+		{image: "localhost:5000/api", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := parseImageRef(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImageRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseImageRef() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryClientDeleteImage(t *testing.T) {
+	tests := []struct {
+		name         string
+		deleteStatus int
+		headStatus   int
+		wantErr      error
+	}{
+		{name: "deleted", headStatus: http.StatusOK, deleteStatus: http.StatusAccepted},
+		{name: "tag already gone", headStatus: http.StatusNotFound, wantErr: ErrImageNotFound},
+		{name: "deletion disabled", headStatus: http.StatusOK, deleteStatus: http.StatusMethodNotAllowed, wantErr: ErrDeleteUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted string
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/token" {
+					if user, pass, _ := r.BasicAuth(); user != "bot" || pass != "secret" {
+						t.Errorf("token request credentials = %q/%q", user, pass)
+					}
+					if scope := r.URL.Query().Get("scope"); scope != "repository:org/api:pull,delete" {
+						t.Errorf("scope = %q", scope)
+					}
+					fmt.Fprint(w, `{"token":"t0k"}`)
+					return
+				}
+
+				if r.Header.Get("Authorization") != "Bearer t0k" {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.test"`, server.URL))
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				switch r.Method {
+				case http.MethodHead:
+					if r.URL.Path != "/v2/org/api/manifests/pr-12-abc1234" || !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+						t.Errorf("unexpected HEAD %s accept %q", r.URL.Path, r.Header.Get("Accept"))
+					}
+					w.Header().Set("Docker-Content-Digest", "sha256:feed")
+					w.WriteHeader(tt.headStatus)
+				case http.MethodDelete:
+					deleted = r.URL.Path
+					w.WriteHeader(tt.deleteStatus)
+				}
+			}))
+			defer server.Close()
+
+			client := NewRegistryClient("bot", "secret")
+			client.scheme = "http"
+			host := strings.TrimPrefix(server.URL, "http://")
+
+			err := client.DeleteImage(context.Background(), host+"/org/api:pr-12-abc1234")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteImage() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.deleteStatus != 0 && deleted != "/v2/org/api/manifests/sha256:feed" {
+				t.Errorf("deleted %q, want the manifest digest", deleted)
+			}
+		})
+	}
+}
+
+func TestRegistryClientResolveDigestWithProjectCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "project-bot" || pass != "project-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodHead || r.URL.Path != "/v2/org/api/manifests/v1.2.0" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Docker-Content-Digest", "sha256:feed")
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	client := NewRegistryClient("platform", "platform-secret")
+	client.scheme = "http"
+	if _, err := client.ResolveDigest(context.Background(), host+"/org/api:v1.2.0"); err == nil {
+		t.Fatal("ResolveDigest() with the platform's credentials succeeded, want rejected")
+	}
+
+	scoped := client.WithCredentials([]db.RegistryAuth{{Registry: host, Username: "project-bot", Password: "project-secret"}})
+	digest, err := scoped.ResolveDigest(context.Background(), host+"/org/api:v1.2.0")
+	if err != nil {
+		t.Fatalf("ResolveDigest() error = %v", err)
+	}
+	if digest != "sha256:feed" {
+		t.Errorf("ResolveDigest() = %q, want sha256:feed", digest)
+	}
+}
@@ -0,0 +1,17 @@
+// Package pricing holds the prices of the plan usage is billed at. The
+// usage API reports costs with them and deployment annotations estimate
+// cost deltas with them, so the two agree.
+package pricing
+
+// Plan prices in USD (in a real system, these would come from a billing service)
+const (
+	ProPlanBase      = 20.00
+	ComputePerGBHour = 0.05
+	BuildPerMinute   = 0.01
+	StoragePerGB     = 0.25
+	BandwidthPerGB   = 0.10
+)
+
+// HoursPerMonth is the average number of hours in a month, for monthly
+// estimates
+const HoursPerMonth = 730
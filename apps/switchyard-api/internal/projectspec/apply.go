@@ -0,0 +1,132 @@
+package projectspec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/hosts"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ApplyOptions control how a spec is applied
+type ApplyOptions struct {
+	DryRun bool // Report the changes without making them
+	// Prune deletes environment variables and dependencies the spec does not
+	// list. Services, domains and addons are never deleted by a spec.
+	Prune     bool
+	UserID    *uuid.UUID
+	UserEmail string
+}
+
+// Result reports the changes an apply made, or would make on a dry run
+type Result struct {
+	types.ProjectSpecApplyResult
+
+	// Domains are the custom domains created, which still need routing
+	Domains []*types.CustomDomain `json:"-"`
+}
+
+// Manager exports projects as specs and applies specs to them
+type Manager struct {
+	repos    *db.Repositories
+	addons   *addons.AddonService
+	logger   *logrus.Logger
+	reserved *hosts.Reserved
+}
+
+// NewManager creates the project spec manager
+func NewManager(repos *db.Repositories, addonService *addons.AddonService, logger *logrus.Logger) *Manager {
+	return &Manager{
+		repos:  repos,
+		addons: addonService,
+		logger: logger,
+	}
+}
+
+// SetReservedHosts sets the hostnames specs may not claim as domains
+func (m *Manager) SetReservedHosts(reserved *hosts.Reserved) {
+	m.reserved = reserved
+}
+
+// Apply brings a project to a spec. Applying the same spec twice makes no
+// changes the second time. Services, variables, dependencies and domains are
+// written in one transaction; addons are provisioned afterwards, and addon
+// failures are reported as warnings.
+func (m *Manager) Apply(ctx context.Context, project *types.Project, spec *types.ProjectSpec, opts ApplyOptions) (*Result, error) {
+	if err := Validate(spec); err != nil {
+		return nil, err
+	}
+	if spec.Metadata.Name != project.Slug {
+		return nil, &InvalidSpecError{Problems: []string{
+			fmt.Sprintf("metadata.name %s does not match project %s", spec.Metadata.Name, project.Slug),
+		}}
+	}
+
+	st, err := m.loadState(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkDomains(ctx, spec, st); err != nil {
+		return nil, err
+	}
+	p, err := m.plan(spec, st, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{ProjectSpecApplyResult: types.ProjectSpecApplyResult{
+		DryRun:   opts.DryRun,
+		Changes:  p.changes(),
+		Warnings: p.warnings,
+	}}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if len(p.steps) > 0 {
+		err = m.repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+			for _, s := range p.steps {
+				if err := s.run(ctx, tx); err != nil {
+					return fmt.Errorf("failed to %s: %w", describe(s.change), err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Domains = p.domains
+	}
+
+	for _, s := range p.addonSteps {
+		if err := s.run(ctx, nil); err != nil {
+			m.logger.WithError(err).WithField("project", project.Slug).Warn("Failed to apply addon change")
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Failed to %s: %v", describe(s.change), err))
+		}
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"project": project.Slug,
+		"changes": len(result.Changes),
+		"prune":   opts.Prune,
+	}).Info("Applied project spec")
+
+	return result, nil
+}
+
+// describe names a change for errors and logs
+func describe(change types.ProjectSpecChange) string {
+	name := change.Name
+	if change.Service != "" {
+		name = change.Service + "/" + name
+	}
+	if change.Environment != "" {
+		name += " (" + change.Environment + ")"
+	}
+	return fmt.Sprintf("%s %s %s", change.Action, change.Kind, name)
+}
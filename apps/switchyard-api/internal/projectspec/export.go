@@ -0,0 +1,124 @@
+package projectspec
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Export describes a project as a spec. Secret values are left out, and
+// platform domains and preview databases are not part of the spec.
+func (m *Manager) Export(ctx context.Context, project *types.Project) (*types.ProjectSpec, error) {
+	st, err := m.loadState(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &types.ProjectSpec{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata:   types.ProjectSpecMetadata{Name: project.Slug},
+		Spec: types.ProjectSpecConfig{
+			Services: []types.ProjectServiceSpec{},
+		},
+	}
+
+	for _, service := range st.sortedServices() {
+		svc := types.ProjectServiceSpec{
+			Name:    service.Name,
+			GitRepo: service.GitRepo,
+			AppPath: service.AppPath,
+			Build:   service.BuildConfig,
+			AutoDeploy: &types.ProjectAutoDeploySpec{
+				Enabled:     service.AutoDeploy,
+				Branch:      service.AutoDeployBranch,
+				Environment: service.AutoDeployEnv,
+			},
+		}
+		if len(service.Labels) > 0 {
+			svc.Labels = service.Labels
+		}
+		if !k8s.SchedulesAnywhere(service.Scheduling) {
+			svc.Scheduling = service.Scheduling
+		}
+		svc.GPU = service.GPU
+		svc.Command = service.Command
+		svc.Shutdown = service.Shutdown
+		if service.HighAvailability {
+			svc.HighAvailability = &service.HighAvailability
+		}
+
+		for _, dep := range st.dependencies[service.ID] {
+			if target := st.serviceByID(dep.DependsOnServiceID); target != nil {
+				svc.DependsOn = append(svc.DependsOn, types.ProjectDependencySpec{
+					Service: target.Name,
+					Type:    string(dep.DependencyType),
+				})
+			}
+		}
+
+		for _, ev := range st.envVars[service.ID] {
+			envName, ok := st.environmentName(ev.EnvironmentID)
+			if !ok {
+				continue
+			}
+			exported := types.ProjectEnvVarSpec{Key: ev.Key, Secret: ev.IsSecret, Environment: envName}
+			if !ev.IsSecret {
+				exported.Value = ev.Value
+			}
+			svc.Env = append(svc.Env, exported)
+		}
+		sort.SliceStable(svc.Env, func(i, j int) bool {
+			if svc.Env[i].Environment != svc.Env[j].Environment {
+				return svc.Env[i].Environment < svc.Env[j].Environment
+			}
+			return svc.Env[i].Key < svc.Env[j].Key
+		})
+
+		for _, domain := range st.domains[service.ID] {
+			envName, ok := st.environmentName(&domain.EnvironmentID)
+			if !ok || domain.IsPlatformDomain {
+				continue
+			}
+			svc.Domains = append(svc.Domains, types.ProjectDomainSpec{
+				Domain:      domain.Domain,
+				Environment: envName,
+				TLSEnabled:  domain.TLSEnabled,
+				TLSIssuer:   domain.TLSIssuer,
+			})
+		}
+
+		spec.Spec.Services = append(spec.Spec.Services, svc)
+	}
+
+	for _, addon := range st.sortedAddons() {
+		if strings.HasPrefix(addon.K8sNamespace, previews.NamespacePrefix) {
+			continue
+		}
+		envName, ok := st.environmentName(addon.EnvironmentID)
+		if !ok {
+			continue
+		}
+		exported := types.ProjectAddonSpec{
+			Name:        addon.Name,
+			Type:        addon.Type,
+			Environment: envName,
+			Config:      addon.Config,
+		}
+		for _, binding := range st.bindings[addon.ID] {
+			if service := st.serviceByID(binding.ServiceID); service != nil {
+				exported.Bindings = append(exported.Bindings, types.ProjectAddonBindingSpec{
+					Service: service.Name,
+					EnvVar:  binding.EnvVarName,
+				})
+			}
+		}
+		spec.Spec.Addons = append(spec.Spec.Addons, exported)
+	}
+
+	return spec, nil
+}
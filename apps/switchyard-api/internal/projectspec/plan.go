@@ -0,0 +1,844 @@
+package projectspec
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// state is what a project holds of the parts a spec describes
+type state struct {
+	project      *types.Project
+	environments map[string]*types.Environment // By name
+	services     map[string]*types.Service     // By name
+	envVars      map[uuid.UUID][]*types.EnvironmentVariable
+	domains      map[uuid.UUID][]types.CustomDomain
+	dependencies map[uuid.UUID][]*db.ServiceDependency
+	addons       map[string]*types.DatabaseAddon // By name
+	bindings     map[uuid.UUID][]*types.DatabaseAddonBinding
+	// takenDomains are domains of the spec already used by another service
+	// or environment
+	takenDomains map[string]bool
+}
+
+func (m *Manager) loadState(ctx context.Context, project *types.Project) (*state, error) {
+	st := &state{
+		project:      project,
+		environments: make(map[string]*types.Environment),
+		services:     make(map[string]*types.Service),
+		envVars:      make(map[uuid.UUID][]*types.EnvironmentVariable),
+		domains:      make(map[uuid.UUID][]types.CustomDomain),
+		dependencies: make(map[uuid.UUID][]*db.ServiceDependency),
+		addons:       make(map[string]*types.DatabaseAddon),
+		bindings:     make(map[uuid.UUID][]*types.DatabaseAddonBinding),
+		takenDomains: make(map[string]bool),
+	}
+
+	environments, err := m.repos.Environments.ListByProject(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+	for _, env := range environments {
+		st.environments[env.Name] = env
+	}
+
+	services, err := m.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, service := range services {
+		st.services[service.Name] = service
+
+		vars, err := m.repos.EnvVars.List(ctx, service.ID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list environment variables of %s: %w", service.Name, err)
+		}
+		st.envVars[service.ID] = vars
+
+		domains, err := m.repos.CustomDomains.GetByServiceID(ctx, service.ID.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list domains of %s: %w", service.Name, err)
+		}
+		st.domains[service.ID] = domains
+
+		deps, err := m.repos.ServiceDependencies.GetByService(ctx, service.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dependencies of %s: %w", service.Name, err)
+		}
+		st.dependencies[service.ID] = deps
+	}
+
+	projectAddons, err := m.repos.DatabaseAddons.ListByProject(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addons: %w", err)
+	}
+	for _, addon := range projectAddons {
+		if addon.Status == types.DatabaseAddonStatusDeleting || addon.Status == types.DatabaseAddonStatusDeleted {
+			continue
+		}
+		st.addons[addon.Name] = addon
+
+		bindings, err := m.repos.DatabaseAddons.GetBindingsByAddon(ctx, addon.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bindings of %s: %w", addon.Name, err)
+		}
+		for _, binding := range bindings {
+			if binding.Status == types.DatabaseAddonBindingStatusActive {
+				st.bindings[addon.ID] = append(st.bindings[addon.ID], binding)
+			}
+		}
+	}
+
+	return st, nil
+}
+
+// checkDomains records the domains of a spec that are in use elsewhere
+func (m *Manager) checkDomains(ctx context.Context, spec *types.ProjectSpec, st *state) error {
+	for _, svc := range spec.Spec.Services {
+		for _, domain := range svc.Domains {
+			if st.findDomain(svc.Name, domain) != nil {
+				continue
+			}
+			exists, err := m.repos.CustomDomains.Exists(ctx, domain.Domain)
+			if err != nil {
+				return fmt.Errorf("failed to check domain %s: %w", domain.Domain, err)
+			}
+			if exists {
+				st.takenDomains[domain.Domain] = true
+			}
+		}
+	}
+	return nil
+}
+
+// findDomain returns the domain of a service in the environment of a spec
+// domain, or nil
+func (st *state) findDomain(serviceName string, domain types.ProjectDomainSpec) *types.CustomDomain {
+	service, env := st.services[serviceName], st.environments[domain.Environment]
+	if service == nil || env == nil {
+		return nil
+	}
+	for i := range st.domains[service.ID] {
+		existing := &st.domains[service.ID][i]
+		if existing.Domain == domain.Domain && existing.EnvironmentID == env.ID {
+			return existing
+		}
+	}
+	return nil
+}
+
+// environmentName returns the name of an environment of the project, or ""
+// for nil, which stands for all environments. ok is false for environments of
+// other projects.
+func (st *state) environmentName(id *uuid.UUID) (name string, ok bool) {
+	if id == nil {
+		return "", true
+	}
+	for _, env := range st.environments {
+		if env.ID == *id {
+			return env.Name, true
+		}
+	}
+	return "", false
+}
+
+// serviceByID returns a service of the project, or nil
+func (st *state) serviceByID(id uuid.UUID) *types.Service {
+	for _, service := range st.services {
+		if service.ID == id {
+			return service
+		}
+	}
+	return nil
+}
+
+func (st *state) sortedServices() []*types.Service {
+	services := make([]*types.Service, 0, len(st.services))
+	for _, service := range st.services {
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+func (st *state) sortedAddons() []*types.DatabaseAddon {
+	list := make([]*types.DatabaseAddon, 0, len(st.addons))
+	for _, addon := range st.addons {
+		list = append(list, addon)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// step is one change of a plan with the write that makes it
+type step struct {
+	change types.ProjectSpecChange
+	run    func(ctx context.Context, tx *db.Repositories) error
+}
+
+// plan is the changes that bring a project to a spec. Steps run in one
+// transaction; addon steps run after it, as addons are provisioned outside
+// the database.
+type plan struct {
+	steps      []step
+	addonSteps []step
+	warnings   []string
+	// domains are the custom domains the steps create
+	domains []*types.CustomDomain
+}
+
+func (p *plan) changes() []types.ProjectSpecChange {
+	changes := make([]types.ProjectSpecChange, 0, len(p.steps)+len(p.addonSteps))
+	for _, s := range p.steps {
+		changes = append(changes, s.change)
+	}
+	for _, s := range p.addonSteps {
+		changes = append(changes, s.change)
+	}
+	return changes
+}
+
+// planner builds a plan. Steps are grouped by kind so services exist before
+// the variables, dependencies and domains that refer to them.
+type planner struct {
+	m    *Manager
+	st   *state
+	opts *ApplyOptions
+	errs problems
+
+	services     []step
+	envVars      []step
+	dependencies []step
+	domains      []step
+	plan         plan
+
+	// specServices are the services of the spec, existing or to be created
+	specServices map[string]*types.Service
+}
+
+// plan compares a validated spec with the project's state
+func (m *Manager) plan(spec *types.ProjectSpec, st *state, opts *ApplyOptions) (*plan, error) {
+	p := &planner{m: m, st: st, opts: opts, specServices: make(map[string]*types.Service)}
+
+	for i := range spec.Spec.Services {
+		p.planService(&spec.Spec.Services[i])
+	}
+	for i := range spec.Spec.Services {
+		svc := &spec.Spec.Services[i]
+		service := p.specServices[svc.Name]
+		p.planEnvVars(svc, service)
+		p.planDependencies(svc, service)
+		p.planDomains(svc, service)
+	}
+	p.checkCycles(spec)
+	for i := range spec.Spec.Addons {
+		p.planAddon(&spec.Spec.Addons[i])
+	}
+
+	if err := p.errs.err(); err != nil {
+		return nil, err
+	}
+
+	p.plan.steps = append(p.plan.steps, p.services...)
+	p.plan.steps = append(p.plan.steps, p.envVars...)
+	p.plan.steps = append(p.plan.steps, p.dependencies...)
+	p.plan.steps = append(p.plan.steps, p.domains...)
+	return &p.plan, nil
+}
+
+// service returns a service of the spec or the project by name, or nil
+func (p *planner) service(name string) *types.Service {
+	if service, ok := p.specServices[name]; ok {
+		return service
+	}
+	return p.st.services[name]
+}
+
+// environment resolves an environment name; "" stands for all environments
+func (p *planner) environment(name, field string) (env *types.Environment, ok bool) {
+	if name == "" {
+		return nil, true
+	}
+	env, ok = p.st.environments[name]
+	if !ok {
+		p.errs.add("%s: environment %s does not exist in project %s", field, name, p.st.project.Slug)
+	}
+	return env, ok
+}
+
+func (p *planner) planService(svc *types.ProjectServiceSpec) {
+	build := svc.Build
+	if build.Type == "" {
+		build.Type = types.BuildTypeAuto
+	}
+
+	scheduling := svc.Scheduling
+	if k8s.SchedulesAnywhere(scheduling) {
+		scheduling = nil
+	}
+	command := svc.Command
+	if command.IsEmpty() {
+		command = nil
+	}
+	shutdown := svc.Shutdown
+	if shutdown != nil && *shutdown == (types.ShutdownConfig{}) {
+		shutdown = nil
+	}
+
+	existing := p.st.services[svc.Name]
+	if existing == nil {
+		service := &types.Service{
+			ProjectID:   p.st.project.ID,
+			Name:        svc.Name,
+			GitRepo:     svc.GitRepo,
+			AppPath:     svc.AppPath,
+			BuildConfig: build,
+			AutoDeploy:  true,
+			Labels:      svc.Labels,
+			Scheduling:  scheduling,
+			GPU:         svc.GPU,
+			Command:     command,
+			Shutdown:    shutdown,
+		}
+		if svc.HighAvailability != nil {
+			service.HighAvailability = *svc.HighAvailability
+		}
+		if svc.AutoDeploy != nil {
+			service.AutoDeploy = svc.AutoDeploy.Enabled
+			service.AutoDeployBranch = svc.AutoDeploy.Branch
+			service.AutoDeployEnv = svc.AutoDeploy.Environment
+		}
+		p.specServices[svc.Name] = service
+
+		p.services = append(p.services, step{
+			change: types.ProjectSpecChange{Action: "create", Kind: "service", Name: svc.Name},
+			run: func(ctx context.Context, tx *db.Repositories) error {
+				if err := tx.Services.Create(service); err != nil {
+					return err
+				}
+				if service.Scheduling != nil {
+					if err := tx.Services.UpdateScheduling(ctx, service.ID, service.Scheduling); err != nil {
+						return err
+					}
+				}
+				if service.GPU != nil {
+					if err := tx.Services.UpdateGPU(ctx, service.ID, service.GPU); err != nil {
+						return err
+					}
+				}
+				if service.Command != nil {
+					if err := tx.Services.UpdateCommand(ctx, service.ID, service.Command); err != nil {
+						return err
+					}
+				}
+				if service.Shutdown != nil {
+					if err := tx.Services.UpdateShutdown(ctx, service.ID, service.Shutdown); err != nil {
+						return err
+					}
+				}
+				if service.HighAvailability {
+					if err := tx.Services.UpdateHighAvailability(ctx, service.ID, true); err != nil {
+						return err
+					}
+				}
+				if len(service.Labels) == 0 {
+					return nil
+				}
+				return tx.Services.UpdateLabels(ctx, service.ID, service.Labels)
+			},
+		})
+		return
+	}
+	p.specServices[svc.Name] = existing
+
+	updated := *existing
+	var fields []string
+	if updated.GitRepo != svc.GitRepo {
+		updated.GitRepo = svc.GitRepo
+		fields = append(fields, "git_repo")
+	}
+	if updated.AppPath != svc.AppPath {
+		updated.AppPath = svc.AppPath
+		fields = append(fields, "app_path")
+	}
+	if !reflect.DeepEqual(normalizeBuild(updated.BuildConfig), normalizeBuild(build)) {
+		updated.BuildConfig = build
+		fields = append(fields, "build")
+	}
+	if autoDeploy := svc.AutoDeploy; autoDeploy != nil {
+		if updated.AutoDeploy != autoDeploy.Enabled {
+			updated.AutoDeploy = autoDeploy.Enabled
+			fields = append(fields, "auto_deploy")
+		}
+		if autoDeploy.Branch != "" && updated.AutoDeployBranch != autoDeploy.Branch {
+			updated.AutoDeployBranch = autoDeploy.Branch
+			fields = append(fields, "auto_deploy_branch")
+		}
+		if autoDeploy.Environment != "" && updated.AutoDeployEnv != autoDeploy.Environment {
+			updated.AutoDeployEnv = autoDeploy.Environment
+			fields = append(fields, "auto_deploy_env")
+		}
+	}
+	labelsChanged := (len(existing.Labels) != 0 || len(svc.Labels) != 0) && !reflect.DeepEqual(existing.Labels, svc.Labels)
+	if labelsChanged {
+		updated.Labels = svc.Labels
+		fields = append(fields, "labels")
+	}
+	schedulingChanged := !reflect.DeepEqual(existing.Scheduling, scheduling)
+	if schedulingChanged {
+		updated.Scheduling = scheduling
+		fields = append(fields, "scheduling")
+	}
+	gpuChanged := !reflect.DeepEqual(existing.GPU, svc.GPU)
+	if gpuChanged {
+		updated.GPU = svc.GPU
+		fields = append(fields, "gpu")
+	}
+	commandChanged := !reflect.DeepEqual(existing.Command, command)
+	if commandChanged {
+		updated.Command = command
+		fields = append(fields, "command")
+	}
+	shutdownChanged := !reflect.DeepEqual(existing.Shutdown, shutdown)
+	if shutdownChanged {
+		updated.Shutdown = shutdown
+		fields = append(fields, "shutdown")
+	}
+	haChanged := svc.HighAvailability != nil && existing.HighAvailability != *svc.HighAvailability
+	if haChanged {
+		updated.HighAvailability = *svc.HighAvailability
+		fields = append(fields, "high_availability")
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	p.services = append(p.services, step{
+		change: types.ProjectSpecChange{Action: "update", Kind: "service", Name: svc.Name, Fields: fields},
+		run: func(ctx context.Context, tx *db.Repositories) error {
+			if err := tx.Services.Update(ctx, &updated); err != nil {
+				return err
+			}
+			if schedulingChanged {
+				if err := tx.Services.UpdateScheduling(ctx, updated.ID, updated.Scheduling); err != nil {
+					return err
+				}
+			}
+			if gpuChanged {
+				if err := tx.Services.UpdateGPU(ctx, updated.ID, updated.GPU); err != nil {
+					return err
+				}
+			}
+			if commandChanged {
+				if err := tx.Services.UpdateCommand(ctx, updated.ID, updated.Command); err != nil {
+					return err
+				}
+			}
+			if shutdownChanged {
+				if err := tx.Services.UpdateShutdown(ctx, updated.ID, updated.Shutdown); err != nil {
+					return err
+				}
+			}
+			if haChanged {
+				if err := tx.Services.UpdateHighAvailability(ctx, updated.ID, updated.HighAvailability); err != nil {
+					return err
+				}
+			}
+			if !labelsChanged {
+				return nil
+			}
+			return tx.Services.UpdateLabels(ctx, updated.ID, updated.Labels)
+		},
+	})
+}
+
+// normalizeBuild drops empty build args so configs read from YAML and the
+// database compare equal
+func normalizeBuild(build types.BuildConfig) types.BuildConfig {
+	if len(build.BuildArgs) == 0 {
+		build.BuildArgs = nil
+	}
+	return build
+}
+
+func (p *planner) planEnvVars(svc *types.ProjectServiceSpec, service *types.Service) {
+	existing := make(map[string]*types.EnvironmentVariable)
+	for _, ev := range p.st.envVars[service.ID] {
+		existing[envVarScope(ev.EnvironmentID, ev.Key)] = ev
+	}
+
+	wanted := make(map[string]bool)
+	for i, spec := range svc.Env {
+		spec := spec
+		env, ok := p.environment(spec.Environment, fmt.Sprintf("service %s env %s", svc.Name, spec.Key))
+		if !ok {
+			continue
+		}
+		var envID *uuid.UUID
+		if env != nil {
+			envID = &env.ID
+		}
+		scope := envVarScope(envID, spec.Key)
+		wanted[scope] = true
+		change := types.ProjectSpecChange{Kind: "env_var", Service: svc.Name, Name: spec.Key, Environment: spec.Environment}
+
+		ev := existing[scope]
+		if ev == nil {
+			if spec.Value == "" {
+				p.errs.add("spec.services[%s].env[%d]: secret %s has no value and is not set yet", svc.Name, i, spec.Key)
+				continue
+			}
+			change.Action = "create"
+			p.envVars = append(p.envVars, step{
+				change: change,
+				run: func(ctx context.Context, tx *db.Repositories) error {
+					return tx.EnvVars.Create(ctx, &types.EnvironmentVariable{
+						ServiceID:      service.ID,
+						EnvironmentID:  envID,
+						Key:            spec.Key,
+						Value:          spec.Value,
+						IsSecret:       spec.Secret,
+						CreatedBy:      p.opts.UserID,
+						CreatedByEmail: p.opts.UserEmail,
+					})
+				},
+			})
+			continue
+		}
+
+		updated := *ev
+		// A secret without a value keeps the one set
+		if spec.Value != "" && ev.Value != spec.Value {
+			updated.Value = spec.Value
+			change.Fields = append(change.Fields, "value")
+		}
+		if ev.IsSecret != spec.Secret {
+			updated.IsSecret = spec.Secret
+			change.Fields = append(change.Fields, "secret")
+		}
+		if len(change.Fields) == 0 {
+			continue
+		}
+		change.Action = "update"
+		p.envVars = append(p.envVars, step{
+			change: change,
+			run: func(ctx context.Context, tx *db.Repositories) error {
+				return tx.EnvVars.Update(ctx, &updated)
+			},
+		})
+	}
+
+	if !p.opts.Prune {
+		return
+	}
+	for _, ev := range p.st.envVars[service.ID] {
+		if wanted[envVarScope(ev.EnvironmentID, ev.Key)] {
+			continue
+		}
+		envName, ok := p.st.environmentName(ev.EnvironmentID)
+		if !ok {
+			continue
+		}
+		id := ev.ID
+		p.envVars = append(p.envVars, step{
+			change: types.ProjectSpecChange{Action: "delete", Kind: "env_var", Service: svc.Name, Name: ev.Key, Environment: envName},
+			run: func(ctx context.Context, tx *db.Repositories) error {
+				return tx.EnvVars.Delete(ctx, id)
+			},
+		})
+	}
+}
+
+func envVarScope(envID *uuid.UUID, key string) string {
+	if envID == nil {
+		return "/" + key
+	}
+	return envID.String() + "/" + key
+}
+
+func (p *planner) planDependencies(svc *types.ProjectServiceSpec, service *types.Service) {
+	existing := make(map[uuid.UUID]*db.ServiceDependency)
+	for _, dep := range p.st.dependencies[service.ID] {
+		existing[dep.DependsOnServiceID] = dep
+	}
+
+	wanted := make(map[uuid.UUID]bool)
+	for _, spec := range svc.DependsOn {
+		target := p.service(spec.Service)
+		if target == nil {
+			p.errs.add("service %s depends on %s, which is neither in the spec nor in project %s", svc.Name, spec.Service, p.st.project.Slug)
+			continue
+		}
+		depType := db.DependencyType(spec.Type)
+		if depType == "" {
+			depType = db.DependencyTypeRuntime
+		}
+		change := types.ProjectSpecChange{Action: "create", Kind: "dependency", Service: svc.Name, Name: spec.Service}
+
+		dep := existing[target.ID]
+		if target.ID != uuid.Nil {
+			wanted[target.ID] = true
+		}
+		if dep != nil && dep.DependencyType == depType {
+			continue
+		}
+		if dep != nil {
+			change.Action = "update"
+			change.Fields = []string{"type"}
+		}
+		p.dependencies = append(p.dependencies, step{
+			change: change,
+			run: func(ctx context.Context, tx *db.Repositories) error {
+				if dep != nil {
+					if err := tx.ServiceDependencies.Delete(ctx, service.ID, target.ID); err != nil {
+						return err
+					}
+				}
+				return tx.ServiceDependencies.Create(ctx, &db.ServiceDependency{
+					ServiceID:          service.ID,
+					DependsOnServiceID: target.ID,
+					DependencyType:     depType,
+				})
+			},
+		})
+	}
+
+	if !p.opts.Prune {
+		return
+	}
+	for _, dep := range p.st.dependencies[service.ID] {
+		target := p.st.serviceByID(dep.DependsOnServiceID)
+		if wanted[dep.DependsOnServiceID] || target == nil {
+			continue
+		}
+		dependsOnID := dep.DependsOnServiceID
+		p.dependencies = append(p.dependencies, step{
+			change: types.ProjectSpecChange{Action: "delete", Kind: "dependency", Service: svc.Name, Name: target.Name},
+			run: func(ctx context.Context, tx *db.Repositories) error {
+				return tx.ServiceDependencies.Delete(ctx, service.ID, dependsOnID)
+			},
+		})
+	}
+}
+
+// checkCycles reports dependencies that would form a cycle once applied
+func (p *planner) checkCycles(spec *types.ProjectSpec) {
+	graph := make(map[string][]string)
+	for _, service := range p.st.services {
+		for _, dep := range p.st.dependencies[service.ID] {
+			if target := p.st.serviceByID(dep.DependsOnServiceID); target != nil {
+				graph[service.Name] = append(graph[service.Name], target.Name)
+			}
+		}
+	}
+	for _, svc := range spec.Spec.Services {
+		var targets []string
+		if !p.opts.Prune {
+			targets = graph[svc.Name]
+		}
+		for _, dep := range svc.DependsOn {
+			targets = append(targets, dep.Service)
+		}
+		graph[svc.Name] = targets
+	}
+
+	names := make([]string, 0, len(graph))
+	for name := range graph {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	marks := make(map[string]int)
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch marks[name] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		marks[name] = visiting
+		for _, target := range graph[name] {
+			if visit(target) {
+				return true
+			}
+		}
+		marks[name] = done
+		return false
+	}
+	for _, name := range names {
+		if visit(name) {
+			p.errs.add("dependencies of service %s form a cycle", name)
+			return
+		}
+	}
+}
+
+func (p *planner) planDomains(svc *types.ProjectServiceSpec, service *types.Service) {
+	for _, spec := range svc.Domains {
+		env, ok := p.environment(spec.Environment, fmt.Sprintf("service %s domain %s", svc.Name, spec.Domain))
+		if !ok {
+			continue
+		}
+		change := types.ProjectSpecChange{Kind: "domain", Service: svc.Name, Name: spec.Domain, Environment: spec.Environment}
+
+		existing := p.st.findDomain(svc.Name, spec)
+		if existing == nil {
+			if err := p.m.reserved.CheckClaim(spec.Domain, false); err != nil {
+				p.errs.add("service %s: %v", svc.Name, err)
+				continue
+			}
+			if p.st.takenDomains[spec.Domain] {
+				p.errs.add("service %s: domain %s is already in use", svc.Name, spec.Domain)
+				continue
+			}
+			domain := &types.CustomDomain{
+				EnvironmentID: env.ID,
+				Domain:        spec.Domain,
+				TLSEnabled:    spec.TLSEnabled,
+				TLSIssuer:     defaultTLSIssuer(spec),
+			}
+			p.plan.domains = append(p.plan.domains, domain)
+			change.Action = "create"
+			p.domains = append(p.domains, step{
+				change: change,
+				run: func(ctx context.Context, tx *db.Repositories) error {
+					domain.ServiceID = service.ID
+					return tx.CustomDomains.Create(ctx, domain)
+				},
+			})
+			continue
+		}
+
+		updated := *existing
+		if updated.TLSEnabled != spec.TLSEnabled {
+			updated.TLSEnabled = spec.TLSEnabled
+			change.Fields = append(change.Fields, "tls_enabled")
+		}
+		if spec.TLSIssuer != "" && updated.TLSIssuer != spec.TLSIssuer {
+			updated.TLSIssuer = spec.TLSIssuer
+			change.Fields = append(change.Fields, "tls_issuer")
+		}
+		if len(change.Fields) == 0 {
+			continue
+		}
+		change.Action = "update"
+		p.domains = append(p.domains, step{
+			change: change,
+			run: func(ctx context.Context, tx *db.Repositories) error {
+				return tx.CustomDomains.Update(ctx, &updated)
+			},
+		})
+	}
+}
+
+// defaultTLSIssuer returns the issuer of a domain, which defaults to the
+// production issuer in the production environment and to staging elsewhere
+func defaultTLSIssuer(spec types.ProjectDomainSpec) string {
+	switch {
+	case spec.TLSIssuer != "":
+		return spec.TLSIssuer
+	case spec.Environment == "production":
+		return "letsencrypt-prod"
+	default:
+		return "letsencrypt-staging"
+	}
+}
+
+func (p *planner) planAddon(spec *types.ProjectAddonSpec) {
+	env, ok := p.environment(spec.Environment, "addon "+spec.Name)
+	if !ok {
+		return
+	}
+
+	for _, binding := range spec.Bindings {
+		if p.service(binding.Service) == nil {
+			p.errs.add("addon %s is bound to %s, which is neither in the spec nor in project %s", spec.Name, binding.Service, p.st.project.Slug)
+		}
+	}
+
+	existing := p.st.addons[spec.Name]
+	if existing == nil {
+		if p.m.addons == nil {
+			p.plan.warnings = append(p.plan.warnings, fmt.Sprintf("Addon %s was not created: the addon service is not configured", spec.Name))
+			return
+		}
+		req := &addons.CreateAddonRequest{
+			ProjectID: p.st.project.ID,
+			Type:      spec.Type,
+			Name:      spec.Name,
+			Config:    spec.Config,
+			UserID:    p.opts.UserID,
+			UserEmail: p.opts.UserEmail,
+		}
+		if env != nil {
+			req.EnvironmentID = &env.ID
+		}
+		p.plan.addonSteps = append(p.plan.addonSteps, step{
+			change: types.ProjectSpecChange{Action: "create", Kind: "addon", Name: spec.Name, Environment: spec.Environment},
+			run: func(ctx context.Context, _ *db.Repositories) error {
+				_, err := p.m.addons.CreateAddon(ctx, req)
+				return err
+			},
+		})
+		if len(spec.Bindings) > 0 {
+			p.plan.warnings = append(p.plan.warnings, fmt.Sprintf("Addon %s is bound once it is ready: apply the spec again", spec.Name))
+		}
+		return
+	}
+
+	existingEnv, _ := p.st.environmentName(existing.EnvironmentID)
+	if existing.Type != spec.Type || existingEnv != spec.Environment {
+		p.plan.warnings = append(p.plan.warnings, fmt.Sprintf("Addon %s keeps its type and environment: they cannot be changed by applying a spec", spec.Name))
+	}
+
+	bound := make(map[uuid.UUID]*types.DatabaseAddonBinding)
+	for _, binding := range p.st.bindings[existing.ID] {
+		bound[binding.ServiceID] = binding
+	}
+	for _, binding := range spec.Bindings {
+		service := p.service(binding.Service)
+		if service == nil {
+			continue
+		}
+		current := bound[service.ID]
+		if current != nil && service.ID != uuid.Nil && current.EnvVarName == binding.EnvVar {
+			continue
+		}
+		if !existing.IsAvailable() || p.m.addons == nil {
+			p.plan.warnings = append(p.plan.warnings, fmt.Sprintf("Addon %s is not ready to be bound to %s: apply the spec again once it is", spec.Name, binding.Service))
+			continue
+		}
+
+		change := types.ProjectSpecChange{Action: "create", Kind: "addon_binding", Service: binding.Service, Name: spec.Name}
+		if current != nil {
+			change.Action = "update"
+			change.Fields = []string{"env_var"}
+		}
+		addonID, envVar := existing.ID, binding.EnvVar
+		p.plan.addonSteps = append(p.plan.addonSteps, step{
+			change: change,
+			run: func(ctx context.Context, _ *db.Repositories) error {
+				if current != nil {
+					if err := p.m.addons.DeleteBinding(ctx, addonID, service.ID); err != nil {
+						return err
+					}
+				}
+				_, err := p.m.addons.CreateBinding(ctx, addonID, service.ID, envVar)
+				return err
+			},
+		})
+	}
+}
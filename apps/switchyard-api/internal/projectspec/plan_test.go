@@ -0,0 +1,312 @@
+package projectspec
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/hosts"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// testState is a project with an api service that matches validSpec, apart
+// from the worker service it does not have yet
+func testState() *state {
+	project := &types.Project{ID: uuid.New(), Slug: "shop"}
+	production := &types.Environment{ID: uuid.New(), ProjectID: project.ID, Name: "production"}
+	staging := &types.Environment{ID: uuid.New(), ProjectID: project.ID, Name: "staging"}
+	api := &types.Service{
+		ID:               uuid.New(),
+		ProjectID:        project.ID,
+		Name:             "api",
+		GitRepo:          "https://github.com/acme/shop",
+		AppPath:          "apps/api",
+		BuildConfig:      types.BuildConfig{Type: types.BuildTypeAuto},
+		AutoDeploy:       true,
+		AutoDeployBranch: "main",
+		AutoDeployEnv:    "production",
+	}
+
+	return &state{
+		project:      project,
+		environments: map[string]*types.Environment{"production": production, "staging": staging},
+		services:     map[string]*types.Service{"api": api},
+		envVars: map[uuid.UUID][]*types.EnvironmentVariable{
+			api.ID: {
+				{ID: uuid.New(), ServiceID: api.ID, Key: "LOG_LEVEL", Value: "info"},
+				{ID: uuid.New(), ServiceID: api.ID, EnvironmentID: &staging.ID, Key: "LOG_LEVEL", Value: "debug"},
+				{ID: uuid.New(), ServiceID: api.ID, Key: "API_KEY", Value: "s3cret", IsSecret: true},
+				{ID: uuid.New(), ServiceID: api.ID, Key: "LEGACY_FLAG", Value: "1"},
+			},
+		},
+		domains: map[uuid.UUID][]types.CustomDomain{
+			api.ID: {{ServiceID: api.ID, EnvironmentID: production.ID, Domain: "api.acme.com", TLSEnabled: true, TLSIssuer: "letsencrypt-prod"}},
+		},
+		dependencies: map[uuid.UUID][]*db.ServiceDependency{},
+		addons:       map[string]*types.DatabaseAddon{},
+		bindings:     map[uuid.UUID][]*types.DatabaseAddonBinding{},
+		takenDomains: map[string]bool{},
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(spec *types.ProjectSpec, st *state)
+		opts      ApplyOptions
+		want      []string // Described changes, in order
+		wantError string
+	}{
+		{
+			name: "new service and its dependency",
+			want: []string{
+				"create service worker",
+				"create dependency api/worker",
+			},
+		},
+		{
+			name: "applied spec makes no changes",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+			},
+		},
+		{
+			name: "empty scheduling makes no changes",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+				spec.Spec.Services[0].Scheduling = &types.SchedulingConfig{}
+			},
+		},
+		{
+			name: "changed scheduling",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+				spec.Spec.Services[0].Scheduling = &types.SchedulingConfig{
+					NodeSelector: map[string]string{"karpenter.sh/nodepool": "spot"},
+				}
+			},
+			want: []string{"update service api"},
+		},
+		{
+			name: "added GPUs",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+				spec.Spec.Services[0].GPU = &types.GPUConfig{Count: 1}
+			},
+			want: []string{"update service api"},
+		},
+		{
+			name: "custom command",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+				spec.Spec.Services[0].Command = &types.ContainerCommand{Args: []string{"worker"}}
+			},
+			want: []string{"update service api"},
+		},
+		{
+			name: "graceful shutdown",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+				spec.Spec.Services[0].Shutdown = &types.ShutdownConfig{PreStop: &types.PreStopHook{SleepSeconds: 10}, MaxUnavailable: "0"}
+			},
+			want: []string{"update service api"},
+		},
+		{
+			name: "high availability",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+				enabled := true
+				spec.Spec.Services[0].HighAvailability = &enabled
+			},
+			want: []string{"update service api"},
+		},
+		{
+			name: "empty command makes no changes",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+				spec.Spec.Services[0].Command = &types.ContainerCommand{}
+			},
+		},
+		{
+			name: "changed service and variables",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services[0].AppPath = "services/api"
+				spec.Spec.Services[0].Labels = map[string]string{"tier": "backend"}
+				spec.Spec.Services[0].Env[1].Value = "warn"
+				spec.Spec.Services[0].Env = append(spec.Spec.Services[0].Env, types.ProjectEnvVarSpec{Key: "REGION", Value: "mx"})
+			},
+			want: []string{
+				"update service api",
+				"create service worker",
+				"update env_var api/LOG_LEVEL (staging)",
+				"create env_var api/REGION",
+				"create dependency api/worker",
+			},
+		},
+		{
+			name: "prune deletes unlisted variables and dependencies",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				api := st.services["api"]
+				other := &types.Service{ID: uuid.New(), Name: "cache"}
+				st.services["cache"] = other
+				st.dependencies[api.ID] = []*db.ServiceDependency{{ServiceID: api.ID, DependsOnServiceID: other.ID, DependencyType: db.DependencyTypeRuntime}}
+			},
+			opts: ApplyOptions{Prune: true},
+			want: []string{
+				"create service worker",
+				"delete env_var api/LEGACY_FLAG",
+				"create dependency api/worker",
+				"delete dependency api/cache",
+			},
+		},
+		{
+			name: "changed dependency type",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				cache := &types.Service{ID: uuid.New(), Name: "cache"}
+				st.services["cache"] = cache
+				api := st.services["api"]
+				st.dependencies[api.ID] = []*db.ServiceDependency{{ServiceID: api.ID, DependsOnServiceID: cache.ID, DependencyType: db.DependencyTypeRuntime}}
+				spec.Spec.Services[0].DependsOn = []types.ProjectDependencySpec{{Service: "cache", Type: "build"}}
+			},
+			want: []string{"update dependency api/cache"},
+		},
+		{
+			name: "new domain and TLS change",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services[0].Domains[0].TLSEnabled = false
+				spec.Spec.Services[1].Domains = []types.ProjectDomainSpec{{Domain: "jobs.acme.com", Environment: "staging"}}
+			},
+			want: []string{
+				"create service worker",
+				"create dependency api/worker",
+				"update domain api/api.acme.com (production)",
+				"create domain worker/jobs.acme.com (staging)",
+			},
+		},
+		{
+			name: "new addon without the addon service",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services = spec.Spec.Services[:1]
+				spec.Spec.Services[0].DependsOn = nil
+				spec.Spec.Addons = []types.ProjectAddonSpec{{Name: "db", Type: types.DatabaseAddonTypePostgres}}
+			},
+		},
+		{
+			name: "unknown environment",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services[0].Env[1].Environment = "qa"
+			},
+			wantError: "environment qa does not exist",
+		},
+		{
+			name: "unknown dependency",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services[0].DependsOn[0].Service = "billing"
+			},
+			wantError: "depends on billing",
+		},
+		{
+			name: "secret that is not set yet",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services[0].Env[2].Key = "NEW_TOKEN"
+			},
+			wantError: "secret NEW_TOKEN has no value",
+		},
+		{
+			name: "domain in use",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services[1].Domains = []types.ProjectDomainSpec{{Domain: "www.acme.com", Environment: "production"}}
+				st.takenDomains["www.acme.com"] = true
+			},
+			wantError: "domain www.acme.com is already in use",
+		},
+		{
+			name: "domain of the platform",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services[1].Domains = []types.ProjectDomainSpec{{Domain: "api.enclii.dev", Environment: "production"}}
+			},
+			wantError: "reserved for the platform",
+		},
+		{
+			name: "dependency cycle",
+			modify: func(spec *types.ProjectSpec, st *state) {
+				spec.Spec.Services[1].DependsOn = []types.ProjectDependencySpec{{Service: "api"}}
+			},
+			wantError: "form a cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, st := validSpec(), testState()
+			spec.Spec.Addons = nil
+			if tt.modify != nil {
+				tt.modify(spec, st)
+			}
+			if err := Validate(spec); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			m := &Manager{reserved: hosts.NewReserved([]string{"https://api.enclii.dev"}, "enclii.dev")}
+			p, err := m.plan(spec, st, &tt.opts)
+			if tt.wantError != "" {
+				var invalid *InvalidSpecError
+				if !errors.As(err, &invalid) || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("plan() error = %v, want InvalidSpecError containing %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("plan() error = %v", err)
+			}
+
+			got := []string{}
+			for _, change := range p.changes() {
+				got = append(got, describe(change))
+			}
+			want := tt.want
+			if want == nil {
+				want = []string{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("changes = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPlanAddonWarnings(t *testing.T) {
+	spec, st := validSpec(), testState()
+	spec.Spec.Services = spec.Spec.Services[:1]
+	spec.Spec.Services[0].DependsOn = nil
+	st.addons["db"] = &types.DatabaseAddon{ID: uuid.New(), Name: "db", Type: types.DatabaseAddonTypeRedis, Status: types.DatabaseAddonStatusProvisioning}
+
+	p, err := (&Manager{}).plan(spec, st, &ApplyOptions{})
+	if err != nil {
+		t.Fatalf("plan() error = %v", err)
+	}
+	if len(p.changes()) != 0 {
+		t.Errorf("changes = %v, want none", p.changes())
+	}
+
+	want := []string{
+		"Addon db keeps its type and environment: they cannot be changed by applying a spec",
+		"Addon db is not ready to be bound to api: apply the spec again once it is",
+	}
+	if !reflect.DeepEqual(p.warnings, want) {
+		t.Errorf("warnings = %v, want %v", p.warnings, want)
+	}
+}
@@ -0,0 +1,184 @@
+// Package projectspec exports projects as declarative specs (enclii.yaml) and
+// applies specs back to them, so a project can be managed from a Git
+// repository.
+package projectspec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// APIVersion is the version exported specs are written with
+	APIVersion = "enclii.dev/v1"
+	// Kind is the kind of project specs
+	Kind = "Project"
+)
+
+var (
+	nameRegex   = regexp.MustCompile(`^[a-z0-9]([a-z0-9\-]{0,61}[a-z0-9])?$`)
+	envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	domainRegex = regexp.MustCompile(`^([a-z0-9]([a-z0-9\-]{0,61}[a-z0-9])?\.)+[a-z0-9]([a-z0-9\-]{0,61}[a-z0-9])?$`)
+)
+
+// InvalidSpecError lists the problems that keep a spec from being applied
+type InvalidSpecError struct {
+	Problems []string
+}
+
+func (e *InvalidSpecError) Error() string {
+	return "invalid project spec: " + strings.Join(e.Problems, "; ")
+}
+
+// problems collects the problems found in a spec
+type problems []string
+
+func (p *problems) add(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return &InvalidSpecError{Problems: p}
+}
+
+// Validate checks a spec on its own. References to environments and to
+// services outside the spec are checked against the project when planning.
+func Validate(spec *types.ProjectSpec) error {
+	var errs problems
+
+	if spec.APIVersion != APIVersion && spec.APIVersion != "enclii.dev/v1alpha" {
+		errs.add("apiVersion must be '%s' or 'enclii.dev/v1alpha'", APIVersion)
+	}
+	if spec.Kind != Kind {
+		errs.add("kind must be '%s'", Kind)
+	}
+	if spec.Metadata.Name == "" {
+		errs.add("metadata.name is required")
+	}
+
+	services := make(map[string]bool)
+	domains := make(map[string]bool)
+	for i, svc := range spec.Spec.Services {
+		field := fmt.Sprintf("spec.services[%d]", i)
+		if !nameRegex.MatchString(svc.Name) {
+			errs.add("%s.name must be a valid DNS name", field)
+		} else if services[svc.Name] {
+			errs.add("%s.name: duplicate service %s", field, svc.Name)
+		}
+		services[svc.Name] = true
+
+		if svc.GitRepo == "" {
+			errs.add("%s.gitRepo is required", field)
+		}
+		switch svc.Build.Type {
+		case "", types.BuildTypeAuto, types.BuildTypeDockerfile, types.BuildTypeBuildpack:
+		default:
+			errs.add("%s.build.type must be one of: auto, dockerfile, buildpack", field)
+		}
+		if a := svc.Build.Artifacts; a != "" && !strings.HasPrefix(a, "/") {
+			errs.add("%s.build.artifacts must be an absolute path in the image", field)
+		}
+		if err := k8s.ValidateBuildResources(svc.Build.Resources); err != nil {
+			errs.add("%s.build.resources: %v", field, err)
+		}
+		if err := k8s.ValidateScheduling(svc.Scheduling); err != nil {
+			errs.add("%s.scheduling: %v", field, err)
+		}
+		if err := k8s.ValidateGPU(svc.GPU); err != nil {
+			errs.add("%s.gpu: %v", field, err)
+		}
+		if err := k8s.ValidateCommand(svc.Command); err != nil {
+			errs.add("%s.command: %v", field, err)
+		}
+		if err := k8s.ValidateShutdown(svc.Shutdown); err != nil {
+			errs.add("%s.shutdown: %v", field, err)
+		}
+
+		dependencies := make(map[string]bool)
+		for j, dep := range svc.DependsOn {
+			depField := fmt.Sprintf("%s.dependsOn[%d]", field, j)
+			switch {
+			case dep.Service == "":
+				errs.add("%s.service is required", depField)
+			case dep.Service == svc.Name:
+				errs.add("%s: a service cannot depend on itself", depField)
+			case dependencies[dep.Service]:
+				errs.add("%s: duplicate dependency on %s", depField, dep.Service)
+			}
+			dependencies[dep.Service] = true
+
+			switch db.DependencyType(dep.Type) {
+			case "", db.DependencyTypeRuntime, db.DependencyTypeBuild, db.DependencyTypeData:
+			default:
+				errs.add("%s.type must be one of: runtime, build, data", depField)
+			}
+		}
+
+		vars := make(map[string]bool)
+		for j, ev := range svc.Env {
+			envField := fmt.Sprintf("%s.env[%d]", field, j)
+			if len(ev.Key) > 255 || !envKeyRegex.MatchString(ev.Key) {
+				errs.add("%s.key must start with a letter or underscore and contain only letters, digits and underscores", envField)
+			}
+			scoped := ev.Environment + "/" + ev.Key
+			if vars[scoped] {
+				errs.add("%s: duplicate variable %s", envField, ev.Key)
+			}
+			vars[scoped] = true
+			if ev.Value == "" && !ev.Secret {
+				errs.add("%s.value is required", envField)
+			}
+		}
+
+		for j, domain := range svc.Domains {
+			domainField := fmt.Sprintf("%s.domains[%d]", field, j)
+			if len(domain.Domain) > 253 || !domainRegex.MatchString(domain.Domain) {
+				errs.add("%s.domain is not a valid domain", domainField)
+			} else if domains[domain.Domain] {
+				errs.add("%s: duplicate domain %s", domainField, domain.Domain)
+			}
+			domains[domain.Domain] = true
+			if domain.Environment == "" {
+				errs.add("%s.environment is required", domainField)
+			}
+		}
+	}
+
+	addonNames := make(map[string]bool)
+	for i, addon := range spec.Spec.Addons {
+		field := fmt.Sprintf("spec.addons[%d]", i)
+		if !nameRegex.MatchString(addon.Name) {
+			errs.add("%s.name must be a valid DNS name", field)
+		} else if addonNames[addon.Name] {
+			errs.add("%s.name: duplicate addon %s", field, addon.Name)
+		}
+		addonNames[addon.Name] = true
+
+		switch addon.Type {
+		case types.DatabaseAddonTypePostgres, types.DatabaseAddonTypeRedis, types.DatabaseAddonTypeMySQL,
+			types.DatabaseAddonTypeMongoDB, types.DatabaseAddonTypeBucket:
+		default:
+			errs.add("%s.type must be one of: postgres, redis, mysql, mongodb, bucket", field)
+		}
+
+		for j, binding := range addon.Bindings {
+			bindingField := fmt.Sprintf("%s.bindings[%d]", field, j)
+			if binding.Service == "" {
+				errs.add("%s.service is required", bindingField)
+			}
+			if !envKeyRegex.MatchString(binding.EnvVar) {
+				errs.add("%s.envVar must be a valid environment variable name", bindingField)
+			}
+		}
+	}
+
+	return errs.err()
+}
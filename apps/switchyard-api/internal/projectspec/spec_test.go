@@ -0,0 +1,160 @@
+package projectspec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func validSpec() *types.ProjectSpec {
+	return &types.ProjectSpec{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata:   types.ProjectSpecMetadata{Name: "shop"},
+		Spec: types.ProjectSpecConfig{
+			Services: []types.ProjectServiceSpec{
+				{
+					Name:      "api",
+					GitRepo:   "https://github.com/acme/shop",
+					AppPath:   "apps/api",
+					DependsOn: []types.ProjectDependencySpec{{Service: "worker"}},
+					Env: []types.ProjectEnvVarSpec{
+						{Key: "LOG_LEVEL", Value: "info"},
+						{Key: "LOG_LEVEL", Value: "debug", Environment: "staging"},
+						{Key: "API_KEY", Secret: true},
+					},
+					Domains: []types.ProjectDomainSpec{{Domain: "api.acme.com", Environment: "production", TLSEnabled: true}},
+				},
+				{Name: "worker", GitRepo: "https://github.com/acme/shop", Build: types.BuildConfig{Type: types.BuildTypeDockerfile}},
+			},
+			Addons: []types.ProjectAddonSpec{
+				{Name: "db", Type: types.DatabaseAddonTypePostgres, Bindings: []types.ProjectAddonBindingSpec{{Service: "api", EnvVar: "DATABASE_URL"}}},
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(spec *types.ProjectSpec)
+		want   string // Substring of the problem; empty for a valid spec
+	}{
+		{name: "valid", modify: func(*types.ProjectSpec) {}},
+		{name: "wrong kind", modify: func(s *types.ProjectSpec) { s.Kind = "Service" }, want: "kind must be"},
+		{name: "wrong api version", modify: func(s *types.ProjectSpec) { s.APIVersion = "v2" }, want: "apiVersion must be"},
+		{name: "missing project name", modify: func(s *types.ProjectSpec) { s.Metadata.Name = "" }, want: "metadata.name is required"},
+		{
+			name:   "invalid service name",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].Name = "API_Server" },
+			want:   "spec.services[0].name must be a valid DNS name",
+		},
+		{
+			name:   "duplicate service",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[1].Name = "api" },
+			want:   "duplicate service api",
+		},
+		{
+			name:   "missing git repo",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[1].GitRepo = "" },
+			want:   "spec.services[1].gitRepo is required",
+		},
+		{
+			name:   "unknown build type",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[1].Build.Type = "nix" },
+			want:   "build.type must be one of",
+		},
+		{
+			name: "invalid toleration",
+			modify: func(s *types.ProjectSpec) {
+				s.Spec.Services[0].Scheduling = &types.SchedulingConfig{Tolerations: []types.Toleration{{Key: "spot", Effect: "Evict"}}}
+			},
+			want: "spec.services[0].scheduling: tolerations[0]",
+		},
+		{
+			name:   "invalid MIG profile",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].GPU = &types.GPUConfig{Count: 1, MIGProfile: "7g"} },
+			want:   "spec.services[0].gpu: mig_profile",
+		},
+		{
+			name:   "relative working directory",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].Command = &types.ContainerCommand{WorkingDir: "app"} },
+			want:   "spec.services[0].command: working_dir",
+		},
+		{
+			name:   "invalid max surge",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].Shutdown = &types.ShutdownConfig{MaxSurge: "200%"} },
+			want:   "spec.services[0].shutdown: max_surge",
+		},
+		{
+			name:   "self dependency",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].DependsOn[0].Service = "api" },
+			want:   "cannot depend on itself",
+		},
+		{
+			name:   "unknown dependency type",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].DependsOn[0].Type = "soft" },
+			want:   "dependsOn[0].type must be one of",
+		},
+		{
+			name:   "invalid variable key",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].Env[0].Key = "LOG-LEVEL" },
+			want:   "env[0].key must start with",
+		},
+		{
+			name:   "duplicate variable in an environment",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].Env[1].Environment = "" },
+			want:   "duplicate variable LOG_LEVEL",
+		},
+		{
+			name:   "plain variable without a value",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].Env[0].Value = "" },
+			want:   "env[0].value is required",
+		},
+		{
+			name:   "invalid domain",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].Domains[0].Domain = "localhost" },
+			want:   "domains[0].domain is not a valid domain",
+		},
+		{
+			name:   "domain without environment",
+			modify: func(s *types.ProjectSpec) { s.Spec.Services[0].Domains[0].Environment = "" },
+			want:   "domains[0].environment is required",
+		},
+		{
+			name:   "unknown addon type",
+			modify: func(s *types.ProjectSpec) { s.Spec.Addons[0].Type = "cassandra" },
+			want:   "spec.addons[0].type must be one of",
+		},
+		{
+			name:   "invalid binding variable",
+			modify: func(s *types.ProjectSpec) { s.Spec.Addons[0].Bindings[0].EnvVar = "1URL" },
+			want:   "bindings[0].envVar must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := validSpec()
+			tt.modify(spec)
+
+			err := Validate(spec)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var invalid *InvalidSpecError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Validate() error = %v, want InvalidSpecError", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
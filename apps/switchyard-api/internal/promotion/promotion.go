@@ -0,0 +1,190 @@
+// Package promotion moves releases along the promotion pipeline of their
+// project. Each environment may name the environment it promotes to (dev →
+// staging → prod); a release running in one is promoted by deploying the
+// same release, and so the same image, to the next. Promotions are recorded,
+// so the promotions of a release form its promotion chain. Environments with
+// auto-promote promote releases once they pass their soak there.
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+var (
+	// ErrNotValidated is returned when a release does not run in an
+	// environment it could be promoted from
+	ErrNotValidated = errors.New("release is not running in an environment it can be promoted from")
+
+	// ErrAmbiguousSource is returned when a release runs in several
+	// environments and none of them promotes to the target
+	ErrAmbiguousSource = errors.New("release runs in more than one environment; name the one to promote from")
+
+	// ErrPipelineLoop is returned when an environment would promote, through
+	// the pipeline, back to itself
+	ErrPipelineLoop = errors.New("the promotion pipeline would loop back to this environment")
+)
+
+// Source finds the environment a release is promoted to target from: the
+// environment named from, or else the one whose pipeline leads to target,
+// or else the only environment the release runs in. The release must be
+// the current deployment of its service there.
+func Source(ctx context.Context, repos *db.Repositories, release *types.Release, target *types.Environment, from string) (*types.Environment, error) {
+	envs, err := repos.Environments.ListByProject(target.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	var candidates []*types.Environment
+	for _, env := range envs {
+		if env.ID == target.ID {
+			continue
+		}
+		current, err := repos.Deployments.GetCurrentByServiceAndEnvironment(ctx, release.ServiceID, env.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current deployment: %w", err)
+		}
+		if current.ReleaseID == release.ID {
+			candidates = append(candidates, env)
+		}
+	}
+	return pickSource(candidates, envs, target, from)
+}
+
+// pickSource picks the environment to promote from among the candidates a
+// release runs in
+func pickSource(candidates []*types.Environment, envs []*types.Environment, target *types.Environment, from string) (*types.Environment, error) {
+	if from != "" {
+		for _, env := range candidates {
+			if env.Name == from {
+				return env, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: it does not run in %s", ErrNotValidated, from)
+	}
+
+	var leading []*types.Environment
+	for _, env := range candidates {
+		if leadsTo(envs, env, target) {
+			leading = append(leading, env)
+		}
+	}
+	switch {
+	case len(leading) == 1:
+		return leading[0], nil
+	case len(leading) == 0 && len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) == 0:
+		return nil, ErrNotValidated
+	}
+	return nil, ErrAmbiguousSource
+}
+
+// leadsTo reports whether promotions from env reach target through the
+// pipeline
+func leadsTo(envs []*types.Environment, env, target *types.Environment) bool {
+	byID := make(map[uuid.UUID]*types.Environment, len(envs))
+	for _, e := range envs {
+		byID[e.ID] = e
+	}
+	seen := map[uuid.UUID]bool{}
+	for next := env.PromotesTo; next != nil && !seen[*next]; {
+		if *next == target.ID {
+			return true
+		}
+		seen[*next] = true
+		e, ok := byID[*next]
+		if !ok {
+			return false
+		}
+		next = e.PromotesTo
+	}
+	return false
+}
+
+// CheckPipeline returns ErrPipelineLoop when env promoting to next would
+// make the pipeline loop
+func CheckPipeline(envs []*types.Environment, env, next *types.Environment) error {
+	if next.ID == env.ID || leadsTo(envs, next, env) {
+		return ErrPipelineLoop
+	}
+	return nil
+}
+
+// Replicas is the scale a promotion keeps: that of the service in the
+// target environment, or else one replica
+func Replicas(ctx context.Context, repos *db.Repositories, serviceID uuid.UUID, to *types.Environment) (int, error) {
+	current, err := repos.Deployments.GetCurrentByServiceAndEnvironment(ctx, serviceID, to.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current deployment: %w", err)
+	}
+	if current.Replicas > 0 {
+		return current.Replicas, nil
+	}
+	return 1, nil
+}
+
+// Promote deploys a release running in one environment to another and
+// records the promotion, in one transaction. Without replicas the
+// deployment keeps the scale given by Replicas. The deployment is pending;
+// the caller schedules it.
+func Promote(ctx context.Context, repos *db.Repositories, release *types.Release, from, to *types.Environment, replicas int, promotedBy string, auto bool) (*types.ReleasePromotion, *types.Deployment, error) {
+	if replicas <= 0 {
+		var err error
+		if replicas, err = Replicas(ctx, repos, release.ServiceID, to); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	now := time.Now()
+	deployment := &types.Deployment{
+		ID:            uuid.New(),
+		ReleaseID:     release.ID,
+		EnvironmentID: to.ID,
+		Replicas:      replicas,
+		Status:        types.DeploymentStatusPending,
+		Health:        types.HealthStatusUnknown,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	promotion := &types.ReleasePromotion{
+		ReleaseID:         release.ID,
+		FromEnvironmentID: from.ID,
+		FromEnvironment:   from.Name,
+		ToEnvironmentID:   to.ID,
+		ToEnvironment:     to.Name,
+		ImageURI:          release.ImageURI,
+		ImageDigest:       release.ImageDigest,
+		Auto:              auto,
+		PromotedBy:        promotedBy,
+	}
+
+	err := repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+		if err := tx.Deployments.Create(deployment); err != nil {
+			return fmt.Errorf("failed to create deployment: %w", err)
+		}
+		promotion.DeploymentID = &deployment.ID
+		if err := tx.ReleasePromotions.Create(ctx, promotion); err != nil {
+			return fmt.Errorf("failed to record promotion: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return promotion, deployment, nil
+}
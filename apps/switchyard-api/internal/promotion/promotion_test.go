@@ -0,0 +1,73 @@
+package promotion
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// pipeline returns environments named in order, each promoting to the next
+func pipeline(names ...string) []*types.Environment {
+	envs := make([]*types.Environment, len(names))
+	for i, name := range names {
+		envs[i] = &types.Environment{ID: uuid.New(), Name: name}
+	}
+	for i := 0; i+1 < len(envs); i++ {
+		envs[i].PromotesTo = &envs[i+1].ID
+	}
+	return envs
+}
+
+func TestPickSource(t *testing.T) {
+	envs := pipeline("dev", "staging", "prod")
+	dev, staging, prod := envs[0], envs[1], envs[2]
+	preview := &types.Environment{ID: uuid.New(), Name: "preview"}
+	all := append(envs, preview)
+
+	tests := []struct {
+		name       string
+		candidates []*types.Environment
+		from       string
+		want       *types.Environment
+		wantErr    error
+	}{
+		{"next stage", []*types.Environment{staging}, "", staging, nil},
+		{"earlier stage", []*types.Environment{dev}, "", dev, nil},
+		{"several stages lead to the target", []*types.Environment{dev, staging}, "", nil, ErrAmbiguousSource},
+		{"pipeline over outside environment", []*types.Environment{preview, staging}, "", staging, nil},
+		{"only environment outside the pipeline", []*types.Environment{preview}, "", preview, nil},
+		{"named", []*types.Environment{dev, staging}, "dev", dev, nil},
+		{"named but not running there", []*types.Environment{staging}, "dev", nil, ErrNotValidated},
+		{"running nowhere", nil, "", nil, ErrNotValidated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickSource(tt.candidates, all, prod, tt.from)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("pickSource() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("pickSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPipeline(t *testing.T) {
+	envs := pipeline("dev", "staging", "prod")
+	dev, staging, prod := envs[0], envs[1], envs[2]
+
+	if err := CheckPipeline(envs, prod, dev); !errors.Is(err, ErrPipelineLoop) {
+		t.Errorf("prod -> dev: error = %v, want ErrPipelineLoop", err)
+	}
+	if err := CheckPipeline(envs, staging, staging); !errors.Is(err, ErrPipelineLoop) {
+		t.Errorf("staging -> staging: error = %v, want ErrPipelineLoop", err)
+	}
+	if err := CheckPipeline(envs, dev, prod); err != nil {
+		t.Errorf("dev -> prod: error = %v, want nil", err)
+	}
+}
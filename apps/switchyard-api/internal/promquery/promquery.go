@@ -0,0 +1,117 @@
+// Package promquery runs instant queries against a Prometheus server. The
+// soak monitor, idle scaling and the topology read their signals with it and
+// map the series of a result to what they measure.
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client runs instant queries against a Prometheus server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the Prometheus server at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Sample is the value of one series of an instant query. The value may be
+// NaN or infinite, e.g. a ratio over a window without requests.
+type Sample struct {
+	Metric map[string]string
+	Value  float64
+}
+
+// Query runs an instant query and returns the series of its vector result
+func (c *Client) Query(ctx context.Context, query string) ([]Sample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/api/v1/query?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prometheus response: %w", err)
+	}
+
+	return parseVector(resp.StatusCode, body)
+}
+
+// vectorResponse is the part of a Prometheus instant query response that is read
+type vectorResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// parseVector reads the series of an instant query response
+func parseVector(statusCode int, body []byte) ([]Sample, error) {
+	var result vectorResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("prometheus returned %d: invalid response", statusCode)
+	}
+	if statusCode != http.StatusOK || result.Status != "success" {
+		return nil, fmt.Errorf("prometheus returned %d: %s", statusCode, result.Error)
+	}
+	if result.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("prometheus returned a %s, expected a vector", result.Data.ResultType)
+	}
+
+	samples := make([]Sample, 0, len(result.Data.Result))
+	for _, series := range result.Data.Result {
+		raw, ok := series.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("prometheus returned a non-string sample value")
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("prometheus returned an invalid sample value %q", raw)
+		}
+		samples = append(samples, Sample{Metric: series.Metric, Value: value})
+	}
+	return samples, nil
+}
+
+// Scalar reads the single series of a query meant to yield one. No series,
+// or a NaN or infinite value, yields nil; several series are an error.
+func Scalar(samples []Sample) (*float64, error) {
+	if len(samples) == 0 {
+		return nil, nil
+	}
+	if len(samples) > 1 {
+		return nil, fmt.Errorf("prometheus returned %d series, expected one", len(samples))
+	}
+	value := samples[0].Value
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, nil
+	}
+	return &value, nil
+}
@@ -0,0 +1,105 @@
+package promquery
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScalar(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       *float64
+		wantErr    bool
+	}{
+		{
+			name:       "value",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1760616000,"0.025"]}]}}`,
+			want:       floatPtr(0.025),
+		},
+		{
+			name:       "no series",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		},
+		{
+			name:       "NaN",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1760616000,"NaN"]}]}}`,
+		},
+		{
+			name:       "query error",
+			statusCode: http.StatusBadRequest,
+			body:       `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			wantErr:    true,
+		},
+		{
+			name:       "several series",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{"resultType":"vector","result":[{"value":[1,"0.1"]},{"value":[1,"0.2"]}]}}`,
+			wantErr:    true,
+		},
+		{
+			name:       "not json",
+			statusCode: http.StatusBadGateway,
+			body:       `<html>bad gateway</html>`,
+			wantErr:    true,
+		},
+		{
+			name:       "matrix",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples, err := parseVector(tt.statusCode, []byte(tt.body))
+			var got *float64
+			if err == nil {
+				got, err = Scalar(samples)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scalar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("Scalar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("path = %s, want /api/v1/query", r.URL.Path)
+		}
+		query = r.URL.Query().Get("query")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"workload":"api"},"value":[1760616000,"1.5"]},` +
+			`{"metric":{"workload":"web"},"value":[1760616000,"NaN"]}]}}`))
+	}))
+	defer server.Close()
+
+	samples, err := NewClient(server.URL+"/").Query(context.Background(), `up{job="api"}`)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if query != `up{job="api"}` {
+		t.Errorf("query = %q", query)
+	}
+	if len(samples) != 2 || samples[0].Metric["workload"] != "api" || samples[0].Value != 1.5 || !math.IsNaN(samples[1].Value) {
+		t.Errorf("Query() = %+v", samples)
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
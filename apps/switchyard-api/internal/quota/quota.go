@@ -0,0 +1,265 @@
+// Package quota enforces the resource quotas of teams and projects. A quota
+// caps the live services, the CPU and memory requested by the pods of the
+// latest pending or running deployments across environments, the storage of
+// database addons and the open preview environments. A project is held to
+// its own quota and to its team's, and each limit left unset is unlimited.
+// Quotas are checked when something is created or deployed; what already
+// runs past a lowered quota keeps running. Kubernetes ResourceQuota objects
+// in the environment namespaces of a project back the CPU and memory limits
+// in the cluster (see Syncer).
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Requests of a pod whose service sets none, shared with the manifests the
+// reconciler renders
+const (
+	DefaultCPURequest    = "100m"
+	DefaultMemoryRequest = "128Mi"
+)
+
+// ErrQuotaExceeded is returned when something would take a team or a
+// project past its resource quota
+var ErrQuotaExceeded = errors.New("resource quota exceeded")
+
+// Scopes of a quota
+const (
+	ScopeTeam    = "team"
+	ScopeProject = "project"
+)
+
+// Resources a quota limits, named after their fields in types.ResourceUsage
+const (
+	ResourceServices            = "services"
+	ResourceCPUMillicores       = "cpu_millicores"
+	ResourceMemoryMB            = "memory_mb"
+	ResourceAddonStorageGB      = "addon_storage_gb"
+	ResourcePreviewEnvironments = "preview_environments"
+)
+
+// ExceededError tells which limit a request would pass, and by how much
+type ExceededError struct {
+	Scope     string `json:"scope"` // team or project
+	Name      string `json:"name"`  // Slug of the team or project
+	Resource  string `json:"resource"`
+	Limit     int    `json:"limit"`
+	Current   int    `json:"current"`
+	Requested int    `json:"requested"`
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %s %s exceeded: %d in use and %d requested, limit %d",
+		e.Resource, e.Scope, e.Name, e.Current, e.Requested, e.Limit)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Details describes the exceeded limit for an API error response
+func (e *ExceededError) Details() map[string]any {
+	return map[string]any{
+		"scope":     e.Scope,
+		"name":      e.Name,
+		"resource":  e.Resource,
+		"limit":     e.Limit,
+		"current":   e.Current,
+		"requested": e.Requested,
+	}
+}
+
+// CheckNewService checks that a project and its team may have one more
+// service
+func CheckNewService(ctx context.Context, repos *db.Repositories, projectID uuid.UUID) error {
+	return check(ctx, repos, projectID, uuid.Nil, uuid.Nil, types.ResourceUsage{Services: 1})
+}
+
+// CheckDeploy checks that deploying a service with the given replicas into
+// an environment keeps its project and team within their CPU and memory
+// limits. The service's current deployment in the environment is replaced,
+// so it is not counted.
+func CheckDeploy(ctx context.Context, repos *db.Repositories, service *types.Service, environmentID uuid.UUID, replicas int) error {
+	if replicas < 1 {
+		replicas = 1
+	}
+	cpu, memory := PodRequests(service.Resources)
+	return check(ctx, repos, service.ProjectID, service.ID, environmentID, types.ResourceUsage{
+		CPUMillicores: cpu * replicas,
+		MemoryMB:      memory * replicas,
+	})
+}
+
+// CheckAddonStorage checks that a project and its team may hold the given
+// gigabytes of addon storage more
+func CheckAddonStorage(ctx context.Context, repos *db.Repositories, projectID uuid.UUID, storageGB int) error {
+	if storageGB <= 0 {
+		return nil
+	}
+	return check(ctx, repos, projectID, uuid.Nil, uuid.Nil, types.ResourceUsage{AddonStorageGB: storageGB})
+}
+
+// CheckNewPreview checks that a project and its team may open one more
+// preview environment
+func CheckNewPreview(ctx context.Context, repos *db.Repositories, projectID uuid.UUID) error {
+	return check(ctx, repos, projectID, uuid.Nil, uuid.Nil, types.ResourceUsage{PreviewEnvironments: 1})
+}
+
+// ProjectUsage returns what a project holds
+func ProjectUsage(ctx context.Context, repos *db.Repositories, projectID uuid.UUID) (*types.ResourceUsage, error) {
+	counts, err := repos.ResourceQuotas.CountForProject(ctx, projectID, uuid.Nil, uuid.Nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count project resources: %w", err)
+	}
+	return usageOf(counts), nil
+}
+
+// TeamUsage returns what the projects of a team hold
+func TeamUsage(ctx context.Context, repos *db.Repositories, teamID uuid.UUID) (*types.ResourceUsage, error) {
+	counts, err := repos.ResourceQuotas.CountForTeam(ctx, teamID, uuid.Nil, uuid.Nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count team resources: %w", err)
+	}
+	return usageOf(counts), nil
+}
+
+// check checks a request against the quota of a project and then of its
+// team. Counting is skipped for scopes without a quota.
+func check(ctx context.Context, repos *db.Repositories, projectID, serviceID, environmentID uuid.UUID, requested types.ResourceUsage) error {
+	projectQuota, err := repos.ResourceQuotas.GetForProject(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to get project quota: %w", err)
+	}
+	if Limits(projectQuota, requested) {
+		counts, err := repos.ResourceQuotas.CountForProject(ctx, projectID, serviceID, environmentID)
+		if err != nil {
+			return fmt.Errorf("failed to count project resources: %w", err)
+		}
+		if exceeded := Evaluate(projectQuota, usageOf(counts), requested); exceeded != nil {
+			exceeded.Scope = ScopeProject
+			if project, err := repos.Projects.GetByID(ctx, projectID); err == nil {
+				exceeded.Name = project.Slug
+			}
+			return exceeded
+		}
+	}
+
+	teamID, err := repos.ResourceQuotas.TeamOfProject(ctx, projectID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to get team of project: %w", err)
+	}
+	if teamID == nil {
+		return nil
+	}
+	teamQuota, err := repos.ResourceQuotas.GetForTeam(ctx, *teamID)
+	if err != nil {
+		return fmt.Errorf("failed to get team quota: %w", err)
+	}
+	if !Limits(teamQuota, requested) {
+		return nil
+	}
+	counts, err := repos.ResourceQuotas.CountForTeam(ctx, *teamID, serviceID, environmentID)
+	if err != nil {
+		return fmt.Errorf("failed to count team resources: %w", err)
+	}
+	if exceeded := Evaluate(teamQuota, usageOf(counts), requested); exceeded != nil {
+		exceeded.Scope = ScopeTeam
+		if team, err := repos.Teams.GetByID(ctx, *teamID); err == nil {
+			exceeded.Name = team.Slug
+		}
+		return exceeded
+	}
+	return nil
+}
+
+// Limits reports whether a quota limits any resource of a request
+func Limits(quota *types.ResourceQuota, requested types.ResourceUsage) bool {
+	for _, limit := range limitsOf(quota, &types.ResourceUsage{}, requested) {
+		if limit.max != nil && limit.requested > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate checks that a request fits in what is left of a quota, returning
+// the first limit it passes
+func Evaluate(quota *types.ResourceQuota, usage *types.ResourceUsage, requested types.ResourceUsage) *ExceededError {
+	for _, limit := range limitsOf(quota, usage, requested) {
+		if limit.max == nil || limit.requested <= 0 || limit.current+limit.requested <= *limit.max {
+			continue
+		}
+		return &ExceededError{
+			Resource:  limit.resource,
+			Limit:     *limit.max,
+			Current:   limit.current,
+			Requested: limit.requested,
+		}
+	}
+	return nil
+}
+
+type limit struct {
+	resource  string
+	max       *int
+	current   int
+	requested int
+}
+
+func limitsOf(quota *types.ResourceQuota, usage *types.ResourceUsage, requested types.ResourceUsage) []limit {
+	return []limit{
+		{ResourceServices, quota.MaxServices, usage.Services, requested.Services},
+		{ResourceCPUMillicores, quota.MaxCPUMillicores, usage.CPUMillicores, requested.CPUMillicores},
+		{ResourceMemoryMB, quota.MaxMemoryMB, usage.MemoryMB, requested.MemoryMB},
+		{ResourceAddonStorageGB, quota.MaxAddonStorageGB, usage.AddonStorageGB, requested.AddonStorageGB},
+		{ResourcePreviewEnvironments, quota.MaxPreviewEnvironments, usage.PreviewEnvironments, requested.PreviewEnvironments},
+	}
+}
+
+// usageOf turns counts into usage. Resources of services are not stored, so
+// every pod counts with the default requests.
+func usageOf(counts *db.ResourceCounts) *types.ResourceUsage {
+	cpu, memory := PodRequests(nil)
+	return &types.ResourceUsage{
+		Services:            counts.Services,
+		CPUMillicores:       counts.Pods * cpu,
+		MemoryMB:            counts.Pods * memory,
+		AddonStorageGB:      counts.AddonStorageGB,
+		PreviewEnvironments: counts.PreviewEnvironments,
+	}
+}
+
+// PodRequests returns the CPU, in millicores, and memory, in MiB, a pod of a
+// service requests
+func PodRequests(cfg *types.ResourceConfig) (cpuMillicores, memoryMB int) {
+	cpuRequest, memoryRequest := DefaultCPURequest, DefaultMemoryRequest
+	if cfg != nil && cfg.CPURequest != "" {
+		cpuRequest = cfg.CPURequest
+	}
+	if cfg != nil && cfg.MemoryRequest != "" {
+		memoryRequest = cfg.MemoryRequest
+	}
+
+	cpu, err := resource.ParseQuantity(cpuRequest)
+	if err != nil {
+		cpu = resource.MustParse(DefaultCPURequest)
+	}
+	memory, err := resource.ParseQuantity(memoryRequest)
+	if err != nil {
+		memory = resource.MustParse(DefaultMemoryRequest)
+	}
+	return int(cpu.MilliValue()), int((memory.Value() + (1<<20 - 1)) >> 20)
+}
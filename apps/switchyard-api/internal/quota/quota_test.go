@@ -0,0 +1,148 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestEvaluate(t *testing.T) {
+	quota := &types.ResourceQuota{MaxServices: intPtr(5), MaxCPUMillicores: intPtr(1000)}
+	tests := []struct {
+		name      string
+		usage     types.ResourceUsage
+		requested types.ResourceUsage
+		want      string // Resource exceeded; empty when the request fits
+	}{
+		{name: "fits", usage: types.ResourceUsage{Services: 4}, requested: types.ResourceUsage{Services: 1}},
+		{name: "services exceeded", usage: types.ResourceUsage{Services: 5}, requested: types.ResourceUsage{Services: 1}, want: ResourceServices},
+		{name: "cpu exceeded", usage: types.ResourceUsage{CPUMillicores: 900}, requested: types.ResourceUsage{CPUMillicores: 200}, want: ResourceCPUMillicores},
+		{name: "unlimited resource", usage: types.ResourceUsage{MemoryMB: 1 << 20}, requested: types.ResourceUsage{MemoryMB: 128}},
+		{name: "over but nothing more requested", usage: types.ResourceUsage{Services: 9}, requested: types.ResourceUsage{PreviewEnvironments: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceeded := Evaluate(quota, &tt.usage, tt.requested)
+			if tt.want == "" {
+				if exceeded != nil {
+					t.Fatalf("Evaluate() = %v, want no error", exceeded)
+				}
+				return
+			}
+			if exceeded == nil || exceeded.Resource != tt.want {
+				t.Fatalf("Evaluate() = %v, want %s exceeded", exceeded, tt.want)
+			}
+			if !errors.Is(exceeded, ErrQuotaExceeded) {
+				t.Error("ExceededError should wrap ErrQuotaExceeded")
+			}
+		})
+	}
+
+	exceeded := Evaluate(quota, &types.ResourceUsage{CPUMillicores: 900}, types.ResourceUsage{CPUMillicores: 200})
+	exceeded.Scope, exceeded.Name = ScopeProject, "shop"
+	if got, want := exceeded.Error(), "cpu_millicores quota of project shop exceeded: 900 in use and 200 requested, limit 1000"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	quota := &types.ResourceQuota{MaxPreviewEnvironments: intPtr(3)}
+	if Limits(quota, types.ResourceUsage{Services: 1}) {
+		t.Error("a quota without a service limit should not limit services")
+	}
+	if !Limits(quota, types.ResourceUsage{PreviewEnvironments: 1}) {
+		t.Error("a preview limit should limit previews")
+	}
+	if Limits(&types.ResourceQuota{}, types.ResourceUsage{Services: 1, CPUMillicores: 100}) {
+		t.Error("an empty quota limits nothing")
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        *types.ResourceConfig
+		wantCPU    int
+		wantMemory int
+	}{
+		{name: "defaults", wantCPU: 100, wantMemory: 128},
+		{name: "set", cfg: &types.ResourceConfig{CPURequest: "1.5", MemoryRequest: "1Gi"}, wantCPU: 1500, wantMemory: 1024},
+		{name: "decimal memory rounds up", cfg: &types.ResourceConfig{MemoryRequest: "100M"}, wantCPU: 100, wantMemory: 96},
+		{name: "invalid falls back", cfg: &types.ResourceConfig{CPURequest: "lots"}, wantCPU: 100, wantMemory: 128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpu, memory := PodRequests(tt.cfg)
+			if cpu != tt.wantCPU || memory != tt.wantMemory {
+				t.Errorf("PodRequests() = %d, %d, want %d, %d", cpu, memory, tt.wantCPU, tt.wantMemory)
+			}
+		})
+	}
+}
+
+func TestHardLimits(t *testing.T) {
+	project := &types.ResourceQuota{MaxCPUMillicores: intPtr(4000)}
+	team := &types.ResourceQuota{MaxCPUMillicores: intPtr(8000), MaxMemoryMB: intPtr(2048)}
+
+	hard := HardLimits(project, team)
+	if cpu := hard[corev1.ResourceRequestsCPU]; cpu.MilliValue() != 5000 {
+		t.Errorf("requests.cpu = %s, want the project's 4 cores plus headroom", cpu.String())
+	}
+	if memory := hard[corev1.ResourceRequestsMemory]; memory.Value() != 2560<<20 {
+		t.Errorf("requests.memory = %s, want the team's 2Gi plus headroom", memory.String())
+	}
+
+	if hard := HardLimits(&types.ResourceQuota{MaxServices: intPtr(1)}); len(hard) != 0 {
+		t.Errorf("HardLimits() = %v, want nothing without CPU or memory limits", hard)
+	}
+}
+
+func TestApplyAndRemove(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	s := NewSyncer(nil, kube, logrus.New())
+	ctx := context.Background()
+	namespace := "enclii-shop-production"
+
+	hard := corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2")}
+	if err := s.apply(ctx, namespace, hard); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	hard = corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("3")}
+	if err := s.apply(ctx, namespace, hard); err != nil {
+		t.Fatalf("second apply: %v", err)
+	}
+
+	quota, err := kube.CoreV1().ResourceQuotas(namespace).Get(ctx, KubeObjectName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get resource quota: %v", err)
+	}
+	if cpu := quota.Spec.Hard[corev1.ResourceRequestsCPU]; cpu.MilliValue() != 3000 {
+		t.Errorf("requests.cpu = %s, want the updated 3", cpu.String())
+	}
+	if _, err := kube.CoreV1().LimitRanges(namespace).Get(ctx, KubeObjectName, metav1.GetOptions{}); err != nil {
+		t.Errorf("get limit range: %v", err)
+	}
+
+	if err := s.remove(ctx, namespace); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := kube.CoreV1().ResourceQuotas(namespace).Get(ctx, KubeObjectName, metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("resource quota kept after remove: %v", err)
+	}
+	if err := s.remove(ctx, namespace); err != nil {
+		t.Errorf("remove of missing objects: %v", err)
+	}
+}
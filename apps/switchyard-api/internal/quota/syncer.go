@@ -0,0 +1,226 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// KubeObjectName names the ResourceQuota and LimitRange in each
+	// environment namespace
+	KubeObjectName = "enclii-quota"
+
+	// SurgeHeadroomPercent is added to the limits in the cluster, so pods
+	// surging during a rolling update still fit
+	SurgeHeadroomPercent = 25
+
+	// SyncInterval is how often the objects of all limited projects are
+	// synced
+	SyncInterval = 10 * time.Minute
+)
+
+// Syncer keeps a ResourceQuota in each environment namespace of a project
+// with CPU or memory limits. The ResourceQuota caps the requests of the
+// namespace at the tightest of the project's and team's limits, plus surge
+// headroom; the quota spans all environments, so this is a backstop for
+// pods created outside the API. A LimitRange gives containers without
+// requests the defaults, since a ResourceQuota on requests rejects them
+// otherwise.
+type Syncer struct {
+	repos  *db.Repositories
+	kube   kubernetes.Interface
+	logger *logrus.Logger
+}
+
+// NewSyncer creates a quota syncer
+func NewSyncer(repos *db.Repositories, kube kubernetes.Interface, logger *logrus.Logger) *Syncer {
+	return &Syncer{
+		repos:  repos,
+		kube:   kube,
+		logger: logger,
+	}
+}
+
+// SyncAll syncs the objects of every project held to a quota
+func (s *Syncer) SyncAll(ctx context.Context) {
+	projectIDs, err := s.repos.ResourceQuotas.ListProjectsWithQuotas(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list projects with quotas")
+		return
+	}
+	s.syncProjects(ctx, projectIDs)
+}
+
+// SyncTeam syncs the objects of every project of a team, after its quota
+// changed
+func (s *Syncer) SyncTeam(ctx context.Context, teamID uuid.UUID) {
+	projectIDs, err := s.repos.ResourceQuotas.ListTeamProjects(ctx, teamID)
+	if err != nil {
+		s.logger.WithError(err).WithField("team_id", teamID).Error("Failed to list team projects")
+		return
+	}
+	s.syncProjects(ctx, projectIDs)
+}
+
+func (s *Syncer) syncProjects(ctx context.Context, projectIDs []uuid.UUID) {
+	for _, projectID := range projectIDs {
+		if err := s.SyncProject(ctx, projectID); err != nil {
+			s.logger.WithError(err).WithField("project_id", projectID).Warn("Failed to sync project quota")
+		}
+	}
+}
+
+// SyncProject applies the objects of a project to its environment
+// namespaces, or removes them once the project has no CPU or memory limit.
+// Namespaces that do not exist yet are skipped.
+func (s *Syncer) SyncProject(ctx context.Context, projectID uuid.UUID) error {
+	hard, err := s.hardLimits(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	environments, err := s.repos.Environments.ListByProject(projectID)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	var errs []error
+	for _, env := range environments {
+		if env.KubeNamespace == "" {
+			continue
+		}
+		if len(hard) == 0 {
+			err = s.remove(ctx, env.KubeNamespace)
+		} else {
+			err = s.apply(ctx, env.KubeNamespace, hard)
+		}
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("%s: %w", env.KubeNamespace, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hardLimits returns the requests a namespace of a project may hold
+func (s *Syncer) hardLimits(ctx context.Context, projectID uuid.UUID) (corev1.ResourceList, error) {
+	projectQuota, err := s.repos.ResourceQuotas.GetForProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project quota: %w", err)
+	}
+	quotas := []*types.ResourceQuota{projectQuota}
+
+	teamID, err := s.repos.ResourceQuotas.TeamOfProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team of project: %w", err)
+	}
+	if teamID != nil {
+		teamQuota, err := s.repos.ResourceQuotas.GetForTeam(ctx, *teamID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get team quota: %w", err)
+		}
+		quotas = append(quotas, teamQuota)
+	}
+	return HardLimits(quotas...), nil
+}
+
+// HardLimits returns the requests.cpu and requests.memory of a
+// ResourceQuota for the tightest of the given quotas, with surge headroom.
+// Unlimited resources are left out.
+func HardLimits(quotas ...*types.ResourceQuota) corev1.ResourceList {
+	var cpu, memory *int
+	for _, quota := range quotas {
+		cpu = tighter(cpu, quota.MaxCPUMillicores)
+		memory = tighter(memory, quota.MaxMemoryMB)
+	}
+
+	hard := corev1.ResourceList{}
+	if cpu != nil {
+		hard[corev1.ResourceRequestsCPU] = *resource.NewMilliQuantity(withHeadroom(*cpu), resource.DecimalSI)
+	}
+	if memory != nil {
+		hard[corev1.ResourceRequestsMemory] = *resource.NewQuantity(withHeadroom(*memory)<<20, resource.BinarySI)
+	}
+	return hard
+}
+
+func tighter(current, limit *int) *int {
+	if limit == nil || (current != nil && *current <= *limit) {
+		return current
+	}
+	return limit
+}
+
+func withHeadroom(value int) int64 {
+	return int64(value) * (100 + SurgeHeadroomPercent) / 100
+}
+
+// apply creates or updates the ResourceQuota and LimitRange of a namespace
+func (s *Syncer) apply(ctx context.Context, namespace string, hard corev1.ResourceList) error {
+	labels := map[string]string{"app.kubernetes.io/managed-by": "enclii"}
+
+	quotas := s.kube.CoreV1().ResourceQuotas(namespace)
+	existing, err := quotas.Get(ctx, KubeObjectName, metav1.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+		desired := &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: KubeObjectName, Namespace: namespace, Labels: labels},
+			Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+		}
+		if _, err := quotas.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create resource quota: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get resource quota: %w", err)
+	default:
+		existing.Spec.Hard = hard
+		if _, err := quotas.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update resource quota: %w", err)
+		}
+	}
+
+	limitRanges := s.kube.CoreV1().LimitRanges(namespace)
+	_, err = limitRanges.Get(ctx, KubeObjectName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		desired := &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: KubeObjectName, Namespace: namespace, Labels: labels},
+			Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+				Type: corev1.LimitTypeContainer,
+				DefaultRequest: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(DefaultCPURequest),
+					corev1.ResourceMemory: resource.MustParse(DefaultMemoryRequest),
+				},
+			}}},
+		}
+		if _, err := limitRanges.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create limit range: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get limit range: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the ResourceQuota and LimitRange of a namespace
+func (s *Syncer) remove(ctx context.Context, namespace string) error {
+	err := s.kube.CoreV1().ResourceQuotas(namespace).Delete(ctx, KubeObjectName, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete resource quota: %w", err)
+	}
+	err = s.kube.CoreV1().LimitRanges(namespace).Delete(ctx, KubeObjectName, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete limit range: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+// Package ratelimit enforces the rate limit policies of expensive routes,
+// such as builds and deploys, on top of the limits per IP, user and API
+// token
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/middleware"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// policyRefreshInterval is how often policies are reloaded, so a change
+// made through another replica applies within it
+const policyRefreshInterval = 30 * time.Second
+
+// PolicySource lists the stored policies. db.RateLimitPolicyRepository is one.
+type PolicySource interface {
+	List(ctx context.Context) ([]*types.RateLimitPolicy, error)
+}
+
+// Policies holds each request to the budgets of the enabled policies of its
+// route. Policies are reloaded lazily, and each keeps its counts across
+// reloads until it is changed.
+type Policies struct {
+	source PolicySource
+	store  middleware.RateStore
+	logger *logrus.Logger
+
+	mu       sync.RWMutex
+	routes   map[string][]*policyLimiter
+	loadedAt time.Time
+	loading  sync.Mutex
+}
+
+// policyLimiter counts the requests of one version of a policy
+type policyLimiter struct {
+	policyID  uuid.UUID
+	updatedAt time.Time
+	limiter   *middleware.RateLimiter
+}
+
+// NewPolicies creates an enforcer of the policies of a source, counting in
+// a shared store. A nil store counts in memory.
+func NewPolicies(source PolicySource, store middleware.RateStore, logger *logrus.Logger) *Policies {
+	return &Policies{
+		source: source,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Middleware rejects requests over the budget of a policy of their route
+// with 429 and a structured error naming the policy. It must run after the
+// auth middleware, so user and token policies can tell callers apart.
+func (p *Policies) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, l := range p.match(c.Request.Context(), c.Request.Method, c.FullPath()) {
+			if !l.limiter.Check(c) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// Invalidate makes the next request reload the policies, for changes made
+// through this replica
+func (p *Policies) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loadedAt = time.Time{}
+}
+
+// match returns the limiters of the policies of a route
+func (p *Policies) match(ctx context.Context, method, route string) []*policyLimiter {
+	if route == "" {
+		return nil
+	}
+
+	p.mu.RLock()
+	stale := time.Since(p.loadedAt) >= policyRefreshInterval
+	p.mu.RUnlock()
+	if stale {
+		p.refresh(ctx)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	matched := append([]*policyLimiter(nil), p.routes[routeKey(method, route)]...)
+	return append(matched, p.routes[routeKey("*", route)]...)
+}
+
+// refresh reloads the policies, once for concurrent requests. Unchanged
+// policies keep their limiters. On failure the previous policies are kept
+// until the next try.
+func (p *Policies) refresh(ctx context.Context) {
+	p.loading.Lock()
+	defer p.loading.Unlock()
+
+	p.mu.RLock()
+	fresh := time.Since(p.loadedAt) < policyRefreshInterval
+	p.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	policies, err := p.source.List(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loadedAt = time.Now()
+	if err != nil {
+		p.logger.WithError(err).Warn("Failed to load rate limit policies")
+		return
+	}
+
+	previous := make(map[uuid.UUID]*policyLimiter)
+	for _, limiters := range p.routes {
+		for _, l := range limiters {
+			previous[l.policyID] = l
+		}
+	}
+
+	routes := make(map[string][]*policyLimiter)
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		l, ok := previous[policy.ID]
+		if ok && l.updatedAt.Equal(policy.UpdatedAt) {
+			delete(previous, policy.ID)
+		} else {
+			l = p.newLimiter(policy)
+		}
+		key := routeKey(policy.Method, policy.Route)
+		routes[key] = append(routes[key], l)
+	}
+	p.routes = routes
+
+	// Limiters of policies that were changed, disabled or deleted
+	for _, l := range previous {
+		l.limiter.Stop()
+	}
+}
+
+func (p *Policies) newLimiter(policy *types.RateLimitPolicy) *policyLimiter {
+	return &policyLimiter{
+		policyID:  policy.ID,
+		updatedAt: policy.UpdatedAt,
+		limiter: middleware.NewRateLimiter(middleware.RateLimitConfig{
+			Name:    "policy:" + policy.Name,
+			Limit:   policy.Requests,
+			Window:  time.Duration(policy.WindowSeconds) * time.Second,
+			KeyFunc: scopeKeyFunc(policy.Scope),
+		}).WithStore(p.store),
+	}
+}
+
+// scopeKeyFunc returns the key function counting a policy's budget for its
+// scope. Requests the scope does not cover, such as those without an API
+// token for the token scope, are not counted.
+func scopeKeyFunc(scope types.RateLimitScope) func(*gin.Context) string {
+	switch scope {
+	case types.RateLimitScopeToken:
+		return middleware.TokenKeyFunc
+	case types.RateLimitScopeIP:
+		return middleware.IPKeyFunc
+	default:
+		return middleware.AuthenticatedUserKeyFunc
+	}
+}
+
+// ValidMethod reports whether a policy may be set on an HTTP method
+func ValidMethod(method string) bool {
+	switch method {
+	case "*", http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// ValidScope reports whether a policy may be counted for a scope
+func ValidScope(scope types.RateLimitScope) bool {
+	switch scope {
+	case types.RateLimitScopeUser, types.RateLimitScopeToken, types.RateLimitScopeIP:
+		return true
+	}
+	return false
+}
+
+func routeKey(method, route string) string {
+	return strings.ToUpper(method) + " " + route
+}
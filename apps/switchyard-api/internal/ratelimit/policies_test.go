@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// fakeSource serves policies like the repository, counting loads
+type fakeSource struct {
+	mu       sync.Mutex
+	policies []*types.RateLimitPolicy
+	err      error
+	loads    int
+}
+
+func (s *fakeSource) List(_ context.Context) ([]*types.RateLimitPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	return s.policies, s.err
+}
+
+func buildPolicy(requests int) *types.RateLimitPolicy {
+	return &types.RateLimitPolicy{
+		ID:            uuid.MustParse("0f8e5a52-6a3b-4c1e-9b0d-3f2a1c4d5e6f"),
+		Name:          "build",
+		Method:        http.MethodPost,
+		Route:         "/v1/services/:id/build",
+		Scope:         types.RateLimitScopeUser,
+		Requests:      requests,
+		WindowSeconds: 3600,
+		Enabled:       true,
+		UpdatedAt:     time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// serve sends a request as a user through the policies
+func serve(policies *Policies, method, path, userID string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	}, policies.Middleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/v1/services/:id/build", ok)
+	router.GET("/v1/services/:id/build", ok)
+	router.POST("/v1/services/:id/deploy", ok)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestPoliciesLimitTheirRoute(t *testing.T) {
+	policies := NewPolicies(&fakeSource{policies: []*types.RateLimitPolicy{buildPolicy(1)}}, nil, logrus.New())
+
+	if w := serve(policies, http.MethodPost, "/v1/services/a/build", "alice"); w.Code != http.StatusOK {
+		t.Fatalf("first build status = %d, want %d", w.Code, http.StatusOK)
+	}
+	// The budget is per user and per route pattern, not per path
+	if w := serve(policies, http.MethodPost, "/v1/services/b/build", "alice"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second build status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w := serve(policies, http.MethodPost, "/v1/services/a/build", "bob"); w.Code != http.StatusOK {
+		t.Errorf("build by another user status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serve(policies, http.MethodGet, "/v1/services/a/build", "alice"); w.Code != http.StatusOK {
+		t.Errorf("GET on the route status = %d, want it outside the POST policy", w.Code)
+	}
+	if w := serve(policies, http.MethodPost, "/v1/services/a/deploy", "alice"); w.Code != http.StatusOK {
+		t.Errorf("other route status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestPoliciesMatchAnyMethod(t *testing.T) {
+	policy := buildPolicy(1)
+	policy.Method = "*"
+	policies := NewPolicies(&fakeSource{policies: []*types.RateLimitPolicy{policy}}, nil, logrus.New())
+
+	serve(policies, http.MethodGet, "/v1/services/a/build", "alice")
+	if w := serve(policies, http.MethodPost, "/v1/services/a/build", "alice"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want the * policy to count every method", w.Code)
+	}
+}
+
+func TestPoliciesSkipDisabled(t *testing.T) {
+	policy := buildPolicy(1)
+	policy.Enabled = false
+	policies := NewPolicies(&fakeSource{policies: []*types.RateLimitPolicy{policy}}, nil, logrus.New())
+
+	for i := 0; i < 3; i++ {
+		if w := serve(policies, http.MethodPost, "/v1/services/a/build", "alice"); w.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want a disabled policy ignored", i, w.Code)
+		}
+	}
+}
+
+func TestPoliciesReload(t *testing.T) {
+	source := &fakeSource{policies: []*types.RateLimitPolicy{buildPolicy(1)}}
+	policies := NewPolicies(source, nil, logrus.New())
+
+	serve(policies, http.MethodPost, "/v1/services/a/build", "alice")
+	if source.loads != 1 {
+		t.Fatalf("loads = %d, want policies loaded once while fresh", source.loads)
+	}
+
+	// A reload of the same policy keeps counting
+	policies.Invalidate()
+	if w := serve(policies, http.MethodPost, "/v1/services/a/build", "alice"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status after reload = %d, want the count kept", w.Code)
+	}
+
+	// A changed policy applies after the next reload
+	changed := buildPolicy(5)
+	changed.UpdatedAt = changed.UpdatedAt.Add(time.Minute)
+	source.policies = []*types.RateLimitPolicy{changed}
+	policies.Invalidate()
+	if w := serve(policies, http.MethodPost, "/v1/services/a/build", "alice"); w.Code != http.StatusOK {
+		t.Errorf("status after raising the budget = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := source.loads; got != 3 {
+		t.Errorf("loads = %d, want %d", got, 3)
+	}
+}
+
+func TestPoliciesKeptWhenReloadFails(t *testing.T) {
+	source := &fakeSource{policies: []*types.RateLimitPolicy{buildPolicy(1)}}
+	policies := NewPolicies(source, nil, logrus.New())
+	serve(policies, http.MethodPost, "/v1/services/a/build", "alice")
+
+	source.err = errors.New("connection refused")
+	policies.Invalidate()
+	if w := serve(policies, http.MethodPost, "/v1/services/a/build", "alice"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want the previous policies enforced", w.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+)
+
+// AddonBackupController runs scheduled addon backups, tracks backup and
+// restore jobs to completion, and enforces backup retention
+type AddonBackupController struct {
+	addonService *addons.AddonService
+	logger       *logrus.Logger
+	interval     time.Duration
+	stopCh       chan struct{}
+}
+
+// NewAddonBackupController creates a new addon backup controller
+func NewAddonBackupController(addonService *addons.AddonService, logger *logrus.Logger) *AddonBackupController {
+	return &AddonBackupController{
+		addonService: addonService,
+		logger:       logger,
+		interval:     time.Minute,
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start begins the backup loop
+func (c *AddonBackupController) Start(ctx context.Context) {
+	c.logger.Info("Starting addon backup controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.runOnce(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.runOnce(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Addon backup controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Addon backup controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *AddonBackupController) Stop() {
+	close(c.stopCh)
+}
+
+// runOnce performs a single pass: finish running jobs first so a completed
+// backup counts before the schedule is evaluated
+func (c *AddonBackupController) runOnce(ctx context.Context) {
+	now := time.Now()
+	c.addonService.SyncBackups(ctx)
+	c.addonService.SyncRestores(ctx)
+	c.addonService.RunScheduledBackups(ctx, now)
+	c.addonService.PruneExpiredBackups(ctx, now)
+}
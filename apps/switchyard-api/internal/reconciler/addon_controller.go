@@ -12,7 +12,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/clients"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/events"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
@@ -24,6 +27,12 @@ type AddonReconciler struct {
 	dynamicClient dynamic.Interface
 	logger        *logrus.Logger
 	stopCh        chan struct{}
+	eventBroker   *events.Broker         // Optional - streams addon status changes
+	addonService  *addons.AddonService   // Optional - tracks in-progress resizes
+	waybill       *clients.WaybillClient // Optional - receives bucket storage usage for billing
+	buckets       *addons.BucketProvisioner
+
+	lastUsageCollection time.Time // Last storage/connection usage sample (see addon_metrics.go)
 }
 
 // CloudNativePG Group Version Resource
@@ -47,9 +56,25 @@ func NewAddonReconciler(repos *db.Repositories, k8sClient *k8s.Client, logger *l
 		dynamicClient: dynamicClient,
 		logger:        logger,
 		stopCh:        make(chan struct{}),
+		buckets:       addons.NewBucketProvisioner(k8sClient, logger),
 	}
 }
 
+// SetEventBroker sets the broker used to stream addon status changes
+func (r *AddonReconciler) SetEventBroker(broker *events.Broker) {
+	r.eventBroker = broker
+}
+
+// SetAddonService sets the addon service used to track in-progress resizes
+func (r *AddonReconciler) SetAddonService(svc *addons.AddonService) {
+	r.addonService = svc
+}
+
+// SetWaybillClient sets the client that bucket storage usage is billed through
+func (r *AddonReconciler) SetWaybillClient(client *clients.WaybillClient) {
+	r.waybill = client
+}
+
 // Start begins the addon reconciliation loop
 func (r *AddonReconciler) Start(ctx context.Context) {
 	r.logger.Info("Starting addon reconciler")
@@ -59,11 +84,17 @@ func (r *AddonReconciler) Start(ctx context.Context) {
 
 	// Initial reconciliation
 	r.reconcileAll(ctx)
+	r.syncResizes(ctx)
+	r.collectRedisMetrics(ctx)
+	r.collectDatabaseMetrics(ctx)
 
 	for {
 		select {
 		case <-ticker.C:
 			r.reconcileAll(ctx)
+			r.syncResizes(ctx)
+			r.collectRedisMetrics(ctx)
+			r.collectDatabaseMetrics(ctx)
 		case <-r.stopCh:
 			r.logger.Info("Addon reconciler stopped")
 			return
@@ -99,6 +130,25 @@ func (r *AddonReconciler) reconcileAll(ctx context.Context) {
 	}
 }
 
+// syncResizes advances in-progress resizes and streams the addons that finished
+func (r *AddonReconciler) syncResizes(ctx context.Context) {
+	if r.addonService == nil {
+		return
+	}
+
+	for _, addon := range r.addonService.SyncResizes(ctx) {
+		if r.eventBroker == nil {
+			continue
+		}
+		event := events.NewStatusEvent(events.ResourceAddon, addon.ID, string(addon.Status))
+		event.ProjectID = &addon.ProjectID
+		event.EnvironmentID = addon.EnvironmentID
+		event.Message = addon.StatusMessage
+		event.Data = map[string]any{"addon_type": addon.Type, "name": addon.Name}
+		r.eventBroker.Publish(ctx, event)
+	}
+}
+
 // reconcileAddon checks and updates a single addon's status
 func (r *AddonReconciler) reconcileAddon(ctx context.Context, addon *types.DatabaseAddon) {
 	logger := r.logger.WithFields(logrus.Fields{
@@ -120,6 +170,12 @@ func (r *AddonReconciler) reconcileAddon(ctx context.Context, addon *types.Datab
 		r.reconcilePostgresAddon(ctx, addon, logger)
 	case types.DatabaseAddonTypeRedis:
 		r.reconcileRedisAddon(ctx, addon, logger)
+	case types.DatabaseAddonTypeMySQL:
+		r.reconcileStatefulSetAddon(ctx, addon, "MySQL", "app", "app", logger)
+	case types.DatabaseAddonTypeMongoDB:
+		r.reconcileStatefulSetAddon(ctx, addon, "MongoDB", "app", "app", logger)
+	case types.DatabaseAddonTypeBucket:
+		r.reconcileBucketAddon(ctx, addon, logger)
 	default:
 		logger.Warn("Unknown addon type, skipping reconciliation")
 	}
@@ -261,6 +317,75 @@ func (r *AddonReconciler) reconcileRedisAddon(ctx context.Context, addon *types.
 	}
 }
 
+// reconcileStatefulSetAddon checks and updates the status of a standalone
+// StatefulSet-backed addon (MySQL, MongoDB)
+func (r *AddonReconciler) reconcileStatefulSetAddon(ctx context.Context, addon *types.DatabaseAddon, engine, database, username string, logger *logrus.Entry) {
+	statefulSet, err := r.k8sClient.Clientset.AppsV1().StatefulSets(addon.K8sNamespace).Get(
+		ctx,
+		addon.K8sResourceName,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		logger.WithError(err).Warnf("Failed to get %s StatefulSet", engine)
+		if addon.Status == types.DatabaseAddonStatusDeleting {
+			r.markAddonDeleted(ctx, addon, logger)
+		}
+		return
+	}
+
+	status := &AddonStatusResult{
+		Host:         fmt.Sprintf("%s.%s.svc.cluster.local", addon.K8sResourceName, addon.K8sNamespace),
+		Port:         int(getAddonPort(addon.Type)),
+		DatabaseName: database,
+		Username:     username,
+	}
+
+	if statefulSet.Status.ReadyReplicas == *statefulSet.Spec.Replicas && statefulSet.Status.ReadyReplicas > 0 {
+		status.Status = types.DatabaseAddonStatusReady
+		status.StatusMessage = fmt.Sprintf("%s ready with %d replicas", engine, statefulSet.Status.ReadyReplicas)
+		status.Ready = true
+	} else {
+		status.Status = types.DatabaseAddonStatusProvisioning
+		status.StatusMessage = fmt.Sprintf("%s provisioning: %d/%d replicas ready",
+			engine, statefulSet.Status.ReadyReplicas, *statefulSet.Spec.Replicas)
+	}
+
+	if r.shouldUpdateAddon(addon, status) {
+		r.updateAddonFromStatus(ctx, addon, status, logger)
+	}
+}
+
+// reconcileBucketAddon checks and updates a bucket addon's status. MinIO
+// buckets wait for their setup Job; cloud buckets for their credentials.
+func (r *AddonReconciler) reconcileBucketAddon(ctx context.Context, addon *types.DatabaseAddon, logger *logrus.Entry) {
+	result, err := r.buckets.GetStatus(ctx, addon)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get bucket status")
+		return
+	}
+
+	if result.Status == types.DatabaseAddonStatusDeleted {
+		if addon.Status == types.DatabaseAddonStatusDeleting {
+			r.markAddonDeleted(ctx, addon, logger)
+		}
+		return
+	}
+
+	status := &AddonStatusResult{
+		Status:        result.Status,
+		StatusMessage: result.StatusMessage,
+		Host:          result.Host,
+		Port:          result.Port,
+		DatabaseName:  result.DatabaseName,
+		Username:      result.Username,
+		Ready:         result.Ready,
+	}
+
+	if r.shouldUpdateAddon(addon, status) {
+		r.updateAddonFromStatus(ctx, addon, status, logger)
+	}
+}
+
 // AddonStatusResult contains the parsed status of an addon
 type AddonStatusResult struct {
 	Status        types.DatabaseAddonStatus
@@ -306,6 +431,15 @@ func (r *AddonReconciler) updateAddonFromStatus(ctx context.Context, addon *type
 		"old_status": oldStatus,
 		"new_status": status.Status,
 	}).Info("Addon status updated")
+
+	if r.eventBroker != nil && oldStatus != status.Status {
+		event := events.NewStatusEvent(events.ResourceAddon, addon.ID, string(status.Status))
+		event.ProjectID = &addon.ProjectID
+		event.EnvironmentID = addon.EnvironmentID
+		event.Message = status.StatusMessage
+		event.Data = map[string]any{"addon_type": addon.Type, "name": addon.Name}
+		r.eventBroker.Publish(ctx, event)
+	}
 }
 
 // markAddonDeleted marks an addon as deleted after K8s resource is gone
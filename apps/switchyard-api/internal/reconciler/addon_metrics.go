@@ -0,0 +1,370 @@
+package reconciler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/clients"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// addonUsageTimeout bounds how long a single usage query against an addon may take
+	addonUsageTimeout = 10 * time.Second
+
+	// addonUsageInterval is how often storage and connection usage is sampled.
+	// It is longer than the reconcile interval to keep load on addons low.
+	addonUsageInterval = 5 * time.Minute
+
+	// walArchiveLagWarning is the archive lag logged as a warning. CloudNativePG
+	// forces a WAL switch every 5 minutes, so idle clusters stay below it.
+	walArchiveLagWarning = 15 * time.Minute
+)
+
+// Usage queries per engine; each returns a single value
+const (
+	postgresStorageQuery     = `SELECT pg_database_size(current_database())`
+	postgresConnectionsQuery = `SELECT count(*) FROM pg_stat_activity WHERE datname = current_database()`
+	postgresArchiverQuery    = `SELECT last_archived_time FROM pg_stat_archiver`
+	mysqlStorageQuery        = `SELECT COALESCE(SUM(data_length + index_length), 0) FROM information_schema.tables WHERE table_schema = DATABASE()`
+	mysqlConnectionsQuery    = `SHOW GLOBAL STATUS LIKE 'Threads_connected'`
+
+	// mongoUsageScript prints "<storage bytes> <current connections>"
+	mongoUsageScript = `const s = db.stats(); print(Math.round(s.storageSize + s.indexSize) + " " + db.serverStatus().connections.current)`
+)
+
+// AddonUsage contains the storage and connection figures surfaced on a database addon
+type AddonUsage struct {
+	StorageUsedBytes  int64
+	ConnectionsActive int
+}
+
+// collectDatabaseMetrics refreshes storage and connection usage for all ready
+// PostgreSQL, MySQL, MongoDB, and bucket addons. Storage usage also backs storage billing.
+func (r *AddonReconciler) collectDatabaseMetrics(ctx context.Context) {
+	if time.Since(r.lastUsageCollection) < addonUsageInterval {
+		return
+	}
+	r.lastUsageCollection = time.Now()
+
+	for _, addonType := range []types.DatabaseAddonType{
+		types.DatabaseAddonTypePostgres,
+		types.DatabaseAddonTypeMySQL,
+		types.DatabaseAddonTypeMongoDB,
+		types.DatabaseAddonTypeBucket,
+	} {
+		addons, err := r.repos.DatabaseAddons.ListReadyByType(ctx, addonType)
+		if err != nil {
+			r.logger.WithError(err).WithField("type", addonType).Error("Failed to list ready addons for metrics")
+			continue
+		}
+
+		for _, addon := range addons {
+			logger := r.logger.WithFields(logrus.Fields{
+				"addon_id":  addon.ID,
+				"type":      addon.Type,
+				"namespace": addon.K8sNamespace,
+				"resource":  addon.K8sResourceName,
+			})
+
+			if addon.Type == types.DatabaseAddonTypePostgres && addon.Config.PITREnabled {
+				r.collectWALArchiveStatus(ctx, addon, logger)
+			}
+
+			usage, err := r.fetchAddonUsage(ctx, addon)
+			if err != nil {
+				logger.WithError(err).Debug("Failed to collect addon usage")
+				continue
+			}
+
+			// Waybill bills each hour at its latest sample, so buckets report every sample
+			if addon.Type == types.DatabaseAddonTypeBucket {
+				r.reportBucketUsage(ctx, addon, usage.StorageUsedBytes, logger)
+			}
+
+			if usage.StorageUsedBytes == addon.StorageUsedBytes && usage.ConnectionsActive == addon.ConnectionsActive {
+				continue
+			}
+
+			if err := r.repos.DatabaseAddons.UpdateMetrics(ctx, addon.ID, usage.StorageUsedBytes, addon.MemoryUsedBytes, usage.ConnectionsActive); err != nil {
+				logger.WithError(err).Warn("Failed to store addon usage")
+			}
+		}
+	}
+}
+
+// fetchAddonUsage queries the storage and connection usage of a database addon
+func (r *AddonReconciler) fetchAddonUsage(ctx context.Context, addon *types.DatabaseAddon) (*AddonUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, addonUsageTimeout)
+	defer cancel()
+
+	switch addon.Type {
+	case types.DatabaseAddonTypePostgres:
+		return r.fetchPostgresUsage(ctx, addon)
+	case types.DatabaseAddonTypeMySQL:
+		return r.fetchMySQLUsage(ctx, addon)
+	case types.DatabaseAddonTypeMongoDB:
+		return r.fetchMongoDBUsage(ctx, addon)
+	case types.DatabaseAddonTypeBucket:
+		return r.fetchBucketUsage(ctx, addon)
+	default:
+		return nil, fmt.Errorf("usage collection not supported for addon type: %s", addon.Type)
+	}
+}
+
+// fetchPostgresUsage queries database size and connections of a PostgreSQL addon
+func (r *AddonReconciler) fetchPostgresUsage(ctx context.Context, addon *types.DatabaseAddon) (*AddonUsage, error) {
+	conn, err := r.openPostgres(ctx, addon)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	usage := &AddonUsage{}
+	if err := conn.QueryRowContext(ctx, postgresStorageQuery).Scan(&usage.StorageUsedBytes); err != nil {
+		return nil, fmt.Errorf("failed to query database size: %w", err)
+	}
+	if err := conn.QueryRowContext(ctx, postgresConnectionsQuery).Scan(&usage.ConnectionsActive); err != nil {
+		return nil, fmt.Errorf("failed to query connections: %w", err)
+	}
+
+	return usage, nil
+}
+
+// collectWALArchiveStatus records when an addon with point-in-time recovery
+// last archived a WAL segment and how old that segment is
+func (r *AddonReconciler) collectWALArchiveStatus(ctx context.Context, addon *types.DatabaseAddon, logger *logrus.Entry) {
+	ctx, cancel := context.WithTimeout(ctx, addonUsageTimeout)
+	defer cancel()
+
+	conn, err := r.openPostgres(ctx, addon)
+	if err != nil {
+		logger.WithError(err).Debug("Failed to connect for WAL archive status")
+		return
+	}
+	defer conn.Close()
+
+	var lastArchived sql.NullTime
+	if err := conn.QueryRowContext(ctx, postgresArchiverQuery).Scan(&lastArchived); err != nil {
+		logger.WithError(err).Debug("Failed to query WAL archive status")
+		return
+	}
+
+	var archivedAt *time.Time
+	var lagSeconds *int64
+	if lastArchived.Valid {
+		lag := walArchiveLag(lastArchived.Time, time.Now())
+		archivedAt = &lastArchived.Time
+		lagSeconds = &lag
+		if lag > int64(walArchiveLagWarning.Seconds()) {
+			logger.WithField("lag_seconds", lag).Warn("WAL archiving is falling behind")
+		}
+	}
+
+	if err := r.repos.DatabaseAddons.UpdateWALArchiveStatus(ctx, addon.ID, archivedAt, lagSeconds); err != nil {
+		logger.WithError(err).Warn("Failed to store WAL archive status")
+	}
+}
+
+// walArchiveLag returns the age of the last archived WAL segment in seconds
+func walArchiveLag(lastArchived, now time.Time) int64 {
+	lag := int64(now.Sub(lastArchived).Seconds())
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
+// openPostgres connects with the CloudNativePG app credentials
+func (r *AddonReconciler) openPostgres(ctx context.Context, addon *types.DatabaseAddon) (*sql.DB, error) {
+	secret, err := r.connectionSecret(ctx, addon)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(string(secret["username"]), string(secret["password"])),
+		Host:     net.JoinHostPort(addonServiceHost(addon, string(secret["host"])), secretPort(secret, 5432)),
+		Path:     "/" + string(secret["dbname"]),
+		RawQuery: fmt.Sprintf("sslmode=require&connect_timeout=%d", int(addonUsageTimeout.Seconds())),
+	}
+
+	conn, err := sql.Open("postgres", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres connection: %w", err)
+	}
+	return conn, nil
+}
+
+// fetchMySQLUsage connects with the addon's app credentials
+func (r *AddonReconciler) fetchMySQLUsage(ctx context.Context, addon *types.DatabaseAddon) (*AddonUsage, error) {
+	secret, err := r.connectionSecret(ctx, addon)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(addonServiceHost(addon, string(secret["host"])), secretPort(secret, 3306))
+	cfg.User = string(secret["username"])
+	cfg.Passwd = string(secret["password"])
+	cfg.DBName = string(secret["database"])
+	cfg.Timeout = addonUsageTimeout
+	cfg.ReadTimeout = addonUsageTimeout
+
+	conn, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mysql connection: %w", err)
+	}
+	defer conn.Close()
+
+	usage := &AddonUsage{}
+	if err := conn.QueryRowContext(ctx, mysqlStorageQuery).Scan(&usage.StorageUsedBytes); err != nil {
+		return nil, fmt.Errorf("failed to query database size: %w", err)
+	}
+
+	var variable string
+	if err := conn.QueryRowContext(ctx, mysqlConnectionsQuery).Scan(&variable, &usage.ConnectionsActive); err != nil {
+		return nil, fmt.Errorf("failed to query connections: %w", err)
+	}
+
+	return usage, nil
+}
+
+// fetchMongoDBUsage runs mongosh inside the addon pod, using the root
+// credentials already present in the container environment
+func (r *AddonReconciler) fetchMongoDBUsage(ctx context.Context, addon *types.DatabaseAddon) (*AddonUsage, error) {
+	command := []string{"sh", "-c", fmt.Sprintf(
+		`mongosh --quiet -u "$MONGO_INITDB_ROOT_USERNAME" -p "$MONGO_INITDB_ROOT_PASSWORD" --authenticationDatabase admin "$MONGO_INITDB_DATABASE" --eval '%s'`,
+		mongoUsageScript,
+	)}
+
+	// The StatefulSet runs a single replica
+	podName := addon.K8sResourceName + "-0"
+	output, err := r.k8sClient.ExecInPod(ctx, addon.K8sNamespace, podName, "mongodb", command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query MongoDB stats: %w", err)
+	}
+
+	return parseMongoUsage(output)
+}
+
+// parseMongoUsage extracts storage bytes and connections from mongoUsageScript output
+func parseMongoUsage(output string) (*AddonUsage, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected MongoDB stats output %q", output)
+	}
+
+	storage, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage size %q: %w", fields[0], err)
+	}
+	connections, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection count %q: %w", fields[1], err)
+	}
+
+	return &AddonUsage{StorageUsedBytes: storage, ConnectionsActive: connections}, nil
+}
+
+// fetchBucketUsage sums the object sizes of a bucket with its scoped access key.
+// Buckets have no connections, so ConnectionsActive stays zero.
+func (r *AddonReconciler) fetchBucketUsage(ctx context.Context, addon *types.DatabaseAddon) (*AddonUsage, error) {
+	secret, err := r.connectionSecret(ctx, addon)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := addons.MeasureBucket(ctx, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &AddonUsage{StorageUsedBytes: size}, nil
+}
+
+// reportBucketUsage sends a bucket's storage sample to Waybill
+func (r *AddonReconciler) reportBucketUsage(ctx context.Context, addon *types.DatabaseAddon, storageBytes int64, logger *logrus.Entry) {
+	if r.waybill == nil {
+		return
+	}
+
+	err := r.waybill.RecordEvent(ctx, bucketUsageEvent(addon, storageBytes, time.Now()))
+	if err != nil {
+		logger.WithError(err).Warn("Failed to report bucket usage to Waybill")
+	}
+}
+
+// bucketUsageEvent builds the Waybill storage sample of a bucket
+func bucketUsageEvent(addon *types.DatabaseAddon, storageBytes int64, at time.Time) *clients.WaybillEvent {
+	return &clients.WaybillEvent{
+		EventType:    clients.WaybillEventStorageUsage,
+		ProjectID:    addon.ProjectID,
+		ResourceType: "bucket",
+		ResourceID:   addon.ID,
+		ResourceName: addon.Name,
+		Metrics: map[string]float64{
+			"size_gb": float64(storageBytes) / (1024 * 1024 * 1024),
+		},
+		Metadata: map[string]string{
+			"provider": addon.Config.BucketProvider,
+		},
+		Timestamp:      &at,
+		IdempotencyKey: fmt.Sprintf("storage.usage:%s:%d", addon.ID, at.Unix()),
+	}
+}
+
+// connectionSecret reads the addon's connection secret
+func (r *AddonReconciler) connectionSecret(ctx context.Context, addon *types.DatabaseAddon) (map[string][]byte, error) {
+	if addon.ConnectionSecret == "" {
+		return nil, fmt.Errorf("addon does not have connection secret configured")
+	}
+
+	secret, err := r.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Get(
+		ctx,
+		addon.ConnectionSecret,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection secret: %w", err)
+	}
+
+	return secret.Data, nil
+}
+
+// addonServiceHost qualifies a namespace-local service host so it resolves
+// from the API server's namespace
+func addonServiceHost(addon *types.DatabaseAddon, host string) string {
+	if host == "" {
+		host = addon.Host
+	}
+	if host == "" {
+		host = addon.K8sResourceName
+	}
+	if !strings.Contains(host, ".") {
+		host = fmt.Sprintf("%s.%s.svc.cluster.local", host, addon.K8sNamespace)
+	}
+	return host
+}
+
+// secretPort returns the port stored in a connection secret, or the default
+func secretPort(secret map[string][]byte, defaultPort int) string {
+	if port, err := strconv.Atoi(string(secret["port"])); err == nil && port > 0 {
+		return strconv.Itoa(port)
+	}
+	return strconv.Itoa(defaultPort)
+}
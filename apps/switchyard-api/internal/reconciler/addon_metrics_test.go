@@ -0,0 +1,137 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestParseMongoUsage(t *testing.T) {
+	tests := []struct {
+		name            string
+		output          string
+		wantStorage     int64
+		wantConnections int
+		wantErr         bool
+	}{
+		{
+			name:            "single line",
+			output:          "1048576 12\n",
+			wantStorage:     1048576,
+			wantConnections: 12,
+		},
+		{
+			name:            "warnings before result",
+			output:          "Warning: Found ~/.mongorc.js\n20480 3",
+			wantStorage:     20480,
+			wantConnections: 3,
+		},
+		{
+			name:    "empty output",
+			output:  "",
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric",
+			output:  "NaN 3",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMongoUsage(tt.output)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.StorageUsedBytes != tt.wantStorage {
+				t.Errorf("StorageUsedBytes = %d, want %d", got.StorageUsedBytes, tt.wantStorage)
+			}
+			if got.ConnectionsActive != tt.wantConnections {
+				t.Errorf("ConnectionsActive = %d, want %d", got.ConnectionsActive, tt.wantConnections)
+			}
+		})
+	}
+}
+
+func TestAddonServiceHost(t *testing.T) {
+	addon := &types.DatabaseAddon{K8sNamespace: "proj-ns", K8sResourceName: "mysql-abc"}
+
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "short service name", host: "pg-abc-rw", want: "pg-abc-rw.proj-ns.svc.cluster.local"},
+		{name: "already qualified", host: "mysql-abc.proj-ns.svc.cluster.local", want: "mysql-abc.proj-ns.svc.cluster.local"},
+		{name: "falls back to resource name", host: "", want: "mysql-abc.proj-ns.svc.cluster.local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addonServiceHost(addon, tt.host); got != tt.want {
+				t.Errorf("addonServiceHost() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWALArchiveLag(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		lastArchived time.Time
+		want         int64
+	}{
+		{name: "recent segment", lastArchived: now.Add(-90 * time.Second), want: 90},
+		{name: "stalled archiving", lastArchived: now.Add(-2 * time.Hour), want: 7200},
+		{name: "clock skew", lastArchived: now.Add(5 * time.Second), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := walArchiveLag(tt.lastArchived, now); got != tt.want {
+				t.Errorf("walArchiveLag() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketUsageEvent(t *testing.T) {
+	addon := &types.DatabaseAddon{
+		ID:        uuid.New(),
+		ProjectID: uuid.New(),
+		Name:      "uploads",
+		Type:      types.DatabaseAddonTypeBucket,
+		Config:    types.DatabaseAddonConfig{BucketProvider: "minio"},
+	}
+	at := time.Date(2026, 3, 1, 12, 5, 0, 0, time.UTC)
+
+	event := bucketUsageEvent(addon, 3*1024*1024*1024/2, at)
+
+	if event.EventType != "storage.usage" || event.ResourceType != "bucket" {
+		t.Errorf("event type = %s/%s", event.EventType, event.ResourceType)
+	}
+	if event.ProjectID != addon.ProjectID || event.ResourceID != addon.ID {
+		t.Errorf("event does not identify the addon: %+v", event)
+	}
+	if event.Metrics["size_gb"] != 1.5 {
+		t.Errorf("size_gb = %v, want 1.5", event.Metrics["size_gb"])
+	}
+	if event.Timestamp == nil || !event.Timestamp.Equal(at) {
+		t.Errorf("timestamp = %v", event.Timestamp)
+	}
+	if again := bucketUsageEvent(addon, 0, at); event.IdempotencyKey == "" || again.IdempotencyKey != event.IdempotencyKey {
+		t.Errorf("idempotency key = %q, want one per addon and sample time", event.IdempotencyKey)
+	}
+}
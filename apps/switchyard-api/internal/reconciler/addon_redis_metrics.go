@@ -0,0 +1,136 @@
+package reconciler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// redisMetricsTimeout bounds how long a single Redis INFO round-trip may take
+const redisMetricsTimeout = 5 * time.Second
+
+// RedisMetrics contains the usage figures surfaced on a Redis addon
+type RedisMetrics struct {
+	UsedMemoryBytes  int64
+	ConnectedClients int
+}
+
+// collectRedisMetrics refreshes memory and client metrics for all ready Redis addons
+func (r *AddonReconciler) collectRedisMetrics(ctx context.Context) {
+	addons, err := r.repos.DatabaseAddons.ListReadyByType(ctx, types.DatabaseAddonTypeRedis)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list ready Redis addons")
+		return
+	}
+
+	for _, addon := range addons {
+		logger := r.logger.WithFields(logrus.Fields{
+			"addon_id":  addon.ID,
+			"namespace": addon.K8sNamespace,
+			"resource":  addon.K8sResourceName,
+		})
+
+		metrics, err := r.fetchRedisMetrics(ctx, addon)
+		if err != nil {
+			logger.WithError(err).Debug("Failed to collect Redis metrics")
+			continue
+		}
+
+		if metrics.UsedMemoryBytes == addon.MemoryUsedBytes && metrics.ConnectedClients == addon.ConnectionsActive {
+			continue
+		}
+
+		if err := r.repos.DatabaseAddons.UpdateMetrics(ctx, addon.ID, addon.StorageUsedBytes, metrics.UsedMemoryBytes, metrics.ConnectedClients); err != nil {
+			logger.WithError(err).Warn("Failed to store Redis metrics")
+		}
+	}
+}
+
+// fetchRedisMetrics queries INFO memory and INFO clients on a Redis addon
+func (r *AddonReconciler) fetchRedisMetrics(ctx context.Context, addon *types.DatabaseAddon) (*RedisMetrics, error) {
+	host := addon.Host
+	if host == "" {
+		host = fmt.Sprintf("%s.%s.svc.cluster.local", addon.K8sResourceName, addon.K8sNamespace)
+	}
+	port := addon.Port
+	if port == 0 {
+		port = 6379
+	}
+
+	var password string
+	if addon.ConnectionSecret != "" {
+		secret, err := r.k8sClient.Clientset.CoreV1().Secrets(addon.K8sNamespace).Get(
+			ctx,
+			addon.ConnectionSecret,
+			metav1.GetOptions{},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get connection secret: %w", err)
+		}
+		password = string(secret.Data["password"])
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:        fmt.Sprintf("%s:%d", host, port),
+		Password:    password,
+		DialTimeout: redisMetricsTimeout,
+		ReadTimeout: redisMetricsTimeout,
+	})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, redisMetricsTimeout)
+	defer cancel()
+
+	info, err := client.Info(ctx, "memory", "clients").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Redis INFO: %w", err)
+	}
+
+	return parseRedisInfo(info)
+}
+
+// parseRedisInfo extracts used_memory and connected_clients from Redis INFO output
+func parseRedisInfo(info string) (*RedisMetrics, error) {
+	metrics := &RedisMetrics{}
+	var foundMemory, foundClients bool
+
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "used_memory":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid used_memory %q: %w", value, err)
+			}
+			metrics.UsedMemoryBytes = n
+			foundMemory = true
+		case "connected_clients":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid connected_clients %q: %w", value, err)
+			}
+			metrics.ConnectedClients = n
+			foundClients = true
+		}
+	}
+
+	if !foundMemory || !foundClients {
+		return nil, fmt.Errorf("redis INFO missing used_memory or connected_clients")
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,52 @@
+package reconciler
+
+import "testing"
+
+func TestParseRedisInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        string
+		wantMemory  int64
+		wantClients int
+		wantErr     bool
+	}{
+		{
+			name: "memory and clients sections",
+			info: "# Clients\r\nconnected_clients:7\r\nblocked_clients:0\r\n\r\n" +
+				"# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\n",
+			wantMemory:  1048576,
+			wantClients: 7,
+		},
+		{
+			name:    "missing clients section",
+			info:    "# Memory\r\nused_memory:1024\r\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed value",
+			info:    "connected_clients:abc\r\nused_memory:1024\r\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRedisInfo(tt.info)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.UsedMemoryBytes != tt.wantMemory {
+				t.Errorf("UsedMemoryBytes = %d, want %d", got.UsedMemoryBytes, tt.wantMemory)
+			}
+			if got.ConnectedClients != tt.wantClients {
+				t.Errorf("ConnectedClients = %d, want %d", got.ConnectedClients, tt.wantClients)
+			}
+		})
+	}
+}
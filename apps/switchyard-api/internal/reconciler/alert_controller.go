@@ -0,0 +1,62 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/alerting"
+)
+
+// AlertController periodically evaluates the alert rules of services
+type AlertController struct {
+	evaluator *alerting.Evaluator
+	logger    *logrus.Logger
+	interval  time.Duration
+	stopCh    chan struct{}
+}
+
+// NewAlertController creates a new alert controller
+func NewAlertController(evaluator *alerting.Evaluator, logger *logrus.Logger) *AlertController {
+	return &AlertController{
+		evaluator: evaluator,
+		logger:    logger,
+		interval:  alerting.EvaluationInterval,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start begins the evaluation loop
+func (c *AlertController) Start(ctx context.Context) {
+	c.logger.Info("Starting alert controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.evaluate(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.evaluate(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Alert controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Alert controller context cancelled")
+			return
+		}
+	}
+}
+
+func (c *AlertController) evaluate(ctx context.Context) {
+	if err := c.evaluator.Evaluate(ctx); err != nil {
+		c.logger.WithError(err).Error("Failed to evaluate alert rules")
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *AlertController) Stop() {
+	close(c.stopCh)
+}
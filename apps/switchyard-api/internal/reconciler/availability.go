@@ -0,0 +1,128 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// desiredReplicas is the number of pods a deployment runs
+func desiredReplicas(req *ReconcileRequest) int32 {
+	if req.Deployment.Replicas > 0 {
+		return int32(req.Deployment.Replicas)
+	}
+	return 1
+}
+
+// highlyAvailable reports whether a service's pods get a disruption budget
+// and are spread across nodes: it asks for it and runs more than one
+// replica. A single replica is left alone, since a budget would block node
+// drains.
+func highlyAvailable(req *ReconcileRequest) bool {
+	return req.Service.HighAvailability && desiredReplicas(req) > 1
+}
+
+// buildAntiAffinity prefers to place a service's pods on different nodes,
+// and then in different zones, without making pods unschedulable on small
+// clusters
+func buildAntiAffinity(req *ReconcileRequest, selectorLabels map[string]string) *corev1.Affinity {
+	if !highlyAvailable(req) {
+		return nil
+	}
+	selector := &metav1.LabelSelector{MatchLabels: selectorLabels}
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight:          100,
+					PodAffinityTerm: corev1.PodAffinityTerm{LabelSelector: selector, TopologyKey: corev1.LabelHostname},
+				},
+				{
+					Weight:          50,
+					PodAffinityTerm: corev1.PodAffinityTerm{LabelSelector: selector, TopologyKey: corev1.LabelTopologyZone},
+				},
+			},
+		},
+	}
+}
+
+// generatePodDisruptionBudget creates a PodDisruptionBudget that lets
+// voluntary disruptions such as node drains evict one of a service's pods
+// at a time, or nil when the service is not highly available
+func (r *ServiceReconciler) generatePodDisruptionBudget(req *ReconcileRequest, namespace string) *policyv1.PodDisruptionBudget {
+	if !highlyAvailable(req) {
+		return nil
+	}
+	maxUnavailable := intstr.FromInt32(1)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      req.Service.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":                   req.Service.Name,
+				"enclii.dev/service":    req.Service.Name,
+				"enclii.dev/project":    req.Service.ProjectID.String(),
+				"enclii.dev/managed-by": "switchyard",
+			},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{
+				"app":                req.Service.Name,
+				"enclii.dev/service": req.Service.Name,
+			}},
+		},
+	}
+}
+
+// applyPodDisruptionBudget creates or updates a PodDisruptionBudget
+func (r *ServiceReconciler) applyPodDisruptionBudget(ctx context.Context, pdb *policyv1.PodDisruptionBudget) error {
+	pdbClient := r.k8sClient.Clientset.PolicyV1().PodDisruptionBudgets(pdb.Namespace)
+
+	existing, err := pdbClient.Get(ctx, pdb.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			if _, err := pdbClient.Create(ctx, pdb, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create pod disruption budget: %w", err)
+			}
+			r.logger.WithField("pdb", pdb.Name).Info("Created pod disruption budget")
+			return nil
+		}
+		return fmt.Errorf("failed to get pod disruption budget: %w", err)
+	}
+
+	pdb.ResourceVersion = existing.ResourceVersion
+	if _, err := pdbClient.Update(ctx, pdb, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update pod disruption budget: %w", err)
+	}
+	return nil
+}
+
+// deletePodDisruptionBudget removes the PodDisruptionBudget of a service
+// that is no longer highly available. Budgets Switchyard did not create are
+// left alone.
+func (r *ServiceReconciler) deletePodDisruptionBudget(ctx context.Context, namespace, serviceName string) error {
+	pdbClient := r.k8sClient.Clientset.PolicyV1().PodDisruptionBudgets(namespace)
+
+	existing, err := pdbClient.Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get pod disruption budget: %w", err)
+	}
+	if existing.Labels["enclii.dev/managed-by"] != "switchyard" {
+		return nil
+	}
+
+	if err := pdbClient.Delete(ctx, serviceName, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pod disruption budget: %w", err)
+	}
+	r.logger.WithField("pdb", serviceName).Info("Deleted pod disruption budget")
+	return nil
+}
@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildcontext"
+)
+
+// BuildContextController periodically deletes uploaded build contexts past
+// their TTL from object storage
+type BuildContextController struct {
+	contexts *buildcontext.Service
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewBuildContextController creates a new build context controller
+func NewBuildContextController(contexts *buildcontext.Service, logger *logrus.Logger) *BuildContextController {
+	return &BuildContextController{
+		contexts: contexts,
+		logger:   logger,
+		interval: buildcontext.PurgeInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the purge loop
+func (c *BuildContextController) Start(ctx context.Context) {
+	c.logger.Info("Starting build context controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.contexts.PurgeExpired(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.contexts.PurgeExpired(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Build context controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Build context controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *BuildContextController) Stop() {
+	close(c.stopCh)
+}
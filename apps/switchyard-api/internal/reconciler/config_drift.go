@@ -0,0 +1,108 @@
+package reconciler
+
+import (
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ReconcilerEnvVarKeys are set on every service container by the reconciler
+// rather than from the service's environment variables
+var ReconcilerEnvVarKeys = []string{
+	"ENCLII_SERVICE_NAME",
+	"ENCLII_PROJECT_ID",
+	"ENCLII_RELEASE_VERSION",
+	"ENCLII_DEPLOYMENT_ID",
+	"PORT",
+}
+
+// DesiredEnvVar is an environment variable a service's pods should have
+type DesiredEnvVar struct {
+	Value     string
+	IsSecret  bool
+	UpdatedAt time.Time
+}
+
+// PodConfigDrift lists the keys in which a pod's environment differs from the
+// desired environment variables. It never carries values.
+type PodConfigDrift struct {
+	Pod       string     `json:"pod"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// Missing keys are desired but not set on the pod
+	Missing []string `json:"missing"`
+	// Extra keys are set on the pod but no longer desired
+	Extra []string `json:"extra"`
+	// Stale keys are set on the pod with an outdated value
+	Stale []string `json:"stale"`
+}
+
+// InSync reports whether the pod has the desired environment
+func (d *PodConfigDrift) InSync() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Stale) == 0
+}
+
+// DiffPodConfig compares the desired environment variables with those of a
+// running pod. values holds the pod's inline values as returned by
+// GetPodEnvVars; variables the pod reads from a secret are taken from its spec.
+// Secret values cannot be read back, so a secret is stale when it changed
+// after the pod started. Keys in ignore, such as those set by the reconciler
+// or by addon bindings, are left out on both sides.
+func DiffPodConfig(desired map[string]DesiredEnvVar, pod *corev1.Pod, values map[string]string, ignore map[string]bool) PodConfigDrift {
+	drift := PodConfigDrift{
+		Pod:     pod.Name,
+		Missing: []string{},
+		Extra:   []string{},
+		Stale:   []string{},
+	}
+
+	started := pod.CreationTimestamp.Time
+	if pod.Status.StartTime != nil {
+		started = pod.Status.StartTime.Time
+	}
+	if !started.IsZero() {
+		drift.StartedAt = &started
+	}
+
+	// present holds every key the pod sets, inline or from a secret
+	present := make(map[string]bool)
+	for key := range values {
+		present[key] = true
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil {
+				present[env.Name] = true
+			}
+		}
+	}
+
+	for key, want := range desired {
+		if ignore[key] {
+			continue
+		}
+		value, inline := values[key]
+		switch {
+		case !present[key]:
+			drift.Missing = append(drift.Missing, key)
+		case want.IsSecret:
+			// A secret set inline predates it being marked secret
+			if inline || want.UpdatedAt.After(started) {
+				drift.Stale = append(drift.Stale, key)
+			}
+		case !inline || value != want.Value:
+			drift.Stale = append(drift.Stale, key)
+		}
+	}
+
+	for key := range present {
+		if _, ok := desired[key]; !ok && !ignore[key] {
+			drift.Extra = append(drift.Extra, key)
+		}
+	}
+
+	sort.Strings(drift.Missing)
+	sort.Strings(drift.Extra)
+	sort.Strings(drift.Stale)
+	return drift
+}
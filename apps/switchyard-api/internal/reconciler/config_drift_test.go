@@ -0,0 +1,96 @@
+package reconciler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffPodConfig(t *testing.T) {
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	before, after := started.Add(-time.Hour), started.Add(time.Hour)
+
+	secretRef := func(name string) corev1.EnvVar {
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{Key: name},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		desired map[string]DesiredEnvVar
+		refs    []corev1.EnvVar
+		values  map[string]string
+		want    PodConfigDrift
+	}{
+		{
+			name:    "in sync",
+			desired: map[string]DesiredEnvVar{"LOG_LEVEL": {Value: "info"}, "API_KEY": {Value: "k", IsSecret: true, UpdatedAt: before}},
+			refs:    []corev1.EnvVar{secretRef("API_KEY")},
+			values:  map[string]string{"LOG_LEVEL": "info", "PORT": "8080"},
+			want:    PodConfigDrift{Missing: []string{}, Extra: []string{}, Stale: []string{}},
+		},
+		{
+			name:    "missing and extra keys",
+			desired: map[string]DesiredEnvVar{"LOG_LEVEL": {Value: "info"}, "API_KEY": {Value: "k", IsSecret: true}},
+			refs:    []corev1.EnvVar{secretRef("OLD_TOKEN")},
+			values:  map[string]string{"FEATURE_X": "on"},
+			want:    PodConfigDrift{Missing: []string{"API_KEY", "LOG_LEVEL"}, Extra: []string{"FEATURE_X", "OLD_TOKEN"}, Stale: []string{}},
+		},
+		{
+			name:    "changed value",
+			desired: map[string]DesiredEnvVar{"LOG_LEVEL": {Value: "debug"}},
+			values:  map[string]string{"LOG_LEVEL": "info"},
+			want:    PodConfigDrift{Missing: []string{}, Extra: []string{}, Stale: []string{"LOG_LEVEL"}},
+		},
+		{
+			name:    "secret changed after the pod started",
+			desired: map[string]DesiredEnvVar{"API_KEY": {Value: "k", IsSecret: true, UpdatedAt: after}},
+			refs:    []corev1.EnvVar{secretRef("API_KEY")},
+			want:    PodConfigDrift{Missing: []string{}, Extra: []string{}, Stale: []string{"API_KEY"}},
+		},
+		{
+			name:    "variable marked secret after the pod started",
+			desired: map[string]DesiredEnvVar{"API_KEY": {Value: "k", IsSecret: true, UpdatedAt: before}},
+			values:  map[string]string{"API_KEY": "k"},
+			want:    PodConfigDrift{Missing: []string{}, Extra: []string{}, Stale: []string{"API_KEY"}},
+		},
+		{
+			name:    "secret made a plain variable",
+			desired: map[string]DesiredEnvVar{"API_KEY": {Value: "k"}},
+			refs:    []corev1.EnvVar{secretRef("API_KEY")},
+			want:    PodConfigDrift{Missing: []string{}, Extra: []string{}, Stale: []string{"API_KEY"}},
+		},
+	}
+
+	ignore := map[string]bool{"PORT": true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "api-7d9f-abcde"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Env: tt.refs}}},
+				Status:     corev1.PodStatus{StartTime: &metav1.Time{Time: started}},
+			}
+
+			got := DiffPodConfig(tt.desired, pod, tt.values, ignore)
+			if got.Pod != pod.Name || got.StartedAt == nil || !got.StartedAt.Equal(started) {
+				t.Errorf("pod = %q started %v, want %q started %v", got.Pod, got.StartedAt, pod.Name, started)
+			}
+			if !reflect.DeepEqual(got.Missing, tt.want.Missing) {
+				t.Errorf("Missing = %v, want %v", got.Missing, tt.want.Missing)
+			}
+			if !reflect.DeepEqual(got.Extra, tt.want.Extra) {
+				t.Errorf("Extra = %v, want %v", got.Extra, tt.want.Extra)
+			}
+			if !reflect.DeepEqual(got.Stale, tt.want.Stale) {
+				t.Errorf("Stale = %v, want %v", got.Stale, tt.want.Stale)
+			}
+			if got.InSync() != tt.want.InSync() {
+				t.Errorf("InSync() = %v, want %v", got.InSync(), tt.want.InSync())
+			}
+		})
+	}
+}
@@ -3,17 +3,24 @@ package reconciler
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/events"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/registry"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/soak"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -28,21 +35,37 @@ type Controller struct {
 	// Notification service for webhooks (optional)
 	notificationService *notifications.Service
 
+	// Event broker for real-time status streaming (optional)
+	eventBroker *events.Broker
+
+	// Soak monitor that holds new releases before they are marked stable (optional)
+	soakMonitor *soak.Monitor
+
+	// Clients of registered clusters environments can be pinned to (optional)
+	clients *k8s.ClientPool
+
 	// Control channels
 	stopCh   chan struct{}
-	workCh   chan *ReconcileWork
+	queue    *workQueue // Claimed work waiting for a worker
 	resultCh chan *ReconcileWorkResult
 
+	// Durable work queue shared by all replicas (nil = in-memory only)
+	jobs    *db.ReconcileJobRepository
+	owner   string        // Identifies this replica's job claims
+	claimCh chan struct{} // Wakes the job claimer
+
 	// Worker management
 	workers int
 	wg      sync.WaitGroup
 	started bool
 	mu      sync.RWMutex
 
-	// Backpressure tracking
-	droppedWork int64            // Atomic counter for dropped work items
-	retryQueue  []*ReconcileWork // Items that need to be retried when queue has space
-	retryMu     sync.Mutex       // Protects retryQueue
+	// Work dropped because the in-memory queue was full (no durable queue)
+	droppedWork int64
+
+	// Stall detection thresholds
+	deploymentStallThreshold time.Duration
+	buildStallThreshold      time.Duration
 }
 
 // ReconcileWork represents a unit of reconciliation work
@@ -51,6 +74,18 @@ type ReconcileWork struct {
 	Priority     int
 	Attempt      int
 	ScheduledAt  time.Time
+
+	// JobID is the durable job the work was claimed from, if any
+	JobID uuid.UUID
+
+	// Class and EnvironmentID place the work in the queue; they are looked
+	// up from the deployment when the work is queued without them
+	Class         WorkClass
+	EnvironmentID string
+
+	// DriftPolicy is what to do with manual changes to the deployment's
+	// resources; empty means overwrite
+	DriftPolicy types.DriftPolicy
 }
 
 // ReconcileWorkResult represents the result of reconciliation work
@@ -61,10 +96,11 @@ type ReconcileWorkResult struct {
 
 // QueuePressure tracks work queue metrics
 type QueuePressure struct {
-	QueueSize     int
+	QueueSize     int // Claimed work waiting for a worker on this replica
 	QueueCapacity int
 	DroppedWork   int64
-	RetryQueue    int
+	Composition   QueueComposition
+	Backlog       *db.ReconcileBacklog // Durable queue across replicas (nil without one)
 }
 
 // ErrQueueFull is returned when the work queue cannot accept more work
@@ -72,17 +108,31 @@ var ErrQueueFull = fmt.Errorf("work queue is full")
 
 // NewController creates a new reconciliation controller
 func NewController(database *sql.DB, repositories *db.Repositories, k8sClient *k8s.Client, logger *logrus.Logger) *Controller {
-	return &Controller{
+	c := &Controller{
 		db:                database,
 		repositories:      repositories,
 		serviceReconciler: NewServiceReconciler(k8sClient, logger),
 		k8sClient:         k8sClient,
 		logger:            logger,
 		stopCh:            make(chan struct{}),
-		workCh:            make(chan *ReconcileWork, 100),
 		resultCh:          make(chan *ReconcileWorkResult, 100),
+		claimCh:           make(chan struct{}, 1),
+		owner:             claimOwner(),
 		workers:           5, // Number of concurrent reconcilers
+
+		deploymentStallThreshold: DefaultDeploymentStallThreshold,
+		buildStallThreshold:      DefaultBuildStallThreshold,
 	}
+
+	// With the durable queue, only a few jobs per worker are claimed ahead
+	// so the rest stay available to other replicas in priority order
+	capacity := defaultWorkQueueCapacity
+	if repositories != nil && repositories.ReconcileJobs != nil {
+		c.jobs = repositories.ReconcileJobs
+		capacity = c.workers * claimPrefetchPerWorker
+	}
+	c.queue = newWorkQueue(capacity, defaultStarvationAge)
+	return c
 }
 
 // SetNotificationService sets the notification service for sending webhook events
@@ -90,6 +140,30 @@ func (c *Controller) SetNotificationService(svc *notifications.Service) {
 	c.notificationService = svc
 }
 
+// SetEventBroker sets the broker used to stream deployment status changes
+func (c *Controller) SetEventBroker(broker *events.Broker) {
+	c.eventBroker = broker
+}
+
+// SetSoakMonitor sets the monitor that starts a soak when a deployment becomes running
+func (c *Controller) SetSoakMonitor(monitor *soak.Monitor) {
+	c.soakMonitor = monitor
+}
+
+// SetClientPool sets the clients of registered clusters. Without it every
+// environment is reconciled in the cluster the API runs in.
+func (c *Controller) SetClientPool(pool *k8s.ClientPool) {
+	c.clients = pool
+}
+
+// clientFor returns the client of the cluster an environment is deployed to
+func (c *Controller) clientFor(ctx context.Context, environment *types.Environment) (*k8s.Client, error) {
+	if environment.ClusterID == nil || c.clients == nil {
+		return c.k8sClient, nil
+	}
+	return c.clients.Get(ctx, environment.ClusterID)
+}
+
 // Start begins the reconciliation controller
 func (c *Controller) Start(ctx context.Context) error {
 	c.mu.Lock()
@@ -116,13 +190,23 @@ func (c *Controller) Start(ctx context.Context) error {
 	c.wg.Add(1)
 	go c.workScheduler(ctx)
 
-	// Start K8s→DB sync job (runs every 60 seconds)
+	// Start K8s→DB sync (watches managed deployments and pods)
+	c.wg.Add(1)
+	go c.k8sWatcher(ctx)
+
+	// Start metrics reporter
 	c.wg.Add(1)
-	go c.k8sSyncScheduler(ctx)
+	go c.metricsReporter(ctx)
+
+	// Start job claimer (moves durable jobs into the work queue)
+	if c.jobs != nil {
+		c.wg.Add(1)
+		go c.jobClaimer(ctx)
+	}
 
-	// Start retry queue processor (drains retry queue when work queue has space)
+	// Start stall watchdog (flags deployments and builds stuck past their thresholds)
 	c.wg.Add(1)
-	go c.retryQueueProcessor(ctx)
+	go c.stallWatchdog(ctx)
 
 	c.logger.WithField("workers", c.workers).Info("Reconciliation controller started")
 	return nil
@@ -140,12 +224,14 @@ func (c *Controller) Stop() {
 	c.logger.Info("Stopping reconciliation controller")
 	close(c.stopCh)
 	c.wg.Wait()
+	c.releaseClaims()
 	c.started = false
 	c.logger.Info("Reconciliation controller stopped")
 }
 
-// ScheduleReconciliation adds a deployment to the reconciliation queue.
-// Returns ErrQueueFull if the queue cannot accept the work.
+// ScheduleReconciliation adds a deployment to the reconciliation queue,
+// from which any replica running the controller takes it. Returns an error
+// if the work could not be queued.
 func (c *Controller) ScheduleReconciliation(deploymentID string, priority int) error {
 	work := &ReconcileWork{
 		DeploymentID: deploymentID,
@@ -157,32 +243,111 @@ func (c *Controller) ScheduleReconciliation(deploymentID string, priority int) e
 	return c.enqueueWork(work)
 }
 
-// enqueueWork attempts to add work to the queue, with retry queue fallback
+// ForceReconciliation queues a deployment to be reconciled again ahead of
+// scheduled work, handling manual changes to its resources under policy.
+// Returns an error if the work could not be queued.
+func (c *Controller) ForceReconciliation(deploymentID string, policy types.DriftPolicy) error {
+	work := &ReconcileWork{
+		DeploymentID: deploymentID,
+		Priority:     100,
+		Attempt:      1,
+		ScheduledAt:  time.Now(),
+		DriftPolicy:  policy,
+	}
+
+	return c.enqueueWork(work)
+}
+
+// enqueueWork queues work in the durable queue, or in memory when there is
+// none
 func (c *Controller) enqueueWork(work *ReconcileWork) error {
-	select {
-	case c.workCh <- work:
+	c.classifyWork(work)
+
+	if c.jobs == nil {
+		return c.enqueueLocal(work)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.jobs.Enqueue(ctx, jobFromWork(work)); err != nil {
+		c.logger.WithError(err).WithField("deployment", work.DeploymentID).Error("Failed to queue reconciliation work")
+		return err
+	}
+	c.signalClaim()
+
+	c.logger.WithFields(logrus.Fields{
+		"deployment": work.DeploymentID,
+		"class":      work.Class,
+		"priority":   work.Priority,
+		"attempt":    work.Attempt,
+	}).Debug("Scheduled reconciliation work")
+	return nil
+}
+
+// enqueueLocal queues work in memory. Work that does not fit is dropped;
+// pending deployments are queued again by the work scheduler.
+func (c *Controller) enqueueLocal(work *ReconcileWork) error {
+	accepted, evicted := c.queue.push(work)
+	if evicted != nil {
+		atomic.AddInt64(&c.droppedWork, 1)
+		monitoring.RecordReconcileDropped(string(evicted.Class))
 		c.logger.WithFields(logrus.Fields{
-			"deployment": work.DeploymentID,
-			"priority":   work.Priority,
-			"attempt":    work.Attempt,
-		}).Debug("Scheduled reconciliation work")
-		return nil
-	default:
-		// Queue is full - add to retry queue with backpressure tracking
+			"deployment": evicted.DeploymentID,
+			"class":      evicted.Class,
+			"preempted":  work.DeploymentID,
+		}).Info("Work queue full, dropped lower-class work")
+	}
+	if !accepted {
 		atomic.AddInt64(&c.droppedWork, 1)
+		monitoring.RecordReconcileDropped(string(work.Class))
+		c.logger.WithFields(logrus.Fields{
+			"deployment":    work.DeploymentID,
+			"dropped_total": atomic.LoadInt64(&c.droppedWork),
+		}).Warn("Work queue full, dropped work")
+		return ErrQueueFull
+	}
 
-		c.retryMu.Lock()
-		c.retryQueue = append(c.retryQueue, work)
-		retryLen := len(c.retryQueue)
-		c.retryMu.Unlock()
+	c.logger.WithFields(logrus.Fields{
+		"deployment": work.DeploymentID,
+		"class":      work.Class,
+		"priority":   work.Priority,
+		"attempt":    work.Attempt,
+	}).Debug("Scheduled reconciliation work")
+	return nil
+}
 
-		c.logger.WithFields(logrus.Fields{
-			"deployment":       work.DeploymentID,
-			"retry_queue_size": retryLen,
-			"dropped_total":    atomic.LoadInt64(&c.droppedWork),
-		}).Warn("Work queue full, added to retry queue")
+// classifyWork sets the class and environment of work from its
+// deployment. Work whose deployment cannot be looked up is standard.
+func (c *Controller) classifyWork(work *ReconcileWork) {
+	if work.Class != "" {
+		return
+	}
+	work.Class = WorkClassStandard
+	if c.repositories == nil || c.repositories.Deployments == nil {
+		return
+	}
 
-		return ErrQueueFull
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deployment, err := c.repositories.Deployments.GetByID(ctx, work.DeploymentID)
+	if err != nil {
+		c.logger.WithError(err).WithField("deployment", work.DeploymentID).Debug("Failed to classify reconciliation work")
+		return
+	}
+	work.EnvironmentID = deployment.EnvironmentID.String()
+
+	if c.repositories.PreviewEnvironments != nil {
+		if _, err := c.repositories.PreviewEnvironments.GetByDeployment(ctx, deployment.ID); err == nil {
+			work.Class = WorkClassPreview
+			return
+		}
+	}
+	if c.repositories.Environments != nil {
+		if env, err := c.repositories.Environments.GetByID(ctx, deployment.EnvironmentID); err == nil && env.Name == "production" {
+			work.Class = WorkClassProduction
+		}
 	}
 }
 
@@ -201,16 +366,35 @@ func (c *Controller) worker(ctx context.Context, workerID int) {
 		case <-ctx.Done():
 			logger.Debug("Worker context cancelled")
 			return
-		case work := <-c.workCh:
-			result := c.processWork(ctx, work, logger)
+		default:
+		}
 
+		work := c.queue.pop()
+		if work != nil {
+			// Room for the claimer to take another job
+			c.signalClaim()
+		}
+		if work == nil {
 			select {
-			case c.resultCh <- &ReconcileWorkResult{Work: work, Result: result}:
 			case <-c.stopCh:
+				logger.Debug("Worker stopping")
 				return
 			case <-ctx.Done():
+				logger.Debug("Worker context cancelled")
 				return
+			case <-c.queue.ready:
 			}
+			continue
+		}
+
+		result := c.processWork(ctx, work, logger)
+
+		select {
+		case c.resultCh <- &ReconcileWorkResult{Work: work, Result: result}:
+		case <-c.stopCh:
+			return
+		case <-ctx.Done():
+			return
 		}
 	}
 }
@@ -269,12 +453,33 @@ func (c *Controller) processWork(ctx context.Context, work *ReconcileWork, logge
 		}
 	}
 
+	// Keep other deployments of the service out until this one is handled
+	if result := c.acquireDeployLock(ctx, deployment, service, environment, logger); result != nil {
+		return result
+	}
+
+	// Announce new deployments on their first reconciliation
+	if work.Attempt == 0 && deployment.Status == types.DeploymentStatusPending && c.notificationService != nil {
+		go c.sendDeploymentStartedNotification(ctx, deployment, release)
+	}
+
+	// Reconcile in the cluster the environment is deployed to
+	k8sClient, err := c.clientFor(ctx, environment)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get cluster client")
+		return &ReconcileResult{
+			Success: false,
+			Message: "Failed to connect to the environment's cluster",
+			Error:   err,
+		}
+	}
+
 	// CRITICAL: Check if K8s deployment has reconciliation disabled BEFORE reconciling
 	// This prevents the reconciler from overwriting manually-managed deployments like Janua
 	// The annotation check in syncDeploymentToDatabase only applies during K8s→DB sync,
 	// this check applies during actual reconciliation of existing DB records
-	if c.k8sClient != nil {
-		existing, err := c.k8sClient.Clientset.AppsV1().Deployments(environment.KubeNamespace).Get(
+	if k8sClient != nil {
+		existing, err := k8sClient.Clientset.AppsV1().Deployments(environment.KubeNamespace).Get(
 			ctx, service.Name, metav1.GetOptions{},
 		)
 		if err == nil {
@@ -324,6 +529,24 @@ func (c *Controller) processWork(ctx context.Context, work *ReconcileWork, logge
 		envVars = make(map[string]string)
 	}
 
+	// Preview deployments get the service's preview overrides on top
+	if c.repositories.EnvVars != nil && c.repositories.PreviewEnvironments != nil {
+		if _, err := c.repositories.PreviewEnvironments.GetByDeployment(ctx, deployment.ID); err == nil {
+			overrides, err := c.repositories.EnvVars.GetPreviewDecryptedWithMeta(ctx, service.ID)
+			if err != nil {
+				logger.WithError(err).Warn("Failed to get preview env var overrides, continuing without them")
+			} else {
+				converted := make([]EnvVarWithMeta, len(overrides))
+				for i, ev := range overrides {
+					converted[i] = EnvVarWithMeta{Key: ev.Key, Value: ev.Value, IsSecret: ev.IsSecret}
+				}
+				envVarsWithMeta = applyEnvVarOverrides(envVars, envVarsWithMeta, converted)
+			}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			logger.WithError(err).Warn("Failed to look up preview of deployment")
+		}
+	}
+
 	// Get database addon bindings for this service
 	var addonBindings []AddonBinding
 	if c.repositories.DatabaseAddons != nil {
@@ -338,8 +561,12 @@ func (c *Controller) processWork(ctx context.Context, work *ReconcileWork, logge
 					logger.WithError(err).WithField("addon_id", binding.AddonID).Warn("Failed to get addon for binding")
 					continue
 				}
-				// Only include ready addons
-				if addon.Status != types.DatabaseAddonStatusReady {
+				// Addons scoped to an environment only serve that environment
+				if addon.EnvironmentID != nil && *addon.EnvironmentID != environment.ID {
+					continue
+				}
+				// Only include ready addons (resizing addons keep serving)
+				if !addon.IsAvailable() {
 					logger.WithFields(logrus.Fields{
 						"addon_id": addon.ID,
 						"status":   addon.Status,
@@ -352,24 +579,85 @@ func (c *Controller) processWork(ctx context.Context, work *ReconcileWork, logge
 					K8sNamespace:     addon.K8sNamespace,
 					K8sResourceName:  addon.K8sResourceName,
 					ConnectionSecret: addon.ConnectionSecret,
+					External:         addon.Type == types.DatabaseAddonTypeBucket && addon.Config.BucketProvider == addons.BucketProviderS3,
 				})
 			}
 		}
 	}
 
+	// Only rollouts of pending deployments go through phases; periodic
+	// reconciles of running ones would churn them
+	rollout := deployment.Status == types.DeploymentStatusPending
+	if rollout {
+		c.enterPhase(ctx, deployment.ID, types.DeploymentPhaseImageVerifying, logger)
+	}
+
+	// Get the project's private registry credentials for the pull secret.
+	// Without them an image from a private registry fails to pull, so a
+	// failure to read them fails the reconcile.
+	var registryCredentials []db.RegistryAuth
+	if c.repositories.RegistryCredentials != nil {
+		creds, err := c.repositories.RegistryCredentials.ListDecrypted(ctx, service.ProjectID, &environment.ID)
+		if err != nil {
+			return &ReconcileResult{
+				Success: false,
+				Message: "Failed to get registry credentials",
+				Error:   err,
+			}
+		}
+		registryCredentials, err = registry.Resolve(ctx, creds)
+		if err != nil {
+			return &ReconcileResult{
+				Success: false,
+				Message: "Failed to get registry tokens",
+				Error:   err,
+			}
+		}
+	}
+
+	// Get the service's command in this environment. A failure would run
+	// the wrong process, so it fails the reconcile.
+	var command *types.ContainerCommand
+	if c.repositories.ServiceCommands != nil {
+		resolved, err := c.repositories.ServiceCommands.Resolve(ctx, service, environment.ID)
+		if err != nil {
+			return &ReconcileResult{
+				Success: false,
+				Message: "Failed to get service command",
+				Error:   err,
+			}
+		}
+		command = resolved
+	}
+
 	// Create reconcile request
 	req := &ReconcileRequest{
-		Service:         service,
-		Release:         release,
-		Deployment:      deployment,
-		Environment:     environment,
-		EnvVars:         envVars,
-		EnvVarsWithMeta: envVarsWithMeta,
-		AddonBindings:   addonBindings,
+		Service:             service,
+		Release:             release,
+		Deployment:          deployment,
+		Environment:         environment,
+		EnvVars:             envVars,
+		EnvVarsWithMeta:     envVarsWithMeta,
+		AddonBindings:       addonBindings,
+		DriftPolicy:         work.DriftPolicy,
+		Command:             command,
+		RegistryCredentials: registryCredentials,
+	}
+	if rollout {
+		req.OnPhase = func(phase types.DeploymentPhase) {
+			c.enterPhase(ctx, deployment.ID, phase, logger)
+		}
+		req.OnRollout = func(ready, desired int) {
+			c.recordRollout(ctx, deployment.ID, ready, desired, logger)
+		}
 	}
 
+	// Record the resolved configuration for later inspection
+	c.captureConfigSnapshot(ctx, req, logger)
+
 	// Perform reconciliation
-	result := c.serviceReconciler.Reconcile(ctx, req)
+	result := c.serviceReconciler.WithClient(k8sClient).Reconcile(ctx, req)
+	result.Rollout = rollout
 
 	duration := time.Since(start)
 	logger.WithFields(logrus.Fields{
@@ -378,41 +666,73 @@ func (c *Controller) processWork(ctx context.Context, work *ReconcileWork, logge
 		"message":  result.Message,
 	}).Info("Completed reconciliation work")
 
+	monitoring.RecordReconcile(c.projectLabel(ctx, service.ProjectID), environment.Name, string(work.Class), reconcileOutcome(result), duration)
+
 	return result
 }
 
+// projectLabel names a project in metrics, by slug when it can be found
+func (c *Controller) projectLabel(ctx context.Context, projectID uuid.UUID) string {
+	if c.repositories.Projects != nil {
+		if project, err := c.repositories.Projects.GetByID(ctx, projectID); err == nil {
+			return project.Slug
+		}
+	}
+	return projectID.String()
+}
+
+// reconcileOutcome classifies a result for metrics
+func reconcileOutcome(result *ReconcileResult) string {
+	switch {
+	case result.Success:
+		return "success"
+	case result.NextCheck != nil:
+		return "retry"
+	default:
+		return "failure"
+	}
+}
+
 // GetStatus returns the current status of the controller
 func (c *Controller) GetStatus() map[string]interface{} {
+	backlog := c.backlog()
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	c.retryMu.Lock()
-	retryQueueLen := len(c.retryQueue)
-	c.retryMu.Unlock()
-
-	return map[string]interface{}{
-		"started":            c.started,
-		"workers":            c.workers,
-		"work_queue":         len(c.workCh),
-		"work_queue_cap":     cap(c.workCh),
-		"result_queue":       len(c.resultCh),
-		"result_queue_cap":   cap(c.resultCh),
-		"retry_queue":        retryQueueLen,
-		"dropped_work_total": atomic.LoadInt64(&c.droppedWork),
+	composition := c.queue.composition()
+
+	status := map[string]interface{}{
+		"started":                        c.started,
+		"workers":                        c.workers,
+		"work_queue":                     c.queue.len(),
+		"work_queue_cap":                 c.queue.capacity,
+		"work_queue_by_class":            composition.ByClass,
+		"work_queue_by_environment":      composition.ByEnvironment,
+		"work_queue_oldest_wait_seconds": composition.OldestWait.Seconds(),
+		"result_queue":                   len(c.resultCh),
+		"result_queue_cap":               cap(c.resultCh),
+		"dropped_work_total":             atomic.LoadInt64(&c.droppedWork),
 	}
+	if backlog != nil {
+		status["backlog_ready"] = backlog.Ready
+		status["backlog_scheduled"] = backlog.Scheduled
+		status["backlog_claimed"] = backlog.Claimed
+		status["backlog_by_class"] = backlog.ByClass
+		status["backlog_by_environment"] = backlog.ByEnvironment
+		status["backlog_oldest_ready_seconds"] = backlog.OldestReady.Seconds()
+	}
+	return status
 }
 
 // GetQueuePressure returns detailed backpressure metrics
 func (c *Controller) GetQueuePressure() QueuePressure {
-	c.retryMu.Lock()
-	retryQueueLen := len(c.retryQueue)
-	c.retryMu.Unlock()
-
 	return QueuePressure{
-		QueueSize:     len(c.workCh),
-		QueueCapacity: cap(c.workCh),
+		QueueSize:     c.queue.len(),
+		QueueCapacity: c.queue.capacity,
 		DroppedWork:   atomic.LoadInt64(&c.droppedWork),
-		RetryQueue:    retryQueueLen,
+		Composition:   c.queue.composition(),
+		Backlog:       c.backlog(),
 	}
 }
 
@@ -425,8 +745,9 @@ func (c *Controller) HealthCheck() error {
 		return fmt.Errorf("controller not started")
 	}
 
-	// Check if work channels are functional
-	if len(c.workCh) == cap(c.workCh) {
+	// A full queue only means trouble without the durable queue, which
+	// keeps the queue topped up by design
+	if c.jobs == nil && c.queue.free() == 0 {
 		return fmt.Errorf("work queue is full")
 	}
 
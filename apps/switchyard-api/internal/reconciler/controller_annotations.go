@@ -0,0 +1,142 @@
+package reconciler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/pricing"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// defaultMemoryRequest matches the default applied in buildResourceRequirements
+const defaultMemoryRequest = "128Mi"
+
+// annotateDeployment computes cost and performance deltas for a completed deployment
+// and stores them on the deployment record. Failures are logged and never block the result.
+func (c *Controller) annotateDeployment(ctx context.Context, deploymentID uuid.UUID, logger *logrus.Entry) {
+	deployment, err := c.repositories.Deployments.GetByID(ctx, deploymentID.String())
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get deployment for annotations")
+		return
+	}
+
+	release, err := c.repositories.Releases.GetByID(deployment.ReleaseID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get release for annotations")
+		return
+	}
+
+	service, err := c.repositories.Services.GetByID(release.ServiceID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get service for annotations")
+		return
+	}
+
+	var previous *types.Deployment
+	var previousRelease *types.Release
+	previous, err = c.repositories.Deployments.GetPreviousSuccessful(ctx, deployment, service.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.WithError(err).Warn("Failed to get previous deployment for annotations")
+	}
+	if previous != nil {
+		previousRelease, err = c.repositories.Releases.GetByID(previous.ReleaseID)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to get previous release for annotations")
+			previousRelease = nil
+		}
+	}
+
+	avgBuild, err := c.repositories.Releases.GetAverageBuildDuration(ctx, service.ID, release.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get average build duration for annotations")
+		avgBuild = nil
+	}
+
+	annotations := computeDeploymentAnnotations(deployment, release, previous, previousRelease, avgBuild, service.Resources)
+	if err := c.repositories.Deployments.UpdateAnnotations(ctx, deployment.ID, annotations); err != nil {
+		logger.WithError(err).Warn("Failed to store deployment annotations")
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"estimated_monthly_cost": annotations.EstimatedMonthlyCost,
+	}).Debug("Deployment annotations stored")
+}
+
+// computeDeploymentAnnotations derives deltas between a deployment and its predecessor.
+// previous, previousRelease, and avgBuildSecs may be nil.
+func computeDeploymentAnnotations(
+	deployment *types.Deployment,
+	release *types.Release,
+	previous *types.Deployment,
+	previousRelease *types.Release,
+	avgBuildSecs *float64,
+	resources *types.ResourceConfig,
+) *types.DeploymentAnnotations {
+	annotations := &types.DeploymentAnnotations{
+		ImageSizeBytes:       release.ImageSizeBytes,
+		BuildDurationSecs:    release.BuildDurationSecs,
+		AvgBuildDurationSecs: avgBuildSecs,
+		EstimatedMonthlyCost: estimateMonthlyCost(deployment.Replicas, resources),
+		ComputedAt:           time.Now(),
+	}
+
+	if release.BuildDurationSecs != nil && avgBuildSecs != nil && *avgBuildSecs > 0 {
+		pct := roundTo((*release.BuildDurationSecs-*avgBuildSecs) / *avgBuildSecs * 100, 1)
+		annotations.BuildDurationDeltaPct = &pct
+	}
+
+	if previous == nil {
+		return annotations
+	}
+
+	previousID := previous.ID
+	annotations.PreviousDeploymentID = &previousID
+
+	replicasDelta := deployment.Replicas - previous.Replicas
+	annotations.ReplicasDelta = &replicasDelta
+
+	// Prefer the estimate recorded at the time of the previous deployment so
+	// resource changes made since then are reflected in the delta
+	previousCost := estimateMonthlyCost(previous.Replicas, resources)
+	if previous.Annotations != nil {
+		previousCost = previous.Annotations.EstimatedMonthlyCost
+	}
+	costDelta := roundTo(annotations.EstimatedMonthlyCost-previousCost, 2)
+	annotations.EstimatedMonthlyCostDelta = &costDelta
+
+	if release.ImageSizeBytes != nil && previousRelease != nil && previousRelease.ImageSizeBytes != nil {
+		sizeDelta := *release.ImageSizeBytes - *previousRelease.ImageSizeBytes
+		annotations.ImageSizeDeltaBytes = &sizeDelta
+	}
+
+	return annotations
+}
+
+// estimateMonthlyCost estimates compute cost in USD from the memory request and replica count
+func estimateMonthlyCost(replicas int, resources *types.ResourceConfig) float64 {
+	memRequest := defaultMemoryRequest
+	if resources != nil && resources.MemoryRequest != "" {
+		memRequest = resources.MemoryRequest
+	}
+
+	qty, err := resource.ParseQuantity(memRequest)
+	if err != nil {
+		qty = resource.MustParse(defaultMemoryRequest)
+	}
+
+	memoryGB := float64(qty.Value()) / (1024 * 1024 * 1024)
+	return roundTo(memoryGB*float64(replicas)*pricing.HoursPerMonth*pricing.ComputePerGBHour, 2)
+}
+
+func roundTo(v float64, places int) float64 {
+	factor := math.Pow(10, float64(places))
+	return math.Round(v*factor) / factor
+}
@@ -0,0 +1,81 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func int64Ptr(v int64) *int64       { return &v }
+func float64Ptr(v float64) *float64 { return &v }
+
+func TestEstimateMonthlyCost(t *testing.T) {
+	tests := []struct {
+		name      string
+		replicas  int
+		resources *types.ResourceConfig
+		expected  float64
+	}{
+		{"default memory single replica", 1, nil, 4.56},
+		{"default memory three replicas", 3, nil, 13.69},
+		{"1Gi two replicas", 2, &types.ResourceConfig{MemoryRequest: "1Gi"}, 73},
+		{"invalid quantity falls back to default", 1, &types.ResourceConfig{MemoryRequest: "lots"}, 4.56},
+		{"zero replicas", 0, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateMonthlyCost(tt.replicas, tt.resources); got != tt.expected {
+				t.Errorf("estimateMonthlyCost() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComputeDeploymentAnnotations_FirstDeployment(t *testing.T) {
+	deployment := &types.Deployment{ID: uuid.New(), Replicas: 1}
+	release := &types.Release{ImageSizeBytes: int64Ptr(100), BuildDurationSecs: float64Ptr(90)}
+
+	got := computeDeploymentAnnotations(deployment, release, nil, nil, float64Ptr(60), nil)
+
+	if got.PreviousDeploymentID != nil {
+		t.Errorf("expected no previous deployment, got %v", got.PreviousDeploymentID)
+	}
+	if got.EstimatedMonthlyCostDelta != nil || got.ImageSizeDeltaBytes != nil || got.ReplicasDelta != nil {
+		t.Errorf("expected no deltas for first deployment, got %+v", got)
+	}
+	if got.BuildDurationDeltaPct == nil || *got.BuildDurationDeltaPct != 50 {
+		t.Errorf("expected build duration delta 50%%, got %v", got.BuildDurationDeltaPct)
+	}
+}
+
+func TestComputeDeploymentAnnotations_WithPrevious(t *testing.T) {
+	previous := &types.Deployment{
+		ID:          uuid.New(),
+		Replicas:    1,
+		Annotations: &types.DeploymentAnnotations{EstimatedMonthlyCost: 4.56},
+	}
+	previousRelease := &types.Release{ImageSizeBytes: int64Ptr(1000)}
+	deployment := &types.Deployment{ID: uuid.New(), Replicas: 3}
+	release := &types.Release{ImageSizeBytes: int64Ptr(750)}
+
+	got := computeDeploymentAnnotations(deployment, release, previous, previousRelease, nil, nil)
+
+	if got.PreviousDeploymentID == nil || *got.PreviousDeploymentID != previous.ID {
+		t.Errorf("expected previous deployment %s, got %v", previous.ID, got.PreviousDeploymentID)
+	}
+	if got.ReplicasDelta == nil || *got.ReplicasDelta != 2 {
+		t.Errorf("expected replicas delta 2, got %v", got.ReplicasDelta)
+	}
+	if got.ImageSizeDeltaBytes == nil || *got.ImageSizeDeltaBytes != -250 {
+		t.Errorf("expected image size delta -250, got %v", got.ImageSizeDeltaBytes)
+	}
+	if got.EstimatedMonthlyCostDelta == nil || *got.EstimatedMonthlyCostDelta != 9.13 {
+		t.Errorf("expected cost delta 9.13, got %v", got.EstimatedMonthlyCostDelta)
+	}
+	if got.BuildDurationDeltaPct != nil {
+		t.Errorf("expected no build duration delta without history, got %v", *got.BuildDurationDeltaPct)
+	}
+}
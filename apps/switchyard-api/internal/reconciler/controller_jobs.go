@@ -0,0 +1,215 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Durable work queue timings
+const (
+	// claimPrefetchPerWorker is how many claimed jobs each worker may have
+	// waiting for it
+	claimPrefetchPerWorker = 2
+	// jobClaimLease is how long a claim lasts without renewal; jobs held by a
+	// replica that died are claimable again after this
+	jobClaimLease = 2 * time.Minute
+	// jobClaimInterval is how often the claimer polls for jobs queued by
+	// other replicas
+	jobClaimInterval = 2 * time.Second
+	// jobRenewInterval is how often claims are renewed
+	jobRenewInterval = 30 * time.Second
+)
+
+// claimOwner identifies this replica's claims, unique per process
+func claimOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = fmt.Sprintf("pid-%d", os.Getpid())
+	}
+	return hostname + "-" + uuid.NewString()[:8]
+}
+
+// jobFromWork converts work into a durable job
+func jobFromWork(work *ReconcileWork) *db.ReconcileJob {
+	job := &db.ReconcileJob{
+		Class:       string(work.Class),
+		Priority:    work.Priority,
+		Attempt:     work.Attempt,
+		DriftPolicy: string(work.DriftPolicy),
+		RunAfter:    work.ScheduledAt,
+	}
+	job.DeploymentID, _ = uuid.Parse(work.DeploymentID)
+	if environmentID, err := uuid.Parse(work.EnvironmentID); err == nil {
+		job.EnvironmentID = &environmentID
+	}
+	if job.RunAfter.IsZero() {
+		job.RunAfter = time.Now()
+	}
+	return job
+}
+
+// workFromJob converts a claimed job into work for the local queue
+func workFromJob(job *db.ReconcileJob) *ReconcileWork {
+	work := &ReconcileWork{
+		DeploymentID: job.DeploymentID.String(),
+		Priority:     job.Priority,
+		Attempt:      job.Attempt,
+		ScheduledAt:  job.RunAfter,
+		JobID:        job.ID,
+		Class:        WorkClass(job.Class),
+		DriftPolicy:  types.DriftPolicy(job.DriftPolicy),
+	}
+	if job.EnvironmentID != nil {
+		work.EnvironmentID = job.EnvironmentID.String()
+	}
+	return work
+}
+
+// ensureQueued queues work unless the deployment is already queued. Unlike
+// enqueueWork, it never makes a running job run again, so it is safe for
+// periodic scans.
+func (c *Controller) ensureQueued(ctx context.Context, work *ReconcileWork) (bool, error) {
+	if c.jobs == nil {
+		return true, c.enqueueWork(work)
+	}
+
+	c.classifyWork(work)
+	queued, err := c.jobs.EnqueueIfAbsent(ctx, jobFromWork(work))
+	if err != nil {
+		return false, err
+	}
+	if queued {
+		c.signalClaim()
+	}
+	return queued, nil
+}
+
+// signalClaim wakes the job claimer without blocking
+func (c *Controller) signalClaim() {
+	select {
+	case c.claimCh <- struct{}{}:
+	default:
+	}
+}
+
+// jobClaimer keeps the local work queue filled with jobs claimed from the
+// durable queue, and keeps its claims alive while they are worked on
+func (c *Controller) jobClaimer(ctx context.Context) {
+	defer c.wg.Done()
+
+	logger := c.logger.WithFields(logrus.Fields{
+		"component": "job-claimer",
+		"owner":     c.owner,
+	})
+	logger.Debug("Starting job claimer")
+
+	claimTicker := time.NewTicker(jobClaimInterval)
+	defer claimTicker.Stop()
+	renewTicker := time.NewTicker(jobRenewInterval)
+	defer renewTicker.Stop()
+
+	c.claimJobs(ctx, logger)
+
+	for {
+		select {
+		case <-c.stopCh:
+			logger.Debug("Job claimer stopping")
+			return
+		case <-ctx.Done():
+			logger.Debug("Job claimer context cancelled")
+			return
+		case <-c.claimCh:
+			c.claimJobs(ctx, logger)
+		case <-claimTicker.C:
+			c.claimJobs(ctx, logger)
+		case <-renewTicker.C:
+			if _, err := c.jobs.RenewClaims(ctx, c.owner, jobClaimLease); err != nil {
+				logger.WithError(err).Error("Failed to renew job claims")
+			}
+		}
+	}
+}
+
+// claimJobs claims as many jobs as the local work queue has room for
+func (c *Controller) claimJobs(ctx context.Context, logger *logrus.Entry) {
+	free := c.queue.free()
+	if free == 0 {
+		return
+	}
+
+	jobs, err := c.jobs.Claim(ctx, c.owner, free, jobClaimLease, c.queue.starvationAge)
+	if err != nil {
+		logger.WithError(err).Error("Failed to claim reconcile jobs")
+		return
+	}
+
+	for _, job := range jobs {
+		// Only the claimer pushes, so the queue has room for every claimed job
+		if accepted, _ := c.queue.push(workFromJob(job)); !accepted {
+			logger.WithField("deployment", job.DeploymentID).Warn("Work queue full, job left to expire")
+		}
+	}
+
+	if len(jobs) > 0 {
+		logger.WithField("count", len(jobs)).Debug("Claimed reconcile jobs")
+	}
+}
+
+// finishJob removes a durable job after its final result, or reschedules
+// it for a retry
+func (c *Controller) finishJob(ctx context.Context, work *ReconcileWork, result *ReconcileResult, logger *logrus.Entry) {
+	if !result.Success && result.NextCheck != nil {
+		if err := c.jobs.Retry(ctx, work.JobID, c.owner, *result.NextCheck); err != nil {
+			logger.WithError(err).Error("Failed to reschedule reconcile job")
+		}
+		return
+	}
+	if err := c.jobs.Complete(ctx, work.JobID, c.owner); err != nil {
+		logger.WithError(err).Error("Failed to complete reconcile job")
+	}
+}
+
+// releaseClaims gives up this replica's claims on shutdown, so other
+// replicas pick the jobs up without waiting for the leases to run out
+func (c *Controller) releaseClaims() {
+	if c.jobs == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	released, err := c.jobs.ReleaseAll(ctx, c.owner)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to release reconcile job claims")
+		return
+	}
+	if released > 0 {
+		c.logger.WithField("count", released).Info("Released reconcile job claims")
+	}
+}
+
+// backlog summarizes the durable queue, or returns nil without one
+func (c *Controller) backlog() *db.ReconcileBacklog {
+	if c.jobs == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	backlog, err := c.jobs.Backlog(ctx)
+	if err != nil {
+		c.logger.WithError(err).Warn("Failed to summarize reconcile backlog")
+		return nil
+	}
+	return backlog
+}
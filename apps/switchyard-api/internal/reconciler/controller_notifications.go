@@ -2,11 +2,13 @@ package reconciler
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/events"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -31,37 +33,66 @@ func (c *Controller) sendDeploymentNotification(ctx context.Context, deploymentI
 		return
 	}
 
-	// Get service
-	service, err := c.repositories.Services.GetByID(release.ServiceID)
+	// Determine event type
+	var eventType types.WebhookEventType
+	if status == types.DeploymentStatusRunning {
+		eventType = types.WebhookEventDeploymentSucceeded
+	} else {
+		eventType = types.WebhookEventDeploymentFailed
+	}
+
+	event, err := c.newDeploymentWebhookEvent(ctx, deployment, release, eventType)
 	if err != nil {
-		logger.WithError(err).Error("Failed to get service for notification")
+		logger.WithError(err).Error("Failed to build deployment notification")
 		return
 	}
+	event.Deployment.Status = string(status)
 
-	// Get project
-	project, err := c.repositories.Projects.GetByID(ctx, service.ProjectID)
+	// Add error message for failed deployments
+	if status == types.DeploymentStatusFailed && result != nil && result.Error != nil {
+		event.Deployment.Error = result.Error.Error()
+	}
+
+	// Send notification
+	if err := c.notificationService.SendEvent(ctx, event.ProjectID, event); err != nil {
+		logger.WithError(err).Error("Failed to send deployment notification")
+	} else {
+		logger.Info("Deployment notification sent")
+	}
+}
+
+// sendDeploymentStartedNotification announces that a deployment began
+// rolling out
+func (c *Controller) sendDeploymentStartedNotification(ctx context.Context, deployment *types.Deployment, release *types.Release) {
+	event, err := c.newDeploymentWebhookEvent(ctx, deployment, release, types.WebhookEventDeploymentStarted)
 	if err != nil {
-		logger.WithError(err).Error("Failed to get project for notification")
+		c.logger.WithField("deployment_id", deployment.ID).WithError(err).Error("Failed to build deployment notification")
 		return
 	}
 
-	// Get environment
-	environment, err := c.repositories.Environments.GetByID(ctx, deployment.EnvironmentID)
+	if err := c.notificationService.SendEvent(ctx, event.ProjectID, event); err != nil {
+		c.logger.WithField("deployment_id", deployment.ID).WithError(err).Error("Failed to send deployment notification")
+	}
+}
+
+// newDeploymentWebhookEvent builds the webhook payload for a deployment event
+func (c *Controller) newDeploymentWebhookEvent(ctx context.Context, deployment *types.Deployment, release *types.Release, eventType types.WebhookEventType) (*types.WebhookEvent, error) {
+	service, err := c.repositories.Services.GetByID(release.ServiceID)
 	if err != nil {
-		logger.WithError(err).Error("Failed to get environment for notification")
-		return
+		return nil, fmt.Errorf("failed to get service: %w", err)
 	}
 
-	// Determine event type
-	var eventType types.WebhookEventType
-	if status == types.DeploymentStatusRunning {
-		eventType = types.WebhookEventDeploymentSucceeded
-	} else {
-		eventType = types.WebhookEventDeploymentFailed
+	project, err := c.repositories.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	environment, err := c.repositories.Environments.GetByID(ctx, deployment.EnvironmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment: %w", err)
 	}
 
-	// Build webhook event
-	event := &types.WebhookEvent{
+	return &types.WebhookEvent{
 		ID:        uuid.New(),
 		Type:      eventType,
 		Timestamp: time.Now(),
@@ -75,20 +106,37 @@ func (c *Controller) sendDeploymentNotification(ctx context.Context, deploymentI
 			ID:          deployment.ID,
 			ServiceName: service.Name,
 			Environment: environment.Name,
-			Status:      string(status),
+			Status:      string(deployment.Status),
 			CommitSHA:   release.GitSHA,
+			Annotations: deployment.Annotations,
 		},
-	}
+	}, nil
+}
 
-	// Add error message for failed deployments
-	if status == types.DeploymentStatusFailed && result != nil && result.Error != nil {
-		event.Deployment.Error = result.Error.Error()
+// publishDeploymentEvent streams a deployment status change to event subscribers
+func (c *Controller) publishDeploymentEvent(ctx context.Context, deploymentID uuid.UUID, status types.DeploymentStatus, health types.HealthStatus, errorMsg *string) {
+	event := events.NewStatusEvent(events.ResourceDeployment, deploymentID, string(status))
+	event.Data = map[string]any{"health": health}
+	if errorMsg != nil {
+		event.Message = *errorMsg
 	}
 
-	// Send notification
-	if err := c.notificationService.SendEvent(ctx, project.ID, event); err != nil {
-		logger.WithError(err).Error("Failed to send deployment notification")
-	} else {
-		logger.Info("Deployment notification sent")
+	c.publishScopedDeploymentEvent(ctx, deploymentID, event)
+}
+
+// publishScopedDeploymentEvent resolves the service and project of a
+// deployment event so subscribers can filter by project, then publishes it
+func (c *Controller) publishScopedDeploymentEvent(ctx context.Context, deploymentID uuid.UUID, event *events.Event) {
+	if deployment, err := c.repositories.Deployments.GetByID(ctx, deploymentID.String()); err == nil {
+		event.Data["environment_id"] = deployment.EnvironmentID
+		event.EnvironmentID = &deployment.EnvironmentID
+		if release, err := c.repositories.Releases.GetByID(deployment.ReleaseID); err == nil {
+			event.ServiceID = &release.ServiceID
+			if service, err := c.repositories.Services.GetByID(release.ServiceID); err == nil {
+				event.ProjectID = &service.ProjectID
+			}
+		}
 	}
+
+	c.eventBroker.Publish(ctx, event)
 }
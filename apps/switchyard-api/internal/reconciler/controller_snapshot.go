@@ -0,0 +1,169 @@
+package reconciler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// captureConfigSnapshot records the resolved configuration of a deployment the
+// first time it is reconciled. Failures are logged and never block the rollout.
+func (c *Controller) captureConfigSnapshot(ctx context.Context, req *ReconcileRequest, logger *logrus.Entry) {
+	if c.repositories.DeploymentSnapshots == nil {
+		return
+	}
+
+	// Retries reconcile the same deployment; the first snapshot wins
+	exists, err := c.repositories.DeploymentSnapshots.Exists(ctx, req.Deployment.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to check for existing config snapshot")
+		return
+	}
+	if exists {
+		return
+	}
+
+	serviceID := req.Service.ID.String()
+	environmentID := req.Deployment.EnvironmentID.String()
+
+	routes, err := c.repositories.Routes.GetByServiceAndEnvironment(ctx, serviceID, environmentID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get routes for config snapshot")
+	}
+	domains, err := c.repositories.CustomDomains.GetByServiceAndEnvironment(ctx, serviceID, environmentID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get custom domains for config snapshot")
+	}
+
+	snapshot, err := c.buildConfigSnapshot(req, routes, domains)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to build config snapshot")
+		return
+	}
+
+	if _, err := c.repositories.DeploymentSnapshots.Create(ctx, snapshot); err != nil {
+		logger.WithError(err).Warn("Failed to store config snapshot")
+		return
+	}
+
+	logger.WithField("manifest_hash", snapshot.ManifestHash).Debug("Captured deployment config snapshot")
+}
+
+// buildConfigSnapshot assembles a snapshot from a reconcile request
+func (c *Controller) buildConfigSnapshot(req *ReconcileRequest, routes []types.Route, domains []types.CustomDomain) (*types.DeploymentConfigSnapshot, error) {
+	namespace := req.Environment.KubeNamespace
+	deployment, service, err := c.serviceReconciler.generateManifests(req, namespace, envSecretName(req.Service.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate manifests: %w", err)
+	}
+
+	manifestJSON, err := json.Marshal(struct {
+		Deployment interface{} `json:"deployment"`
+		Service    interface{} `json:"service"`
+	}{deployment.Spec, service.Spec})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifests: %w", err)
+	}
+	manifestSum := sha256.Sum256(manifestJSON)
+
+	replicas := req.Deployment.Replicas
+	if deployment.Spec.Replicas != nil {
+		replicas = int(*deployment.Spec.Replicas)
+	}
+
+	snapshot := &types.DeploymentConfigSnapshot{
+		DeploymentID:     req.Deployment.ID,
+		ServiceID:        req.Service.ID,
+		EnvironmentID:    req.Deployment.EnvironmentID,
+		ReleaseID:        req.Release.ID,
+		ImageURI:         req.Release.ImageURI,
+		Namespace:        namespace,
+		EnvVars:          snapshotEnvVars(req),
+		AddonBindings:    []types.SnapshotAddonBinding{},
+		Routes:           []types.SnapshotRoute{},
+		Domains:          []string{},
+		Replicas:         replicas,
+		Resources:        req.Service.Resources,
+		HealthCheck:      req.Service.HealthCheck,
+		Scheduling:       req.Service.Scheduling,
+		GPU:              req.Service.GPU,
+		Command:          containerCommand(req),
+		Shutdown:         req.Service.Shutdown,
+		HighAvailability: req.Service.HighAvailability,
+		ManifestHash:     hex.EncodeToString(manifestSum[:]),
+		CapturedAt:       time.Now(),
+	}
+
+	for _, binding := range req.AddonBindings {
+		snapshot.AddonBindings = append(snapshot.AddonBindings, types.SnapshotAddonBinding{
+			EnvVarName:       binding.EnvVarName,
+			AddonType:        binding.AddonType,
+			K8sResourceName:  binding.K8sResourceName,
+			ConnectionSecret: binding.ConnectionSecret,
+		})
+	}
+	for _, route := range routes {
+		snapshot.Routes = append(snapshot.Routes, types.SnapshotRoute{
+			Path:     route.Path,
+			PathType: route.PathType,
+			Port:     route.Port,
+		})
+	}
+	for _, domain := range domains {
+		snapshot.Domains = append(snapshot.Domains, domain.Domain)
+	}
+
+	return snapshot, nil
+}
+
+// snapshotEnvVars converts the request's env vars into hashed, masked entries sorted by key
+func snapshotEnvVars(req *ReconcileRequest) []types.SnapshotEnvVar {
+	salt := req.Service.ID.String()
+	result := []types.SnapshotEnvVar{}
+
+	if len(req.EnvVarsWithMeta) > 0 {
+		for _, ev := range req.EnvVarsWithMeta {
+			result = append(result, snapshotEnvVar(salt, ev.Key, ev.Value, ev.IsSecret))
+		}
+	} else {
+		for key, value := range req.EnvVars {
+			result = append(result, snapshotEnvVar(salt, key, value, false))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+func snapshotEnvVar(salt, key, value string, isSecret bool) types.SnapshotEnvVar {
+	return types.SnapshotEnvVar{
+		Key:         key,
+		ValueHash:   hashEnvValue(salt, key, value),
+		MaskedValue: maskEnvValue(value, isSecret),
+		IsSecret:    isSecret,
+	}
+}
+
+// hashEnvValue returns a short SHA-256 fingerprint of a value. The service ID and
+// key are mixed in so identical values in different services don't share a hash.
+func hashEnvValue(salt, key, value string) string {
+	sum := sha256.Sum256([]byte(salt + "\x00" + key + "\x00" + value))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// maskEnvValue hides a value, keeping a short prefix/suffix of long non-secret values
+func maskEnvValue(value string, isSecret bool) string {
+	if isSecret || len(value) < 12 {
+		return strings.Repeat("*", 8)
+	}
+	return value[:2] + strings.Repeat("*", 8) + value[len(value)-2:]
+}
@@ -2,7 +2,6 @@ package reconciler
 
 import (
 	"context"
-	"time"
 
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
@@ -10,86 +9,6 @@ import (
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
-// k8sSyncScheduler runs the K8s→DB sync job every 60 seconds
-func (c *Controller) k8sSyncScheduler(ctx context.Context) {
-	defer c.wg.Done()
-
-	logger := c.logger.WithField("component", "k8s-sync")
-	logger.Info("Starting K8s→DB sync scheduler")
-
-	// Run initial sync immediately
-	c.runK8sSync(ctx, logger)
-
-	ticker := time.NewTicker(60 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-c.stopCh:
-			logger.Debug("K8s sync scheduler stopping")
-			return
-		case <-ctx.Done():
-			logger.Debug("K8s sync scheduler context cancelled")
-			return
-		case <-ticker.C:
-			c.runK8sSync(ctx, logger)
-		}
-	}
-}
-
-// runK8sSync synchronizes K8s deployment state to database
-func (c *Controller) runK8sSync(ctx context.Context, logger *logrus.Entry) {
-	if c.k8sClient == nil {
-		logger.Debug("K8s client not available, skipping sync")
-		return
-	}
-
-	// Dynamic namespace discovery: Get all unique namespaces from environments table
-	// This ensures new environment patterns (e.g., enclii-{project_slug}-{env_name}) are monitored
-	envs, err := c.repositories.Environments.ListAll()
-	if err != nil {
-		logger.WithError(err).Error("Failed to list environments for namespace sync")
-		return
-	}
-
-	// Build unique namespace set for scanning.
-	// The ownership check (enclii.dev/managed-by: switchyard) ensures only Enclii-created
-	// deployments are imported, regardless of which namespace they're in.
-	namespaceSet := make(map[string]bool)
-	// Include core namespaces for health monitoring
-	for _, ns := range []string{"enclii", "janua", "data", "monitoring"} {
-		namespaceSet[ns] = true
-	}
-	// Add all environment namespaces from database
-	for _, env := range envs {
-		if env.KubeNamespace != "" {
-			namespaceSet[env.KubeNamespace] = true
-		}
-	}
-	// Convert set to slice
-	namespaces := make([]string, 0, len(namespaceSet))
-	for ns := range namespaceSet {
-		namespaces = append(namespaces, ns)
-	}
-
-	logger.WithField("namespace_count", len(namespaces)).Debug("Syncing K8s deployments from namespaces")
-
-	for _, ns := range namespaces {
-		deployments, err := c.k8sClient.ListDeployments(ctx, ns)
-		if err != nil {
-			logger.WithFields(logrus.Fields{
-				"namespace": ns,
-				"error":     err,
-			}).Warn("Failed to list K8s deployments, skipping namespace")
-			continue
-		}
-
-		for _, dep := range deployments {
-			c.syncDeploymentToDatabase(ctx, ns, dep, logger)
-		}
-	}
-}
-
 // syncDeploymentToDatabase checks if a K8s deployment has corresponding DB records.
 // IMPORTANT: Only syncs deployments that Enclii created (have enclii.dev/managed-by: switchyard label).
 // This prevents auto-importing external services that happen to share names with registered services.
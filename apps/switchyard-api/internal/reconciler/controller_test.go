@@ -1,10 +1,18 @@
 package reconciler
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 func TestIsEncliiManagedDeployment(t *testing.T) {
@@ -68,3 +76,85 @@ func TestIsEncliiManagedDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestDeploymentKeyForPod(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api-7d9f8-x2k4p",
+			Namespace: "enclii-shop-production",
+			Labels:    map[string]string{"enclii.dev/service": "api"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		obj     interface{}
+		wantKey string
+		wantOK  bool
+	}{
+		{name: "pod", obj: pod, wantKey: "enclii-shop-production/api", wantOK: true},
+		{name: "deleted pod", obj: cache.DeletedFinalStateUnknown{Key: "enclii-shop-production/api-7d9f8-x2k4p", Obj: pod}, wantKey: "enclii-shop-production/api", wantOK: true},
+		{name: "pod without service label", obj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "enclii"}}},
+		{name: "not a pod", obj: &appsv1.Deployment{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := deploymentKeyForPod(tt.obj)
+			if key != tt.wantKey || ok != tt.wantOK {
+				t.Errorf("deploymentKeyForPod() = %q, %v, expected %q, %v", key, ok, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestControllerWithoutJobStoreQueuesInMemory(t *testing.T) {
+	c := NewController(nil, nil, nil, logrus.New())
+
+	if err := c.ForceReconciliation("d1", types.DriftPolicyPreserve); err != nil {
+		t.Fatalf("ForceReconciliation() = %v", err)
+	}
+	work := c.queue.pop()
+	if work == nil || work.DriftPolicy != types.DriftPolicyPreserve || work.JobID != uuid.Nil {
+		t.Fatalf("queued work = %+v", work)
+	}
+
+	for i := 0; i < c.queue.capacity; i++ {
+		if err := c.ScheduleReconciliation(fmt.Sprintf("d%d", i), 1); err != nil {
+			t.Fatalf("ScheduleReconciliation(%d) = %v", i, err)
+		}
+	}
+	if err := c.ScheduleReconciliation("overflow", 1); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("ScheduleReconciliation() on a full queue = %v, want ErrQueueFull", err)
+	}
+	if dropped := c.GetQueuePressure().DroppedWork; dropped != 1 {
+		t.Errorf("DroppedWork = %d, want 1", dropped)
+	}
+}
+
+func TestJobWorkRoundTrip(t *testing.T) {
+	environmentID := uuid.New()
+	work := &ReconcileWork{
+		DeploymentID:  uuid.NewString(),
+		Priority:      3,
+		Attempt:       2,
+		Class:         WorkClassProduction,
+		EnvironmentID: environmentID.String(),
+		DriftPolicy:   types.DriftPolicyPreserve,
+	}
+
+	job := jobFromWork(work)
+	if job.RunAfter.IsZero() {
+		t.Error("RunAfter not defaulted")
+	}
+	if job.EnvironmentID == nil || *job.EnvironmentID != environmentID {
+		t.Errorf("EnvironmentID = %v", job.EnvironmentID)
+	}
+
+	job.ID = uuid.New()
+	got := workFromJob(job)
+	if got.JobID != job.ID || got.DeploymentID != work.DeploymentID || got.Class != work.Class ||
+		got.EnvironmentID != work.EnvironmentID || got.DriftPolicy != work.DriftPolicy || got.Attempt != 2 {
+		t.Errorf("workFromJob() = %+v", got)
+	}
+}
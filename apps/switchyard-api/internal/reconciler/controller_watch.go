@@ -0,0 +1,239 @@
+package reconciler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// managedBySelector selects the Kubernetes objects Enclii created
+	managedBySelector = "enclii.dev/managed-by=switchyard"
+
+	// k8sFullResyncInterval is how often every watched deployment is synced
+	// again from the informer cache, as a safety net for missed events
+	k8sFullResyncInterval = 10 * time.Minute
+
+	// k8sSyncWorkers is how many deployments are synced to the database at once
+	k8sSyncWorkers = 2
+
+	// clusterListInterval is how often registered clusters are listed to
+	// start and stop their watches
+	clusterListInterval = time.Minute
+)
+
+// k8sWatcher keeps deployment records in step with Kubernetes, watching the
+// cluster the API runs in and every registered cluster
+func (c *Controller) k8sWatcher(ctx context.Context) {
+	defer c.wg.Done()
+
+	logger := c.logger.WithField("component", "k8s-sync")
+	if c.k8sClient == nil {
+		logger.Debug("K8s client not available, skipping sync")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if c.clients == nil || c.repositories == nil || c.repositories.Clusters == nil {
+		c.watchCluster(ctx, c.k8sClient, logger)
+		return
+	}
+
+	var watchers sync.WaitGroup
+	watchers.Add(1)
+	go func() {
+		defer watchers.Done()
+		c.watchCluster(ctx, c.k8sClient, logger.WithField("cluster", types.LocalClusterName))
+	}()
+	c.watchRegisteredClusters(ctx, &watchers, logger)
+	watchers.Wait()
+}
+
+// watchRegisteredClusters runs a watch of each registered cluster until the
+// context ends, starting watches of clusters registered later and stopping
+// those of removed clusters
+func (c *Controller) watchRegisteredClusters(ctx context.Context, watchers *sync.WaitGroup, logger *logrus.Entry) {
+	running := make(map[uuid.UUID]context.CancelFunc)
+	ticker := time.NewTicker(clusterListInterval)
+	defer ticker.Stop()
+
+	for {
+		clusters, err := c.repositories.Clusters.List(ctx)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to list clusters to watch")
+		} else {
+			registered := make(map[uuid.UUID]bool, len(clusters))
+			for _, cluster := range clusters {
+				registered[cluster.ID] = true
+				if running[cluster.ID] != nil {
+					continue
+				}
+				client, err := c.clients.Get(ctx, &cluster.ID)
+				if err != nil {
+					logger.WithError(err).WithField("cluster", cluster.Name).Warn("Failed to connect to cluster")
+					continue
+				}
+
+				clusterCtx, stop := context.WithCancel(ctx)
+				running[cluster.ID] = stop
+				watchers.Add(1)
+				go func(name string) {
+					defer watchers.Done()
+					c.watchCluster(clusterCtx, client, logger.WithField("cluster", name))
+				}(cluster.Name)
+			}
+			for id, stop := range running {
+				if !registered[id] {
+					stop()
+					delete(running, id)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchCluster watches one cluster until the context ends. Shared informers
+// watch the Deployments and Pods Enclii manages across all namespaces, and
+// every change queues the deployment for a K8s→DB sync.
+func (c *Controller) watchCluster(ctx context.Context, client *k8s.Client, logger *logrus.Entry) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Events for the same deployment collapse into one sync while queued
+	queue := workqueue.NewNamed("k8s-sync")
+	enqueue := func(obj interface{}) {
+		if key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj); err == nil {
+			queue.Add(key)
+		}
+	}
+	enqueuePod := func(obj interface{}) {
+		if key, ok := deploymentKeyForPod(obj); ok {
+			queue.Add(key)
+		}
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		client.Clientset,
+		k8sFullResyncInterval,
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = managedBySelector
+		}),
+	)
+	deployments := factory.Apps().V1().Deployments()
+	pods := factory.Core().V1().Pods()
+
+	_, err := deployments.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+	})
+	if err == nil {
+		_, err = pods.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    enqueuePod,
+			UpdateFunc: func(_, obj interface{}) { enqueuePod(obj) },
+			DeleteFunc: enqueuePod,
+		})
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to register K8s informer handlers")
+		cancel()
+		return
+	}
+
+	logger.Info("Starting K8s watch of Enclii-managed deployments and pods")
+	factory.Start(ctx.Done())
+	defer func() {
+		cancel()
+		queue.ShutDown()
+		factory.Shutdown()
+	}()
+
+	if !cache.WaitForCacheSync(ctx.Done(), deployments.Informer().HasSynced, pods.Informer().HasSynced) {
+		logger.Warn("K8s informer caches did not sync, stopping K8s watch")
+		return
+	}
+	logger.WithField("queued", queue.Len()).Info("K8s informer caches synced")
+
+	lister := deployments.Lister()
+	var workers sync.WaitGroup
+	for i := 0; i < k8sSyncWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for c.processK8sSync(ctx, queue, lister, logger) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	logger.Debug("K8s watch stopping")
+	queue.ShutDown()
+	workers.Wait()
+}
+
+// processK8sSync syncs the next queued deployment to the database. It
+// returns false once the queue is shut down.
+func (c *Controller) processK8sSync(ctx context.Context, queue workqueue.Interface, lister appslisters.DeploymentLister, logger *logrus.Entry) bool {
+	item, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+	defer queue.Done(item)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(item.(string))
+	if err != nil {
+		return true
+	}
+
+	// Deleted deployments, and pods of deployments Enclii does not manage,
+	// have nothing to sync
+	dep, err := lister.Deployments(namespace).Get(name)
+	if err != nil {
+		return true
+	}
+
+	c.syncDeploymentToDatabase(ctx, namespace, *dep, logger)
+	return true
+}
+
+// deploymentKeyForPod returns the namespace/name key of the deployment a
+// pod belongs to. Enclii names a service's deployment after the service.
+func deploymentKeyForPod(obj interface{}) (string, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return "", false
+	}
+	service := pod.Labels["enclii.dev/service"]
+	if service == "" {
+		return "", false
+	}
+	return pod.Namespace + "/" + service, true
+}
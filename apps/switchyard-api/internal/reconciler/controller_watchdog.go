@@ -0,0 +1,187 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/events"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Default stall thresholds (overridable via SetStallThresholds)
+const (
+	DefaultDeploymentStallThreshold = 15 * time.Minute
+	DefaultBuildStallThreshold      = 30 * time.Minute
+
+	stallWatchdogInterval = time.Minute
+)
+
+// SetStallThresholds sets how long a deployment may stay pending and a build
+// may stay building before it is flagged as stalled. Zero keeps the default.
+func (c *Controller) SetStallThresholds(deployment, build time.Duration) {
+	if deployment > 0 {
+		c.deploymentStallThreshold = deployment
+	}
+	if build > 0 {
+		c.buildStallThreshold = build
+	}
+}
+
+// stallWatchdog periodically flags deployments and builds that have been in
+// the same state for longer than their threshold
+func (c *Controller) stallWatchdog(ctx context.Context) {
+	defer c.wg.Done()
+
+	logger := c.logger.WithField("component", "stall-watchdog")
+	logger.WithFields(logrus.Fields{
+		"deployment_threshold": c.deploymentStallThreshold,
+		"build_threshold":      c.buildStallThreshold,
+	}).Info("Starting stall watchdog")
+
+	ticker := time.NewTicker(stallWatchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			logger.Debug("Stall watchdog stopping")
+			return
+		case <-ctx.Done():
+			logger.Debug("Stall watchdog context cancelled")
+			return
+		case <-ticker.C:
+			c.checkStalled(ctx, time.Now(), logger)
+		}
+	}
+}
+
+// checkStalled flags pending deployments and building releases past their thresholds
+func (c *Controller) checkStalled(ctx context.Context, now time.Time, logger *logrus.Entry) {
+	deployments, err := c.repositories.Deployments.ListStalled(ctx, types.DeploymentStatusPending, now.Add(-c.deploymentStallThreshold))
+	if err != nil {
+		logger.WithError(err).Error("Failed to list stalled deployments")
+	}
+	for _, deployment := range deployments {
+		reason := fmt.Sprintf("pending for %s", now.Sub(deployment.CreatedAt).Round(time.Minute))
+		if err := c.markDeploymentStalled(ctx, deployment, reason); err != nil {
+			logger.WithError(err).WithField("deployment_id", deployment.ID).Warn("Failed to flag stalled deployment")
+		}
+	}
+
+	releases, err := c.repositories.Releases.ListStalledBuilds(ctx, now.Add(-c.buildStallThreshold))
+	if err != nil {
+		logger.WithError(err).Error("Failed to list stalled builds")
+	}
+	for _, release := range releases {
+		reason := fmt.Sprintf("building for %s", now.Sub(release.CreatedAt).Round(time.Minute))
+		if err := c.MarkBuildStalled(ctx, release.ID, reason); err != nil {
+			logger.WithError(err).WithField("release_id", release.ID).Warn("Failed to flag stalled build")
+		}
+	}
+}
+
+// markDeploymentStalled sets the stalled condition on a deployment and notifies once
+func (c *Controller) markDeploymentStalled(ctx context.Context, deployment *types.Deployment, reason string) error {
+	marked, err := c.repositories.Deployments.MarkStalled(ctx, deployment.ID)
+	if err != nil || !marked {
+		return err
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"deployment_id": deployment.ID,
+		"reason":        reason,
+	}).Warn("Deployment stalled")
+
+	event := events.NewStatusEvent(events.ResourceDeployment, deployment.ID, string(deployment.Status))
+	event.Message = "Deployment stalled: " + reason
+	event.Data = map[string]any{"stalled": true, "environment_id": deployment.EnvironmentID}
+	event.EnvironmentID = &deployment.EnvironmentID
+
+	release, err := c.repositories.Releases.GetByID(deployment.ReleaseID)
+	if err != nil {
+		c.eventBroker.Publish(ctx, event)
+		return fmt.Errorf("failed to get release: %w", err)
+	}
+	event.ServiceID = &release.ServiceID
+	if service, err := c.repositories.Services.GetByID(release.ServiceID); err == nil {
+		event.ProjectID = &service.ProjectID
+	}
+	c.eventBroker.Publish(ctx, event)
+
+	if c.notificationService == nil {
+		return nil
+	}
+
+	webhookEvent, err := c.newDeploymentWebhookEvent(ctx, deployment, release, types.WebhookEventDeploymentStalled)
+	if err != nil {
+		return err
+	}
+	webhookEvent.Deployment.Error = reason
+
+	return c.notificationService.SendEvent(ctx, webhookEvent.ProjectID, webhookEvent)
+}
+
+// MarkBuildStalled sets the stalled condition on a building release and
+// notifies once. Used by the watchdog and by Roundhouse stall reports.
+func (c *Controller) MarkBuildStalled(ctx context.Context, releaseID uuid.UUID, reason string) error {
+	marked, err := c.repositories.Releases.MarkStalled(ctx, releaseID)
+	if err != nil || !marked {
+		return err
+	}
+
+	release, err := c.repositories.Releases.GetByID(releaseID)
+	if err != nil {
+		return fmt.Errorf("failed to get release: %w", err)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"release_id": release.ID,
+		"service_id": release.ServiceID,
+		"reason":     reason,
+	}).Warn("Build stalled")
+
+	service, err := c.repositories.Services.GetByID(release.ServiceID)
+	if err != nil {
+		return fmt.Errorf("failed to get service: %w", err)
+	}
+
+	event := events.NewStatusEvent(events.ResourceBuild, release.ID, string(release.Status))
+	event.ServiceID = &release.ServiceID
+	event.ProjectID = &service.ProjectID
+	event.Message = "Build stalled: " + reason
+	event.Data = map[string]any{"stalled": true, "git_sha": release.GitSHA, "version": release.Version}
+	c.eventBroker.Publish(ctx, event)
+
+	if c.notificationService == nil {
+		return nil
+	}
+
+	project, err := c.repositories.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to get project: %w", err)
+	}
+
+	return c.notificationService.SendEvent(ctx, project.ID, &types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      types.WebhookEventBuildStalled,
+		Timestamp: time.Now(),
+		ProjectID: project.ID,
+		Project: types.WebhookProjectInfo{
+			ID:   project.ID,
+			Name: project.Name,
+			Slug: project.Slug,
+		},
+		Build: &types.WebhookBuildInfo{
+			ID:          release.ID,
+			ServiceName: service.Name,
+			Status:      string(release.Status),
+			CommitSHA:   release.GitSHA,
+			ImageTag:    release.Version,
+			Error:       reason,
+		},
+	})
+}
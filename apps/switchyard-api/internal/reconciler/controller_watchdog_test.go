@@ -0,0 +1,51 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSetStallThresholds(t *testing.T) {
+	tests := []struct {
+		name           string
+		deployment     time.Duration
+		build          time.Duration
+		wantDeployment time.Duration
+		wantBuild      time.Duration
+	}{
+		{
+			name:           "zero keeps defaults",
+			wantDeployment: DefaultDeploymentStallThreshold,
+			wantBuild:      DefaultBuildStallThreshold,
+		},
+		{
+			name:           "overrides both",
+			deployment:     5 * time.Minute,
+			build:          time.Hour,
+			wantDeployment: 5 * time.Minute,
+			wantBuild:      time.Hour,
+		},
+		{
+			name:           "overrides build only",
+			build:          45 * time.Minute,
+			wantDeployment: DefaultDeploymentStallThreshold,
+			wantBuild:      45 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewController(nil, nil, nil, logrus.New())
+			c.SetStallThresholds(tt.deployment, tt.build)
+
+			if c.deploymentStallThreshold != tt.wantDeployment {
+				t.Errorf("deploymentStallThreshold = %v, want %v", c.deploymentStallThreshold, tt.wantDeployment)
+			}
+			if c.buildStallThreshold != tt.wantBuild {
+				t.Errorf("buildStallThreshold = %v, want %v", c.buildStallThreshold, tt.wantBuild)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/monitoring"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -41,11 +42,20 @@ func (c *Controller) handleResult(ctx context.Context, workResult *ReconcileWork
 		"success":    result.Success,
 	})
 
+	// Keep a record of manual changes found on the deployment's resources
+	if len(result.Drift) > 0 {
+		c.recordDrift(ctx, work, result.Drift, logger)
+	}
+
 	// Update deployment status in database
 	var status types.DeploymentStatus
 	var health types.HealthStatus
 
-	if result.Success {
+	if result.Superseded {
+		status = types.DeploymentStatusSuperseded
+		health = types.HealthStatusUnknown
+		logger.Info(result.Message)
+	} else if result.Success {
 		status = types.DeploymentStatusRunning
 		health = types.HealthStatusHealthy
 		logger.Info("Deployment reconciled successfully")
@@ -55,30 +65,11 @@ func (c *Controller) handleResult(ctx context.Context, workResult *ReconcileWork
 			status = types.DeploymentStatusPending
 			health = types.HealthStatusUnknown
 
-			// Schedule retry with proper backpressure handling
-			retryWork := &ReconcileWork{
-				DeploymentID: work.DeploymentID,
-				Priority:     work.Priority + 1, // Increase priority for retries
-				Attempt:      work.Attempt + 1,
-				ScheduledAt:  *result.NextCheck,
+			// Durable jobs are rescheduled in the database below
+			if work.JobID == uuid.Nil {
+				c.retryLocal(work, *result.NextCheck)
 			}
 
-			go func() {
-				time.Sleep(time.Until(*result.NextCheck))
-				select {
-				case <-c.stopCh:
-					return
-				default:
-				}
-				if err := c.enqueueWork(retryWork); err != nil {
-					// Work was added to retry queue, will be processed later
-					c.logger.WithFields(logrus.Fields{
-						"deployment": retryWork.DeploymentID,
-						"attempt":    retryWork.Attempt,
-					}).Debug("Retry scheduled to retry queue")
-				}
-			}()
-
 			logger.WithField("next_check", result.NextCheck).Info("Scheduled reconciliation retry")
 		} else {
 			// Failed permanently
@@ -101,15 +92,119 @@ func (c *Controller) handleResult(ctx context.Context, workResult *ReconcileWork
 		errStr := result.Error.Error()
 		errorMsg = &errStr
 	}
+	if status == types.DeploymentStatusSuperseded {
+		errorMsg = &result.Message
+	}
 	err = c.repositories.Deployments.UpdateStatusWithError(deploymentUUID, status, health, errorMsg)
 	if err != nil {
 		logger.WithError(err).Error("Failed to update deployment status")
 	}
 
+	// The status is written, so the next deployment of the service may go
+	c.releaseDeployLock(ctx, deploymentUUID, logger)
+
+	// Only now let go of the job, so a crash above leaves it to be retried
+	if work.JobID != uuid.Nil {
+		c.finishJob(ctx, work, result, logger)
+	}
+
+	// Stream the status change to dashboard subscribers
+	if c.eventBroker != nil {
+		go c.publishDeploymentEvent(ctx, deploymentUUID, status, health, errorMsg)
+	}
+
+	// Attach cost/performance annotations before notifying so webhook payloads include them
+	if status == types.DeploymentStatusRunning {
+		if result.Rollout {
+			c.enterPhase(ctx, deploymentUUID, types.DeploymentPhaseHooksRunning, logger)
+		}
+		c.annotateDeployment(ctx, deploymentUUID, logger)
+
+		// Hold the release in a soak under the environment's policy
+		if c.soakMonitor != nil {
+			if err := c.soakMonitor.Begin(ctx, deploymentUUID); err != nil {
+				logger.WithError(err).Warn("Failed to start deployment soak")
+			}
+		}
+	}
+
 	// Send webhook notifications for final states (success or permanent failure)
 	if c.notificationService != nil && (status == types.DeploymentStatusRunning || status == types.DeploymentStatusFailed) {
 		go c.sendDeploymentNotification(ctx, deploymentUUID, status, result)
 	}
+
+	// Leave the last phase in place so it shows where the deployment ended
+	switch {
+	case status == types.DeploymentStatusRunning && result.Rollout:
+		c.enterPhase(ctx, deploymentUUID, types.DeploymentPhaseDone, logger)
+	case status == types.DeploymentStatusFailed || status == types.DeploymentStatusSuperseded:
+		c.finishPhase(ctx, deploymentUUID, logger)
+	}
+}
+
+// retryLocal queues work again in memory once next is reached
+func (c *Controller) retryLocal(work *ReconcileWork, next time.Time) {
+	retryWork := &ReconcileWork{
+		DeploymentID: work.DeploymentID,
+		Priority:     work.Priority + 1, // Increase priority for retries
+		Attempt:      work.Attempt + 1,
+		ScheduledAt:  next,
+
+		Class:         work.Class,
+		EnvironmentID: work.EnvironmentID,
+		DriftPolicy:   work.DriftPolicy,
+	}
+
+	go func() {
+		time.Sleep(time.Until(next))
+		select {
+		case <-c.stopCh:
+			return
+		default:
+		}
+		// A dropped retry is picked up again by the pending deployment scan
+		_ = c.enqueueWork(retryWork)
+	}()
+}
+
+// recordDrift stores the manual changes a reconciliation found on managed
+// resources
+func (c *Controller) recordDrift(ctx context.Context, work *ReconcileWork, drift []ResourceDrift, logger *logrus.Entry) {
+	action := work.DriftPolicy
+	if action == "" {
+		action = types.DriftPolicyOverwrite
+	}
+
+	deploymentUUID, err := uuid.Parse(work.DeploymentID)
+	if err != nil {
+		return
+	}
+
+	for _, d := range drift {
+		logger.WithFields(logrus.Fields{
+			"kind":    d.Kind,
+			"name":    d.Name,
+			"manager": d.Manager,
+			"fields":  d.Fields,
+			"action":  action,
+		}).Warn("Managed resource was changed outside Enclii")
+
+		if c.repositories == nil || c.repositories.DriftEvents == nil {
+			continue
+		}
+		event := &types.DriftEvent{
+			DeploymentID: deploymentUUID,
+			Kind:         d.Kind,
+			Namespace:    d.Namespace,
+			Name:         d.Name,
+			Manager:      d.Manager,
+			Fields:       d.Fields,
+			Action:       action,
+		}
+		if err := c.repositories.DriftEvents.Create(ctx, event); err != nil {
+			logger.WithError(err).Error("Failed to record drift event")
+		}
+	}
 }
 
 // workScheduler periodically checks for pending deployments
@@ -157,13 +252,10 @@ func (c *Controller) schedulePendingWork(ctx context.Context, logger *logrus.Ent
 			ScheduledAt:  time.Now(),
 		}
 
-		if err := c.enqueueWork(work); err != nil {
-			// Added to retry queue, will be processed when queue has space
-			logger.WithFields(logrus.Fields{
-				"deployment":       deployment.ID,
-				"retry_queue_size": len(c.retryQueue),
-			}).Debug("Pending deployment added to retry queue")
-		} else {
+		queued, err := c.ensureQueued(ctx, work)
+		if err != nil {
+			logger.WithError(err).WithField("deployment", deployment.ID).Warn("Failed to queue pending deployment")
+		} else if queued {
 			logger.WithFields(logrus.Fields{
 				"deployment": deployment.ID,
 				"age":        age,
@@ -177,76 +269,36 @@ func (c *Controller) schedulePendingWork(ctx context.Context, logger *logrus.Ent
 	}
 }
 
-// retryQueueProcessor drains the retry queue when the work queue has space
-func (c *Controller) retryQueueProcessor(ctx context.Context) {
+// metricsReporter periodically exports queue depth and backlog gauges
+func (c *Controller) metricsReporter(ctx context.Context) {
 	defer c.wg.Done()
 
-	logger := c.logger.WithField("component", "retry-processor")
-	logger.Debug("Starting retry queue processor")
-
-	ticker := time.NewTicker(2 * time.Second)
+	ticker := time.NewTicker(15 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-c.stopCh:
-			logger.Debug("Retry queue processor stopping")
 			return
 		case <-ctx.Done():
-			logger.Debug("Retry queue processor context cancelled")
 			return
 		case <-ticker.C:
-			c.drainRetryQueue(logger)
+			c.reportQueueMetrics()
 		}
 	}
 }
 
-// drainRetryQueue attempts to move items from retry queue to work queue
-func (c *Controller) drainRetryQueue(logger *logrus.Entry) {
-	c.retryMu.Lock()
-	if len(c.retryQueue) == 0 {
-		c.retryMu.Unlock()
-		return
-	}
-
-	// Check if work queue has space (at least 20% free)
-	workQueueFree := cap(c.workCh) - len(c.workCh)
-	if workQueueFree < cap(c.workCh)/5 {
-		c.retryMu.Unlock()
-		return
-	}
-
-	// Move items from retry queue to work queue
-	toMove := len(c.retryQueue)
-	if toMove > workQueueFree {
-		toMove = workQueueFree
-	}
-
-	moved := 0
-	remaining := make([]*ReconcileWork, 0, len(c.retryQueue)-toMove)
-
-	for i, work := range c.retryQueue {
-		if i < toMove {
-			select {
-			case c.workCh <- work:
-				moved++
-			default:
-				// Queue filled up, keep remaining items
-				remaining = append(remaining, c.retryQueue[i:]...)
-				break
-			}
-		} else {
-			remaining = append(remaining, work)
-		}
+// reportQueueMetrics exports the local queue depth, and the durable backlog
+// when there is one
+func (c *Controller) reportQueueMetrics() {
+	composition := c.queue.composition()
+	byClass := make(map[string]int, len(composition.ByClass))
+	for class, count := range composition.ByClass {
+		byClass[string(class)] = count
 	}
+	monitoring.SetReconcileQueueDepth(byClass)
 
-	c.retryQueue = remaining
-	c.retryMu.Unlock()
-
-	if moved > 0 {
-		logger.WithFields(logrus.Fields{
-			"moved":     moved,
-			"remaining": len(remaining),
-		}).Debug("Drained retry queue to work queue")
+	if backlog := c.backlog(); backlog != nil {
+		monitoring.SetReconcileBacklog(backlog.Ready, backlog.Scheduled, backlog.Claimed, backlog.ByClass, backlog.OldestReady)
 	}
 }
@@ -0,0 +1,191 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Deploy lock timings
+const (
+	// deployLockLease bounds how long a reconciliation holds the deploy lock
+	// of its service. It outlasts the readiness wait of a reconcile, so only
+	// the lock of a replica that died runs out.
+	deployLockLease = 10 * time.Minute
+	// deployLockRetry is how soon a deployment waiting for the deploy lock
+	// or for an older deployment is tried again
+	deployLockRetry = 15 * time.Second
+)
+
+// concurrencyAction is what a pending deployment does about the other
+// pending deployments of its service in the environment
+type concurrencyAction int
+
+const (
+	concurrencyProceed concurrencyAction = iota
+	concurrencyWait
+	concurrencySuperseded
+)
+
+// concurrencyDecision is the outcome of decideConcurrency
+type concurrencyDecision struct {
+	action concurrencyAction
+	// other is the newer deployment superseding this one, or the older one
+	// it waits for
+	other *types.Deployment
+	// supersede lists the older deployments this one supersedes
+	supersede []*types.Deployment
+}
+
+// deployedBefore orders deployments by creation, then ID
+func deployedBefore(a, b *types.Deployment) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID.String() < b.ID.String()
+}
+
+// decideConcurrency decides what a pending deployment does about the other
+// pending deployments of its service in an environment. Superseding, the
+// newest one rolls out and the rest are superseded; queueing, the oldest
+// rolls out first while the others wait.
+func decideConcurrency(mode types.DeployConcurrency, deployment *types.Deployment, pending []*types.Deployment) concurrencyDecision {
+	var decision concurrencyDecision
+	for _, other := range pending {
+		if other.ID == deployment.ID {
+			continue
+		}
+		older := deployedBefore(other, deployment)
+
+		if mode == types.DeployConcurrencyQueue {
+			if older && (decision.other == nil || deployedBefore(other, decision.other)) {
+				decision.action = concurrencyWait
+				decision.other = other
+			}
+			continue
+		}
+
+		if older {
+			decision.supersede = append(decision.supersede, other)
+		} else if decision.other == nil || deployedBefore(decision.other, other) {
+			decision.action = concurrencySuperseded
+			decision.other = other
+		}
+	}
+	if decision.action != concurrencyProceed {
+		decision.supersede = nil
+	}
+	return decision
+}
+
+// supersededMessage explains why a deployment was superseded
+func supersededMessage(by *types.Deployment) string {
+	return fmt.Sprintf("Superseded by deployment %s", by.ID)
+}
+
+// waitResult retries work once the deploy lock may be free
+func waitResult(message string) *ReconcileResult {
+	next := time.Now().Add(deployLockRetry)
+	return &ReconcileResult{
+		Success:   false,
+		Message:   message,
+		NextCheck: &next,
+	}
+}
+
+// acquireDeployLock takes the deploy lock of a deployment's service in its
+// environment and applies the environment's deploy concurrency to pending
+// deployments. It returns a result when the deployment must not be
+// reconciled now; the lock is released once the result is handled.
+func (c *Controller) acquireDeployLock(ctx context.Context, deployment *types.Deployment, service *types.Service, environment *types.Environment, logger *logrus.Entry) *ReconcileResult {
+	if deployment.Status == types.DeploymentStatusSuperseded {
+		message := "Deployment was superseded"
+		if deployment.ErrorMessage != nil {
+			message = *deployment.ErrorMessage
+		}
+		return &ReconcileResult{Superseded: true, Message: message}
+	}
+	if c.repositories.DeployLocks == nil {
+		return nil
+	}
+
+	lock, acquired, err := c.repositories.DeployLocks.Acquire(ctx, service.ID, environment.ID, deployment.ID, c.owner, deployLockLease)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to acquire deploy lock")
+		return waitResult("Failed to acquire deploy lock, will retry")
+	}
+	if !acquired {
+		if lock == nil {
+			return waitResult("Waiting for the deploy lock of the service")
+		}
+		logger.WithField("holder", lock.DeploymentID).Info("Waiting for deploy lock")
+		return waitResult(fmt.Sprintf("Waiting for deployment %s of the service to finish reconciling", lock.DeploymentID))
+	}
+
+	// Running deployments are reconciled again as they are
+	if deployment.Status != types.DeploymentStatusPending {
+		return nil
+	}
+
+	pending, err := c.repositories.Deployments.ListPendingByServiceAndEnvironment(ctx, service.ID, environment.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to list pending deployments of the service")
+		return waitResult("Failed to check pending deployments of the service, will retry")
+	}
+
+	mode := environment.DeployConcurrency
+	if mode == "" {
+		mode = types.DeployConcurrencySupersede
+	}
+	decision := decideConcurrency(mode, deployment, pending)
+
+	switch decision.action {
+	case concurrencyWait:
+		logger.WithField("waiting_for", decision.other.ID).Info("Queued behind an older deployment")
+		return waitResult(fmt.Sprintf("Queued behind deployment %s", decision.other.ID))
+	case concurrencySuperseded:
+		logger.WithField("superseded_by", decision.other.ID).Info("Deployment superseded")
+		return &ReconcileResult{Superseded: true, Message: supersededMessage(decision.other)}
+	}
+
+	for _, older := range decision.supersede {
+		c.supersede(ctx, older, deployment, logger)
+	}
+	return nil
+}
+
+// supersede marks an older pending deployment superseded by a newer one
+func (c *Controller) supersede(ctx context.Context, deployment, by *types.Deployment, logger *logrus.Entry) {
+	message := supersededMessage(by)
+	marked, err := c.repositories.Deployments.MarkSuperseded(ctx, deployment.ID, message)
+	if err != nil {
+		logger.WithError(err).WithField("superseded", deployment.ID).Warn("Failed to mark deployment superseded")
+		return
+	}
+	if !marked {
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"superseded":    deployment.ID,
+		"superseded_by": by.ID,
+	}).Info("Deployment superseded")
+	if c.eventBroker != nil {
+		go c.publishDeploymentEvent(ctx, deployment.ID, types.DeploymentStatusSuperseded, types.HealthStatusUnknown, &message)
+	}
+}
+
+// releaseDeployLock gives up the deploy lock a deployment holds, if any
+func (c *Controller) releaseDeployLock(ctx context.Context, deploymentID uuid.UUID, logger *logrus.Entry) {
+	if c.repositories.DeployLocks == nil {
+		return
+	}
+	if err := c.repositories.DeployLocks.Release(ctx, deploymentID); err != nil {
+		logger.WithError(err).Warn("Failed to release deploy lock")
+	}
+}
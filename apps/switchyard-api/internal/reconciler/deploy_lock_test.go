@@ -0,0 +1,66 @@
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestDecideConcurrency(t *testing.T) {
+	base := time.Now()
+	deployment := func(age time.Duration) *types.Deployment {
+		return &types.Deployment{
+			ID:        uuid.New(),
+			Status:    types.DeploymentStatusPending,
+			CreatedAt: base.Add(-age),
+		}
+	}
+	oldest := deployment(3 * time.Minute)
+	older := deployment(2 * time.Minute)
+	current := deployment(time.Minute)
+	newer := deployment(0)
+
+	t.Run("alone", func(t *testing.T) {
+		got := decideConcurrency(types.DeployConcurrencySupersede, current, []*types.Deployment{current})
+		if got.action != concurrencyProceed || len(got.supersede) != 0 {
+			t.Errorf("decision = %+v, want proceed", got)
+		}
+	})
+
+	t.Run("supersede older", func(t *testing.T) {
+		got := decideConcurrency(types.DeployConcurrencySupersede, current, []*types.Deployment{oldest, older, current})
+		if got.action != concurrencyProceed {
+			t.Fatalf("action = %v, want proceed", got.action)
+		}
+		if len(got.supersede) != 2 || got.supersede[0] != oldest || got.supersede[1] != older {
+			t.Errorf("supersede = %v, want oldest and older", got.supersede)
+		}
+	})
+
+	t.Run("superseded by newer", func(t *testing.T) {
+		got := decideConcurrency(types.DeployConcurrencySupersede, older, []*types.Deployment{oldest, older, current, newer})
+		if got.action != concurrencySuperseded || got.other != newer {
+			t.Errorf("decision = %+v, want superseded by newest", got)
+		}
+		if len(got.supersede) != 0 {
+			t.Errorf("supersede = %v, want none", got.supersede)
+		}
+	})
+
+	t.Run("queue behind oldest", func(t *testing.T) {
+		got := decideConcurrency(types.DeployConcurrencyQueue, current, []*types.Deployment{older, oldest, current, newer})
+		if got.action != concurrencyWait || got.other != oldest {
+			t.Errorf("decision = %+v, want wait for oldest", got)
+		}
+	})
+
+	t.Run("queue head proceeds", func(t *testing.T) {
+		got := decideConcurrency(types.DeployConcurrencyQueue, oldest, []*types.Deployment{oldest, older, newer})
+		if got.action != concurrencyProceed || len(got.supersede) != 0 {
+			t.Errorf("decision = %+v, want proceed", got)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/services"
+)
+
+// DeploymentGroupScheduleController periodically sends the reminders of
+// scheduled deployment groups and executes them once they are due
+type DeploymentGroupScheduleController struct {
+	groups   *services.DeploymentGroupService
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewDeploymentGroupScheduleController creates a new deployment group schedule controller
+func NewDeploymentGroupScheduleController(groups *services.DeploymentGroupService, logger *logrus.Logger) *DeploymentGroupScheduleController {
+	return &DeploymentGroupScheduleController{
+		groups:   groups,
+		logger:   logger,
+		interval: services.ScheduleSyncInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the sync loop
+func (c *DeploymentGroupScheduleController) Start(ctx context.Context) {
+	c.logger.Info("Starting deployment group schedule controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.groups.SyncScheduled(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.groups.SyncScheduled(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Deployment group schedule controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Deployment group schedule controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *DeploymentGroupScheduleController) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,63 @@
+package reconciler
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/events"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// enterPhase records a deployment entering a phase of its rollout and
+// streams it to event subscribers. Progress is informational, so failing to
+// record it does not hold up the deployment.
+func (c *Controller) enterPhase(ctx context.Context, deploymentID uuid.UUID, phase types.DeploymentPhase, logger *logrus.Entry) {
+	if c.repositories.DeploymentPhases == nil {
+		return
+	}
+	if err := c.repositories.DeploymentPhases.Enter(ctx, deploymentID, phase); err != nil {
+		logger.WithError(err).WithField("phase", phase).Warn("Failed to record deployment phase")
+		return
+	}
+	if c.eventBroker != nil {
+		go c.publishProgressEvent(ctx, deploymentID, phase, 0, 0)
+	}
+}
+
+// recordRollout records how many new pods of a deployment are ready
+func (c *Controller) recordRollout(ctx context.Context, deploymentID uuid.UUID, ready, desired int, logger *logrus.Entry) {
+	if c.repositories.DeploymentPhases == nil {
+		return
+	}
+	if err := c.repositories.DeploymentPhases.UpdateRollout(ctx, deploymentID, ready, desired); err != nil {
+		logger.WithError(err).Warn("Failed to record rollout progress")
+		return
+	}
+	if c.eventBroker != nil {
+		go c.publishProgressEvent(ctx, deploymentID, types.DeploymentPhaseWaitingRollout, ready, desired)
+	}
+}
+
+// finishPhase ends the phase of a deployment that failed or was superseded
+func (c *Controller) finishPhase(ctx context.Context, deploymentID uuid.UUID, logger *logrus.Entry) {
+	if c.repositories.DeploymentPhases == nil {
+		return
+	}
+	if err := c.repositories.DeploymentPhases.Finish(ctx, deploymentID); err != nil {
+		logger.WithError(err).Warn("Failed to finish deployment phase")
+	}
+}
+
+// publishProgressEvent streams a deployment's phase and percent complete
+func (c *Controller) publishProgressEvent(ctx context.Context, deploymentID uuid.UUID, phase types.DeploymentPhase, ready, desired int) {
+	event := events.NewProgressEvent(events.ResourceDeployment, deploymentID, string(phase))
+	event.Data = map[string]any{"percent": phase.Percent(ready, desired)}
+	if desired > 0 {
+		event.Data["ready_replicas"] = ready
+		event.Data["desired_replicas"] = desired
+	}
+
+	c.publishScopedDeploymentEvent(ctx, deploymentID, event)
+}
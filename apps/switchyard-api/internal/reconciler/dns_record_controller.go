@@ -0,0 +1,58 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/dns"
+)
+
+// DNSRecordController periodically compares managed DNS records with what
+// their domains need, records drift, and retries records that failed to be
+// written
+type DNSRecordController struct {
+	records  *dns.Service
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewDNSRecordController creates a new DNS record controller
+func NewDNSRecordController(records *dns.Service, logger *logrus.Logger) *DNSRecordController {
+	return &DNSRecordController{
+		records:  records,
+		logger:   logger,
+		interval: dns.CheckInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the drift check loop
+func (c *DNSRecordController) Start(ctx context.Context) {
+	c.logger.Info("Starting DNS record controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.records.CheckAll(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.records.CheckAll(ctx)
+		case <-c.stopCh:
+			c.logger.Info("DNS record controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("DNS record controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *DNSRecordController) Stop() {
+	close(c.stopCh)
+}
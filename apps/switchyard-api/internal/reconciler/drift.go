@@ -0,0 +1,345 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// FieldManager is the field manager the reconciler server-side applies
+// managed resources as
+const FieldManager = "switchyard"
+
+// legacyFieldManager is the field manager Kubernetes recorded for resources
+// the reconciler created or updated before it used server-side apply. Its
+// fields are taken over on the first apply and are not drift.
+var legacyFieldManager = strings.SplitN(rest.DefaultKubernetesUserAgent(), "/", 2)[0]
+
+// ResourceDrift lists the fields of a managed resource that another field
+// manager, such as kubectl-edit, changed away from the values Enclii applies
+type ResourceDrift struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Manager   string
+	Fields    []string
+}
+
+// applyPatchFunc sends a server-side apply patch for one resource
+type applyPatchFunc func(ctx context.Context, data []byte, opts metav1.PatchOptions) error
+
+// serverSideApply applies desired as the reconciler's field manager. A dry
+// run without force first finds the fields other managers changed; they are
+// returned as drift and, under DriftPolicyPreserve, kept at their live
+// values. The apply itself is forced so the reconciler takes over the rest.
+func serverSideApply(ctx context.Context, desired, live runtime.Object, policy types.DriftPolicy, patch applyPatchFunc) ([]ResourceDrift, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %T: %w", desired, err)
+	}
+	// Status is not applied, and an unset creation time is not a value
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %T: %w", desired, err)
+	}
+
+	force := false
+	err = patch(ctx, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil && !errors.IsConflict(err) {
+		return nil, err
+	}
+
+	var drift []ResourceDrift
+	if err != nil {
+		gvk := desired.GetObjectKind().GroupVersionKind()
+		metadata, _ := content["metadata"].(map[string]interface{})
+		namespace, _ := metadata["namespace"].(string)
+		name, _ := metadata["name"].(string)
+		drift = conflictDrift(err, gvk.Kind, namespace, name)
+	}
+
+	if policy == types.DriftPolicyPreserve && len(drift) > 0 && live != nil {
+		liveContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert live %T: %w", live, err)
+		}
+		for _, d := range drift {
+			for _, field := range d.Fields {
+				path, err := parseFieldPath(field)
+				if err != nil {
+					return nil, err
+				}
+				preserved, _ := preserveField(content, liveContent, true, path)
+				content = preserved.(map[string]interface{})
+			}
+		}
+		if data, err = json.Marshal(content); err != nil {
+			return nil, fmt.Errorf("failed to marshal %T: %w", desired, err)
+		}
+	}
+
+	force = true
+	if err := patch(ctx, data, metav1.PatchOptions{FieldManager: FieldManager, Force: &force}); err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// conflictDrift groups the field manager conflicts of a failed apply by the
+// manager that owns the fields
+func conflictDrift(err error, kind, namespace, name string) []ResourceDrift {
+	status, ok := err.(errors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return nil
+	}
+
+	byManager := make(map[string][]string)
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		// Messages read: conflict with "kubectl-edit" using apps/v1
+		manager := cause.Message
+		if start := strings.Index(manager, `"`); start >= 0 {
+			if end := strings.Index(manager[start+1:], `"`); end >= 0 {
+				manager = manager[start+1 : start+1+end]
+			}
+		}
+		if manager == legacyFieldManager {
+			continue
+		}
+		byManager[manager] = append(byManager[manager], cause.Field)
+	}
+
+	drift := make([]ResourceDrift, 0, len(byManager))
+	for manager, fields := range byManager {
+		sort.Strings(fields)
+		drift = append(drift, ResourceDrift{
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+			Manager:   manager,
+			Fields:    fields,
+		})
+	}
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Manager < drift[j].Manager })
+	return drift
+}
+
+// pathElement is one step of a field path as Kubernetes reports it:
+// .field, [key=value,...] in a keyed list, [=value] in a set, or [index]
+type pathElement struct {
+	field   string
+	key     map[string]interface{}
+	value   interface{}
+	isValue bool
+	index   int
+}
+
+// parseFieldPath parses a field path such as
+// .spec.template.spec.containers[name="api"].image
+func parseFieldPath(path string) ([]pathElement, error) {
+	var elements []pathElement
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			end := i + 1
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			elements = append(elements, pathElement{field: path[i+1 : end], index: -1})
+			i = end
+
+		case '[':
+			end, quoted := i+1, false
+			for end < len(path) && (quoted || path[end] != ']') {
+				if path[end] == '"' && path[end-1] != '\\' {
+					quoted = !quoted
+				}
+				end++
+			}
+			if end == len(path) {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+			element, err := parseSelector(path[i+1 : end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path %q: %w", path, err)
+			}
+			elements = append(elements, element)
+			i = end + 1
+
+		default:
+			return nil, fmt.Errorf("invalid field path %q", path)
+		}
+	}
+	return elements, nil
+}
+
+// parseSelector parses the inside of a [...] path element
+func parseSelector(selector string) (pathElement, error) {
+	if strings.HasPrefix(selector, "=") {
+		var value interface{}
+		if err := json.Unmarshal([]byte(selector[1:]), &value); err != nil {
+			return pathElement{}, err
+		}
+		return pathElement{value: value, isValue: true, index: -1}, nil
+	}
+	if index, err := strconv.Atoi(selector); err == nil {
+		return pathElement{index: index}, nil
+	}
+
+	key := make(map[string]interface{})
+	for _, part := range splitOutsideQuotes(selector, ',') {
+		name, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			return pathElement{}, fmt.Errorf("invalid key %q", part)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return pathElement{}, err
+		}
+		key[name] = value
+	}
+	return pathElement{key: key, index: -1}, nil
+}
+
+// splitOutsideQuotes splits s at every sep that is not inside a JSON string
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"' && (i == 0 || s[i-1] != '\\'):
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// preserveField sets the field at path in desired to its value in live, or
+// removes it when live does not have it. It returns the updated desired
+// value, and false when that value should be removed.
+func preserveField(desired, live interface{}, inLive bool, path []pathElement) (interface{}, bool) {
+	if len(path) == 0 {
+		if !inLive {
+			return nil, false
+		}
+		return runtime.DeepCopyJSONValue(live), true
+	}
+
+	element, rest := path[0], path[1:]
+	switch {
+	case element.field != "":
+		d, _ := desired.(map[string]interface{})
+		if d == nil {
+			d = make(map[string]interface{})
+		}
+		l, _ := live.(map[string]interface{})
+		liveValue, ok := l[element.field]
+		if value, keep := preserveField(d[element.field], liveValue, ok, rest); keep {
+			d[element.field] = value
+		} else {
+			delete(d, element.field)
+		}
+		return d, true
+
+	case element.key != nil:
+		d, _ := desired.([]interface{})
+		l, _ := live.([]interface{})
+		var desiredItem, liveItem interface{}
+		di, li := findKeyed(d, element.key), findKeyed(l, element.key)
+		if di >= 0 {
+			desiredItem = d[di]
+		}
+		if li >= 0 {
+			liveItem = l[li]
+		}
+		value, keep := preserveField(desiredItem, liveItem, li >= 0, rest)
+		switch {
+		case keep && di >= 0:
+			d[di] = value
+		case keep:
+			d = append(d, value)
+		case di >= 0:
+			d = append(d[:di], d[di+1:]...)
+		}
+		return d, true
+
+	case element.isValue:
+		d, _ := desired.([]interface{})
+		l, _ := live.([]interface{})
+		di, li := findValue(d, element.value), findValue(l, element.value)
+		switch {
+		case li >= 0 && di < 0:
+			d = append(d, l[li])
+		case li < 0 && di >= 0:
+			d = append(d[:di], d[di+1:]...)
+		}
+		return d, true
+
+	default:
+		d, _ := desired.([]interface{})
+		l, _ := live.([]interface{})
+		if element.index >= len(d) || element.index >= len(l) {
+			return desired, desired != nil
+		}
+		if value, keep := preserveField(d[element.index], l[element.index], true, rest); keep {
+			d[element.index] = value
+		}
+		return d, true
+	}
+}
+
+// findKeyed returns the index of the list item whose fields match key
+func findKeyed(items []interface{}, key map[string]interface{}) int {
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matches := true
+		for name, value := range key {
+			// JSON numbers parse as float64, converted objects hold int64
+			if fmt.Sprint(fields[name]) != fmt.Sprint(value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return i
+		}
+	}
+	return -1
+}
+
+// findValue returns the index of a value in a set list
+func findValue(items []interface{}, value interface{}) int {
+	for i, item := range items {
+		if fmt.Sprint(item) == fmt.Sprint(value) {
+			return i
+		}
+	}
+	return -1
+}
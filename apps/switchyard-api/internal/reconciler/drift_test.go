@@ -0,0 +1,167 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func applyConflict(conflicts map[string]string) error {
+	var causes []metav1.StatusCause
+	for field, manager := range conflicts {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: fmt.Sprintf("conflict with %q using apps/v1", manager),
+			Field:   field,
+		})
+	}
+	return errors.NewApplyConflict(causes, "Apply failed with conflicts")
+}
+
+func TestParseFieldPath(t *testing.T) {
+	path, err := parseFieldPath(`.spec.template.spec.containers[name="api"].env[name="A,B"].value`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 8 {
+		t.Fatalf("len = %d, want 8", len(path))
+	}
+	if path[4].key["name"] != "api" || path[6].key["name"] != "A,B" || path[7].field != "value" {
+		t.Errorf("path = %+v", path)
+	}
+
+	path, err = parseFieldPath(`.spec.ports[port=80,protocol="TCP"].targetPort`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path[2].key["port"] != float64(80) || path[2].key["protocol"] != "TCP" {
+		t.Errorf("keyed element = %+v", path[2])
+	}
+
+	if _, err := parseFieldPath(`.spec.containers[name="api"`); err == nil {
+		t.Error("unterminated selector parsed")
+	}
+}
+
+func TestConflictDrift(t *testing.T) {
+	err := applyConflict(map[string]string{
+		".spec.replicas": "kubectl-edit",
+		`.spec.template.spec.containers[name="api"].image`: "kubectl-edit",
+		".metadata.labels.team":                            "argocd",
+		".spec.paused":                                     legacyFieldManager,
+	})
+
+	drift := conflictDrift(err, "Deployment", "prod", "api")
+	if len(drift) != 2 {
+		t.Fatalf("drift = %+v, want 2 managers", drift)
+	}
+	if drift[0].Manager != "argocd" || drift[1].Manager != "kubectl-edit" {
+		t.Errorf("managers = %s, %s", drift[0].Manager, drift[1].Manager)
+	}
+	if got := fmt.Sprint(drift[1].Fields); got != `[.spec.replicas .spec.template.spec.containers[name="api"].image]` {
+		t.Errorf("fields = %s", got)
+	}
+
+	if drift := conflictDrift(errors.NewNotFound(appsv1.Resource("deployments"), "api"), "Deployment", "prod", "api"); len(drift) != 0 {
+		t.Errorf("drift from a non-conflict = %+v", drift)
+	}
+}
+
+func TestServerSideApplyPreservesDrift(t *testing.T) {
+	replicas, liveReplicas := int32(2), int32(5)
+	desired := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "api", Image: "api:v2"}},
+			}},
+		},
+	}
+	live := desired.DeepCopy()
+	live.Spec.Replicas = &liveReplicas
+	live.Spec.Template.Spec.Containers[0].Image = "api:hotfix"
+
+	conflict := applyConflict(map[string]string{
+		".spec.replicas": "kubectl-edit",
+		`.spec.template.spec.containers[name="api"].image`: "kubectl-edit",
+	})
+
+	for _, tc := range []struct {
+		policy   types.DriftPolicy
+		replicas float64
+		image    string
+	}{
+		{types.DriftPolicyOverwrite, 2, "api:v2"},
+		{types.DriftPolicyPreserve, 5, "api:hotfix"},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			var applied map[string]interface{}
+			patch := func(_ context.Context, data []byte, opts metav1.PatchOptions) error {
+				if opts.FieldManager != FieldManager {
+					t.Errorf("field manager = %q", opts.FieldManager)
+				}
+				if len(opts.DryRun) > 0 {
+					if *opts.Force {
+						t.Error("dry run was forced")
+					}
+					return conflict
+				}
+				return json.Unmarshal(data, &applied)
+			}
+
+			drift, err := serverSideApply(context.Background(), desired.DeepCopy(), live, tc.policy, patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(drift) != 1 || drift[0].Kind != "Deployment" || drift[0].Name != "api" || len(drift[0].Fields) != 2 {
+				t.Errorf("drift = %+v", drift)
+			}
+
+			spec := applied["spec"].(map[string]interface{})
+			if spec["replicas"] != tc.replicas {
+				t.Errorf("replicas = %v, want %v", spec["replicas"], tc.replicas)
+			}
+			container := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})[0]
+			if image := container.(map[string]interface{})["image"]; image != tc.image {
+				t.Errorf("image = %v, want %s", image, tc.image)
+			}
+			if _, ok := applied["status"]; ok {
+				t.Error("status was applied")
+			}
+		})
+	}
+}
+
+func TestPreserveFieldRemovesFieldsMissingLive(t *testing.T) {
+	desired := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{"a": "1", "b": "2"},
+		},
+	}
+	live := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{"a": "1"},
+		},
+	}
+
+	path, err := parseFieldPath(".metadata.annotations.b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	preserveField(desired, live, true, path)
+
+	annotations := desired["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	if _, ok := annotations["b"]; ok || annotations["a"] != "1" {
+		t.Errorf("annotations = %v", annotations)
+	}
+}
@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cmek"
+)
+
+// EncryptionKeyController periodically checks that customer-managed team keys
+// are still enabled and usable, and records their health
+type EncryptionKeyController struct {
+	keys     *cmek.Service
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewEncryptionKeyController creates a new encryption key controller
+func NewEncryptionKeyController(keys *cmek.Service, logger *logrus.Logger) *EncryptionKeyController {
+	return &EncryptionKeyController{
+		keys:     keys,
+		logger:   logger,
+		interval: cmek.DataKeyCacheTTL,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the health check loop
+func (c *EncryptionKeyController) Start(ctx context.Context) {
+	c.logger.Info("Starting encryption key controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.keys.CheckAll(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.keys.CheckAll(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Encryption key controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Encryption key controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *EncryptionKeyController) Stop() {
+	close(c.stopCh)
+}
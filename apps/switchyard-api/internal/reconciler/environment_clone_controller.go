@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/environments"
+)
+
+// EnvironmentCloneController periodically moves the addon copies of cloned
+// environments along, from provisioning through restore to bound
+type EnvironmentCloneController struct {
+	cloner   *environments.Cloner
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewEnvironmentCloneController creates a new environment clone controller
+func NewEnvironmentCloneController(cloner *environments.Cloner, logger *logrus.Logger) *EnvironmentCloneController {
+	return &EnvironmentCloneController{
+		cloner:   cloner,
+		logger:   logger,
+		interval: environments.SyncInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the sync loop
+func (c *EnvironmentCloneController) Start(ctx context.Context) {
+	c.logger.Info("Starting environment clone controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.cloner.Sync(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.cloner.Sync(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Environment clone controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Environment clone controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *EnvironmentCloneController) Stop() {
+	close(c.stopCh)
+}
@@ -5,6 +5,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/addons"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -17,6 +18,10 @@ func getAddonPort(addonType types.DatabaseAddonType) int32 {
 		return 6379
 	case types.DatabaseAddonTypeMySQL:
 		return 3306
+	case types.DatabaseAddonTypeMongoDB:
+		return 27017
+	case types.DatabaseAddonTypeBucket:
+		return addons.DefaultMinIOPort
 	default:
 		return 5432 // Default to PostgreSQL port
 	}
@@ -24,7 +29,9 @@ func getAddonPort(addonType types.DatabaseAddonType) int32 {
 
 // buildAddonEnvVars creates environment variables for database addon bindings
 // For PostgreSQL: References the CloudNativePG-generated secret
-// For Redis: Uses direct connection URL (no authentication by default)
+// For Redis: References the generated credentials secret, or a direct URL for legacy unauthenticated instances
+// For MySQL and MongoDB: References the "uri" key of the provisioner-generated secret
+// For buckets: EnvVarName is a prefix for the endpoint, bucket, region, and access key vars
 func buildAddonEnvVars(bindings []AddonBinding) []corev1.EnvVar {
 	var envVars []corev1.EnvVar
 
@@ -51,7 +58,22 @@ func buildAddonEnvVars(bindings []AddonBinding) []corev1.EnvVar {
 			})
 
 		case types.DatabaseAddonTypeRedis:
-			// Redis uses direct connection URL (no secret needed for basic setup)
+			if binding.ConnectionSecret != "" {
+				envVars = append(envVars, corev1.EnvVar{
+					Name: binding.EnvVarName,
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: binding.ConnectionSecret,
+							},
+							Key: "uri",
+						},
+					},
+				})
+				continue
+			}
+
+			// Addons provisioned before auth was enabled have no secret
 			redisURL := fmt.Sprintf("redis://%s.%s.svc.cluster.local:6379/0",
 				binding.K8sResourceName, binding.K8sNamespace)
 
@@ -60,11 +82,11 @@ func buildAddonEnvVars(bindings []AddonBinding) []corev1.EnvVar {
 				Value: redisURL,
 			})
 
-		case types.DatabaseAddonTypeMySQL:
-			// MySQL secret reference (similar to PostgreSQL)
+		case types.DatabaseAddonTypeMySQL, types.DatabaseAddonTypeMongoDB:
+			// StatefulSet-backed engines store the URI in "<resource>-secret"
 			secretName := binding.ConnectionSecret
 			if secretName == "" {
-				secretName = fmt.Sprintf("%s-credentials", binding.K8sResourceName)
+				secretName = fmt.Sprintf("%s-secret", binding.K8sResourceName)
 			}
 
 			envVars = append(envVars, corev1.EnvVar{
@@ -78,12 +100,55 @@ func buildAddonEnvVars(bindings []AddonBinding) []corev1.EnvVar {
 					},
 				},
 			})
+
+		case types.DatabaseAddonTypeBucket:
+			for _, v := range addons.BucketEnvVars(binding.EnvVarName) {
+				envVars = append(envVars, corev1.EnvVar{
+					Name: v.Name,
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: binding.ConnectionSecret,
+							},
+							Key: v.SecretKey,
+						},
+					},
+				})
+			}
 		}
 	}
 
 	return envVars
 }
 
+// applyEnvVarOverrides overlays preview overrides on a deployment's env vars,
+// replacing variables with the same key. The legacy map is always updated;
+// the metadata list only when it is in use, so it never hides the map.
+func applyEnvVarOverrides(envVars map[string]string, envVarsWithMeta []EnvVarWithMeta, overrides []EnvVarWithMeta) []EnvVarWithMeta {
+	useMeta := len(envVarsWithMeta) > 0 || len(envVars) == 0
+
+	for _, override := range overrides {
+		envVars[override.Key] = override.Value
+		if !useMeta {
+			continue
+		}
+
+		replaced := false
+		for i := range envVarsWithMeta {
+			if envVarsWithMeta[i].Key == override.Key {
+				envVarsWithMeta[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			envVarsWithMeta = append(envVarsWithMeta, override)
+		}
+	}
+
+	return envVarsWithMeta
+}
+
 // sanitizeDomainForSecret converts a domain name to a valid Kubernetes secret name
 func sanitizeDomainForSecret(domain string) string {
 	// Replace dots with dashes for valid secret name
@@ -0,0 +1,118 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestBuildAddonEnvVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		binding AddonBinding
+		want    map[string]string // env var -> secret/key
+	}{
+		{
+			name: "mysql",
+			binding: AddonBinding{
+				EnvVarName:       "MYSQL_URL",
+				AddonType:        types.DatabaseAddonTypeMySQL,
+				K8sResourceName:  "mysql-1234abcd",
+				ConnectionSecret: "mysql-1234abcd-secret",
+			},
+			want: map[string]string{"MYSQL_URL": "mysql-1234abcd-secret/uri"},
+		},
+		{
+			name: "bucket expands the prefix",
+			binding: AddonBinding{
+				EnvVarName:       "UPLOADS",
+				AddonType:        types.DatabaseAddonTypeBucket,
+				K8sResourceName:  "minio-1234abcd",
+				ConnectionSecret: "minio-1234abcd-credentials",
+			},
+			want: map[string]string{
+				"UPLOADS_ENDPOINT":          "minio-1234abcd-credentials/endpoint",
+				"UPLOADS_BUCKET":            "minio-1234abcd-credentials/bucket",
+				"UPLOADS_REGION":            "minio-1234abcd-credentials/region",
+				"UPLOADS_ACCESS_KEY_ID":     "minio-1234abcd-credentials/access_key_id",
+				"UPLOADS_SECRET_ACCESS_KEY": "minio-1234abcd-credentials/secret_access_key",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envVars := buildAddonEnvVars([]AddonBinding{tt.binding})
+			if len(envVars) != len(tt.want) {
+				t.Fatalf("got %d env vars, want %d", len(envVars), len(tt.want))
+			}
+			for _, env := range envVars {
+				if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+					t.Errorf("%s is not sourced from a secret", env.Name)
+					continue
+				}
+				ref := env.ValueFrom.SecretKeyRef
+				if got := ref.Name + "/" + ref.Key; got != tt.want[env.Name] {
+					t.Errorf("%s = %q, want %q", env.Name, got, tt.want[env.Name])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyEnvVarOverrides(t *testing.T) {
+	overrides := []EnvVarWithMeta{
+		{Key: "PAYMENTS_URL", Value: "https://sandbox.payments.example.com"},
+		{Key: "PAYMENTS_KEY", Value: "sk_test", IsSecret: true},
+	}
+
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		withMeta []EnvVarWithMeta
+		wantMeta []EnvVarWithMeta
+	}{
+		{
+			name:    "overrides replace and extend",
+			envVars: map[string]string{"PAYMENTS_URL": "https://payments.example.com", "LOG_LEVEL": "info"},
+			withMeta: []EnvVarWithMeta{
+				{Key: "PAYMENTS_URL", Value: "https://payments.example.com"},
+				{Key: "LOG_LEVEL", Value: "info"},
+			},
+			wantMeta: []EnvVarWithMeta{
+				{Key: "PAYMENTS_URL", Value: "https://sandbox.payments.example.com"},
+				{Key: "LOG_LEVEL", Value: "info"},
+				{Key: "PAYMENTS_KEY", Value: "sk_test", IsSecret: true},
+			},
+		},
+		{
+			name:     "service without env vars",
+			envVars:  map[string]string{},
+			wantMeta: overrides,
+		},
+		{
+			name:    "legacy map only",
+			envVars: map[string]string{"LOG_LEVEL": "info"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMeta := applyEnvVarOverrides(tt.envVars, tt.withMeta, overrides)
+
+			if len(gotMeta) != len(tt.wantMeta) {
+				t.Fatalf("envVarsWithMeta = %+v, want %+v", gotMeta, tt.wantMeta)
+			}
+			for i := range tt.wantMeta {
+				if gotMeta[i] != tt.wantMeta[i] {
+					t.Errorf("envVarsWithMeta[%d] = %+v, want %+v", i, gotMeta[i], tt.wantMeta[i])
+				}
+			}
+			for _, override := range overrides {
+				if tt.envVars[override.Key] != override.Value {
+					t.Errorf("envVars[%s] = %q, want %q", override.Key, tt.envVars[override.Key], override.Value)
+				}
+			}
+		})
+	}
+}
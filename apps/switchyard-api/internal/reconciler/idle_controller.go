@@ -0,0 +1,62 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/idle"
+)
+
+// IdleController periodically puts idle services to sleep and reports their savings
+type IdleController struct {
+	manager  *idle.Manager
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewIdleController creates a new idle controller
+func NewIdleController(manager *idle.Manager, logger *logrus.Logger) *IdleController {
+	return &IdleController{
+		manager:  manager,
+		logger:   logger,
+		interval: idle.SyncInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the sync loop
+func (c *IdleController) Start(ctx context.Context) {
+	c.logger.Info("Starting idle controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.run(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.run(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Idle controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Idle controller context cancelled")
+			return
+		}
+	}
+}
+
+func (c *IdleController) run(ctx context.Context) {
+	if err := c.manager.Sync(ctx); err != nil {
+		c.logger.WithError(err).Error("Failed to sync idle services")
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *IdleController) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,63 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/logshipping"
+)
+
+// LogShippingController periodically syncs the log shipper DaemonSet and
+// the namespaces it ships logs from
+type LogShippingController struct {
+	shipper  *logshipping.Shipper
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewLogShippingController creates a new log shipping controller
+func NewLogShippingController(shipper *logshipping.Shipper, logger *logrus.Logger) *LogShippingController {
+	return &LogShippingController{
+		shipper:  shipper,
+		logger:   logger,
+		interval: logshipping.SyncInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the sync loop
+func (c *LogShippingController) Start(ctx context.Context) {
+	c.logger.Info("Starting log shipping controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.sync(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.sync(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Log shipping controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Log shipping controller context cancelled")
+			return
+		}
+	}
+}
+
+func (c *LogShippingController) sync(ctx context.Context) {
+	if err := c.shipper.Sync(ctx); err != nil {
+		c.logger.WithError(err).Error("Failed to sync log shipping")
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *LogShippingController) Stop() {
+	close(c.stopCh)
+}
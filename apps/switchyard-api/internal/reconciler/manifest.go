@@ -2,6 +2,7 @@ package reconciler
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -12,6 +13,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -74,9 +77,9 @@ func mustParseQuantity(s string) resource.Quantity {
 // buildResourceRequirements creates container resource requirements from config or defaults
 func buildResourceRequirements(cfg *types.ResourceConfig) corev1.ResourceRequirements {
 	// Default values
-	cpuRequest := "100m"
+	cpuRequest := quota.DefaultCPURequest
 	cpuLimit := "500m"
-	memRequest := "128Mi"
+	memRequest := quota.DefaultMemoryRequest
 	memLimit := "512Mi"
 
 	if cfg != nil {
@@ -106,6 +109,53 @@ func buildResourceRequirements(cfg *types.ResourceConfig) corev1.ResourceRequire
 	}
 }
 
+// containerCommand is the command the service runs in the target
+// environment: its override there, or else the service's own
+func containerCommand(req *ReconcileRequest) *types.ContainerCommand {
+	if req.Command != nil {
+		return req.Command
+	}
+	return req.Service.Command
+}
+
+// buildNodeSelector returns the node labels a service's pods require
+func buildNodeSelector(cfg *types.SchedulingConfig) map[string]string {
+	if cfg == nil || len(cfg.NodeSelector) == 0 {
+		return nil
+	}
+	selector := make(map[string]string, len(cfg.NodeSelector))
+	for key, value := range cfg.NodeSelector {
+		selector[key] = value
+	}
+	return selector
+}
+
+// buildTopologySpread spreads the pods matching podLabels across the
+// domains of each constraint's topology key
+func buildTopologySpread(cfg *types.SchedulingConfig, podLabels map[string]string) []corev1.TopologySpreadConstraint {
+	if cfg == nil || len(cfg.TopologySpread) == 0 {
+		return nil
+	}
+	constraints := make([]corev1.TopologySpreadConstraint, 0, len(cfg.TopologySpread))
+	for _, spread := range cfg.TopologySpread {
+		maxSkew := spread.MaxSkew
+		if maxSkew < 1 {
+			maxSkew = 1
+		}
+		action := corev1.UnsatisfiableConstraintAction(spread.WhenUnsatisfiable)
+		if action == "" {
+			action = corev1.ScheduleAnyway
+		}
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           maxSkew,
+			TopologyKey:       spread.TopologyKey,
+			WhenUnsatisfiable: action,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: podLabels},
+		})
+	}
+	return constraints
+}
+
 // buildLivenessProbe creates a liveness probe from config or defaults
 func buildLivenessProbe(cfg *types.HealthCheckConfig, containerPort int32) *corev1.Probe {
 	// Check if probes are disabled
@@ -211,6 +261,44 @@ func buildReadinessProbe(cfg *types.HealthCheckConfig, containerPort int32) *cor
 	}
 }
 
+// runtimeDefaultPort returns the detected framework's conventional port when the
+// service sets neither ENCLII_PORT nor PORT, otherwise the platform default
+func runtimeDefaultPort(rt *types.ServiceRuntime, fallback int32) int32 {
+	if rt == nil || rt.DefaultPort <= 0 || rt.DefaultPort > 65535 {
+		return fallback
+	}
+	return int32(rt.DefaultPort)
+}
+
+// runtimeHealthCheck fills in the probe path for frameworks that don't serve
+// /health out of the box. Explicitly configured paths always win.
+func runtimeHealthCheck(cfg *types.HealthCheckConfig, rt *types.ServiceRuntime) *types.HealthCheckConfig {
+	if rt == nil || rt.HealthPath == "" {
+		return cfg
+	}
+	if cfg == nil {
+		return &types.HealthCheckConfig{Path: rt.HealthPath}
+	}
+	if cfg.Path != "" {
+		return cfg
+	}
+	withDefault := *cfg
+	withDefault.Path = rt.HealthPath
+	return &withDefault
+}
+
+// buildImagePullSecrets returns the platform's registry credentials, plus
+// the project's own when it has any
+func buildImagePullSecrets(req *ReconcileRequest) []corev1.LocalObjectReference {
+	secrets := []corev1.LocalObjectReference{
+		{Name: "enclii-registry-credentials"},
+	}
+	if len(req.RegistryCredentials) > 0 {
+		secrets = append(secrets, corev1.LocalObjectReference{Name: pullSecretName(req.Service.Name)})
+	}
+	return secrets
+}
+
 // generateManifests creates Kubernetes Deployment and Service manifests for a service
 func (r *ServiceReconciler) generateManifests(req *ReconcileRequest, namespace, secretName string) (*appsv1.Deployment, *corev1.Service, error) {
 	labels := map[string]string{
@@ -223,11 +311,13 @@ func (r *ServiceReconciler) generateManifests(req *ReconcileRequest, namespace,
 		"enclii.dev/managed-by": "switchyard",
 	}
 
-	// Default configuration
-	replicas := int32(1)
+	replicas := desiredReplicas(req)
 
 	// Determine the port to use (from ENCLII_PORT env var or default to 8080)
-	containerPort, portErr := parseContainerPort(req.EnvVars)
+	containerPort, portSource, portErr := parseContainerPortWithSource(req.EnvVars)
+	if portErr == nil && portSource == PortSourceDefault {
+		containerPort = runtimeDefaultPort(req.Service.Runtime, containerPort)
+	}
 	if portErr != nil {
 		// Log the error but continue with default - this is a configuration issue
 		logrus.WithFields(logrus.Fields{
@@ -262,24 +352,13 @@ func (r *ServiceReconciler) generateManifests(req *ReconcileRequest, namespace,
 
 	// Add user-defined environment variables (from database)
 	// Secrets are referenced via K8s Secret, non-secrets are inline values
-	hasSecrets := false
+	secretData := envSecretData(req)
 	if len(req.EnvVarsWithMeta) > 0 {
 		// New path: use metadata-aware env vars
 		for _, ev := range req.EnvVarsWithMeta {
 			if ev.IsSecret {
 				// Secret values are stored in K8s Secret, reference via secretKeyRef
-				envVars = append(envVars, corev1.EnvVar{
-					Name: ev.Key,
-					ValueFrom: &corev1.EnvVarSource{
-						SecretKeyRef: &corev1.SecretKeySelector{
-							LocalObjectReference: corev1.LocalObjectReference{
-								Name: secretName,
-							},
-							Key: ev.Key,
-						},
-					},
-				})
-				hasSecrets = true
+				envVars = append(envVars, secretEnvVar(secretName, ev.Key))
 			} else {
 				// Non-secret values are inline
 				envVars = append(envVars, corev1.EnvVar{
@@ -289,15 +368,28 @@ func (r *ServiceReconciler) generateManifests(req *ReconcileRequest, namespace,
 			}
 		}
 	} else {
-		// Legacy path: all values inline (backwards compatibility)
-		for key, value := range req.EnvVars {
-			envVars = append(envVars, corev1.EnvVar{
-				Name:  key,
-				Value: value,
-			})
+		// Legacy path: without metadata any value may be a secret, so all of
+		// them are referenced from the K8s Secret
+		keys := make([]string, 0, len(req.EnvVars))
+		for key := range req.EnvVars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			envVars = append(envVars, secretEnvVar(secretName, key))
 		}
 	}
 
+	// Pods only read secretKeyRef values on start, so the hash of the
+	// secret's data rolls them when a value changes
+	podAnnotations := map[string]string{
+		"enclii.dev/git-sha": req.Release.GitSHA,
+	}
+	hasSecrets := len(secretData) > 0
+	if hasSecrets {
+		podAnnotations[EnvSecretHashAnnotation] = envSecretHash(req.Service.ID.String(), secretData)
+	}
+
 	// Log secret injection status
 	if hasSecrets {
 		logrus.WithFields(logrus.Fields{
@@ -310,6 +402,19 @@ func (r *ServiceReconciler) generateManifests(req *ReconcileRequest, namespace,
 	addonEnvVars := buildAddonEnvVars(req.AddonBindings)
 	envVars = append(envVars, addonEnvVars...)
 
+	healthCheck := runtimeHealthCheck(req.Service.HealthCheck, req.Service.Runtime)
+	resources := buildResourceRequirements(req.Service.Resources)
+	k8s.AddGPUs(&resources, req.Service.GPU)
+	placement := k8s.WithGPU(req.Service.Scheduling, req.Service.GPU)
+	command := containerCommand(req)
+	if command == nil {
+		command = &types.ContainerCommand{}
+	}
+	selectorLabels := map[string]string{
+		"app":                req.Service.Name,
+		"enclii.dev/service": req.Service.Name,
+	}
+
 	// Create deployment manifest
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -324,30 +429,25 @@ func (r *ServiceReconciler) generateManifests(req *ReconcileRequest, namespace,
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app":                req.Service.Name,
-					"enclii.dev/service": req.Service.Name,
-				},
+				MatchLabels: selectorLabels,
 			},
 			Strategy: appsv1.DeploymentStrategy{
-				Type: appsv1.RollingUpdateDeploymentStrategyType,
-				RollingUpdate: &appsv1.RollingUpdateDeployment{
-					MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "25%"},
-					MaxSurge:       &intstr.IntOrString{Type: intstr.String, StrVal: "25%"},
-				},
+				Type:          appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: k8s.RollingUpdate(req.Service.Shutdown),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
-					Annotations: map[string]string{
-						"enclii.dev/git-sha": req.Release.GitSHA,
-					},
+					Labels:      labels,
+					Annotations: podAnnotations,
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
-							Name:  req.Service.Name,
-							Image: req.Release.ImageURI,
+							Name:       req.Service.Name,
+							Image:      req.Release.ImageURI,
+							Command:    command.Command,
+							Args:       command.Args,
+							WorkingDir: command.WorkingDir,
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "http",
@@ -356,20 +456,26 @@ func (r *ServiceReconciler) generateManifests(req *ReconcileRequest, namespace,
 								},
 							},
 							Env:            envVars,
-							Resources:      buildResourceRequirements(req.Service.Resources),
-							LivenessProbe:  buildLivenessProbe(req.Service.HealthCheck, containerPort),
-							ReadinessProbe: buildReadinessProbe(req.Service.HealthCheck, containerPort),
+							Resources:      resources,
+							LivenessProbe:  buildLivenessProbe(healthCheck, containerPort),
+							ReadinessProbe: buildReadinessProbe(healthCheck, containerPort),
 							VolumeMounts:   buildVolumeMountsWithKubeconfig(req.Service.Volumes, req.EnvVars),
+							Lifecycle:      k8s.PreStop(req.Service.Shutdown, containerPort),
 						},
 					},
 					// ImagePullSecrets for private registries (GHCR, etc.)
 					// This ensures pods can pull images that require authentication
-					ImagePullSecrets: []corev1.LocalObjectReference{
-						{Name: "enclii-registry-credentials"},
-					},
+					ImagePullSecrets:              buildImagePullSecrets(req),
 					Volumes:                       buildVolumesWithKubeconfig(req.Service.Volumes, req.Service.Name, req.EnvVars),
 					RestartPolicy:                 corev1.RestartPolicyAlways,
-					TerminationGracePeriodSeconds: &[]int64{30}[0],
+					TerminationGracePeriodSeconds: k8s.TerminationGracePeriod(req.Service.Shutdown),
+					// Placement on node pools (e.g., GPU or spot nodes)
+					NodeSelector:              buildNodeSelector(placement),
+					Tolerations:               k8s.Tolerations(placement),
+					TopologySpreadConstraints: buildTopologySpread(placement, selectorLabels),
+					RuntimeClassName:          k8s.GPURuntimeClass(req.Service.GPU),
+					// Spread highly available services across nodes and zones
+					Affinity: buildAntiAffinity(req, selectorLabels),
 				},
 			},
 		},
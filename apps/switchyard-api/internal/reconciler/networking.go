@@ -8,7 +8,11 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // generateIngress creates an Ingress manifest for custom domains
@@ -120,37 +124,34 @@ func (r *ServiceReconciler) generateIngress(req *ReconcileRequest, namespace str
 	return ingress, nil
 }
 
-// applyIngress creates or updates an Ingress
-func (r *ServiceReconciler) applyIngress(ctx context.Context, ingress *networkingv1.Ingress) error {
+// applyIngress server-side applies an Ingress and returns the manual changes
+// found on it
+func (r *ServiceReconciler) applyIngress(ctx context.Context, ingress *networkingv1.Ingress, policy types.DriftPolicy) ([]ResourceDrift, error) {
 	ingressClient := r.k8sClient.Clientset.NetworkingV1().Ingresses(ingress.Namespace)
 
-	// Try to get existing ingress
+	var live runtime.Object
 	existing, err := ingressClient.Get(ctx, ingress.Name, metav1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			// Create new ingress
-			_, err = ingressClient.Create(ctx, ingress, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to create ingress: %w", err)
-			}
-			r.logger.WithField("ingress", ingress.Name).Info("Created new ingress")
-			return nil
-		}
-		return fmt.Errorf("failed to get ingress: %w", err)
+	switch {
+	case err == nil:
+		live = existing
+	case !errors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get ingress: %w", err)
 	}
 
-	// Update existing ingress
-	existing.Labels = ingress.Labels
-	existing.Annotations = ingress.Annotations
-	existing.Spec = ingress.Spec
-
-	_, err = ingressClient.Update(ctx, existing, metav1.UpdateOptions{})
+	ingress.TypeMeta = metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"}
+	drift, err := serverSideApply(ctx, ingress, live, policy, func(ctx context.Context, data []byte, opts metav1.PatchOptions) error {
+		_, err := ingressClient.Patch(ctx, ingress.Name, k8stypes.ApplyPatchType, data, opts)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update ingress: %w", err)
+		return nil, fmt.Errorf("failed to apply ingress: %w", err)
 	}
 
-	r.logger.WithField("ingress", ingress.Name).Info("Updated existing ingress")
-	return nil
+	r.logger.WithFields(map[string]interface{}{
+		"ingress": ingress.Name,
+		"created": live == nil,
+	}).Info("Applied ingress")
+	return drift, nil
 }
 
 // generateNetworkPolicies creates ingress and egress NetworkPolicy manifests for service isolation
@@ -296,6 +297,14 @@ func (r *ServiceReconciler) generateNetworkPolicies(req *ReconcileRequest, names
 
 	// Add egress rules for each addon binding (database access)
 	for _, binding := range req.AddonBindings {
+		if binding.External {
+			egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{
+				Ports: []networkingv1.NetworkPolicyPort{
+					{Protocol: protocolPtr(corev1.ProtocolTCP), Port: &intstr.IntOrString{Type: intstr.Int, IntVal: 443}},
+				},
+			})
+			continue
+		}
 		addonPort := getAddonPort(binding.AddonType)
 		egressRules = append(egressRules, networkingv1.NetworkPolicyEgressRule{
 			To: []networkingv1.NetworkPolicyPeer{
@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+)
+
+// PreviewCleanupController periodically tears down closed preview
+// environments that have outlived their project's TTL
+type PreviewCleanupController struct {
+	cleaner  *previews.Cleaner
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewPreviewCleanupController creates a new preview cleanup controller
+func NewPreviewCleanupController(cleaner *previews.Cleaner, logger *logrus.Logger) *PreviewCleanupController {
+	return &PreviewCleanupController{
+		cleaner:  cleaner,
+		logger:   logger,
+		interval: previews.CheckInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the cleanup loop
+func (c *PreviewCleanupController) Start(ctx context.Context) {
+	c.logger.Info("Starting preview cleanup controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.cleaner.CleanupExpired(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.cleaner.CleanupExpired(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Preview cleanup controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Preview cleanup controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *PreviewCleanupController) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+)
+
+// PreviewDatabaseController periodically moves preview databases along, from
+// provisioning through restore or seeding to ready
+type PreviewDatabaseController struct {
+	databases *previews.Databases
+	logger    *logrus.Logger
+	interval  time.Duration
+	stopCh    chan struct{}
+}
+
+// NewPreviewDatabaseController creates a new preview database controller
+func NewPreviewDatabaseController(databases *previews.Databases, logger *logrus.Logger) *PreviewDatabaseController {
+	return &PreviewDatabaseController{
+		databases: databases,
+		logger:    logger,
+		interval:  previews.DatabaseSyncInterval,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start begins the sync loop
+func (c *PreviewDatabaseController) Start(ctx context.Context) {
+	c.logger.Info("Starting preview database controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.databases.Sync(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.databases.Sync(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Preview database controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Preview database controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *PreviewDatabaseController) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,155 @@
+package reconciler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/registry"
+)
+
+// RegistryTokenController periodically rewrites the pull secrets of
+// projects with ECR or GCR credentials, whose tokens expire long before
+// services are redeployed
+type RegistryTokenController struct {
+	repos    *db.Repositories
+	kube     kubernetes.Interface
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewRegistryTokenController creates a new registry token controller
+func NewRegistryTokenController(repos *db.Repositories, kube kubernetes.Interface, logger *logrus.Logger) *RegistryTokenController {
+	return &RegistryTokenController{
+		repos:    repos,
+		kube:     kube,
+		logger:   logger,
+		interval: registry.TokenRefreshMargin / 2,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the refresh loop
+func (c *RegistryTokenController) Start(ctx context.Context) {
+	c.logger.Info("Starting registry token controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.refreshAll(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.refreshAll(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Registry token controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Registry token controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *RegistryTokenController) Stop() {
+	close(c.stopCh)
+}
+
+// refreshAll refreshes the pull secrets of every project with token credentials
+func (c *RegistryTokenController) refreshAll(ctx context.Context) {
+	projectIDs, err := c.repos.RegistryCredentials.ListTokenProjects(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to list projects with registry tokens")
+		return
+	}
+
+	for _, projectID := range projectIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		updated, err := c.refreshProject(ctx, projectID)
+		if err != nil {
+			c.logger.WithError(err).WithField("project_id", projectID).Error("Failed to refresh registry tokens")
+			continue
+		}
+		if updated > 0 {
+			c.logger.WithFields(logrus.Fields{
+				"project_id": projectID,
+				"secrets":    updated,
+			}).Info("Refreshed registry pull secrets")
+		}
+	}
+}
+
+// refreshProject rewrites the service pull secrets of a project whose
+// tokens have changed, returning how many it updated
+func (c *RegistryTokenController) refreshProject(ctx context.Context, projectID uuid.UUID) (int, error) {
+	secrets, err := c.kube.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("enclii.dev/project=%s,enclii.dev/service,enclii.dev/managed-by=switchyard", projectID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pull secrets: %w", err)
+	}
+
+	// Secrets of the same namespace share the credentials of its environment
+	configs := make(map[string][]byte)
+	updated := 0
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			continue
+		}
+
+		config, ok := configs[secret.Namespace]
+		if !ok {
+			config, err = c.namespaceConfig(ctx, projectID, secret.Namespace)
+			if err != nil {
+				return updated, err
+			}
+			configs[secret.Namespace] = config
+		}
+		if config == nil || bytes.Equal(secret.Data[corev1.DockerConfigJsonKey], config) {
+			continue
+		}
+
+		secret.Data = map[string][]byte{corev1.DockerConfigJsonKey: config}
+		if _, err := c.kube.CoreV1().Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return updated, fmt.Errorf("failed to update pull secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// namespaceConfig builds the Docker config of the environment deployed to a
+// namespace, or of the project when the namespace is not an environment's.
+// It is nil when the project has no credentials left.
+func (c *RegistryTokenController) namespaceConfig(ctx context.Context, projectID uuid.UUID, namespace string) ([]byte, error) {
+	var environmentID *uuid.UUID
+	if env, err := c.repos.Environments.GetByKubeNamespace(namespace); err == nil && env.ProjectID == projectID {
+		environmentID = &env.ID
+	}
+
+	auths, err := c.repos.RegistryCredentials.ListDecrypted(ctx, projectID, environmentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(auths) == 0 {
+		return nil, nil
+	}
+	if auths, err = registry.Resolve(ctx, auths); err != nil {
+		return nil, err
+	}
+	return registry.DockerConfigJSON(auths)
+}
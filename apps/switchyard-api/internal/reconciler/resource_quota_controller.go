@@ -0,0 +1,58 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
+)
+
+// ResourceQuotaController periodically syncs the Kubernetes ResourceQuota
+// objects of projects held to a resource quota, so new environments get
+// them and edits in the cluster are undone
+type ResourceQuotaController struct {
+	syncer   *quota.Syncer
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewResourceQuotaController creates a new resource quota controller
+func NewResourceQuotaController(syncer *quota.Syncer, logger *logrus.Logger) *ResourceQuotaController {
+	return &ResourceQuotaController{
+		syncer:   syncer,
+		logger:   logger,
+		interval: quota.SyncInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the sync loop
+func (c *ResourceQuotaController) Start(ctx context.Context) {
+	c.logger.Info("Starting resource quota controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.syncer.SyncAll(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.syncer.SyncAll(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Resource quota controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Resource quota controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *ResourceQuotaController) Stop() {
+	close(c.stopCh)
+}
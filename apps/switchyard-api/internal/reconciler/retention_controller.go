@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/retention"
+)
+
+// RetentionController periodically purges project data past the retention
+// of each project's policy
+type RetentionController struct {
+	retention *retention.Service
+	logger    *logrus.Logger
+	interval  time.Duration
+	stopCh    chan struct{}
+}
+
+// NewRetentionController creates a new retention controller
+func NewRetentionController(svc *retention.Service, logger *logrus.Logger) *RetentionController {
+	return &RetentionController{
+		retention: svc,
+		logger:    logger,
+		interval:  retention.PurgeInterval,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start begins the purge loop
+func (c *RetentionController) Start(ctx context.Context) {
+	c.logger.Info("Starting retention controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.retention.PurgeExpired(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.retention.PurgeExpired(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Retention controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Retention controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *RetentionController) Stop() {
+	close(c.stopCh)
+}
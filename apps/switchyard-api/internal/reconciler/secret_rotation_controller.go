@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/secretrotation"
+)
+
+// SecretRotationController periodically reminds projects of rotatable
+// secrets older than their max age
+type SecretRotationController struct {
+	reminder *secretrotation.Reminder
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewSecretRotationController creates a new secret rotation controller
+func NewSecretRotationController(reminder *secretrotation.Reminder, logger *logrus.Logger) *SecretRotationController {
+	return &SecretRotationController{
+		reminder: reminder,
+		logger:   logger,
+		interval: secretrotation.CheckInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the reminder loop
+func (c *SecretRotationController) Start(ctx context.Context) {
+	c.logger.Info("Starting secret rotation controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.reminder.SendDue(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.reminder.SendDue(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Secret rotation controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Secret rotation controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *SecretRotationController) Stop() {
+	close(c.stopCh)
+}
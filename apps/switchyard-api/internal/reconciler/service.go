@@ -2,7 +2,10 @@ package reconciler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,8 +14,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/registry"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -39,6 +46,33 @@ type ReconcileRequest struct {
 	EnvVars         map[string]string // User-defined environment variables (decrypted) - DEPRECATED: use EnvVarsWithMeta
 	EnvVarsWithMeta []EnvVarWithMeta  // Environment variables with IsSecret metadata for proper K8s secret creation
 	AddonBindings   []AddonBinding    // Database addon bindings for env var injection
+	DriftPolicy     types.DriftPolicy // What to do with manual changes to managed resources (default overwrite)
+
+	// Command is the service's command override in the target environment;
+	// nil falls back to Service.Command
+	Command *types.ContainerCommand
+
+	// RegistryCredentials are the project's private registry credentials,
+	// mounted as an additional imagePullSecret
+	RegistryCredentials []db.RegistryAuth
+
+	// OnPhase and OnRollout, when set, are told of the deployment's progress
+	OnPhase   func(phase types.DeploymentPhase)
+	OnRollout func(ready, desired int)
+}
+
+// enterPhase reports the deployment entering a phase
+func (req *ReconcileRequest) enterPhase(phase types.DeploymentPhase) {
+	if req.OnPhase != nil {
+		req.OnPhase(phase)
+	}
+}
+
+// reportRollout reports the replicas of the new rollout that are ready
+func (req *ReconcileRequest) reportRollout(ready, desired int) {
+	if req.OnRollout != nil {
+		req.OnRollout(ready, desired)
+	}
 }
 
 // AddonBinding represents a database addon bound to this service
@@ -48,6 +82,7 @@ type AddonBinding struct {
 	K8sNamespace     string                  // Namespace where addon resources exist
 	K8sResourceName  string                  // Name of the addon K8s resource
 	ConnectionSecret string                  // K8s secret name with credentials (for postgres)
+	External         bool                    // Addon lives outside the cluster (cloud buckets), reached over HTTPS
 }
 
 type ReconcileResult struct {
@@ -56,6 +91,9 @@ type ReconcileResult struct {
 	K8sObjects []string
 	NextCheck  *time.Time
 	Error      error
+	Drift      []ResourceDrift // Manual changes found on managed resources
+	Superseded bool            // A newer deployment of the service replaced this one
+	Rollout    bool            // A pending deployment was rolled out, recording its phases
 }
 
 func NewServiceReconciler(k8sClient *k8s.Client, logger *logrus.Logger) *ServiceReconciler {
@@ -65,6 +103,15 @@ func NewServiceReconciler(k8sClient *k8s.Client, logger *logrus.Logger) *Service
 	}
 }
 
+// WithClient returns a reconciler that works in the cluster of the given
+// client
+func (r *ServiceReconciler) WithClient(k8sClient *k8s.Client) *ServiceReconciler {
+	if k8sClient == r.k8sClient {
+		return r
+	}
+	return &ServiceReconciler{k8sClient: k8sClient, logger: r.logger}
+}
+
 // Reconcile ensures the desired state matches the actual state in Kubernetes
 func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest) *ReconcileResult {
 	logger := r.logger.WithFields(logrus.Fields{
@@ -74,6 +121,7 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 	})
 
 	logger.Info("Starting service reconciliation")
+	req.enterPhase(types.DeploymentPhaseApplying)
 
 	// Determine the Kubernetes namespace from the environment
 	// The environment MUST have kube_namespace set - this is a data integrity requirement
@@ -122,7 +170,7 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 	}
 
 	// Create K8s Secret for secret env vars (values not exposed in pod spec)
-	secretName := fmt.Sprintf("%s-secrets", req.Service.Name)
+	secretName := envSecretName(req.Service.Name)
 	if err := r.ensureEnvSecret(ctx, req, namespace, secretName); err != nil {
 		return &ReconcileResult{
 			Success: false,
@@ -131,6 +179,15 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 		}
 	}
 
+	// Create the pull secret for the project's private registries
+	if err := r.ensurePullSecret(ctx, req, namespace); err != nil {
+		return &ReconcileResult{
+			Success: false,
+			Message: "Failed to create registry pull secret",
+			Error:   err,
+		}
+	}
+
 	// Generate Kubernetes manifests
 	deployment, service, err := r.generateManifests(req, namespace, secretName)
 	if err != nil {
@@ -142,22 +199,28 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 	}
 
 	// Apply deployment
-	if err := r.applyDeployment(ctx, deployment); err != nil {
+	var drift []ResourceDrift
+	resourceDrift, err := r.applyDeployment(ctx, deployment, req.DriftPolicy)
+	if err != nil {
 		return &ReconcileResult{
 			Success: false,
 			Message: "Failed to apply deployment",
 			Error:   err,
 		}
 	}
+	drift = append(drift, resourceDrift...)
 
 	// Apply service
-	if err := r.applyService(ctx, service); err != nil {
+	resourceDrift, err = r.applyService(ctx, service, req.DriftPolicy)
+	if err != nil {
 		return &ReconcileResult{
 			Success: false,
 			Message: "Failed to apply service",
 			Error:   err,
+			Drift:   drift,
 		}
 	}
+	drift = append(drift, resourceDrift...)
 
 	// Apply Ingress if custom domains are configured
 	k8sObjects := []string{
@@ -172,16 +235,20 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 				Success: false,
 				Message: "Failed to generate ingress",
 				Error:   err,
+				Drift:   drift,
 			}
 		}
 
-		if err := r.applyIngress(ctx, ingress); err != nil {
+		resourceDrift, err := r.applyIngress(ctx, ingress, req.DriftPolicy)
+		if err != nil {
 			return &ReconcileResult{
 				Success: false,
 				Message: "Failed to apply ingress",
 				Error:   err,
+				Drift:   drift,
 			}
 		}
+		drift = append(drift, resourceDrift...)
 
 		k8sObjects = append(k8sObjects, fmt.Sprintf("ingress/%s", ingress.Name))
 	}
@@ -193,6 +260,7 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 			Success: false,
 			Message: "Failed to generate network policies",
 			Error:   err,
+			Drift:   drift,
 		}
 	}
 
@@ -202,18 +270,37 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 				Success: false,
 				Message: fmt.Sprintf("Failed to apply network policy %s", np.Name),
 				Error:   err,
+				Drift:   drift,
 			}
 		}
 		k8sObjects = append(k8sObjects, fmt.Sprintf("networkpolicy/%s", np.Name))
 	}
 
+	// Keep node drains from evicting all replicas of highly available
+	// services at once
+	if pdb := r.generatePodDisruptionBudget(req, namespace); pdb != nil {
+		if err := r.applyPodDisruptionBudget(ctx, pdb); err != nil {
+			return &ReconcileResult{
+				Success: false,
+				Message: "Failed to apply pod disruption budget",
+				Error:   err,
+				Drift:   drift,
+			}
+		}
+		k8sObjects = append(k8sObjects, fmt.Sprintf("poddisruptionbudget/%s", pdb.Name))
+	} else if err := r.deletePodDisruptionBudget(ctx, namespace, req.Service.Name); err != nil {
+		logger.WithError(err).Warn("Failed to delete pod disruption budget")
+	}
+
 	// Wait for deployment to be ready
-	ready, err := r.waitForDeploymentReady(ctx, deployment.Namespace, deployment.Name, 5*time.Minute)
+	req.enterPhase(types.DeploymentPhaseWaitingRollout)
+	ready, err := r.waitForDeploymentReady(ctx, deployment.Namespace, deployment.Name, 5*time.Minute, req.reportRollout)
 	if err != nil {
 		return &ReconcileResult{
 			Success: false,
 			Message: "Failed to wait for deployment readiness",
 			Error:   err,
+			Drift:   drift,
 		}
 	}
 
@@ -223,6 +310,7 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 			Success:   false,
 			Message:   "Deployment not ready, will retry",
 			NextCheck: &nextCheck,
+			Drift:     drift,
 		}
 	}
 
@@ -232,6 +320,7 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req *ReconcileRequest
 		Success:    true,
 		Message:    "Service deployed successfully",
 		K8sObjects: k8sObjects,
+		Drift:      drift,
 	}
 }
 
@@ -327,96 +416,150 @@ func (r *ServiceReconciler) ensureRegistryCredentials(ctx context.Context, targe
 	return nil
 }
 
-func (r *ServiceReconciler) applyDeployment(ctx context.Context, deployment *appsv1.Deployment) error {
+// applyDeployment server-side applies a deployment and returns the manual
+// changes found on it
+func (r *ServiceReconciler) applyDeployment(ctx context.Context, deployment *appsv1.Deployment, policy types.DriftPolicy) ([]ResourceDrift, error) {
 	deploymentClient := r.k8sClient.Clientset.AppsV1().Deployments(deployment.Namespace)
 
-	// Try to get existing deployment
+	var live runtime.Object
 	existing, err := deploymentClient.Get(ctx, deployment.Name, metav1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			// Create new deployment
-			_, err = deploymentClient.Create(ctx, deployment, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to create deployment: %w", err)
-			}
-			r.logger.WithField("deployment", deployment.Name).Info("Created new deployment")
-			return nil
+	switch {
+	case err == nil:
+		// Kubernetes doesn't allow changing spec.selector on existing deployments,
+		// so keep it and make sure the pod template labels still match it
+		deployment.Spec.Selector = existing.Spec.Selector
+		for key, value := range existing.Spec.Selector.MatchLabels {
+			deployment.Spec.Template.Labels[key] = value
 		}
-		return fmt.Errorf("failed to get existing deployment: %w", err)
+		live = existing
+	case !errors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get existing deployment: %w", err)
 	}
 
-	// Update existing deployment - preserve the immutable selector
-	// Kubernetes doesn't allow changing spec.selector on existing deployments
-	deployment.ResourceVersion = existing.ResourceVersion
-	deployment.Spec.Selector = existing.Spec.Selector
-
-	// Also ensure pod template labels match the selector (required by k8s)
-	// Preserve selector labels in pod template while adding our metadata labels
-	for key, value := range existing.Spec.Selector.MatchLabels {
-		deployment.Spec.Template.Labels[key] = value
-	}
-
-	_, err = deploymentClient.Update(ctx, deployment, metav1.UpdateOptions{})
+	deployment.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+	drift, err := serverSideApply(ctx, deployment, live, policy, func(ctx context.Context, data []byte, opts metav1.PatchOptions) error {
+		_, err := deploymentClient.Patch(ctx, deployment.Name, k8stypes.ApplyPatchType, data, opts)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update deployment: %w", err)
+		return nil, fmt.Errorf("failed to apply deployment: %w", err)
 	}
-	r.logger.WithField("deployment", deployment.Name).Info("Updated existing deployment")
-	return nil
+	r.logger.WithFields(logrus.Fields{
+		"deployment": deployment.Name,
+		"created":    live == nil,
+	}).Info("Applied deployment")
+	return drift, nil
 }
 
-func (r *ServiceReconciler) applyService(ctx context.Context, service *corev1.Service) error {
+// applyService server-side applies a service and returns the manual changes
+// found on it
+func (r *ServiceReconciler) applyService(ctx context.Context, service *corev1.Service, policy types.DriftPolicy) ([]ResourceDrift, error) {
 	serviceClient := r.k8sClient.Clientset.CoreV1().Services(service.Namespace)
 
-	// Try to get existing service
+	var live runtime.Object
 	existing, err := serviceClient.Get(ctx, service.Name, metav1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			// Create new service
-			_, err = serviceClient.Create(ctx, service, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("failed to create service: %w", err)
-			}
-			r.logger.WithField("service", service.Name).Info("Created new service")
-			return nil
+	switch {
+	case err == nil:
+		// Keep the existing selector so it matches the deployment's pods; the
+		// cluster IP is left to Kubernetes
+		if len(existing.Spec.Selector) > 0 {
+			service.Spec.Selector = existing.Spec.Selector
 		}
-		return fmt.Errorf("failed to get existing service: %w", err)
-	}
-
-	// Update existing service (preserve cluster IP and selector)
-	// Service selectors should generally match what the deployment is using
-	service.ResourceVersion = existing.ResourceVersion
-	service.Spec.ClusterIP = existing.Spec.ClusterIP
-
-	// Preserve the existing selector to match the deployment's pods
-	// Only use our new selector for new services
-	if len(existing.Spec.Selector) > 0 {
-		service.Spec.Selector = existing.Spec.Selector
+		live = existing
+	case !errors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get existing service: %w", err)
 	}
 
-	_, err = serviceClient.Update(ctx, service, metav1.UpdateOptions{})
+	service.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+	drift, err := serverSideApply(ctx, service, live, policy, func(ctx context.Context, data []byte, opts metav1.PatchOptions) error {
+		_, err := serviceClient.Patch(ctx, service.Name, k8stypes.ApplyPatchType, data, opts)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update service: %w", err)
+		return nil, fmt.Errorf("failed to apply service: %w", err)
 	}
-	r.logger.WithField("service", service.Name).Info("Updated existing service")
-	return nil
+	r.logger.WithFields(logrus.Fields{
+		"service": service.Name,
+		"created": live == nil,
+	}).Info("Applied service")
+	return drift, nil
 }
 
-// ensureEnvSecret creates or updates a K8s Secret containing secret env vars
-// This ensures sensitive values are not exposed in Pod specs and are stored encrypted in etcd
-func (r *ServiceReconciler) ensureEnvSecret(ctx context.Context, req *ReconcileRequest, namespace, secretName string) error {
-	// Collect secret values
-	secretData := make(map[string][]byte)
+// EnvSecretHashAnnotation is set on a service's pod template to a hash of
+// its env Secret's data, so changing a secret value rolls the pods
+const EnvSecretHashAnnotation = "enclii.dev/env-secret-hash"
 
+// envSecretName is the name of the K8s Secret holding a service's secret env
+// vars. Each environment has its own namespace, so there is one per service
+// and environment.
+func envSecretName(serviceName string) string {
+	return fmt.Sprintf("%s-secrets", serviceName)
+}
+
+// envSecretData returns the env vars of a request that belong in its K8s
+// Secret: the secrets, or every variable when metadata is unavailable
+func envSecretData(req *ReconcileRequest) map[string][]byte {
+	data := make(map[string][]byte)
 	if len(req.EnvVarsWithMeta) > 0 {
 		for _, ev := range req.EnvVarsWithMeta {
 			if ev.IsSecret {
-				secretData[ev.Key] = []byte(ev.Value)
+				data[ev.Key] = []byte(ev.Value)
 			}
 		}
+		return data
+	}
+	for key, value := range req.EnvVars {
+		data[key] = []byte(value)
+	}
+	return data
+}
+
+// envSecretHash returns a fingerprint of a Secret's data. The service ID is
+// mixed in like in hashEnvValue, so the annotation can't be matched against
+// hashes of guessed values from other services.
+func envSecretHash(salt string, data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	h.Write([]byte(salt))
+	for _, key := range keys {
+		h.Write([]byte("\x00" + key + "\x00"))
+		h.Write(data[key])
 	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// secretEnvVar references key of the env Secret secretName
+func secretEnvVar(secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: key,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		},
+	}
+}
+
+// ensureEnvSecret creates or updates a K8s Secret containing secret env vars
+// This ensures sensitive values are not exposed in Pod specs and are stored encrypted in etcd
+func (r *ServiceReconciler) ensureEnvSecret(ctx context.Context, req *ReconcileRequest, namespace, secretName string) error {
+	secretData := envSecretData(req)
+	secretClient := r.k8sClient.Clientset.CoreV1().Secrets(namespace)
 
-	// If no secrets, skip creating the secret
+	// Without secrets, remove the values a previous deployment left behind
 	if len(secretData) == 0 {
+		err := secretClient.Delete(ctx, secretName, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete unused secret: %w", err)
+		}
 		r.logger.WithField("service", req.Service.Name).Debug("No secrets to inject, skipping K8s Secret creation")
 		return nil
 	}
@@ -435,6 +578,7 @@ func (r *ServiceReconciler) ensureEnvSecret(ctx context.Context, req *ReconcileR
 			Annotations: map[string]string{
 				"enclii.dev/deployment-id": req.Deployment.ID.String(),
 				"enclii.dev/updated":       time.Now().Format(time.RFC3339),
+				EnvSecretHashAnnotation:    envSecretHash(req.Service.ID.String(), secretData),
 			},
 		},
 		Type: corev1.SecretTypeOpaque,
@@ -442,8 +586,6 @@ func (r *ServiceReconciler) ensureEnvSecret(ctx context.Context, req *ReconcileR
 	}
 
 	// Apply the secret (create or update)
-	secretClient := r.k8sClient.Clientset.CoreV1().Secrets(namespace)
-
 	existing, err := secretClient.Get(ctx, secretName, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -477,13 +619,74 @@ func (r *ServiceReconciler) ensureEnvSecret(ctx context.Context, req *ReconcileR
 	return nil
 }
 
-func (r *ServiceReconciler) waitForDeploymentReady(ctx context.Context, namespace, name string, timeout time.Duration) (bool, error) {
+// pullSecretName is the name of the pull secret holding a service's project
+// registry credentials
+func pullSecretName(serviceName string) string {
+	return fmt.Sprintf("%s-registry-credentials", serviceName)
+}
+
+// ensurePullSecret creates or updates the kubernetes.io/dockerconfigjson
+// secret of the project's registry credentials. Projects without any keep
+// pulling with the platform's credentials only.
+func (r *ServiceReconciler) ensurePullSecret(ctx context.Context, req *ReconcileRequest, namespace string) error {
+	if len(req.RegistryCredentials) == 0 {
+		return nil
+	}
+
+	config, err := registry.DockerConfigJSON(req.RegistryCredentials)
+	if err != nil {
+		return fmt.Errorf("failed to build docker config: %w", err)
+	}
+
+	secretName := pullSecretName(req.Service.Name)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":                   req.Service.Name,
+				"enclii.dev/service":    req.Service.Name,
+				"enclii.dev/project":    req.Service.ProjectID.String(),
+				"enclii.dev/managed-by": "switchyard",
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: config},
+	}
+
+	secretClient := r.k8sClient.Clientset.CoreV1().Secrets(namespace)
+	existing, err := secretClient.Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get existing pull secret: %w", err)
+		}
+		if _, err := secretClient.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create pull secret: %w", err)
+		}
+		r.logger.WithFields(logrus.Fields{
+			"service":    req.Service.Name,
+			"secret":     secretName,
+			"registries": len(req.RegistryCredentials),
+		}).Info("Created registry pull secret")
+		return nil
+	}
+
+	secret.ResourceVersion = existing.ResourceVersion
+	if _, err := secretClient.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update pull secret: %w", err)
+	}
+	return nil
+}
+
+func (r *ServiceReconciler) waitForDeploymentReady(ctx context.Context, namespace, name string, timeout time.Duration, onRollout func(ready, desired int)) (bool, error) {
 	deploymentClient := r.k8sClient.Clientset.AppsV1().Deployments(namespace)
 	podClient := r.k8sClient.Clientset.CoreV1().Pods(namespace)
 
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	lastReady, lastDesired := -1, -1
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -494,6 +697,14 @@ func (r *ServiceReconciler) waitForDeploymentReady(ctx context.Context, namespac
 				return false, err
 			}
 
+			// Report new pods as they become ready
+			ready := int(min(deployment.Status.ReadyReplicas, deployment.Status.UpdatedReplicas))
+			desired := int(*deployment.Spec.Replicas)
+			if ready != lastReady || desired != lastDesired {
+				onRollout(ready, desired)
+				lastReady, lastDesired = ready, desired
+			}
+
 			// Check if deployment is ready
 			if deployment.Status.ReadyReplicas == *deployment.Spec.Replicas &&
 				deployment.Status.UpdatedReplicas == *deployment.Spec.Replicas {
@@ -617,6 +828,11 @@ func (r *ServiceReconciler) Delete(ctx context.Context, namespace, serviceName s
 		return fmt.Errorf("failed to delete service: %w", err)
 	}
 
+	// Delete the pod disruption budget
+	if err := r.deletePodDisruptionBudget(ctx, namespace, serviceName); err != nil {
+		r.logger.WithError(err).Warn("Failed to delete pod disruption budget")
+	}
+
 	// Delete PVCs associated with this service
 	pvcClient := r.k8sClient.Clientset.CoreV1().PersistentVolumeClaims(namespace)
 	listOptions := metav1.ListOptions{
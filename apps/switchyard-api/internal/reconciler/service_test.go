@@ -1,10 +1,15 @@
 package reconciler
 
 import (
+	"reflect"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 // TestParseContainerPort tests the parseContainerPort function
@@ -301,3 +306,279 @@ func TestPortSourceConstants(t *testing.T) {
 		t.Errorf("PortSourceDefault = %v, want default", PortSourceDefault)
 	}
 }
+
+// TestRuntimeDefaults tests port and probe defaults derived from the detected runtime
+func TestRuntimeDefaults(t *testing.T) {
+	nextjs := &types.ServiceRuntime{Language: "nodejs", Framework: "nextjs", DefaultPort: 3000, HealthPath: "/"}
+
+	if got := runtimeDefaultPort(nil, 4200); got != 4200 {
+		t.Errorf("runtimeDefaultPort(nil) = %d, want 4200", got)
+	}
+	if got := runtimeDefaultPort(nextjs, 4200); got != 3000 {
+		t.Errorf("runtimeDefaultPort(nextjs) = %d, want 3000", got)
+	}
+
+	if got := runtimeHealthCheck(nil, nextjs); got == nil || got.Path != "/" {
+		t.Errorf("runtimeHealthCheck(nil, nextjs) = %+v, want path /", got)
+	}
+
+	configured := &types.HealthCheckConfig{Path: "/healthz"}
+	if got := runtimeHealthCheck(configured, nextjs); got.Path != "/healthz" {
+		t.Errorf("explicit path overridden: got %q", got.Path)
+	}
+
+	partial := &types.HealthCheckConfig{PeriodSeconds: 20}
+	got := runtimeHealthCheck(partial, nextjs)
+	if got.Path != "/" || got.PeriodSeconds != 20 {
+		t.Errorf("runtimeHealthCheck(partial) = %+v, want path / and period 20", got)
+	}
+	if partial.Path != "" {
+		t.Error("runtimeHealthCheck mutated the service config")
+	}
+}
+
+// TestBuildImagePullSecrets tests that project registry credentials add a pull secret
+func TestBuildImagePullSecrets(t *testing.T) {
+	req := &ReconcileRequest{Service: &types.Service{Name: "api"}}
+
+	secrets := buildImagePullSecrets(req)
+	if len(secrets) != 1 || secrets[0].Name != "enclii-registry-credentials" {
+		t.Fatalf("without credentials got %v, want only the platform secret", secrets)
+	}
+
+	req.RegistryCredentials = []db.RegistryAuth{{Registry: "ghcr.io", Username: "bot", Password: "token"}}
+	secrets = buildImagePullSecrets(req)
+	if len(secrets) != 2 || secrets[1].Name != "api-registry-credentials" {
+		t.Errorf("with credentials got %v, want the platform secret and api-registry-credentials", secrets)
+	}
+}
+
+// TestBuildScheduling tests that a service's placement is rendered into its pod spec
+func TestBuildScheduling(t *testing.T) {
+	if buildNodeSelector(nil) != nil || buildTopologySpread(nil, nil) != nil {
+		t.Error("services without scheduling got placement constraints")
+	}
+
+	cfg := &types.SchedulingConfig{
+		NodeSelector: map[string]string{"karpenter.sh/nodepool": "spot"},
+		TopologySpread: []types.TopologySpreadConstraint{
+			{TopologyKey: "topology.kubernetes.io/zone"},
+			{TopologyKey: "kubernetes.io/hostname", MaxSkew: 2, WhenUnsatisfiable: "DoNotSchedule"},
+		},
+	}
+	if got := buildNodeSelector(cfg); got["karpenter.sh/nodepool"] != "spot" {
+		t.Errorf("buildNodeSelector() = %v", got)
+	}
+
+	podLabels := map[string]string{"app": "api"}
+	spread := buildTopologySpread(cfg, podLabels)
+	if len(spread) != 2 {
+		t.Fatalf("buildTopologySpread() returned %d constraints, want 2", len(spread))
+	}
+	if spread[0].MaxSkew != 1 || spread[0].WhenUnsatisfiable != corev1.ScheduleAnyway {
+		t.Errorf("defaults = skew %d %s, want 1 ScheduleAnyway", spread[0].MaxSkew, spread[0].WhenUnsatisfiable)
+	}
+	if spread[1].MaxSkew != 2 || spread[1].WhenUnsatisfiable != corev1.DoNotSchedule {
+		t.Errorf("explicit = skew %d %s", spread[1].MaxSkew, spread[1].WhenUnsatisfiable)
+	}
+	if spread[1].LabelSelector.MatchLabels["app"] != "api" {
+		t.Error("constraints do not select the service's pods")
+	}
+}
+
+func TestGenerateManifestsGPU(t *testing.T) {
+	r := &ServiceReconciler{}
+	req := &ReconcileRequest{
+		Service:    &types.Service{Name: "inference", GPU: &types.GPUConfig{Count: 2, MIGProfile: "1g.5gb"}},
+		Release:    &types.Release{Version: "v1"},
+		Deployment: &types.Deployment{},
+	}
+
+	deployment, _, err := r.generateManifests(req, "enclii-ml-production", "inference-secrets")
+	if err != nil {
+		t.Fatalf("generateManifests() error = %v", err)
+	}
+	pod := deployment.Spec.Template.Spec
+	limit := pod.Containers[0].Resources.Limits[corev1.ResourceName("nvidia.com/mig-1g.5gb")]
+	if limit.Value() != 2 {
+		t.Errorf("MIG limit = %s, want 2", limit.String())
+	}
+	if pod.RuntimeClassName == nil || *pod.RuntimeClassName != "nvidia" {
+		t.Errorf("runtime class = %v, want nvidia", pod.RuntimeClassName)
+	}
+	if len(pod.Tolerations) != 1 || pod.Tolerations[0].Key != "nvidia.com/gpu" {
+		t.Errorf("tolerations = %+v, want the GPU taint tolerated", pod.Tolerations)
+	}
+
+	req.Service.GPU = nil
+	deployment, _, _ = r.generateManifests(req, "enclii-ml-production", "inference-secrets")
+	if pod := deployment.Spec.Template.Spec; pod.RuntimeClassName != nil || len(pod.Tolerations) != 0 {
+		t.Error("a service without GPUs got a GPU runtime class or toleration")
+	}
+}
+
+func TestGenerateManifestsCommand(t *testing.T) {
+	r := &ServiceReconciler{}
+	req := &ReconcileRequest{
+		Service: &types.Service{
+			Name:    "worker",
+			Command: &types.ContainerCommand{Command: []string{"bundle", "exec"}, Args: []string{"sidekiq"}, WorkingDir: "/app"},
+		},
+		Release:    &types.Release{Version: "v1"},
+		Deployment: &types.Deployment{},
+	}
+
+	deployment, _, err := r.generateManifests(req, "enclii-shop-production", "worker-secrets")
+	if err != nil {
+		t.Fatalf("generateManifests() error = %v", err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if !reflect.DeepEqual(container.Command, []string{"bundle", "exec"}) || !reflect.DeepEqual(container.Args, []string{"sidekiq"}) || container.WorkingDir != "/app" {
+		t.Errorf("container runs %v %v in %q, want the service's command", container.Command, container.Args, container.WorkingDir)
+	}
+
+	// The environment's command replaces the service's as a whole
+	req.Command = &types.ContainerCommand{Args: []string{"sidekiq", "-q", "critical"}}
+	deployment, _, _ = r.generateManifests(req, "enclii-shop-production", "worker-secrets")
+	container = deployment.Spec.Template.Spec.Containers[0]
+	if container.Command != nil || !reflect.DeepEqual(container.Args, []string{"sidekiq", "-q", "critical"}) || container.WorkingDir != "" {
+		t.Errorf("container runs %v %v in %q, want the environment's command", container.Command, container.Args, container.WorkingDir)
+	}
+
+	req.Command, req.Service.Command = nil, nil
+	deployment, _, _ = r.generateManifests(req, "enclii-shop-production", "worker-secrets")
+	if container := deployment.Spec.Template.Spec.Containers[0]; container.Command != nil || container.Args != nil {
+		t.Error("a service without a command overrides the image's")
+	}
+}
+
+func TestGenerateManifestsShutdown(t *testing.T) {
+	r := &ServiceReconciler{}
+	grace := int64(90)
+	req := &ReconcileRequest{
+		Service: &types.Service{
+			Name: "api",
+			Shutdown: &types.ShutdownConfig{
+				TerminationGracePeriodSeconds: &grace,
+				PreStop:                       &types.PreStopHook{SleepSeconds: 15},
+				MaxUnavailable:                "0",
+			},
+		},
+		Release:    &types.Release{Version: "v1"},
+		Deployment: &types.Deployment{},
+	}
+
+	deployment, _, err := r.generateManifests(req, "enclii-shop-production", "api-secrets")
+	if err != nil {
+		t.Fatalf("generateManifests() error = %v", err)
+	}
+	pod := deployment.Spec.Template.Spec
+	if *pod.TerminationGracePeriodSeconds != 90 {
+		t.Errorf("termination grace period = %d, want 90", *pod.TerminationGracePeriodSeconds)
+	}
+	if lifecycle := pod.Containers[0].Lifecycle; lifecycle == nil || lifecycle.PreStop.Sleep == nil || lifecycle.PreStop.Sleep.Seconds != 15 {
+		t.Errorf("lifecycle = %+v, want a 15s preStop sleep", lifecycle)
+	}
+	update := deployment.Spec.Strategy.RollingUpdate
+	if update.MaxUnavailable.String() != "0" || update.MaxSurge.String() != "25%" {
+		t.Errorf("rolling update = %s/%s, want 25%%/0", update.MaxSurge.String(), update.MaxUnavailable.String())
+	}
+
+	req.Service.Shutdown = nil
+	deployment, _, _ = r.generateManifests(req, "enclii-shop-production", "api-secrets")
+	if pod := deployment.Spec.Template.Spec; *pod.TerminationGracePeriodSeconds != 30 || pod.Containers[0].Lifecycle != nil {
+		t.Error("a service without shutdown settings did not get the defaults")
+	}
+}
+
+func TestGenerateManifestsHighAvailability(t *testing.T) {
+	r := &ServiceReconciler{}
+	req := &ReconcileRequest{
+		Service:    &types.Service{Name: "api", HighAvailability: true},
+		Release:    &types.Release{Version: "v1"},
+		Deployment: &types.Deployment{Replicas: 3},
+	}
+
+	deployment, _, err := r.generateManifests(req, "enclii-shop-production", "api-secrets")
+	if err != nil {
+		t.Fatalf("generateManifests() error = %v", err)
+	}
+	affinity := deployment.Spec.Template.Spec.Affinity
+	if affinity == nil || len(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 2 {
+		t.Fatalf("affinity = %+v, want preferred anti-affinity across nodes and zones", affinity)
+	}
+	if term := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm; term.TopologyKey != corev1.LabelHostname {
+		t.Errorf("first anti-affinity topology = %s, want %s", term.TopologyKey, corev1.LabelHostname)
+	}
+	pdb := r.generatePodDisruptionBudget(req, "enclii-shop-production")
+	if pdb == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 || pdb.Spec.Selector.MatchLabels["enclii.dev/service"] != "api" {
+		t.Errorf("pdb = %+v, want one pod of api unavailable at a time", pdb)
+	}
+
+	// A single replica gets neither, so node drains are not blocked
+	req.Deployment.Replicas = 1
+	deployment, _, _ = r.generateManifests(req, "enclii-shop-production", "api-secrets")
+	if deployment.Spec.Template.Spec.Affinity != nil || r.generatePodDisruptionBudget(req, "enclii-shop-production") != nil {
+		t.Error("a single replica got anti-affinity or a disruption budget")
+	}
+
+	req.Service.HighAvailability, req.Deployment.Replicas = false, 3
+	if r.generatePodDisruptionBudget(req, "enclii-shop-production") != nil {
+		t.Error("a service that is not highly available got a disruption budget")
+	}
+}
+
+func TestGenerateManifestsEnvSecret(t *testing.T) {
+	r := &ServiceReconciler{}
+	req := &ReconcileRequest{
+		Service:    &types.Service{Name: "api"},
+		Release:    &types.Release{Version: "v1"},
+		Deployment: &types.Deployment{},
+		EnvVarsWithMeta: []EnvVarWithMeta{
+			{Key: "LOG_LEVEL", Value: "info"},
+			{Key: "STRIPE_API_KEY", Value: "sk_live_old", IsSecret: true},
+		},
+	}
+
+	deployment, _, err := r.generateManifests(req, "enclii-shop-production", "api-secrets")
+	if err != nil {
+		t.Fatalf("generateManifests() error = %v", err)
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, ev := range deployment.Spec.Template.Spec.Containers[0].Env {
+		env[ev.Name] = ev
+	}
+	if env["LOG_LEVEL"].Value != "info" {
+		t.Errorf("LOG_LEVEL = %+v, want inline info", env["LOG_LEVEL"])
+	}
+	if ref := env["STRIPE_API_KEY"].ValueFrom; env["STRIPE_API_KEY"].Value != "" || ref == nil || ref.SecretKeyRef.Name != "api-secrets" {
+		t.Errorf("STRIPE_API_KEY = %+v, want a reference to api-secrets", env["STRIPE_API_KEY"])
+	}
+	hash := deployment.Spec.Template.Annotations[EnvSecretHashAnnotation]
+	if hash == "" {
+		t.Fatal("pod template has no env secret hash")
+	}
+
+	// A new secret value changes the pod template, which rolls the pods
+	req.EnvVarsWithMeta[1].Value = "sk_live_new"
+	deployment, _, _ = r.generateManifests(req, "enclii-shop-production", "api-secrets")
+	if deployment.Spec.Template.Annotations[EnvSecretHashAnnotation] == hash {
+		t.Error("env secret hash did not change with the secret value")
+	}
+
+	// Without metadata nothing is inlined
+	req.EnvVarsWithMeta = nil
+	req.EnvVars = map[string]string{"STRIPE_API_KEY": "sk_live_new"}
+	deployment, _, _ = r.generateManifests(req, "enclii-shop-production", "api-secrets")
+	for _, ev := range deployment.Spec.Template.Spec.Containers[0].Env {
+		if ev.Name == "STRIPE_API_KEY" && ev.ValueFrom == nil {
+			t.Error("legacy env var was inlined")
+		}
+	}
+
+	req.EnvVars = nil
+	deployment, _, _ = r.generateManifests(req, "enclii-shop-production", "api-secrets")
+	if _, ok := deployment.Spec.Template.Annotations[EnvSecretHashAnnotation]; ok {
+		t.Error("a service without secrets got an env secret hash")
+	}
+}
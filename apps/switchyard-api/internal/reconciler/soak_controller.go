@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/soak"
+)
+
+// SoakController periodically evaluates soaking deployments, marking their
+// releases stable or rolling them back
+type SoakController struct {
+	monitor  *soak.Monitor
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewSoakController creates a new soak controller
+func NewSoakController(monitor *soak.Monitor, logger *logrus.Logger) *SoakController {
+	return &SoakController{
+		monitor:  monitor,
+		logger:   logger,
+		interval: soak.SyncInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the sync loop
+func (c *SoakController) Start(ctx context.Context) {
+	c.logger.Info("Starting soak controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.monitor.Sync(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.monitor.Sync(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Soak controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Soak controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *SoakController) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,57 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/trash"
+)
+
+// TrashPurgeController periodically purges deleted projects and services
+// whose retention window has passed
+type TrashPurgeController struct {
+	trash    *trash.Service
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewTrashPurgeController creates a new trash purge controller
+func NewTrashPurgeController(trashService *trash.Service, logger *logrus.Logger) *TrashPurgeController {
+	return &TrashPurgeController{
+		trash:    trashService,
+		logger:   logger,
+		interval: trash.PurgeInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the purge loop
+func (c *TrashPurgeController) Start(ctx context.Context) {
+	c.logger.Info("Starting trash purge controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.trash.PurgeExpired(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.trash.PurgeExpired(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Trash purge controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Trash purge controller context cancelled")
+			return
+		}
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *TrashPurgeController) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,62 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/uptime"
+)
+
+// UptimeController periodically runs the due uptime checks of services
+type UptimeController struct {
+	runner   *uptime.Runner
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewUptimeController creates a new uptime controller
+func NewUptimeController(runner *uptime.Runner, logger *logrus.Logger) *UptimeController {
+	return &UptimeController{
+		runner:   runner,
+		logger:   logger,
+		interval: uptime.TickInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the check loop
+func (c *UptimeController) Start(ctx context.Context) {
+	c.logger.Info("Starting uptime controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.run(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.run(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Uptime controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Uptime controller context cancelled")
+			return
+		}
+	}
+}
+
+func (c *UptimeController) run(ctx context.Context) {
+	if err := c.runner.RunDue(ctx); err != nil {
+		c.logger.WithError(err).Error("Failed to run uptime checks")
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *UptimeController) Stop() {
+	close(c.stopCh)
+}
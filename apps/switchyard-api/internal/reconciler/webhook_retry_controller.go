@@ -0,0 +1,63 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+)
+
+// WebhookRetryController periodically retries the webhook deliveries whose
+// next attempt is due
+type WebhookRetryController struct {
+	service  *notifications.Service
+	logger   *logrus.Logger
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewWebhookRetryController creates a new webhook retry controller
+func NewWebhookRetryController(service *notifications.Service, logger *logrus.Logger) *WebhookRetryController {
+	return &WebhookRetryController{
+		service:  service,
+		logger:   logger,
+		interval: notifications.WebhookRetryInterval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the retry loop
+func (c *WebhookRetryController) Start(ctx context.Context) {
+	c.logger.Info("Starting webhook retry controller")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.run(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.run(ctx)
+		case <-c.stopCh:
+			c.logger.Info("Webhook retry controller stopped")
+			return
+		case <-ctx.Done():
+			c.logger.Info("Webhook retry controller context cancelled")
+			return
+		}
+	}
+}
+
+func (c *WebhookRetryController) run(ctx context.Context) {
+	if err := c.service.RetryDue(ctx); err != nil {
+		c.logger.WithError(err).Error("Failed to retry webhook deliveries")
+	}
+}
+
+// Stop gracefully shuts down the controller
+func (c *WebhookRetryController) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,300 @@
+package reconciler
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// WorkClass is the kind of environment reconciliation work deploys to. It
+// decides which work runs first.
+type WorkClass string
+
+const (
+	// WorkClassProduction is work for the production environment, which
+	// always runs before any other
+	WorkClassProduction WorkClass = "production"
+	// WorkClassStandard is work for every other regular environment
+	WorkClassStandard WorkClass = "standard"
+	// WorkClassPreview is work for pull request previews, which run last
+	WorkClassPreview WorkClass = "preview"
+)
+
+// rank orders classes; lower ranks run first
+func (c WorkClass) rank() int {
+	switch c {
+	case WorkClassProduction:
+		return 0
+	case WorkClassPreview:
+		return 2
+	default:
+		return 1
+	}
+}
+
+const (
+	// defaultWorkQueueCapacity is how much work the queue holds before new
+	// work goes to the retry queue
+	defaultWorkQueueCapacity = 100
+
+	// defaultStarvationAge is how long work waits before it is treated as
+	// one class higher, so a busy class cannot hold back the ones below it
+	// forever. Work is never promoted to production.
+	defaultStarvationAge = 2 * time.Minute
+)
+
+// queuedWork is work waiting in the queue
+type queuedWork struct {
+	work       *ReconcileWork
+	lane       *workLane
+	enqueuedAt time.Time
+	index      int // Position in the lane heap
+}
+
+// workLane holds the queued work of one class in one environment, highest
+// priority first, then oldest first
+type workLane struct {
+	key           string
+	class         WorkClass
+	environmentID string
+	items         []*queuedWork
+	lastServed    uint64 // Pop sequence of the last work taken from the lane
+}
+
+func (l *workLane) Len() int { return len(l.items) }
+
+func (l *workLane) Less(i, j int) bool {
+	a, b := l.items[i], l.items[j]
+	if a.work.Priority != b.work.Priority {
+		return a.work.Priority > b.work.Priority
+	}
+	return a.enqueuedAt.Before(b.enqueuedAt)
+}
+
+func (l *workLane) Swap(i, j int) {
+	l.items[i], l.items[j] = l.items[j], l.items[i]
+	l.items[i].index = i
+	l.items[j].index = j
+}
+
+func (l *workLane) Push(x any) {
+	item := x.(*queuedWork)
+	item.index = len(l.items)
+	l.items = append(l.items, item)
+}
+
+func (l *workLane) Pop() any {
+	old := l.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	l.items = old[:n-1]
+	return item
+}
+
+// QueueComposition describes the work waiting in the queue
+type QueueComposition struct {
+	ByClass       map[WorkClass]int `json:"by_class"`
+	ByEnvironment map[string]int    `json:"by_environment"`
+	OldestWait    time.Duration     `json:"oldest_wait"`
+}
+
+// workQueue is the reconciliation work queue. Work is taken by class first,
+// production before standard before preview, with work that waited past the
+// starvation age promoted a class for each age it waited. Environments of
+// the same class take turns, so one environment with a burst of work does
+// not hold back the others. Within an environment, higher priority and
+// then older work goes first. A deployment is queued at most once.
+type workQueue struct {
+	mu            sync.Mutex
+	lanes         map[string]*workLane
+	byDeployment  map[string]*queuedWork
+	size          int
+	capacity      int
+	starvationAge time.Duration
+	served        uint64
+	ready         chan struct{}
+	now           func() time.Time
+}
+
+// newWorkQueue creates a work queue holding up to capacity items
+func newWorkQueue(capacity int, starvationAge time.Duration) *workQueue {
+	return &workQueue{
+		lanes:         make(map[string]*workLane),
+		byDeployment:  make(map[string]*queuedWork),
+		capacity:      capacity,
+		starvationAge: starvationAge,
+		ready:         make(chan struct{}, 1),
+		now:           time.Now,
+	}
+}
+
+// push queues work. Work for a deployment that is already queued raises
+// the queued work's priority, and sets its drift policy, instead. When the queue is full, work is only
+// accepted by evicting work of a lower class, which is returned so the
+// caller can retry it later; otherwise push reports false.
+func (q *workQueue) push(work *ReconcileWork) (accepted bool, evicted *ReconcileWork) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if existing, ok := q.byDeployment[work.DeploymentID]; ok {
+		if work.DriftPolicy != "" {
+			existing.work.DriftPolicy = work.DriftPolicy
+		}
+		if work.Priority > existing.work.Priority {
+			existing.work.Priority = work.Priority
+			heap.Fix(existing.lane, existing.index)
+		}
+		return true, nil
+	}
+
+	if q.size >= q.capacity {
+		victim := q.evictionCandidate(work.Class)
+		if victim == nil {
+			return false, nil
+		}
+		q.remove(victim)
+		evicted = victim.work
+	}
+
+	key := string(work.Class) + "/" + work.EnvironmentID
+	lane, ok := q.lanes[key]
+	if !ok {
+		lane = &workLane{key: key, class: work.Class, environmentID: work.EnvironmentID}
+		q.lanes[key] = lane
+	}
+	item := &queuedWork{work: work, lane: lane, enqueuedAt: q.now()}
+	heap.Push(lane, item)
+	q.byDeployment[work.DeploymentID] = item
+	q.size++
+
+	q.signal()
+	return true, evicted
+}
+
+// evictionCandidate returns the queued work that work of class may evict:
+// the newest lowest-priority work of the lowest class below it
+func (q *workQueue) evictionCandidate(class WorkClass) *queuedWork {
+	var victim *queuedWork
+	for _, lane := range q.lanes {
+		if lane.class.rank() <= class.rank() {
+			continue
+		}
+		for _, item := range lane.items {
+			switch {
+			case victim == nil,
+				item.lane.class.rank() > victim.lane.class.rank(),
+				item.lane.class == victim.lane.class && item.work.Priority < victim.work.Priority,
+				item.lane.class == victim.lane.class && item.work.Priority == victim.work.Priority && item.enqueuedAt.After(victim.enqueuedAt):
+				victim = item
+			}
+		}
+	}
+	return victim
+}
+
+// pop takes the next work to run, or returns nil when the queue is empty
+func (q *workQueue) pop() *ReconcileWork {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	var next *workLane
+	nextRank := 0
+	for _, lane := range q.lanes {
+		rank := q.effectiveRank(lane, now)
+		switch {
+		case next == nil,
+			rank < nextRank,
+			rank == nextRank && lane.lastServed < next.lastServed,
+			rank == nextRank && lane.lastServed == next.lastServed && lane.items[0].enqueuedAt.Before(next.items[0].enqueuedAt):
+			next, nextRank = lane, rank
+		}
+	}
+	if next == nil {
+		return nil
+	}
+
+	item := heap.Pop(next).(*queuedWork)
+	q.served++
+	next.lastServed = q.served
+	if next.Len() == 0 {
+		delete(q.lanes, next.key)
+	}
+	delete(q.byDeployment, item.work.DeploymentID)
+	q.size--
+
+	// Another worker may be waiting for the rest
+	if q.size > 0 {
+		q.signal()
+	}
+	return item.work
+}
+
+// effectiveRank is the rank of a lane's next work after starvation
+// promotion, which stops short of production
+func (q *workQueue) effectiveRank(lane *workLane, now time.Time) int {
+	rank := lane.class.rank()
+	if rank == 0 || q.starvationAge <= 0 {
+		return rank
+	}
+	promotions := int(now.Sub(lane.items[0].enqueuedAt) / q.starvationAge)
+	return max(rank-promotions, 1)
+}
+
+// remove takes queued work out of the queue
+func (q *workQueue) remove(item *queuedWork) {
+	lane := item.lane
+	heap.Remove(lane, item.index)
+	if lane.Len() == 0 {
+		delete(q.lanes, lane.key)
+	}
+	delete(q.byDeployment, item.work.DeploymentID)
+	q.size--
+}
+
+// signal wakes a waiting worker
+func (q *workQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// len returns how much work is queued
+func (q *workQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// free returns how much more work the queue holds
+func (q *workQueue) free() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.capacity - q.size
+}
+
+// composition returns how much work of each class and environment is
+// queued, and how long the oldest has waited
+func (q *workQueue) composition() QueueComposition {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	comp := QueueComposition{
+		ByClass:       make(map[WorkClass]int),
+		ByEnvironment: make(map[string]int),
+	}
+	now := q.now()
+	for _, lane := range q.lanes {
+		comp.ByClass[lane.class] += lane.Len()
+		if lane.environmentID != "" {
+			comp.ByEnvironment[lane.environmentID] += lane.Len()
+		}
+		for _, item := range lane.items {
+			comp.OldestWait = max(comp.OldestWait, now.Sub(item.enqueuedAt))
+		}
+	}
+	return comp
+}
@@ -0,0 +1,138 @@
+package reconciler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// testQueue returns a work queue whose clock only moves when advanced
+func testQueue(capacity int) (*workQueue, func(time.Duration)) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	q := newWorkQueue(capacity, time.Minute)
+	q.now = func() time.Time { return now }
+	return q, func(d time.Duration) { now = now.Add(d) }
+}
+
+func testWork(id string, class WorkClass, env string, priority int) *ReconcileWork {
+	return &ReconcileWork{DeploymentID: id, Class: class, EnvironmentID: env, Priority: priority}
+}
+
+func popAll(q *workQueue) []string {
+	var ids []string
+	for w := q.pop(); w != nil; w = q.pop() {
+		ids = append(ids, w.DeploymentID)
+	}
+	return ids
+}
+
+func TestWorkQueueProductionFirst(t *testing.T) {
+	q, _ := testQueue(10)
+	q.push(testWork("preview", WorkClassPreview, "pr", 50))
+	q.push(testWork("staging", WorkClassStandard, "staging", 50))
+	q.push(testWork("prod", WorkClassProduction, "prod", 0))
+
+	got := fmt.Sprint(popAll(q))
+	if want := "[prod staging preview]"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestWorkQueueEnvironmentFairness(t *testing.T) {
+	q, advance := testQueue(10)
+	for i := 0; i < 3; i++ {
+		q.push(testWork(fmt.Sprintf("a%d", i), WorkClassPreview, "a", 0))
+		advance(time.Second)
+	}
+	q.push(testWork("b0", WorkClassPreview, "b", 0))
+
+	got := fmt.Sprint(popAll(q))
+	if want := "[a0 b0 a1 a2]"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestWorkQueuePriorityWithinEnvironment(t *testing.T) {
+	q, advance := testQueue(10)
+	q.push(testWork("low", WorkClassStandard, "staging", 1))
+	advance(time.Second)
+	q.push(testWork("high", WorkClassStandard, "staging", 5))
+
+	got := fmt.Sprint(popAll(q))
+	if want := "[high low]"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestWorkQueueStarvation(t *testing.T) {
+	q, advance := testQueue(10)
+	q.push(testWork("preview", WorkClassPreview, "pr", 0))
+	advance(90 * time.Second)
+	q.push(testWork("staging", WorkClassStandard, "staging", 0))
+	q.push(testWork("prod", WorkClassProduction, "prod", 0))
+
+	// Promoted to standard, and older than the staging work
+	got := fmt.Sprint(popAll(q))
+	if want := "[prod preview staging]"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestWorkQueueDeduplicates(t *testing.T) {
+	q, _ := testQueue(10)
+	q.push(testWork("d1", WorkClassStandard, "staging", 1))
+	q.push(testWork("d2", WorkClassStandard, "staging", 2))
+	if accepted, _ := q.push(testWork("d1", WorkClassStandard, "staging", 5)); !accepted {
+		t.Fatal("requeued deployment was not accepted")
+	}
+
+	if q.len() != 2 {
+		t.Errorf("len = %d, want 2", q.len())
+	}
+	if got := q.pop().DeploymentID; got != "d1" {
+		t.Errorf("first = %s, want d1 with its raised priority", got)
+	}
+}
+
+func TestWorkQueueFullPreemptsLowerClass(t *testing.T) {
+	q, advance := testQueue(2)
+	q.push(testWork("preview-old", WorkClassPreview, "pr", 0))
+	advance(time.Second)
+	q.push(testWork("preview-new", WorkClassPreview, "pr", 0))
+
+	if accepted, evicted := q.push(testWork("preview-3", WorkClassPreview, "pr", 0)); accepted || evicted != nil {
+		t.Errorf("preview work on a full queue: accepted = %v, evicted = %v", accepted, evicted)
+	}
+
+	accepted, evicted := q.push(testWork("prod", WorkClassProduction, "prod", 0))
+	if !accepted {
+		t.Fatal("production work was not accepted on a full queue")
+	}
+	if evicted == nil || evicted.DeploymentID != "preview-new" {
+		t.Errorf("evicted = %v, want preview-new", evicted)
+	}
+
+	got := fmt.Sprint(popAll(q))
+	if want := "[prod preview-old]"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestWorkQueueComposition(t *testing.T) {
+	q, advance := testQueue(10)
+	q.push(testWork("p1", WorkClassProduction, "prod", 0))
+	advance(30 * time.Second)
+	q.push(testWork("r1", WorkClassPreview, "pr-1", 0))
+	q.push(testWork("r2", WorkClassPreview, "pr-2", 0))
+
+	comp := q.composition()
+	if comp.ByClass[WorkClassProduction] != 1 || comp.ByClass[WorkClassPreview] != 2 {
+		t.Errorf("by class = %v", comp.ByClass)
+	}
+	if comp.ByEnvironment["pr-1"] != 1 || comp.ByEnvironment["prod"] != 1 {
+		t.Errorf("by environment = %v", comp.ByEnvironment)
+	}
+	if comp.OldestWait != 30*time.Second {
+		t.Errorf("oldest wait = %v, want 30s", comp.OldestWait)
+	}
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+// dockerHubConfigKey is the key Docker and Kubernetes look Docker Hub
+// credentials up by
+const dockerHubConfigKey = "https://index.docker.io/v1/"
+
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Auth     string `json:"auth"`
+}
+
+// DockerConfigJSON builds the .dockerconfigjson of a kubernetes.io/dockerconfigjson
+// pull secret, which is also the config.json Docker, Cosign and Grype read
+func DockerConfigJSON(auths []db.RegistryAuth) ([]byte, error) {
+	config := dockerConfig{Auths: make(map[string]dockerAuth, len(auths))}
+	for _, a := range auths {
+		config.Auths[configKey(a.Registry)] = newDockerAuth(a)
+	}
+	return json.Marshal(config)
+}
+
+// MergeDockerConfig adds registry credentials to an existing Docker config,
+// such as the platform's build credentials. Hosts the base config already
+// has keep their credentials unless they are listed in override; base may
+// be empty.
+func MergeDockerConfig(base []byte, auths []db.RegistryAuth, override ...string) ([]byte, error) {
+	config := dockerConfig{}
+	if len(base) > 0 {
+		if err := json.Unmarshal(base, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse docker config: %w", err)
+		}
+	}
+	if config.Auths == nil {
+		config.Auths = make(map[string]dockerAuth, len(auths))
+	}
+
+	replace := make(map[string]bool, len(override))
+	for _, host := range override {
+		replace[host] = true
+	}
+	for _, a := range auths {
+		key := configKey(a.Registry)
+		if _, ok := config.Auths[key]; ok && !replace[a.Registry] {
+			continue
+		}
+		config.Auths[key] = newDockerAuth(a)
+	}
+	return json.Marshal(config)
+}
+
+// configKey is the key of a registry host in the auths of a Docker config
+func configKey(host string) string {
+	if host == DockerHub {
+		return dockerHubConfigKey
+	}
+	return host
+}
+
+func newDockerAuth(a db.RegistryAuth) dockerAuth {
+	return dockerAuth{
+		Username: a.Username,
+		Password: a.Password,
+		Auth:     base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password)),
+	}
+}
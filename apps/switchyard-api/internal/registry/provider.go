@@ -0,0 +1,306 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// TokenRefreshMargin is how long before it expires a token is replaced.
+// Pull secrets refreshed more often than this never hold an expired token.
+const TokenRefreshMargin = 30 * time.Minute
+
+var (
+	ecrHostPattern = regexp.MustCompile(`^[0-9]{12}\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+	gcrHostPattern = regexp.MustCompile(`^([a-z]+\.)?gcr\.io$|^[a-z0-9-]+-docker\.pkg\.dev$`)
+)
+
+// Token is a credential registry clients accept, valid until ExpiresAt;
+// ExpiresAt is zero when it does not expire
+type Token struct {
+	Username  string
+	Password  string
+	ExpiresAt time.Time
+}
+
+// Backend exchanges the stored credential of a registry provider for a
+// Token
+type Backend interface {
+	Token(ctx context.Context, auth db.RegistryAuth) (*Token, error)
+}
+
+// ValidateCredential checks a credential against its provider before it is
+// stored, returning the username to store. GCR credentials take the
+// service account's email as their username.
+func ValidateCredential(provider types.RegistryProvider, host, username, password string) (string, error) {
+	switch provider {
+	case types.RegistryProviderBasic:
+	case types.RegistryProviderECR:
+		if !ecrHostPattern.MatchString(host) {
+			return "", fmt.Errorf("%s is not an ECR registry, expected <account>.dkr.ecr.<region>.amazonaws.com", host)
+		}
+		if !strings.HasPrefix(username, "AKIA") && !strings.HasPrefix(username, "ASIA") {
+			return "", fmt.Errorf("ECR credentials take an AWS access key ID as username and its secret as password")
+		}
+	case types.RegistryProviderGCR:
+		if !gcrHostPattern.MatchString(host) {
+			return "", fmt.Errorf("%s is not a Container Registry or Artifact Registry host", host)
+		}
+		key, err := parseServiceAccountKey(password)
+		if err != nil {
+			return "", err
+		}
+		return key.ClientEmail, nil
+	case types.RegistryProviderHarbor:
+		if !strings.HasPrefix(username, "robot") {
+			return "", fmt.Errorf("Harbor credentials must be a robot account, e.g. robot$project+deployer")
+		}
+	default:
+		return "", fmt.Errorf("unknown registry provider %q, expected basic, ecr, gcr or harbor", provider)
+	}
+
+	if username == "" || strings.Contains(username, ":") {
+		return "", fmt.Errorf("username must be non-empty and cannot contain ':'")
+	}
+	return username, nil
+}
+
+// staticBackend uses the stored credential as it is: basic credentials and
+// Harbor robot accounts, whose registry issues its own short-lived tokens
+// from them
+type staticBackend struct{}
+
+func (staticBackend) Token(_ context.Context, auth db.RegistryAuth) (*Token, error) {
+	return &Token{Username: auth.Username, Password: auth.Password}, nil
+}
+
+// ecrBackend exchanges an AWS access key for an ECR authorization token,
+// valid for 12 hours
+type ecrBackend struct {
+	endpoint   func(region string) string // Optional override for tests
+	signer     *v4.Signer
+	httpClient *http.Client
+}
+
+func (b *ecrBackend) Token(ctx context.Context, auth db.RegistryAuth) (*Token, error) {
+	m := ecrHostPattern.FindStringSubmatch(auth.Registry)
+	if m == nil {
+		return nil, fmt.Errorf("%s is not an ECR registry", auth.Registry)
+	}
+	region := m[2]
+	endpoint := fmt.Sprintf("https://api.ecr.%s.amazonaws.com%s", region, m[3])
+	if b.endpoint != nil {
+		endpoint = b.endpoint(region)
+	}
+
+	body := []byte("{}")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ECR token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken")
+
+	creds := aws.Credentials{AccessKeyID: auth.Username, SecretAccessKey: auth.Password}
+	hash := sha256.Sum256(body)
+	if err := b.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "ecr", region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign ECR token request: %w", err)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ECR token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ECR token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECR returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var out struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+		} `json:"authorizationData"`
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode ECR token response: %w", err)
+	}
+	if len(out.AuthorizationData) == 0 {
+		return nil, fmt.Errorf("ECR returned no authorization token")
+	}
+
+	data := out.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(data.AuthorizationToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ECR authorization token: %w", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return nil, fmt.Errorf("ECR authorization token is malformed")
+	}
+	return &Token{
+		Username:  username,
+		Password:  password,
+		ExpiresAt: time.Unix(int64(data.ExpiresAt), 0),
+	}, nil
+}
+
+// serviceAccountKey is the part of a Google service account JSON key that
+// is read
+type serviceAccountKey struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+func parseServiceAccountKey(password string) (*serviceAccountKey, error) {
+	var key serviceAccountKey
+	if err := json.Unmarshal([]byte(password), &key); err != nil {
+		return nil, fmt.Errorf("GCR credentials take a service account JSON key as password: %w", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, fmt.Errorf("GCR credentials take a service account JSON key as password")
+	}
+	return &key, nil
+}
+
+// gcrBackend exchanges a service account key for an OAuth access token
+// Container Registry and Artifact Registry accept, valid for an hour
+type gcrBackend struct {
+	httpClient *http.Client
+}
+
+func (b *gcrBackend) Token(ctx context.Context, auth db.RegistryAuth) (*Token, error) {
+	key, err := parseServiceAccountKey(auth.Password)
+	if err != nil {
+		return nil, err
+	}
+	tokenURL := key.TokenURI
+	if tokenURL == "" {
+		tokenURL = "https://oauth2.googleapis.com/token"
+	}
+	config := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{"https://www.googleapis.com/auth/cloud-platform"},
+		TokenURL:     tokenURL,
+	}
+
+	token, err := config.TokenSource(context.WithValue(ctx, oauth2.HTTPClient, b.httpClient)).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a Google access token for %s: %w", key.ClientEmail, err)
+	}
+	return &Token{
+		Username:  "oauth2accesstoken",
+		Password:  token.AccessToken,
+		ExpiresAt: token.Expiry,
+	}, nil
+}
+
+// Resolver exchanges registry credentials for tokens through the backend of
+// their provider, reusing tokens until TokenRefreshMargin before they
+// expire
+type Resolver struct {
+	backends map[types.RegistryProvider]Backend
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]*Token
+}
+
+// NewResolver creates a resolver with the backends of every provider
+func NewResolver() *Resolver {
+	httpClient := &http.Client{Timeout: 15 * time.Second}
+	return &Resolver{
+		backends: map[types.RegistryProvider]Backend{
+			types.RegistryProviderBasic:  staticBackend{},
+			types.RegistryProviderHarbor: staticBackend{},
+			types.RegistryProviderECR:    &ecrBackend{signer: v4.NewSigner(), httpClient: httpClient},
+			types.RegistryProviderGCR:    &gcrBackend{httpClient: httpClient},
+		},
+		now:   time.Now,
+		cache: make(map[string]*Token),
+	}
+}
+
+// defaultResolver is shared by the API, reconciler and cleanup so tokens
+// are cached once per process
+var defaultResolver = NewResolver()
+
+// Resolve exchanges credentials for tokens with the shared resolver
+func Resolve(ctx context.Context, auths []db.RegistryAuth) ([]db.RegistryAuth, error) {
+	return defaultResolver.Resolve(ctx, auths)
+}
+
+// Resolve returns the credentials with the username and password of each
+// replaced by a token of its provider
+func (r *Resolver) Resolve(ctx context.Context, auths []db.RegistryAuth) ([]db.RegistryAuth, error) {
+	resolved := make([]db.RegistryAuth, 0, len(auths))
+	for _, auth := range auths {
+		token, err := r.token(ctx, auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get a token for %s: %w", auth.Registry, err)
+		}
+		auth.Username, auth.Password = token.Username, token.Password
+		resolved = append(resolved, auth)
+	}
+	return resolved, nil
+}
+
+func (r *Resolver) token(ctx context.Context, auth db.RegistryAuth) (*Token, error) {
+	provider := auth.Provider
+	if provider == "" {
+		provider = types.RegistryProviderBasic
+	}
+	backend, ok := r.backends[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown registry provider %q", provider)
+	}
+
+	hash := sha256.Sum256([]byte(auth.Password))
+	key := strings.Join([]string{string(provider), auth.Registry, auth.Username, hex.EncodeToString(hash[:])}, "|")
+
+	r.mu.Lock()
+	cached := r.cache[key]
+	r.mu.Unlock()
+	if cached != nil && (cached.ExpiresAt.IsZero() || r.now().Add(TokenRefreshMargin).Before(cached.ExpiresAt)) {
+		return cached, nil
+	}
+
+	token, err := backend.Token(ctx, auth)
+	if err != nil {
+		return nil, err
+	}
+	if !token.ExpiresAt.IsZero() {
+		r.mu.Lock()
+		r.cache[key] = token
+		r.mu.Unlock()
+	}
+	return token, nil
+}
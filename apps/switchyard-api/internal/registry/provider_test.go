@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func serviceAccountJSON(t *testing.T, tokenURI string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: mustMarshalPKCS8(t, key)})
+	data, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "deployer@project.iam.gserviceaccount.com",
+		"private_key":    string(keyPEM),
+		"private_key_id": "key-1",
+		"token_uri":      tokenURI,
+	})
+	return string(data)
+}
+
+func mustMarshalPKCS8(t *testing.T, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestValidateCredential(t *testing.T) {
+	gcrKey := serviceAccountJSON(t, "")
+	tests := []struct {
+		name         string
+		provider     types.RegistryProvider
+		host         string
+		username     string
+		password     string
+		wantUsername string
+		wantErr      bool
+	}{
+		{name: "basic", provider: types.RegistryProviderBasic, host: "ghcr.io", username: "bot", password: "x", wantUsername: "bot"},
+		{name: "basic without username", provider: types.RegistryProviderBasic, host: "ghcr.io", password: "x", wantErr: true},
+		{name: "ecr", provider: types.RegistryProviderECR, host: "123456789012.dkr.ecr.eu-west-1.amazonaws.com", username: "AKIAEXAMPLE", password: "x", wantUsername: "AKIAEXAMPLE"},
+		{name: "ecr on another host", provider: types.RegistryProviderECR, host: "ghcr.io", username: "AKIAEXAMPLE", password: "x", wantErr: true},
+		{name: "ecr without access key", provider: types.RegistryProviderECR, host: "123456789012.dkr.ecr.eu-west-1.amazonaws.com", username: "bot", password: "x", wantErr: true},
+		{name: "gcr takes the key's email", provider: types.RegistryProviderGCR, host: "europe-docker.pkg.dev", password: gcrKey, wantUsername: "deployer@project.iam.gserviceaccount.com"},
+		{name: "gcr without a key", provider: types.RegistryProviderGCR, host: "gcr.io", password: "secret", wantErr: true},
+		{name: "harbor robot", provider: types.RegistryProviderHarbor, host: "harbor.example.com", username: "robot$team+ci", password: "x", wantUsername: "robot$team+ci"},
+		{name: "harbor user", provider: types.RegistryProviderHarbor, host: "harbor.example.com", username: "alice", password: "x", wantErr: true},
+		{name: "unknown provider", provider: "quay", host: "quay.io", username: "bot", password: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username, err := ValidateCredential(tt.provider, tt.host, tt.username, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateCredential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if username != tt.wantUsername {
+				t.Errorf("username = %q, want %q", username, tt.wantUsername)
+			}
+		})
+	}
+}
+
+func TestECRBackendToken(t *testing.T) {
+	expiresAt := time.Now().Add(12 * time.Hour).Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken" {
+			t.Errorf("X-Amz-Target = %q", target)
+		}
+		if auth := r.Header.Get("Authorization"); !strings.Contains(auth, "Credential=AKIAEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/ecr/aws4_request") {
+			t.Errorf("Authorization = %q", auth)
+		}
+		token := base64.StdEncoding.EncodeToString([]byte("AWS:ecr-password"))
+		fmt.Fprintf(w, `{"authorizationData":[{"authorizationToken":%q,"expiresAt":%d}]}`, token, expiresAt.Unix())
+	}))
+	defer server.Close()
+
+	backend := &ecrBackend{
+		endpoint:   func(string) string { return server.URL },
+		signer:     v4.NewSigner(),
+		httpClient: server.Client(),
+	}
+	token, err := backend.Token(context.Background(), db.RegistryAuth{
+		Registry: "123456789012.dkr.ecr.eu-west-1.amazonaws.com",
+		Username: "AKIAEXAMPLE",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.Username != "AWS" || token.Password != "ecr-password" || !token.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Token() = %+v", token)
+	}
+}
+
+func TestGCRBackendToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("assertion") == "" {
+			t.Errorf("token request has no assertion: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"ya29.token","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer server.Close()
+
+	backend := &gcrBackend{httpClient: server.Client()}
+	token, err := backend.Token(context.Background(), db.RegistryAuth{
+		Registry: "gcr.io",
+		Password: serviceAccountJSON(t, server.URL),
+	})
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.Username != "oauth2accesstoken" || token.Password != "ya29.token" {
+		t.Errorf("Token() = %+v", token)
+	}
+	if until := time.Until(token.ExpiresAt); until < 50*time.Minute || until > time.Hour {
+		t.Errorf("token expires in %v, want about an hour", until)
+	}
+}
+
+type countingBackend struct {
+	calls int
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+func (b *countingBackend) Token(_ context.Context, auth db.RegistryAuth) (*Token, error) {
+	b.calls++
+	return &Token{Username: "AWS", Password: fmt.Sprintf("token-%d", b.calls), ExpiresAt: b.now().Add(b.ttl)}, nil
+}
+
+func TestResolverReusesTokensUntilTheyNearExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	backend := &countingBackend{ttl: 12 * time.Hour, now: clock}
+	resolver := &Resolver{
+		backends: map[types.RegistryProvider]Backend{
+			types.RegistryProviderBasic: staticBackend{},
+			types.RegistryProviderECR:   backend,
+		},
+		now:   clock,
+		cache: make(map[string]*Token),
+	}
+	auths := []db.RegistryAuth{
+		{Registry: "ghcr.io", Username: "bot", Password: "pat"},
+		{Registry: "123456789012.dkr.ecr.eu-west-1.amazonaws.com", Provider: types.RegistryProviderECR, Username: "AKIAEXAMPLE", Password: "secret", PushRepository: "123456789012.dkr.ecr.eu-west-1.amazonaws.com/api"},
+	}
+
+	resolve := func() []db.RegistryAuth {
+		t.Helper()
+		resolved, err := resolver.Resolve(context.Background(), auths)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		return resolved
+	}
+
+	first := resolve()
+	if first[0].Username != "bot" || first[0].Password != "pat" {
+		t.Errorf("basic credential = %+v, want it unchanged", first[0])
+	}
+	if first[1].Username != "AWS" || first[1].Password != "token-1" || first[1].PushRepository == "" {
+		t.Errorf("ECR credential = %+v", first[1])
+	}
+
+	now = now.Add(11 * time.Hour)
+	if got := resolve()[1].Password; got != "token-1" || backend.calls != 1 {
+		t.Errorf("after 11h password = %q with %d calls, want the cached token", got, backend.calls)
+	}
+
+	now = now.Add(40 * time.Minute)
+	if got := resolve()[1].Password; got != "token-2" {
+		t.Errorf("near expiry password = %q, want a new token", got)
+	}
+}
@@ -0,0 +1,136 @@
+// Package registry parses container image references and builds the Docker
+// config pods and tools use to authenticate to private registries
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DockerHub is the normalized host of Docker Hub
+const DockerHub = "docker.io"
+
+var (
+	digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	hostPattern   = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?(:[0-9]{1,5})?$`)
+	repoPattern   = regexp.MustCompile(`^[a-z0-9]+([._-]+[a-z0-9]+)*(/[a-z0-9]+([._-]+[a-z0-9]+)*)*$`)
+	tagPattern    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// Reference is a parsed image reference such as ghcr.io/org/api:v1.2.0
+type Reference struct {
+	Host       string // Normalized registry host
+	Repository string
+	Tag        string // Empty when the reference only has a digest
+	Digest     string // sha256:<hex>, empty when the reference follows a tag
+}
+
+// ParseReference parses an image reference. It must name a tag, a digest
+// or both; images without either would silently follow latest.
+func ParseReference(image string) (*Reference, error) {
+	if image == "" || strings.ContainsAny(image, " \t\n") || strings.Contains(image, "://") {
+		return nil, fmt.Errorf("invalid image reference %q", image)
+	}
+	ref := &Reference{Host: DockerHub}
+
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !digestPattern.MatchString(ref.Digest) {
+			return nil, fmt.Errorf("image %q has an invalid digest, expected sha256:<64 hex characters>", image)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+		if !tagPattern.MatchString(ref.Tag) {
+			return nil, fmt.Errorf("image %q has an invalid tag", image)
+		}
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		return nil, fmt.Errorf("image %q has no tag or digest", image)
+	}
+
+	if i := strings.Index(name, "/"); i >= 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			host, err := NormalizeHost(first)
+			if err != nil {
+				return nil, err
+			}
+			ref.Host, name = host, name[i+1:]
+		}
+	}
+	if ref.Host == DockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if !repoPattern.MatchString(name) {
+		return nil, fmt.Errorf("image %q has an invalid repository", image)
+	}
+	ref.Repository = name
+
+	return ref, nil
+}
+
+// WithDigest returns the reference pinned to a digest. The tag is kept for
+// readability; the runtime pulls by digest.
+func (r *Reference) WithDigest(digest string) (*Reference, error) {
+	if !digestPattern.MatchString(digest) {
+		return nil, fmt.Errorf("invalid digest %q, expected sha256:<64 hex characters>", digest)
+	}
+	if r.Digest != "" && r.Digest != digest {
+		return nil, fmt.Errorf("image is already pinned to %s", r.Digest)
+	}
+	pinned := *r
+	pinned.Digest = digest
+	return &pinned, nil
+}
+
+// String returns the reference in the form container runtimes pull
+func (r *Reference) String() string {
+	s := r.Host + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// ParseRepository parses the repository images are pushed under, such as
+// registry.example.com/team. It returns the normalized host and the path.
+func ParseRepository(repository string) (host, path string, err error) {
+	repository = strings.TrimSuffix(strings.TrimSpace(repository), "/")
+	i := strings.Index(repository, "/")
+	if i < 0 || strings.Contains(repository, "://") {
+		return "", "", fmt.Errorf("invalid repository %q, expected <registry>/<path>", repository)
+	}
+	if host, err = NormalizeHost(repository[:i]); err != nil {
+		return "", "", err
+	}
+	path = repository[i+1:]
+	if !repoPattern.MatchString(path) {
+		return "", "", fmt.Errorf("repository %q has an invalid path", repository)
+	}
+	return host, path, nil
+}
+
+// NormalizeHost returns the canonical form of a registry host as given in
+// credentials or image references. The hosts of Docker Hub all become
+// docker.io.
+func NormalizeHost(registry string) (string, error) {
+	host := strings.ToLower(strings.TrimSpace(registry))
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	switch host {
+	case "docker.io", "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return DockerHub, nil
+	}
+	if !hostPattern.MatchString(host) {
+		return "", fmt.Errorf("invalid registry host %q", registry)
+	}
+	return host, nil
+}
@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+		str   string
+	}{
+		{"ghcr.io/madfam/api:v1.2.0", Reference{Host: "ghcr.io", Repository: "madfam/api", Tag: "v1.2.0"}, "ghcr.io/madfam/api:v1.2.0"},
+		{"nginx:1.27", Reference{Host: DockerHub, Repository: "library/nginx", Tag: "1.27"}, "docker.io/library/nginx:1.27"},
+		{"index.docker.io/org/app:latest", Reference{Host: DockerHub, Repository: "org/app", Tag: "latest"}, "docker.io/org/app:latest"},
+		{"localhost:5000/app@" + testDigest, Reference{Host: "localhost:5000", Repository: "app", Digest: testDigest}, "localhost:5000/app@" + testDigest},
+		{"registry.example.com:8443/team/app:1@" + testDigest, Reference{Host: "registry.example.com:8443", Repository: "team/app", Tag: "1", Digest: testDigest}, "registry.example.com:8443/team/app:1@" + testDigest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref, err := ParseReference(tt.image)
+			if err != nil {
+				t.Fatalf("ParseReference(%q) error = %v", tt.image, err)
+			}
+			if *ref != tt.want {
+				t.Errorf("ParseReference(%q) = %+v, want %+v", tt.image, *ref, tt.want)
+			}
+			if got := ref.String(); got != tt.str {
+				t.Errorf("String() = %q, want %q", got, tt.str)
+			}
+		})
+	}
+}
+
+func TestParseReferenceInvalid(t *testing.T) {
+	for _, image := range []string{
+		"",
+		"ghcr.io/madfam/api",
+		"https://ghcr.io/madfam/api:v1",
+		"ghcr.io/madfam/API:v1",
+		"ghcr.io/madfam/api:v1 --privileged",
+		"ghcr.io/madfam/api@sha256:abc",
+		"ghcr.io/madfam/api:-v1",
+	} {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("ParseReference(%q) succeeded, want error", image)
+		}
+	}
+}
+
+func TestWithDigest(t *testing.T) {
+	ref, err := ParseReference("ghcr.io/madfam/api:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := ref.WithDigest(testDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pinned.String(), "ghcr.io/madfam/api:v1@"+testDigest; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if ref.Digest != "" {
+		t.Error("WithDigest changed the original reference")
+	}
+
+	other := "sha256:" + strings.Repeat("f", 64)
+	if _, err := pinned.WithDigest(other); err == nil {
+		t.Error("WithDigest with a different digest succeeded, want error")
+	}
+	if _, err := ref.WithDigest("latest"); err == nil {
+		t.Error("WithDigest with an invalid digest succeeded, want error")
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := map[string]string{
+		"ghcr.io":                             "ghcr.io",
+		"GHCR.IO":                             "ghcr.io",
+		"https://registry.example.com/":       "registry.example.com",
+		"https://index.docker.io/v1/":         DockerHub,
+		"registry-1.docker.io":                DockerHub,
+		"123.dkr.ecr.us-east-1.amazonaws.com": "123.dkr.ecr.us-east-1.amazonaws.com",
+		"localhost:5000":                      "localhost:5000",
+	}
+	for in, want := range tests {
+		got, err := NormalizeHost(in)
+		if err != nil {
+			t.Errorf("NormalizeHost(%q) error = %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("NormalizeHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "not a host", "ghcr.io:port"} {
+		if _, err := NormalizeHost(in); err == nil {
+			t.Errorf("NormalizeHost(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestDockerConfigJSON(t *testing.T) {
+	data, err := DockerConfigJSON([]db.RegistryAuth{
+		{Registry: "ghcr.io", Username: "bot", Password: "s3cret"},
+		{Registry: DockerHub, Username: "hub", Password: "token"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Auths["ghcr.io"]; got.Username != "bot" || got.Password != "s3cret" || got.Auth != "Ym90OnMzY3JldA==" {
+		t.Errorf("ghcr.io auth = %+v", got)
+	}
+	if _, ok := config.Auths[dockerHubConfigKey]; !ok {
+		t.Errorf("Docker Hub auth missing under %q: %s", dockerHubConfigKey, data)
+	}
+}
+
+func TestParseRepository(t *testing.T) {
+	host, path, err := ParseRepository("Registry.Example.com:5000/team/images/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "registry.example.com:5000" || path != "team/images" {
+		t.Errorf("ParseRepository = %q, %q", host, path)
+	}
+
+	for _, in := range []string{"", "ghcr.io", "https://ghcr.io/acme", "ghcr.io/Acme", "ghcr.io/acme:v1"} {
+		if _, _, err := ParseRepository(in); err == nil {
+			t.Errorf("ParseRepository(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestMergeDockerConfig(t *testing.T) {
+	base := []byte(`{"auths":{"ghcr.io":{"auth":"cGxhdGZvcm06dG9rZW4="}}}`)
+	auths := []db.RegistryAuth{
+		{Registry: "ghcr.io", Username: "acme", Password: "secret"},
+		{Registry: "registry.acme.com", Username: "bot", Password: "pw"},
+	}
+
+	data, err := MergeDockerConfig(base, auths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Auths["ghcr.io"]; got.Auth != "cGxhdGZvcm06dG9rZW4=" || got.Username != "" {
+		t.Errorf("platform credential was replaced: %+v", got)
+	}
+	if got := config.Auths["registry.acme.com"]; got.Username != "bot" {
+		t.Errorf("project credential missing: %s", data)
+	}
+
+	data, err = MergeDockerConfig(base, auths, "ghcr.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Auths["ghcr.io"]; got.Username != "acme" {
+		t.Errorf("override did not replace the platform credential: %+v", got)
+	}
+
+	if _, err := MergeDockerConfig([]byte("not json"), auths); err == nil {
+		t.Error("MergeDockerConfig with an invalid base succeeded, want error")
+	}
+}
@@ -0,0 +1,141 @@
+// Package retention applies per-project retention policies within the bounds
+// of the project's plan: it resolves the effective retention of each class of
+// data, previews purge volumes, and purges data past its retention.
+package retention
+
+import (
+	"fmt"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// DataClasses lists the classes of data retention policies cover, in the
+// order they are reported
+var DataClasses = []types.RetentionDataClass{
+	types.RetentionBuildLogs,
+	types.RetentionAccessLogs,
+	types.RetentionAuditLogs,
+	types.RetentionUsageDetail,
+}
+
+// defaultPlan is the plan of projects without a subscription or with an
+// unknown plan
+const defaultPlan = "hobby"
+
+// planBounds are the retention periods each plan allows. Audit entries have a
+// floor so they cannot be purged before compliance reviews see them.
+var planBounds = map[string]map[types.RetentionDataClass]types.RetentionBounds{
+	"hobby": {
+		types.RetentionBuildLogs:   {MinDays: 1, MaxDays: 14, DefaultDays: 7},
+		types.RetentionAccessLogs:  {MinDays: 1, MaxDays: 14, DefaultDays: 7},
+		types.RetentionAuditLogs:   {MinDays: 30, MaxDays: 90, DefaultDays: 90},
+		types.RetentionUsageDetail: {MinDays: 7, MaxDays: 30, DefaultDays: 30},
+	},
+	"pro": {
+		types.RetentionBuildLogs:   {MinDays: 1, MaxDays: 90, DefaultDays: 30},
+		types.RetentionAccessLogs:  {MinDays: 1, MaxDays: 90, DefaultDays: 30},
+		types.RetentionAuditLogs:   {MinDays: 90, MaxDays: 365, DefaultDays: 365},
+		types.RetentionUsageDetail: {MinDays: 7, MaxDays: 180, DefaultDays: 90},
+	},
+	"team": {
+		types.RetentionBuildLogs:   {MinDays: 1, MaxDays: 365, DefaultDays: 90},
+		types.RetentionAccessLogs:  {MinDays: 1, MaxDays: 365, DefaultDays: 90},
+		types.RetentionAuditLogs:   {MinDays: 90, MaxDays: 730, DefaultDays: 365},
+		types.RetentionUsageDetail: {MinDays: 7, MaxDays: 365, DefaultDays: 180},
+	},
+}
+
+// Release history is kept by count rather than age and does not depend on the
+// plan. The floor keeps enough releases around to roll back to.
+const (
+	DefaultKeepReleases = 20
+	MinKeepReleases     = 3
+	MaxKeepReleases     = 1000
+)
+
+// Bounds returns the retention bounds of a plan and the plan they belong to,
+// which is the default plan when planID is unknown
+func Bounds(planID string) (string, map[types.RetentionDataClass]types.RetentionBounds) {
+	if bounds, ok := planBounds[planID]; ok {
+		return planID, bounds
+	}
+	return defaultPlan, planBounds[defaultPlan]
+}
+
+// configuredDays returns the days a policy sets for a class, or nil when it
+// uses the plan default
+func configuredDays(policy *types.RetentionPolicy, class types.RetentionDataClass) *int {
+	if policy == nil {
+		return nil
+	}
+	switch class {
+	case types.RetentionBuildLogs:
+		return policy.BuildLogDays
+	case types.RetentionAccessLogs:
+		return policy.AccessLogDays
+	case types.RetentionAuditLogs:
+		return policy.AuditLogDays
+	case types.RetentionUsageDetail:
+		return policy.UsageDetailDays
+	}
+	return nil
+}
+
+// Validate checks that every period a policy sets is within the plan's bounds
+func Validate(policy *types.RetentionPolicy, bounds map[types.RetentionDataClass]types.RetentionBounds) []string {
+	var problems []string
+	for _, class := range DataClasses {
+		days := configuredDays(policy, class)
+		if days == nil {
+			continue
+		}
+		b := bounds[class]
+		if *days < b.MinDays || *days > b.MaxDays {
+			problems = append(problems, fmt.Sprintf("%s must be between %d and %d days on this plan", class, b.MinDays, b.MaxDays))
+		}
+	}
+	if policy != nil && policy.KeepReleases != nil {
+		if keep := *policy.KeepReleases; keep < MinKeepReleases || keep > MaxKeepReleases {
+			problems = append(problems, fmt.Sprintf("keep_releases must be between %d and %d", MinKeepReleases, MaxKeepReleases))
+		}
+	}
+	return problems
+}
+
+// Effective resolves the days each class is kept: the policy's setting
+// clamped to the plan's bounds, or the plan default. Clamping covers
+// policies set on a plan the project has since left.
+func Effective(policy *types.RetentionPolicy, bounds map[types.RetentionDataClass]types.RetentionBounds) map[types.RetentionDataClass]int {
+	effective := make(map[types.RetentionDataClass]int, len(DataClasses))
+	for _, class := range DataClasses {
+		b := bounds[class]
+		days := b.DefaultDays
+		if configured := configuredDays(policy, class); configured != nil {
+			days = *configured
+		}
+		if days < b.MinDays {
+			days = b.MinDays
+		}
+		if days > b.MaxDays {
+			days = b.MaxDays
+		}
+		effective[class] = days
+	}
+	return effective
+}
+
+// EffectiveKeepReleases returns how many releases of each service a policy
+// keeps
+func EffectiveKeepReleases(policy *types.RetentionPolicy) int {
+	if policy == nil || policy.KeepReleases == nil {
+		return DefaultKeepReleases
+	}
+	keep := *policy.KeepReleases
+	if keep < MinKeepReleases {
+		return MinKeepReleases
+	}
+	if keep > MaxKeepReleases {
+		return MaxKeepReleases
+	}
+	return keep
+}
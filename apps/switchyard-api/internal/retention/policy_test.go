@@ -0,0 +1,106 @@
+package retention
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestBoundsFallsBackToDefaultPlan(t *testing.T) {
+	plan, bounds := Bounds("enterprise-legacy")
+	if plan != defaultPlan {
+		t.Errorf("plan = %q, want %q", plan, defaultPlan)
+	}
+	if bounds[types.RetentionAuditLogs] != planBounds[defaultPlan][types.RetentionAuditLogs] {
+		t.Error("expected the default plan's bounds")
+	}
+
+	if plan, _ := Bounds("pro"); plan != "pro" {
+		t.Errorf("plan = %q, want pro", plan)
+	}
+}
+
+func TestPlansCoverEveryDataClass(t *testing.T) {
+	for plan, bounds := range planBounds {
+		for _, class := range DataClasses {
+			b, ok := bounds[class]
+			if !ok {
+				t.Errorf("plan %s has no bounds for %s", plan, class)
+				continue
+			}
+			if b.MinDays < 1 || b.DefaultDays < b.MinDays || b.DefaultDays > b.MaxDays {
+				t.Errorf("plan %s has inconsistent bounds for %s: %+v", plan, class, b)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	_, bounds := Bounds("hobby")
+
+	tests := []struct {
+		name     string
+		policy   *types.RetentionPolicy
+		problems int
+	}{
+		{"plan defaults", &types.RetentionPolicy{}, 0},
+		{"within bounds", &types.RetentionPolicy{BuildLogDays: intPtr(3), AuditLogDays: intPtr(30)}, 0},
+		{"above plan maximum", &types.RetentionPolicy{BuildLogDays: intPtr(30)}, 1},
+		{"below audit floor", &types.RetentionPolicy{AuditLogDays: intPtr(7)}, 1},
+		{"several problems", &types.RetentionPolicy{AccessLogDays: intPtr(0), UsageDetailDays: intPtr(365)}, 2},
+		{"keep releases", &types.RetentionPolicy{KeepReleases: intPtr(50)}, 0},
+		{"too few releases", &types.RetentionPolicy{KeepReleases: intPtr(1)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Validate(tt.policy, bounds); len(got) != tt.problems {
+				t.Errorf("Validate() = %v, want %d problems", got, tt.problems)
+			}
+		})
+	}
+}
+
+func TestEffective(t *testing.T) {
+	_, bounds := Bounds("hobby")
+
+	// A policy set on a larger plan is clamped after a downgrade
+	policy := &types.RetentionPolicy{BuildLogDays: intPtr(3), AuditLogDays: intPtr(365)}
+	effective := Effective(policy, bounds)
+
+	want := map[types.RetentionDataClass]int{
+		types.RetentionBuildLogs:   3,
+		types.RetentionAccessLogs:  7,
+		types.RetentionAuditLogs:   90,
+		types.RetentionUsageDetail: 30,
+	}
+	for class, days := range want {
+		if effective[class] != days {
+			t.Errorf("effective[%s] = %d, want %d", class, effective[class], days)
+		}
+	}
+
+	if got := Effective(nil, bounds)[types.RetentionAccessLogs]; got != 7 {
+		t.Errorf("nil policy: access logs = %d, want plan default 7", got)
+	}
+}
+
+func TestEffectiveKeepReleases(t *testing.T) {
+	tests := []struct {
+		policy *types.RetentionPolicy
+		want   int
+	}{
+		{nil, DefaultKeepReleases},
+		{&types.RetentionPolicy{}, DefaultKeepReleases},
+		{&types.RetentionPolicy{KeepReleases: intPtr(5)}, 5},
+		{&types.RetentionPolicy{KeepReleases: intPtr(1)}, MinKeepReleases},
+		{&types.RetentionPolicy{KeepReleases: intPtr(5000)}, MaxKeepReleases},
+	}
+	for _, tt := range tests {
+		if got := EffectiveKeepReleases(tt.policy); got != tt.want {
+			t.Errorf("EffectiveKeepReleases(%+v) = %d, want %d", tt.policy, got, tt.want)
+		}
+	}
+}
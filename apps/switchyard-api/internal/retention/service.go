@@ -0,0 +1,322 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/clients"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// PurgeInterval is how often data past its retention is purged
+	PurgeInterval = 6 * time.Hour
+
+	// purgeBatchSize bounds the records deleted per table and statement
+	purgeBatchSize = 1000
+
+	// maxBatchesPerRun bounds a purge run per project and class, so a large
+	// backlog is worked off over several runs
+	maxBatchesPerRun = 50
+
+	// MaxPreviewWindowDays caps how far ahead purge volumes are previewed
+	MaxPreviewWindowDays = 90
+
+	// pruneBatchSize bounds the releases pruned per service and batch; it
+	// stays within what Roundhouse collects per request
+	pruneBatchSize = 100
+)
+
+// ImageCollector deletes the images of pruned releases from the registry
+type ImageCollector interface {
+	CollectImages(ctx context.Context, images []string) (*clients.ImageGCResult, error)
+}
+
+// Service resolves, previews, and enforces project retention policies
+type Service struct {
+	repos  *db.Repositories
+	images ImageCollector
+	logger *logrus.Logger
+}
+
+// NewService creates a retention service
+func NewService(repos *db.Repositories, logger *logrus.Logger) *Service {
+	return &Service{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+// SetImageCollector sets what deletes the images of pruned releases.
+// Without one, releases are pruned and their images left in the registry.
+func (s *Service) SetImageCollector(images ImageCollector) {
+	s.images = images
+}
+
+// Get returns the configured and effective retention of a project
+func (s *Service) Get(ctx context.Context, projectID uuid.UUID) (*types.ProjectRetention, error) {
+	planID, err := s.repos.Retention.GetPlanID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	plan, bounds := Bounds(planID)
+
+	policy, err := s.repos.Retention.GetPolicy(ctx, projectID)
+	if err == sql.ErrNoRows {
+		policy = &types.RetentionPolicy{ProjectID: projectID}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &types.ProjectRetention{
+		ProjectID:             projectID,
+		Plan:                  plan,
+		Policy:                *policy,
+		Effective:             Effective(policy, bounds),
+		EffectiveKeepReleases: EffectiveKeepReleases(policy),
+		Bounds:                bounds,
+	}, nil
+}
+
+// Update replaces the retention policy of a project. It returns the problems
+// with the policy instead of saving it when it is outside the plan's bounds.
+func (s *Service) Update(ctx context.Context, policy *types.RetentionPolicy) (*types.ProjectRetention, []string, error) {
+	planID, err := s.repos.Retention.GetPlanID(ctx, policy.ProjectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, bounds := Bounds(planID)
+
+	if problems := Validate(policy, bounds); len(problems) > 0 {
+		return nil, problems, nil
+	}
+	if err := s.repos.Retention.UpsertPolicy(ctx, policy); err != nil {
+		return nil, nil, err
+	}
+
+	retention, err := s.Get(ctx, policy.ProjectID)
+	return retention, nil, err
+}
+
+// Preview counts the records of a project the next purge run deletes, and
+// those deleted by the end of a window of days from now
+func (s *Service) Preview(ctx context.Context, projectID uuid.UUID, windowDays int) (*types.RetentionPurgePreview, error) {
+	retention, err := s.Get(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	preview := &types.RetentionPurgePreview{
+		ProjectID:   projectID,
+		WindowDays:  windowDays,
+		GeneratedAt: now,
+	}
+	for _, class := range DataClasses {
+		days := retention.Effective[class]
+		cutoff := now.AddDate(0, 0, -days)
+
+		nextRun, err := s.repos.Retention.CountBefore(ctx, class, projectID, cutoff)
+		if err != nil {
+			return nil, err
+		}
+		withinWindow, err := s.repos.Retention.CountBefore(ctx, class, projectID, cutoff.AddDate(0, 0, windowDays))
+		if err != nil {
+			return nil, err
+		}
+
+		preview.Volumes = append(preview.Volumes, types.RetentionPurgeVolume{
+			DataClass:     class,
+			RetentionDays: days,
+			Cutoff:        cutoff,
+			NextRun:       nextRun,
+			WithinWindow:  withinWindow,
+		})
+	}
+
+	preview.PrunableReleases, err = s.repos.Releases.CountPrunable(ctx, projectID, retention.EffectiveKeepReleases)
+	if err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// PurgeExpired deletes the data of every project past its effective retention
+func (s *Service) PurgeExpired(ctx context.Context) {
+	projects, err := s.repos.Projects.List()
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list projects for retention purge")
+		return
+	}
+
+	for _, project := range projects {
+		if ctx.Err() != nil {
+			return
+		}
+		s.purgeProject(ctx, project)
+	}
+}
+
+// purgeProject deletes the data of one project past its effective retention
+func (s *Service) purgeProject(ctx context.Context, project *types.Project) {
+	retention, err := s.Get(ctx, project.ID)
+	if err != nil {
+		s.logger.WithError(err).WithField("project", project.Slug).Warn("Failed to resolve retention policy")
+		return
+	}
+
+	now := time.Now()
+	for _, class := range DataClasses {
+		days := retention.Effective[class]
+		cutoff := now.AddDate(0, 0, -days)
+
+		var purged int64
+		for batch := 0; batch < maxBatchesPerRun; batch++ {
+			deleted, err := s.repos.Retention.DeleteBefore(ctx, class, project.ID, cutoff, purgeBatchSize)
+			purged += deleted
+			if err != nil {
+				s.logger.WithError(err).WithFields(logrus.Fields{
+					"project":    project.Slug,
+					"data_class": class,
+				}).Warn("Failed to purge data past its retention")
+				break
+			}
+			if deleted < purgeBatchSize {
+				break
+			}
+		}
+
+		if purged > 0 {
+			s.logger.WithFields(logrus.Fields{
+				"project":        project.Slug,
+				"data_class":     class,
+				"retention_days": days,
+				"count":          purged,
+			}).Info("Purged data past its retention")
+		}
+	}
+
+	s.pruneReleases(ctx, project, retention.EffectiveKeepReleases)
+}
+
+// pruneReleases prunes the releases of each service of a project beyond the
+// newest keep
+func (s *Service) pruneReleases(ctx context.Context, project *types.Project, keep int) {
+	services, err := s.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		s.logger.WithError(err).WithField("project", project.Slug).Warn("Failed to list services for release pruning")
+		return
+	}
+
+	for _, service := range services {
+		logger := s.logger.WithFields(logrus.Fields{
+			"project": project.Slug,
+			"service": service.Name,
+		})
+
+		var pruned, deployments int64
+		for batch := 0; batch < maxBatchesPerRun && ctx.Err() == nil; batch++ {
+			releases, err := s.repos.Releases.ListPrunable(ctx, service.ID, keep, pruneBatchSize)
+			if err != nil {
+				logger.WithError(err).Warn("Failed to list prunable releases")
+				break
+			}
+			if len(releases) == 0 {
+				break
+			}
+
+			ids, err := s.collectImages(ctx, releases)
+			if err != nil {
+				logger.WithError(err).Warn("Failed to delete images of pruned releases")
+			}
+			if len(ids) > 0 {
+				deleted, err := s.repos.Releases.MarkPruned(ctx, ids)
+				if err != nil {
+					logger.WithError(err).Warn("Failed to mark releases pruned")
+					break
+				}
+				pruned += int64(len(ids))
+				deployments += deleted
+			}
+			// Releases held back by a failed image deletion are retried next run
+			if len(ids) < len(releases) || len(releases) < pruneBatchSize {
+				break
+			}
+		}
+
+		if pruned > 0 {
+			logger.WithFields(logrus.Fields{
+				"keep_releases": keep,
+				"releases":      pruned,
+				"deployments":   deployments,
+			}).Info("Pruned releases beyond their retention")
+		}
+	}
+}
+
+// collectImages deletes the images of releases being pruned that no other
+// release still uses, and returns the releases that can be marked pruned:
+// all but those whose image could not be deleted. Images of releases
+// deploying an existing image are not built by the platform and are kept.
+func (s *Service) collectImages(ctx context.Context, releases []*types.Release) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(releases))
+	for _, release := range releases {
+		ids = append(ids, release.ID)
+	}
+	if s.images == nil {
+		return ids, nil
+	}
+
+	seen := make(map[string]bool)
+	var images []string
+	for _, release := range releases {
+		if release.Source == types.ReleaseSourceBuild && release.ImageURI != "" && !seen[release.ImageURI] {
+			seen[release.ImageURI] = true
+			images = append(images, release.ImageURI)
+		}
+	}
+	if len(images) == 0 {
+		return ids, nil
+	}
+
+	inUse, err := s.repos.Releases.ImagesInUse(ctx, images, ids)
+	if err != nil {
+		return nil, err
+	}
+	var unused []string
+	for _, image := range images {
+		if !inUse[image] {
+			unused = append(unused, image)
+		}
+	}
+	if len(unused) == 0 {
+		return ids, nil
+	}
+
+	result, err := s.images.CollectImages(ctx, unused)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Failed) == 0 {
+		return ids, nil
+	}
+
+	failed := make(map[string]bool, len(result.Failed))
+	for _, failure := range result.Failed {
+		failed[failure.Image] = true
+	}
+	kept := ids[:0]
+	for _, release := range releases {
+		if !failed[release.ImageURI] {
+			kept = append(kept, release.ID)
+		}
+	}
+	return kept, fmt.Errorf("%d of %d images could not be deleted, first: %s", len(result.Failed), len(unused), result.Failed[0].Error)
+}
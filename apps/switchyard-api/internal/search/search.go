@@ -0,0 +1,229 @@
+// Package search ranks the resources matching an org-wide search. Queries
+// match fuzzily: the characters of the query must appear in order in a
+// field, and exact, prefix and word matches rank above scattered ones, so a
+// command palette can jump to a project, service, deployment or domain from
+// a few keystrokes.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// MaxQueryLength is the longest query searched
+const MaxQueryLength = 100
+
+// fieldWeights scale the score of a match by the field it is in, so names
+// outrank repos and images
+var fieldWeights = map[string]float64{
+	"name":      1.0,
+	"slug":      1.0,
+	"domain":    1.0,
+	"version":   0.9,
+	"git_repo":  0.8,
+	"image_uri": 0.8,
+}
+
+// typeOrder breaks ties between results of different types
+var typeOrder = map[types.SearchResultType]int{
+	types.SearchResultProject:    0,
+	types.SearchResultService:    1,
+	types.SearchResultDomain:     2,
+	types.SearchResultDeployment: 3,
+}
+
+// Pattern is the ILIKE pattern of the values a query may match: its
+// characters in order, with anything between them
+func Pattern(query string) string {
+	var sb strings.Builder
+	sb.WriteByte('%')
+	for _, r := range strings.ToLower(query) {
+		if r == '%' || r == '_' || r == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+		sb.WriteByte('%')
+	}
+	return sb.String()
+}
+
+// Score rates how well a value matches a query, from 0 (no match) to 1 (the
+// same, ignoring case). Prefixes rank above matches at the start of a word,
+// which rank above other substrings, which rank above characters scattered
+// through the value; shorter values rank above longer ones.
+func Score(query, value string) float64 {
+	q, v := strings.ToLower(query), strings.ToLower(value)
+	if q == "" || v == "" {
+		return 0
+	}
+	if q == v {
+		return 1
+	}
+
+	// Closeness in length separates matches of the same kind
+	closeness := 0.09 * float64(utf8.RuneCountInString(q)) / float64(utf8.RuneCountInString(v))
+	if strings.HasPrefix(v, q) {
+		return 0.9 + closeness
+	}
+	if i := strings.Index(v, q); i >= 0 {
+		if wordStart(v, i) {
+			return 0.8 + closeness
+		}
+		return 0.7 + closeness
+	}
+
+	span, ok := subsequenceSpan(q, v)
+	if !ok {
+		return 0
+	}
+	// The tighter the characters, the better the match
+	return 0.3 + 0.3*float64(utf8.RuneCountInString(q))/float64(span)
+}
+
+// wordStart reports whether byte offset i of s begins a word
+func wordStart(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	return strings.ContainsRune(" -_./:@", rune(s[i-1]))
+}
+
+// subsequenceSpan finds the characters of q in order in v, as early as
+// possible, and returns the number of characters of v from the first to the
+// last of them
+func subsequenceSpan(q, v string) (int, bool) {
+	qr := []rune(q)
+	start, matched := -1, 0
+	pos := 0
+	for _, r := range v {
+		if r == qr[matched] {
+			if start < 0 {
+				start = pos
+			}
+			matched++
+			if matched == len(qr) {
+				return pos - start + 1, true
+			}
+		}
+		pos++
+	}
+	return 0, false
+}
+
+// Grant is a project, or one environment of it, that a caller may see
+type Grant struct {
+	ProjectID     uuid.UUID
+	EnvironmentID *uuid.UUID // nil for the whole project
+}
+
+// Access limits results to what a caller may see. A nil Access sees
+// everything; otherwise a result needs a grant for its project, and results
+// in an environment need a grant for the project or that environment.
+type Access []Grant
+
+// ProjectIDs returns the projects the access covers
+func (a Access) ProjectIDs() []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(a))
+	ids := make([]uuid.UUID, 0, len(a))
+	for _, grant := range a {
+		if !seen[grant.ProjectID] {
+			seen[grant.ProjectID] = true
+			ids = append(ids, grant.ProjectID)
+		}
+	}
+	return ids
+}
+
+// Allows reports whether a result in a project, and maybe an environment of
+// it, may be seen
+func (a Access) Allows(projectID uuid.UUID, environmentID *uuid.UUID) bool {
+	if a == nil {
+		return true
+	}
+	for _, grant := range a {
+		if grant.ProjectID != projectID {
+			continue
+		}
+		if environmentID == nil || grant.EnvironmentID == nil || *grant.EnvironmentID == *environmentID {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersect returns the access allowed by both a and other
+func (a Access) Intersect(other Access) Access {
+	if a == nil {
+		return other
+	}
+	if other == nil {
+		return a
+	}
+	both := Access{}
+	for _, grant := range a {
+		for _, o := range other {
+			if grant.ProjectID != o.ProjectID {
+				continue
+			}
+			switch {
+			case grant.EnvironmentID == nil:
+				both = append(both, o)
+			case o.EnvironmentID == nil || *o.EnvironmentID == *grant.EnvironmentID:
+				both = append(both, grant)
+			}
+		}
+	}
+	return both
+}
+
+// Rank scores candidates against a query by their best matching field,
+// drops those that do not match, are not allowed by access or are not of
+// one of types (any type when empty), and returns the best limit, best
+// first
+func Rank(query string, candidates []*db.SearchCandidate, access Access, only []types.SearchResultType, limit int) []types.SearchResult {
+	wanted := make(map[types.SearchResultType]bool, len(only))
+	for _, t := range only {
+		wanted[t] = true
+	}
+
+	results := []types.SearchResult{}
+	for _, candidate := range candidates {
+		result := candidate.Result
+		if len(wanted) > 0 && !wanted[result.Type] {
+			continue
+		}
+		if !access.Allows(result.ProjectID, candidate.EnvironmentID) {
+			continue
+		}
+		for field, value := range candidate.Fields {
+			score := Score(query, value) * fieldWeights[field]
+			if score > result.Score || (score == result.Score && score > 0 && field < result.MatchedField) {
+				result.Score, result.MatchedField, result.Matched = score, field, value
+			}
+		}
+		if result.Score > 0 {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if typeOrder[a.Type] != typeOrder[b.Type] {
+			return typeOrder[a.Type] < typeOrder[b.Type]
+		}
+		return a.Title < b.Title
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
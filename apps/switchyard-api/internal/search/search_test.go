@@ -0,0 +1,126 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestPattern(t *testing.T) {
+	tests := map[string]string{
+		"api":   "%a%p%i%",
+		"API":   "%a%p%i%",
+		"50%_x": "%5%0%\\%%\\_%x%",
+	}
+	for query, want := range tests {
+		if got := Pattern(query); got != want {
+			t.Errorf("Pattern(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestScoreOrdering(t *testing.T) {
+	// Each value matches "api" worse than the one before it
+	values := []string{
+		"api",
+		"api-gateway",
+		"billing-api",
+		"rapid",
+		"a-p-i",
+		"analytics-pipeline",
+	}
+	prev := 2.0
+	for _, value := range values {
+		score := Score("api", value)
+		if score <= 0 || score >= prev {
+			t.Errorf("Score(api, %q) = %v, want between 0 and %v", value, score, prev)
+		}
+		prev = score
+	}
+
+	if score := Score("api", "worker"); score != 0 {
+		t.Errorf("Score(api, worker) = %v, want 0", score)
+	}
+	if score := Score("pia", "api"); score != 0 {
+		t.Errorf("Score(pia, api) = %v, want 0 for characters out of order", score)
+	}
+}
+
+func TestAccess(t *testing.T) {
+	project, other := uuid.New(), uuid.New()
+	prod, staging := uuid.New(), uuid.New()
+
+	whole := Access{{ProjectID: project}}
+	prodOnly := Access{{ProjectID: project, EnvironmentID: &prod}}
+
+	if !Access(nil).Allows(other, nil) {
+		t.Error("nil access should allow everything")
+	}
+	if !whole.Allows(project, &staging) || whole.Allows(other, nil) {
+		t.Error("project grant should cover its environments and no other project")
+	}
+	if !prodOnly.Allows(project, nil) || !prodOnly.Allows(project, &prod) || prodOnly.Allows(project, &staging) {
+		t.Error("environment grant should cover the project and that environment only")
+	}
+
+	both := whole.Intersect(prodOnly)
+	if !both.Allows(project, &prod) || both.Allows(project, &staging) {
+		t.Errorf("intersection should narrow to the environment grant, got %+v", both)
+	}
+	if got := Access(nil).Intersect(whole); len(got) != 1 {
+		t.Errorf("nil access intersected with a grant should be the grant, got %+v", got)
+	}
+	if got := whole.Intersect(Access{{ProjectID: other}}); got == nil || got.Allows(project, nil) {
+		t.Errorf("disjoint grants should allow nothing, got %+v", got)
+	}
+}
+
+func TestRank(t *testing.T) {
+	project, hidden := uuid.New(), uuid.New()
+	candidate := func(typ types.SearchResultType, projectID uuid.UUID, title string, fields map[string]string) *db.SearchCandidate {
+		return &db.SearchCandidate{
+			Result: types.SearchResult{Type: typ, ID: uuid.New(), Title: title, ProjectID: projectID},
+			Fields: fields,
+		}
+	}
+	candidates := []*db.SearchCandidate{
+		candidate(types.SearchResultService, project, "worker", map[string]string{"name": "worker", "git_repo": "github.com/acme/api"}),
+		candidate(types.SearchResultService, project, "api", map[string]string{"name": "api", "git_repo": "github.com/acme/api"}),
+		candidate(types.SearchResultDomain, project, "api.acme.com", map[string]string{"domain": "api.acme.com"}),
+		candidate(types.SearchResultProject, hidden, "api", map[string]string{"name": "api", "slug": "api"}),
+		candidate(types.SearchResultDeployment, project, "web@v1", map[string]string{"version": "v1", "image_uri": "ghcr.io/acme/web:v1"}),
+	}
+	access := Access{{ProjectID: project}}
+
+	results := Rank("api", candidates, access, nil, 10)
+	var titles []string
+	for _, r := range results {
+		titles = append(titles, r.Title)
+	}
+	want := []string{"api", "api.acme.com", "worker"}
+	if len(titles) != len(want) {
+		t.Fatalf("Rank = %v, want %v", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Fatalf("Rank = %v, want %v", titles, want)
+		}
+	}
+	if results[0].MatchedField != "name" || results[0].Score != 1 {
+		t.Errorf("exact name match = %s %v, want name 1", results[0].MatchedField, results[0].Score)
+	}
+	if results[2].MatchedField != "git_repo" {
+		t.Errorf("worker matched on %s, want git_repo", results[2].MatchedField)
+	}
+
+	domains := Rank("api", candidates, access, []types.SearchResultType{types.SearchResultDomain}, 10)
+	if len(domains) != 1 || domains[0].Type != types.SearchResultDomain {
+		t.Errorf("type filter returned %+v", domains)
+	}
+	if limited := Rank("api", candidates, nil, nil, 2); len(limited) != 2 {
+		t.Errorf("limit returned %d results, want 2", len(limited))
+	}
+}
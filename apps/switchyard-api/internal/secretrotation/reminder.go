@@ -0,0 +1,133 @@
+// Package secretrotation reminds projects to rotate secret env vars. A
+// rotatable secret with a max age gets a secret.rotation_due event once its
+// value is older than that, repeated weekly until it is rotated. Secrets
+// with a rotation in progress are not reminded of.
+package secretrotation
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// CheckInterval is how often secrets are checked against their max age
+	CheckInterval = time.Hour
+
+	// ReminderRepeat is how often the reminder of a secret is sent again
+	// while it stays overdue
+	ReminderRepeat = 7 * 24 * time.Hour
+
+	// MaxMaxAgeDays caps the max age of a secret
+	MaxMaxAgeDays = 3650
+)
+
+// EventSender delivers secret events to a project's webhooks
+type EventSender interface {
+	SendEvent(ctx context.Context, projectID uuid.UUID, event *types.WebhookEvent) error
+}
+
+// Reminder sends the reminders of secrets that are due for rotation
+type Reminder struct {
+	repos  *db.Repositories
+	events EventSender
+	logger *logrus.Logger
+}
+
+// NewReminder creates a reminder
+func NewReminder(repos *db.Repositories, logger *logrus.Logger) *Reminder {
+	return &Reminder{repos: repos, logger: logger}
+}
+
+// SetEventSender sets where reminders are sent. Without one, overdue
+// secrets are only marked reminded.
+func (r *Reminder) SetEventSender(events EventSender) {
+	r.events = events
+}
+
+// AgeDays returns how many whole days old a value set at since is
+func AgeDays(since, now time.Time) int {
+	if now.Before(since) {
+		return 0
+	}
+	return int(now.Sub(since) / (24 * time.Hour))
+}
+
+// SendDue sends a reminder for every secret older than its max age
+func (r *Reminder) SendDue(ctx context.Context) {
+	now := time.Now()
+	due, err := r.repos.EnvVars.ListRotationsDue(ctx, now, ReminderRepeat)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list secrets due for rotation")
+		return
+	}
+
+	for _, rotation := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		// Recorded before sending, so a failed delivery is not retried every check
+		if err := r.repos.EnvVars.MarkRotationReminded(ctx, rotation.EnvVarID); err != nil {
+			r.logger.WithError(err).WithField("env_var_id", rotation.EnvVarID).Error("Failed to record secret rotation reminder")
+			continue
+		}
+		r.notify(ctx, rotation, now)
+	}
+}
+
+// notify sends the reminder of a secret to its project's webhooks
+func (r *Reminder) notify(ctx context.Context, rotation *types.EnvVarRotation, now time.Time) {
+	if r.events == nil || rotation.MaxAgeDays == nil {
+		return
+	}
+	logger := r.logger.WithField("env_var_id", rotation.EnvVarID)
+
+	service, err := r.repos.Services.GetByID(rotation.ServiceID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get service for secret rotation reminder")
+		return
+	}
+	project, err := r.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get project for secret rotation reminder")
+		return
+	}
+	environment := "all"
+	if rotation.EnvironmentID != nil {
+		env, err := r.repos.Environments.GetByID(ctx, *rotation.EnvironmentID)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to get environment for secret rotation reminder")
+			return
+		}
+		environment = env.Name
+	}
+
+	event := &types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      types.WebhookEventSecretRotationDue,
+		Timestamp: now,
+		ProjectID: project.ID,
+		Project: types.WebhookProjectInfo{
+			ID:   project.ID,
+			Name: project.Name,
+			Slug: project.Slug,
+		},
+		Secret: &types.WebhookSecretInfo{
+			EnvVarID:    rotation.EnvVarID,
+			ServiceName: service.Name,
+			Environment: environment,
+			Key:         rotation.Key,
+			AgeDays:     AgeDays(rotation.ValueSince, now),
+			MaxAgeDays:  *rotation.MaxAgeDays,
+			ValueSince:  rotation.ValueSince,
+		},
+	}
+	if err := r.events.SendEvent(ctx, project.ID, event); err != nil {
+		logger.WithError(err).Warn("Failed to send secret rotation reminder")
+	}
+}
@@ -0,0 +1,26 @@
+package secretrotation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgeDays(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		since time.Time
+		want  int
+	}{
+		{now, 0},
+		{now.Add(-23 * time.Hour), 0},
+		{now.Add(-24 * time.Hour), 1},
+		{now.AddDate(0, 0, -90).Add(-time.Minute), 90},
+		{now.Add(time.Hour), 0},
+	}
+	for _, tt := range tests {
+		if got := AgeDays(tt.since, now); got != tt.want {
+			t.Errorf("AgeDays(%v) = %d, want %d", tt.since, got, tt.want)
+		}
+	}
+}
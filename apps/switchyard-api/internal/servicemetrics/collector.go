@@ -0,0 +1,252 @@
+// Package servicemetrics reports the resource usage and traffic of a
+// service over a time window, for the dashboard and the CLI.
+package servicemetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/soak"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// Series names and their units
+const (
+	SeriesCPU         = "cpu"          // millicores
+	SeriesMemory      = "memory"       // bytes
+	SeriesRestarts    = "restarts"     // count
+	SeriesRequestRate = "request_rate" // requests_per_second
+	SeriesErrorRate   = "error_rate"   // ratio
+)
+
+// Report sources
+const (
+	SourcePrometheus    = "prometheus"
+	SourceMetricsServer = "metrics-server"
+)
+
+// Window limits
+const (
+	DefaultWindow = time.Hour
+	MinWindow     = 5 * time.Minute
+	MaxWindow     = 7 * 24 * time.Hour
+
+	// pointsPerWindow is roughly how many points a series has
+	pointsPerWindow = 120
+	// minStep is the shortest step, about one scrape interval
+	minStep = 15 * time.Second
+	// minRateInterval is the shortest interval rates are taken over, so
+	// every rate spans a few scrapes
+	minRateInterval = 2 * time.Minute
+)
+
+// ErrUnavailable is returned when neither Prometheus nor the cluster is configured
+var ErrUnavailable = errors.New("service metrics are not available")
+
+// podsOfApp joins a per-pod expression to the pods labelled with the
+// service's app label, using kube-state-metrics
+const podsOfApp = ` * on(namespace, pod) group_left() max by (namespace, pod) (kube_pod_labels{namespace="$namespace",label_app="$app"})`
+
+// seriesQuery is the PromQL of one series. $namespace, $app and $rate are
+// replaced before the query runs.
+type seriesQuery struct {
+	name  string
+	unit  string
+	query string
+}
+
+var resourceQueries = []seriesQuery{
+	{SeriesCPU, "millicores", `sum(rate(container_cpu_usage_seconds_total{namespace="$namespace",container!="",container!="POD"}[$rate])` + podsOfApp + `) * 1000`},
+	{SeriesMemory, "bytes", `sum(container_memory_working_set_bytes{namespace="$namespace",container!="",container!="POD"}` + podsOfApp + `)`},
+	{SeriesRestarts, "count", `sum(kube_pod_container_status_restarts_total{namespace="$namespace"}` + podsOfApp + `)`},
+	{SeriesRequestRate, "requests_per_second", `sum(rate(nginx_ingress_controller_requests{exported_namespace="$namespace",exported_service="$app"}[$rate]))`},
+}
+
+// Target identifies the pods of one service
+type Target struct {
+	Namespace string
+	App       string
+}
+
+// Collector reads service metrics from Prometheus, or the current usage from
+// metrics-server when Prometheus is not configured or cannot be reached
+type Collector struct {
+	prometheus *PrometheusClient
+	queries    []seriesQuery
+	kube       *k8s.Client
+}
+
+// NewCollector creates a collector. prometheus and kube may each be nil.
+// errorRateQuery is the soak monitor's error ratio query, with $namespace,
+// $service and $window; empty uses soak.DefaultErrorRateQuery.
+func NewCollector(prometheus *PrometheusClient, errorRateQuery string, kube *k8s.Client) *Collector {
+	if errorRateQuery == "" {
+		errorRateQuery = soak.DefaultErrorRateQuery
+	}
+	errorRate := strings.NewReplacer("$service", "$app", "$window", "$rate").Replace(errorRateQuery)
+
+	return &Collector{
+		prometheus: prometheus,
+		queries:    append(append([]seriesQuery{}, resourceQueries...), seriesQuery{SeriesErrorRate, "ratio", errorRate}),
+		kube:       kube,
+	}
+}
+
+// ParseWindow reads a window such as 15m, 6h or 7d. Empty is DefaultWindow.
+func ParseWindow(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultWindow, nil
+	}
+
+	var window time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", value)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", value)
+		}
+		window = parsed
+	}
+
+	if window < MinWindow || window > MaxWindow {
+		return 0, fmt.Errorf("window must be between %s and %s", MinWindow, MaxWindow)
+	}
+	return window, nil
+}
+
+// stepFor is the resolution of a window's series
+func stepFor(window time.Duration) time.Duration {
+	step := (window / pointsPerWindow).Truncate(time.Second)
+	if step < minStep {
+		step = minStep
+	}
+	return step
+}
+
+// Collect reports a service's metrics over the window ending now
+func (c *Collector) Collect(ctx context.Context, target Target, window time.Duration) (*types.ServiceMetricsReport, error) {
+	end := time.Now().UTC().Truncate(time.Second)
+	report := &types.ServiceMetricsReport{
+		Namespace: target.Namespace,
+		Window:    window.String(),
+		Start:     end.Add(-window),
+		End:       end,
+		Series:    []types.MetricSeries{},
+	}
+
+	var promErr error
+	if c.prometheus != nil {
+		step := stepFor(window)
+		series, err := c.queryPrometheus(ctx, target, report.Start, end, step)
+		if err == nil {
+			report.Source = SourcePrometheus
+			report.Step = step.String()
+			report.Series = series
+			return report, nil
+		}
+		promErr = err
+	}
+
+	if c.kube == nil {
+		if promErr != nil {
+			return nil, promErr
+		}
+		return nil, ErrUnavailable
+	}
+
+	series, err := c.currentUsage(ctx, target, end)
+	if err != nil {
+		if promErr != nil {
+			return nil, fmt.Errorf("%w; metrics-server fallback: %v", promErr, err)
+		}
+		return nil, err
+	}
+	report.Source = SourceMetricsServer
+	report.Start = end
+	report.Series = series
+	return report, nil
+}
+
+// queryPrometheus runs every series query; series without data are left out
+func (c *Collector) queryPrometheus(ctx context.Context, target Target, start, end time.Time, step time.Duration) ([]types.MetricSeries, error) {
+	rate := step
+	if rate < minRateInterval {
+		rate = minRateInterval
+	}
+	replacer := strings.NewReplacer(
+		"$namespace", target.Namespace,
+		"$app", target.App,
+		"$rate", fmt.Sprintf("%ds", int(rate.Seconds())),
+	)
+
+	results := make([][]types.MetricPoint, len(c.queries))
+	g, ctx := errgroup.WithContext(ctx)
+	for i, q := range c.queries {
+		g.Go(func() error {
+			points, err := c.prometheus.QueryRange(ctx, replacer.Replace(q.query), start, end, step)
+			if err != nil {
+				return fmt.Errorf("%s: %w", q.name, err)
+			}
+			results[i] = points
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	series := []types.MetricSeries{}
+	for i, q := range c.queries {
+		if len(results[i]) > 0 {
+			series = append(series, types.MetricSeries{Name: q.name, Unit: q.unit, Points: results[i]})
+		}
+	}
+	return series, nil
+}
+
+// currentUsage reads one point of CPU and memory from metrics-server and
+// the restart count from the pods. Usage is left out when metrics-server
+// is not installed.
+func (c *Collector) currentUsage(ctx context.Context, target Target, now time.Time) ([]types.MetricSeries, error) {
+	pods, err := c.kube.ListPods(ctx, target.Namespace, "app="+target.App)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	series := []types.MetricSeries{}
+	if usage, err := c.kube.GetServiceMetrics(ctx, target.Namespace, target.App); err == nil {
+		series = append(series,
+			pointSeries(SeriesCPU, "millicores", now, float64(usage.TotalCPU)),
+			pointSeries(SeriesMemory, "bytes", now, float64(usage.TotalMemory)),
+		)
+	}
+
+	restarts := 0
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += int(status.RestartCount)
+		}
+	}
+	series = append(series, pointSeries(SeriesRestarts, "count", now, float64(restarts)))
+	return series, nil
+}
+
+func pointSeries(name, unit string, at time.Time, value float64) types.MetricSeries {
+	return types.MetricSeries{
+		Name:   name,
+		Unit:   unit,
+		Points: []types.MetricPoint{{Timestamp: at, Value: value}},
+	}
+}
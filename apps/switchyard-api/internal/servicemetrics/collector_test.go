@@ -0,0 +1,113 @@
+package servicemetrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: time.Hour},
+		{value: "15m", want: 15 * time.Minute},
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "1m", wantErr: true},
+		{value: "30d", wantErr: true},
+		{value: "xd", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWindow(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWindow(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseWindow(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	if got := stepFor(5 * time.Minute); got != minStep {
+		t.Errorf("stepFor(5m) = %s, want %s", got, minStep)
+	}
+	if got := stepFor(24 * time.Hour); got != 12*time.Minute {
+		t.Errorf("stepFor(24h) = %s, want 12m", got)
+	}
+}
+
+func TestCollectFromPrometheus(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+
+		// No ingress traffic: request and error rates have no series
+		if strings.Contains(query, "nginx_ingress_controller_requests") {
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"values":[[1700000000,"42"]]}]}}`))
+	}))
+	defer server.Close()
+
+	collector := NewCollector(NewPrometheusClient(server.URL), "", nil)
+	report, err := collector.Collect(context.Background(), Target{Namespace: "enclii-shop-production", App: "api"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if report.Source != SourcePrometheus || report.Step != "30s" {
+		t.Errorf("report source %s step %s, want prometheus at 30s", report.Source, report.Step)
+	}
+	var names []string
+	for _, series := range report.Series {
+		names = append(names, series.Name)
+	}
+	if got := strings.Join(names, ","); got != "cpu,memory,restarts" {
+		t.Errorf("series = %s, want cpu,memory,restarts", got)
+	}
+
+	if len(queries) != 5 {
+		t.Fatalf("ran %d queries, want 5", len(queries))
+	}
+	for _, query := range queries {
+		if strings.Contains(query, "$") {
+			t.Errorf("query has unreplaced placeholders: %s", query)
+		}
+		if !strings.Contains(query, `"enclii-shop-production"`) {
+			t.Errorf("query not scoped to the namespace: %s", query)
+		}
+		if strings.Contains(query, "rate(") && !strings.Contains(query, "[120s]") {
+			t.Errorf("rate not taken over the minimum interval: %s", query)
+		}
+	}
+}
+
+func TestCollectUnavailable(t *testing.T) {
+	_, err := NewCollector(nil, "", nil).Collect(context.Background(), Target{Namespace: "ns", App: "api"}, time.Hour)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Collect() error = %v, want ErrUnavailable", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err = NewCollector(NewPrometheusClient(server.URL), "", nil).Collect(context.Background(), Target{Namespace: "ns", App: "api"}, time.Hour)
+	if err == nil || errors.Is(err, ErrUnavailable) {
+		t.Errorf("Collect() error = %v, want the Prometheus failure", err)
+	}
+}
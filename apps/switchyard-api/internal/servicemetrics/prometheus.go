@@ -0,0 +1,123 @@
+package servicemetrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// PrometheusClient runs range queries against a Prometheus server
+type PrometheusClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPrometheusClient creates a client for the Prometheus server at baseURL
+func NewPrometheusClient(baseURL string) *PrometheusClient {
+	return &PrometheusClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// QueryRange runs a query that yields at most one series and returns its
+// points. No series yields no points.
+func (p *PrometheusClient) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]types.MetricPoint, error) {
+	params := url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.Itoa(int(step.Seconds()))},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		p.baseURL+"/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prometheus response: %w", err)
+	}
+
+	return parseMatrix(resp.StatusCode, body)
+}
+
+// promRangeResponse is the part of a Prometheus range query response that is read
+type promRangeResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Values [][2]interface{} `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// parseMatrix reads the single series of a range query response. Samples
+// that are NaN or infinite (e.g. an error ratio without requests) are
+// dropped.
+func parseMatrix(statusCode int, body []byte) ([]types.MetricPoint, error) {
+	var result promRangeResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("prometheus returned %d: invalid response", statusCode)
+	}
+	if statusCode != http.StatusOK || result.Status != "success" {
+		return nil, fmt.Errorf("prometheus returned %d: %s", statusCode, result.Error)
+	}
+	if result.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("prometheus returned a %s, expected a matrix", result.Data.ResultType)
+	}
+	if len(result.Data.Result) == 0 {
+		return nil, nil
+	}
+	if len(result.Data.Result) > 1 {
+		return nil, fmt.Errorf("prometheus returned %d series, expected one", len(result.Data.Result))
+	}
+
+	values := result.Data.Result[0].Values
+	points := make([]types.MetricPoint, 0, len(values))
+	for _, value := range values {
+		ts, ok := value[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("prometheus returned a non-numeric timestamp")
+		}
+		raw, ok := value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("prometheus returned a non-string sample value")
+		}
+		sample, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("prometheus returned an invalid sample value %q", raw)
+		}
+		if math.IsNaN(sample) || math.IsInf(sample, 0) {
+			continue
+		}
+		sec, frac := math.Modf(ts)
+		points = append(points, types.MetricPoint{
+			Timestamp: time.Unix(int64(sec), int64(frac*1e9)).UTC(),
+			Value:     sample,
+		})
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	return points, nil
+}
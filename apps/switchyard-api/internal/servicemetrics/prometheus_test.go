@@ -0,0 +1,47 @@
+package servicemetrics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseMatrix(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"matrix","result":[
+		{"metric":{},"values":[[1700000060,"2.5"],[1700000000,"1"],[1700000120,"NaN"]]}
+	]}}`
+
+	points, err := parseMatrix(http.StatusOK, []byte(body))
+	if err != nil {
+		t.Fatalf("parseMatrix() error = %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("parseMatrix() = %+v, want two points without the NaN", points)
+	}
+	if !points[0].Timestamp.Equal(time.Unix(1700000000, 0)) || points[0].Value != 1 || points[1].Value != 2.5 {
+		t.Errorf("parseMatrix() = %+v, want points in time order", points)
+	}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantErr    bool
+		wantEmpty  bool
+	}{
+		{name: "no series", statusCode: http.StatusOK, body: `{"status":"success","data":{"resultType":"matrix","result":[]}}`, wantEmpty: true},
+		{name: "query error", statusCode: http.StatusBadRequest, body: `{"status":"error","error":"parse error"}`, wantErr: true},
+		{name: "vector", statusCode: http.StatusOK, body: `{"status":"success","data":{"resultType":"vector","result":[]}}`, wantErr: true},
+		{name: "several series", statusCode: http.StatusOK, body: `{"status":"success","data":{"resultType":"matrix","result":[{"values":[]},{"values":[]}]}}`, wantErr: true},
+		{name: "not json", statusCode: http.StatusBadGateway, body: `bad gateway`, wantErr: true},
+	}
+	for _, tt := range tests {
+		points, err := parseMatrix(tt.statusCode, []byte(tt.body))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if tt.wantEmpty && len(points) != 0 {
+			t.Errorf("%s: points = %+v, want none", tt.name, points)
+		}
+	}
+}
@@ -38,19 +38,23 @@ func NewAuthService(
 
 // RegisterRequest represents a user registration request
 type RegisterRequest struct {
-	Email     string
-	Password  string
-	Name      string
-	IP        string
-	UserAgent string
+	Email      string
+	Password   string
+	Name       string
+	InviteCode string
+	IP         string
+	UserAgent  string
 }
 
-// RegisterResponse represents the response from registration
+// RegisterResponse represents the response from registration. When the email
+// address must be verified first, no tokens are issued and VerificationToken
+// is to be emailed to the user.
 type RegisterResponse struct {
-	User         *types.User
-	AccessToken  string
-	RefreshToken string
-	ExpiresAt    time.Time
+	User              *types.User
+	AccessToken       string
+	RefreshToken      string
+	ExpiresAt         time.Time
+	VerificationToken string
 }
 
 // Register registers a new user
@@ -65,11 +69,12 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 
 	// Delegate to provider
 	providerReq := &auth.RegisterRequest{
-		Email:     req.Email,
-		Password:  req.Password,
-		Name:      req.Name,
-		IP:        req.IP,
-		UserAgent: req.UserAgent,
+		Email:      req.Email,
+		Password:   req.Password,
+		Name:       req.Name,
+		InviteCode: req.InviteCode,
+		IP:         req.IP,
+		UserAgent:  req.UserAgent,
 	}
 
 	providerResp, err := s.provider.Register(ctx, providerReq)
@@ -94,9 +99,10 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 				Email: providerResp.Email,
 				Name:  providerResp.Name,
 			},
-			AccessToken:  providerResp.AccessToken,
-			RefreshToken: providerResp.RefreshToken,
-			ExpiresAt:    providerResp.ExpiresAt,
+			AccessToken:       providerResp.AccessToken,
+			RefreshToken:      providerResp.RefreshToken,
+			ExpiresAt:         providerResp.ExpiresAt,
+			VerificationToken: providerResp.VerificationToken,
 		}, nil
 	}
 
@@ -104,6 +110,50 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 	user.PasswordHash = ""
 
 	return &RegisterResponse{
+		User:              user,
+		AccessToken:       providerResp.AccessToken,
+		RefreshToken:      providerResp.RefreshToken,
+		ExpiresAt:         providerResp.ExpiresAt,
+		VerificationToken: providerResp.VerificationToken,
+	}, nil
+}
+
+// VerifyEmailRequest represents a request to verify an email address
+type VerifyEmailRequest struct {
+	Token     string
+	IP        string
+	UserAgent string
+}
+
+// VerifyEmail verifies the email address of a new account and signs its user in
+// NOTE: This method only works in local JWT auth mode.
+func (s *AuthService) VerifyEmail(ctx context.Context, req *VerifyEmailRequest) (*LoginResponse, error) {
+	providerResp, err := s.provider.VerifyEmail(ctx, &auth.VerifyEmailRequest{
+		Token:     req.Token,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	if err != nil {
+		if err == auth.ErrNotSupported {
+			return nil, errors.ErrInternal.WithDetails(map[string]any{
+				"reason": "Email verification not available in OIDC mode.",
+			})
+		}
+		return nil, err
+	}
+
+	user, err := s.repos.Users.GetByID(ctx, providerResp.UserID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", providerResp.UserID).Error("Failed to retrieve user after email verification")
+		user = &types.User{
+			ID:    providerResp.UserID,
+			Email: providerResp.Email,
+			Name:  providerResp.Name,
+		}
+	}
+	user.PasswordHash = ""
+
+	return &LoginResponse{
 		User:         user,
 		AccessToken:  providerResp.AccessToken,
 		RefreshToken: providerResp.RefreshToken,
@@ -111,6 +161,19 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*Regi
 	}, nil
 }
 
+// ResendEmailVerification issues a new verification token for an unverified
+// account. It returns nil when there is nothing to send.
+// NOTE: This method only works in local JWT auth mode.
+func (s *AuthService) ResendEmailVerification(ctx context.Context, email string) (*auth.EmailVerification, error) {
+	verification, err := s.provider.ResendEmailVerification(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err == auth.ErrNotSupported {
+		return nil, errors.ErrInternal.WithDetails(map[string]any{
+			"reason": "Email verification not available in OIDC mode.",
+		})
+	}
+	return verification, err
+}
+
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Email     string
@@ -119,12 +182,15 @@ type LoginRequest struct {
 	UserAgent string
 }
 
-// LoginResponse represents the response from login
+// LoginResponse represents the response from login. When the user has
+// two-factor authentication enabled, only TwoFactorToken is set; it is
+// exchanged for tokens with VerifyTwoFactorLogin.
 type LoginResponse struct {
-	User         *types.User
-	AccessToken  string
-	RefreshToken string
-	ExpiresAt    time.Time
+	User           *types.User
+	AccessToken    string
+	RefreshToken   string
+	ExpiresAt      time.Time
+	TwoFactorToken string
 }
 
 // Login authenticates a user
@@ -171,9 +237,10 @@ func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 				Email: providerResp.Email,
 				Name:  providerResp.Name,
 			},
-			AccessToken:  providerResp.AccessToken,
-			RefreshToken: providerResp.RefreshToken,
-			ExpiresAt:    providerResp.ExpiresAt,
+			AccessToken:    providerResp.AccessToken,
+			RefreshToken:   providerResp.RefreshToken,
+			ExpiresAt:      providerResp.ExpiresAt,
+			TwoFactorToken: providerResp.TwoFactorToken,
 		}, nil
 	}
 
@@ -181,10 +248,11 @@ func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 	user.PasswordHash = ""
 
 	return &LoginResponse{
-		User:         user,
-		AccessToken:  providerResp.AccessToken,
-		RefreshToken: providerResp.RefreshToken,
-		ExpiresAt:    providerResp.ExpiresAt,
+		User:           user,
+		AccessToken:    providerResp.AccessToken,
+		RefreshToken:   providerResp.RefreshToken,
+		ExpiresAt:      providerResp.ExpiresAt,
+		TwoFactorToken: providerResp.TwoFactorToken,
 	}, nil
 }
 
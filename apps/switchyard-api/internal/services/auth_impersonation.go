@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ImpersonationRequest asks for a session acting as a user, for a platform
+// operator giving support
+type ImpersonationRequest struct {
+	Operator *SessionActor
+	UserID   uuid.UUID
+	Reason   string
+}
+
+// ImpersonationResponse carries the access token of an impersonated session
+type ImpersonationResponse struct {
+	User          *types.User
+	Tokens        *auth.TokenPair
+	Impersonation *auth.Impersonation
+}
+
+// Impersonate issues an operator an access token acting as a user. The
+// token cannot be refreshed, carries the operator and reason in its
+// impersonation claim, and is recorded in the audit log of the user.
+func (s *AuthService) Impersonate(ctx context.Context, req *ImpersonationRequest) (*ImpersonationResponse, error) {
+	if req.Operator.UserID == req.UserID {
+		return nil, errors.ErrInvalidInput.WithDetails(map[string]any{
+			"reason": "You cannot impersonate yourself",
+		})
+	}
+
+	user, err := s.repos.Users.GetByID(ctx, req.UserID)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound.WithDetails(map[string]any{
+			"reason": "User not found",
+		})
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if !user.Active {
+		return nil, errors.ErrForbidden.WithDetails(map[string]any{
+			"reason": "Deactivated users cannot be impersonated",
+		})
+	}
+
+	impersonation := &auth.Impersonation{
+		OperatorID:    req.Operator.UserID,
+		OperatorEmail: req.Operator.Email,
+		Reason:        req.Reason,
+	}
+	tokens, err := s.provider.ImpersonateUser(ctx, user.ID, impersonation, auth.SessionDevice{
+		IP:        req.Operator.IP,
+		UserAgent: req.Operator.UserAgent,
+	})
+	if stderrors.Is(err, auth.ErrCannotImpersonate) {
+		return nil, errors.ErrForbidden.WithError(err).WithDetails(map[string]any{
+			"reason": err.Error(),
+		})
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to issue impersonation token")
+		return nil, errors.Wrap(err, errors.ErrInternal)
+	}
+
+	// Operators from OIDC may not have a local user row
+	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      nil,
+		ActorEmail:   req.Operator.Email,
+		ActorRole:    types.Role(req.Operator.Role),
+		Action:       "admin.impersonation_started",
+		ResourceType: "user",
+		ResourceID:   user.ID.String(),
+		ResourceName: user.Email,
+		IPAddress:    req.Operator.IP,
+		UserAgent:    req.Operator.UserAgent,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"operator_id": req.Operator.UserID.String(),
+			"reason":      req.Reason,
+			"expires_at":  tokens.ExpiresAt,
+		},
+	})
+
+	return &ImpersonationResponse{
+		User:          user,
+		Tokens:        tokens,
+		Impersonation: impersonation,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/cache"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// SessionActor is the signed-in user managing their sessions
+type SessionActor struct {
+	UserID    uuid.UUID
+	Email     string
+	Role      string
+	SessionID string // Session of the request, if it was made with session tokens
+	IP        string
+	UserAgent string
+}
+
+// ListSessions returns the active sessions of a user, most recently used
+// first
+func (s *AuthService) ListSessions(ctx context.Context, userID uuid.UUID) ([]*cache.Session, error) {
+	return s.provider.ListSessions(ctx, userID)
+}
+
+// RevokeSession signs a user out of one of their sessions
+func (s *AuthService) RevokeSession(ctx context.Context, actor *SessionActor, sessionID string) error {
+	if err := s.provider.RevokeUserSession(ctx, actor.UserID, sessionID); err != nil {
+		return err
+	}
+
+	s.auditSessions(ctx, actor, "session_revoked", map[string]interface{}{
+		"session_id": sessionID,
+		"current":    sessionID == actor.SessionID,
+	})
+	return nil
+}
+
+// RevokeOtherSessions signs a user out everywhere but the current session,
+// and returns how many sessions it revoked
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, actor *SessionActor) (int, error) {
+	revoked, err := s.provider.RevokeOtherSessions(ctx, actor.UserID, actor.SessionID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", actor.UserID).Error("Failed to revoke other sessions")
+		if revoked == 0 {
+			return 0, err
+		}
+	}
+
+	s.auditSessions(ctx, actor, "sessions_revoked", map[string]interface{}{
+		"revoked_count": revoked,
+	})
+	return revoked, err
+}
+
+func (s *AuthService) auditSessions(ctx context.Context, actor *SessionActor, action string, auditContext map[string]interface{}) {
+	// Like logout, OIDC users may not have a local user row
+	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      nil,
+		ActorEmail:   actor.Email,
+		ActorRole:    types.Role(actor.Role),
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   actor.UserID.String(),
+		ResourceName: actor.Email,
+		IPAddress:    actor.IP,
+		UserAgent:    actor.UserAgent,
+		Outcome:      "success",
+		Context:      auditContext,
+	})
+}
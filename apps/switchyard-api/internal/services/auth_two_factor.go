@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/auth"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// TwoFactorLoginRequest completes a login with a TOTP or recovery code
+type TwoFactorLoginRequest struct {
+	TwoFactorToken string
+	Code           string
+	IP             string
+	UserAgent      string
+}
+
+// VerifyTwoFactorLogin exchanges the challenge of a password login and a
+// second factor for tokens
+// NOTE: This method only works in local JWT auth mode.
+func (s *AuthService) VerifyTwoFactorLogin(ctx context.Context, req *TwoFactorLoginRequest) (*LoginResponse, error) {
+	providerResp, err := s.provider.VerifyTwoFactorLogin(ctx, &auth.TwoFactorLoginRequest{
+		TwoFactorToken: req.TwoFactorToken,
+		Code:           req.Code,
+		IP:             req.IP,
+		UserAgent:      req.UserAgent,
+	})
+	if err != nil {
+		if err == auth.ErrNotSupported {
+			return nil, errTwoFactorNotSupported
+		}
+		return nil, err
+	}
+
+	user, err := s.repos.Users.GetByID(ctx, providerResp.UserID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", providerResp.UserID).Error("Failed to retrieve user after two-factor login")
+		user = &types.User{
+			ID:    providerResp.UserID,
+			Email: providerResp.Email,
+			Name:  providerResp.Name,
+		}
+	}
+	user.PasswordHash = ""
+
+	return &LoginResponse{
+		User:         user,
+		AccessToken:  providerResp.AccessToken,
+		RefreshToken: providerResp.RefreshToken,
+		ExpiresAt:    providerResp.ExpiresAt,
+	}, nil
+}
+
+// errTwoFactorNotSupported is returned in OIDC mode, where the identity
+// provider enforces MFA
+var errTwoFactorNotSupported = errors.ErrInternal.WithDetails(map[string]any{
+	"reason": "Two-factor authentication is managed by the identity provider in OIDC mode.",
+})
+
+// TwoFactorStatus describes the two-factor authentication of a user
+type TwoFactorStatus struct {
+	Enabled                bool
+	EnabledAt              *time.Time
+	RecoveryCodesRemaining int
+}
+
+// GetTwoFactorStatus returns whether a user has two-factor authentication
+// enabled and how many recovery codes they have left
+func (s *AuthService) GetTwoFactorStatus(ctx context.Context, userID uuid.UUID) (*TwoFactorStatus, error) {
+	tf, err := s.repos.TwoFactor.Get(ctx, userID)
+	if err == sql.ErrNoRows || (err == nil && tf.EnabledAt == nil) {
+		return &TwoFactorStatus{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	remaining, err := s.repos.TwoFactor.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	return &TwoFactorStatus{
+		Enabled:                true,
+		EnabledAt:              tf.EnabledAt,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// TwoFactorEnrollment is a new authenticator for the user to add to their app
+type TwoFactorEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// BeginTwoFactorEnrollment creates a pending authenticator for a user. It is
+// enabled by EnableTwoFactor once the user enters a code from it.
+// NOTE: This method only works in local JWT auth mode.
+func (s *AuthService) BeginTwoFactorEnrollment(ctx context.Context, claims *auth.Claims) (*TwoFactorEnrollment, error) {
+	if !s.provider.SupportsLocalAuth() {
+		return nil, errTwoFactorNotSupported
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternal)
+	}
+
+	if err := s.repos.TwoFactor.SetPending(ctx, claims.UserID, secret); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrTwoFactorAlreadyEnabled
+		}
+		s.logger.WithError(err).WithField("user_id", claims.UserID).Error("Failed to store two-factor secret")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(secret, claims.Email),
+	}, nil
+}
+
+// TwoFactorRequest is a two-factor operation of a signed-in user, confirmed
+// with a code
+type TwoFactorRequest struct {
+	Claims      *auth.Claims
+	TokenString string // Access token of the session, revoked when new tokens are issued
+	Code        string // TOTP code, or a recovery code where accepted
+	IP          string
+	UserAgent   string
+}
+
+// TwoFactorResponse carries the tokens of a session that just verified a
+// second factor, and new recovery codes when some were issued
+type TwoFactorResponse struct {
+	AccessToken   string
+	RefreshToken  string
+	ExpiresAt     time.Time
+	RecoveryCodes []string
+}
+
+// EnableTwoFactor confirms the pending authenticator of a user with a code
+// from it. It returns the user's recovery codes, and new tokens, since the
+// session just verified a second factor.
+func (s *AuthService) EnableTwoFactor(ctx context.Context, req *TwoFactorRequest) (*TwoFactorResponse, error) {
+	userID := req.Claims.UserID
+
+	tf, err := s.repos.TwoFactor.Get(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrTwoFactorNotEnabled.WithDetails(map[string]any{
+			"reason": "Start enrollment first",
+		})
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if tf.EnabledAt != nil {
+		return nil, errors.ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := auth.ValidateTOTP(tf.Secret, req.Code, time.Now())
+	if !ok {
+		s.auditTwoFactor(ctx, req, "two_factor_enable", "failure", nil)
+		return nil, errors.ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err = s.repos.WithTransaction(ctx, func(txRepos *db.Repositories) error {
+		if err := txRepos.TwoFactor.Enable(ctx, userID, step); err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ErrTwoFactorAlreadyEnabled
+			}
+			return errors.Wrap(err, errors.ErrDatabaseError)
+		}
+		var err error
+		if codes, err = txRepos.TwoFactor.ReplaceRecoveryCodes(ctx, userID); err != nil {
+			return errors.Wrap(err, errors.ErrDatabaseError)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditTwoFactor(ctx, req, "two_factor_enable", "success", nil)
+
+	resp, err := s.reissueWithTwoFactor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = codes
+	return resp, nil
+}
+
+// VerifyTwoFactor checks a code of a signed-in user and issues new tokens
+// that allow sensitive operations for auth.TwoFactorMaxAge
+func (s *AuthService) VerifyTwoFactor(ctx context.Context, req *TwoFactorRequest) (*TwoFactorResponse, error) {
+	method, err := auth.VerifySecondFactor(ctx, s.repos.TwoFactor, req.Claims.UserID, req.Code)
+	if err != nil {
+		if errors.Is(err, errors.ErrInvalidTwoFactorCode) {
+			s.auditTwoFactor(ctx, req, "two_factor_verify", "failure", nil)
+		}
+		return nil, err
+	}
+
+	s.auditTwoFactor(ctx, req, "two_factor_verify", "success", map[string]interface{}{"method": method})
+	return s.reissueWithTwoFactor(ctx, req)
+}
+
+// DisableTwoFactor removes the authenticator and recovery codes of a user.
+// A valid code is required, so a stolen session cannot turn it off.
+func (s *AuthService) DisableTwoFactor(ctx context.Context, req *TwoFactorRequest) error {
+	method, err := auth.VerifySecondFactor(ctx, s.repos.TwoFactor, req.Claims.UserID, req.Code)
+	if err != nil {
+		if errors.Is(err, errors.ErrInvalidTwoFactorCode) {
+			s.auditTwoFactor(ctx, req, "two_factor_disable", "failure", nil)
+		}
+		return err
+	}
+
+	if err := s.repos.TwoFactor.Delete(ctx, req.Claims.UserID); err != nil && err != sql.ErrNoRows {
+		s.logger.WithError(err).WithField("user_id", req.Claims.UserID).Error("Failed to disable two-factor authentication")
+		return errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	s.auditTwoFactor(ctx, req, "two_factor_disable", "success", map[string]interface{}{"method": method})
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user after
+// checking a code
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, req *TwoFactorRequest) ([]string, error) {
+	if _, err := auth.VerifySecondFactor(ctx, s.repos.TwoFactor, req.Claims.UserID, req.Code); err != nil {
+		if errors.Is(err, errors.ErrInvalidTwoFactorCode) {
+			s.auditTwoFactor(ctx, req, "two_factor_recovery_codes_regenerate", "failure", nil)
+		}
+		return nil, err
+	}
+
+	var codes []string
+	err := s.repos.WithTransaction(ctx, func(txRepos *db.Repositories) error {
+		var err error
+		codes, err = txRepos.TwoFactor.ReplaceRecoveryCodes(ctx, req.Claims.UserID)
+		return err
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", req.Claims.UserID).Error("Failed to regenerate recovery codes")
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	s.auditTwoFactor(ctx, req, "two_factor_recovery_codes_regenerate", "success", nil)
+	return codes, nil
+}
+
+// reissueWithTwoFactor issues tokens that record a second factor verified
+// now, and revokes the session they replace
+func (s *AuthService) reissueWithTwoFactor(ctx context.Context, req *TwoFactorRequest) (*TwoFactorResponse, error) {
+	now := time.Now()
+	tokenPair, err := s.provider.GenerateTokenPair(&auth.User{
+		ID:          req.Claims.UserID,
+		Email:       req.Claims.Email,
+		Role:        req.Claims.Role,
+		ProjectIDs:  req.Claims.ProjectIDs,
+		Active:      true,
+		TwoFactorAt: &now,
+		Device:      auth.SessionDevice{IP: req.IP, UserAgent: req.UserAgent},
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", req.Claims.UserID).Error("Failed to generate token pair")
+		return nil, errors.Wrap(err, errors.ErrInternal)
+	}
+
+	if err := s.provider.RevokeSessionFromToken(ctx, req.TokenString); err != nil {
+		s.logger.WithError(err).WithField("user_id", req.Claims.UserID).Warn("Failed to revoke session replaced after two-factor verification")
+	}
+
+	return &TwoFactorResponse{
+		AccessToken:  tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		ExpiresAt:    tokenPair.ExpiresAt,
+	}, nil
+}
+
+func (s *AuthService) auditTwoFactor(ctx context.Context, req *TwoFactorRequest, action, outcome string, auditContext map[string]interface{}) {
+	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      &req.Claims.UserID,
+		ActorEmail:   req.Claims.Email,
+		ActorRole:    types.Role(req.Claims.Role),
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   req.Claims.UserID.String(),
+		ResourceName: req.Claims.Email,
+		IPAddress:    req.IP,
+		UserAgent:    req.UserAgent,
+		Outcome:      outcome,
+		Context:      auditContext,
+	})
+}
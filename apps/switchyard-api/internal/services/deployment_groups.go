@@ -10,6 +10,7 @@ import (
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -22,6 +23,7 @@ import (
 type DeploymentGroupService struct {
 	repos             *db.Repositories
 	deploymentService *DeploymentService
+	notifications     *notifications.Service
 	logger            *logrus.Logger
 }
 
@@ -54,6 +56,11 @@ type CreateGroupDeploymentRequest struct {
 	UserID        string
 	UserEmail     string
 	UserRole      string
+
+	// ScheduledAt defers execution to a time; nil leaves the group pending
+	// until it is executed on request
+	ScheduledAt          *time.Time
+	RespectFreezeWindows bool // A scheduled group waits out freeze windows of its environment
 }
 
 // CreateGroupDeploymentResponse represents the response from creating a group deployment
@@ -127,11 +134,20 @@ func (s *DeploymentGroupService) CreateGroupDeployment(ctx context.Context, req
 		})
 	}
 
+	status := db.DeploymentGroupStatusPending
+	if req.ScheduledAt != nil {
+		if err := s.checkSchedule(ctx, environmentID, *req.ScheduledAt, req.RespectFreezeWindows); err != nil {
+			return nil, err
+		}
+		status = db.DeploymentGroupStatusScheduled
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"project_id":     req.ProjectID,
 		"environment_id": req.EnvironmentID,
 		"services_count": len(serviceIDs),
 		"strategy":       strategy,
+		"scheduled_at":   req.ScheduledAt,
 	}).Info("Creating deployment group")
 
 	// Calculate deployment order using topological sort
@@ -146,11 +162,16 @@ func (s *DeploymentGroupService) CreateGroupDeployment(ctx context.Context, req
 		ProjectID:     projectID,
 		EnvironmentID: environmentID,
 		Name:          &name,
-		Status:        db.DeploymentGroupStatusPending,
+		Status:        status,
 		Strategy:      strategy,
 		TriggeredBy:   &req.TriggeredBy,
 		GitSHA:        &req.GitSHA,
 	}
+	if req.ScheduledAt != nil {
+		scheduledAt := req.ScheduledAt.UTC()
+		group.ScheduledAt = &scheduledAt
+		group.RespectFreezeWindows = req.RespectFreezeWindows
+	}
 	if req.PRURL != "" {
 		group.PRURL = &req.PRURL
 	}
@@ -176,6 +197,7 @@ func (s *DeploymentGroupService) CreateGroupDeployment(ctx context.Context, req
 			"services_count": len(serviceIDs),
 			"strategy":       string(strategy),
 			"layers_count":   len(deploymentOrder),
+			"scheduled_at":   group.ScheduledAt,
 		},
 	})
 
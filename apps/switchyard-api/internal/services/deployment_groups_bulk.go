@@ -0,0 +1,449 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/deploypolicy"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/gpuquota"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// =============================================================================
+// Bulk Operations (Restart, Redeploy, or Scale Many Services at Once)
+// =============================================================================
+
+// BulkAction is an operation applied to every service selected by a bulk request
+type BulkAction string
+
+const (
+	// BulkActionRestart rolls the pods of the currently running release
+	BulkActionRestart BulkAction = "restart"
+	// BulkActionRedeployLatest deploys the latest ready release of each service
+	BulkActionRedeployLatest BulkAction = "redeploy-latest"
+	// BulkActionScale redeploys the running release with a new replica count
+	BulkActionScale BulkAction = "scale"
+)
+
+// MaxBulkReplicas caps the replica count a bulk scale may request
+const MaxBulkReplicas = 50
+
+// Per-service outcomes of a bulk operation
+const (
+	BulkResultQueued  = "queued"
+	BulkResultSkipped = "skipped"
+	BulkResultFailed  = "failed"
+)
+
+// BulkOperationRequest represents a request to apply an action to a filtered set of services
+type BulkOperationRequest struct {
+	ProjectSlug string
+	Environment string            // Environment name (required)
+	Action      BulkAction        // restart, redeploy-latest, scale
+	Replicas    int               // Target replicas (scale only)
+	Labels      map[string]string // Only services carrying all of these labels
+	ServiceIDs  []string          // Only these services (all if empty)
+	Strategy    string            // "parallel", "dependency_ordered", "sequential"
+	UserID      string
+	UserEmail   string
+	UserRole    string
+
+	// OverrideReason justifies a redeploy against the environment's deploy
+	// policy. Restarts and scaling keep the running release and are not
+	// subject to the policy.
+	OverrideReason string
+}
+
+// BulkServiceResult is the outcome of a bulk operation for one service
+type BulkServiceResult struct {
+	ServiceID    uuid.UUID  `json:"service_id"`
+	ServiceName  string     `json:"service_name"`
+	Status       string     `json:"status"` // queued, skipped, failed
+	DeploymentID *uuid.UUID `json:"deployment_id,omitempty"`
+	ReleaseID    *uuid.UUID `json:"release_id,omitempty"`
+	Replicas     int        `json:"replicas,omitempty"`
+	Message      string     `json:"message,omitempty"`
+}
+
+// BulkOperationSummary counts the per-service outcomes of a bulk operation
+type BulkOperationSummary struct {
+	Matched int `json:"matched"`
+	Queued  int `json:"queued"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// BulkOperationResponse represents the result of a bulk operation.
+// Group tracks the deployments created for the operation.
+type BulkOperationResponse struct {
+	Group   *db.DeploymentGroup
+	Action  BulkAction
+	Results []BulkServiceResult
+	Summary BulkOperationSummary
+}
+
+// bulkTarget is a service selected for a bulk operation and the deployment it gets
+type bulkTarget struct {
+	service   *types.Service
+	releaseID uuid.UUID
+	replicas  int
+}
+
+// ParseBulkAction validates a bulk action and its replica count
+func ParseBulkAction(action string, replicas int) (BulkAction, error) {
+	switch BulkAction(action) {
+	case BulkActionRestart, BulkActionRedeployLatest:
+		return BulkAction(action), nil
+	case BulkActionScale:
+		if replicas < 1 || replicas > MaxBulkReplicas {
+			return "", errors.ErrValidation.WithDetails(map[string]any{
+				"field":  "replicas",
+				"reason": fmt.Sprintf("replicas must be between 1 and %d", MaxBulkReplicas),
+			})
+		}
+		return BulkActionScale, nil
+	default:
+		return "", errors.ErrValidation.WithDetails(map[string]any{
+			"field":  "action",
+			"reason": "Invalid action: must be restart, redeploy-latest, or scale",
+		})
+	}
+}
+
+// MatchesLabels reports whether a service carries every label in the selector
+func MatchesLabels(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if got, ok := labels[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ExecuteBulkOperation applies an action to the selected services of a project as a
+// single deployment group. Services the action cannot apply to are skipped and
+// reported; the group can be tracked and rolled back like any other.
+func (s *DeploymentGroupService) ExecuteBulkOperation(ctx context.Context, req *BulkOperationRequest) (*BulkOperationResponse, error) {
+	action, err := ParseBulkAction(string(req.Action), req.Replicas)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := db.DeploymentGroupStrategyParallel
+	switch req.Strategy {
+	case "parallel", "":
+		strategy = db.DeploymentGroupStrategyParallel
+	case "sequential":
+		strategy = db.DeploymentGroupStrategySequential
+	case "dependency_ordered":
+		strategy = db.DeploymentGroupStrategyDependencyOrdered
+	default:
+		return nil, errors.ErrValidation.WithDetails(map[string]any{
+			"field":  "strategy",
+			"reason": "Invalid strategy: must be parallel, sequential, or dependency_ordered",
+		})
+	}
+
+	project, err := s.repos.Projects.GetBySlug(req.ProjectSlug)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrProjectNotFound)
+	}
+	env, err := s.repos.Environments.GetByProjectAndName(project.ID, req.Environment)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrEnvironmentNotFound)
+	}
+
+	var override *deploypolicy.Decision
+	if action == BulkActionRedeployLatest {
+		override, err = checkDeployPolicy(ctx, s.repos, env.ID, types.Role(req.UserRole), req.OverrideReason)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	selected, err := s.selectBulkServices(project.ID, req.ServiceIDs, req.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, errors.ErrValidation.WithDetails(map[string]any{
+			"reason": "No services match the filter",
+		})
+	}
+
+	// Resolve the release and replicas each service is deployed with
+	var results []BulkServiceResult
+	targets := map[uuid.UUID]*bulkTarget{}
+	var targetIDs []uuid.UUID
+	for _, svc := range selected {
+		target, skipReason, err := s.planBulkTarget(ctx, svc, env.ID, action, req.Replicas)
+		switch {
+		case err != nil:
+			results = append(results, BulkServiceResult{ServiceID: svc.ID, ServiceName: svc.Name, Status: BulkResultFailed, Message: err.Error()})
+		case target == nil:
+			results = append(results, BulkServiceResult{ServiceID: svc.ID, ServiceName: svc.Name, Status: BulkResultSkipped, Message: skipReason})
+		default:
+			targets[svc.ID] = target
+			targetIDs = append(targetIDs, svc.ID)
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"project":     project.Slug,
+		"environment": env.Name,
+		"action":      action,
+		"matched":     len(selected),
+		"targets":     len(targetIDs),
+	}).Info("Executing bulk operation")
+
+	// Record the operation as a deployment group, even if nothing was queued
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s-%s", action, project.Slug, now.Format("20060102-150405"))
+	triggeredBy := "bulk:" + string(action)
+	group := &db.DeploymentGroup{
+		ProjectID:     project.ID,
+		EnvironmentID: env.ID,
+		Name:          &name,
+		Status:        db.DeploymentGroupStatusInProgress,
+		Strategy:      strategy,
+		TriggeredBy:   &triggeredBy,
+		StartedAt:     &now,
+	}
+	if err := s.repos.DeploymentGroups.Create(ctx, group); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	if override != nil {
+		deploypolicy.RecordOverride(ctx, s.repos, &types.AuditLog{
+			ActorEmail:    req.UserEmail,
+			ActorRole:     types.Role(req.UserRole),
+			ResourceType:  "deployment_group",
+			ResourceID:    group.ID.String(),
+			ResourceName:  name,
+			ProjectID:     &project.ID,
+			EnvironmentID: &env.ID,
+		}, override, req.OverrideReason)
+	}
+
+	if len(targetIDs) > 0 {
+		layers, err := s.bulkDeployOrder(ctx, strategy, targetIDs)
+		if err != nil {
+			errMsg := err.Error()
+			if updateErr := s.repos.DeploymentGroups.UpdateCompleted(ctx, group.ID, db.DeploymentGroupStatusFailed, &errMsg); updateErr != nil {
+				s.logger.Error("Failed to update group failed status", "error", updateErr)
+			}
+			return nil, err
+		}
+
+		for order, layer := range layers {
+			for _, serviceID := range layer {
+				results = append(results, s.createBulkDeployment(ctx, group, targets[serviceID], order))
+			}
+		}
+	}
+
+	summary := BulkOperationSummary{Matched: len(selected)}
+	for _, result := range results {
+		switch result.Status {
+		case BulkResultQueued:
+			summary.Queued++
+		case BulkResultSkipped:
+			summary.Skipped++
+		case BulkResultFailed:
+			summary.Failed++
+		}
+	}
+
+	finalStatus := db.DeploymentGroupStatusSucceeded
+	var errMsg *string
+	if summary.Failed > 0 {
+		finalStatus = db.DeploymentGroupStatusFailed
+		msg := fmt.Sprintf("%d of %d services failed", summary.Failed, summary.Matched)
+		errMsg = &msg
+	}
+	if err := s.repos.DeploymentGroups.UpdateCompleted(ctx, group.ID, finalStatus, errMsg); err != nil {
+		s.logger.Error("Failed to update group completed status", "error", err)
+	}
+
+	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      nil,
+		ActorEmail:   req.UserEmail,
+		ActorRole:    types.Role(req.UserRole),
+		Action:       "bulk_operation_executed",
+		ResourceType: "deployment_group",
+		ResourceID:   group.ID.String(),
+		ResourceName: name,
+		ProjectID:    &project.ID,
+		Outcome:      string(finalStatus),
+		Context: map[string]interface{}{
+			"action":      string(action),
+			"environment": env.Name,
+			"labels":      req.Labels,
+			"replicas":    req.Replicas,
+			"matched":     summary.Matched,
+			"queued":      summary.Queued,
+			"skipped":     summary.Skipped,
+			"failed":      summary.Failed,
+		},
+	})
+
+	if refreshed, err := s.repos.DeploymentGroups.GetByID(ctx, group.ID); err == nil {
+		group = refreshed
+	}
+
+	return &BulkOperationResponse{
+		Group:   group,
+		Action:  action,
+		Results: results,
+		Summary: summary,
+	}, nil
+}
+
+// selectBulkServices returns the project services matching the ID and label filters
+func (s *DeploymentGroupService) selectBulkServices(projectID uuid.UUID, serviceIDs []string, labels map[string]string) ([]*types.Service, error) {
+	services, err := s.repos.Services.ListByProject(projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	wanted := map[uuid.UUID]bool{}
+	for _, id := range serviceIDs {
+		svcID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrInvalidInput)
+		}
+		wanted[svcID] = true
+	}
+
+	var selected []*types.Service
+	for _, svc := range services {
+		if len(wanted) > 0 && !wanted[svc.ID] {
+			continue
+		}
+		if !MatchesLabels(svc.Labels, labels) {
+			continue
+		}
+		selected = append(selected, svc)
+	}
+	return selected, nil
+}
+
+// planBulkTarget resolves the deployment a service gets for an action. A nil target
+// with a reason means the action does not apply to the service.
+func (s *DeploymentGroupService) planBulkTarget(ctx context.Context, svc *types.Service, environmentID uuid.UUID, action BulkAction, replicas int) (*bulkTarget, string, error) {
+	current, err := s.repos.Deployments.GetCurrentByServiceAndEnvironment(ctx, svc.ID, environmentID)
+	if err != nil && !stderrors.Is(err, sql.ErrNoRows) {
+		return nil, "", fmt.Errorf("failed to get current deployment: %w", err)
+	}
+
+	switch action {
+	case BulkActionRestart:
+		if current == nil {
+			return nil, "not running in this environment", nil
+		}
+		return &bulkTarget{service: svc, releaseID: current.ReleaseID, replicas: current.Replicas}, "", nil
+
+	case BulkActionScale:
+		if current == nil {
+			return nil, "not running in this environment", nil
+		}
+		if current.Replicas == replicas {
+			return nil, fmt.Sprintf("already running %d replicas", replicas), nil
+		}
+		return &bulkTarget{service: svc, releaseID: current.ReleaseID, replicas: replicas}, "", nil
+
+	case BulkActionRedeployLatest:
+		releases, err := s.repos.Releases.ListByService(svc.ID)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get releases: %w", err)
+		}
+		for _, release := range releases {
+			if release.Status != types.ReleaseStatusReady {
+				continue
+			}
+			target := &bulkTarget{service: svc, releaseID: release.ID, replicas: 1}
+			if current != nil && current.Replicas > 0 {
+				target.replicas = current.Replicas
+			}
+			return target, "", nil
+		}
+		return nil, "no ready release", nil
+	}
+
+	return nil, "", fmt.Errorf("unsupported action: %s", action)
+}
+
+// bulkDeployOrder returns the layers services are deployed in for a strategy
+func (s *DeploymentGroupService) bulkDeployOrder(ctx context.Context, strategy db.DeploymentGroupStrategy, serviceIDs []uuid.UUID) ([][]uuid.UUID, error) {
+	switch strategy {
+	case db.DeploymentGroupStrategyDependencyOrdered:
+		return s.TopologicalSort(ctx, serviceIDs)
+	case db.DeploymentGroupStrategySequential:
+		layers := make([][]uuid.UUID, len(serviceIDs))
+		for i, id := range serviceIDs {
+			layers[i] = []uuid.UUID{id}
+		}
+		return layers, nil
+	default:
+		return [][]uuid.UUID{serviceIDs}, nil
+	}
+}
+
+// createBulkDeployment creates the deployment for one bulk target
+func (s *DeploymentGroupService) createBulkDeployment(ctx context.Context, group *db.DeploymentGroup, target *bulkTarget, order int) BulkServiceResult {
+	result := BulkServiceResult{
+		ServiceID:   target.service.ID,
+		ServiceName: target.service.Name,
+		ReleaseID:   &target.releaseID,
+		Replicas:    target.replicas,
+	}
+
+	// Deployments created earlier in the operation count against the quotas
+	if err := gpuquota.Check(ctx, s.repos, target.service, group.EnvironmentID, target.replicas); err != nil {
+		result.Status = BulkResultFailed
+		result.Message = err.Error()
+		return result
+	}
+	if err := quota.CheckDeploy(ctx, s.repos, target.service, group.EnvironmentID, target.replicas); err != nil {
+		result.Status = BulkResultFailed
+		result.Message = err.Error()
+		return result
+	}
+
+	// A new deployment changes the pod template, so even the same release rolls its pods
+	deployment := &types.Deployment{
+		ReleaseID:     target.releaseID,
+		EnvironmentID: group.EnvironmentID,
+		GroupID:       &group.ID,
+		DeployOrder:   order,
+		Replicas:      target.replicas,
+		Status:        types.DeploymentStatusPending,
+		Health:        types.HealthStatusUnknown,
+	}
+	if err := s.repos.Deployments.Create(deployment); err != nil {
+		result.Status = BulkResultFailed
+		result.Message = fmt.Sprintf("failed to create deployment: %v", err)
+		return result
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"deployment_id": deployment.ID,
+		"service_id":    target.service.ID,
+		"release_id":    target.releaseID,
+		"group_id":      group.ID,
+		"deploy_order":  order,
+	}).Debug("Created deployment for bulk operation")
+
+	result.Status = BulkResultQueued
+	result.DeploymentID = &deployment.ID
+	return result
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+)
+
+func TestParseBulkAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		replicas int
+		want     BulkAction
+		wantErr  bool
+	}{
+		{name: "restart", action: "restart", want: BulkActionRestart},
+		{name: "redeploy latest", action: "redeploy-latest", want: BulkActionRedeployLatest},
+		{name: "scale", action: "scale", replicas: 3, want: BulkActionScale},
+		{name: "scale to zero", action: "scale", replicas: 0, wantErr: true},
+		{name: "scale beyond limit", action: "scale", replicas: MaxBulkReplicas + 1, wantErr: true},
+		{name: "replicas ignored for restart", action: "restart", replicas: 99, want: BulkActionRestart},
+		{name: "unknown action", action: "delete", wantErr: true},
+		{name: "empty action", action: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBulkAction(tt.action, tt.replicas)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBulkAction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, errors.ErrValidation) {
+					t.Errorf("ParseBulkAction() error = %v, want validation error", err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseBulkAction() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesLabels(t *testing.T) {
+	labels := map[string]string{"tier": "frontend", "team": "growth"}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		selector map[string]string
+		want     bool
+	}{
+		{name: "empty selector matches all", labels: labels, selector: nil, want: true},
+		{name: "empty selector matches unlabeled", labels: nil, selector: map[string]string{}, want: true},
+		{name: "single label", labels: labels, selector: map[string]string{"tier": "frontend"}, want: true},
+		{name: "all labels", labels: labels, selector: map[string]string{"tier": "frontend", "team": "growth"}, want: true},
+		{name: "value mismatch", labels: labels, selector: map[string]string{"tier": "backend"}, want: false},
+		{name: "missing key", labels: labels, selector: map[string]string{"region": "eu"}, want: false},
+		{name: "empty value does not match missing key", labels: labels, selector: map[string]string{"region": ""}, want: false},
+		{name: "unlabeled service", labels: nil, selector: map[string]string{"tier": "frontend"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchesLabels(tt.labels, tt.selector); got != tt.want {
+				t.Errorf("MatchesLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/deploypolicy"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/gpuquota"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -24,6 +27,10 @@ type ExecuteGroupDeploymentRequest struct {
 	UserID    string
 	UserEmail string
 	UserRole  string
+
+	// OverrideReason justifies deploying against the environment's deploy
+	// policy. Only admins can override.
+	OverrideReason string
 }
 
 // ExecuteGroupDeploymentResponse represents the result of executing a deployment group
@@ -53,12 +60,29 @@ func (s *DeploymentGroupService) ExecuteGroupDeployment(ctx context.Context, req
 		})
 	}
 
+	override, err := checkDeployPolicy(ctx, s.repos, group.EnvironmentID, types.Role(req.UserRole), req.OverrideReason)
+	if err != nil {
+		return nil, err
+	}
+
 	// Mark group as started
 	if err := s.repos.DeploymentGroups.UpdateStarted(ctx, groupID); err != nil {
 		s.logger.Error("Failed to update group started status", "error", err)
 		return nil, errors.Wrap(err, errors.ErrDatabaseError)
 	}
 
+	if override != nil {
+		deploypolicy.RecordOverride(ctx, s.repos, &types.AuditLog{
+			ActorEmail:    req.UserEmail,
+			ActorRole:     types.Role(req.UserRole),
+			ResourceType:  "deployment_group",
+			ResourceID:    group.ID.String(),
+			ResourceName:  stringValue(group.Name),
+			ProjectID:     &group.ProjectID,
+			EnvironmentID: &group.EnvironmentID,
+		}, override, req.OverrideReason)
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"group_id": req.GroupID,
 		"strategy": group.Strategy,
@@ -289,6 +313,17 @@ func (s *DeploymentGroupService) deployService(
 		return nil, fmt.Errorf("no ready release found for service %s", serviceID)
 	}
 
+	service, err := s.repos.Services.GetByID(serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", serviceID, err)
+	}
+	if err := gpuquota.Check(ctx, s.repos, service, group.EnvironmentID, 1); err != nil {
+		return nil, fmt.Errorf("service %s: %w", service.Name, err)
+	}
+	if err := quota.CheckDeploy(ctx, s.repos, service, group.EnvironmentID, 1); err != nil {
+		return nil, fmt.Errorf("service %s: %w", service.Name, err)
+	}
+
 	// Create deployment
 	deployment := &types.Deployment{
 		ID:            uuid.New(),
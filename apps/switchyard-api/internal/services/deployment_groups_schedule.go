@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/deploypolicy"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/notifications"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// =============================================================================
+// Group Scheduling (Deferred Execution, Freeze Windows and Reminders)
+// =============================================================================
+
+const (
+	// ScheduleSyncInterval is how often scheduled groups are checked
+	ScheduleSyncInterval = time.Minute
+
+	// ScheduleReminderLead is how long before its scheduled time the reminder
+	// of a group is sent
+	ScheduleReminderLead = 15 * time.Minute
+)
+
+// SetNotificationService enables the reminders sent before scheduled groups run
+func (s *DeploymentGroupService) SetNotificationService(notificationService *notifications.Service) {
+	s.notifications = notificationService
+}
+
+// CancelGroupDeploymentRequest represents a request to cancel a scheduled group
+type CancelGroupDeploymentRequest struct {
+	GroupID   string
+	UserEmail string
+	UserRole  string
+}
+
+// CancelGroupDeployment cancels a deployment group that is waiting for its
+// scheduled time
+func (s *DeploymentGroupService) CancelGroupDeployment(ctx context.Context, req *CancelGroupDeploymentRequest) (*db.DeploymentGroup, error) {
+	groupID, err := uuid.Parse(req.GroupID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInvalidInput)
+	}
+
+	group, err := s.repos.DeploymentGroups.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDeploymentNotFound)
+	}
+
+	if group.Status != db.DeploymentGroupStatusScheduled {
+		return nil, errors.ErrConflict.WithDetails(map[string]any{
+			"reason": fmt.Sprintf("Only scheduled deployment groups can be cancelled, current status: %s", group.Status),
+		})
+	}
+
+	cancelled, err := s.repos.DeploymentGroups.Cancel(ctx, groupID, req.UserEmail)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if !cancelled {
+		return nil, errors.ErrConflict.WithDetails(map[string]any{
+			"reason": "Deployment group started before it could be cancelled",
+		})
+	}
+
+	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      nil,
+		ActorEmail:   req.UserEmail,
+		ActorRole:    types.Role(req.UserRole),
+		Action:       "deployment_group_cancelled",
+		ResourceType: "deployment_group",
+		ResourceID:   group.ID.String(),
+		ResourceName: stringValue(group.Name),
+		ProjectID:    &group.ProjectID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"scheduled_at": group.ScheduledAt,
+		},
+	})
+
+	return s.repos.DeploymentGroups.GetByID(ctx, groupID)
+}
+
+// SyncScheduled sends the reminders of scheduled groups that are coming up
+// and executes the groups that are due
+func (s *DeploymentGroupService) SyncScheduled(ctx context.Context) {
+	now := time.Now()
+
+	groups, err := s.repos.DeploymentGroups.ListScheduledBefore(ctx, now.Add(ScheduleReminderLead))
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list scheduled deployment groups")
+		return
+	}
+
+	for _, group := range groups {
+		if group.ScheduledAt.After(now) {
+			if group.ReminderSentAt == nil {
+				s.sendScheduleReminder(ctx, group)
+			}
+			continue
+		}
+		s.runScheduled(ctx, group, now)
+	}
+}
+
+// checkSchedule validates the scheduled time of a new group
+func (s *DeploymentGroupService) checkSchedule(ctx context.Context, environmentID uuid.UUID, scheduledAt time.Time, respectFreezeWindows bool) error {
+	if !scheduledAt.After(time.Now()) {
+		return errors.ErrValidation.WithDetails(map[string]any{
+			"field":  "scheduled_at",
+			"reason": "Scheduled time must be in the future",
+		})
+	}
+	if !respectFreezeWindows {
+		return nil
+	}
+
+	windows, err := s.repos.FreezeWindows.ListEndingAfter(ctx, environmentID, scheduledAt)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if end, frozen := freezeWindowEnd(windows, scheduledAt); frozen {
+		return errors.ErrConflict.WithDetails(map[string]any{
+			"field":          "scheduled_at",
+			"reason":         "Scheduled time falls in a freeze window of the environment",
+			"freeze_ends_at": end,
+		})
+	}
+
+	return nil
+}
+
+// runScheduled executes a due group, or postpones it to the end of the
+// freeze window it falls in
+func (s *DeploymentGroupService) runScheduled(ctx context.Context, group *db.DeploymentGroup, now time.Time) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"group_id":     group.ID,
+		"scheduled_at": group.ScheduledAt,
+	})
+
+	if group.RespectFreezeWindows {
+		windows, err := s.repos.FreezeWindows.ListEndingAfter(ctx, group.EnvironmentID, now)
+		if err != nil {
+			logger.WithError(err).Error("Failed to list freeze windows")
+			return
+		}
+		if end, frozen := freezeWindowEnd(windows, now); frozen {
+			if err := s.repos.DeploymentGroups.Reschedule(ctx, group.ID, end); err != nil {
+				logger.WithError(err).Error("Failed to postpone scheduled deployment group")
+				return
+			}
+			logger.WithField("postponed_to", end).Info("Postponed scheduled deployment group until its freeze window ends")
+			s.auditSchedule(ctx, group, "deployment_group_postponed", map[string]interface{}{
+				"scheduled_at": group.ScheduledAt,
+				"postponed_to": end,
+			})
+			return
+		}
+	}
+
+	// Scheduled groups never override the deploy policy of the environment
+	decision, err := deploypolicy.Check(ctx, s.repos, group.EnvironmentID, now)
+	if err != nil {
+		logger.WithError(err).Error("Failed to check deploy policy")
+		return
+	}
+	if !decision.Allowed {
+		if decision.NextAllowedAt == nil {
+			logger.WithField("reason", decision.Reason).Warn("Deploy policy blocks scheduled deployment group")
+			return
+		}
+		if err := s.repos.DeploymentGroups.Reschedule(ctx, group.ID, *decision.NextAllowedAt); err != nil {
+			logger.WithError(err).Error("Failed to postpone scheduled deployment group")
+			return
+		}
+		logger.WithField("postponed_to", *decision.NextAllowedAt).Info("Postponed scheduled deployment group until its deploy policy allows deploys")
+		s.auditSchedule(ctx, group, "deployment_group_postponed", map[string]interface{}{
+			"scheduled_at": group.ScheduledAt,
+			"postponed_to": *decision.NextAllowedAt,
+			"reason":       decision.Reason,
+		})
+		return
+	}
+
+	// Claiming the group first keeps a cancellation from racing the execution
+	claimed, err := s.repos.DeploymentGroups.ClaimScheduled(ctx, group.ID)
+	if err != nil {
+		logger.WithError(err).Error("Failed to claim scheduled deployment group")
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	logger.Info("Executing scheduled deployment group")
+	_, err = s.ExecuteGroupDeployment(ctx, &ExecuteGroupDeploymentRequest{
+		GroupID:   group.ID.String(),
+		UserEmail: stringValue(group.TriggeredBy),
+		UserRole:  string(types.RoleSystem),
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to execute scheduled deployment group")
+	}
+}
+
+// sendScheduleReminder notifies the project that a scheduled group runs soon
+func (s *DeploymentGroupService) sendScheduleReminder(ctx context.Context, group *db.DeploymentGroup) {
+	// Recorded before sending, so a failed delivery is not retried every sync
+	if err := s.repos.DeploymentGroups.MarkReminderSent(ctx, group.ID); err != nil {
+		s.logger.WithError(err).WithField("group_id", group.ID).Error("Failed to record deployment group reminder")
+		return
+	}
+	if s.notifications == nil {
+		return
+	}
+
+	project, err := s.repos.Projects.GetByID(ctx, group.ProjectID)
+	if err != nil {
+		s.logger.WithError(err).WithField("group_id", group.ID).Error("Failed to get project for deployment group reminder")
+		return
+	}
+	environment, err := s.repos.Environments.GetByID(ctx, group.EnvironmentID)
+	if err != nil {
+		s.logger.WithError(err).WithField("group_id", group.ID).Error("Failed to get environment for deployment group reminder")
+		return
+	}
+
+	event := &types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      types.WebhookEventDeploymentGroupReminder,
+		Timestamp: time.Now(),
+		ProjectID: project.ID,
+		Project: types.WebhookProjectInfo{
+			ID:   project.ID,
+			Name: project.Name,
+			Slug: project.Slug,
+		},
+		DeploymentGroup: &types.WebhookDeploymentGroupInfo{
+			ID:          group.ID,
+			Name:        stringValue(group.Name),
+			Environment: environment.Name,
+			Strategy:    string(group.Strategy),
+			Status:      string(group.Status),
+			ScheduledAt: group.ScheduledAt,
+			ScheduledBy: stringValue(group.TriggeredBy),
+			CommitSHA:   stringValue(group.GitSHA),
+		},
+	}
+
+	if err := s.notifications.SendEvent(ctx, project.ID, event); err != nil {
+		s.logger.WithError(err).WithField("group_id", group.ID).Warn("Failed to send deployment group reminder")
+	}
+}
+
+// auditSchedule records a change the scheduler made to a group
+func (s *DeploymentGroupService) auditSchedule(ctx context.Context, group *db.DeploymentGroup, action string, details map[string]interface{}) {
+	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorEmail:   "system",
+		ActorRole:    types.RoleSystem,
+		Action:       action,
+		ResourceType: "deployment_group",
+		ResourceID:   group.ID.String(),
+		ResourceName: stringValue(group.Name),
+		ProjectID:    &group.ProjectID,
+		Outcome:      "success",
+		Context:      details,
+	})
+}
+
+// freezeWindowEnd reports whether a time falls in one of the windows, and
+// when the freeze ends. Windows that overlap or follow on from each other
+// extend the freeze.
+func freezeWindowEnd(windows []*db.FreezeWindow, at time.Time) (time.Time, bool) {
+	end, frozen := at, false
+	for extended := true; extended; {
+		extended = false
+		for _, window := range windows {
+			if !window.StartsAt.After(end) && window.EndsAt.After(end) {
+				end, frozen, extended = window.EndsAt, true, true
+			}
+		}
+	}
+	return end, frozen
+}
+
+// stringValue dereferences an optional string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+)
+
+func TestFreezeWindowEnd(t *testing.T) {
+	base := time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)
+	window := func(startHours, endHours int) *db.FreezeWindow {
+		return &db.FreezeWindow{
+			StartsAt: base.Add(time.Duration(startHours) * time.Hour),
+			EndsAt:   base.Add(time.Duration(endHours) * time.Hour),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		windows    []*db.FreezeWindow
+		wantEnd    time.Time
+		wantFrozen bool
+	}{
+		{name: "no windows", wantEnd: base},
+		{name: "window later", windows: []*db.FreezeWindow{window(1, 2)}, wantEnd: base},
+		{name: "inside window", windows: []*db.FreezeWindow{window(-1, 1)}, wantEnd: base.Add(time.Hour), wantFrozen: true},
+		{name: "window starts now", windows: []*db.FreezeWindow{window(0, 3)}, wantEnd: base.Add(3 * time.Hour), wantFrozen: true},
+		{name: "window ends now", windows: []*db.FreezeWindow{window(-2, 0)}, wantEnd: base},
+		{
+			name:       "adjoining windows extend the freeze",
+			windows:    []*db.FreezeWindow{window(1, 4), window(-1, 1)},
+			wantEnd:    base.Add(4 * time.Hour),
+			wantFrozen: true,
+		},
+		{
+			name:       "gap between windows",
+			windows:    []*db.FreezeWindow{window(-1, 1), window(2, 4)},
+			wantEnd:    base.Add(time.Hour),
+			wantFrozen: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, frozen := freezeWindowEnd(tt.windows, base)
+			if frozen != tt.wantFrozen {
+				t.Errorf("freezeWindowEnd() frozen = %v, want %v", frozen, tt.wantFrozen)
+			}
+			if !end.Equal(tt.wantEnd) {
+				t.Errorf("freezeWindowEnd() end = %v, want %v", end, tt.wantEnd)
+			}
+		})
+	}
+}
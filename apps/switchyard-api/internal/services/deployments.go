@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"time"
 
@@ -9,7 +10,10 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/deploypolicy"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/gpuquota"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -117,6 +121,10 @@ type DeployServiceRequest struct {
 	UserID        string
 	UserEmail     string
 	UserRole      string
+
+	// OverrideReason justifies deploying against the environment's deploy
+	// policy. Only admins can override.
+	OverrideReason string
 }
 
 // DeployServiceResponse represents the result of a deployment
@@ -153,6 +161,12 @@ func (s *DeploymentService) DeployService(ctx context.Context, req *DeployServic
 			"reason": "Release is not ready for deployment",
 		})
 	}
+	if release.PrunedAt != nil {
+		return nil, errors.ErrInvalidInput.WithDetails(map[string]any{
+			"pruned_at": release.PrunedAt,
+			"reason":    "Release was pruned and its image deleted",
+		})
+	}
 
 	s.logger.WithFields(logrus.Fields{
 		"service_id":     req.ServiceID,
@@ -160,6 +174,22 @@ func (s *DeploymentService) DeployService(ctx context.Context, req *DeployServic
 		"environment_id": req.EnvironmentID,
 	}).Info("Starting deployment")
 
+	override, err := checkDeployPolicy(ctx, s.repos, environmentID, types.Role(req.UserRole), req.OverrideReason)
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := s.repos.Services.GetByID(release.ServiceID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrServiceNotFound)
+	}
+	if err := checkGPUQuota(ctx, s.repos, service, environmentID, req.Replicas); err != nil {
+		return nil, err
+	}
+	if err := checkResourceQuota(ctx, s.repos, service, environmentID, req.Replicas); err != nil {
+		return nil, err
+	}
+
 	// Create deployment record
 	deployment := &types.Deployment{
 		ID:            uuid.New(),
@@ -192,6 +222,20 @@ func (s *DeploymentService) DeployService(ctx context.Context, req *DeployServic
 		},
 	})
 
+	if override != nil {
+		deploypolicy.RecordOverride(ctx, s.repos, &types.AuditLog{
+			ActorEmail:    req.UserEmail,
+			ActorRole:     types.Role(req.UserRole),
+			ResourceType:  "deployment",
+			ResourceID:    deployment.ID.String(),
+			ResourceName:  fmt.Sprintf("deployment-%s", deployment.ID.String()[:8]),
+			EnvironmentID: &environmentID,
+			Context: map[string]interface{}{
+				"release_id": req.ReleaseID,
+			},
+		}, override, req.OverrideReason)
+	}
+
 	// Deployment will be picked up by reconciler
 	return &DeployServiceResponse{
 		Deployment: deployment,
@@ -353,3 +397,71 @@ func (s *DeploymentService) ListReleases(ctx context.Context, serviceID string)
 
 	return releases, nil
 }
+
+// checkDeployPolicy checks a deploy into an environment against its deploy
+// policy. A blocked deploy goes ahead only when the actor may override the
+// policy; the overridden decision is returned so it can be recorded in the
+// audit log once the deployment exists.
+func checkDeployPolicy(ctx context.Context, repos *db.Repositories, environmentID uuid.UUID, role types.Role, overrideReason string) (*deploypolicy.Decision, error) {
+	decision, err := deploypolicy.Check(ctx, repos, environmentID, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if decision.Allowed {
+		return nil, nil
+	}
+
+	details := map[string]any{
+		"reason":          decision.Reason,
+		"next_allowed_at": decision.NextAllowedAt,
+		"allow_override":  decision.AllowOverride,
+	}
+	if overrideReason == "" {
+		blocked := fmt.Errorf("blocked by the deploy policy of the environment: %s", decision.Reason)
+		return nil, errors.ErrConflict.WithError(blocked).WithDetails(details)
+	}
+	if err := deploypolicy.CheckOverride(decision, role, overrideReason); err != nil {
+		switch err {
+		case deploypolicy.ErrOverrideForbidden:
+			return nil, errors.ErrForbidden.WithError(err).WithDetails(details)
+		case deploypolicy.ErrJustificationRequired:
+			return nil, errors.ErrValidation.WithError(err).WithDetails(details)
+		}
+		return nil, errors.ErrConflict.WithError(err).WithDetails(details)
+	}
+	return decision, nil
+}
+
+// checkGPUQuota checks a deploy of a GPU service against the GPU quota of
+// the project's team
+func checkGPUQuota(ctx context.Context, repos *db.Repositories, service *types.Service, environmentID uuid.UUID, replicas int) error {
+	err := gpuquota.Check(ctx, repos, service, environmentID, replicas)
+	if err == nil {
+		return nil
+	}
+	if stderrors.Is(err, gpuquota.ErrQuotaExceeded) {
+		return errors.ErrConflict.WithError(err).WithDetails(map[string]any{
+			"reason": "GPU quota exceeded",
+		})
+	}
+	return errors.Wrap(err, errors.ErrDatabaseError)
+}
+
+// checkResourceQuota checks the CPU and memory of a deploy against the
+// resource quotas of the service's project and team
+func checkResourceQuota(ctx context.Context, repos *db.Repositories, service *types.Service, environmentID uuid.UUID, replicas int) error {
+	return quotaError(quota.CheckDeploy(ctx, repos, service, environmentID, replicas))
+}
+
+// quotaError maps a failed quota check to an error carrying the usage and
+// limit of the exceeded quota
+func quotaError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var exceeded *quota.ExceededError
+	if stderrors.As(err, &exceeded) {
+		return errors.ErrQuotaExceeded.WithError(err).WithDetails(exceeded.Details())
+	}
+	return errors.Wrap(err, errors.ErrDatabaseError)
+}
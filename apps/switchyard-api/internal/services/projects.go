@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -10,8 +11,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/buildlimits"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
 	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/k8s"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
 	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
@@ -135,6 +139,7 @@ type CreateServiceRequest struct {
 	AutoDeployBranch string // Branch for auto-deploy (e.g., "main")
 	AutoDeployEnv    string // Environment for auto-deploy (e.g., "production")
 	BuildConfig      types.BuildConfig
+	Labels           map[string]string
 	UserID           string
 	UserEmail        string
 	UserRole         string
@@ -153,16 +158,28 @@ func (s *ProjectService) CreateService(ctx context.Context, req *CreateServiceRe
 		return nil, errors.Wrap(err, errors.ErrInvalidInput)
 	}
 
-	// Validate project exists
-	_, err = s.repos.Projects.GetByID(ctx, projectID)
+	// Validate project exists and is not in the trash
+	project, err := s.repos.Projects.GetByID(ctx, projectID)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrProjectNotFound)
 	}
+	if project.DeletedAt != nil {
+		return nil, errors.ErrProjectNotFound
+	}
 
 	// Validate input
 	if err := s.validateServiceInput(req.Name, req.GitRepo); err != nil {
 		return nil, err
 	}
+	if err := validateServiceLabels(req.Labels); err != nil {
+		return nil, err
+	}
+	if err := s.validateBuildResources(ctx, projectID, req.BuildConfig.Resources); err != nil {
+		return nil, err
+	}
+	if err := quotaError(quota.CheckNewService(ctx, s.repos, projectID)); err != nil {
+		return nil, err
+	}
 
 	// Validate user ID format (OIDC users don't have local user rows, so we don't use it for FK)
 	if _, err := uuid.Parse(req.UserID); err != nil {
@@ -203,6 +220,7 @@ func (s *ProjectService) CreateService(ctx context.Context, req *CreateServiceRe
 		AutoDeploy:       autoDeploy,
 		AutoDeployBranch: autoDeployBranch,
 		AutoDeployEnv:    autoDeployEnv,
+		Labels:           req.Labels,
 		CreatedAt:        time.Now(),
 		UpdatedAt:        time.Now(),
 	}
@@ -211,6 +229,12 @@ func (s *ProjectService) CreateService(ctx context.Context, req *CreateServiceRe
 		s.logger.Error("Failed to create service", "error", err)
 		return nil, errors.Wrap(err, errors.ErrDatabaseError)
 	}
+	if len(service.Labels) > 0 {
+		if err := s.repos.Services.UpdateLabels(ctx, service.ID, service.Labels); err != nil {
+			s.logger.Error("Failed to set service labels", "error", err)
+			return nil, errors.Wrap(err, errors.ErrDatabaseError)
+		}
+	}
 
 	// Audit log - OIDC users don't have local user row, use nil
 	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
@@ -232,6 +256,83 @@ func (s *ProjectService) CreateService(ctx context.Context, req *CreateServiceRe
 	}, nil
 }
 
+// RenameProject changes the display name of a project
+func (s *ProjectService) RenameProject(ctx context.Context, project *types.Project, name, userEmail, userRole string) error {
+	if err := s.validateProjectInput(name, project.Slug); err != nil {
+		return err
+	}
+	if name == project.Name {
+		return nil
+	}
+
+	if err := s.repos.Projects.UpdateName(ctx, project.ID, name); err != nil {
+		s.logger.Error("Failed to rename project", "error", err)
+		return errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      nil,
+		ActorEmail:   userEmail,
+		ActorRole:    types.Role(userRole),
+		Action:       "project_renamed",
+		ResourceType: "project",
+		ResourceID:   project.ID.String(),
+		ResourceName: name,
+		ProjectID:    &project.ID,
+		Outcome:      "success",
+		Context: map[string]interface{}{
+			"previous_name": project.Name,
+		},
+	})
+
+	project.Name = name
+	return nil
+}
+
+// ReplaceService stores new settings for an existing service. Empty
+// auto-deploy settings take the same defaults as on creation.
+func (s *ProjectService) ReplaceService(ctx context.Context, service *types.Service, userEmail, userRole string) error {
+	if err := s.validateServiceInput(service.Name, service.GitRepo); err != nil {
+		return err
+	}
+	if err := validateServiceLabels(service.Labels); err != nil {
+		return err
+	}
+	if err := s.validateBuildResources(ctx, service.ProjectID, service.BuildConfig.Resources); err != nil {
+		return err
+	}
+
+	if service.AutoDeployBranch == "" {
+		service.AutoDeployBranch = "main"
+	}
+	if service.AutoDeployEnv == "" {
+		service.AutoDeployEnv = s.determineAutoDeployEnv(ctx, service.ProjectID)
+	}
+
+	if err := s.repos.Services.Update(ctx, service); err != nil {
+		s.logger.Error("Failed to update service", "error", err)
+		return errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	if err := s.repos.Services.UpdateLabels(ctx, service.ID, service.Labels); err != nil {
+		s.logger.Error("Failed to update service labels", "error", err)
+		return errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	s.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorID:      nil,
+		ActorEmail:   userEmail,
+		ActorRole:    types.Role(userRole),
+		Action:       "service_updated",
+		ResourceType: "service",
+		ResourceID:   service.ID.String(),
+		ResourceName: service.Name,
+		ProjectID:    &service.ProjectID,
+		Outcome:      "success",
+	})
+
+	return nil
+}
+
 // GetService retrieves a service by ID
 func (s *ProjectService) GetService(ctx context.Context, serviceID string) (*types.Service, error) {
 	// Parse service ID
@@ -266,6 +367,22 @@ func (s *ProjectService) ListServices(ctx context.Context, projectSlug string) (
 	return services, nil
 }
 
+// ListServicesPage lists a page of the services of a project
+func (s *ProjectService) ListServicesPage(ctx context.Context, projectSlug string, params db.ListParams) ([]*types.Service, error) {
+	project, err := s.repos.Projects.GetBySlug(projectSlug)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrProjectNotFound)
+	}
+
+	services, err := s.repos.Services.ListPageByProject(ctx, project.ID, params)
+	if err != nil {
+		s.logger.Error("Failed to list services", "project_slug", projectSlug, "error", err)
+		return nil, errors.Wrap(err, errors.ErrDatabaseError)
+	}
+
+	return services, nil
+}
+
 // validateProjectInput validates project creation input
 func (s *ProjectService) validateProjectInput(name, slug string) error {
 	if strings.TrimSpace(name) == "" {
@@ -327,6 +444,41 @@ func (s *ProjectService) validateServiceInput(name, gitRepo string) error {
 	return nil
 }
 
+// validateBuildResources checks a service's build resources and holds them
+// to the build limits of the project's team
+func (s *ProjectService) validateBuildResources(ctx context.Context, projectID uuid.UUID, resources *types.BuildResources) error {
+	if err := k8s.ValidateBuildResources(resources); err != nil {
+		return errors.ErrValidation.WithDetails(map[string]any{
+			"field":  "build_config.resources",
+			"reason": err.Error(),
+		})
+	}
+	err := buildlimits.Check(ctx, s.repos, projectID, resources)
+	if stderrors.Is(err, buildlimits.ErrLimitExceeded) {
+		return errors.ErrValidation.WithDetails(map[string]any{
+			"field":  "build_config.resources",
+			"reason": err.Error(),
+		})
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabaseError)
+	}
+	return nil
+}
+
+// validateServiceLabels checks label keys are 1-63 characters
+func validateServiceLabels(labels map[string]string) error {
+	for key := range labels {
+		if key == "" || len(key) > 63 {
+			return errors.ErrValidation.WithDetails(map[string]any{
+				"field":  "labels",
+				"reason": fmt.Sprintf("Invalid label key %q: must be 1-63 characters", key),
+			})
+		}
+	}
+	return nil
+}
+
 // isValidSlug checks if a slug is valid (lowercase alphanumeric + hyphens)
 func isValidSlug(slug string) bool {
 	slugRegex := regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$`)
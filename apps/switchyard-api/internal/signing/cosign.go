@@ -3,6 +3,7 @@ package signing
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -98,25 +99,31 @@ func (s *Signer) signWithKey(ctx context.Context, imageURI string, result *SignR
 
 // VerifySignature verifies the signature of a container image
 func (s *Signer) VerifySignature(ctx context.Context, imageURI string) (bool, error) {
+	return s.VerifySignatureWithConfig(ctx, imageURI, "")
+}
+
+// VerifySignatureWithConfig verifies the signature of a container image in a
+// private registry, reading registry credentials from the Docker config in
+// dockerConfigDir. An empty dockerConfigDir uses the default config.
+func (s *Signer) VerifySignatureWithConfig(ctx context.Context, imageURI, dockerConfigDir string) (bool, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
+	var cmd *exec.Cmd
 	if s.keyless {
 		// Verify keyless signature
 		// cosign verify IMAGE_URI
-		cmd := exec.CommandContext(timeoutCtx, "cosign", "verify", imageURI)
-		_, err := cmd.Output()
-		if err != nil {
-			return false, fmt.Errorf("signature verification failed: %w", err)
-		}
-		return true, nil
+		cmd = exec.CommandContext(timeoutCtx, "cosign", "verify", imageURI)
+	} else {
+		// Verify with public key
+		// cosign verify --key env://COSIGN_PUBLIC_KEY IMAGE_URI
+		cmd = exec.CommandContext(timeoutCtx, "cosign", "verify", "--key", "env://COSIGN_PUBLIC_KEY", imageURI)
+	}
+	if dockerConfigDir != "" {
+		cmd.Env = append(os.Environ(), "DOCKER_CONFIG="+dockerConfigDir)
 	}
 
-	// Verify with public key
-	// cosign verify --key env://COSIGN_PUBLIC_KEY IMAGE_URI
-	cmd := exec.CommandContext(timeoutCtx, "cosign", "verify", "--key", "env://COSIGN_PUBLIC_KEY", imageURI)
-	_, err := cmd.Output()
-	if err != nil {
+	if _, err := cmd.Output(); err != nil {
 		return false, fmt.Errorf("signature verification failed: %w", err)
 	}
 
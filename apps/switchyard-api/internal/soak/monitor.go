@@ -0,0 +1,591 @@
+// Package soak holds deployments in a soaking state under their environment's
+// policy, watching restarts and error rates before their release is marked
+// stable, and rolls back to the previous release when a soak fails.
+// Environments without a policy get the deploy guard, a short soak with
+// default limits, unless they opt out.
+package soak
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/deploypolicy"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/gpuquota"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/promotion"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/quota"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// SyncInterval is how often soaking deployments are checked
+	SyncInterval = 30 * time.Second
+
+	// MaxDurationMinutes caps the soak of a policy at one day
+	MaxDurationMinutes = 24 * 60
+
+	// deploymentLabel selects the pods of a deployment
+	deploymentLabel = "enclii.dev/deployment"
+
+	// Deploy guard limits, for environments without a soak policy
+	GuardDurationMinutes = 10
+	GuardMaxRestarts     = 3
+	GuardMaxErrorRate    = 0.05
+)
+
+// ErrReleaseNotStable is returned when a release is deployed into an
+// environment that requires stable releases before it passed a soak elsewhere
+var ErrReleaseNotStable = errors.New("release has not passed a soak in another environment")
+
+// Scheduler queues a deployment for reconciliation
+type Scheduler interface {
+	ScheduleReconciliation(deploymentID string, priority int) error
+}
+
+// EventSender delivers rollback events to a project's webhooks
+type EventSender interface {
+	SendEvent(ctx context.Context, projectID uuid.UUID, event *types.WebhookEvent) error
+}
+
+// Monitor starts and evaluates deployment soaks
+type Monitor struct {
+	repos      *db.Repositories
+	kube       kubernetes.Interface
+	errorRates ErrorRateSource
+	scheduler  Scheduler
+	events     EventSender
+	logger     *logrus.Logger
+}
+
+// NewMonitor creates the soak monitor
+func NewMonitor(repos *db.Repositories, kube kubernetes.Interface, logger *logrus.Logger) *Monitor {
+	return &Monitor{
+		repos:  repos,
+		kube:   kube,
+		logger: logger,
+	}
+}
+
+// SetErrorRateSource sets where error rates are read from. Without one,
+// policies with a max error rate only check restarts.
+func (m *Monitor) SetErrorRateSource(source ErrorRateSource) {
+	m.errorRates = source
+}
+
+// SetScheduler sets what reconciles rollback deployments right away.
+// Without one, they are picked up by the next pending work scan.
+func (m *Monitor) SetScheduler(scheduler Scheduler) {
+	m.scheduler = scheduler
+}
+
+// SetEventSender sets where rollbacks are announced. Without one, they are
+// only logged.
+func (m *Monitor) SetEventSender(events EventSender) {
+	m.events = events
+}
+
+// GuardPolicy is the soak policy of the deploy guard: a short soak that
+// rolls back deployments that crash or fail requests
+func GuardPolicy(environmentID uuid.UUID) *types.SoakPolicy {
+	maxErrorRate := GuardMaxErrorRate
+	return &types.SoakPolicy{
+		EnvironmentID:   environmentID,
+		DurationMinutes: GuardDurationMinutes,
+		MaxRestarts:     GuardMaxRestarts,
+		MaxErrorRate:    &maxErrorRate,
+		AutoRollback:    true,
+	}
+}
+
+// PolicyFor returns the soak policy deployments to an environment are held
+// to: its own soak policy, or else the deploy guard unless the environment
+// opted out. It returns sql.ErrNoRows when there is neither.
+func PolicyFor(ctx context.Context, repos *db.Repositories, env *types.Environment) (*types.SoakPolicy, error) {
+	policy, err := repos.Soaks.GetPolicy(ctx, env.ID)
+	if errors.Is(err, sql.ErrNoRows) && !env.DeployGuardDisabled {
+		return GuardPolicy(env.ID), nil
+	}
+	return policy, err
+}
+
+// ValidatePolicy checks the limits of a soak policy
+func ValidatePolicy(policy *types.SoakPolicy) error {
+	if policy.DurationMinutes < 0 || policy.DurationMinutes > MaxDurationMinutes {
+		return fmt.Errorf("duration_minutes must be between 0 and %d", MaxDurationMinutes)
+	}
+	if policy.MaxRestarts < 0 {
+		return fmt.Errorf("max_restarts must not be negative")
+	}
+	if policy.MaxErrorRate != nil && (*policy.MaxErrorRate < 0 || *policy.MaxErrorRate > 1) {
+		return fmt.Errorf("max_error_rate must be a fraction between 0 and 1")
+	}
+	return nil
+}
+
+// CheckPromotion returns ErrReleaseNotStable when the environment requires
+// stable releases and the release has not passed a soak in another one
+func CheckPromotion(ctx context.Context, repos *db.Repositories, releaseID, environmentID uuid.UUID) error {
+	policy, err := repos.Soaks.GetPolicy(ctx, environmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get soak policy: %w", err)
+	}
+	if !policy.RequireStable {
+		return nil
+	}
+
+	stable, err := repos.Soaks.IsReleaseStable(ctx, releaseID, environmentID)
+	if err != nil {
+		return fmt.Errorf("failed to check release stability: %w", err)
+	}
+	if !stable {
+		return ErrReleaseNotStable
+	}
+	return nil
+}
+
+// Begin starts the soak of a deployment that just became running, under its
+// environment's soak policy or the deploy guard. Deployments are soaked once;
+// redeploys of a release that already passed in the environment and
+// rollbacks of failed soaks are not soaked.
+func (m *Monitor) Begin(ctx context.Context, deploymentID uuid.UUID) error {
+	deployment, err := m.repos.Deployments.GetByID(ctx, deploymentID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	env, err := m.repos.Environments.GetByID(ctx, deployment.EnvironmentID)
+	if err != nil {
+		return fmt.Errorf("failed to get environment: %w", err)
+	}
+
+	policy, err := PolicyFor(ctx, m.repos, env)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get soak policy: %w", err)
+	}
+	if policy.DurationMinutes == 0 {
+		return nil
+	}
+
+	rollback, err := m.repos.Soaks.IsRollback(ctx, deployment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check for soak rollback: %w", err)
+	}
+	if rollback {
+		return nil
+	}
+	passed, err := m.repos.Soaks.HasPassed(ctx, deployment.ReleaseID, deployment.EnvironmentID)
+	if err != nil {
+		return fmt.Errorf("failed to check earlier soaks: %w", err)
+	}
+	if passed {
+		return nil
+	}
+
+	baseline, err := m.restarts(ctx, env.KubeNamespace, deployment.ID)
+	if err != nil {
+		m.logger.WithError(err).WithField("deployment_id", deployment.ID).Warn("Failed to read pod restarts, soaking from zero")
+	}
+
+	now := time.Now()
+	soak := &types.DeploymentSoak{
+		DeploymentID:     deployment.ID,
+		EnvironmentID:    deployment.EnvironmentID,
+		ReleaseID:        deployment.ReleaseID,
+		StartedAt:        now,
+		EndsAt:           now.Add(time.Duration(policy.DurationMinutes) * time.Minute),
+		BaselineRestarts: baseline,
+	}
+	started, err := m.repos.Soaks.Begin(ctx, soak)
+	if err != nil {
+		return fmt.Errorf("failed to start soak: %w", err)
+	}
+	if started {
+		m.logger.WithFields(logrus.Fields{
+			"deployment_id": deployment.ID,
+			"release_id":    deployment.ReleaseID,
+			"ends_at":       soak.EndsAt,
+		}).Info("Deployment soaking")
+	}
+
+	return nil
+}
+
+// Sync evaluates the deployments that are soaking
+func (m *Monitor) Sync(ctx context.Context) {
+	soaks, err := m.repos.Soaks.ListSoaking(ctx)
+	if err != nil {
+		m.logger.WithError(err).Error("Failed to list soaking deployments")
+		return
+	}
+
+	for _, soak := range soaks {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := m.sync(ctx, soak); err != nil {
+			m.logger.WithError(err).WithField("deployment_id", soak.DeploymentID).Warn("Failed to sync deployment soak")
+		}
+	}
+}
+
+// sync evaluates one soak. Errors are retried on the next sync.
+func (m *Monitor) sync(ctx context.Context, soak *types.DeploymentSoak) error {
+	deployment, err := m.repos.Deployments.GetByID(ctx, soak.DeploymentID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+	switch deployment.Status {
+	case types.DeploymentStatusPending:
+		return nil // Being reconciled again; judge it once it settles
+	case types.DeploymentStatusFailed:
+		return m.complete(ctx, soak, types.SoakStatusFailed, "Deployment failed while soaking")
+	}
+
+	release, err := m.repos.Releases.GetByID(deployment.ReleaseID)
+	if err != nil {
+		return fmt.Errorf("failed to get release: %w", err)
+	}
+	current, err := m.repos.Deployments.GetCurrentByServiceAndEnvironment(ctx, release.ServiceID, deployment.EnvironmentID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get current deployment: %w", err)
+	}
+	if current != nil && current.ID != deployment.ID && current.CreatedAt.After(deployment.CreatedAt) {
+		return m.complete(ctx, soak, types.SoakStatusFailed, fmt.Sprintf("Superseded by deployment %s before the soak ended", current.ID))
+	}
+
+	env, err := m.repos.Environments.GetByID(ctx, soak.EnvironmentID)
+	if err != nil {
+		return fmt.Errorf("failed to get environment: %w", err)
+	}
+	policy, err := PolicyFor(ctx, m.repos, env)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.complete(ctx, soak, types.SoakStatusPassed, "Soak policy was removed and the deploy guard is off")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get soak policy: %w", err)
+	}
+	restarts, err := m.restarts(ctx, env.KubeNamespace, soak.DeploymentID)
+	if err != nil {
+		return err
+	}
+	soak.Restarts = restarts - soak.BaselineRestarts
+	if soak.Restarts < 0 {
+		soak.Restarts = 0 // Pods were replaced and their counts started over
+	}
+
+	if policy.MaxErrorRate != nil && m.errorRates != nil {
+		service, err := m.repos.Services.GetByID(release.ServiceID)
+		if err != nil {
+			return fmt.Errorf("failed to get service: %w", err)
+		}
+		rate, err := m.errorRates.ErrorRate(ctx, env.KubeNamespace, service.Name, soak.StartedAt)
+		if err != nil {
+			m.logger.WithError(err).WithField("deployment_id", soak.DeploymentID).Warn("Failed to read error rate, checking restarts only")
+		} else if rate != nil {
+			soak.ErrorRate = rate
+		}
+	}
+
+	status, message := Evaluate(policy, soak, time.Now())
+	if status == types.SoakStatusSoaking {
+		return m.repos.Soaks.Update(ctx, soak)
+	}
+	if status == types.SoakStatusFailed && policy.AutoRollback {
+		return m.rollback(ctx, soak, deployment, release, env, message)
+	}
+	if err := m.complete(ctx, soak, status, message); err != nil {
+		return err
+	}
+	if status == types.SoakStatusPassed && env.AutoPromote && env.PromotesTo != nil {
+		m.autoPromote(ctx, deployment, release, env)
+	}
+	return nil
+}
+
+// Evaluate decides the outcome of a soak from its latest observations. The
+// soak fails as soon as a limit is exceeded and passes once it ran its course.
+func Evaluate(policy *types.SoakPolicy, soak *types.DeploymentSoak, now time.Time) (types.SoakStatus, string) {
+	if soak.Restarts > policy.MaxRestarts {
+		return types.SoakStatusFailed, fmt.Sprintf("%d container restarts while soaking, at most %d allowed", soak.Restarts, policy.MaxRestarts)
+	}
+	if policy.MaxErrorRate != nil && soak.ErrorRate != nil && *soak.ErrorRate > *policy.MaxErrorRate {
+		return types.SoakStatusFailed, fmt.Sprintf("Error rate %.2f%% while soaking, at most %.2f%% allowed", *soak.ErrorRate*100, *policy.MaxErrorRate*100)
+	}
+	if !now.Before(soak.EndsAt) {
+		return types.SoakStatusPassed, ""
+	}
+	return types.SoakStatusSoaking, ""
+}
+
+// complete records the outcome of a soak
+func (m *Monitor) complete(ctx context.Context, soak *types.DeploymentSoak, status types.SoakStatus, message string) error {
+	soak.Status = status
+	soak.Message = message
+	if err := m.repos.Soaks.Update(ctx, soak); err != nil {
+		return fmt.Errorf("failed to save soak: %w", err)
+	}
+
+	logger := m.logger.WithFields(logrus.Fields{
+		"deployment_id": soak.DeploymentID,
+		"release_id":    soak.ReleaseID,
+	})
+	if status == types.SoakStatusPassed {
+		logger.Info("Deployment passed soak, release is stable")
+	} else {
+		logger.WithField("reason", message).Warn("Deployment failed soak")
+	}
+	return nil
+}
+
+// rollback fails a soak and redeploys the release that ran in the
+// environment before it
+func (m *Monitor) rollback(ctx context.Context, soak *types.DeploymentSoak, deployment *types.Deployment, release *types.Release, env *types.Environment, message string) error {
+	previous, err := m.repos.Deployments.GetPreviousRelease(ctx, deployment, release.ServiceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m.complete(ctx, soak, types.SoakStatusFailed, message+"; no previous release to roll back to")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get previous release: %w", err)
+	}
+
+	now := time.Now()
+	rollback := &types.Deployment{
+		ID:            uuid.New(),
+		ReleaseID:     previous.ReleaseID,
+		EnvironmentID: deployment.EnvironmentID,
+		Replicas:      deployment.Replicas,
+		Status:        types.DeploymentStatusPending,
+		Health:        types.HealthStatusUnknown,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	soak.Status = types.SoakStatusFailed
+	soak.Message = message + "; rolled back to release " + previous.ReleaseID.String()
+	soak.RollbackDeploymentID = &rollback.ID
+
+	err = m.repos.WithTransaction(ctx, func(tx *db.Repositories) error {
+		if err := tx.Deployments.Create(rollback); err != nil {
+			return fmt.Errorf("failed to create rollback deployment: %w", err)
+		}
+		return tx.Soaks.Update(ctx, soak)
+	})
+	if err != nil {
+		return err
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"deployment_id":          soak.DeploymentID,
+		"release_id":             soak.ReleaseID,
+		"rollback_deployment_id": rollback.ID,
+		"rollback_release_id":    previous.ReleaseID,
+		"reason":                 message,
+	}).Warn("Deployment failed soak, rolling back")
+
+	if m.scheduler != nil {
+		if err := m.scheduler.ScheduleReconciliation(rollback.ID.String(), 1); err != nil {
+			m.logger.WithError(err).WithField("deployment_id", rollback.ID).Warn("Failed to schedule soak rollback, leaving it to the pending work scan")
+		}
+	}
+	m.notifyRollback(ctx, deployment, release, env, soak.Message)
+	return nil
+}
+
+// autoPromote promotes a release that passed its soak to the environment
+// its environment promotes to. Auto-promotions never override the deploy
+// policy or quotas of the target and never take local builds to production;
+// a blocked auto-promotion is logged and audited, and left to a manual
+// promotion.
+func (m *Monitor) autoPromote(ctx context.Context, deployment *types.Deployment, release *types.Release, from *types.Environment) {
+	logger := m.logger.WithFields(logrus.Fields{
+		"deployment_id": deployment.ID,
+		"release_id":    release.ID,
+	})
+
+	to, err := m.repos.Environments.GetByID(ctx, *from.PromotesTo)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get environment to auto-promote to")
+		return
+	}
+	service, err := m.repos.Services.GetByID(release.ServiceID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get service to auto-promote")
+		return
+	}
+	logger = logger.WithField("environment", to.Name)
+
+	current, err := m.repos.Deployments.GetCurrentByServiceAndEnvironment(ctx, release.ServiceID, to.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.WithError(err).Warn("Failed to get current deployment to auto-promote over")
+		return
+	}
+	if current != nil && current.ReleaseID == release.ID {
+		return
+	}
+
+	blocked := func(reason string) {
+		logger.WithField("reason", reason).Info("Auto-promotion skipped")
+		m.auditPromotion(ctx, "release.promotion_blocked", "denied", service, release, from, to, map[string]interface{}{
+			"reason": reason,
+		})
+	}
+
+	if to.Name == "production" {
+		if _, err := m.repos.BuildContexts.GetByReleaseID(ctx, release.ID); err == nil {
+			blocked("release was built from a local build context")
+			return
+		}
+	}
+	if err := CheckPromotion(ctx, m.repos, release.ID, to.ID); err != nil {
+		if !errors.Is(err, ErrReleaseNotStable) {
+			logger.WithError(err).Warn("Failed to check release stability for auto-promotion")
+			return
+		}
+		blocked(err.Error())
+		return
+	}
+	if decision, err := deploypolicy.Check(ctx, m.repos, to.ID, time.Now()); err != nil {
+		logger.WithError(err).Warn("Failed to check deploy policy for auto-promotion")
+		return
+	} else if !decision.Allowed {
+		blocked(decision.Reason)
+		return
+	}
+	replicas, err := promotion.Replicas(ctx, m.repos, service.ID, to)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get scale to auto-promote at")
+		return
+	}
+	if err := gpuquota.Check(ctx, m.repos, service, to.ID, replicas); err != nil {
+		if !errors.Is(err, gpuquota.ErrQuotaExceeded) {
+			logger.WithError(err).Warn("Failed to check GPU quota for auto-promotion")
+			return
+		}
+		blocked(err.Error())
+		return
+	}
+	if err := quota.CheckDeploy(ctx, m.repos, service, to.ID, replicas); err != nil {
+		if !errors.Is(err, quota.ErrQuotaExceeded) {
+			logger.WithError(err).Warn("Failed to check resource quota for auto-promotion")
+			return
+		}
+		blocked(err.Error())
+		return
+	}
+
+	promoted, rollout, err := promotion.Promote(ctx, m.repos, release, from, to, replicas, "", true)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to auto-promote release")
+		return
+	}
+	logger.WithField("promoted_deployment_id", rollout.ID).Info("Release passed soak, auto-promoted")
+
+	if m.scheduler != nil {
+		if err := m.scheduler.ScheduleReconciliation(rollout.ID.String(), 1); err != nil {
+			m.logger.WithError(err).WithField("deployment_id", rollout.ID).Warn("Failed to schedule auto-promotion, leaving it to the pending work scan")
+		}
+	}
+	m.auditPromotion(ctx, "release.promoted", "success", service, release, from, to, map[string]interface{}{
+		"deployment_id": rollout.ID.String(),
+		"image_uri":     promoted.ImageURI,
+		"image_digest":  promoted.ImageDigest,
+		"auto":          true,
+	})
+}
+
+// auditPromotion records an auto-promotion, or why one was skipped
+func (m *Monitor) auditPromotion(ctx context.Context, action, outcome string, service *types.Service, release *types.Release, from, to *types.Environment, details map[string]interface{}) {
+	details["from"] = from.Name
+	details["to"] = to.Name
+	if err := m.repos.AuditLogs.Log(ctx, &types.AuditLog{
+		ActorEmail:    "auto-promote@system.enclii.dev",
+		ActorRole:     types.RoleSystem,
+		Action:        action,
+		ResourceType:  "release",
+		ResourceID:    release.ID.String(),
+		ResourceName:  service.Name,
+		ProjectID:     &service.ProjectID,
+		EnvironmentID: &to.ID,
+		Outcome:       outcome,
+		Context:       details,
+	}); err != nil {
+		m.logger.WithError(err).WithField("release_id", release.ID).Warn("Failed to audit auto-promotion")
+	}
+}
+
+// notifyRollback announces a rollback to the project's webhooks
+func (m *Monitor) notifyRollback(ctx context.Context, deployment *types.Deployment, release *types.Release, env *types.Environment, message string) {
+	if m.events == nil {
+		return
+	}
+	logger := m.logger.WithField("deployment_id", deployment.ID)
+
+	service, err := m.repos.Services.GetByID(release.ServiceID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get service for rollback notification")
+		return
+	}
+	project, err := m.repos.Projects.GetByID(ctx, service.ProjectID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get project for rollback notification")
+		return
+	}
+
+	event := &types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      types.WebhookEventDeploymentRolledBack,
+		Timestamp: time.Now(),
+		ProjectID: project.ID,
+		Project: types.WebhookProjectInfo{
+			ID:   project.ID,
+			Name: project.Name,
+			Slug: project.Slug,
+		},
+		Deployment: &types.WebhookDeploymentInfo{
+			ID:          deployment.ID,
+			ServiceName: service.Name,
+			Environment: env.Name,
+			Status:      "rolled_back",
+			CommitSHA:   release.GitSHA,
+			Error:       message,
+		},
+	}
+	if err := m.events.SendEvent(ctx, project.ID, event); err != nil {
+		logger.WithError(err).Warn("Failed to send rollback notification")
+	}
+}
+
+// restarts sums the container restarts of a deployment's pods
+func (m *Monitor) restarts(ctx context.Context, namespace string, deploymentID uuid.UUID) (int, error) {
+	pods, err := m.kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: deploymentLabel + "=" + deploymentID.String(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list deployment pods: %w", err)
+	}
+	return countRestarts(pods.Items), nil
+}
+
+// countRestarts sums the restart counts of the containers of pods
+func countRestarts(pods []corev1.Pod) int {
+	total := 0
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			total += int(status.RestartCount)
+		}
+	}
+	return total
+}
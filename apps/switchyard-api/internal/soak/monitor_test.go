@@ -0,0 +1,87 @@
+package soak
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestEvaluate(t *testing.T) {
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+
+	tests := []struct {
+		name      string
+		policy    types.SoakPolicy
+		restarts  int
+		errorRate *float64
+		now       time.Time
+		want      types.SoakStatus
+	}{
+		{name: "still soaking", policy: types.SoakPolicy{MaxRestarts: 1}, restarts: 1, now: start.Add(10 * time.Minute), want: types.SoakStatusSoaking},
+		{name: "ran its course", policy: types.SoakPolicy{MaxRestarts: 1}, now: end, want: types.SoakStatusPassed},
+		{name: "too many restarts", policy: types.SoakPolicy{MaxRestarts: 1}, restarts: 2, now: start.Add(time.Minute), want: types.SoakStatusFailed},
+		{name: "restarts checked after the end", policy: types.SoakPolicy{}, restarts: 1, now: end.Add(time.Minute), want: types.SoakStatusFailed},
+		{name: "error rate over limit", policy: types.SoakPolicy{MaxErrorRate: floatPtr(0.01)}, errorRate: floatPtr(0.05), now: start.Add(time.Minute), want: types.SoakStatusFailed},
+		{name: "error rate at limit", policy: types.SoakPolicy{MaxErrorRate: floatPtr(0.01)}, errorRate: floatPtr(0.01), now: end, want: types.SoakStatusPassed},
+		{name: "no traffic", policy: types.SoakPolicy{MaxErrorRate: floatPtr(0.01)}, now: end, want: types.SoakStatusPassed},
+		{name: "error rate not checked", policy: types.SoakPolicy{}, errorRate: floatPtr(0.5), now: end, want: types.SoakStatusPassed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			soak := &types.DeploymentSoak{StartedAt: start, EndsAt: end, Restarts: tt.restarts, ErrorRate: tt.errorRate}
+			got, message := Evaluate(&tt.policy, soak, tt.now)
+			if got != tt.want {
+				t.Errorf("Evaluate() = %s (%q), want %s", got, message, tt.want)
+			}
+			if (got == types.SoakStatusFailed) != (message != "") {
+				t.Errorf("Evaluate() message = %q for status %s", message, got)
+			}
+		})
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  types.SoakPolicy
+		wantErr bool
+	}{
+		{name: "valid", policy: types.SoakPolicy{DurationMinutes: 30, MaxRestarts: 2, MaxErrorRate: floatPtr(0.02)}},
+		{name: "disabled", policy: types.SoakPolicy{}},
+		{name: "deploy guard", policy: *GuardPolicy(uuid.New())},
+		{name: "negative duration", policy: types.SoakPolicy{DurationMinutes: -1}, wantErr: true},
+		{name: "duration over a day", policy: types.SoakPolicy{DurationMinutes: MaxDurationMinutes + 1}, wantErr: true},
+		{name: "negative restarts", policy: types.SoakPolicy{DurationMinutes: 30, MaxRestarts: -1}, wantErr: true},
+		{name: "error rate as percent", policy: types.SoakPolicy{DurationMinutes: 30, MaxErrorRate: floatPtr(5)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePolicy(&tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCountRestarts(t *testing.T) {
+	pods := []corev1.Pod{
+		{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 2}, {RestartCount: 1}}}},
+		{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 0}}}},
+		{},
+	}
+
+	if got := countRestarts(pods); got != 3 {
+		t.Errorf("countRestarts() = %d, want 3", got)
+	}
+}
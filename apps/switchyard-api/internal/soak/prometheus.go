@@ -0,0 +1,61 @@
+package soak
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/promquery"
+)
+
+// DefaultErrorRateQuery is the fraction of 5xx responses the ingress
+// controller served for a service. $namespace, $service and $window are
+// replaced before the query runs.
+const DefaultErrorRateQuery = `sum(rate(nginx_ingress_controller_requests{exported_namespace="$namespace",exported_service="$service",status=~"5.."}[$window]))` +
+	` / sum(rate(nginx_ingress_controller_requests{exported_namespace="$namespace",exported_service="$service"}[$window]))`
+
+// minErrorRateWindow is the shortest window rates are taken over, so the
+// first checks of a soak still see a few scrapes
+const minErrorRateWindow = time.Minute
+
+// ErrorRateSource reports the fraction of failed requests a service served
+// since a point in time. It returns nil when there was no traffic to judge.
+type ErrorRateSource interface {
+	ErrorRate(ctx context.Context, namespace, service string, since time.Time) (*float64, error)
+}
+
+// PrometheusErrorRates reads error rates from a Prometheus server
+type PrometheusErrorRates struct {
+	client *promquery.Client
+	query  string
+}
+
+// NewPrometheusErrorRates creates an error rate source for the Prometheus
+// server at baseURL. An empty query uses DefaultErrorRateQuery.
+func NewPrometheusErrorRates(baseURL, query string) *PrometheusErrorRates {
+	if query == "" {
+		query = DefaultErrorRateQuery
+	}
+	return &PrometheusErrorRates{client: promquery.NewClient(baseURL), query: query}
+}
+
+// ErrorRate runs the error rate query for a service over the time since the soak started
+func (p *PrometheusErrorRates) ErrorRate(ctx context.Context, namespace, service string, since time.Time) (*float64, error) {
+	window := time.Since(since)
+	if window < minErrorRateWindow {
+		window = minErrorRateWindow
+	}
+	query := strings.NewReplacer(
+		"$namespace", namespace,
+		"$service", service,
+		"$window", fmt.Sprintf("%ds", int(window.Seconds())),
+	).Replace(p.query)
+
+	samples, err := p.client.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// No series or NaN means there were no requests in the window
+	return promquery.Scalar(samples)
+}
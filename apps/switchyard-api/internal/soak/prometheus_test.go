@@ -0,0 +1,40 @@
+package soak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusErrorRatesQuery(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("path = %s, want /api/v1/query", r.URL.Path)
+		}
+		query = r.URL.Query().Get("query")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1760616000,"0"]}]}}`))
+	}))
+	defer server.Close()
+
+	source := NewPrometheusErrorRates(server.URL+"/", "")
+	rate, err := source.ErrorRate(context.Background(), "enclii-shop-prod", "api", time.Now().Add(-10*time.Minute))
+	if err != nil {
+		t.Fatalf("ErrorRate() error = %v", err)
+	}
+	if rate == nil || *rate != 0 {
+		t.Errorf("ErrorRate() = %v, want 0", rate)
+	}
+
+	for _, want := range []string{`exported_namespace="enclii-shop-prod"`, `exported_service="api"`, `[600s]`} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query %q does not contain %s", query, want)
+		}
+	}
+	if strings.Contains(query, "$") {
+		t.Errorf("query %q has unreplaced placeholders", query)
+	}
+}
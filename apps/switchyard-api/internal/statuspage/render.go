@@ -0,0 +1,113 @@
+package statuspage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+var pageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"label":   healthLabel,
+	"percent": formatPercent,
+	"banner": func(h types.PublicHealth) string {
+		if h == types.PublicHealthOperational {
+			return "All systems operational"
+		}
+		return healthLabel(h)
+	},
+	"dayClass": func(day types.UptimeDay) string {
+		switch {
+		case day.Uptime == nil:
+			return "none"
+		case *day.Uptime >= 99.9:
+			return "operational"
+		case *day.Uptime >= 95:
+			return "degraded"
+		default:
+			return "outage"
+		}
+	},
+	"time": func(t time.Time) string { return t.UTC().Format("Jan 2, 15:04 MST") },
+}).Parse(pageHTML))
+
+// Render writes a status as a self-contained HTML page
+func Render(w io.Writer, status *types.PublicStatus) error {
+	return pageTemplate.Execute(w, status)
+}
+
+func healthLabel(h types.PublicHealth) string {
+	switch h {
+	case types.PublicHealthOutage:
+		return "Outage"
+	case types.PublicHealthDegraded:
+		return "Degraded performance"
+	default:
+		return "Operational"
+	}
+}
+
+func formatPercent(uptime *float64) string {
+	if uptime == nil {
+		return "No data"
+	}
+	return fmt.Sprintf("%.2f%%", *uptime)
+}
+
+const pageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="60">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;background:#f6f7f9;color:#1f2328}
+main{max-width:760px;margin:0 auto;padding:32px 16px}
+h1{font-size:28px;margin:0 0 8px}
+h2{font-size:18px;margin:32px 0 12px}
+p.description{color:#59636e;margin:0 0 24px}
+.banner{border-radius:8px;padding:16px 20px;color:#fff;font-weight:600;font-size:18px}
+.banner.operational,.day.operational{background:#1f883d}
+.banner.degraded,.day.degraded{background:#bf8700}
+.banner.outage,.day.outage{background:#cf222e}
+.card{background:#fff;border:1px solid #d1d9e0;border-radius:8px;padding:16px 20px;margin-bottom:12px}
+.row{display:flex;justify-content:space-between;align-items:baseline;gap:12px}
+.name{font-weight:600}
+.state.operational{color:#1f883d}.state.degraded{color:#9a6700}.state.outage{color:#cf222e}
+.bars{display:flex;gap:2px;margin:12px 0 6px}
+.day{flex:1;height:28px;border-radius:2px}
+.day.none{background:#d1d9e0}
+.muted{color:#59636e;font-size:13px}
+.incident .title{font-weight:600}
+footer{margin-top:32px;text-align:center}
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+{{with .Description}}<p class="description">{{.}}</p>{{end}}
+<div class="banner {{.Status}}">{{banner .Status}}</div>
+
+<h2>Services</h2>
+{{range .Services}}<div class="card">
+<div class="row"><span class="name">{{.Name}}</span><span class="state {{.Status}}">{{label .Status}}</span></div>
+<div class="bars">{{range .History}}<div class="day {{dayClass .}}" title="{{.Date}}: {{percent .Uptime}}"></div>{{end}}</div>
+<div class="row muted"><span>30 days ago</span><span>{{percent .Uptime}} uptime</span><span>Today</span></div>
+</div>
+{{else}}<div class="card muted">No services</div>
+{{end}}
+<h2>Recent incidents</h2>
+{{range .Incidents}}<div class="card incident">
+<div class="row"><span class="title">{{.Service}}: {{.Title}}</span><span class="state {{if .Ongoing}}degraded{{else}}operational{{end}}">{{if .Ongoing}}Ongoing{{else}}Resolved{{end}}</span></div>
+<div class="muted">Started {{time .StartedAt}}{{with .ResolvedAt}} &middot; resolved {{time .}}{{end}}</div>
+</div>
+{{else}}<div class="card muted">No incidents in the last 7 days</div>
+{{end}}
+<footer class="muted">Updated {{time .GeneratedAt}}</footer>
+</main>
+</body>
+</html>
+`
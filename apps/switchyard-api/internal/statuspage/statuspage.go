@@ -0,0 +1,317 @@
+// Package statuspage builds the public status pages of projects. A page
+// shows the health of the services of one environment, incidents derived
+// from fired alerts and failed deployments, and daily uptime from the
+// services' uptime checks. Only names, states and times are published.
+package statuspage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// HistoryDays is how many days of uptime a page shows
+	HistoryDays = 30
+
+	// IncidentWindow is how far back incidents are shown
+	IncidentWindow = 7 * 24 * time.Hour
+
+	// maxIncidents is the most incidents a page shows
+	maxIncidents = 20
+
+	// cacheTTL is how long a built status is served before it is rebuilt,
+	// so that unauthenticated traffic does not reach the database on
+	// every request
+	cacheTTL = 30 * time.Second
+
+	// deployFailedTitle is the title of incidents from failed deployments
+	deployFailedTitle = "Deployment failed"
+)
+
+// Builder builds and caches the public status of projects
+type Builder struct {
+	repos *db.Repositories
+	now   func() time.Time
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedStatus
+}
+
+type cachedStatus struct {
+	status    *types.PublicStatus
+	updatedAt time.Time // UpdatedAt of the page it was built for
+	expires   time.Time
+}
+
+// NewBuilder creates a builder
+func NewBuilder(repos *db.Repositories) *Builder {
+	return &Builder{
+		repos: repos,
+		now:   time.Now,
+		cache: make(map[uuid.UUID]cachedStatus),
+	}
+}
+
+// Build returns the public status of a page, from the cache when it was
+// built recently for the page's current settings
+func (b *Builder) Build(ctx context.Context, page *types.StatusPage) (*types.PublicStatus, error) {
+	now := b.now()
+
+	b.mu.Lock()
+	cached, ok := b.cache[page.ProjectID]
+	b.mu.Unlock()
+	if ok && now.Before(cached.expires) && cached.updatedAt.Equal(page.UpdatedAt) {
+		return cached.status, nil
+	}
+
+	in, err := b.load(ctx, page, now)
+	if err != nil {
+		return nil, err
+	}
+	status := summarize(page, in, now)
+
+	b.mu.Lock()
+	b.cache[page.ProjectID] = cachedStatus{status: status, updatedAt: page.UpdatedAt, expires: now.Add(cacheTTL)}
+	b.mu.Unlock()
+	return status, nil
+}
+
+// Invalidate drops the cached status of a project, after its page changed
+func (b *Builder) Invalidate(projectID uuid.UUID) {
+	b.mu.Lock()
+	delete(b.cache, projectID)
+	b.mu.Unlock()
+}
+
+// inputs are the records a status is summarized from
+type inputs struct {
+	services []*types.Service
+	checks   []*types.UptimeCheck
+	alerts   []*types.Alert
+	failures []db.DeployFailure
+	days     []db.ServiceUptimeDay
+}
+
+func (b *Builder) load(ctx context.Context, page *types.StatusPage, now time.Time) (*inputs, error) {
+	var in inputs
+	var err error
+
+	if in.services, err = b.repos.Services.ListByProject(page.ProjectID); err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	if in.checks, err = b.repos.UptimeChecks.ListByEnvironment(ctx, page.EnvironmentID); err != nil {
+		return nil, err
+	}
+	if in.alerts, err = b.repos.Alerts.ListFiredByEnvironment(ctx, page.EnvironmentID, now.Add(-IncidentWindow)); err != nil {
+		return nil, err
+	}
+	if in.failures, err = b.repos.StatusPages.ListDeployFailures(ctx, page.EnvironmentID, now.Add(-IncidentWindow), 100); err != nil {
+		return nil, err
+	}
+	if in.days, err = b.repos.UptimeChecks.DailyUptimeByEnvironment(ctx, page.EnvironmentID, historyStart(now)); err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// historyStart is the start of the first UTC day of the uptime history
+func historyStart(now time.Time) time.Time {
+	today := now.UTC().Truncate(24 * time.Hour)
+	return today.AddDate(0, 0, -(HistoryDays - 1))
+}
+
+// summarize derives the public status of a page from its records. A
+// service has an outage while one of its uptime checks is down, and is
+// degraded while one of its alerts is firing or its latest deployment
+// failed.
+func summarize(page *types.StatusPage, in *inputs, now time.Time) *types.PublicStatus {
+	names := make(map[uuid.UUID]string, len(in.services))
+	health := make(map[uuid.UUID]types.PublicHealth, len(in.services))
+	for _, service := range in.services {
+		names[service.ID] = service.Name
+		health[service.ID] = types.PublicHealthOperational
+	}
+	worsen := func(serviceID uuid.UUID, h types.PublicHealth) {
+		if current, ok := health[serviceID]; ok && severity(h) > severity(current) {
+			health[serviceID] = h
+		}
+	}
+
+	incidents := []types.StatusIncident{}
+	for _, alert := range in.alerts {
+		name, ok := names[alert.ServiceID]
+		if !ok || alert.FiredAt == nil {
+			continue
+		}
+		ongoing := alert.Status == types.AlertStatusFiring
+		if ongoing {
+			worsen(alert.ServiceID, types.PublicHealthDegraded)
+		}
+		incidents = append(incidents, types.StatusIncident{
+			Service:    name,
+			Kind:       types.StatusIncidentAlert,
+			Title:      alert.RuleName,
+			Ongoing:    ongoing,
+			StartedAt:  *alert.FiredAt,
+			ResolvedAt: alert.ResolvedAt,
+		})
+	}
+	incidents = append(incidents, deployIncidents(in.failures, names, worsen)...)
+
+	for _, check := range in.checks {
+		if check.Status == types.UptimeStatusDown {
+			worsen(check.ServiceID, types.PublicHealthOutage)
+		}
+	}
+
+	sort.SliceStable(incidents, func(i, j int) bool {
+		if incidents[i].Ongoing != incidents[j].Ongoing {
+			return incidents[i].Ongoing
+		}
+		return incidents[i].StartedAt.After(incidents[j].StartedAt)
+	})
+	if len(incidents) > maxIncidents {
+		incidents = incidents[:maxIncidents]
+	}
+
+	history := dailyHistory(in.days, now)
+	overall := types.PublicHealthOperational
+	services := make([]types.PublicServiceStatus, 0, len(in.services))
+	for _, service := range in.services {
+		status := types.PublicServiceStatus{
+			Name:    service.Name,
+			Status:  health[service.ID],
+			History: history[service.ID],
+		}
+		if status.History == nil {
+			status.History = emptyHistory(now)
+		}
+		status.Uptime = meanUptime(status.History)
+		if severity(status.Status) > severity(overall) {
+			overall = status.Status
+		}
+		services = append(services, status)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	return &types.PublicStatus{
+		Title:       page.Title,
+		Description: page.Description,
+		Status:      overall,
+		Services:    services,
+		Incidents:   incidents,
+		GeneratedAt: now,
+	}
+}
+
+// deployIncidents turns failed deployments into incidents. Failures of a
+// service that the same later deployment resolved, or that none has yet,
+// are one incident starting at the first of them.
+func deployIncidents(failures []db.DeployFailure, names map[uuid.UUID]string, worsen func(uuid.UUID, types.PublicHealth)) []types.StatusIncident {
+	type key struct {
+		serviceID  uuid.UUID
+		resolvedAt time.Time
+	}
+	merged := make(map[key]*types.StatusIncident)
+	var order []key
+
+	for _, failure := range failures {
+		name, ok := names[failure.ServiceID]
+		if !ok {
+			continue
+		}
+		k := key{serviceID: failure.ServiceID}
+		if failure.ResolvedAt != nil {
+			k.resolvedAt = *failure.ResolvedAt
+		} else {
+			worsen(failure.ServiceID, types.PublicHealthDegraded)
+		}
+
+		if incident, ok := merged[k]; ok {
+			if failure.FailedAt.Before(incident.StartedAt) {
+				incident.StartedAt = failure.FailedAt
+			}
+			continue
+		}
+		merged[k] = &types.StatusIncident{
+			Service:    name,
+			Kind:       types.StatusIncidentDeployFailure,
+			Title:      deployFailedTitle,
+			Ongoing:    failure.ResolvedAt == nil,
+			StartedAt:  failure.FailedAt,
+			ResolvedAt: failure.ResolvedAt,
+		}
+		order = append(order, k)
+	}
+
+	incidents := make([]types.StatusIncident, 0, len(order))
+	for _, k := range order {
+		incidents = append(incidents, *merged[k])
+	}
+	return incidents
+}
+
+// dailyHistory lays out the daily uptime of each service over the last
+// HistoryDays days, oldest first
+func dailyHistory(days []db.ServiceUptimeDay, now time.Time) map[uuid.UUID][]types.UptimeDay {
+	start := historyStart(now)
+	history := make(map[uuid.UUID][]types.UptimeDay)
+	for _, day := range days {
+		index := int(day.Day.Sub(start) / (24 * time.Hour))
+		if index < 0 || index >= HistoryDays {
+			continue
+		}
+		if history[day.ServiceID] == nil {
+			history[day.ServiceID] = emptyHistory(now)
+		}
+		uptime := day.Uptime
+		history[day.ServiceID][index].Uptime = &uptime
+	}
+	return history
+}
+
+// emptyHistory is a history without results
+func emptyHistory(now time.Time) []types.UptimeDay {
+	start := historyStart(now)
+	history := make([]types.UptimeDay, HistoryDays)
+	for i := range history {
+		history[i].Date = start.AddDate(0, 0, i).Format("2006-01-02")
+	}
+	return history
+}
+
+// meanUptime averages the days of a history that have results
+func meanUptime(history []types.UptimeDay) *float64 {
+	var sum float64
+	var n int
+	for _, day := range history {
+		if day.Uptime != nil {
+			sum += *day.Uptime
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	mean := sum / float64(n)
+	return &mean
+}
+
+func severity(h types.PublicHealth) int {
+	switch h {
+	case types.PublicHealthOutage:
+		return 2
+	case types.PublicHealthDegraded:
+		return 1
+	default:
+		return 0
+	}
+}
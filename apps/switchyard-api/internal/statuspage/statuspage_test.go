@@ -0,0 +1,143 @@
+package statuspage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	api := &types.Service{ID: uuid.New(), Name: "api"}
+	web := &types.Service{ID: uuid.New(), Name: "web"}
+	worker := &types.Service{ID: uuid.New(), Name: "worker"}
+	page := &types.StatusPage{Title: "Shop status"}
+
+	firedAt := now.Add(-2 * time.Hour)
+	resolvedAt := now.Add(-time.Hour)
+	deployedAt := now.Add(-30 * time.Minute)
+	in := &inputs{
+		services: []*types.Service{worker, web, api},
+		checks: []*types.UptimeCheck{
+			{ServiceID: api.ID, Status: types.UptimeStatusDown},
+			{ServiceID: web.ID, Status: types.UptimeStatusUp},
+		},
+		alerts: []*types.Alert{
+			{ServiceID: web.ID, RuleName: "High latency", Summary: "p95 at 2300ms on 10.0.0.4", Status: types.AlertStatusFiring, FiredAt: &firedAt},
+			{ServiceID: api.ID, RuleName: "Error rate", Status: types.AlertStatusResolved, FiredAt: &firedAt, ResolvedAt: &resolvedAt},
+		},
+		failures: []db.DeployFailure{
+			{ServiceID: worker.ID, FailedAt: now.Add(-10 * time.Minute)},
+			{ServiceID: worker.ID, FailedAt: now.Add(-20 * time.Minute)},
+			{ServiceID: api.ID, FailedAt: now.Add(-40 * time.Minute), ResolvedAt: &deployedAt},
+		},
+		days: []db.ServiceUptimeDay{
+			{ServiceID: api.ID, Day: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), Uptime: 100},
+			{ServiceID: api.ID, Day: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), Uptime: 90},
+			{ServiceID: api.ID, Day: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), Uptime: 0},
+		},
+	}
+
+	status := summarize(page, in, now)
+
+	if status.Status != types.PublicHealthOutage {
+		t.Errorf("status = %s, want outage", status.Status)
+	}
+	want := map[string]types.PublicHealth{
+		"api":    types.PublicHealthOutage,   // Uptime check down
+		"web":    types.PublicHealthDegraded, // Alert firing
+		"worker": types.PublicHealthDegraded, // Latest deployment failed
+	}
+	if len(status.Services) != 3 || status.Services[0].Name != "api" {
+		t.Fatalf("services = %+v, want api, web and worker by name", status.Services)
+	}
+	for _, service := range status.Services {
+		if service.Status != want[service.Name] {
+			t.Errorf("%s is %s, want %s", service.Name, service.Status, want[service.Name])
+		}
+		if len(service.History) != HistoryDays {
+			t.Errorf("%s has %d days of history, want %d", service.Name, len(service.History), HistoryDays)
+		}
+	}
+
+	apiHistory := status.Services[0].History
+	if apiHistory[HistoryDays-1].Date != "2026-10-17" || apiHistory[0].Date != "2026-09-18" {
+		t.Errorf("history runs %s to %s", apiHistory[0].Date, apiHistory[HistoryDays-1].Date)
+	}
+	if apiHistory[HistoryDays-2].Uptime == nil || *apiHistory[HistoryDays-2].Uptime != 100 {
+		t.Errorf("uptime of yesterday = %v, want 100", apiHistory[HistoryDays-2].Uptime)
+	}
+	if status.Services[0].Uptime == nil || *status.Services[0].Uptime != 95 {
+		t.Errorf("uptime of api = %v, want 95 from the days with results", status.Services[0].Uptime)
+	}
+	if status.Services[1].Uptime != nil {
+		t.Errorf("uptime of web = %v, want none without results", *status.Services[1].Uptime)
+	}
+
+	if len(status.Incidents) != 4 {
+		t.Fatalf("incidents = %+v, want 4", status.Incidents)
+	}
+	// Ongoing incidents come first, newest first
+	first := status.Incidents[0]
+	if first.Service != "worker" || first.Kind != types.StatusIncidentDeployFailure || !first.Ongoing ||
+		!first.StartedAt.Equal(now.Add(-20*time.Minute)) {
+		t.Errorf("first incident = %+v, want the merged ongoing deploy failures of worker", first)
+	}
+	second := status.Incidents[1]
+	if second.Service != "web" || second.Title != "High latency" || !second.Ongoing {
+		t.Errorf("second incident = %+v, want the firing alert of web", second)
+	}
+	for _, incident := range status.Incidents[2:] {
+		if incident.Ongoing || incident.ResolvedAt == nil {
+			t.Errorf("incident %+v should be resolved", incident)
+		}
+	}
+}
+
+func TestSummarizeWithoutIncidents(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	in := &inputs{services: []*types.Service{{ID: uuid.New(), Name: "api"}}}
+
+	status := summarize(&types.StatusPage{Title: "Status"}, in, now)
+	if status.Status != types.PublicHealthOperational {
+		t.Errorf("status = %s, want operational", status.Status)
+	}
+	if status.Incidents == nil || len(status.Incidents) != 0 {
+		t.Errorf("incidents = %v, want an empty list", status.Incidents)
+	}
+}
+
+func TestRender(t *testing.T) {
+	uptime := 99.5
+	status := &types.PublicStatus{
+		Title:  "<script>alert(1)</script>",
+		Status: types.PublicHealthDegraded,
+		Services: []types.PublicServiceStatus{
+			{Name: "api", Status: types.PublicHealthDegraded, Uptime: &uptime, History: emptyHistory(time.Now())},
+		},
+		Incidents: []types.StatusIncident{
+			{Service: "api", Kind: types.StatusIncidentAlert, Title: "High latency", Ongoing: true, StartedAt: time.Now()},
+		},
+		GeneratedAt: time.Now(),
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, status); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{"&lt;script&gt;", "Degraded performance", "99.50% uptime", "api: High latency", "Ongoing"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Error("page contains an unescaped title")
+	}
+}
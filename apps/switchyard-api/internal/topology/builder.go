@@ -18,6 +18,7 @@ import (
 type GraphBuilder struct {
 	repos     *db.Repositories
 	k8sClient *k8s.Client
+	traffic   TrafficSource
 	logger    *logrus.Logger
 }
 
@@ -40,6 +41,18 @@ func (b *GraphBuilder) BuildTopology(ctx context.Context, environment string) (*
 		return nil, fmt.Errorf("failed to fetch services: %w", err)
 	}
 
+	// Uptime checks mark services whose endpoints are down
+	uptimeChecks := make(map[uuid.UUID][]*types.UptimeCheck)
+	if checks, err := b.repos.UptimeChecks.ListEnabled(ctx); err != nil {
+		b.logger.Warnf("Failed to fetch uptime checks: %v", err)
+	} else {
+		for _, check := range checks {
+			if environment == "all" || check.Environment == environment {
+				uptimeChecks[check.ServiceID] = append(uptimeChecks[check.ServiceID], check)
+			}
+		}
+	}
+
 	// Build service nodes
 	nodes := make([]*ServiceNode, 0)
 	nodeMap := make(map[string]*ServiceNode)
@@ -116,6 +129,7 @@ func (b *GraphBuilder) BuildTopology(ctx context.Context, environment string) (*
 			ImageURI:          imageURI,
 			UpdatedAt:         updatedAt,
 		}
+		applyUptime(node, uptimeChecks[service.ID])
 
 		nodes = append(nodes, node)
 		nodeMap[service.ID.String()] = node
@@ -147,6 +161,37 @@ func (b *GraphBuilder) BuildTopology(ctx context.Context, environment string) (*
 	return graph, nil
 }
 
+// applyUptime surfaces the uptime checks of a service on its node. The
+// node shows the worst check, and an endpoint that is down makes the
+// service unhealthy whatever its replicas report.
+func applyUptime(node *ServiceNode, checks []*types.UptimeCheck) {
+	if len(checks) == 0 {
+		return
+	}
+
+	up, down := 0, 0
+	for _, check := range checks {
+		switch check.Status {
+		case types.UptimeStatusUp:
+			up++
+		case types.UptimeStatusDown:
+			down++
+		}
+	}
+
+	switch {
+	case down > 0:
+		node.Uptime = string(types.UptimeStatusDown)
+		node.Status = HealthStatusUnhealthy
+	case up == len(checks):
+		node.Uptime = string(types.UptimeStatusUp)
+	default:
+		node.Uptime = string(types.UptimeStatusUnknown)
+	}
+	node.Metadata["uptime_checks"] = fmt.Sprintf("%d", len(checks))
+	node.Metadata["uptime_checks_down"] = fmt.Sprintf("%d", down)
+}
+
 // detectServiceType attempts to determine the service type from configuration
 func detectServiceType(service *types.Service) ServiceType {
 	// In production, analyze service config, ports, environment variables
@@ -154,7 +199,7 @@ func detectServiceType(service *types.Service) ServiceType {
 	gitRepo := strings.ToLower(service.GitRepo)
 	name := strings.ToLower(service.Name)
 
-	if strings.Contains(name, "postgres") || strings.Contains(name, "mysql") || strings.Contains(name, "database") {
+	if strings.Contains(name, "postgres") || strings.Contains(name, "mysql") || strings.Contains(name, "mongo") || strings.Contains(name, "database") {
 		return ServiceTypeDatabase
 	}
 	if strings.Contains(name, "redis") || strings.Contains(name, "cache") {
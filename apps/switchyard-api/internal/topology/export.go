@@ -0,0 +1,138 @@
+package topology
+
+import (
+	"fmt"
+	"strings"
+)
+
+// statusColors are the fill colors of nodes in exported graphs
+var statusColors = map[HealthStatus]string{
+	HealthStatusHealthy:   "#c8e6c9",
+	HealthStatusDegraded:  "#fff3c4",
+	HealthStatusUnhealthy: "#ffcdd2",
+	HealthStatusUnknown:   "#e0e0e0",
+}
+
+// statusColor returns the fill color of a node
+func statusColor(status HealthStatus) string {
+	if color, ok := statusColors[status]; ok {
+		return color
+	}
+	return statusColors[HealthStatusUnknown]
+}
+
+// nodeLabel is the name of a node followed by its status
+func nodeLabel(node *ServiceNode) string {
+	if node.Kind == NodeKindDomain {
+		return node.Name
+	}
+	return fmt.Sprintf("%s (%s)", node.Name, node.Status)
+}
+
+// edgeLabel describes an edge; observed edges carry their request rate
+func edgeLabel(edge *DependencyEdge) string {
+	if edge.Source == EdgeSourceObserved {
+		return fmt.Sprintf("%.2f req/s", edge.RequestsPerSecond)
+	}
+	return edge.Protocol
+}
+
+// DOT renders the graph in the Graphviz DOT language. Addons are drawn as
+// cylinders, domains as ellipses and observed traffic as dashed edges.
+func (g *TopologyGraph) DOT(title string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", dotQuote(title))
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	sb.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	for _, node := range g.Nodes {
+		shape := "box"
+		switch node.Kind {
+		case NodeKindAddon:
+			shape = "cylinder"
+		case NodeKindDomain:
+			shape = "ellipse"
+		}
+		fmt.Fprintf(&sb, "  %s [label=%s, shape=%s, fillcolor=%s];\n",
+			dotQuote(node.ID), dotQuote(nodeLabel(node)), shape, dotQuote(statusColor(node.Status)))
+	}
+
+	for _, edge := range g.Edges {
+		attrs := []string{}
+		if label := edgeLabel(edge); label != "" {
+			attrs = append(attrs, "label="+dotQuote(label))
+		}
+		if edge.Source == EdgeSourceObserved {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&sb, "  %s -> %s", dotQuote(edge.SourceID), dotQuote(edge.TargetID))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(attrs, ", "))
+		}
+		sb.WriteString(";\n")
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// dotQuote quotes a DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// Mermaid renders the graph as a Mermaid flowchart. Addons are drawn as
+// databases, domains as stadiums and observed traffic as dotted edges.
+func (g *TopologyGraph) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	ids := make(map[string]string, len(g.Nodes))
+	statuses := map[HealthStatus][]string{}
+	for i, node := range g.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.ID] = id
+		label := mermaidQuote(nodeLabel(node))
+		switch node.Kind {
+		case NodeKindAddon:
+			fmt.Fprintf(&sb, "  %s[(%s)]\n", id, label)
+		case NodeKindDomain:
+			fmt.Fprintf(&sb, "  %s([%s])\n", id, label)
+		default:
+			fmt.Fprintf(&sb, "  %s[%s]\n", id, label)
+		}
+		statuses[node.Status] = append(statuses[node.Status], id)
+	}
+
+	for _, edge := range g.Edges {
+		source, ok := ids[edge.SourceID]
+		target, ok2 := ids[edge.TargetID]
+		if !ok || !ok2 {
+			continue
+		}
+		arrow := "-->"
+		if edge.Source == EdgeSourceObserved {
+			arrow = "-.->"
+		}
+		if label := edgeLabel(edge); label != "" {
+			fmt.Fprintf(&sb, "  %s %s|%s| %s\n", source, arrow, mermaidQuote(label), target)
+		} else {
+			fmt.Fprintf(&sb, "  %s %s %s\n", source, arrow, target)
+		}
+	}
+
+	for _, status := range []HealthStatus{HealthStatusHealthy, HealthStatusDegraded, HealthStatusUnhealthy, HealthStatusUnknown} {
+		if len(statuses[status]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  classDef %s fill:%s\n", status, statusColor(status))
+		fmt.Fprintf(&sb, "  class %s %s\n", strings.Join(statuses[status], ","), status)
+	}
+	return sb.String()
+}
+
+// mermaidQuote quotes a Mermaid label
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,361 @@
+package topology
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ErrTrafficUnavailable is returned when traffic edges are asked for and no
+// traffic source is configured
+var ErrTrafficUnavailable = errors.New("runtime traffic is not available: no Prometheus server is configured")
+
+// ProjectOptions chooses what a project service map includes besides its
+// declared topology
+type ProjectOptions struct {
+	Traffic       bool          // Add edges for the traffic observed between services
+	TrafficWindow time.Duration // Window the traffic is averaged over
+}
+
+// SetTrafficSource sets where the traffic between services is read from.
+// Without one, service maps only show declared edges.
+func (b *GraphBuilder) SetTrafficSource(source TrafficSource) {
+	b.traffic = source
+}
+
+// BuildProjectTopology constructs the service map of a project in one
+// environment: its services with their live health, the addons bound to
+// them, the domains routing to them, their declared dependencies and,
+// optionally, the traffic observed between them.
+func (b *GraphBuilder) BuildProjectTopology(ctx context.Context, project *types.Project, env *types.Environment, opts ProjectOptions) (*TopologyGraph, error) {
+	if opts.Traffic && b.traffic == nil {
+		return nil, ErrTrafficUnavailable
+	}
+
+	services, err := b.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch services: %w", err)
+	}
+
+	uptimeChecks := make(map[uuid.UUID][]*types.UptimeCheck)
+	if checks, err := b.repos.UptimeChecks.ListEnabled(ctx); err != nil {
+		b.logger.Warnf("Failed to fetch uptime checks: %v", err)
+	} else {
+		for _, check := range checks {
+			if check.Environment == env.Name {
+				uptimeChecks[check.ServiceID] = append(uptimeChecks[check.ServiceID], check)
+			}
+		}
+	}
+
+	nodes := make([]*ServiceNode, 0, len(services))
+	edges := make([]*DependencyEdge, 0)
+	byName := make(map[string]*ServiceNode, len(services))
+	now := time.Now().UTC()
+
+	for _, service := range services {
+		node, err := b.projectServiceNode(ctx, project, env, service)
+		if err != nil {
+			return nil, err
+		}
+		applyUptime(node, uptimeChecks[service.ID])
+		nodes = append(nodes, node)
+		byName[service.Name] = node
+
+		deps, err := b.repos.ServiceDependencies.GetByService(ctx, service.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch dependencies of %s: %w", service.Name, err)
+		}
+		for _, dep := range deps {
+			edges = append(edges, declaredEdge(dep))
+		}
+
+		domains, err := b.repos.CustomDomains.GetByServiceAndEnvironment(ctx, service.ID.String(), env.ID.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch domains of %s: %w", service.Name, err)
+		}
+		for _, domain := range domains {
+			domainNode, edge := domainNodeAndEdge(project, env, &domain)
+			nodes = append(nodes, domainNode)
+			edges = append(edges, edge)
+		}
+	}
+
+	addons, err := b.repos.DatabaseAddons.ListByProject(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch addons: %w", err)
+	}
+	for _, addon := range addons {
+		if addon.EnvironmentID != nil && *addon.EnvironmentID != env.ID {
+			continue
+		}
+		nodes = append(nodes, addonNode(project, env, addon))
+
+		bindings, err := b.repos.DatabaseAddons.GetBindingsByAddon(ctx, addon.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch bindings of %s: %w", addon.Name, err)
+		}
+		for _, binding := range bindings {
+			edges = append(edges, &DependencyEdge{
+				ID:        fmt.Sprintf("%s-%s", binding.ServiceID, addon.ID),
+				SourceID:  binding.ServiceID.String(),
+				TargetID:  addon.ID.String(),
+				Type:      DependencyTypeStorage,
+				Protocol:  string(addon.Type),
+				Required:  true,
+				Source:    EdgeSourceBinding,
+				Metadata:  map[string]string{"env_var": binding.EnvVarName},
+				CreatedAt: binding.CreatedAt,
+			})
+		}
+	}
+
+	if opts.Traffic {
+		flows, err := b.traffic.Traffic(ctx, env.KubeNamespace, opts.TrafficWindow)
+		if err != nil {
+			b.logger.Warnf("Failed to read traffic of %s: %v", env.KubeNamespace, err)
+		}
+		edges = append(edges, observedEdges(flows, byName, opts.TrafficWindow, now)...)
+	}
+
+	sortGraph(nodes, edges)
+
+	serviceNodes := make([]*ServiceNode, 0, len(services))
+	for _, node := range nodes {
+		if node.Kind == NodeKindService {
+			serviceNodes = append(serviceNodes, node)
+		}
+	}
+
+	return &TopologyGraph{
+		Nodes:       nodes,
+		Edges:       edges,
+		Environment: env.Name,
+		GeneratedAt: now,
+		Stats:       b.calculateStats(serviceNodes, edges),
+	}, nil
+}
+
+// projectServiceNode builds the node of a service with the health of its
+// current deployment in an environment. The replicas of environments on
+// the API's own cluster are read live; those on registered clusters come
+// from the deployment's last reconciled health.
+func (b *GraphBuilder) projectServiceNode(ctx context.Context, project *types.Project, env *types.Environment, service *types.Service) (*ServiceNode, error) {
+	node := &ServiceNode{
+		ID:          service.ID.String(),
+		Name:        service.Name,
+		ProjectID:   project.ID.String(),
+		ProjectName: project.Name,
+		Environment: env.Name,
+		Kind:        NodeKindService,
+		Type:        detectServiceType(service),
+		Status:      HealthStatusUnknown,
+		Metadata:    make(map[string]string),
+		UpdatedAt:   service.UpdatedAt,
+	}
+
+	deployment, err := b.repos.Deployments.GetCurrentByServiceAndEnvironment(ctx, service.ID, env.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		node.Metadata["deployed"] = "false"
+		return node, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current deployment of %s: %w", service.Name, err)
+	}
+	node.UpdatedAt = deployment.UpdatedAt
+	node.Metadata["deployment_id"] = deployment.ID.String()
+
+	if release, err := b.repos.Releases.GetByID(deployment.ReleaseID); err == nil {
+		node.Version = release.Version
+		node.ImageURI = release.ImageURI
+	}
+
+	if env.ClusterID == nil && b.k8sClient != nil && env.KubeNamespace != "" {
+		if status, err := b.k8sClient.GetDeploymentStatusInfo(ctx, env.KubeNamespace, service.Name); err == nil {
+			node.Replicas = int(status.Replicas)
+			node.AvailableReplicas = int(status.AvailableReplicas)
+			node.Status = replicaHealth(node.Replicas, node.AvailableReplicas)
+			return node, nil
+		}
+	}
+
+	node.Replicas = deployment.Replicas
+	switch deployment.Health {
+	case types.HealthStatusHealthy:
+		node.Status = HealthStatusHealthy
+	case types.HealthStatusUnhealthy:
+		node.Status = HealthStatusUnhealthy
+	}
+	return node, nil
+}
+
+// replicaHealth judges a service by how many of its replicas are available
+func replicaHealth(replicas, available int) HealthStatus {
+	switch {
+	case available == replicas && replicas > 0:
+		return HealthStatusHealthy
+	case available > 0:
+		return HealthStatusDegraded
+	}
+	return HealthStatusUnhealthy
+}
+
+// declaredEdge is the edge of a service dependency declared on the project
+func declaredEdge(dep *db.ServiceDependency) *DependencyEdge {
+	edgeType := DependencyTypeSync
+	if dep.DependencyType == db.DependencyTypeData {
+		edgeType = DependencyTypeStorage
+	}
+	return &DependencyEdge{
+		ID:        fmt.Sprintf("%s-%s", dep.ServiceID, dep.DependsOnServiceID),
+		SourceID:  dep.ServiceID.String(),
+		TargetID:  dep.DependsOnServiceID.String(),
+		Type:      edgeType,
+		Required:  dep.DependencyType == db.DependencyTypeRuntime,
+		Source:    EdgeSourceDeclared,
+		Metadata:  map[string]string{"dependency_type": string(dep.DependencyType)},
+		CreatedAt: dep.CreatedAt,
+	}
+}
+
+// domainNodeAndEdge is the node of a custom domain and its edge to the
+// service it routes to
+func domainNodeAndEdge(project *types.Project, env *types.Environment, domain *types.CustomDomain) (*ServiceNode, *DependencyEdge) {
+	status := HealthStatusUnknown
+	switch {
+	case domain.Status == "error" || domain.DNSStatus == "error":
+		status = HealthStatusUnhealthy
+	case domain.DNSStatus == "drifted":
+		status = HealthStatusDegraded
+	case domain.Status == "active" && domain.Verified:
+		status = HealthStatusHealthy
+	}
+
+	protocol := "http"
+	if domain.TLSEnabled {
+		protocol = "https"
+	}
+
+	node := &ServiceNode{
+		ID:          domain.ID.String(),
+		Name:        domain.Domain,
+		ProjectID:   project.ID.String(),
+		ProjectName: project.Name,
+		Environment: env.Name,
+		Kind:        NodeKindDomain,
+		Type:        ServiceTypeDomain,
+		Status:      status,
+		Metadata: map[string]string{
+			"verified": fmt.Sprintf("%t", domain.Verified),
+			"tls":      fmt.Sprintf("%t", domain.TLSEnabled),
+		},
+		UpdatedAt: domain.UpdatedAt,
+	}
+	edge := &DependencyEdge{
+		ID:        fmt.Sprintf("%s-%s", domain.ID, domain.ServiceID),
+		SourceID:  domain.ID.String(),
+		TargetID:  domain.ServiceID.String(),
+		Type:      DependencyTypeIngress,
+		Protocol:  protocol,
+		Required:  true,
+		Source:    EdgeSourceIngress,
+		Metadata:  make(map[string]string),
+		CreatedAt: domain.CreatedAt,
+	}
+	return node, edge
+}
+
+// addonNode is the node of an addon, judged by its provisioning status
+func addonNode(project *types.Project, env *types.Environment, addon *types.DatabaseAddon) *ServiceNode {
+	status := HealthStatusUnknown
+	switch addon.Status {
+	case types.DatabaseAddonStatusReady:
+		status = HealthStatusHealthy
+	case types.DatabaseAddonStatusResizing:
+		status = HealthStatusDegraded
+	case types.DatabaseAddonStatusFailed:
+		status = HealthStatusUnhealthy
+	}
+
+	serviceType := ServiceTypeDatabase
+	switch addon.Type {
+	case types.DatabaseAddonTypeRedis:
+		serviceType = ServiceTypeCache
+	case types.DatabaseAddonTypeBucket:
+		serviceType = ServiceTypeStorage
+	}
+
+	return &ServiceNode{
+		ID:          addon.ID.String(),
+		Name:        addon.Name,
+		ProjectID:   project.ID.String(),
+		ProjectName: project.Name,
+		Environment: env.Name,
+		Kind:        NodeKindAddon,
+		Type:        serviceType,
+		Status:      status,
+		Metadata: map[string]string{
+			"addon_type": string(addon.Type),
+			"status":     string(addon.Status),
+		},
+		UpdatedAt: addon.UpdatedAt,
+	}
+}
+
+// observedEdges turns the traffic between workloads into edges between the
+// services of the same names. Traffic to or from other workloads is left out.
+func observedEdges(flows []TrafficFlow, byName map[string]*ServiceNode, window time.Duration, now time.Time) []*DependencyEdge {
+	edges := make([]*DependencyEdge, 0, len(flows))
+	for _, flow := range flows {
+		source, ok := byName[flow.Source]
+		if !ok {
+			continue
+		}
+		target, ok := byName[flow.Destination]
+		if !ok || target == source {
+			continue
+		}
+		edges = append(edges, &DependencyEdge{
+			ID:                fmt.Sprintf("%s-%s-observed", source.ID, target.ID),
+			SourceID:          source.ID,
+			TargetID:          target.ID,
+			Type:              DependencyTypeSync,
+			Source:            EdgeSourceObserved,
+			Metadata:          map[string]string{"window": window.String()},
+			CreatedAt:         now,
+			RequestsPerSecond: flow.RequestsPerSecond,
+		})
+	}
+	return edges
+}
+
+// kindOrder lists services before the addons and domains around them
+var kindOrder = map[NodeKind]int{NodeKindService: 0, NodeKindAddon: 1, NodeKindDomain: 2}
+
+// sortGraph orders nodes by kind and name and edges by their ends, so
+// exports of an unchanged project are identical
+func sortGraph(nodes []*ServiceNode, edges []*DependencyEdge) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if kindOrder[nodes[i].Kind] != kindOrder[nodes[j].Kind] {
+			return kindOrder[nodes[i].Kind] < kindOrder[nodes[j].Kind]
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].SourceID != edges[j].SourceID {
+			return edges[i].SourceID < edges[j].SourceID
+		}
+		if edges[i].TargetID != edges[j].TargetID {
+			return edges[i].TargetID < edges[j].TargetID
+		}
+		return edges[i].Source < edges[j].Source
+	})
+}
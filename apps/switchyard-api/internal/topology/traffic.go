@@ -0,0 +1,77 @@
+package topology
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/promquery"
+)
+
+// DefaultTrafficQuery is the request rate between the workloads of a
+// namespace as the Istio sidecars of the callers report it. $namespace and
+// $window are replaced before the query runs.
+const DefaultTrafficQuery = `sum by (source_workload, destination_workload) (rate(istio_requests_total{reporter="source",source_workload_namespace="$namespace",destination_workload_namespace="$namespace"}[$window]))`
+
+// TrafficFlow is the request rate from one workload to another
+type TrafficFlow struct {
+	Source            string
+	Destination       string
+	RequestsPerSecond float64
+}
+
+// TrafficSource reports the traffic between the workloads of a namespace
+// over a window up to now
+type TrafficSource interface {
+	Traffic(ctx context.Context, namespace string, window time.Duration) ([]TrafficFlow, error)
+}
+
+// PrometheusTraffic reads traffic between workloads from a Prometheus server
+type PrometheusTraffic struct {
+	client *promquery.Client
+	query  string
+}
+
+// NewPrometheusTraffic creates a traffic source for the Prometheus server at
+// baseURL. An empty query uses DefaultTrafficQuery.
+func NewPrometheusTraffic(baseURL, query string) *PrometheusTraffic {
+	if query == "" {
+		query = DefaultTrafficQuery
+	}
+	return &PrometheusTraffic{client: promquery.NewClient(baseURL), query: query}
+}
+
+// Traffic runs the traffic query for a namespace over a window
+func (p *PrometheusTraffic) Traffic(ctx context.Context, namespace string, window time.Duration) ([]TrafficFlow, error) {
+	query := strings.NewReplacer(
+		"$namespace", namespace,
+		"$window", fmt.Sprintf("%ds", int(window.Seconds())),
+	).Replace(p.query)
+
+	samples, err := p.client.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return trafficFlows(samples), nil
+}
+
+// trafficFlows maps the series of the traffic query, one per pair of
+// workloads, to flows. Series without both workload labels and idle or NaN
+// rates are dropped.
+func trafficFlows(samples []promquery.Sample) []TrafficFlow {
+	flows := make([]TrafficFlow, 0, len(samples))
+	for _, sample := range samples {
+		source, destination := sample.Metric["source_workload"], sample.Metric["destination_workload"]
+		if source == "" || destination == "" {
+			continue
+		}
+		rate := sample.Value
+		if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+			continue
+		}
+		flows = append(flows, TrafficFlow{Source: source, Destination: destination, RequestsPerSecond: rate})
+	}
+	return flows
+}
@@ -11,6 +11,7 @@ type ServiceNode struct {
 	ProjectID   string            `json:"project_id"`
 	ProjectName string            `json:"project_name"`
 	Environment string            `json:"environment"`
+	Kind        NodeKind          `json:"kind,omitempty"`     // "service", "addon", "domain"; set on project service maps
 	Type        ServiceType       `json:"type"`               // "http", "grpc", "database", "queue", "cache"
 	Status      HealthStatus      `json:"status"`             // "healthy", "degraded", "unhealthy", "unknown"
 	Metadata    map[string]string `json:"metadata"`           // Additional service metadata
@@ -21,6 +22,7 @@ type ServiceNode struct {
 	AvailableReplicas int     `json:"available_replicas"`
 	ErrorRate         float64 `json:"error_rate,omitempty"`    // 0.0 to 1.0
 	ResponseTime      float64 `json:"response_time,omitempty"` // Average response time in ms
+	Uptime            string  `json:"uptime,omitempty"`        // "up", "down", "unknown"; empty without uptime checks
 
 	// Deployment info
 	Version   string    `json:"version,omitempty"`
@@ -38,9 +40,20 @@ const (
 	ServiceTypeQueue    ServiceType = "queue"
 	ServiceTypeCache    ServiceType = "cache"
 	ServiceTypeStorage  ServiceType = "storage"
+	ServiceTypeDomain   ServiceType = "domain"
 	ServiceTypeUnknown  ServiceType = "unknown"
 )
 
+// NodeKind tells the services of a project service map from the addons
+// and domains around them
+type NodeKind string
+
+const (
+	NodeKindService NodeKind = "service"
+	NodeKindAddon   NodeKind = "addon"
+	NodeKindDomain  NodeKind = "domain"
+)
+
 // HealthStatus represents the health state of a service
 type HealthStatus string
 
@@ -65,8 +78,12 @@ type DependencyEdge struct {
 	Type      DependencyType    `json:"type"`               // "sync", "async", "storage"
 	Protocol  string            `json:"protocol,omitempty"` // "http", "grpc", "tcp", "postgres", etc.
 	Required  bool              `json:"required"`           // Is this dependency required for startup?
+	Source    EdgeSource        `json:"source,omitempty"`   // Where the edge comes from; set on project service maps
 	Metadata  map[string]string `json:"metadata,omitempty"`
 	CreatedAt time.Time         `json:"created_at"`
+
+	// Observed traffic edges only
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty"`
 }
 
 // DependencyType represents the type of dependency
@@ -76,6 +93,17 @@ const (
 	DependencyTypeSync    DependencyType = "sync"    // Synchronous dependency (HTTP, gRPC)
 	DependencyTypeAsync   DependencyType = "async"   // Asynchronous dependency (message queue)
 	DependencyTypeStorage DependencyType = "storage" // Storage dependency (database, cache)
+	DependencyTypeIngress DependencyType = "ingress" // A domain routing to a service
+)
+
+// EdgeSource is where an edge of a project service map comes from
+type EdgeSource string
+
+const (
+	EdgeSourceDeclared EdgeSource = "declared" // Service dependencies declared on the project
+	EdgeSourceBinding  EdgeSource = "binding"  // Addons bound to services
+	EdgeSourceIngress  EdgeSource = "ingress"  // Custom domains of services
+	EdgeSourceObserved EdgeSource = "observed" // Runtime traffic seen in network metrics
 )
 
 // TopologyGraph represents the complete service topology
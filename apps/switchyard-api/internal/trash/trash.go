@@ -0,0 +1,401 @@
+// Package trash soft-deletes projects and services. A deleted project or
+// service is scaled to zero and hidden, and stays restorable for a retention
+// window; once it passes, its workloads, ingresses, namespaces, container
+// images and database rows are purged for good.
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/registry"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// DefaultRetentionDays is how long trashed projects and services stay
+	// restorable when the platform does not configure a retention window
+	DefaultRetentionDays = 7
+	// PurgeInterval is how often the trash is checked for expired items
+	PurgeInterval = time.Hour
+	// ReplicasAnnotation keeps the replicas a deployment had before it was
+	// trashed, so a restore scales it back
+	ReplicasAnnotation = "enclii.dev/trashed-replicas"
+)
+
+// WorkloadDeleter removes a service's deployment, service and volumes from a namespace
+type WorkloadDeleter interface {
+	Delete(ctx context.Context, namespace, serviceName string) error
+}
+
+// ImageDeleter removes a container image from its registry
+type ImageDeleter = previews.ImageDeleter
+
+// credentialedImageDeleter is an ImageDeleter that can authenticate with a
+// project's own registry credentials
+type credentialedImageDeleter interface {
+	ImageDeleter
+	WithCredentials(auths []db.RegistryAuth) *previews.RegistryClient
+}
+
+// Service moves projects and services to the trash, restores them, and
+// purges them once their retention window has passed
+type Service struct {
+	repos         *db.Repositories
+	kube          kubernetes.Interface
+	workloads     WorkloadDeleter
+	images        ImageDeleter
+	retentionDays int
+	logger        *logrus.Logger
+}
+
+// NewService creates a trash service. A nil kube leaves workloads running
+// while trashed; a nil images leaves container images in the registry.
+func NewService(repos *db.Repositories, kube kubernetes.Interface, workloads WorkloadDeleter, images ImageDeleter, logger *logrus.Logger) *Service {
+	return &Service{
+		repos:         repos,
+		kube:          kube,
+		workloads:     workloads,
+		images:        images,
+		retentionDays: DefaultRetentionDays,
+		logger:        logger,
+	}
+}
+
+// SetRetentionDays sets how many days trashed items stay restorable
+func (s *Service) SetRetentionDays(days int) {
+	if days >= 0 {
+		s.retentionDays = days
+	}
+}
+
+// RetentionDays returns how many days trashed items stay restorable
+func (s *Service) RetentionDays() int {
+	return s.retentionDays
+}
+
+// RestorableUntil returns when an item trashed at deletedAt is purged
+func (s *Service) RestorableUntil(deletedAt time.Time) time.Time {
+	return deletedAt.Add(time.Duration(s.retentionDays) * 24 * time.Hour)
+}
+
+// TrashService moves a service to the trash and scales it to zero in every
+// environment of its project
+func (s *Service) TrashService(ctx context.Context, service *types.Service, deletedBy *uuid.UUID) (time.Time, error) {
+	deletedAt, err := s.repos.Services.Trash(ctx, service.ID, deletedBy)
+	if err != nil {
+		return time.Time{}, err
+	}
+	s.scale(ctx, service.ProjectID, []*types.Service{service}, s.pause)
+	return deletedAt, nil
+}
+
+// RestoreService takes a service out of the trash and scales it back up
+func (s *Service) RestoreService(ctx context.Context, service *types.Service) error {
+	if err := s.repos.Services.Restore(ctx, service.ID); err != nil {
+		return err
+	}
+	s.scale(ctx, service.ProjectID, []*types.Service{service}, s.resume)
+	return nil
+}
+
+// TrashProject moves a project and its services to the trash and scales
+// the services to zero
+func (s *Service) TrashProject(ctx context.Context, project *types.Project, deletedBy *uuid.UUID) (time.Time, error) {
+	services, err := s.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to list services: %w", err)
+	}
+	deletedAt, err := s.repos.Projects.Trash(ctx, project.ID, deletedBy)
+	if err != nil {
+		return time.Time{}, err
+	}
+	s.scale(ctx, project.ID, services, s.pause)
+	return deletedAt, nil
+}
+
+// RestoreProject takes a project and the services trashed with it out of
+// the trash and scales them back up
+func (s *Service) RestoreProject(ctx context.Context, project *types.Project) error {
+	if err := s.repos.Projects.Restore(ctx, project.ID); err != nil {
+		return err
+	}
+	services, err := s.repos.Services.ListByProject(project.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list restored services: %w", err)
+	}
+	s.scale(ctx, project.ID, services, s.resume)
+	return nil
+}
+
+// scale applies fn to the deployment of every service in every environment
+// of a project. The trash itself is kept in the database, so failures only
+// leave a workload running or stopped and are logged.
+func (s *Service) scale(ctx context.Context, projectID uuid.UUID, services []*types.Service, fn func(ctx context.Context, namespace, name string) error) {
+	if s.kube == nil || len(services) == 0 {
+		return
+	}
+	environments, err := s.repos.Environments.ListByProject(projectID)
+	if err != nil {
+		s.logger.WithError(err).WithField("project_id", projectID).Warn("Failed to list environments to scale trashed services")
+		return
+	}
+	for _, env := range environments {
+		for _, service := range services {
+			if err := fn(ctx, env.KubeNamespace, service.Name); err != nil {
+				s.logger.WithError(err).WithFields(logrus.Fields{
+					"service":   service.Name,
+					"namespace": env.KubeNamespace,
+				}).Warn("Failed to scale trashed service")
+			}
+		}
+	}
+}
+
+// pause scales a deployment to zero, keeping its replicas in an annotation
+func (s *Service) pause(ctx context.Context, namespace, name string) error {
+	deployments := s.kube.AppsV1().Deployments(namespace)
+	deployment, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		// Not deployed to this environment
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if replicas == 0 {
+		return nil
+	}
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[ReplicasAnnotation] = strconv.Itoa(int(replicas))
+	zero := int32(0)
+	deployment.Spec.Replicas = &zero
+
+	if _, err := deployments.Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to scale deployment to zero: %w", err)
+	}
+	return nil
+}
+
+// resume scales a deployment back to the replicas it had when trashed
+func (s *Service) resume(ctx context.Context, namespace, name string) error {
+	deployments := s.kube.AppsV1().Deployments(namespace)
+	deployment, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	saved, ok := deployment.Annotations[ReplicasAnnotation]
+	if !ok {
+		return nil
+	}
+	replicas, err := strconv.ParseInt(saved, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation %q", ReplicasAnnotation, saved)
+	}
+	restored := int32(replicas)
+	deployment.Spec.Replicas = &restored
+	delete(deployment.Annotations, ReplicasAnnotation)
+
+	if _, err := deployments.Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to scale deployment back up: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired permanently deletes every project and service that has been
+// in the trash longer than the retention window. Items that fail to purge
+// are left for the next sweep to retry.
+func (s *Service) PurgeExpired(ctx context.Context) {
+	before := time.Now().Add(-time.Duration(s.retentionDays) * 24 * time.Hour)
+
+	projects, err := s.repos.Projects.ListPurgeable(ctx, before)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list expired trashed projects")
+		return
+	}
+	for _, project := range projects {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.PurgeProject(ctx, project); err != nil {
+			s.logger.WithError(err).WithField("project", project.Slug).Warn("Project purge incomplete, will retry")
+		}
+	}
+
+	services, err := s.repos.Services.ListPurgeable(ctx, before)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list expired trashed services")
+		return
+	}
+	for _, service := range services {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.PurgeService(ctx, service); err != nil {
+			s.logger.WithError(err).WithField("service", service.Name).Warn("Service purge incomplete, will retry")
+		}
+	}
+}
+
+// PurgeProject permanently deletes a trashed project: its services, the
+// namespaces of its environments, and then the project with everything
+// that cascades from it
+func (s *Service) PurgeProject(ctx context.Context, project *types.Project) error {
+	services, err := s.repos.Services.ListTrashedByProject(ctx, project.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	var errs []error
+	for _, service := range services {
+		if err := s.PurgeService(ctx, service); err != nil {
+			errs = append(errs, fmt.Errorf("service %s: %w", service.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	environments, err := s.repos.Environments.ListByProject(project.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+	if s.kube != nil {
+		for _, env := range environments {
+			err := s.kube.CoreV1().Namespaces().Delete(ctx, env.KubeNamespace, metav1.DeleteOptions{})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete namespace %s: %w", env.KubeNamespace, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if err := s.repos.Projects.Delete(ctx, project.ID); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"project_id": project.ID,
+		"project":    project.Slug,
+		"services":   len(services),
+	}).Info("Purged trashed project")
+	return nil
+}
+
+// PurgeService permanently deletes a trashed service: its workload and
+// ingress in every environment, the images of its releases, and its rows.
+// Images are deleted before the releases that reference them.
+func (s *Service) PurgeService(ctx context.Context, service *types.Service) error {
+	environments, err := s.repos.Environments.ListByProject(service.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	var errs []error
+	for _, env := range environments {
+		if s.workloads != nil {
+			if err := s.workloads.Delete(ctx, env.KubeNamespace, service.Name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete workload in %s: %w", env.KubeNamespace, err))
+			}
+		}
+		if s.kube != nil {
+			err := s.kube.NetworkingV1().Ingresses(env.KubeNamespace).Delete(ctx, service.Name, metav1.DeleteOptions{})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete ingress in %s: %w", env.KubeNamespace, err))
+			}
+		}
+	}
+
+	releases, err := s.repos.Releases.ListByService(service.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list releases: %w", err)
+	}
+	if err := s.deleteImages(ctx, service.ProjectID, releases); err != nil {
+		errs = append(errs, err)
+	}
+	// Release rows are the only record of the images, so keep them and the
+	// service until everything is gone
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	serviceID := service.ID.String()
+	if err := s.repos.CustomDomains.DeleteByServiceID(ctx, serviceID); err != nil {
+		return fmt.Errorf("failed to delete custom domains: %w", err)
+	}
+	if err := s.repos.Routes.DeleteByServiceID(ctx, serviceID); err != nil {
+		return fmt.Errorf("failed to delete routes: %w", err)
+	}
+	// Env vars, dependencies, releases and the rest cascade
+	if err := s.repos.Services.Delete(ctx, service.ID); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"service_id": service.ID,
+		"service":    service.Name,
+		"releases":   len(releases),
+	}).Info("Purged trashed service")
+	return nil
+}
+
+// deleteImages deletes the images of releases, each image once. Images
+// that are already gone or that the registry cannot delete are skipped.
+func (s *Service) deleteImages(ctx context.Context, projectID uuid.UUID, releases []*types.Release) error {
+	if s.images == nil {
+		return nil
+	}
+
+	images := s.images
+	if scoped, isScoped := s.images.(credentialedImageDeleter); isScoped && s.repos != nil && s.repos.RegistryCredentials != nil {
+		auths, err := s.repos.RegistryCredentials.ListDecrypted(ctx, projectID, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get registry credentials: %w", err)
+		}
+		if auths, err = registry.Resolve(ctx, auths); err != nil {
+			return err
+		}
+		images = scoped.WithCredentials(auths)
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for _, release := range releases {
+		if release.ImageURI == "" || seen[release.ImageURI] {
+			continue
+		}
+		seen[release.ImageURI] = true
+
+		err := images.DeleteImage(ctx, release.ImageURI)
+		switch {
+		case err == nil, errors.Is(err, previews.ErrImageNotFound):
+		case errors.Is(err, previews.ErrDeleteUnsupported):
+			// The registry's own retention policy has to reclaim it
+		default:
+			errs = append(errs, fmt.Errorf("failed to delete image %s: %w", release.ImageURI, err))
+		}
+	}
+	return errors.Join(errs...)
+}
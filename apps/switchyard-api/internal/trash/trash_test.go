@@ -0,0 +1,114 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/previews"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+type fakeImageDeleter map[string]error
+
+func (f fakeImageDeleter) DeleteImage(ctx context.Context, image string) error {
+	return f[image]
+}
+
+func TestRestorableUntil(t *testing.T) {
+	s := NewService(nil, nil, nil, nil, logrus.New())
+	s.SetRetentionDays(3)
+	s.SetRetentionDays(-1) // ignored
+
+	deletedAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	if got, want := s.RestorableUntil(deletedAt), deletedAt.Add(72*time.Hour); !got.Equal(want) {
+		t.Errorf("RestorableUntil() = %v, want %v", got, want)
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	three := int32(3)
+	kube := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "enclii-shop-production"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &three},
+	})
+	s := NewService(nil, kube, nil, nil, logrus.New())
+	ctx := context.Background()
+	deployments := kube.AppsV1().Deployments("enclii-shop-production")
+
+	if err := s.pause(ctx, "enclii-shop-production", "api"); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	deployment, _ := deployments.Get(ctx, "api", metav1.GetOptions{})
+	if *deployment.Spec.Replicas != 0 || deployment.Annotations[ReplicasAnnotation] != "3" {
+		t.Errorf("paused to %d replicas, annotation %q", *deployment.Spec.Replicas, deployment.Annotations[ReplicasAnnotation])
+	}
+
+	// Trashing again keeps the replicas saved the first time
+	if err := s.pause(ctx, "enclii-shop-production", "api"); err != nil {
+		t.Fatalf("second pause: %v", err)
+	}
+
+	if err := s.resume(ctx, "enclii-shop-production", "api"); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	deployment, _ = deployments.Get(ctx, "api", metav1.GetOptions{})
+	if *deployment.Spec.Replicas != 3 {
+		t.Errorf("resumed to %d replicas, want 3", *deployment.Spec.Replicas)
+	}
+	if _, ok := deployment.Annotations[ReplicasAnnotation]; ok {
+		t.Error("annotation kept after resume")
+	}
+
+	// Services not deployed to an environment are skipped
+	if err := s.pause(ctx, "enclii-shop-staging", "api"); err != nil {
+		t.Errorf("pause of a missing deployment: %v", err)
+	}
+	if err := s.resume(ctx, "enclii-shop-staging", "api"); err != nil {
+		t.Errorf("resume of a missing deployment: %v", err)
+	}
+}
+
+func TestDeleteImages(t *testing.T) {
+	releases := []*types.Release{
+		{ImageURI: "ghcr.io/org/api:v1"},
+		{ImageURI: "ghcr.io/org/api:v1"},
+		{ImageURI: "ghcr.io/org/api:v2"},
+		{},
+	}
+
+	tests := []struct {
+		name    string
+		images  ImageDeleter
+		wantErr bool
+	}{
+		{name: "image deletion disabled", images: nil},
+		{name: "all deleted", images: fakeImageDeleter{}},
+		{
+			name:   "already gone or unsupported",
+			images: fakeImageDeleter{"ghcr.io/org/api:v1": previews.ErrImageNotFound, "ghcr.io/org/api:v2": previews.ErrDeleteUnsupported},
+		},
+		{
+			name:    "registry error",
+			images:  fakeImageDeleter{"ghcr.io/org/api:v2": errors.New("registry returned 500")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(nil, nil, nil, tt.images, logrus.New())
+			err := s.deleteImages(context.Background(), uuid.Nil, releases)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("deleteImages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+// Package uptime runs the synthetic HTTP checks of service endpoints. Each
+// check requests a path of a custom domain of the service, or of the
+// service inside the cluster, on its interval and records whether the
+// expected status came back. A check goes down after failure_threshold
+// failures in a row and comes back up on the first success; both are sent
+// to the project's webhooks.
+package uptime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/db"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// TickInterval is how often the runner looks for due checks. Intervals
+	// of checks are rounded up to it.
+	TickInterval = 15 * time.Second
+
+	// ResultRetention is how long results are kept for uptime percentages
+	ResultRetention = 30 * 24 * time.Hour
+
+	// pruneInterval is how often results past ResultRetention are deleted
+	pruneInterval = time.Hour
+
+	// maxConcurrentProbes is the most checks that run at once
+	maxConcurrentProbes = 10
+)
+
+// EventSender delivers uptime events to a project's webhooks
+type EventSender interface {
+	SendEvent(ctx context.Context, projectID uuid.UUID, event *types.WebhookEvent) error
+}
+
+// Runner runs due uptime checks and keeps their state up to date
+type Runner struct {
+	repos      *db.Repositories
+	client     *http.Client
+	events     EventSender
+	logger     *logrus.Logger
+	lastPruned time.Time
+}
+
+// NewRunner creates a runner. Redirects are not followed, so a check can
+// expect a redirect status.
+func NewRunner(repos *db.Repositories, logger *logrus.Logger) *Runner {
+	return &Runner{
+		repos: repos,
+		client: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+	}
+}
+
+// SetEventSender sets where uptime events are sent. Without one, no
+// webhooks are notified.
+func (r *Runner) SetEventSender(events EventSender) {
+	r.events = events
+}
+
+// target is the endpoint a check requests, with the service and
+// environment it belongs to
+type target struct {
+	url     string
+	service *types.Service
+	project *types.Project
+	env     *types.Environment
+}
+
+// RunDue runs every check whose interval has passed, records the results,
+// and notifies checks that went down or recovered
+func (r *Runner) RunDue(ctx context.Context) error {
+	now := time.Now()
+	if now.Sub(r.lastPruned) >= pruneInterval {
+		if pruned, err := r.repos.UptimeChecks.PruneResults(ctx, now.Add(-ResultRetention)); err != nil {
+			r.logger.WithError(err).Warn("Failed to prune uptime check results")
+		} else {
+			r.lastPruned = now
+			if pruned > 0 {
+				r.logger.WithField("count", pruned).Debug("Pruned uptime check results")
+			}
+		}
+	}
+
+	checks, err := r.repos.UptimeChecks.ListDue(ctx, now)
+	if err != nil {
+		return err
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	asleep := r.asleepServices(ctx)
+	targets := newTargetCache(r.repos)
+	resolved := make([]*target, len(checks))
+	for i, check := range checks {
+		if asleep[[2]uuid.UUID{check.ServiceID, check.EnvironmentID}] {
+			// A check would wake the service it watches
+			continue
+		}
+		t, err := targets.forCheck(ctx, check)
+		if err != nil {
+			r.logger.WithError(err).WithField("check_id", check.ID).Warn("Failed to resolve uptime check endpoint")
+			continue
+		}
+		resolved[i] = t
+	}
+
+	results := make([]*types.UptimeCheckResult, len(checks))
+	sem := make(chan struct{}, maxConcurrentProbes)
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		if resolved[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, check *types.UptimeCheck) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = probe(ctx, r.client, resolved[i].url, check.ExpectedStatus, time.Duration(check.TimeoutSeconds)*time.Second)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for i, check := range checks {
+		if results[i] == nil {
+			continue
+		}
+		if err := r.record(ctx, check, resolved[i], results[i]); err != nil {
+			r.logger.WithError(err).WithField("check_id", check.ID).Error("Failed to record uptime check result")
+		}
+	}
+	return nil
+}
+
+// asleepServices returns the service and environment pairs scaled to zero
+// while idle. Their checks are skipped.
+func (r *Runner) asleepServices(ctx context.Context) map[[2]uuid.UUID]bool {
+	asleep := make(map[[2]uuid.UUID]bool)
+	targets, err := r.repos.IdleScaling.ListTargets(ctx)
+	if err != nil {
+		r.logger.WithError(err).Warn("Failed to list sleeping services")
+		return asleep
+	}
+	for _, t := range targets {
+		if t.Scaling.State == types.IdleStateAsleep {
+			asleep[[2]uuid.UUID{t.Scaling.ServiceID, t.Scaling.EnvironmentID}] = true
+		}
+	}
+	return asleep
+}
+
+// record stores a result and notifies when the check changed between up
+// and down
+func (r *Runner) record(ctx context.Context, check *types.UptimeCheck, t *target, result *types.UptimeCheckResult) error {
+	var downSince time.Time
+	if check.StatusChangedAt != nil {
+		downSince = *check.StatusChangedAt
+	}
+
+	change := advance(check, result)
+	if err := r.repos.UptimeChecks.RecordResult(ctx, check, result); err != nil {
+		return err
+	}
+
+	switch change {
+	case changeDown:
+		r.logger.WithFields(logrus.Fields{
+			"service":     t.service.Name,
+			"environment": t.env.Name,
+			"url":         t.url,
+		}).Warn("Uptime check down")
+		r.notify(ctx, types.WebhookEventUptimeDown, check, t, result, result.CheckedAt, 0)
+	case changeRecovered:
+		r.logger.WithFields(logrus.Fields{
+			"service":     t.service.Name,
+			"environment": t.env.Name,
+			"url":         t.url,
+		}).Info("Uptime check recovered")
+		r.notify(ctx, types.WebhookEventUptimeRecovered, check, t, result, downSince, result.CheckedAt.Sub(downSince))
+	}
+	return nil
+}
+
+// notify sends an uptime event to the project's webhooks
+func (r *Runner) notify(ctx context.Context, eventType types.WebhookEventType, check *types.UptimeCheck, t *target, result *types.UptimeCheckResult, downSince time.Time, downtime time.Duration) {
+	if r.events == nil {
+		return
+	}
+
+	event := &types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		Timestamp: time.Now(),
+		ProjectID: t.project.ID,
+		Project: types.WebhookProjectInfo{
+			ID:   t.project.ID,
+			Name: t.project.Name,
+			Slug: t.project.Slug,
+		},
+		Uptime: &types.WebhookUptimeInfo{
+			CheckID:     check.ID,
+			ServiceName: t.service.Name,
+			Environment: t.env.Name,
+			URL:         t.url,
+			StatusCode:  result.StatusCode,
+			Error:       result.Error,
+			DownSince:   downSince,
+			DownSeconds: int64(downtime.Seconds()),
+		},
+	}
+	if err := r.events.SendEvent(ctx, t.project.ID, event); err != nil {
+		r.logger.WithError(err).WithField("check_id", check.ID).Warn("Failed to send uptime event")
+	}
+}
+
+// change is how a result moved a check between up and down
+type change int
+
+const (
+	changeNone change = iota
+	changeDown
+	changeRecovered
+)
+
+// advance applies a result to the state of a check. A check goes down
+// after FailureThreshold failures in a row, and up on the first success;
+// only coming back from down counts as a recovery.
+func advance(check *types.UptimeCheck, result *types.UptimeCheckResult) change {
+	checkedAt := result.CheckedAt
+
+	if result.Up {
+		check.ConsecutiveFailures = 0
+		if check.Status == types.UptimeStatusUp {
+			return changeNone
+		}
+		wasDown := check.Status == types.UptimeStatusDown
+		check.Status = types.UptimeStatusUp
+		check.StatusChangedAt = &checkedAt
+		if wasDown {
+			return changeRecovered
+		}
+		return changeNone
+	}
+
+	check.ConsecutiveFailures++
+	if check.Status == types.UptimeStatusDown || check.ConsecutiveFailures < check.FailureThreshold {
+		return changeNone
+	}
+	check.Status = types.UptimeStatusDown
+	check.StatusChangedAt = &checkedAt
+	return changeDown
+}
+
+// probe requests a URL and reports whether the expected status came back
+// within the timeout
+func probe(ctx context.Context, client *http.Client, url string, expectedStatus int, timeout time.Duration) *types.UptimeCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := &types.UptimeCheckResult{CheckedAt: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", "enclii-uptime/1.0")
+
+	resp, err := client.Do(req)
+	result.LatencyMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	status := resp.StatusCode
+	result.StatusCode = &status
+	result.Up = status == expectedStatus
+	if !result.Up {
+		result.Error = fmt.Sprintf("expected status %d, got %d", expectedStatus, status)
+	}
+	return result
+}
+
+// checkURL returns the URL a check requests: its custom domain over HTTPS, or
+// the service's ClusterIP Service inside the cluster
+func checkURL(check *types.UptimeCheck, serviceName, namespace string) string {
+	if check.Domain != "" {
+		return "https://" + check.Domain + check.Path
+	}
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local%s", serviceName, namespace, check.Path)
+}
+
+// targetCache loads the services, projects and environments of checks
+// once per run
+type targetCache struct {
+	repos        *db.Repositories
+	services     map[uuid.UUID]*types.Service
+	projects     map[uuid.UUID]*types.Project
+	environments map[uuid.UUID]*types.Environment
+}
+
+func newTargetCache(repos *db.Repositories) *targetCache {
+	return &targetCache{
+		repos:        repos,
+		services:     make(map[uuid.UUID]*types.Service),
+		projects:     make(map[uuid.UUID]*types.Project),
+		environments: make(map[uuid.UUID]*types.Environment),
+	}
+}
+
+func (c *targetCache) forCheck(ctx context.Context, check *types.UptimeCheck) (*target, error) {
+	service, ok := c.services[check.ServiceID]
+	if !ok {
+		var err error
+		if service, err = c.repos.Services.GetByID(check.ServiceID); err != nil {
+			return nil, fmt.Errorf("failed to get service: %w", err)
+		}
+		c.services[check.ServiceID] = service
+	}
+
+	project, ok := c.projects[service.ProjectID]
+	if !ok {
+		var err error
+		if project, err = c.repos.Projects.GetByID(ctx, service.ProjectID); err != nil {
+			return nil, fmt.Errorf("failed to get project: %w", err)
+		}
+		c.projects[service.ProjectID] = project
+	}
+
+	env, ok := c.environments[check.EnvironmentID]
+	if !ok {
+		var err error
+		if env, err = c.repos.Environments.GetByID(ctx, check.EnvironmentID); err != nil {
+			return nil, fmt.Errorf("failed to get environment: %w", err)
+		}
+		c.environments[check.EnvironmentID] = env
+	}
+
+	namespace := env.KubeNamespace
+	if namespace == "" {
+		namespace = fmt.Sprintf("enclii-%s-%s", project.Slug, env.Name)
+	}
+
+	return &target{
+		url:     checkURL(check, service.Name, namespace),
+		service: service,
+		project: project,
+		env:     env,
+	}, nil
+}
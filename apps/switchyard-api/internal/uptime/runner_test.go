@@ -0,0 +1,105 @@
+package uptime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func TestAdvance(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	up := &types.UptimeCheckResult{CheckedAt: now, Up: true}
+	failed := &types.UptimeCheckResult{CheckedAt: now}
+
+	tests := []struct {
+		name         string
+		status       types.UptimeStatus
+		failures     int
+		result       *types.UptimeCheckResult
+		want         change
+		wantStatus   types.UptimeStatus
+		wantFailures int
+	}{
+		{"first success is up without a recovery", types.UptimeStatusUnknown, 0, up, changeNone, types.UptimeStatusUp, 0},
+		{"success keeps up", types.UptimeStatusUp, 0, up, changeNone, types.UptimeStatusUp, 0},
+		{"one failure is not down yet", types.UptimeStatusUp, 0, failed, changeNone, types.UptimeStatusUp, 1},
+		{"failures reaching the threshold go down", types.UptimeStatusUp, 1, failed, changeDown, types.UptimeStatusDown, 2},
+		{"unchecked endpoint that never answers goes down", types.UptimeStatusUnknown, 1, failed, changeDown, types.UptimeStatusDown, 2},
+		{"down is notified once", types.UptimeStatusDown, 2, failed, changeNone, types.UptimeStatusDown, 3},
+		{"success after down recovers", types.UptimeStatusDown, 3, up, changeRecovered, types.UptimeStatusUp, 0},
+		{"success resets failures", types.UptimeStatusUp, 1, up, changeNone, types.UptimeStatusUp, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := &types.UptimeCheck{Status: tt.status, ConsecutiveFailures: tt.failures, FailureThreshold: 2}
+			if got := advance(check, tt.result); got != tt.want {
+				t.Errorf("advance() = %v, want %v", got, tt.want)
+			}
+			if check.Status != tt.wantStatus || check.ConsecutiveFailures != tt.wantFailures {
+				t.Errorf("check is %s with %d failures, want %s with %d", check.Status, check.ConsecutiveFailures, tt.wantStatus, tt.wantFailures)
+			}
+			if tt.want != changeNone && (check.StatusChangedAt == nil || !check.StatusChangedAt.Equal(now)) {
+				t.Errorf("status_changed_at = %v, want %v", check.StatusChangedAt, now)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/healthz", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := NewRunner(nil, nil).client
+
+	got := probe(ctx, client, server.URL+"/healthz", http.StatusOK, time.Second)
+	if !got.Up || got.StatusCode == nil || *got.StatusCode != http.StatusOK || got.Error != "" {
+		t.Errorf("probe() of a healthy endpoint = %+v", got)
+	}
+
+	got = probe(ctx, client, server.URL+"/missing", http.StatusOK, time.Second)
+	if got.Up || got.Error != "expected status 200, got 404" {
+		t.Errorf("probe() of a missing path = %+v", got)
+	}
+
+	// Redirects are not followed
+	got = probe(ctx, client, server.URL+"/old", http.StatusMovedPermanently, time.Second)
+	if !got.Up {
+		t.Errorf("probe() expecting a redirect = %+v", got)
+	}
+
+	got = probe(ctx, client, server.URL+"/slow", http.StatusOK, 50*time.Millisecond)
+	if got.Up || got.StatusCode != nil || !strings.Contains(got.Error, "deadline exceeded") {
+		t.Errorf("probe() past the timeout = %+v", got)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	check := &types.UptimeCheck{Path: "/healthz"}
+	if got := checkURL(check, "api", "enclii-shop-production"); got != "http://api.enclii-shop-production.svc.cluster.local/healthz" {
+		t.Errorf("checkURL() in the cluster = %q", got)
+	}
+
+	check.Domain = "shop.example.com"
+	if got := checkURL(check, "api", "enclii-shop-production"); got != "https://shop.example.com/healthz" {
+		t.Errorf("checkURL() of a custom domain = %q", got)
+	}
+}
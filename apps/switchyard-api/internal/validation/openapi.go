@@ -0,0 +1,684 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/mail"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/madfam-org/enclii/apps/switchyard-api/internal/errors"
+)
+
+// OpenAPIValidator checks requests against the operations of an OpenAPI 3
+// spec before they reach a handler: path and query parameters and JSON
+// bodies. Requests to routes the spec does not describe pass through.
+type OpenAPIValidator struct {
+	operations map[string]*openAPIOperation
+	schemas    map[string]*schema
+}
+
+// SchemaError is a single way a request does not match the API schema
+type SchemaError struct {
+	In      string `json:"in"`              // path, query or body
+	Field   string `json:"field,omitempty"` // e.g. "build_config.type" or "services[0].name"
+	Keyword string `json:"keyword"`         // schema keyword that failed, e.g. "required" or "enum"
+	Message string `json:"message"`
+}
+
+// SchemaErrors lists every way a request does not match the API schema
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		if err.Field == "" {
+			messages = append(messages, fmt.Sprintf("%s: %s", err.In, err.Message))
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s %s: %s", err.In, err.Field, err.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// openAPIDocument is the part of an OpenAPI document requests are checked against
+type openAPIDocument struct {
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths      map[string]*openAPIPathItem `yaml:"paths"`
+	Components struct {
+		Schemas    map[string]*schema           `yaml:"schemas"`
+		Parameters map[string]*openAPIParameter `yaml:"parameters"`
+	} `yaml:"components"`
+}
+
+type openAPIPathItem struct {
+	Parameters []*openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation   `yaml:"get"`
+	Put        *openAPIOperation   `yaml:"put"`
+	Post       *openAPIOperation   `yaml:"post"`
+	Patch      *openAPIOperation   `yaml:"patch"`
+	Delete     *openAPIOperation   `yaml:"delete"`
+}
+
+type openAPIOperation struct {
+	Parameters  []*openAPIParameter `yaml:"parameters"`
+	RequestBody *struct {
+		Required bool `yaml:"required"`
+		Content  map[string]struct {
+			Schema *schema `yaml:"schema"`
+		} `yaml:"content"`
+	} `yaml:"requestBody"`
+
+	// pathParams maps route segment positions to the spec's parameter names
+	pathParams map[int]string
+	params     []*openAPIParameter
+	body       *schema
+	bodyNeeded bool
+}
+
+type openAPIParameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *schema `yaml:"schema"`
+}
+
+// schema is the subset of JSON Schema used by the spec's request definitions
+type schema struct {
+	Ref                  string                `yaml:"$ref"`
+	Type                 schemaTypes           `yaml:"type"`
+	Format               string                `yaml:"format"`
+	Enum                 []interface{}         `yaml:"enum"`
+	Pattern              string                `yaml:"pattern"`
+	MinLength            *int                  `yaml:"minLength"`
+	MaxLength            *int                  `yaml:"maxLength"`
+	Minimum              *float64              `yaml:"minimum"`
+	Maximum              *float64              `yaml:"maximum"`
+	MinItems             *int                  `yaml:"minItems"`
+	MaxItems             *int                  `yaml:"maxItems"`
+	Items                *schema               `yaml:"items"`
+	Properties           map[string]*schema    `yaml:"properties"`
+	Required             []string              `yaml:"required"`
+	AdditionalProperties *additionalProperties `yaml:"additionalProperties"`
+	Nullable             bool                  `yaml:"nullable"`
+	AllOf                []*schema             `yaml:"allOf"`
+	AnyOf                []*schema             `yaml:"anyOf"`
+	OneOf                []*schema             `yaml:"oneOf"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes accepts both `type: string` and OpenAPI 3.1's `type: [string, "null"]`
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = schemaTypes{node.Value}
+		return nil
+	}
+	var types []string
+	if err := node.Decode(&types); err != nil {
+		return err
+	}
+	*t = types
+	return nil
+}
+
+// additionalProperties is either a boolean or a schema for unlisted properties
+type additionalProperties struct {
+	allowed bool
+	schema  *schema
+}
+
+func (a *additionalProperties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&a.allowed)
+	}
+	a.allowed = true
+	return node.Decode(&a.schema)
+}
+
+// LoadOpenAPISpec reads an OpenAPI spec from disk and builds a validator from it
+func LoadOpenAPISpec(path string) (*OpenAPIValidator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI spec: %w", err)
+	}
+	return NewOpenAPIValidator(data)
+}
+
+// NewOpenAPIValidator builds a validator from an OpenAPI spec. Route paths
+// are matched under the path of the spec's first server URL (e.g. /v1).
+func NewOpenAPIValidator(spec []byte) (*OpenAPIValidator, error) {
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	basePath := ""
+	if len(doc.Servers) > 0 {
+		server, err := url.Parse(doc.Servers[0].URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server URL %q: %w", doc.Servers[0].URL, err)
+		}
+		basePath = strings.TrimSuffix(server.Path, "/")
+	}
+
+	v := &OpenAPIValidator{
+		operations: map[string]*openAPIOperation{},
+		schemas:    doc.Components.Schemas,
+	}
+
+	compiled := map[*schema]bool{}
+	for name, s := range v.schemas {
+		if err := v.compile(s, compiled); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	for path, item := range doc.Paths {
+		if item == nil {
+			continue
+		}
+		methods := map[string]*openAPIOperation{
+			"GET": item.Get, "PUT": item.Put, "POST": item.Post, "PATCH": item.Patch, "DELETE": item.Delete,
+		}
+		for method, op := range methods {
+			if op == nil {
+				continue
+			}
+			if err := v.prepare(op, item, basePath+path, doc.Components.Parameters, compiled); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			v.operations[method+" "+routeKey(basePath+path)] = op
+		}
+	}
+
+	return v, nil
+}
+
+// prepare resolves the parameters and JSON body schema of an operation
+func (v *OpenAPIValidator) prepare(op *openAPIOperation, item *openAPIPathItem, path string, shared map[string]*openAPIParameter, compiled map[*schema]bool) error {
+	op.pathParams = map[int]string{}
+	for i, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			op.pathParams[i] = strings.Trim(segment, "{}")
+		}
+	}
+
+	// Operation parameters override path-level ones with the same name and location
+	byKey := map[string]*openAPIParameter{}
+	var order []string
+	for _, param := range append(append([]*openAPIParameter{}, item.Parameters...), op.Parameters...) {
+		if param.Ref != "" {
+			resolved, ok := shared[strings.TrimPrefix(param.Ref, "#/components/parameters/")]
+			if !ok {
+				return fmt.Errorf("unresolved parameter %s", param.Ref)
+			}
+			param = resolved
+		}
+		if err := v.compile(param.Schema, compiled); err != nil {
+			return fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		key := param.In + ":" + param.Name
+		if _, seen := byKey[key]; !seen {
+			order = append(order, key)
+		}
+		byKey[key] = param
+	}
+	for _, key := range order {
+		op.params = append(op.params, byKey[key])
+	}
+
+	if op.RequestBody != nil {
+		for mediaType, content := range op.RequestBody.Content {
+			if isJSONMediaType(mediaType) && content.Schema != nil {
+				if err := v.compile(content.Schema, compiled); err != nil {
+					return fmt.Errorf("request body: %w", err)
+				}
+				op.body = content.Schema
+				op.bodyNeeded = op.RequestBody.Required
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// compile checks refs and compiles patterns once for every schema in the tree
+func (v *OpenAPIValidator) compile(s *schema, compiled map[*schema]bool) error {
+	if s == nil || compiled[s] {
+		return nil
+	}
+	compiled[s] = true
+
+	if s.Ref != "" {
+		if v.resolve(s) == nil {
+			return fmt.Errorf("unresolved schema %s", s.Ref)
+		}
+		return nil
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+
+	children := []*schema{s.Items}
+	for _, property := range s.Properties {
+		children = append(children, property)
+	}
+	if s.AdditionalProperties != nil {
+		children = append(children, s.AdditionalProperties.schema)
+	}
+	children = append(children, s.AllOf...)
+	children = append(children, s.AnyOf...)
+	children = append(children, s.OneOf...)
+	for _, child := range children {
+		if err := v.compile(child, compiled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve follows $ref to a component schema. It returns nil for a dangling ref.
+func (v *OpenAPIValidator) resolve(s *schema) *schema {
+	for depth := 0; s != nil && s.Ref != "" && depth < 32; depth++ {
+		s = v.schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	if s != nil && s.Ref != "" {
+		return nil
+	}
+	return s
+}
+
+// routeKey normalizes a spec path (/services/{id}) or a gin route
+// (/services/:id) so both produce the same key
+func routeKey(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") ||
+			(strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")) {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Middleware rejects requests that do not match the API schema with 422
+// Unprocessable Entity and every violation as field-level details
+func (v *OpenAPIValidator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if errs := v.ValidateRequest(c); len(errs) > 0 {
+			appErr := errors.ErrSchemaValidation.WithDetails(errs)
+			c.AbortWithStatusJSON(appErr.HTTPStatus, errors.GetErrorResponse(appErr))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ValidateRequest checks the path parameters, query parameters and JSON body
+// of a routed request against its operation in the spec. The body is left
+// readable for the handler.
+func (v *OpenAPIValidator) ValidateRequest(c *gin.Context) SchemaErrors {
+	route := c.FullPath()
+	op := v.operations[c.Request.Method+" "+routeKey(route)]
+	if op == nil {
+		return nil
+	}
+
+	var errs SchemaErrors
+
+	// Path parameters are matched by position; the route may name them differently
+	routeSegments := strings.Split(route, "/")
+	pathValues := map[string]string{}
+	for i, name := range op.pathParams {
+		if i < len(routeSegments) {
+			pathValues[name] = strings.TrimPrefix(c.Param(strings.TrimLeft(routeSegments[i], ":*")), "/")
+		}
+	}
+
+	query := c.Request.URL.Query()
+	for _, param := range op.params {
+		switch param.In {
+		case "path":
+			value, ok := pathValues[param.Name]
+			if !ok {
+				continue
+			}
+			v.validateValue(param.Schema, coerceParameter(v.resolve(param.Schema), []string{value}), param.Name, "path", &errs)
+		case "query":
+			values, ok := query[param.Name]
+			if !ok {
+				if param.Required {
+					errs = append(errs, SchemaError{In: "query", Field: param.Name, Keyword: "required", Message: "is required"})
+				}
+				continue
+			}
+			v.validateValue(param.Schema, coerceParameter(v.resolve(param.Schema), values), param.Name, "query", &errs)
+		}
+	}
+
+	if op.body != nil {
+		errs = append(errs, v.validateBody(c, op)...)
+	}
+
+	return errs
+}
+
+// validateBody checks a JSON request body and puts it back for the handler.
+// Bodies sent as another content type are left to the handler to reject.
+func (v *OpenAPIValidator) validateBody(c *gin.Context, op *openAPIOperation) SchemaErrors {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request.Body)
+		c.Request.Body.Close()
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return SchemaErrors{{In: "body", Keyword: "json", Message: "request body could not be read"}}
+		}
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.bodyNeeded {
+			return SchemaErrors{{In: "body", Keyword: "required", Message: "request body is required"}}
+		}
+		return nil
+	}
+
+	if contentType := c.GetHeader("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !isJSONMediaType(mediaType) {
+			return nil
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return SchemaErrors{{In: "body", Keyword: "json", Message: "invalid JSON: " + err.Error()}}
+	}
+
+	var errs SchemaErrors
+	v.validateValue(op.body, value, "", "body", &errs)
+	return errs
+}
+
+// coerceParameter converts raw parameter strings to the JSON value their
+// schema expects. Values that do not convert stay strings and fail the type check.
+func coerceParameter(s *schema, values []string) interface{} {
+	if s != nil && s.Type.has("array") {
+		items := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			items = append(items, coerceParameter(s.Items, []string{value}))
+		}
+		return items
+	}
+
+	raw := values[0]
+	if s == nil {
+		return raw
+	}
+	switch {
+	case s.Type.has("integer"), s.Type.has("number"):
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case s.Type.has("boolean"):
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+func (t schemaTypes) has(name string) bool {
+	for _, typ := range t {
+		if typ == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateValue checks a decoded JSON value against a schema, appending each
+// violation found under the field path
+func (v *OpenAPIValidator) validateValue(s *schema, value interface{}, field, in string, errs *SchemaErrors) {
+	s = v.resolve(s)
+	if s == nil {
+		return
+	}
+	fail := func(keyword, format string, args ...interface{}) {
+		*errs = append(*errs, SchemaError{In: in, Field: field, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, sub := range s.AllOf {
+		v.validateValue(sub, value, field, in, errs)
+	}
+	if len(s.AnyOf) > 0 && v.countMatches(s.AnyOf, value, field, in) == 0 {
+		fail("anyOf", "must match at least one of the allowed schemas")
+	}
+	if len(s.OneOf) > 0 && v.countMatches(s.OneOf, value, field, in) != 1 {
+		fail("oneOf", "must match exactly one of the allowed schemas")
+	}
+
+	if value == nil {
+		if len(s.Type) > 0 && !s.Nullable && !s.Type.has("null") {
+			fail("type", "must not be null")
+		}
+		return
+	}
+	if len(s.Type) > 0 && !matchesType(s.Type, value) {
+		fail("type", "must be %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		fail("enum", "must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch value := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(value)
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("minLength", "must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("maxLength", "must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(value) {
+			fail("pattern", "must match %s", s.Pattern)
+		}
+		if s.Format != "" && !matchesFormat(s.Format, value) {
+			fail("format", "must be a valid %s", s.Format)
+		}
+
+	case json.Number:
+		n, err := value.Float64()
+		if err != nil {
+			return
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("minimum", "must be at least %s", strconv.FormatFloat(*s.Minimum, 'f', -1, 64))
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("maximum", "must be at most %s", strconv.FormatFloat(*s.Maximum, 'f', -1, 64))
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			fail("minItems", "must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(value) > *s.MaxItems {
+			fail("maxItems", "must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range value {
+			v.validateValue(s.Items, item, fmt.Sprintf("%s[%d]", field, i), in, errs)
+		}
+
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				*errs = append(*errs, SchemaError{In: in, Field: joinField(field, name), Keyword: "required", Message: "is required"})
+			}
+		}
+
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := s.Properties[name]; ok {
+				v.validateValue(property, value[name], joinField(field, name), in, errs)
+				continue
+			}
+			if extra := s.AdditionalProperties; extra != nil {
+				if !extra.allowed {
+					*errs = append(*errs, SchemaError{In: in, Field: joinField(field, name), Keyword: "additionalProperties", Message: "is not a known field"})
+					continue
+				}
+				v.validateValue(extra.schema, value[name], joinField(field, name), in, errs)
+			}
+		}
+	}
+}
+
+// countMatches returns how many of the schemas a value matches
+func (v *OpenAPIValidator) countMatches(schemas []*schema, value interface{}, field, in string) int {
+	matches := 0
+	for _, sub := range schemas {
+		var subErrs SchemaErrors
+		v.validateValue(sub, value, field, in, &subErrs)
+		if len(subErrs) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func matchesType(types schemaTypes, value interface{}) bool {
+	for _, typ := range types {
+		switch value := value.(type) {
+		case string:
+			if typ == "string" {
+				return true
+			}
+		case bool:
+			if typ == "boolean" {
+				return true
+			}
+		case json.Number:
+			if typ == "number" {
+				return true
+			}
+			if typ == "integer" {
+				if n, err := value.Float64(); err == nil && n == math.Trunc(n) {
+					return true
+				}
+			}
+		case []interface{}:
+			if typ == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if typ == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enumContains compares a JSON value with enum values decoded from YAML
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		switch value := value.(type) {
+		case json.Number:
+			n, err := value.Float64()
+			if err != nil {
+				continue
+			}
+			switch allowed := allowed.(type) {
+			case int:
+				if n == float64(allowed) {
+					return true
+				}
+			case float64:
+				if n == allowed {
+					return true
+				}
+			}
+		default:
+			if allowed == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		values = append(values, fmt.Sprintf("%v", value))
+	}
+	return strings.Join(values, ", ")
+}
+
+// matchesFormat checks the string formats the spec uses. Unknown formats pass.
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "uuid":
+		_, err := uuid.Parse(value)
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	default:
+		return true
+	}
+}
@@ -0,0 +1,338 @@
+package validation
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testSpec = `
+openapi: 3.1.0
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /services/{id}/env-vars:
+    post:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+            format: uuid
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/CreateEnvVarRequest'
+  /projects/{slug}/services:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/limit'
+        - name: status
+          in: query
+          schema:
+            type: string
+            enum: [active, paused]
+  /teams:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name, slug]
+              additionalProperties: false
+              properties:
+                name:
+                  type: string
+                  minLength: 2
+                slug:
+                  type: string
+                  pattern: '^[a-z0-9-]+$'
+                billing_email:
+                  type: [string, "null"]
+                  format: email
+                members:
+                  type: array
+                  maxItems: 2
+                  items:
+                    type: object
+                    required: [email]
+                    properties:
+                      email:
+                        type: string
+                        format: email
+                      role:
+                        type: string
+                        enum: [admin, member]
+components:
+  parameters:
+    limit:
+      name: limit
+      in: query
+      schema:
+        type: integer
+        maximum: 100
+  schemas:
+    CreateEnvVarRequest:
+      type: object
+      required: [key, value]
+      properties:
+        key:
+          type: string
+        value:
+          type: string
+        is_secret:
+          type: boolean
+`
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	v, err := NewOpenAPIValidator([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("NewOpenAPIValidator() error = %v", err)
+	}
+
+	router := gin.New()
+	router.Use(v.Middleware())
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	}
+	router.POST("/v1/services/:id/env-vars", echo)
+	router.GET("/v1/projects/:slug/services", echo)
+	router.POST("/v1/teams", echo)
+	router.POST("/v1/undocumented", echo)
+	return router
+}
+
+func TestOpenAPIValidatorMiddleware(t *testing.T) {
+	router := newTestRouter(t)
+	serviceID := "11111111-2222-3333-4444-555555555555"
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		form       bool
+		wantStatus int
+		wantErrors []SchemaError
+	}{
+		{
+			name:       "valid body",
+			method:     http.MethodPost,
+			path:       "/v1/services/" + serviceID + "/env-vars",
+			body:       `{"key": "PORT", "value": "8080", "is_secret": false}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid path parameter",
+			method:     http.MethodPost,
+			path:       "/v1/services/not-a-uuid/env-vars",
+			body:       `{"key": "PORT", "value": "8080"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []SchemaError{{In: "path", Field: "id", Keyword: "format"}},
+		},
+		{
+			name:       "missing and mistyped fields",
+			method:     http.MethodPost,
+			path:       "/v1/services/" + serviceID + "/env-vars",
+			body:       `{"key": "PORT", "is_secret": "yes"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []SchemaError{
+				{In: "body", Field: "value", Keyword: "required"},
+				{In: "body", Field: "is_secret", Keyword: "type"},
+			},
+		},
+		{
+			name:       "missing body",
+			method:     http.MethodPost,
+			path:       "/v1/services/" + serviceID + "/env-vars",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []SchemaError{{In: "body", Keyword: "required"}},
+		},
+		{
+			name:       "malformed JSON",
+			method:     http.MethodPost,
+			path:       "/v1/services/" + serviceID + "/env-vars",
+			body:       `{"key": `,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []SchemaError{{In: "body", Keyword: "json"}},
+		},
+		{
+			name:       "valid query",
+			method:     http.MethodGet,
+			path:       "/v1/projects/web/services?limit=20&status=active",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid query",
+			method:     http.MethodGet,
+			path:       "/v1/projects/web/services?limit=500&status=deleted",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []SchemaError{
+				{In: "query", Field: "limit", Keyword: "maximum"},
+				{In: "query", Field: "status", Keyword: "enum"},
+			},
+		},
+		{
+			name:       "non-integer query",
+			method:     http.MethodGet,
+			path:       "/v1/projects/web/services?limit=ten",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []SchemaError{{In: "query", Field: "limit", Keyword: "type"}},
+		},
+		{
+			name:       "nested fields",
+			method:     http.MethodPost,
+			path:       "/v1/teams",
+			body:       `{"name": "A", "slug": "Bad Slug", "billing_email": null, "members": [{"email": "ops@example.com", "role": "owner"}, {}], "plan": "pro"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []SchemaError{
+				{In: "body", Field: "members[0].role", Keyword: "enum"},
+				{In: "body", Field: "members[1].email", Keyword: "required"},
+				{In: "body", Field: "name", Keyword: "minLength"},
+				{In: "body", Field: "plan", Keyword: "additionalProperties"},
+				{In: "body", Field: "slug", Keyword: "pattern"},
+			},
+		},
+		{
+			name:       "too many items",
+			method:     http.MethodPost,
+			path:       "/v1/teams",
+			body:       `{"name": "Ops", "slug": "ops", "billing_email": "billing@", "members": [{"email": "a@example.com"}, {"email": "b@example.com"}, {"email": "c@example.com"}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []SchemaError{
+				{In: "body", Field: "billing_email", Keyword: "format"},
+				{In: "body", Field: "members", Keyword: "maxItems"},
+			},
+		},
+		{
+			name:       "non-JSON body left to the handler",
+			method:     http.MethodPost,
+			path:       "/v1/teams",
+			body:       `name=ops`,
+			form:       true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "undocumented route",
+			method:     http.MethodPost,
+			path:       "/v1/undocumented",
+			body:       `not json`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				contentType := "application/json"
+				if tt.form {
+					contentType = "application/x-www-form-urlencoded"
+				}
+				req.Header.Set("Content-Type", contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				if w.Body.String() != tt.body {
+					t.Errorf("handler saw body %q, want %q", w.Body.String(), tt.body)
+				}
+				return
+			}
+
+			var resp struct {
+				Error struct {
+					Code    string        `json:"code"`
+					Details []SchemaError `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid error response: %v", err)
+			}
+			if resp.Error.Code != "SCHEMA_VALIDATION_FAILED" {
+				t.Errorf("code = %q, want SCHEMA_VALIDATION_FAILED", resp.Error.Code)
+			}
+			if len(resp.Error.Details) != len(tt.wantErrors) {
+				t.Fatalf("details = %+v, want %d errors", resp.Error.Details, len(tt.wantErrors))
+			}
+			for i, want := range tt.wantErrors {
+				got := resp.Error.Details[i]
+				if got.In != want.In || got.Field != want.Field || got.Keyword != want.Keyword {
+					t.Errorf("details[%d] = %+v, want %s %q %s", i, got, want.In, want.Field, want.Keyword)
+				}
+				if got.Message == "" {
+					t.Errorf("details[%d] has no message", i)
+				}
+			}
+		})
+	}
+}
+
+func TestNewOpenAPIValidatorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{name: "not YAML", spec: "paths: [unclosed"},
+		{
+			name: "dangling schema ref",
+			spec: `
+paths:
+  /things:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Missing'
+`,
+		},
+		{
+			name: "invalid pattern",
+			spec: `
+components:
+  schemas:
+    Thing:
+      type: string
+      pattern: '[unclosed'
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewOpenAPIValidator([]byte(tt.spec)); err == nil {
+				t.Error("NewOpenAPIValidator() error = nil, want error")
+			}
+		})
+	}
+}
+
+// The API's own spec must always load, or requests go unvalidated
+func TestLoadOpenAPISpecRepository(t *testing.T) {
+	v, err := LoadOpenAPISpec("../../../../docs/api/openapi.yaml")
+	if err != nil {
+		t.Fatalf("LoadOpenAPISpec() error = %v", err)
+	}
+	if v.operations["POST /v1/services/{}/env-vars"] == nil {
+		t.Error("spec has no operation for POST /v1/services/:id/env-vars")
+	}
+}
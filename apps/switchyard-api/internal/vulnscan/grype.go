@@ -0,0 +1,165 @@
+// Package vulnscan scans container images for known vulnerabilities with
+// Grype
+package vulnscan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severity is the severity Grype rates a vulnerability with
+type Severity string
+
+const (
+	SeverityNegligible Severity = "negligible"
+	SeverityLow        Severity = "low"
+	SeverityMedium     Severity = "medium"
+	SeverityHigh       Severity = "high"
+	SeverityCritical   Severity = "critical"
+)
+
+// severityRank orders severities; unknown severities rank below negligible
+var severityRank = map[Severity]int{
+	SeverityNegligible: 1,
+	SeverityLow:        2,
+	SeverityMedium:     3,
+	SeverityHigh:       4,
+	SeverityCritical:   5,
+}
+
+// ParseSeverity parses a severity threshold such as "high"
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(strings.ToLower(s))
+	if _, ok := severityRank[severity]; !ok {
+		return "", fmt.Errorf("invalid severity %q, expected negligible, low, medium, high or critical", s)
+	}
+	return severity, nil
+}
+
+// AtLeast reports whether s is as severe as threshold or more
+func (s Severity) AtLeast(threshold Severity) bool {
+	return severityRank[s] >= severityRank[threshold]
+}
+
+// Scanner scans container images with Grype
+type Scanner struct {
+	timeout time.Duration
+}
+
+// NewScanner creates a new vulnerability scanner
+func NewScanner(timeout time.Duration) *Scanner {
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+
+	return &Scanner{
+		timeout: timeout,
+	}
+}
+
+// Report is the outcome of scanning an image
+type Report struct {
+	ImageURI  string           `json:"image_uri"`
+	Counts    map[Severity]int `json:"counts"`              // Vulnerabilities found per severity
+	Blocking  []string         `json:"blocking,omitempty"`  // IDs at or above the threshold
+	Threshold Severity         `json:"threshold,omitempty"` // Severity that fails the scan
+	ScannedAt time.Time        `json:"scanned_at"`
+}
+
+// Passed reports whether the image has no vulnerability at or above the
+// threshold
+func (r *Report) Passed() bool {
+	return len(r.Blocking) == 0
+}
+
+// Summary describes the blocking vulnerabilities of a failed scan
+func (r *Report) Summary() string {
+	if r.Passed() {
+		return fmt.Sprintf("no vulnerabilities of %s severity or above", r.Threshold)
+	}
+	ids := r.Blocking
+	more := ""
+	if len(ids) > 5 {
+		ids, more = ids[:5], fmt.Sprintf(" and %d more", len(r.Blocking)-5)
+	}
+	return fmt.Sprintf("%d vulnerabilities of %s severity or above: %s%s", len(r.Blocking), r.Threshold, strings.Join(ids, ", "), more)
+}
+
+// grypeOutput is the part of Grype's JSON output that is read
+type grypeOutput struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+		} `json:"vulnerability"`
+	} `json:"matches"`
+}
+
+// ScanImage scans an image in a registry, failing it on vulnerabilities at
+// or above threshold. Registry credentials are read from the Docker config
+// in dockerConfigDir; an empty dockerConfigDir uses the default config.
+func (s *Scanner) ScanImage(ctx context.Context, imageURI, dockerConfigDir string, threshold Severity) (*Report, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// Example: grype registry:ghcr.io/madfam/my-service:v1.0.0 -o json
+	cmd := exec.CommandContext(timeoutCtx, "grype", "registry:"+imageURI, "-o", "json")
+	if dockerConfigDir != "" {
+		cmd.Env = append(os.Environ(), "DOCKER_CONFIG="+dockerConfigDir)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("grype failed (exit %d): %s", exitErr.ExitCode(), string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("failed to run grype: %w", err)
+	}
+
+	report, err := parseReport(output, threshold)
+	if err != nil {
+		return nil, err
+	}
+	report.ImageURI = imageURI
+	return report, nil
+}
+
+// parseReport counts the matches of Grype's JSON output by severity
+func parseReport(output []byte, threshold Severity) (*Report, error) {
+	var result grypeOutput
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse grype output: %w", err)
+	}
+
+	report := &Report{
+		Counts:    make(map[Severity]int),
+		Threshold: threshold,
+		ScannedAt: time.Now().UTC(),
+	}
+	seen := make(map[string]bool)
+	for _, match := range result.Matches {
+		severity := Severity(strings.ToLower(match.Vulnerability.Severity))
+		report.Counts[severity]++
+		if severity.AtLeast(threshold) && !seen[match.Vulnerability.ID] {
+			seen[match.Vulnerability.ID] = true
+			report.Blocking = append(report.Blocking, match.Vulnerability.ID)
+		}
+	}
+	sort.Strings(report.Blocking)
+
+	return report, nil
+}
+
+// ValidateGrypeInstalled checks if Grype is installed and available
+func (s *Scanner) ValidateGrypeInstalled() error {
+	if _, err := exec.LookPath("grype"); err != nil {
+		return fmt.Errorf("grype is not installed or not in PATH. Install from: https://github.com/anchore/grype")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package vulnscan
+
+import (
+	"strings"
+	"testing"
+)
+
+const grypeJSON = `{
+  "matches": [
+    {"vulnerability": {"id": "CVE-2024-0001", "severity": "Critical"}},
+    {"vulnerability": {"id": "CVE-2024-0002", "severity": "High"}},
+    {"vulnerability": {"id": "CVE-2024-0002", "severity": "High"}},
+    {"vulnerability": {"id": "CVE-2024-0003", "severity": "Medium"}},
+    {"vulnerability": {"id": "GHSA-xxxx", "severity": "Unknown"}}
+  ]
+}`
+
+func TestParseReport(t *testing.T) {
+	report, err := parseReport([]byte(grypeJSON), SeverityHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Passed() {
+		t.Error("Passed() = true, want false")
+	}
+	if got := strings.Join(report.Blocking, ","); got != "CVE-2024-0001,CVE-2024-0002" {
+		t.Errorf("Blocking = %s", got)
+	}
+	if report.Counts[SeverityHigh] != 2 || report.Counts[SeverityCritical] != 1 || report.Counts["unknown"] != 1 {
+		t.Errorf("Counts = %v", report.Counts)
+	}
+	if got := report.Summary(); !strings.HasPrefix(got, "2 vulnerabilities of high severity or above") {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestParseReportPasses(t *testing.T) {
+	report, err := parseReport([]byte(grypeJSON), SeverityCritical)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Passed() {
+		t.Fatal("Passed() = true with a critical vulnerability")
+	}
+
+	report, err = parseReport([]byte(`{"matches": []}`), SeverityLow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Passed() {
+		t.Errorf("Passed() = false without matches, blocking %v", report.Blocking)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if got, err := ParseSeverity("HIGH"); err != nil || got != SeverityHigh {
+		t.Errorf("ParseSeverity(HIGH) = %q, %v", got, err)
+	}
+	if _, err := ParseSeverity("unknown"); err == nil {
+		t.Error("ParseSeverity(unknown) succeeded, want error")
+	}
+	if !SeverityCritical.AtLeast(SeverityHigh) || SeverityMedium.AtLeast(SeverityHigh) {
+		t.Error("AtLeast ordering is wrong")
+	}
+}
@@ -9,7 +9,7 @@ import { DatabaseCard } from "@/components/databases/DatabaseCard";
 
 // Database addon types matching the API
 export type DatabaseAddonType = 'postgres' | 'redis' | 'mysql';
-export type DatabaseAddonStatus = 'pending' | 'provisioning' | 'ready' | 'failed' | 'deleting' | 'deleted';
+export type DatabaseAddonStatus = 'pending' | 'provisioning' | 'ready' | 'resizing' | 'failed' | 'deleting' | 'deleted';
 
 export interface DatabaseAddon {
   id: string;
@@ -16,6 +16,7 @@ const STATUS_COLORS: Record<DatabaseAddonStatus, string> = {
   pending: 'bg-gray-100 text-gray-800',
   provisioning: 'bg-status-warning-muted text-status-warning-foreground',
   ready: 'bg-status-success-muted text-status-success-foreground',
+  resizing: 'bg-status-warning-muted text-status-warning-foreground',
   failed: 'bg-status-error-muted text-status-error-foreground',
   deleting: 'bg-status-warning-muted text-status-warning-foreground',
   deleted: 'bg-gray-100 text-gray-500',
@@ -100,7 +101,7 @@ export function DatabaseCard({ database, onDelete, isDeleting }: DatabaseCardPro
             </div>
           </div>
           <span className={`inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium ${statusColor}`}>
-            {database.status === 'provisioning' && (
+            {(database.status === 'provisioning' || database.status === 'resizing') && (
               <svg className="w-3 h-3 mr-1 animate-spin" fill="none" viewBox="0 0 24 24">
                 <circle className="opacity-25" cx="12" cy="12" r="10" stroke="currentColor" strokeWidth="4"/>
                 <path className="opacity-75" fill="currentColor" d="M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z"/>
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -11,8 +12,12 @@ import (
 
 	_ "github.com/lib/pq"
 	"github.com/madfam-org/enclii/apps/waybill/internal/aggregation"
+	"github.com/madfam-org/enclii/apps/waybill/internal/bandwidth"
+	"github.com/madfam-org/enclii/apps/waybill/internal/billing"
+	"github.com/madfam-org/enclii/apps/waybill/internal/budgets"
 	"github.com/madfam-org/enclii/apps/waybill/internal/config"
 	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+	"github.com/madfam-org/enclii/apps/waybill/internal/invoices"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
@@ -49,9 +54,57 @@ func main() {
 	logger.Info("connected to database")
 
 	// Initialize services
-	collector := events.NewCollector(db, logger)
+	collector := events.NewCollector(db, cfg.EventDedupWindow, logger)
 	hourlyAggregator := aggregation.NewHourlyAggregator(db, collector, logger)
 
+	var bandwidthCollector *bandwidth.Collector
+	if cfg.PrometheusURL != "" {
+		source := bandwidth.NewPrometheusSource(cfg.PrometheusURL, cfg.BandwidthIngressQuery, cfg.BandwidthEgressQuery)
+		bandwidthCollector = bandwidth.NewCollector(db, source, collector, logger)
+		logger.Info("bandwidth collection enabled", zap.String("prometheus_url", cfg.PrometheusURL))
+	} else {
+		logger.Warn("PROMETHEUS_URL not set, bandwidth is not collected")
+	}
+
+	pricing := &billing.Pricing{
+		ComputePerGBHour:  cfg.PriceComputePerGBHour,
+		BuildPerMinute:    cfg.PriceBuildPerMinute,
+		StoragePerGBMonth: cfg.PriceStoragePerGBMonth,
+		BandwidthPerGB:    cfg.PriceBandwidthPerGB,
+	}
+	allowance := &billing.Allowance{
+		ComputeGBHours: cfg.FreeComputeGBHours,
+		BuildMinutes:   cfg.FreeBuildMinutes,
+		StorageGBHours: cfg.FreeStorageGBMonths * 720, // GB-months to GB-hours
+		BandwidthGB:    cfg.FreeBandwidthGB,
+	}
+	var notifier budgets.Notifier
+	if cfg.SwitchyardURL != "" {
+		notifier = budgets.NewSwitchyardNotifier(cfg.SwitchyardURL, cfg.InternalAPIKey)
+	} else {
+		logger.Warn("SWITCHYARD_URL not set, budget alerts are recorded but not sent")
+	}
+	budgetEvaluator := budgets.NewEvaluator(budgets.NewStore(db), billing.NewCalculator(db, pricing, allowance, logger), notifier, logger)
+
+	var stripeSyncer invoices.StripeSyncer
+	if cfg.StripeSecretKey != "" {
+		stripeSyncer = billing.NewStripeClient(cfg.StripeSecretKey, logger)
+	} else {
+		logger.Warn("STRIPE_SECRET_KEY not set, invoices are not synced to Stripe")
+	}
+	invoiceService := invoices.NewService(invoices.NewStore(db), pricing, allowance, stripeSyncer, logger)
+
+	// One-off commands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "collect-bandwidth":
+			collectBandwidth(os.Args[2:], bandwidthCollector, hourlyAggregator, logger)
+			return
+		default:
+			logger.Fatal("unknown command", zap.String("command", os.Args[1]))
+		}
+	}
+
 	// Create cron scheduler
 	c := cron.New(cron.WithSeconds())
 
@@ -67,14 +120,66 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 		defer cancel()
 
+		// Traffic is recorded first so the aggregation bills it
+		if bandwidthCollector != nil {
+			if err := bandwidthCollector.Collect(ctx, previousHour); err != nil {
+				logger.Error("bandwidth collection failed", zap.Error(err))
+			}
+		}
+
 		if err := hourlyAggregator.Run(ctx, previousHour); err != nil {
 			logger.Error("hourly aggregation failed", zap.Error(err))
+			return
+		}
+
+		// Events that arrived after their hour was aggregated correct it
+		if _, err := hourlyAggregator.ReaggregateLate(ctx, previousHour); err != nil {
+			logger.Error("late event re-aggregation failed", zap.Error(err))
+		}
+
+		// Budgets see the spend up to the hour just aggregated
+		if err := budgetEvaluator.Run(ctx, previousHour.Add(time.Hour)); err != nil {
+			logger.Error("budget evaluation failed", zap.Error(err))
 		}
 	})
 	if err != nil {
 		logger.Fatal("failed to schedule hourly aggregation", zap.Error(err))
 	}
 
+	// Draft last month's invoices once its final hour has been aggregated
+	_, err = c.AddFunc("0 30 0 1 * *", func() {
+		previousMonth := time.Now().UTC().AddDate(0, -1, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+
+		generated, err := invoiceService.GenerateMonth(ctx, previousMonth)
+		if err != nil {
+			logger.Error("invoice generation failed", zap.Error(err))
+			return
+		}
+		logger.Info("monthly invoices drafted",
+			zap.String("period", previousMonth.Format("2006-01")),
+			zap.Int("invoices", generated),
+		)
+	})
+	if err != nil {
+		logger.Fatal("failed to schedule invoice generation", zap.Error(err))
+	}
+
+	// Pick up Stripe payments and retry failed pushes
+	_, err = c.AddFunc("0 15 3 * * *", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+
+		if err := invoiceService.SyncFinalized(ctx); err != nil {
+			logger.Error("invoice sync failed", zap.Error(err))
+		}
+	})
+	if err != nil {
+		logger.Fatal("failed to schedule invoice sync", zap.Error(err))
+	}
+
 	// Start the scheduler
 	c.Start()
 	logger.Info("aggregator scheduler started")
@@ -92,3 +197,41 @@ func main() {
 
 	logger.Info("aggregator shutdown complete")
 }
+
+// collectBandwidth collects the bandwidth of past hours and re-aggregates
+// them, e.g. after Prometheus was unreachable:
+//
+//	aggregator collect-bandwidth -from 2026-01-01T00:00:00Z -to 2026-01-02T00:00:00Z
+func collectBandwidth(args []string, collector *bandwidth.Collector, aggregator *aggregation.HourlyAggregator, logger *zap.Logger) {
+	if collector == nil {
+		logger.Fatal("PROMETHEUS_URL is required to collect bandwidth")
+	}
+
+	previousHour := time.Now().UTC().Add(-time.Hour).Truncate(time.Hour)
+	flags := flag.NewFlagSet("collect-bandwidth", flag.ExitOnError)
+	from := flags.String("from", previousHour.Format(time.RFC3339), "first hour to collect (RFC 3339)")
+	to := flags.String("to", previousHour.Add(time.Hour).Format(time.RFC3339), "end of the last hour to collect (RFC 3339)")
+	flags.Parse(args)
+
+	start, err := time.Parse(time.RFC3339, *from)
+	if err != nil {
+		logger.Fatal("invalid -from", zap.Error(err))
+	}
+	end, err := time.Parse(time.RFC3339, *to)
+	if err != nil {
+		logger.Fatal("invalid -to", zap.Error(err))
+	}
+
+	ctx := context.Background()
+	if err := collector.CollectRange(ctx, start, end); err != nil {
+		logger.Fatal("bandwidth collection failed", zap.Error(err))
+	}
+	if err := aggregator.RunForRange(ctx, start, end); err != nil {
+		logger.Fatal("aggregation failed", zap.Error(err))
+	}
+
+	logger.Info("bandwidth collected",
+		zap.Time("from", start),
+		zap.Time("to", end),
+	)
+}
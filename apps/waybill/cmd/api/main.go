@@ -8,8 +8,13 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/madfam-org/enclii/apps/waybill/internal/api"
 	"github.com/madfam-org/enclii/apps/waybill/internal/billing"
+	"github.com/madfam-org/enclii/apps/waybill/internal/budgets"
 	"github.com/madfam-org/enclii/apps/waybill/internal/config"
+	"github.com/madfam-org/enclii/apps/waybill/internal/credits"
 	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+	"github.com/madfam-org/enclii/apps/waybill/internal/invoices"
+	"github.com/madfam-org/enclii/apps/waybill/internal/payments"
+	"github.com/madfam-org/enclii/apps/waybill/internal/usage"
 	"go.uber.org/zap"
 )
 
@@ -45,7 +50,7 @@ func main() {
 	logger.Info("connected to database")
 
 	// Initialize services
-	collector := events.NewCollector(db, logger)
+	collector := events.NewCollector(db, cfg.EventDedupWindow, logger)
 
 	pricing := &billing.Pricing{
 		ComputePerGBHour:  cfg.PriceComputePerGBHour,
@@ -53,7 +58,13 @@ func main() {
 		StoragePerGBMonth: cfg.PriceStoragePerGBMonth,
 		BandwidthPerGB:    cfg.PriceBandwidthPerGB,
 	}
-	calculator := billing.NewCalculator(db, pricing, logger)
+	allowance := &billing.Allowance{
+		ComputeGBHours: cfg.FreeComputeGBHours,
+		BuildMinutes:   cfg.FreeBuildMinutes,
+		StorageGBHours: cfg.FreeStorageGBMonths * 720, // GB-months to GB-hours
+		BandwidthGB:    cfg.FreeBandwidthGB,
+	}
+	calculator := billing.NewCalculator(db, pricing, allowance, logger)
 
 	var stripeClient *billing.StripeClient
 	if cfg.StripeSecretKey != "" {
@@ -61,8 +72,32 @@ func main() {
 		logger.Info("Stripe integration enabled")
 	}
 
+	// Budgets are evaluated by the aggregator; the API only reads spend
+	budgetStore := budgets.NewStore(db)
+	budgetEvaluator := budgets.NewEvaluator(budgetStore, calculator, nil, logger)
+
+	// A nil *StripeClient must not become a non-nil syncer
+	var stripeSyncer invoices.StripeSyncer
+	if stripeClient != nil {
+		stripeSyncer = stripeClient
+	}
+	invoiceService := invoices.NewService(invoices.NewStore(db), pricing, allowance, stripeSyncer, logger)
+
+	creditService := credits.NewService(credits.NewStore(db), calculator, logger)
+
+	var paymentNotifier payments.Notifier
+	if cfg.SwitchyardURL != "" {
+		paymentNotifier = payments.NewSwitchyardNotifier(cfg.SwitchyardURL, cfg.InternalAPIKey)
+	} else {
+		logger.Warn("SWITCHYARD_URL not set, dunning emails and suspensions are not sent")
+	}
+	if cfg.StripeWebhookSecret == "" {
+		logger.Warn("STRIPE_WEBHOOK_SECRET not set, Stripe webhooks are rejected")
+	}
+	paymentProcessor := payments.NewProcessor(payments.NewStore(db), paymentNotifier, cfg.StripeWebhookSecret, cfg.DunningSuspendAfter, logger)
+
 	// Create handlers
-	handlers := api.NewHandlers(collector, calculator, stripeClient, logger)
+	handlers := api.NewHandlers(collector, calculator, stripeClient, budgetStore, budgetEvaluator, invoiceService, creditService, paymentProcessor, usage.NewStore(db), logger)
 
 	// Create API server
 	server := api.NewServer(handlers, &api.ServerConfig{
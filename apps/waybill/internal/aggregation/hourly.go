@@ -4,13 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/madfam-org/enclii/apps/waybill/internal/events"
 	"go.uber.org/zap"
 )
 
+const (
+	// lateHoursPerRun caps the project-hours one late pass re-aggregates, so
+	// a backlog of late events is worked off over several runs
+	lateHoursPerRun = 500
+
+	// correctionEpsilon is the smallest change recorded as a correction
+	correctionEpsilon = 1e-9
+)
+
 // HourlyAggregator handles hourly usage aggregation
 type HourlyAggregator struct {
 	db        *sql.DB
@@ -61,7 +72,7 @@ func (a *HourlyAggregator) Run(ctx context.Context, hour time.Time) error {
 
 	// Aggregate each project
 	for _, projectID := range projectIDs {
-		if err := a.aggregateProject(ctx, projectID, hour, nextHour); err != nil {
+		if err := a.aggregateProject(ctx, projectID, hour, nextHour, false); err != nil {
 			a.logger.Error("failed to aggregate project",
 				zap.String("project_id", projectID.String()),
 				zap.Error(err),
@@ -78,14 +89,24 @@ func (a *HourlyAggregator) Run(ctx context.Context, hour time.Time) error {
 	return nil
 }
 
-func (a *HourlyAggregator) aggregateProject(ctx context.Context, projectID uuid.UUID, start, end time.Time) error {
+// aggregateProject replaces the hourly usage of a project with one computed
+// from all its events in the hour, and marks them processed. When the hour
+// had been aggregated before, or late is set because events arrived after
+// it closed, every change to a metric is recorded as a correction.
+func (a *HourlyAggregator) aggregateProject(ctx context.Context, projectID uuid.UUID, start, end time.Time, late bool) error {
 	eventList, err := a.collector.GetEventsByProject(ctx, projectID, start, end)
 	if err != nil {
 		return err
 	}
 
-	// Calculate metrics
-	metrics := a.calculateMetrics(eventList, start, end)
+	// Calculate metrics per resource, and the project totals from them
+	resources := a.calculateResourceMetrics(eventList, start, end)
+	metrics := make(map[events.MetricType]float64)
+	for _, resource := range resources {
+		for metricType, value := range resource.metrics {
+			metrics[metricType] += value
+		}
+	}
 
 	// Insert hourly usage records
 	tx, err := a.db.BeginTx(ctx, nil)
@@ -94,11 +115,22 @@ func (a *HourlyAggregator) aggregateProject(ctx context.Context, projectID uuid.
 	}
 	defer tx.Rollback()
 
+	previous, err := previousUsage(ctx, tx, projectID, start)
+	if err != nil {
+		return err
+	}
+
+	// Metrics that drop to zero leave no row behind
+	if _, err := tx.ExecContext(ctx, `DELETE FROM hourly_usage WHERE project_id = $1 AND hour = $2`, projectID, start); err != nil {
+		return fmt.Errorf("failed to clear hourly usage: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM hourly_resource_usage WHERE project_id = $1 AND hour = $2`, projectID, start); err != nil {
+		return fmt.Errorf("failed to clear hourly resource usage: %w", err)
+	}
+
 	insertQuery := `
 		INSERT INTO hourly_usage (id, project_id, metric_type, value, hour, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (project_id, metric_type, hour)
-		DO UPDATE SET value = EXCLUDED.value
 	`
 
 	for metricType, value := range metrics {
@@ -119,6 +151,49 @@ func (a *HourlyAggregator) aggregateProject(ctx context.Context, projectID uuid.
 		}
 	}
 
+	// Per-resource usage itemizes invoices by service
+	resourceQuery := `
+		INSERT INTO hourly_resource_usage (project_id, resource_type, resource_id, resource_name, metric_type, value, hour)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	for resourceID, resource := range resources {
+		for metricType, value := range resource.metrics {
+			if value == 0 {
+				continue
+			}
+
+			_, err := tx.ExecContext(ctx, resourceQuery,
+				projectID,
+				resource.resourceType,
+				resourceID,
+				resource.name,
+				metricType,
+				value,
+				start,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert hourly resource usage: %w", err)
+			}
+		}
+	}
+
+	if late || len(previous) > 0 {
+		if err := a.recordCorrections(ctx, tx, projectID, start, previous, metrics); err != nil {
+			return err
+		}
+	}
+
+	eventIDs := make([]uuid.UUID, len(eventList))
+	for i, event := range eventList {
+		eventIDs[i] = event.ID
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE usage_events SET processed_at = $1 WHERE id = ANY($2) AND processed_at IS NULL`,
+		time.Now(), pq.Array(eventIDs))
+	if err != nil {
+		return fmt.Errorf("failed to mark events processed: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -126,13 +201,156 @@ func (a *HourlyAggregator) aggregateProject(ctx context.Context, projectID uuid.
 	return nil
 }
 
-func (a *HourlyAggregator) calculateMetrics(eventList []*events.UsageEvent, start, end time.Time) map[events.MetricType]float64 {
-	metrics := make(map[events.MetricType]float64)
+// previousUsage returns the hourly usage a project-hour was last aggregated
+// to, locking it against concurrent aggregation
+func previousUsage(ctx context.Context, tx *sql.Tx, projectID uuid.UUID, hour time.Time) (map[events.MetricType]float64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT metric_type, value FROM hourly_usage
+		WHERE project_id = $1 AND hour = $2
+		FOR UPDATE
+	`, projectID, hour)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly usage: %w", err)
+	}
+	defer rows.Close()
+
+	previous := make(map[events.MetricType]float64)
+	for rows.Next() {
+		var metricType string
+		var value float64
+		if err := rows.Scan(&metricType, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan hourly usage: %w", err)
+		}
+		previous[events.MetricType(metricType)] = value
+	}
+	return previous, rows.Err()
+}
+
+// recordCorrections records every metric of a project-hour that
+// re-aggregation changed
+func (a *HourlyAggregator) recordCorrections(ctx context.Context, tx *sql.Tx, projectID uuid.UUID, hour time.Time, previous, metrics map[events.MetricType]float64) error {
+	changed := make(map[events.MetricType]bool)
+	for metricType := range previous {
+		changed[metricType] = true
+	}
+	for metricType := range metrics {
+		changed[metricType] = true
+	}
+
+	for metricType := range changed {
+		delta := metrics[metricType] - previous[metricType]
+		if math.Abs(delta) < correctionEpsilon {
+			continue
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO usage_corrections (project_id, metric_type, hour, previous_value, value, delta)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, projectID, metricType, hour, previous[metricType], metrics[metricType], delta)
+		if err != nil {
+			return fmt.Errorf("failed to record usage correction: %w", err)
+		}
+
+		a.logger.Info("usage corrected",
+			zap.String("project_id", projectID.String()),
+			zap.String("metric_type", string(metricType)),
+			zap.Time("hour", hour),
+			zap.Float64("delta", delta),
+		)
+	}
+	return nil
+}
+
+// ReaggregateLate re-aggregates the closed hours, before the given one, that
+// events arrived for after they were aggregated, oldest first. It returns
+// how many project-hours it re-aggregated.
+func (a *HourlyAggregator) ReaggregateLate(ctx context.Context, before time.Time) (int, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT project_id, date_trunc('hour', "timestamp") AS hour
+		FROM usage_events
+		WHERE processed_at IS NULL AND "timestamp" < $1
+		GROUP BY project_id, hour
+		ORDER BY hour
+		LIMIT $2
+	`, before.Truncate(time.Hour), lateHoursPerRun)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get late events: %w", err)
+	}
+
+	type projectHour struct {
+		projectID uuid.UUID
+		hour      time.Time
+	}
+	var late []projectHour
+	for rows.Next() {
+		var ph projectHour
+		if err := rows.Scan(&ph.projectID, &ph.hour); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan late events: %w", err)
+		}
+		late = append(late, ph)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get late events: %w", err)
+	}
+
+	reaggregated := 0
+	for _, ph := range late {
+		hour := ph.hour.UTC().Truncate(time.Hour)
+		if err := a.aggregateProject(ctx, ph.projectID, hour, hour.Add(time.Hour), true); err != nil {
+			a.logger.Error("failed to re-aggregate late events",
+				zap.String("project_id", ph.projectID.String()),
+				zap.Time("hour", hour),
+				zap.Error(err),
+			)
+			continue
+		}
+		reaggregated++
+	}
+
+	if len(late) > 0 {
+		a.logger.Info("late events re-aggregated",
+			zap.Int("project_hours", reaggregated),
+			zap.Int("failed", len(late)-reaggregated),
+		)
+	}
+	return reaggregated, nil
+}
+
+// resourceUsage is the usage of one resource (service, volume, bucket...)
+// in an hour
+type resourceUsage struct {
+	resourceType string
+	name         string
+	metrics      map[events.MetricType]float64
+}
+
+func (a *HourlyAggregator) calculateResourceMetrics(eventList []*events.UsageEvent, start, end time.Time) map[uuid.UUID]*resourceUsage {
+	resources := make(map[uuid.UUID]*resourceUsage)
+	metricsOf := func(event *events.UsageEvent) map[events.MetricType]float64 {
+		resource, ok := resources[event.ResourceID]
+		if !ok {
+			resource = &resourceUsage{metrics: make(map[events.MetricType]float64)}
+			resources[event.ResourceID] = resource
+		}
+		// Events are ordered by timestamp, so the latest name wins
+		resource.resourceType = event.ResourceType
+		if event.ResourceName != "" {
+			resource.name = event.ResourceName
+		}
+		return resource.metrics
+	}
 
 	// Track active deployments for compute calculation
 	activeDeployments := make(map[uuid.UUID]*deploymentState)
 
+	// Latest storage sample per resource; each resource is billed once for the hour
+	storageSamples := make(map[uuid.UUID]float64)
+
 	for _, event := range eventList {
+		metrics := metricsOf(event)
+
 		switch event.EventType {
 		case events.EventDeploymentStarted:
 			activeDeployments[event.ResourceID] = &deploymentState{
@@ -172,6 +390,13 @@ func (a *HourlyAggregator) calculateMetrics(eventList []*events.UsageEvent, star
 			sizeGB := event.Metrics["size_gb"]
 			metrics[events.MetricStorageGBHours] += sizeGB // 1 hour
 
+		case events.EventStorageUsage:
+			// Events are ordered by timestamp, so the last sample wins
+			storageSamples[event.ResourceID] = event.Metrics["size_gb"]
+
+		case events.EventIdleSavings:
+			metrics[events.MetricIdleSavedGBHours] += event.Metrics["saved_gb_hours"]
+
 		case events.EventBandwidthUsage:
 			metrics[events.MetricBandwidthGB] += event.Metrics["egress_gb"]
 
@@ -183,12 +408,16 @@ func (a *HourlyAggregator) calculateMetrics(eventList []*events.UsageEvent, star
 	}
 
 	// Close any still-active deployments at end of hour
-	for _, state := range activeDeployments {
+	for resourceID, state := range activeDeployments {
 		gbHours := a.calculateGBHours(state, end)
-		metrics[events.MetricComputeGBHours] += gbHours
+		resources[resourceID].metrics[events.MetricComputeGBHours] += gbHours
+	}
+
+	for resourceID, sizeGB := range storageSamples {
+		resources[resourceID].metrics[events.MetricStorageGBHours] += sizeGB // 1 hour
 	}
 
-	return metrics
+	return resources
 }
 
 type deploymentState struct {
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/budgets"
+	"go.uber.org/zap"
+)
+
+// budgetScope reads the team or project a budget route is for
+type budgetScope struct {
+	teamID    *uuid.UUID
+	projectID *uuid.UUID
+}
+
+func parseBudgetScope(c *gin.Context) (*budgetScope, bool) {
+	if param := c.Param("team_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team ID"})
+			return nil, false
+		}
+		return &budgetScope{teamID: &id}, true
+	}
+
+	id, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return nil, false
+	}
+	return &budgetScope{projectID: &id}, true
+}
+
+func (h *Handlers) getBudget(ctx context.Context, scope *budgetScope) (*budgets.Budget, error) {
+	if scope.teamID != nil {
+		return h.budgets.GetByTeam(ctx, *scope.teamID)
+	}
+	return h.budgets.GetByProject(ctx, *scope.projectID)
+}
+
+// GetBudget returns the budget of a team or project with the spend of the
+// current period and the alerts it fired
+func (h *Handlers) GetBudget(c *gin.Context) {
+	scope, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	budget, err := h.getBudget(ctx, scope)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no budget set"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to get budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get budget"})
+		return
+	}
+
+	now := time.Now().UTC()
+	spend, err := h.evaluator.Spend(ctx, budget, now)
+	if err != nil {
+		h.logger.Error("failed to calculate budget spend", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate spend"})
+		return
+	}
+
+	period := budgets.PeriodStart(now)
+	alerts, err := h.budgets.ListAlerts(ctx, budget.ID, period)
+	if err != nil {
+		h.logger.Error("failed to list budget alerts", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, &budgets.BudgetStatus{
+		Budget:      budget,
+		PeriodStart: period,
+		Spend:       spend,
+		Percent:     spend / budget.MonthlyLimit * 100,
+		Alerts:      alerts,
+	})
+}
+
+// SetBudget creates or replaces the budget of a team or project
+func (h *Handlers) SetBudget(c *gin.Context) {
+	scope, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+
+	var req budgets.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.HardLimit != nil && *req.HardLimit < req.MonthlyLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hard_limit must be at least monthly_limit"})
+		return
+	}
+	if req.Currency != "" && len(req.Currency) != 3 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be a 3-letter ISO code"})
+		return
+	}
+
+	var budget *budgets.Budget
+	var err error
+	if scope.teamID != nil {
+		budget, err = h.budgets.SetForTeam(c.Request.Context(), *scope.teamID, &req)
+	} else {
+		budget, err = h.budgets.SetForProject(c.Request.Context(), *scope.projectID, &req)
+	}
+	if err != nil {
+		h.logger.Error("failed to set budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// DeleteBudget removes the budget of a team or project
+func (h *Handlers) DeleteBudget(c *gin.Context) {
+	scope, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	budget, err := h.getBudget(ctx, scope)
+	if err == nil {
+		err = h.budgets.Delete(ctx, budget.ID)
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no budget set"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to delete budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true})
+}
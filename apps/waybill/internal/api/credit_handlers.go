@@ -0,0 +1,70 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/madfam-org/enclii/apps/waybill/internal/credits"
+	"go.uber.org/zap"
+)
+
+// GetCredits returns the credits of a team or team-less project, what is
+// left of them, and the rate month-to-date usage spends them at
+func (h *Handlers) GetCredits(c *gin.Context) {
+	scope, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+
+	balance, err := h.credits.Balance(c.Request.Context(), credits.Scope{
+		TeamID:    scope.teamID,
+		ProjectID: scope.projectID,
+	}, time.Now().UTC())
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	case errors.Is(err, credits.ErrBilledToTeam):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		h.logger.Error("failed to get credits", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get credits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// GrantCredit gives a team or project promotional or prepaid credit
+func (h *Handlers) GrantCredit(c *gin.Context) {
+	var req credits.GrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.TeamID == nil) == (req.ProjectID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of team_id and project_id is required"})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	credit, err := h.credits.Grant(c.Request.Context(), &req)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "team or project not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to grant credit", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to grant credit"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, credit)
+}
@@ -8,7 +8,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/madfam-org/enclii/apps/waybill/internal/billing"
+	"github.com/madfam-org/enclii/apps/waybill/internal/budgets"
+	"github.com/madfam-org/enclii/apps/waybill/internal/credits"
 	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+	"github.com/madfam-org/enclii/apps/waybill/internal/invoices"
+	"github.com/madfam-org/enclii/apps/waybill/internal/payments"
+	"github.com/madfam-org/enclii/apps/waybill/internal/usage"
 	"go.uber.org/zap"
 )
 
@@ -17,6 +22,12 @@ type Handlers struct {
 	collector  *events.Collector
 	calculator *billing.Calculator
 	stripe     *billing.StripeClient
+	budgets    *budgets.Store
+	evaluator  *budgets.Evaluator
+	invoices   *invoices.Service
+	credits    *credits.Service
+	payments   *payments.Processor
+	usage      *usage.Store
 	logger     *zap.Logger
 }
 
@@ -25,12 +36,24 @@ func NewHandlers(
 	collector *events.Collector,
 	calculator *billing.Calculator,
 	stripe *billing.StripeClient,
+	budgetStore *budgets.Store,
+	evaluator *budgets.Evaluator,
+	invoiceService *invoices.Service,
+	creditService *credits.Service,
+	paymentProcessor *payments.Processor,
+	usageStore *usage.Store,
 	logger *zap.Logger,
 ) *Handlers {
 	return &Handlers{
 		collector:  collector,
 		calculator: calculator,
 		stripe:     stripe,
+		budgets:    budgetStore,
+		evaluator:  evaluator,
+		invoices:   invoiceService,
+		credits:    creditService,
+		payments:   paymentProcessor,
+		usage:      usageStore,
 		logger:     logger,
 	}
 }
@@ -43,13 +66,26 @@ func (h *Handlers) RecordEvent(c *gin.Context) {
 		return
 	}
 
-	event, err := h.collector.Record(c.Request.Context(), &req)
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+	}
+
+	event, duplicate, err := h.collector.Record(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.Error("failed to record event", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record event"})
 		return
 	}
 
+	if duplicate {
+		c.JSON(http.StatusOK, gin.H{
+			"event_id":  event.ID,
+			"recorded":  false,
+			"duplicate": true,
+		})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"event_id": event.ID,
 		"recorded": true,
@@ -66,14 +102,16 @@ func (h *Handlers) RecordEventBatch(c *gin.Context) {
 		return
 	}
 
-	if err := h.collector.RecordBatch(c.Request.Context(), req.Events); err != nil {
+	duplicates, err := h.collector.RecordBatch(c.Request.Context(), req.Events)
+	if err != nil {
 		h.logger.Error("failed to record events", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record events"})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"recorded": len(req.Events),
+		"recorded":   len(req.Events) - duplicates,
+		"duplicates": duplicates,
 	})
 }
 
@@ -135,21 +173,22 @@ func (h *Handlers) EstimateCost(c *gin.Context) {
 	c.JSON(http.StatusOK, estimate)
 }
 
-// GetInvoices lists invoices for a project
-func (h *Handlers) GetInvoices(c *gin.Context) {
-	projectID, err := uuid.Parse(c.Param("project_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+// EstimateServiceCost returns the projected monthly cost of a proposed
+// service configuration, so clients can show the cost of a change before
+// deploying it
+func (h *Handlers) EstimateServiceCost(c *gin.Context) {
+	var spec billing.ServiceSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get Stripe customer ID from database (simplified - would query subscriptions table)
-	// For now, return mock data structure
-	c.JSON(http.StatusOK, gin.H{
-		"project_id": projectID,
-		"invoices":   []interface{}{},
-		"message":    "Stripe integration required for invoice listing",
-	})
+	estimate, err := h.calculator.EstimateService(&spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, estimate)
 }
 
 // GetPlans returns available pricing plans
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/invoices"
+	"go.uber.org/zap"
+)
+
+// GetInvoices lists the invoices of a team, or of a project together with
+// those of its team
+func (h *Handlers) GetInvoices(c *gin.Context) {
+	scope, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var list []*invoices.Invoice
+	var err error
+	if scope.teamID != nil {
+		list, err = h.invoices.Store().ListForTeam(ctx, *scope.teamID)
+	} else {
+		list, err = h.invoices.Store().ListForProject(ctx, *scope.projectID)
+	}
+	if err != nil {
+		h.logger.Error("failed to list invoices", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invoices": list})
+}
+
+// GenerateInvoice creates or refreshes the draft invoice of a team or project
+// for a month
+func (h *Handlers) GenerateInvoice(c *gin.Context) {
+	scope, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+
+	var req invoices.GenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	period, err := invoices.ParsePeriod(req.Period)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period must be a month like 2026-01"})
+		return
+	}
+
+	inv, err := h.invoices.Generate(c.Request.Context(), invoices.Scope{
+		TeamID:    scope.teamID,
+		ProjectID: scope.projectID,
+	}, period)
+	if err != nil {
+		h.invoiceError(c, "failed to generate invoice", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inv)
+}
+
+// GetInvoice returns an invoice with its line items
+func (h *Handlers) GetInvoice(c *gin.Context) {
+	h.withInvoice(c, "failed to get invoice", h.invoices.Store().Get)
+}
+
+// GetInvoicePDF renders an invoice as a PDF
+func (h *Handlers) GetInvoicePDF(c *gin.Context) {
+	id, ok := parseInvoiceID(c)
+	if !ok {
+		return
+	}
+
+	inv, err := h.invoices.Store().Get(c.Request.Context(), id)
+	if err != nil {
+		h.invoiceError(c, "failed to get invoice", err)
+		return
+	}
+
+	pdf, err := invoices.RenderPDF(inv)
+	if err != nil {
+		h.logger.Error("failed to render invoice", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render invoice"})
+		return
+	}
+
+	filename := "draft-" + inv.PeriodStart.Format("2006-01")
+	if inv.Number != nil {
+		filename = *inv.Number
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, filename))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// FinalizeInvoice numbers and freezes a draft invoice and pushes it to Stripe
+func (h *Handlers) FinalizeInvoice(c *gin.Context) {
+	h.withInvoice(c, "failed to finalize invoice", h.invoices.Finalize)
+}
+
+// MarkInvoicePaid records the payment of a finalized invoice made outside
+// Stripe
+func (h *Handlers) MarkInvoicePaid(c *gin.Context) {
+	h.withInvoice(c, "failed to mark invoice paid", h.invoices.MarkPaid)
+}
+
+// SyncInvoice pushes a finalized invoice to Stripe, or pulls its payment
+// status
+func (h *Handlers) SyncInvoice(c *gin.Context) {
+	h.withInvoice(c, "failed to sync invoice", h.invoices.Sync)
+}
+
+// withInvoice runs an operation on the invoice of the route and responds
+// with the invoice it returns
+func (h *Handlers) withInvoice(c *gin.Context, message string, op func(context.Context, uuid.UUID) (*invoices.Invoice, error)) {
+	id, ok := parseInvoiceID(c)
+	if !ok {
+		return
+	}
+
+	inv, err := op(c.Request.Context(), id)
+	if err != nil {
+		h.invoiceError(c, message, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inv)
+}
+
+func parseInvoiceID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("invoice_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invoice ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// invoiceError maps invoice errors to responses
+func (h *Handlers) invoiceError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, invoices.ErrNotDraft), errors.Is(err, invoices.ErrNotFinalized), errors.Is(err, invoices.ErrCreditsChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, invoices.ErrBilledToTeam):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
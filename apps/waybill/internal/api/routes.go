@@ -41,6 +41,9 @@ func (s *Server) setupRoutes(cfg *ServerConfig) {
 	s.router.GET("/health", s.handlers.HealthCheck)
 	s.router.GET("/ready", s.handlers.HealthCheck)
 
+	// Stripe webhooks (authenticated by their signature)
+	s.router.POST("/webhooks/stripe", s.handlers.StripeWebhook)
+
 	// Internal API (for Switchyard/Roundhouse)
 	internal := s.router.Group("/internal")
 	if cfg.InternalAPIKey != "" {
@@ -49,6 +52,7 @@ func (s *Server) setupRoutes(cfg *ServerConfig) {
 	{
 		internal.POST("/events", s.handlers.RecordEvent)
 		internal.POST("/events/batch", s.handlers.RecordEventBatch)
+		internal.POST("/credits", s.handlers.GrantCredit)
 	}
 
 	// Public API (authenticated via JWT from Switchyard)
@@ -58,10 +62,32 @@ func (s *Server) setupRoutes(cfg *ServerConfig) {
 		// Usage
 		api.GET("/projects/:project_id/usage/current", s.handlers.GetCurrentUsage)
 		api.GET("/projects/:project_id/usage/history", s.handlers.GetUsageHistory)
+		api.GET("/usage", s.handlers.GetTeamUsage)
 		api.POST("/estimate", s.handlers.EstimateCost)
+		api.POST("/cost/estimate", s.handlers.EstimateServiceCost)
 
-		// Billing
+		// Invoices
 		api.GET("/projects/:project_id/invoices", s.handlers.GetInvoices)
+		api.POST("/projects/:project_id/invoices", s.handlers.GenerateInvoice)
+		api.GET("/teams/:team_id/invoices", s.handlers.GetInvoices)
+		api.POST("/teams/:team_id/invoices", s.handlers.GenerateInvoice)
+		api.GET("/invoices/:invoice_id", s.handlers.GetInvoice)
+		api.GET("/invoices/:invoice_id/pdf", s.handlers.GetInvoicePDF)
+		api.POST("/invoices/:invoice_id/finalize", s.handlers.FinalizeInvoice)
+		api.POST("/invoices/:invoice_id/mark-paid", s.handlers.MarkInvoicePaid)
+		api.POST("/invoices/:invoice_id/sync", s.handlers.SyncInvoice)
+
+		// Credits
+		api.GET("/projects/:project_id/credits", s.handlers.GetCredits)
+		api.GET("/teams/:team_id/credits", s.handlers.GetCredits)
+
+		// Budgets
+		api.GET("/projects/:project_id/budget", s.handlers.GetBudget)
+		api.PUT("/projects/:project_id/budget", s.handlers.SetBudget)
+		api.DELETE("/projects/:project_id/budget", s.handlers.DeleteBudget)
+		api.GET("/teams/:team_id/budget", s.handlers.GetBudget)
+		api.PUT("/teams/:team_id/budget", s.handlers.SetBudget)
+		api.DELETE("/teams/:team_id/budget", s.handlers.DeleteBudget)
 
 		// Plans
 		api.GET("/plans", s.handlers.GetPlans)
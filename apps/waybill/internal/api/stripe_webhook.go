@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/madfam-org/enclii/apps/waybill/internal/payments"
+	"go.uber.org/zap"
+)
+
+// maxWebhookBytes bounds the Stripe webhook payloads read
+const maxWebhookBytes = 1 << 20
+
+// StripeWebhook applies a signed Stripe event. Failures answer 500 so
+// Stripe redelivers the event.
+func (h *Handlers) StripeWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+
+	err = h.payments.HandleWebhook(c.Request.Context(), payload, c.GetHeader("Stripe-Signature"))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"received": true})
+	case errors.Is(err, payments.ErrNotConfigured):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, payments.ErrInvalidSignature):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("failed to process stripe webhook", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process event"})
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/usage"
+	"go.uber.org/zap"
+)
+
+// GetTeamUsage returns the usage of a team's projects grouped by period,
+// project and service, as JSON or, with format=csv or Accept: text/csv, as
+// a CSV download for internal chargeback
+func (h *Handlers) GetTeamUsage(c *gin.Context) {
+	teamID, err := uuid.Parse(c.Query("team"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "team must be a team ID"})
+		return
+	}
+
+	now := time.Now().UTC()
+	q := &usage.Query{
+		TeamID:      teamID,
+		From:        time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		To:          now,
+		Granularity: usage.Granularity(c.DefaultQuery("granularity", string(usage.GranularityDay))),
+	}
+	if from := c.Query("from"); from != "" {
+		if q.From, err = parseUsageTime(from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date or RFC 3339 time"})
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if q.To, err = parseUsageTime(to); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date or RFC 3339 time"})
+			return
+		}
+	}
+	if err := q.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.usage.Report(c.Request.Context(), q)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "team not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to get team usage", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get usage"})
+		return
+	}
+
+	if c.Query("format") != "csv" && !strings.Contains(c.GetHeader("Accept"), "text/csv") {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	filename := fmt.Sprintf("usage-%s-%s-%s.csv", teamID, q.From.Format("20060102"), q.To.Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+	if err := usage.WriteCSV(c.Writer, report); err != nil {
+		h.logger.Error("failed to write usage CSV", zap.Error(err))
+	}
+}
+
+// parseUsageTime parses an RFC 3339 time or a date, which is midnight UTC
+func parseUsageTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,177 @@
+package bandwidth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+	"go.uber.org/zap"
+)
+
+// collectorName marks the usage events this collector records, so an hour
+// that was already collected is not recorded twice
+const collectorName = "bandwidth"
+
+const bytesPerGB = 1024 * 1024 * 1024
+
+// Collector turns per-service traffic into bandwidth usage events
+type Collector struct {
+	db        *sql.DB
+	source    Source
+	collector *events.Collector
+	logger    *zap.Logger
+}
+
+// NewCollector creates a new bandwidth collector
+func NewCollector(db *sql.DB, source Source, collector *events.Collector, logger *zap.Logger) *Collector {
+	return &Collector{
+		db:        db,
+		source:    source,
+		collector: collector,
+		logger:    logger,
+	}
+}
+
+// serviceRef is the Enclii service behind a Kubernetes service
+type serviceRef struct {
+	id          uuid.UUID
+	projectID   uuid.UUID
+	teamID      *uuid.UUID
+	name        string
+	environment string
+}
+
+// Collect records the traffic of every service during an hour as
+// bandwidth.usage events timestamped at the start of the hour. Hours that
+// were already collected are skipped.
+func (c *Collector) Collect(ctx context.Context, hour time.Time) error {
+	hour = hour.Truncate(time.Hour)
+	nextHour := hour.Add(time.Hour)
+
+	collected, err := c.isCollected(ctx, hour, nextHour)
+	if err != nil {
+		return err
+	}
+	if collected {
+		c.logger.Info("bandwidth already collected", zap.Time("hour", hour))
+		return nil
+	}
+
+	samples, err := c.source.Usage(ctx, hour, nextHour)
+	if err != nil {
+		return fmt.Errorf("failed to read bandwidth usage: %w", err)
+	}
+
+	var requests []*events.EventRequest
+	unknown := 0
+	for _, sample := range samples {
+		ref, err := c.resolveService(ctx, sample.Namespace, sample.Service)
+		if err == sql.ErrNoRows {
+			// Platform components and services deleted since are not billed
+			unknown++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		timestamp := hour
+		requests = append(requests, &events.EventRequest{
+			EventType:    events.EventBandwidthUsage,
+			ProjectID:    ref.projectID,
+			TeamID:       ref.teamID,
+			ResourceType: "service",
+			ResourceID:   ref.id,
+			ResourceName: ref.name,
+			Metrics: map[string]float64{
+				"ingress_gb": sample.IngressBytes / bytesPerGB,
+				"egress_gb":  sample.EgressBytes / bytesPerGB,
+			},
+			Metadata: map[string]string{
+				"collector":   collectorName,
+				"namespace":   sample.Namespace,
+				"environment": ref.environment,
+			},
+			Timestamp:      &timestamp,
+			IdempotencyKey: fmt.Sprintf("bandwidth:%s:%s:%d", ref.id, sample.Namespace, hour.Unix()),
+		})
+	}
+
+	if len(requests) > 0 {
+		if _, err := c.collector.RecordBatch(ctx, requests); err != nil {
+			return err
+		}
+	}
+
+	c.logger.Info("bandwidth collected",
+		zap.Time("hour", hour),
+		zap.Int("services", len(requests)),
+		zap.Int("unknown_services", unknown),
+	)
+
+	return nil
+}
+
+// CollectRange collects every hour in [start, end) (backfill)
+func (c *Collector) CollectRange(ctx context.Context, start, end time.Time) error {
+	current := start.Truncate(time.Hour)
+	end = end.Truncate(time.Hour)
+
+	for current.Before(end) {
+		if err := c.Collect(ctx, current); err != nil {
+			return fmt.Errorf("failed at hour %v: %w", current, err)
+		}
+		current = current.Add(time.Hour)
+	}
+
+	return nil
+}
+
+func (c *Collector) isCollected(ctx context.Context, start, end time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM usage_events
+			WHERE event_type = $1 AND timestamp >= $2 AND timestamp < $3
+			  AND metadata->>'collector' = $4
+		)
+	`
+
+	var collected bool
+	if err := c.db.QueryRowContext(ctx, query, events.EventBandwidthUsage, start, end, collectorName).Scan(&collected); err != nil {
+		return false, fmt.Errorf("failed to check collected bandwidth: %w", err)
+	}
+	return collected, nil
+}
+
+// resolveService finds the service deployed as a Kubernetes service in an
+// environment namespace. It returns sql.ErrNoRows for services Enclii does
+// not manage.
+func (c *Collector) resolveService(ctx context.Context, namespace, name string) (*serviceRef, error) {
+	query := `
+		SELECT s.id, s.project_id, p.team_id, s.name, e.name
+		FROM services s
+		JOIN environments e ON e.project_id = s.project_id
+		JOIN projects p ON p.id = s.project_id
+		WHERE e.kube_namespace = $1 AND s.name = $2
+		LIMIT 1
+	`
+
+	var ref serviceRef
+	err := c.db.QueryRowContext(ctx, query, namespace, name).Scan(
+		&ref.id,
+		&ref.projectID,
+		&ref.teamID,
+		&ref.name,
+		&ref.environment,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve service %s/%s: %w", namespace, name, err)
+	}
+	return &ref, nil
+}
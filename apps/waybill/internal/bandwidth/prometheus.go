@@ -0,0 +1,192 @@
+package bandwidth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default queries read the bytes the ingress controller received from and
+// sent to clients per service. $window is replaced with the length of the
+// collected period before the query runs.
+const (
+	DefaultIngressQuery = `sum by (exported_namespace, exported_service) (increase(nginx_ingress_controller_request_size_sum[$window]))`
+	DefaultEgressQuery  = `sum by (exported_namespace, exported_service) (increase(nginx_ingress_controller_response_size_sum[$window]))`
+)
+
+// Sample is the traffic of one Kubernetes service over a period
+type Sample struct {
+	Namespace    string
+	Service      string
+	IngressBytes float64
+	EgressBytes  float64
+}
+
+// Source reports per-service traffic for a period
+type Source interface {
+	Usage(ctx context.Context, start, end time.Time) ([]*Sample, error)
+}
+
+// PrometheusSource reads service traffic from a Prometheus server
+type PrometheusSource struct {
+	baseURL      string
+	ingressQuery string
+	egressQuery  string
+	httpClient   *http.Client
+}
+
+// NewPrometheusSource creates a traffic source for the Prometheus server at
+// baseURL. Empty queries use the nginx ingress defaults; replacements (for
+// example from Cilium/Hubble metrics) must return one series per service,
+// labeled with its namespace and service.
+func NewPrometheusSource(baseURL, ingressQuery, egressQuery string) *PrometheusSource {
+	if ingressQuery == "" {
+		ingressQuery = DefaultIngressQuery
+	}
+	if egressQuery == "" {
+		egressQuery = DefaultEgressQuery
+	}
+	return &PrometheusSource{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		ingressQuery: ingressQuery,
+		egressQuery:  egressQuery,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// promQueryResponse is the part of a Prometheus instant query response that is read
+type promQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Usage runs the ingress and egress queries over [start, end)
+func (p *PrometheusSource) Usage(ctx context.Context, start, end time.Time) ([]*Sample, error) {
+	window := fmt.Sprintf("%ds", int(end.Sub(start).Seconds()))
+
+	ingress, err := p.query(ctx, strings.ReplaceAll(p.ingressQuery, "$window", window), end)
+	if err != nil {
+		return nil, fmt.Errorf("ingress query failed: %w", err)
+	}
+	egress, err := p.query(ctx, strings.ReplaceAll(p.egressQuery, "$window", window), end)
+	if err != nil {
+		return nil, fmt.Errorf("egress query failed: %w", err)
+	}
+
+	samples := make(map[serviceKey]*Sample)
+	sample := func(key serviceKey) *Sample {
+		if s, ok := samples[key]; ok {
+			return s
+		}
+		s := &Sample{Namespace: key.namespace, Service: key.service}
+		samples[key] = s
+		return s
+	}
+	for key, bytes := range ingress {
+		sample(key).IngressBytes = bytes
+	}
+	for key, bytes := range egress {
+		sample(key).EgressBytes = bytes
+	}
+
+	result := make([]*Sample, 0, len(samples))
+	for _, s := range samples {
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+type serviceKey struct {
+	namespace string
+	service   string
+}
+
+// query runs an instant query evaluated at ts and returns its value per service
+func (p *PrometheusSource) query(ctx context.Context, query string, ts time.Time) (map[serviceKey]float64, error) {
+	params := url.Values{
+		"query": {query},
+		"time":  {strconv.FormatInt(ts.Unix(), 10)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prometheus response: %w", err)
+	}
+
+	return parseServiceBytes(resp.StatusCode, body)
+}
+
+// parseServiceBytes reads a vector of per-service byte counts. Series are
+// matched to services by their exported_namespace/exported_service labels
+// (as relabeled when scraping the ingress controller), or namespace/service.
+func parseServiceBytes(statusCode int, body []byte) (map[serviceKey]float64, error) {
+	var result promQueryResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("prometheus returned %d: invalid response", statusCode)
+	}
+	if statusCode != http.StatusOK || result.Status != "success" {
+		return nil, fmt.Errorf("prometheus returned %d: %s", statusCode, result.Error)
+	}
+	if result.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("prometheus returned a %s, expected a vector", result.Data.ResultType)
+	}
+
+	values := make(map[serviceKey]float64, len(result.Data.Result))
+	for _, series := range result.Data.Result {
+		key := serviceKey{
+			namespace: firstLabel(series.Metric, "exported_namespace", "namespace"),
+			service:   firstLabel(series.Metric, "exported_service", "service"),
+		}
+		if key.namespace == "" || key.service == "" {
+			continue
+		}
+
+		raw, ok := series.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("prometheus returned a non-string sample value")
+		}
+		bytes, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("prometheus returned an invalid sample value %q", raw)
+		}
+		if math.IsNaN(bytes) || math.IsInf(bytes, 0) || bytes <= 0 {
+			continue
+		}
+		values[key] += bytes
+	}
+
+	return values, nil
+}
+
+func firstLabel(labels map[string]string, names ...string) string {
+	for _, name := range names {
+		if value := labels[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
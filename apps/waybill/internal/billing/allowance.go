@@ -0,0 +1,37 @@
+package billing
+
+import "github.com/madfam-org/enclii/apps/waybill/internal/events"
+
+// Allowance is the usage every project gets free each month, before any of
+// it is charged. It is the same for every project on the platform.
+type Allowance struct {
+	ComputeGBHours float64 `json:"compute_gb_hours"`
+	BuildMinutes   float64 `json:"build_minutes"`
+	StorageGBHours float64 `json:"storage_gb_hours"` // A GB-month is 720 GB-hours
+	BandwidthGB    float64 `json:"bandwidth_gb"`
+}
+
+// Free returns the free quantity of a metric, in the unit it is aggregated
+// in. A nil allowance is zero.
+func (a *Allowance) Free(metricType events.MetricType) float64 {
+	if a == nil {
+		return 0
+	}
+	switch metricType {
+	case events.MetricComputeGBHours:
+		return a.ComputeGBHours
+	case events.MetricBuildMinutes:
+		return a.BuildMinutes
+	case events.MetricStorageGBHours:
+		return a.StorageGBHours
+	case events.MetricBandwidthGB:
+		return a.BandwidthGB
+	default:
+		return 0
+	}
+}
+
+// Covered returns how much of a monthly quantity the allowance covers
+func (a *Allowance) Covered(metricType events.MetricType, value float64) float64 {
+	return min(a.Free(metricType), max(value, 0))
+}
@@ -13,10 +13,10 @@ import (
 
 // Pricing contains the price per unit for each metric
 type Pricing struct {
-	ComputePerGBHour  float64 // $/GB-hour
-	BuildPerMinute    float64 // $/minute
-	StoragePerGBMonth float64 // $/GB-month
-	BandwidthPerGB    float64 // $/GB egress
+	ComputePerGBHour  float64 `json:"compute_per_gb_hour"`  // $/GB-hour
+	BuildPerMinute    float64 `json:"build_per_minute"`     // $/minute
+	StoragePerGBMonth float64 `json:"storage_per_gb_month"` // $/GB-month
+	BandwidthPerGB    float64 `json:"bandwidth_per_gb"`     // $/GB egress
 }
 
 // DefaultPricing returns Railway-like default pricing
@@ -31,24 +31,28 @@ func DefaultPricing() *Pricing {
 
 // Calculator handles usage to cost calculations
 type Calculator struct {
-	db      *sql.DB
-	pricing *Pricing
-	logger  *zap.Logger
+	db        *sql.DB
+	pricing   *Pricing
+	allowance *Allowance
+	logger    *zap.Logger
 }
 
-// NewCalculator creates a new billing calculator
-func NewCalculator(db *sql.DB, pricing *Pricing, logger *zap.Logger) *Calculator {
+// NewCalculator creates a new billing calculator. Usage within the free
+// allowance, which may be nil, is not charged.
+func NewCalculator(db *sql.DB, pricing *Pricing, allowance *Allowance, logger *zap.Logger) *Calculator {
 	if pricing == nil {
 		pricing = DefaultPricing()
 	}
 	return &Calculator{
-		db:      db,
-		pricing: pricing,
-		logger:  logger,
+		db:        db,
+		pricing:   pricing,
+		allowance: allowance,
+		logger:    logger,
 	}
 }
 
-// CalculateUsageSummary calculates usage and costs for a project
+// CalculateUsageSummary calculates usage and costs for a project. The free
+// allowance is monthly, so periods should not span more than a month.
 func (c *Calculator) CalculateUsageSummary(ctx context.Context, projectID uuid.UUID, start, end time.Time) (*events.UsageSummary, error) {
 	summary := &events.UsageSummary{
 		ProjectID:   projectID,
@@ -56,6 +60,7 @@ func (c *Calculator) CalculateUsageSummary(ctx context.Context, projectID uuid.U
 		PeriodEnd:   end,
 		Metrics:     make(map[events.MetricType]float64),
 		Costs:       make(map[events.MetricType]float64),
+		Free:        make(map[events.MetricType]float64),
 	}
 
 	// Query aggregated hourly usage
@@ -81,7 +86,11 @@ func (c *Calculator) CalculateUsageSummary(ctx context.Context, projectID uuid.U
 
 		mt := events.MetricType(metricType)
 		summary.Metrics[mt] = total
-		summary.Costs[mt] = c.calculateCost(mt, total, start, end)
+		free := c.allowance.Covered(mt, total)
+		if free > 0 {
+			summary.Free[mt] = free
+		}
+		summary.Costs[mt] = c.pricing.Cost(mt, total-free)
 	}
 
 	// Calculate total cost
@@ -99,29 +108,29 @@ func (c *Calculator) CalculateUsageSummary(ctx context.Context, projectID uuid.U
 	return summary, nil
 }
 
-// calculateCost calculates cost for a specific metric
-func (c *Calculator) calculateCost(metricType events.MetricType, value float64, start, end time.Time) float64 {
+// UnitPrice returns the price of one unit of a metric as aggregated in
+// hourly_usage. Storage is priced per GB-hour, a GB-month being 720 hours.
+func (p *Pricing) UnitPrice(metricType events.MetricType) float64 {
 	switch metricType {
 	case events.MetricComputeGBHours:
-		return value * c.pricing.ComputePerGBHour
+		return p.ComputePerGBHour
 	case events.MetricBuildMinutes:
-		return value * c.pricing.BuildPerMinute
+		return p.BuildPerMinute
 	case events.MetricStorageGBHours:
-		// Convert GB-hours to GB-month equivalent
-		hours := end.Sub(start).Hours()
-		monthHours := 24 * 30.0
-		gbMonths := value / monthHours * hours
-		return gbMonths * c.pricing.StoragePerGBMonth
+		return p.StoragePerGBMonth / hoursPerMonth
 	case events.MetricBandwidthGB:
-		return value * c.pricing.BandwidthPerGB
-	case events.MetricCustomDomains:
-		// Custom domains are free
-		return 0
+		return p.BandwidthPerGB
 	default:
+		// Custom domains are free, and idle savings are not usage
 		return 0
 	}
 }
 
+// Cost returns the cost of a quantity of a metric
+func (p *Pricing) Cost(metricType events.MetricType, value float64) float64 {
+	return value * p.UnitPrice(metricType)
+}
+
 // GetCurrentPeriodUsage gets usage for the current billing period
 func (c *Calculator) GetCurrentPeriodUsage(ctx context.Context, projectID uuid.UUID) (*events.UsageSummary, error) {
 	// Get the start of the current month
@@ -161,6 +170,16 @@ func (c *Calculator) GetHistoricalUsage(ctx context.Context, projectID uuid.UUID
 	return summaries, nil
 }
 
+// Pricing returns the rates the calculator bills with
+func (c *Calculator) Pricing() *Pricing {
+	return c.pricing
+}
+
+// Allowance returns the monthly free allowance, which may be nil
+func (c *Calculator) Allowance() *Allowance {
+	return c.allowance
+}
+
 // EstimateCost estimates the cost for given resource specs
 func (c *Calculator) EstimateCost(specs *ResourceSpecs) *CostEstimate {
 	estimate := &CostEstimate{
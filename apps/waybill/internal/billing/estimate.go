@@ -0,0 +1,192 @@
+package billing
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// hoursPerMonth is the month length estimates are projected over,
+// matching CalculateUsageSummary
+const hoursPerMonth = 24 * 30.0
+
+// defaultBuildsPerMonth is assumed when a spec does not say how often it builds
+const defaultBuildsPerMonth = 30
+
+// ServiceSpec is a proposed service configuration to estimate
+type ServiceSpec struct {
+	Replicas        int         `json:"replicas"`                   // Defaults to 1
+	CPU             string      `json:"cpu"`                        // Kubernetes quantity, e.g. "500m" or "2"
+	Memory          string      `json:"memory"`                     // Kubernetes quantity, e.g. "512Mi" or "1Gi"
+	StorageGB       float64     `json:"storage_gb,omitempty"`       // Attached volumes
+	BandwidthGB     float64     `json:"bandwidth_gb,omitempty"`     // Expected egress per month
+	BuildsPerMonth  *int        `json:"builds_per_month,omitempty"` // Defaults to 30
+	AvgBuildMinutes float64     `json:"avg_build_minutes,omitempty"`
+	Addons          []AddonSpec `json:"addons,omitempty"`
+}
+
+// AddonSpec is the size of an addon (database, cache, bucket) a service uses
+type AddonSpec struct {
+	Name      string  `json:"name,omitempty"`
+	Type      string  `json:"type"` // e.g. "postgres", "redis", "bucket"
+	Replicas  int     `json:"replicas,omitempty"`
+	CPU       string  `json:"cpu,omitempty"`
+	Memory    string  `json:"memory,omitempty"`
+	StorageGB float64 `json:"storage_gb,omitempty"`
+}
+
+// ServiceCostEstimate is the projected monthly cost of a ServiceSpec
+type ServiceCostEstimate struct {
+	ComputeGBHours float64              `json:"compute_gb_hours"`
+	Compute        float64              `json:"compute"`
+	Storage        float64              `json:"storage"`
+	Bandwidth      float64              `json:"bandwidth"`
+	Builds         float64              `json:"builds"`
+	Addons         []*AddonCostEstimate `json:"addons,omitempty"`
+	TotalMonthly   float64              `json:"total_monthly"`
+	Pricing        *Pricing             `json:"pricing"` // Rates the estimate used
+}
+
+// AddonCostEstimate is the projected monthly cost of an AddonSpec
+type AddonCostEstimate struct {
+	Name         string  `json:"name,omitempty"`
+	Type         string  `json:"type"`
+	Compute      float64 `json:"compute"`
+	Storage      float64 `json:"storage"`
+	TotalMonthly float64 `json:"total_monthly"`
+}
+
+// EstimateService projects the monthly cost of a service configuration.
+// Compute is billed like running deployments: the larger of CPU cores and
+// memory GB, per replica, for every hour of the month.
+func (c *Calculator) EstimateService(spec *ServiceSpec) (*ServiceCostEstimate, error) {
+	gbHours, err := monthlyGBHours(spec.Replicas, spec.CPU, spec.Memory)
+	if err != nil {
+		return nil, err
+	}
+
+	builds := defaultBuildsPerMonth
+	if spec.BuildsPerMonth != nil {
+		builds = *spec.BuildsPerMonth
+	}
+
+	estimate := &ServiceCostEstimate{
+		ComputeGBHours: gbHours,
+		Compute:        roundCents(gbHours * c.pricing.ComputePerGBHour),
+		Storage:        roundCents(spec.StorageGB * c.pricing.StoragePerGBMonth),
+		Bandwidth:      roundCents(spec.BandwidthGB * c.pricing.BandwidthPerGB),
+		Builds:         roundCents(float64(builds) * spec.AvgBuildMinutes * c.pricing.BuildPerMinute),
+		Pricing:        c.pricing,
+	}
+	total := estimate.Compute + estimate.Storage + estimate.Bandwidth + estimate.Builds
+
+	for _, addon := range spec.Addons {
+		addonGBHours, err := monthlyGBHours(addon.Replicas, addon.CPU, addon.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("addon %s: %w", addonLabel(addon), err)
+		}
+
+		addonEstimate := &AddonCostEstimate{
+			Name:    addon.Name,
+			Type:    addon.Type,
+			Compute: roundCents(addonGBHours * c.pricing.ComputePerGBHour),
+			Storage: roundCents(addon.StorageGB * c.pricing.StoragePerGBMonth),
+		}
+		addonEstimate.TotalMonthly = roundCents(addonEstimate.Compute + addonEstimate.Storage)
+		estimate.Addons = append(estimate.Addons, addonEstimate)
+		total += addonEstimate.TotalMonthly
+	}
+
+	estimate.TotalMonthly = roundCents(total)
+	return estimate, nil
+}
+
+func addonLabel(addon AddonSpec) string {
+	if addon.Name != "" {
+		return addon.Name
+	}
+	return addon.Type
+}
+
+// monthlyGBHours returns the GB-hours a workload is billed for in a month
+func monthlyGBHours(replicas int, cpu, memory string) (float64, error) {
+	if replicas <= 0 {
+		replicas = 1
+	}
+
+	cores, err := parseCPU(cpu)
+	if err != nil {
+		return 0, err
+	}
+	memoryGB, err := parseMemoryGB(memory)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Max(cores, memoryGB) * float64(replicas) * hoursPerMonth, nil
+}
+
+// parseCPU parses a Kubernetes CPU quantity ("250m", "0.5", "2") into cores
+func parseCPU(quantity string) (float64, error) {
+	quantity = strings.TrimSpace(quantity)
+	if quantity == "" {
+		return 0, nil
+	}
+
+	number, divisor := quantity, 1.0
+	if strings.HasSuffix(quantity, "m") {
+		number, divisor = strings.TrimSuffix(quantity, "m"), 1000
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid cpu quantity %q", quantity)
+	}
+	return value / divisor, nil
+}
+
+// memoryUnits maps Kubernetes memory suffixes to GB (GiB, as billed)
+var memoryUnits = []struct {
+	suffix string
+	gb     float64
+}{
+	{"Ki", 1.0 / (1024 * 1024)},
+	{"Mi", 1.0 / 1024},
+	{"Gi", 1},
+	{"Ti", 1024},
+	{"K", 1e3 / (1 << 30)},
+	{"M", 1e6 / (1 << 30)},
+	{"G", 1e9 / (1 << 30)},
+	{"T", 1e12 / (1 << 30)},
+}
+
+// parseMemoryGB parses a Kubernetes memory quantity ("512Mi", "1Gi") into
+// GB. Plain numbers are bytes.
+func parseMemoryGB(quantity string) (float64, error) {
+	quantity = strings.TrimSpace(quantity)
+	if quantity == "" {
+		return 0, nil
+	}
+
+	perUnit := 1.0 / (1 << 30)
+	number := quantity
+	for _, unit := range memoryUnits {
+		if strings.HasSuffix(quantity, unit.suffix) {
+			number = strings.TrimSuffix(quantity, unit.suffix)
+			perUnit = unit.gb
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid memory quantity %q", quantity)
+	}
+	return value * perUnit, nil
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -2,7 +2,10 @@ package billing
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stripe/stripe-go/v76"
@@ -10,6 +13,7 @@ import (
 	"github.com/stripe/stripe-go/v76/invoice"
 	"github.com/stripe/stripe-go/v76/invoiceitem"
 	"github.com/stripe/stripe-go/v76/subscription"
+	"github.com/stripe/stripe-go/v76/webhook"
 	"go.uber.org/zap"
 )
 
@@ -132,6 +136,90 @@ func (s *StripeClient) CreateUsageInvoice(ctx context.Context, customerID string
 	return inv, nil
 }
 
+// CreateInvoice creates a Stripe invoice holding exactly the given items
+// and finalizes it. It returns the Stripe invoice ID.
+func (s *StripeClient) CreateInvoice(ctx context.Context, customerID, currency string, items []*UsageLineItem, metadata map[string]string) (string, error) {
+	inv, err := invoice.New(&stripe.InvoiceParams{
+		Customer:                    stripe.String(customerID),
+		AutoAdvance:                 stripe.Bool(true),
+		CollectionMethod:            stripe.String("charge_automatically"),
+		PendingInvoiceItemsBehavior: stripe.String("exclude"),
+		Metadata:                    metadata,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	for _, item := range items {
+		_, err := invoiceitem.New(&stripe.InvoiceItemParams{
+			Customer:    stripe.String(customerID),
+			Invoice:     stripe.String(inv.ID),
+			Amount:      stripe.Int64(int64(item.AmountCents)),
+			Currency:    stripe.String(strings.ToLower(currency)),
+			Description: stripe.String(item.Description),
+			Metadata: map[string]string{
+				"metric_type": item.MetricType,
+				"quantity":    fmt.Sprintf("%.4f", item.Quantity),
+				"unit_price":  fmt.Sprintf("%.6f", item.UnitPrice),
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create invoice item: %w", err)
+		}
+	}
+
+	if _, err := invoice.FinalizeInvoice(inv.ID, &stripe.InvoiceFinalizeInvoiceParams{}); err != nil {
+		return "", fmt.Errorf("failed to finalize invoice: %w", err)
+	}
+
+	s.logger.Info("stripe invoice created",
+		zap.String("invoice_id", inv.ID),
+		zap.String("customer_id", customerID),
+		zap.Int("items", len(items)),
+	)
+
+	return inv.ID, nil
+}
+
+// InvoiceStatus returns the status of a Stripe invoice, e.g. "open" or "paid"
+func (s *StripeClient) InvoiceStatus(ctx context.Context, invoiceID string) (string, error) {
+	inv, err := s.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		return "", err
+	}
+	return string(inv.Status), nil
+}
+
+// WebhookEvent is a Stripe event delivered to the webhook endpoint
+type WebhookEvent struct {
+	ID      string
+	Type    string
+	Created time.Time
+	Object  json.RawMessage // The object the event is about, e.g. an invoice
+}
+
+// ParseWebhookEvent verifies the Stripe-Signature header of a webhook
+// payload against the endpoint secret and decodes the event. Events of other
+// API versions are accepted; their objects are decoded leniently.
+func ParseWebhookEvent(payload []byte, signature, secret string) (*WebhookEvent, error) {
+	event, err := webhook.ConstructEventWithOptions(payload, signature, secret, webhook.ConstructEventOptions{
+		IgnoreAPIVersionMismatch: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &WebhookEvent{
+		ID:      event.ID,
+		Type:    string(event.Type),
+		Created: time.Unix(event.Created, 0).UTC(),
+	}
+	if event.Data != nil {
+		parsed.Object = event.Data.Raw
+	}
+	return parsed, nil
+}
+
 // UsageLineItem represents a line item for usage billing
 type UsageLineItem struct {
 	MetricType  string  `json:"metric_type"`
@@ -0,0 +1,193 @@
+package budgets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+	"go.uber.org/zap"
+)
+
+// SpendCalculator prices the usage of a project
+type SpendCalculator interface {
+	CalculateUsageSummary(ctx context.Context, projectID uuid.UUID, start, end time.Time) (*events.UsageSummary, error)
+}
+
+// Evaluator compares month-to-date spend with budgets and fires alerts
+type Evaluator struct {
+	store      *Store
+	calculator SpendCalculator
+	notifier   Notifier
+	logger     *zap.Logger
+}
+
+// NewEvaluator creates a new budget evaluator. Without a notifier, alerts
+// are recorded but not sent.
+func NewEvaluator(store *Store, calculator SpendCalculator, notifier Notifier, logger *zap.Logger) *Evaluator {
+	return &Evaluator{
+		store:      store,
+		calculator: calculator,
+		notifier:   notifier,
+		logger:     logger,
+	}
+}
+
+// Spend returns what the projects of a budget spent from the start of the
+// billing period until now
+func (e *Evaluator) Spend(ctx context.Context, budget *Budget, now time.Time) (float64, error) {
+	projectIDs, err := e.store.ProjectIDs(ctx, budget)
+	if err != nil {
+		return 0, err
+	}
+	return e.spend(ctx, projectIDs, PeriodStart(now), now)
+}
+
+func (e *Evaluator) spend(ctx context.Context, projectIDs []uuid.UUID, start, end time.Time) (float64, error) {
+	var total float64
+	for _, projectID := range projectIDs {
+		summary, err := e.calculator.CalculateUsageSummary(ctx, projectID, start, end)
+		if err != nil {
+			return 0, err
+		}
+		total += summary.TotalCost
+	}
+	return total, nil
+}
+
+// Run evaluates every budget against its spend for the billing period of
+// now, records the thresholds crossed, and sends the alerts not sent yet
+func (e *Evaluator) Run(ctx context.Context, now time.Time) error {
+	period := PeriodStart(now)
+
+	budgets, err := e.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, budget := range budgets {
+		if err := e.evaluate(ctx, budget, period, now); err != nil {
+			e.logger.Error("failed to evaluate budget",
+				zap.String("budget_id", budget.ID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return e.deliverPending(ctx, period)
+}
+
+func (e *Evaluator) evaluate(ctx context.Context, budget *Budget, period, now time.Time) error {
+	spend, err := e.Spend(ctx, budget, now)
+	if err != nil {
+		return err
+	}
+
+	// Thresholds crossed in the same run (e.g. a budget set mid-month) are
+	// recorded, but only the highest is sent
+	var crossed []*Alert
+	for _, threshold := range CrossedThresholds(spend, budget.MonthlyLimit) {
+		alert, err := e.store.RecordAlert(ctx, budget.ID, period, AlertThreshold, threshold, spend)
+		if err != nil {
+			return err
+		}
+		if alert != nil {
+			crossed = append(crossed, alert)
+		}
+	}
+	if len(crossed) > 1 {
+		var superseded []uuid.UUID
+		for _, alert := range crossed[:len(crossed)-1] {
+			superseded = append(superseded, alert.ID)
+		}
+		if err := e.store.MarkNotified(ctx, superseded); err != nil {
+			return err
+		}
+	}
+
+	if budget.HardLimit != nil && spend >= *budget.HardLimit {
+		if _, err := e.store.RecordAlert(ctx, budget.ID, period, AlertHardCap, 100, spend); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliverPending sends the alerts of a period that were not delivered,
+// including those that failed in earlier runs
+func (e *Evaluator) deliverPending(ctx context.Context, period time.Time) error {
+	if e.notifier == nil {
+		return nil
+	}
+
+	alerts, err := e.store.PendingAlerts(ctx, period)
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		notification, err := e.notification(ctx, alert)
+		if err != nil {
+			e.logger.Error("failed to prepare budget alert", zap.String("alert_id", alert.ID.String()), zap.Error(err))
+			continue
+		}
+
+		if err := e.notifier.Notify(ctx, notification); err != nil {
+			e.logger.Warn("failed to send budget alert, retrying next run",
+				zap.String("budget_id", alert.BudgetID.String()),
+				zap.String("kind", string(alert.Kind)),
+				zap.Int("threshold", alert.Threshold),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if err := e.store.MarkNotified(ctx, []uuid.UUID{alert.ID}); err != nil {
+			return err
+		}
+
+		e.logger.Info("budget alert sent",
+			zap.String("budget_id", alert.BudgetID.String()),
+			zap.String("kind", string(alert.Kind)),
+			zap.Int("threshold", alert.Threshold),
+			zap.Float64("spend", alert.Spend),
+		)
+	}
+
+	return nil
+}
+
+func (e *Evaluator) notification(ctx context.Context, alert *Alert) (*Notification, error) {
+	budget, err := e.store.getByID(ctx, alert.BudgetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+	projectIDs, err := e.store.ProjectIDs(ctx, budget)
+	if err != nil {
+		return nil, err
+	}
+	teamID, err := e.store.TeamID(ctx, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := budget.MonthlyLimit
+	if alert.Kind == AlertHardCap && budget.HardLimit != nil {
+		limit = *budget.HardLimit
+	}
+
+	return &Notification{
+		BudgetID:    budget.ID,
+		TeamID:      teamID,
+		ProjectID:   budget.ProjectID,
+		ProjectIDs:  projectIDs,
+		Kind:        alert.Kind,
+		Threshold:   alert.Threshold,
+		PeriodStart: alert.PeriodStart,
+		Spend:       alert.Spend,
+		Limit:       limit,
+		Currency:    budget.Currency,
+	}, nil
+}
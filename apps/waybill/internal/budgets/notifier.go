@@ -0,0 +1,46 @@
+package budgets
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/switchyard"
+)
+
+// Notification tells switchyard-api that a budget crossed a threshold. It
+// sends the alert emails and webhooks, and for AlertHardCap scales the
+// non-production services of the projects to zero.
+type Notification struct {
+	BudgetID    uuid.UUID   `json:"budget_id"`
+	TeamID      *uuid.UUID  `json:"team_id,omitempty"`    // Team of the budget, or of its project
+	ProjectID   *uuid.UUID  `json:"project_id,omitempty"` // Set for project budgets
+	ProjectIDs  []uuid.UUID `json:"project_ids"`
+	Kind        AlertKind   `json:"kind"`
+	Threshold   int         `json:"threshold"`
+	PeriodStart time.Time   `json:"period_start"`
+	Spend       float64     `json:"spend"`
+	Limit       float64     `json:"limit"` // Monthly limit, or hard limit for AlertHardCap
+	Currency    string      `json:"currency"`
+}
+
+// Notifier delivers budget notifications
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// SwitchyardNotifier posts budget notifications to switchyard-api
+type SwitchyardNotifier struct {
+	client *switchyard.Client
+}
+
+// NewSwitchyardNotifier creates a notifier for the switchyard-api at
+// baseURL. apiKey is the key switchyard-api uses to call Waybill.
+func NewSwitchyardNotifier(baseURL, apiKey string) *SwitchyardNotifier {
+	return &SwitchyardNotifier{client: switchyard.NewClient(baseURL, apiKey)}
+}
+
+// Notify sends a notification to switchyard-api
+func (n *SwitchyardNotifier) Notify(ctx context.Context, notification *Notification) error {
+	return n.client.Callback(ctx, "budget", notification)
+}
@@ -0,0 +1,254 @@
+package budgets
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Store persists budgets and the alerts they fired
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates a new budget store
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const budgetColumns = `id, team_id, project_id, monthly_limit, hard_limit, currency, created_at, updated_at`
+
+func scanBudget(row interface{ Scan(...any) error }) (*Budget, error) {
+	var budget Budget
+	err := row.Scan(
+		&budget.ID,
+		&budget.TeamID,
+		&budget.ProjectID,
+		&budget.MonthlyLimit,
+		&budget.HardLimit,
+		&budget.Currency,
+		&budget.CreatedAt,
+		&budget.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// GetByTeam returns the budget of a team, or sql.ErrNoRows
+func (s *Store) GetByTeam(ctx context.Context, teamID uuid.UUID) (*Budget, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE team_id = $1`, teamID)
+	return scanBudget(row)
+}
+
+// GetByProject returns the budget of a project, or sql.ErrNoRows
+func (s *Store) GetByProject(ctx context.Context, projectID uuid.UUID) (*Budget, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE project_id = $1`, projectID)
+	return scanBudget(row)
+}
+
+// List returns all budgets
+func (s *Store) List(ctx context.Context) ([]*Budget, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+budgetColumns+` FROM budgets ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []*Budget
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets, rows.Err()
+}
+
+// SetForTeam creates or replaces the budget of a team
+func (s *Store) SetForTeam(ctx context.Context, teamID uuid.UUID, req *BudgetRequest) (*Budget, error) {
+	query := `
+		INSERT INTO budgets (team_id, monthly_limit, hard_limit, currency)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (team_id) WHERE team_id IS NOT NULL
+		DO UPDATE SET monthly_limit = EXCLUDED.monthly_limit, hard_limit = EXCLUDED.hard_limit,
+		              currency = EXCLUDED.currency, updated_at = NOW()
+		RETURNING ` + budgetColumns
+	return scanBudget(s.db.QueryRowContext(ctx, query, teamID, req.MonthlyLimit, req.HardLimit, currency(req)))
+}
+
+// SetForProject creates or replaces the budget of a project
+func (s *Store) SetForProject(ctx context.Context, projectID uuid.UUID, req *BudgetRequest) (*Budget, error) {
+	query := `
+		INSERT INTO budgets (project_id, monthly_limit, hard_limit, currency)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id) WHERE project_id IS NOT NULL
+		DO UPDATE SET monthly_limit = EXCLUDED.monthly_limit, hard_limit = EXCLUDED.hard_limit,
+		              currency = EXCLUDED.currency, updated_at = NOW()
+		RETURNING ` + budgetColumns
+	return scanBudget(s.db.QueryRowContext(ctx, query, projectID, req.MonthlyLimit, req.HardLimit, currency(req)))
+}
+
+func currency(req *BudgetRequest) string {
+	if req.Currency == "" {
+		return "USD"
+	}
+	return req.Currency
+}
+
+// Delete removes a budget and its alerts
+func (s *Store) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ProjectIDs returns the projects a budget covers: the project itself, or
+// every project of the team
+func (s *Store) ProjectIDs(ctx context.Context, budget *Budget) ([]uuid.UUID, error) {
+	if budget.ProjectID != nil {
+		return []uuid.UUID{*budget.ProjectID}, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM projects WHERE team_id = $1 ORDER BY created_at`, budget.TeamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team projects: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan project ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// TeamID returns the team that owns a budget's projects, if any
+func (s *Store) TeamID(ctx context.Context, budget *Budget) (*uuid.UUID, error) {
+	if budget.TeamID != nil {
+		return budget.TeamID, nil
+	}
+
+	var teamID *uuid.UUID
+	err := s.db.QueryRowContext(ctx, `SELECT team_id FROM projects WHERE id = $1`, budget.ProjectID).Scan(&teamID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query project team: %w", err)
+	}
+	return teamID, nil
+}
+
+// RecordAlert records a crossed threshold. It returns nil when the alert was
+// already recorded for the period.
+func (s *Store) RecordAlert(ctx context.Context, budgetID uuid.UUID, period time.Time, kind AlertKind, threshold int, spend float64) (*Alert, error) {
+	query := `
+		INSERT INTO budget_alerts (budget_id, period_start, kind, threshold, spend)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (budget_id, period_start, kind, threshold) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	alert := &Alert{
+		BudgetID:    budgetID,
+		PeriodStart: period,
+		Kind:        kind,
+		Threshold:   threshold,
+		Spend:       spend,
+	}
+	err := s.db.QueryRowContext(ctx, query, budgetID, period, kind, threshold, spend).Scan(&alert.ID, &alert.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record budget alert: %w", err)
+	}
+	return alert, nil
+}
+
+// ListAlerts returns the alerts of a budget in a period
+func (s *Store) ListAlerts(ctx context.Context, budgetID uuid.UUID, period time.Time) ([]*Alert, error) {
+	query := `
+		SELECT id, budget_id, period_start, kind, threshold, spend, notified_at, created_at
+		FROM budget_alerts
+		WHERE budget_id = $1 AND period_start = $2
+		ORDER BY created_at, threshold
+	`
+	return s.queryAlerts(ctx, query, budgetID, period)
+}
+
+// PendingAlerts returns alerts of a period switchyard-api has not accepted yet
+func (s *Store) PendingAlerts(ctx context.Context, period time.Time) ([]*Alert, error) {
+	query := `
+		SELECT id, budget_id, period_start, kind, threshold, spend, notified_at, created_at
+		FROM budget_alerts
+		WHERE period_start = $1 AND notified_at IS NULL
+		ORDER BY created_at, threshold
+	`
+	return s.queryAlerts(ctx, query, period)
+}
+
+func (s *Store) queryAlerts(ctx context.Context, query string, args ...any) ([]*Alert, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query budget alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []*Alert{}
+	for rows.Next() {
+		var alert Alert
+		err := rows.Scan(
+			&alert.ID,
+			&alert.BudgetID,
+			&alert.PeriodStart,
+			&alert.Kind,
+			&alert.Threshold,
+			&alert.Spend,
+			&alert.NotifiedAt,
+			&alert.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget alert: %w", err)
+		}
+		alerts = append(alerts, &alert)
+	}
+	return alerts, rows.Err()
+}
+
+// MarkNotified marks alerts as delivered
+func (s *Store) MarkNotified(ctx context.Context, alertIDs []uuid.UUID) error {
+	if len(alertIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(alertIDs))
+	for i, id := range alertIDs {
+		ids[i] = id.String()
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE budget_alerts SET notified_at = $1 WHERE id = ANY($2)`, time.Now(), pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to mark budget alerts notified: %w", err)
+	}
+	return nil
+}
+
+// getByID returns a budget by ID
+func (s *Store) getByID(ctx context.Context, id uuid.UUID) (*Budget, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE id = $1`, id)
+	return scanBudget(row)
+}
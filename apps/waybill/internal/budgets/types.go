@@ -0,0 +1,75 @@
+package budgets
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Thresholds are the percentages of the monthly limit that fire alerts
+var Thresholds = []int{50, 80, 100}
+
+// AlertKind tells threshold alerts from hard cap enforcement
+type AlertKind string
+
+const (
+	AlertThreshold AlertKind = "threshold"
+	AlertHardCap   AlertKind = "hard_cap"
+)
+
+// Budget is the monthly spending limit of a team or a project
+type Budget struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	TeamID       *uuid.UUID `json:"team_id,omitempty" db:"team_id"`
+	ProjectID    *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	MonthlyLimit float64    `json:"monthly_limit" db:"monthly_limit"`
+	HardLimit    *float64   `json:"hard_limit,omitempty" db:"hard_limit"` // nil = no cap
+	Currency     string     `json:"currency" db:"currency"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// BudgetRequest is the request to set a budget
+type BudgetRequest struct {
+	MonthlyLimit float64  `json:"monthly_limit" binding:"required,gt=0"`
+	HardLimit    *float64 `json:"hard_limit,omitempty"`
+	Currency     string   `json:"currency,omitempty"`
+}
+
+// Alert is a threshold a budget crossed during a billing period
+type Alert struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	BudgetID    uuid.UUID  `json:"budget_id" db:"budget_id"`
+	PeriodStart time.Time  `json:"period_start" db:"period_start"`
+	Kind        AlertKind  `json:"kind" db:"kind"`
+	Threshold   int        `json:"threshold" db:"threshold"`
+	Spend       float64    `json:"spend" db:"spend"`
+	NotifiedAt  *time.Time `json:"notified_at,omitempty" db:"notified_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// BudgetStatus is a budget with the spend of the current period
+type BudgetStatus struct {
+	*Budget
+	PeriodStart time.Time `json:"period_start"`
+	Spend       float64   `json:"spend"`
+	Percent     float64   `json:"percent"`
+	Alerts      []*Alert  `json:"alerts"`
+}
+
+// PeriodStart returns the start of the billing month t falls in
+func PeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// CrossedThresholds returns the alert thresholds spend has reached
+func CrossedThresholds(spend, monthlyLimit float64) []int {
+	var crossed []int
+	for _, threshold := range Thresholds {
+		if spend >= monthlyLimit*float64(threshold)/100 {
+			crossed = append(crossed, threshold)
+		}
+	}
+	return crossed
+}
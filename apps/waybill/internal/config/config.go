@@ -18,18 +18,41 @@ type Config struct {
 	StripeWebhookSecret  string `mapstructure:"STRIPE_WEBHOOK_SECRET"`
 	StripePublishableKey string `mapstructure:"STRIPE_PUBLISHABLE_KEY"`
 
+	// Dunning: failed payments in a row before services are suspended
+	DunningSuspendAfter int `mapstructure:"DUNNING_SUSPEND_AFTER"`
+
 	// Aggregation
 	AggregationInterval time.Duration `mapstructure:"AGGREGATION_INTERVAL"`
 	RetentionDays       int           `mapstructure:"RETENTION_DAYS"`
 
+	// Ingestion: how long an event without an idempotency key is remembered
+	// to drop retried sends of it (0 disables)
+	EventDedupWindow time.Duration `mapstructure:"EVENT_DEDUP_WINDOW"`
+
+	// Bandwidth (not collected when PROMETHEUS_URL is unset; empty queries
+	// read nginx ingress metrics)
+	PrometheusURL         string `mapstructure:"PROMETHEUS_URL"`
+	BandwidthIngressQuery string `mapstructure:"BANDWIDTH_INGRESS_QUERY"`
+	BandwidthEgressQuery  string `mapstructure:"BANDWIDTH_EGRESS_QUERY"`
+
 	// Pricing (defaults, can be overridden per plan)
 	PriceComputePerGBHour  float64 `mapstructure:"PRICE_COMPUTE_GB_HOUR"`
 	PriceBuildPerMinute    float64 `mapstructure:"PRICE_BUILD_MINUTE"`
 	PriceStoragePerGBMonth float64 `mapstructure:"PRICE_STORAGE_GB_MONTH"`
 	PriceBandwidthPerGB    float64 `mapstructure:"PRICE_BANDWIDTH_GB"`
 
+	// Free allowance every project gets each month (none by default)
+	FreeComputeGBHours  float64 `mapstructure:"FREE_COMPUTE_GB_HOURS"`
+	FreeBuildMinutes    float64 `mapstructure:"FREE_BUILD_MINUTES"`
+	FreeStorageGBMonths float64 `mapstructure:"FREE_STORAGE_GB_MONTHS"`
+	FreeBandwidthGB     float64 `mapstructure:"FREE_BANDWIDTH_GB"`
+
 	// Internal API
 	InternalAPIKey string `mapstructure:"INTERNAL_API_KEY"`
+
+	// Switchyard API, notified of budget alerts and billing account changes
+	// (only recorded when unset)
+	SwitchyardURL string `mapstructure:"SWITCHYARD_URL"`
 }
 
 func Load() (*Config, error) {
@@ -37,6 +60,8 @@ func Load() (*Config, error) {
 	viper.SetDefault("API_PORT", "8080")
 	viper.SetDefault("AGGREGATION_INTERVAL", time.Hour)
 	viper.SetDefault("RETENTION_DAYS", 90)
+	viper.SetDefault("EVENT_DEDUP_WINDOW", 10*time.Minute)
+	viper.SetDefault("DUNNING_SUSPEND_AFTER", 3)
 
 	// Default pricing (similar to Railway)
 	viper.SetDefault("PRICE_COMPUTE_GB_HOUR", 0.000463)
@@ -53,13 +78,23 @@ func Load() (*Config, error) {
 	viper.BindEnv("STRIPE_SECRET_KEY")
 	viper.BindEnv("STRIPE_WEBHOOK_SECRET")
 	viper.BindEnv("STRIPE_PUBLISHABLE_KEY")
+	viper.BindEnv("DUNNING_SUSPEND_AFTER")
 	viper.BindEnv("AGGREGATION_INTERVAL")
 	viper.BindEnv("RETENTION_DAYS")
+	viper.BindEnv("EVENT_DEDUP_WINDOW")
+	viper.BindEnv("PROMETHEUS_URL")
+	viper.BindEnv("BANDWIDTH_INGRESS_QUERY")
+	viper.BindEnv("BANDWIDTH_EGRESS_QUERY")
 	viper.BindEnv("PRICE_COMPUTE_GB_HOUR")
 	viper.BindEnv("PRICE_BUILD_MINUTE")
 	viper.BindEnv("PRICE_STORAGE_GB_MONTH")
 	viper.BindEnv("PRICE_BANDWIDTH_GB")
+	viper.BindEnv("FREE_COMPUTE_GB_HOURS")
+	viper.BindEnv("FREE_BUILD_MINUTES")
+	viper.BindEnv("FREE_STORAGE_GB_MONTHS")
+	viper.BindEnv("FREE_BANDWIDTH_GB")
 	viper.BindEnv("INTERNAL_API_KEY")
+	viper.BindEnv("SWITCHYARD_URL")
 
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
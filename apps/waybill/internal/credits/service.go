@@ -0,0 +1,117 @@
+package credits
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/billing"
+	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+	"go.uber.org/zap"
+)
+
+// ledgerEntries is how many transactions a balance lists
+const ledgerEntries = 50
+
+// SpendCalculator prices the usage of a project, after the free allowance
+type SpendCalculator interface {
+	CalculateUsageSummary(ctx context.Context, projectID uuid.UUID, start, end time.Time) (*events.UsageSummary, error)
+	Allowance() *billing.Allowance
+}
+
+// Service grants credits and reports how fast they are being spent
+type Service struct {
+	store      *Store
+	calculator SpendCalculator
+	logger     *zap.Logger
+}
+
+// NewService creates a new credit service
+func NewService(store *Store, calculator SpendCalculator, logger *zap.Logger) *Service {
+	return &Service{
+		store:      store,
+		calculator: calculator,
+		logger:     logger,
+	}
+}
+
+// Grant gives a team or project credit
+func (s *Service) Grant(ctx context.Context, req *GrantRequest) (*Credit, error) {
+	credit, err := s.store.Grant(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("credit granted",
+		zap.String("credit_id", credit.ID.String()),
+		zap.String("kind", string(credit.Kind)),
+		zap.Float64("amount", credit.Amount))
+	return credit, nil
+}
+
+// Balance returns the credits of a scope with their remaining total, the
+// month-to-date usage cost, and when that rate of spending exhausts them.
+// Credits are consumed when invoices are finalized, so this month's usage
+// is still to be drawn from the remaining total.
+func (s *Service) Balance(ctx context.Context, scope Scope, now time.Time) (*Balance, error) {
+	projectIDs, err := s.store.ProjectIDs(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	credits, err := s.store.List(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.store.Transactions(ctx, scope, ledgerEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	start := periodStart(now)
+	var monthToDate float64
+	for _, projectID := range projectIDs {
+		summary, err := s.calculator.CalculateUsageSummary(ctx, projectID, start, now)
+		if err != nil {
+			return nil, err
+		}
+		monthToDate += summary.TotalCost
+	}
+
+	balance := &Balance{
+		TeamID:        scope.TeamID,
+		ProjectID:     scope.ProjectID,
+		Remaining:     remaining(credits),
+		PeriodStart:   start,
+		MonthToDate:   monthToDate,
+		FreeAllowance: s.calculator.Allowance(),
+		Credits:       credits,
+		Transactions:  transactions,
+	}
+
+	// Spending is measured over at least a day so the first hours of a
+	// month do not project a wild rate
+	days := max(now.Sub(start).Hours()/24, 1)
+	balance.BurnRateDaily = monthToDate / days
+	if balance.Remaining > 0 && balance.BurnRateDaily > 0 {
+		left := max(balance.Remaining-monthToDate, 0)
+		depletesAt := now.Add(time.Duration(left / balance.BurnRateDaily * float64(24*time.Hour)))
+		balance.DepletesAt = &depletesAt
+	}
+	return balance, nil
+}
+
+// remaining sums the unexpired balance of credits
+func remaining(credits []*Credit) float64 {
+	var total float64
+	for _, c := range credits {
+		if !c.Expired {
+			total += c.Remaining
+		}
+	}
+	return total
+}
+
+// periodStart returns the start of the billing month t falls in
+func periodStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
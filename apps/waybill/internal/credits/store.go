@@ -0,0 +1,193 @@
+package credits
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Store persists credits and their ledger
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates a new credit store
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const creditColumns = `
+	id, team_id, project_id, kind, amount, used_amount, amount - used_amount,
+	COALESCE(description, ''), expires_at, COALESCE(expires_at <= NOW(), false), created_at
+`
+
+func scanCredit(row interface{ Scan(...any) error }) (*Credit, error) {
+	var c Credit
+	err := row.Scan(
+		&c.ID,
+		&c.TeamID,
+		&c.ProjectID,
+		&c.Kind,
+		&c.Amount,
+		&c.UsedAmount,
+		&c.Remaining,
+		&c.Description,
+		&c.ExpiresAt,
+		&c.Expired,
+		&c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Grant gives a team or project credit and records the grant in the
+// ledger. It returns sql.ErrNoRows when the team or project does not exist.
+func (s *Store) Grant(ctx context.Context, req *GrantRequest) (*Credit, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lookup := `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1)`
+	scopeID := req.ProjectID
+	if req.TeamID != nil {
+		lookup = `SELECT EXISTS (SELECT 1 FROM teams WHERE id = $1)`
+		scopeID = req.TeamID
+	}
+	var exists bool
+	if err := tx.QueryRowContext(ctx, lookup, scopeID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up credit owner: %w", err)
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	credit, err := scanCredit(tx.QueryRowContext(ctx, `
+		INSERT INTO credits (team_id, project_id, kind, amount, description, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING `+creditColumns,
+		req.TeamID, req.ProjectID, req.Kind, req.Amount, req.Description, req.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert credit: %w", err)
+	}
+
+	description := "Granted"
+	if req.Description != "" {
+		description = "Granted: " + req.Description
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO credit_transactions (credit_id, amount, description)
+		VALUES ($1, $2, $3)
+	`, credit.ID, credit.Amount, description)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record credit transaction: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return credit, nil
+}
+
+// scopeFilter matches the credits a scope spends: a team's own and those of
+// its projects, or a project's
+const scopeFilter = `
+	(c.team_id = $1
+	 OR c.project_id IN (SELECT id FROM projects WHERE team_id = $1)
+	 OR c.project_id = $2)
+`
+
+// List returns the credits of a scope, newest first
+func (s *Store) List(ctx context.Context, scope Scope) ([]*Credit, error) {
+	query := `SELECT ` + creditColumns + ` FROM credits c WHERE ` + scopeFilter + ` ORDER BY c.created_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, scope.TeamID, scope.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query credits: %w", err)
+	}
+	defer rows.Close()
+
+	credits := []*Credit{}
+	for rows.Next() {
+		credit, err := scanCredit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan credit: %w", err)
+		}
+		credits = append(credits, credit)
+	}
+	return credits, rows.Err()
+}
+
+// Transactions returns the latest ledger entries of the credits of a scope,
+// newest first
+func (s *Store) Transactions(ctx context.Context, scope Scope, limit int) ([]*Transaction, error) {
+	query := `
+		SELECT t.id, t.credit_id, t.invoice_id, t.amount, COALESCE(t.description, ''), t.created_at
+		FROM credit_transactions t
+		JOIN credits c ON c.id = t.credit_id
+		WHERE ` + scopeFilter + `
+		ORDER BY t.created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, scope.TeamID, scope.ProjectID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query credit transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := []*Transaction{}
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.CreditID, &t.InvoiceID, &t.Amount, &t.Description, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan credit transaction: %w", err)
+		}
+		transactions = append(transactions, &t)
+	}
+	return transactions, rows.Err()
+}
+
+// ProjectIDs returns the projects whose usage a scope's credits pay for. It
+// returns sql.ErrNoRows for unknown teams and projects, and ErrBilledToTeam
+// for projects in a team.
+func (s *Store) ProjectIDs(ctx context.Context, scope Scope) ([]uuid.UUID, error) {
+	if scope.ProjectID != nil {
+		var teamID *uuid.UUID
+		if err := s.db.QueryRowContext(ctx, `SELECT team_id FROM projects WHERE id = $1`, scope.ProjectID).Scan(&teamID); err != nil {
+			return nil, err
+		}
+		if teamID != nil {
+			return nil, ErrBilledToTeam
+		}
+		return []uuid.UUID{*scope.ProjectID}, nil
+	}
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM teams WHERE id = $1)`, scope.TeamID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM projects WHERE team_id = $1 ORDER BY created_at`, scope.TeamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team projects: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan project ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,80 @@
+package credits
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/billing"
+)
+
+// Kind tells granted credits from purchased ones
+type Kind string
+
+const (
+	KindPromotional Kind = "promotional"
+	KindPrepaid     Kind = "prepaid"
+)
+
+// ErrBilledToTeam is returned for the credits of a project that belongs to a
+// team; its usage is paid from the team's credits
+var ErrBilledToTeam = errors.New("project is billed to its team; see the team's credits")
+
+// Scope is whose credits to read: a team (with those of its projects) or a
+// project without a team
+type Scope struct {
+	TeamID    *uuid.UUID
+	ProjectID *uuid.UUID
+}
+
+// Credit is an amount a team or project can spend before being charged.
+// Finalized invoices draw from it.
+type Credit struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	TeamID      *uuid.UUID `json:"team_id,omitempty" db:"team_id"`
+	ProjectID   *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	Kind        Kind       `json:"kind" db:"kind"`
+	Amount      float64    `json:"amount" db:"amount"`
+	UsedAmount  float64    `json:"used_amount" db:"used_amount"`
+	Remaining   float64    `json:"remaining"`
+	Description string     `json:"description,omitempty" db:"description"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	Expired     bool       `json:"expired"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Transaction is an entry of the credit ledger: a grant (positive) or the
+// part of a credit an invoice consumed (negative)
+type Transaction struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	CreditID    uuid.UUID  `json:"credit_id" db:"credit_id"`
+	InvoiceID   *uuid.UUID `json:"invoice_id,omitempty" db:"invoice_id"`
+	Amount      float64    `json:"amount" db:"amount"`
+	Description string     `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// GrantRequest is the request to give a team or project credit
+type GrantRequest struct {
+	TeamID      *uuid.UUID `json:"team_id,omitempty"`
+	ProjectID   *uuid.UUID `json:"project_id,omitempty"`
+	Kind        Kind       `json:"kind" binding:"required,oneof=promotional prepaid"`
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	Description string     `json:"description,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// Balance is what is left of the credits of a team or project and how fast
+// month-to-date usage is spending it
+type Balance struct {
+	TeamID        *uuid.UUID         `json:"team_id,omitempty"`
+	ProjectID     *uuid.UUID         `json:"project_id,omitempty"`
+	Remaining     float64            `json:"remaining"` // Unexpired credit not consumed by finalized invoices
+	PeriodStart   time.Time          `json:"period_start"`
+	MonthToDate   float64            `json:"month_to_date"`   // Usage cost after the free allowance
+	BurnRateDaily float64            `json:"burn_rate_daily"` // Month-to-date cost per day
+	DepletesAt    *time.Time         `json:"depletes_at,omitempty"`
+	FreeAllowance *billing.Allowance `json:"free_allowance,omitempty"`
+	Credits       []*Credit          `json:"credits"`
+	Transactions  []*Transaction     `json:"transactions"`
+}
@@ -2,31 +2,115 @@ package events
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// DefaultDedupWindow is how long an event without an idempotency key is
+// remembered, so a retried send of it is not recorded twice
+const DefaultDedupWindow = 10 * time.Minute
+
 // Collector handles event ingestion
 type Collector struct {
-	db     *sql.DB
-	logger *zap.Logger
+	db          *sql.DB
+	dedupWindow time.Duration
+	logger      *zap.Logger
 }
 
-// NewCollector creates a new event collector
-func NewCollector(db *sql.DB, logger *zap.Logger) *Collector {
+// NewCollector creates a new event collector. Events without an idempotency
+// key that repeat one recorded within dedupWindow are dropped; a zero window
+// disables this.
+func NewCollector(db *sql.DB, dedupWindow time.Duration, logger *zap.Logger) *Collector {
 	return &Collector{
-		db:     db,
-		logger: logger,
+		db:          db,
+		dedupWindow: dedupWindow,
+		logger:      logger,
+	}
+}
+
+// Record stores a usage event. When the event is a duplicate, of an earlier
+// event with the same idempotency key or of an identical event within the
+// dedup window, nothing is stored and the earlier event's ID is returned with
+// duplicate set.
+func (c *Collector) Record(ctx context.Context, req *EventRequest) (event *UsageEvent, duplicate bool, err error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
+
+	event, duplicate, err = c.insert(ctx, tx, req, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if duplicate {
+		c.logger.Info("duplicate event dropped",
+			zap.String("event_id", event.ID.String()),
+			zap.String("event_type", string(req.EventType)),
+			zap.String("project_id", req.ProjectID.String()),
+		)
+		return event, true, nil
+	}
+
+	c.logger.Info("event recorded",
+		zap.String("event_id", event.ID.String()),
+		zap.String("event_type", string(event.EventType)),
+		zap.String("project_id", event.ProjectID.String()),
+		zap.String("resource_type", event.ResourceType),
+	)
+
+	return event, false, nil
+}
+
+// RecordBatch stores multiple events in a transaction, skipping duplicates,
+// and returns how many were skipped
+func (c *Collector) RecordBatch(ctx context.Context, events []*EventRequest) (int, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	duplicates := 0
+	for _, req := range events {
+		_, duplicate, err := c.insert(ctx, tx, req, now)
+		if err != nil {
+			return 0, err
+		}
+		if duplicate {
+			duplicates++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	c.logger.Info("batch events recorded",
+		zap.Int("count", len(events)-duplicates),
+		zap.Int("duplicates", duplicates),
+	)
+	return duplicates, nil
 }
 
-// Record stores a usage event
-func (c *Collector) Record(ctx context.Context, req *EventRequest) (*UsageEvent, error) {
+// insert stores an event unless it is a duplicate, in which case the
+// earlier event is returned with only its ID set
+func (c *Collector) insert(ctx context.Context, tx *sql.Tx, req *EventRequest, now time.Time) (*UsageEvent, bool, error) {
 	event := &UsageEvent{
 		ID:           uuid.New(),
 		ProjectID:    req.ProjectID,
@@ -37,33 +121,60 @@ func (c *Collector) Record(ctx context.Context, req *EventRequest) (*UsageEvent,
 		ResourceName: req.ResourceName,
 		Metrics:      req.Metrics,
 		Metadata:     req.Metadata,
-		CreatedAt:    time.Now(),
+		Timestamp:    now,
+		CreatedAt:    now,
 	}
-
 	if req.Timestamp != nil {
 		event.Timestamp = *req.Timestamp
-	} else {
-		event.Timestamp = time.Now()
+	}
+
+	fingerprint := fingerprintOf(req)
+	if req.IdempotencyKey == "" && c.dedupWindow > 0 {
+		// Concurrent sends of the same event wait for each other here, so
+		// the later one sees the earlier
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, fingerprint); err != nil {
+			return nil, false, fmt.Errorf("failed to lock event: %w", err)
+		}
+		var existing uuid.UUID
+		err := tx.QueryRowContext(ctx, `
+			SELECT id FROM usage_events
+			WHERE fingerprint = $1 AND created_at > $2
+			ORDER BY created_at DESC
+			LIMIT 1
+		`, fingerprint, now.Add(-c.dedupWindow)).Scan(&existing)
+		if err == nil {
+			return &UsageEvent{ID: existing}, true, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, false, fmt.Errorf("failed to check for duplicate event: %w", err)
+		}
 	}
 
 	metricsJSON, err := json.Marshal(event.Metrics)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal metrics: %w", err)
+		return nil, false, fmt.Errorf("failed to marshal metrics: %w", err)
 	}
 
 	metadataJSON, err := json.Marshal(event.Metadata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		return nil, false, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	var idempotencyKey *string
+	if req.IdempotencyKey != "" {
+		idempotencyKey = &req.IdempotencyKey
 	}
 
 	query := `
 		INSERT INTO usage_events (
 			id, project_id, team_id, event_type, resource_type,
-			resource_id, resource_name, metrics, metadata, timestamp, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			resource_id, resource_name, metrics, metadata, timestamp, created_at,
+			idempotency_key, fingerprint
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
 	`
 
-	_, err = c.db.ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query,
 		event.ID,
 		event.ProjectID,
 		event.TeamID,
@@ -75,75 +186,43 @@ func (c *Collector) Record(ctx context.Context, req *EventRequest) (*UsageEvent,
 		metadataJSON,
 		event.Timestamp,
 		event.CreatedAt,
+		idempotencyKey,
+		fingerprint,
 	)
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert event: %w", err)
+		return nil, false, fmt.Errorf("failed to insert event: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return nil, false, fmt.Errorf("failed to insert event: %w", err)
+	} else if inserted == 0 {
+		var existing uuid.UUID
+		if err := tx.QueryRowContext(ctx, `SELECT id FROM usage_events WHERE idempotency_key = $1`, req.IdempotencyKey).Scan(&existing); err != nil {
+			return nil, false, fmt.Errorf("failed to get duplicate event: %w", err)
+		}
+		return &UsageEvent{ID: existing}, true, nil
 	}
 
-	c.logger.Info("event recorded",
-		zap.String("event_id", event.ID.String()),
-		zap.String("event_type", string(event.EventType)),
-		zap.String("project_id", event.ProjectID.String()),
-		zap.String("resource_type", event.ResourceType),
-	)
-
-	return event, nil
+	return event, false, nil
 }
 
-// RecordBatch stores multiple events in a transaction
-func (c *Collector) RecordBatch(ctx context.Context, events []*EventRequest) error {
-	tx, err := c.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO usage_events (
-			id, project_id, team_id, event_type, resource_type,
-			resource_id, resource_name, metrics, metadata, timestamp, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
+// fingerprintOf identifies the content of an event: its type, project,
+// resource, metrics and, when the sender set it, timestamp
+func fingerprintOf(req *EventRequest) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s", req.EventType, req.ProjectID, req.ResourceType, req.ResourceID)
+	if req.Timestamp != nil {
+		fmt.Fprintf(h, "|%s", req.Timestamp.UTC().Format(time.RFC3339Nano))
 	}
-	defer stmt.Close()
-
-	now := time.Now()
-	for _, req := range events {
-		metricsJSON, _ := json.Marshal(req.Metrics)
-		metadataJSON, _ := json.Marshal(req.Metadata)
-
-		timestamp := now
-		if req.Timestamp != nil {
-			timestamp = *req.Timestamp
-		}
 
-		_, err = stmt.ExecContext(ctx,
-			uuid.New(),
-			req.ProjectID,
-			req.TeamID,
-			req.EventType,
-			req.ResourceType,
-			req.ResourceID,
-			req.ResourceName,
-			metricsJSON,
-			metadataJSON,
-			timestamp,
-			now,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert event: %w", err)
-		}
+	names := make([]string, 0, len(req.Metrics))
+	for name := range req.Metrics {
+		names = append(names, name)
 	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "|%s=%s", name, strconv.FormatFloat(req.Metrics[name], 'g', -1, 64))
 	}
-
-	c.logger.Info("batch events recorded", zap.Int("count", len(events)))
-	return nil
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 // GetUnprocessedEvents retrieves events that haven't been aggregated
@@ -202,12 +281,7 @@ func (c *Collector) MarkProcessed(ctx context.Context, eventIDs []uuid.UUID) err
 
 	query := `UPDATE usage_events SET processed_at = $1 WHERE id = ANY($2)`
 
-	ids := make([]string, len(eventIDs))
-	for i, id := range eventIDs {
-		ids[i] = id.String()
-	}
-
-	_, err := c.db.ExecContext(ctx, query, time.Now(), ids)
+	_, err := c.db.ExecContext(ctx, query, time.Now(), pq.Array(eventIDs))
 	if err != nil {
 		return fmt.Errorf("failed to mark events processed: %w", err)
 	}
@@ -24,6 +24,10 @@ const (
 	EventVolumeCreated EventType = "volume.created"
 	EventVolumeDeleted EventType = "volume.deleted"
 	EventVolumeResized EventType = "volume.resized"
+	EventStorageUsage  EventType = "storage.usage" // Periodic sample of used storage (e.g. buckets)
+
+	// Scale-to-zero events
+	EventIdleSavings EventType = "compute.idle_savings" // Compute a service scaled to zero did not use over a period
 
 	// Network events
 	EventBandwidthUsage EventType = "bandwidth.usage"
@@ -37,11 +41,12 @@ const (
 type MetricType string
 
 const (
-	MetricComputeGBHours MetricType = "compute_gb_hours"
-	MetricBuildMinutes   MetricType = "build_minutes"
-	MetricStorageGBHours MetricType = "storage_gb_hours"
-	MetricBandwidthGB    MetricType = "bandwidth_gb"
-	MetricCustomDomains  MetricType = "custom_domains"
+	MetricComputeGBHours   MetricType = "compute_gb_hours"
+	MetricBuildMinutes     MetricType = "build_minutes"
+	MetricStorageGBHours   MetricType = "storage_gb_hours"
+	MetricBandwidthGB      MetricType = "bandwidth_gb"
+	MetricCustomDomains    MetricType = "custom_domains"
+	MetricIdleSavedGBHours MetricType = "idle_saved_gb_hours" // Not billed; reported as savings
 )
 
 // UsageEvent represents a single usage event
@@ -71,6 +76,10 @@ type EventRequest struct {
 	Metrics      map[string]float64 `json:"metrics" binding:"required"`
 	Metadata     map[string]string  `json:"metadata,omitempty"`
 	Timestamp    *time.Time         `json:"timestamp,omitempty"`
+
+	// IdempotencyKey identifies the event to the sender; an event with a key
+	// that was already recorded is dropped
+	IdempotencyKey string `json:"idempotency_key,omitempty" binding:"max=255"`
 }
 
 // HourlyUsage represents aggregated hourly usage
@@ -100,6 +109,7 @@ type UsageSummary struct {
 	PeriodEnd        time.Time              `json:"period_end"`
 	Metrics          map[MetricType]float64 `json:"metrics"`
 	Costs            map[MetricType]float64 `json:"costs"`
+	Free             map[MetricType]float64 `json:"free,omitempty"` // Usage covered by the free allowance
 	TotalCost        float64                `json:"total_cost"`
 	EstimatedMonthly float64                `json:"estimated_monthly"`
 }
@@ -0,0 +1,286 @@
+package invoices
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+)
+
+// billableMetrics are the metrics invoices charge for, in invoice order
+var billableMetrics = []events.MetricType{
+	events.MetricComputeGBHours,
+	events.MetricBuildMinutes,
+	events.MetricStorageGBHours,
+	events.MetricBandwidthGB,
+}
+
+// metricLabels names billable metrics on invoices
+var metricLabels = map[events.MetricType]struct{ name, unit string }{
+	events.MetricComputeGBHours: {"Compute", "GB-hours"},
+	events.MetricBuildMinutes:   {"Builds", "minutes"},
+	events.MetricStorageGBHours: {"Storage", "GB-hours"},
+	events.MetricBandwidthGB:    {"Bandwidth", "GB"},
+}
+
+// Generate creates or refreshes the draft invoice of a team or project for
+// the month period falls in. Finalized invoices are not changed
+// (ErrNotDraft).
+func (s *Service) Generate(ctx context.Context, scope Scope, period time.Time) (*Invoice, error) {
+	period = period.UTC()
+	start := time.Date(period.Year(), period.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	projects, err := s.store.projects(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &Invoice{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Currency:    "USD",
+	}
+	for _, p := range projects {
+		items, err := s.projectLineItems(ctx, p, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %w", p.ID, err)
+		}
+		inv.LineItems = append(inv.LineItems, items...)
+	}
+
+	var total float64
+	for _, item := range inv.LineItems {
+		total += item.Amount
+	}
+
+	// Credits are reserved on the draft and consumed when it is finalized
+	if total > 0 {
+		credits, err := s.store.availableCredits(ctx, scope, start)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range credits {
+			item := creditLineItem(c, roundCents(total), start, end)
+			if item == nil {
+				break
+			}
+			inv.LineItems = append(inv.LineItems, item)
+			total += item.Amount
+		}
+	}
+	inv.Total = roundCents(total)
+
+	id, err := s.store.SaveDraft(ctx, scope, inv)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Get(ctx, id)
+}
+
+// GenerateMonth refreshes the draft invoices of every team and team-less
+// project that had usage or a subscription in the month period falls in. It
+// returns how many drafts it saved.
+func (s *Service) GenerateMonth(ctx context.Context, period time.Time) (int, error) {
+	period = period.UTC()
+	start := time.Date(period.Year(), period.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	scopes, err := s.store.ScopesWithActivity(ctx, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for _, scope := range scopes {
+		_, err := s.Generate(ctx, scope, start)
+		if err == ErrNotDraft {
+			continue
+		}
+		if err != nil {
+			s.logger.Error("failed to generate invoice", scopeFields(scope, err)...)
+			continue
+		}
+		generated++
+	}
+	return generated, nil
+}
+
+// projectLineItems returns the plan and usage charges of a project: each plan
+// it was on, prorated, then each metric each of its services used
+func (s *Service) projectLineItems(ctx context.Context, p project, start, end time.Time) ([]*LineItem, error) {
+	var items []*LineItem
+
+	plans, err := s.store.planPeriods(ctx, p.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, plan := range plans {
+		share := prorate(plan.From, plan.To, start, end)
+		description := fmt.Sprintf("%s plan", plan.PlanName)
+		if share < 1 {
+			description = fmt.Sprintf("%s plan, %s to %s (prorated)", plan.PlanName,
+				plan.From.UTC().Format("Jan 2"), plan.To.UTC().Add(-time.Second).Format("Jan 2"))
+		}
+		items = append(items, &LineItem{
+			ProjectID:   &p.ID,
+			Kind:        LinePlan,
+			Description: description,
+			Quantity:    math.Round(share*10000) / 10000,
+			UnitPrice:   plan.PriceMonthly,
+			Amount:      roundCents(plan.PriceMonthly * share),
+			PeriodStart: plan.From,
+			PeriodEnd:   plan.To,
+		})
+	}
+
+	totals, err := s.store.projectUsage(ctx, p.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := s.store.resourceUsage(ctx, p.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	attributed := make(map[string]float64)
+	for _, r := range resources {
+		attributed[r.MetricType] += r.Value
+
+		name := r.ResourceName
+		if name == "" {
+			name = fmt.Sprintf("%s %s", r.ResourceType, r.ResourceID.String()[:8])
+		}
+		resourceID := r.ResourceID
+		if item := s.usageLineItem(p.ID, &resourceID, name, r.MetricType, r.Value, start, end); item != nil {
+			items = append(items, item)
+		}
+	}
+
+	// Hours aggregated before usage was tracked per resource only have
+	// project totals
+	for _, metricType := range billableMetrics {
+		remainder := totals[string(metricType)] - attributed[string(metricType)]
+		if item := s.usageLineItem(p.ID, nil, "", string(metricType), remainder, start, end); item != nil {
+			items = append(items, item)
+		}
+	}
+
+	for _, metricType := range billableMetrics {
+		if item := s.allowanceLineItem(p.ID, metricType, totals[string(metricType)], start, end); item != nil {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// usageLineItem prices the usage of a metric, or returns nil when it costs
+// nothing
+func (s *Service) usageLineItem(projectID uuid.UUID, resourceID *uuid.UUID, resourceName, metricType string, quantity float64, start, end time.Time) *LineItem {
+	unitPrice := s.pricing.UnitPrice(events.MetricType(metricType))
+	amount := roundCents(quantity * unitPrice)
+	if amount <= 0 {
+		return nil
+	}
+
+	label, ok := metricLabels[events.MetricType(metricType)]
+	if !ok {
+		label.name, label.unit = metricType, "units"
+	}
+	description := fmt.Sprintf("%s (%.2f %s)", label.name, quantity, label.unit)
+	if resourceName != "" {
+		description = fmt.Sprintf("%s: %s", resourceName, description)
+	} else if resourceID == nil {
+		description = fmt.Sprintf("Other usage: %s", description)
+	}
+
+	return &LineItem{
+		ProjectID:    &projectID,
+		ResourceID:   resourceID,
+		ResourceName: resourceName,
+		Kind:         LineUsage,
+		MetricType:   metricType,
+		Description:  description,
+		Quantity:     quantity,
+		UnitPrice:    unitPrice,
+		Amount:       amount,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+	}
+}
+
+// allowanceLineItem deducts the usage of a metric the free allowance
+// covers, or returns nil when it covers none
+func (s *Service) allowanceLineItem(projectID uuid.UUID, metricType events.MetricType, used float64, start, end time.Time) *LineItem {
+	free := s.allowance.Covered(metricType, used)
+	unitPrice := s.pricing.UnitPrice(metricType)
+	amount := roundCents(free * unitPrice)
+	if amount <= 0 {
+		return nil
+	}
+
+	label := metricLabels[metricType]
+	return &LineItem{
+		ProjectID:   &projectID,
+		Kind:        LineAllowance,
+		MetricType:  string(metricType),
+		Description: fmt.Sprintf("Free allowance: %s (%.2f %s)", label.name, free, label.unit),
+		Quantity:    free,
+		UnitPrice:   -unitPrice,
+		Amount:      -amount,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}
+}
+
+// creditLineItem applies as much of a credit as the amount still due
+// allows, or returns nil when nothing is due
+func creditLineItem(c credit, due float64, start, end time.Time) *LineItem {
+	amount := roundCents(min(c.Balance, due))
+	if amount <= 0 {
+		return nil
+	}
+
+	description := "Promotional credit"
+	if c.Kind == "prepaid" {
+		description = "Prepaid credit"
+	}
+	if c.Description != "" {
+		description = fmt.Sprintf("%s: %s", description, c.Description)
+	}
+
+	creditID := c.ID
+	return &LineItem{
+		CreditID:    &creditID,
+		Kind:        LineCredit,
+		Description: description,
+		Quantity:    1,
+		UnitPrice:   -amount,
+		Amount:      -amount,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}
+}
+
+// prorate returns the share of the period [start, end) that [from, to)
+// covers
+func prorate(from, to, start, end time.Time) float64 {
+	if from.Before(start) {
+		from = start
+	}
+	if to.After(end) {
+		to = end
+	}
+	if !to.After(from) {
+		return 0
+	}
+	return to.Sub(from).Seconds() / end.Sub(start).Seconds()
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
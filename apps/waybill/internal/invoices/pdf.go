@@ -0,0 +1,295 @@
+package invoices
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Page layout, in points (US Letter)
+const (
+	pageWidth    = 612.0
+	pageHeight   = 792.0
+	marginX      = 50.0
+	marginTop    = 742.0
+	marginBottom = 80.0
+	rowHeight    = 16.0
+
+	colQuantity  = 390.0 // Right edges of the numeric columns
+	colUnitPrice = 475.0
+	colAmount    = pageWidth - marginX
+	descWidth    = 270.0
+)
+
+// RenderPDF renders an invoice as a PDF document
+func RenderPDF(inv *Invoice) ([]byte, error) {
+	doc := newPDFDoc()
+
+	doc.text(fontBold, 20, marginX, doc.y, "Enclii")
+	doc.textRight(fontBold, 16, colAmount, doc.y, "INVOICE")
+	doc.y -= 20
+	number := "DRAFT"
+	if inv.Number != nil {
+		number = *inv.Number
+	}
+	doc.textRight(fontRegular, 10, colAmount, doc.y, number)
+	doc.y -= 30
+
+	details := [][2]string{
+		{"Bill to", inv.AccountName},
+		{"Period", fmt.Sprintf("%s (%s - %s)", inv.PeriodStart.UTC().Format("January 2006"),
+			inv.PeriodStart.UTC().Format("Jan 2, 2006"), inv.PeriodEnd.UTC().AddDate(0, 0, -1).Format("Jan 2, 2006"))},
+		{"Status", strings.ToUpper(string(inv.Status))},
+	}
+	if inv.FinalizedAt != nil {
+		details = append(details, [2]string{"Issued", inv.FinalizedAt.UTC().Format("Jan 2, 2006")})
+	}
+	if inv.PaidAt != nil {
+		details = append(details, [2]string{"Paid", inv.PaidAt.UTC().Format("Jan 2, 2006")})
+	}
+	for _, detail := range details {
+		doc.text(fontBold, 10, marginX, doc.y, detail[0])
+		doc.text(fontRegular, 10, marginX+70, doc.y, detail[1])
+		doc.y -= 14
+	}
+	doc.y -= 16
+
+	doc.tableHeader()
+
+	// Team invoices group line items under their projects, then credits
+	grouped := inv.TeamID != nil
+	var lastGroup string
+	for _, item := range inv.LineItems {
+		group := item.ProjectName
+		if item.Kind == LineCredit {
+			group = "Credits"
+		}
+		if grouped && group != lastGroup {
+			doc.ensureSpace(2 * rowHeight)
+			doc.y -= 4
+			doc.text(fontBold, 10, marginX, doc.y, truncateText(fontBold, 10, group, colAmount-marginX))
+			doc.y -= rowHeight
+			lastGroup = group
+		}
+
+		doc.ensureSpace(rowHeight)
+		indent := 0.0
+		if grouped {
+			indent = 10
+		}
+		doc.text(fontRegular, 9, marginX+indent, doc.y, truncateText(fontRegular, 9, item.Description, descWidth-indent))
+		doc.textRight(fontRegular, 9, colQuantity, doc.y, formatDecimal(item.Quantity, 4))
+		doc.textRight(fontRegular, 9, colUnitPrice, doc.y, formatMoney(inv.Currency, item.UnitPrice, 6))
+		doc.textRight(fontRegular, 9, colAmount, doc.y, formatMoney(inv.Currency, item.Amount, 2))
+		doc.y -= rowHeight
+	}
+
+	if len(inv.LineItems) == 0 {
+		doc.text(fontRegular, 9, marginX, doc.y, "No charges for this period.")
+		doc.y -= rowHeight
+	}
+
+	doc.ensureSpace(2 * rowHeight)
+	doc.line(colQuantity, doc.y+rowHeight-4, colAmount, doc.y+rowHeight-4)
+	doc.y -= 4
+	doc.textRight(fontBold, 11, colUnitPrice, doc.y, "Total")
+	doc.textRight(fontBold, 11, colAmount, doc.y, formatMoney(inv.Currency, inv.Total, 2))
+	doc.y -= 2 * rowHeight
+
+	doc.ensureSpace(rowHeight)
+	doc.text(fontRegular, 8, marginX, doc.y, fmt.Sprintf("Amounts in %s. Usage is metered hourly; plans changed mid-month are prorated.", inv.Currency))
+
+	return doc.bytes(), nil
+}
+
+// pdfFont names the standard Type 1 fonts in the page resources
+type pdfFont string
+
+const (
+	fontRegular pdfFont = "F1" // Helvetica
+	fontBold    pdfFont = "F2" // Helvetica-Bold
+)
+
+// pdfDoc lays out text top to bottom across pages
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func newPDFDoc() *pdfDoc {
+	doc := &pdfDoc{}
+	doc.newPage()
+	return doc
+}
+
+func (d *pdfDoc) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *pdfDoc) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = marginTop
+}
+
+// ensureSpace starts a new page, repeating the table header, when height
+// does not fit above the bottom margin
+func (d *pdfDoc) ensureSpace(height float64) {
+	if d.y-height >= marginBottom {
+		return
+	}
+	d.newPage()
+	d.tableHeader()
+}
+
+func (d *pdfDoc) tableHeader() {
+	d.text(fontBold, 9, marginX, d.y, "Description")
+	d.textRight(fontBold, 9, colQuantity, d.y, "Quantity")
+	d.textRight(fontBold, 9, colUnitPrice, d.y, "Unit price")
+	d.textRight(fontBold, 9, colAmount, d.y, "Amount")
+	d.line(marginX, d.y-5, colAmount, d.y-5)
+	d.y -= rowHeight + 4
+}
+
+func (d *pdfDoc) text(font pdfFont, size, x, y float64, s string) {
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapePDFText(s))
+}
+
+func (d *pdfDoc) textRight(font pdfFont, size, right, y float64, s string) {
+	d.text(font, size, right-textWidth(font, size, s), y, s)
+}
+
+func (d *pdfDoc) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// bytes assembles the document: catalog, page tree, fonts, then a page and
+// content stream per page, followed by the cross-reference table
+func (d *pdfDoc) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	const firstPage = 5
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// escapePDFText encodes a string as the contents of a PDF literal string.
+// Characters outside Latin-1 are replaced, as the fonts use WinAnsiEncoding.
+func escapePDFText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// helveticaWidths are the Helvetica glyph widths of ASCII 32-126, in
+// thousandths of the font size. Helvetica-Bold is measured with them too;
+// its digits, which right-aligned amounts consist of, are the same width.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth approximates the width of a string in points
+func textWidth(font pdfFont, size float64, s string) float64 {
+	var units int
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			units += helveticaWidths[r-32]
+		} else {
+			units += 556
+		}
+	}
+	width := float64(units) * size / 1000
+	if font == fontBold {
+		width *= 1.05
+	}
+	return width
+}
+
+// truncateText shortens a string with an ellipsis to fit a width
+func truncateText(font pdfFont, size float64, s string, width float64) string {
+	if textWidth(font, size, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(font, size, string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
+
+// formatDecimal formats a number with at most decimals decimal places
+func formatDecimal(value float64, decimals int) string {
+	s := strconv.FormatFloat(value, 'f', decimals, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// formatMoney formats an amount in a currency, with at least two and at most
+// decimals decimal places so tiny unit prices stay visible
+func formatMoney(currency string, amount float64, decimals int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s := strconv.FormatFloat(amount, 'f', decimals, 64)
+	if decimals > 2 {
+		s = strings.TrimRight(s, "0")
+		if i := strings.Index(s, "."); i >= 0 && len(s)-i-1 < 2 {
+			s += strings.Repeat("0", 2-(len(s)-i-1))
+		}
+	}
+	if currency == "USD" {
+		return sign + "$" + s
+	}
+	return sign + s + " " + currency
+}
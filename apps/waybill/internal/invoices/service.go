@@ -0,0 +1,186 @@
+package invoices
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/billing"
+	"go.uber.org/zap"
+)
+
+// StripeSyncer pushes finalized invoices to Stripe and reads back whether
+// they were paid
+type StripeSyncer interface {
+	CreateInvoice(ctx context.Context, customerID, currency string, items []*billing.UsageLineItem, metadata map[string]string) (string, error)
+	InvoiceStatus(ctx context.Context, invoiceID string) (string, error)
+}
+
+// Service generates invoices and moves them through their lifecycle
+type Service struct {
+	store     *Store
+	pricing   *billing.Pricing
+	allowance *billing.Allowance
+	stripe    StripeSyncer
+	logger    *zap.Logger
+}
+
+// NewService creates a new invoice service. Usage within the free
+// allowance, which may be nil, is deducted on invoices. Without a Stripe
+// syncer, invoices are only tracked locally and marked paid by hand.
+func NewService(store *Store, pricing *billing.Pricing, allowance *billing.Allowance, stripe StripeSyncer, logger *zap.Logger) *Service {
+	return &Service{
+		store:     store,
+		pricing:   pricing,
+		allowance: allowance,
+		stripe:    stripe,
+		logger:    logger,
+	}
+}
+
+// Store returns the store invoices are read from
+func (s *Service) Store() *Store {
+	return s.store
+}
+
+// Finalize numbers and freezes a draft invoice, then pushes it to Stripe.
+// A failed push is logged and retried by Sync.
+func (s *Service) Finalize(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	if err := s.store.Finalize(ctx, id); err != nil {
+		return nil, err
+	}
+
+	inv, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pushToStripe(ctx, inv); err != nil {
+		s.logger.Warn("failed to sync invoice to stripe",
+			zap.String("invoice_id", id.String()),
+			zap.Error(err))
+	}
+	return inv, nil
+}
+
+// MarkPaid records the payment of a finalized invoice
+func (s *Service) MarkPaid(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	if err := s.store.MarkPaid(ctx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.store.Get(ctx, id)
+}
+
+// Sync pushes a finalized invoice to Stripe if it was not yet, or marks it
+// paid if Stripe says it was
+func (s *Service) Sync(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	inv, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != StatusFinalized {
+		return nil, ErrNotFinalized
+	}
+	if s.stripe == nil {
+		return inv, nil
+	}
+
+	if inv.StripeInvoiceID == nil {
+		if err := s.pushToStripe(ctx, inv); err != nil {
+			return nil, err
+		}
+		return s.store.Get(ctx, id)
+	}
+
+	status, err := s.stripe.InvoiceStatus(ctx, *inv.StripeInvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if status == "paid" {
+		return s.MarkPaid(ctx, id)
+	}
+	return inv, nil
+}
+
+// SyncFinalized syncs every finalized invoice with Stripe
+func (s *Service) SyncFinalized(ctx context.Context) error {
+	if s.stripe == nil {
+		return nil
+	}
+
+	invoices, err := s.store.ListFinalized(ctx)
+	if err != nil {
+		return err
+	}
+	for _, inv := range invoices {
+		if _, err := s.Sync(ctx, inv.ID); err != nil {
+			s.logger.Error("failed to sync invoice with stripe",
+				zap.String("invoice_id", inv.ID.String()),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// pushToStripe creates the Stripe invoice of a finalized invoice. Invoices
+// of accounts without a Stripe customer stay local.
+func (s *Service) pushToStripe(ctx context.Context, inv *Invoice) error {
+	if s.stripe == nil || inv.StripeInvoiceID != nil {
+		return nil
+	}
+
+	customerID, err := s.store.StripeCustomerID(ctx, inv)
+	if err != nil {
+		return err
+	}
+	if customerID == "" {
+		s.logger.Debug("no stripe customer for invoice", zap.String("invoice_id", inv.ID.String()))
+		return nil
+	}
+
+	items := make([]*billing.UsageLineItem, 0, len(inv.LineItems))
+	for _, item := range inv.LineItems {
+		description := item.Description
+		if inv.TeamID != nil && item.ProjectName != "" {
+			description = fmt.Sprintf("%s: %s", item.ProjectName, description)
+		}
+		items = append(items, &billing.UsageLineItem{
+			MetricType:  item.MetricType,
+			Description: description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			AmountCents: int(math.Round(item.Amount * 100)),
+		})
+	}
+
+	metadata := map[string]string{
+		"invoice_id":   inv.ID.String(),
+		"period_start": inv.PeriodStart.Format("2006-01-02"),
+	}
+	if inv.Number != nil {
+		metadata["invoice_number"] = *inv.Number
+	}
+
+	stripeInvoiceID, err := s.stripe.CreateInvoice(ctx, customerID, inv.Currency, items, metadata)
+	if err != nil {
+		return err
+	}
+	if err := s.store.SetStripeInvoiceID(ctx, inv.ID, stripeInvoiceID); err != nil {
+		return err
+	}
+	inv.StripeInvoiceID = &stripeInvoiceID
+	return nil
+}
+
+// scopeFields returns log fields naming who an invoice bills
+func scopeFields(scope Scope, err error) []zap.Field {
+	fields := []zap.Field{zap.Error(err)}
+	if scope.TeamID != nil {
+		fields = append(fields, zap.String("team_id", scope.TeamID.String()))
+	}
+	if scope.ProjectID != nil {
+		fields = append(fields, zap.String("project_id", scope.ProjectID.String()))
+	}
+	return fields
+}
@@ -0,0 +1,619 @@
+package invoices
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store persists invoices and reads the usage they are generated from
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates a new invoice store
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const invoiceSelect = `
+	SELECT i.id, i.number, i.team_id, i.project_id, COALESCE(t.name, p.name, ''),
+	       i.period_start, i.period_end, i.status, i.currency, i.total,
+	       i.stripe_invoice_id, i.finalized_at, i.paid_at, i.created_at, i.updated_at
+	FROM invoices i
+	LEFT JOIN teams t ON t.id = i.team_id
+	LEFT JOIN projects p ON p.id = i.project_id
+`
+
+func scanInvoice(row interface{ Scan(...any) error }) (*Invoice, error) {
+	var inv Invoice
+	err := row.Scan(
+		&inv.ID,
+		&inv.Number,
+		&inv.TeamID,
+		&inv.ProjectID,
+		&inv.AccountName,
+		&inv.PeriodStart,
+		&inv.PeriodEnd,
+		&inv.Status,
+		&inv.Currency,
+		&inv.Total,
+		&inv.StripeInvoiceID,
+		&inv.FinalizedAt,
+		&inv.PaidAt,
+		&inv.CreatedAt,
+		&inv.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Get returns an invoice with its line items, or sql.ErrNoRows
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	inv, err := scanInvoice(s.db.QueryRowContext(ctx, invoiceSelect+` WHERE i.id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	inv.LineItems, err = s.lineItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// GetByScope returns the invoice of a team or project for the month starting
+// at periodStart, without line items, or sql.ErrNoRows
+func (s *Store) GetByScope(ctx context.Context, scope Scope, periodStart time.Time) (*Invoice, error) {
+	if scope.TeamID != nil {
+		return scanInvoice(s.db.QueryRowContext(ctx, invoiceSelect+` WHERE i.team_id = $1 AND i.period_start = $2`, scope.TeamID, periodStart))
+	}
+	return scanInvoice(s.db.QueryRowContext(ctx, invoiceSelect+` WHERE i.project_id = $1 AND i.period_start = $2`, scope.ProjectID, periodStart))
+}
+
+// ListForTeam returns the invoices of a team, newest first
+func (s *Store) ListForTeam(ctx context.Context, teamID uuid.UUID) ([]*Invoice, error) {
+	return s.queryInvoices(ctx, invoiceSelect+` WHERE i.team_id = $1 ORDER BY i.period_start DESC`, teamID)
+}
+
+// ListForProject returns the invoices a project's usage appears on: its own,
+// or its team's. Newest first.
+func (s *Store) ListForProject(ctx context.Context, projectID uuid.UUID) ([]*Invoice, error) {
+	query := invoiceSelect + `
+		WHERE i.project_id = $1
+		   OR i.team_id = (SELECT team_id FROM projects WHERE id = $1)
+		ORDER BY i.period_start DESC
+	`
+	return s.queryInvoices(ctx, query, projectID)
+}
+
+// ListFinalized returns the invoices awaiting payment
+func (s *Store) ListFinalized(ctx context.Context) ([]*Invoice, error) {
+	return s.queryInvoices(ctx, invoiceSelect+` WHERE i.status = 'finalized' ORDER BY i.finalized_at`)
+}
+
+func (s *Store) queryInvoices(ctx context.Context, query string, args ...any) ([]*Invoice, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invoices: %w", err)
+	}
+	defer rows.Close()
+
+	invoices := []*Invoice{}
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		invoices = append(invoices, inv)
+	}
+	return invoices, rows.Err()
+}
+
+func (s *Store) lineItems(ctx context.Context, invoiceID uuid.UUID) ([]*LineItem, error) {
+	query := `
+		SELECT li.id, li.project_id, COALESCE(p.name, ''), li.resource_id, COALESCE(li.resource_name, ''),
+		       li.credit_id, li.kind, COALESCE(li.metric_type, ''), li.description, li.quantity, li.unit_price,
+		       li.amount, li.period_start, li.period_end
+		FROM invoice_line_items li
+		LEFT JOIN projects p ON p.id = li.project_id
+		WHERE li.invoice_id = $1
+		ORDER BY li.position
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invoice line items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*LineItem{}
+	for rows.Next() {
+		var item LineItem
+		err := rows.Scan(
+			&item.ID,
+			&item.ProjectID,
+			&item.ProjectName,
+			&item.ResourceID,
+			&item.ResourceName,
+			&item.CreditID,
+			&item.Kind,
+			&item.MetricType,
+			&item.Description,
+			&item.Quantity,
+			&item.UnitPrice,
+			&item.Amount,
+			&item.PeriodStart,
+			&item.PeriodEnd,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invoice line item: %w", err)
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+// SaveDraft creates the draft invoice of a scope and period, or replaces the
+// line items and total of the existing draft. It returns ErrNotDraft when the
+// invoice was already finalized.
+func (s *Store) SaveDraft(ctx context.Context, scope Scope, inv *Invoice) (uuid.UUID, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	var status Status
+	lookup := `SELECT id, status FROM invoices WHERE project_id = $1 AND period_start = $2 FOR UPDATE`
+	scopeID := scope.ProjectID
+	if scope.TeamID != nil {
+		lookup = `SELECT id, status FROM invoices WHERE team_id = $1 AND period_start = $2 FOR UPDATE`
+		scopeID = scope.TeamID
+	}
+	err = tx.QueryRowContext(ctx, lookup, scopeID, inv.PeriodStart).Scan(&id, &status)
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO invoices (team_id, project_id, period_start, period_end, currency, total)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, scope.TeamID, scope.ProjectID, inv.PeriodStart, inv.PeriodEnd, inv.Currency, inv.Total).Scan(&id)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to create invoice: %w", err)
+		}
+	case err != nil:
+		return uuid.Nil, fmt.Errorf("failed to get invoice: %w", err)
+	case status != StatusDraft:
+		return uuid.Nil, ErrNotDraft
+	default:
+		_, err = tx.ExecContext(ctx, `UPDATE invoices SET total = $2, currency = $3, updated_at = NOW() WHERE id = $1`, id, inv.Total, inv.Currency)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to update invoice: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM invoice_line_items WHERE invoice_id = $1`, id); err != nil {
+			return uuid.Nil, fmt.Errorf("failed to clear invoice line items: %w", err)
+		}
+	}
+
+	insertItem := `
+		INSERT INTO invoice_line_items (invoice_id, project_id, resource_id, resource_name, credit_id, kind, metric_type,
+		                                description, quantity, unit_price, amount, period_start, period_end, position)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, NULLIF($7, ''), $8, $9, $10, $11, $12, $13, $14)
+	`
+	for i, item := range inv.LineItems {
+		_, err := tx.ExecContext(ctx, insertItem,
+			id,
+			item.ProjectID,
+			item.ResourceID,
+			item.ResourceName,
+			item.CreditID,
+			item.Kind,
+			item.MetricType,
+			item.Description,
+			item.Quantity,
+			item.UnitPrice,
+			item.Amount,
+			item.PeriodStart,
+			item.PeriodEnd,
+			i,
+		)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to insert invoice line item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return id, nil
+}
+
+// Finalize numbers a draft invoice and freezes it, consuming the credits
+// its credit lines apply. It returns ErrCreditsChanged when a credit no
+// longer covers its line.
+func (s *Store) Finalize(ctx context.Context, id uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE invoices
+		SET status = 'finalized',
+		    number = 'INV-' || to_char(period_start, 'YYYYMM') || '-' || lpad(nextval('invoice_number_seq')::text, 5, '0'),
+		    finalized_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+	`
+	if err := transition(ctx, tx, query, ErrNotDraft, id); err != nil {
+		return err
+	}
+	if err := consumeCredits(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// MarkPaid records the payment of a finalized invoice
+func (s *Store) MarkPaid(ctx context.Context, id uuid.UUID, paidAt time.Time) error {
+	query := `
+		UPDATE invoices SET status = 'paid', paid_at = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'finalized'
+	`
+	return transition(ctx, s.db, query, ErrNotFinalized, id, paidAt)
+}
+
+// execQuerier is a database or a transaction
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// transition runs a status update, telling a missing invoice (sql.ErrNoRows)
+// from one in the wrong state (wrongState)
+func transition(ctx context.Context, db execQuerier, query string, wrongState error, id uuid.UUID, args ...any) error {
+	result, err := db.ExecContext(ctx, query, append([]any{id}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to update invoice: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM invoices WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return wrongState
+}
+
+// consumeCredits draws the credit lines of an invoice from their credits and
+// records them in the credit ledger
+func consumeCredits(ctx context.Context, tx *sql.Tx, invoiceID uuid.UUID) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT li.credit_id, -li.amount, i.number
+		FROM invoice_line_items li
+		JOIN invoices i ON i.id = li.invoice_id
+		WHERE li.invoice_id = $1 AND li.kind = 'credit'
+	`, invoiceID)
+	if err != nil {
+		return fmt.Errorf("failed to query credit lines: %w", err)
+	}
+
+	type use struct {
+		creditID *uuid.UUID
+		amount   float64
+		number   string
+	}
+	var uses []use
+	for rows.Next() {
+		var u use
+		if err := rows.Scan(&u.creditID, &u.amount, &u.number); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan credit line: %w", err)
+		}
+		uses = append(uses, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query credit lines: %w", err)
+	}
+
+	for _, u := range uses {
+		// Deleted credits leave lines without a credit
+		if u.creditID == nil {
+			return ErrCreditsChanged
+		}
+		result, err := tx.ExecContext(ctx, `
+			UPDATE credits SET used_amount = used_amount + $2
+			WHERE id = $1 AND amount - used_amount >= $2
+		`, u.creditID, u.amount)
+		if err != nil {
+			return fmt.Errorf("failed to consume credit: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return ErrCreditsChanged
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO credit_transactions (credit_id, invoice_id, amount, description)
+			VALUES ($1, $2, $3, $4)
+		`, u.creditID, invoiceID, -u.amount, "Applied to invoice "+u.number)
+		if err != nil {
+			return fmt.Errorf("failed to record credit transaction: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetStripeInvoiceID records the Stripe invoice an invoice was synced to
+func (s *Store) SetStripeInvoiceID(ctx context.Context, id uuid.UUID, stripeInvoiceID string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE invoices SET stripe_invoice_id = $2, updated_at = NOW() WHERE id = $1`, id, stripeInvoiceID)
+	if err != nil {
+		return fmt.Errorf("failed to set stripe invoice ID: %w", err)
+	}
+	return nil
+}
+
+// StripeCustomerID returns the Stripe customer an invoice is charged to:
+// that of the most recent subscription of its projects
+func (s *Store) StripeCustomerID(ctx context.Context, inv *Invoice) (string, error) {
+	query := `
+		SELECT s.stripe_customer_id
+		FROM subscriptions s
+		JOIN projects p ON p.id = s.project_id
+		WHERE s.stripe_customer_id IS NOT NULL
+		  AND (p.id = $1 OR p.team_id = $2)
+		ORDER BY s.created_at DESC
+		LIMIT 1
+	`
+
+	var customerID string
+	err := s.db.QueryRowContext(ctx, query, inv.ProjectID, inv.TeamID).Scan(&customerID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query stripe customer: %w", err)
+	}
+	return customerID, nil
+}
+
+// project is a project billed on an invoice
+type project struct {
+	ID   uuid.UUID
+	Name string
+}
+
+// projects returns the projects a scope bills
+func (s *Store) projects(ctx context.Context, scope Scope) ([]project, error) {
+	if scope.ProjectID != nil {
+		var p project
+		var teamID *uuid.UUID
+		err := s.db.QueryRowContext(ctx, `SELECT id, name, team_id FROM projects WHERE id = $1`, scope.ProjectID).Scan(&p.ID, &p.Name, &teamID)
+		if err != nil {
+			return nil, err
+		}
+		if teamID != nil {
+			return nil, ErrBilledToTeam
+		}
+		return []project{p}, nil
+	}
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM teams WHERE id = $1)`, scope.TeamID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name FROM projects WHERE team_id = $1 ORDER BY name`, scope.TeamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []project
+	for rows.Next() {
+		var p project
+		if err := rows.Scan(&p.ID, &p.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+// credit is a credit with a balance left that an invoice can apply
+type credit struct {
+	ID          uuid.UUID
+	Kind        string
+	Description string
+	Balance     float64
+}
+
+// availableCredits returns the credits with a balance left that a scope can
+// apply to the period starting at periodStart, soonest to expire first. A
+// team applies its own credits and those of its projects.
+func (s *Store) availableCredits(ctx context.Context, scope Scope, periodStart time.Time) ([]credit, error) {
+	query := `
+		SELECT id, kind, COALESCE(description, ''), amount - used_amount
+		FROM credits
+		WHERE (team_id = $1
+		       OR project_id IN (SELECT id FROM projects WHERE team_id = $1)
+		       OR project_id = $2)
+		  AND used_amount < amount
+		  AND (expires_at IS NULL OR expires_at > $3)
+		ORDER BY expires_at NULLS LAST, created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, scope.TeamID, scope.ProjectID, periodStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query credits: %w", err)
+	}
+	defer rows.Close()
+
+	var credits []credit
+	for rows.Next() {
+		var c credit
+		if err := rows.Scan(&c.ID, &c.Kind, &c.Description, &c.Balance); err != nil {
+			return nil, fmt.Errorf("failed to scan credit: %w", err)
+		}
+		credits = append(credits, c)
+	}
+	return credits, rows.Err()
+}
+
+// planPeriod is the part of a billing period a project was on a plan
+type planPeriod struct {
+	PlanName     string
+	PriceMonthly float64
+	From, To     time.Time
+}
+
+// planPeriods returns the plans a project was subscribed to during a
+// period. A plan change ends one subscription and starts another, so each
+// subscription covers part of the period.
+func (s *Store) planPeriods(ctx context.Context, projectID uuid.UUID, start, end time.Time) ([]planPeriod, error) {
+	query := `
+		SELECT pp.name, pp.price_monthly,
+		       GREATEST(s.created_at, $2),
+		       LEAST(COALESCE(s.cancelled_at, $3), $3)
+		FROM subscriptions s
+		JOIN pricing_plans pp ON pp.id = s.plan_id
+		WHERE s.project_id = $1
+		  AND s.created_at < $3
+		  AND (s.cancelled_at IS NULL OR s.cancelled_at > $2)
+		ORDER BY 3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, projectID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []planPeriod
+	for rows.Next() {
+		var p planPeriod
+		if err := rows.Scan(&p.PlanName, &p.PriceMonthly, &p.From, &p.To); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+// projectUsage returns the usage of a project per metric during a period
+func (s *Store) projectUsage(ctx context.Context, projectID uuid.UUID, start, end time.Time) (map[string]float64, error) {
+	query := `
+		SELECT metric_type, SUM(value)
+		FROM hourly_usage
+		WHERE project_id = $1 AND hour >= $2 AND hour < $3
+		GROUP BY metric_type
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, projectID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]float64)
+	for rows.Next() {
+		var metricType string
+		var total float64
+		if err := rows.Scan(&metricType, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		usage[metricType] = total
+	}
+	return usage, rows.Err()
+}
+
+// resourceMetric is the usage of a metric by a resource during a period
+type resourceMetric struct {
+	ResourceID   uuid.UUID
+	ResourceType string
+	ResourceName string
+	MetricType   string
+	Value        float64
+}
+
+// resourceUsage returns the usage of a project per resource and metric
+// during a period
+func (s *Store) resourceUsage(ctx context.Context, projectID uuid.UUID, start, end time.Time) ([]resourceMetric, error) {
+	query := `
+		SELECT resource_id, MAX(resource_type), COALESCE(MAX(resource_name), ''), metric_type, SUM(value)
+		FROM hourly_resource_usage
+		WHERE project_id = $1 AND hour >= $2 AND hour < $3
+		GROUP BY resource_id, metric_type
+		ORDER BY 3, 4
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, projectID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resource usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []resourceMetric
+	for rows.Next() {
+		var m resourceMetric
+		if err := rows.Scan(&m.ResourceID, &m.ResourceType, &m.ResourceName, &m.MetricType, &m.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan resource usage: %w", err)
+		}
+		usage = append(usage, m)
+	}
+	return usage, rows.Err()
+}
+
+// ScopesWithActivity returns the teams and team-less projects that had usage
+// or a subscription during a period
+func (s *Store) ScopesWithActivity(ctx context.Context, start, end time.Time) ([]Scope, error) {
+	query := `
+		SELECT DISTINCT p.team_id, CASE WHEN p.team_id IS NULL THEN p.id END
+		FROM projects p
+		WHERE EXISTS (
+		        SELECT 1 FROM hourly_usage hu
+		        WHERE hu.project_id = p.id AND hu.hour >= $1 AND hu.hour < $2
+		      )
+		   OR EXISTS (
+		        SELECT 1 FROM subscriptions s
+		        WHERE s.project_id = p.id
+		          AND s.created_at < $2
+		          AND (s.cancelled_at IS NULL OR s.cancelled_at > $1)
+		      )
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query billable accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var scopes []Scope
+	for rows.Next() {
+		var scope Scope
+		if err := rows.Scan(&scope.TeamID, &scope.ProjectID); err != nil {
+			return nil, fmt.Errorf("failed to scan billable account: %w", err)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, rows.Err()
+}
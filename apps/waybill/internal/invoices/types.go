@@ -0,0 +1,97 @@
+package invoices
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status is the lifecycle state of an invoice
+type Status string
+
+const (
+	StatusDraft     Status = "draft"     // Regenerated from usage until finalized
+	StatusFinalized Status = "finalized" // Numbered and immutable, awaiting payment
+	StatusPaid      Status = "paid"
+)
+
+// LineKind tells plan charges from usage charges, and both from the
+// deductions made for the free allowance and credits
+type LineKind string
+
+const (
+	LinePlan      LineKind = "plan"
+	LineUsage     LineKind = "usage"
+	LineAllowance LineKind = "allowance" // Negative: usage within the free allowance
+	LineCredit    LineKind = "credit"    // Negative: a credit applied to the invoice
+)
+
+var (
+	// ErrNotDraft is returned when changing an invoice that was finalized
+	ErrNotDraft = errors.New("invoice is not a draft")
+	// ErrNotFinalized is returned when paying an invoice that is not finalized
+	ErrNotFinalized = errors.New("invoice is not finalized")
+	// ErrBilledToTeam is returned when invoicing a project that belongs to a
+	// team; its usage is on the team's invoice
+	ErrBilledToTeam = errors.New("project is billed on its team's invoice")
+	// ErrCreditsChanged is returned when finalizing a draft whose credits
+	// were consumed or expired since it was generated
+	ErrCreditsChanged = errors.New("credits changed since the draft was generated; generate it again")
+)
+
+// Scope is who an invoice bills: a team (all its projects) or a project
+// without a team
+type Scope struct {
+	TeamID    *uuid.UUID
+	ProjectID *uuid.UUID
+}
+
+// Invoice is the bill of a team or project for a month
+type Invoice struct {
+	ID              uuid.UUID   `json:"id" db:"id"`
+	Number          *string     `json:"number,omitempty" db:"number"` // Set when finalized
+	TeamID          *uuid.UUID  `json:"team_id,omitempty" db:"team_id"`
+	ProjectID       *uuid.UUID  `json:"project_id,omitempty" db:"project_id"`
+	AccountName     string      `json:"account_name"` // Team or project name
+	PeriodStart     time.Time   `json:"period_start" db:"period_start"`
+	PeriodEnd       time.Time   `json:"period_end" db:"period_end"` // Exclusive
+	Status          Status      `json:"status" db:"status"`
+	Currency        string      `json:"currency" db:"currency"`
+	Total           float64     `json:"total" db:"total"`
+	StripeInvoiceID *string     `json:"stripe_invoice_id,omitempty" db:"stripe_invoice_id"`
+	FinalizedAt     *time.Time  `json:"finalized_at,omitempty" db:"finalized_at"`
+	PaidAt          *time.Time  `json:"paid_at,omitempty" db:"paid_at"`
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+	LineItems       []*LineItem `json:"line_items,omitempty"`
+}
+
+// LineItem is a charge on an invoice: a plan, or the usage of a metric by a
+// service of a project. Allowance and credit lines deduct from it.
+type LineItem struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ProjectID    *uuid.UUID `json:"project_id,omitempty" db:"project_id"` // nil for credits
+	ProjectName  string     `json:"project_name,omitempty"`
+	ResourceID   *uuid.UUID `json:"resource_id,omitempty" db:"resource_id"` // nil for plans and unattributed usage
+	CreditID     *uuid.UUID `json:"credit_id,omitempty" db:"credit_id"`
+	ResourceName string     `json:"resource_name,omitempty" db:"resource_name"`
+	Kind         LineKind   `json:"kind" db:"kind"`
+	MetricType   string     `json:"metric_type,omitempty" db:"metric_type"`
+	Description  string     `json:"description" db:"description"`
+	Quantity     float64    `json:"quantity" db:"quantity"`
+	UnitPrice    float64    `json:"unit_price" db:"unit_price"`
+	Amount       float64    `json:"amount" db:"amount"`
+	PeriodStart  time.Time  `json:"period_start" db:"period_start"`
+	PeriodEnd    time.Time  `json:"period_end" db:"period_end"`
+}
+
+// GenerateRequest is the request to generate the draft invoice of a month
+type GenerateRequest struct {
+	Period string `json:"period" binding:"required"` // "2026-10"
+}
+
+// ParsePeriod parses a "YYYY-MM" billing month into its first day
+func ParsePeriod(period string) (time.Time, error) {
+	return time.Parse("2006-01", period)
+}
@@ -0,0 +1,69 @@
+package payments
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/switchyard"
+)
+
+// NotificationKind is what happened to a billing account
+type NotificationKind string
+
+const (
+	NotifyPaymentFailed        NotificationKind = "payment_failed"
+	NotifySuspended            NotificationKind = "suspended"
+	NotifyPaymentRecovered     NotificationKind = "payment_recovered"
+	NotifyCardExpiring         NotificationKind = "card_expiring"
+	NotifySubscriptionCanceled NotificationKind = "subscription_canceled"
+)
+
+// Notification tells switchyard-api about a billing account. It sends the
+// dunning emails, and for NotifySuspended scales every service of the
+// projects to zero until a payment succeeds.
+type Notification struct {
+	Kind           NotificationKind `json:"kind"`
+	TeamID         *uuid.UUID       `json:"team_id,omitempty"`
+	ProjectID      *uuid.UUID       `json:"project_id,omitempty"` // Set for projects without a team
+	ProjectIDs     []uuid.UUID      `json:"project_ids"`
+	Status         AccountStatus    `json:"status"`
+	Amount         float64          `json:"amount,omitempty"`
+	Currency       string           `json:"currency,omitempty"`
+	FailedPayments int              `json:"failed_payments,omitempty"`
+	SuspendAfter   int              `json:"suspend_after,omitempty"`
+	NextAttempt    *time.Time       `json:"next_attempt,omitempty"`
+	InvoiceURL     string           `json:"invoice_url,omitempty"`
+	Resume         bool             `json:"resume,omitempty"` // Recovered from suspension
+	Card           *Card            `json:"card,omitempty"`
+	PlanName       string           `json:"plan_name,omitempty"`
+}
+
+// Card describes an expiring card
+type Card struct {
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+}
+
+// Notifier delivers billing notifications
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// SwitchyardNotifier posts billing notifications to switchyard-api
+type SwitchyardNotifier struct {
+	client *switchyard.Client
+}
+
+// NewSwitchyardNotifier creates a notifier for the switchyard-api at
+// baseURL. apiKey is the key switchyard-api uses to call Waybill.
+func NewSwitchyardNotifier(baseURL, apiKey string) *SwitchyardNotifier {
+	return &SwitchyardNotifier{client: switchyard.NewClient(baseURL, apiKey)}
+}
+
+// Notify sends a notification to switchyard-api
+func (n *SwitchyardNotifier) Notify(ctx context.Context, notification *Notification) error {
+	return n.client.Callback(ctx, "billing", notification)
+}
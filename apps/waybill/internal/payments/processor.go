@@ -0,0 +1,354 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/billing"
+	"go.uber.org/zap"
+)
+
+// DefaultSuspendAfter is how many failed payments in a row suspend an
+// account when not configured
+const DefaultSuspendAfter = 3
+
+// accountStore is where a Processor applies events; *Store implements it
+type accountStore interface {
+	EventProcessed(ctx context.Context, eventID string) (bool, error)
+	MarkEventProcessed(ctx context.Context, eventID, eventType string) error
+	Account(ctx context.Context, customerID string) (*Account, error)
+	RecordPaymentFailure(ctx context.Context, eventID, eventType string, id uuid.UUID, message string) (*Account, bool, error)
+	Suspend(ctx context.Context, id uuid.UUID) (bool, error)
+	RecordPayment(ctx context.Context, id uuid.UUID) error
+	ProjectIDs(ctx context.Context, account *Account) ([]uuid.UUID, error)
+	MarkInvoicePaid(ctx context.Context, stripeInvoiceID string, paidAt time.Time) error
+	CreateSubscription(ctx context.Context, sub *subscriptionRecord) (bool, error)
+	CancelSubscription(ctx context.Context, stripeSubscriptionID string, cancelledAt time.Time) (string, error)
+}
+
+// Processor applies Stripe webhook events to billing accounts,
+// subscriptions and invoices
+type Processor struct {
+	store         accountStore
+	notifier      Notifier
+	webhookSecret string
+	suspendAfter  int
+	logger        *zap.Logger
+}
+
+// NewProcessor creates a new Stripe webhook processor. Accounts are
+// suspended after suspendAfter failed payments in a row. Without a
+// notifier, account changes are recorded but dunning emails are not sent
+// and services are not stopped.
+func NewProcessor(store *Store, notifier Notifier, webhookSecret string, suspendAfter int, logger *zap.Logger) *Processor {
+	if suspendAfter <= 0 {
+		suspendAfter = DefaultSuspendAfter
+	}
+	return &Processor{
+		store:         store,
+		notifier:      notifier,
+		webhookSecret: webhookSecret,
+		suspendAfter:  suspendAfter,
+		logger:        logger,
+	}
+}
+
+// HandleWebhook verifies and applies a webhook delivery. Errors other than
+// ErrNotConfigured and ErrInvalidSignature should be answered with a 5xx so
+// Stripe retries the delivery.
+func (p *Processor) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	if p.webhookSecret == "" {
+		return ErrNotConfigured
+	}
+
+	event, err := billing.ParseWebhookEvent(payload, signature, p.webhookSecret)
+	if err != nil {
+		p.logger.Warn("rejected stripe webhook", zap.Error(err))
+		return ErrInvalidSignature
+	}
+
+	processed, err := p.store.EventProcessed(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+	if processed {
+		return nil
+	}
+
+	if err := p.apply(ctx, event); err != nil {
+		return fmt.Errorf("%s %s: %w", event.Type, event.ID, err)
+	}
+	return p.store.MarkEventProcessed(ctx, event.ID, event.Type)
+}
+
+func (p *Processor) apply(ctx context.Context, event *billing.WebhookEvent) error {
+	switch event.Type {
+	case "invoice.paid", "invoice.payment_succeeded":
+		var inv stripeInvoice
+		if err := json.Unmarshal(event.Object, &inv); err != nil {
+			return err
+		}
+		return p.paymentSucceeded(ctx, &inv, event.Created)
+
+	case "invoice.payment_failed":
+		var inv stripeInvoice
+		if err := json.Unmarshal(event.Object, &inv); err != nil {
+			return err
+		}
+		return p.paymentFailed(ctx, event, &inv)
+
+	case "customer.subscription.created":
+		var sub stripeSubscription
+		if err := json.Unmarshal(event.Object, &sub); err != nil {
+			return err
+		}
+		return p.subscriptionCreated(ctx, &sub, event.Created)
+
+	case "customer.subscription.deleted":
+		var sub stripeSubscription
+		if err := json.Unmarshal(event.Object, &sub); err != nil {
+			return err
+		}
+		return p.subscriptionDeleted(ctx, &sub, event.Created)
+
+	case "customer.source.expiring":
+		var card stripeCard
+		if err := json.Unmarshal(event.Object, &card); err != nil {
+			return err
+		}
+		return p.cardExpiring(ctx, &card)
+
+	default:
+		p.logger.Debug("ignoring stripe event", zap.String("type", event.Type))
+		return nil
+	}
+}
+
+// paymentSucceeded marks the invoice paid and, after failed payments,
+// returns the account to good standing and resumes suspended services
+func (p *Processor) paymentSucceeded(ctx context.Context, inv *stripeInvoice, at time.Time) error {
+	if err := p.store.MarkInvoicePaid(ctx, inv.ID, at); err != nil {
+		return err
+	}
+
+	account, ok, err := p.account(ctx, inv.Customer)
+	if !ok {
+		return err
+	}
+	if account.Status == AccountActive && account.FailedPayments == 0 {
+		return nil
+	}
+
+	if err := p.store.RecordPayment(ctx, account.ID); err != nil {
+		return err
+	}
+	p.logger.Info("billing account recovered",
+		zap.String("account_id", account.ID.String()),
+		zap.String("previous_status", string(account.Status)))
+
+	return p.notify(ctx, account, &Notification{
+		Kind:     NotifyPaymentRecovered,
+		Status:   AccountActive,
+		Amount:   centsToAmount(inv.AmountDue),
+		Currency: strings.ToUpper(inv.Currency),
+		Resume:   account.Status == AccountSuspended,
+	})
+}
+
+// paymentFailed counts the failure and sends a dunning email, or suspends
+// the account once payments failed suspendAfter times in a row. The failure
+// is counted together with recording the event, so a redelivery of an event
+// whose notification failed does not count it again.
+func (p *Processor) paymentFailed(ctx context.Context, event *billing.WebhookEvent, inv *stripeInvoice) error {
+	account, ok, err := p.account(ctx, inv.Customer)
+	if !ok {
+		return err
+	}
+
+	var message string
+	if inv.LastPaymentError != nil {
+		message = inv.LastPaymentError.Message
+	}
+	account, applied, err := p.store.RecordPaymentFailure(ctx, event.ID, event.Type, account.ID, message)
+	if err != nil || !applied {
+		return err
+	}
+
+	notification := &Notification{
+		Kind:           NotifyPaymentFailed,
+		Status:         account.Status,
+		Amount:         centsToAmount(inv.AmountDue),
+		Currency:       strings.ToUpper(inv.Currency),
+		FailedPayments: account.FailedPayments,
+		SuspendAfter:   p.suspendAfter,
+		NextAttempt:    unixTime(inv.NextPaymentAttempt),
+		InvoiceURL:     inv.HostedInvoiceURL,
+	}
+
+	// Suspension is repeated on later failures, so switchyard-api stops the
+	// services even if it missed the first notification
+	if account.FailedPayments >= p.suspendAfter {
+		suspended, err := p.store.Suspend(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+		if suspended {
+			p.logger.Warn("billing account suspended",
+				zap.String("account_id", account.ID.String()),
+				zap.Int("failed_payments", account.FailedPayments))
+		}
+		notification.Kind = NotifySuspended
+		notification.Status = AccountSuspended
+	}
+
+	return p.notify(ctx, account, notification)
+}
+
+// subscriptionCreated records the subscription for the project in its
+// metadata
+func (p *Processor) subscriptionCreated(ctx context.Context, sub *stripeSubscription, at time.Time) error {
+	projectID, err := uuid.Parse(sub.Metadata["project_id"])
+	if err != nil {
+		p.logger.Warn("stripe subscription without project_id metadata", zap.String("subscription_id", sub.ID))
+		return nil
+	}
+	planID := sub.planID()
+	if planID == "" {
+		p.logger.Warn("stripe subscription without plan", zap.String("subscription_id", sub.ID))
+		return nil
+	}
+
+	startedAt := at
+	if start := unixTime(sub.StartDate); start != nil {
+		startedAt = *start
+	}
+
+	created, err := p.store.CreateSubscription(ctx, &subscriptionRecord{
+		ProjectID:            projectID,
+		PlanID:               planID,
+		StripeCustomerID:     sub.Customer,
+		StripeSubscriptionID: sub.ID,
+		Status:               sub.Status,
+		StartedAt:            startedAt,
+		CurrentPeriodStart:   unixTime(sub.CurrentPeriodStart),
+		CurrentPeriodEnd:     unixTime(sub.CurrentPeriodEnd),
+	})
+	if err == sql.ErrNoRows {
+		p.logger.Warn("stripe subscription for unknown project",
+			zap.String("subscription_id", sub.ID),
+			zap.String("project_id", projectID.String()))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if created {
+		p.logger.Info("subscription created",
+			zap.String("subscription_id", sub.ID),
+			zap.String("project_id", projectID.String()),
+			zap.String("plan_id", planID))
+	}
+
+	// Create the billing account up front so its first failed payment
+	// finds it
+	_, _, err = p.account(ctx, sub.Customer)
+	return err
+}
+
+// subscriptionDeleted ends the subscription and tells the account
+func (p *Processor) subscriptionDeleted(ctx context.Context, sub *stripeSubscription, at time.Time) error {
+	cancelledAt := at
+	if canceled := unixTime(sub.CanceledAt); canceled != nil {
+		cancelledAt = *canceled
+	}
+
+	planName, err := p.store.CancelSubscription(ctx, sub.ID, cancelledAt)
+	if err == sql.ErrNoRows {
+		p.logger.Warn("cancelled stripe subscription was never recorded", zap.String("subscription_id", sub.ID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	account, ok, err := p.account(ctx, sub.Customer)
+	if !ok {
+		return err
+	}
+	return p.notify(ctx, account, &Notification{
+		Kind:     NotifySubscriptionCanceled,
+		Status:   account.Status,
+		PlanName: planName,
+	})
+}
+
+// cardExpiring asks the account to update its card
+func (p *Processor) cardExpiring(ctx context.Context, card *stripeCard) error {
+	account, ok, err := p.account(ctx, card.Customer)
+	if !ok {
+		return err
+	}
+
+	details := &Card{Brand: card.Brand, Last4: card.Last4, ExpMonth: card.ExpMonth, ExpYear: card.ExpYear}
+	if card.Card != nil {
+		details = &Card{Brand: card.Card.Brand, Last4: card.Card.Last4, ExpMonth: card.Card.ExpMonth, ExpYear: card.Card.ExpYear}
+	}
+
+	return p.notify(ctx, account, &Notification{
+		Kind:   NotifyCardExpiring,
+		Status: account.Status,
+		Card:   details,
+	})
+}
+
+// account returns the billing account of a Stripe customer. ok is false,
+// with a nil error, for customers Waybill does not bill.
+func (p *Processor) account(ctx context.Context, customerID string) (account *Account, ok bool, err error) {
+	if customerID == "" {
+		return nil, false, nil
+	}
+	account, err = p.store.Account(ctx, customerID)
+	if err == sql.ErrNoRows {
+		p.logger.Debug("stripe customer without subscription", zap.String("customer_id", customerID))
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return account, true, nil
+}
+
+// notify sends a notification about an account to switchyard-api. The
+// account change is already recorded, so a failed notification is logged
+// rather than having Stripe redeliver the event.
+func (p *Processor) notify(ctx context.Context, account *Account, notification *Notification) error {
+	if p.notifier == nil {
+		return nil
+	}
+
+	projectIDs, err := p.store.ProjectIDs(ctx, account)
+	if err != nil {
+		return err
+	}
+	notification.TeamID = account.TeamID
+	notification.ProjectID = account.ProjectID
+	notification.ProjectIDs = projectIDs
+
+	if err := p.notifier.Notify(ctx, notification); err != nil {
+		p.logger.Error("failed to send billing notification",
+			zap.String("account_id", account.ID.String()),
+			zap.String("kind", string(notification.Kind)),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// centsToAmount converts a Stripe amount in the smallest currency unit
+func centsToAmount(cents int64) float64 {
+	return float64(cents) / 100
+}
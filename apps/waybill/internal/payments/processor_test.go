@@ -0,0 +1,167 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// fakeStore keeps one billing account in memory. Like Store, it records a
+// payment failure together with its event.
+type fakeStore struct {
+	account *Account
+	events  map[string]bool
+
+	// raceEvents makes EventProcessed miss recorded events, as when two
+	// deliveries of an event are checked before either is applied
+	raceEvents bool
+	// projectErrs are returned by the first calls of ProjectIDs
+	projectErrs []error
+}
+
+func newFakeStore() *fakeStore {
+	teamID := uuid.New()
+	return &fakeStore{
+		account: &Account{ID: uuid.New(), StripeCustomerID: "cus_1", TeamID: &teamID, Status: AccountActive},
+		events:  make(map[string]bool),
+	}
+}
+
+func (s *fakeStore) EventProcessed(ctx context.Context, eventID string) (bool, error) {
+	return s.events[eventID] && !s.raceEvents, nil
+}
+
+func (s *fakeStore) MarkEventProcessed(ctx context.Context, eventID, eventType string) error {
+	s.events[eventID] = true
+	return nil
+}
+
+func (s *fakeStore) Account(ctx context.Context, customerID string) (*Account, error) {
+	copied := *s.account
+	return &copied, nil
+}
+
+func (s *fakeStore) RecordPaymentFailure(ctx context.Context, eventID, eventType string, id uuid.UUID, message string) (*Account, bool, error) {
+	if s.events[eventID] {
+		return nil, false, nil
+	}
+	s.events[eventID] = true
+	s.account.FailedPayments++
+	if s.account.Status != AccountSuspended {
+		s.account.Status = AccountPastDue
+	}
+	copied := *s.account
+	return &copied, true, nil
+}
+
+func (s *fakeStore) Suspend(ctx context.Context, id uuid.UUID) (bool, error) {
+	suspended := s.account.Status != AccountSuspended
+	s.account.Status = AccountSuspended
+	return suspended, nil
+}
+
+func (s *fakeStore) RecordPayment(ctx context.Context, id uuid.UUID) error {
+	s.account.Status = AccountActive
+	s.account.FailedPayments = 0
+	return nil
+}
+
+func (s *fakeStore) ProjectIDs(ctx context.Context, account *Account) ([]uuid.UUID, error) {
+	if len(s.projectErrs) > 0 {
+		err := s.projectErrs[0]
+		s.projectErrs = s.projectErrs[1:]
+		return nil, err
+	}
+	return []uuid.UUID{uuid.New()}, nil
+}
+
+func (s *fakeStore) MarkInvoicePaid(ctx context.Context, stripeInvoiceID string, paidAt time.Time) error {
+	return nil
+}
+
+func (s *fakeStore) CreateSubscription(ctx context.Context, sub *subscriptionRecord) (bool, error) {
+	return true, nil
+}
+
+func (s *fakeStore) CancelSubscription(ctx context.Context, stripeSubscriptionID string, cancelledAt time.Time) (string, error) {
+	return "", nil
+}
+
+type fakeNotifier struct {
+	notifications []*Notification
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, notification *Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+// signedEvent returns a Stripe event and the signature header Stripe would
+// send it with
+func signedEvent(t *testing.T, id, eventType, object string) ([]byte, string) {
+	t.Helper()
+	payload := []byte(fmt.Sprintf(`{"id":%q,"object":"event","type":%q,"created":%d,"data":{"object":%s}}`,
+		id, eventType, time.Now().Unix(), object))
+
+	timestamp := time.Now().Unix()
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	fmt.Fprintf(mac, "%d.%s", timestamp, payload)
+	return payload, fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestPaymentFailedEventCountedOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*fakeStore)
+	}{
+		{
+			name: "redelivered after a failed notification",
+			setup: func(s *fakeStore) {
+				s.projectErrs = []error{errors.New("connection reset")}
+			},
+		},
+		{
+			name: "delivered twice concurrently",
+			setup: func(s *fakeStore) {
+				s.raceEvents = true
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			tt.setup(store)
+			processor := &Processor{
+				store:         store,
+				notifier:      &fakeNotifier{},
+				webhookSecret: testWebhookSecret,
+				suspendAfter:  DefaultSuspendAfter,
+				logger:        zap.NewNop(),
+			}
+
+			payload, signature := signedEvent(t, "evt_failed_1", "invoice.payment_failed",
+				`{"id":"in_1","customer":"cus_1","amount_due":2000,"currency":"usd"}`)
+			for i := 0; i < 2; i++ {
+				_ = processor.HandleWebhook(context.Background(), payload, signature)
+			}
+
+			if store.account.FailedPayments != 1 {
+				t.Errorf("FailedPayments = %d, want 1", store.account.FailedPayments)
+			}
+			if store.account.Status != AccountPastDue {
+				t.Errorf("Status = %s, want %s", store.account.Status, AccountPastDue)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store persists billing accounts and the Stripe events applied to them
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates a new payments store
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const accountColumns = `
+	id, stripe_customer_id, team_id, project_id, status, failed_payments,
+	last_payment_error, past_due_since, suspended_at, created_at, updated_at
+`
+
+func scanAccount(row interface{ Scan(...any) error }) (*Account, error) {
+	var a Account
+	err := row.Scan(
+		&a.ID,
+		&a.StripeCustomerID,
+		&a.TeamID,
+		&a.ProjectID,
+		&a.Status,
+		&a.FailedPayments,
+		&a.LastPaymentError,
+		&a.PastDueSince,
+		&a.SuspendedAt,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// EventProcessed reports whether a Stripe event was already applied.
+// Stripe delivers events at least once.
+func (s *Store) EventProcessed(ctx context.Context, eventID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM stripe_webhook_events WHERE id = $1)`, eventID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to query stripe event: %w", err)
+	}
+	return exists, nil
+}
+
+// MarkEventProcessed records that a Stripe event was applied
+func (s *Store) MarkEventProcessed(ctx context.Context, eventID, eventType string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO stripe_webhook_events (id, type) VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING
+	`, eventID, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to record stripe event: %w", err)
+	}
+	return nil
+}
+
+// Account returns the billing account of a Stripe customer, creating it for
+// the team or project its subscriptions belong to. It returns sql.ErrNoRows
+// for customers without a subscription.
+func (s *Store) Account(ctx context.Context, customerID string) (*Account, error) {
+	account, err := scanAccount(s.db.QueryRowContext(ctx,
+		`SELECT `+accountColumns+` FROM billing_accounts WHERE stripe_customer_id = $1`, customerID))
+	if err != sql.ErrNoRows {
+		return account, err
+	}
+
+	// Projects in a team are billed to the team
+	var teamID *uuid.UUID
+	var projectID uuid.UUID
+	err = s.db.QueryRowContext(ctx, `
+		SELECT p.team_id, p.id
+		FROM subscriptions s
+		JOIN projects p ON p.id = s.project_id
+		WHERE s.stripe_customer_id = $1
+		ORDER BY s.created_at DESC
+		LIMIT 1
+	`, customerID).Scan(&teamID, &projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scopeProject *uuid.UUID
+	if teamID == nil {
+		scopeProject = &projectID
+	}
+
+	account, err = scanAccount(s.db.QueryRowContext(ctx, `
+		INSERT INTO billing_accounts (stripe_customer_id, team_id, project_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (stripe_customer_id) DO UPDATE SET updated_at = NOW()
+		RETURNING `+accountColumns,
+		customerID, teamID, scopeProject))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create billing account: %w", err)
+	}
+	return account, nil
+}
+
+// RecordPaymentFailure counts the failed payment a Stripe event reports and
+// marks the account past due. Suspended accounts stay suspended. The event
+// is recorded as processed in the same transaction, so a redelivered or
+// concurrently delivered event is counted once: applied is false, with a nil
+// account, when the event was already recorded.
+func (s *Store) RecordPaymentFailure(ctx context.Context, eventID, eventType string, id uuid.UUID, message string) (account *Account, applied bool, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO stripe_webhook_events (id, type) VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING
+	`, eventID, eventType)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record stripe event: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, false, err
+	}
+
+	account, err = scanAccount(tx.QueryRowContext(ctx, `
+		UPDATE billing_accounts
+		SET failed_payments = failed_payments + 1,
+		    status = CASE WHEN status = 'suspended' THEN status ELSE 'past_due' END,
+		    past_due_since = COALESCE(past_due_since, NOW()),
+		    last_payment_error = NULLIF($2, ''),
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+accountColumns,
+		id, message))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record payment failure: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return account, true, nil
+}
+
+// Suspend marks an account suspended. It reports false when it already was.
+func (s *Store) Suspend(ctx context.Context, id uuid.UUID) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE billing_accounts
+		SET status = 'suspended', suspended_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status <> 'suspended'
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to suspend billing account: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// RecordPayment returns an account to good standing after a successful
+// payment
+func (s *Store) RecordPayment(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE billing_accounts
+		SET status = 'active', failed_payments = 0, last_payment_error = NULL,
+		    past_due_since = NULL, suspended_at = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to record payment: %w", err)
+	}
+	return nil
+}
+
+// ProjectIDs returns the projects an account pays for
+func (s *Store) ProjectIDs(ctx context.Context, account *Account) ([]uuid.UUID, error) {
+	if account.ProjectID != nil {
+		return []uuid.UUID{*account.ProjectID}, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM projects WHERE team_id = $1`, account.TeamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team projects: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkInvoicePaid marks the finalized invoice synced to a Stripe invoice
+// paid. Stripe invoices Waybill did not create are ignored.
+func (s *Store) MarkInvoicePaid(ctx context.Context, stripeInvoiceID string, paidAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE invoices SET status = 'paid', paid_at = $2, updated_at = NOW()
+		WHERE stripe_invoice_id = $1 AND status = 'finalized'
+	`, stripeInvoiceID, paidAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark invoice paid: %w", err)
+	}
+	return nil
+}
+
+// subscriptionRecord is a Stripe subscription to record for a project
+type subscriptionRecord struct {
+	ProjectID            uuid.UUID
+	PlanID               string
+	StripeCustomerID     string
+	StripeSubscriptionID string
+	Status               string
+	StartedAt            time.Time
+	CurrentPeriodStart   *time.Time
+	CurrentPeriodEnd     *time.Time
+}
+
+// CreateSubscription records a new subscription of a project and ends the
+// subscription it replaces, so invoices prorate both plans. It reports false
+// when the subscription was already recorded.
+func (s *Store) CreateSubscription(ctx context.Context, sub *subscriptionRecord) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var teamID *uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT team_id FROM projects WHERE id = $1 FOR UPDATE`, sub.ProjectID).Scan(&teamID)
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM subscriptions WHERE stripe_subscription_id = $1)`,
+		sub.StripeSubscriptionID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to query subscription: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE subscriptions
+		SET status = 'cancelled', cancelled_at = $2, updated_at = NOW()
+		WHERE project_id = $1 AND cancelled_at IS NULL
+	`, sub.ProjectID, sub.StartedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to end previous subscription: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO subscriptions (
+			id, project_id, team_id, plan_id, stripe_customer_id, stripe_subscription_id,
+			status, current_period_start, current_period_end, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+	`,
+		uuid.New(),
+		sub.ProjectID,
+		teamID,
+		sub.PlanID,
+		sub.StripeCustomerID,
+		sub.StripeSubscriptionID,
+		sub.Status,
+		sub.CurrentPeriodStart,
+		sub.CurrentPeriodEnd,
+		sub.StartedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert subscription: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// CancelSubscription ends a subscription. It returns the name of the plan
+// it was on, or sql.ErrNoRows when Waybill never recorded it.
+func (s *Store) CancelSubscription(ctx context.Context, stripeSubscriptionID string, cancelledAt time.Time) (string, error) {
+	var planName string
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE subscriptions
+		SET status = 'cancelled', cancelled_at = COALESCE(cancelled_at, $2), updated_at = NOW()
+		WHERE stripe_subscription_id = $1
+		RETURNING COALESCE((SELECT name FROM pricing_plans WHERE id = subscriptions.plan_id), plan_id)
+	`, stripeSubscriptionID, cancelledAt).Scan(&planName)
+	if err == sql.ErrNoRows {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+	return planName, nil
+}
@@ -0,0 +1,117 @@
+package payments
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountStatus is the payment standing of a billing account
+type AccountStatus string
+
+const (
+	AccountActive    AccountStatus = "active"
+	AccountPastDue   AccountStatus = "past_due"  // A payment failed; Stripe is retrying
+	AccountSuspended AccountStatus = "suspended" // Payments kept failing; services are stopped
+)
+
+var (
+	// ErrNotConfigured is returned for webhooks when no endpoint secret is set
+	ErrNotConfigured = errors.New("stripe webhooks are not configured")
+	// ErrInvalidSignature is returned for webhooks Stripe did not sign
+	ErrInvalidSignature = errors.New("invalid stripe signature")
+)
+
+// Account is the Stripe customer a team, or a project without a team, pays
+// with
+type Account struct {
+	ID               uuid.UUID     `json:"id" db:"id"`
+	StripeCustomerID string        `json:"stripe_customer_id" db:"stripe_customer_id"`
+	TeamID           *uuid.UUID    `json:"team_id,omitempty" db:"team_id"`
+	ProjectID        *uuid.UUID    `json:"project_id,omitempty" db:"project_id"`
+	Status           AccountStatus `json:"status" db:"status"`
+	FailedPayments   int           `json:"failed_payments" db:"failed_payments"` // Since the last successful payment
+	LastPaymentError *string       `json:"last_payment_error,omitempty" db:"last_payment_error"`
+	PastDueSince     *time.Time    `json:"past_due_since,omitempty" db:"past_due_since"`
+	SuspendedAt      *time.Time    `json:"suspended_at,omitempty" db:"suspended_at"`
+	CreatedAt        time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// Stripe objects, decoded from webhook events. Only the fields Waybill
+// acts on are listed.
+
+type stripeInvoice struct {
+	ID                 string `json:"id"`
+	Customer           string `json:"customer"`
+	AmountDue          int64  `json:"amount_due"`
+	Currency           string `json:"currency"`
+	NextPaymentAttempt int64  `json:"next_payment_attempt"`
+	HostedInvoiceURL   string `json:"hosted_invoice_url"`
+	LastPaymentError   *struct {
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+type stripeSubscription struct {
+	ID                 string            `json:"id"`
+	Customer           string            `json:"customer"`
+	Status             string            `json:"status"`
+	Metadata           map[string]string `json:"metadata"`
+	StartDate          int64             `json:"start_date"`
+	CanceledAt         int64             `json:"canceled_at"`
+	CurrentPeriodStart int64             `json:"current_period_start"`
+	CurrentPeriodEnd   int64             `json:"current_period_end"`
+	Items              struct {
+		Data []struct {
+			Price struct {
+				LookupKey string            `json:"lookup_key"`
+				Metadata  map[string]string `json:"metadata"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// planID returns the Enclii plan of a subscription: the plan_id metadata of
+// the subscription or its price, or the lookup key of the price
+func (s *stripeSubscription) planID() string {
+	if id := s.Metadata["plan_id"]; id != "" {
+		return id
+	}
+	for _, item := range s.Items.Data {
+		if id := item.Price.Metadata["plan_id"]; id != "" {
+			return id
+		}
+		if item.Price.LookupKey != "" {
+			return item.Price.LookupKey
+		}
+	}
+	return ""
+}
+
+// stripeCard is the object of customer.source.expiring: a card, or a
+// source wrapping one
+type stripeCard struct {
+	Object   string `json:"object"`
+	Customer string `json:"customer"`
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+	Card     *struct {
+		Brand    string `json:"brand"`
+		Last4    string `json:"last4"`
+		ExpMonth int    `json:"exp_month"`
+		ExpYear  int    `json:"exp_year"`
+	} `json:"card"`
+}
+
+// unixTime converts a Stripe timestamp, where 0 means unset
+func unixTime(seconds int64) *time.Time {
+	if seconds == 0 {
+		return nil
+	}
+	t := time.Unix(seconds, 0).UTC()
+	return &t
+}
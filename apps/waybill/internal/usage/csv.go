@@ -0,0 +1,54 @@
+package usage
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvHeader names the columns of a CSV report
+var csvHeader = []string{
+	"period_start", "project_id", "project_name", "resource_type", "resource_id", "resource_name",
+	"compute_gb_hours", "build_minutes", "storage_gb_hours", "bandwidth_gb", "idle_saved_gb_hours",
+}
+
+// WriteCSV writes the rows of a report as CSV. Usage not attributed to a
+// resource has empty resource columns.
+func WriteCSV(w io.Writer, report *Report) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		var resourceID string
+		if row.ResourceID != nil {
+			resourceID = row.ResourceID.String()
+		}
+		err := out.Write([]string{
+			row.PeriodStart.UTC().Format(time.RFC3339),
+			row.ProjectID.String(),
+			row.ProjectName,
+			row.ResourceType,
+			resourceID,
+			row.ResourceName,
+			formatValue(row.ComputeGBHours),
+			formatValue(row.BuildMinutes),
+			formatValue(row.StorageGBHours),
+			formatValue(row.BandwidthGB),
+			formatValue(row.IdleSavedGBHours),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// formatValue formats a metric with the precision usage is stored at
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', 6, 64)
+}
@@ -0,0 +1,176 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+)
+
+// Store reads aggregated usage for reports
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates a new usage report store
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// rowKey identifies a row of a report; a nil resource is usage not
+// attributed to one
+type rowKey struct {
+	period     time.Time
+	projectID  uuid.UUID
+	resourceID uuid.UUID
+}
+
+// Report returns the usage of a team's projects grouped by period, project
+// and resource, or sql.ErrNoRows when the team does not exist
+func (s *Store) Report(ctx context.Context, q *Query) (*Report, error) {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM teams WHERE id = $1)`, q.TeamID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	rows := make(map[rowKey]*Row)
+	attributed := make(map[rowKey]map[events.MetricType]float64)
+
+	resourceQuery := `
+		SELECT date_trunc($4, r.hour AT TIME ZONE 'UTC'), r.project_id, p.name,
+		       r.resource_id, MAX(r.resource_type), COALESCE(MAX(r.resource_name), ''),
+		       r.metric_type, SUM(r.value)
+		FROM hourly_resource_usage r
+		JOIN projects p ON p.id = r.project_id
+		WHERE p.team_id = $1 AND r.hour >= $2 AND r.hour < $3
+		GROUP BY 1, r.project_id, p.name, r.resource_id, r.metric_type
+	`
+	err := s.query(ctx, resourceQuery, q, func(scan func(...any) error) error {
+		var row Row
+		var resourceID uuid.UUID
+		var metricType string
+		var value float64
+		if err := scan(&row.PeriodStart, &row.ProjectID, &row.ProjectName, &resourceID,
+			&row.ResourceType, &row.ResourceName, &metricType, &value); err != nil {
+			return fmt.Errorf("failed to scan resource usage: %w", err)
+		}
+		row.PeriodStart = row.PeriodStart.UTC()
+
+		key := rowKey{period: row.PeriodStart, projectID: row.ProjectID, resourceID: resourceID}
+		existing, ok := rows[key]
+		if !ok {
+			row.ResourceID = &resourceID
+			existing = &row
+			rows[key] = existing
+		}
+		existing.add(events.MetricType(metricType), value)
+
+		projectKey := rowKey{period: row.PeriodStart, projectID: row.ProjectID}
+		if attributed[projectKey] == nil {
+			attributed[projectKey] = make(map[events.MetricType]float64)
+		}
+		attributed[projectKey][events.MetricType(metricType)] += value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Hours aggregated before usage was tracked per resource only have
+	// project totals
+	totalQuery := `
+		SELECT date_trunc($4, u.hour AT TIME ZONE 'UTC'), u.project_id, p.name, u.metric_type, SUM(u.value)
+		FROM hourly_usage u
+		JOIN projects p ON p.id = u.project_id
+		WHERE p.team_id = $1 AND u.hour >= $2 AND u.hour < $3
+		GROUP BY 1, u.project_id, p.name, u.metric_type
+	`
+	err = s.query(ctx, totalQuery, q, func(scan func(...any) error) error {
+		var period time.Time
+		var projectID uuid.UUID
+		var projectName, metricType string
+		var value float64
+		if err := scan(&period, &projectID, &projectName, &metricType, &value); err != nil {
+			return fmt.Errorf("failed to scan usage: %w", err)
+		}
+		period = period.UTC()
+
+		key := rowKey{period: period, projectID: projectID}
+		remainder := value - attributed[key][events.MetricType(metricType)]
+		if remainder <= 1e-9 {
+			return nil
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{PeriodStart: period, ProjectID: projectID, ProjectName: projectName}
+			rows[key] = row
+		}
+		row.add(events.MetricType(metricType), remainder)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		TeamID:      q.TeamID,
+		From:        q.From,
+		To:          q.To,
+		Granularity: q.Granularity,
+		Rows:        make([]*Row, 0, len(rows)),
+	}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, row)
+		report.Totals.ComputeGBHours += row.ComputeGBHours
+		report.Totals.BuildMinutes += row.BuildMinutes
+		report.Totals.StorageGBHours += row.StorageGBHours
+		report.Totals.BandwidthGB += row.BandwidthGB
+		report.Totals.IdleSavedGBHours += row.IdleSavedGBHours
+	}
+
+	// Unattributed usage sorts after the resources of its project
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if !a.PeriodStart.Equal(b.PeriodStart) {
+			return a.PeriodStart.Before(b.PeriodStart)
+		}
+		if a.ProjectName != b.ProjectName {
+			return a.ProjectName < b.ProjectName
+		}
+		if a.ProjectID != b.ProjectID {
+			return a.ProjectID.String() < b.ProjectID.String()
+		}
+		if (a.ResourceID == nil) != (b.ResourceID == nil) {
+			return b.ResourceID == nil
+		}
+		if a.ResourceName != b.ResourceName {
+			return a.ResourceName < b.ResourceName
+		}
+		return a.ResourceID != nil && a.ResourceID.String() < b.ResourceID.String()
+	})
+	return report, nil
+}
+
+// query runs a report query over the range and granularity of q, calling
+// each for every row
+func (s *Store) query(ctx context.Context, query string, q *Query, each func(scan func(...any) error) error) error {
+	rows, err := s.db.QueryContext(ctx, query, q.TeamID, q.From, q.To, string(q.Granularity))
+	if err != nil {
+		return fmt.Errorf("failed to query usage: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := each(rows.Scan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,102 @@
+package usage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/apps/waybill/internal/events"
+)
+
+// Granularity is the length of the periods usage is grouped into
+type Granularity string
+
+const (
+	GranularityHour Granularity = "hour"
+	GranularityDay  Granularity = "day"
+)
+
+// maxRange bounds the rows a report can hold
+var maxRange = map[Granularity]time.Duration{
+	GranularityHour: 31 * 24 * time.Hour,
+	GranularityDay:  366 * 24 * time.Hour,
+}
+
+// ErrRangeTooLong is returned for reports spanning more than their
+// granularity allows
+var ErrRangeTooLong = errors.New("range too long: at most 31 days by hour or 366 days by day")
+
+// Query selects the usage of a team's projects over [From, To)
+type Query struct {
+	TeamID      uuid.UUID
+	From        time.Time
+	To          time.Time
+	Granularity Granularity
+}
+
+// Validate checks the range and granularity of a query
+func (q *Query) Validate() error {
+	limit, ok := maxRange[q.Granularity]
+	if !ok {
+		return errors.New("granularity must be hour or day")
+	}
+	if !q.To.After(q.From) {
+		return errors.New("to must be after from")
+	}
+	if q.To.Sub(q.From) > limit {
+		return ErrRangeTooLong
+	}
+	return nil
+}
+
+// Row is the usage of one service or other resource of a project during one
+// period. Usage aggregated before per-resource tracking existed has no
+// resource.
+type Row struct {
+	PeriodStart      time.Time  `json:"period_start"`
+	ProjectID        uuid.UUID  `json:"project_id"`
+	ProjectName      string     `json:"project_name"`
+	ResourceType     string     `json:"resource_type,omitempty"`
+	ResourceID       *uuid.UUID `json:"resource_id,omitempty"`
+	ResourceName     string     `json:"resource_name,omitempty"`
+	ComputeGBHours   float64    `json:"compute_gb_hours"`
+	BuildMinutes     float64    `json:"build_minutes"`
+	StorageGBHours   float64    `json:"storage_gb_hours"`
+	BandwidthGB      float64    `json:"bandwidth_gb"`
+	IdleSavedGBHours float64    `json:"idle_saved_gb_hours"` // Compute not used while scaled to zero
+}
+
+// add adds a quantity of a metric to the row
+func (r *Row) add(metricType events.MetricType, value float64) {
+	switch metricType {
+	case events.MetricComputeGBHours:
+		r.ComputeGBHours += value
+	case events.MetricBuildMinutes:
+		r.BuildMinutes += value
+	case events.MetricStorageGBHours:
+		r.StorageGBHours += value
+	case events.MetricBandwidthGB:
+		r.BandwidthGB += value
+	case events.MetricIdleSavedGBHours:
+		r.IdleSavedGBHours += value
+	}
+}
+
+// Totals sums the metrics of a report
+type Totals struct {
+	ComputeGBHours   float64 `json:"compute_gb_hours"`
+	BuildMinutes     float64 `json:"build_minutes"`
+	StorageGBHours   float64 `json:"storage_gb_hours"`
+	BandwidthGB      float64 `json:"bandwidth_gb"`
+	IdleSavedGBHours float64 `json:"idle_saved_gb_hours"`
+}
+
+// Report is the usage of a team grouped by period, project and resource
+type Report struct {
+	TeamID      uuid.UUID   `json:"team_id"`
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	Granularity Granularity `json:"granularity"`
+	Rows        []*Row      `json:"rows"`
+	Totals      Totals      `json:"totals"`
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/sirupsen/logrus"
@@ -26,6 +27,11 @@ func main() {
 	rootCmd := cmd.NewRootCommand(cfg)
 
 	if err := rootCmd.Execute(); err != nil {
+		// enclii run exits with the code of the remote command
+		var exitErr *cmd.ExitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -8,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -49,6 +51,10 @@ func (e APIError) Error() string {
 
 // HTTP helper methods
 func (c *APIClient) makeRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return c.makeRequestWithContentType(ctx, method, path, "application/json", body)
+}
+
+func (c *APIClient) makeRequestWithContentType(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Response, error) {
 	url := c.baseURL + path
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
@@ -56,7 +62,7 @@ func (c *APIClient) makeRequest(ctx context.Context, method, path string, body i
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", c.userAgent)
 
 	if c.token != "" {
@@ -100,6 +106,36 @@ func (c *APIClient) post(ctx context.Context, path string, payload interface{},
 	return c.handleResponse(resp, result)
 }
 
+func (c *APIClient) put(ctx context.Context, path string, payload interface{}, result interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	resp, err := c.makeRequest(ctx, "PUT", path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return c.handleResponse(resp, result)
+}
+
+func (c *APIClient) patch(ctx context.Context, path string, payload interface{}, result interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	resp, err := c.makeRequest(ctx, "PATCH", path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return c.handleResponse(resp, result)
+}
+
 func (c *APIClient) handleResponse(resp *http.Response, result interface{}) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -108,13 +144,15 @@ func (c *APIClient) handleResponse(resp *http.Response, result interface{}) erro
 
 	if resp.StatusCode >= 400 {
 		var apiErr struct {
-			Error string `json:"error"`
+			Error    string   `json:"error"`
+			Problems []string `json:"problems"`
 		}
 
 		if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error != "" {
 			return APIError{
 				StatusCode: resp.StatusCode,
 				Message:    apiErr.Error,
+				Details:    strings.Join(apiErr.Problems, "; "),
 			}
 		}
 
@@ -194,16 +232,31 @@ func (c *APIClient) GetService(ctx context.Context, serviceID string) (*types.Se
 	return &service, nil
 }
 
+// ListServices returns every service of a project, following the pages of
+// the list
 func (c *APIClient) ListServices(ctx context.Context, projectSlug string) ([]*types.Service, error) {
-	var response struct {
-		Services []*types.Service `json:"services"`
-	}
+	var services []*types.Service
+	cursor := ""
+	for {
+		var response struct {
+			Services   []*types.Service `json:"services"`
+			NextCursor string           `json:"next_cursor"`
+		}
 
-	if err := c.get(ctx, fmt.Sprintf("/v1/projects/%s/services", projectSlug), &response); err != nil {
-		return nil, fmt.Errorf("failed to list services: %w", err)
-	}
+		path := fmt.Sprintf("/v1/projects/%s/services", projectSlug)
+		if cursor != "" {
+			path += "?cursor=" + url.QueryEscape(cursor)
+		}
+		if err := c.get(ctx, path, &response); err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
 
-	return response.Services, nil
+		services = append(services, response.Services...)
+		if response.NextCursor == "" {
+			return services, nil
+		}
+		cursor = response.NextCursor
+	}
 }
 
 // DeleteService deletes a service by ID
@@ -250,6 +303,102 @@ func (c *APIClient) BuildService(ctx context.Context, serviceID, gitSHA string)
 	return &release, nil
 }
 
+// BuildServiceFromContext builds an uploaded build context instead of a git commit
+func (c *APIClient) BuildServiceFromContext(ctx context.Context, serviceID, contextID string) (*types.Release, error) {
+	payload := map[string]string{
+		"context_id": contextID,
+	}
+
+	var release types.Release
+	if err := c.post(ctx, fmt.Sprintf("/v1/services/%s/build", serviceID), payload, &release); err != nil {
+		return nil, fmt.Errorf("failed to build service: %w", err)
+	}
+
+	return &release, nil
+}
+
+// BuildContext is a gzipped tarball of a local working directory uploaded for a build
+type BuildContext struct {
+	ID        string    `json:"id"`
+	Digest    string    `json:"digest"`
+	SizeBytes int64     `json:"size_bytes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UploadBuildContext uploads a gzipped tarball read from body. Uploads can
+// take longer than regular requests, so the client timeout does not apply.
+func (c *APIClient) UploadBuildContext(ctx context.Context, serviceID string, body io.Reader, size int64) (*BuildContext, error) {
+	url := fmt.Sprintf("%s/v1/services/%s/build-contexts", c.baseURL, serviceID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload build context: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var bc BuildContext
+	if err := c.handleResponse(resp, &bc); err != nil {
+		return nil, fmt.Errorf("failed to upload build context: %w", err)
+	}
+
+	return &bc, nil
+}
+
+// BuildArtifact is a file a build published to object storage
+type BuildArtifact struct {
+	ID          string    `json:"id"`
+	Path        string    `json:"path"`
+	SizeBytes   int64     `json:"size_bytes"`
+	DownloadURL string    `json:"download_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ListBuildArtifacts lists the artifacts a build published
+func (c *APIClient) ListBuildArtifacts(ctx context.Context, releaseID string) ([]BuildArtifact, error) {
+	var response struct {
+		Artifacts []BuildArtifact `json:"artifacts"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/v1/builds/%s/artifacts", releaseID), &response); err != nil {
+		return nil, fmt.Errorf("failed to list build artifacts: %w", err)
+	}
+
+	return response.Artifacts, nil
+}
+
+// DownloadBuildArtifact writes an artifact to w. The download URL is signed
+// for object storage, so it is fetched without the API token, and like
+// uploads without the client timeout.
+func (c *APIClient) DownloadBuildArtifact(ctx context.Context, artifact BuildArtifact, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", artifact.DownloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", artifact.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: HTTP %d", artifact.Path, resp.StatusCode)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download %s: %w", artifact.Path, err)
+	}
+	return nil
+}
+
 func (c *APIClient) DeployService(ctx context.Context, serviceID string, req DeployRequest) (*types.Deployment, error) {
 	var deployment types.Deployment
 	if err := c.post(ctx, fmt.Sprintf("/v1/services/%s/deploy", serviceID), req, &deployment); err != nil {
@@ -280,6 +429,27 @@ func (c *APIClient) ListReleases(ctx context.Context, serviceID string) ([]*type
 	return response.Releases, nil
 }
 
+// CreateImageReleaseRequest is the request body for releasing an existing
+// registry image without a build
+type CreateImageReleaseRequest struct {
+	ImageURI        string `json:"image_uri"`
+	Digest          string `json:"digest,omitempty"`
+	Version         string `json:"version,omitempty"`
+	VerifySignature bool   `json:"verify_signature,omitempty"`
+	ScanSeverity    string `json:"scan_severity,omitempty"`
+}
+
+// CreateImageRelease creates a release of an image already in a registry.
+// With checks requested it stays building until they pass.
+func (c *APIClient) CreateImageRelease(ctx context.Context, serviceID string, req CreateImageReleaseRequest) (*types.Release, error) {
+	var release types.Release
+	if err := c.post(ctx, fmt.Sprintf("/v1/services/%s/releases", serviceID), req, &release); err != nil {
+		return nil, fmt.Errorf("failed to create release: %w", err)
+	}
+
+	return &release, nil
+}
+
 // Deployments
 func (c *APIClient) GetLatestDeployment(ctx context.Context, serviceID string) (*DeploymentWithRelease, error) {
 	var response DeploymentWithRelease
@@ -299,6 +469,17 @@ func (c *APIClient) GetDeployment(ctx context.Context, deploymentID string) (*ty
 	return &deployment, nil
 }
 
+// GetDeploymentSoak returns the soak of a deployment. Deployments to
+// environments without a soak policy return a 404 APIError.
+func (c *APIClient) GetDeploymentSoak(ctx context.Context, deploymentID string) (*types.DeploymentSoak, error) {
+	var soak types.DeploymentSoak
+	if err := c.get(ctx, fmt.Sprintf("/v1/deployments/%s/soak", deploymentID), &soak); err != nil {
+		return nil, fmt.Errorf("failed to get deployment soak: %w", err)
+	}
+
+	return &soak, nil
+}
+
 func (c *APIClient) ListServiceDeployments(ctx context.Context, serviceID string) ([]*types.Deployment, error) {
 	var response struct {
 		Deployments []*types.Deployment `json:"deployments"`
@@ -369,6 +550,78 @@ func (c *APIClient) GetLogsRaw(ctx context.Context, deploymentID string, opts Lo
 	return response.Logs, nil
 }
 
+// SearchLogs searches a service's logs over a time range. Without Start,
+// the server searches the last hour.
+func (c *APIClient) SearchLogs(ctx context.Context, serviceID, envName string, opts LogSearchOptions) (*types.LogSearchResult, error) {
+	params := url.Values{}
+	if envName != "" {
+		params.Set("env", envName)
+	}
+	if opts.Query != "" {
+		params.Set("q", opts.Query)
+	}
+	if opts.Regexp != "" {
+		params.Set("regex", opts.Regexp)
+	}
+	if opts.Pod != "" {
+		params.Set("pod", opts.Pod)
+	}
+	if opts.Container != "" {
+		params.Set("container", opts.Container)
+	}
+	if opts.Stream != "" {
+		params.Set("stream", opts.Stream)
+	}
+	if opts.Start != nil {
+		params.Set("start", opts.Start.Format(time.RFC3339))
+	}
+	if opts.End != nil {
+		params.Set("end", opts.End.Format(time.RFC3339))
+	}
+	if opts.Limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", opts.Limit))
+	}
+	if opts.Forward {
+		params.Set("direction", "forward")
+	}
+
+	endpoint := fmt.Sprintf("/v1/services/%s/logs/search", serviceID)
+	if params.Encode() != "" {
+		endpoint += "?" + params.Encode()
+	}
+
+	var result types.LogSearchResult
+	if err := c.get(ctx, endpoint, &result); err != nil {
+		return nil, fmt.Errorf("failed to search logs: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetServiceMetrics gets the metric series of a service in an environment
+// over a window such as 1h or 7d
+func (c *APIClient) GetServiceMetrics(ctx context.Context, serviceID, envName, window string) (*types.ServiceMetricsReport, error) {
+	params := url.Values{}
+	if envName != "" {
+		params.Set("env", envName)
+	}
+	if window != "" {
+		params.Set("window", window)
+	}
+
+	endpoint := fmt.Sprintf("/v1/services/%s/metrics", serviceID)
+	if params.Encode() != "" {
+		endpoint += "?" + params.Encode()
+	}
+
+	var report types.ServiceMetricsReport
+	if err := c.get(ctx, endpoint, &report); err != nil {
+		return nil, fmt.Errorf("failed to get service metrics: %w", err)
+	}
+
+	return &report, nil
+}
+
 // Rollback
 func (c *APIClient) RollbackDeployment(ctx context.Context, deploymentID string, req RollbackRequest) error {
 	if err := c.post(ctx, fmt.Sprintf("/v1/deployments/%s/rollback", deploymentID), req, nil); err != nil {
@@ -378,6 +631,55 @@ func (c *APIClient) RollbackDeployment(ctx context.Context, deploymentID string,
 	return nil
 }
 
+// PromoteRelease deploys a release running in one environment to another.
+// from names the environment to promote from when the release runs in several.
+func (c *APIClient) PromoteRelease(ctx context.Context, releaseID, to, from string, req PromoteRequest) (*PromoteResponse, error) {
+	params := url.Values{"to": {to}}
+	if from != "" {
+		params.Set("from", from)
+	}
+
+	var response PromoteResponse
+	if err := c.post(ctx, fmt.Sprintf("/v1/releases/%s/promote?%s", releaseID, params.Encode()), req, &response); err != nil {
+		return nil, fmt.Errorf("failed to promote release: %w", err)
+	}
+
+	return &response, nil
+}
+
+// ListReleasePromotions returns the promotion chain of a release
+func (c *APIClient) ListReleasePromotions(ctx context.Context, releaseID string) ([]*types.ReleasePromotion, error) {
+	var response struct {
+		Promotions []*types.ReleasePromotion `json:"promotions"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/v1/releases/%s/promotions", releaseID), &response); err != nil {
+		return nil, fmt.Errorf("failed to list release promotions: %w", err)
+	}
+
+	return response.Promotions, nil
+}
+
+// Search finds the projects, services, deployments and domains matching a
+// query, best match first. kinds limits the results to some types of
+// resource; limit 0 takes the server's default.
+func (c *APIClient) Search(ctx context.Context, query string, kinds []string, limit int) (*types.SearchResponse, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	if len(kinds) > 0 {
+		params.Set("type", strings.Join(kinds, ","))
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	var response types.SearchResponse
+	if err := c.get(ctx, "/v1/search?"+params.Encode(), &response); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	return &response, nil
+}
+
 // Health check
 func (c *APIClient) Health(ctx context.Context) (*HealthResponse, error) {
 	var health HealthResponse
@@ -394,18 +696,44 @@ type DeployRequest struct {
 	EnvironmentName string            `json:"environment_name"` // e.g., "dev", "staging", "production"
 	Environment     map[string]string `json:"environment,omitempty"`
 	Replicas        int               `json:"replicas,omitempty"`
+	OverrideReason  string            `json:"override_reason,omitempty"` // Justifies overriding the deploy policy
 }
 
 type RollbackRequest struct {
 	ToRelease string `json:"to_release,omitempty"`
 }
 
+// PromoteRequest adjusts a promotion; all fields are optional
+type PromoteRequest struct {
+	Replicas       int    `json:"replicas,omitempty"`        // Defaults to the scale in the target environment
+	OverrideReason string `json:"override_reason,omitempty"` // Justifies overriding the deploy policy
+}
+
+// PromoteResponse is a promotion and the deployment it created
+type PromoteResponse struct {
+	Promotion  *types.ReleasePromotion `json:"promotion"`
+	Deployment *types.Deployment       `json:"deployment"`
+}
+
 type LogOptions struct {
 	Follow bool
 	Lines  int
 	Since  *time.Time
 }
 
+// LogSearchOptions filters a log search
+type LogSearchOptions struct {
+	Query     string // Case-insensitive substring
+	Regexp    string
+	Pod       string
+	Container string
+	Stream    string // stdout or stderr
+	Start     *time.Time
+	End       *time.Time
+	Limit     int
+	Forward   bool // Oldest first
+}
+
 type LogLine struct {
 	Timestamp time.Time `json:"timestamp"`
 	Pod       string    `json:"pod"`
@@ -562,6 +890,128 @@ func (c *APIClient) RevealEnvVar(ctx context.Context, serviceID, envVarID string
 	return response.Value, nil
 }
 
+// EnvImportResult is the outcome of an env var import
+type EnvImportResult struct {
+	Created     int      `json:"created"`
+	Updated     int      `json:"updated"`
+	Skipped     int      `json:"skipped"`
+	CreatedKeys []string `json:"created_keys"`
+	UpdatedKeys []string `json:"updated_keys"`
+	SkippedKeys []string `json:"skipped_keys"`
+}
+
+// ExportEnvVars returns the environment variables of a service as a dotenv
+// file. Secret values are only included with includeSecrets (audited).
+func (c *APIClient) ExportEnvVars(ctx context.Context, serviceID string, environmentID *string, includeSecrets bool) (string, error) {
+	query := url.Values{"format": {"dotenv"}}
+	if environmentID != nil && *environmentID != "" {
+		query.Set("environment_id", *environmentID)
+	}
+	if includeSecrets {
+		query.Set("include_secrets", "true")
+	}
+
+	resp, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/v1/services/%s/env/export?%s", serviceID, query.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return "", fmt.Errorf("failed to export env vars: %w", c.handleResponse(resp, nil))
+	}
+
+	return string(body), nil
+}
+
+// ImportEnvVars creates or updates the environment variables of a service
+// from a dotenv file. strategy is skip or overwrite for keys that exist.
+func (c *APIClient) ImportEnvVars(ctx context.Context, serviceID, dotenv string, environmentID *string, strategy string) (*EnvImportResult, error) {
+	query := url.Values{"strategy": {strategy}}
+	if environmentID != nil && *environmentID != "" {
+		query.Set("environment_id", *environmentID)
+	}
+
+	resp, err := c.makeRequestWithContentType(ctx, "POST", fmt.Sprintf("/v1/services/%s/env/import?%s", serviceID, query.Encode()), "text/plain", strings.NewReader(dotenv))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result EnvImportResult
+	if err := c.handleResponse(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to import env vars: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ListPreviewEnvVars returns the env var overrides of a service's previews
+func (c *APIClient) ListPreviewEnvVars(ctx context.Context, serviceID string) ([]EnvVarResponse, error) {
+	var response struct {
+		Variables []EnvVarResponse `json:"variables"`
+	}
+
+	if err := c.get(ctx, fmt.Sprintf("/v1/services/%s/preview-env", serviceID), &response); err != nil {
+		return nil, fmt.Errorf("failed to list preview env vars: %w", err)
+	}
+
+	return response.Variables, nil
+}
+
+// ReplacePreviewEnvVars replaces the env var overrides of a service's
+// previews. An empty list removes them.
+func (c *APIClient) ReplacePreviewEnvVars(ctx context.Context, serviceID string, vars []EnvVarRequest) ([]EnvVarResponse, error) {
+	if vars == nil {
+		vars = []EnvVarRequest{}
+	}
+	payload := map[string]interface{}{
+		"variables": vars,
+	}
+
+	var response struct {
+		Variables []EnvVarResponse `json:"variables"`
+	}
+
+	if err := c.put(ctx, fmt.Sprintf("/v1/services/%s/preview-env", serviceID), payload, &response); err != nil {
+		return nil, fmt.Errorf("failed to replace preview env vars: %w", err)
+	}
+
+	return response.Variables, nil
+}
+
+// ExportProjectSpec returns a project described as an enclii.yaml spec
+func (c *APIClient) ExportProjectSpec(ctx context.Context, projectSlug string) (*types.ProjectSpec, error) {
+	var spec types.ProjectSpec
+	if err := c.get(ctx, fmt.Sprintf("/v1/projects/%s/spec", projectSlug), &spec); err != nil {
+		return nil, fmt.Errorf("failed to export project spec: %w", err)
+	}
+
+	return &spec, nil
+}
+
+// ApplyProjectSpec brings a project to a spec, or reports what would change
+// on a dry run. With prune, environment variables and dependencies missing
+// from the spec are deleted.
+func (c *APIClient) ApplyProjectSpec(ctx context.Context, spec *types.ProjectSpec, dryRun, prune bool) (*types.ProjectSpecApplyResult, error) {
+	query := url.Values{}
+	query.Set("dry_run", strconv.FormatBool(dryRun))
+	query.Set("prune", strconv.FormatBool(prune))
+
+	var result types.ProjectSpecApplyResult
+	path := fmt.Sprintf("/v1/projects/%s/spec?%s", spec.Metadata.Name, query.Encode())
+	if err := c.post(ctx, path, spec, &result); err != nil {
+		return nil, fmt.Errorf("failed to apply project spec: %w", err)
+	}
+
+	return &result, nil
+}
+
 // ListServicesWithInfo returns all services for a project with basic info
 func (c *APIClient) ListServicesWithInfo(ctx context.Context, projectSlug string) ([]*ServiceInfo, error) {
 	services, err := c.ListServices(ctx, projectSlug)
@@ -694,11 +1144,68 @@ func (c *APIClient) VerifyCustomDomain(ctx context.Context, serviceID, domainID
 	return &response, nil
 }
 
+// Container Commands
+
+// ServiceCommands is the command a service runs and the commands it runs in
+// particular environments instead
+type ServiceCommands struct {
+	Command      *types.ContainerCommand            `json:"command"`
+	Environments []*types.ServiceEnvironmentCommand `json:"environments"`
+}
+
+// GetServiceCommands returns the commands of a service
+func (c *APIClient) GetServiceCommands(ctx context.Context, serviceID string) (*ServiceCommands, error) {
+	var commands ServiceCommands
+	if err := c.get(ctx, fmt.Sprintf("/v1/services/%s/commands", serviceID), &commands); err != nil {
+		return nil, fmt.Errorf("failed to get commands: %w", err)
+	}
+
+	return &commands, nil
+}
+
+// SetServiceCommand replaces the command of a service; an empty command
+// runs the image as built
+func (c *APIClient) SetServiceCommand(ctx context.Context, serviceID string, command *types.ContainerCommand) error {
+	payload := map[string]interface{}{"command": command}
+	if err := c.patch(ctx, fmt.Sprintf("/v1/services/%s", serviceID), payload, nil); err != nil {
+		return fmt.Errorf("failed to set command: %w", err)
+	}
+
+	return nil
+}
+
+// SetServiceEnvironmentCommand sets the command a service runs in one
+// environment instead of its own
+func (c *APIClient) SetServiceEnvironmentCommand(ctx context.Context, serviceID, envName string, command *types.ContainerCommand) error {
+	if err := c.put(ctx, fmt.Sprintf("/v1/services/%s/commands/%s", serviceID, envName), command, nil); err != nil {
+		return fmt.Errorf("failed to set command: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteServiceEnvironmentCommand removes the command a service runs in one
+// environment, so it runs its own there again
+func (c *APIClient) DeleteServiceEnvironmentCommand(ctx context.Context, serviceID, envName string) error {
+	resp, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/v1/services/%s/commands/%s", serviceID, envName), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := c.handleResponse(resp, nil); err != nil {
+		return fmt.Errorf("failed to remove command: %w", err)
+	}
+
+	return nil
+}
+
 // Log Streaming (WebSocket)
 
 // LogStreamMessage represents a log message from WebSocket streaming
 type LogStreamMessage struct {
 	Type      string    `json:"type"` // "log", "error", "info", "connected", "disconnected"
+	Service   string    `json:"service,omitempty"`
 	Pod       string    `json:"pod"`
 	Container string    `json:"container"`
 	Timestamp time.Time `json:"timestamp"`
@@ -722,6 +1229,56 @@ func (c *APIClient) StreamLogs(ctx context.Context, serviceID string, envName st
 		return nil, nil, fmt.Errorf("failed to build WebSocket URL: %w", err)
 	}
 
+	return c.dialLogStream(ctx, wsURL)
+}
+
+// StreamProjectLogs streams the logs of several services of a project over one
+// WebSocket connection. Each message names its service. An empty services
+// list streams every service of the project; a non-empty grep pattern keeps
+// only matching log lines.
+func (c *APIClient) StreamProjectLogs(ctx context.Context, projectSlug, envName string, services []string, grep string, opts StreamLogsOptions) (<-chan LogStreamMessage, <-chan error, error) {
+	params := url.Values{}
+	if envName != "" {
+		params.Set("env", envName)
+	}
+	if len(services) > 0 {
+		params.Set("services", strings.Join(services, ","))
+	}
+	if grep != "" {
+		params.Set("grep", grep)
+	}
+	if opts.Lines > 0 {
+		params.Set("lines", fmt.Sprintf("%d", opts.Lines))
+	}
+	if opts.Timestamps {
+		params.Set("timestamps", "true")
+	}
+	if opts.Since != nil {
+		params.Set("since", opts.Since.Format(time.RFC3339))
+	}
+
+	wsURL := c.wsBaseURL() + fmt.Sprintf("/v1/projects/%s/logs/stream", url.PathEscape(projectSlug))
+	if encoded := params.Encode(); encoded != "" {
+		wsURL += "?" + encoded
+	}
+
+	return c.dialLogStream(ctx, wsURL)
+}
+
+// wsBaseURL returns the API base URL with a WebSocket scheme
+func (c *APIClient) wsBaseURL() string {
+	baseURL := c.baseURL
+	if strings.HasPrefix(baseURL, "https://") {
+		baseURL = "wss://" + strings.TrimPrefix(baseURL, "https://")
+	} else if strings.HasPrefix(baseURL, "http://") {
+		baseURL = "ws://" + strings.TrimPrefix(baseURL, "http://")
+	}
+	return baseURL
+}
+
+// dialLogStream connects to a log streaming WebSocket and relays its messages
+// until the connection closes or ctx is cancelled
+func (c *APIClient) dialLogStream(ctx context.Context, wsURL string) (<-chan LogStreamMessage, <-chan error, error) {
 	// Set up WebSocket dialer with auth header
 	dialer := websocket.Dialer{
 		HandshakeTimeout: 10 * time.Second,
@@ -793,13 +1350,7 @@ func (c *APIClient) StreamLogs(ctx context.Context, serviceID string, envName st
 
 // buildWSURL constructs the WebSocket URL for log streaming
 func (c *APIClient) buildWSURL(serviceID, envName string, opts StreamLogsOptions) (string, error) {
-	// Parse base URL and convert to WebSocket scheme
-	baseURL := c.baseURL
-	if strings.HasPrefix(baseURL, "https://") {
-		baseURL = "wss://" + strings.TrimPrefix(baseURL, "https://")
-	} else if strings.HasPrefix(baseURL, "http://") {
-		baseURL = "ws://" + strings.TrimPrefix(baseURL, "http://")
-	}
+	baseURL := c.wsBaseURL()
 
 	// Build path with query parameters
 	path := fmt.Sprintf("/v1/services/%s/logs/stream", serviceID)
@@ -922,6 +1473,104 @@ func (c *APIClient) StreamDeploymentLogs(ctx context.Context, deploymentID strin
 	return logChan, errChan, nil
 }
 
+// ==================== Status & Events API ====================
+
+// GetProjectStatus returns a snapshot of a project's services, recent builds,
+// and build queue
+func (c *APIClient) GetProjectStatus(ctx context.Context, projectSlug string) (*types.ProjectStatus, error) {
+	var status types.ProjectStatus
+	if err := c.get(ctx, fmt.Sprintf("/v1/projects/%s/status", url.PathEscape(projectSlug)), &status); err != nil {
+		return nil, fmt.Errorf("failed to get project status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// StatusEvent is a deployment, build, or addon status change from the event stream
+type StatusEvent struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"` // e.g., "deployment.status", "build.status", "addon.status"
+	ResourceType string         `json:"resource_type"`
+	ResourceID   string         `json:"resource_id"`
+	ProjectID    string         `json:"project_id,omitempty"`
+	ServiceID    string         `json:"service_id,omitempty"`
+	Status       string         `json:"status"`
+	Message      string         `json:"message,omitempty"`
+	Data         map[string]any `json:"data,omitempty"`
+	Timestamp    time.Time      `json:"timestamp"`
+}
+
+// StreamEvents subscribes to the status change events of a project over
+// Server-Sent Events. The client timeout does not apply; the stream runs until
+// ctx is cancelled or the server closes it.
+func (c *APIClient) StreamEvents(ctx context.Context, projectID string) (<-chan StatusEvent, <-chan error, error) {
+	path := "/v1/events/stream"
+	if projectID != "" {
+		path += "?project_id=" + url.QueryEscape(projectID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to event stream: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, APIError{StatusCode: resp.StatusCode, Message: string(body)}
+	}
+
+	eventChan := make(chan StatusEvent, 100)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(eventChan)
+		defer close(errChan)
+		defer resp.Body.Close()
+
+		// Events are "event:" and "data:" lines terminated by a blank line;
+		// lines starting with ":" are heartbeats
+		scanner := bufio.NewScanner(resp.Body)
+		var eventName, data string
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if data != "" && eventName != "connected" {
+					var event StatusEvent
+					if err := json.Unmarshal([]byte(data), &event); err == nil {
+						select {
+						case eventChan <- event:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+				eventName, data = "", ""
+			case strings.HasPrefix(line, "event:"):
+				eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			}
+		}
+
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			errChan <- fmt.Errorf("event stream error: %w", err)
+		}
+	}()
+
+	return eventChan, errChan, nil
+}
+
 // ==================== Functions API ====================
 
 // FunctionInvokeResult represents the result of invoking a function
@@ -1032,3 +1681,147 @@ func (c *APIClient) GetFunctionLogs(ctx context.Context, nameOrID string, lines
 
 	return response.Logs, nil
 }
+
+// Port forwarding
+
+// PortForwardTarget is a service or addon resolved for port forwarding
+type PortForwardTarget struct {
+	Target      string `json:"target"`
+	Kind        string `json:"kind"`
+	Environment string `json:"environment"`
+	Port        int    `json:"port"`
+}
+
+// GetPortForwardTarget resolves what a port-forward would connect to,
+// without connecting. A zero port selects the target's default port.
+func (c *APIClient) GetPortForwardTarget(ctx context.Context, projectSlug, envName, target string, port int) (*PortForwardTarget, error) {
+	var resolved PortForwardTarget
+	if err := c.get(ctx, c.portForwardPath(projectSlug, envName, target, port), &resolved); err != nil {
+		return nil, fmt.Errorf("failed to resolve port-forward target: %w", err)
+	}
+	return &resolved, nil
+}
+
+// DialPortForward opens a tunnel to a port of a service or addon. Each
+// tunnel carries one TCP connection.
+func (c *APIClient) DialPortForward(ctx context.Context, projectSlug, envName, target string, port int) (io.ReadWriteCloser, error) {
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 10 * time.Second,
+	}
+
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	header.Set("User-Agent", c.userAgent)
+
+	conn, resp, err := dialer.DialContext(ctx, c.wsBaseURL()+c.portForwardPath(projectSlug, envName, target, port), header)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 {
+			body, _ := io.ReadAll(resp.Body)
+			return nil, fmt.Errorf("port-forward connection failed (%d): %s", resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("failed to connect port-forward: %w", err)
+	}
+
+	return &portForwardConn{conn: conn}, nil
+}
+
+func (c *APIClient) portForwardPath(projectSlug, envName, target string, port int) string {
+	params := url.Values{}
+	params.Set("target", target)
+	if envName != "" {
+		params.Set("env", envName)
+	}
+	if port > 0 {
+		params.Set("port", fmt.Sprintf("%d", port))
+	}
+	return fmt.Sprintf("/v1/projects/%s/port-forward?%s", url.PathEscape(projectSlug), params.Encode())
+}
+
+// portForwardConn reads and writes the binary messages of a port-forward
+// WebSocket as a byte stream
+type portForwardConn struct {
+	conn   *websocket.Conn
+	reader io.Reader
+}
+
+func (p *portForwardConn) Read(b []byte) (int, error) {
+	for {
+		if p.reader == nil {
+			messageType, reader, err := p.conn.NextReader()
+			if err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			if messageType != websocket.BinaryMessage {
+				continue
+			}
+			p.reader = reader
+		}
+
+		n, err := p.reader.Read(b)
+		if err == io.EOF {
+			p.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (p *portForwardConn) Write(b []byte) (int, error) {
+	if err := p.conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close ends the tunnel with a normal closure, so the server closes its
+// connection to the target
+func (p *portForwardConn) Close() error {
+	p.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+	return p.conn.Close()
+}
+
+// One-off jobs
+
+// RunJobRequest is the request body for running a one-off job
+type RunJobRequest struct {
+	Environment    string   `json:"environment"`
+	Command        []string `json:"command"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+}
+
+// RunJob runs a command once in the image and configuration a service is
+// deployed with in an environment
+func (c *APIClient) RunJob(ctx context.Context, serviceID string, req RunJobRequest) (*types.OneOffJob, error) {
+	var job types.OneOffJob
+	if err := c.post(ctx, fmt.Sprintf("/v1/services/%s/jobs", serviceID), req, &job); err != nil {
+		return nil, fmt.Errorf("failed to run job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// GetJob returns a one-off job, with its exit code once it finishes
+func (c *APIClient) GetJob(ctx context.Context, jobID string) (*types.OneOffJob, error) {
+	var job types.OneOffJob
+	if err := c.get(ctx, fmt.Sprintf("/v1/jobs/%s", jobID), &job); err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// StreamJobLogs streams the output of a one-off job. The stream closes when
+// the command exits.
+func (c *APIClient) StreamJobLogs(ctx context.Context, jobID string) (<-chan LogStreamMessage, <-chan error, error) {
+	return c.dialLogStream(ctx, c.wsBaseURL()+fmt.Sprintf("/v1/jobs/%s/logs/stream", jobID))
+}
@@ -1,15 +1,20 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -233,6 +238,41 @@ func TestAPIClient_DeployService(t *testing.T) {
 	assert.Equal(t, deployment.Replicas, result.Replicas)
 }
 
+func TestAPIClient_UploadBuildContext(t *testing.T) {
+	serviceID := uuid.New()
+	contextID := uuid.New()
+	payload := []byte{0x1f, 0x8b, 0x08, 0x00}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v1/services/"+serviceID.String()+"/build-contexts", r.URL.Path)
+		assert.Equal(t, "application/gzip", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		assert.Equal(t, int64(len(payload)), r.ContentLength)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, payload, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":         contextID.String(),
+			"digest":     "9f86d081884c7d659a2feaa0c55ad015",
+			"size_bytes": len(payload),
+		})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+
+	bc, err := client.UploadBuildContext(context.Background(), serviceID.String(), bytes.NewReader(payload), int64(len(payload)))
+
+	require.NoError(t, err)
+	assert.Equal(t, contextID.String(), bc.ID)
+	assert.Equal(t, int64(len(payload)), bc.SizeBytes)
+}
+
 func TestAPIClient_ErrorHandling(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -316,6 +356,275 @@ func TestAPIClient_Authentication(t *testing.T) {
 	assert.Equal(t, 401, apiErr.StatusCode)
 }
 
+func TestAPIClient_ReplacePreviewEnvVars(t *testing.T) {
+	serviceID := uuid.New()
+
+	tests := []struct {
+		name     string
+		vars     []EnvVarRequest
+		wantKeys []string
+	}{
+		{
+			name: "set overrides",
+			vars: []EnvVarRequest{
+				{Key: "PAYMENTS_URL", Value: "https://sandbox.payments.example.com"},
+				{Key: "PAYMENTS_KEY", Value: "sk_test", IsSecret: true},
+			},
+			wantKeys: []string{"PAYMENTS_URL", "PAYMENTS_KEY"},
+		},
+		{
+			name:     "clear overrides",
+			vars:     nil,
+			wantKeys: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "PUT", r.Method)
+				assert.Equal(t, "/v1/services/"+serviceID.String()+"/preview-env", r.URL.Path)
+
+				var req struct {
+					Variables []EnvVarRequest `json:"variables"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				require.NotNil(t, req.Variables, "variables must be sent as a list")
+
+				resp := make([]EnvVarResponse, len(req.Variables))
+				for i, v := range req.Variables {
+					resp[i] = EnvVarResponse{ServiceID: serviceID, Key: v.Key, Value: v.Value, IsSecret: v.IsSecret}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]interface{}{"variables": resp, "count": len(resp)})
+			}))
+			defer server.Close()
+
+			client := NewAPIClient(server.URL, "test-token")
+			vars, err := client.ReplacePreviewEnvVars(context.Background(), serviceID.String(), tt.vars)
+			require.NoError(t, err)
+
+			keys := make([]string, len(vars))
+			for i, v := range vars {
+				keys[i] = v.Key
+			}
+			assert.Equal(t, tt.wantKeys, keys)
+		})
+	}
+}
+
+func TestAPIClient_ApplyProjectSpec(t *testing.T) {
+	spec := &types.ProjectSpec{
+		APIVersion: "enclii.dev/v1",
+		Kind:       "Project",
+		Metadata:   types.ProjectSpecMetadata{Name: "shop"},
+		Spec: types.ProjectSpecConfig{
+			Services: []types.ProjectServiceSpec{{Name: "api", GitRepo: "https://github.com/acme/shop"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		dryRun     bool
+		prune      bool
+		status     int
+		response   interface{}
+		wantErr    string
+		wantResult *types.ProjectSpecApplyResult
+	}{
+		{
+			name:   "dry run",
+			dryRun: true,
+			status: http.StatusOK,
+			response: types.ProjectSpecApplyResult{
+				DryRun:  true,
+				Changes: []types.ProjectSpecChange{{Action: "create", Kind: "service", Name: "api"}},
+			},
+			wantResult: &types.ProjectSpecApplyResult{
+				DryRun:  true,
+				Changes: []types.ProjectSpecChange{{Action: "create", Kind: "service", Name: "api"}},
+			},
+		},
+		{
+			name:   "invalid spec",
+			prune:  true,
+			status: http.StatusBadRequest,
+			response: map[string]interface{}{
+				"error":    "Invalid project spec",
+				"problems": []string{"service api depends on db, which is neither in the spec nor in project shop"},
+			},
+			wantErr: "Invalid project spec (service api depends on db",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, "/v1/projects/shop/spec", r.URL.Path)
+				assert.Equal(t, strconv.FormatBool(tt.dryRun), r.URL.Query().Get("dry_run"))
+				assert.Equal(t, strconv.FormatBool(tt.prune), r.URL.Query().Get("prune"))
+
+				var req types.ProjectSpec
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, spec.Spec.Services, req.Spec.Services)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(tt.response)
+			}))
+			defer server.Close()
+
+			client := NewAPIClient(server.URL, "test-token")
+			result, err := client.ApplyProjectSpec(context.Background(), spec, tt.dryRun, tt.prune)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResult, result)
+		})
+	}
+}
+
+func TestAPIClient_StreamProjectLogs(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/projects/shop/logs/stream", r.URL.Path)
+		assert.Equal(t, "staging", r.URL.Query().Get("env"))
+		assert.Equal(t, "api,worker", r.URL.Query().Get("services"))
+		assert.Equal(t, "error|panic", r.URL.Query().Get("grep"))
+		assert.Equal(t, "50", r.URL.Query().Get("lines"))
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		conn.WriteJSON(LogStreamMessage{Type: "connected", Message: "Connected"})
+		conn.WriteJSON(LogStreamMessage{Type: "log", Service: "worker", Pod: "worker-1", Message: "panic: boom"})
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+	logChan, errChan, err := client.StreamProjectLogs(context.Background(), "shop", "staging",
+		[]string{"api", "worker"}, "error|panic", StreamLogsOptions{Lines: 50})
+	require.NoError(t, err)
+
+	var messages []LogStreamMessage
+	for msg := range logChan {
+		messages = append(messages, msg)
+	}
+	assert.NoError(t, <-errChan)
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, "worker", messages[1].Service)
+	assert.Equal(t, "panic: boom", messages[1].Message)
+}
+
+func TestAPIClient_SearchLogs(t *testing.T) {
+	serviceID := uuid.New()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/v1/services/"+serviceID.String()+"/logs/search", r.URL.Path)
+		query := r.URL.Query()
+		assert.Equal(t, "production", query.Get("env"))
+		assert.Equal(t, "timeout", query.Get("q"))
+		assert.Equal(t, "stderr", query.Get("stream"))
+		assert.Equal(t, start.Format(time.RFC3339), query.Get("start"))
+		assert.Equal(t, "200", query.Get("limit"))
+		assert.Equal(t, "forward", query.Get("direction"))
+		assert.Empty(t, query.Get("end"))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.LogSearchResult{
+			ServiceID:   serviceID,
+			ServiceName: "api",
+			Environment: "production",
+			Source:      "loki",
+			Entries: []types.LogEntry{
+				{Timestamp: start.Add(time.Minute), Pod: "api-1", Stream: "stderr", Line: "upstream timeout"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+	result, err := client.SearchLogs(context.Background(), serviceID.String(), "production", LogSearchOptions{
+		Query:   "timeout",
+		Stream:  "stderr",
+		Start:   &start,
+		Limit:   200,
+		Forward: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "loki", result.Source)
+	require.Len(t, result.Entries, 1)
+	assert.Equal(t, "upstream timeout", result.Entries[0].Line)
+}
+
+func TestAPIClient_GetServiceMetrics(t *testing.T) {
+	serviceID := uuid.New()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/v1/services/"+serviceID.String()+"/metrics", r.URL.Path)
+		assert.Equal(t, "production", r.URL.Query().Get("env"))
+		assert.Equal(t, "24h", r.URL.Query().Get("window"))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ServiceMetricsReport{
+			ServiceID: serviceID,
+			Source:    "prometheus",
+			Window:    "24h0m0s",
+			Series: []types.MetricSeries{
+				{Name: "cpu", Unit: "millicores", Points: []types.MetricPoint{{Timestamp: time.Now(), Value: 250}}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+	report, err := client.GetServiceMetrics(context.Background(), serviceID.String(), "production", "24h")
+	require.NoError(t, err)
+
+	require.Len(t, report.Series, 1)
+	assert.Equal(t, "cpu", report.Series[0].Name)
+	assert.Equal(t, 250.0, report.Series[0].Points[0].Value)
+}
+
+func TestAPIClient_StreamEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/events/stream", r.URL.Path)
+		assert.Equal(t, "proj-1", r.URL.Query().Get("project_id"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: connected\ndata: {\"timestamp\":\"2026-01-01T00:00:00Z\"}\n\n")
+		fmt.Fprint(w, ": heartbeat\n\n")
+		fmt.Fprint(w, "id: e1\nevent: build.status\ndata: {\"type\":\"build.status\",\"resource_type\":\"build\",\"status\":\"ready\"}\n\n")
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+	eventChan, errChan, err := client.StreamEvents(context.Background(), "proj-1")
+	require.NoError(t, err)
+
+	var events []StatusEvent
+	for event := range eventChan {
+		events = append(events, event)
+	}
+	assert.NoError(t, <-errChan)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "build", events[0].ResourceType)
+	assert.Equal(t, "ready", events[0].Status)
+}
+
 // Benchmark tests
 func BenchmarkAPIClient_GetProject(b *testing.B) {
 	projectID := uuid.New()
@@ -343,3 +652,140 @@ func BenchmarkAPIClient_GetProject(b *testing.B) {
 		}
 	}
 }
+
+func TestAPIClient_DialPortForward(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/projects/shop/port-forward", r.URL.Path)
+		assert.Equal(t, "db", r.URL.Query().Get("target"))
+		assert.Equal(t, "staging", r.URL.Query().Get("env"))
+		assert.Equal(t, "5432", r.URL.Query().Get("port"))
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// Echo binary messages until the client closes
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+	tunnel, err := client.DialPortForward(context.Background(), "shop", "staging", "db", 5432)
+	require.NoError(t, err)
+
+	_, err = tunnel.Write([]byte("hello"))
+	require.NoError(t, err)
+	_, err = tunnel.Write([]byte(" world"))
+	require.NoError(t, err)
+
+	received := make([]byte, len("hello world"))
+	_, err = io.ReadFull(tunnel, received)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(received))
+
+	assert.NoError(t, tunnel.Close())
+}
+
+func TestAPIClient_RunJob(t *testing.T) {
+	serviceID := uuid.New()
+	jobID := uuid.New()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v1/services/"+serviceID.String()+"/jobs", r.URL.Path)
+
+		var req RunJobRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "staging", req.Environment)
+		assert.Equal(t, []string{"rails", "db:migrate"}, req.Command)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(&types.OneOffJob{
+			ID:        jobID,
+			ServiceID: serviceID,
+			Command:   req.Command,
+			Status:    types.OneOffJobStatusPending,
+		})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+	job, err := client.RunJob(context.Background(), serviceID.String(), RunJobRequest{
+		Environment: "staging",
+		Command:     []string{"rails", "db:migrate"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, jobID, job.ID)
+	assert.Equal(t, types.OneOffJobStatusPending, job.Status)
+}
+
+func TestAPIClient_CreateImageRelease(t *testing.T) {
+	serviceID := uuid.New()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v1/services/"+serviceID.String()+"/releases", r.URL.Path)
+
+		var req CreateImageReleaseRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "ghcr.io/acme/api:v1.2.0", req.ImageURI)
+		assert.True(t, req.VerifySignature)
+		assert.Equal(t, "high", req.ScanSeverity)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(&types.Release{
+			ID:        uuid.New(),
+			ServiceID: serviceID,
+			ImageURI:  req.ImageURI,
+			Source:    types.ReleaseSourceImage,
+			Status:    types.ReleaseStatusBuilding,
+		})
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+	release, err := client.CreateImageRelease(context.Background(), serviceID.String(), CreateImageReleaseRequest{
+		ImageURI:        "ghcr.io/acme/api:v1.2.0",
+		VerifySignature: true,
+		ScanSeverity:    "high",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.ReleaseSourceImage, release.Source)
+	assert.Equal(t, types.ReleaseStatusBuilding, release.Status)
+}
+
+func TestAPIClient_StreamJobLogs(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/jobs/job-1/logs/stream", r.URL.Path)
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		conn.WriteJSON(LogStreamMessage{Type: "log", Message: "migrated"})
+		conn.WriteJSON(LogStreamMessage{Type: "disconnected", Message: "Job succeeded with exit code 0"})
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, "test-token")
+	logs, errs, err := client.StreamJobLogs(context.Background(), "job-1")
+	require.NoError(t, err)
+
+	var messages []string
+	for msg := range logs {
+		messages = append(messages, msg.Message)
+	}
+	assert.Equal(t, []string{"migrated", "Job succeeded with exit code 0"}, messages)
+	assert.NoError(t, <-errs)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/cli/internal/spec"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func NewApplyCommand(cfg *config.Config) *cobra.Command {
+	var specFile string
+	var dryRun bool
+	var prune bool
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply a project spec (enclii.yaml) to its project",
+		Long: `Brings a project to the services, environment variables, domains,
+dependencies and addons described in a project spec. Applying the same spec
+again makes no changes, so it can run from a GitOps pipeline on every push.
+
+Secrets may be listed without a value to keep the value already set.
+Services, domains and addons are never deleted by apply; with --prune,
+environment variables and dependencies missing from the spec are.
+
+Export the current spec of a project with:
+  curl -H "Authorization: Bearer $ENCLII_API_TOKEN" "$ENCLII_API_ENDPOINT/v1/projects/<slug>/spec?format=yaml"
+
+Examples:
+  # Show what would change
+  enclii apply -f enclii.yaml --dry-run
+
+  # Apply the spec
+  enclii apply -f enclii.yaml
+
+  # Also delete variables and dependencies the spec does not list
+  enclii apply -f enclii.yaml --prune`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runApply(cfg, specFile, dryRun, prune)
+		},
+	}
+
+	cmd.Flags().StringVarP(&specFile, "file", "f", "enclii.yaml", "Path to the project spec file")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without making changes")
+	cmd.Flags().BoolVar(&prune, "prune", false, "Delete environment variables and dependencies missing from the spec")
+
+	return cmd
+}
+
+func runApply(cfg *config.Config, specFile string, dryRun, prune bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	projectSpec, err := spec.NewParser().ParseProjectSpec(specFile)
+	if err != nil {
+		return err
+	}
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	if dryRun {
+		fmt.Printf("Planning changes to project '%s' (dry run)...\n\n", projectSpec.Metadata.Name)
+	} else {
+		fmt.Printf("Applying %s to project '%s'...\n\n", specFile, projectSpec.Metadata.Name)
+	}
+
+	result, err := apiClient.ApplyProjectSpec(ctx, projectSpec, dryRun, prune)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Changes) == 0 {
+		fmt.Println("No changes. The project matches the spec.")
+	}
+	for _, change := range result.Changes {
+		fmt.Println(formatSpecChange(change))
+	}
+
+	if len(result.Warnings) > 0 {
+		fmt.Println()
+		for _, warning := range result.Warnings {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+	}
+
+	if len(result.Changes) > 0 {
+		fmt.Println()
+		if dryRun {
+			fmt.Printf("%d change(s) would be made. Run without --dry-run to apply them.\n", len(result.Changes))
+		} else {
+			fmt.Printf("Applied %d change(s)\n", len(result.Changes))
+		}
+	}
+
+	return nil
+}
+
+// formatSpecChange renders a change as a line of a plan, e.g.
+// "  ~ env_var api/LOG_LEVEL (staging): value"
+func formatSpecChange(change types.ProjectSpecChange) string {
+	symbol := "~"
+	switch change.Action {
+	case "create":
+		symbol = "+"
+	case "delete":
+		symbol = "-"
+	}
+
+	name := change.Name
+	if change.Service != "" {
+		name = change.Service + "/" + name
+	}
+	line := fmt.Sprintf("  %s %s %s", symbol, change.Kind, name)
+	if change.Environment != "" {
+		line += fmt.Sprintf(" (%s)", change.Environment)
+	}
+	if len(change.Fields) > 0 {
+		line += ": " + strings.Join(change.Fields, ", ")
+	}
+	return line
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// NewCommandCommand creates the command that manages what a service's
+// container runs
+func NewCommandCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "command",
+		Short: "Manage the command, args and working directory of services",
+		Long: `Manage what a service's container runs.
+
+A command overrides the image's ENTRYPOINT, its args override CMD and its
+working directory overrides WORKDIR, so one image can back several services
+(e.g., web, worker and scheduler). A command set for one environment replaces
+the service's command there. Changes apply on the next deploy.
+
+Examples:
+  # Show a service's command and its per-environment commands
+  enclii command show --service worker
+
+  # Run a worker from the same image as the web service
+  enclii command set --service worker -- bundle exec sidekiq -q default
+
+  # Override the ENTRYPOINT and working directory too
+  enclii command set --service scheduler --entrypoint /usr/bin/tini --workdir /app -- ./bin/scheduler
+
+  # Run a different queue in staging
+  enclii command set --service worker --env staging -- bundle exec sidekiq -q staging
+
+  # Go back to the service's command in staging, or to the image's own
+  enclii command unset --service worker --env staging
+  enclii command unset --service worker`,
+	}
+
+	cmd.AddCommand(newCommandShowCommand(cfg))
+	cmd.AddCommand(newCommandSetCommand(cfg))
+	cmd.AddCommand(newCommandUnsetCommand(cfg))
+
+	return cmd
+}
+
+// newCommandShowCommand creates the 'command show' subcommand
+func newCommandShowCommand(cfg *config.Config) *cobra.Command {
+	var serviceName string
+	var specFile string
+
+	cmd := &cobra.Command{
+		Use:     "show",
+		Aliases: []string{"get", "ls"},
+		Short:   "Show the commands of a service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCommandShow(cfg, serviceName, specFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&serviceName, "service", "s", "", "Service name (uses service.yaml if not specified)")
+	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml")
+
+	return cmd
+}
+
+// newCommandSetCommand creates the 'command set' subcommand
+func newCommandSetCommand(cfg *config.Config) *cobra.Command {
+	var serviceName string
+	var envName string
+	var specFile string
+	var entrypoint []string
+	var workingDir string
+
+	cmd := &cobra.Command{
+		Use:   "set [flags] -- ARGS...",
+		Short: "Set the command of a service",
+		Long: `Set the command of a service, or of a service in one environment.
+
+The arguments after -- replace the image's CMD. Each --entrypoint flag adds
+an entry of the ENTRYPOINT replacement. The command replaces the one set
+before as a whole.
+
+Examples:
+  enclii command set --service worker -- bundle exec sidekiq
+  enclii command set --service worker --env staging --workdir /srv -- ./worker --verbose`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			command := &types.ContainerCommand{Command: entrypoint, Args: args, WorkingDir: workingDir}
+			if command.IsEmpty() {
+				return fmt.Errorf("nothing to set: pass args after --, --entrypoint or --workdir (use 'enclii command unset' to remove a command)")
+			}
+			return runCommandSet(cfg, serviceName, envName, specFile, command)
+		},
+	}
+
+	cmd.Flags().StringVarP(&serviceName, "service", "s", "", "Service name (uses service.yaml if not specified)")
+	cmd.Flags().StringVarP(&envName, "env", "e", "", "Environment to set the command in (default: all environments)")
+	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml")
+	cmd.Flags().StringArrayVar(&entrypoint, "entrypoint", nil, "Entry of the ENTRYPOINT replacement (repeatable)")
+	cmd.Flags().StringVarP(&workingDir, "workdir", "w", "", "Working directory (absolute path)")
+
+	return cmd
+}
+
+// newCommandUnsetCommand creates the 'command unset' subcommand
+func newCommandUnsetCommand(cfg *config.Config) *cobra.Command {
+	var serviceName string
+	var envName string
+	var specFile string
+
+	cmd := &cobra.Command{
+		Use:     "unset",
+		Aliases: []string{"rm", "remove"},
+		Short:   "Remove the command of a service",
+		Long: `Remove the command of a service, so it runs the image as built, or of a
+service in one environment, so it runs the service's command there.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCommandUnset(cfg, serviceName, envName, specFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&serviceName, "service", "s", "", "Service name (uses service.yaml if not specified)")
+	cmd.Flags().StringVarP(&envName, "env", "e", "", "Environment to remove the command of")
+	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml")
+
+	return cmd
+}
+
+// runCommandShow implements the command show command
+func runCommandShow(cfg *config.Config, serviceName, specFile string) error {
+	ctx := context.Background()
+
+	service, _, err := resolveService(ctx, cfg, serviceName, specFile)
+	if err != nil {
+		return err
+	}
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+	commands, err := apiClient.GetServiceCommands(ctx, service.ID.String())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENVIRONMENT\tENTRYPOINT\tARGS\tWORKDIR")
+	fmt.Fprintf(w, "(all)\t%s\n", formatContainerCommand(commands.Command))
+	for _, override := range commands.Environments {
+		fmt.Fprintf(w, "%s\t%s\n", override.Environment, formatContainerCommand(&override.Command))
+	}
+	w.Flush()
+
+	return nil
+}
+
+// runCommandSet implements the command set command
+func runCommandSet(cfg *config.Config, serviceName, envName, specFile string, command *types.ContainerCommand) error {
+	ctx := context.Background()
+
+	service, _, err := resolveService(ctx, cfg, serviceName, specFile)
+	if err != nil {
+		return err
+	}
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+	if envName == "" {
+		err = apiClient.SetServiceCommand(ctx, service.ID.String(), command)
+	} else {
+		err = apiClient.SetServiceEnvironmentCommand(ctx, service.ID.String(), envName, command)
+	}
+	if err != nil {
+		return err
+	}
+
+	if envName == "" {
+		fmt.Printf("✅ Command of %s set\n", service.Name)
+	} else {
+		fmt.Printf("✅ Command of %s in %s set\n", service.Name, envName)
+	}
+	fmt.Println("💡 It applies on the next deploy.")
+
+	return nil
+}
+
+// runCommandUnset implements the command unset command
+func runCommandUnset(cfg *config.Config, serviceName, envName, specFile string) error {
+	ctx := context.Background()
+
+	service, _, err := resolveService(ctx, cfg, serviceName, specFile)
+	if err != nil {
+		return err
+	}
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+	if envName == "" {
+		if err := apiClient.SetServiceCommand(ctx, service.ID.String(), &types.ContainerCommand{}); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s runs its image's own command\n", service.Name)
+	} else {
+		if err := apiClient.DeleteServiceEnvironmentCommand(ctx, service.ID.String(), envName); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s runs its own command in %s\n", service.Name, envName)
+	}
+	fmt.Println("💡 It applies on the next deploy.")
+
+	return nil
+}
+
+// formatContainerCommand formats a command as table columns, with "-" for
+// what the image decides
+func formatContainerCommand(c *types.ContainerCommand) string {
+	if c == nil {
+		c = &types.ContainerCommand{}
+	}
+	workingDir := c.WorkingDir
+	if workingDir == "" {
+		workingDir = "-"
+	}
+	return fmt.Sprintf("%s\t%s\t%s", formatArgv(c.Command), formatArgv(c.Args), workingDir)
+}
+
+// formatArgv quotes the entries of a command that a shell would split
+func formatArgv(argv []string) string {
+	if len(argv) == 0 {
+		return "-"
+	}
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\$") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+)
+
+const (
+	// completionCacheTTL is how old cached names may get before completion
+	// refreshes them in the background
+	completionCacheTTL = 5 * time.Minute
+
+	// completionFetchTimeout bounds the one foreground fetch made when
+	// nothing is cached yet, so a slow API never stalls the shell for long
+	completionFetchTimeout = 2 * time.Second
+
+	// completionRefreshTimeout bounds a background refresh
+	completionRefreshTimeout = 30 * time.Second
+
+	// completionCacheRefreshCommand is the hidden command that refreshes the
+	// cache in a detached process
+	completionCacheRefreshCommand = "__refresh-completion-cache"
+
+	// projectArgAnnotation marks commands whose first argument is a project
+	projectArgAnnotation = "enclii/project-arg"
+)
+
+// completionFunc completes positional arguments or flag values
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeProjects completes a single project slug argument
+func completeProjects(cfg *config.Config) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return cachedNames(cmd, cfg, config.CompletionProjects, ""), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeServices completes service names of the command's project. With
+// multiple, services already given are offered no more.
+func completeServices(cfg *config.Config, multiple bool) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if !multiple && len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		names := cachedNames(cmd, cfg, config.CompletionServices, completionProject(cmd, args, cfg))
+		return excludeNames(names, args), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeEnvironments completes environment names of the command's project
+func completeEnvironments(cfg *config.Config) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return cachedNames(cmd, cfg, config.CompletionEnvironments, completionProject(cmd, args, cfg)), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// registerEnvironmentCompletion completes the --env flag of every command
+// that has one and does not complete it itself
+func registerEnvironmentCompletion(cmd *cobra.Command, cfg *config.Config) {
+	if cmd.LocalFlags().Lookup("env") != nil {
+		if _, ok := cmd.GetFlagCompletionFunc("env"); !ok {
+			_ = cmd.RegisterFlagCompletionFunc("env", completeEnvironments(cfg))
+		}
+	}
+	for _, child := range cmd.Commands() {
+		registerEnvironmentCompletion(child, cfg)
+	}
+}
+
+// completionProject returns the project a command being completed works on
+// the way the command resolves it: its project argument, the project of its
+// service.yaml, or the configured project
+func completionProject(cmd *cobra.Command, args []string, cfg *config.Config) string {
+	if cmd.Annotations[projectArgAnnotation] == "true" && len(args) > 0 {
+		return args[0]
+	}
+	if flag := cmd.Flags().Lookup("file"); flag != nil {
+		return resolveProjectSlug(flag.Value.String(), cfg)
+	}
+	if cfg.Project != "" {
+		return cfg.Project
+	}
+	return "default"
+}
+
+// cachedNames returns the cached names of a kind of resource, starting a
+// background refresh when they are stale. Only when nothing is cached yet
+// are they fetched right away.
+func cachedNames(cmd *cobra.Command, cfg *config.Config, kind, project string) []string {
+	if err := applyGlobalFlags(cmd, cfg); err != nil {
+		return nil
+	}
+
+	path := config.GetCompletionCachePath(cfg)
+	cache := config.LoadCompletionCache(path)
+	now := time.Now()
+
+	list, ok := cache.Get(cfg.APIEndpoint, kind, project)
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), completionFetchTimeout)
+		defer cancel()
+
+		names, err := fetchCompletionNames(ctx, cfg, kind, project)
+		if err != nil {
+			cache.MarkAttempted(cfg.APIEndpoint, kind, project, now)
+			_ = cache.Save(path)
+			return nil
+		}
+		cache.Set(cfg.APIEndpoint, kind, project, names, now)
+		_ = cache.Save(path)
+		return names
+	}
+
+	if list.Stale(now, completionCacheTTL) {
+		// Marked first so repeated completions start a single refresh
+		cache.MarkAttempted(cfg.APIEndpoint, kind, project, now)
+		if cache.Save(path) == nil {
+			startCompletionCacheRefresh(cfg, kind, project)
+		}
+	}
+	return list.Names
+}
+
+// startCompletionCacheRefresh refreshes a cached list in a detached process,
+// so the completion answers without waiting for the API
+func startCompletionCacheRefresh(cfg *config.Config, kind, project string) {
+	executable, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	args := []string{completionCacheRefreshCommand, kind}
+	if project != "" {
+		args = append(args, project)
+	}
+
+	// The endpoint and token resolved from flags and contexts are handed
+	// over through the environment, keeping the token off the command line
+	refresh := exec.Command(executable, args...)
+	refresh.Env = append(os.Environ(), "ENCLII_API_ENDPOINT="+cfg.APIEndpoint)
+	if cfg.APIToken != "" {
+		refresh.Env = append(refresh.Env, "ENCLII_API_TOKEN="+cfg.APIToken)
+	}
+
+	if err := refresh.Start(); err != nil {
+		return
+	}
+	_ = refresh.Process.Release()
+}
+
+// newCompletionCacheRefreshCommand refreshes one list of the completion
+// cache. It is started by completions and not meant to be run by hand.
+func newCompletionCacheRefreshCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:           completionCacheRefreshCommand + " KIND [PROJECT]",
+		Hidden:        true,
+		Args:          cobra.RangeArgs(1, 2),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kind := args[0]
+			var project string
+			if len(args) > 1 {
+				project = args[1]
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), completionRefreshTimeout)
+			defer cancel()
+
+			names, err := fetchCompletionNames(ctx, cfg, kind, project)
+			if err != nil {
+				return err
+			}
+
+			// Reloaded after the fetch so concurrent refreshes of other lists
+			// are not overwritten
+			path := config.GetCompletionCachePath(cfg)
+			cache := config.LoadCompletionCache(path)
+			cache.Set(cfg.APIEndpoint, kind, project, names, time.Now())
+			return cache.Save(path)
+		},
+	}
+}
+
+// fetchCompletionNames fetches the names of a kind of resource from the API
+func fetchCompletionNames(ctx context.Context, cfg *config.Config, kind, project string) ([]string, error) {
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	var names []string
+	switch kind {
+	case config.CompletionProjects:
+		projects, err := apiClient.ListProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			names = append(names, p.Slug)
+		}
+	case config.CompletionServices:
+		services, err := apiClient.ListServices(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range services {
+			names = append(names, s.Name)
+		}
+	case config.CompletionEnvironments:
+		environments, err := apiClient.ListEnvironments(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range environments {
+			names = append(names, e.Name)
+		}
+	default:
+		return nil, fmt.Errorf("unknown completion kind %q", kind)
+	}
+	return names, nil
+}
+
+// excludeNames drops the names already given as arguments
+func excludeNames(names, given []string) []string {
+	if len(given) == 0 {
+		return names
+	}
+	var remaining []string
+	for _, name := range names {
+		if !slices.Contains(given, name) {
+			remaining = append(remaining, name)
+		}
+	}
+	return remaining
+}
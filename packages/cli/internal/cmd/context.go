@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+)
+
+// NewContextCommand creates the context management command with subcommands
+func NewContextCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "context",
+		Aliases: []string{"ctx"},
+		Short:   "Manage named contexts for installations and teams",
+		Long: `Manage named contexts stored in the CLI config file.
+
+A context bundles an API endpoint, token, and default team, project, and
+environment, so you can switch between Enclii installations and teams
+without re-typing flags.
+
+Examples:
+  # Create or update a context
+  enclii context set staging --context-api-endpoint https://api.staging.example.com --project web --env staging
+
+  # List contexts
+  enclii context list
+
+  # Switch the current context
+  enclii context use staging
+
+  # Run a single command against another context
+  enclii ps --context production`,
+	}
+
+	cmd.AddCommand(newContextListCommand(cfg))
+	cmd.AddCommand(newContextUseCommand(cfg))
+	cmd.AddCommand(newContextShowCommand(cfg))
+	cmd.AddCommand(newContextSetCommand(cfg))
+	cmd.AddCommand(newContextDeleteCommand(cfg))
+
+	return cmd
+}
+
+// newContextListCommand creates the 'context list' subcommand
+func newContextListCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all contexts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runContextList(cfg)
+		},
+	}
+}
+
+// newContextUseCommand creates the 'context use' subcommand
+func newContextUseCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use NAME",
+		Short: "Set the current context",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runContextUse(cfg, args[0])
+		},
+	}
+}
+
+// newContextShowCommand creates the 'context show' subcommand
+func newContextShowCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [NAME]",
+		Short: "Show a context (default: the active context)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := cfg.Context
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return runContextShow(cfg, name)
+		},
+	}
+}
+
+// newContextSetCommand creates the 'context set' subcommand
+func newContextSetCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set NAME",
+		Short: "Create or update a context",
+		Long: `Create a context, or update the given fields of an existing one.
+
+Examples:
+  enclii context set prod --context-api-endpoint https://api.enclii.dev --team platform --project web --env production
+  enclii context set prod --env staging`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runContextSet(cmd, cfg, args[0])
+		},
+	}
+
+	// Named apart from the global --api-endpoint/--api-token flags, which
+	// configure the current command rather than the stored context
+	cmd.Flags().String("context-api-endpoint", "", "API endpoint URL for this context")
+	cmd.Flags().String("context-api-token", "", "API token for this context (default: use 'enclii login' credentials)")
+	cmd.Flags().String("team", "", "Default team")
+	cmd.Flags().StringP("project", "p", "", "Default project slug")
+	cmd.Flags().StringP("env", "e", "", "Default environment")
+
+	return cmd
+}
+
+// newContextDeleteCommand creates the 'context delete' subcommand
+func newContextDeleteCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete NAME",
+		Aliases: []string{"rm"},
+		Short:   "Delete a context",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runContextDelete(cfg, args[0])
+		},
+	}
+}
+
+func runContextList(cfg *config.Config) error {
+	file, err := config.LoadContexts(cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if len(file.Contexts) == 0 {
+		fmt.Println("No contexts configured")
+		fmt.Println("💡 Create one with: enclii context set NAME --context-api-endpoint URL")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CURRENT\tNAME\tAPI ENDPOINT\tTEAM\tPROJECT\tENVIRONMENT")
+	for _, ctx := range file.Contexts {
+		current := ""
+		if ctx.Name == cfg.Context {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			current, ctx.Name, orDash(ctx.APIEndpoint), orDash(ctx.Team), orDash(ctx.Project), orDash(ctx.Environment))
+	}
+	return w.Flush()
+}
+
+func runContextUse(cfg *config.Config, name string) error {
+	file, err := config.LoadContexts(cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := file.Get(name); !ok {
+		return fmt.Errorf("context %q not found (see 'enclii context list')", name)
+	}
+
+	file.CurrentContext = name
+	if err := file.Save(cfg.ConfigFile); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Switched to context %q\n", name)
+	return nil
+}
+
+func runContextShow(cfg *config.Config, name string) error {
+	if name == "" {
+		fmt.Println("No context selected")
+		fmt.Printf("API endpoint: %s\n", cfg.APIEndpoint)
+		return nil
+	}
+
+	file, err := config.LoadContexts(cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, ok := file.Get(name)
+	if !ok {
+		return fmt.Errorf("context %q not found (see 'enclii context list')", name)
+	}
+
+	token := "(login credentials)"
+	if ctx.APIToken != "" {
+		token = maskToken(ctx.APIToken)
+	}
+
+	fmt.Printf("Context:      %s\n", ctx.Name)
+	fmt.Printf("API endpoint: %s\n", orDash(ctx.APIEndpoint))
+	fmt.Printf("API token:    %s\n", token)
+	fmt.Printf("Team:         %s\n", orDash(ctx.Team))
+	fmt.Printf("Project:      %s\n", orDash(ctx.Project))
+	fmt.Printf("Environment:  %s\n", orDash(ctx.Environment))
+	return nil
+}
+
+func runContextSet(cmd *cobra.Command, cfg *config.Config, name string) error {
+	file, err := config.LoadContexts(cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	ctx := config.Context{Name: name}
+	if existing, ok := file.Get(name); ok {
+		ctx = *existing
+	}
+
+	// Only overwrite the fields that were passed so a context can be edited in place
+	fields := map[string]*string{
+		"context-api-endpoint": &ctx.APIEndpoint,
+		"context-api-token":    &ctx.APIToken,
+		"team":                 &ctx.Team,
+		"project":              &ctx.Project,
+		"env":                  &ctx.Environment,
+	}
+	for flag, value := range fields {
+		if cmd.Flags().Changed(flag) {
+			*value, _ = cmd.Flags().GetString(flag)
+		}
+	}
+
+	file.Set(ctx)
+	if file.CurrentContext == "" {
+		file.CurrentContext = name
+	}
+	if err := file.Save(cfg.ConfigFile); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Context %q saved\n", name)
+	return nil
+}
+
+func runContextDelete(cfg *config.Config, name string) error {
+	file, err := config.LoadContexts(cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if !file.Delete(name) {
+		return fmt.Errorf("context %q not found (see 'enclii context list')", name)
+	}
+	if err := file.Save(cfg.ConfigFile); err != nil {
+		return err
+	}
+
+	fmt.Printf("🗑️  Context %q deleted\n", name)
+	return nil
+}
+
+// orDash renders empty values as "-" in tables
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// maskToken shows only the last characters of a token
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -17,106 +18,225 @@ import (
 )
 
 func NewDeployCommand(cfg *config.Config) *cobra.Command {
+	var opts deployOptions
 	var environment string
-	var wait bool
-	var specFile string
 
 	cmd := &cobra.Command{
-		Use:   "deploy",
+		Use:   "deploy [service]",
 		Short: "Build and deploy service",
-		Long:  "Build the current service and deploy it to the specified environment",
+		Long: `Build the current service and deploy it to the specified environment.
+
+The release already built for the current commit is reused unless --rebuild
+is given. With --verify the command waits for the deployment to become
+healthy and runs the verification checks of service.yaml (spec.verify),
+exiting non-zero if any step fails, so it can be the single deploy step of a
+CI pipeline.
+
+A service name deploys that service. Without a service.yaml it must already
+exist in the configured project.
+
+With --local the working directory is uploaded and built as-is, including
+uncommitted changes. Local builds can only be deployed to non-production
+environments.
+
+With --image an image already in a registry is released and deployed
+without a build. Use 'enclii releases create' to have it verified first.
+
+Examples:
+  # Deploy the current commit to production and verify it
+  enclii deploy api --env prod --wait --verify`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeServices(cfg, false),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return deployService(cfg, environment, wait, specFile)
+			if len(args) > 0 {
+				opts.serviceName = args[0]
+			}
+			opts.environment = targetEnvironment(cmd, cfg, environment)
+			return deployService(cfg, opts)
 		},
 	}
 
 	cmd.Flags().StringVarP(&environment, "env", "e", "dev", "Environment to deploy to (dev, staging, prod)")
-	cmd.Flags().BoolVarP(&wait, "wait", "w", false, "Wait for deployment to complete")
-	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml specification file")
+	cmd.Flags().BoolVarP(&opts.wait, "wait", "w", false, "Wait for deployment to complete")
+	cmd.Flags().StringVarP(&opts.specFile, "file", "f", "service.yaml", "Path to service.yaml specification file")
+	cmd.Flags().BoolVar(&opts.local, "local", false, "Build the working directory, including uncommitted changes, instead of the current commit")
+	cmd.Flags().BoolVar(&opts.verify, "verify", false, "Run the verification checks once the deployment is healthy (implies --wait)")
+	cmd.Flags().BoolVar(&opts.rebuild, "rebuild", false, "Build the current commit even if a release of it exists")
+	cmd.Flags().StringVar(&opts.image, "image", "", "Deploy an existing registry image instead of building, e.g. ghcr.io/acme/api:v1.2.0")
+	cmd.Flags().StringVar(&opts.override, "override-reason", "", "Justification for an emergency deploy outside the environment's deploy windows or during a freeze (admins only)")
 
 	return cmd
 }
 
-func deployService(cfg *config.Config, environment string, wait bool, specFile string) error {
+// deployOptions configures deployService
+type deployOptions struct {
+	serviceName string // Service to deploy; defaults to the one in specFile
+	environment string
+	specFile    string
+	wait        bool
+	verify      bool
+	local       bool
+	rebuild     bool
+	image       string // Registry image to deploy without a build
+	override    string // Justification for deploying against the environment's deploy policy
+}
+
+func deployService(cfg *config.Config, opts deployOptions) error {
 	ctx := context.Background()
+	environment := opts.environment
+
+	if opts.local && isProductionEnvironment(environment) {
+		return fmt.Errorf("local builds cannot be deployed to %s; commit and push your changes instead", environment)
+	}
+	if opts.image != "" && (opts.local || opts.rebuild) {
+		return fmt.Errorf("--image cannot be combined with --local or --rebuild")
+	}
 
 	fmt.Printf("🚂 Deploying to %s environment...\n", environment)
 
 	// Check if we're in a git repository and get current commit
-	gitSHA, err := getCurrentGitSHA()
-	if err != nil {
-		return fmt.Errorf("failed to get git SHA: %w", err)
+	var gitSHA string
+	switch {
+	case opts.image != "":
+		fmt.Printf("📦 Deploying image: %s\n", opts.image)
+	case opts.local:
+		fmt.Println("📦 Building from the local working directory")
+	default:
+		var err error
+		gitSHA, err = getCurrentGitSHA()
+		if err != nil {
+			return fmt.Errorf("failed to get git SHA: %w", err)
+		}
+		fmt.Printf("📦 Building from commit: %s\n", gitSHA[:8])
 	}
 
-	fmt.Printf("📦 Building from commit: %s\n", gitSHA[:8])
-
-	// 1. Parse service.yaml
-	parser := spec.NewParser()
-	serviceSpec, err := parser.ParseServiceSpec(specFile)
-	if err != nil {
-		return fmt.Errorf("failed to parse %s: %w", specFile, err)
+	// 1. Parse service.yaml, which only a named service may go without
+	var serviceSpec *types.ServiceSpec
+	if _, err := os.Stat(opts.specFile); err == nil || opts.serviceName == "" {
+		parser := spec.NewParser()
+		serviceSpec, err = parser.ParseServiceSpec(opts.specFile)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", opts.specFile, err)
+		}
+		if opts.serviceName != "" && serviceSpec.Metadata.Name != opts.serviceName {
+			return fmt.Errorf("%s describes service %s, not %s", opts.specFile, serviceSpec.Metadata.Name, opts.serviceName)
+		}
 	}
 
-	fmt.Printf("🔧 Service: %s (project: %s)\n", serviceSpec.Metadata.Name, serviceSpec.Metadata.Project)
-
 	// 2. Create API client
 	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
 
-	// 3. Ensure project exists
-	project, err := ensureProject(ctx, apiClient, serviceSpec.Metadata.Project)
-	if err != nil {
-		return fmt.Errorf("failed to ensure project: %w", err)
-	}
+	// 3. Resolve the project and service, creating them from the spec
+	var project *types.Project
+	var service *types.Service
+	var err error
+	if serviceSpec != nil {
+		fmt.Printf("🔧 Service: %s (project: %s)\n", serviceSpec.Metadata.Name, serviceSpec.Metadata.Project)
 
-	// 4. Ensure service exists
-	service, err := ensureService(ctx, apiClient, project, serviceSpec)
-	if err != nil {
-		return fmt.Errorf("failed to ensure service: %w", err)
+		project, err = ensureProject(ctx, apiClient, serviceSpec.Metadata.Project)
+		if err != nil {
+			return fmt.Errorf("failed to ensure project: %w", err)
+		}
+		service, err = ensureService(ctx, apiClient, project, serviceSpec)
+		if err != nil {
+			return fmt.Errorf("failed to ensure service: %w", err)
+		}
+	} else {
+		projectSlug := resolveProjectSlug(opts.specFile, cfg)
+		fmt.Printf("🔧 Service: %s (project: %s)\n", opts.serviceName, projectSlug)
+
+		project, err = apiClient.GetProject(ctx, projectSlug)
+		if err != nil {
+			return fmt.Errorf("failed to get project: %w", err)
+		}
+		service, err = findService(ctx, apiClient, project.Slug, opts.serviceName)
+		if err != nil {
+			return err
+		}
 	}
 
-	// 5. Ensure environment exists
+	// 4. Ensure environment exists
 	if err := ensureEnvironment(ctx, apiClient, project.Slug, environment); err != nil {
 		return fmt.Errorf("failed to ensure environment: %w", err)
 	}
 
-	// 6. Trigger build
-	fmt.Println("🏗️  Building service...")
-	release, err := apiClient.BuildService(ctx, service.ID.String(), gitSHA)
-	if err != nil {
-		return fmt.Errorf("failed to build service: %w", err)
+	// 5. Release the image, or trigger or reuse the build
+	var release *types.Release
+	if opts.image != "" {
+		release, err = apiClient.CreateImageRelease(ctx, service.ID.String(), client.CreateImageReleaseRequest{ImageURI: opts.image})
+		if err != nil {
+			return fmt.Errorf("failed to create release: %w", err)
+		}
+		fmt.Printf("📦 Release created: %s\n", release.Version)
+	} else {
+		if !opts.local && !opts.rebuild {
+			release, err = findCommitRelease(ctx, apiClient, service.ID.String(), gitSHA)
+			if err != nil {
+				return fmt.Errorf("failed to list releases: %w", err)
+			}
+		}
+		if release != nil {
+			fmt.Printf("♻️  Reusing release %s built for this commit\n", release.Version)
+		} else {
+			fmt.Println("🏗️  Building service...")
+			if opts.local {
+				release, err = buildFromWorkingDirectory(ctx, apiClient, service.ID.String())
+			} else {
+				release, err = apiClient.BuildService(ctx, service.ID.String(), gitSHA)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to build service: %w", err)
+			}
+			fmt.Printf("📦 Build initiated: %s\n", release.Version)
+		}
 	}
 
-	fmt.Printf("📦 Build initiated: %s\n", release.Version)
-
-	// 7. Wait for build completion (simplified polling)
-	if err := waitForBuild(ctx, apiClient, service.ID.String(), release.ID.String()); err != nil {
-		return fmt.Errorf("build failed: %w", err)
+	// 6. Wait for build completion (simplified polling)
+	if release.Status != types.ReleaseStatusReady {
+		if err := waitForBuild(ctx, apiClient, service.ID.String(), release.ID.String()); err != nil {
+			return fmt.Errorf("build failed: %w", err)
+		}
 	}
 
-	// 8. Deploy to environment
+	// 7. Deploy to environment
 	fmt.Println("🚀 Deploying to Kubernetes...")
 	deployReq := client.DeployRequest{
 		ReleaseID:       release.ID.String(),
 		EnvironmentName: environment, // e.g., "dev", "staging", "production"
 		Replicas:        1,
+		OverrideReason:  opts.override,
 	}
 
-	_, err = apiClient.DeployService(ctx, service.ID.String(), deployReq)
+	deployment, err := apiClient.DeployService(ctx, service.ID.String(), deployReq)
 	if err != nil {
 		return fmt.Errorf("failed to deploy service: %w", err)
 	}
 
-	if wait {
-		fmt.Println("⏳ Waiting for deployment...")
-		if err := waitForDeployment(ctx, apiClient, service.ID.String()); err != nil {
-			return fmt.Errorf("deployment failed: %w", err)
-		}
-		fmt.Println("✅ Deployment successful!")
-		fmt.Printf("🌐 Service available at: https://%s.%s.%s.enclii.dev\n",
-			serviceSpec.Metadata.Name, serviceSpec.Metadata.Project, environment)
-	} else {
+	serviceURL := fmt.Sprintf("https://%s.%s.%s.enclii.dev", service.Name, project.Slug, environment)
+	if !opts.wait && !opts.verify {
 		fmt.Println("✅ Deployment initiated")
-		fmt.Printf("📊 Monitor progress: enclii logs %s -f\n", serviceSpec.Metadata.Name)
+		fmt.Printf("📊 Monitor progress: enclii logs %s -f\n", service.Name)
+		return nil
+	}
+
+	fmt.Println("⏳ Waiting for deployment...")
+	if err := waitForDeployment(ctx, apiClient, deployment.ID.String()); err != nil {
+		return fmt.Errorf("deployment failed: %w", err)
+	}
+	fmt.Println("✅ Deployment successful!")
+	fmt.Printf("🌐 Service available at: %s\n", serviceURL)
+
+	// 8. Verify the deployment
+	if opts.verify {
+		baseURL := serviceURL
+		if serviceSpec != nil && serviceSpec.Spec.Verify != nil && serviceSpec.Spec.Verify.BaseURL != "" {
+			baseURL = serviceSpec.Spec.Verify.BaseURL
+		}
+		fmt.Printf("🔎 Verifying %s...\n", baseURL)
+		if err := verifyDeployment(ctx, apiClient, serviceSpec, baseURL, deployment.ID.String()); err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		fmt.Println("✅ Deployment verified")
 	}
 
 	return nil
@@ -184,6 +304,40 @@ func ensureService(ctx context.Context, apiClient *client.APIClient, project *ty
 	return apiClient.CreateService(ctx, project.Slug, newService)
 }
 
+// findService looks up an existing service of a project by name
+func findService(ctx context.Context, apiClient *client.APIClient, projectSlug, name string) (*types.Service, error) {
+	services, err := apiClient.ListServices(ctx, projectSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	for _, svc := range services {
+		if svc.Name == name {
+			return svc, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s not found in project %s", name, projectSlug)
+}
+
+// findCommitRelease returns the newest release of a service built from
+// gitSHA that has not failed, or nil when there is none
+func findCommitRelease(ctx context.Context, apiClient *client.APIClient, serviceID, gitSHA string) (*types.Release, error) {
+	releases, err := apiClient.ListReleases(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newest *types.Release
+	for _, release := range releases {
+		if release.GitSHA != gitSHA || release.Status == types.ReleaseStatusFailed {
+			continue
+		}
+		if newest == nil || release.CreatedAt.After(newest.CreatedAt) {
+			newest = release
+		}
+	}
+	return newest, nil
+}
+
 func ensureEnvironment(ctx context.Context, apiClient *client.APIClient, projectSlug, envName string) error {
 	// Try to create the environment (will fail with 409 if it already exists, which is fine)
 	_, err := apiClient.CreateEnvironment(ctx, projectSlug, envName)
@@ -244,31 +398,68 @@ func waitForBuild(ctx context.Context, apiClient *client.APIClient, serviceID, r
 	}
 }
 
-func waitForDeployment(ctx context.Context, apiClient *client.APIClient, serviceID string) error {
+// waitForDeployment polls a deployment until it runs healthy, printing each
+// change of its status and of its rollout progress
+func waitForDeployment(ctx context.Context, apiClient *client.APIClient, deploymentID string) error {
 	timeout := time.After(5 * time.Minute)
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
+	var lastStatus types.DeploymentStatus
+	var lastHealth types.HealthStatus
+	var lastProgress string
 	for {
 		select {
 		case <-timeout:
-			return fmt.Errorf("deployment timeout after 5 minutes")
+			return fmt.Errorf("deployment timeout after 5 minutes (status: %s, health: %s)", lastStatus, lastHealth)
 		case <-ticker.C:
-			status, err := apiClient.GetServiceStatus(ctx, serviceID)
+			deployment, err := apiClient.GetDeployment(ctx, deploymentID)
 			if err != nil {
 				continue
 			}
 
-			switch status.Health {
-			case types.HealthStatusHealthy:
+			if deployment.Status != lastStatus || deployment.Health != lastHealth {
+				fmt.Printf("   %s (health: %s)\n", deployment.Status, deployment.Health)
+				lastStatus, lastHealth = deployment.Status, deployment.Health
+			}
+			if progress := formatProgress(deployment.Progress); progress != "" && progress != lastProgress {
+				fmt.Printf("   %s\n", progress)
+				lastProgress = progress
+			}
+
+			switch {
+			case deployment.Status == types.DeploymentStatusSuperseded:
+				if deployment.ErrorMessage != nil {
+					return fmt.Errorf("%s", *deployment.ErrorMessage)
+				}
+				return fmt.Errorf("deployment %s was superseded", deploymentID)
+			case deployment.Status == types.DeploymentStatusFailed:
+				if deployment.ErrorMessage != nil {
+					return fmt.Errorf("%s", *deployment.ErrorMessage)
+				}
+				return fmt.Errorf("deployment %s failed", deploymentID)
+			case deployment.Status == types.DeploymentStatusRunning && deployment.Health == types.HealthStatusHealthy:
 				return nil
-			case types.HealthStatusUnhealthy:
-				fmt.Print("⚠")
-			default:
-				fmt.Print(".")
 			}
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
 }
+
+// formatProgress renders a deployment's phase as a progress bar, e.g.
+// "[###########---------]  56% waiting-rollout (1/3 ready)"
+func formatProgress(progress *types.DeploymentProgress) string {
+	if progress == nil {
+		return ""
+	}
+	const width = 20
+	filled := width * progress.Percent / 100
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", width-filled)
+
+	line := fmt.Sprintf("[%s] %3d%% %s", bar, progress.Percent, progress.Phase)
+	if progress.Phase == types.DeploymentPhaseWaitingRollout && progress.DesiredReplicas > 0 {
+		line += fmt.Sprintf(" (%d/%d ready)", progress.ReadyReplicas, progress.DesiredReplicas)
+	}
+	return line
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
@@ -35,15 +37,32 @@ func NewLogsCommand(cfg *config.Config) *cobra.Command {
 	var since string
 	var timestamps bool
 	var specFile string
+	var all bool
+	var grep string
+	var search string
+	var until string
+	var pod string
+	var stream string
 
 	cmd := &cobra.Command{
-		Use:   "logs [service]",
+		Use:   "logs [service...]",
 		Short: "Show service logs",
 		Long: `Display logs for a service in the specified environment.
 
 Real-time streaming is supported with the --follow flag, which establishes
 a WebSocket connection to stream logs as they are generated.
 
+Several services, or every service of the project with --all, are tailed
+together in one stream with a color-coded service prefix on each line.
+Tailing several services always follows.
+
+With --search, logs are searched over a time range instead of tailed. When
+the platform aggregates logs, the search covers everything shipped from the
+service, including pods that no longer exist; otherwise it covers what the
+running pods still hold. --since and --until bound the range (the last hour
+by default), --grep adds a regular expression lines must match, and -n
+caps the number of lines.
+
 Examples:
   # Show last 100 lines of logs
   enclii logs my-service
@@ -55,12 +74,24 @@ Examples:
   enclii logs my-service --since 1h
 
   # Show production logs with timestamps
-  enclii logs my-service --env production --timestamps`,
-		Args: cobra.MaximumNArgs(1),
+  enclii logs my-service --env production --timestamps
+
+  # Tail two services together, keeping only errors
+  enclii logs api worker --grep 'error|panic'
+
+  # Tail every service of the project from the last 15 minutes
+  enclii logs --all --since 15m
+
+  # Search yesterday's production logs for timeouts
+  enclii logs my-service --env production --search timeout --since 48h --until 24h
+
+  # Search stderr of one pod for panics
+  enclii logs my-service --search panic --pod my-service-7d9f8 --stream stderr`,
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completeServices(cfg, true),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var serviceName string
-			if len(args) > 0 {
-				serviceName = args[0]
+			if all && len(args) > 0 {
+				return fmt.Errorf("--all cannot be combined with service names")
 			}
 
 			// Parse --since duration
@@ -73,7 +104,55 @@ Examples:
 				sinceTime = parsed
 			}
 
-			return showLogs(cfg, serviceName, environment, follow, lines, sinceTime, timestamps, specFile)
+			var grepPattern *regexp.Regexp
+			if grep != "" {
+				parsed, err := regexp.Compile(grep)
+				if err != nil {
+					return fmt.Errorf("invalid --grep pattern: %w", err)
+				}
+				grepPattern = parsed
+			}
+
+			env := targetEnvironment(cmd, cfg, environment)
+			if cmd.Flags().Changed("search") {
+				if all || len(args) > 1 || follow {
+					return fmt.Errorf("--search works on one service and cannot follow")
+				}
+				var serviceName string
+				if len(args) > 0 {
+					serviceName = args[0]
+				}
+				opts := client.LogSearchOptions{
+					Query:   search,
+					Regexp:  grep,
+					Pod:     pod,
+					Stream:  stream,
+					Start:   sinceTime,
+					Limit:   lines,
+					Forward: true,
+				}
+				if until != "" {
+					parsed, err := parseSinceDuration(until)
+					if err != nil {
+						return fmt.Errorf("invalid --until value: %w", err)
+					}
+					opts.End = parsed
+				}
+				if opts.Start == nil && opts.End != nil {
+					start := opts.End.Add(-time.Hour)
+					opts.Start = &start
+				}
+				return searchLogs(cfg, serviceName, env, opts, timestamps, specFile)
+			}
+			if all || len(args) > 1 {
+				return showProjectLogs(cfg, args, env, lines, sinceTime, timestamps, grep, specFile)
+			}
+
+			var serviceName string
+			if len(args) > 0 {
+				serviceName = args[0]
+			}
+			return showLogs(cfg, serviceName, env, follow, lines, sinceTime, timestamps, grepPattern, specFile)
 		},
 	}
 
@@ -83,13 +162,19 @@ Examples:
 	cmd.Flags().StringVar(&since, "since", "", "Show logs since duration (e.g., 5m, 1h, 24h)")
 	cmd.Flags().BoolVar(&timestamps, "timestamps", false, "Show timestamps with each log line")
 	cmd.Flags().StringVarP(&specFile, "file", "F", "service.yaml", "Path to service.yaml specification file")
+	cmd.Flags().BoolVar(&all, "all", false, "Tail every service of the project")
+	cmd.Flags().StringVar(&grep, "grep", "", "Only show log lines matching this regular expression")
+	cmd.Flags().StringVar(&search, "search", "", "Search logs over a time range for lines containing this text (case-insensitive)")
+	cmd.Flags().StringVar(&until, "until", "", "With --search, end the range this long ago (e.g., 30m, 24h)")
+	cmd.Flags().StringVar(&pod, "pod", "", "With --search, only search logs of this pod")
+	cmd.Flags().StringVar(&stream, "stream", "", "With --search, only search stdout or stderr")
 
 	return cmd
 }
 
-func showLogs(cfg *config.Config, serviceName, environment string, follow bool, lines int, since *time.Time, timestamps bool, specFile string) error {
+// logsContext returns a context cancelled on Ctrl+C
+func logsContext() (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
 	// Handle Ctrl+C gracefully
 	sigChan := make(chan os.Signal, 1)
@@ -100,6 +185,13 @@ func showLogs(cfg *config.Config, serviceName, environment string, follow bool,
 		cancel()
 	}()
 
+	return ctx, cancel
+}
+
+func showLogs(cfg *config.Config, serviceName, environment string, follow bool, lines int, since *time.Time, timestamps bool, grep *regexp.Regexp, specFile string) error {
+	ctx, cancel := logsContext()
+	defer cancel()
+
 	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
 
 	// Resolve service name
@@ -144,15 +236,15 @@ func showLogs(cfg *config.Config, serviceName, environment string, follow bool,
 
 	if follow {
 		// Use WebSocket streaming for real-time logs
-		return streamLogsRealtime(ctx, apiClient, targetService.ID.String(), environment, lines, since, timestamps)
+		return streamLogsRealtime(ctx, apiClient, targetService.ID.String(), environment, lines, since, timestamps, grep)
 	}
 
 	// One-time log fetch for non-follow mode
-	return fetchLogsOnce(ctx, apiClient, targetService.ID.String(), lines, since)
+	return fetchLogsOnce(ctx, apiClient, targetService.ID.String(), lines, since, grep)
 }
 
 // streamLogsRealtime establishes a WebSocket connection for real-time log streaming
-func streamLogsRealtime(ctx context.Context, apiClient *client.APIClient, serviceID, envName string, lines int, since *time.Time, timestamps bool) error {
+func streamLogsRealtime(ctx context.Context, apiClient *client.APIClient, serviceID, envName string, lines int, since *time.Time, timestamps bool, grep *regexp.Regexp) error {
 	fmt.Println("🔗 Connecting to log stream...")
 
 	opts := client.StreamLogsOptions{
@@ -210,6 +302,10 @@ func streamLogsRealtime(ctx context.Context, apiClient *client.APIClient, servic
 				fmt.Printf("ℹ️  %s\n", msg.Message)
 				continue
 			case "log":
+				if grep != nil && !grep.MatchString(msg.Message) {
+					continue
+				}
+
 				// Assign color to pod
 				podKey := msg.Pod
 				if msg.Container != "" {
@@ -249,7 +345,7 @@ func streamLogsRealtime(ctx context.Context, apiClient *client.APIClient, servic
 }
 
 // fetchLogsOnce gets logs without streaming (one-time fetch)
-func fetchLogsOnce(ctx context.Context, apiClient *client.APIClient, serviceID string, lines int, since *time.Time) error {
+func fetchLogsOnce(ctx context.Context, apiClient *client.APIClient, serviceID string, lines int, since *time.Time, grep *regexp.Regexp) error {
 	// Get latest deployment to fetch logs from
 	deploymentResp, err := apiClient.GetLatestDeployment(ctx, serviceID)
 	if err != nil {
@@ -284,6 +380,10 @@ func fetchLogsOnce(ctx context.Context, apiClient *client.APIClient, serviceID s
 		return err
 	}
 
+	if logs != "" && grep != nil {
+		logs = filterLogLines(logs, grep)
+	}
+
 	if logs == "" {
 		fmt.Println("(No logs available)")
 	} else {
@@ -293,6 +393,218 @@ func fetchLogsOnce(ctx context.Context, apiClient *client.APIClient, serviceID s
 	return nil
 }
 
+// searchLogs searches a service's logs over a time range and prints the
+// matching lines oldest first
+func searchLogs(cfg *config.Config, serviceName, environment string, opts client.LogSearchOptions, timestamps bool, specFile string) error {
+	ctx, cancel := logsContext()
+	defer cancel()
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	resolvedServiceName, projectSlug, err := resolveServiceName(serviceName, specFile, cfg)
+	if err != nil {
+		return err
+	}
+
+	services, err := apiClient.ListServices(ctx, projectSlug)
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	var targetService *types.Service
+	for _, svc := range services {
+		if svc.Name == resolvedServiceName {
+			targetService = svc
+			break
+		}
+	}
+	if targetService == nil {
+		fmt.Printf("❌ Service '%s' not found in project '%s'\n", resolvedServiceName, projectSlug)
+		return fmt.Errorf("service not found")
+	}
+
+	result, err := apiClient.SearchLogs(ctx, targetService.ID.String(), environment, opts)
+	if err != nil {
+		fmt.Printf("❌ Failed to search logs: %v\n", err)
+		return err
+	}
+
+	fmt.Printf("🔎 Searching logs for %s in %s environment", result.ServiceName, environment)
+	if opts.Query != "" {
+		fmt.Printf(" for %q", opts.Query)
+	}
+	fmt.Println()
+	fmt.Printf("   %s → %s", result.Start.Local().Format("2006-01-02 15:04:05"), result.End.Local().Format("2006-01-02 15:04:05"))
+	if result.Source == "kubernetes" {
+		fmt.Printf(" (running pods only, log aggregation is not enabled)")
+	}
+	fmt.Println()
+	fmt.Println("─────────────────────────────────────────────────")
+
+	if len(result.Entries) == 0 {
+		fmt.Println("(No matching logs)")
+		return nil
+	}
+
+	podColorMap := make(map[string]string)
+	for _, entry := range result.Entries {
+		color, exists := podColorMap[entry.Pod]
+		if !exists {
+			color = podColors[len(podColorMap)%len(podColors)]
+			podColorMap[entry.Pod] = color
+		}
+
+		if timestamps {
+			fmt.Printf("%s[%s]%s %s%s%s %s\n",
+				"\033[90m", entry.Timestamp.Local().Format("2006-01-02 15:04:05"), colorReset,
+				color, entry.Pod, colorReset, entry.Line)
+		} else {
+			fmt.Printf("%s%s%s %s\n", color, entry.Pod, colorReset, entry.Line)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d matching lines", len(result.Entries))
+	if opts.Limit > 0 && len(result.Entries) >= opts.Limit {
+		fmt.Printf(" (limit reached, narrow the range or raise -n)")
+	}
+	fmt.Println()
+	return nil
+}
+
+// filterLogLines keeps the lines of logs matching grep
+func filterLogLines(logs string, grep *regexp.Regexp) string {
+	var matched []string
+	for _, line := range strings.Split(logs, "\n") {
+		if grep.MatchString(line) {
+			matched = append(matched, line)
+		}
+	}
+	return strings.Join(matched, "\n")
+}
+
+// showProjectLogs tails several services of a project, or all of them when
+// serviceNames is empty, in one stream prefixed by service
+func showProjectLogs(cfg *config.Config, serviceNames []string, environment string, lines int, since *time.Time, timestamps bool, grep, specFile string) error {
+	ctx, cancel := logsContext()
+	defer cancel()
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+	projectSlug := resolveProjectSlug(specFile, cfg)
+
+	services, err := apiClient.ListServices(ctx, projectSlug)
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	available := make(map[string]bool, len(services))
+	for _, svc := range services {
+		available[svc.Name] = true
+	}
+	var missing []string
+	for _, name := range serviceNames {
+		if !available[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("❌ Services not found in project '%s': %s\n", projectSlug, strings.Join(missing, ", "))
+		fmt.Println()
+		fmt.Println("💡 Available services:")
+		for _, svc := range services {
+			fmt.Printf("   - %s\n", svc.Name)
+		}
+		return fmt.Errorf("service not found")
+	}
+
+	// Colors follow the order services were named in, or the project order
+	prefixed := serviceNames
+	if len(prefixed) == 0 {
+		for _, svc := range services {
+			prefixed = append(prefixed, svc.Name)
+		}
+	}
+	if len(prefixed) == 0 {
+		return fmt.Errorf("project '%s' has no services", projectSlug)
+	}
+	serviceColors := make(map[string]string, len(prefixed))
+	width := 0
+	for i, name := range prefixed {
+		serviceColors[name] = podColors[i%len(podColors)]
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	fmt.Printf("📋 Showing logs for %s in %s environment (following)\n", strings.Join(prefixed, ", "), environment)
+	if grep != "" {
+		fmt.Printf("   Matching: %s\n", grep)
+	}
+	fmt.Println("─────────────────────────────────────────────────")
+	fmt.Println("🔗 Connecting to log stream...")
+
+	opts := client.StreamLogsOptions{
+		Lines:      lines,
+		Timestamps: timestamps,
+		Since:      since,
+	}
+	logChan, errChan, err := apiClient.StreamProjectLogs(ctx, projectSlug, environment, serviceNames, grep, opts)
+	if err != nil {
+		fmt.Printf("❌ Failed to connect to log stream: %v\n", err)
+		return err
+	}
+
+	fmt.Println("✅ Connected! Streaming logs... (Press Ctrl+C to stop)")
+	fmt.Println()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-errChan:
+			if !ok {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("stream error: %w", err)
+		case msg, ok := <-logChan:
+			if !ok {
+				fmt.Println("\n📋 Log stream ended")
+				return nil
+			}
+
+			switch msg.Type {
+			case "connected":
+				continue
+			case "disconnected":
+				fmt.Println("\n⚠️  Disconnected from server")
+				return nil
+			case "error":
+				if msg.Service != "" {
+					fmt.Printf("⚠️  %s: %s\n", msg.Service, msg.Message)
+				} else {
+					fmt.Printf("⚠️  Server error: %s\n", msg.Message)
+				}
+			case "info":
+				fmt.Printf("ℹ️  %s\n", msg.Message)
+			case "log":
+				color := serviceColors[msg.Service]
+				prefix := fmt.Sprintf("%s%-*s |%s", color, width, msg.Service, colorReset)
+				if timestamps && !msg.Timestamp.IsZero() {
+					fmt.Printf("%s[%s]%s %s %s\n", "\033[90m", msg.Timestamp.Format("15:04:05"), colorReset, prefix, msg.Message)
+				} else {
+					fmt.Printf("%s %s\n", prefix, msg.Message)
+				}
+			default:
+				if msg.Message != "" {
+					fmt.Println(msg.Message)
+				}
+			}
+		}
+	}
+}
+
 // resolveServiceName determines the service name from args, spec file, or defaults
 func resolveServiceName(serviceName, specFile string, cfg *config.Config) (string, string, error) {
 	projectSlug := cfg.Project
@@ -318,6 +630,19 @@ func resolveServiceName(serviceName, specFile string, cfg *config.Config) (strin
 	return "", "", fmt.Errorf("service name required: either provide as argument or ensure service.yaml exists")
 }
 
+// resolveProjectSlug determines the project from the spec file or the CLI
+// configuration
+func resolveProjectSlug(specFile string, cfg *config.Config) string {
+	parser := spec.NewParser()
+	if serviceSpec, err := parser.ParseServiceSpec(specFile); err == nil && serviceSpec.Metadata.Project != "" {
+		return serviceSpec.Metadata.Project
+	}
+	if cfg.Project != "" {
+		return cfg.Project
+	}
+	return "default"
+}
+
 // parseSinceDuration parses duration strings like "5m", "1h", "24h"
 func parseSinceDuration(since string) (*time.Time, error) {
 	duration, err := time.ParseDuration(since)
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/cli/internal/helpers"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// sparkBlocks draws trends, lowest to highest
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparklineWidth is the most points a trend is drawn with
+const sparklineWidth = 40
+
+func NewMetricsCommand(cfg *config.Config) *cobra.Command {
+	var environment string
+	var window string
+	var specFile string
+
+	cmd := &cobra.Command{
+		Use:   "metrics [service]",
+		Short: "Show service CPU, memory and traffic",
+		Long: `Show a service's CPU, memory, restarts, request rate and error rate over
+a window, with the current value, range and trend of each.
+
+Request and error rates appear when the platform's Prometheus sees ingress
+traffic for the service. Without Prometheus, only current CPU, memory and
+restarts are shown.
+
+Examples:
+  # Last hour of the service in service.yaml
+  enclii metrics
+
+  # Last day of production
+  enclii metrics api --env production --window 24h
+
+  # Last week
+  enclii metrics api --window 7d`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeServices(cfg, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var serviceName string
+			if len(args) > 0 {
+				serviceName = args[0]
+			}
+			return showMetrics(cfg, serviceName, targetEnvironment(cmd, cfg, environment), window, specFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&environment, "env", "e", "dev", "Environment to show metrics for")
+	cmd.Flags().StringVarP(&window, "window", "w", "1h", "Time window (e.g., 15m, 6h, 7d)")
+	cmd.Flags().StringVarP(&specFile, "file", "F", "service.yaml", "Path to service.yaml specification file")
+
+	return cmd
+}
+
+func showMetrics(cfg *config.Config, serviceName, environment, window, specFile string) error {
+	ctx := context.Background()
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	resolvedServiceName, projectSlug, err := resolveServiceName(serviceName, specFile, cfg)
+	if err != nil {
+		return err
+	}
+	service, err := helpers.FindServiceByName(ctx, apiClient, projectSlug, resolvedServiceName)
+	if err != nil {
+		return err
+	}
+
+	report, err := apiClient.GetServiceMetrics(ctx, service.ID.String(), environment, window)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return err
+	}
+
+	fmt.Printf("📈 Metrics for %s in %s environment", report.ServiceName, environment)
+	if report.Source == "metrics-server" {
+		fmt.Printf(" (current usage, Prometheus is not configured)")
+	} else {
+		fmt.Printf(" (last %s)", window)
+	}
+	fmt.Println()
+	fmt.Println()
+
+	if len(report.Series) == 0 {
+		fmt.Println("(No metrics available, is the service running?)")
+		return nil
+	}
+
+	fmt.Printf("%-14s %-12s %-12s %-12s %s\n", "METRIC", "CURRENT", "MIN", "MAX", "TREND")
+	fmt.Println(strings.Repeat("─", 95))
+	for _, series := range report.Series {
+		if len(series.Points) == 0 {
+			continue
+		}
+		low, high := seriesRange(series.Points)
+		fmt.Printf("%-14s %-12s %-12s %-12s %s\n",
+			series.Name,
+			formatMetric(series.Points[len(series.Points)-1].Value, series.Unit),
+			formatMetric(low, series.Unit),
+			formatMetric(high, series.Unit),
+			sparkline(series.Points, low, high))
+	}
+
+	return nil
+}
+
+// seriesRange returns the lowest and highest value of a series
+func seriesRange(points []types.MetricPoint) (float64, float64) {
+	low, high := points[0].Value, points[0].Value
+	for _, point := range points[1:] {
+		low = min(low, point.Value)
+		high = max(high, point.Value)
+	}
+	return low, high
+}
+
+// sparkline draws a series, averaging points into at most sparklineWidth blocks
+func sparkline(points []types.MetricPoint, low, high float64) string {
+	if len(points) < 2 {
+		return ""
+	}
+
+	buckets := min(len(points), sparklineWidth)
+	var line strings.Builder
+	for b := 0; b < buckets; b++ {
+		from, to := b*len(points)/buckets, (b+1)*len(points)/buckets
+		sum := 0.0
+		for _, point := range points[from:to] {
+			sum += point.Value
+		}
+		level := 0
+		if high > low {
+			level = int((sum/float64(to-from) - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		line.WriteRune(sparkBlocks[level])
+	}
+	return line.String()
+}
+
+// formatMetric renders a value in its unit
+func formatMetric(value float64, unit string) string {
+	switch unit {
+	case "millicores":
+		if value >= 1000 {
+			return fmt.Sprintf("%.2f cores", value/1000)
+		}
+		return fmt.Sprintf("%.0fm", value)
+	case "bytes":
+		const mi = 1024 * 1024
+		if value >= 1024*mi {
+			return fmt.Sprintf("%.2f Gi", value/(1024*mi))
+		}
+		return fmt.Sprintf("%.0f Mi", value/mi)
+	case "requests_per_second":
+		return fmt.Sprintf("%.1f req/s", value)
+	case "ratio":
+		return fmt.Sprintf("%.2f%%", value*100)
+	default:
+		return fmt.Sprintf("%.0f", value)
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+)
+
+func NewPortForwardCommand(cfg *config.Config) *cobra.Command {
+	var environment string
+	var specFile string
+	var address string
+
+	cmd := &cobra.Command{
+		Use:   "port-forward <service|addon> <local-port>[:<remote-port>]",
+		Short: "Forward a local port to a service or addon",
+		Long: `Forward connections to a local port to a service or database addon of the
+project, tunneled through the Enclii API. No cluster credentials are needed;
+port forwarding requires the developer role and every connection is audit
+logged.
+
+The remote port defaults to the first port of a service, or the port of an
+addon.
+
+Examples:
+  # Reach the staging database on localhost:5432
+  enclii port-forward db 5432 --env staging
+
+  # Reach port 80 of the api service on localhost:8080
+  enclii port-forward api 8080:80`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeServices(cfg, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			localPort, remotePort, err := parsePortMapping(args[1])
+			if err != nil {
+				return err
+			}
+			return portForward(cfg, resolveProjectSlug(specFile, cfg), targetEnvironment(cmd, cfg, environment), args[0], address, localPort, remotePort)
+		},
+	}
+
+	cmd.Flags().StringVarP(&environment, "env", "e", "dev", "Environment of the service or addon")
+	cmd.Flags().StringVarP(&specFile, "file", "F", "service.yaml", "Path to service.yaml specification file")
+	cmd.Flags().StringVar(&address, "address", "127.0.0.1", "Local address to listen on")
+
+	return cmd
+}
+
+// portForward listens on a local port and tunnels every connection to the
+// target until Ctrl+C
+func portForward(cfg *config.Config, projectSlug, environment, target, address string, localPort, remotePort int) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	// Fail on an unknown target or missing permission before listening
+	resolved, err := apiClient.GetPortForwardTarget(ctx, projectSlug, environment, target, remotePort)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(localPort)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", localPort, err)
+	}
+	defer listener.Close()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+		listener.Close()
+	}()
+
+	fmt.Printf("🔌 Forwarding %s -> %s %s:%d (%s)\n", listener.Addr(), resolved.Kind, resolved.Target, resolved.Port, resolved.Environment)
+	fmt.Println("   Press Ctrl+C to stop")
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				wg.Wait()
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fmt.Printf("   Handling connection from %s\n", conn.RemoteAddr())
+			if err := tunnelConnection(ctx, apiClient, conn, projectSlug, environment, target, resolved.Port); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			}
+		}()
+	}
+}
+
+// tunnelConnection copies one local connection through a port-forward
+// tunnel until either side closes
+func tunnelConnection(ctx context.Context, apiClient *client.APIClient, conn net.Conn, projectSlug, environment, target string, port int) error {
+	defer conn.Close()
+
+	tunnel, err := apiClient.DialPortForward(ctx, projectSlug, environment, target, port)
+	if err != nil {
+		return err
+	}
+	defer tunnel.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(tunnel, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, tunnel)
+		done <- struct{}{}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// parsePortMapping parses LOCAL[:REMOTE]. A missing remote port is zero, the
+// target's default.
+func parsePortMapping(mapping string) (localPort, remotePort int, err error) {
+	local, remote, hasRemote := strings.Cut(mapping, ":")
+	if localPort, err = parsePort(local); err != nil {
+		return 0, 0, err
+	}
+	if hasRemote {
+		if remotePort, err = parsePort(remote); err != nil {
+			return 0, 0, err
+		}
+	}
+	return localPort, remotePort, nil
+}
+
+func parsePort(raw string) (int, error) {
+	port, err := strconv.Atoi(raw)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", raw)
+	}
+	return port, nil
+}
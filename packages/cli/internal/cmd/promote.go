@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+)
+
+func NewPromoteCommand(cfg *config.Config) *cobra.Command {
+	var to string
+	var from string
+	var replicas int
+	var overrideReason string
+	var history bool
+
+	cmd := &cobra.Command{
+		Use:   "promote <release-id>",
+		Short: "Promote a release to the next environment",
+		Long: `Promote a release that runs in one environment to another, deploying the
+same image. The release must be the current deployment of its service in the
+environment it is promoted from.
+
+Examples:
+  # Promote a release from staging to production
+  enclii promote 70c1bded-7f28-4438-87ff-393efffd3bad --to production
+
+  # Name the environment to promote from when the release runs in several
+  enclii promote 70c1bded-7f28-4438-87ff-393efffd3bad --to production --from staging
+
+  # Show the promotion chain of a release
+  enclii promote 70c1bded-7f28-4438-87ff-393efffd3bad --history`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+			if history {
+				return showPromotions(ctx, apiClient, args[0])
+			}
+			if to == "" {
+				return fmt.Errorf("--to is required")
+			}
+
+			fmt.Printf("🚀 Promoting release %s to %s...\n", args[0], to)
+			response, err := apiClient.PromoteRelease(ctx, args[0], to, from, client.PromoteRequest{
+				Replicas:       replicas,
+				OverrideReason: overrideReason,
+			})
+			if err != nil {
+				fmt.Printf("❌ Promotion failed: %v\n", err)
+				return err
+			}
+
+			fmt.Printf("✅ Promoted from %s to %s\n", response.Promotion.FromEnvironment, response.Promotion.ToEnvironment)
+			fmt.Printf("   Image: %s\n", response.Promotion.ImageURI)
+			if response.Promotion.ImageDigest != "" {
+				fmt.Printf("   Digest: %s\n", response.Promotion.ImageDigest)
+			}
+			fmt.Printf("   Deployment: %s\n", response.Deployment.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "Environment to promote to")
+	cmd.Flags().StringVar(&from, "from", "", "Environment to promote from, when the release runs in several")
+	cmd.Flags().IntVar(&replicas, "replicas", 0, "Replicas to run (default: the current scale in the target environment)")
+	cmd.Flags().StringVar(&overrideReason, "override-reason", "", "Justification for overriding the deploy policy (admins only)")
+	cmd.Flags().BoolVar(&history, "history", false, "Show the promotion chain of the release instead")
+	_ = cmd.RegisterFlagCompletionFunc("to", completeEnvironments(cfg))
+	_ = cmd.RegisterFlagCompletionFunc("from", completeEnvironments(cfg))
+
+	return cmd
+}
+
+// showPromotions prints the promotion chain of a release
+func showPromotions(ctx context.Context, apiClient *client.APIClient, releaseID string) error {
+	promotions, err := apiClient.ListReleasePromotions(ctx, releaseID)
+	if err != nil {
+		return err
+	}
+	if len(promotions) == 0 {
+		fmt.Println("Release was not promoted")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FROM\tTO\tBY\tPROMOTED")
+	for _, p := range promotions {
+		by := p.PromotedBy
+		if p.Auto {
+			by = "auto"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.FromEnvironment, p.ToEnvironment, by, p.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
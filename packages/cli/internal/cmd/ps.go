@@ -20,7 +20,7 @@ func NewPsCommand(cfg *config.Config) *cobra.Command {
 		Short: "List services and their status",
 		Long:  "Show running services, their health, and resource usage",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return listServices(cfg, environment)
+			return listServices(cfg, targetEnvironment(cmd, cfg, environment))
 		},
 	}
 
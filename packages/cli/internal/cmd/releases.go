@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 
 	"github.com/madfam-org/enclii/packages/cli/internal/client"
 	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
 )
 
 func NewReleasesCommand(cfg *config.Config) *cobra.Command {
@@ -39,7 +42,8 @@ Examples:
 
   # Limit to specific number
   enclii releases switchyard-api -n 5`,
-		Args: cobra.MaximumNArgs(1),
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeServices(cfg, false),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 			apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
@@ -128,12 +132,17 @@ Examples:
 					sha = sha[:8]
 				}
 
-				fmt.Printf("%s %s%-8s\033[0m  %s  (git: %s)  %s\n",
+				source := "git: " + sha
+				if r.Source == types.ReleaseSourceImage {
+					source = "image: " + r.ImageURI
+				}
+
+				fmt.Printf("%s %s%-8s\033[0m  %s  (%s)  %s\n",
 					statusIcon,
 					statusColor,
 					r.Status,
 					r.Version,
-					sha,
+					source,
 					timeAgo,
 				)
 
@@ -174,9 +183,163 @@ Examples:
 	cmd.Flags().BoolVarP(&showAll, "all", "a", false, "Show all releases")
 	cmd.Flags().StringVar(&serviceID, "id", "", "Service ID (alternative to name)")
 
+	cmd.AddCommand(newReleasesCreateCommand(cfg))
+	cmd.AddCommand(newReleasesArtifactsCommand(cfg))
+
+	return cmd
+}
+
+func newReleasesCreateCommand(cfg *config.Config) *cobra.Command {
+	var req client.CreateImageReleaseRequest
+
+	cmd := &cobra.Command{
+		Use:   "create <service-name> --image <image>",
+		Short: "Release an existing registry image without a build",
+		Long: `Create a release from an image that is already in a container registry.
+
+Use 'enclii deploy --image' to release an image and deploy it in one step.
+Images in private registries need a registry credential on the project.
+
+With --verify-signature or --scan-severity the release stays building until
+the checks pass, and fails with the reason when they do not.
+
+Examples:
+  # Release a public image
+  enclii releases create api --image nginx:1.27
+
+  # Pin the release to a digest
+  enclii releases create api --image ghcr.io/acme/api:v1.2.0 --digest sha256:...
+
+  # Require a Cosign signature and no high or critical vulnerabilities
+  enclii releases create api --image ghcr.io/acme/api:v1.2.0 --verify-signature --scan-severity high`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeServices(cfg, false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+			projectSlug := cfg.Project
+			if projectSlug == "" {
+				projectSlug = "default"
+			}
+			service, err := findService(ctx, apiClient, projectSlug, args[0])
+			if err != nil {
+				return err
+			}
+
+			release, err := apiClient.CreateImageRelease(ctx, service.ID.String(), req)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Release %s of %s created (id: %s)\n", release.Version, release.ImageURI, release.ID)
+			if release.Status == types.ReleaseStatusBuilding {
+				fmt.Printf("Checks are running; follow them with 'enclii releases %s'\n", service.Name)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&req.ImageURI, "image", "", "Image to release, e.g. ghcr.io/acme/api:v1.2.0")
+	cmd.Flags().StringVar(&req.Digest, "digest", "", "Pin the release to an image digest (sha256:...)")
+	cmd.Flags().StringVar(&req.Version, "version", "", "Release version (default: a timestamp and the image tag)")
+	cmd.Flags().BoolVar(&req.VerifySignature, "verify-signature", false, "Require a valid Cosign signature")
+	cmd.Flags().StringVar(&req.ScanSeverity, "scan-severity", "", "Fail on vulnerabilities of this severity or above (low, medium, high, critical)")
+	_ = cmd.MarkFlagRequired("image")
+
+	return cmd
+}
+
+func newReleasesArtifactsCommand(cfg *config.Config) *cobra.Command {
+	var downloadDir string
+
+	cmd := &cobra.Command{
+		Use:   "artifacts <release-id>",
+		Short: "List or download the artifacts a build published",
+		Long: `List the files a build published, such as test reports, coverage or
+compiled binaries, and optionally download them.
+
+Builds publish the files in the directory set as build.artifacts in the
+service spec, e.g. /app/dist.
+
+Examples:
+  # List the artifacts of a build
+  enclii releases artifacts 9b2d6c1e-4f0a-4c8e-a1b3-5d7e9f0a2b4c
+
+  # Download them, keeping their paths, into ./artifacts
+  enclii releases artifacts 9b2d6c1e-4f0a-4c8e-a1b3-5d7e9f0a2b4c --download ./artifacts`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+			artifacts, err := apiClient.ListBuildArtifacts(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			if len(artifacts) == 0 {
+				fmt.Println("No artifacts published by this build")
+				return nil
+			}
+
+			if downloadDir == "" {
+				for _, a := range artifacts {
+					fmt.Printf("%-60s  %10s\n", a.Path, formatArtifactSize(a.SizeBytes))
+				}
+				return nil
+			}
+
+			for _, a := range artifacts {
+				if a.DownloadURL == "" {
+					return fmt.Errorf("artifact storage is not configured on the server; artifacts cannot be downloaded")
+				}
+				// Paths come from the build, so keep them inside the target directory
+				target := filepath.Join(downloadDir, filepath.FromSlash(a.Path))
+				if !strings.HasPrefix(target, filepath.Clean(downloadDir)+string(filepath.Separator)) {
+					return fmt.Errorf("artifact path %q escapes the download directory", a.Path)
+				}
+				if err := downloadArtifact(ctx, apiClient, a, target); err != nil {
+					return err
+				}
+				fmt.Printf("📥 %s (%s)\n", target, formatArtifactSize(a.SizeBytes))
+			}
+			fmt.Printf("✅ Downloaded %d artifacts to %s\n", len(artifacts), downloadDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&downloadDir, "download", "d", "", "Download the artifacts into this directory")
+
 	return cmd
 }
 
+func downloadArtifact(ctx context.Context, apiClient *client.APIClient, artifact client.BuildArtifact, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	if err := apiClient.DownloadBuildArtifact(ctx, artifact, f); err != nil {
+		f.Close()
+		os.Remove(target)
+		return err
+	}
+	return f.Close()
+}
+
+func formatArtifactSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
 func formatTimeAgo(t time.Time) string {
 	now := time.Now()
 	diff := now.Sub(t)
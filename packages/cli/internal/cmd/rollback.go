@@ -16,16 +16,17 @@ func NewRollbackCommand(cfg *config.Config) *cobra.Command {
 	var releaseID string
 
 	cmd := &cobra.Command{
-		Use:   "rollback [service]",
-		Short: "Rollback service to previous release",
-		Long:  "Rollback a service to a previous release version",
-		Args:  cobra.MaximumNArgs(1),
+		Use:               "rollback [service]",
+		Short:             "Rollback service to previous release",
+		Long:              "Rollback a service to a previous release version",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeServices(cfg, false),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var serviceName string
 			if len(args) > 0 {
 				serviceName = args[0]
 			}
-			return rollbackService(cfg, serviceName, environment, releaseID)
+			return rollbackService(cfg, serviceName, targetEnvironment(cmd, cfg, environment), releaseID)
 		},
 	}
 
@@ -15,14 +15,8 @@ func NewRootCommand(cfg *config.Config) *cobra.Command {
 scale, and operate containerized services with guardrails.
 
 Learn more at https://enclii.dev`,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
-			// Bind flags to viper and update config with flag values
-			if endpoint, _ := cmd.Flags().GetString("api-endpoint"); endpoint != "" {
-				cfg.APIEndpoint = endpoint
-			}
-			if token, _ := cmd.Flags().GetString("api-token"); token != "" {
-				cfg.APIToken = token
-			}
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return applyGlobalFlags(cmd, cfg)
 		},
 	}
 
@@ -30,6 +24,7 @@ Learn more at https://enclii.dev`,
 	rootCmd.PersistentFlags().String("api-endpoint", cfg.APIEndpoint, "API endpoint URL")
 	rootCmd.PersistentFlags().String("api-token", cfg.APIToken, "API authentication token (or set ENCLII_API_TOKEN)")
 	rootCmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().String("context", "", "Context to use for this command (see 'enclii context list')")
 
 	// Bind flags to viper for environment variable support
 	viper.BindPFlag("api-endpoint", rootCmd.PersistentFlags().Lookup("api-endpoint"))
@@ -38,9 +33,16 @@ Learn more at https://enclii.dev`,
 	// Add subcommands
 	rootCmd.AddCommand(NewInitCommand(cfg))
 	rootCmd.AddCommand(NewDeployCommand(cfg))
+	rootCmd.AddCommand(NewUpCommand(cfg))
 	rootCmd.AddCommand(NewLogsCommand(cfg))
+	rootCmd.AddCommand(NewPortForwardCommand(cfg))
+	rootCmd.AddCommand(NewRunCommand(cfg))
 	rootCmd.AddCommand(NewPsCommand(cfg))
+	rootCmd.AddCommand(NewMetricsCommand(cfg))
+	rootCmd.AddCommand(NewStatusCommand(cfg))
 	rootCmd.AddCommand(NewRollbackCommand(cfg))
+	rootCmd.AddCommand(NewPromoteCommand(cfg))
+	rootCmd.AddCommand(NewSearchCommand(cfg))
 	rootCmd.AddCommand(NewVersionCommand())
 	rootCmd.AddCommand(NewLocalCommand(cfg))
 	rootCmd.AddCommand(NewServicesSyncCommand(cfg))
@@ -48,6 +50,8 @@ Learn more at https://enclii.dev`,
 	rootCmd.AddCommand(NewSecretsCommand(cfg))
 	rootCmd.AddCommand(NewDomainsCommand(cfg))
 	rootCmd.AddCommand(NewReleasesCommand(cfg))
+	rootCmd.AddCommand(NewCommandCommand(cfg))
+	rootCmd.AddCommand(NewApplyCommand(cfg))
 
 	// Serverless functions (scale-to-zero)
 	rootCmd.AddCommand(NewFunctionsCommand(cfg))
@@ -56,10 +60,43 @@ Learn more at https://enclii.dev`,
 	rootCmd.AddCommand(NewLoginCommand(cfg))
 	rootCmd.AddCommand(NewLogoutCommand(cfg))
 	rootCmd.AddCommand(NewWhoamiCommand(cfg))
+	rootCmd.AddCommand(NewContextCommand(cfg))
+
+	// Shell completion of resource names
+	rootCmd.AddCommand(newCompletionCacheRefreshCommand(cfg))
+	registerEnvironmentCompletion(rootCmd, cfg)
 
 	return rootCmd
 }
 
+// applyGlobalFlags updates the configuration from the global flags
+func applyGlobalFlags(cmd *cobra.Command, cfg *config.Config) error {
+	// A --context override is applied first so explicit flags still win
+	if name, _ := cmd.Flags().GetString("context"); name != "" {
+		if err := cfg.UseContext(name); err != nil {
+			return err
+		}
+	}
+
+	// Bind flags to viper and update config with flag values
+	if endpoint, _ := cmd.Flags().GetString("api-endpoint"); endpoint != "" && cmd.Flags().Changed("api-endpoint") {
+		cfg.APIEndpoint = endpoint
+	}
+	if token, _ := cmd.Flags().GetString("api-token"); token != "" && cmd.Flags().Changed("api-token") {
+		cfg.APIToken = token
+	}
+	return nil
+}
+
+// targetEnvironment returns the --env flag value, falling back to the
+// active context's default environment when the flag was not given
+func targetEnvironment(cmd *cobra.Command, cfg *config.Config, flagValue string) string {
+	if !cmd.Flags().Changed("env") && cfg.DefaultEnvironment != "" {
+		return cfg.DefaultEnvironment
+	}
+	return flagValue
+}
+
 func NewVersionCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// jobPollInterval is how often a job is checked for its exit code once its
+// output ends
+const jobPollInterval = 2 * time.Second
+
+// ExitCodeError reports that a remote command exited with a non-zero code,
+// which the CLI exits with in turn
+type ExitCodeError struct {
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("remote command exited with code %d", e.Code)
+}
+
+func NewRunCommand(cfg *config.Config) *cobra.Command {
+	var environment string
+	var specFile string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "run <service> -- <command> [args...]",
+		Short: "Run a one-off command in a deployed service",
+		Long: `Run a command once in the image, environment variables, and secrets a
+service is deployed with, such as a database migration or a maintenance
+script. The command's output streams to the terminal and enclii exits with
+the command's exit code.
+
+The command runs as its own job next to the service and is not retried.
+Stopping enclii with Ctrl+C stops following the output; the command keeps
+running until it exits or reaches --timeout.
+
+Examples:
+  # Migrate the staging database
+  enclii run api --env staging -- rails db:migrate
+
+  # Commands are not interactive, but their output pipes like a local one
+  enclii run worker -- ./bin/report --month 2026-09 > report.csv`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if dash := cmd.ArgsLenAtDash(); dash == 0 || dash > 1 {
+				return fmt.Errorf("expected one service before --, got %d", dash)
+			}
+			if len(args) < 2 {
+				return fmt.Errorf("requires a service and a command: enclii run <service> -- <command>")
+			}
+			return nil
+		},
+		ValidArgsFunction: completeServices(cfg, false),
+		SilenceUsage:      true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runJob(cfg, resolveProjectSlug(specFile, cfg), targetEnvironment(cmd, cfg, environment), args[0], args[1:], timeout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&environment, "env", "e", "dev", "Environment whose deployment to run in")
+	cmd.Flags().StringVarP(&specFile, "file", "F", "service.yaml", "Path to service.yaml specification file")
+	cmd.Flags().DurationVar(&timeout, "timeout", time.Hour, "Stop the command after this long (at most 24h)")
+
+	return cmd
+}
+
+// runJob starts a one-off job, streams its output to stdout, and returns an
+// ExitCodeError when the command fails
+func runJob(cfg *config.Config, projectSlug, environment, serviceName string, command []string, timeout time.Duration) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	service, err := findService(ctx, apiClient, projectSlug, serviceName)
+	if err != nil {
+		return err
+	}
+
+	job, err := apiClient.RunJob(ctx, service.ID.String(), client.RunJobRequest{
+		Environment:    environment,
+		Command:        command,
+		TimeoutSeconds: int(timeout / time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Progress goes to stderr so the command's output can be piped
+	fmt.Fprintf(os.Stderr, "🏃 Running %q in %s (%s)\n", strings.Join(command, " "), service.Name, environment)
+
+	if err := followJobOutput(ctx, apiClient, job.ID.String()); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+
+	finished, err := waitForJob(ctx, apiClient, job.ID.String())
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintf(os.Stderr, "\n⏹️  Stopped following job %s; the command keeps running\n", job.K8sJobName)
+			return nil
+		}
+		return err
+	}
+
+	switch {
+	case finished.Status == types.OneOffJobStatusSucceeded:
+		fmt.Fprintln(os.Stderr, "✅ Command succeeded")
+		return nil
+	case finished.ExitCode != nil:
+		return &ExitCodeError{Code: *finished.ExitCode}
+	default:
+		return fmt.Errorf("job failed: %s", finished.Message)
+	}
+}
+
+// followJobOutput prints the output of a job until its command exits
+func followJobOutput(ctx context.Context, apiClient *client.APIClient, jobID string) error {
+	logs, errs, err := apiClient.StreamJobLogs(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	for msg := range logs {
+		switch msg.Type {
+		case "log":
+			fmt.Println(msg.Message)
+		case "error":
+			fmt.Fprintf(os.Stderr, "❌ %s\n", msg.Message)
+		}
+	}
+	return <-errs
+}
+
+// waitForJob polls a job until it finishes
+func waitForJob(ctx context.Context, apiClient *client.APIClient, jobID string) (*types.OneOffJob, error) {
+	for {
+		job, err := apiClient.GetJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status == types.OneOffJobStatusSucceeded || job.Status == types.OneOffJobStatusFailed {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
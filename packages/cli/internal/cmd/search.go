@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+)
+
+func NewSearchCommand(cfg *config.Config) *cobra.Command {
+	var kinds []string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search projects, services, deployments and domains",
+		Long: `Search the projects, services, deployments and domains you can access by
+name, slug, domain, git repo, image or version. Matching is fuzzy: the
+characters of the query must appear in order, and closer matches rank first.
+
+Examples:
+  # Find anything named like "api"
+  enclii search api
+
+  # Find domains only
+  enclii search acme.com --type domain
+
+  # Find the deployments running an image
+  enclii search ghcr.io/acme/web --type deployment`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+			response, err := apiClient.Search(ctx, strings.Join(args, " "), kinds, limit)
+			if err != nil {
+				return err
+			}
+			if len(response.Results) == 0 {
+				fmt.Printf("No results for %q\n", response.Query)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TYPE\tNAME\tPROJECT\tMATCHED\tID")
+			for _, r := range response.Results {
+				matched := r.MatchedField
+				if r.Matched != r.Title {
+					matched = fmt.Sprintf("%s: %s", r.MatchedField, r.Matched)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Type, r.Title, r.ProjectSlug, matched, r.ID)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&kinds, "type", nil, "Only return these types: project, service, deployment, domain")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Maximum results (default 20, at most 50)")
+
+	return cmd
+}
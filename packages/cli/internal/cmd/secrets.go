@@ -39,13 +39,23 @@ Examples:
   enclii secrets delete API_KEY
 
   # Set multiple variables at once
-  enclii secrets set API_KEY=xxx DB_URL=postgres://... --secret`,
+  enclii secrets set API_KEY=xxx DB_URL=postgres://... --secret
+
+  # Download the variables of staging into .env, and upload them back
+  enclii env pull --env staging
+  enclii env push --env staging --overwrite
+
+  # Point preview environments at a sandbox API
+  enclii secrets preview set PAYMENTS_URL=https://sandbox.payments.example.com`,
 	}
 
 	cmd.AddCommand(newSecretsSetCommand(cfg))
 	cmd.AddCommand(newSecretsListCommand(cfg))
 	cmd.AddCommand(newSecretsDeleteCommand(cfg))
 	cmd.AddCommand(newSecretsGetCommand(cfg))
+	cmd.AddCommand(newSecretsPreviewCommand(cfg))
+	cmd.AddCommand(newSecretsPullCommand(cfg))
+	cmd.AddCommand(newSecretsPushCommand(cfg))
 
 	return cmd
 }
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/cli/internal/spec"
+)
+
+// newSecretsPullCommand creates the 'secrets pull' subcommand
+func newSecretsPullCommand(cfg *config.Config) *cobra.Command {
+	var envName string
+	var specFile string
+	var output string
+	var includeSecrets bool
+
+	cmd := &cobra.Command{
+		Use:   "pull",
+		Short: "Download environment variables into a .env file",
+		Long: `Download the environment variables of a service into a .env file.
+
+With --env, the file holds the variables that environment's deployments get;
+otherwise the ones that apply to all environments.
+
+Secret values are left out, listed as comments, unless --include-secrets is
+set. Including them needs permission to reveal secrets and is logged for
+audit purposes.
+
+Examples:
+  enclii env pull
+  enclii env pull --env staging -o .env.staging
+  enclii env pull --env production --include-secrets -o -`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSecretsPull(cfg, envName, specFile, output, includeSecrets)
+		},
+	}
+
+	cmd.Flags().StringVarP(&envName, "env", "e", "", "Environment to pull (default: variables of all environments)")
+	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml specification file")
+	cmd.Flags().StringVarP(&output, "output", "o", ".env", "File to write, or - for stdout")
+	cmd.Flags().BoolVar(&includeSecrets, "include-secrets", false, "Include secret values (logged for audit)")
+
+	return cmd
+}
+
+// newSecretsPushCommand creates the 'secrets push' subcommand
+func newSecretsPushCommand(cfg *config.Config) *cobra.Command {
+	var envName string
+	var specFile string
+	var input string
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: "Upload environment variables from a .env file",
+		Long: `Upload the variables of a .env file to a service.
+
+Variables that already exist are skipped unless --overwrite is set.
+Overwritten variables stay secret or plain as they were; new variables are
+stored as secrets when their key looks sensitive (TOKEN, PASSWORD, KEY, ...).
+
+Examples:
+  enclii env push
+  enclii env push --env staging -i .env.staging --overwrite`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSecretsPush(cfg, envName, specFile, input, overwrite)
+		},
+	}
+
+	cmd.Flags().StringVarP(&envName, "env", "e", "", "Target environment (default: all environments)")
+	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml specification file")
+	cmd.Flags().StringVarP(&input, "input", "i", ".env", "File to read")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite variables that already exist")
+
+	return cmd
+}
+
+// resolveSecretsTarget returns the service of specFile and the ID of its
+// environment envName, nil when envName is empty
+func resolveSecretsTarget(ctx context.Context, apiClient *client.APIClient, specFile, envName string) (*client.ServiceInfo, *string, error) {
+	parser := spec.NewParser()
+	serviceSpec, err := parser.ParseServiceSpec(specFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", specFile, err)
+	}
+
+	service, err := getServiceByName(ctx, apiClient, serviceSpec.Metadata.Project, serviceSpec.Metadata.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find service: %w", err)
+	}
+
+	if envName == "" {
+		return service, nil, nil
+	}
+	env, err := getEnvironmentByName(ctx, apiClient, serviceSpec.Metadata.Project, envName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find environment %s: %w", envName, err)
+	}
+	id := env.ID.String()
+	return service, &id, nil
+}
+
+// runSecretsPull implements the secrets pull command
+func runSecretsPull(cfg *config.Config, envName, specFile, output string, includeSecrets bool) error {
+	ctx := context.Background()
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	service, envID, err := resolveSecretsTarget(ctx, apiClient, specFile, envName)
+	if err != nil {
+		return err
+	}
+
+	dotenv, err := apiClient.ExportEnvVars(ctx, service.ID.String(), envID, includeSecrets)
+	if err != nil {
+		return err
+	}
+
+	if output == "-" {
+		fmt.Print(dotenv)
+	} else {
+		// Owner-only, the file may hold secret values
+		if err := os.WriteFile(output, []byte(dotenv), 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ Wrote %s\n", output)
+	}
+
+	if includeSecrets {
+		fmt.Fprintf(os.Stderr, "⚠️  Secrets exported - this action has been logged\n")
+	} else if strings.Contains(dotenv, "(secret, not exported)") {
+		fmt.Fprintf(os.Stderr, "💡 Secret values were left out; use --include-secrets to export them\n")
+	}
+
+	return nil
+}
+
+// runSecretsPush implements the secrets push command
+func runSecretsPush(cfg *config.Config, envName, specFile, input string, overwrite bool) error {
+	ctx := context.Background()
+
+	dotenv, err := os.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", input, err)
+	}
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	service, envID, err := resolveSecretsTarget(ctx, apiClient, specFile, envName)
+	if err != nil {
+		return err
+	}
+
+	strategy := "skip"
+	if overwrite {
+		strategy = "overwrite"
+	}
+
+	result, err := apiClient.ImportEnvVars(ctx, service.ID.String(), string(dotenv), envID, strategy)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Imported %s: %d created, %d updated, %d skipped\n", input, result.Created, result.Updated, result.Skipped)
+	if len(result.SkippedKeys) > 0 && !overwrite {
+		fmt.Printf("💡 Skipped existing %s; use --overwrite to replace them\n", strings.Join(result.SkippedKeys, ", "))
+	}
+	if result.Created+result.Updated > 0 {
+		fmt.Printf("💡 Run 'enclii deploy' to apply changes to your running service\n")
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/cli/internal/spec"
+)
+
+// newSecretsPreviewCommand creates the 'secrets preview' subcommand
+func newSecretsPreviewCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Manage environment variable overrides for preview environments",
+		Long: `Manage environment variables that apply only to the preview environments
+of a service, on top of its own variables. Use them to point pull request
+previews at sandbox APIs.
+
+Overrides are picked up by each preview on its next deploy.
+
+Examples:
+  # Point previews at the payments sandbox
+  enclii secrets preview set PAYMENTS_URL=https://sandbox.payments.example.com
+
+  # List the preview overrides
+  enclii secrets preview list
+
+  # Remove all preview overrides
+  enclii secrets preview clear`,
+	}
+
+	cmd.AddCommand(newSecretsPreviewSetCommand(cfg))
+	cmd.AddCommand(newSecretsPreviewListCommand(cfg))
+	cmd.AddCommand(newSecretsPreviewClearCommand(cfg))
+
+	return cmd
+}
+
+// newSecretsPreviewSetCommand creates the 'secrets preview set' subcommand
+func newSecretsPreviewSetCommand(cfg *config.Config) *cobra.Command {
+	var secretKeys []string
+	var specFile string
+
+	cmd := &cobra.Command{
+		Use:   "set KEY=VALUE [KEY2=VALUE2 ...]",
+		Short: "Replace the preview overrides of a service",
+		Long: `Replace the preview overrides of a service with the given variables.
+Overrides not listed are removed.
+
+Use --secret KEY to store a variable as a secret (masked in responses).
+
+Examples:
+  enclii secrets preview set PAYMENTS_URL=https://sandbox.payments.example.com
+  enclii secrets preview set PAYMENTS_URL=https://sandbox.payments.example.com PAYMENTS_KEY=sk_test --secret PAYMENTS_KEY`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSecretsPreviewSet(cfg, args, secretKeys, specFile)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&secretKeys, "secret", "s", nil, "Keys to store as secrets (encrypted, masked)")
+	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml specification file")
+
+	return cmd
+}
+
+// newSecretsPreviewListCommand creates the 'secrets preview list' subcommand
+func newSecretsPreviewListCommand(cfg *config.Config) *cobra.Command {
+	var specFile string
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the preview overrides of a service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSecretsPreviewList(cfg, specFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml specification file")
+
+	return cmd
+}
+
+// newSecretsPreviewClearCommand creates the 'secrets preview clear' subcommand
+func newSecretsPreviewClearCommand(cfg *config.Config) *cobra.Command {
+	var specFile string
+
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all preview overrides of a service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSecretsPreviewReplace(cfg, nil, specFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&specFile, "file", "f", "service.yaml", "Path to service.yaml specification file")
+
+	return cmd
+}
+
+// runSecretsPreviewSet implements the secrets preview set command
+func runSecretsPreviewSet(cfg *config.Config, keyValues, secretKeys []string, specFile string) error {
+	secret := make(map[string]bool, len(secretKeys))
+	for _, key := range secretKeys {
+		secret[key] = true
+	}
+
+	vars, err := parsePreviewEnvVars(keyValues, secret)
+	if err != nil {
+		return err
+	}
+
+	return runSecretsPreviewReplace(cfg, vars, specFile)
+}
+
+// parsePreviewEnvVars parses KEY=VALUE pairs, marking the keys in secret as secrets
+func parsePreviewEnvVars(keyValues []string, secret map[string]bool) ([]client.EnvVarRequest, error) {
+	vars := make([]client.EnvVarRequest, 0, len(keyValues))
+	seen := make(map[string]bool, len(keyValues))
+	for _, kv := range keyValues {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid format for %q, expected KEY=VALUE", kv)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("%s is set more than once", parts[0])
+		}
+		seen[parts[0]] = true
+		vars = append(vars, client.EnvVarRequest{
+			Key:      parts[0],
+			Value:    parts[1],
+			IsSecret: secret[parts[0]],
+		})
+	}
+
+	for key := range secret {
+		if !seen[key] {
+			return nil, fmt.Errorf("--secret %s does not match any KEY=VALUE", key)
+		}
+	}
+
+	return vars, nil
+}
+
+// runSecretsPreviewReplace replaces the preview overrides of the service in specFile
+func runSecretsPreviewReplace(cfg *config.Config, vars []client.EnvVarRequest, specFile string) error {
+	ctx := context.Background()
+
+	// Parse service.yaml
+	parser := spec.NewParser()
+	serviceSpec, err := parser.ParseServiceSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", specFile, err)
+	}
+
+	// Create API client
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	// Get service
+	service, err := getServiceByName(ctx, apiClient, serviceSpec.Metadata.Project, serviceSpec.Metadata.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find service: %w", err)
+	}
+
+	if _, err := apiClient.ReplacePreviewEnvVars(ctx, service.ID.String(), vars); err != nil {
+		return fmt.Errorf("failed to set preview overrides: %w", err)
+	}
+
+	if len(vars) == 0 {
+		fmt.Println("✅ Removed all preview overrides")
+	} else {
+		fmt.Printf("✅ Set %d preview overrides\n", len(vars))
+	}
+	fmt.Printf("💡 Previews pick up the change on their next deploy\n")
+
+	return nil
+}
+
+// runSecretsPreviewList implements the secrets preview list command
+func runSecretsPreviewList(cfg *config.Config, specFile string) error {
+	ctx := context.Background()
+
+	// Parse service.yaml
+	parser := spec.NewParser()
+	serviceSpec, err := parser.ParseServiceSpec(specFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", specFile, err)
+	}
+
+	// Create API client
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	// Get service
+	service, err := getServiceByName(ctx, apiClient, serviceSpec.Metadata.Project, serviceSpec.Metadata.Name)
+	if err != nil {
+		return fmt.Errorf("failed to find service: %w", err)
+	}
+
+	vars, err := apiClient.ListPreviewEnvVars(ctx, service.ID.String())
+	if err != nil {
+		return fmt.Errorf("failed to list preview overrides: %w", err)
+	}
+
+	if len(vars) == 0 {
+		fmt.Println("No preview overrides found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSECRET")
+	for _, ev := range vars {
+		secretIcon := ""
+		if ev.IsSecret {
+			secretIcon = "🔒"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", ev.Key, ev.Value, secretIcon)
+	}
+	w.Flush()
+
+	return nil
+}
@@ -25,7 +25,9 @@ func NewServicesDeleteCommand(cfg *config.Config) *cobra.Command {
 		Short: "Delete a service from a project",
 		Long: `Deletes a service and all associated resources (deployments, releases, etc.).
 
-This operation is irreversible. Use with caution.
+The service is scaled to zero and moved to the trash, where an admin can
+restore it until the retention window ends (7 days by default). After that
+it is purged for good.
 
 Examples:
   # Delete a service by name within a project
@@ -104,7 +106,7 @@ func runServicesDelete(cfg *config.Config, projectSlug, serviceName, serviceID s
 
 	// Confirm deletion unless --force is set
 	if !force {
-		fmt.Printf("WARNING: This will permanently delete the service and all associated resources:\n")
+		fmt.Printf("WARNING: This will delete the service and, once the trash retention window ends, all associated resources:\n")
 		fmt.Printf("  - All deployments\n")
 		fmt.Printf("  - All releases\n")
 		fmt.Printf("  - All environment variables\n")
@@ -138,6 +140,6 @@ func runServicesDelete(cfg *config.Config, projectSlug, serviceName, serviceID s
 		return fmt.Errorf("failed to delete service: %w", err)
 	}
 
-	fmt.Println("Service deleted successfully.")
+	fmt.Println("Service moved to trash. An admin can restore it until the retention window ends.")
 	return nil
 }
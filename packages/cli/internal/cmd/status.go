@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// statusRefreshInterval is how often the dashboard refreshes without events,
+	// since health changes are not all published as events
+	statusRefreshInterval = 15 * time.Second
+
+	// statusEventDebounce coalesces bursts of events into one refresh
+	statusEventDebounce = 500 * time.Millisecond
+
+	// statusEventHistory is how many recent events the dashboard lists
+	statusEventHistory = 8
+)
+
+// Terminal control sequences of the dashboard
+const (
+	ansiAltScreen     = "\033[?1049h"
+	ansiMainScreen    = "\033[?1049l"
+	ansiHideCursor    = "\033[?25l"
+	ansiShowCursor    = "\033[?25h"
+	ansiClearAndHome  = "\033[H\033[2J"
+	ansiBold          = "\033[1m"
+	ansiGray          = "\033[90m"
+	ansiStatusUnknown = "\033[37m"
+)
+
+func NewStatusCommand(cfg *config.Config) *cobra.Command {
+	var watch bool
+	var environment string
+	var specFile string
+
+	cmd := &cobra.Command{
+		Use:   "status [project]",
+		Short: "Show project status",
+		Long: `Show the services of a project with their deployment status and health in
+each environment, recent builds, and build queue pressure.
+
+With --watch the status is rendered as a live dashboard, refreshed as
+deployment, build, and addon events arrive. Press Ctrl+C to quit.
+
+Examples:
+  # Show the status of the current project once
+  enclii status
+
+  # Watch a project live, only showing production deployments
+  enclii status shop --watch --env production`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProjects(cfg),
+		Annotations:       map[string]string{projectArgAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectSlug := resolveProjectSlug(specFile, cfg)
+			if len(args) > 0 {
+				projectSlug = args[0]
+			}
+			if watch {
+				return watchStatus(cfg, projectSlug, environment)
+			}
+			return showStatus(cfg, projectSlug, environment)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Live dashboard refreshed by status events")
+	cmd.Flags().StringVarP(&environment, "env", "e", "", "Only show deployments to this environment")
+	cmd.Flags().StringVarP(&specFile, "file", "F", "service.yaml", "Path to service.yaml specification file")
+
+	return cmd
+}
+
+// showStatus prints the status of a project once
+func showStatus(cfg *config.Config, projectSlug, environment string) error {
+	ctx := context.Background()
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	status, err := apiClient.GetProjectStatus(ctx, projectSlug)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(renderStatus(status, environment, nil, ""))
+	return nil
+}
+
+// watchStatus renders a project's status as a live dashboard until Ctrl+C
+func watchStatus(cfg *config.Config, projectSlug, environment string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	apiClient := client.NewAPIClient(cfg.APIEndpoint, cfg.APIToken)
+
+	// Fail before taking over the terminal
+	status, err := apiClient.GetProjectStatus(ctx, projectSlug)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(ansiAltScreen + ansiHideCursor)
+	defer fmt.Print(ansiShowCursor + ansiMainScreen)
+
+	var recent []client.StatusEvent
+	var eventChan <-chan client.StatusEvent
+	connect := func() {
+		events, _, err := apiClient.StreamEvents(ctx, status.ProjectID.String())
+		if err == nil {
+			eventChan = events
+		}
+	}
+	connect()
+
+	draw := func() {
+		mode := "live"
+		if eventChan == nil {
+			mode = fmt.Sprintf("polling every %s", statusRefreshInterval)
+		}
+		fmt.Print(ansiClearAndHome + renderStatus(status, environment, recent, mode))
+	}
+	draw()
+
+	ticker := time.NewTicker(statusRefreshInterval)
+	defer ticker.Stop()
+	debounce := time.NewTimer(statusEventDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-eventChan:
+			if !ok {
+				// Reconnected on the next tick
+				eventChan = nil
+				draw()
+				continue
+			}
+			recent = append([]client.StatusEvent{event}, recent...)
+			if len(recent) > statusEventHistory {
+				recent = recent[:statusEventHistory]
+			}
+			debounce.Reset(statusEventDebounce)
+
+		case <-debounce.C:
+			if refreshed, err := apiClient.GetProjectStatus(ctx, projectSlug); err == nil {
+				status = refreshed
+			}
+			draw()
+
+		case <-ticker.C:
+			if eventChan == nil {
+				connect()
+			}
+			if refreshed, err := apiClient.GetProjectStatus(ctx, projectSlug); err == nil {
+				status = refreshed
+			}
+			draw()
+		}
+	}
+}
+
+// renderStatus lays out a project status. Recent events and the refresh mode
+// are only shown by the live dashboard.
+func renderStatus(status *types.ProjectStatus, environment string, recent []client.StatusEvent, mode string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s📊 %s%s  %s%s", ansiBold, status.ProjectSlug, colorReset, ansiGray, status.GeneratedAt.Local().Format("15:04:05"))
+	if mode != "" {
+		fmt.Fprintf(&b, " · %s · Ctrl+C to quit", mode)
+	}
+	b.WriteString(colorReset + "\n\n")
+
+	// Services
+	fmt.Fprintf(&b, "%sSERVICES%s\n", ansiBold, colorReset)
+	fmt.Fprintf(&b, "%-20s %-12s %-10s %-10s %-9s %-28s %s\n", "NAME", "ENV", "STATUS", "HEALTH", "REPLICAS", "VERSION", "UPDATED")
+	if len(status.Services) == 0 {
+		b.WriteString("No services in this project\n")
+	}
+	for _, service := range status.Services {
+		var deployments []types.DeploymentStatusEntry
+		for _, d := range service.Deployments {
+			if environment == "" || d.Environment == environment {
+				deployments = append(deployments, d)
+			}
+		}
+		if len(deployments) == 0 {
+			fmt.Fprintf(&b, "%-20s %s%s%s\n", truncate(service.Name, 20), ansiGray, "not deployed", colorReset)
+			continue
+		}
+		for i, d := range deployments {
+			name := ""
+			if i == 0 {
+				name = truncate(service.Name, 20)
+			}
+			fmt.Fprintf(&b, "%-20s %-12s %s %s %-9d %-28s %s\n",
+				name,
+				truncate(d.Environment, 12),
+				colored(getStatusColor(string(d.Status)), fmt.Sprintf("%-10s", d.Status)),
+				colored(getHealthColor(string(d.Health)), fmt.Sprintf("%-10s", d.Health)),
+				d.Replicas,
+				truncate(d.Version, 28),
+				formatDuration(time.Since(d.UpdatedAt)))
+		}
+	}
+	b.WriteString("\n")
+
+	// Builds
+	fmt.Fprintf(&b, "%sRECENT BUILDS%s\n", ansiBold, colorReset)
+	if len(status.RecentBuilds) == 0 {
+		b.WriteString("No builds yet\n")
+	} else {
+		fmt.Fprintf(&b, "%-20s %-28s %-9s %-10s %s\n", "SERVICE", "VERSION", "COMMIT", "STATUS", "AGE")
+		for _, build := range status.RecentBuilds {
+			fmt.Fprintf(&b, "%-20s %-28s %-9s %s %s\n",
+				truncate(build.ServiceName, 20),
+				truncate(build.Version, 28),
+				truncate(build.GitSHA, 7),
+				colored(buildStatusColor(build.Status), fmt.Sprintf("%-10s", build.Status)),
+				formatDuration(time.Since(build.CreatedAt)))
+		}
+	}
+	b.WriteString("\n")
+
+	// Queue
+	q := status.Queue
+	fmt.Fprintf(&b, "%sBUILD QUEUE%s\n", ansiBold, colorReset)
+	fmt.Fprintf(&b, "Project:  %d queued, %d building", q.ProjectQueued, q.ProjectBuilding)
+	if q.OldestQueuedAt != nil {
+		fmt.Fprintf(&b, " (oldest waiting %s)", formatDuration(time.Since(*q.OldestQueuedAt)))
+	}
+	fmt.Fprintf(&b, "\nPlatform: %d queued, %d building\n", q.Queued, q.Building)
+
+	// Events
+	if mode != "" {
+		fmt.Fprintf(&b, "\n%sEVENTS%s\n", ansiBold, colorReset)
+		if len(recent) == 0 {
+			b.WriteString("Waiting for events...\n")
+		}
+		for _, event := range recent {
+			fmt.Fprintf(&b, "%s%s%s %-18s %-10s %s\n",
+				ansiGray, event.Timestamp.Local().Format("15:04:05"), colorReset,
+				event.Type, event.Status, event.Message)
+		}
+	}
+
+	return b.String()
+}
+
+func buildStatusColor(status types.ReleaseStatus) string {
+	switch status {
+	case types.ReleaseStatusReady:
+		return "\033[32m" // Green
+	case types.ReleaseStatusBuilding:
+		return "\033[33m" // Yellow
+	case types.ReleaseStatusFailed:
+		return "\033[31m" // Red
+	default:
+		return ansiStatusUnknown
+	}
+}
+
+// colored wraps already padded text in a color, so colors do not count
+// towards column widths
+func colored(color, text string) string {
+	return color + text + colorReset
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 1 {
+		return s[:n]
+	}
+	return s[:n-1] + "…"
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/cli/internal/config"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+func NewUpCommand(cfg *config.Config) *cobra.Command {
+	var environment string
+	opts := deployOptions{local: true}
+
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "Build and deploy the working directory",
+		Long: `Upload the working directory, including uncommitted changes, build it, and
+deploy it to a non-production environment. Equivalent to "enclii deploy --local".
+
+Files matched by .dockerignore and the .git directory are not uploaded.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.environment = targetEnvironment(cmd, cfg, environment)
+			return deployService(cfg, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&environment, "env", "e", "dev", "Environment to deploy to (dev, staging)")
+	cmd.Flags().BoolVarP(&opts.wait, "wait", "w", false, "Wait for deployment to complete")
+	cmd.Flags().StringVarP(&opts.specFile, "file", "f", "service.yaml", "Path to service.yaml specification file")
+
+	return cmd
+}
+
+// isProductionEnvironment reports whether local builds must be refused for an environment
+func isProductionEnvironment(environment string) bool {
+	switch strings.ToLower(environment) {
+	case "prod", "production":
+		return true
+	}
+	return false
+}
+
+// buildFromWorkingDirectory uploads the working directory as a build context
+// and triggers a build of it
+func buildFromWorkingDirectory(ctx context.Context, apiClient *client.APIClient, serviceID string) (*types.Release, error) {
+	archive, err := os.CreateTemp("", "enclii-context-*.tar.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := writeBuildContext(archive, "."); err != nil {
+		return nil, fmt.Errorf("failed to archive working directory: %w", err)
+	}
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("📤 Uploading build context (%.1f MB)...\n", float64(size)/(1024*1024))
+	bc, err := apiClient.UploadBuildContext(ctx, serviceID, archive, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiClient.BuildServiceFromContext(ctx, serviceID, bc.ID)
+}
+
+// writeBuildContext writes a gzipped tarball of dir to w, skipping .git and
+// anything matched by dir's .dockerignore
+func writeBuildContext(w io.Writer, dir string) error {
+	ignore, err := readDockerignore(filepath.Join(dir, ".dockerignore"))
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == ".git" || isIgnored(ignore, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Symlinks and special files are not part of a build context
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// readDockerignore returns the patterns of a .dockerignore file, if any
+func readDockerignore(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.Trim(filepath.ToSlash(line), "/"))
+	}
+	return patterns, scanner.Err()
+}
+
+// isIgnored reports whether a slash-separated relative path matches a
+// .dockerignore pattern. Patterns match the path itself or any of its parent
+// directories; "!" exceptions are not supported.
+func isIgnored(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		}
+		for p := rel; p != "." && p != ""; p = filepath.ToSlash(filepath.Dir(p)) {
+			if ok, _ := filepath.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
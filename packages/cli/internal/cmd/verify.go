@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/madfam-org/enclii/packages/cli/internal/client"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+const (
+	// defaultVerifyTimeout bounds one attempt of a verification check
+	defaultVerifyTimeout = 10 * time.Second
+
+	// verifyRetryDelay is the pause between attempts of a failing check
+	verifyRetryDelay = 5 * time.Second
+
+	// soakStartTimeout is how long a deployment may go without a soak before
+	// the environment is assumed to have no soak policy
+	soakStartTimeout = 2 * time.Minute
+)
+
+// verificationChecks returns the checks of a spec, or a request of the runtime
+// health check path when the spec configures none
+func verificationChecks(serviceSpec *types.ServiceSpec) []types.VerifyCheck {
+	if serviceSpec != nil && serviceSpec.Spec.Verify != nil && len(serviceSpec.Spec.Verify.Checks) > 0 {
+		return serviceSpec.Spec.Verify.Checks
+	}
+
+	path := "/health"
+	if serviceSpec != nil && serviceSpec.Spec.Runtime.HealthCheck != "" {
+		path = serviceSpec.Spec.Runtime.HealthCheck
+	}
+	return []types.VerifyCheck{{Name: "health", Path: path, Retries: 3}}
+}
+
+// verifyDeployment runs the verification checks of a deployment against
+// baseURL and, when the spec asks for it, waits for the deployment's soak.
+// It returns an error naming every failed check.
+func verifyDeployment(ctx context.Context, apiClient *client.APIClient, serviceSpec *types.ServiceSpec, baseURL, deploymentID string) error {
+	httpClient := &http.Client{}
+
+	var failed []string
+	for _, check := range verificationChecks(serviceSpec) {
+		if err := runVerifyCheck(ctx, httpClient, baseURL, check); err != nil {
+			fmt.Printf("   ❌ %s: %v\n", check.Name, err)
+			failed = append(failed, check.Name)
+			continue
+		}
+		fmt.Printf("   ✅ %s\n", check.Name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("verification checks failed: %s", strings.Join(failed, ", "))
+	}
+
+	if serviceSpec != nil && serviceSpec.Spec.Verify != nil && serviceSpec.Spec.Verify.Soak {
+		fmt.Println("⏳ Waiting for soak...")
+		if err := waitForSoak(ctx, apiClient, deploymentID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runVerifyCheck requests a check's path until it answers as expected or its
+// attempts are exhausted
+func runVerifyCheck(ctx context.Context, httpClient *http.Client, baseURL string, check types.VerifyCheck) error {
+	timeout := defaultVerifyTimeout
+	if check.TimeoutSeconds > 0 {
+		timeout = time.Duration(check.TimeoutSeconds) * time.Second
+	}
+
+	var err error
+	for attempt := 0; attempt <= check.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(verifyRetryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = checkResponse(attemptCtx, httpClient, strings.TrimRight(baseURL, "/")+check.Path, check)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// checkResponse makes one request of a check and compares the response with
+// the expected status and body
+func checkResponse(ctx context.Context, httpClient *http.Client, url string, check types.VerifyCheck) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	expectStatus := check.ExpectStatus
+	if expectStatus == 0 {
+		expectStatus = http.StatusOK
+	}
+	if resp.StatusCode != expectStatus {
+		return fmt.Errorf("GET %s returned %d, expected %d", check.Path, resp.StatusCode, expectStatus)
+	}
+
+	if check.ExpectBody != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		if !strings.Contains(string(body), check.ExpectBody) {
+			return fmt.Errorf("GET %s response does not contain %q", check.Path, check.ExpectBody)
+		}
+	}
+
+	return nil
+}
+
+// waitForSoak waits until the soak of a deployment completes. A deployment
+// that is not soaked within soakStartTimeout is treated as having no soak.
+func waitForSoak(ctx context.Context, apiClient *client.APIClient, deploymentID string) error {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	started := time.Now()
+
+	for {
+		soak, err := apiClient.GetDeploymentSoak(ctx, deploymentID)
+		var apiErr client.APIError
+		switch {
+		case err == nil:
+			switch soak.Status {
+			case types.SoakStatusPassed:
+				fmt.Println("   ✅ soak passed")
+				return nil
+			case types.SoakStatusFailed:
+				return fmt.Errorf("soak failed: %s", soak.Message)
+			}
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+			if time.Since(started) > soakStartTimeout {
+				fmt.Println("   ℹ️  The environment has no soak policy; skipping soak")
+				return nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
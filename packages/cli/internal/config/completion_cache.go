@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Kinds of resources kept in the completion cache
+const (
+	CompletionProjects     = "projects"
+	CompletionServices     = "services"
+	CompletionEnvironments = "environments"
+)
+
+// CompletionList is one cached list of resource names
+type CompletionList struct {
+	Names     []string  `json:"names"`
+	FetchedAt time.Time `json:"fetched_at"`
+
+	// AttemptedAt is the last refresh attempt, successful or not, so an
+	// unreachable API is not asked again on every completion
+	AttemptedAt time.Time `json:"attempted_at,omitempty"`
+}
+
+// Stale reports whether the list is due for a refresh
+func (l CompletionList) Stale(now time.Time, ttl time.Duration) bool {
+	last := l.FetchedAt
+	if l.AttemptedAt.After(last) {
+		last = l.AttemptedAt
+	}
+	return now.Sub(last) > ttl
+}
+
+// CompletionCache holds the resource names shell completion offers, per API
+// endpoint, so completion answers instantly and works offline
+type CompletionCache struct {
+	Endpoints map[string]map[string]CompletionList `json:"endpoints"`
+}
+
+// GetCompletionCachePath returns the path of the completion cache, next to
+// the config file
+func GetCompletionCachePath(cfg *Config) string {
+	return filepath.Join(filepath.Dir(cfg.ConfigFile), "cache", "completion.json")
+}
+
+// LoadCompletionCache reads the completion cache. A missing or unreadable
+// cache yields an empty one, since it can always be refetched.
+func LoadCompletionCache(path string) *CompletionCache {
+	cache := &CompletionCache{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, cache)
+	}
+	if cache.Endpoints == nil {
+		cache.Endpoints = make(map[string]map[string]CompletionList)
+	}
+	return cache
+}
+
+// Save writes the completion cache. The file is replaced atomically because
+// completions read it while a background refresh writes it.
+func (c *CompletionCache) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode completion cache: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".completion-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write completion cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write completion cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write completion cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write completion cache: %w", err)
+	}
+	return nil
+}
+
+// Get returns the cached list of a kind of resource. Services and
+// environments are cached per project; projects ignore the project.
+func (c *CompletionCache) Get(endpoint, kind, project string) (CompletionList, bool) {
+	list, ok := c.Endpoints[endpoint][completionKey(kind, project)]
+	return list, ok
+}
+
+// Set stores a freshly fetched list
+func (c *CompletionCache) Set(endpoint, kind, project string, names []string, now time.Time) {
+	c.lists(endpoint)[completionKey(kind, project)] = CompletionList{Names: names, FetchedAt: now, AttemptedAt: now}
+}
+
+// MarkAttempted records a failed refresh, keeping the names already cached
+func (c *CompletionCache) MarkAttempted(endpoint, kind, project string, now time.Time) {
+	lists := c.lists(endpoint)
+	key := completionKey(kind, project)
+	list := lists[key]
+	list.AttemptedAt = now
+	lists[key] = list
+}
+
+func (c *CompletionCache) lists(endpoint string) map[string]CompletionList {
+	if c.Endpoints == nil {
+		c.Endpoints = make(map[string]map[string]CompletionList)
+	}
+	lists, ok := c.Endpoints[endpoint]
+	if !ok {
+		lists = make(map[string]CompletionList)
+		c.Endpoints[endpoint] = lists
+	}
+	return lists
+}
+
+func completionKey(kind, project string) string {
+	if kind == CompletionProjects {
+		return kind
+	}
+	return kind + "/" + project
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionCache_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "completion.json")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Missing file yields an empty cache
+	cache := LoadCompletionCache(path)
+	_, ok := cache.Get("https://api.enclii.dev", CompletionProjects, "")
+	assert.False(t, ok)
+
+	cache.Set("https://api.enclii.dev", CompletionProjects, "ignored", []string{"shop", "blog"}, now)
+	cache.Set("https://api.enclii.dev", CompletionServices, "shop", []string{"api", "web"}, now)
+	cache.Set("http://localhost:8080", CompletionServices, "shop", []string{"local-api"}, now)
+	require.NoError(t, cache.Save(path))
+
+	loaded := LoadCompletionCache(path)
+	projects, ok := loaded.Get("https://api.enclii.dev", CompletionProjects, "")
+	require.True(t, ok)
+	assert.Equal(t, []string{"shop", "blog"}, projects.Names)
+	assert.True(t, projects.FetchedAt.Equal(now))
+
+	services, ok := loaded.Get("http://localhost:8080", CompletionServices, "shop")
+	require.True(t, ok)
+	assert.Equal(t, []string{"local-api"}, services.Names, "lists are kept per endpoint")
+
+	_, ok = loaded.Get("https://api.enclii.dev", CompletionEnvironments, "shop")
+	assert.False(t, ok)
+}
+
+func TestCompletionCache_Staleness(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache := &CompletionCache{}
+
+	cache.Set("api", CompletionServices, "shop", []string{"api"}, now.Add(-time.Hour))
+	list, _ := cache.Get("api", CompletionServices, "shop")
+	assert.True(t, list.Stale(now, 5*time.Minute))
+
+	// A failed refresh keeps the names but postpones the next attempt
+	cache.MarkAttempted("api", CompletionServices, "shop", now)
+	list, _ = cache.Get("api", CompletionServices, "shop")
+	assert.Equal(t, []string{"api"}, list.Names)
+	assert.False(t, list.Stale(now.Add(time.Minute), 5*time.Minute))
+}
+
+func TestLoadCompletionCache_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "completion.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0600))
+
+	cache := LoadCompletionCache(path)
+	assert.NotNil(t, cache.Endpoints)
+}
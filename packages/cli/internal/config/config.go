@@ -35,6 +35,11 @@ type Config struct {
 	Project    string
 	ProjectDir string
 	ConfigFile string
+
+	// Context defaults (see contexts.go)
+	Context            string // Name of the active context, empty when none is selected
+	Team               string
+	DefaultEnvironment string // Target environment used when --env is not given
 }
 
 func Load() (*Config, error) {
@@ -49,6 +54,9 @@ func Load() (*Config, error) {
 	viper.SetDefault("project", "default")
 	viper.SetDefault("project-dir", ".")
 	viper.SetDefault("config-file", os.Getenv("HOME")+"/.enclii/config.yml")
+	viper.SetDefault("team", "")
+	viper.SetDefault("default-env", "")
+	viper.SetDefault("context", "")
 
 	// Parse log level
 	logLevelStr := viper.GetString("log-level")
@@ -65,6 +73,27 @@ func Load() (*Config, error) {
 		Project:     viper.GetString("project"),
 		ProjectDir:  viper.GetString("project-dir"),
 		ConfigFile:  viper.GetString("config-file"),
+		Team:        viper.GetString("team"),
+
+		DefaultEnvironment: viper.GetString("default-env"),
+	}
+
+	// Apply the selected context (ENCLII_CONTEXT, else the file's current-context)
+	contexts, err := LoadContexts(config.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	contextName := viper.GetString("context")
+	if contextName == "" {
+		contextName = contexts.CurrentContext
+	}
+	if contextName != "" {
+		if ctx, ok := contexts.Get(contextName); ok {
+			config.applyContext(ctx)
+		} else {
+			// Keep the CLI usable so the context can be fixed with `enclii context use`
+			logrus.Warnf("context %q not found in %s, using defaults", contextName, config.ConfigFile)
+		}
 	}
 
 	// Load OAuth credentials if available
@@ -83,6 +112,12 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// envSet reports whether a setting was given through its ENCLII_* environment variable
+func envSet(key string) bool {
+	_, ok := os.LookupEnv("ENCLII_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_")))
+	return ok
+}
+
 // loadCredentials loads saved OAuth credentials from disk
 func loadCredentials() (*Credentials, error) {
 	home, err := os.UserHomeDir()
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Context is a named set of connection settings and defaults, similar to a
+// kubectl context. Empty fields fall back to the regular configuration.
+type Context struct {
+	Name        string `yaml:"name"`
+	APIEndpoint string `yaml:"api-endpoint,omitempty"`
+	APIToken    string `yaml:"api-token,omitempty"`
+	Team        string `yaml:"team,omitempty"`
+	Project     string `yaml:"project,omitempty"`
+	Environment string `yaml:"environment,omitempty"`
+}
+
+// ContextsFile is the on-disk layout of the CLI config file (~/.enclii/config.yml)
+type ContextsFile struct {
+	CurrentContext string    `yaml:"current-context,omitempty"`
+	Contexts       []Context `yaml:"contexts,omitempty"`
+}
+
+// LoadContexts reads the contexts from the config file. A missing file
+// yields an empty set of contexts.
+func LoadContexts(path string) (*ContextsFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ContextsFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file ContextsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// Save writes the contexts to the config file. The file holds API tokens,
+// so it is only readable by the current user.
+func (f *ContextsFile) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	sort.Slice(f.Contexts, func(i, j int) bool {
+		return f.Contexts[i].Name < f.Contexts[j].Name
+	})
+
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Get returns the context with the given name
+func (f *ContextsFile) Get(name string) (*Context, bool) {
+	for i := range f.Contexts {
+		if f.Contexts[i].Name == name {
+			return &f.Contexts[i], true
+		}
+	}
+	return nil, false
+}
+
+// Set adds a context or replaces the one with the same name
+func (f *ContextsFile) Set(ctx Context) {
+	if existing, ok := f.Get(ctx.Name); ok {
+		*existing = ctx
+		return
+	}
+	f.Contexts = append(f.Contexts, ctx)
+}
+
+// Delete removes a context, clearing it as the current context if selected
+func (f *ContextsFile) Delete(name string) bool {
+	for i := range f.Contexts {
+		if f.Contexts[i].Name == name {
+			f.Contexts = append(f.Contexts[:i], f.Contexts[i+1:]...)
+			if f.CurrentContext == name {
+				f.CurrentContext = ""
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// UseContext applies the named context to the config. Values given through
+// ENCLII_* environment variables keep precedence over the context.
+func (c *Config) UseContext(name string) error {
+	file, err := LoadContexts(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, ok := file.Get(name)
+	if !ok {
+		return fmt.Errorf("context %q not found in %s", name, c.ConfigFile)
+	}
+
+	c.applyContext(ctx)
+	return nil
+}
+
+// applyContext overlays the non-empty fields of a context onto the config
+func (c *Config) applyContext(ctx *Context) {
+	c.Context = ctx.Name
+	if ctx.APIEndpoint != "" && !envSet("api-endpoint") {
+		c.APIEndpoint = ctx.APIEndpoint
+	}
+	if ctx.APIToken != "" && !envSet("api-token") {
+		c.APIToken = ctx.APIToken
+	}
+	if ctx.Team != "" && !envSet("team") {
+		c.Team = ctx.Team
+	}
+	if ctx.Project != "" && !envSet("project") {
+		c.Project = ctx.Project
+	}
+	if ctx.Environment != "" && !envSet("default-env") {
+		c.DefaultEnvironment = ctx.Environment
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextsFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	// Missing file yields no contexts
+	file, err := LoadContexts(path)
+	require.NoError(t, err)
+	assert.Empty(t, file.Contexts)
+
+	file.Set(Context{Name: "prod", APIEndpoint: "https://api.enclii.dev", Project: "web"})
+	file.Set(Context{Name: "local", APIEndpoint: "http://localhost:8080"})
+	file.CurrentContext = "prod"
+	require.NoError(t, file.Save(path))
+
+	loaded, err := LoadContexts(path)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", loaded.CurrentContext)
+	require.Len(t, loaded.Contexts, 2)
+	assert.Equal(t, "local", loaded.Contexts[0].Name, "contexts are saved sorted by name")
+
+	// Set replaces an existing context in place
+	loaded.Set(Context{Name: "prod", APIEndpoint: "https://api.example.com"})
+	ctx, ok := loaded.Get("prod")
+	require.True(t, ok)
+	assert.Equal(t, "https://api.example.com", ctx.APIEndpoint)
+	assert.Len(t, loaded.Contexts, 2)
+
+	// Deleting the current context clears the selection
+	assert.True(t, loaded.Delete("prod"))
+	assert.Empty(t, loaded.CurrentContext)
+	assert.False(t, loaded.Delete("prod"))
+}
+
+func TestConfig_UseContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	file := &ContextsFile{Contexts: []Context{
+		{Name: "staging", APIEndpoint: "https://api.staging.example.com", APIToken: "tok", Team: "platform", Environment: "staging"},
+	}}
+	require.NoError(t, file.Save(path))
+
+	tests := []struct {
+		name         string
+		context      string
+		env          map[string]string
+		wantErr      bool
+		wantEndpoint string
+		wantEnv      string
+	}{
+		{
+			name:         "applies context values",
+			context:      "staging",
+			wantEndpoint: "https://api.staging.example.com",
+			wantEnv:      "staging",
+		},
+		{
+			name:         "environment variables take precedence",
+			context:      "staging",
+			env:          map[string]string{"ENCLII_API_ENDPOINT": "http://localhost:8080"},
+			wantEndpoint: "https://default.example.com",
+			wantEnv:      "staging",
+		},
+		{
+			name:    "unknown context",
+			context: "missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg := &Config{APIEndpoint: "https://default.example.com", ConfigFile: path}
+			err := cfg.UseContext(tt.context)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.context, cfg.Context)
+			assert.Equal(t, tt.wantEndpoint, cfg.APIEndpoint)
+			assert.Equal(t, tt.wantEnv, cfg.DefaultEnvironment)
+			assert.Equal(t, "platform", cfg.Team)
+		})
+	}
+}
@@ -53,6 +53,39 @@ func (p *Parser) ParseServiceSpec(path string) (*types.ServiceSpec, error) {
 	return &spec, nil
 }
 
+// ParseProjectSpec reads a project spec (enclii.yaml). Only its kind and
+// project are checked here; the API validates the rest when it is applied.
+func (p *Parser) ParseProjectSpec(path string) (*types.ProjectSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project spec file: %w", err)
+	}
+
+	var spec types.ProjectSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse project spec YAML: %w", err)
+	}
+
+	var errors []ValidationError
+	if spec.Kind != "Project" {
+		errors = append(errors, ValidationError{
+			Field:   "kind",
+			Message: "must be 'Project'",
+		})
+	}
+	if spec.Metadata.Name == "" {
+		errors = append(errors, ValidationError{
+			Field:   "metadata.name",
+			Message: "is required",
+		})
+	}
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("project spec validation failed: validation errors: %v", errors)
+	}
+
+	return &spec, nil
+}
+
 func (p *Parser) ValidateServiceSpec(spec *types.ServiceSpec, projectDir string) error {
 	var errors []ValidationError
 
@@ -150,6 +183,13 @@ func (p *Parser) validateSpec(spec *types.ServiceSpecConfig, projectDir string)
 		return err
 	}
 
+	// Validate post-deploy verification
+	if spec.Verify != nil {
+		if err := p.validateVerifySpec(spec.Verify); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -261,6 +301,53 @@ func (p *Parser) validateEnvVars(envVars []types.EnvVar) error {
 	return nil
 }
 
+func (p *Parser) validateVerifySpec(verify *types.VerifySpec) error {
+	if verify.BaseURL != "" && !strings.HasPrefix(verify.BaseURL, "https://") && !strings.HasPrefix(verify.BaseURL, "http://") {
+		return ValidationError{
+			Field:   "spec.verify.baseURL",
+			Message: "must be an http:// or https:// URL",
+		}
+	}
+
+	seenNames := make(map[string]bool)
+	for i, check := range verify.Checks {
+		if check.Name == "" {
+			return ValidationError{
+				Field:   fmt.Sprintf("spec.verify.checks[%d].name", i),
+				Message: "is required",
+			}
+		}
+		if seenNames[check.Name] {
+			return ValidationError{
+				Field:   fmt.Sprintf("spec.verify.checks[%d].name", i),
+				Message: fmt.Sprintf("duplicate check: %s", check.Name),
+			}
+		}
+		seenNames[check.Name] = true
+
+		if !strings.HasPrefix(check.Path, "/") {
+			return ValidationError{
+				Field:   fmt.Sprintf("spec.verify.checks[%d].path", i),
+				Message: "must start with '/'",
+			}
+		}
+		if check.ExpectStatus != 0 && (check.ExpectStatus < 100 || check.ExpectStatus > 599) {
+			return ValidationError{
+				Field:   fmt.Sprintf("spec.verify.checks[%d].expectStatus", i),
+				Message: "must be an HTTP status code",
+			}
+		}
+		if check.TimeoutSeconds < 0 || check.Retries < 0 {
+			return ValidationError{
+				Field:   fmt.Sprintf("spec.verify.checks[%d]", i),
+				Message: "timeoutSeconds and retries must not be negative",
+			}
+		}
+	}
+
+	return nil
+}
+
 func (p *Parser) validateAutoDetection(projectDir string) error {
 	detectedFiles := []string{}
 
@@ -80,6 +80,79 @@ func TestParseServiceSpec(t *testing.T) {
 	}
 }
 
+func TestParseProjectSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			yaml: `apiVersion: enclii.dev/v1
+kind: Project
+metadata:
+  name: shop
+spec:
+  services:
+    - name: api
+      gitRepo: https://github.com/acme/shop
+      appPath: apps/api
+      env:
+        - key: LOG_LEVEL
+          value: info
+      domains:
+        - domain: api.acme.com
+          environment: production
+          tls: true
+  addons:
+    - name: db
+      type: postgres
+      bindings:
+        - service: api
+          envVar: DATABASE_URL
+`,
+		},
+		{
+			name:    "service spec",
+			yaml:    "apiVersion: enclii.dev/v1\nkind: Service\nmetadata:\n  name: api\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing project",
+			yaml:    "apiVersion: enclii.dev/v1\nkind: Project\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "enclii.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			spec, err := NewParser().ParseProjectSpec(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProjectSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			svc := spec.Spec.Services[0]
+			if spec.Metadata.Name != "shop" || svc.GitRepo != "https://github.com/acme/shop" || svc.AppPath != "apps/api" {
+				t.Errorf("unexpected service: %+v", svc)
+			}
+			if len(svc.Domains) != 1 || !svc.Domains[0].TLSEnabled {
+				t.Errorf("unexpected domains: %+v", svc.Domains)
+			}
+			if binding := spec.Spec.Addons[0].Bindings[0]; binding.EnvVar != "DATABASE_URL" {
+				t.Errorf("unexpected binding: %+v", binding)
+			}
+		})
+	}
+}
+
 func TestValidateServiceSpec(t *testing.T) {
 	parser := NewParser()
 
@@ -151,6 +224,70 @@ func TestValidateServiceSpec(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid verification checks",
+			spec: &types.ServiceSpec{
+				APIVersion: "enclii.dev/v1alpha",
+				Kind:       "Service",
+				Metadata: types.ServiceMetadata{
+					Name:    "test-service",
+					Project: "test-project",
+				},
+				Spec: types.ServiceSpecConfig{
+					Build:   types.BuildSpec{Type: "auto"},
+					Runtime: types.RuntimeSpec{Port: 8080, Replicas: 1},
+					Verify: &types.VerifySpec{
+						BaseURL: "https://shop.example.com",
+						Checks: []types.VerifyCheck{
+							{Name: "health", Path: "/health"},
+							{Name: "catalog", Path: "/api/products", ExpectStatus: 200, ExpectBody: "items", Retries: 3},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "verification check without leading slash",
+			spec: &types.ServiceSpec{
+				APIVersion: "enclii.dev/v1alpha",
+				Kind:       "Service",
+				Metadata: types.ServiceMetadata{
+					Name:    "test-service",
+					Project: "test-project",
+				},
+				Spec: types.ServiceSpecConfig{
+					Build:   types.BuildSpec{Type: "auto"},
+					Runtime: types.RuntimeSpec{Port: 8080, Replicas: 1},
+					Verify: &types.VerifySpec{
+						Checks: []types.VerifyCheck{{Name: "health", Path: "health"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate verification checks",
+			spec: &types.ServiceSpec{
+				APIVersion: "enclii.dev/v1alpha",
+				Kind:       "Service",
+				Metadata: types.ServiceMetadata{
+					Name:    "test-service",
+					Project: "test-project",
+				},
+				Spec: types.ServiceSpecConfig{
+					Build:   types.BuildSpec{Type: "auto"},
+					Runtime: types.RuntimeSpec{Port: 8080, Replicas: 1},
+					Verify: &types.VerifySpec{
+						Checks: []types.VerifyCheck{
+							{Name: "health", Path: "/health"},
+							{Name: "health", Path: "/ready"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	// Create temp directory for validation tests
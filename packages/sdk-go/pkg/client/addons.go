@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// AddonsService covers managed database addons and their service bindings
+type AddonsService struct {
+	client *Client
+}
+
+// CreateAddonRequest is the body of Addons.Create
+type CreateAddonRequest struct {
+	Name string                  `json:"name"`
+	Type types.DatabaseAddonType `json:"type"`
+	// EnvironmentID scopes the addon to one environment
+	EnvironmentID *string                    `json:"environment_id,omitempty"`
+	Config        *types.DatabaseAddonConfig `json:"config,omitempty"`
+}
+
+// BindAddonRequest is the body of Addons.Bind
+type BindAddonRequest struct {
+	ServiceID string `json:"service_id"`
+	// EnvVarName is the variable the connection URL is injected as; empty
+	// uses the addon type's default, e.g. DATABASE_URL
+	EnvVarName string `json:"env_var_name,omitempty"`
+}
+
+// List returns the addons of a project
+func (s *AddonsService) List(ctx context.Context, projectSlug string, opts ...ListOption) (*Page[*types.DatabaseAddon], error) {
+	return list[*types.DatabaseAddon](ctx, s.client, pathf("/projects/%s/addons", projectSlug), "addons", opts)
+}
+
+// Iter walks every addon of a project
+func (s *AddonsService) Iter(ctx context.Context, projectSlug string, opts ...ListOption) *Iterator[*types.DatabaseAddon] {
+	return iterate[*types.DatabaseAddon](ctx, s.client, pathf("/projects/%s/addons", projectSlug), "addons", opts)
+}
+
+// Get returns an addon and its bindings
+func (s *AddonsService) Get(ctx context.Context, addonID string) (*types.DatabaseAddonWithBindings, error) {
+	var addon types.DatabaseAddonWithBindings
+	if err := s.client.get(ctx, pathf("/addons/%s", addonID), nil, &addon); err != nil {
+		return nil, err
+	}
+	return &addon, nil
+}
+
+// Create provisions an addon. Provisioning continues in the background;
+// poll Get until the status is ready.
+func (s *AddonsService) Create(ctx context.Context, projectSlug string, req *CreateAddonRequest) (*types.DatabaseAddon, error) {
+	var resp struct {
+		Addon *types.DatabaseAddon `json:"addon"`
+	}
+	if err := s.client.post(ctx, pathf("/projects/%s/addons", projectSlug), req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Addon, nil
+}
+
+// Resize changes an addon's plan, storage or replicas and returns the
+// resize that was started
+func (s *AddonsService) Resize(ctx context.Context, addonID string, req *types.DatabaseAddonUpdateRequest) (*types.DatabaseAddonResize, error) {
+	var resize types.DatabaseAddonResize
+	if err := s.client.patch(ctx, pathf("/addons/%s", addonID), req, &resize); err != nil {
+		return nil, err
+	}
+	return &resize, nil
+}
+
+// Delete deprovisions an addon. Requires the admin role.
+func (s *AddonsService) Delete(ctx context.Context, addonID string) error {
+	return s.client.delete(ctx, pathf("/addons/%s", addonID))
+}
+
+// Credentials returns the connection details of an addon
+func (s *AddonsService) Credentials(ctx context.Context, addonID string) (*types.DatabaseAddonCredentials, error) {
+	var creds types.DatabaseAddonCredentials
+	if err := s.client.get(ctx, pathf("/addons/%s/credentials", addonID), nil, &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// Bind injects an addon's connection URL into a service
+func (s *AddonsService) Bind(ctx context.Context, addonID string, req *BindAddonRequest) (*types.DatabaseAddonBinding, error) {
+	var resp struct {
+		Binding *types.DatabaseAddonBinding `json:"binding"`
+	}
+	if err := s.client.post(ctx, pathf("/addons/%s/bindings", addonID), req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Binding, nil
+}
+
+// Unbind removes an addon's binding to a service
+func (s *AddonsService) Unbind(ctx context.Context, addonID, serviceID string) error {
+	return s.client.delete(ctx, pathf("/addons/%s/bindings/%s", addonID, serviceID))
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// AdminService covers the platform administration API. It needs the
+// operator, admin or superadmin role.
+type AdminService struct {
+	client *Client
+}
+
+// TeamSuspensionStatus is a team after Admin.SuspendTeam or
+// Admin.UnsuspendTeam, with the services that were scaled as
+// "project/service (environment)"
+type TeamSuspensionStatus struct {
+	Team       string                `json:"team"`
+	Suspension *types.TeamSuspension `json:"suspension,omitempty"`
+	Services   []string              `json:"services"`
+}
+
+// ImpersonateRequest is the body of Admin.Impersonate. Set either UserID or
+// Email.
+type ImpersonateRequest struct {
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	Email  string     `json:"email,omitempty"`
+	Reason string     `json:"reason"`
+}
+
+// Impersonation is an access token acting as a user. It cannot be
+// refreshed; use it with NewClient(WithAPIToken(...)) until it expires.
+type Impersonation struct {
+	User        *types.User `json:"user"`
+	AccessToken string      `json:"access_token"`
+	TokenType   string      `json:"token_type"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+	Banner      string      `json:"banner"`
+	Reason      string      `json:"reason"`
+}
+
+// ListTeams returns every team. WithQuery("status", "suspended") keeps the
+// suspended ones.
+func (s *AdminService) ListTeams(ctx context.Context, opts ...ListOption) (*Page[*types.AdminTeam], error) {
+	return list[*types.AdminTeam](ctx, s.client, "/admin/teams", "teams", opts)
+}
+
+// ListProjects returns every project. WithQuery("team", slug) keeps those
+// of one team.
+func (s *AdminService) ListProjects(ctx context.Context, opts ...ListOption) (*Page[*types.AdminProject], error) {
+	return list[*types.AdminProject](ctx, s.client, "/admin/projects", "projects", opts)
+}
+
+// Metrics returns platform counts, the build queue, and the builds of the
+// last 24 hours
+func (s *AdminService) Metrics(ctx context.Context) (*types.PlatformMetrics, error) {
+	var metrics types.PlatformMetrics
+	if err := s.client.get(ctx, "/admin/metrics", nil, &metrics); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// SuspendTeam scales every service of a team to zero and blocks changes by
+// its members until it is unsuspended
+func (s *AdminService) SuspendTeam(ctx context.Context, slug, reason string) (*TeamSuspensionStatus, error) {
+	var status TeamSuspensionStatus
+	body := map[string]string{"reason": reason}
+	if err := s.client.post(ctx, pathf("/admin/teams/%s/suspend", slug), body, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// UnsuspendTeam lifts the suspension of a team and scales its services back
+// up
+func (s *AdminService) UnsuspendTeam(ctx context.Context, slug string) (*TeamSuspensionStatus, error) {
+	var status TeamSuspensionStatus
+	if err := s.client.post(ctx, pathf("/admin/teams/%s/unsuspend", slug), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Impersonate starts a 30-minute support session acting as a user
+func (s *AdminService) Impersonate(ctx context.Context, req *ImpersonateRequest) (*Impersonation, error) {
+	var impersonation Impersonation
+	if err := s.client.post(ctx, "/admin/impersonate", req, &impersonation); err != nil {
+		return nil, err
+	}
+	return &impersonation, nil
+}
+
+// RateLimitPolicyRequest is the body of Admin.CreateRateLimitPolicy and
+// Admin.UpdateRateLimitPolicy. Route is a route pattern with the /v1 prefix,
+// e.g. /v1/services/:id/build.
+type RateLimitPolicyRequest struct {
+	Name          string               `json:"name"`
+	Method        string               `json:"method"` // HTTP method, or "*" for any
+	Route         string               `json:"route"`
+	Scope         types.RateLimitScope `json:"scope,omitempty"` // Defaults to user
+	Requests      int                  `json:"requests"`
+	WindowSeconds int                  `json:"window_seconds"`
+	Enabled       *bool                `json:"enabled,omitempty"` // Defaults to true
+	Description   string               `json:"description,omitempty"`
+}
+
+// ListRateLimitPolicies returns the budgets of expensive routes
+func (s *AdminService) ListRateLimitPolicies(ctx context.Context) ([]*types.RateLimitPolicy, error) {
+	var resp struct {
+		Policies []*types.RateLimitPolicy `json:"policies"`
+	}
+	if err := s.client.get(ctx, "/admin/rate-limit-policies", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Policies, nil
+}
+
+// CreateRateLimitPolicy gives a route its own budget. It needs the admin
+// role.
+func (s *AdminService) CreateRateLimitPolicy(ctx context.Context, req *RateLimitPolicyRequest) (*types.RateLimitPolicy, error) {
+	var policy types.RateLimitPolicy
+	if err := s.client.post(ctx, "/admin/rate-limit-policies", req, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// UpdateRateLimitPolicy replaces a policy. It needs the admin role.
+func (s *AdminService) UpdateRateLimitPolicy(ctx context.Context, id uuid.UUID, req *RateLimitPolicyRequest) (*types.RateLimitPolicy, error) {
+	var policy types.RateLimitPolicy
+	if err := s.client.put(ctx, pathf("/admin/rate-limit-policies/%s", id.String()), nil, req, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// DeleteRateLimitPolicy deletes a policy. It needs the admin role.
+func (s *AdminService) DeleteRateLimitPolicy(ctx context.Context, id uuid.UUID) error {
+	return s.client.delete(ctx, pathf("/admin/rate-limit-policies/%s", id.String()))
+}
+
+// SetTokenRateLimit sets the requests per minute an API token may make; nil
+// returns it to the platform's per-token limit. It needs the admin role.
+func (s *AdminService) SetTokenRateLimit(ctx context.Context, tokenID uuid.UUID, perMinute *int) error {
+	body := map[string]*int{"rate_limit_per_minute": perMinute}
+	return s.client.put(ctx, pathf("/admin/tokens/%s/rate-limit", tokenID.String()), nil, body, nil)
+}
+
+// ListJobs returns background jobs, newest first. WithQuery("status",
+// "failed") keeps the failed ones, WithQuery("kind", "addon.provision")
+// those of one kind.
+func (s *AdminService) ListJobs(ctx context.Context, opts ...ListOption) (*Page[*types.BackgroundJob], error) {
+	return list[*types.BackgroundJob](ctx, s.client, "/admin/jobs", "jobs", opts)
+}
+
+// GetJob returns a background job with its payload and last error
+func (s *AdminService) GetJob(ctx context.Context, id uuid.UUID) (*types.BackgroundJob, error) {
+	var job types.BackgroundJob
+	if err := s.client.get(ctx, pathf("/admin/jobs/%s", id.String()), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RetryJob queues a failed background job again with a fresh set of
+// attempts
+func (s *AdminService) RetryJob(ctx context.Context, id uuid.UUID) (*types.BackgroundJob, error) {
+	var job types.BackgroundJob
+	if err := s.client.post(ctx, pathf("/admin/jobs/%s/retry", id.String()), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
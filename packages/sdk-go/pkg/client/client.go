@@ -0,0 +1,351 @@
+// Package client is a typed Go client for the Enclii (switchyard) API.
+//
+// A Client groups the API by resource: Projects, Services, Deployments,
+// EnvVars, Addons, Previews, Teams and Admin. Every call takes a context,
+// failed requests that are safe to repeat are retried with exponential
+// backoff, and error responses come back as typed errors (see errors.go).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the hosted Enclii API
+	DefaultBaseURL = "https://api.enclii.dev"
+
+	// DefaultUserAgent identifies SDK requests unless WithUserAgent is given
+	DefaultUserAgent = "enclii-sdk-go/1.0.0"
+
+	// TokenEnvVar is read for the API token when WithAPIToken is not given
+	TokenEnvVar = "ENCLII_TOKEN"
+
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 30 * time.Second
+)
+
+// Client talks to the switchyard API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	token      string
+	userAgent  string
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+	logger     *log.Logger
+
+	Projects    *ProjectsService
+	Services    *ServicesService
+	Deployments *DeploymentsService
+	EnvVars     *EnvVarsService
+	Addons      *AddonsService
+	Previews    *PreviewsService
+	Teams       *TeamsService
+	Admin       *AdminService
+}
+
+// Option configures a Client
+type Option func(*options)
+
+type options struct {
+	baseURL    string
+	token      string
+	userAgent  string
+	httpClient *http.Client
+	timeout    time.Duration
+	maxRetries int
+	retryDelay time.Duration
+	debug      bool
+}
+
+// WithAPIToken sets the bearer token sent with every request
+func WithAPIToken(token string) Option {
+	return func(o *options) { o.token = token }
+}
+
+// WithBaseURL points the client at another switchyard API, e.g. a
+// self-hosted installation or http://localhost:8080
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) { o.baseURL = baseURL }
+}
+
+// WithHTTPClient replaces the underlying HTTP client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) { o.httpClient = httpClient }
+}
+
+// WithTimeout bounds each HTTP attempt. The context passed to a call bounds
+// the call as a whole, retries included.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// WithRetry sets how many times a failed request is retried and the delay
+// before the first retry. The delay doubles on every further attempt. Zero
+// retries disables retrying.
+func WithRetry(maxRetries int, delay time.Duration) Option {
+	return func(o *options) {
+		o.maxRetries = maxRetries
+		o.retryDelay = delay
+	}
+}
+
+// WithDebug logs every request and its outcome to stderr
+func WithDebug(debug bool) Option {
+	return func(o *options) { o.debug = debug }
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) { o.userAgent = userAgent }
+}
+
+// NewClient creates a client. Without WithAPIToken the token is read from
+// the ENCLII_TOKEN environment variable.
+func NewClient(opts ...Option) *Client {
+	o := options{
+		baseURL:    DefaultBaseURL,
+		token:      os.Getenv(TokenEnvVar),
+		userAgent:  DefaultUserAgent,
+		maxRetries: defaultMaxRetries,
+		retryDelay: defaultRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	httpClient := o.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if o.timeout > 0 {
+		// Copy so the caller's client is left untouched
+		copied := *httpClient
+		copied.Timeout = o.timeout
+		httpClient = &copied
+	}
+	if o.maxRetries < 0 {
+		o.maxRetries = 0
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(o.baseURL, "/"),
+		token:      o.token,
+		userAgent:  o.userAgent,
+		httpClient: httpClient,
+		maxRetries: o.maxRetries,
+		retryDelay: o.retryDelay,
+	}
+	if o.debug {
+		c.logger = log.New(os.Stderr, "enclii: ", log.LstdFlags)
+	}
+
+	c.Projects = &ProjectsService{client: c}
+	c.Services = &ServicesService{client: c}
+	c.Deployments = &DeploymentsService{client: c}
+	c.EnvVars = &EnvVarsService{client: c}
+	c.Addons = &AddonsService{client: c}
+	c.Previews = &PreviewsService{client: c}
+	c.Teams = &TeamsService{client: c}
+	c.Admin = &AdminService{client: c}
+	return c
+}
+
+// do sends a request to path under /v1, retrying when the failure is
+// transient, and decodes a successful JSON response into result
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, payload, result interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	endpoint := c.baseURL + "/v1" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, endpoint, body)
+		if err == nil && resp.StatusCode < 400 {
+			defer resp.Body.Close()
+			return decodeResponse(resp, result)
+		}
+
+		var apiErr error
+		var retryAfter time.Duration
+		if err == nil {
+			apiErr = parseError(resp)
+			resp.Body.Close()
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+
+		if attempt >= c.maxRetries || !retryable(method, resp, err) {
+			if err != nil {
+				return fmt.Errorf("request failed: %w", err)
+			}
+			return apiErr
+		}
+
+		delay := c.backoff(attempt, retryAfter)
+		c.debugf("%s %s: retrying in %s (attempt %d of %d)", method, path, delay, attempt+1, c.maxRetries)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.debugf("%s %s: %v", method, endpoint, err)
+		return nil, err
+	}
+	c.debugf("%s %s: %d (%s)", method, endpoint, resp.StatusCode, time.Since(start).Round(time.Millisecond))
+	return resp, nil
+}
+
+func (c *Client) debugf(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, args...)
+	}
+}
+
+// backoff is the wait before the retry following attempt. A Retry-After
+// from the server wins; otherwise the delay doubles per attempt, with
+// jitter, up to maxRetryDelay.
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryDelay)
+	}
+	if c.retryDelay <= 0 {
+		return 0
+	}
+	delay := c.retryDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// Up to 20% jitter keeps many clients from retrying in lockstep
+	return delay - time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// retryable reports whether a failed attempt may be repeated. Rate limited
+// requests were never processed, so any method is retried; network errors
+// and gateway failures are only retried for idempotent methods, since a
+// POST may already have taken effect.
+func retryable(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		return idempotent(method) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func decodeResponse(resp *http.Response, result interface{}) error {
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, result)
+}
+
+func (c *Client) post(ctx context.Context, path string, payload, result interface{}) error {
+	return c.do(ctx, http.MethodPost, path, nil, payload, result)
+}
+
+func (c *Client) put(ctx context.Context, path string, query url.Values, payload, result interface{}) error {
+	return c.do(ctx, http.MethodPut, path, query, payload, result)
+}
+
+func (c *Client) patch(ctx context.Context, path string, payload, result interface{}) error {
+	return c.do(ctx, http.MethodPatch, path, nil, payload, result)
+}
+
+func (c *Client) delete(ctx context.Context, path string) error {
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
+
+// pathf builds a request path, escaping each argument as a path segment
+func pathf(format string, args ...string) string {
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		escaped[i] = url.PathEscape(arg)
+	}
+	return fmt.Sprintf(format, escaped...)
+}
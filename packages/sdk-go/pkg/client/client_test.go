@@ -0,0 +1,378 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	opts = append([]Option{WithBaseURL(server.URL), WithAPIToken("test-token"), WithRetry(3, time.Millisecond)}, opts...)
+	return NewClient(opts...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestClient_SendsAuthAndUserAgent(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want Bearer test-token", got)
+		}
+		if got := r.Header.Get("User-Agent"); got != "my-app/1.0" {
+			t.Errorf("User-Agent = %q, want my-app/1.0", got)
+		}
+		if r.URL.Path != "/v1/projects/my-app" {
+			t.Errorf("path = %q, want /v1/projects/my-app", r.URL.Path)
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"name": "My App", "slug": "my-app"})
+	}, WithUserAgent("my-app/1.0"))
+
+	project, err := c.Projects.Get(context.Background(), "my-app")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if project.Slug != "my-app" {
+		t.Errorf("Slug = %q, want my-app", project.Slug)
+	}
+}
+
+func TestNewClient_ReadsTokenFromEnvironment(t *testing.T) {
+	t.Setenv(TokenEnvVar, "env-token")
+
+	if c := NewClient(); c.token != "env-token" {
+		t.Errorf("token = %q, want env-token", c.token)
+	}
+	if c := NewClient(WithAPIToken("explicit")); c.token != "explicit" {
+		t.Errorf("token = %q, want explicit", c.token)
+	}
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "unavailable"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id": "00000000-0000-0000-0000-000000000001"})
+	})
+
+	if _, err := c.Deployments.Get(context.Background(), "dep"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestClient_DoesNotRetryPostOnServerError(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "unavailable"})
+	})
+
+	_, err := c.Projects.Create(context.Background(), &CreateProjectRequest{Name: "a", Slug: "a"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("error = %v, want a 503 APIError", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestClient_RetriesRateLimitedRequests(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "Rate limit exceeded"})
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]string{"slug": "a"})
+	})
+
+	if _, err := c.Projects.Create(context.Background(), &CreateProjectRequest{Name: "a", Slug: "a"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestClient_RateLimitErrorAfterRetries(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+			"error":          "Too many requests",
+			"code":           "RATE_LIMIT_EXCEEDED",
+			"limiter":        "policy:build",
+			"limit":          30,
+			"window_seconds": 3600,
+			"retry_after":    1,
+			"reset_at":       "2026-10-17T12:29:00Z",
+		})
+	}, WithRetry(0, 0))
+
+	_, err := c.Teams.Get(context.Background(), "core")
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("error = %v, want RateLimitError", err)
+	}
+	if rateErr.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", rateErr.RetryAfter)
+	}
+	if rateErr.Limiter != "policy:build" || rateErr.Limit != 30 || rateErr.Window != time.Hour {
+		t.Errorf("RateLimitError = %+v, want the build policy's 30 per hour", rateErr)
+	}
+	if want := time.Date(2026, 10, 17, 12, 29, 0, 0, time.UTC); !rateErr.ResetAt.Equal(want) {
+		t.Errorf("ResetAt = %v, want %v", rateErr.ResetAt, want)
+	}
+}
+
+func TestClient_StopsRetryingWhenContextEnds(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "Rate limit exceeded"})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Projects.Get(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("call took %v, want it to stop at the deadline", elapsed)
+	}
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   map[string]interface{}
+		check  func(t *testing.T, err error)
+	}{
+		{
+			name:   "not found",
+			status: http.StatusNotFound,
+			body:   map[string]interface{}{"error": "Service not found"},
+			check: func(t *testing.T, err error) {
+				var e *NotFoundError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %T, want *NotFoundError", err)
+				}
+				if e.ResourceID != "svc-1" {
+					t.Errorf("ResourceID = %q, want svc-1", e.ResourceID)
+				}
+			},
+		},
+		{
+			name:   "unauthorized",
+			status: http.StatusUnauthorized,
+			body:   map[string]interface{}{"error": "Invalid token"},
+			check: func(t *testing.T, err error) {
+				var e *AuthError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %T, want *AuthError", err)
+				}
+			},
+		},
+		{
+			name:   "validation with problems",
+			status: http.StatusBadRequest,
+			body:   map[string]interface{}{"error": "Invalid project spec", "problems": []string{"a", "b"}},
+			check: func(t *testing.T, err error) {
+				var e *ValidationError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %T, want *ValidationError", err)
+				}
+				if len(e.Errors) != 2 {
+					t.Errorf("Errors = %v, want 2 entries", e.Errors)
+				}
+			},
+		},
+		{
+			name:   "quota exceeded",
+			status: http.StatusUnprocessableEntity,
+			body: map[string]interface{}{
+				"error": "Resource quota exceeded",
+				"quota": map[string]interface{}{"scope": "team", "name": "acme", "resource": "services", "limit": 5, "current": 5, "requested": 1},
+			},
+			check: func(t *testing.T, err error) {
+				var e *QuotaError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %T, want *QuotaError", err)
+				}
+				if e.Resource != "services" || e.Limit != 5 {
+					t.Errorf("quota = %+v, want the services limit of 5", e)
+				}
+				var validation *ValidationError
+				if !errors.As(err, &validation) {
+					t.Error("a quota error should also be a *ValidationError")
+				}
+			},
+		},
+		{
+			name:   "conflict",
+			status: http.StatusConflict,
+			body:   map[string]interface{}{"error": "already exists"},
+			check: func(t *testing.T, err error) {
+				var e *ConflictError
+				if !errors.As(err, &e) {
+					t.Fatalf("error = %T, want *ConflictError", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, tt.status, tt.body)
+			})
+
+			_, err := c.Services.Get(context.Background(), "svc-1")
+			tt.check(t, err)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("errors.As(*APIError) = %v, want status %d", apiErr, tt.status)
+			}
+		})
+	}
+}
+
+func TestIterator_WalksEveryPage(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if got := r.URL.Query().Get("limit"); got != "2" {
+			t.Errorf("limit = %q, want 2", got)
+		}
+		items := map[int][]map[string]string{
+			1: {{"slug": "a"}, {"slug": "b"}},
+			2: {{"slug": "c"}},
+		}[page]
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"projects":   items,
+			"pagination": map[string]interface{}{"page": page, "limit": 2, "total": 3, "has_next": page < 2},
+		})
+	})
+
+	it := c.Projects.Iter(context.Background(), WithPerPage(2))
+	var slugs []string
+	for it.Next() {
+		slugs = append(slugs, it.Value().Slug)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(slugs) != 3 || slugs[0] != "a" || slugs[2] != "c" {
+		t.Errorf("slugs = %v, want [a b c]", slugs)
+	}
+}
+
+func TestIterator_FollowsCursors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("sort") != "name" || q.Get("order") != "asc" || q.Get("since") != "2026-10-01T00:00:00Z" {
+			t.Errorf("query = %v, want sort, order and since", q)
+		}
+		pages := map[string]map[string]interface{}{
+			"":   {"services": []map[string]string{{"name": "api"}, {"name": "web"}}, "next_cursor": "c1"},
+			"c1": {"services": []map[string]string{{"name": "worker"}}, "next_cursor": ""},
+		}
+		writeJSON(w, http.StatusOK, pages[q.Get("cursor")])
+	})
+
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	it := c.Services.Iter(context.Background(), "shop", WithSort("name", "asc"), WithCreatedBetween(since, time.Time{}))
+	var names []string
+	for it.Next() {
+		names = append(names, it.Value().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(names) != 3 || names[0] != "api" || names[2] != "worker" {
+		t.Errorf("names = %v, want [api web worker]", names)
+	}
+}
+
+func TestList_UnpaginatedEndpointIsSinglePage(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"environment_variables": []map[string]string{{"key": "A"}, {"key": "B"}},
+		})
+	})
+
+	page, err := c.EnvVars.List(context.Background(), "svc")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(page.Items) != 2 || page.Total != 2 {
+		t.Errorf("Items = %d, Total = %d, want 2 and 2", len(page.Items), page.Total)
+	}
+	if page.HasNextPage() {
+		t.Error("HasNextPage() = true, want false")
+	}
+}
+
+func TestEnvVars_SetScopesToEnvironment(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v1/services/svc/env-vars/keys/API_KEY" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("environment_id"); got != "env-1" {
+			t.Errorf("environment_id = %q, want env-1", got)
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := body["EnvironmentID"]; ok {
+			t.Error("environment ID leaked into the request body")
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"key": "API_KEY", "is_secret": true})
+	})
+
+	ev, err := c.EnvVars.Set(context.Background(), "svc", "API_KEY", &SetEnvVarRequest{Value: "x", IsSecret: true, EnvironmentID: "env-1"})
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if ev.Key != "API_KEY" {
+		t.Errorf("Key = %q, want API_KEY", ev.Key)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{retryDelay: 100 * time.Millisecond}
+
+	if got := c.backoff(0, 2*time.Second); got != 2*time.Second {
+		t.Errorf("backoff with Retry-After = %v, want 2s", got)
+	}
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		got := c.backoff(attempt, 0)
+		if got > want || got < want*4/5 {
+			t.Errorf("backoff(%d) = %v, want within 20%% below %v", attempt, got, want)
+		}
+	}
+	if got := c.backoff(20, 0); got > maxRetryDelay {
+		t.Errorf("backoff(20) = %v, want at most %v", got, maxRetryDelay)
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// DeploymentsService covers deploying releases and inspecting deployments
+type DeploymentsService struct {
+	client *Client
+}
+
+// CreateDeploymentRequest is the body of Deployments.Create
+type CreateDeploymentRequest struct {
+	ReleaseID string `json:"release_id"`
+	// EnvironmentName is the target environment, e.g. "staging"
+	EnvironmentName string            `json:"environment_name,omitempty"`
+	Environment     map[string]string `json:"environment,omitempty"`
+	Replicas        int               `json:"replicas,omitempty"`
+	// ChangeTicketURL is required by production deploy policies that ask for one
+	ChangeTicketURL string `json:"change_ticket_url,omitempty"`
+	// OverrideReason justifies deploying against the environment's deploy policy
+	OverrideReason string `json:"override_reason,omitempty"`
+}
+
+// RollbackResult is the outcome of Deployments.Rollback
+type RollbackResult struct {
+	Message           string            `json:"message"`
+	RolledBackTo      *types.Deployment `json:"rolled_back_to"`
+	CurrentDeployment *types.Deployment `json:"current_deployment"`
+}
+
+// List returns the deployments of a service across its releases
+func (s *DeploymentsService) List(ctx context.Context, serviceID string, opts ...ListOption) (*Page[*types.Deployment], error) {
+	return list[*types.Deployment](ctx, s.client, pathf("/services/%s/deployments", serviceID), "deployments", opts)
+}
+
+// Iter walks every deployment of a service
+func (s *DeploymentsService) Iter(ctx context.Context, serviceID string, opts ...ListOption) *Iterator[*types.Deployment] {
+	return iterate[*types.Deployment](ctx, s.client, pathf("/services/%s/deployments", serviceID), "deployments", opts)
+}
+
+// Get returns a deployment by ID
+func (s *DeploymentsService) Get(ctx context.Context, deploymentID string) (*types.Deployment, error) {
+	var deployment types.Deployment
+	if err := s.client.get(ctx, pathf("/deployments/%s", deploymentID), nil, &deployment); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// Latest returns the most recent deployment of a service
+func (s *DeploymentsService) Latest(ctx context.Context, serviceID string) (*types.Deployment, error) {
+	var resp struct {
+		Deployment *types.Deployment `json:"deployment"`
+	}
+	if err := s.client.get(ctx, pathf("/services/%s/deployments/latest", serviceID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Deployment, nil
+}
+
+// Create deploys a release of a service
+func (s *DeploymentsService) Create(ctx context.Context, serviceID string, req *CreateDeploymentRequest) (*types.Deployment, error) {
+	var deployment types.Deployment
+	if err := s.client.post(ctx, pathf("/services/%s/deploy", serviceID), req, &deployment); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// Rollback returns a deployment's environment to the previous release
+func (s *DeploymentsService) Rollback(ctx context.Context, deploymentID string) (*RollbackResult, error) {
+	var result RollbackResult
+	if err := s.client.post(ctx, pathf("/deployments/%s/rollback", deploymentID), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Snapshot returns the configuration a deployment was rolled out with
+func (s *DeploymentsService) Snapshot(ctx context.Context, deploymentID string) (*types.DeploymentConfigSnapshot, error) {
+	var snapshot types.DeploymentConfigSnapshot
+	if err := s.client.get(ctx, pathf("/deployments/%s/snapshot", deploymentID), nil, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
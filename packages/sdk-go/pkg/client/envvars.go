@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// EnvVarsService covers a service's environment variables. Secret values
+// come back masked; the API never returns them in list or get responses.
+type EnvVarsService struct {
+	client *Client
+}
+
+// CreateEnvVarRequest is the body of EnvVars.Create
+type CreateEnvVarRequest struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// EnvironmentID scopes the variable to one environment; nil applies it to all
+	EnvironmentID *string `json:"environment_id,omitempty"`
+	IsSecret      bool    `json:"is_secret"`
+}
+
+// UpdateEnvVarRequest is the body of EnvVars.Update. Nil fields are left
+// unchanged.
+type UpdateEnvVarRequest struct {
+	Key      *string `json:"key,omitempty"`
+	Value    *string `json:"value,omitempty"`
+	IsSecret *bool   `json:"is_secret,omitempty"`
+}
+
+// SetEnvVarRequest is the body of EnvVars.Set
+type SetEnvVarRequest struct {
+	Value    string `json:"value"`
+	IsSecret bool   `json:"is_secret"`
+	// EnvironmentID scopes the variable to one environment; empty applies it to all
+	EnvironmentID string `json:"-"`
+}
+
+// List returns a service's variables. WithQuery("environment_id", id)
+// narrows them to one environment.
+func (s *EnvVarsService) List(ctx context.Context, serviceID string, opts ...ListOption) (*Page[*types.EnvironmentVariableResponse], error) {
+	return list[*types.EnvironmentVariableResponse](ctx, s.client, pathf("/services/%s/env-vars", serviceID), "environment_variables", opts)
+}
+
+// Iter walks every variable of a service
+func (s *EnvVarsService) Iter(ctx context.Context, serviceID string, opts ...ListOption) *Iterator[*types.EnvironmentVariableResponse] {
+	return iterate[*types.EnvironmentVariableResponse](ctx, s.client, pathf("/services/%s/env-vars", serviceID), "environment_variables", opts)
+}
+
+// Get returns a variable by ID
+func (s *EnvVarsService) Get(ctx context.Context, serviceID, varID string) (*types.EnvironmentVariableResponse, error) {
+	var ev types.EnvironmentVariableResponse
+	if err := s.client.get(ctx, pathf("/services/%s/env-vars/%s", serviceID, varID), nil, &ev); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// Create adds a variable. It fails with a ConflictError if the key is
+// already set for the same scope; use Set to create or replace.
+func (s *EnvVarsService) Create(ctx context.Context, serviceID string, req *CreateEnvVarRequest) (*types.EnvironmentVariableResponse, error) {
+	var ev types.EnvironmentVariableResponse
+	if err := s.client.post(ctx, pathf("/services/%s/env-vars", serviceID), req, &ev); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// Update changes the fields set in req
+func (s *EnvVarsService) Update(ctx context.Context, serviceID, varID string, req *UpdateEnvVarRequest) (*types.EnvironmentVariableResponse, error) {
+	var ev types.EnvironmentVariableResponse
+	if err := s.client.put(ctx, pathf("/services/%s/env-vars/%s", serviceID, varID), nil, req, &ev); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// Set creates or replaces the variable key. Unlike Create it is idempotent,
+// so it is retried on transient failures.
+func (s *EnvVarsService) Set(ctx context.Context, serviceID, key string, req *SetEnvVarRequest) (*types.EnvironmentVariableResponse, error) {
+	var query url.Values
+	if req.EnvironmentID != "" {
+		query = url.Values{"environment_id": {req.EnvironmentID}}
+	}
+	var ev types.EnvironmentVariableResponse
+	if err := s.client.put(ctx, pathf("/services/%s/env-vars/keys/%s", serviceID, key), query, req, &ev); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// Delete removes a variable
+func (s *EnvVarsService) Delete(ctx context.Context, serviceID, varID string) error {
+	return s.client.delete(ctx, pathf("/services/%s/env-vars/%s", serviceID, varID))
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIError is an error response from the API. The more specific errors
+// below embed it, so errors.As(err, &apiErr) matches every API failure.
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Problems lists individual failures when the API reports several,
+	// e.g. for an invalid project spec
+	Problems []string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Problems) > 0 {
+		return fmt.Sprintf("API error %d: %s (%s)", e.StatusCode, e.Message, strings.Join(e.Problems, "; "))
+	}
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+}
+
+// NotFoundError is returned for 404 responses
+type NotFoundError struct {
+	APIError
+	// ResourceID is the last path segment of the request, usually the ID or
+	// slug that was looked up
+	ResourceID string
+}
+
+func (e *NotFoundError) Unwrap() error { return &e.APIError }
+
+// AuthError is returned for 401 and 403 responses: the token is missing,
+// invalid, or lacks the role the operation needs
+type AuthError struct {
+	APIError
+}
+
+func (e *AuthError) Unwrap() error { return &e.APIError }
+
+// RateLimitError is returned for 429 responses once retries are exhausted.
+// Limiter names the limit that was hit: "ip", "user", "token", or
+// "policy:<name>" for the budget of an expensive route.
+type RateLimitError struct {
+	APIError
+	RetryAfter time.Duration
+	Limiter    string
+	Limit      int
+	Window     time.Duration
+	ResetAt    time.Time
+}
+
+func (e *RateLimitError) Unwrap() error { return &e.APIError }
+
+// ValidationError is returned for 400 and 422 responses
+type ValidationError struct {
+	APIError
+	Errors []string
+}
+
+func (e *ValidationError) Unwrap() error { return &e.APIError }
+
+// QuotaError is returned for 422 responses to requests that would take a
+// team or a project past its resource quota, e.g. a new service or a deploy
+type QuotaError struct {
+	ValidationError
+	Scope     string `json:"scope"` // team or project
+	Name      string `json:"name"`  // Slug of the team or project
+	Resource  string `json:"resource"`
+	Limit     int    `json:"limit"`
+	Current   int    `json:"current"`
+	Requested int    `json:"requested"`
+}
+
+func (e *QuotaError) Unwrap() error { return &e.ValidationError }
+
+// ConflictError is returned for 409 responses, e.g. a slug already in use,
+// and for 412 responses when an If-Match precondition fails
+type ConflictError struct {
+	APIError
+}
+
+func (e *ConflictError) Unwrap() error { return &e.APIError }
+
+// parseError turns an error response into the matching typed error
+func parseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	var payload struct {
+		Error    string      `json:"error"`
+		Message  string      `json:"message"`
+		Problems []string    `json:"problems"`
+		Quota    *QuotaError `json:"quota"`
+
+		// Set on 429 responses
+		Limiter       string    `json:"limiter"`
+		Limit         int       `json:"limit"`
+		WindowSeconds int       `json:"window_seconds"`
+		ResetAt       time.Time `json:"reset_at"`
+	}
+	base := APIError{StatusCode: resp.StatusCode}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		base.Message = payload.Error
+		if payload.Message != "" {
+			base.Message += ": " + payload.Message
+		}
+		base.Problems = payload.Problems
+	} else if text := strings.TrimSpace(string(body)); text != "" {
+		base.Message = text
+	} else {
+		base.Message = http.StatusText(resp.StatusCode)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return &NotFoundError{APIError: base, ResourceID: lastPathSegment(resp.Request)}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &AuthError{APIError: base}
+	case http.StatusTooManyRequests:
+		return &RateLimitError{
+			APIError:   base,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Limiter:    payload.Limiter,
+			Limit:      payload.Limit,
+			Window:     time.Duration(payload.WindowSeconds) * time.Second,
+			ResetAt:    payload.ResetAt,
+		}
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		errs := base.Problems
+		if len(errs) == 0 {
+			errs = []string{base.Message}
+		}
+		validation := ValidationError{APIError: base, Errors: errs}
+		if payload.Quota != nil && resp.StatusCode == http.StatusUnprocessableEntity {
+			payload.Quota.ValidationError = validation
+			return payload.Quota
+		}
+		return &validation
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return &ConflictError{APIError: base}
+	}
+	return &base
+}
+
+func lastPathSegment(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	path := strings.TrimRight(req.URL.Path, "/")
+	return path[strings.LastIndex(path, "/")+1:]
+}
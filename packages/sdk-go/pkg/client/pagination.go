@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListOption adjusts a List call
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	page    int
+	perPage int
+	cursor  string
+	query   url.Values
+}
+
+// WithPage requests a page of results, counting from 1. Cursor-paginated
+// lists ignore it; use WithCursor.
+func WithPage(page int) ListOption {
+	return func(o *listOptions) { o.page = page }
+}
+
+// WithCursor requests the page after the one that returned cursor as its
+// NextCursor
+func WithCursor(cursor string) ListOption {
+	return func(o *listOptions) { o.cursor = cursor }
+}
+
+// WithSort sorts a cursor-paginated list by a sort key such as created_at
+// or name, in order "asc" or "desc"; an empty order uses the key's default
+func WithSort(sort, order string) ListOption {
+	return func(o *listOptions) {
+		WithQuery("sort", sort)(o)
+		if order != "" {
+			WithQuery("order", order)(o)
+		}
+	}
+}
+
+// WithCreatedBetween keeps the items created at or after since and before
+// until; a zero time leaves that end open
+func WithCreatedBetween(since, until time.Time) ListOption {
+	return func(o *listOptions) {
+		if !since.IsZero() {
+			WithQuery("since", since.Format(time.RFC3339))(o)
+		}
+		if !until.IsZero() {
+			WithQuery("until", until.Format(time.RFC3339))(o)
+		}
+	}
+}
+
+// WithTrashed lists the deleted projects or services still in the trash
+// instead of the live ones. Requires the admin role.
+func WithTrashed() ListOption {
+	return WithQuery("trashed", "true")
+}
+
+// WithPerPage sets the page size. The API caps it at 100.
+func WithPerPage(perPage int) ListOption {
+	return func(o *listOptions) { o.perPage = perPage }
+}
+
+// WithQuery adds a filter query parameter, e.g. WithQuery("status", "active")
+func WithQuery(key, value string) ListOption {
+	return func(o *listOptions) {
+		if o.query == nil {
+			o.query = url.Values{}
+		}
+		o.query.Set(key, value)
+	}
+}
+
+func newListOptions(opts []ListOption) listOptions {
+	o := listOptions{page: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.page < 1 {
+		o.page = 1
+	}
+	return o
+}
+
+func (o listOptions) values() url.Values {
+	q := url.Values{}
+	for key, vals := range o.query {
+		q[key] = vals
+	}
+	if o.page > 1 {
+		q.Set("page", strconv.Itoa(o.page))
+	}
+	if o.perPage > 0 {
+		q.Set("limit", strconv.Itoa(o.perPage))
+	}
+	if o.cursor != "" {
+		q.Set("cursor", o.cursor)
+	}
+	return q
+}
+
+// Page is one page of a list. Endpoints that return their whole collection
+// at once come back as a single page with HasNextPage false.
+type Page[T any] struct {
+	Items   []T
+	Page    int
+	PerPage int
+	// Total is the size of the whole collection when the API reports it
+	Total int64
+	// NextCursor fetches the next page of cursor-paginated lists, see
+	// WithCursor; empty on the last page and for page-numbered lists
+	NextCursor string
+
+	hasNext bool
+	fetch   func(ctx context.Context, opts listOptions) (*Page[T], error)
+	opts    listOptions
+}
+
+// HasNextPage reports whether NextPage will return more items
+func (p *Page[T]) HasNextPage() bool {
+	return p.hasNext
+}
+
+// NextPage fetches the page after this one
+func (p *Page[T]) NextPage(ctx context.Context) (*Page[T], error) {
+	if !p.hasNext {
+		return nil, fmt.Errorf("no next page")
+	}
+	opts := p.opts
+	if p.NextCursor != "" {
+		opts.cursor = p.NextCursor
+	} else {
+		opts.page = p.Page + 1
+	}
+	return p.fetch(ctx, opts)
+}
+
+// Iterator walks every item of a list, fetching further pages as needed:
+//
+//	it := client.Projects.Iter(ctx)
+//	for it.Next() {
+//		fmt.Println(it.Value().Name)
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	ctx   context.Context
+	first func(ctx context.Context) (*Page[T], error)
+	page  *Page[T]
+	index int
+	err   error
+}
+
+func newIterator[T any](ctx context.Context, first func(ctx context.Context) (*Page[T], error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, first: first, index: -1}
+}
+
+// Next advances to the next item and reports whether there is one
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.page == nil {
+		if it.page, it.err = it.first(it.ctx); it.err != nil {
+			return false
+		}
+	}
+	for it.index+1 >= len(it.page.Items) {
+		if !it.page.HasNextPage() {
+			return false
+		}
+		next, err := it.page.NextPage(it.ctx)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page, it.index = next, -1
+	}
+	it.index++
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	var zero T
+	if it.page == nil || it.index < 0 || it.index >= len(it.page.Items) {
+		return zero
+	}
+	return it.page.Items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// pagination is the block page-numbered API responses carry next to their
+// items. Cursor-paginated responses carry a next_cursor instead.
+type pagination struct {
+	Page    int   `json:"page"`
+	Limit   int   `json:"limit"`
+	Total   int64 `json:"total"`
+	HasNext bool  `json:"has_next"`
+}
+
+// listPage fetches one page of path, reading the items from the response
+// field key (or "data" for the API's generic paginated envelope)
+func listPage[T any](c *Client, path, key string) func(ctx context.Context, opts listOptions) (*Page[T], error) {
+	var fetch func(ctx context.Context, opts listOptions) (*Page[T], error)
+	fetch = func(ctx context.Context, opts listOptions) (*Page[T], error) {
+		var raw map[string]json.RawMessage
+		if err := c.get(ctx, path, opts.values(), &raw); err != nil {
+			return nil, err
+		}
+
+		page := &Page[T]{Page: opts.page, PerPage: opts.perPage, fetch: fetch, opts: opts}
+		items, ok := raw[key]
+		if !ok {
+			items = raw["data"]
+		}
+		if len(items) > 0 && string(items) != "null" {
+			if err := json.Unmarshal(items, &page.Items); err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+			}
+		}
+		if cursor, ok := raw["next_cursor"]; ok {
+			if err := json.Unmarshal(cursor, &page.NextCursor); err != nil {
+				return nil, fmt.Errorf("failed to decode next_cursor: %w", err)
+			}
+			page.hasNext = page.NextCursor != ""
+		} else if block, ok := raw["pagination"]; ok {
+			var p pagination
+			if err := json.Unmarshal(block, &p); err == nil {
+				page.Page, page.PerPage, page.Total, page.hasNext = p.Page, p.Limit, p.Total, p.HasNext
+			}
+		} else {
+			page.Total = int64(len(page.Items))
+		}
+		return page, nil
+	}
+	return fetch
+}
+
+// list fetches the first page requested by opts
+func list[T any](ctx context.Context, c *Client, path, key string, opts []ListOption) (*Page[T], error) {
+	return listPage[T](c, path, key)(ctx, newListOptions(opts))
+}
+
+// iterate walks every item of path across pages
+func iterate[T any](ctx context.Context, c *Client, path, key string, opts []ListOption) *Iterator[T] {
+	fetch := listPage[T](c, path, key)
+	o := newListOptions(opts)
+	return newIterator(ctx, func(ctx context.Context) (*Page[T], error) {
+		return fetch(ctx, o)
+	})
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// PreviewsService covers per-pull-request preview environments
+type PreviewsService struct {
+	client *Client
+}
+
+// CreatePreviewRequest is the body of Previews.Create
+type CreatePreviewRequest struct {
+	ServiceID    string `json:"service_id"`
+	PRNumber     int    `json:"pr_number"`
+	PRTitle      string `json:"pr_title,omitempty"`
+	PRURL        string `json:"pr_url,omitempty"`
+	PRAuthor     string `json:"pr_author,omitempty"`
+	PRBranch     string `json:"pr_branch"`
+	PRBaseBranch string `json:"pr_base_branch,omitempty"`
+	CommitSHA    string `json:"commit_sha"`
+}
+
+// CreatePreviewResult is the outcome of Previews.Create. Action is
+// "created" for a new preview and "updated" when an open preview for the
+// same pull request was moved to the new commit.
+type CreatePreviewResult struct {
+	Preview *types.PreviewEnvironment `json:"preview"`
+	Action  string                    `json:"action"`
+	Message string                    `json:"message"`
+}
+
+// List returns the previews of a service
+func (s *PreviewsService) List(ctx context.Context, serviceID string, opts ...ListOption) (*Page[*types.PreviewEnvironment], error) {
+	return list[*types.PreviewEnvironment](ctx, s.client, pathf("/services/%s/previews", serviceID), "previews", opts)
+}
+
+// Iter walks every preview of a service
+func (s *PreviewsService) Iter(ctx context.Context, serviceID string, opts ...ListOption) *Iterator[*types.PreviewEnvironment] {
+	return iterate[*types.PreviewEnvironment](ctx, s.client, pathf("/services/%s/previews", serviceID), "previews", opts)
+}
+
+// ListByProject returns the previews of every service in a project
+func (s *PreviewsService) ListByProject(ctx context.Context, projectSlug string, opts ...ListOption) (*Page[*types.PreviewEnvironment], error) {
+	return list[*types.PreviewEnvironment](ctx, s.client, pathf("/projects/%s/previews", projectSlug), "previews", opts)
+}
+
+// Get returns a preview by ID
+func (s *PreviewsService) Get(ctx context.Context, previewID string) (*types.PreviewEnvironment, error) {
+	var resp struct {
+		Preview *types.PreviewEnvironment `json:"preview"`
+	}
+	if err := s.client.get(ctx, pathf("/previews/%s", previewID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Preview, nil
+}
+
+// Create opens a preview for a pull request, or moves the open one to a
+// new commit
+func (s *PreviewsService) Create(ctx context.Context, req *CreatePreviewRequest) (*CreatePreviewResult, error) {
+	var result CreatePreviewResult
+	if err := s.client.post(ctx, "/previews", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close tears a preview down, e.g. once its pull request is merged
+func (s *PreviewsService) Close(ctx context.Context, previewID string) error {
+	return s.client.post(ctx, pathf("/previews/%s/close", previewID), nil, nil)
+}
+
+// Wake scales a sleeping preview back up
+func (s *PreviewsService) Wake(ctx context.Context, previewID string) error {
+	return s.client.post(ctx, pathf("/previews/%s/wake", previewID), nil, nil)
+}
+
+// Delete removes a preview and its history. Requires the admin role.
+func (s *PreviewsService) Delete(ctx context.Context, previewID string) error {
+	return s.client.delete(ctx, pathf("/previews/%s", previewID))
+}
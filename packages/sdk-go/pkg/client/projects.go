@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ProjectsService covers /v1/projects and a project's environments
+type ProjectsService struct {
+	client *Client
+}
+
+// CreateProjectRequest is the body of Projects.Create
+type CreateProjectRequest struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description,omitempty"`
+}
+
+// CreateEnvironmentRequest is the body of Projects.CreateEnvironment
+type CreateEnvironmentRequest struct {
+	Name          string `json:"name"`
+	KubeNamespace string `json:"kube_namespace,omitempty"`
+	// Cluster is a registered cluster name; empty deploys to the API's own
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// List returns the projects the caller can see
+func (s *ProjectsService) List(ctx context.Context, opts ...ListOption) (*Page[*types.Project], error) {
+	return list[*types.Project](ctx, s.client, "/projects", "projects", opts)
+}
+
+// Iter walks every project
+func (s *ProjectsService) Iter(ctx context.Context, opts ...ListOption) *Iterator[*types.Project] {
+	return iterate[*types.Project](ctx, s.client, "/projects", "projects", opts)
+}
+
+// ListEvents returns the event history of a project, newest first. Filter
+// by type with WithQuery("type", "deployment,build.failed").
+func (s *ProjectsService) ListEvents(ctx context.Context, slug string, opts ...ListOption) (*Page[*types.ProjectEvent], error) {
+	return list[*types.ProjectEvent](ctx, s.client, pathf("/projects/%s/events", slug), "events", opts)
+}
+
+// IterEvents walks the event history of a project
+func (s *ProjectsService) IterEvents(ctx context.Context, slug string, opts ...ListOption) *Iterator[*types.ProjectEvent] {
+	return iterate[*types.ProjectEvent](ctx, s.client, pathf("/projects/%s/events", slug), "events", opts)
+}
+
+// Get returns a project by slug
+func (s *ProjectsService) Get(ctx context.Context, slug string) (*types.Project, error) {
+	var project types.Project
+	if err := s.client.get(ctx, pathf("/projects/%s", slug), nil, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// Create creates a project. Requires the admin role.
+func (s *ProjectsService) Create(ctx context.Context, req *CreateProjectRequest) (*types.Project, error) {
+	var project types.Project
+	if err := s.client.post(ctx, "/projects", req, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// Delete moves a project and its services to the trash, where they can be
+// restored until the retention window ends. Requires the admin role.
+func (s *ProjectsService) Delete(ctx context.Context, slug string) error {
+	return s.client.delete(ctx, pathf("/projects/%s", slug))
+}
+
+// Restore takes a project and the services deleted with it out of the
+// trash. Requires the admin role.
+func (s *ProjectsService) Restore(ctx context.Context, slug string) (*types.Project, error) {
+	var project types.Project
+	if err := s.client.post(ctx, pathf("/projects/%s/restore", slug), nil, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetQuota returns a project's resource quota and what it holds. The
+// project is also held to the quota of its team.
+func (s *ProjectsService) GetQuota(ctx context.Context, slug string) (*ResourceQuotaStatus, error) {
+	var status ResourceQuotaStatus
+	if err := s.client.get(ctx, pathf("/projects/%s/quota", slug), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SetQuota replaces a project's resource quota; nil limits are removed.
+// Requires a platform admin.
+func (s *ProjectsService) SetQuota(ctx context.Context, slug string, quota *types.ResourceQuota) (*ResourceQuotaStatus, error) {
+	var status ResourceQuotaStatus
+	if err := s.client.put(ctx, pathf("/projects/%s/quota", slug), nil, quota, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Status returns the live status of every service in a project
+func (s *ProjectsService) Status(ctx context.Context, slug string) (*types.ProjectStatus, error) {
+	var status types.ProjectStatus
+	if err := s.client.get(ctx, pathf("/projects/%s/status", slug), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// ListEnvironments returns a project's environments
+func (s *ProjectsService) ListEnvironments(ctx context.Context, slug string) ([]*types.Environment, error) {
+	var resp struct {
+		Environments []*types.Environment `json:"environments"`
+	}
+	if err := s.client.get(ctx, pathf("/projects/%s/environments", slug), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Environments, nil
+}
+
+// GetEnvironment returns one of a project's environments by name
+func (s *ProjectsService) GetEnvironment(ctx context.Context, slug, envName string) (*types.Environment, error) {
+	var env types.Environment
+	if err := s.client.get(ctx, pathf("/projects/%s/environments/%s", slug, envName), nil, &env); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+// CreateEnvironment adds an environment to a project
+func (s *ProjectsService) CreateEnvironment(ctx context.Context, slug string, req *CreateEnvironmentRequest) (*types.Environment, error) {
+	var env types.Environment
+	if err := s.client.post(ctx, pathf("/projects/%s/environments", slug), req, &env); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// ServicesService covers a project's services, their builds and releases
+type ServicesService struct {
+	client *Client
+}
+
+// CreateServiceRequest is the body of Services.Create
+type CreateServiceRequest struct {
+	Name        string            `json:"name"`
+	GitRepo     string            `json:"git_repo"`
+	BuildConfig types.BuildConfig `json:"build_config"`
+}
+
+// UpdateServiceRequest is the body of Services.Update. Nil fields are left
+// unchanged.
+type UpdateServiceRequest struct {
+	Name             *string                 `json:"name,omitempty"`
+	GitRepo          *string                 `json:"git_repo,omitempty"`
+	AppPath          *string                 `json:"app_path,omitempty"`
+	AutoDeploy       *bool                   `json:"auto_deploy,omitempty"`
+	AutoDeployBranch *string                 `json:"auto_deploy_branch,omitempty"`
+	AutoDeployEnv    *string                 `json:"auto_deploy_env,omitempty"`
+	BuildConfig      *types.BuildConfig      `json:"build_config,omitempty"`
+	Labels           *map[string]string      `json:"labels,omitempty"` // Replaces all labels; {} clears them
+	Scheduling       *types.SchedulingConfig `json:"scheduling,omitempty"`
+	GPU              *types.GPUConfig        `json:"gpu,omitempty"`
+	Command          *types.ContainerCommand `json:"command,omitempty"`
+	Shutdown         *types.ShutdownConfig   `json:"shutdown,omitempty"`
+	HighAvailability *bool                   `json:"high_availability,omitempty"`
+}
+
+// BuildRequest is the body of Services.Build. Empty fields build the
+// service's default branch.
+type BuildRequest struct {
+	GitSHA    string `json:"git_sha,omitempty"`
+	GitBranch string `json:"git_branch,omitempty"`
+	// ContextID builds an uploaded build context instead of a git checkout
+	ContextID string `json:"context_id,omitempty"`
+}
+
+// List returns the services of a project
+func (s *ServicesService) List(ctx context.Context, projectSlug string, opts ...ListOption) (*Page[*types.Service], error) {
+	return list[*types.Service](ctx, s.client, pathf("/projects/%s/services", projectSlug), "services", opts)
+}
+
+// Iter walks every service of a project
+func (s *ServicesService) Iter(ctx context.Context, projectSlug string, opts ...ListOption) *Iterator[*types.Service] {
+	return iterate[*types.Service](ctx, s.client, pathf("/projects/%s/services", projectSlug), "services", opts)
+}
+
+// Get returns a service by ID
+func (s *ServicesService) Get(ctx context.Context, serviceID string) (*types.Service, error) {
+	var service types.Service
+	if err := s.client.get(ctx, pathf("/services/%s", serviceID), nil, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// Create adds a service to a project
+func (s *ServicesService) Create(ctx context.Context, projectSlug string, req *CreateServiceRequest) (*types.Service, error) {
+	var service types.Service
+	if err := s.client.post(ctx, pathf("/projects/%s/services", projectSlug), req, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// Update changes the fields set in req and returns the updated service
+func (s *ServicesService) Update(ctx context.Context, serviceID string, req *UpdateServiceRequest) (*types.Service, error) {
+	var resp struct {
+		Service *types.Service `json:"service"`
+	}
+	if err := s.client.patch(ctx, pathf("/services/%s", serviceID), req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Service, nil
+}
+
+// Delete moves a service to the trash, where it can be restored until the
+// retention window ends. Requires the admin role.
+func (s *ServicesService) Delete(ctx context.Context, serviceID string) error {
+	return s.client.delete(ctx, pathf("/services/%s", serviceID))
+}
+
+// Restore takes a service out of the trash. Requires the admin role.
+func (s *ServicesService) Restore(ctx context.Context, serviceID string) (*types.Service, error) {
+	var resp struct {
+		Service *types.Service `json:"service"`
+	}
+	if err := s.client.post(ctx, pathf("/services/%s/restore", serviceID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Service, nil
+}
+
+// Build starts a build and returns the release it will produce
+func (s *ServicesService) Build(ctx context.Context, serviceID string, req *BuildRequest) (*types.Release, error) {
+	if req == nil {
+		req = &BuildRequest{}
+	}
+	var release types.Release
+	if err := s.client.post(ctx, pathf("/services/%s/build", serviceID), req, &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// ListReleases returns the releases built for a service
+func (s *ServicesService) ListReleases(ctx context.Context, serviceID string) ([]*types.Release, error) {
+	var resp struct {
+		Releases []*types.Release `json:"releases"`
+	}
+	if err := s.client.get(ctx, pathf("/services/%s/releases", serviceID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Releases, nil
+}
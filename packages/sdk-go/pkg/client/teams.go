@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/madfam-org/enclii/packages/sdk-go/pkg/types"
+)
+
+// TeamsService covers teams and their members
+type TeamsService struct {
+	client *Client
+}
+
+// Team is a team as the teams API returns it, including the caller's role
+type Team struct {
+	ID              uuid.UUID             `json:"id"`
+	Name            string                `json:"name"`
+	Slug            string                `json:"slug"`
+	Description     *string               `json:"description,omitempty"`
+	AvatarURL       *string               `json:"avatar_url,omitempty"`
+	BillingEmail    *string               `json:"billing_email,omitempty"`
+	MemberCount     int                   `json:"member_count"`
+	TwoFactorPolicy types.TwoFactorPolicy `json:"two_factor_policy"`
+	UserRole        string                `json:"user_role,omitempty"`
+	CreatedAt       string                `json:"created_at"`
+	UpdatedAt       string                `json:"updated_at"`
+}
+
+// TeamMember is a member of a team
+type TeamMember struct {
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Name     *string   `json:"name,omitempty"`
+	Role     string    `json:"role"`
+	JoinedAt string    `json:"joined_at"`
+}
+
+// CreateTeamRequest is the body of Teams.Create
+type CreateTeamRequest struct {
+	Name         string  `json:"name"`
+	Slug         string  `json:"slug"`
+	Description  *string `json:"description,omitempty"`
+	BillingEmail *string `json:"billing_email,omitempty"`
+}
+
+// UpdateTeamRequest is the body of Teams.Update. Nil fields are left
+// unchanged.
+type UpdateTeamRequest struct {
+	Name         *string `json:"name,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	BillingEmail *string `json:"billing_email,omitempty"`
+	AvatarURL    *string `json:"avatar_url,omitempty"`
+	// TwoFactorPolicy is one of "off", "production" or "all"
+	TwoFactorPolicy *string `json:"two_factor_policy,omitempty"`
+}
+
+// List returns the teams the caller belongs to
+func (s *TeamsService) List(ctx context.Context, opts ...ListOption) (*Page[*Team], error) {
+	return list[*Team](ctx, s.client, "/teams", "teams", opts)
+}
+
+// Iter walks every team the caller belongs to
+func (s *TeamsService) Iter(ctx context.Context, opts ...ListOption) *Iterator[*Team] {
+	return iterate[*Team](ctx, s.client, "/teams", "teams", opts)
+}
+
+// Get returns a team by slug
+func (s *TeamsService) Get(ctx context.Context, slug string) (*Team, error) {
+	var team Team
+	if err := s.client.get(ctx, pathf("/teams/%s", slug), nil, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// Create creates a team with the caller as its owner
+func (s *TeamsService) Create(ctx context.Context, req *CreateTeamRequest) (*Team, error) {
+	var team Team
+	if err := s.client.post(ctx, "/teams", req, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// Update changes the fields set in req
+func (s *TeamsService) Update(ctx context.Context, slug string, req *UpdateTeamRequest) (*Team, error) {
+	var team Team
+	if err := s.client.patch(ctx, pathf("/teams/%s", slug), req, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// Delete deletes a team. Only its owner may.
+func (s *TeamsService) Delete(ctx context.Context, slug string) error {
+	return s.client.delete(ctx, pathf("/teams/%s", slug))
+}
+
+// ListMembers returns the members of a team
+func (s *TeamsService) ListMembers(ctx context.Context, slug string, opts ...ListOption) (*Page[*TeamMember], error) {
+	return list[*TeamMember](ctx, s.client, pathf("/teams/%s/members", slug), "members", opts)
+}
+
+// RemoveMember removes a member from a team
+func (s *TeamsService) RemoveMember(ctx context.Context, slug, memberID string) error {
+	return s.client.delete(ctx, pathf("/teams/%s/members/%s", slug, memberID))
+}
+
+// ResourceQuotaStatus is the resource quota of a team or a project and what
+// it holds
+type ResourceQuotaStatus struct {
+	Team    string               `json:"team,omitempty"`
+	Project string               `json:"project,omitempty"`
+	Quota   *types.ResourceQuota `json:"quota"`
+	Usage   *types.ResourceUsage `json:"usage"`
+}
+
+// GetQuota returns a team's resource quota and what its projects hold
+func (s *TeamsService) GetQuota(ctx context.Context, slug string) (*ResourceQuotaStatus, error) {
+	var status ResourceQuotaStatus
+	if err := s.client.get(ctx, pathf("/teams/%s/quota", slug), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SetQuota replaces a team's resource quota; nil limits are removed.
+// Requires a platform admin.
+func (s *TeamsService) SetQuota(ctx context.Context, slug string, quota *types.ResourceQuota) (*ResourceQuotaStatus, error) {
+	var status ResourceQuotaStatus
+	if err := s.client.put(ctx, pathf("/teams/%s/quota", slug), nil, quota, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,156 @@
+// Package redact masks secrets in log lines: known secret values, and
+// tokens recognisable by their shape such as AWS keys and bearer tokens.
+// A Redactor counts what it masks by kind, so redactions can be reported.
+//
+// Roundhouse redacts build logs with it before storing them, and Switchyard
+// redacts logs before returning or streaming them.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Mask replaces redacted secrets
+	Mask = "********"
+
+	// MinValueLength keeps very short values from masking unrelated text
+	MinValueLength = 4
+)
+
+// Kinds of redactions
+const (
+	KindSecret       = "secret"
+	KindAWSAccessKey = "aws_access_key"
+	KindAWSSecretKey = "aws_secret_key"
+	KindBearerToken  = "bearer_token"
+	KindGitHubToken  = "github_token"
+)
+
+// pattern is a token shape. Only the last group of the expression is
+// masked, so a key name or scheme before the token stays readable.
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var patterns = []pattern{
+	{KindAWSAccessKey, regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{KindAWSSecretKey, regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})`)},
+	{KindBearerToken, regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{8,}=*)`)},
+	{KindGitHubToken, regexp.MustCompile(`\b((?:gh[pousr]_[A-Za-z0-9]{36,})|(?:github_pat_[A-Za-z0-9_]{22,}))\b`)},
+}
+
+// Sources of redacted logs, as reported with their redactions
+const (
+	SourceBuild   = "build"   // Build logs stored by Roundhouse
+	SourceRuntime = "runtime" // Logs of running services and jobs
+)
+
+// Redactor masks secrets in log lines. A nil Redactor masks nothing.
+type Redactor struct {
+	values   *strings.Replacer
+	patterns bool
+	observe  func(counts map[string]int)
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// New returns a Redactor masking values, and each line of them, and with
+// patterns also the token patterns
+func New(values []string, patterns bool) *Redactor {
+	var lines []string
+	for _, value := range values {
+		lines = append(lines, value)
+		lines = append(lines, strings.Split(value, "\n")...)
+	}
+	// Longest first, so a value containing another is masked whole
+	sort.Slice(lines, func(i, j int) bool { return len(lines[i]) > len(lines[j]) })
+
+	var pairs []string
+	seen := make(map[string]bool)
+	for _, value := range lines {
+		value = strings.TrimSpace(value)
+		if len(value) < MinValueLength || seen[value] {
+			continue
+		}
+		seen[value] = true
+		pairs = append(pairs, value, Mask)
+	}
+
+	r := &Redactor{patterns: patterns, counts: make(map[string]int)}
+	if len(pairs) > 0 {
+		r.values = strings.NewReplacer(pairs...)
+	}
+	return r
+}
+
+// Redact returns line with its secrets masked
+func (r *Redactor) Redact(line string) string {
+	if r == nil {
+		return line
+	}
+	counts := make(map[string]int)
+
+	if r.values != nil {
+		before := strings.Count(line, Mask)
+		line = r.values.Replace(line)
+		counts[KindSecret] = strings.Count(line, Mask) - before
+	}
+
+	if r.patterns {
+		for _, p := range patterns {
+			line = p.re.ReplaceAllStringFunc(line, func(match string) string {
+				sub := p.re.FindStringSubmatchIndex(match)
+				start, end := sub[len(sub)-2], sub[len(sub)-1]
+				if start < 0 {
+					return match
+				}
+				counts[p.kind]++
+				return match[:start] + Mask + match[end:]
+			})
+		}
+	}
+
+	r.mu.Lock()
+	for kind, n := range counts {
+		if n > 0 {
+			r.counts[kind] += n
+		}
+	}
+	r.mu.Unlock()
+	if r.observe != nil {
+		r.observe(counts)
+	}
+	return line
+}
+
+// Observe has fn called with the secrets masked by each line redacted, by
+// kind, and returns r
+func (r *Redactor) Observe(fn func(counts map[string]int)) *Redactor {
+	if r != nil {
+		r.observe = fn
+	}
+	return r
+}
+
+// Counts returns how many secrets of each kind were masked
+func (r *Redactor) Counts() map[string]int {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.counts) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(r.counts))
+	for kind, n := range r.counts {
+		counts[kind] = n
+	}
+	return counts
+}
@@ -80,3 +80,9 @@ func IsValidUUID(s string) bool {
 	_, err := uuid.Parse(s)
 	return err == nil
 }
+
+// IsAvailable reports whether an addon can serve connections. Resizes are
+// applied in place, so a resizing addon stays bound to its services.
+func (a *DatabaseAddon) IsAvailable() bool {
+	return a.Status == DatabaseAddonStatusReady || a.Status == DatabaseAddonStatusResizing
+}
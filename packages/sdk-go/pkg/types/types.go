@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,21 +9,82 @@ import (
 
 // Project represents a collection of services
 type Project struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Slug      string    `json:"slug" db:"slug"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID             uuid.UUID `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name"`
+	Slug           string    `json:"slug" db:"slug"`
+	PreviewTTLDays *int      `json:"preview_ttl_days,omitempty" db:"preview_ttl_days"` // nil = platform default
+	// LogRedactionDisabled stops masking token patterns and secret env var
+	// values in the project's logs
+	LogRedactionDisabled bool       `json:"log_redaction_disabled,omitempty" db:"log_redaction_disabled"`
+	DeletedAt            *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Set while the project is in the trash
+	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // Environment represents a deployment target (dev, staging, prod, preview-*)
 type Environment struct {
-	ID            uuid.UUID `json:"id" db:"id"`
-	ProjectID     uuid.UUID `json:"project_id" db:"project_id"`
-	Name          string    `json:"name" db:"name"`
-	KubeNamespace string    `json:"kube_namespace" db:"kube_namespace"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	ID            uuid.UUID  `json:"id" db:"id"`
+	ProjectID     uuid.UUID  `json:"project_id" db:"project_id"`
+	Name          string     `json:"name" db:"name"`
+	KubeNamespace string     `json:"kube_namespace" db:"kube_namespace"`
+	ClusterID     *uuid.UUID `json:"cluster_id,omitempty" db:"cluster_id"` // Cluster deployed to; nil for the one the API runs in
+	Cluster       string     `json:"cluster,omitempty" db:"-"`             // Name of ClusterID
+	// DeployConcurrency is what a deployment does about pending deployments
+	// of the same service in the environment
+	DeployConcurrency DeployConcurrency `json:"deploy_concurrency" db:"deploy_concurrency"`
+	// DeployGuardDisabled opts the environment out of the deploy guard, which
+	// rolls back deployments that crash or fail requests soon after a deploy
+	DeployGuardDisabled bool `json:"deploy_guard_disabled" db:"deploy_guard_disabled"`
+	// PromotesTo is the next environment of the promotion pipeline, e.g.,
+	// staging for development
+	PromotesTo *uuid.UUID `json:"promotes_to,omitempty" db:"promotes_to"`
+	// AutoPromote promotes releases to PromotesTo once they pass their soak
+	AutoPromote bool      `json:"auto_promote" db:"auto_promote"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DeployConcurrency decides between pending deployments of the same service
+// in an environment. They are never reconciled at the same time either way.
+type DeployConcurrency string
+
+const (
+	// DeployConcurrencySupersede rolls out the newest pending deployment
+	// and marks older ones superseded
+	DeployConcurrencySupersede DeployConcurrency = "supersede"
+	// DeployConcurrencyQueue rolls out pending deployments one after the
+	// other, oldest first
+	DeployConcurrencyQueue DeployConcurrency = "queue"
+)
+
+// DeployLock is held while a deployment of a service is reconciled in an
+// environment
+type DeployLock struct {
+	ServiceID     uuid.UUID `json:"service_id" db:"service_id"`
+	ServiceName   string    `json:"service_name,omitempty" db:"-"`
+	EnvironmentID uuid.UUID `json:"environment_id" db:"environment_id"`
+	DeploymentID  uuid.UUID `json:"deployment_id" db:"deployment_id"`
+	Owner         string    `json:"owner" db:"owner"` // Replica holding the lock
+	LockedUntil   time.Time `json:"locked_until" db:"locked_until"`
+	AcquiredAt    time.Time `json:"acquired_at" db:"acquired_at"`
+}
+
+// ReleasePromotion records a release promoted from an environment it was
+// validated in to another. The promotions of a release form its promotion
+// chain.
+type ReleasePromotion struct {
+	ID                uuid.UUID  `json:"id" db:"id"`
+	ReleaseID         uuid.UUID  `json:"release_id" db:"release_id"`
+	FromEnvironmentID uuid.UUID  `json:"from_environment_id" db:"from_environment_id"`
+	FromEnvironment   string     `json:"from_environment,omitempty" db:"-"` // Name of FromEnvironmentID
+	ToEnvironmentID   uuid.UUID  `json:"to_environment_id" db:"to_environment_id"`
+	ToEnvironment     string     `json:"to_environment,omitempty" db:"-"` // Name of ToEnvironmentID
+	DeploymentID      *uuid.UUID `json:"deployment_id,omitempty" db:"deployment_id"`
+	ImageURI          string     `json:"image_uri" db:"image_uri"`                 // Image the promotion deployed, the same in both environments
+	ImageDigest       string     `json:"image_digest,omitempty" db:"image_digest"` // Digest the image was pinned to, when known
+	Auto              bool       `json:"auto" db:"auto"`                           // Promoted automatically after passing its soak
+	PromotedBy        string     `json:"promoted_by,omitempty" db:"promoted_by"`   // Email of the user who promoted it; empty when automatic
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
 }
 
 // Service represents a deployable application
@@ -39,10 +101,28 @@ type Service struct {
 	HealthCheck *HealthCheckConfig `json:"health_check,omitempty" db:"health_check"`
 	// Resource configuration for container limits
 	Resources *ResourceConfig `json:"resources,omitempty" db:"resources"`
+	// Scheduling places the service's pods on particular nodes
+	Scheduling *SchedulingConfig `json:"scheduling,omitempty" db:"scheduling"`
+	// GPU requests NVIDIA GPUs for each of the service's pods
+	GPU *GPUConfig `json:"gpu,omitempty" db:"gpu"`
+	// Command overrides the entrypoint, args and working directory of the image,
+	// so one image can back several services (e.g., web, worker, scheduler)
+	Command *ContainerCommand `json:"command,omitempty" db:"command"`
+	// Shutdown controls how the service's pods drain and are replaced during deploys
+	Shutdown *ShutdownConfig `json:"shutdown,omitempty" db:"shutdown"`
+	// HighAvailability gives services with more than one replica a pod
+	// disruption budget and spreads their pods across nodes and zones
+	HighAvailability bool `json:"high_availability" db:"high_availability"`
+	// Runtime is the auto-detected language/framework (set on registration and each build)
+	Runtime *ServiceRuntime `json:"runtime,omitempty" db:"runtime"`
 	// AutoDeploy configuration for webhook-triggered deployments
 	AutoDeploy       bool   `json:"auto_deploy" db:"auto_deploy"`               // Enable auto-deploy on successful build
 	AutoDeployBranch string `json:"auto_deploy_branch" db:"auto_deploy_branch"` // Branch to auto-deploy (e.g., "main", "master")
 	AutoDeployEnv    string `json:"auto_deploy_env" db:"auto_deploy_env"`       // Target environment (e.g., "development", "staging")
+	// AutoDeployRules filter which pushes build and deploy the service
+	AutoDeployRules *AutoDeployRules `json:"auto_deploy_rules,omitempty" db:"auto_deploy_rules"`
+	// Labels group services for bulk operations (e.g., {"tier": "frontend"})
+	Labels map[string]string `json:"labels,omitempty" db:"labels"`
 	// Health tracking fields (populated by Cartographer from K8s)
 	K8sNamespace    *string      `json:"k8s_namespace,omitempty" db:"k8s_namespace"` // Actual K8s namespace (may differ from project slug)
 	Health          HealthStatus `json:"health" db:"health"`                         // Service health: unknown, healthy, unhealthy
@@ -50,10 +130,24 @@ type Service struct {
 	DesiredReplicas int          `json:"desired_replicas" db:"desired_replicas"`     // Desired replica count from K8s
 	ReadyReplicas   int          `json:"ready_replicas" db:"ready_replicas"`         // Ready replica count from K8s
 	LastHealthCheck *time.Time   `json:"last_health_check,omitempty" db:"last_health_check"`
+	DeletedAt       *time.Time   `json:"deleted_at,omitempty" db:"deleted_at"` // Set while the service is in the trash
 	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
 }
 
+// ServiceRuntime describes the language and framework detected from a service's source
+type ServiceRuntime struct {
+	Language       string    `json:"language"`                  // "nodejs", "go", "python", "ruby", "java", "rust", "static"
+	Framework      string    `json:"framework,omitempty"`       // "nextjs", "express", "gin", "fastapi", "rails", ...
+	PackageManager string    `json:"package_manager,omitempty"` // "npm", "pnpm", "yarn", "bun", "go", "pip", "poetry", "uv", ...
+	HasDockerfile  bool      `json:"has_dockerfile"`
+	BuildStrategy  string    `json:"build_strategy"`         // "dockerfile" or "buildpacks"
+	DefaultPort    int       `json:"default_port,omitempty"` // Conventional listen port for the framework
+	HealthPath     string    `json:"health_path,omitempty"`  // Probe path that works without app changes
+	DetectedFrom   string    `json:"detected_from"`          // "registration" or "build"
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
 // HealthCheckConfig defines how Kubernetes probes should check service health
 type HealthCheckConfig struct {
 	// Path for HTTP health check endpoint (default: "/health")
@@ -88,14 +182,165 @@ type ResourceConfig struct {
 	MemoryLimit string `json:"memory_limit,omitempty" yaml:"memoryLimit,omitempty"`
 }
 
+// SchedulingConfig defines which nodes a service's pods may run on and how
+// they are spread across them
+type SchedulingConfig struct {
+	// NodeSelector requires node labels (e.g., {"cloud.google.com/gke-nodepool": "gpu"})
+	NodeSelector map[string]string `json:"node_selector,omitempty" yaml:"nodeSelector,omitempty"`
+	// Tolerations let pods run on tainted nodes (e.g., a spot pool)
+	Tolerations []Toleration `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+	// TopologySpread spreads the service's pods across zones or nodes
+	TopologySpread []TopologySpreadConstraint `json:"topology_spread,omitempty" yaml:"topologySpread,omitempty"`
+}
+
+// Toleration tolerates node taints matching its key, value and effect
+type Toleration struct {
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// Operator is "Equal" (default) or "Exists"
+	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value    string `json:"value,omitempty" yaml:"value,omitempty"`
+	// Effect is "NoSchedule", "PreferNoSchedule" or "NoExecute"; empty matches all
+	Effect string `json:"effect,omitempty" yaml:"effect,omitempty"`
+	// TolerationSeconds bounds how long a NoExecute taint is tolerated
+	TolerationSeconds *int64 `json:"toleration_seconds,omitempty" yaml:"tolerationSeconds,omitempty"`
+}
+
+// TopologySpreadConstraint limits how unevenly a service's pods are spread
+// across the domains of a node label
+type TopologySpreadConstraint struct {
+	// TopologyKey is the node label of the domains (e.g., "topology.kubernetes.io/zone")
+	TopologyKey string `json:"topology_key" yaml:"topologyKey"`
+	// MaxSkew is the largest allowed difference in pods between domains (default: 1)
+	MaxSkew int32 `json:"max_skew,omitempty" yaml:"maxSkew,omitempty"`
+	// WhenUnsatisfiable is "DoNotSchedule" or "ScheduleAnyway" (default)
+	WhenUnsatisfiable string `json:"when_unsatisfiable,omitempty" yaml:"whenUnsatisfiable,omitempty"`
+}
+
+// GPUConfig requests NVIDIA GPUs for a container
+type GPUConfig struct {
+	// Count is the number of GPUs, or of MIG slices when MIGProfile is set
+	Count int `json:"count" yaml:"count"`
+	// MIGProfile requests slices of a partitioned GPU (e.g., "1g.5gb") instead of whole GPUs
+	MIGProfile string `json:"mig_profile,omitempty" yaml:"migProfile,omitempty"`
+	// RuntimeClass is the container runtime with GPU access (default: "nvidia")
+	RuntimeClass string `json:"runtime_class,omitempty" yaml:"runtimeClass,omitempty"`
+}
+
+// AutoDeployMode is whether pushes build a service
+type AutoDeployMode string
+
+const (
+	// AutoDeployModeAuto builds pushes that match the auto-deploy rules
+	AutoDeployModeAuto AutoDeployMode = "auto"
+	// AutoDeployModeManual never builds on push; releases are built and
+	// deployed by hand
+	AutoDeployModeManual AutoDeployMode = "manual"
+)
+
+// AutoDeployRules filter which pushes build a service and where the builds
+// are deployed. Without rules, pushes to main and master build.
+type AutoDeployRules struct {
+	// Mode is "auto" (default) or "manual"
+	Mode AutoDeployMode `json:"mode,omitempty"`
+	// Branches are globs of the branches whose pushes build and deploy to
+	// the auto-deploy environment (default: main, master and the auto-deploy
+	// branch)
+	Branches []string `json:"branches,omitempty"`
+	// IgnorePaths are globs of files whose changes alone don't build
+	// (e.g., "docs/**", "*.md")
+	IgnorePaths []string `json:"ignore_paths,omitempty"`
+	// Tags deploy builds of pushed tags; tags that match no rule don't build
+	Tags []TagDeployRule `json:"tags,omitempty"`
+}
+
+// TagDeployRule deploys builds of the tags matching a glob to an environment
+type TagDeployRule struct {
+	Pattern     string `json:"pattern"`     // Tag glob (e.g., "v*")
+	Environment string `json:"environment"` // Environment the builds deploy to (e.g., "production")
+}
+
+// ShutdownConfig controls how a service's pods are stopped and replaced, so
+// they can drain connections during deploys
+type ShutdownConfig struct {
+	// TerminationGracePeriodSeconds is how long pods have to stop, preStop
+	// hook included, before they are killed (default: 30)
+	TerminationGracePeriodSeconds *int64 `json:"termination_grace_period_seconds,omitempty" yaml:"terminationGracePeriodSeconds,omitempty"`
+	// PreStop runs before pods are sent SIGTERM
+	PreStop *PreStopHook `json:"pre_stop,omitempty" yaml:"preStop,omitempty"`
+	// MaxSurge is how many pods a deploy starts above the replicas, as a
+	// percentage or a number (default: "25%")
+	MaxSurge string `json:"max_surge,omitempty" yaml:"maxSurge,omitempty"`
+	// MaxUnavailable is how many replicas a deploy may take down at once,
+	// as a percentage or a number (default: "25%")
+	MaxUnavailable string `json:"max_unavailable,omitempty" yaml:"maxUnavailable,omitempty"`
+}
+
+// PreStopHook delays SIGTERM to a pod, either by waiting or by a request to
+// the container. Exactly one of SleepSeconds and HTTPPath is set.
+type PreStopHook struct {
+	// SleepSeconds waits so load balancers stop sending requests first
+	SleepSeconds int64 `json:"sleep_seconds,omitempty" yaml:"sleepSeconds,omitempty"`
+	// HTTPPath is requested with GET, e.g., to start draining (e.g., "/drain")
+	HTTPPath string `json:"http_path,omitempty" yaml:"httpPath,omitempty"`
+	// Port of the request (default: the container port)
+	Port int `json:"port,omitempty" yaml:"port,omitempty"`
+}
+
+// ContainerCommand overrides what a service's container runs. Fields left
+// empty keep the image's own ENTRYPOINT, CMD and WORKDIR.
+type ContainerCommand struct {
+	// Command replaces the image's ENTRYPOINT (e.g., ["bundle", "exec"])
+	Command []string `json:"command,omitempty" yaml:"command,omitempty"`
+	// Args replaces the image's CMD (e.g., ["sidekiq", "-q", "default"])
+	Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+	// WorkingDir replaces the image's WORKDIR
+	WorkingDir string `json:"working_dir,omitempty" yaml:"workingDir,omitempty"`
+}
+
+// IsEmpty reports whether a command leaves the image's own in place
+func (c *ContainerCommand) IsEmpty() bool {
+	return c == nil || (len(c.Command) == 0 && len(c.Args) == 0 && c.WorkingDir == "")
+}
+
 // BuildConfig defines how to build a service
 type BuildConfig struct {
-	Type       BuildType         `json:"type"`
-	Dockerfile string            `json:"dockerfile,omitempty"`
-	Buildpack  string            `json:"buildpack,omitempty"`
-	Context    string            `json:"context,omitempty"`
-	BuildArgs  map[string]string `json:"build_args,omitempty"`
-	Target     string            `json:"target,omitempty"`
+	Type       BuildType         `json:"type" yaml:"type"`
+	Dockerfile string            `json:"dockerfile,omitempty" yaml:"dockerfile,omitempty"`
+	Buildpack  string            `json:"buildpack,omitempty" yaml:"buildpack,omitempty"`
+	Context    string            `json:"context,omitempty" yaml:"context,omitempty"`
+	BuildArgs  map[string]string `json:"build_args,omitempty" yaml:"buildArgs,omitempty"`
+	Target     string            `json:"target,omitempty" yaml:"target,omitempty"`
+	// GPU runs the build on a GPU node with one GPU, for builds that compile
+	// or test against CUDA
+	GPU bool `json:"gpu,omitempty" yaml:"gpu,omitempty"`
+	// Artifacts is a directory of the built image whose files are published
+	// as build artifacts, e.g. /app/dist or /app/coverage
+	Artifacts string `json:"artifacts,omitempty" yaml:"artifacts,omitempty"`
+	// Resources sizes the build, e.g. for builds the defaults OOM-kill
+	Resources *BuildResources `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+// BuildResources are the resources and timeout of a service's builds and
+// how they use the layer cache. Unset fields keep the build defaults: 1 CPU
+// and 2Gi requested, 4 CPUs and 8Gi at most, the platform's build timeout
+// and a 7 day cache.
+type BuildResources struct {
+	CPURequest     string `json:"cpu_request,omitempty" yaml:"cpuRequest,omitempty"`       // e.g. "2"
+	MemoryRequest  string `json:"memory_request,omitempty" yaml:"memoryRequest,omitempty"` // e.g. "4Gi"
+	CPULimit       string `json:"cpu_limit,omitempty" yaml:"cpuLimit,omitempty"`
+	MemoryLimit    string `json:"memory_limit,omitempty" yaml:"memoryLimit,omitempty"`
+	TimeoutMinutes int    `json:"timeout_minutes,omitempty" yaml:"timeoutMinutes,omitempty"`
+	// Cache turns the layer cache off when false; nil keeps it on
+	Cache         *bool `json:"cache,omitempty" yaml:"cache,omitempty"`
+	CacheTTLHours int   `json:"cache_ttl_hours,omitempty" yaml:"cacheTTLHours,omitempty"`
+}
+
+// TeamBuildLimits are the largest build resources the services of a team
+// may set. Empty fields are not limited.
+type TeamBuildLimits struct {
+	MaxCPU            string `json:"max_cpu,omitempty"`    // e.g. "8"
+	MaxMemory         string `json:"max_memory,omitempty"` // e.g. "16Gi"
+	MaxTimeoutMinutes int    `json:"max_timeout_minutes,omitempty"`
 }
 
 type BuildType string
@@ -113,12 +358,19 @@ type Release struct {
 	Version             string        `json:"version" db:"version"`
 	ImageURI            string        `json:"image_uri" db:"image_uri"`
 	GitSHA              string        `json:"git_sha" db:"git_sha"`
+	Source              ReleaseSource `json:"source" db:"source"`                       // How the image was produced
+	ImageDigest         string        `json:"image_digest,omitempty" db:"image_digest"` // Digest an image release is pinned to
 	Status              ReleaseStatus `json:"status" db:"status"`
 	ErrorMessage        *string       `json:"error_message,omitempty" db:"error_message"`     // Error from build failure
 	SBOM                string        `json:"sbom,omitempty" db:"sbom"`                       // Software Bill of Materials (JSON)
 	SBOMFormat          string        `json:"sbom_format,omitempty" db:"sbom_format"`         // e.g., "cyclonedx-json", "spdx-json"
 	ImageSignature      string        `json:"image_signature,omitempty" db:"image_signature"` // Cosign signature
 	SignatureVerifiedAt *time.Time    `json:"signature_verified_at,omitempty" db:"signature_verified_at"`
+	ImageSizeBytes      *int64        `json:"image_size_bytes,omitempty" db:"image_size_bytes"`             // Size of the built image
+	BuildDurationSecs   *float64      `json:"build_duration_seconds,omitempty" db:"build_duration_seconds"` // Wall-clock build time
+	StalledAt           *time.Time    `json:"stalled_at,omitempty" db:"stalled_at"`                         // Set when the build exceeds the stall threshold
+	PrunedAt            *time.Time    `json:"pruned_at,omitempty" db:"pruned_at"`                           // Set when retention pruned the release; it can't be deployed
+	DeployEnv           string        `json:"deploy_env,omitempty" db:"deploy_env"`                         // Environment a tag push deploys the build to, over the service's auto-deploy environment
 	CreatedAt           time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time     `json:"updated_at" db:"updated_at"`
 }
@@ -131,6 +383,16 @@ const (
 	ReleaseStatusFailed   ReleaseStatus = "failed"
 )
 
+// ReleaseSource is how the image of a release was produced
+type ReleaseSource string
+
+const (
+	// ReleaseSourceBuild releases are built by Enclii from git or an uploaded build context
+	ReleaseSourceBuild ReleaseSource = "build"
+	// ReleaseSourceImage releases deploy an existing registry image without a build
+	ReleaseSourceImage ReleaseSource = "image"
+)
+
 // Deployment represents a running instance of a release in an environment
 type Deployment struct {
 	ID            uuid.UUID        `json:"id" db:"id"`
@@ -142,18 +404,788 @@ type Deployment struct {
 	Status        DeploymentStatus `json:"status" db:"status"`
 	Health        HealthStatus     `json:"health" db:"health"`
 	ErrorMessage  *string          `json:"error_message,omitempty" db:"error_message"` // Error from reconciliation failure
+	StalledAt     *time.Time       `json:"stalled_at,omitempty" db:"stalled_at"`       // Set when pending exceeds the stall threshold
+	// Annotations are computed deltas attached once the deployment completes
+	Annotations *DeploymentAnnotations `json:"annotations,omitempty" db:"annotations"`
+	// Progress is filled in when a single deployment is fetched
+	Progress  *DeploymentProgress `json:"progress,omitempty" db:"-"`
+	CreatedAt time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// DeploymentAnnotations captures cost and performance deltas of a deployment
+// compared to the previous successful deployment of the same service and environment.
+// Nil fields mean the value could not be computed (e.g., first deployment, missing build data).
+type DeploymentAnnotations struct {
+	PreviousDeploymentID *uuid.UUID `json:"previous_deployment_id,omitempty"`
+
+	// Image size
+	ImageSizeBytes      *int64 `json:"image_size_bytes,omitempty"`
+	ImageSizeDeltaBytes *int64 `json:"image_size_delta_bytes,omitempty"`
+
+	// Build duration compared to the service's average successful build
+	BuildDurationSecs     *float64 `json:"build_duration_seconds,omitempty"`
+	AvgBuildDurationSecs  *float64 `json:"avg_build_duration_seconds,omitempty"`
+	BuildDurationDeltaPct *float64 `json:"build_duration_delta_pct,omitempty"`
+
+	// Estimated monthly compute cost (USD) from resources x replicas
+	EstimatedMonthlyCost      float64  `json:"estimated_monthly_cost"`
+	EstimatedMonthlyCostDelta *float64 `json:"estimated_monthly_cost_delta,omitempty"`
+	ReplicasDelta             *int     `json:"replicas_delta,omitempty"`
+
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// SoakPolicy is how long deployments soak in an environment, and what they
+// must stay under, before their release is marked stable
+type SoakPolicy struct {
+	EnvironmentID   uuid.UUID `json:"environment_id" db:"environment_id"`
+	DurationMinutes int       `json:"duration_minutes" db:"duration_minutes"`       // 0 = no soak
+	MaxRestarts     int       `json:"max_restarts" db:"max_restarts"`               // Container restarts allowed during the soak
+	MaxErrorRate    *float64  `json:"max_error_rate,omitempty" db:"max_error_rate"` // Fraction of 5xx responses, nil = not checked
+	AutoRollback    bool      `json:"auto_rollback" db:"auto_rollback"`             // Redeploy the previous release when the soak fails
+	RequireStable   bool      `json:"require_stable" db:"require_stable"`           // Only accept releases that passed a soak elsewhere
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DeployPolicy restricts when releases are deployed to an environment.
+// Deploys are allowed inside one of Windows, or at any time when there are
+// none, and never during a freeze window of the environment. Admins can
+// override a blocked deploy in an emergency by giving a justification,
+// unless AllowOverride is off.
+type DeployPolicy struct {
+	EnvironmentID uuid.UUID      `json:"environment_id" db:"environment_id"`
+	Timezone      string         `json:"timezone" db:"timezone"` // IANA name windows are in, e.g. UTC or Europe/Berlin
+	Windows       []DeployWindow `json:"windows" db:"windows"`
+	AllowOverride bool           `json:"allow_override" db:"allow_override"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// RegistryCredential authenticates the builds and pods of a project to a
+// private container registry. The password is write-only and never returned.
+type RegistryCredential struct {
+	ID             uuid.UUID        `json:"id" db:"id"`
+	ProjectID      uuid.UUID        `json:"project_id" db:"project_id"`
+	EnvironmentID  *uuid.UUID       `json:"environment_id,omitempty" db:"environment_id"` // Only used in this environment; nil for all of them
+	Environment    string           `json:"environment,omitempty" db:"-"`                 // Name of EnvironmentID
+	Registry       string           `json:"registry" db:"registry"`                       // Registry host, e.g. ghcr.io or docker.io
+	Provider       RegistryProvider `json:"provider" db:"provider"`
+	Username       string           `json:"username" db:"username"`
+	PushRepository string           `json:"push_repository,omitempty" db:"push_repository"` // Where builds push images, e.g. ghcr.io/acme; empty for the platform registry
+	CreatedBy      string           `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// BuildSecret is a secret of a project that only its builds see, e.g. a
+// private package registry token. Builds get it as a file under
+// /run/secrets and never in image layers. The value is write-only and
+// never returned.
+type BuildSecret struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	Name      string    `json:"name" db:"name"` // e.g. NPM_TOKEN
+	CreatedBy string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RegistryProvider is how a registry credential authenticates
+type RegistryProvider string
+
+const (
+	RegistryProviderBasic  RegistryProvider = "basic"  // Username and password or token
+	RegistryProviderECR    RegistryProvider = "ecr"    // AWS access key, exchanged for 12-hour ECR tokens
+	RegistryProviderGCR    RegistryProvider = "gcr"    // Service account JSON key, exchanged for access tokens
+	RegistryProviderHarbor RegistryProvider = "harbor" // Harbor robot account
+)
+
+// Cluster is a Kubernetes cluster environments can be deployed to besides
+// the one the API runs in. Its kubeconfig is write-only and never returned.
+type Cluster struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description,omitempty" db:"description"`
+	APIServer   string    `json:"api_server" db:"api_server"`
+	KubeContext string    `json:"kube_context,omitempty" db:"kube_context"` // Empty for the kubeconfig's current context
+	CreatedBy   string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// LocalClusterName names the cluster the API runs in
+const LocalClusterName = "local"
+
+// ClusterHealth is the reachability and capacity of a cluster. Requests
+// are the sum of the resource requests of its running and pending pods.
+type ClusterHealth struct {
+	Cluster           string    `json:"cluster"`
+	Reachable         bool      `json:"reachable"`
+	Error             string    `json:"error,omitempty"`
+	Version           string    `json:"version,omitempty"`
+	Nodes             int       `json:"nodes"`
+	ReadyNodes        int       `json:"ready_nodes"`
+	CPUAllocatable    int64     `json:"cpu_allocatable_millicores"`
+	CPURequested      int64     `json:"cpu_requested_millicores"`
+	MemoryAllocatable int64     `json:"memory_allocatable_bytes"`
+	MemoryRequested   int64     `json:"memory_requested_bytes"`
+	PodCapacity       int64     `json:"pod_capacity"`
+	Pods              int       `json:"pods"`
+	Environments      int       `json:"environments"` // Environments deployed to the cluster
+	CheckedAt         time.Time `json:"checked_at"`
+}
+
+// NodePool is a group of a cluster's nodes sharing a pool label, as set by
+// GKE, EKS, AKS, Karpenter or enclii.dev/node-pool
+type NodePool struct {
+	Name          string            `json:"name"`
+	Label         string            `json:"label,omitempty"` // Label the pool is named by; empty for unpooled nodes
+	Nodes         int               `json:"nodes"`
+	ReadyNodes    int               `json:"ready_nodes"`
+	Labels        map[string]string `json:"labels"` // Labels every node of the pool has
+	Taints        []Taint           `json:"taints"` // Taints every node of the pool has
+	InstanceTypes []string          `json:"instance_types,omitempty"`
+}
+
+// Taint keeps pods that do not tolerate it off a node
+type Taint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// DeployWindow is a weekly period in which deploys are allowed, from Start
+// up to End on each of Days
+type DeployWindow struct {
+	Days  []string `json:"days"`  // mon, tue, wed, thu, fri, sat, sun
+	Start string   `json:"start"` // HH:MM
+	End   string   `json:"end"`   // HH:MM after Start; 24:00 for the end of the day
+}
+
+// IdleState is whether a service scaled to zero while idle is running
+type IdleState string
+
+const (
+	IdleStateAwake  IdleState = "awake"
+	IdleStateAsleep IdleState = "asleep" // Scaled to zero; woken by its next request
+)
+
+// ServiceIdleScaling scales a service in a non-production environment to
+// zero replicas after IdleMinutes without requests, and back up on the next
+// request to one of its custom domains
+type ServiceIdleScaling struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	ServiceID     uuid.UUID  `json:"service_id" db:"service_id"`
+	EnvironmentID uuid.UUID  `json:"environment_id" db:"environment_id"`
+	Environment   string     `json:"environment,omitempty" db:"-"`
+	IdleMinutes   int        `json:"idle_minutes" db:"idle_minutes"`
+	State         IdleState  `json:"state" db:"state"`
+	Replicas      int        `json:"replicas" db:"replicas"`             // Replicas to wake up with
+	ReplicaGB     float64    `json:"replica_gb" db:"replica_gb"`         // GB-equivalent of one replica, as Waybill bills compute
+	LastActiveAt  time.Time  `json:"last_active_at" db:"last_active_at"` // Last time requests were seen
+	AsleepSince   *time.Time `json:"asleep_since,omitempty" db:"asleep_since"`
+	SavedGBHours  float64    `json:"saved_gb_hours" db:"saved_gb_hours"`                           // Compute not used while asleep, in total
+	SavingsUntil  *time.Time `json:"savings_reported_until,omitempty" db:"savings_reported_until"` // Savings are reported to Waybill up to here
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ServiceEnvironmentCommand overrides a service's command in one
+// environment. It replaces the service's command as a whole.
+type ServiceEnvironmentCommand struct {
+	ID            uuid.UUID        `json:"id" db:"id"`
+	ServiceID     uuid.UUID        `json:"service_id" db:"service_id"`
+	EnvironmentID uuid.UUID        `json:"environment_id" db:"environment_id"`
+	Environment   string           `json:"environment,omitempty" db:"-"`
+	Command       ContainerCommand `json:"command" db:"command"`
 	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
 }
 
+// SoakStatus represents the status of a deployment soak
+type SoakStatus string
+
+const (
+	SoakStatusSoaking SoakStatus = "soaking"
+	SoakStatusPassed  SoakStatus = "passed"
+	SoakStatusFailed  SoakStatus = "failed"
+)
+
+// DeploymentSoak is the soak of one deployment under its environment's policy.
+// A release is stable once one of its deployments passed.
+type DeploymentSoak struct {
+	DeploymentID         uuid.UUID  `json:"deployment_id" db:"deployment_id"`
+	EnvironmentID        uuid.UUID  `json:"environment_id" db:"environment_id"`
+	ReleaseID            uuid.UUID  `json:"release_id" db:"release_id"`
+	Status               SoakStatus `json:"status" db:"status"`
+	StartedAt            time.Time  `json:"started_at" db:"started_at"`
+	EndsAt               time.Time  `json:"ends_at" db:"ends_at"`
+	BaselineRestarts     int        `json:"-" db:"baseline_restarts"` // Pod restarts when the soak started
+	Restarts             int        `json:"restarts" db:"restarts"`   // Restarts since the soak started
+	ErrorRate            *float64   `json:"error_rate,omitempty" db:"error_rate"`
+	Message              string     `json:"message,omitempty" db:"message"`
+	RollbackDeploymentID *uuid.UUID `json:"rollback_deployment_id,omitempty" db:"rollback_deployment_id"`
+	CompletedAt          *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// DriftPolicy is what a reconcile does with fields of a managed Kubernetes
+// resource that were changed outside Enclii
+type DriftPolicy string
+
+const (
+	DriftPolicyOverwrite DriftPolicy = "overwrite" // Restore the values Enclii manages
+	DriftPolicyPreserve  DriftPolicy = "preserve"  // Keep the manual values
+)
+
+// DriftEvent records the fields of a managed Kubernetes resource that one
+// field manager changed outside Enclii, found while a deployment was
+// reconciled, and what the reconcile did with them
+type DriftEvent struct {
+	ID           uuid.UUID   `json:"id" db:"id"`
+	DeploymentID uuid.UUID   `json:"deployment_id" db:"deployment_id"`
+	Kind         string      `json:"kind" db:"kind"`
+	Namespace    string      `json:"namespace" db:"namespace"`
+	Name         string      `json:"name" db:"name"`
+	Manager      string      `json:"manager" db:"manager"` // e.g. kubectl-edit
+	Fields       []string    `json:"fields" db:"fields"`   // e.g. .spec.replicas
+	Action       DriftPolicy `json:"action" db:"action"`
+	DetectedAt   time.Time   `json:"detected_at" db:"detected_at"`
+}
+
+// LogEntry is one aggregated log line of a service
+type LogEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Pod       string    `json:"pod,omitempty"`
+	Container string    `json:"container,omitempty"`
+	Stream    string    `json:"stream,omitempty"` // stdout or stderr
+	Line      string    `json:"line"`
+}
+
+// LogSearchResult is the answer to a log search over a time range
+type LogSearchResult struct {
+	ServiceID   uuid.UUID  `json:"service_id"`
+	ServiceName string     `json:"service_name"`
+	Environment string     `json:"environment"`
+	Source      string     `json:"source"`          // loki, or kubernetes for recent pod logs
+	Query       string     `json:"query,omitempty"` // LogQL that ran
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	Entries     []LogEntry `json:"entries"`
+}
+
+// MetricPoint is one sample of a metric time series
+type MetricPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// MetricSeries is a time series of one service metric
+type MetricSeries struct {
+	Name   string        `json:"name"` // cpu, memory, restarts, request_rate or error_rate
+	Unit   string        `json:"unit"` // millicores, bytes, count, requests_per_second or ratio
+	Points []MetricPoint `json:"points"`
+}
+
+// ServiceMetricsReport holds the metrics of a service over a window. Series
+// the cluster does not expose are left out.
+type ServiceMetricsReport struct {
+	ServiceID   uuid.UUID      `json:"service_id"`
+	ServiceName string         `json:"service_name"`
+	Environment string         `json:"environment"`
+	Namespace   string         `json:"namespace"`
+	Source      string         `json:"source"` // prometheus, or metrics-server for current usage only
+	Window      string         `json:"window"`
+	Step        string         `json:"step,omitempty"`
+	Start       time.Time      `json:"start"`
+	End         time.Time      `json:"end"`
+	Series      []MetricSeries `json:"series"`
+}
+
+// AlertRuleKind is the condition an alert rule watches a service for
+type AlertRuleKind string
+
+const (
+	AlertRuleCrashLoop        AlertRuleKind = "crash_loop"        // A container is in CrashLoopBackOff
+	AlertRuleRestartCount     AlertRuleKind = "restart_count"     // Containers of current pods restarted more than the threshold
+	AlertRuleOOMKilled        AlertRuleKind = "oom_killed"        // A container was recently killed for running out of memory
+	AlertRuleReadinessFailing AlertRuleKind = "readiness_failing" // A running pod is not ready
+	AlertRuleHPAAtMax         AlertRuleKind = "hpa_at_max"        // The autoscaler runs the most replicas it may
+	AlertRuleErrorRate        AlertRuleKind = "error_rate"        // The share of failed requests exceeds the threshold
+)
+
+// AlertRule raises an alert when a service meets a condition for long
+// enough. Alerts go to the project's webhooks and to NotifyEmails.
+type AlertRule struct {
+	ID            uuid.UUID     `json:"id" db:"id"`
+	ServiceID     uuid.UUID     `json:"service_id" db:"service_id"`
+	EnvironmentID *uuid.UUID    `json:"environment_id,omitempty" db:"environment_id"` // Every environment when nil
+	Name          string        `json:"name" db:"name"`
+	Kind          AlertRuleKind `json:"kind" db:"kind"`
+	Threshold     float64       `json:"threshold" db:"threshold"`     // Restarts for restart_count, a ratio for error_rate
+	ForMinutes    int           `json:"for_minutes" db:"for_minutes"` // How long the condition holds before the alert fires
+	NotifyEmails  []string      `json:"notify_emails" db:"notify_emails"`
+	Enabled       bool          `json:"enabled" db:"enabled"`
+	CreatedBy     string        `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// AlertStatus is where an alert is in its lifecycle
+type AlertStatus string
+
+const (
+	AlertStatusPending  AlertStatus = "pending"  // Condition met, waiting out the rule's for_minutes
+	AlertStatusFiring   AlertStatus = "firing"   // Notified; stays firing until the condition clears
+	AlertStatusResolved AlertStatus = "resolved" // Condition cleared
+)
+
+// Alert is one occurrence of an alert rule's condition in an environment.
+// A rule has at most one open alert per environment.
+type Alert struct {
+	ID            uuid.UUID     `json:"id" db:"id"`
+	RuleID        uuid.UUID     `json:"rule_id" db:"rule_id"`
+	RuleName      string        `json:"rule_name" db:"rule_name"`
+	ServiceID     uuid.UUID     `json:"service_id" db:"service_id"`
+	EnvironmentID uuid.UUID     `json:"environment_id" db:"environment_id"`
+	Environment   string        `json:"environment" db:"environment"`
+	Kind          AlertRuleKind `json:"kind" db:"kind"`
+	Status        AlertStatus   `json:"status" db:"status"`
+	Summary       string        `json:"summary" db:"summary"`
+	Value         *float64      `json:"value,omitempty" db:"value"`
+	StartedAt     time.Time     `json:"started_at" db:"started_at"`
+	FiredAt       *time.Time    `json:"fired_at,omitempty" db:"fired_at"`
+	LastSeenAt    time.Time     `json:"last_seen_at" db:"last_seen_at"`
+	ResolvedAt    *time.Time    `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// UptimeStatus is whether an uptime check's endpoint is reachable
+type UptimeStatus string
+
+const (
+	UptimeStatusUnknown UptimeStatus = "unknown" // Not checked yet
+	UptimeStatusUp      UptimeStatus = "up"
+	UptimeStatusDown    UptimeStatus = "down" // Failed FailureThreshold checks in a row
+)
+
+// UptimeCheck requests an HTTP endpoint of a service on an interval and
+// tracks its uptime. The endpoint is a custom domain of the service, or
+// the service inside the cluster when CustomDomainID is nil.
+type UptimeCheck struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	ServiceID        uuid.UUID  `json:"service_id" db:"service_id"`
+	EnvironmentID    uuid.UUID  `json:"environment_id" db:"environment_id"`
+	Environment      string     `json:"environment" db:"environment"`
+	CustomDomainID   *uuid.UUID `json:"custom_domain_id,omitempty" db:"custom_domain_id"`
+	Domain           string     `json:"domain,omitempty" db:"domain"`
+	Path             string     `json:"path" db:"path"`
+	ExpectedStatus   int        `json:"expected_status" db:"expected_status"`
+	IntervalSeconds  int        `json:"interval_seconds" db:"interval_seconds"`
+	TimeoutSeconds   int        `json:"timeout_seconds" db:"timeout_seconds"`
+	FailureThreshold int        `json:"failure_threshold" db:"failure_threshold"` // Failed checks in a row before the check is down
+	Enabled          bool       `json:"enabled" db:"enabled"`
+
+	// Latest state, kept up to date by the check runner
+	Status              UptimeStatus `json:"status" db:"status"`
+	StatusChangedAt     *time.Time   `json:"status_changed_at,omitempty" db:"status_changed_at"`
+	ConsecutiveFailures int          `json:"consecutive_failures" db:"consecutive_failures"`
+	LastCheckedAt       *time.Time   `json:"last_checked_at,omitempty" db:"last_checked_at"`
+	LastStatusCode      *int         `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastLatencyMs       *int         `json:"last_latency_ms,omitempty" db:"last_latency_ms"`
+	LastError           string       `json:"last_error,omitempty" db:"last_error"`
+
+	Uptime    *UptimePercentages `json:"uptime,omitempty"`
+	CreatedBy string             `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// UptimePercentages is the percentage of successful checks over recent
+// windows. A window without results is nil.
+type UptimePercentages struct {
+	Last24h *float64 `json:"last_24h"`
+	Last7d  *float64 `json:"last_7d"`
+	Last30d *float64 `json:"last_30d"`
+}
+
+// UptimeCheckResult is the outcome of one run of an uptime check
+type UptimeCheckResult struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	CheckID    uuid.UUID `json:"check_id" db:"check_id"`
+	CheckedAt  time.Time `json:"checked_at" db:"checked_at"`
+	Up         bool      `json:"up" db:"up"`
+	StatusCode *int      `json:"status_code,omitempty" db:"status_code"`
+	LatencyMs  int       `json:"latency_ms" db:"latency_ms"`
+	Error      string    `json:"error,omitempty" db:"error"`
+}
+
+// StatusPage is the opt-in public status page of a project. It shows the
+// services of one environment at /status/:slug, and at CustomDomain when
+// set.
+type StatusPage struct {
+	ProjectID     uuid.UUID `json:"project_id" db:"project_id"`
+	Enabled       bool      `json:"enabled" db:"enabled"`
+	Title         string    `json:"title" db:"title"`
+	Description   string    `json:"description,omitempty" db:"description"`
+	EnvironmentID uuid.UUID `json:"environment_id" db:"environment_id"`
+	Environment   string    `json:"environment" db:"environment"`
+	CustomDomain  string    `json:"custom_domain,omitempty" db:"custom_domain"`
+	// DomainVerifiedAt is set once the custom domain's TXT record is
+	// checked; the page is only served there after
+	DomainVerifiedAt *time.Time `json:"domain_verified_at,omitempty" db:"domain_verified_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PublicHealth is the health of a service as shown on a status page
+type PublicHealth string
+
+const (
+	PublicHealthOperational PublicHealth = "operational"
+	PublicHealthDegraded    PublicHealth = "degraded" // An alert is firing or the latest deployment failed
+	PublicHealthOutage      PublicHealth = "outage"   // An uptime check is down
+)
+
+// PublicStatus is what a status page shows. It holds no internal details
+// such as IDs, URLs or error messages.
+type PublicStatus struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description,omitempty"`
+	Status      PublicHealth          `json:"status"` // The worst status of the services
+	Services    []PublicServiceStatus `json:"services"`
+	Incidents   []StatusIncident      `json:"incidents"`
+	GeneratedAt time.Time             `json:"generated_at"`
+}
+
+// PublicServiceStatus is the health and uptime history of one service
+type PublicServiceStatus struct {
+	Name    string       `json:"name"`
+	Status  PublicHealth `json:"status"`
+	Uptime  *float64     `json:"uptime,omitempty"` // Percentage over the history; nil without uptime checks
+	History []UptimeDay  `json:"history"`          // One entry per day, oldest first
+}
+
+// UptimeDay is the uptime percentage of a service on one UTC day. Uptime
+// is nil for a day without results.
+type UptimeDay struct {
+	Date   string   `json:"date"` // YYYY-MM-DD
+	Uptime *float64 `json:"uptime"`
+}
+
+// StatusIncidentKind is what an incident on a status page was derived from
+type StatusIncidentKind string
+
+const (
+	StatusIncidentAlert         StatusIncidentKind = "alert"
+	StatusIncidentDeployFailure StatusIncidentKind = "deploy_failure"
+)
+
+// StatusIncident is a recent incident of a service on a status page
+type StatusIncident struct {
+	Service    string             `json:"service"`
+	Kind       StatusIncidentKind `json:"kind"`
+	Title      string             `json:"title"`
+	Ongoing    bool               `json:"ongoing"`
+	StartedAt  time.Time          `json:"started_at"`
+	ResolvedAt *time.Time         `json:"resolved_at,omitempty"`
+}
+
+// OneOffJobStatus represents the status of a one-off job
+type OneOffJobStatus string
+
+const (
+	OneOffJobStatusPending   OneOffJobStatus = "pending"
+	OneOffJobStatusRunning   OneOffJobStatus = "running"
+	OneOffJobStatusSucceeded OneOffJobStatus = "succeeded"
+	OneOffJobStatusFailed    OneOffJobStatus = "failed"
+)
+
+// OneOffJob is a command run once in a service's deployed image and
+// configuration, such as a migration or a maintenance script
+type OneOffJob struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	ServiceID      uuid.UUID       `json:"service_id" db:"service_id"`
+	EnvironmentID  uuid.UUID       `json:"environment_id" db:"environment_id"`
+	Command        []string        `json:"command" db:"command"`
+	Status         OneOffJobStatus `json:"status" db:"status"`
+	ExitCode       *int            `json:"exit_code,omitempty" db:"exit_code"`
+	Message        string          `json:"message,omitempty" db:"message"`
+	Image          string          `json:"image,omitempty" db:"image"`
+	K8sNamespace   string          `json:"k8s_namespace" db:"k8s_namespace"`
+	K8sJobName     string          `json:"k8s_job_name" db:"k8s_job_name"`
+	TimeoutSeconds int             `json:"timeout_seconds" db:"timeout_seconds"`
+	CreatedBy      string          `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	StartedAt      *time.Time      `json:"started_at,omitempty" db:"started_at"`
+	FinishedAt     *time.Time      `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// RetentionDataClass is a kind of project data that is purged after a
+// retention period
+type RetentionDataClass string
+
+const (
+	RetentionBuildLogs   RetentionDataClass = "build_logs"   // Build history and its logs
+	RetentionAccessLogs  RetentionDataClass = "access_logs"  // Preview environment access logs
+	RetentionAuditLogs   RetentionDataClass = "audit_logs"   // Project audit log entries
+	RetentionUsageDetail RetentionDataClass = "usage_detail" // Raw and hourly usage; daily totals are kept for billing
+)
+
+// RetentionPolicy is how many days a project keeps each class of data, and
+// how many releases of each service. Nil fields use the default of the
+// project's plan.
+type RetentionPolicy struct {
+	ProjectID       uuid.UUID `json:"project_id" db:"project_id"`
+	BuildLogDays    *int      `json:"build_log_days,omitempty" db:"build_log_days"`
+	AccessLogDays   *int      `json:"access_log_days,omitempty" db:"access_log_days"`
+	AuditLogDays    *int      `json:"audit_log_days,omitempty" db:"audit_log_days"`
+	UsageDetailDays *int      `json:"usage_detail_days,omitempty" db:"usage_detail_days"`
+	KeepReleases    *int      `json:"keep_releases,omitempty" db:"keep_releases"` // Releases kept per service besides those of active deployments
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RetentionBounds are the retention periods a plan allows for a class of data
+type RetentionBounds struct {
+	MinDays     int `json:"min_days"`
+	MaxDays     int `json:"max_days"`
+	DefaultDays int `json:"default_days"`
+}
+
+// ProjectRetention is the configured and effective retention of a project
+type ProjectRetention struct {
+	ProjectID uuid.UUID                              `json:"project_id"`
+	Plan      string                                 `json:"plan"`
+	Policy    RetentionPolicy                        `json:"policy"`
+	Effective map[RetentionDataClass]int             `json:"effective_days"` // What purges use, after plan defaults and bounds
+	Bounds    map[RetentionDataClass]RetentionBounds `json:"bounds"`
+
+	// EffectiveKeepReleases is how many releases of each service pruning keeps
+	EffectiveKeepReleases int `json:"effective_keep_releases"`
+}
+
+// RetentionPurgeVolume is how many records of a class purges will delete
+type RetentionPurgeVolume struct {
+	DataClass     RetentionDataClass `json:"data_class"`
+	RetentionDays int                `json:"retention_days"`
+	Cutoff        time.Time          `json:"cutoff"`        // Records older than this are purged on the next run
+	NextRun       int64              `json:"next_run"`      // Records the next run deletes
+	WithinWindow  int64              `json:"within_window"` // Records deleted by the end of the preview window, including NextRun
+}
+
+// RetentionPurgePreview shows the purge volumes of a project before they happen
+type RetentionPurgePreview struct {
+	ProjectID   uuid.UUID              `json:"project_id"`
+	WindowDays  int                    `json:"window_days"`
+	Volumes     []RetentionPurgeVolume `json:"volumes"`
+	GeneratedAt time.Time              `json:"generated_at"`
+
+	// PrunableReleases is how many releases the next run prunes
+	PrunableReleases int64 `json:"prunable_releases"`
+}
+
+// ProjectStatus is a snapshot of a project's services, recent builds, and
+// build queue for live status views
+type ProjectStatus struct {
+	ProjectID    uuid.UUID            `json:"project_id"`
+	ProjectSlug  string               `json:"project_slug"`
+	Services     []ServiceStatusEntry `json:"services"`
+	RecentBuilds []BuildStatusEntry   `json:"recent_builds"`
+	Queue        BuildQueueStats      `json:"queue"`
+	GeneratedAt  time.Time            `json:"generated_at"`
+}
+
+// ServiceStatusEntry is a service with its latest deployment in each
+// environment it was deployed to
+type ServiceStatusEntry struct {
+	ServiceID   uuid.UUID               `json:"service_id"`
+	Name        string                  `json:"name"`
+	Deployments []DeploymentStatusEntry `json:"deployments"`
+}
+
+// DeploymentStatusEntry is the latest deployment of a service in an environment
+type DeploymentStatusEntry struct {
+	ServiceID    uuid.UUID        `json:"service_id"`
+	Environment  string           `json:"environment"`
+	DeploymentID uuid.UUID        `json:"deployment_id"`
+	Version      string           `json:"version"`
+	Status       DeploymentStatus `json:"status"`
+	Health       HealthStatus     `json:"health"`
+	Replicas     int              `json:"replicas"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// BuildStatusEntry is a recent build of a project
+type BuildStatusEntry struct {
+	ReleaseID   uuid.UUID     `json:"release_id"`
+	ServiceName string        `json:"service_name"`
+	Version     string        `json:"version"`
+	GitSHA      string        `json:"git_sha"`
+	Status      ReleaseStatus `json:"status"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// BuildQueueStats is the pressure on the build queue, platform-wide and from
+// one project. Only builds dispatched to build workers are queued.
+type BuildQueueStats struct {
+	Queued          int        `json:"queued"`
+	Building        int        `json:"building"`
+	ProjectQueued   int        `json:"project_queued"`
+	ProjectBuilding int        `json:"project_building"`
+	OldestQueuedAt  *time.Time `json:"oldest_queued_at,omitempty"` // Oldest queued build of the project
+}
+
+// SearchResultType is the kind of resource a search result is
+type SearchResultType string
+
+const (
+	SearchResultProject    SearchResultType = "project"
+	SearchResultService    SearchResultType = "service"
+	SearchResultDeployment SearchResultType = "deployment"
+	SearchResultDomain     SearchResultType = "domain"
+)
+
+// SearchResult is a resource matching a search, with the field that matched
+// and how well. Higher scores rank first.
+type SearchResult struct {
+	Type         SearchResultType `json:"type"`
+	ID           uuid.UUID        `json:"id"`
+	Title        string           `json:"title"`
+	Subtitle     string           `json:"subtitle,omitempty"`
+	ProjectID    uuid.UUID        `json:"project_id"`
+	ProjectSlug  string           `json:"project_slug"`
+	ServiceID    *uuid.UUID       `json:"service_id,omitempty"`
+	Environment  string           `json:"environment,omitempty"`
+	MatchedField string           `json:"matched_field"` // name, slug, domain, git_repo, image_uri, version
+	Matched      string           `json:"matched"`       // Value of the matched field
+	Score        float64          `json:"score"`         // 0.0 to 1.0
+	Path         string           `json:"path"`          // API path of the resource
+}
+
+// SearchResponse is the ranked results of a search
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
+
+// DeploymentConfigSnapshot is an immutable record of the resolved configuration
+// a deployment ran with, captured once when the deployment is first reconciled.
+// Environment variable values are never stored; only a hash and a masked preview.
+type DeploymentConfigSnapshot struct {
+	DeploymentID  uuid.UUID `json:"deployment_id"`
+	ServiceID     uuid.UUID `json:"service_id"`
+	EnvironmentID uuid.UUID `json:"environment_id"`
+	ReleaseID     uuid.UUID `json:"release_id"`
+	ImageURI      string    `json:"image_uri"`
+	Namespace     string    `json:"namespace"`
+
+	EnvVars       []SnapshotEnvVar       `json:"env_vars"`
+	AddonBindings []SnapshotAddonBinding `json:"addon_bindings"`
+	Routes        []SnapshotRoute        `json:"routes"`
+	Domains       []string               `json:"domains"`
+
+	Replicas         int                `json:"replicas"`
+	Resources        *ResourceConfig    `json:"resources,omitempty"`
+	HealthCheck      *HealthCheckConfig `json:"health_check,omitempty"`
+	Scheduling       *SchedulingConfig  `json:"scheduling,omitempty"`
+	GPU              *GPUConfig         `json:"gpu,omitempty"`
+	Command          *ContainerCommand  `json:"command,omitempty"`
+	Shutdown         *ShutdownConfig    `json:"shutdown,omitempty"`
+	HighAvailability bool               `json:"high_availability,omitempty"`
+
+	// ManifestHash is the SHA-256 of the generated Kubernetes Deployment and Service specs
+	ManifestHash string    `json:"manifest_hash"`
+	CapturedAt   time.Time `json:"captured_at"`
+}
+
+// SnapshotEnvVar is an environment variable as recorded in a config snapshot.
+// ValueHash is stable per service, so equal hashes across deployments mean an unchanged value.
+type SnapshotEnvVar struct {
+	Key         string `json:"key"`
+	ValueHash   string `json:"value_hash"`
+	MaskedValue string `json:"masked_value"`
+	IsSecret    bool   `json:"is_secret"`
+}
+
+// SnapshotAddonBinding is a database addon binding as recorded in a config snapshot
+type SnapshotAddonBinding struct {
+	EnvVarName       string            `json:"env_var_name"`
+	AddonType        DatabaseAddonType `json:"addon_type"`
+	K8sResourceName  string            `json:"k8s_resource_name"`
+	ConnectionSecret string            `json:"connection_secret,omitempty"`
+}
+
+// SnapshotRoute is an ingress route as recorded in a config snapshot
+type SnapshotRoute struct {
+	Path     string `json:"path"`
+	PathType string `json:"path_type"`
+	Port     int    `json:"port"`
+}
+
 type DeploymentStatus string
 
 const (
 	DeploymentStatusPending DeploymentStatus = "pending"
 	DeploymentStatusRunning DeploymentStatus = "running"
 	DeploymentStatusFailed  DeploymentStatus = "failed"
+	// DeploymentStatusSuperseded is a deployment a newer deployment of the
+	// same service replaced before it rolled out
+	DeploymentStatusSuperseded DeploymentStatus = "superseded"
 )
 
+// DeploymentPhase is the step of a rollout a deployment has reached
+type DeploymentPhase string
+
+const (
+	DeploymentPhaseQueued         DeploymentPhase = "queued"          // Waiting for the reconciler
+	DeploymentPhaseImageVerifying DeploymentPhase = "image-verifying" // Resolving the image and its registry credentials
+	DeploymentPhaseApplying       DeploymentPhase = "applying"        // Applying manifests to the cluster
+	DeploymentPhaseWaitingRollout DeploymentPhase = "waiting-rollout" // Waiting for new pods to become ready
+	DeploymentPhaseHooksRunning   DeploymentPhase = "hooks-running"   // Annotations, soak and webhooks after the rollout
+	DeploymentPhaseDone           DeploymentPhase = "done"
+)
+
+// Percent estimates how far a deployment in the phase has come. While
+// waiting for the rollout it moves with the replicas that are ready.
+func (p DeploymentPhase) Percent(readyReplicas, desiredReplicas int) int {
+	switch p {
+	case DeploymentPhaseImageVerifying:
+		return 10
+	case DeploymentPhaseApplying:
+		return 25
+	case DeploymentPhaseWaitingRollout:
+		if desiredReplicas <= 0 {
+			return 40
+		}
+		ready := min(max(readyReplicas, 0), desiredReplicas)
+		return 40 + 50*ready/desiredReplicas
+	case DeploymentPhaseHooksRunning:
+		return 95
+	case DeploymentPhaseDone:
+		return 100
+	default:
+		return 0
+	}
+}
+
+// DeploymentPhaseRecord is when a deployment entered and left a phase. A
+// phase entered again on a retry keeps its latest times.
+type DeploymentPhaseRecord struct {
+	Phase      DeploymentPhase `json:"phase" db:"phase"`
+	StartedAt  time.Time       `json:"started_at" db:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// DeploymentProgress is the phase a deployment is in and how it got there
+type DeploymentProgress struct {
+	Phase           DeploymentPhase          `json:"phase"`
+	Percent         int                      `json:"percent"`
+	ReadyReplicas   int                      `json:"ready_replicas,omitempty"`
+	DesiredReplicas int                      `json:"desired_replicas,omitempty"`
+	Phases          []*DeploymentPhaseRecord `json:"phases"`
+}
+
 type HealthStatus string
 
 const (
@@ -180,6 +1212,7 @@ type ServiceSpecConfig struct {
 	Runtime RuntimeSpec `yaml:"runtime" json:"runtime"`
 	Env     []EnvVar    `yaml:"env,omitempty" json:"env,omitempty"`
 	Volumes []Volume    `yaml:"volumes,omitempty" json:"volumes,omitempty"`
+	Verify  *VerifySpec `yaml:"verify,omitempty" json:"verify,omitempty"`
 }
 
 type BuildSpec struct {
@@ -198,6 +1231,25 @@ type EnvVar struct {
 	Value string `yaml:"value" json:"value"`
 }
 
+// VerifySpec configures the checks `enclii deploy --verify` runs once a
+// deployment is healthy. Without checks, the runtime health check path is
+// requested.
+type VerifySpec struct {
+	BaseURL string        `yaml:"baseURL,omitempty" json:"base_url,omitempty"` // Defaults to the service's enclii.dev URL
+	Checks  []VerifyCheck `yaml:"checks,omitempty" json:"checks,omitempty"`
+	Soak    bool          `yaml:"soak,omitempty" json:"soak,omitempty"` // Also wait for the environment's soak to pass
+}
+
+// VerifyCheck is an HTTP request a verified deployment must answer as expected
+type VerifyCheck struct {
+	Name           string `yaml:"name" json:"name"`
+	Path           string `yaml:"path" json:"path"`
+	ExpectStatus   int    `yaml:"expectStatus,omitempty" json:"expect_status,omitempty"`     // Defaults to 200
+	ExpectBody     string `yaml:"expectBody,omitempty" json:"expect_body,omitempty"`         // Substring the body must contain
+	TimeoutSeconds int    `yaml:"timeoutSeconds,omitempty" json:"timeout_seconds,omitempty"` // Per attempt, defaults to 10
+	Retries        int    `yaml:"retries,omitempty" json:"retries,omitempty"`                // Attempts after the first, defaults to 0
+}
+
 // Volume represents a persistent volume configuration for a service
 type Volume struct {
 	Name             string `yaml:"name" json:"name"`
@@ -207,6 +1259,121 @@ type Volume struct {
 	AccessMode       string `yaml:"accessMode,omitempty" json:"access_mode,omitempty"`              // defaults to "ReadWriteOnce"
 }
 
+// ProjectSpec is the declarative description of a project kept in
+// enclii.yaml. It is exported from a project and applied back to it.
+type ProjectSpec struct {
+	APIVersion string              `yaml:"apiVersion" json:"api_version"`
+	Kind       string              `yaml:"kind" json:"kind"` // "Project"
+	Metadata   ProjectSpecMetadata `yaml:"metadata" json:"metadata"`
+	Spec       ProjectSpecConfig   `yaml:"spec" json:"spec"`
+}
+
+type ProjectSpecMetadata struct {
+	Name string `yaml:"name" json:"name"` // Project slug
+}
+
+type ProjectSpecConfig struct {
+	Services []ProjectServiceSpec `yaml:"services" json:"services"`
+	Addons   []ProjectAddonSpec   `yaml:"addons,omitempty" json:"addons,omitempty"`
+}
+
+// ProjectServiceSpec describes a service with its variables, domains and
+// the services it depends on
+type ProjectServiceSpec struct {
+	Name             string                  `yaml:"name" json:"name"`
+	GitRepo          string                  `yaml:"gitRepo" json:"git_repo"`
+	AppPath          string                  `yaml:"appPath,omitempty" json:"app_path,omitempty"`
+	Build            BuildConfig             `yaml:"build" json:"build"`
+	AutoDeploy       *ProjectAutoDeploySpec  `yaml:"autoDeploy,omitempty" json:"auto_deploy,omitempty"` // Omitted leaves the setting as is
+	Labels           map[string]string       `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Scheduling       *SchedulingConfig       `yaml:"scheduling,omitempty" json:"scheduling,omitempty"`
+	GPU              *GPUConfig              `yaml:"gpu,omitempty" json:"gpu,omitempty"`
+	Command          *ContainerCommand       `yaml:"command,omitempty" json:"command,omitempty"`
+	Shutdown         *ShutdownConfig         `yaml:"shutdown,omitempty" json:"shutdown,omitempty"`
+	HighAvailability *bool                   `yaml:"highAvailability,omitempty" json:"high_availability,omitempty"` // Omitted leaves the setting as is
+	DependsOn        []ProjectDependencySpec `yaml:"dependsOn,omitempty" json:"depends_on,omitempty"`
+	Env              []ProjectEnvVarSpec     `yaml:"env,omitempty" json:"env,omitempty"`
+	Domains          []ProjectDomainSpec     `yaml:"domains,omitempty" json:"domains,omitempty"`
+}
+
+type ProjectAutoDeploySpec struct {
+	Enabled     bool   `yaml:"enabled" json:"enabled"`
+	Branch      string `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Environment string `yaml:"environment,omitempty" json:"environment,omitempty"`
+}
+
+type ProjectDependencySpec struct {
+	Service string `yaml:"service" json:"service"`
+	Type    string `yaml:"type,omitempty" json:"type,omitempty"` // runtime (default), build or data
+}
+
+// ProjectEnvVarSpec is an environment variable of a service. Secrets are
+// exported without their value; a secret without a value keeps the one set.
+type ProjectEnvVarSpec struct {
+	Key         string `yaml:"key" json:"key"`
+	Value       string `yaml:"value,omitempty" json:"value,omitempty"`
+	Secret      bool   `yaml:"secret,omitempty" json:"secret,omitempty"`
+	Environment string `yaml:"environment,omitempty" json:"environment,omitempty"` // Empty = all environments
+}
+
+type ProjectDomainSpec struct {
+	Domain      string `yaml:"domain" json:"domain"`
+	Environment string `yaml:"environment" json:"environment"`
+	TLSEnabled  bool   `yaml:"tls,omitempty" json:"tls_enabled,omitempty"`
+	TLSIssuer   string `yaml:"tlsIssuer,omitempty" json:"tls_issuer,omitempty"`
+}
+
+// ProjectAddonSpec describes an addon and the services bound to it
+type ProjectAddonSpec struct {
+	Name        string                    `yaml:"name" json:"name"`
+	Type        DatabaseAddonType         `yaml:"type" json:"type"`
+	Environment string                    `yaml:"environment,omitempty" json:"environment,omitempty"` // Empty = all environments
+	Config      DatabaseAddonConfig       `yaml:"config,omitempty" json:"config,omitempty"`
+	Bindings    []ProjectAddonBindingSpec `yaml:"bindings,omitempty" json:"bindings,omitempty"`
+}
+
+type ProjectAddonBindingSpec struct {
+	Service string `yaml:"service" json:"service"`
+	EnvVar  string `yaml:"envVar" json:"env_var"`
+}
+
+// ProjectSpecChange is one change applying a project spec makes, or would
+// make in a dry run
+type ProjectSpecChange struct {
+	Action      string   `json:"action"` // create, update or delete
+	Kind        string   `json:"kind"`   // service, env_var, domain, dependency, addon or addon_binding
+	Service     string   `json:"service,omitempty"`
+	Name        string   `json:"name"`
+	Environment string   `json:"environment,omitempty"`
+	Fields      []string `json:"fields,omitempty"` // Changed fields of updates
+}
+
+// ProjectSpecApplyResult reports the changes of applying a project spec
+type ProjectSpecApplyResult struct {
+	DryRun   bool                `json:"dry_run"`
+	Changes  []ProjectSpecChange `json:"changes"`
+	Warnings []string            `json:"warnings,omitempty"`
+}
+
+// ResourceState is a resource as seen by infrastructure-as-code tools, with
+// the ETag to send in If-Match when changing it
+type ResourceState struct {
+	ID         uuid.UUID   `json:"id"`
+	ETag       string      `json:"etag"`
+	Attributes interface{} `json:"attributes"` // The resource itself
+}
+
+// ProjectResourceState is the state of every resource of a project, from
+// which a Terraform/OpenTofu provider imports it. Secret values are masked.
+type ProjectResourceState struct {
+	Project      ResourceState   `json:"project"`
+	Environments []*Environment  `json:"environments"`
+	Services     []ResourceState `json:"services"`
+	EnvVars      []ResourceState `json:"env_vars"`
+	Domains      []ResourceState `json:"domains"`
+	Addons       []ResourceState `json:"addons"`
+}
+
 // Role represents a user's role in the system
 type Role string
 
@@ -214,7 +1381,8 @@ const (
 	RoleAdmin     Role = "admin"
 	RoleDeveloper Role = "developer"
 	RoleViewer    Role = "viewer"
-	RoleSystem    Role = "system" // For automated system actions (webhooks, auto-deploy)
+	RoleOperator  Role = "operator" // Platform operator: reads everything, suspends teams, impersonates users for support
+	RoleSystem    Role = "system"   // For automated system actions (webhooks, auto-deploy)
 )
 
 // User represents a user account in the system
@@ -230,6 +1398,8 @@ type User struct {
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"` // Nil until the user follows the verification link
 }
 
 // Team represents a group of users
@@ -241,6 +1411,17 @@ type Team struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// TwoFactorPolicy decides which operations in a team's projects need a
+// recent second factor. Any policy other than off also requires members to
+// be enrolled to create API tokens.
+type TwoFactorPolicy string
+
+const (
+	TwoFactorPolicyOff        TwoFactorPolicy = "off"
+	TwoFactorPolicyProduction TwoFactorPolicy = "production" // Production deploys
+	TwoFactorPolicyAll        TwoFactorPolicy = "all"        // Production deploys and secret reads
+)
+
 // ProjectAccess represents a user's access to a project with environment-specific permissions
 type ProjectAccess struct {
 	ID            uuid.UUID  `json:"id" db:"id"`
@@ -307,6 +1488,12 @@ type CustomDomain struct {
 	TLSProvider        string     `json:"tls_provider" db:"tls_provider"` // "cert-manager", "cloudflare-for-saas"
 	Status             string     `json:"status" db:"status"`             // "pending", "verifying", "active", "error"
 	DNSCNAME           string     `json:"dns_cname,omitempty" db:"dns_cname"`
+	DNSMode            string     `json:"dns_mode" db:"dns_mode"` // "manual", "managed"
+	DNSProviderID      *uuid.UUID `json:"dns_provider_id,omitempty" db:"dns_provider_id"`
+	DNSRecordID        string     `json:"-" db:"dns_record_id"`                                 // Record ID at the DNS provider
+	DNSStatus          string     `json:"dns_status,omitempty" db:"dns_status"`                 // "in_sync", "drifted", "error"
+	DNSStatusMessage   string     `json:"dns_status_message,omitempty" db:"dns_status_message"` // What drifted or failed
+	DNSCheckedAt       *time.Time `json:"dns_checked_at,omitempty" db:"dns_checked_at"`
 }
 
 // Route represents an HTTP route configuration for a service
@@ -368,6 +1555,19 @@ const (
 	TLSProviderCloudflareForSaaS = "cloudflare-for-saas"
 )
 
+// DNSMode constants
+const (
+	DNSModeManual  = "manual"  // The user creates the CNAME at their DNS provider
+	DNSModeManaged = "managed" // Enclii creates the CNAME through the team's DNS provider
+)
+
+// DNSStatus constants for managed records
+const (
+	DNSStatusInSync  = "in_sync"
+	DNSStatusDrifted = "drifted"
+	DNSStatusError   = "error"
+)
+
 // ServiceNetworking represents the combined networking info for a service
 type ServiceNetworking struct {
 	ServiceID      uuid.UUID         `json:"service_id"`
@@ -391,6 +1591,8 @@ type DomainInfo struct {
 	DNSVerifiedAt    *time.Time `json:"dns_verified_at,omitempty"`
 	VerificationTXT  string     `json:"verification_txt,omitempty"`
 	DNSCNAME         string     `json:"dns_cname,omitempty"`
+	DNSMode          string     `json:"dns_mode,omitempty"`   // "manual", "managed"
+	DNSStatus        string     `json:"dns_status,omitempty"` // Drift check result for managed records
 	CreatedAt        time.Time  `json:"created_at"`
 }
 
@@ -439,13 +1641,47 @@ type EnvironmentVariableResponse struct {
 	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
+// EnvVarRotation is the rotation setup of a rotatable secret env var and
+// the rotation in progress, if any. While a rotation is in progress the
+// variable keeps its value and deployments also get the old and the staged
+// value as KEY_CURRENT and KEY_NEXT.
+type EnvVarRotation struct {
+	EnvVarID       uuid.UUID  `json:"env_var_id"`
+	ServiceID      uuid.UUID  `json:"service_id"`
+	EnvironmentID  *uuid.UUID `json:"environment_id,omitempty"` // NULL = all environments
+	Key            string     `json:"key"`
+	MaxAgeDays     *int       `json:"max_age_days,omitempty"` // Reminders are sent once the value is older
+	RotatedAt      *time.Time `json:"rotated_at,omitempty"`   // Last finalized rotation
+	ValueSince     time.Time  `json:"value_since"`            // When the current value was set
+	InProgress     bool       `json:"in_progress"`
+	PendingValue   string     `json:"-"` // Staged value, decrypted (never exposed)
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	StartedByEmail string     `json:"started_by_email,omitempty"`
+	RemindedAt     *time.Time `json:"reminded_at,omitempty"`
+}
+
+// PreviewEnvVar overrides a service environment variable in its preview
+// environments only, e.g. to point previews at sandbox APIs
+type PreviewEnvVar struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	ServiceID      uuid.UUID  `json:"service_id" db:"service_id"`
+	Key            string     `json:"key" db:"key"`
+	Value          string     `json:"value" db:"-"`             // Decrypted value, masked in API responses if is_secret
+	ValueEncrypted string     `json:"-" db:"value_encrypted"`   // Encrypted value (stored in DB)
+	IsSecret       bool       `json:"is_secret" db:"is_secret"` // If true, stored in a K8s Secret like other secrets
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedByEmail string     `json:"created_by_email,omitempty" db:"created_by_email"`
+}
+
 // EnvVarAuditLog represents an audit entry for env var changes
 type EnvVarAuditLog struct {
 	ID            uuid.UUID  `json:"id" db:"id"`
 	EnvVarID      uuid.UUID  `json:"env_var_id" db:"env_var_id"`
 	ServiceID     uuid.UUID  `json:"service_id" db:"service_id"`
 	EnvironmentID *uuid.UUID `json:"environment_id,omitempty" db:"environment_id"`
-	Action        string     `json:"action" db:"action"` // created, updated, deleted, revealed
+	Action        string     `json:"action" db:"action"` // created, updated, deleted, revealed, exported, rotation_started, rotation_finalized, rotation_aborted
 	Key           string     `json:"key" db:"key"`
 	OldValueHash  string     `json:"old_value_hash,omitempty" db:"old_value_hash"`
 	NewValueHash  string     `json:"new_value_hash,omitempty" db:"new_value_hash"`
@@ -503,9 +1739,83 @@ type PreviewEnvironment struct {
 	BuildLogsURL string     `json:"build_logs_url,omitempty" db:"build_logs_url"`
 
 	// Timestamps
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
-	ClosedAt  *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	CleanedUpAt *time.Time `json:"cleaned_up_at,omitempty" db:"cleaned_up_at"` // Set once the closed preview is torn down
+}
+
+// PreviewDatabaseMode selects how a preview database gets its data
+type PreviewDatabaseMode string
+
+const (
+	PreviewDatabaseModeClone PreviewDatabaseMode = "clone" // Restore a snapshot of the template addon
+	PreviewDatabaseModeSeed  PreviewDatabaseMode = "seed"  // Start empty and run a seed command
+)
+
+// PreviewDatabaseConfig gives every preview of a service its own database,
+// shaped after one of the project's addons
+type PreviewDatabaseConfig struct {
+	ServiceID       uuid.UUID           `json:"service_id" db:"service_id"`
+	TemplateAddonID uuid.UUID           `json:"template_addon_id" db:"template_addon_id"`
+	Mode            PreviewDatabaseMode `json:"mode" db:"mode"`
+	BackupID        *uuid.UUID          `json:"backup_id,omitempty" db:"backup_id"`       // Clone: backup to restore, nil for the latest completed one
+	SeedCommand     string              `json:"seed_command,omitempty" db:"seed_command"` // Seed: run in the preview image with the database bound
+	EnvVarName      string              `json:"env_var_name" db:"env_var_name"`
+	CreatedAt       time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// PreviewDatabaseStatus represents the status of a preview database
+type PreviewDatabaseStatus string
+
+const (
+	PreviewDatabaseStatusProvisioning PreviewDatabaseStatus = "provisioning"
+	PreviewDatabaseStatusRestoring    PreviewDatabaseStatus = "restoring"
+	PreviewDatabaseStatusSeeding      PreviewDatabaseStatus = "seeding"
+	PreviewDatabaseStatusReady        PreviewDatabaseStatus = "ready"
+	PreviewDatabaseStatusFailed       PreviewDatabaseStatus = "failed"
+)
+
+// PreviewDatabase is the ephemeral database of one preview environment
+type PreviewDatabase struct {
+	ID            uuid.UUID             `json:"id" db:"id"`
+	PreviewID     uuid.UUID             `json:"preview_id" db:"preview_id"`
+	AddonID       uuid.UUID             `json:"addon_id" db:"addon_id"`
+	Mode          PreviewDatabaseMode   `json:"mode" db:"mode"`
+	EnvVarName    string                `json:"env_var_name" db:"env_var_name"`
+	Status        PreviewDatabaseStatus `json:"status" db:"status"`
+	StatusMessage string                `json:"status_message,omitempty" db:"status_message"`
+	RestoreID     *uuid.UUID            `json:"restore_id,omitempty" db:"restore_id"` // Restore that loads the snapshot
+	CreatedAt     time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at" db:"updated_at"`
+	ReadyAt       *time.Time            `json:"ready_at,omitempty" db:"ready_at"`
+}
+
+// EnvironmentAddonCopyStatus represents the status of an addon copied into a cloned environment
+type EnvironmentAddonCopyStatus string
+
+const (
+	EnvironmentAddonCopyStatusProvisioning EnvironmentAddonCopyStatus = "provisioning"
+	EnvironmentAddonCopyStatusRestoring    EnvironmentAddonCopyStatus = "restoring"
+	EnvironmentAddonCopyStatusReady        EnvironmentAddonCopyStatus = "ready"
+	EnvironmentAddonCopyStatusFailed       EnvironmentAddonCopyStatus = "failed"
+)
+
+// EnvironmentAddonCopy is an addon created for a cloned environment from an
+// addon of its source. Once ready it is bound to the services the source was.
+type EnvironmentAddonCopy struct {
+	ID            uuid.UUID                  `json:"id" db:"id"`
+	EnvironmentID uuid.UUID                  `json:"environment_id" db:"environment_id"`
+	SourceAddonID *uuid.UUID                 `json:"source_addon_id,omitempty" db:"source_addon_id"` // nil once the source is deleted
+	AddonID       uuid.UUID                  `json:"addon_id" db:"addon_id"`
+	CopyData      bool                       `json:"copy_data" db:"copy_data"`
+	Status        EnvironmentAddonCopyStatus `json:"status" db:"status"`
+	StatusMessage string                     `json:"status_message,omitempty" db:"status_message"`
+	RestoreID     *uuid.UUID                 `json:"restore_id,omitempty" db:"restore_id"` // Restore that loads the source's backup
+	CreatedAt     time.Time                  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at" db:"updated_at"`
+	ReadyAt       *time.Time                 `json:"ready_at,omitempty" db:"ready_at"`
 }
 
 // PreviewCommentStatus represents the status of a preview comment
@@ -579,6 +1889,8 @@ type APIToken struct {
 	LastUsedIP string     `json:"last_used_ip,omitempty" db:"last_used_ip"`
 	Revoked    bool       `json:"revoked" db:"revoked"`
 	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	BotID      *uuid.UUID `json:"bot_id,omitempty" db:"bot_id"` // Set when the token acts as a bot
+	RateLimit  *int       `json:"rate_limit_per_minute,omitempty" db:"rate_limit_per_minute"`
 	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
@@ -593,9 +1905,46 @@ type APITokenCreateResponse struct {
 	ExpireAt *string   `json:"expire_at"` // ISO8601 expiration (if set)
 }
 
+// SignupInvite is an invite code platform admins hand out when signup is
+// invite-only. The code itself is only returned when the invite is created.
+type SignupInvite struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Code      string     `json:"code,omitempty"`             // Full code (only shown once!)
+	Prefix    string     `json:"prefix" db:"prefix"`         // Display prefix
+	Email     string     `json:"email,omitempty" db:"email"` // Only this address may use the code
+	Note      string     `json:"note,omitempty" db:"note"`   // Who or what the invite is for
+	MaxUses   int        `json:"max_uses" db:"max_uses"`     // Accounts the code may create
+	Uses      int        `json:"uses" db:"uses"`             // Accounts created with the code
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Bot is a non-human identity for CI pipelines. Its API tokens act with the
+// bot's role and only reach the projects and environments granted to it.
+type Bot struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	Name           string     `json:"name" db:"name"`
+	Description    string     `json:"description,omitempty" db:"description"`
+	Role           Role       `json:"role" db:"role"` // developer or viewer
+	Disabled       bool       `json:"disabled" db:"disabled"`
+	Grants         []BotGrant `json:"grants"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedByEmail string     `json:"created_by_email,omitempty" db:"created_by_email"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// BotGrant lets a bot act on a project, or on one environment of it
+type BotGrant struct {
+	ProjectID     uuid.UUID  `json:"project_id" db:"project_id"`
+	EnvironmentID *uuid.UUID `json:"environment_id,omitempty" db:"environment_id"` // nil = every environment of the project
+}
+
 // ============================================================================
 // DATABASE ADDON TYPES
-// One-click database provisioning for PostgreSQL, Redis, MySQL
+// One-click database provisioning for PostgreSQL, Redis, MySQL, MongoDB
 // Matches Railway's core value proposition
 // ============================================================================
 
@@ -606,6 +1955,8 @@ const (
 	DatabaseAddonTypePostgres DatabaseAddonType = "postgres"
 	DatabaseAddonTypeRedis    DatabaseAddonType = "redis"
 	DatabaseAddonTypeMySQL    DatabaseAddonType = "mysql"
+	DatabaseAddonTypeMongoDB  DatabaseAddonType = "mongodb"
+	DatabaseAddonTypeBucket   DatabaseAddonType = "bucket" // S3-compatible object storage
 )
 
 // DatabaseAddonStatus represents the provisioning status of a database addon
@@ -615,6 +1966,7 @@ const (
 	DatabaseAddonStatusPending      DatabaseAddonStatus = "pending"
 	DatabaseAddonStatusProvisioning DatabaseAddonStatus = "provisioning"
 	DatabaseAddonStatusReady        DatabaseAddonStatus = "ready"
+	DatabaseAddonStatusResizing     DatabaseAddonStatus = "resizing"
 	DatabaseAddonStatusFailed       DatabaseAddonStatus = "failed"
 	DatabaseAddonStatusDeleting     DatabaseAddonStatus = "deleting"
 	DatabaseAddonStatusDeleted      DatabaseAddonStatus = "deleted"
@@ -622,12 +1974,26 @@ const (
 
 // DatabaseAddonConfig represents the configuration for a database addon
 type DatabaseAddonConfig struct {
-	Version   string `json:"version,omitempty"`    // e.g., "16" for PostgreSQL 16
-	StorageGB int    `json:"storage_gb,omitempty"` // Storage size in GB
-	CPU       string `json:"cpu,omitempty"`        // CPU request/limit (e.g., "100m", "500m")
-	Memory    string `json:"memory,omitempty"`     // Memory request/limit (e.g., "256Mi", "1Gi")
-	HAEnabled bool   `json:"ha_enabled,omitempty"` // High availability mode
-	Replicas  int    `json:"replicas,omitempty"`   // Number of replicas (for HA)
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"`      // e.g., "16" for PostgreSQL 16
+	StorageGB int    `json:"storage_gb,omitempty" yaml:"storageGB,omitempty"` // Storage size in GB
+	CPU       string `json:"cpu,omitempty" yaml:"cpu,omitempty"`              // CPU request/limit (e.g., "100m", "500m")
+	Memory    string `json:"memory,omitempty" yaml:"memory,omitempty"`        // Memory request/limit (e.g., "256Mi", "1Gi")
+	HAEnabled bool   `json:"ha_enabled,omitempty" yaml:"haEnabled,omitempty"` // High availability mode
+	Replicas  int    `json:"replicas,omitempty" yaml:"replicas,omitempty"`    // Number of replicas (for HA)
+
+	// Redis-specific settings
+	MaxMemory       string `json:"maxmemory,omitempty" yaml:"maxMemory,omitempty"`              // Redis maxmemory (e.g., "200mb"); defaults to ~80% of Memory
+	MaxMemoryPolicy string `json:"maxmemory_policy,omitempty" yaml:"maxMemoryPolicy,omitempty"` // Eviction policy (e.g., "allkeys-lru", "noeviction")
+
+	// Backup policy (dump-based engines: MySQL, MongoDB)
+	BackupSchedule      string `json:"backup_schedule,omitempty" yaml:"backupSchedule,omitempty"`            // Cron expression (e.g., "0 3 * * *"); empty disables scheduled backups
+	BackupRetentionDays int    `json:"backup_retention_days,omitempty" yaml:"backupRetentionDays,omitempty"` // Days to keep completed backups
+
+	// Point-in-time recovery (PostgreSQL): continuously archives WAL to backup storage
+	PITREnabled bool `json:"pitr_enabled,omitempty" yaml:"pitrEnabled,omitempty"`
+
+	// Bucket-specific settings
+	BucketProvider string `json:"bucket_provider,omitempty" yaml:"bucketProvider,omitempty"` // "minio" (in-cluster) or "s3" (platform cloud account)
 }
 
 // DatabaseAddon represents a provisioned database instance
@@ -654,9 +2020,14 @@ type DatabaseAddon struct {
 
 	// Resource tracking
 	StorageUsedBytes  int64      `json:"storage_used_bytes" db:"storage_used_bytes"`
-	ConnectionsActive int        `json:"connections_active" db:"connections_active"`
+	MemoryUsedBytes   int64      `json:"memory_used_bytes" db:"memory_used_bytes"`
+	ConnectionsActive int        `json:"connections_active" db:"connections_active"` // Connected clients for Redis
 	LastBackupAt      *time.Time `json:"last_backup_at,omitempty" db:"last_backup_at"`
 
+	// WAL archiving (PostgreSQL addons with point-in-time recovery)
+	WALArchivedAt        *time.Time `json:"wal_archived_at,omitempty" db:"wal_archived_at"`                 // Last successfully archived WAL segment
+	WALArchiveLagSeconds *int64     `json:"wal_archive_lag_seconds,omitempty" db:"wal_archive_lag_seconds"` // Age of the last archived segment when sampled
+
 	// Audit fields
 	CreatedBy      *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
 	CreatedByEmail string     `json:"created_by_email,omitempty" db:"created_by_email"`
@@ -682,7 +2053,7 @@ type DatabaseAddonBinding struct {
 	ID         uuid.UUID                  `json:"id" db:"id"`
 	AddonID    uuid.UUID                  `json:"addon_id" db:"addon_id"`
 	ServiceID  uuid.UUID                  `json:"service_id" db:"service_id"`
-	EnvVarName string                     `json:"env_var_name" db:"env_var_name"` // e.g., "DATABASE_URL", "REDIS_URL"
+	EnvVarName string                     `json:"env_var_name" db:"env_var_name"` // e.g., "DATABASE_URL", "REDIS_URL", "MONGODB_URI"
 	Status     DatabaseAddonBindingStatus `json:"status" db:"status"`
 	CreatedAt  time.Time                  `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time                  `json:"updated_at" db:"updated_at"`
@@ -720,6 +2091,71 @@ type DatabaseAddonBackup struct {
 	CompletedAt   *time.Time                `json:"completed_at,omitempty" db:"completed_at"`
 	ExpiresAt     *time.Time                `json:"expires_at,omitempty" db:"expires_at"`
 	CreatedAt     time.Time                 `json:"created_at" db:"created_at"`
+
+	// Customer-managed encryption (object storage backups of CMEK teams only)
+	EncryptionKeyID *uuid.UUID `json:"encryption_key_id,omitempty" db:"encryption_key_id"`
+	WrappedDataKey  string     `json:"-" db:"wrapped_data_key"` // SSE-C key wrapped by the customer key
+}
+
+// DatabaseAddonRestoreStatus represents the status of a restore
+type DatabaseAddonRestoreStatus string
+
+const (
+	DatabaseAddonRestoreStatusPending    DatabaseAddonRestoreStatus = "pending"
+	DatabaseAddonRestoreStatusInProgress DatabaseAddonRestoreStatus = "in_progress"
+	DatabaseAddonRestoreStatusCompleted  DatabaseAddonRestoreStatus = "completed"
+	DatabaseAddonRestoreStatusFailed     DatabaseAddonRestoreStatus = "failed"
+)
+
+// DatabaseAddonRestore represents a restore of a database addon from one of its backups
+type DatabaseAddonRestore struct {
+	ID               uuid.UUID                  `json:"id" db:"id"`
+	AddonID          uuid.UUID                  `json:"addon_id" db:"addon_id"`
+	BackupID         *uuid.UUID                 `json:"backup_id,omitempty" db:"backup_id"`     // nil once the backup has expired
+	TargetTime       *time.Time                 `json:"target_time,omitempty" db:"target_time"` // Set for point-in-time restores
+	Status           DatabaseAddonRestoreStatus `json:"status" db:"status"`
+	StatusMessage    string                     `json:"status_message,omitempty" db:"status_message"`
+	Phase            string                     `json:"phase,omitempty" db:"phase"` // e.g., "downloading", "restoring"
+	Progress         int                        `json:"progress" db:"progress"`     // 0-100
+	RequestedBy      *uuid.UUID                 `json:"requested_by,omitempty" db:"requested_by"`
+	RequestedByEmail string                     `json:"requested_by_email,omitempty" db:"requested_by_email"`
+	StartedAt        *time.Time                 `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt      *time.Time                 `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt        time.Time                  `json:"created_at" db:"created_at"`
+}
+
+// DatabaseAddonResizeStatus represents the status of a resize
+type DatabaseAddonResizeStatus string
+
+const (
+	DatabaseAddonResizeStatusInProgress DatabaseAddonResizeStatus = "in_progress"
+	DatabaseAddonResizeStatusCompleted  DatabaseAddonResizeStatus = "completed"
+	DatabaseAddonResizeStatusRolledBack DatabaseAddonResizeStatus = "rolled_back"
+	DatabaseAddonResizeStatusFailed     DatabaseAddonResizeStatus = "failed" // Rollback also failed
+)
+
+// DatabaseAddonResize represents a change of an addon's storage, compute class, or replica count
+type DatabaseAddonResize struct {
+	ID               uuid.UUID                 `json:"id" db:"id"`
+	AddonID          uuid.UUID                 `json:"addon_id" db:"addon_id"`
+	Status           DatabaseAddonResizeStatus `json:"status" db:"status"`
+	StatusMessage    string                    `json:"status_message,omitempty" db:"status_message"`
+	PreviousConfig   DatabaseAddonConfig       `json:"previous_config" db:"previous_config"`
+	TargetConfig     DatabaseAddonConfig       `json:"target_config" db:"target_config"`
+	RequestedBy      *uuid.UUID                `json:"requested_by,omitempty" db:"requested_by"`
+	RequestedByEmail string                    `json:"requested_by_email,omitempty" db:"requested_by_email"`
+	StartedAt        time.Time                 `json:"started_at" db:"started_at"`
+	CompletedAt      *time.Time                `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// DatabaseAddonPITRStatus reports the WAL archiving state and recovery window of a PostgreSQL addon
+type DatabaseAddonPITRStatus struct {
+	Enabled                  bool       `json:"enabled"`
+	Archiving                bool       `json:"archiving"`                            // WAL segments are being archived successfully
+	Message                  string     `json:"message,omitempty"`                    // Archiving error reported by the operator
+	FirstRecoverabilityPoint *time.Time `json:"first_recoverability_point,omitempty"` // Earliest valid restore target
+	LastArchivedAt           *time.Time `json:"last_archived_at,omitempty"`
+	ArchiveLagSeconds        *int64     `json:"archive_lag_seconds,omitempty"`
 }
 
 // DatabaseAddonCredentials contains connection credentials for a database addon
@@ -741,6 +2177,16 @@ type DatabaseAddonCreateRequest struct {
 	Config        DatabaseAddonConfig `json:"config,omitempty"`
 }
 
+// DatabaseAddonUpdateRequest is the API request for resizing a database addon.
+// Omitted fields keep their current value; Plan sets CPU and memory together.
+type DatabaseAddonUpdateRequest struct {
+	Plan      string  `json:"plan,omitempty"`       // Compute class (e.g., "small", "medium")
+	StorageGB *int    `json:"storage_gb,omitempty"` // Storage can only grow
+	CPU       *string `json:"cpu,omitempty"`
+	Memory    *string `json:"memory,omitempty"`
+	Replicas  *int    `json:"replicas,omitempty"` // PostgreSQL only
+}
+
 // DatabaseAddonWithBindings includes the addon and its service bindings
 type DatabaseAddonWithBindings struct {
 	DatabaseAddon
@@ -899,9 +2345,11 @@ const (
 type WebhookDeliveryStatus string
 
 const (
-	WebhookDeliveryStatusPending WebhookDeliveryStatus = "pending"
-	WebhookDeliveryStatusSuccess WebhookDeliveryStatus = "success"
-	WebhookDeliveryStatusFailed  WebhookDeliveryStatus = "failed"
+	WebhookDeliveryStatusPending  WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSuccess  WebhookDeliveryStatus = "success"
+	WebhookDeliveryStatusFailed   WebhookDeliveryStatus = "failed"
+	WebhookDeliveryStatusRetrying WebhookDeliveryStatus = "retrying" // Failed attempt; sent again at NextAttemptAt
+	WebhookDeliveryStatusDead     WebhookDeliveryStatus = "dead"     // Out of attempts; listed as a dead letter until replayed
 )
 
 // WebhookEventType defines the events that can trigger webhooks
@@ -909,15 +2357,21 @@ type WebhookEventType string
 
 const (
 	// Deployment events
-	WebhookEventDeploymentStarted   WebhookEventType = "deployment.started"
-	WebhookEventDeploymentSucceeded WebhookEventType = "deployment.succeeded"
-	WebhookEventDeploymentFailed    WebhookEventType = "deployment.failed"
-	WebhookEventDeploymentCancelled WebhookEventType = "deployment.cancelled"
+	WebhookEventDeploymentStarted    WebhookEventType = "deployment.started"
+	WebhookEventDeploymentSucceeded  WebhookEventType = "deployment.succeeded"
+	WebhookEventDeploymentFailed     WebhookEventType = "deployment.failed"
+	WebhookEventDeploymentCancelled  WebhookEventType = "deployment.cancelled"
+	WebhookEventDeploymentStalled    WebhookEventType = "deployment.stalled"
+	WebhookEventDeploymentRolledBack WebhookEventType = "deployment.rolled_back"
+
+	// Deployment group events
+	WebhookEventDeploymentGroupReminder WebhookEventType = "deployment_group.reminder"
 
 	// Build events
 	WebhookEventBuildStarted   WebhookEventType = "build.started"
 	WebhookEventBuildSucceeded WebhookEventType = "build.succeeded"
 	WebhookEventBuildFailed    WebhookEventType = "build.failed"
+	WebhookEventBuildStalled   WebhookEventType = "build.stalled"
 
 	// Service events
 	WebhookEventServiceCreated   WebhookEventType = "service.created"
@@ -929,6 +2383,21 @@ const (
 	// Database addon events
 	WebhookEventDatabaseReady  WebhookEventType = "database.ready"
 	WebhookEventDatabaseFailed WebhookEventType = "database.failed"
+
+	// Budget events
+	WebhookEventBudgetThreshold   WebhookEventType = "budget.threshold"
+	WebhookEventBudgetCapExceeded WebhookEventType = "budget.cap_exceeded"
+
+	// Alert events
+	WebhookEventAlertFiring   WebhookEventType = "alert.firing"
+	WebhookEventAlertResolved WebhookEventType = "alert.resolved"
+
+	// Uptime events
+	WebhookEventUptimeDown      WebhookEventType = "uptime.down"
+	WebhookEventUptimeRecovered WebhookEventType = "uptime.recovered"
+
+	// Secret events
+	WebhookEventSecretRotationDue WebhookEventType = "secret.rotation_due"
 )
 
 // WebhookDestination represents a configured webhook endpoint
@@ -986,6 +2455,37 @@ type WebhookDelivery struct {
 	CompletedAt   *time.Time            `json:"completed_at,omitempty" db:"completed_at"`
 	DurationMs    *int                  `json:"duration_ms,omitempty" db:"duration_ms"`
 	AttemptNumber int                   `json:"attempt_number" db:"attempt_number"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	ReplayOf      *uuid.UUID            `json:"replay_of,omitempty" db:"replay_of"` // Delivery whose payload this one replayed
+}
+
+// EmailDeliveryStatus represents the status of a transactional email
+type EmailDeliveryStatus string
+
+const (
+	EmailDeliveryStatusQueued   EmailDeliveryStatus = "queued"
+	EmailDeliveryStatusSending  EmailDeliveryStatus = "sending"
+	EmailDeliveryStatusRetrying EmailDeliveryStatus = "retrying" // Failed attempt; sent again at NextAttemptAt
+	EmailDeliveryStatusSent     EmailDeliveryStatus = "sent"
+	EmailDeliveryStatusFailed   EmailDeliveryStatus = "failed"
+)
+
+// EmailDelivery tracks one transactional email. The message body is not
+// kept, since it may carry tokens.
+type EmailDelivery struct {
+	ID                uuid.UUID           `json:"id" db:"id"`
+	Template          string              `json:"template" db:"template"`
+	Recipient         string              `json:"recipient" db:"recipient"`
+	Subject           string              `json:"subject" db:"subject"`
+	Provider          string              `json:"provider" db:"provider"`
+	Status            EmailDeliveryStatus `json:"status" db:"status"`
+	Attempts          int                 `json:"attempts" db:"attempts"`
+	LastError         string              `json:"last_error,omitempty" db:"last_error"`
+	ProviderMessageID string              `json:"provider_message_id,omitempty" db:"provider_message_id"`
+	NextAttemptAt     *time.Time          `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	SentAt            *time.Time          `json:"sent_at,omitempty" db:"sent_at"`
+	CreatedAt         time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at" db:"updated_at"`
 }
 
 // WebhookCreateRequest is the API request for creating a webhook
@@ -1023,10 +2523,15 @@ type WebhookEvent struct {
 	Project   WebhookProjectInfo `json:"project"`
 
 	// Event-specific data (one of these will be populated)
-	Deployment *WebhookDeploymentInfo `json:"deployment,omitempty"`
-	Build      *WebhookBuildInfo      `json:"build,omitempty"`
-	Service    *WebhookServiceInfo    `json:"service,omitempty"`
-	Database   *WebhookDatabaseInfo   `json:"database,omitempty"`
+	Deployment      *WebhookDeploymentInfo      `json:"deployment,omitempty"`
+	Build           *WebhookBuildInfo           `json:"build,omitempty"`
+	Service         *WebhookServiceInfo         `json:"service,omitempty"`
+	Database        *WebhookDatabaseInfo        `json:"database,omitempty"`
+	DeploymentGroup *WebhookDeploymentGroupInfo `json:"deployment_group,omitempty"`
+	Budget          *WebhookBudgetInfo          `json:"budget,omitempty"`
+	Alert           *WebhookAlertInfo           `json:"alert,omitempty"`
+	Uptime          *WebhookUptimeInfo          `json:"uptime,omitempty"`
+	Secret          *WebhookSecretInfo          `json:"secret,omitempty"`
 }
 
 // WebhookProjectInfo contains project info included in webhook payloads
@@ -1048,6 +2553,9 @@ type WebhookDeploymentInfo struct {
 	URL           string    `json:"url,omitempty"`
 	Duration      *int      `json:"duration_seconds,omitempty"`
 	Error         string    `json:"error,omitempty"`
+
+	// Cost/performance deltas (only for succeeded deployments)
+	Annotations *DeploymentAnnotations `json:"annotations,omitempty"`
 }
 
 // WebhookBuildInfo contains build info for webhook payloads
@@ -1079,6 +2587,228 @@ type WebhookDatabaseInfo struct {
 	Error  string    `json:"error,omitempty"`
 }
 
+// WebhookDeploymentGroupInfo contains deployment group info for webhook payloads
+type WebhookDeploymentGroupInfo struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Environment string     `json:"environment"`
+	Strategy    string     `json:"strategy"`
+	Status      string     `json:"status"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	ScheduledBy string     `json:"scheduled_by,omitempty"`
+	CommitSHA   string     `json:"commit_sha,omitempty"`
+}
+
+// WebhookBudgetInfo contains budget info for budget events
+type WebhookBudgetInfo struct {
+	Scope        string    `json:"scope"` // "team" or "project"
+	Name         string    `json:"name"`
+	Threshold    int       `json:"threshold"` // Percent of the monthly limit
+	Spend        float64   `json:"spend"`
+	Limit        float64   `json:"limit"`
+	Currency     string    `json:"currency"`
+	PeriodStart  time.Time `json:"period_start"`
+	ScaledToZero []string  `json:"scaled_to_zero,omitempty"` // Services stopped by the hard cap
+}
+
+// WebhookAlertInfo contains alert info for alert events
+type WebhookAlertInfo struct {
+	ID          uuid.UUID  `json:"id"`
+	RuleID      uuid.UUID  `json:"rule_id"`
+	RuleName    string     `json:"rule_name"`
+	Kind        string     `json:"kind"`
+	ServiceName string     `json:"service_name"`
+	Environment string     `json:"environment"`
+	Summary     string     `json:"summary"`
+	Value       *float64   `json:"value,omitempty"`
+	Threshold   float64    `json:"threshold,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+// WebhookUptimeInfo contains uptime check info for uptime events
+type WebhookUptimeInfo struct {
+	CheckID     uuid.UUID `json:"check_id"`
+	ServiceName string    `json:"service_name"`
+	Environment string    `json:"environment"`
+	URL         string    `json:"url"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DownSince   time.Time `json:"down_since"`
+	DownSeconds int64     `json:"down_seconds,omitempty"` // Set on recovery
+}
+
+// WebhookSecretInfo contains secret env var info for secret events. Values
+// are never included.
+type WebhookSecretInfo struct {
+	EnvVarID    uuid.UUID `json:"env_var_id"`
+	ServiceName string    `json:"service_name"`
+	Environment string    `json:"environment"` // "all" for variables of all environments
+	Key         string    `json:"key"`
+	AgeDays     int       `json:"age_days"`
+	MaxAgeDays  int       `json:"max_age_days"`
+	ValueSince  time.Time `json:"value_since"`
+}
+
+// Event types only recorded in the event history of projects; the others
+// are webhook event types
+const (
+	ProjectEventEnvVarChanged = "env_var.changed"
+	ProjectEventMemberAdded   = "member.added"
+)
+
+// ProjectEvent is an entry of the event history of a project
+type ProjectEvent struct {
+	ID           uuid.UUID      `json:"id" db:"id"`
+	ProjectID    uuid.UUID      `json:"project_id" db:"project_id"`
+	Type         string         `json:"type" db:"type"`                             // e.g. deployment.succeeded, env_var.changed
+	ResourceType string         `json:"resource_type,omitempty" db:"resource_type"` // deployment, build, service, env_var, member
+	ResourceID   *uuid.UUID     `json:"resource_id,omitempty" db:"resource_id"`
+	ActorID      *uuid.UUID     `json:"actor_id,omitempty" db:"actor_id"` // nil for events of the platform itself
+	ActorEmail   string         `json:"actor_email,omitempty" db:"actor_email"`
+	Message      string         `json:"message" db:"message"`
+	Data         map[string]any `json:"data,omitempty" db:"data"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+}
+
+// ResourceQuota caps what a team or a project may hold. Each limit is nil
+// for no limit.
+type ResourceQuota struct {
+	MaxServices            *int       `json:"max_services"`
+	MaxCPUMillicores       *int       `json:"max_cpu_millicores"` // CPU requested by pods, e.g. 2000 for 2 cores
+	MaxMemoryMB            *int       `json:"max_memory_mb"`      // Memory requested by pods, in MiB
+	MaxAddonStorageGB      *int       `json:"max_addon_storage_gb"`
+	MaxPreviewEnvironments *int       `json:"max_preview_environments"`
+	UpdatedAt              *time.Time `json:"updated_at,omitempty"` // Nil until the quota is first set
+}
+
+// ResourceUsage is what a team or a project holds, as counted against its
+// resource quota
+type ResourceUsage struct {
+	Services            int `json:"services"`
+	CPUMillicores       int `json:"cpu_millicores"`
+	MemoryMB            int `json:"memory_mb"`
+	AddonStorageGB      int `json:"addon_storage_gb"`
+	PreviewEnvironments int `json:"preview_environments"`
+}
+
+// TeamSuspension is set on a team a platform operator suspended: its
+// services are scaled to zero and its projects take no changes until the
+// team is unsuspended
+type TeamSuspension struct {
+	SuspendedAt time.Time `json:"suspended_at"`
+	Reason      string    `json:"reason"`
+	SuspendedBy string    `json:"suspended_by"` // Email of the operator
+}
+
+// AdminTeam is a team as platform operators list it
+type AdminTeam struct {
+	ID         uuid.UUID       `json:"id"`
+	Name       string          `json:"name"`
+	Slug       string          `json:"slug"`
+	Members    int             `json:"members"`
+	Projects   int             `json:"projects"`
+	Suspension *TeamSuspension `json:"suspension,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AdminProject is a project as platform operators list it
+type AdminProject struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Slug          string     `json:"slug"`
+	TeamID        *uuid.UUID `json:"team_id,omitempty"`
+	TeamSlug      string     `json:"team_slug,omitempty"`
+	Services      int        `json:"services"`
+	TeamSuspended bool       `json:"team_suspended"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// PlatformMetrics is the load of the whole platform: what it holds, its
+// build queue, and the builds that finished in the last day
+type PlatformMetrics struct {
+	Teams          int                 `json:"teams"`
+	SuspendedTeams int                 `json:"suspended_teams"`
+	Projects       int                 `json:"projects"`
+	Services       int                 `json:"services"`
+	Queue          PlatformBuildQueue  `json:"queue"`
+	Builds         PlatformBuildWindow `json:"builds"`
+	GeneratedAt    time.Time           `json:"generated_at"`
+}
+
+// PlatformBuildQueue counts the build jobs waiting for and held by workers
+type PlatformBuildQueue struct {
+	Queued         int        `json:"queued"`
+	Building       int        `json:"building"`
+	OldestQueuedAt *time.Time `json:"oldest_queued_at,omitempty"`
+}
+
+// PlatformBuildWindow sums up the build jobs that finished within a window
+type PlatformBuildWindow struct {
+	Window              string  `json:"window"` // e.g. "24h"
+	Completed           int     `json:"completed"`
+	Failed              int     `json:"failed"`
+	Cancelled           int     `json:"cancelled"`
+	AvgDurationSeconds  float64 `json:"avg_duration_seconds"`
+	P95DurationSeconds  float64 `json:"p95_duration_seconds"`
+	AvgQueueWaitSeconds float64 `json:"avg_queue_wait_seconds"`
+}
+
+// RateLimitScope is who the budget of a rate limit policy is counted for
+type RateLimitScope string
+
+const (
+	RateLimitScopeUser  RateLimitScope = "user"
+	RateLimitScopeToken RateLimitScope = "token"
+	RateLimitScopeIP    RateLimitScope = "ip"
+)
+
+// RateLimitPolicy gives a route its own budget, on top of the limits per
+// IP, user and API token. Expensive routes such as builds and deploys get
+// tighter ones.
+type RateLimitPolicy struct {
+	ID            uuid.UUID      `json:"id"`
+	Name          string         `json:"name"`
+	Method        string         `json:"method"` // HTTP method, or "*" for any
+	Route         string         `json:"route"`  // Route pattern, e.g. /v1/services/:id/build
+	Scope         RateLimitScope `json:"scope"`
+	Requests      int            `json:"requests"`
+	WindowSeconds int            `json:"window_seconds"`
+	Enabled       bool           `json:"enabled"`
+	Description   string         `json:"description,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// BackgroundJobStatus is the state of a background job
+type BackgroundJobStatus string
+
+const (
+	BackgroundJobStatusQueued    BackgroundJobStatus = "queued"    // Waiting for its run time or a free worker
+	BackgroundJobStatusRunning   BackgroundJobStatus = "running"   // Claimed by a replica
+	BackgroundJobStatusSucceeded BackgroundJobStatus = "succeeded" // Finished
+	BackgroundJobStatusFailed    BackgroundJobStatus = "failed"    // Out of attempts, or failed for good
+)
+
+// BackgroundJob is asynchronous work, such as tearing down a closed preview,
+// that any replica may run. Failed attempts are retried with backoff until
+// the job runs out of attempts.
+type BackgroundJob struct {
+	ID          uuid.UUID           `json:"id"`
+	Kind        string              `json:"kind"` // e.g. preview.cleanup
+	Payload     json.RawMessage     `json:"payload"`
+	Status      BackgroundJobStatus `json:"status"`
+	Attempts    int                 `json:"attempts"`
+	MaxAttempts int                 `json:"max_attempts"`
+	RunAfter    time.Time           `json:"run_after"`            // Not run before this time
+	ClaimedBy   string              `json:"claimed_by,omitempty"` // Replica running the job
+	LastError   string              `json:"last_error,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	StartedAt   *time.Time          `json:"started_at,omitempty"` // Start of the latest attempt
+	FinishedAt  *time.Time          `json:"finished_at,omitempty"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
 // CIRunStatus represents the status of a CI workflow run
 type CIRunStatus string
 
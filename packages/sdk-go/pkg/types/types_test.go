@@ -0,0 +1,28 @@
+package types
+
+import "testing"
+
+func TestDeploymentPhase_Percent(t *testing.T) {
+	tests := []struct {
+		name           string
+		phase          DeploymentPhase
+		ready, desired int
+		want           int
+	}{
+		{"queued", DeploymentPhaseQueued, 0, 0, 0},
+		{"applying", DeploymentPhaseApplying, 0, 0, 25},
+		{"rollout without replicas", DeploymentPhaseWaitingRollout, 0, 0, 40},
+		{"rollout partly ready", DeploymentPhaseWaitingRollout, 1, 2, 65},
+		{"rollout over ready", DeploymentPhaseWaitingRollout, 5, 2, 90},
+		{"hooks", DeploymentPhaseHooksRunning, 0, 0, 95},
+		{"done", DeploymentPhaseDone, 0, 0, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.phase.Percent(tt.ready, tt.desired); got != tt.want {
+				t.Errorf("%s.Percent(%d, %d) = %d, want %d", tt.phase, tt.ready, tt.desired, got, tt.want)
+			}
+		})
+	}
+}